@@ -24,6 +24,8 @@ var (
 	BuildStr      string = "No Build Date"
 	InputPlugins  []string
 	OutputPlugins []string
+	// ConfigHash is the sha256 of the JSON config the agent runs, it tells apart the hosts running divergent configs
+	ConfigHash string
 
 	userAgent string
 )
@@ -4,10 +4,12 @@
 package aws
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/client"
@@ -38,6 +40,9 @@ type CredentialConfig struct {
 	Profile   string
 	Filename  string
 	Token     string
+	// ExternalID is passed when assuming the role, which trusts the agent only with it, e.g. the role of a monitoring
+	// account guarding against the confused deputy problem
+	ExternalID string
 }
 
 type stsCredentialProvider struct {
@@ -96,6 +101,7 @@ func (c *CredentialConfig) rootCredentials() client.ConfigProvider {
 		Region:                        aws.String(c.Region),
 		CredentialsChainVerboseErrors: aws.Bool(true),
 		HTTPClient:                    &http.Client{Timeout: 1 * time.Minute},
+		EndpointResolver:              EndpointResolver(),
 	}
 	config.Credentials = getRootCredentialsFromChain(c)
 	return getSession(config)
@@ -104,10 +110,11 @@ func (c *CredentialConfig) rootCredentials() client.ConfigProvider {
 func (c *CredentialConfig) assumeCredentials() client.ConfigProvider {
 	rootCredentials := c.rootCredentials()
 	config := &aws.Config{
-		Region:     aws.String(c.Region),
-		HTTPClient: &http.Client{Timeout: 1 * time.Minute},
+		Region:           aws.String(c.Region),
+		HTTPClient:       &http.Client{Timeout: 1 * time.Minute},
+		EndpointResolver: EndpointResolver(),
 	}
-	config.Credentials = newStsCredentials(rootCredentials, c.RoleARN, c.Region)
+	config.Credentials = newStsCredentials(rootCredentials, c.RoleARN, c.Region, c.ExternalID)
 	return getSession(config)
 }
 
@@ -120,6 +127,14 @@ func (c *CredentialConfig) Credentials() client.ConfigProvider {
 }
 
 func (s *stsCredentialProvider) Retrieve() (credentials.Value, error) {
+	v, err := s.retrieve()
+	if err != nil {
+		postCredentialExpiring(s.regional.RoleARN, err)
+	}
+	return v, err
+}
+
+func (s *stsCredentialProvider) retrieve() (credentials.Value, error) {
 	if s.fallbackProvider != nil {
 		return s.fallbackProvider.Retrieve()
 	}
@@ -137,15 +152,32 @@ func (s *stsCredentialProvider) Retrieve() (credentials.Value, error) {
 	return v, err
 }
 
-func newStsCredentials(c client.ConfigProvider, roleARN string, region string) *credentials.Credentials {
+// postCredentialExpiring posts that the credentials cannot be renewed, the calls fail once they expire
+func postCredentialExpiring(source string, err error) {
+	agentevents.Post(agentevents.Event{
+		Type:       agentevents.TypeCredentialExpiring,
+		Severity:   agentevents.SeverityError,
+		Component:  "aws",
+		Message:    fmt.Sprintf("unable to renew the credentials: %v", err),
+		Attributes: map[string]string{"source": source},
+	})
+}
+
+func newStsCredentials(c client.ConfigProvider, roleARN string, region string, externalID string) *credentials.Credentials {
+	var externalIDValue *string
+	if externalID != "" {
+		externalIDValue = aws.String(externalID)
+	}
+
 	regional := &stscreds.AssumeRoleProvider{
 		Client: sts.New(c, &aws.Config{
 			Region:              aws.String(region),
 			STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
 			HTTPClient:          &http.Client{Timeout: 1 * time.Minute},
 		}),
-		RoleARN:  roleARN,
-		Duration: stscreds.DefaultDuration,
+		RoleARN:    roleARN,
+		Duration:   stscreds.DefaultDuration,
+		ExternalID: externalIDValue,
 	}
 
 	fallbackRegion := getFallbackRegion(region)
@@ -157,8 +189,9 @@ func newStsCredentials(c client.ConfigProvider, roleARN string, region string) *
 			STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
 			HTTPClient:          &http.Client{Timeout: 1 * time.Minute},
 		}),
-		RoleARN:  roleARN,
-		Duration: stscreds.DefaultDuration,
+		RoleARN:    roleARN,
+		Duration:   stscreds.DefaultDuration,
+		ExternalID: externalIDValue,
 	}
 
 	return credentials.NewCredentials(&stsCredentialProvider{regional: regional, partitional: partitional})
@@ -0,0 +1,152 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package aws
+
+import (
+	"context"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultDNSCacheTTL is how long the addresses of the endpoints are cached when the http_client options do not
+// set it. The system resolver does not tell the TTL of the records, so it is the longest one the agent relies on.
+const defaultDNSCacheTTL = time.Minute
+
+// dnsCache resolves the hosts the transport dials once per ttl, e.g. the VPC endpoints of CloudWatch whose
+// addresses change when their network interfaces are replaced. The connections are spread over the addresses
+// of the host, and a host is resolved again when none of its cached addresses can be dialed. The addresses are
+// kept when the host can no longer be resolved, until it can be again.
+type dnsCache struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+	dial   func(ctx context.Context, network, address string) (net.Conn, error)
+	now    func() time.Time
+	// changed is called when the addresses of a host change, to close the idle connections to the old ones
+	changed func()
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+	// next is the address the next connection starts from
+	next int
+}
+
+func newDNSCache(ttl time.Duration, dialer *net.Dialer) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		dial:    dialer.DialContext,
+		now:     time.Now,
+		entries: make(map[string]*dnsEntry),
+	}
+}
+
+func (c *dnsCache) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dial(ctx, network, address)
+	}
+	addrs, fresh, err := c.resolve(ctx, host, false)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.dialAny(ctx, network, addrs, port)
+	if err == nil || fresh || ctx.Err() != nil {
+		return conn, err
+	}
+
+	// the cached addresses may be stale, e.g. the network interfaces of the endpoint were replaced
+	log.Printf("D! Unable to connect to the cached addresses of %v, resolving it again: %v", host, err)
+	retry, _, rerr := c.resolve(ctx, host, true)
+	if rerr != nil || sameAddrs(retry, addrs) {
+		return nil, err
+	}
+	return c.dialAny(ctx, network, retry, port)
+}
+
+func (c *dnsCache) dialAny(ctx context.Context, network string, addrs []string, port string) (net.Conn, error) {
+	var err error
+	for _, addr := range addrs {
+		var conn net.Conn
+		if conn, err = c.dial(ctx, network, net.JoinHostPort(addr, port)); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// resolve returns the addresses of the host, starting from the next one to spread the connections over them,
+// and whether they were just resolved
+func (c *dnsCache) resolve(ctx context.Context, host string, force bool) ([]string, bool, error) {
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	if ok && !force && c.now().Before(e.expires) {
+		addrs := e.rotate()
+		c.mu.Unlock()
+		return addrs, false, nil
+	}
+	c.mu.Unlock()
+
+	addrs, err := c.lookup(ctx, host)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok = c.entries[host]
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			log.Printf("W! Unable to resolve %v, using the addresses resolved before: %v", host, err)
+			e.expires = c.now().Add(c.ttl)
+			return e.rotate(), false, nil
+		}
+		return nil, false, err
+	}
+	sort.Strings(addrs)
+	if ok && !sameAddrs(addrs, e.addrs) {
+		log.Printf("I! The addresses of %v changed from %v to %v", host, e.addrs, addrs)
+		if c.changed != nil {
+			c.changed()
+		}
+	}
+	if !ok {
+		e = &dnsEntry{}
+		c.entries[host] = e
+	}
+	e.addrs = addrs
+	e.expires = c.now().Add(c.ttl)
+	return e.rotate(), true, nil
+}
+
+func (e *dnsEntry) rotate() []string {
+	n := len(e.addrs)
+	start := e.next % n
+	e.next = start + 1
+	addrs := make([]string, 0, n)
+	return append(append(addrs, e.addrs[start:]...), e.addrs[:start]...)
+}
+
+// sameAddrs compares the addresses regardless of their order
+func sameAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, addr := range a {
+		seen[addr] = true
+	}
+	for _, addr := range b {
+		if !seen[addr] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	addrs   []string
+	err     error
+	lookups int
+	// reachable are the addresses which can be dialed
+	reachable map[string]bool
+	dialed    []string
+}
+
+func (r *fakeResolver) lookup(ctx context.Context, host string) ([]string, error) {
+	r.lookups++
+	return r.addrs, r.err
+}
+
+func (r *fakeResolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	r.dialed = append(r.dialed, address)
+	host, _, _ := net.SplitHostPort(address)
+	if !r.reachable[host] {
+		return nil, errors.New("connection refused")
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func newTestDNSCache(r *fakeResolver, now *time.Time) *dnsCache {
+	c := newDNSCache(time.Minute, &net.Dialer{})
+	c.lookup, c.dial = r.lookup, r.dial
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestDNSCacheTTL(t *testing.T) {
+	now := time.Now()
+	r := &fakeResolver{addrs: []string{"10.0.0.2", "10.0.0.1"}, reachable: map[string]bool{"10.0.0.1": true, "10.0.0.2": true}}
+	c := newTestDNSCache(r, &now)
+
+	for i := 0; i < 4; i++ {
+		conn, err := c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+		require.NoError(t, err)
+		conn.Close()
+	}
+	assert.Equal(t, 1, r.lookups)
+	// the connections are spread over the addresses
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.2:443", "10.0.0.1:443", "10.0.0.2:443"}, r.dialed)
+
+	now = now.Add(time.Minute)
+	_, err := c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.lookups)
+
+	// the IP addresses are not resolved
+	_, err = c.DialContext(context.Background(), "tcp", "10.0.0.1:443")
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.lookups)
+}
+
+func TestDNSCacheReresolvesOnConnectionErrors(t *testing.T) {
+	now := time.Now()
+	r := &fakeResolver{addrs: []string{"10.0.0.1"}, reachable: map[string]bool{"10.0.0.1": true}}
+	c := newTestDNSCache(r, &now)
+	changed := 0
+	c.changed = func() { changed++ }
+
+	_, err := c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+	require.NoError(t, err)
+
+	// the network interface of the endpoint is replaced before the addresses expire
+	r.addrs, r.reachable = []string{"10.0.0.9"}, map[string]bool{"10.0.0.9": true}
+	r.dialed = nil
+	_, err = c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.9:443"}, r.dialed)
+	assert.Equal(t, 2, r.lookups)
+	assert.Equal(t, 1, changed)
+
+	// an unreachable endpoint which resolves to the same addresses fails without dialing them again
+	r.reachable = nil
+	r.dialed = nil
+	_, err = c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"10.0.0.9:443"}, r.dialed)
+	assert.Equal(t, 1, changed)
+}
+
+func TestDNSCacheKeepsAddressesWhenResolutionFails(t *testing.T) {
+	now := time.Now()
+	r := &fakeResolver{addrs: []string{"10.0.0.1"}, reachable: map[string]bool{"10.0.0.1": true}}
+	c := newTestDNSCache(r, &now)
+	_, err := c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	r.addrs, r.err = nil, errors.New("no such host")
+	_, err = c.DialContext(context.Background(), "tcp", "logs.us-east-1.amazonaws.com:443")
+	require.NoError(t, err)
+
+	_, err = c.DialContext(context.Background(), "tcp", "monitoring.us-east-1.amazonaws.com:443")
+	assert.EqualError(t, err, "no such host")
+}
@@ -0,0 +1,164 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package aws
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+const (
+	classicPartition = "aws"
+
+	// The environment variables of the instance metadata endpoint, named as in the later versions of the SDKs
+	metadataEndpointEnv     = "AWS_EC2_METADATA_SERVICE_ENDPOINT"
+	metadataEndpointModeEnv = "AWS_EC2_METADATA_SERVICE_ENDPOINT_MODE"
+	ipv6MetadataEndpoint    = "http://[fd00:ec2::254]/latest"
+)
+
+// The commercial regions where the services have FIPS endpoints named {service}-fips.{region}.amazonaws.com
+var fipsRegionRegex = regexp.MustCompile(`^(us|ca)-(east|west|central)-\d$`)
+
+// The DNS suffixes of the dual-stack endpoints, named {service}.{region}.{suffix}, of the partitions having them
+var dualStackDNSSuffixes = map[string]string{
+	classicPartition: "api.aws",
+	pdtPartition:     "api.aws",
+	bjsPartition:     "api.amazonwebservices.com.cn",
+}
+
+// EndpointOptions selects the variant of the endpoint of a service
+type EndpointOptions struct {
+	// FIPS selects the FIPS 140-2 endpoint
+	FIPS bool
+	// DualStack selects the endpoint reachable over both IPv4 and IPv6, required on IPv6-only hosts
+	DualStack bool
+}
+
+// ResolveEndpoint returns the endpoint of the service in the region and the region to sign its requests with.
+// The regions not known to the SDK yet, e.g. newly launched opt-in regions, are resolved from the naming scheme
+// of their partition, i.e. GovCloud, China or ISO.
+//
+// With fips the FIPS 140-2 endpoint is returned: the one listed by the SDK when there is one, the regular endpoint
+// in GovCloud and ISO where all the endpoints are FIPS validated, otherwise the {service}-fips endpoint of the US
+// and Canada regions. An error is returned for the regions without FIPS endpoints, e.g. the China ones.
+//
+// With dual-stack the {service}.{region}.api.aws endpoint is returned, or its China equivalent. The ISO
+// partitions have no dual-stack endpoints.
+func ResolveEndpoint(service, region string, options EndpointOptions) (endpoints.ResolvedEndpoint, error) {
+	if region == "" {
+		return endpoints.ResolvedEndpoint{}, fmt.Errorf("no region to resolve the %v endpoint in", service)
+	}
+	partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if !ok {
+		return endpoints.ResolvedEndpoint{}, fmt.Errorf("region %v is not in any known partition", region)
+	}
+	if options.DualStack {
+		return resolveDualStackEndpoint(partition, service, region, options.FIPS)
+	}
+	if !options.FIPS {
+		return partition.EndpointFor(service, region)
+	}
+
+	if s, ok := partition.Services()[service]; ok {
+		if _, ok := s.Endpoints()["fips-"+region]; ok {
+			return partition.EndpointFor(service, "fips-"+region)
+		}
+	}
+	switch partition.ID() {
+	case pdtPartition, dcaPartition, lckPartition:
+		return partition.EndpointFor(service, region)
+	case classicPartition:
+		if fipsRegionRegex.MatchString(region) {
+			return endpoints.ResolvedEndpoint{
+				URL:           fmt.Sprintf("https://%v-fips.%v.amazonaws.com", service, region),
+				PartitionID:   partition.ID(),
+				SigningRegion: region,
+				SigningMethod: "v4",
+			}, nil
+		}
+	}
+	return endpoints.ResolvedEndpoint{}, fmt.Errorf("%v has no FIPS endpoint in region %v", service, region)
+}
+
+func resolveDualStackEndpoint(partition endpoints.Partition, service, region string, fips bool) (endpoints.ResolvedEndpoint, error) {
+	suffix, ok := dualStackDNSSuffixes[partition.ID()]
+	if !ok {
+		return endpoints.ResolvedEndpoint{}, fmt.Errorf("%v has no dual-stack endpoint in region %v", service, region)
+	}
+	name := service
+	if fips {
+		switch {
+		case partition.ID() == pdtPartition:
+			// all the GovCloud endpoints are FIPS validated
+		case partition.ID() == classicPartition && fipsRegionRegex.MatchString(region):
+			name = service + "-fips"
+		default:
+			return endpoints.ResolvedEndpoint{}, fmt.Errorf("%v has no FIPS endpoint in region %v", service, region)
+		}
+	}
+	return endpoints.ResolvedEndpoint{
+		URL:           fmt.Sprintf("https://%v.%v.%v", name, region, suffix),
+		PartitionID:   partition.ID(),
+		SigningRegion: region,
+		SigningMethod: "v4",
+	}, nil
+}
+
+// EndpointConfig returns the endpoint and the signing region of the client of the service, the endpoint override
+// is used as is when set and the region is then left to the session, as it is without any option. An error is
+// returned when the endpoint of the options cannot be resolved, rather than falling back to the default endpoint
+// which is neither FIPS nor dual-stack.
+func EndpointConfig(service, region, endpointOverride string, options EndpointOptions) (*aws.Config, error) {
+	if endpointOverride != "" || options == (EndpointOptions{}) {
+		return &aws.Config{Endpoint: aws.String(endpointOverride)}, nil
+	}
+	e, err := ResolveEndpoint(service, region, options)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve the %v endpoint: %v", service, err)
+	}
+	log.Printf("D! Resolved the %v endpoint of region %v to %v, signed for region %v", service, region, e.URL, e.SigningRegion)
+	config := &aws.Config{Endpoint: aws.String(e.URL)}
+	if e.SigningRegion != "" {
+		config.Region = aws.String(e.SigningRegion)
+	}
+	return config, nil
+}
+
+// MetadataEndpoint returns the instance metadata endpoint set by AWS_EC2_METADATA_SERVICE_ENDPOINT, or the IPv6
+// one with AWS_EC2_METADATA_SERVICE_ENDPOINT_MODE=IPv6. It is empty when the default IPv4 endpoint is used.
+func MetadataEndpoint() string {
+	if endpoint := os.Getenv(metadataEndpointEnv); endpoint != "" {
+		endpoint = strings.TrimSuffix(endpoint, "/")
+		if !strings.HasSuffix(endpoint, "/latest") {
+			endpoint += "/latest"
+		}
+		return endpoint
+	}
+	if strings.EqualFold(os.Getenv(metadataEndpointModeEnv), "IPv6") {
+		return ipv6MetadataEndpoint
+	}
+	return ""
+}
+
+// EndpointResolver returns the resolver of the sessions of the agent, it resolves the instance metadata
+// endpoint to MetadataEndpoint, for both the metadata clients and the instance role credentials.
+func EndpointResolver() endpoints.Resolver {
+	metadata := MetadataEndpoint()
+	if metadata == "" {
+		return endpoints.DefaultResolver()
+	}
+	return endpoints.ResolverFunc(func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		if service == ec2metadata.ServiceName {
+			return endpoints.ResolvedEndpoint{URL: metadata, SigningRegion: region}, nil
+		}
+		return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package aws
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	tests := []struct {
+		service, region string
+		options         EndpointOptions
+		url, signing    string
+	}{
+		{"logs", "us-east-1", EndpointOptions{}, "https://logs.us-east-1.amazonaws.com", "us-east-1"},
+		{"logs", "us-east-1", EndpointOptions{FIPS: true}, "https://logs-fips.us-east-1.amazonaws.com", "us-east-1"},
+		{"monitoring", "us-west-2", EndpointOptions{FIPS: true}, "https://monitoring-fips.us-west-2.amazonaws.com", "us-west-2"},
+		// an opt-in region the SDK does not know yet
+		{"logs", "ap-southeast-9", EndpointOptions{}, "https://logs.ap-southeast-9.amazonaws.com", "ap-southeast-9"},
+		{"logs", "cn-northwest-1", EndpointOptions{}, "https://logs.cn-northwest-1.amazonaws.com.cn", "cn-northwest-1"},
+		{"logs", "us-gov-west-1", EndpointOptions{FIPS: true}, "https://logs.us-gov-west-1.amazonaws.com", "us-gov-west-1"},
+		{"logs", "us-gov-east-2", EndpointOptions{}, "https://logs.us-gov-east-2.amazonaws.com", "us-gov-east-2"},
+		{"logs", "us-iso-east-1", EndpointOptions{FIPS: true}, "https://logs.us-iso-east-1.c2s.ic.gov", "us-iso-east-1"},
+		{"logs", "us-isob-east-1", EndpointOptions{}, "https://logs.us-isob-east-1.sc2s.sgov.gov", "us-isob-east-1"},
+		{"logs", "eu-west-1", EndpointOptions{DualStack: true}, "https://logs.eu-west-1.api.aws", "eu-west-1"},
+		{"monitoring", "us-east-1", EndpointOptions{FIPS: true, DualStack: true}, "https://monitoring-fips.us-east-1.api.aws", "us-east-1"},
+		{"logs", "us-gov-east-1", EndpointOptions{FIPS: true, DualStack: true}, "https://logs.us-gov-east-1.api.aws", "us-gov-east-1"},
+		{"logs", "cn-north-1", EndpointOptions{DualStack: true}, "https://logs.cn-north-1.api.amazonwebservices.com.cn", "cn-north-1"},
+	}
+	for _, test := range tests {
+		e, err := ResolveEndpoint(test.service, test.region, test.options)
+		assert.NoError(t, err, test.region)
+		assert.Equal(t, test.url, e.URL, test.region)
+		assert.Equal(t, test.signing, e.SigningRegion, test.region)
+	}
+}
+
+func TestResolveEndpointWithoutFips(t *testing.T) {
+	_, err := ResolveEndpoint("logs", "cn-north-1", EndpointOptions{FIPS: true})
+	assert.Error(t, err)
+	_, err = ResolveEndpoint("logs", "eu-west-1", EndpointOptions{FIPS: true})
+	assert.Error(t, err)
+	_, err = ResolveEndpoint("logs", "eu-west-1", EndpointOptions{FIPS: true, DualStack: true})
+	assert.Error(t, err)
+	_, err = ResolveEndpoint("logs", "us-iso-east-1", EndpointOptions{DualStack: true})
+	assert.Error(t, err)
+	_, err = ResolveEndpoint("logs", "", EndpointOptions{})
+	assert.Error(t, err)
+}
+
+func TestEndpointConfig(t *testing.T) {
+	c, err := EndpointConfig("logs", "us-east-1", "https://example.com", EndpointOptions{FIPS: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", aws.StringValue(c.Endpoint))
+	assert.Nil(t, c.Region)
+
+	c, err = EndpointConfig("logs", "us-east-2", "", EndpointOptions{FIPS: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://logs-fips.us-east-2.amazonaws.com", aws.StringValue(c.Endpoint))
+	assert.Equal(t, "us-east-2", aws.StringValue(c.Region))
+
+	// the endpoint of the options is required, the default one is not FIPS
+	_, err = EndpointConfig("logs", "cn-north-1", "", EndpointOptions{FIPS: true})
+	assert.EqualError(t, err, "unable to resolve the logs endpoint: logs has no FIPS endpoint in region cn-north-1")
+	_, err = EndpointConfig("logs", "us-iso-east-1", "", EndpointOptions{DualStack: true})
+	assert.Error(t, err)
+}
+
+func TestMetadataEndpoint(t *testing.T) {
+	os.Unsetenv(metadataEndpointEnv)
+	os.Unsetenv(metadataEndpointModeEnv)
+	assert.Equal(t, "", MetadataEndpoint())
+	e, err := EndpointResolver().EndpointFor(ec2metadata.ServiceName, "us-east-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://169.254.169.254/latest", e.URL)
+
+	os.Setenv(metadataEndpointModeEnv, "ipv6")
+	defer os.Unsetenv(metadataEndpointModeEnv)
+	assert.Equal(t, ipv6MetadataEndpoint, MetadataEndpoint())
+	e, err = EndpointResolver().EndpointFor(ec2metadata.ServiceName, "us-east-1")
+	assert.NoError(t, err)
+	assert.Equal(t, ipv6MetadataEndpoint, e.URL)
+	e, err = EndpointResolver().EndpointFor("logs", "us-east-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://logs.us-east-1.amazonaws.com", e.URL)
+
+	os.Setenv(metadataEndpointEnv, "http://[fd00:ec2::254]/")
+	defer os.Unsetenv(metadataEndpointEnv)
+	assert.Equal(t, "http://[fd00:ec2::254]/latest", MetadataEndpoint())
+}
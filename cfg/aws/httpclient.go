@@ -0,0 +1,113 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package aws
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
+)
+
+var (
+	transportOnce sync.Once
+	transport     *http.Transport
+)
+
+// HTTPClient returns the HTTP client of the AWS service clients of the outputs, the requests time out after the timeout. All
+// of them share the transport, so that the connections to the endpoints are reused across the clients. It is
+// tuned by the http_client options of the agent section, set by the translator in the env config.
+func HTTPClient(timeout time.Duration) *http.Client {
+	transportOnce.Do(func() {
+		transport = newTransport()
+	})
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// newTransport returns the default transport of net/http with the options of the env config, the defaults are
+// kept for the ones which are not set or not valid
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if n := positiveInt(envconfig.CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST); n > 0 {
+		t.MaxIdleConnsPerHost = n
+		if n > t.MaxIdleConns {
+			t.MaxIdleConns = n
+		}
+	}
+	if d := positiveDuration(envconfig.CWAGENT_HTTP_IDLE_CONN_TIMEOUT); d > 0 {
+		t.IdleConnTimeout = d
+	}
+	if d := positiveDuration(envconfig.CWAGENT_HTTP_TLS_HANDSHAKE_TIMEOUT); d > 0 {
+		t.TLSHandshakeTimeout = d
+	}
+	if v := os.Getenv(envconfig.CWAGENT_HTTP_DISABLE_HTTP2); v != "" {
+		if disabled, err := strconv.ParseBool(v); err != nil {
+			invalidHTTPOption(envconfig.CWAGENT_HTTP_DISABLE_HTTP2, v)
+		} else if disabled {
+			// a non-nil empty map keeps the transport from upgrading the TLS connections to HTTP/2
+			t.ForceAttemptHTTP2 = false
+			t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		}
+	}
+
+	ttl := defaultDNSCacheTTL
+	if v := os.Getenv(envconfig.CWAGENT_DNS_CACHE_TTL); v != "" {
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			invalidHTTPOption(envconfig.CWAGENT_DNS_CACHE_TTL, v)
+		} else {
+			ttl = d
+		}
+	}
+	// the hosts are resolved for every connection when the cache is disabled
+	if ttl > 0 {
+		cache := newDNSCache(ttl, &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+		cache.changed = t.CloseIdleConnections
+		t.DialContext = cache.DialContext
+	}
+	return t
+}
+
+func positiveInt(env string) int {
+	v := os.Getenv(env)
+	if v == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i <= 0 {
+		invalidHTTPOption(env, v)
+		return 0
+	}
+	return i
+}
+
+func positiveDuration(env string) time.Duration {
+	v := os.Getenv(env)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		invalidHTTPOption(env, v)
+		return 0
+	}
+	return d
+}
+
+func invalidHTTPOption(env, v string) {
+	log.Printf("W! Invalid %s %q, using the default of the HTTP transport", env, v)
+	agentevents.Post(agentevents.Event{
+		Type:      agentevents.TypeConfigFallback,
+		Severity:  agentevents.SeverityWarning,
+		Component: "aws",
+		Message:   fmt.Sprintf("invalid %s %q, the default of the HTTP transport is used", env, v),
+	})
+}
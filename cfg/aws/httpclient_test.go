@@ -0,0 +1,74 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package aws
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func setEnv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+}
+
+func TestNewTransport(t *testing.T) {
+	defaults := http.DefaultTransport.(*http.Transport)
+	tr := newTransport()
+	assert.Equal(t, defaults.MaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, defaults.IdleConnTimeout, tr.IdleConnTimeout)
+	assert.True(t, tr.ForceAttemptHTTP2)
+	assert.Nil(t, tr.TLSNextProto)
+	assert.NotEqual(t, reflect.ValueOf(defaults.DialContext).Pointer(), reflect.ValueOf(tr.DialContext).Pointer())
+
+	setEnv(t, map[string]string{
+		envconfig.CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST: "200",
+		envconfig.CWAGENT_HTTP_IDLE_CONN_TIMEOUT:       "30s",
+		envconfig.CWAGENT_HTTP_TLS_HANDSHAKE_TIMEOUT:   "5s",
+		envconfig.CWAGENT_HTTP_DISABLE_HTTP2:           "true",
+		envconfig.CWAGENT_DNS_CACHE_TTL:                "0s",
+	})
+	tr = newTransport()
+	assert.Equal(t, 200, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 200, tr.MaxIdleConns)
+	assert.Equal(t, 30*time.Second, tr.IdleConnTimeout)
+	assert.Equal(t, 5*time.Second, tr.TLSHandshakeTimeout)
+	assert.False(t, tr.ForceAttemptHTTP2)
+	assert.NotNil(t, tr.TLSNextProto)
+	assert.Empty(t, tr.TLSNextProto)
+	// the hosts are resolved by the dialer of net/http
+	assert.Equal(t, reflect.ValueOf(defaults.DialContext).Pointer(), reflect.ValueOf(tr.DialContext).Pointer())
+}
+
+func TestNewTransportInvalidOptions(t *testing.T) {
+	setEnv(t, map[string]string{
+		envconfig.CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST: "-1",
+		envconfig.CWAGENT_HTTP_IDLE_CONN_TIMEOUT:       "forever",
+		envconfig.CWAGENT_HTTP_DISABLE_HTTP2:           "maybe",
+	})
+	defaults := http.DefaultTransport.(*http.Transport)
+	tr := newTransport()
+	assert.Equal(t, defaults.MaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, defaults.IdleConnTimeout, tr.IdleConnTimeout)
+	assert.True(t, tr.ForceAttemptHTTP2)
+}
+
+func TestHTTPClientSharesTransport(t *testing.T) {
+	a, b := HTTPClient(time.Minute), HTTPClient(5*time.Minute)
+	assert.Equal(t, time.Minute, a.Timeout)
+	assert.Equal(t, 5*time.Minute, b.Timeout)
+	assert.True(t, a.Transport == b.Transport)
+}
@@ -22,6 +22,9 @@ func (p *Refreshable_shared_credentials_provider) Retrieve() (credentials.Value,
 
 	p.SetExpiration(time.Now().Add(p.ExpiryWindow), 0)
 	creds, e := p.sharedCredentialsProvider.Retrieve()
+	if e != nil {
+		postCredentialExpiring(p.sharedCredentialsProvider.Filename, e)
+	}
 
 	return creds, e
 }
@@ -5,10 +5,36 @@ package envconfig
 
 const (
 	//the following are the names of environment variables
-	HTTP_PROXY         = "HTTP_PROXY"
-	HTTPS_PROXY        = "HTTPS_PROXY"
-	NO_PROXY           = "NO_PROXY"
-	AWS_CSM_ENABLED    = "AWS_CSM_ENABLED"
-	AWS_CA_BUNDLE      = "AWS_CA_BUNDLE"
-	CWAGENT_USER_AGENT = "CWAGENT_USER_AGENT"
+	HTTP_PROXY               = "HTTP_PROXY"
+	HTTPS_PROXY              = "HTTPS_PROXY"
+	NO_PROXY                 = "NO_PROXY"
+	AWS_CSM_ENABLED          = "AWS_CSM_ENABLED"
+	AWS_CA_BUNDLE            = "AWS_CA_BUNDLE"
+	CWAGENT_USER_AGENT       = "CWAGENT_USER_AGENT"
+	CWAGENT_COLLECTD_TYPESDB = "CWAGENT_COLLECTD_TYPESDB"
+
+	CWAGENT_COLLECTION_JITTER = "CWAGENT_COLLECTION_JITTER"
+	CWAGENT_CONFIG_HASH       = "CWAGENT_CONFIG_HASH"
+
+	CWAGENT_K8S_API_QPS            = "CWAGENT_K8S_API_QPS"
+	CWAGENT_K8S_API_BURST          = "CWAGENT_K8S_API_BURST"
+	CWAGENT_K8S_RESYNC_INTERVAL    = "CWAGENT_K8S_RESYNC_INTERVAL"
+	CWAGENT_K8S_MAX_CACHED_OBJECTS = "CWAGENT_K8S_MAX_CACHED_OBJECTS"
+
+	CWAGENT_SELF_UPDATE_SOURCE         = "CWAGENT_SELF_UPDATE_SOURCE"
+	CWAGENT_SELF_UPDATE_PUBLIC_KEY     = "CWAGENT_SELF_UPDATE_PUBLIC_KEY"
+	CWAGENT_SELF_UPDATE_CHECK_INTERVAL = "CWAGENT_SELF_UPDATE_CHECK_INTERVAL"
+	CWAGENT_SELF_UPDATE_HEALTH_WINDOW  = "CWAGENT_SELF_UPDATE_HEALTH_WINDOW"
+
+	CWAGENT_DEBUG_SAMPLE_DIR          = "CWAGENT_DEBUG_SAMPLE_DIR"
+	CWAGENT_DEBUG_SAMPLE_MAX_PAYLOADS = "CWAGENT_DEBUG_SAMPLE_MAX_PAYLOADS"
+	CWAGENT_DEBUG_SAMPLE_MAX_BYTES    = "CWAGENT_DEBUG_SAMPLE_MAX_BYTES"
+	CWAGENT_DEBUG_SAMPLE_EVERY        = "CWAGENT_DEBUG_SAMPLE_EVERY"
+	CWAGENT_DEBUG_SAMPLE_REDACT       = "CWAGENT_DEBUG_SAMPLE_REDACT"
+
+	CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST = "CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST"
+	CWAGENT_HTTP_IDLE_CONN_TIMEOUT       = "CWAGENT_HTTP_IDLE_CONN_TIMEOUT"
+	CWAGENT_HTTP_TLS_HANDSHAKE_TIMEOUT   = "CWAGENT_HTTP_TLS_HANDSHAKE_TIMEOUT"
+	CWAGENT_HTTP_DISABLE_HTTP2           = "CWAGENT_HTTP_DISABLE_HTTP2"
+	CWAGENT_DNS_CACHE_TTL                = "CWAGENT_DNS_CACHE_TTL"
 )
@@ -23,14 +23,22 @@ import (
 	"time"
 
 	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
 	"github.com/aws/amazon-cloudwatch-agent/cfg/migrate"
+	"github.com/aws/amazon-cloudwatch-agent/internal/jitter"
+	"github.com/aws/amazon-cloudwatch-agent/internal/selfupdate"
+	"github.com/aws/amazon-cloudwatch-agent/internal/toggle"
 	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/parsers/collectd"
 	"github.com/aws/amazon-cloudwatch-agent/profiler"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
 
 	lumberjack "github.com/aws/amazon-cloudwatch-agent/logger"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/models"
 
 	//_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -38,7 +46,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	//_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	//_ "github.com/influxdata/telegraf/plugins/processors/all"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins"
+	"github.com/aws/amazon-cloudwatch-agent/plugins"
 	"github.com/kardianos/service"
 )
 
@@ -54,6 +62,14 @@ var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "enable test mode: gather metrics, print them out, and exit")
 var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test mode")
+var fSnapshot = flag.Bool("snapshot", false, "collect the metrics of the inputs of the config once, print the datums the cloudwatch outputs would publish as json lines, with their names, dimensions, values and units, and exit. The metrics published as embedded metric format logs by cloudwatchlogs are not covered. The service inputs are run for -test-wait seconds")
+var fRecord = flag.String("record", "", "record the raw log lines, statsd datagrams and prometheus scrapes received into this file, for replaying them later")
+var fPreflight = flag.Bool("preflight", false, "check the access to the files and the sockets of the inputs of the config and the IAM actions of the plugins calling AWS, print the report as json and exit")
+var fReplay = flag.String("replay", "", "run the samples recorded in this file through the inputs of the config, print the resulting log events and metrics, and exit")
+var fBackfill = flag.Bool("backfill", false, "read the files of the logfile input of the config once from their beginning, push their log events to the log outputs and exit")
+var fSince = flag.String("since", "", "backfill the log events timestamped since this duration before now, e.g. 72h, or this RFC3339 time, at most 14 days ago")
+var fBackfillFiles = flag.String("backfill-files", "", "backfill the files matched by these comma separated globs rather than by the file_path of the config, e.g. the rotated files")
+var fDecommission = flag.Bool("decommission", false, "delete the resources the outputs of the config manage for the instance, e.g. its alarms, and exit")
 var fSchemaTest = flag.Bool("schematest", false, "validate the toml file schema")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fEnvConfig = flag.String("envconfig", "", "env configuration file to load")
@@ -63,6 +79,8 @@ var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
 var fPidfile = flag.String("pidfile", "", "file to write our pid to")
+var fDisabledFile = flag.String("disabled-file", "", "file the inputs and the log targets disabled with persist through the control API are saved into, they are disabled again at every start")
+var fControlFile = flag.String("control-file", "", "serve the control API and write its address and token into this file, or the file to read them from with the control command")
 var fSectionFilters = flag.String("section-filter", "",
 	"filter the sections to print, separator is ':'. Valid values are 'agent', 'global_tags', 'outputs', 'processors', 'aggregators' and 'inputs'")
 var fInputFilters = flag.String("input-filter", "",
@@ -73,6 +91,8 @@ var fOutputFilters = flag.String("output-filter", "",
 	"filter the outputs to enable, separator is :")
 var fOutputList = flag.Bool("output-list", false,
 	"print available output plugins.")
+var fListPlugins = flag.Bool("list-plugins", false,
+	"print the plugins compiled in, named after their sections as in the build tags, e.g. inputs.cpu, and exit")
 var fAggregatorFilters = flag.String("aggregator-filter", "",
 	"filter the aggregators to enable, separator is :")
 var fProcessorFilters = flag.String("processor-filter", "",
@@ -83,6 +103,7 @@ var fService = flag.String("service", "",
 	"operate on the service (windows only)")
 var fServiceName = flag.String("service-name", "telegraf", "service name (windows only)")
 var fServiceDisplayName = flag.String("service-display-name", "Telegraf Data Collector Service", "service display name (windows only)")
+var fServiceDelayedStart = flag.Bool("service-delayed-start", false, "start the service after the other auto-start services when installing it (windows only)")
 var fRunAsConsole = flag.Bool("console", false, "run as console application (windows only)")
 
 var (
@@ -100,6 +121,14 @@ func reloadLoop(
 	aggregatorFilters []string,
 	processorFilters []string,
 ) {
+	if *fDisabledFile != "" {
+		if err := toggle.Load(*fDisabledFile); err != nil {
+			log.Printf("E! %v", err)
+		}
+	}
+	if controlServer := startControl(); controlServer != nil {
+		defer controlServer.Stop()
+	}
 	reload := make(chan bool, 1)
 	reload <- true
 	for <-reload {
@@ -119,6 +148,13 @@ func reloadLoop(
 					reload <- true
 				}
 				cancel()
+			case <-reloadRequests:
+				log.Printf("I! Reloading Telegraf config")
+				<-reload
+				reload <- true
+				cancel()
+			case <-selfupdate.Restarting():
+				cancel()
 			case <-stop:
 				cancel()
 			}
@@ -141,6 +177,7 @@ func reloadLoop(
 
 		err := runAgent(ctx, inputFilters, outputFilters)
 		if err != nil && err != context.Canceled {
+			serviceStatus.failed(err)
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
 	}
@@ -189,12 +226,15 @@ func runAgent(ctx context.Context,
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
 
+	serviceStatus.starting("loading the configuration " + *fConfig)
 	isOld, err := migrate.IsOldConfig(*fConfig)
 	if err != nil {
 		log.Printf("W! Failed to detect if config file is old format: %v", err)
 	}
 
+	configFile := *fConfig
 	if isOld {
+		serviceStatus.starting("migrating the configuration of the old format")
 		migratedConfFile, err := migrate.MigrateFile(*fConfig)
 		if err != nil {
 			log.Printf("W! Failed to migrate old config format file %v: %v", *fConfig, err)
@@ -204,6 +244,7 @@ func runAgent(ctx context.Context,
 		if err != nil {
 			return err
 		}
+		configFile = migratedConfFile
 
 		agentinfo.BuildStr += "_M"
 	} else {
@@ -219,7 +260,7 @@ func runAgent(ctx context.Context,
 			return err
 		}
 	}
-	if !*fTest && len(c.Outputs) == 0 {
+	if !*fTest && *fReplay == "" && !*fPreflight && len(c.Outputs) == 0 {
 		return errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if len(c.Inputs) == 0 {
@@ -262,6 +303,55 @@ func runAgent(ctx context.Context,
 	logger.SetupLogging(logConfig)
 	log.Printf("I! Starting AmazonCloudWatchAgent %s", agentinfo.Version())
 
+	if *fPreflight {
+		report := runPreflight(c, true)
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		if report.Errors > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *fDecommission {
+		if err := runDecommission(c); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+
+	if *fBackfill {
+		since, err := parseSince(*fSince, time.Now())
+		if err != nil {
+			return err
+		}
+		var globs []string
+		if *fBackfillFiles != "" {
+			globs = strings.Split(*fBackfillFiles, ",")
+		}
+		if err := runBackfill(c, since, globs); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+
+	if *fReplay != "" {
+		if err := replay(c, *fReplay); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+
+	if *fSnapshot {
+		if err := runSnapshot(c, time.Duration(*fTestWait)*time.Second); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+
 	if *fTest || *fTestWait != 0 {
 		testWaitDuration := time.Duration(*fTestWait) * time.Second
 		return ag.Test(ctx, testWaitDuration)
@@ -275,6 +365,8 @@ func runAgent(ctx context.Context,
 
 	agentinfo.InputPlugins = c.InputNames()
 	agentinfo.OutputPlugins = c.OutputNames()
+	// the JSON config is hashed by the translator, the TOML it generates differs between the hosts, e.g. by region
+	agentinfo.ConfigHash = os.Getenv(envconfig.CWAGENT_CONFIG_HASH)
 
 	if *fPidfile != "" {
 		f, err := os.OpenFile(*fPidfile, os.O_CREATE|os.O_WRONLY, 0644)
@@ -293,8 +385,36 @@ func runAgent(ctx context.Context,
 			}()
 		}
 	}
+	if *fRecord != "" {
+		if err := recorder.Recorder.Enable(*fRecord); err != nil {
+			return fmt.Errorf("unable to record samples into %v: %v", *fRecord, err)
+		}
+		log.Printf("I! Recording the raw log lines, statsd datagrams and prometheus scrapes into %v", *fRecord)
+		defer recorder.Recorder.Close()
+	}
+
+	logPreflight(runPreflight(c, false))
+	collectd.WatchTypesDB(ctx, configFile, c.Inputs)
+	toggle.WrapInputs(c.Inputs, func(i telegraf.Input) bool {
+		_, ok := i.(logs.LogCollection)
+		return ok
+	})
+	jitters, err := jitter.Parse(os.Getenv(envconfig.CWAGENT_COLLECTION_JITTER))
+	if err != nil {
+		log.Printf("W! Invalid %v, the inputs collect without jitter: %v", envconfig.CWAGENT_COLLECTION_JITTER, err)
+	}
+	jitter.WrapInputs(c.Inputs, jitters, c.Agent.Interval.Duration, ctx.Done())
+
+	selfUpdate := startSelfUpdate(c)
+
 	logAgent := logs.NewLogAgent(c)
 	go logAgent.Run(ctx)
+	if serviceStatus != (noServiceStatus{}) || selfUpdate {
+		c.Inputs = append(c.Inputs, models.NewRunningInput(serviceStatusInput{}, &models.InputConfig{
+			Name:     "service_status",
+			Interval: serviceStatus.aliveInterval(),
+		}))
+	}
 	return ag.Run(ctx)
 }
 
@@ -323,6 +443,7 @@ func (p *program) run() {
 		p.aggregatorFilters,
 		p.processorFilters,
 	)
+	exitIfUpdated()
 }
 func (p *program) Stop(s service.Service) error {
 	close(stop)
@@ -386,6 +507,21 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "multiline-test":
+			if err := multilineTest(args[1:]); err != nil {
+				log.Fatalf("E! %v", err)
+			}
+			return
+		case "control":
+			if err := controlCommand(args[1:]); err != nil {
+				log.Fatalf("E! %v", err)
+			}
+			return
+		case "suggest":
+			if err := suggestCommand(args[1:], os.Stdout); err != nil {
+				log.Fatalf("E! %v", err)
+			}
+			return
 		}
 	}
 
@@ -413,6 +549,11 @@ func main() {
 			fmt.Printf("  %s\n", k)
 		}
 		return
+	case *fListPlugins:
+		for _, name := range plugins.List() {
+			fmt.Println(name)
+		}
+		return
 	case *fVersion:
 		fmt.Println(agentinfo.FullVersion())
 		return
@@ -473,6 +614,11 @@ func main() {
 			if err != nil {
 				log.Fatal("E! " + err.Error())
 			}
+			if *fService == "install" {
+				if err := configureService(*fServiceName, *fServiceDelayedStart); err != nil {
+					log.Fatal("E! " + err.Error())
+				}
+			}
 			os.Exit(0)
 		} else {
 			winlogger, err := s.Logger(nil)
@@ -481,13 +627,16 @@ func main() {
 				logger.RegisterEventLogger(winlogger)
 				logger.SetupLogging(logger.LogConfig{LogTarget: lumberjack.LogTargetLumberjack})
 			}
-			err = s.Run()
+			err = runWindowsService(*fServiceName, prg)
 
 			if err != nil {
 				log.Println("E! " + err.Error())
 			}
 		}
 	} else {
+		if s, ok := newSystemdService(); ok {
+			serviceStatus = s
+		}
 		stop = make(chan struct{})
 		reloadLoop(
 			stop,
@@ -496,6 +645,7 @@ func main() {
 			aggregatorFilters,
 			processorFilters,
 		)
+		exitIfUpdated()
 	}
 }
 
@@ -0,0 +1,111 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/config"
+)
+
+const (
+	// maxBackfillAge is how far back the log events are backfilled, CloudWatch Logs rejects the ones older than 14
+	// days and the margin keeps them within the window until they are sent
+	maxBackfillAge = 14*24*time.Hour - time.Hour
+	// backfillCloseTimeout is how long the outputs are given to send the log events still buffered
+	backfillCloseTimeout = 5 * time.Minute
+)
+
+// backfiller is an input which reads the historical files once, e.g. the logfile input
+type backfiller interface {
+	Backfill(globs []string, since time.Time) ([]logs.LogSrc, error)
+}
+
+// closeWaiter is an output sending what it buffers once closed, e.g. the cloudwatchlogs output
+type closeWaiter interface {
+	WaitClosed(timeout time.Duration) bool
+}
+
+// parseSince returns the time of the oldest log events to backfill, given as a duration before now, e.g. 72h, or
+// as an RFC3339 time. It is moved into the window accepted by CloudWatch Logs when it is older or not given.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	oldest := now.Add(-maxBackfillAge)
+	if since == "" {
+		return oldest, nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		d, derr := time.ParseDuration(since)
+		if derr != nil || d < 0 {
+			return time.Time{}, fmt.Errorf("invalid since %q, expecting a duration, e.g. 72h, or an RFC3339 time", since)
+		}
+		t = now.Add(-d)
+	}
+	if t.Before(oldest) {
+		log.Printf("W! Backfilling since %v instead of %v, CloudWatch Logs does not accept the log events older than 14 days", oldest.Format(time.RFC3339), t.Format(time.RFC3339))
+		return oldest, nil
+	}
+	return t, nil
+}
+
+// runBackfill reads the historical files matched by the globs, the files of the config when none are given, once
+// through the inputs of the config which support it and pushes their log events to the log outputs. It returns
+// once all of them are sent. The rate limits of the outputs apply, e.g. max_api_tps.
+func runBackfill(c *config.Config, since time.Time, globs []string) error {
+	var srcs []logs.LogSrc
+	for _, input := range c.Inputs {
+		b, ok := input.Input.(backfiller)
+		if !ok {
+			continue
+		}
+		if err := input.Init(); err != nil {
+			return fmt.Errorf("could not initialize input %s: %v", input.LogName(), err)
+		}
+		s, err := b.Backfill(globs, since)
+		if err != nil {
+			return fmt.Errorf("unable to backfill through %v: %v", input.LogName(), err)
+		}
+		srcs = append(srcs, s...)
+	}
+	if len(srcs) == 0 {
+		return errors.New("no files to backfill, the inputs of the config match none modified since then")
+	}
+
+	var waiters []closeWaiter
+	for _, output := range c.Outputs {
+		if _, ok := output.Output.(logs.LogBackend); !ok {
+			continue
+		}
+		if err := output.Init(); err != nil {
+			return fmt.Errorf("could not initialize output %s: %v", output.LogName(), err)
+		}
+		if err := output.Output.Connect(); err != nil {
+			return fmt.Errorf("could not connect output %s: %v", output.LogName(), err)
+		}
+		if w, ok := output.Output.(closeWaiter); ok {
+			waiters = append(waiters, w)
+		}
+	}
+
+	log.Printf("I! Backfilling %v files since %v", len(srcs), since.Format(time.RFC3339))
+	logs.NewLogAgent(c).Backfill(srcs)
+
+	// the outputs send what they buffer once closed
+	for _, output := range c.Outputs {
+		if _, ok := output.Output.(logs.LogBackend); ok {
+			output.Output.Close()
+		}
+	}
+	for _, w := range waiters {
+		if !w.WaitClosed(backfillCloseTimeout) {
+			return fmt.Errorf("the log events were not all sent within %v", backfillCloseTimeout)
+		}
+	}
+	log.Printf("I! Backfilled %v files", len(srcs))
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal/catalog"
+	"github.com/aws/amazon-cloudwatch-agent/internal/control"
+	"github.com/aws/amazon-cloudwatch-agent/internal/flush"
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/aws/amazon-cloudwatch-agent/internal/toggle"
+	"github.com/influxdata/wlog"
+)
+
+// reloadRequests are the reloads of the config requested through the control API, like on SIGHUP
+var reloadRequests = make(chan struct{}, 1)
+
+var startTime = time.Now()
+
+type agentStatus struct {
+	Version    string   `json:"version"`
+	Build      string   `json:"build"`
+	Pid        int      `json:"pid"`
+	Uptime     string   `json:"uptime"`
+	ConfigHash string   `json:"config_hash"`
+	Inputs     []string `json:"inputs"`
+	Outputs    []string `json:"outputs"`
+	LogLevel   string   `json:"log_level"`
+	Disabled   []string `json:"disabled"`
+	// Publishing are the statistics of the batches published to every target
+	Publishing []publishstats.Summary `json:"publishing"`
+}
+
+type metricsCatalog struct {
+	Metrics []catalog.Entry `json:"metrics"`
+	// Truncated is whether metrics are missing since the catalog is full
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func logLevelName() string {
+	for name, level := range wlog.StringToLevel {
+		if level == wlog.LogLevel() {
+			return strings.ToLower(name)
+		}
+	}
+	return ""
+}
+
+var controlHandlers = map[string]control.Handler{
+	"status": func(map[string]string) (interface{}, error) {
+		return agentStatus{
+			Version:    agentinfo.Version(),
+			Build:      agentinfo.Build(),
+			Pid:        os.Getpid(),
+			Uptime:     time.Since(startTime).Round(time.Second).String(),
+			ConfigHash: agentinfo.ConfigHash,
+			Inputs:     agentinfo.InputPlugins,
+			Outputs:    agentinfo.OutputPlugins,
+			LogLevel:   logLevelName(),
+			Disabled:   toggle.List(),
+			Publishing: publishstats.Report(),
+		}, nil
+	},
+	// the catalog of the metrics published since the start of the agent, of the namespace when it is given
+	"catalog": func(args map[string]string) (interface{}, error) {
+		metrics, truncated := catalog.Report(args["namespace"])
+		return metricsCatalog{Metrics: metrics, Truncated: truncated}, nil
+	},
+	"flush": func(map[string]string) (interface{}, error) {
+		flush.Request()
+		return "the outputs are flushing what they buffer", nil
+	},
+	"reload": func(map[string]string) (interface{}, error) {
+		select {
+		case reloadRequests <- struct{}{}:
+		default:
+			// a reload is already pending
+		}
+		return "the config is reloading", nil
+	},
+	"disable": func(args map[string]string) (interface{}, error) {
+		if err := toggle.Disable(args["name"], args["persist"] == "true"); err != nil {
+			return nil, err
+		}
+		return args["name"] + " is disabled", nil
+	},
+	"enable": func(args map[string]string) (interface{}, error) {
+		if err := toggle.Enable(args["name"], args["persist"] == "true"); err != nil {
+			return nil, err
+		}
+		return args["name"] + " is enabled", nil
+	},
+	// the level is reset to the one of the config on the next reload
+	"set-log-level": func(args map[string]string) (interface{}, error) {
+		if err := wlog.SetLevelFromName(args["level"]); err != nil {
+			return nil, fmt.Errorf("%v, expecting debug, info, warn, error or off", err)
+		}
+		return "the log level is " + logLevelName(), nil
+	},
+}
+
+// startControl serves the control API when the agent is given a control file
+func startControl() *control.Server {
+	if *fControlFile == "" {
+		return nil
+	}
+	s := control.NewServer(*fControlFile, controlHandlers)
+	if err := s.Start(); err != nil {
+		log.Printf("E! The control API is disabled: %v", err)
+		return nil
+	}
+	return s
+}
+
+// controlCommand runs the verb of the control API on the running agent, e.g. "control set-log-level level=debug"
+func controlCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: control -control-file <file> status|catalog [namespace=<namespace>]|flush|reload|set-log-level level=<level>|disable name=<target> [persist=true]|enable name=<target> [persist=true]")
+	}
+	if *fControlFile == "" {
+		return fmt.Errorf("the control file is not given, use -control-file")
+	}
+	verbArgs := map[string]string{}
+	for _, arg := range args[1:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid argument %q, expecting key=value", arg)
+		}
+		verbArgs[kv[0]] = kv[1]
+	}
+	result, err := control.Call(*fControlFile, args[0], verbArgs)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(result))
+	return nil
+}
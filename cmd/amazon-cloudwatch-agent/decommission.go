@@ -0,0 +1,34 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf/config"
+)
+
+// decommissioner is an output which cleans up what it manages for the instance once it is decommissioned,
+// e.g. the alarms of the cloudwatch output
+type decommissioner interface {
+	Decommission() error
+}
+
+// runDecommission decommissions all the outputs of the config, the errors are returned together once all of them ran
+func runDecommission(c *config.Config) error {
+	var errs []error
+	for _, output := range c.Outputs {
+		d, ok := output.Output.(decommissioner)
+		if !ok {
+			continue
+		}
+		if err := d.Decommission(); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", output.LogName(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to decommission the outputs: %v", errs)
+	}
+	return nil
+}
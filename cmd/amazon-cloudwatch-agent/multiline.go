@@ -0,0 +1,34 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !custom inputs inputs.logfile
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile"
+)
+
+// multilineTest prints how the lines of a sample file are merged into log entries by a multi-line start pattern,
+// or the named patterns without arguments:
+//   amazon-cloudwatch-agent multiline-test '<pattern or {name}>' <sample file>
+func multilineTest(args []string) error {
+	if len(args) != 2 {
+		fmt.Println("Usage: amazon-cloudwatch-agent multiline-test '<multi_line_start_pattern>' <sample file>")
+		fmt.Println("The named multi-line start patterns:")
+		logfile.PrintMultilinePatterns(os.Stdout)
+		if len(args) == 0 {
+			return nil
+		}
+		return fmt.Errorf("expected a pattern and a sample file, got %v arguments", len(args))
+	}
+	sample, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer sample.Close()
+	return logfile.PrintMultilineEntries(args[0], sample, os.Stdout)
+}
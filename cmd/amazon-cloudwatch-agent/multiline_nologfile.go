@@ -0,0 +1,12 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build custom,!inputs,!inputs.logfile
+
+package main
+
+import "errors"
+
+func multilineTest(args []string) error {
+	return errors.New("multiline-test requires the logfile input, which is not compiled in")
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf/config"
+)
+
+// runPreflight checks the access to the files and the sockets of the inputs of the config, and with iam that the IAM
+// actions of the plugins calling AWS are allowed.
+func runPreflight(c *config.Config, iam bool) preflight.Report {
+	var checks []preflight.Check
+	for _, input := range c.Inputs {
+		var inputChecks []preflight.Check
+		if checker, ok := input.Input.(preflight.Checker); ok {
+			inputChecks = checker.Preflight()
+		} else if inputChecks, ok = socketListenerPreflight(input.Input); !ok {
+			continue
+		}
+		for j := range inputChecks {
+			inputChecks[j].Plugin = input.LogName()
+		}
+		checks = append(checks, inputChecks...)
+	}
+	if iam {
+		checks = append(checks, runIAMPreflight(c)...)
+	}
+	return preflight.NewReport(checks)
+}
+
+// runIAMPreflight checks the IAM actions of the inputs, the processors and the outputs calling AWS. The actions of
+// the outputs sending log events are checked on the log groups of the inputs.
+func runIAMPreflight(c *config.Config) []preflight.Check {
+	var checks, pluginChecks []preflight.Check
+	var groups []preflight.LogGroup
+	for _, input := range c.Inputs {
+		if source, ok := input.Input.(preflight.LogGroupsSource); ok {
+			groups = append(groups, source.LogGroups()...)
+		}
+	}
+	add := func(plugin interface{}, name string) {
+		if checker, ok := plugin.(preflight.LogGroupsIAMChecker); ok {
+			pluginChecks = checker.PreflightIAMLogGroups(groups)
+		} else if checker, ok := plugin.(preflight.IAMChecker); ok {
+			pluginChecks = checker.PreflightIAM()
+		} else {
+			return
+		}
+		for j := range pluginChecks {
+			pluginChecks[j].Plugin = name
+		}
+		checks = append(checks, pluginChecks...)
+	}
+	for _, input := range c.Inputs {
+		add(input.Input, input.LogName())
+	}
+	for _, processor := range c.Processors {
+		name := "processors." + processor.Config.Name
+		if processor.Config.Alias != "" {
+			name += "::" + processor.Config.Alias
+		}
+		add(processor.Processor, name)
+	}
+	for _, output := range c.Outputs {
+		add(output.Output, output.LogName())
+	}
+	return checks
+}
+
+// logPreflight logs every check which did not pass as a json line, so that they can be searched for in the agent log
+func logPreflight(r preflight.Report) {
+	for _, check := range r.Checks {
+		level := "W!"
+		switch check.Status {
+		case preflight.StatusOK:
+			continue
+		case preflight.StatusError:
+			level = "E!"
+		}
+		b, err := json.Marshal(check)
+		if err != nil {
+			continue
+		}
+		log.Printf("%v [preflight] %s", level, b)
+	}
+	log.Printf("I! [preflight] %v checks, %v errors, %v warnings", len(r.Checks), r.Errors, r.Warnings)
+}
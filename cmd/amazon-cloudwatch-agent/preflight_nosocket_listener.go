@@ -0,0 +1,16 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build custom,!inputs,!inputs.socket_listener
+
+package main
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf"
+)
+
+// socketListenerPreflight checks nothing, the socket_listener is not compiled in
+func socketListenerPreflight(input telegraf.Input) ([]preflight.Check, bool) {
+	return nil, false
+}
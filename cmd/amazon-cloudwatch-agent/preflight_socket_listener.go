@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !custom inputs inputs.socket_listener
+
+package main
+
+import (
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/socket_listener"
+)
+
+// socketListenerPreflight checks the socket of the socket_listener of telegraf, used for collectd and emf, since it
+// does not implement preflight.Checker.
+func socketListenerPreflight(input telegraf.Input) ([]preflight.Check, bool) {
+	listener, ok := input.(*socket_listener.SocketListener)
+	if !ok {
+		return nil, false
+	}
+	spl := strings.SplitN(listener.ServiceAddress, "://", 2)
+	if len(spl) != 2 {
+		return nil, false
+	}
+	return []preflight.Check{preflight.CheckListen(spl[0], spl[1])}, true
+}
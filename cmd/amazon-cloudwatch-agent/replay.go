@@ -0,0 +1,84 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// replayedLogEvent is the json line printed for every log event produced by the replay
+type replayedLogEvent struct {
+	Timestamp     string `json:"timestamp,omitempty"`
+	LogGroupName  string `json:"log_group_name"`
+	LogStreamName string `json:"log_stream_name"`
+	Message       string `json:"message"`
+}
+
+// replay feeds the samples of the recording to the inputs of the config which support replaying them,
+// and prints the resulting log events as json lines and the metrics in line protocol.
+// Processors, aggregators and outputs are not run.
+func replay(c *config.Config, path string) error {
+	samples, err := recorder.ReadSamples(path)
+	if err != nil {
+		return fmt.Errorf("unable to read recording %v: %v", path, err)
+	}
+	log.Printf("I! Replaying %v samples of %v", len(samples), path)
+
+	metricC := make(chan telegraf.Metric, 100)
+	printed := make(chan struct{})
+	go func() {
+		defer close(printed)
+		serializer := influx.NewSerializer()
+		for m := range metricC {
+			b, err := serializer.Serialize(m)
+			if err != nil {
+				log.Printf("E! Unable to serialize metric %v: %v", m.Name(), err)
+				continue
+			}
+			os.Stdout.Write(b)
+		}
+	}()
+
+	for _, input := range c.Inputs {
+		switch r := input.Input.(type) {
+		case recorder.LogReplayer:
+			err = r.ReplayLogs(samples, printLogEvent)
+		case recorder.MetricReplayer:
+			err = r.ReplayMetrics(samples, agent.NewAccumulator(input, metricC))
+		default:
+			continue
+		}
+		if err != nil {
+			close(metricC)
+			return fmt.Errorf("unable to replay samples through %v: %v", input.LogName(), err)
+		}
+	}
+	close(metricC)
+	<-printed
+	return nil
+}
+
+func printLogEvent(group, stream string, e logs.LogEvent) {
+	r := replayedLogEvent{LogGroupName: group, LogStreamName: stream, Message: e.Message()}
+	if t := e.Time(); !t.IsZero() {
+		r.Timestamp = t.Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("E! Unable to marshal log event of %v/%v: %v", group, stream, err)
+		return
+	}
+	fmt.Println(string(b))
+}
@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal/selfupdate"
+	"github.com/influxdata/telegraf/config"
+)
+
+// agentHealth is updated by the service status input, a self-update is committed once the agent is started and
+// its gather loop is still running at the end of the health window
+var agentHealth struct {
+	started   int32
+	lastAlive int64
+}
+
+func (serviceStatusInput) recordStarted() {
+	atomic.StoreInt32(&agentHealth.started, 1)
+	atomic.StoreInt64(&agentHealth.lastAlive, time.Now().UnixNano())
+}
+
+func (serviceStatusInput) recordAlive() {
+	atomic.StoreInt64(&agentHealth.lastAlive, time.Now().UnixNano())
+}
+
+// startSelfUpdate starts the self-update when it is configured, returns whether it is started
+func startSelfUpdate(c *config.Config) bool {
+	cfg, ok, err := selfupdate.ConfigFromEnv()
+	if err != nil {
+		log.Printf("E! The self-update is disabled: %v", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		log.Printf("E! The self-update is disabled, unable to locate the agent binary: %v", err)
+		return false
+	}
+	interval := serviceStatus.aliveInterval()
+	if interval == 0 {
+		interval = c.Agent.Interval.Duration
+	}
+	selfupdate.Start(cfg, filepath.Dir(exe), agentinfo.Version(), func() bool {
+		lastAlive := time.Unix(0, atomic.LoadInt64(&agentHealth.lastAlive))
+		return atomic.LoadInt32(&agentHealth.started) == 1 && time.Since(lastAlive) < 3*interval
+	})
+	return true
+}
+
+// exitIfUpdated exits for the service manager to start the new version once the self-update installed it
+func exitIfUpdated() {
+	select {
+	case <-selfupdate.Restarting():
+		log.Printf("I! Exiting to restart into the new version of the agent")
+		os.Exit(selfupdate.RestartExitCode)
+	default:
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// serviceStatusReporter is told about the progress of the agent so that the service wrapper of
+// the platform can report it to the service manager.
+type serviceStatusReporter interface {
+	// starting is called for every step of a start which may take a while, e.g. translating the config
+	starting(step string)
+	// started is called once the outputs are connected and the service inputs are started
+	started()
+	// alive is called from the gather loop of the agent every aliveInterval
+	alive()
+	// aliveInterval returns how often alive must be called, 0 uses the collection interval of the agent
+	aliveInterval() time.Duration
+	// failed is called when the agent is about to exit because of the error
+	failed(err error)
+}
+
+// noServiceStatus is used whenever the agent does not run as a service
+type noServiceStatus struct{}
+
+func (noServiceStatus) starting(string)              {}
+func (noServiceStatus) started()                     {}
+func (noServiceStatus) alive()                       {}
+func (noServiceStatus) aliveInterval() time.Duration { return 0 }
+func (noServiceStatus) failed(error)                 {}
+
+var serviceStatus serviceStatusReporter = noServiceStatus{}
+
+// serviceStatusInput is added after the inputs of the config. The agent starts it once all the outputs are
+// connected and the other service inputs are started, and gathers it from its gather loop like any other input.
+type serviceStatusInput struct{}
+
+func (serviceStatusInput) Description() string {
+	return "Report the agent status to the service manager"
+}
+
+func (serviceStatusInput) SampleConfig() string { return "" }
+
+func (i serviceStatusInput) Start(telegraf.Accumulator) error {
+	serviceStatus.started()
+	i.recordStarted()
+	return nil
+}
+
+func (i serviceStatusInput) Gather(telegraf.Accumulator) error {
+	serviceStatus.alive()
+	i.recordAlive()
+	return nil
+}
+
+func (serviceStatusInput) Stop() {}
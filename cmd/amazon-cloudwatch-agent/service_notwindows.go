@@ -0,0 +1,18 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !windows
+
+package main
+
+import "errors"
+
+var errNotWindows = errors.New("the service manager is only supported on windows")
+
+func runWindowsService(string, *program) error {
+	return errNotWindows
+}
+
+func configureService(string, bool) error {
+	return errNotWindows
+}
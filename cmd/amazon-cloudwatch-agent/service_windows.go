@@ -0,0 +1,257 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+	"unsafe"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	serviceCmdsAccepted = svc.AcceptStop | svc.AcceptShutdown
+	// The service manager considers the service hung when no progress is reported within the wait hint
+	serviceWaitHint      = 30 * time.Second
+	serviceProgressEvery = 5 * time.Second
+	// Reset the failure count of the recovery actions after a day without failure
+	serviceRecoveryResetPeriod = 24 * 60 * 60
+	// eventLogSourcesKey holds the registry keys of the sources of the Application event log
+	eventLogSourcesKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application`
+)
+
+// Event ids of the events written to the Application event log
+const (
+	eventIDStarted     = 100
+	eventIDStopped     = 101
+	eventIDStartFailed = 200
+)
+
+// Restart immediately on the first two failures then after 2 seconds on the subsequent ones
+var serviceRecoveryActions = []mgr.RecoveryAction{
+	{Type: mgr.ServiceRestart, Delay: 0},
+	{Type: mgr.ServiceRestart, Delay: 0},
+	{Type: mgr.ServiceRestart, Delay: 2 * time.Second},
+}
+
+// serviceEvent is the json message of the events written to the event log
+type serviceEvent struct {
+	Event   string `json:"event"`
+	Version string `json:"version"`
+	Config  string `json:"config,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// windowsService runs the agent as a Windows service, it reports the start steps to the service manager
+// so that a long config translation is not mistaken for a hung start.
+type windowsService struct {
+	prg  *program
+	elog *eventlog.Log
+
+	steps chan string
+	ready chan struct{}
+}
+
+func (ws *windowsService) starting(step string) {
+	select {
+	case ws.steps <- step:
+	default:
+	}
+}
+
+func (ws *windowsService) started() {
+	select {
+	case ws.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (ws *windowsService) alive() {}
+
+func (ws *windowsService) aliveInterval() time.Duration {
+	return 0
+}
+
+// failed writes the event synchronously since the agent exits right after
+func (ws *windowsService) failed(err error) {
+	ws.writeEvent(eventIDStartFailed, serviceEvent{Event: "failed", Error: err.Error()})
+}
+
+func (ws *windowsService) Execute(_ []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	var checkPoint uint32
+	reportProgress := func() {
+		checkPoint++
+		changes <- svc.Status{State: svc.StartPending, CheckPoint: checkPoint, WaitHint: uint32(serviceWaitHint / time.Millisecond)}
+	}
+	reportProgress()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ws.prg.run()
+	}()
+
+	ticker := time.NewTicker(serviceProgressEvery)
+	defer ticker.Stop()
+	running := false
+	for {
+		select {
+		case step := <-ws.steps:
+			if !running {
+				log.Printf("I! Service start step: %s", step)
+				reportProgress()
+			}
+		case <-ticker.C:
+			if !running {
+				reportProgress()
+			}
+		case <-ws.ready:
+			if !running {
+				running = true
+				changes <- svc.Status{State: svc.Running, Accepts: serviceCmdsAccepted}
+				ws.writeEvent(eventIDStarted, serviceEvent{Event: "started"})
+			}
+		case <-stopped:
+			// the agent stopped by itself, the service manager applies the recovery actions
+			return true, 1
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				changes <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				ws.stop(changes, stopped)
+				return false, 0
+			}
+		}
+	}
+}
+
+// stop reports the progress of the shutdown until the agent has flushed and exited
+func (ws *windowsService) stop(changes chan<- svc.Status, stopped chan struct{}) {
+	var checkPoint uint32
+	changes <- svc.Status{State: svc.StopPending, WaitHint: uint32(serviceWaitHint / time.Millisecond)}
+	ws.prg.Stop(nil)
+
+	ticker := time.NewTicker(serviceProgressEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopped:
+			ws.writeEvent(eventIDStopped, serviceEvent{Event: "stopped"})
+			return
+		case <-ticker.C:
+			checkPoint++
+			changes <- svc.Status{State: svc.StopPending, CheckPoint: checkPoint, WaitHint: uint32(serviceWaitHint / time.Millisecond)}
+		}
+	}
+}
+
+func (ws *windowsService) writeEvent(eid uint32, e serviceEvent) {
+	if ws.elog == nil {
+		return
+	}
+	e.Version = agentinfo.Version()
+	e.Config = *fConfig
+	msg, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("E! Unable to marshal service event %v: %v", e.Event, err)
+		return
+	}
+	if e.Error != "" {
+		err = ws.elog.Error(eid, string(msg))
+	} else {
+		err = ws.elog.Info(eid, string(msg))
+	}
+	if err != nil {
+		log.Printf("E! Unable to write service event %v to the event log: %v", e.Event, err)
+	}
+}
+
+// runWindowsService runs the agent under the service manager until the service is stopped
+func runWindowsService(name string, prg *program) error {
+	ws := &windowsService{
+		prg:   prg,
+		steps: make(chan string, 10),
+		ready: make(chan struct{}, 1),
+	}
+	elog, err := eventlog.Open(name)
+	if err != nil {
+		log.Printf("W! Unable to open the event log of %v, service events will not be written: %v", name, err)
+	} else {
+		ws.elog = elog
+		defer elog.Close()
+	}
+	serviceStatus = ws
+	return svc.Run(name, ws)
+}
+
+// configureService sets the recovery actions of the installed service, the event log source and optionally
+// the delayed auto-start so that the agent starts once the network and the other services are up.
+func configureService(name string, delayedStart bool) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %v", name, err)
+	}
+	defer s.Close()
+
+	if err := s.SetRecoveryActions(serviceRecoveryActions, serviceRecoveryResetPeriod); err != nil {
+		return fmt.Errorf("unable to set the recovery actions of %s: %v", name, err)
+	}
+	// Also apply the recovery actions when the agent exits with an error, e.g. an invalid config, instead of only on crashes
+	flag := serviceFailureActionsFlag{FailureActionsOnNonCrashFailures: 1}
+	if err := windows.ChangeServiceConfig2(s.Handle, windows.SERVICE_CONFIG_FAILURE_ACTIONS_FLAG, (*byte)(unsafe.Pointer(&flag))); err != nil {
+		return fmt.Errorf("unable to apply the recovery actions of %s on non-crash failures: %v", name, err)
+	}
+
+	if delayedStart {
+		c, err := s.Config()
+		if err != nil {
+			return err
+		}
+		c.StartType = mgr.StartAutomatic
+		c.DelayedAutoStart = true
+		if err := s.UpdateConfig(c); err != nil {
+			return fmt.Errorf("unable to set the delayed auto-start of %s: %v", name, err)
+		}
+	}
+
+	if eventSourceInstalled(name) {
+		return nil
+	}
+	if err := eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		return fmt.Errorf("unable to register the event log source of %s: %v", name, err)
+	}
+	return nil
+}
+
+// eventSourceInstalled tells whether the event log source is registered already, eventlog.Install fails with an
+// untyped error in that case
+func eventSourceInstalled(name string) bool {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, eventLogSourcesKey+`\`+name, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	k.Close()
+	return true
+}
+
+// serviceFailureActionsFlag is the SERVICE_FAILURE_ACTIONS_FLAG structure
+type serviceFailureActionsFlag struct {
+	FailureActionsOnNonCrashFailures int32
+}
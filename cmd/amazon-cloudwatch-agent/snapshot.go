@@ -0,0 +1,209 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/outputs/cloudwatch"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+const (
+	// snapshotInterval is the time between the two collections of the snapshot
+	snapshotInterval = time.Second
+	// snapshotStartTimeout is how long the snapshot waits for the processors starting in the background
+	snapshotStartTimeout = 30 * time.Second
+)
+
+// snapshotter is an output which can tell the datums it would publish for the metrics without publishing them, only
+// the cloudwatch output is one, the embedded metric format logs of cloudwatchlogs are not covered
+type snapshotter interface {
+	Snapshot(metrics []telegraf.Metric) ([]cloudwatch.SnapshotDatum, error)
+}
+
+// starter is a processor which starts in the background after its Init and drops the metrics until it started,
+// e.g. the ec2tagger retrieving the tags of the instance
+type starter interface {
+	Started() bool
+}
+
+// actor is a processor which may act outside of the agent on the metrics, e.g. the anomaly processor running the
+// commands and publishing to the topics of its rules
+type actor interface {
+	HasActions() bool
+}
+
+// runSnapshot collects the metrics of the inputs of the config once, runs them through the processors and prints the
+// datums the outputs would publish as json lines, without publishing them.
+//
+// The inputs are gathered twice, a second apart, and only the metrics of the second collection are published, so that
+// the inputs and the processors computing rates from the previous collection, e.g. cpu and the delta of diskio, have
+// their values. The service inputs, e.g. statsd, are only started when wait is not zero, and the metrics they receive
+// until wait elapsed are published. The log inputs, the aggregators and the processors with actions are not run, and
+// only the cloudwatch outputs are printed.
+func runSnapshot(c *config.Config, wait time.Duration) error {
+	var outputs []*models.RunningOutput
+	for _, output := range c.Outputs {
+		if _, ok := output.Output.(snapshotter); ok {
+			outputs = append(outputs, output)
+		}
+	}
+	if len(outputs) == 0 {
+		return fmt.Errorf("no cloudwatch output in the config, the snapshot does not cover the metrics published as logs")
+	}
+
+	var inputs, serviceInputs []*models.RunningInput
+	for _, input := range c.Inputs {
+		if _, ok := input.Input.(logs.LogCollection); ok {
+			continue
+		}
+		if err := input.Init(); err != nil {
+			return fmt.Errorf("could not initialize input %v: %v", input.LogName(), err)
+		}
+		if _, ok := input.Input.(telegraf.ServiceInput); ok {
+			if wait != 0 {
+				serviceInputs = append(serviceInputs, input)
+			}
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+	processors, err := snapshotProcessors(c.Processors)
+	if err != nil {
+		return err
+	}
+	waitForProcessors(processors, snapshotStartTimeout)
+
+	// the metrics received by the service inputs
+	var collected []telegraf.Metric
+	metricC := make(chan telegraf.Metric, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for m := range metricC {
+			collected = append(collected, m)
+		}
+	}()
+	var started []telegraf.ServiceInput
+	for _, input := range serviceInputs {
+		si := input.Input.(telegraf.ServiceInput)
+		if err := si.Start(agent.NewAccumulator(input, metricC)); err != nil {
+			log.Printf("E! Unable to start the input %v: %v", input.LogName(), err)
+			continue
+		}
+		started = append(started, si)
+	}
+	var published []telegraf.Metric
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(snapshotInterval)
+		}
+		published = published[:0]
+		for _, input := range inputs {
+			gathered := make(chan telegraf.Metric, 100)
+			gatherDone := make(chan struct{})
+			var metrics []telegraf.Metric
+			go func() {
+				defer close(gatherDone)
+				for m := range gathered {
+					metrics = append(metrics, m)
+				}
+			}()
+			err := input.Gather(agent.NewAccumulator(input, gathered))
+			close(gathered)
+			<-gatherDone
+			if err != nil {
+				log.Printf("E! Unable to gather the input %v: %v", input.LogName(), err)
+			}
+			published = append(published, applyProcessors(processors, metrics)...)
+		}
+	}
+	if len(started) > 0 {
+		log.Printf("I! Waiting %v for the service inputs", wait)
+		time.Sleep(wait)
+		for _, si := range started {
+			si.Stop()
+		}
+	}
+	close(metricC)
+	<-done
+	published = append(published, applyProcessors(processors, collected)...)
+
+	for _, output := range outputs {
+		var metrics []telegraf.Metric
+		for _, m := range published {
+			m = m.Copy()
+			if !output.Config.Filter.Select(m) {
+				continue
+			}
+			output.Config.Filter.Modify(m)
+			if len(m.FieldList()) > 0 {
+				metrics = append(metrics, m)
+			}
+		}
+		datums, err := output.Output.(snapshotter).Snapshot(metrics)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot the output %v: %v", output.LogName(), err)
+		}
+		for _, datum := range datums {
+			b, err := json.Marshal(datum)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		}
+	}
+	return nil
+}
+
+// snapshotProcessors initializes the processors run by the snapshot, the processors with actions are skipped so that a
+// snapshot does not run their commands or publish to their topics
+func snapshotProcessors(processors []*models.RunningProcessor) ([]*models.RunningProcessor, error) {
+	var run []*models.RunningProcessor
+	for _, processor := range processors {
+		if a, ok := processor.Processor.(actor); ok && a.HasActions() {
+			log.Printf("I! The processor %v has actions, it is not run by the snapshot", processor.Config.Name)
+			continue
+		}
+		if err := processor.Init(); err != nil {
+			return nil, fmt.Errorf("could not initialize processor %v: %v", processor.Config.Name, err)
+		}
+		run = append(run, processor)
+	}
+	return run, nil
+}
+
+// applyProcessors runs the metrics through the processors in their order, as the agent does
+func applyProcessors(processors []*models.RunningProcessor, metrics []telegraf.Metric) []telegraf.Metric {
+	for _, processor := range processors {
+		metrics = processor.Apply(metrics...)
+	}
+	return metrics
+}
+
+// waitForProcessors waits until the processors starting in the background started, or until the timeout elapsed
+func waitForProcessors(processors []*models.RunningProcessor, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for _, processor := range processors {
+		s, ok := processor.Processor.(starter)
+		if !ok {
+			continue
+		}
+		for !s.Started() {
+			if time.Now().After(deadline) {
+				log.Printf("W! The processor %v did not start within %v, it drops the metrics", processor.Config.Name, timeout)
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/plugins/processors/anomaly"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProcessor struct {
+	initialized bool
+}
+
+func (p *testProcessor) SampleConfig() string { return "" }
+func (p *testProcessor) Description() string  { return "" }
+func (p *testProcessor) Init() error {
+	p.initialized = true
+	return nil
+}
+func (p *testProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric { return in }
+
+func TestSnapshotProcessors(t *testing.T) {
+	above := 95.0
+	threshold := anomaly.Rule{Name: "memory", Metric: "mem_used_percent", Above: &above}
+	command := threshold
+	command.Command = []string{"/usr/local/bin/restart-worker.sh"}
+	topic := threshold
+	topic.SNSTopicARN = "arn:aws:sns:us-east-1:123456789012:on-call"
+
+	p := &testProcessor{}
+	processors := []*models.RunningProcessor{
+		models.NewRunningProcessor(p, &models.ProcessorConfig{Name: "test"}),
+		models.NewRunningProcessor(&anomaly.Anomaly{Rules: []anomaly.Rule{threshold}}, &models.ProcessorConfig{Name: "anomaly"}),
+		models.NewRunningProcessor(&anomaly.Anomaly{Rules: []anomaly.Rule{threshold, command}}, &models.ProcessorConfig{Name: "anomaly"}),
+		models.NewRunningProcessor(&anomaly.Anomaly{Rules: []anomaly.Rule{topic}}, &models.ProcessorConfig{Name: "anomaly"}),
+	}
+	run, err := snapshotProcessors(processors)
+	require.NoError(t, err)
+	// the anomaly processors which would run a command or publish to a topic are skipped
+	assert.Equal(t, processors[:2], run)
+	assert.True(t, p.initialized)
+}
+
+func TestSnapshotProcessorsInitError(t *testing.T) {
+	processors := []*models.RunningProcessor{
+		models.NewRunningProcessor(&anomaly.Anomaly{Rules: []anomaly.Rule{{Metric: "mem_used_percent"}}}, &models.ProcessorConfig{Name: "anomaly"}),
+	}
+	_, err := snapshotProcessors(processors)
+	assert.Error(t, err)
+}
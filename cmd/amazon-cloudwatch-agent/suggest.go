@@ -0,0 +1,89 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/control"
+	"github.com/aws/amazon-cloudwatch-agent/internal/suggest"
+)
+
+const defaultSuggestPeriod = 10 * time.Minute
+
+// suggestCommand reads the status of the running agent at the start and at the end of the period, prints the
+// statistics of the publishing over the period, the suggested tuning and the diff of the JSON config applying it,
+// e.g. "suggest period=30m config=/opt/aws/amazon-cloudwatch-agent/etc/amazon-cloudwatch-agent.json". The tuning is
+// suggested from the default settings and no diff is printed without the JSON config.
+func suggestCommand(args []string, out io.Writer) error {
+	if *fControlFile == "" {
+		return fmt.Errorf("the control file is not given, use -control-file")
+	}
+	period := defaultSuggestPeriod
+	var configFile string
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || (kv[0] != "period" && kv[0] != "config") {
+			return fmt.Errorf("invalid argument %q, usage: suggest [period=<duration>] [config=<JSON config>]", arg)
+		}
+		if kv[0] == "config" {
+			configFile = kv[1]
+			continue
+		}
+		var err error
+		if period, err = time.ParseDuration(kv[1]); err != nil || period <= 0 {
+			return fmt.Errorf("invalid period %q, expecting a positive duration, e.g. 30m", kv[1])
+		}
+	}
+
+	config := &suggest.Config{}
+	if configFile != "" {
+		data, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the config: %v", err)
+		}
+		if config, err = suggest.ParseConfig(data); err != nil {
+			return err
+		}
+	}
+
+	first, err := statusSnapshot()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Reading the publishing statistics of the agent for %v\n", period)
+	time.Sleep(period)
+	last, err := statusSnapshot()
+	if err != nil {
+		return err
+	}
+
+	report, err := suggest.Analyze(first, last, config.Settings)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(out, report)
+	if diff := config.Diff(configFile, report.Suggestions); diff != "" {
+		fmt.Fprint(out, "\n"+diff)
+	}
+	return nil
+}
+
+// statusSnapshot returns the publishing statistics of the status of the running agent
+func statusSnapshot() (suggest.Snapshot, error) {
+	result, err := control.Call(*fControlFile, "status", nil)
+	if err != nil {
+		return suggest.Snapshot{}, err
+	}
+	var status agentStatus
+	if err := json.Unmarshal(result, &status); err != nil {
+		return suggest.Snapshot{}, fmt.Errorf("unable to parse the status of the agent: %v", err)
+	}
+	return suggest.Snapshot{Time: time.Now(), Publishing: status.Publishing}, nil
+}
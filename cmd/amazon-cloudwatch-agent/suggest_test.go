@@ -0,0 +1,65 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/control"
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "suggest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	controlFile := filepath.Join(dir, "control.json")
+	configFile := filepath.Join(dir, "agent.json")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`{
+  "logs": {
+    "force_flush_interval": 5,
+    "logs_collected": {}
+  }
+}
+`), 0644))
+
+	// the batches published between the two statuses are small and flushed on the interval
+	batches := int64(10)
+	s := control.NewServer(controlFile, map[string]control.Handler{
+		"status": func(map[string]string) (interface{}, error) {
+			status := agentStatus{Publishing: []publishstats.Summary{
+				{Target: "logs.app/i-1", Batches: batches, Events: batches * 10, AvgBatchBytes: 2048},
+			}}
+			batches += 10
+			return status, nil
+		},
+	})
+	require.NoError(t, s.Start())
+	defer s.Stop()
+	previous := *fControlFile
+	*fControlFile = controlFile
+	defer func() { *fControlFile = previous }()
+
+	var out bytes.Buffer
+	require.NoError(t, suggestCommand([]string{"period=10ms", "config=" + configFile}, &out))
+	assert.Contains(t, out.String(), "force_flush_interval 5s -> 1m0s")
+	// the diff is of the JSON config, with the interval in seconds
+	assert.Contains(t, out.String(), "--- "+configFile+"\n+++ "+configFile+"\n@@ -1,6 +1,6 @@\n"+
+		" {\n"+
+		"   \"logs\": {\n"+
+		"-    \"force_flush_interval\": 5,\n"+
+		"+    \"force_flush_interval\": 60,\n"+
+		"     \"logs_collected\": {}\n"+
+		"   }\n"+
+		" }\n")
+
+	assert.EqualError(t, suggestCommand([]string{"interval=10ms"}, &out),
+		`invalid argument "interval=10ms", usage: suggest [period=<duration>] [config=<JSON config>]`)
+}
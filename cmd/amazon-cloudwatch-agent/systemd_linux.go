@@ -0,0 +1,54 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/systemd"
+)
+
+// systemdService reports the readiness and the keep-alive pings of the agent when run with Type=notify
+type systemdService struct {
+	watchdog time.Duration
+}
+
+func newSystemdService() (serviceStatusReporter, bool) {
+	if !systemd.Enabled() {
+		return nil, false
+	}
+	return &systemdService{watchdog: systemd.WatchdogInterval()}, true
+}
+
+func (s *systemdService) notify(state string) {
+	if _, err := systemd.Notify(state); err != nil {
+		log.Printf("W! Unable to notify systemd of %q: %v", state, err)
+	}
+}
+
+func (s *systemdService) starting(step string) {
+	s.notify(systemd.Status(step))
+}
+
+func (s *systemdService) started() {
+	s.notify(systemd.StateReady + "\n" + systemd.Status("Running"))
+}
+
+func (s *systemdService) alive() {
+	if s.watchdog > 0 {
+		s.notify(systemd.StateWatchdog)
+	}
+}
+
+// aliveInterval pings twice per watchdog interval so that a single late gather does not restart the agent
+func (s *systemdService) aliveInterval() time.Duration {
+	return s.watchdog / 2
+}
+
+func (s *systemdService) failed(err error) {
+	s.notify(systemd.Status("Failed: " + err.Error()))
+}
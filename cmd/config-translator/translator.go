@@ -25,14 +25,19 @@ const (
 	envConfigFileName = "env-config.json"
 )
 
+// explain is the dotted path of the section of the json config whose translation is printed rather than written
+var explain string
+
 func initFlags() {
 	var inputOs = flag.String("os", "", "Please provide the os preference, valid value: windows/linux.")
 	var inputJsonFile = flag.String("input", "", "Please provide the path of input agent json config file")
 	var inputJsonDir = flag.String("input-dir", "", "Please provide the path of input agent json config directory.")
 	var inputTomlFile = flag.String("output", "", "Please provide the path of the output CWAgent config file")
+	var inputOtelFile = flag.String("otel-output", "", "Optionally provide the path of an equivalent OpenTelemetry Collector config file for the supported pipelines")
 	var inputMode = flag.String("mode", "ec2", "Please provide the mode, i.e. ec2, onPrem")
 	var inputConfig = flag.String("config", "", "Please provide the common-config file")
 	var multiConfig = flag.String("multi-config", "remove", "valid values: default, append, remove")
+	flag.StringVar(&explain, "explain", "", "Optionally print the section of the json config at this path, e.g. metrics.metrics_collected.cpu, and the toml it is translated into, rather than writing the config files")
 	flag.Parse()
 
 	ctx := context.CurrentContext()
@@ -41,6 +46,7 @@ func initFlags() {
 	ctx.SetInputJsonDirPath(*inputJsonDir)
 	ctx.SetMultiConfig(*multiConfig)
 	ctx.SetOutputTomlFilePath(*inputTomlFile)
+	ctx.SetOutputOtelFilePath(*inputOtelFile)
 
 	if *inputConfig != "" {
 		f, err := os.Open(*inputConfig)
@@ -64,7 +70,7 @@ func initFlags() {
 
 /**
  *	config-translator --input ${JSON} --input-dir ${JSON_DIR} --output ${TOML} --mode ${param_mode} --config ${COMMON_CONIG}
- *  --multi-config [default|append|remove]
+ *  --multi-config [default|append|remove] [--explain ${JSON_PATH}]
  *
  *		multi-config:
  *			default:	only process .tmp files
@@ -95,6 +101,11 @@ func main() {
 		panic(fmt.Sprintf("E! Failed to generate merged json config: %v", err))
 	}
 
+	if explain != "" {
+		cmdutil.ExplainJsonMapTranslation(mergedJsonConfigMap, explain)
+		return
+	}
+
 	if os.Getenv(config.RUN_IN_CONTAINER) != config.RUN_IN_CONTAINER_TRUE {
 		// run as user only applies to non container situation.
 		current, e := user.Current()
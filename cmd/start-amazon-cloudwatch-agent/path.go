@@ -34,6 +34,7 @@ func startAgent(writer io.WriteCloser) error {
 			agentBinaryPath, // when using syscall.Exec, must pass binary name as args[0]
 			"-config", tomlConfigPath, "-envconfig", envConfigPath,
 			"-pidfile", AGENT_DIR_LINUX + "/var/amazon-cloudwatch-agent.pid",
+			"-control-file", controlFilePath, "-disabled-file", disabledFilePath,
 		}
 		if err := syscall.Exec(agentBinaryPath, execArgs, os.Environ()); err != nil {
 			return fmt.Errorf("error exec as agent binary: %w", err)
@@ -67,7 +68,8 @@ func startAgent(writer io.WriteCloser) error {
 
 	// linux command has pid passed while windows does not
 	agentCmd := []string{agentBinaryPath, "-config", tomlConfigPath, "-envconfig", envConfigPath,
-		"-pidfile", AGENT_DIR_LINUX + "/var/amazon-cloudwatch-agent.pid"}
+		"-pidfile", AGENT_DIR_LINUX + "/var/amazon-cloudwatch-agent.pid", "-control-file", controlFilePath,
+		"-disabled-file", disabledFilePath}
 	if err = syscall.Exec(name, agentCmd, os.Environ()); err != nil {
 		// log file is closed, so use fmt here
 		fmt.Printf("E! Exec failed: %v \n", err)
@@ -94,6 +96,8 @@ func init() {
 	commonConfigPath = AGENT_DIR_LINUX + "/etc/" + COMMON_CONFIG
 
 	agentLogFilePath = AGENT_DIR_LINUX + "/logs/" + AGENT_LOG_FILE
+	controlFilePath = AGENT_DIR_LINUX + "/var/" + CONTROL
+	disabledFilePath = AGENT_DIR_LINUX + "/etc/" + DISABLED
 
 	translatorBinaryPath = AGENT_DIR_LINUX + "/bin/" + TRANSLATOR_BINARY_LINUX
 	agentBinaryPath = AGENT_DIR_LINUX + "/bin/" + AGENT_BINARY_LINUX
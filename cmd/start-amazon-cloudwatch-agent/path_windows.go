@@ -20,6 +20,9 @@ const (
 
 	TRANSLATOR_BINARY_WINDOWS = "config-translator.exe"
 	AGENT_BINARY_WINDOWS      = "amazon-cloudwatch-agent.exe"
+
+	// The name the service is registered with by amazon-cloudwatch-agent-ctl.ps1
+	SERVICE_NAME_WINDOWS = "AmazonCloudWatchAgent"
 )
 
 func startAgent(writer io.WriteCloser) error {
@@ -28,7 +31,8 @@ func startAgent(writer io.WriteCloser) error {
 		return err
 	}
 
-	cmd := exec.Command(agentBinaryPath, "-config", tomlConfigPath, "-envconfig", envConfigPath)
+	cmd := exec.Command(agentBinaryPath, "-config", tomlConfigPath, "-envconfig", envConfigPath, "-service-name", SERVICE_NAME_WINDOWS,
+		"-control-file", controlFilePath, "-disabled-file", disabledFilePath)
 	stdoutStderr, err := cmd.CombinedOutput()
 	// log file is closed, so use fmt here
 	fmt.Printf("%s \n", stdoutStderr)
@@ -56,6 +60,8 @@ func init() {
 	commonConfigPath = agentConfigDir + "\\" + COMMON_CONFIG
 
 	agentLogFilePath = agentConfigDir + "\\Logs\\" + AGENT_LOG_FILE
+	controlFilePath = agentConfigDir + "\\" + CONTROL
+	disabledFilePath = agentConfigDir + "\\" + DISABLED
 
 	translatorBinaryPath = agentRootDir + "\\" + TRANSLATOR_BINARY_WINDOWS
 	agentBinaryPath = agentRootDir + "\\" + AGENT_BINARY_WINDOWS
@@ -4,12 +4,16 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"syscall"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal/selfupdate"
+	"github.com/aws/amazon-cloudwatch-agent/internal/systemd"
 	"github.com/aws/amazon-cloudwatch-agent/translator/config"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -19,6 +23,8 @@ const (
 	JSON          = "amazon-cloudwatch-agent.json"
 	TOML          = "amazon-cloudwatch-agent.toml"
 	ENV           = "env-config.json"
+	CONTROL       = "control.json"
+	DISABLED      = "disabled-targets.json"
 
 	AGENT_LOG_FILE = "amazon-cloudwatch-agent.log"
 
@@ -34,6 +40,8 @@ var (
 	commonConfigPath string
 
 	agentLogFilePath string
+	controlFilePath  string
+	disabledFilePath string
 
 	translatorBinaryPath string
 	agentBinaryPath      string
@@ -88,7 +96,15 @@ func main() {
 		log.SetOutput(writer)
 	}
 
+	// The launcher is not upgraded by the self-update, so it rolls back the upgrades the agent does not commit
+	if err := selfupdate.Recover(filepath.Dir(agentBinaryPath)); err != nil {
+		log.Printf("E! Cannot recover the self-update of the agent, ERROR is %v \n", err)
+	}
+
+	// The agent reports its readiness itself once started, see the Type=notify systemd unit
+	systemd.Notify(systemd.Status("Translating the JSON config into TOML"))
 	if err := translateConfig(); err != nil {
+		systemd.Notify(systemd.Status(fmt.Sprintf("Failed to translate the JSON config: %v", err)))
 		log.Fatalf("E! Cannot translate JSON config into TOML, ERROR is %v \n", err)
 	}
 	log.Printf("I! Config has been translated into TOML %s \n", tomlConfigPath)
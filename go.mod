@@ -27,10 +27,18 @@ replace k8s.io/klog => github.com/simonpasquier/klog-gokit v0.1.0
 require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/Jeffail/gabs v1.4.0
+	github.com/Microsoft/go-winio v0.4.15-0.20190919025122-fc70bd9a86b5
+	github.com/Shopify/sarama v1.24.1
 	github.com/aws/aws-sdk-go v1.30.15
+	github.com/aws/aws-sdk-go-v2 v1.16.0
+	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.15.0
+	github.com/aws/smithy-go v1.11.1
 	github.com/bigkevmcd/go-configparser v0.0.0-20200217161103-d137835d2579
+	github.com/cilium/ebpf v0.0.0-20191113100448-d9fb101ca1fb
 	github.com/docker/docker v1.13.1
 	github.com/go-kit/kit v0.10.0
+	github.com/go-ole/go-ole v1.2.4
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/gobwas/glob v0.2.3
 	github.com/google/cadvisor v0.36.0
 	github.com/hashicorp/golang-lru v0.5.4
@@ -38,7 +46,9 @@ require (
 	github.com/influxdata/telegraf v0.0.0-00010101000000-000000000000
 	github.com/influxdata/toml v0.0.0-20190415235208-270119a8ce65
 	github.com/influxdata/wlog v0.0.0-20160411224016-7c63b0a71ef8
+	github.com/jackc/pgx v3.6.0+incompatible
 	github.com/kardianos/service v1.0.0
+	github.com/klauspost/compress v1.11.13
 	github.com/oklog/run v1.1.0
 	github.com/opencontainers/runc v1.0.0-rc10
 	github.com/pkg/errors v0.9.1
@@ -47,6 +57,7 @@ require (
 	github.com/prometheus/prometheus v1.8.2-0.20200420081721-18254838fbe2
 	github.com/shirou/gopsutil v2.20.5+incompatible
 	github.com/stretchr/testify v1.5.1
+	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c
 	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
 	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a
@@ -0,0 +1,129 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrCodeCircuitOpen is the code of the error returned without sending the request while the endpoint is unreachable
+const ErrCodeCircuitOpen = "CircuitOpen"
+
+const (
+	circuitFailureThreshold = 5
+	circuitMinProbeDelay    = 2 * time.Second
+	circuitMaxProbeDelay    = 5 * time.Minute
+	// a probe which does not complete within this time, e.g. because the signing failed, does not block the next one
+	circuitProbeTimeout = time.Minute
+)
+
+var circuitBreakers = struct {
+	sync.Mutex
+	breakers map[string]*circuitBreaker
+}{breakers: make(map[string]*circuitBreaker)}
+
+// AddCircuitBreakerHandlers adds the handlers which stop sending the requests of the client to its endpoint after
+// consecutive failures of the endpoint, i.e. no response or a 5xx one. While the circuit is open the requests fail
+// with ErrCodeCircuitOpen and a single request is let through as a probe, first after 2s and then after exponentially
+// longer delays, until one succeeds. The clients of the same service and endpoint share the circuit.
+func AddCircuitBreakerHandlers(h *request.Handlers) {
+	h.Sign.PushFrontNamed(request.NamedHandler{Name: "CircuitBreakerCheckHandler", Fn: func(req *request.Request) {
+		breakerOf(req).check(req)
+	}})
+	h.CompleteAttempt.PushBackNamed(request.NamedHandler{Name: "CircuitBreakerRecordHandler", Fn: func(req *request.Request) {
+		breakerOf(req).record(req)
+	}})
+}
+
+func breakerOf(req *request.Request) *circuitBreaker {
+	name := req.ClientInfo.ServiceName + " " + req.ClientInfo.Endpoint
+	circuitBreakers.Lock()
+	defer circuitBreakers.Unlock()
+	b, ok := circuitBreakers.breakers[name]
+	if !ok {
+		b = &circuitBreaker{name: name, now: time.Now}
+		circuitBreakers.breakers[name] = b
+	}
+	return b
+}
+
+type circuitBreaker struct {
+	sync.Mutex
+	name         string
+	now          func() time.Time
+	failures     int
+	open         bool
+	probeDelay   time.Duration
+	nextProbe    time.Time
+	probeStarted time.Time
+}
+
+func (b *circuitBreaker) check(req *request.Request) {
+	b.Lock()
+	defer b.Unlock()
+	if !b.open {
+		return
+	}
+	now := b.now()
+	if now.Before(b.nextProbe) || now.Sub(b.probeStarted) < circuitProbeTimeout {
+		req.Error = awserr.New(ErrCodeCircuitOpen, fmt.Sprintf("%v is unreachable after %v consecutive failures, the next probe is at %v",
+			b.name, b.failures, b.nextProbe.Format(time.RFC3339)), nil)
+		profiler.Profiler.AddStats([]string{"circuitbreaker", b.name, "rejected"}, 1)
+		return
+	}
+	b.probeStarted = now
+}
+
+func (b *circuitBreaker) record(req *request.Request) {
+	b.Lock()
+	defer b.Unlock()
+	if !endpointFailed(req) {
+		b.failures = 0
+		if b.open {
+			b.open = false
+			b.probeStarted = time.Time{}
+			log.Printf("I! Circuit breaker of %v is closed, the endpoint is reachable again", b.name)
+			profiler.Profiler.AddStats([]string{"circuitbreaker", b.name, "closed"}, 1)
+		}
+		return
+	}
+
+	b.failures++
+	switch {
+	case b.open:
+		// the probe failed
+		b.probeDelay *= 2
+		if b.probeDelay > circuitMaxProbeDelay {
+			b.probeDelay = circuitMaxProbeDelay
+		}
+	case b.failures >= circuitFailureThreshold:
+		b.open = true
+		b.probeDelay = circuitMinProbeDelay
+		log.Printf("W! Circuit breaker of %v is open after %v consecutive failures, last error: %v", b.name, b.failures, req.Error)
+		profiler.Profiler.AddStats([]string{"circuitbreaker", b.name, "opened"}, 1)
+	default:
+		return
+	}
+	b.probeStarted = time.Time{}
+	b.nextProbe = b.now().Add(b.probeDelay)
+}
+
+// endpointFailed returns whether the attempt got no response or a 5xx one. Other errors, e.g. throttling
+// or invalid parameters, show that the endpoint is reachable.
+func endpointFailed(req *request.Request) bool {
+	if req.Error == nil {
+		return false
+	}
+	if aerr, ok := req.Error.(awserr.Error); ok && aerr.Code() == ErrCodeCircuitOpen {
+		return false
+	}
+	return req.HTTPResponse == nil || req.HTTPResponse.StatusCode == 0 || req.HTTPResponse.StatusCode >= 500
+}
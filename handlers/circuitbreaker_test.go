@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var status, calls int32 = http.StatusServiceUnavailable, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := cloudwatchlogs.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	AddCircuitBreakerHandlers(&client.Handlers)
+	createLogGroup := func() error {
+		_, err := client.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("group")})
+		return err
+	}
+	code := func(err error) string {
+		if aerr, ok := err.(awserr.Error); ok {
+			return aerr.Code()
+		}
+		return ""
+	}
+
+	for i := 0; i < circuitFailureThreshold; i++ {
+		assert.NotEqual(t, ErrCodeCircuitOpen, code(createLogGroup()))
+	}
+	assert.Equal(t, ErrCodeCircuitOpen, code(createLogGroup()))
+	assert.Equal(t, int32(circuitFailureThreshold), atomic.LoadInt32(&calls), "no request is sent while the circuit is open")
+
+	b := circuitBreakers.breakers[cloudwatchlogs.ServiceName+" "+server.URL]
+	require.NotNil(t, b)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	// the probe fails and the delay until the next one doubles
+	now = now.Add(circuitMinProbeDelay)
+	assert.NotEqual(t, ErrCodeCircuitOpen, code(createLogGroup()))
+	assert.Equal(t, 2*circuitMinProbeDelay, b.probeDelay)
+	now = now.Add(circuitMinProbeDelay)
+	assert.Equal(t, ErrCodeCircuitOpen, code(createLogGroup()))
+
+	// the probe succeeds and closes the circuit
+	atomic.StoreInt32(&status, http.StatusOK)
+	now = now.Add(circuitMinProbeDelay)
+	assert.NoError(t, createLogGroup())
+	assert.False(t, b.open)
+	assert.NoError(t, createLogGroup())
+	assert.Equal(t, int32(circuitFailureThreshold+3), atomic.LoadInt32(&calls))
+}
+
+func TestCircuitBreakerClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"ThrottlingException","message":"Rate exceeded"}`))
+	}))
+	defer server.Close()
+
+	client := cloudwatchlogs.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	AddCircuitBreakerHandlers(&client.Handlers)
+	for i := 0; i < 2*circuitFailureThreshold; i++ {
+		_, err := client.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("group")})
+		require.Error(t, err)
+		assert.Equal(t, "ThrottlingException", err.(awserr.Error).Code())
+	}
+}
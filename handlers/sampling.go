@@ -0,0 +1,285 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	samplePrefix = "payload-"
+	sampleSuffix = ".json"
+
+	defaultSampleMaxPayloads = 10
+	defaultSampleMaxBytes    = 10 * 1024 * 1024
+	defaultSampleEvery       = 1
+	redacted                 = "<redacted>"
+)
+
+// redaction replaces the matches of the regular expression, the replacement can refer to its groups
+type redaction struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// defaultRedactions are always applied, they match the credentials most likely to end up in the log events. The
+// names of the secrets are kept so the redacted samples are still readable.
+var defaultRedactions = []redaction{
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), redacted},
+	{regexp.MustCompile(`(?i)(aws_secret_access_key|aws_session_token|password|passwd|secret|token)(\\?"?\s*[:=]\s*\\?"?)[^\s"\\&,]+`), "${1}${2}" + redacted},
+	{regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`), "${1}" + redacted},
+}
+
+// payloadSample is the content of the files written by the payload sampler
+type payloadSample struct {
+	Time             time.Time       `json:"time"`
+	Operation        string          `json:"operation"`
+	Endpoint         string          `json:"endpoint"`
+	StatusCode       int             `json:"status_code,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	Retries          int             `json:"retries"`
+	ContentEncoding  string          `json:"content_encoding,omitempty"`
+	UncompressedSize int             `json:"uncompressed_size"`
+	SentSize         int             `json:"sent_size"`
+	Response         json.RawMessage `json:"response,omitempty"`
+	Body             string          `json:"body"`
+}
+
+type payloadSampler struct {
+	sync.Mutex
+	dir        string
+	maxSamples int
+	maxBytes   int64
+	every      int
+	redactions []redaction
+	requests   int
+	// files are the samples in the dir, the oldest first
+	files []sampleFile
+	size  int64
+}
+
+type sampleFile struct {
+	name string
+	size int64
+}
+
+var (
+	samplerOnce sync.Once
+	sampler     *payloadSampler
+)
+
+// AddPayloadSamplingHandlers adds the handlers writing a copy of one in every CWAGENT_DEBUG_SAMPLE_EVERY requests of
+// the operations into the dir of CWAGENT_DEBUG_SAMPLE_DIR, along with the status of their response, to troubleshoot
+// the data not showing up. The body is made readable again when it was compressed, and the secrets it looks like it
+// contains are redacted. The oldest samples are removed once there are more than CWAGENT_DEBUG_SAMPLE_MAX_PAYLOADS of
+// them or they add up to more than CWAGENT_DEBUG_SAMPLE_MAX_BYTES, the clients share the limits.
+//
+// It must be called once the compression handler is added so the size of the payload sent is known.
+func AddPayloadSamplingHandlers(h *request.Handlers, opNames []string) {
+	samplerOnce.Do(func() {
+		s, err := samplerFromEnv()
+		if err != nil {
+			log.Printf("E! The sampling of the payloads is disabled: %v", err)
+			return
+		}
+		sampler = s
+	})
+	if sampler == nil {
+		return
+	}
+	log.Printf("I! Sampling the payloads of %v into %v", strings.Join(opNames, ", "), sampler.dir)
+	h.Build.PushBackNamed(request.NamedHandler{Name: "PayloadSamplingHandler", Fn: func(req *request.Request) {
+		for _, opName := range opNames {
+			if req.Operation.Name == opName {
+				sampler.sample(req)
+				return
+			}
+		}
+	}})
+}
+
+func samplerFromEnv() (*payloadSampler, error) {
+	dir := os.Getenv(envconfig.CWAGENT_DEBUG_SAMPLE_DIR)
+	if dir == "" {
+		return nil, nil
+	}
+	s := &payloadSampler{dir: dir, maxSamples: defaultSampleMaxPayloads, maxBytes: defaultSampleMaxBytes, every: defaultSampleEvery}
+	var err error
+	if s.maxSamples, err = positiveFromEnv(envconfig.CWAGENT_DEBUG_SAMPLE_MAX_PAYLOADS, s.maxSamples); err != nil {
+		return nil, err
+	}
+	maxBytes, err := positiveFromEnv(envconfig.CWAGENT_DEBUG_SAMPLE_MAX_BYTES, int(s.maxBytes))
+	if err != nil {
+		return nil, err
+	}
+	s.maxBytes = int64(maxBytes)
+	if s.every, err = positiveFromEnv(envconfig.CWAGENT_DEBUG_SAMPLE_EVERY, s.every); err != nil {
+		return nil, err
+	}
+	s.redactions = append([]redaction{}, defaultRedactions...)
+	if v := os.Getenv(envconfig.CWAGENT_DEBUG_SAMPLE_REDACT); v != "" {
+		var patterns []string
+		if err := json.Unmarshal([]byte(v), &patterns); err != nil {
+			return nil, fmt.Errorf("invalid %s, expecting a json list of regular expressions: %v", envconfig.CWAGENT_DEBUG_SAMPLE_REDACT, err)
+		}
+		for _, p := range patterns {
+			r, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redaction %q: %v", p, err)
+			}
+			s.redactions = append(s.redactions, redaction{r, redacted})
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := s.loadFiles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func positiveFromEnv(env string, defaultVal int) (int, error) {
+	v := os.Getenv(env)
+	if v == "" {
+		return defaultVal, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", env, v)
+	}
+	return i, nil
+}
+
+// loadFiles counts the samples left by the previous runs of the agent against the limits
+func (s *payloadSampler) loadFiles() error {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasPrefix(info.Name(), samplePrefix) && strings.HasSuffix(info.Name(), sampleSuffix) {
+			s.files = append(s.files, sampleFile{name: info.Name(), size: info.Size()})
+			s.size += info.Size()
+		}
+	}
+	// the names start with the time of the sample
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].name < s.files[j].name })
+	return nil
+}
+
+// sample reads the body of the request once it is built, and writes the sample once the request is complete
+func (s *payloadSampler) sample(req *request.Request) {
+	s.Lock()
+	s.requests++
+	sampled := (s.requests-1)%s.every == 0
+	s.Unlock()
+	if !sampled {
+		return
+	}
+	sent, err := ioutil.ReadAll(req.GetBody())
+	req.ResetBody()
+	if err != nil {
+		log.Printf("W! Unable to sample the payload of %v: %v", req.Operation.Name, err)
+		return
+	}
+	sample := payloadSample{
+		Operation:       req.Operation.Name,
+		Endpoint:        req.ClientInfo.Endpoint,
+		ContentEncoding: req.HTTPRequest.Header.Get("Content-Encoding"),
+		SentSize:        len(sent),
+	}
+	body := sent
+	if sample.ContentEncoding == "gzip" {
+		if body, err = gunzip(sent); err != nil {
+			log.Printf("W! Unable to decompress the payload of %v: %v", req.Operation.Name, err)
+			return
+		}
+	}
+	sample.UncompressedSize = len(body)
+	sample.Body = s.redact(string(body))
+
+	req.Handlers.Complete.PushBack(func(req *request.Request) {
+		sample.Time = time.Now()
+		sample.Retries = req.RetryCount
+		if req.HTTPResponse != nil {
+			sample.StatusCode = req.HTTPResponse.StatusCode
+		}
+		if req.Error != nil {
+			if awsErr, ok := req.Error.(awserr.Error); ok {
+				sample.Error = awsErr.Code() + ": " + awsErr.Message()
+			} else {
+				sample.Error = req.Error.Error()
+			}
+		} else if req.Data != nil {
+			// e.g. the rejected log events of PutLogEvents
+			sample.Response, _ = json.Marshal(req.Data)
+		}
+		s.write(sample)
+	})
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func (s *payloadSampler) redact(body string) string {
+	for _, r := range s.redactions {
+		body = r.re.ReplaceAllString(body, r.replacement)
+	}
+	return body
+}
+
+// write saves the sample and removes the oldest samples beyond the limits
+func (s *payloadSampler) write(sample payloadSample) {
+	data, err := json.MarshalIndent(sample, "", "  ")
+	if err != nil {
+		log.Printf("W! Unable to encode the sample of %v: %v", sample.Operation, err)
+		return
+	}
+	if int64(len(data)) > s.maxBytes {
+		log.Printf("W! The sample of %v is not written, its %v bytes are more than the limit of %v bytes", sample.Operation, len(data), s.maxBytes)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	name := fmt.Sprintf("%s%s-%06d-%s%s", samplePrefix, sample.Time.UTC().Format("20060102T150405.000000000"), s.requests, sample.Operation, sampleSuffix)
+	if err := ioutil.WriteFile(filepath.Join(s.dir, name), data, 0600); err != nil {
+		log.Printf("W! Unable to write the sample of %v: %v", sample.Operation, err)
+		return
+	}
+	s.files = append(s.files, sampleFile{name: name, size: int64(len(data))})
+	s.size += int64(len(data))
+	for len(s.files) > s.maxSamples || s.size > s.maxBytes {
+		oldest := s.files[0]
+		if err := os.Remove(filepath.Join(s.dir, oldest.name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("W! Unable to remove the sample %v: %v", oldest.name, err)
+		}
+		s.files = s.files[1:]
+		s.size -= oldest.size
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/rest"
+)
+
+const (
+	// SigningAlgorithmV4 is the default signature of the requests, scoped to the region of the endpoint
+	SigningAlgorithmV4 = "sigv4"
+	// SigningAlgorithmV4A is the asymmetric signature valid in a set of regions, required by the multi-region endpoints
+	SigningAlgorithmV4A = "sigv4a"
+
+	sigV4AAlgorithm    = "AWS4-ECDSA-P256-SHA256"
+	sigV4ATimeFormat   = "20060102T150405Z"
+	sigV4ADateFormat   = "20060102"
+	sigV4ARegionHeader = "X-Amz-Region-Set"
+)
+
+// UseSigV4A signs the requests with SigV4A instead of SigV4, valid in the regions of the set, e.g. "*" for all of them
+func UseSigV4A(h *request.Handlers, regionSet []string) {
+	h.Sign.Swap(v4.SignRequestHandler.Name, NewSigV4ASignHandler(regionSet))
+}
+
+// NewSigV4ASignHandler signs the requests with SigV4A, valid in the regions of the set
+func NewSigV4ASignHandler(regionSet []string) request.NamedHandler {
+	s := &sigV4ASigner{regionSet: strings.Join(regionSet, ","), now: time.Now}
+	return request.NamedHandler{
+		Name: "SigV4ASignRequestHandler",
+		Fn:   s.sign,
+	}
+}
+
+type sigV4ASigner struct {
+	regionSet string
+	now       func() time.Time
+
+	// the key derived from the last credentials, they only change when they are renewed
+	mu        sync.Mutex
+	keyID     string
+	keySecret string
+	key       *ecdsa.PrivateKey
+}
+
+func (s *sigV4ASigner) sign(req *request.Request) {
+	if req.Config.Credentials == credentials.AnonymousCredentials {
+		return
+	}
+	creds, err := req.Config.Credentials.Get()
+	if err != nil {
+		req.Error = err
+		return
+	}
+	key, err := s.privateKey(creds.AccessKeyID, creds.SecretAccessKey)
+	if err != nil {
+		req.Error = err
+		return
+	}
+
+	bodyDigest, err := payloadDigest(req)
+	if err != nil {
+		req.Error = err
+		return
+	}
+
+	name := req.ClientInfo.SigningName
+	if name == "" {
+		name = req.ClientInfo.ServiceName
+	}
+	now := s.now().UTC()
+	r := req.HTTPRequest
+	// the headers of an earlier attempt are replaced
+	r.Header.Del("Authorization")
+	r.Header.Set("X-Amz-Date", now.Format(sigV4ATimeFormat))
+	r.Header.Set(sigV4ARegionHeader, s.regionSet)
+	if creds.SessionToken != "" {
+		r.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	} else {
+		r.Header.Del("X-Amz-Security-Token")
+	}
+
+	stringToSign, scope, signedHeaders := sigV4AStringToSign(r, name, bodyDigest, now)
+	digest := sha256.Sum256([]byte(stringToSign))
+	sigR, sigS, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		req.Error = err
+		return
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{sigR, sigS})
+	if err != nil {
+		req.Error = err
+		return
+	}
+
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4AAlgorithm, creds.AccessKeyID, scope, signedHeaders, hex.EncodeToString(signature)))
+	req.LastSignedAt = now
+}
+
+// sigV4AStringToSign returns the string signed for the request, the credential scope and the signed headers, all
+// the headers are signed but the ones the SigV4 signer ignores too
+func sigV4AStringToSign(r *http.Request, name, bodyDigest string, now time.Time) (stringToSign, scope, signedHeaders string) {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	headers := []string{"host"}
+	values := map[string][]string{"host": {host}}
+	for k, v := range r.Header {
+		switch k {
+		case "Authorization", "User-Agent", "X-Amzn-Trace-Id":
+			continue
+		}
+		lower := strings.ToLower(k)
+		if _, ok := values[lower]; !ok {
+			headers = append(headers, lower)
+		}
+		values[lower] = append(values[lower], v...)
+	}
+	sort.Strings(headers)
+	var canonicalHeaders strings.Builder
+	for _, k := range headers {
+		canonicalHeaders.WriteString(k + ":" + strings.Join(strings.Fields(strings.Join(values[k], ",")), " ") + "\n")
+	}
+	signedHeaders = strings.Join(headers, ";")
+
+	r.URL.RawQuery = strings.Replace(r.URL.Query().Encode(), "+", "%20", -1)
+	uri := r.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	if name != "s3" {
+		uri = rest.EscapePath(uri, false)
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		uri,
+		r.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		bodyDigest,
+	}, "\n")
+
+	scope = strings.Join([]string{now.Format(sigV4ADateFormat), name, "aws4_request"}, "/")
+	canonicalDigest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign = strings.Join([]string{sigV4AAlgorithm, now.Format(sigV4ATimeFormat), scope, hex.EncodeToString(canonicalDigest[:])}, "\n")
+	return stringToSign, scope, signedHeaders
+}
+
+// privateKey returns the ECDSA key derived from the credentials, the one of the last credentials is kept
+func (s *sigV4ASigner) privateKey(accessKeyID, secretAccessKey string) (*ecdsa.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil && s.keyID == accessKeyID && s.keySecret == secretAccessKey {
+		return s.key, nil
+	}
+	key, err := deriveSigV4AKey(accessKeyID, secretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	s.keyID, s.keySecret, s.key = accessKeyID, secretAccessKey, key
+	return key, nil
+}
+
+// deriveSigV4AKey derives the P-256 key of the credentials, the counter mode HMAC-SHA256 derivation of NIST SP 800-108
+// is repeated with the next counter until the key is below the order of the curve minus one
+func deriveSigV4AKey(accessKeyID, secretAccessKey string) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	params := curve.Params()
+	nMinusTwo := leftPad(new(big.Int).Sub(params.N, big.NewInt(2)).Bytes(), params.BitSize/8)
+	inputKey := []byte("AWS4A" + secretAccessKey)
+
+	for counter := 1; counter <= 0xFF; counter++ {
+		context := append([]byte(accessKeyID), byte(counter))
+		candidate := hmacKeyDerivation(params.BitSize, inputKey, []byte(sigV4AAlgorithm), context)
+		if compareBytes(candidate, nMinusTwo) <= 0 {
+			d := new(big.Int).SetBytes(candidate)
+			d.Add(d, big.NewInt(1))
+			key := &ecdsa.PrivateKey{D: d, PublicKey: ecdsa.PublicKey{Curve: curve}}
+			key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to derive the SigV4A key of the access key %v", accessKeyID)
+}
+
+func hmacKeyDerivation(bitLen int, key, label, context []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(bitLen))
+	var output []byte
+	for i := uint32(1); len(output) < bitLen/8; i++ {
+		mac.Reset()
+		counter := make([]byte, 4)
+		binary.BigEndian.PutUint32(counter, i)
+		mac.Write(counter)
+		mac.Write(label)
+		mac.Write([]byte{0})
+		mac.Write(context)
+		mac.Write(length)
+		output = mac.Sum(output)
+	}
+	return output[:bitLen/8]
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// compareBytes compares the big endian numbers of the same length in constant time, -1, 0 or 1 as bytes.Compare
+func compareBytes(a, b []byte) int {
+	result := 0
+	for i := range a {
+		lt := subtle.ConstantTimeLessOrEq(int(a[i])+1, int(b[i]))
+		gt := subtle.ConstantTimeLessOrEq(int(b[i])+1, int(a[i]))
+		undecided := subtle.ConstantTimeEq(int32(result), 0)
+		result = subtle.ConstantTimeSelect(undecided&lt, -1, result)
+		result = subtle.ConstantTimeSelect(undecided&gt, 1, result)
+	}
+	return result
+}
+
+// payloadDigest returns the hex SHA-256 of the body of the request, which is rewound to where it was
+func payloadDigest(req *request.Request) (string, error) {
+	body := req.GetBody()
+	if body == nil {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
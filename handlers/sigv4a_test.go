@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveSigV4AKey(t *testing.T) {
+	key, err := deriveSigV4AKey("AKISORANDOMAASORANDOM", "q+jcrXGc+0zWN6uzclKVhvMmUsIfRPa4rlRandom")
+	require.NoError(t, err)
+	assert.Equal(t, "15d242ceebf8d8169fd6a8b5a746c41140414c3b07579038da06af89190fffcb", key.PublicKey.X.Text(16))
+	assert.Equal(t, "515242cedd82e94799482e4c0514b505afccf2c0c98d6a553bf539f424c5ec0", key.PublicKey.Y.Text(16))
+}
+
+func TestCompareBytes(t *testing.T) {
+	assert.Equal(t, 0, compareBytes([]byte{1, 2}, []byte{1, 2}))
+	assert.Equal(t, -1, compareBytes([]byte{1, 2}, []byte{2, 1}))
+	assert.Equal(t, 1, compareBytes([]byte{2, 0}, []byte{1, 255}))
+}
+
+func TestSigV4ASignHandler(t *testing.T) {
+	var authorization, regionSet string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		regionSet = r.Header.Get(sigV4ARegionHeader)
+		w.Write([]byte(`{"nextSequenceToken": "2"}`))
+	}))
+	defer server.Close()
+	client := cloudwatchlogs.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", "TOKEN"),
+	})
+	client.Handlers.Build.PushBackNamed(NewRequestCompressionHandler([]string{"PutLogEvents"}))
+	UseSigV4A(&client.Handlers, []string{"us-east-1", "us-west-2"})
+
+	// the signature of the request as sent verifies with the public key of the credentials
+	key, err := deriveSigV4AKey("AKID", "SECRET")
+	require.NoError(t, err)
+	verified := false
+	client.Handlers.Send.PushFront(func(req *request.Request) {
+		digest, err := payloadDigest(req)
+		require.NoError(t, err)
+		signingTime, err := time.Parse(sigV4ATimeFormat, req.HTTPRequest.Header.Get("X-Amz-Date"))
+		require.NoError(t, err)
+		stringToSign, _, _ := sigV4AStringToSign(req.HTTPRequest, "logs", digest, signingTime)
+		m := regexp.MustCompile(`Signature=([0-9a-f]+)$`).FindStringSubmatch(req.HTTPRequest.Header.Get("Authorization"))
+		require.Len(t, m, 2)
+		der, err := hex.DecodeString(m[1])
+		require.NoError(t, err)
+		var sig struct{ R, S *big.Int }
+		_, err = asn1.Unmarshal(der, &sig)
+		require.NoError(t, err)
+		hash := sha256.Sum256([]byte(stringToSign))
+		verified = ecdsa.Verify(&key.PublicKey, hash[:], sig.R, sig.S)
+	})
+
+	_, err = client.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String("group"),
+		LogStreamName: aws.String("stream"),
+		LogEvents:     []*cloudwatchlogs.InputLogEvent{{Message: aws.String(string(make([]byte, 1024))), Timestamp: aws.Int64(1)}},
+	})
+	require.NoError(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, "us-east-1,us-west-2", regionSet)
+	assert.Regexp(t, `^AWS4-ECDSA-P256-SHA256 Credential=AKID/\d{8}/logs/aws4_request, SignedHeaders=[a-z0-9;-]*x-amz-region-set;x-amz-security-token;x-amz-target, Signature=[0-9a-f]+$`, authorization)
+}
@@ -0,0 +1,147 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package agentevents is the channel the components of the agent post the significant conditions to, e.g. a file
+// which cannot be read, so that they can be published as structured events for the triage across a fleet instead of
+// being found in the agent logs of every host.
+package agentevents
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// TypeConfigFallback is posted when an option of the config cannot be applied and a default is used instead
+	TypeConfigFallback = "config_fallback"
+	// TypeFileUnreadable is posted when a file to collect cannot be opened, e.g. for the lack of permissions
+	TypeFileUnreadable = "file_unreadable"
+	// TypeCredentialExpiring is posted when the credentials cannot be renewed before they expire
+	TypeCredentialExpiring = "credential_expiring"
+	// TypeThrottlingSustained is posted when the calls to a service keep being throttled
+	TypeThrottlingSustained = "throttling_sustained"
+
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+const (
+	// repeatWindow is how long the repeats of an event are counted instead of being posted again
+	repeatWindow = 10 * time.Minute
+	// maxTrackedEvents bounds the events tracked for their repeats, e.g. with many unreadable files
+	maxTrackedEvents = 1000
+	subscriberBuffer = 100
+	// maxRecentEvents is how many of the last events are replayed to a new subscriber, e.g. the ones posted while the
+	// outputs connect, before the inputs start
+	maxRecentEvents = 50
+)
+
+// Event is a significant condition posted by a component
+type Event struct {
+	Time       time.Time         `json:"time"`
+	Type       string            `json:"type"`
+	Severity   string            `json:"severity"`
+	Component  string            `json:"component"`
+	Message    string            `json:"message"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Repeats is how many times the same event was posted in the repeat window before it
+	Repeats int `json:"repeats,omitempty"`
+}
+
+type eventKey struct {
+	eventType, component, message string
+}
+
+type tracked struct {
+	posted  time.Time
+	repeats int
+}
+
+type channel struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	tracked     map[eventKey]*tracked
+	recent      []Event
+	now         func() time.Time
+}
+
+var events = newChannel()
+
+func newChannel() *channel {
+	return &channel{
+		subscribers: map[chan Event]struct{}{},
+		tracked:     map[eventKey]*tracked{},
+		now:         time.Now,
+	}
+}
+
+// Post hands the event to the subscribers without blocking, the events are dropped when the subscribers lag behind.
+// The same event posted again within the repeat window is counted in the next one instead.
+func Post(e Event) {
+	events.post(e)
+}
+
+// Subscribe returns the last events posted and the ones posted from now on, until the returned function unsubscribes
+func Subscribe() (<-chan Event, func()) {
+	return events.subscribe()
+}
+
+func (c *channel) post(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if e.Time.IsZero() {
+		e.Time = now
+	}
+	key := eventKey{e.Type, e.Component, e.Message}
+	if t, ok := c.tracked[key]; ok && now.Sub(t.posted) < repeatWindow {
+		t.repeats++
+		return
+	} else if ok {
+		e.Repeats = t.repeats
+		t.posted, t.repeats = now, 0
+	} else {
+		c.track(key, now)
+	}
+	if len(c.recent) == maxRecentEvents {
+		c.recent = c.recent[1:]
+	}
+	c.recent = append(c.recent, e)
+	for s := range c.subscribers {
+		select {
+		case s <- e:
+		default:
+		}
+	}
+}
+
+func (c *channel) track(key eventKey, now time.Time) {
+	if len(c.tracked) >= maxTrackedEvents {
+		for k, t := range c.tracked {
+			if now.Sub(t.posted) >= repeatWindow {
+				delete(c.tracked, k)
+			}
+		}
+	}
+	if len(c.tracked) < maxTrackedEvents {
+		c.tracked[key] = &tracked{posted: now}
+	}
+}
+
+func (c *channel) subscribe() (<-chan Event, func()) {
+	s := make(chan Event, subscriberBuffer)
+	c.mu.Lock()
+	for _, e := range c.recent {
+		s <- e
+	}
+	c.subscribers[s] = struct{}{}
+	c.mu.Unlock()
+	var once sync.Once
+	return s, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.subscribers, s)
+			c.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agentevents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPost(t *testing.T) {
+	c := newChannel()
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	// the events posted before are replayed to the subscribers
+	c.post(Event{Type: TypeFileUnreadable, Component: "inputs.logfile", Message: "unable to open /var/log/a.log"})
+
+	events, unsubscribe := c.subscribe()
+	assert.Equal(t, "unable to open /var/log/a.log", (<-events).Message)
+	unreadable := Event{Type: TypeFileUnreadable, Severity: SeverityError, Component: "inputs.logfile",
+		Message: "unable to open /var/log/b.log"}
+	c.post(unreadable)
+	e := <-events
+	assert.Equal(t, now, e.Time)
+	assert.Equal(t, 0, e.Repeats)
+
+	// the repeats within the window are counted in the next event
+	now = now.Add(time.Minute)
+	c.post(unreadable)
+	c.post(unreadable)
+	c.post(Event{Type: TypeConfigFallback, Component: "aws", Message: "unable to resolve the logs endpoint"})
+	require.Len(t, events, 1)
+	assert.Equal(t, TypeConfigFallback, (<-events).Type)
+	now = now.Add(repeatWindow)
+	c.post(unreadable)
+	e = <-events
+	assert.Equal(t, unreadable.Message, e.Message)
+	assert.Equal(t, 2, e.Repeats)
+
+	unsubscribe()
+	unsubscribe()
+	now = now.Add(repeatWindow)
+	c.post(unreadable)
+	assert.Empty(t, events)
+}
+
+func TestPostSubscriberBehind(t *testing.T) {
+	c := newChannel()
+	for i := 0; i < maxRecentEvents+10; i++ {
+		c.post(Event{Type: TypeFileUnreadable, Message: "before " + time.Duration(i).String()})
+	}
+	events, unsubscribe := c.subscribe()
+	defer unsubscribe()
+	require.Len(t, events, maxRecentEvents)
+	assert.Equal(t, "before 10ns", (<-events).Message)
+	for i := 0; i < subscriberBuffer; i++ {
+		c.post(Event{Type: TypeFileUnreadable, Message: time.Duration(i).String()})
+	}
+	assert.Len(t, events, subscriberBuffer)
+}
+
+func TestTrackedBound(t *testing.T) {
+	c := newChannel()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	for i := 0; i < maxTrackedEvents+10; i++ {
+		c.post(Event{Type: TypeFileUnreadable, Message: time.Duration(i).String()})
+	}
+	assert.Len(t, c.tracked, maxTrackedEvents)
+	// the expired events make room for the new ones
+	now = now.Add(repeatWindow)
+	c.post(Event{Type: TypeFileUnreadable, Message: "new"})
+	assert.Len(t, c.tracked, 1)
+}
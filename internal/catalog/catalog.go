@@ -0,0 +1,85 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package catalog keeps the catalog of the metrics the agent publishes, their names, dimensions, units and the
+// measurements they are from, so the authors of the dashboards can discover what a host emits through the control API
+// rather than guessing it from the config.
+package catalog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the size of the catalog, the entries are keyed by the names of the dimensions rather than their
+// values, it is only reached by the measurements whose tags are unbounded
+const maxEntries = 10000
+
+// Entry is a metric published with a set of dimensions
+type Entry struct {
+	Namespace  string   `json:"namespace"`
+	MetricName string   `json:"metric_name"`
+	Dimensions []string `json:"dimensions"`
+	Unit       string   `json:"unit,omitempty"`
+	// Source is the measurement the metric is from, the name of the input plugin unless it is renamed
+	Source   string    `json:"source"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+var (
+	mu      sync.Mutex
+	entries = map[string]*Entry{}
+	// dropped is whether an entry was not recorded since the catalog is full
+	dropped bool
+	now     = time.Now
+)
+
+// Record records the metric published with the dimensions, the names of the dimensions are sorted
+func Record(namespace, metricName string, dimensions []string, unit, source string) {
+	key := strings.Join(append([]string{namespace, metricName}, dimensions...), "\x00")
+	mu.Lock()
+	defer mu.Unlock()
+	if e, ok := entries[key]; ok {
+		e.Unit = unit
+		e.Source = source
+		e.LastSeen = now()
+		return
+	}
+	if len(entries) >= maxEntries {
+		dropped = true
+		return
+	}
+	entries[key] = &Entry{
+		Namespace:  namespace,
+		MetricName: metricName,
+		Dimensions: append([]string{}, dimensions...),
+		Unit:       unit,
+		Source:     source,
+		LastSeen:   now(),
+	}
+}
+
+// Report returns the entries of the namespace, or of all the namespaces when it is empty, sorted by their
+// namespaces, their names and their dimensions. The catalog is truncated when it is full.
+func Report(namespace string) (report []Entry, truncated bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	report = make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if namespace == "" || e.Namespace == namespace {
+			report = append(report, *e)
+		}
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Namespace != report[j].Namespace {
+			return report[i].Namespace < report[j].Namespace
+		}
+		if report[i].MetricName != report[j].MetricName {
+			return report[i].MetricName < report[j].MetricName
+		}
+		return strings.Join(report[i].Dimensions, ",") < strings.Join(report[j].Dimensions, ",")
+	})
+	return report, dropped
+}
@@ -0,0 +1,55 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package catalog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reset() {
+	entries = map[string]*Entry{}
+	dropped = false
+}
+
+func TestReport(t *testing.T) {
+	reset()
+	at := time.Unix(100, 0)
+	now = func() time.Time { return at }
+	defer func() { now = time.Now }()
+
+	Record("CWAgent", "mem_used_percent", []string{"host"}, "Percent", "mem")
+	Record("CWAgent", "cpu_usage_idle", []string{"cpu", "host"}, "Percent", "cpu")
+	Record("CWAgent", "cpu_usage_idle", []string{"host"}, "Percent", "cpu")
+	Record("Other", "disk_used", nil, "Bytes", "disk")
+	at = time.Unix(200, 0)
+	Record("CWAgent", "mem_used_percent", []string{"host"}, "Percent", "mem")
+
+	report, truncated := Report("CWAgent")
+	assert.False(t, truncated)
+	assert.Equal(t, []Entry{
+		{Namespace: "CWAgent", MetricName: "cpu_usage_idle", Dimensions: []string{"cpu", "host"}, Unit: "Percent", Source: "cpu", LastSeen: time.Unix(100, 0)},
+		{Namespace: "CWAgent", MetricName: "cpu_usage_idle", Dimensions: []string{"host"}, Unit: "Percent", Source: "cpu", LastSeen: time.Unix(100, 0)},
+		{Namespace: "CWAgent", MetricName: "mem_used_percent", Dimensions: []string{"host"}, Unit: "Percent", Source: "mem", LastSeen: time.Unix(200, 0)},
+	}, report)
+
+	report, _ = Report("")
+	assert.Len(t, report, 4)
+	assert.Equal(t, "Other", report[3].Namespace)
+	assert.Equal(t, []string{}, report[3].Dimensions)
+}
+
+func TestReportTruncated(t *testing.T) {
+	reset()
+	defer reset()
+	for i := 0; i < maxEntries+1; i++ {
+		Record("CWAgent", "procstat_cpu_usage", []string{fmt.Sprint(i)}, "", "procstat")
+	}
+	report, truncated := Report("")
+	assert.True(t, truncated)
+	assert.Len(t, report, maxEntries)
+}
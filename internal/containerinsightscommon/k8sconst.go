@@ -17,6 +17,8 @@ const (
 	ContainerNamekey = "ContainerName"
 	ContainerIdkey   = "ContainerId"
 	PodOwnersKey     = "PodOwners"
+	WorkloadTypeKey  = "WorkloadType"
+	WorkloadNameKey  = "WorkloadName"
 
 	RunningPodCount       = "number_of_running_pods"
 	RunningContainerCount = "number_of_running_containers"
@@ -0,0 +1,197 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package control serves the local control API of the running agent, e.g. to query its status, flush its outputs,
+// reload its config or change its log level without parsing its logs.
+//
+// The API listens on a Unix socket only the owner of the agent can connect to, and on a named pipe only SYSTEM and
+// the Administrators can connect to on Windows. The requests must carry the token generated for every run of the agent, it is written along with the
+// address of the API into the control file, which only the owner of the agent can read, and only SYSTEM and the
+// Administrators on Windows.
+package control
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// socketSuffix is appended to the control file for the path of the socket
+	socketSuffix = ".sock"
+
+	tokenHeader    = "Authorization"
+	tokenScheme    = "Bearer "
+	requestTimeout = 30 * time.Second
+)
+
+// Handler runs a verb of the API with the arguments of the request, the result is returned as JSON
+type Handler func(args map[string]string) (interface{}, error)
+
+// Info is the content of the control file, the clients read it to reach the API
+type Info struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Pid     int    `json:"pid"`
+}
+
+type response struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Server of the control API
+type Server struct {
+	controlFile string
+	token       string
+	handlers    map[string]Handler
+	listener    net.Listener
+	server      *http.Server
+}
+
+// NewServer returns the server running the handlers by the verbs of the requests
+func NewServer(controlFile string, handlers map[string]Handler) *Server {
+	return &Server{controlFile: controlFile, handlers: handlers}
+}
+
+// Start listens for the requests and writes the control file the clients read the address and the token from
+func (s *Server) Start() error {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("unable to generate the token of the control API: %v", err)
+	}
+	s.token = hex.EncodeToString(token)
+
+	l, err := listen(s.controlFile)
+	if err != nil {
+		return fmt.Errorf("unable to listen for the control API: %v", err)
+	}
+	s.listener = l
+
+	info := Info{Network: l.Addr().Network(), Address: l.Addr().String(), Token: s.token, Pid: os.Getpid()}
+	data, err := json.Marshal(info)
+	if err != nil {
+		l.Close()
+		return err
+	}
+	// the control file is created again so the permissions of the file of a previous run are not kept
+	os.Remove(s.controlFile)
+	if err := writeControlFile(s.controlFile, data); err != nil {
+		l.Close()
+		return fmt.Errorf("unable to write the control file: %v", err)
+	}
+
+	s.server = &http.Server{Handler: s, ReadTimeout: requestTimeout, WriteTimeout: requestTimeout}
+	go func() {
+		if err := s.server.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Printf("E! control: the control API stopped: %v", err)
+		}
+	}()
+	log.Printf("I! control: serving the control API on %v %v", info.Network, info.Address)
+	return nil
+}
+
+// Stop closes the listener and removes the control file and the socket
+func (s *Server) Stop() {
+	if s.server != nil {
+		s.server.Close()
+	}
+	os.Remove(s.controlFile)
+	os.Remove(s.controlFile + socketSuffix)
+}
+
+// ServeHTTP runs the verb of the path of the request, e.g. POST /status
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get(tokenHeader)
+	if !strings.HasPrefix(auth, tokenScheme) ||
+		subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, tokenScheme)), []byte(s.token)) != 1 {
+		writeResponse(w, http.StatusUnauthorized, response{Error: "invalid token"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, response{Error: "expecting POST"})
+		return
+	}
+	verb := strings.Trim(r.URL.Path, "/")
+	handler, ok := s.handlers[verb]
+	if !ok {
+		writeResponse(w, http.StatusNotFound, response{Error: fmt.Sprintf("unknown verb %q", verb)})
+		return
+	}
+	args := map[string]string{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			writeResponse(w, http.StatusBadRequest, response{Error: fmt.Sprintf("invalid arguments: %v", err)})
+			return
+		}
+	}
+	log.Printf("I! control: %v %v", verb, args)
+	result, err := handler(args)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, response{Error: err.Error()})
+		return
+	}
+	writeResponse(w, http.StatusOK, response{Result: result})
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Call runs the verb on the agent serving the API of the control file, and returns the JSON result
+func Call(controlFile, verb string, args map[string]string) (json.RawMessage, error) {
+	data, err := ioutil.ReadFile(controlFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the control file, is the agent running? %v", err)
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("unable to parse the control file: %v", err)
+	}
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			Dial: func(string, string) (net.Conn, error) {
+				return dial(info)
+			},
+		},
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://agent/"+verb, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(tokenHeader, tokenScheme+info.Token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("unable to parse the response: %v", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("%v failed: %v", verb, result.Error)
+	}
+	return result.Result, nil
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "control")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	controlFile := filepath.Join(dir, "control.json")
+
+	s := NewServer(controlFile, map[string]Handler{
+		"status": func(map[string]string) (interface{}, error) {
+			return map[string]string{"version": "1.2.3"}, nil
+		},
+		"set-log-level": func(args map[string]string) (interface{}, error) {
+			if args["level"] == "" {
+				return nil, errors.New("missing level")
+			}
+			return args["level"], nil
+		},
+	})
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	info, err := os.Stat(controlFile)
+	require.NoError(t, err)
+	if filepath.Separator == '/' {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	result, err := Call(controlFile, "status", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version": "1.2.3"}`, string(result))
+
+	result, err = Call(controlFile, "set-log-level", map[string]string{"level": "debug"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"debug"`, string(result))
+
+	_, err = Call(controlFile, "set-log-level", nil)
+	assert.EqualError(t, err, "set-log-level failed: missing level")
+
+	_, err = Call(controlFile, "unknown", nil)
+	assert.EqualError(t, err, `unknown failed: unknown verb "unknown"`)
+
+	s.Stop()
+	_, err = Call(controlFile, "status", nil)
+	assert.Error(t, err, "the control file is removed once stopped")
+	_, err = os.Stat(controlFile + socketSuffix)
+	assert.True(t, os.IsNotExist(err), "the socket is removed once stopped")
+}
+
+func TestInvalidToken(t *testing.T) {
+	s := NewServer("", nil)
+	s.token = "token"
+	for _, auth := range []string{"", "token", "Bearer other"} {
+		req := httptest.NewRequest(http.MethodPost, "/status", nil)
+		req.Header.Set(tokenHeader, auth)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var resp response
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "invalid token", resp.Error)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !windows
+
+package control
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+)
+
+// listen on the Unix socket next to the control file, only its owner can connect to it
+func listen(controlFile string) (net.Listener, error) {
+	path := controlFile + socketSuffix
+	// the socket of a previous run is left behind when the agent crashed
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func dial(info Info) (net.Conn, error) {
+	return net.Dial(info.Network, info.Address)
+}
+
+// writeControlFile writes the control file only its owner can read
+func writeControlFile(path string, data []byte) error {
+	return ioutil.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,70 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build windows
+
+package control
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"os"
+	"time"
+	"unsafe"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// controlFileSDDL only allows SYSTEM and the Administrators to access the control file and the pipe. The DACL is
+	// protected so the entries of the folder, e.g. the read access of the Users to C:\ProgramData, are not inherited.
+	controlFileSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)"
+
+	pipePrefix  = `\\.\pipe\amazon-cloudwatch-agent-control-`
+	dialTimeout = 5 * time.Second
+)
+
+// listen on a named pipe only SYSTEM and the Administrators can connect to, unlike a port of the loopback interface
+// any local user can. The name of the pipe is random, so the pipe cannot be created by another user first.
+func listen(string) (net.Listener, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	return winio.ListenPipe(pipePrefix+hex.EncodeToString(suffix), &winio.PipeConfig{SecurityDescriptor: controlFileSDDL})
+}
+
+func dial(info Info) (net.Conn, error) {
+	if info.Network != "pipe" {
+		return net.Dial(info.Network, info.Address)
+	}
+	timeout := dialTimeout
+	return winio.DialPipe(info.Address, &timeout)
+}
+
+// writeControlFile creates the control file with the DACL of controlFileSDDL, it fails when the file exists since
+// the DACL of an existing file would not be replaced
+func writeControlFile(path string, data []byte) error {
+	sd, err := windows.SecurityDescriptorFromString(controlFileSDDL)
+	if err != nil {
+		return err
+	}
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	sa := &windows.SecurityAttributes{SecurityDescriptor: sd}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	h, err := windows.CreateFile(name, windows.GENERIC_WRITE, 0, sa, windows.CREATE_NEW, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return &os.PathError{Op: "create", Path: path, Err: err}
+	}
+	f := os.NewFile(uintptr(h), path)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
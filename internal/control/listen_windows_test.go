@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build windows
+
+package control
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+func TestWriteControlFileDACL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "control")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "control.json")
+
+	require.NoError(t, writeControlFile(path, []byte("{}")))
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	require.NoError(t, err)
+	assert.Equal(t, controlFileSDDL, sd.String())
+
+	assert.Error(t, writeControlFile(path, []byte("{}")), "the file exists")
+}
+
+func TestListenPipe(t *testing.T) {
+	l, err := listen("")
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, "pipe", l.Addr().Network())
+	assert.True(t, strings.HasPrefix(l.Addr().String(), pipePrefix))
+
+	conn, err := dial(Info{Network: l.Addr().Network(), Address: l.Addr().String()})
+	require.NoError(t, err)
+	conn.Close()
+}
@@ -0,0 +1,143 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package ec2tags caches the tags of the EC2 instances for the plugins of the agent, so the plugins adding the
+// tags as dimensions share a DescribeTags call instead of each calling it, e.g. the ec2tagger of the metrics and
+// the ones of the container insights.
+package ec2tags
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+const metadataTagsPath = "tags/instance"
+
+// Shared is the cache of the plugins of the agent, the taggers refreshing within a minute of each other share the
+// call
+var Shared = NewCache(time.Minute)
+
+// Metadata is the instance metadata, which has the tags of the instance when the instance metadata tags are
+// enabled on the instance
+type Metadata interface {
+	GetMetadata(p string) (string, error)
+}
+
+type entry struct {
+	tags    map[string]string
+	fetched time.Time
+	sync.Mutex
+}
+
+// Cache caches the tags of the instances by instance ID
+type Cache struct {
+	maxAge  time.Duration
+	entries map[string]*entry
+	sync.Mutex
+}
+
+func NewCache(maxAge time.Duration) *Cache {
+	return &Cache{maxAge: maxAge, entries: map[string]*entry{}}
+}
+
+// Get returns all the tags of the instance, the cached ones when they were fetched within the max age of the cache.
+// The tags are fetched with DescribeTags, or from the instance metadata when DescribeTags fails, e.g. because it
+// is not allowed to the role of the instance. The concurrent callers wait for the same call, the failed ones are
+// not cached. A nil cache fetches the tags on every call.
+func (c *Cache) Get(svc ec2iface.EC2API, md Metadata, instanceId string) (map[string]string, error) {
+	if c == nil {
+		return fetch(svc, md, instanceId)
+	}
+	c.Lock()
+	e, ok := c.entries[instanceId]
+	if !ok {
+		e = &entry{}
+		c.entries[instanceId] = e
+	}
+	c.Unlock()
+
+	e.Lock()
+	defer e.Unlock()
+	if e.tags == nil || time.Since(e.fetched) >= c.maxAge {
+		tags, err := fetch(svc, md, instanceId)
+		if err != nil {
+			return nil, err
+		}
+		e.tags, e.fetched = tags, time.Now()
+	}
+	return copyTags(e.tags), nil
+}
+
+func fetch(svc ec2iface.EC2API, md Metadata, instanceId string) (map[string]string, error) {
+	tags, err := describeTags(svc, instanceId)
+	if err != nil && md != nil {
+		if metadataTags, mdErr := metadataInstanceTags(md); mdErr == nil {
+			return metadataTags, nil
+		}
+	}
+	return tags, err
+}
+
+func describeTags(svc ec2iface.EC2API, instanceId string) (map[string]string, error) {
+	tags := map[string]string{}
+	input := &ec2.DescribeTagsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("resource-type"),
+				Values: aws.StringSlice([]string{"instance"}),
+			},
+			{
+				Name:   aws.String("resource-id"),
+				Values: aws.StringSlice([]string{instanceId}),
+			},
+		},
+	}
+	for {
+		result, err := svc.DescribeTags(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range result.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.SetNextToken(*result.NextToken)
+	}
+	return tags, nil
+}
+
+// metadataInstanceTags returns the tags of the instance metadata, the keys are listed one per line
+func metadataInstanceTags(md Metadata) (map[string]string, error) {
+	keys, err := md.GetMetadata(metadataTagsPath)
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for _, key := range strings.Split(keys, "\n") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		value, err := md.GetMetadata(metadataTagsPath + "/" + key)
+		if err != nil {
+			return nil, err
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	c := make(map[string]string, len(tags))
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
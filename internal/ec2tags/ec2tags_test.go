@@ -0,0 +1,110 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ec2tags
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEC2 struct {
+	ec2iface.EC2API
+	err   error
+	calls int
+	sync.Mutex
+}
+
+func (m *mockEC2) DescribeTags(input *ec2.DescribeTagsInput) (*ec2.DescribeTagsOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if input.NextToken == nil {
+		return &ec2.DescribeTagsOutput{
+			NextToken: aws.String("next"),
+			Tags:      []*ec2.TagDescription{{Key: aws.String("aws:autoscaling:groupName"), Value: aws.String("asg")}},
+		}, nil
+	}
+	return &ec2.DescribeTagsOutput{
+		Tags: []*ec2.TagDescription{{Key: aws.String("eks:nodegroup-name"), Value: aws.String("nodegroup")}},
+	}, nil
+}
+
+type mockMetadata map[string]string
+
+func (m mockMetadata) GetMetadata(p string) (string, error) {
+	if v, ok := m[p]; ok {
+		return v, nil
+	}
+	return "", errors.New("not found")
+}
+
+func TestCacheSharesCalls(t *testing.T) {
+	svc := &mockEC2{}
+	c := NewCache(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tags, err := c.Get(svc, nil, "i-1")
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{"aws:autoscaling:groupName": "asg", "eks:nodegroup-name": "nodegroup"}, tags)
+		}()
+	}
+	wg.Wait()
+	// the two pages of a single call
+	assert.Equal(t, 2, svc.calls)
+
+	_, err := c.Get(svc, nil, "i-2")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, svc.calls)
+}
+
+func TestCacheExpires(t *testing.T) {
+	svc := &mockEC2{}
+	c := NewCache(0)
+	_, err := c.Get(svc, nil, "i-1")
+	assert.NoError(t, err)
+	_, err = c.Get(svc, nil, "i-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, svc.calls)
+}
+
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	svc := &mockEC2{err: errors.New("throttled")}
+	c := NewCache(time.Minute)
+	_, err := c.Get(svc, nil, "i-1")
+	assert.Error(t, err)
+
+	svc.err = nil
+	tags, err := c.Get(svc, nil, "i-1")
+	assert.NoError(t, err)
+	assert.Len(t, tags, 2)
+}
+
+func TestMetadataFallback(t *testing.T) {
+	svc := &mockEC2{err: errors.New("UnauthorizedOperation")}
+	md := mockMetadata{
+		"tags/instance":      "Name\nteam\n",
+		"tags/instance/Name": "web",
+		"tags/instance/team": "payments",
+	}
+	tags, err := (*Cache)(nil).Get(svc, md, "i-1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Name": "web", "team": "payments"}, tags)
+
+	// the instance metadata tags are disabled
+	_, err = (*Cache)(nil).Get(svc, mockMetadata{}, "i-1")
+	assert.EqualError(t, err, "UnauthorizedOperation")
+}
@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ecsservicediscovery
+
+import (
+	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+)
+
+// iamActions are the actions the processors of the discovery call ECS and EC2 with
+func (c *ServiceDiscoveryConfig) iamActions() []string {
+	actions := []string{"ecs:ListTasks", "ecs:DescribeTasks", "ecs:DescribeTaskDefinition", "ecs:DescribeContainerInstances", "ec2:DescribeInstances"}
+	if len(c.ServiceNamesForTasks) > 0 {
+		actions = append(actions, "ecs:ListServices", "ecs:DescribeServices")
+	}
+	return actions
+}
+
+// PreflightIAM checks that the actions of the discovery are allowed in the region of the cluster
+func (c *ServiceDiscoveryConfig) PreflightIAM() []preflight.Check {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region: c.TargetClusterRegion,
+	}
+	return preflight.CheckIAMActions(credentialConfig.Credentials(), c.iamActions())
+}
@@ -0,0 +1,29 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package flush broadcasts the requests to flush what the outputs buffer, e.g. from the control API of the agent.
+// The outputs publish their pending batches right away instead of waiting for their force flush interval.
+package flush
+
+import "sync"
+
+var (
+	mu        sync.Mutex
+	requested = make(chan struct{})
+)
+
+// Requested returns the channel closed by the next flush request, it must be called again for every request
+// since a new channel is returned once the request is broadcast
+func Requested() <-chan struct{} {
+	mu.Lock()
+	defer mu.Unlock()
+	return requested
+}
+
+// Request asks every output to flush what it buffers
+func Request() {
+	mu.Lock()
+	defer mu.Unlock()
+	close(requested)
+	requested = make(chan struct{})
+}
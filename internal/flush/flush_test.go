@@ -0,0 +1,30 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package flush
+
+import (
+	"testing"
+)
+
+func TestRequest(t *testing.T) {
+	first := Requested()
+	select {
+	case <-first:
+		t.Fatal("flush requested before any request")
+	default:
+	}
+
+	Request()
+	select {
+	case <-first:
+	default:
+		t.Fatal("the flush request is not broadcast")
+	}
+
+	select {
+	case <-Requested():
+		t.Fatal("the next request is already broadcast")
+	default:
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package jitter delays the collections of the inputs configured with a collection jitter by a random time up to it,
+// so that the inputs of the same interval do not all collect at the same instant and the CPU the agent uses is spread
+// over the interval on the constrained instances.
+package jitter
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// Parse parses the collection jitters of CWAGENT_COLLECTION_JITTER, set by the translator as a json object of the
+// jitters by input name, one per instance of the input in the order of the config, e.g. {"procstat":["5s","0s"]}
+func Parse(value string) (map[string][]time.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var values map[string][]string
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return nil, err
+	}
+	jitters := make(map[string][]time.Duration, len(values))
+	for name, list := range values {
+		for _, v := range list {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, err
+			}
+			jitters[name] = append(jitters[name], d)
+		}
+	}
+	return jitters, nil
+}
+
+// WrapInputs delays every Gather of the inputs by a random time up to their jitter, the nth instance of an input
+// taking the nth jitter of its name. The jitter is bounded to half of the interval of the input, or of the agent when
+// the input has none, and a delay still pending when stop is closed is cut short without gathering.
+//
+// The inputs are wrapped, so it must run after everything asserting the type of their plugin.
+func WrapInputs(inputs []*models.RunningInput, jitters map[string][]time.Duration, agentInterval time.Duration, stop <-chan struct{}) {
+	instances := make(map[string]int)
+	for _, ri := range inputs {
+		n := instances[ri.Config.Name]
+		instances[ri.Config.Name]++
+		if n >= len(jitters[ri.Config.Name]) {
+			continue
+		}
+		maxJitter := jitters[ri.Config.Name][n]
+		if maxJitter <= 0 {
+			continue
+		}
+		interval := ri.Config.Interval
+		if interval == 0 {
+			interval = agentInterval
+		}
+		if maxJitter > interval/2 {
+			log.Printf("W! jitter: the collection jitter %v of the input %v is bounded to half of its interval %v", maxJitter, ri.LogName(), interval)
+			maxJitter = interval / 2
+		}
+		ji := jitteredInput{Input: ri.Input, maxJitter: maxJitter, stop: stop}
+		if si, ok := ri.Input.(telegraf.ServiceInput); ok {
+			ri.Input = &jitteredServiceInput{jitteredInput: ji, service: si}
+		} else {
+			ri.Input = &ji
+		}
+	}
+}
+
+type jitteredInput struct {
+	telegraf.Input
+	maxJitter time.Duration
+	stop      <-chan struct{}
+}
+
+// Init forwards to the Init of the input, if it has one
+func (j *jitteredInput) Init() error {
+	if i, ok := j.Input.(telegraf.Initializer); ok {
+		return i.Init()
+	}
+	return nil
+}
+
+func (j *jitteredInput) Gather(acc telegraf.Accumulator) error {
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(j.maxJitter))))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-j.stop:
+		return nil
+	}
+	return j.Input.Gather(acc)
+}
+
+// jitteredServiceInput keeps the Start and Stop of a service input, only its Gather is delayed
+type jitteredServiceInput struct {
+	jitteredInput
+	service telegraf.ServiceInput
+}
+
+func (j *jitteredServiceInput) Start(acc telegraf.Accumulator) error {
+	return j.service.Start(acc)
+}
+
+func (j *jitteredServiceInput) Stop() {
+	j.service.Stop()
+}
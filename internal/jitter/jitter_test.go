@@ -0,0 +1,104 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package jitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testInput struct{}
+
+func (testInput) SampleConfig() string { return "" }
+func (testInput) Description() string  { return "" }
+func (testInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("test", map[string]interface{}{"value": 1}, nil)
+	return nil
+}
+
+type testServiceInput struct {
+	testInput
+}
+
+func (testServiceInput) Start(telegraf.Accumulator) error { return nil }
+func (testServiceInput) Stop()                            {}
+
+func TestParse(t *testing.T) {
+	jitters, err := Parse(`{"cpu":["5s"],"procstat":["0s","2s"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]time.Duration{"cpu": {5 * time.Second}, "procstat": {0, 2 * time.Second}}, jitters)
+
+	jitters, err = Parse("")
+	assert.NoError(t, err)
+	assert.Empty(t, jitters)
+	_, err = Parse(`{"cpu":["invalid"]}`)
+	assert.Error(t, err)
+	_, err = Parse(`["5s"]`)
+	assert.Error(t, err)
+}
+
+func TestWrapInputs(t *testing.T) {
+	inputs := []*models.RunningInput{
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "none"}),
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "cpu"}),
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "mem", Interval: 4 * time.Second}),
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "procstat"}),
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "procstat"}),
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "procstat"}),
+		models.NewRunningInput(testServiceInput{}, &models.InputConfig{Name: "statsd"}),
+	}
+	WrapInputs(inputs, map[string][]time.Duration{
+		"cpu":      {5 * time.Second},
+		"mem":      {5 * time.Second},
+		"procstat": {0, 3 * time.Second},
+		"statsd":   {5 * time.Second},
+	}, time.Minute, nil)
+
+	assert.IsType(t, testInput{}, inputs[0].Input)
+	require.IsType(t, &jitteredInput{}, inputs[1].Input)
+	assert.Equal(t, 5*time.Second, inputs[1].Input.(*jitteredInput).maxJitter)
+	require.IsType(t, &jitteredInput{}, inputs[2].Input)
+	assert.Equal(t, 2*time.Second, inputs[2].Input.(*jitteredInput).maxJitter, "the jitter is bounded to half of the interval")
+	// the instances of procstat take the jitters in their order
+	assert.IsType(t, testInput{}, inputs[3].Input)
+	require.IsType(t, &jitteredInput{}, inputs[4].Input)
+	assert.Equal(t, 3*time.Second, inputs[4].Input.(*jitteredInput).maxJitter)
+	assert.IsType(t, testInput{}, inputs[5].Input)
+	// the service inputs are still services
+	assert.Implements(t, (*telegraf.ServiceInput)(nil), inputs[6].Input)
+	assert.Implements(t, (*telegraf.Initializer)(nil), inputs[6].Input)
+}
+
+func TestGather(t *testing.T) {
+	j := &jitteredInput{Input: testInput{}, maxJitter: time.Millisecond}
+	require.NoError(t, j.Init())
+	acc := &testutil.Accumulator{}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, j.Gather(acc))
+	}
+	assert.Len(t, acc.Metrics, 10)
+}
+
+func TestGatherStopped(t *testing.T) {
+	stop := make(chan struct{})
+	j := &jitteredInput{Input: testInput{}, maxJitter: time.Hour, stop: stop}
+	acc := &testutil.Accumulator{}
+	errC := make(chan error)
+	go func() { errC <- j.Gather(acc) }()
+	close(stop)
+	select {
+	case err := <-errC:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the delay of the collection was not cut short")
+	}
+	// the input does not gather once the agent stops
+	assert.Empty(t, acc.GetTelegrafMetrics())
+}
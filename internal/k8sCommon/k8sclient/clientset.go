@@ -59,6 +59,8 @@ func (c *K8sClient) init() {
 			return
 		}
 	}
+	clientOptions = loadOptions()
+	clientOptions.apply(config)
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		log.Printf("E! Failed to build ClientSet: %v", err)
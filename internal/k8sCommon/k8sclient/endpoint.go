@@ -130,7 +130,7 @@ func (c *epClient) Init() {
 	c.store = NewObjStore(transformFuncEndpoint)
 
 	lw := createEndpointListWatch(Get().ClientSet, metav1.NamespaceAll)
-	reflector := cache.NewReflector(lw, &v1.Endpoints{}, c.store, 0)
+	reflector := cache.NewReflector(lw, &v1.Endpoints{}, c.store, clientOptions.resyncPeriod)
 	go reflector.Run(c.stopChan)
 
 	if err := wait.Poll(50*time.Millisecond, 2*time.Second, func() (done bool, err error) {
@@ -109,7 +109,7 @@ func (c *jobClient) Init() {
 	c.store = NewObjStore(transformFuncJob)
 
 	lw := createJobListWatch(Get().ClientSet, metav1.NamespaceAll)
-	reflector := cache.NewReflector(lw, &batchv1.Job{}, c.store, 0)
+	reflector := cache.NewReflector(lw, &batchv1.Job{}, c.store, clientOptions.resyncPeriod)
 	go reflector.Run(c.stopChan)
 
 	if err := wait.Poll(50*time.Millisecond, 2*time.Second, func() (done bool, err error) {
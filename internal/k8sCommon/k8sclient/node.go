@@ -120,7 +120,7 @@ func (c *nodeClient) Init() {
 	c.store = NewObjStore(transformFuncNode)
 
 	lw := createNodeListWatch(Get().ClientSet)
-	reflector := cache.NewReflector(lw, &v1.Node{}, c.store, 0)
+	reflector := cache.NewReflector(lw, &v1.Node{}, c.store, clientOptions.resyncPeriod)
 	go reflector.Run(c.stopChan)
 
 	if err := wait.Poll(50*time.Millisecond, 2*time.Second, func() (done bool, err error) {
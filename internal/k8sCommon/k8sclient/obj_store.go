@@ -18,12 +18,17 @@ type ObjStore struct {
 	objs      map[types.UID]interface{}
 
 	transformFunc func(interface{}) (interface{}, error)
+
+	// maxObjects caps the cached objects, the new ones are dropped once it is reached
+	maxObjects int
+	capped     bool
 }
 
 func NewObjStore(transformFunc func(interface{}) (interface{}, error)) *ObjStore {
 	return &ObjStore{
 		transformFunc: transformFunc,
 		objs:          map[types.UID]interface{}{},
+		maxObjects:    clientOptions.maxCachedObjects,
 	}
 }
 
@@ -56,6 +61,13 @@ func (s *ObjStore) Add(obj interface{}) error {
 	s.Lock()
 	defer s.Unlock()
 
+	if _, ok := s.objs[o.GetUID()]; !ok && s.maxObjects > 0 && len(s.objs) >= s.maxObjects {
+		if !s.capped {
+			log.Printf("W! The cached store reaches the limit of %d objects, dropping the new ones.", s.maxObjects)
+			s.capped = true
+		}
+		return nil
+	}
 	s.objs[o.GetUID()] = toCacheObj
 	s.refreshed = true
 
@@ -124,6 +136,7 @@ func (s *ObjStore) GetByKey(key string) (item interface{}, exists bool, err erro
 func (s *ObjStore) Replace(list []interface{}, _ string) error {
 	s.Lock()
 	s.objs = map[types.UID]interface{}{}
+	s.capped = false
 	s.Unlock()
 
 	for _, o := range list {
@@ -136,7 +149,12 @@ func (s *ObjStore) Replace(list []interface{}, _ string) error {
 	return nil
 }
 
-// Resync implements the Resync method of the store interface.
+// Resync implements the Resync method of the store interface, what is derived from the cached objects is rebuilt.
 func (s *ObjStore) Resync() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.refreshed = true
+
 	return nil
 }
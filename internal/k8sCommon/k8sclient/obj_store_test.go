@@ -0,0 +1,64 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package k8sclient
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+	"github.com/docker/docker/pkg/testutil/assert"
+	"k8s.io/client-go/rest"
+)
+
+func TestObjStore_MaxObjects(t *testing.T) {
+	store := NewObjStore(transformFuncPod)
+	store.maxObjects = 2
+
+	store.Replace(podArray[:3], "")
+	assert.Equal(t, len(store.List()), 2)
+	assert.Equal(t, store.capped, true)
+
+	// the cached objects are still updated once the limit is reached
+	assert.NilError(t, store.Update(podArray[0]))
+	assert.Equal(t, len(store.List()), 2)
+
+	assert.NilError(t, store.Delete(podArray[0]))
+	store.Refreshed()
+	assert.NilError(t, store.Add(podArray[3]))
+	assert.Equal(t, len(store.List()), 2)
+	assert.Equal(t, store.Refreshed(), true)
+}
+
+func TestObjStore_Resync(t *testing.T) {
+	store := NewObjStore(transformFuncPod)
+	store.Replace(podArray, "")
+	store.Refreshed()
+	assert.Equal(t, store.Refreshed(), false)
+
+	assert.NilError(t, store.Resync())
+	assert.Equal(t, store.Refreshed(), true)
+}
+
+func TestLoadOptions(t *testing.T) {
+	os.Setenv(envconfig.CWAGENT_K8S_API_QPS, "20")
+	os.Setenv(envconfig.CWAGENT_K8S_API_BURST, "40")
+	os.Setenv(envconfig.CWAGENT_K8S_RESYNC_INTERVAL, "300s")
+	os.Setenv(envconfig.CWAGENT_K8S_MAX_CACHED_OBJECTS, "invalid")
+	defer func() {
+		os.Unsetenv(envconfig.CWAGENT_K8S_API_QPS)
+		os.Unsetenv(envconfig.CWAGENT_K8S_API_BURST)
+		os.Unsetenv(envconfig.CWAGENT_K8S_RESYNC_INTERVAL)
+		os.Unsetenv(envconfig.CWAGENT_K8S_MAX_CACHED_OBJECTS)
+	}()
+
+	opts := loadOptions()
+	assert.DeepEqual(t, opts, options{qps: 20, burst: 40, resyncPeriod: 5 * time.Minute})
+
+	config := &rest.Config{}
+	opts.apply(config)
+	assert.Equal(t, config.QPS, float32(20))
+	assert.Equal(t, config.Burst, 40)
+}
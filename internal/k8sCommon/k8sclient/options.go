@@ -0,0 +1,80 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package k8sclient
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
+	"k8s.io/client-go/rest"
+)
+
+// options of the client shared by the kubernetes aware components of the agent, they are set by the translator
+// in the env config so every component watches the api server through the same rate limited client and caches
+type options struct {
+	// qps and burst rate limit the requests to the api server, the client-go defaults are used when 0
+	qps   float32
+	burst int
+	// resyncPeriod is how often the cached objects are delivered again to rebuild what is derived from them,
+	// it does not list the objects from the api server again. Never when 0.
+	resyncPeriod time.Duration
+	// maxCachedObjects caps the objects cached by each watch, e.g. the pods of the cluster. Unbounded when 0.
+	maxCachedObjects int
+}
+
+var clientOptions options
+
+func loadOptions() options {
+	var opts options
+	if v := os.Getenv(envconfig.CWAGENT_K8S_API_QPS); v != "" {
+		if qps, err := strconv.ParseFloat(v, 32); err == nil && qps > 0 {
+			opts.qps = float32(qps)
+		} else {
+			log.Printf("W! Invalid %s %q, using the default rate limit", envconfig.CWAGENT_K8S_API_QPS, v)
+			agentevents.Post(agentevents.Event{
+				Type:      agentevents.TypeConfigFallback,
+				Severity:  agentevents.SeverityWarning,
+				Component: "k8sclient",
+				Message:   fmt.Sprintf("invalid %s %q, the default rate limit is used", envconfig.CWAGENT_K8S_API_QPS, v),
+			})
+		}
+	}
+	opts.burst = positiveInt(envconfig.CWAGENT_K8S_API_BURST)
+	opts.maxCachedObjects = positiveInt(envconfig.CWAGENT_K8S_MAX_CACHED_OBJECTS)
+	if v := os.Getenv(envconfig.CWAGENT_K8S_RESYNC_INTERVAL); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			opts.resyncPeriod = d
+		} else {
+			log.Printf("W! Invalid %s %q, the cached objects are not resynced", envconfig.CWAGENT_K8S_RESYNC_INTERVAL, v)
+		}
+	}
+	return opts
+}
+
+func positiveInt(env string) int {
+	v := os.Getenv(env)
+	if v == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i <= 0 {
+		log.Printf("W! Invalid %s %q, ignoring it", env, v)
+		return 0
+	}
+	return i
+}
+
+func (o options) apply(config *rest.Config) {
+	if o.qps > 0 {
+		config.QPS = o.qps
+	}
+	if o.burst > 0 {
+		config.Burst = o.burst
+	}
+}
@@ -80,7 +80,7 @@ func (c *podClient) Init() {
 	c.store = NewObjStore(transformFuncPod)
 
 	lw := createPodListWatch(Get().ClientSet, metav1.NamespaceAll)
-	reflector := cache.NewReflector(lw, &v1.Pod{}, c.store, 0)
+	reflector := cache.NewReflector(lw, &v1.Pod{}, c.store, clientOptions.resyncPeriod)
 	go reflector.Run(c.stopChan)
 
 	if err := wait.Poll(50*time.Millisecond, 2*time.Second, func() (done bool, err error) {
@@ -108,7 +108,7 @@ func (c *replicaSetClient) Init() {
 	c.store = NewObjStore(transformFuncReplicaSet)
 
 	lw := createReplicaSetListWatch(Get().ClientSet, metav1.NamespaceAll)
-	reflector := cache.NewReflector(lw, &appsv1.ReplicaSet{}, c.store, 0)
+	reflector := cache.NewReflector(lw, &appsv1.ReplicaSet{}, c.store, clientOptions.resyncPeriod)
 	go reflector.Run(c.stopChan)
 
 	if err := wait.Poll(50*time.Millisecond, 2*time.Second, func() (done bool, err error) {
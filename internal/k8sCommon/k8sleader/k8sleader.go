@@ -0,0 +1,213 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package k8sleader elects the replica of the agent running the cluster-scoped collection, e.g. the cluster level
+// metrics from the api server and the cluster-wide prometheus targets, so they are not collected by every pod of the
+// daemonset. The other replicas stand by and take over once the leader stops renewing its lease.
+package k8sleader
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/k8sCommon/k8sclient"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+const (
+	LockName = "cwagent-clusterleader"
+	// the lease is held along with the config map the agents used to elect with, so the replicas still electing
+	// with the config map agree on the leader during the upgrades
+	lockType         = resourcelock.ConfigMapsLeasesResourceLock
+	lockNamespaceEnv = "K8S_NAMESPACE"
+
+	leaseDuration = 60 * time.Second
+	renewDeadline = 15 * time.Second
+	retryPeriod   = 5 * time.Second
+)
+
+// Elector runs the leader election of the agent, it is shared by the cluster-scoped collectors so they are all
+// run by the same replica
+type Elector struct {
+	mu        sync.Mutex
+	users     int
+	cancel    context.CancelFunc
+	onStopped []stoppedCallback
+	nextID    int
+	leading   int32
+
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+}
+
+func newElector() *Elector {
+	return &Elector{leaseDuration: leaseDuration, renewDeadline: renewDeadline, retryPeriod: retryPeriod}
+}
+
+var elector = newElector()
+
+// Get returns the leader elector of the agent
+func Get() *Elector {
+	return elector
+}
+
+// Start joins the election with the identity unless the agent already takes part in it, the election is left once
+// every Start is matched by a Stop
+func (e *Elector) Start(identity string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.users++; e.users > 1 {
+		return nil
+	}
+
+	lockNamespace := os.Getenv(lockNamespaceEnv)
+	if lockNamespace == "" {
+		e.users--
+		log.Printf("E! Missing environment variable %s which is required to create lock. Please check your YAML config.", lockNamespaceEnv)
+		return errors.New("missing environment variable " + lockNamespaceEnv)
+	}
+
+	clientSet := k8sclient.Get().ClientSet
+	configMapInterface := clientSet.CoreV1().ConfigMaps(lockNamespace)
+	if configMap, err := configMapInterface.Get(LockName, metav1.GetOptions{}); configMap == nil || err != nil {
+		log.Printf("I! Cannot get the leader config map: %v, try to create the config map...", err)
+		configMap, err = configMapInterface.Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: lockNamespace,
+				Name:      LockName,
+			},
+		})
+		log.Printf("I! configMap: %v, err: %v", configMap, err)
+	}
+
+	lock, err := resourcelock.New(
+		lockType,
+		lockNamespace, LockName,
+		clientSet.CoreV1(),
+		clientSet.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      identity,
+			EventRecorder: createRecorder(clientSet, LockName, lockNamespace),
+		})
+	if err != nil {
+		e.users--
+		log.Printf("E! Failed to create resource lock: %v", err)
+		return err
+	}
+
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	go e.run(ctx, lock)
+	return nil
+}
+
+// Stop leaves the election once every collector started it stops, the lease is released so another replica
+// takes over right away
+func (e *Elector) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.users == 0 {
+		return
+	}
+	if e.users--; e.users == 0 && e.cancel != nil {
+		e.cancel()
+		e.cancel = nil
+	}
+}
+
+// IsLeader returns whether the replica is the leader running the cluster-scoped collection
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leading) == 1
+}
+
+type stoppedCallback struct {
+	id int
+	f  func()
+}
+
+// OnStoppedLeading registers the function called when the replica stops leading, e.g. to release what is only
+// used by the cluster-scoped collection. The returned function deregisters it, e.g. when the collector stops before
+// it is started again on reload.
+func (e *Elector) OnStoppedLeading(f func()) (deregister func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextID++
+	id := e.nextID
+	e.onStopped = append(e.onStopped, stoppedCallback{id: id, f: f})
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for i, c := range e.onStopped {
+			if c.id == id {
+				e.onStopped = append(e.onStopped[:i:i], e.onStopped[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (e *Elector) run(ctx context.Context, lock resourcelock.Interface) {
+	identity := lock.Identity()
+	for {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock: lock,
+			// IMPORTANT: you MUST ensure that any code you have that
+			// is protected by the lease must terminate **before**
+			// you call cancel. Otherwise, you could have a background
+			// loop still running and another process could
+			// get elected before your background loop finished, violating
+			// the stated goal of the lease.
+			LeaseDuration:   e.leaseDuration,
+			RenewDeadline:   e.renewDeadline,
+			RetryPeriod:     e.retryPeriod,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					log.Printf("I! k8sleader OnStartedLeading: %s", identity)
+					atomic.StoreInt32(&e.leading, 1)
+				},
+				OnStoppedLeading: func() {
+					log.Printf("I! k8sleader OnStoppedLeading: %s", identity)
+					atomic.StoreInt32(&e.leading, 0)
+					e.mu.Lock()
+					onStopped := e.onStopped
+					e.mu.Unlock()
+					for _, c := range onStopped {
+						c.f()
+					}
+				},
+				OnNewLeader: func(leader string) {
+					log.Printf("I! k8sleader Switch New Leader: %s", leader)
+				},
+			},
+		})
+
+		select {
+		case <-ctx.Done(): //when leader election ends, the channel ctx.Done() will be closed
+			log.Printf("I! k8sleader shutdown Leader Election: %s", identity)
+			return
+		default:
+		}
+	}
+}
+
+func createRecorder(clientSet kubernetes.Interface, name, namespace string) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: v1core.New(clientSet.CoreV1().RESTClient()).Events(namespace)})
+	return eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: name})
+}
@@ -0,0 +1,101 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package k8sleader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func testElector(t *testing.T, client *fake.Clientset, identity string) (*Elector, context.CancelFunc) {
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, "amazon-cloudwatch", LockName,
+		client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: identity})
+	require.NoError(t, err)
+	e := newElector()
+	e.leaseDuration, e.renewDeadline, e.retryPeriod = time.Second, 500*time.Millisecond, 100*time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	go e.run(ctx, lock)
+	return e, cancel
+}
+
+func waitForLeader(electors ...*Elector) *Elector {
+	for i := 0; i < 50; i++ {
+		for _, e := range electors {
+			if e.IsLeader() {
+				return e
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+func TestElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	e1, cancel1 := testElector(t, client, "node-1")
+	e2, cancel2 := testElector(t, client, "node-2")
+	defer cancel2()
+
+	leader := waitForLeader(e1, e2)
+	require.NotNil(t, leader)
+	time.Sleep(300 * time.Millisecond)
+	assert.NotEqual(t, e1.IsLeader(), e2.IsLeader(), "exactly one replica leads")
+
+	stopped := make(chan struct{})
+	leader.OnStoppedLeading(func() { close(stopped) })
+	// a reloaded collector deregisters its callback, it is not called anymore
+	leader.OnStoppedLeading(func() { t.Error("the deregistered callback is called") })()
+	standby := e2
+	if leader == e2 {
+		standby = e1
+		cancel2()
+	} else {
+		cancel1()
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the leader does not stop leading")
+	}
+
+	// the lease is released on cancel so the standby replica takes over
+	assert.Equal(t, standby, waitForLeader(standby))
+	lease, err := client.CoordinationV1().Leases("amazon-cloudwatch").Get(LockName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, lease.Spec.HolderIdentity)
+	if standby == e1 {
+		assert.Equal(t, "node-1", *lease.Spec.HolderIdentity)
+	} else {
+		assert.Equal(t, "node-2", *lease.Spec.HolderIdentity)
+	}
+	cancel1()
+}
+
+func TestStartWithoutNamespace(t *testing.T) {
+	e := newElector()
+	assert.Error(t, e.Start("node-1"))
+	assert.False(t, e.IsLeader())
+	e.Stop()
+}
+
+func TestOnStoppedLeadingDeregister(t *testing.T) {
+	e := newElector()
+	var calls []int
+	deregister1 := e.OnStoppedLeading(func() { calls = append(calls, 1) })
+	e.OnStoppedLeading(func() { calls = append(calls, 2) })
+	deregister1()
+	deregister1()
+	assert.Len(t, e.onStopped, 1)
+	for _, c := range e.onStopped {
+		c.f()
+	}
+	assert.Equal(t, []int{2}, calls)
+}
@@ -0,0 +1,136 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package lifecycle watches the instance metadata for the notices of the imminent termination of the instance,
+// i.e. the spot interruption notices and the auto scaling scale-in, so the outputs can flush what they buffer
+// before the instance is gone.
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	spotInstanceActionPath   = "spot/instance-action"
+	targetLifecycleStatePath = "autoscaling/target-lifecycle-state"
+	lifecycleStateTerminated = "Terminated"
+
+	// the spot interruption notice is given two minutes before the instance is interrupted
+	pollInterval = 5 * time.Second
+)
+
+type metadataClient interface {
+	Available() bool
+	GetMetadata(p string) (string, error)
+}
+
+// Notice is the termination notice of the instance
+type Notice struct {
+	Reason string
+	// Time is when the instance is expected to be interrupted, it is zero when the notice does not tell
+	Time time.Time
+}
+
+func (n Notice) String() string {
+	if n.Time.IsZero() {
+		return n.Reason
+	}
+	return fmt.Sprintf("%s at %s", n.Reason, n.Time.Format(time.RFC3339))
+}
+
+// Watcher polls the instance metadata until the termination notice of the instance is received
+type Watcher struct {
+	md          metadataClient
+	interval    time.Duration
+	terminating chan struct{}
+	notice      Notice
+}
+
+func newWatcher(md metadataClient, interval time.Duration) *Watcher {
+	return &Watcher{md: md, interval: interval, terminating: make(chan struct{})}
+}
+
+// Terminating returns the channel closed once the termination notice is received
+func (w *Watcher) Terminating() <-chan struct{} {
+	return w.terminating
+}
+
+// Notice returns the termination notice received, it is only set once Terminating is closed
+func (w *Watcher) Notice() Notice {
+	return w.notice
+}
+
+func (w *Watcher) run() {
+	if !w.md.Available() {
+		log.Println("D! lifecycle: ec2metadata is not available, not watching for the termination notices")
+		return
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if notice, ok := w.poll(); ok {
+			w.notice = notice
+			log.Printf("W! lifecycle: the instance is terminating: %v, flushing the buffered logs and metrics", notice)
+			close(w.terminating)
+			return
+		}
+		<-ticker.C
+	}
+}
+
+// poll returns the termination notice of the instance, the metadata paths are not found until there is a notice
+func (w *Watcher) poll() (Notice, bool) {
+	if action, err := w.md.GetMetadata(spotInstanceActionPath); err == nil && action != "" {
+		// {"action": "terminate", "time": "2017-09-18T08:22:00Z"}
+		var a struct {
+			Action string    `json:"action"`
+			Time   time.Time `json:"time"`
+		}
+		if err := json.Unmarshal([]byte(action), &a); err != nil {
+			log.Printf("W! lifecycle: unable to parse the spot instance action %q: %v", action, err)
+		}
+		return Notice{Reason: fmt.Sprintf("spot interruption (%s)", a.Action), Time: a.Time}, true
+	}
+	if state, err := w.md.GetMetadata(targetLifecycleStatePath); err == nil && strings.TrimSpace(state) == lifecycleStateTerminated {
+		return Notice{Reason: "auto scaling lifecycle state " + lifecycleStateTerminated}, true
+	}
+	return Notice{}, false
+}
+
+var (
+	defaultWatcher     *Watcher
+	defaultWatcherOnce sync.Once
+)
+
+func watcher() *Watcher {
+	defaultWatcherOnce.Do(func() {
+		defaultWatcher = newWatcher(nil, pollInterval)
+		ses, err := session.NewSession()
+		if err != nil {
+			log.Printf("E! lifecycle: unable to create the session to watch for the termination notices: %v", err)
+			return
+		}
+		defaultWatcher.md = ec2metadata.New(ses)
+		go defaultWatcher.run()
+	})
+	return defaultWatcher
+}
+
+// Terminating starts watching the instance metadata on the first call and returns the channel closed once the
+// termination notice of the instance is received. It is never closed when not running on EC2.
+func Terminating() <-chan struct{} {
+	return watcher().Terminating()
+}
+
+// TerminationNotice returns the termination notice of the instance once Terminating is closed
+func TerminationNotice() Notice {
+	return watcher().Notice()
+}
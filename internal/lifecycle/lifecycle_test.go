@@ -0,0 +1,91 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package lifecycle
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMetadata struct {
+	sync.Mutex
+	available bool
+	paths     map[string]string
+}
+
+func (m *mockMetadata) Available() bool {
+	return m.available
+}
+
+func (m *mockMetadata) GetMetadata(p string) (string, error) {
+	m.Lock()
+	defer m.Unlock()
+	if v, ok := m.paths[p]; ok {
+		return v, nil
+	}
+	return "", errors.New("EC2MetadataError: failed to make EC2Metadata request, status code: 404")
+}
+
+func (m *mockMetadata) set(p, v string) {
+	m.Lock()
+	defer m.Unlock()
+	m.paths[p] = v
+}
+
+func TestPoll(t *testing.T) {
+	md := &mockMetadata{available: true, paths: map[string]string{}}
+	w := newWatcher(md, time.Millisecond)
+
+	_, ok := w.poll()
+	assert.False(t, ok)
+
+	md.set(targetLifecycleStatePath, "InService")
+	_, ok = w.poll()
+	assert.False(t, ok)
+
+	md.set(targetLifecycleStatePath, "Terminated")
+	notice, ok := w.poll()
+	assert.True(t, ok)
+	assert.Equal(t, "auto scaling lifecycle state Terminated", notice.String())
+
+	md.set(spotInstanceActionPath, `{"action": "terminate", "time": "2017-09-18T08:22:00Z"}`)
+	notice, ok = w.poll()
+	assert.True(t, ok)
+	assert.Equal(t, "spot interruption (terminate) at 2017-09-18T08:22:00Z", notice.String())
+}
+
+func TestWatcherRun(t *testing.T) {
+	md := &mockMetadata{available: true, paths: map[string]string{}}
+	w := newWatcher(md, time.Millisecond)
+	go w.run()
+
+	select {
+	case <-w.Terminating():
+		t.Fatal("terminating before the notice")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	md.set(spotInstanceActionPath, `{"action": "stop", "time": "2017-09-18T08:22:00Z"}`)
+	select {
+	case <-w.Terminating():
+	case <-time.After(time.Second):
+		t.Fatal("the termination notice is not received")
+	}
+	require.Equal(t, "spot interruption (stop)", w.Notice().Reason)
+}
+
+func TestWatcherNotAvailable(t *testing.T) {
+	w := newWatcher(&mockMetadata{}, time.Millisecond)
+	w.run()
+	select {
+	case <-w.Terminating():
+		t.Fatal("terminating without the instance metadata")
+	default:
+	}
+}
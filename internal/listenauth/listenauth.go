@@ -0,0 +1,255 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package listenauth authenticates the clients of the listener inputs, so they can accept data on the interfaces
+// other than the loopback one. The clients are allowed by their address, by a shared token and, on the stream
+// listeners, by a certificate signed by the allowed CAs (mTLS).
+//
+// The token is sent as the first line of each datagram, or of each connection, in the form auth:<token>, and in the
+// Authorization header as Bearer <token> for the HTTP listeners.
+package listenauth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	tlsint "github.com/aws/amazon-cloudwatch-agent/internal/tls"
+)
+
+// TokenPrefix starts the line of the token of the datagrams and of the connections
+const TokenPrefix = "auth:"
+
+// defaultConnTimeout is how long a connection has to complete the TLS handshake and to send the line of the token
+const defaultConnTimeout = 10 * time.Second
+
+var (
+	ErrAddressNotAllowed = errors.New("the client address is not allowed")
+	ErrInvalidToken      = errors.New("the token is missing or invalid")
+)
+
+// Config is the authentication of the clients of a listener input, none of them is required
+type Config struct {
+	// AllowedCIDRs are the networks of the clients, all of them are allowed when empty
+	AllowedCIDRs []string `toml:"allowed_cidrs"`
+	// TokenFile holds the token shared with the clients, surrounding whitespace is trimmed
+	TokenFile string `toml:"token_file"`
+	// The certificate of the listener, the clients must present a certificate signed by TLSAllowedCACerts
+	tlsint.ServerConfig
+}
+
+// Authenticator authenticates the clients with a Config, its zero value allows all the clients
+type Authenticator struct {
+	networks  []*net.IPNet
+	token     []byte
+	tlsConfig *tls.Config
+	// connTimeout is defaultConnTimeout when not set
+	connTimeout time.Duration
+}
+
+// Enabled returns whether any authentication is configured
+func (c *Config) Enabled() bool {
+	return len(c.AllowedCIDRs) > 0 || c.TokenFile != "" || c.TLSCert != "" || len(c.TLSAllowedCACerts) > 0
+}
+
+// Authenticator validates the config and reads the token and the certificates
+func (c *Config) Authenticator() (*Authenticator, error) {
+	a := &Authenticator{}
+	for _, cidr := range c.AllowedCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			// a single address is allowed alone
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid allowed CIDR %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		a.networks = append(a.networks, network)
+	}
+	if c.TokenFile != "" {
+		content, err := ioutil.ReadFile(c.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the token file: %v", err)
+		}
+		a.token = bytes.TrimSpace(content)
+		if len(a.token) == 0 {
+			return nil, fmt.Errorf("the token file %v is empty", c.TokenFile)
+		}
+	}
+	if c.TLSCert != "" || c.TLSKey != "" {
+		if c.TLSCert == "" || c.TLSKey == "" {
+			return nil, errors.New("tls_cert and tls_key must be set together")
+		}
+		tlsConfig, err := c.ServerConfig.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		a.tlsConfig = tlsConfig
+	} else if len(c.TLSAllowedCACerts) > 0 {
+		return nil, errors.New("tls_allowed_cacerts requires tls_cert and tls_key")
+	}
+	return a, nil
+}
+
+// AllowAddr returns whether the address of a client is in the allowed networks
+func (a *Authenticator) AllowAddr(addr net.Addr) bool {
+	if len(a.networks) == 0 {
+		return true
+	}
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.IPAddr:
+		ip = addr.IP
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return false
+	}
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authenticator) validToken(token []byte) bool {
+	return subtle.ConstantTimeCompare(token, a.token) == 1
+}
+
+// Packet authenticates a datagram from the address and returns its payload without the line of the token
+func (a *Authenticator) Packet(addr net.Addr, packet []byte) ([]byte, error) {
+	if !a.AllowAddr(addr) {
+		return nil, ErrAddressNotAllowed
+	}
+	if a.token == nil {
+		return packet, nil
+	}
+	line, rest := packet, []byte(nil)
+	if i := bytes.IndexByte(packet, '\n'); i >= 0 {
+		line, rest = packet[:i], packet[i+1:]
+	}
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte(TokenPrefix)) || !a.validToken(line[len(TokenPrefix):]) {
+		return nil, ErrInvalidToken
+	}
+	return rest, nil
+}
+
+// Listener wraps the stream listener with TLS, and closes the connections of the clients which are not allowed.
+// The token is checked by Conn once the connection is read, not to block the accepting goroutine.
+func (a *Authenticator) Listener(l net.Listener) net.Listener {
+	al := &listener{Listener: l, auth: a}
+	if a.tlsConfig != nil {
+		return tls.NewListener(al, a.tlsConfig)
+	}
+	return al
+}
+
+type listener struct {
+	net.Listener
+	auth *Authenticator
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.auth.AllowAddr(conn.RemoteAddr()) {
+			return conn, nil
+		}
+		log.Printf("D! listenauth: closed the connection of %v: %v", conn.RemoteAddr(), ErrAddressNotAllowed)
+		conn.Close()
+	}
+}
+
+// Conn reads the line of the token of an accepted connection, the reader returned reads the data which follows. The
+// clients which do not complete the TLS handshake and send the token within the timeout are rejected, so the idle
+// connections do not pile up before they are authenticated.
+func (a *Authenticator) Conn(conn net.Conn) (*bufio.Reader, error) {
+	r := bufio.NewReader(conn)
+	tlsConn, isTLS := conn.(*tls.Conn)
+	if !isTLS && a.token == nil {
+		return r, nil
+	}
+	timeout := a.connTimeout
+	if timeout == 0 {
+		timeout = defaultConnTimeout
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if isTLS {
+		// the certificate of the client is verified by the handshake
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, err
+		}
+	}
+	if a.token != nil {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, TokenPrefix) || !a.validToken([]byte(line[len(TokenPrefix):])) {
+			return nil, ErrInvalidToken
+		}
+	}
+	// the authenticated clients may stay idle
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Handler rejects the requests of the clients which are not allowed, or without the token, with 403 and 401
+func (a *Authenticator) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if !a.AllowAddr(&net.IPAddr{IP: net.ParseIP(host)}) {
+			http.Error(w, ErrAddressNotAllowed.Error(), http.StatusForbidden)
+			return
+		}
+		if a.token != nil {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, "Bearer ") || !a.validToken([]byte(strings.TrimPrefix(header, "Bearer "))) {
+				http.Error(w, ErrInvalidToken.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// TLSConfig returns the config of the HTTPS listeners, nil without TLS
+func (a *Authenticator) TLSConfig() *tls.Config {
+	return a.tlsConfig
+}
@@ -0,0 +1,338 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package listenauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tlsint "github.com/aws/amazon-cloudwatch-agent/internal/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "listenauth")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestAuthenticatorConfig(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := (&Config{AllowedCIDRs: []string{"10.0.0.0/33"}}).Authenticator()
+	assert.EqualError(t, err, `invalid allowed CIDR "10.0.0.0/33"`)
+	_, err = (&Config{TokenFile: filepath.Join(dir, "missing")}).Authenticator()
+	assert.Error(t, err)
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, ioutil.WriteFile(empty, []byte("\n"), 0600))
+	_, err = (&Config{TokenFile: empty}).Authenticator()
+	assert.Error(t, err)
+	_, err = (&Config{ServerConfig: tlsint.ServerConfig{TLSCert: "cert.pem"}}).Authenticator()
+	assert.EqualError(t, err, "tls_cert and tls_key must be set together")
+	_, err = (&Config{ServerConfig: tlsint.ServerConfig{TLSAllowedCACerts: []string{"ca.pem"}}}).Authenticator()
+	assert.EqualError(t, err, "tls_allowed_cacerts requires tls_cert and tls_key")
+
+	assert.False(t, (&Config{}).Enabled())
+	assert.True(t, (&Config{AllowedCIDRs: []string{"10.0.0.0/8"}}).Enabled())
+}
+
+func TestAllowAddr(t *testing.T) {
+	a, err := (&Config{AllowedCIDRs: []string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"}}).Authenticator()
+	require.NoError(t, err)
+	assert.True(t, a.AllowAddr(&net.UDPAddr{IP: net.ParseIP("10.1.2.3"), Port: 8125}))
+	assert.True(t, a.AllowAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.7"), Port: 8125}))
+	assert.False(t, a.AllowAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.8"), Port: 8125}))
+	assert.True(t, a.AllowAddr(&net.UDPAddr{IP: net.ParseIP("fd00::1"), Port: 8125}))
+	assert.False(t, a.AllowAddr(&net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8125}))
+
+	assert.True(t, (&Authenticator{}).AllowAddr(&net.UDPAddr{IP: net.ParseIP("127.0.0.1")}))
+}
+
+func TestPacket(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	token := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("s3cret\n"), 0600))
+	a, err := (&Config{AllowedCIDRs: []string{"127.0.0.0/8"}, TokenFile: token}).Authenticator()
+	require.NoError(t, err)
+	local := &net.UDPAddr{IP: net.ParseIP("127.0.0.1")}
+
+	payload, err := a.Packet(local, []byte("auth:s3cret\nrequests:1|c\nlatency:3|ms"))
+	assert.NoError(t, err)
+	assert.Equal(t, "requests:1|c\nlatency:3|ms", string(payload))
+	_, err = a.Packet(local, []byte("auth:wrong\nrequests:1|c"))
+	assert.Equal(t, ErrInvalidToken, err)
+	_, err = a.Packet(local, []byte("requests:1|c"))
+	assert.Equal(t, ErrInvalidToken, err)
+	_, err = a.Packet(&net.UDPAddr{IP: net.ParseIP("10.0.0.1")}, []byte("auth:s3cret\nrequests:1|c"))
+	assert.Equal(t, ErrAddressNotAllowed, err)
+
+	payload, err = (&Authenticator{}).Packet(local, []byte("requests:1|c"))
+	assert.NoError(t, err)
+	assert.Equal(t, "requests:1|c", string(payload))
+}
+
+func TestListenerToken(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	token := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("s3cret"), 0600))
+	a, err := (&Config{TokenFile: token}).Authenticator()
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = a.Listener(l)
+	defer l.Close()
+
+	for _, test := range []struct {
+		data string
+		line string
+		err  error
+	}{
+		{data: "auth:s3cret\nrequests:1|c\n", line: "requests:1|c\n"},
+		{data: "auth:other\nrequests:1|c\n", err: ErrInvalidToken},
+		{data: "requests:1|c\n", err: ErrInvalidToken},
+	} {
+		client, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		_, err = client.Write([]byte(test.data))
+		require.NoError(t, err)
+		client.Close()
+
+		conn, err := l.Accept()
+		require.NoError(t, err)
+		r, err := a.Conn(conn)
+		assert.Equal(t, test.err, err)
+		if err == nil {
+			line, _ := r.ReadString('\n')
+			assert.Equal(t, test.line, line)
+		}
+		conn.Close()
+	}
+}
+
+func TestConnTimeout(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	token := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("s3cret"), 0600))
+	a, err := (&Config{TokenFile: token}).Authenticator()
+	require.NoError(t, err)
+	a.connTimeout = 100 * time.Millisecond
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = a.Listener(l)
+	defer l.Close()
+
+	// the client which stays idle before sending the token is rejected
+	idle, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer idle.Close()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = a.Conn(conn)
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	// the listeners close the rejected connections
+	conn.Close()
+	require.NoError(t, idle.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = idle.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err, "the idle client is disconnected")
+
+	// the client may stay idle once authenticated
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("auth:s3cret\n"))
+	require.NoError(t, err)
+	conn, err = l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	r, err := a.Conn(conn)
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		client.Write([]byte("requests:1|c\n"))
+	}()
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "requests:1|c\n", line)
+}
+
+func TestListenerAllowedCIDRs(t *testing.T) {
+	a, err := (&Config{AllowedCIDRs: []string{"10.0.0.0/8"}}).Authenticator()
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = a.Listener(l)
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	accepted := make(chan struct{})
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			conn.Close()
+			close(accepted)
+		}
+	}()
+	// the connection of the loopback address is closed without being returned
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = client.Read(make([]byte, 1))
+	assert.Error(t, err)
+	l.Close()
+	select {
+	case <-accepted:
+		t.Fatal("the connection of a client which is not allowed was accepted")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestListenerMutualTLS(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ca := newCert(t, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newCert(t, "server", ca).write(t, dir, "server")
+	a, err := (&Config{ServerConfig: tlsint.ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSAllowedCACerts: []string{caFile}}}).Authenticator()
+	require.NoError(t, err)
+	require.NotNil(t, a.TLSConfig())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = a.Listener(l)
+	defer l.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	other := newCert(t, "other ca", nil)
+	for _, test := range []struct {
+		client  *testCert
+		allowed bool
+	}{
+		{client: newCert(t, "client", ca), allowed: true},
+		{client: newCert(t, "client of the other ca", other)},
+		{},
+	} {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if test.client != nil {
+			tlsConfig.Certificates = []tls.Certificate{test.client.tlsCertificate()}
+		}
+		go func() {
+			client, err := tls.Dial("tcp", l.Addr().String(), tlsConfig)
+			if err == nil {
+				client.Write([]byte("requests:1|c\n"))
+				client.Close()
+			}
+		}()
+		conn, err := l.Accept()
+		require.NoError(t, err)
+		r, err := a.Conn(conn)
+		if test.allowed {
+			require.NoError(t, err)
+			line, _ := r.ReadString('\n')
+			assert.Equal(t, "requests:1|c\n", line)
+		} else {
+			assert.Error(t, err)
+		}
+		conn.Close()
+	}
+}
+
+func TestHandler(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	token := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("s3cret"), 0600))
+	a, err := (&Config{AllowedCIDRs: []string{"127.0.0.1"}, TokenFile: token}).Authenticator()
+	require.NoError(t, err)
+	server := httptest.NewServer(a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer server.Close()
+
+	for header, status := range map[string]int{"Bearer s3cret": http.StatusNoContent, "Bearer other": http.StatusUnauthorized, "": http.StatusUnauthorized} {
+		req, err := http.NewRequest("POST", server.URL, nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, status, resp.StatusCode, header)
+	}
+
+	a, err = (&Config{AllowedCIDRs: []string{"10.0.0.0/8"}}).Authenticator()
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/", nil)
+	a.Handler(http.NotFoundHandler()).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -25,5 +25,7 @@ const (
 	LogEntryField = "value"
 
 	WindowsEventLogPrefix = "Amazon_CloudWatch_WindowsEventLog_"
-	LogType               = "log_type"
+	//The prefix of the state files of the journal streams of the systemd units, they save the cursor of the journal.
+	JournalStatePrefix = "Amazon_CloudWatch_Journal_"
+	LogType            = "log_type"
 )
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"strconv"
+)
+
+// Number accepts both the integers and the floats of the TOML config file
+type Number struct {
+	Value float64
+}
+
+// UnmarshalTOML parses the number from the TOML config file
+func (n *Number) UnmarshalTOML(b []byte) error {
+	value, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return err
+	}
+	n.Value = value
+	return nil
+}
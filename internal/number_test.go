@@ -0,0 +1,21 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberUnmarshalTOML(t *testing.T) {
+	var v struct {
+		Integers []Number
+		Floats   []Number
+	}
+	assert.NoError(t, toml.Unmarshal([]byte("integers = [50, 90]\nfloats = [99.9]\n"), &v))
+	assert.Equal(t, []Number{{Value: 50}, {Value: 90}}, v.Integers)
+	assert.Equal(t, []Number{{Value: 99.9}}, v.Floats)
+}
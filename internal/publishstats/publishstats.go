@@ -0,0 +1,233 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package publishstats collects the statistics of the batches published by the outputs for every target, e.g. a log
+// stream, so the status of the agent can show how full the batches are, how well they compress and how long they take
+// to flush. They help tuning the force flush interval of the targets. The timestamp of the newest event accepted by a
+// target is the lag of its delivery the log_delivery input reports.
+package publishstats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is the number of the latest flush latencies the percentile is computed from
+const latencySamples = 1000
+
+// Stats are the statistics of the batches published to a target since the start of the agent
+type Stats struct {
+	mu        sync.Mutex
+	name      string
+	tags      map[string]string
+	first     time.Time
+	last      time.Time
+	batches   int64
+	events    int64
+	rawBytes  int64
+	sentBytes int64
+	// payloadBytes are the sizes of the payloads before they are compressed into the sent bytes
+	payloadBytes int64
+	latencies    []time.Duration
+	next         int
+	// newestEvent is the timestamp of the newest event of the accepted batches
+	newestEvent time.Time
+	// clockSkew is how far the clock of the host is behind the one of the target, 0 unless it is persistent
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// Lag is how far behind the delivery to a target is
+type Lag struct {
+	Target string
+	Tags   map[string]string
+	// IngestionLag is the time elapsed since the timestamp of the newest event accepted by the target
+	IngestionLag time.Duration
+	Events       int64
+}
+
+// Summary is how the statistics of a target are reported
+type Summary struct {
+	Target           string  `json:"target"`
+	Batches          int64   `json:"batches"`
+	Events           int64   `json:"events"`
+	AvgBatchEvents   float64 `json:"avg_batch_events"`
+	AvgBatchBytes    float64 `json:"avg_batch_bytes"`
+	BytesPerSecond   float64 `json:"bytes_per_second"`
+	CompressionRatio float64 `json:"compression_ratio,omitempty"`
+	P99FlushLatency  string  `json:"p99_flush_latency"`
+	IngestionLag     string  `json:"ingestion_lag,omitempty"`
+	ClockSkew        string  `json:"clock_skew,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	targets = map[string]*Stats{}
+)
+
+// Get returns the statistics of the target, they are kept across the reloads of the config
+func Get(name string) *Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	s, ok := targets[name]
+	if !ok {
+		s = &Stats{name: name, now: time.Now}
+		targets[name] = s
+	}
+	return s
+}
+
+// SetTags sets the tags the lag of the target is reported with, e.g. the log group and the log stream
+func (s *Stats) SetTags(tags map[string]string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags = tags
+}
+
+// Batch records a batch published to the target in the latency, from the first attempt to its acceptance
+func (s *Stats) Batch(events int, rawBytes int, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.batches == 0 {
+		s.first = now.Add(-latency)
+	}
+	s.last = now
+	s.batches++
+	s.events += int64(events)
+	s.rawBytes += int64(rawBytes)
+	if len(s.latencies) < latencySamples {
+		s.latencies = append(s.latencies, latency)
+	} else {
+		s.latencies[s.next] = latency
+		s.next = (s.next + 1) % latencySamples
+	}
+}
+
+// Sent records the size of the payload of a batch and the size sent once it is compressed
+func (s *Stats) Sent(payloadBytes int64, sentBytes int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloadBytes += payloadBytes
+	s.sentBytes += sentBytes
+}
+
+// Acknowledged records the timestamp of the newest event of a batch accepted by the target
+func (s *Stats) Acknowledged(newestEvent time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if newestEvent.After(s.newestEvent) {
+		s.newestEvent = newestEvent
+	}
+}
+
+// ClockSkew records how far the clock of the host is behind the one of the target, negative when it is ahead, as
+// estimated from the responses of the target
+func (s *Stats) ClockSkew(skew time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockSkew = skew
+}
+
+// Lag returns the lag of the delivery, false until an event is accepted by the target
+func (s *Stats) Lag() (Lag, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.newestEvent.IsZero() {
+		return Lag{}, false
+	}
+	return Lag{Target: s.name, Tags: s.tags, IngestionLag: s.now().Sub(s.newestEvent), Events: s.events}, true
+}
+
+// Summary reports the statistics, the compression ratio is the size of the payloads divided by the size sent
+func (s *Stats) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := Summary{Target: s.name, Batches: s.batches, Events: s.events}
+	if s.clockSkew != 0 {
+		summary.ClockSkew = s.clockSkew.String()
+	}
+	if s.batches == 0 {
+		return summary
+	}
+	summary.AvgBatchEvents = round(float64(s.events) / float64(s.batches))
+	summary.AvgBatchBytes = round(float64(s.rawBytes) / float64(s.batches))
+	if elapsed := s.last.Sub(s.first).Seconds(); elapsed > 0 {
+		summary.BytesPerSecond = round(float64(s.rawBytes) / elapsed)
+	}
+	if s.sentBytes > 0 {
+		summary.CompressionRatio = round(float64(s.payloadBytes) / float64(s.sentBytes))
+	}
+	summary.P99FlushLatency = percentile(s.latencies, 0.99).String()
+	if !s.newestEvent.IsZero() {
+		summary.IngestionLag = s.now().Sub(s.newestEvent).Round(time.Millisecond).String()
+	}
+	return summary
+}
+
+// Report returns the summaries of all the targets sorted by their names
+func Report() []Summary {
+	all := all()
+	summaries := make([]Summary, 0, len(all))
+	for _, s := range all {
+		summaries = append(summaries, s.Summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Target < summaries[j].Target })
+	return summaries
+}
+
+// Lags returns the lags of the targets which accepted an event, sorted by their names
+func Lags() []Lag {
+	var lags []Lag
+	for _, s := range all() {
+		if lag, ok := s.Lag(); ok {
+			lags = append(lags, lag)
+		}
+	}
+	sort.Slice(lags, func(i, j int) bool { return lags[i].Target < lags[j].Target })
+	return lags
+}
+
+func all() []*Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	all := make([]*Stats, 0, len(targets))
+	for _, s := range targets {
+		all = append(all, s)
+	}
+	return all
+}
+
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
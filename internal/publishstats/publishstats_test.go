@@ -0,0 +1,85 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package publishstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	s := Get("logs.G/S")
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	assert.Same(t, s, Get("logs.G/S"))
+	assert.Equal(t, Summary{Target: "logs.G/S"}, s.Summary())
+
+	s.Batch(10, 1000, time.Second)
+	now = now.Add(9 * time.Second)
+	s.Batch(30, 3000, 100*time.Millisecond)
+	s.Sent(5000, 1000)
+	assert.Equal(t, Summary{
+		Target:           "logs.G/S",
+		Batches:          2,
+		Events:           40,
+		AvgBatchEvents:   20,
+		AvgBatchBytes:    2000,
+		BytesPerSecond:   400,
+		CompressionRatio: 5,
+		P99FlushLatency:  "1s",
+	}, s.Summary())
+
+	var missing *Stats
+	missing.Batch(1, 1, time.Second)
+	missing.Sent(1, 1)
+
+	Get("logs.A/S")
+	report := Report()
+	assert.Equal(t, "logs.A/S", report[0].Target)
+	assert.Equal(t, "logs.G/S", report[1].Target)
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 200; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 198*time.Millisecond, percentile(latencies, 0.99))
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.99))
+}
+
+func TestLag(t *testing.T) {
+	s := Get("logs.LagG/S")
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	_, ok := s.Lag()
+	assert.False(t, ok)
+	for _, lag := range Lags() {
+		assert.NotEqual(t, "logs.LagG/S", lag.Target)
+	}
+
+	s.SetTags(map[string]string{"log_group_name": "LagG", "log_stream_name": "S"})
+	s.Batch(2, 100, time.Second)
+	s.Acknowledged(now.Add(-30 * time.Second))
+	// an older batch accepted later, e.g. a retry, does not lower the newest event
+	s.Acknowledged(now.Add(-time.Hour))
+	s.Acknowledged(time.Time{})
+	now = now.Add(5 * time.Second)
+	lag, ok := s.Lag()
+	assert.True(t, ok)
+	assert.Equal(t, Lag{
+		Target:       "logs.LagG/S",
+		Tags:         map[string]string{"log_group_name": "LagG", "log_stream_name": "S"},
+		IngestionLag: 35 * time.Second,
+		Events:       2,
+	}, lag)
+	assert.Equal(t, "35s", s.Summary().IngestionLag)
+	assert.Contains(t, Lags(), lag)
+
+	var missing *Stats
+	missing.SetTags(nil)
+	missing.Acknowledged(now)
+}
@@ -0,0 +1,163 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package secrets resolves the credentials the plugins of the agent keep in Secrets Manager, e.g. the passwords of
+// the databases, so they are not written in the config of the agent.
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+const getSecretValueAction = "secretsmanager:GetSecretValue"
+
+// Shared is the cache of the plugins of the agent, the secrets are fetched again after 15 minutes so that their
+// rotations are picked up
+var Shared = NewCache(15 * time.Minute)
+
+// Credentials are the user name and the password of a secret. The secrets of the databases managed by RDS and the
+// ones rotated by the functions of Secrets Manager are JSON objects with "username" and "password" keys, any other
+// secret string is the password itself.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Reference is the secret of the credentials of a plugin, fetched with the credentials of the agent. It is embedded
+// in the config of the plugins.
+type Reference struct {
+	// SecretID is the ARN or the name of the secret, no secret is used when it is empty
+	SecretID  string `toml:"secret_id"`
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	RoleARN   string `toml:"role_arn"`
+	Profile   string `toml:"profile"`
+	Filename  string `toml:"shared_credential_file"`
+	Token     string `toml:"token"`
+
+	svc secretsmanageriface.SecretsManagerAPI
+}
+
+// CredentialConfig returns the credentials of the agent the secret is fetched with, which the plugins also use for
+// their other calls to AWS, e.g. the IAM authentication of MSK
+func (r *Reference) CredentialConfig() *internalaws.CredentialConfig {
+	return &internalaws.CredentialConfig{
+		Region:    r.Region,
+		AccessKey: r.AccessKey,
+		SecretKey: r.SecretKey,
+		RoleARN:   r.RoleARN,
+		Profile:   r.Profile,
+		Filename:  r.Filename,
+		Token:     r.Token,
+	}
+}
+
+// Credentials returns the credentials of the secret from the shared cache, false when the plugin has no secret
+func (r *Reference) Credentials() (Credentials, bool, error) {
+	if r.SecretID == "" {
+		return Credentials{}, false, nil
+	}
+	if r.svc == nil {
+		r.svc = secretsmanager.New(r.CredentialConfig().Credentials())
+	}
+	credentials, err := Shared.Get(r.svc, r.SecretID)
+	return credentials, true, err
+}
+
+// PreflightIAM checks that the secret can be fetched
+func (r *Reference) PreflightIAM() []preflight.Check {
+	if r.SecretID == "" {
+		return nil
+	}
+	var resources preflight.ResourceARNs
+	if strings.HasPrefix(r.SecretID, "arn:") {
+		resources = preflight.StaticARNs(r.SecretID)
+	}
+	return preflight.CheckIAMActionsOn(r.CredentialConfig().Credentials(), []string{getSecretValueAction}, resources)
+}
+
+type entry struct {
+	credentials Credentials
+	fetched     time.Time
+	sync.Mutex
+}
+
+// Cache caches the credentials of the secrets by secret ID
+type Cache struct {
+	maxAge  time.Duration
+	entries map[string]*entry
+	sync.Mutex
+}
+
+func NewCache(maxAge time.Duration) *Cache {
+	return &Cache{maxAge: maxAge, entries: map[string]*entry{}}
+}
+
+// Get returns the credentials of the secret, the cached ones when they were fetched within the max age of the
+// cache. The last credentials are kept when fetching them again fails, e.g. when the call is throttled, so that the
+// plugins keep collecting until the secret is rotated.
+func (c *Cache) Get(svc secretsmanageriface.SecretsManagerAPI, secretID string) (Credentials, error) {
+	c.Lock()
+	e, ok := c.entries[secretID]
+	if !ok {
+		e = &entry{}
+		c.entries[secretID] = e
+	}
+	c.Unlock()
+
+	e.Lock()
+	defer e.Unlock()
+	if e.fetched.IsZero() || time.Since(e.fetched) >= c.maxAge {
+		credentials, err := fetch(svc, secretID)
+		if err != nil {
+			if e.fetched.IsZero() {
+				return Credentials{}, err
+			}
+			log.Printf("W! secrets: unable to fetch the secret %v again, its last value is used: %v", secretID, err)
+			return e.credentials, nil
+		}
+		e.credentials, e.fetched = credentials, time.Now()
+	}
+	return e.credentials, nil
+}
+
+func fetch(svc secretsmanageriface.SecretsManagerAPI, secretID string) (Credentials, error) {
+	output, err := svc.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return Credentials{}, fmt.Errorf("unable to get the secret %v: %v", secretID, err)
+	}
+	if output.SecretString == nil {
+		return Credentials{}, fmt.Errorf("the secret %v has no secret string, binary secrets are not supported", secretID)
+	}
+	credentials, err := parse(*output.SecretString)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("invalid secret %v: %v", secretID, err)
+	}
+	return credentials, nil
+}
+
+func parse(secret string) (Credentials, error) {
+	var fields map[string]interface{}
+	if json.Unmarshal([]byte(secret), &fields) != nil {
+		return Credentials{Password: secret}, nil
+	}
+	password, ok := fields["password"].(string)
+	if !ok {
+		return Credentials{}, errors.New("the JSON secret has no password key")
+	}
+	username, _ := fields["username"].(string)
+	return Credentials{Username: username, Password: password}, nil
+}
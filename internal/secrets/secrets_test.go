@@ -0,0 +1,90 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]string
+	err     error
+	calls   int
+}
+
+func (m *mockSecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	secret, ok := m.secrets[aws.StringValue(input.SecretId)]
+	if !ok {
+		return &secretsmanager.GetSecretValueOutput{SecretBinary: []byte{1}}, nil
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(secret)}, nil
+}
+
+func TestParse(t *testing.T) {
+	credentials, err := parse(`{"username":"monitor","password":"p@ss","engine":"mysql","port":3306}`)
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "monitor", Password: "p@ss"}, credentials)
+
+	credentials, err = parse("plain password")
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{Password: "plain password"}, credentials)
+
+	_, err = parse(`{"username":"monitor"}`)
+	assert.Error(t, err)
+}
+
+func TestCacheGet(t *testing.T) {
+	svc := &mockSecretsManager{secrets: map[string]string{"db": `{"username":"monitor","password":"first"}`}}
+	c := NewCache(time.Hour)
+	credentials, err := c.Get(svc, "db")
+	require.NoError(t, err)
+	assert.Equal(t, "first", credentials.Password)
+
+	// the cached credentials are returned until the max age
+	svc.secrets["db"] = `{"username":"monitor","password":"rotated"}`
+	credentials, err = c.Get(svc, "db")
+	require.NoError(t, err)
+	assert.Equal(t, "first", credentials.Password)
+	assert.Equal(t, 1, svc.calls)
+
+	c.entries["db"].fetched = time.Now().Add(-2 * time.Hour)
+	credentials, err = c.Get(svc, "db")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", credentials.Password)
+
+	// the last credentials are kept when fetching them again fails
+	c.entries["db"].fetched = time.Now().Add(-2 * time.Hour)
+	svc.err = errors.New("throttled")
+	credentials, err = c.Get(svc, "db")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", credentials.Password)
+
+	_, err = c.Get(svc, "other")
+	assert.Error(t, err)
+
+	svc.err = nil
+	_, err = c.Get(svc, "binary")
+	assert.Error(t, err)
+}
+
+func TestReferenceWithoutSecret(t *testing.T) {
+	r := &Reference{}
+	_, ok, err := r.Credentials()
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.Empty(t, r.PreflightIAM())
+}
@@ -0,0 +1,206 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// the staged and the replaced files are kept next to the binaries so they are swapped in with renames
+	workDirName     = ".selfupdate"
+	stagingDirName  = "staging"
+	rollbackDirName = "rollback"
+	stateFileName   = "state.json"
+
+	// maxStarts is how many times a pending upgrade is started before it is rolled back, e.g. when the new
+	// version crashes before the end of the health window
+	maxStarts = 3
+)
+
+// state of the self-update, persisted in the work dir
+type state struct {
+	// Pending is set while the upgrade to Version is neither committed nor rolled back
+	Pending         bool      `json:"pending"`
+	Version         string    `json:"version,omitempty"`
+	PreviousVersion string    `json:"previous_version,omitempty"`
+	Files           []string  `json:"files,omitempty"`
+	InstalledAt     time.Time `json:"installed_at,omitempty"`
+	Starts          int       `json:"starts"`
+	// FailedVersions are rolled back, they are not installed again
+	FailedVersions []string `json:"failed_versions,omitempty"`
+}
+
+func (s *state) failed(version string) bool {
+	for _, v := range s.FailedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func workDir(binDir string) string {
+	return filepath.Join(binDir, workDirName)
+}
+
+func loadState(binDir string) (*state, error) {
+	s := &state{}
+	data, err := ioutil.ReadFile(filepath.Join(workDir(binDir), stateFileName))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the state: %v", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("unable to parse the state: %v", err)
+	}
+	return s, nil
+}
+
+// save writes the state into a temporary file renamed into place, so it is never left half written
+func (s *state) save(binDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(workDir(binDir), 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(workDir(binDir), stateFileName)
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("unable to write the state: %v", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// stage downloads the files of the version into the staging dir and checks them against the hashes of the manifest
+func stage(binDir, source, version string, files []File, fetch func(string) ([]byte, error)) error {
+	dir := filepath.Join(workDir(binDir), stagingDirName)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		data, err := fetch(join(source, version, platform, f.Name))
+		if err != nil {
+			return fmt.Errorf("unable to download %v of version %v: %v", f.Name, version, err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return fmt.Errorf("the sha256 of %v of version %v does not match the manifest", f.Name, version)
+		}
+		mode := os.FileMode(0755)
+		if info, err := os.Stat(filepath.Join(binDir, f.Name)); err == nil {
+			mode = info.Mode()
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, f.Name), data, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// install swaps the staged files in, the replaced ones are moved into the rollback dir. The files already swapped
+// are restored when one of the renames fails.
+func install(binDir, previousVersion, version string, files []File) error {
+	staging := filepath.Join(workDir(binDir), stagingDirName)
+	rollbackDir := filepath.Join(workDir(binDir), rollbackDirName)
+	if err := os.RemoveAll(rollbackDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rollbackDir, 0755); err != nil {
+		return err
+	}
+
+	s, err := loadState(binDir)
+	if err != nil {
+		return err
+	}
+	s.Pending, s.Version, s.PreviousVersion, s.InstalledAt, s.Starts = true, version, previousVersion, time.Now(), 0
+	s.Files = nil
+	for _, f := range files {
+		s.Files = append(s.Files, f.Name)
+	}
+	// the state is saved first so an interrupted install is rolled back by the launcher
+	if err := s.save(binDir); err != nil {
+		return err
+	}
+
+	for i, f := range files {
+		target := filepath.Join(binDir, f.Name)
+		installed := i
+		err := os.Rename(target, filepath.Join(rollbackDir, f.Name))
+		if err == nil || os.IsNotExist(err) {
+			// the file is restored from the rollback dir, or removed when it is new, from now on
+			installed = i + 1
+			err = os.Rename(filepath.Join(staging, f.Name), target)
+		}
+		if err != nil {
+			s.Files = s.Files[:installed]
+			if rollbackErr := rollback(binDir, s); rollbackErr != nil {
+				log.Printf("E! selfupdate: %v", rollbackErr)
+			}
+			return fmt.Errorf("unable to install %v of version %v: %v", f.Name, version, err)
+		}
+	}
+	return os.RemoveAll(staging)
+}
+
+// commit discards the previous version once the upgrade is healthy
+func commit(binDir string, s *state) error {
+	s.Pending, s.Starts = false, 0
+	if err := s.save(binDir); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(workDir(binDir), rollbackDirName))
+}
+
+// rollback restores the files replaced by the upgrade, the files the previous version did not have are removed
+func rollback(binDir string, s *state) error {
+	rollbackDir := filepath.Join(workDir(binDir), rollbackDirName)
+	for _, name := range s.Files {
+		target := filepath.Join(binDir, name)
+		err := os.Rename(filepath.Join(rollbackDir, name), target)
+		if os.IsNotExist(err) {
+			err = os.Remove(target)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("unable to roll back %v of version %v: %v", name, s.Version, err)
+		}
+	}
+	s.Pending, s.Starts = false, 0
+	if !s.failed(s.Version) {
+		s.FailedVersions = append(s.FailedVersions, s.Version)
+	}
+	return s.save(binDir)
+}
+
+// Recover is called by the launcher before every start of the agent installed in binDir. It counts the starts of
+// a pending upgrade and rolls it back once the new version was started maxStarts times without being committed.
+func Recover(binDir string) error {
+	s, err := loadState(binDir)
+	if err != nil || !s.Pending {
+		return err
+	}
+	if s.Starts++; s.Starts <= maxStarts {
+		return s.save(binDir)
+	}
+	log.Printf("E! selfupdate: version %v was started %d times without becoming healthy, rolling back to version %v", s.Version, maxStarts, s.PreviousVersion)
+	return rollback(binDir, s)
+}
@@ -0,0 +1,294 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package selfupdate upgrades the agent in place from signed packages published to an S3 or HTTPS location.
+//
+// The location holds manifest.json, signed with ed25519 in manifest.json.sig, and the files of each version under
+// <version>/<GOOS>_<GOARCH>/<name>. The files are staged next to the binaries, checked against the hashes of the
+// manifest and swapped in with renames, the replaced files are kept so the upgrade can be rolled back. The agent
+// then exits for the service manager to start the new version. The config and the state files are not touched.
+//
+// Only versions newer than the running version are installed, and the manifest expires, so a manifest signed for
+// an older version cannot be replayed to downgrade the agent to a version with known issues.
+//
+// The launcher, i.e. start-amazon-cloudwatch-agent, is never replaced so it can always roll back: it counts the
+// starts of the new version with Recover, and restores the previous files if the new version keeps failing before
+// it has run healthy for the health window.
+package selfupdate
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+)
+
+const (
+	// launcherName is the binary that rolls back the upgrades, the manifests cannot replace it
+	launcherName = "start-amazon-cloudwatch-agent"
+
+	manifestName    = "manifest.json"
+	signatureSuffix = ".sig"
+
+	defaultCheckInterval = time.Hour
+	defaultHealthWindow  = 10 * time.Minute
+
+	// RestartExitCode is the exit code of the agent once the new version is installed, it is restarted by the
+	// service manager like after any other failure
+	RestartExitCode = 3
+)
+
+// Config of the self-update, it is set by the translator in the env config
+type Config struct {
+	// Source is the location of the manifest, s3://bucket/prefix, https://host/path or file:///path
+	Source string
+	// PublicKey verifies the signature of the manifest
+	PublicKey ed25519.PublicKey
+	// CheckInterval is how often the manifest is checked for a new version
+	CheckInterval time.Duration
+	// HealthWindow is how long a new version must run healthy before the previous version is discarded
+	HealthWindow time.Duration
+}
+
+// ConfigFromEnv returns the self-update config from the env config, ok is false when the self-update is disabled
+func ConfigFromEnv() (cfg Config, ok bool, err error) {
+	cfg.Source = os.Getenv(envconfig.CWAGENT_SELF_UPDATE_SOURCE)
+	if cfg.Source == "" {
+		return cfg, false, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(os.Getenv(envconfig.CWAGENT_SELF_UPDATE_PUBLIC_KEY))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return cfg, false, fmt.Errorf("invalid %s, expecting a base64 encoded ed25519 public key", envconfig.CWAGENT_SELF_UPDATE_PUBLIC_KEY)
+	}
+	cfg.PublicKey = key
+	if cfg.CheckInterval, err = durationFromEnv(envconfig.CWAGENT_SELF_UPDATE_CHECK_INTERVAL, defaultCheckInterval); err != nil {
+		return cfg, false, err
+	}
+	if cfg.HealthWindow, err = durationFromEnv(envconfig.CWAGENT_SELF_UPDATE_HEALTH_WINDOW, defaultHealthWindow); err != nil {
+		return cfg, false, err
+	}
+	return cfg, true, nil
+}
+
+func durationFromEnv(env string, defaultVal time.Duration) (time.Duration, error) {
+	v := os.Getenv(env)
+	if v == "" {
+		return defaultVal, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", env, v)
+	}
+	return d, nil
+}
+
+// File of a version of the agent
+type File struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes the version of the agent to run
+type Manifest struct {
+	Version string `json:"version"`
+	// Expires is when the manifest stops being valid, it is required and must be renewed by signing the manifest
+	// again before it expires
+	Expires time.Time `json:"expires"`
+	// RolloutPercent is the share of the agents upgraded to the version, it is raised over time to stage the rollout.
+	// Every agent is upgraded when it is not set.
+	RolloutPercent *int `json:"rollout_percent,omitempty"`
+	// Files of the version by platform, e.g. linux_amd64
+	Files map[string][]File `json:"files"`
+}
+
+func parseManifest(data, signature []byte, key ed25519.PublicKey, now time.Time) (*Manifest, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the signature of the manifest: %v", err)
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return nil, errors.New("the signature of the manifest is not valid")
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unable to parse the manifest: %v", err)
+	}
+	if m.Version == "" {
+		return nil, errors.New("the manifest has no version")
+	}
+	if _, err := parseVersion(m.Version); err != nil {
+		return nil, fmt.Errorf("invalid version in the manifest: %v", err)
+	}
+	for _, files := range m.Files {
+		for _, f := range files {
+			if f.Name == "" || strings.ContainsAny(f.Name, `/\`) || f.Name == "." || f.Name == ".." {
+				return nil, fmt.Errorf("invalid file name %q in the manifest", f.Name)
+			}
+			if strings.TrimSuffix(strings.ToLower(f.Name), ".exe") == launcherName {
+				return nil, fmt.Errorf("the manifest cannot replace the launcher %v", f.Name)
+			}
+		}
+	}
+	if m.Expires.IsZero() {
+		return nil, errors.New("the manifest has no expiry")
+	}
+	if now.After(m.Expires) {
+		return nil, fmt.Errorf("the manifest expired at %v", m.Expires.Format(time.RFC3339))
+	}
+	return &m, nil
+}
+
+// inRollout returns whether the host is part of the rollout of the manifest. The hosts are bucketed by the hash
+// of their name and the version, so each version is rolled out to a different subset of the hosts first.
+func (m *Manifest) inRollout(host string) bool {
+	if m.RolloutPercent == nil {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(host + "/" + m.Version))
+	return int(h.Sum32()%100) < *m.RolloutPercent
+}
+
+var (
+	startOnce  sync.Once
+	restarting = make(chan struct{})
+)
+
+// Restarting returns the channel closed once a new version is installed, the agent must then stop and exit with
+// RestartExitCode
+func Restarting() <-chan struct{} {
+	return restarting
+}
+
+// Start runs the self-update of the agent installed in binDir for the lifetime of the process. The pending upgrade
+// to the running version is committed once healthy returns true at the end of the health window.
+func Start(cfg Config, binDir, version string, healthy func() bool) {
+	startOnce.Do(func() {
+		u := &updater{cfg: cfg, binDir: binDir, version: version, healthy: healthy, fetch: fetch,
+			restart: func() { close(restarting) }}
+		go u.run()
+	})
+}
+
+type updater struct {
+	cfg     Config
+	binDir  string
+	version string
+	healthy func() bool
+	fetch   func(location string) ([]byte, error)
+	restart func()
+}
+
+func (u *updater) run() {
+	log.Printf("I! selfupdate: checking %v for a new version every %v", u.cfg.Source, u.cfg.CheckInterval)
+	if u.commitWhenHealthy() {
+		return
+	}
+	for {
+		restart, err := u.check()
+		if err != nil {
+			log.Printf("E! selfupdate: %v", err)
+		}
+		if restart {
+			u.restart()
+			return
+		}
+		time.Sleep(u.cfg.CheckInterval)
+	}
+}
+
+// commitWhenHealthy waits for the health window when the running version is a pending upgrade, and commits it
+// when the agent is healthy. It is rolled back otherwise, and the agent restarted into the previous version.
+func (u *updater) commitWhenHealthy() (restarted bool) {
+	s, err := loadState(u.binDir)
+	if err != nil {
+		log.Printf("E! selfupdate: %v", err)
+		return false
+	}
+	if !s.Pending || s.Version != u.version {
+		return false
+	}
+	log.Printf("I! selfupdate: version %v is checked for %v before discarding version %v", s.Version, u.cfg.HealthWindow, s.PreviousVersion)
+	time.Sleep(u.cfg.HealthWindow)
+	if u.healthy != nil && !u.healthy() {
+		log.Printf("E! selfupdate: version %v is not healthy after %v, rolling back to version %v", s.Version, u.cfg.HealthWindow, s.PreviousVersion)
+		if err := rollback(u.binDir, s); err != nil {
+			log.Printf("E! selfupdate: %v", err)
+			return false
+		}
+		u.restart()
+		return true
+	}
+	if err := commit(u.binDir, s); err != nil {
+		log.Printf("E! selfupdate: %v", err)
+		return false
+	}
+	log.Printf("I! selfupdate: version %v is healthy, the upgrade is committed", s.Version)
+	return false
+}
+
+// check installs the version of the manifest when it is rolled out to the host, restart is true once installed
+func (u *updater) check() (restart bool, err error) {
+	s, err := loadState(u.binDir)
+	if err != nil {
+		return false, err
+	}
+	if s.Pending {
+		// the upgrade is neither committed nor rolled back yet, unless the commit at the end of the health window
+		// failed, it is then retried so the agent keeps checking for the next version
+		if s.Version != u.version || (u.healthy != nil && !u.healthy()) {
+			return false, nil
+		}
+		if err := commit(u.binDir, s); err != nil {
+			return false, err
+		}
+		log.Printf("I! selfupdate: version %v is healthy, the upgrade is committed", s.Version)
+	}
+	data, err := u.fetch(join(u.cfg.Source, manifestName))
+	if err != nil {
+		return false, fmt.Errorf("unable to get the manifest: %v", err)
+	}
+	sig, err := u.fetch(join(u.cfg.Source, manifestName+signatureSuffix))
+	if err != nil {
+		return false, fmt.Errorf("unable to get the signature of the manifest: %v", err)
+	}
+	m, err := parseManifest(data, sig, u.cfg.PublicKey, time.Now())
+	if err != nil {
+		return false, err
+	}
+	newer, err := newerVersion(m.Version, u.version)
+	if err != nil {
+		return false, err
+	}
+	if !newer || s.failed(m.Version) {
+		return false, nil
+	}
+	host, _ := os.Hostname()
+	if !m.inRollout(host) {
+		log.Printf("D! selfupdate: version %v is not rolled out to this host yet", m.Version)
+		return false, nil
+	}
+	files, ok := m.Files[platform]
+	if !ok || len(files) == 0 {
+		return false, fmt.Errorf("version %v has no files for %v", m.Version, platform)
+	}
+
+	log.Printf("I! selfupdate: upgrading from version %v to version %v", u.version, m.Version)
+	if err := stage(u.binDir, u.cfg.Source, m.Version, files, u.fetch); err != nil {
+		return false, err
+	}
+	if err := install(u.binDir, u.version, m.Version, files); err != nil {
+		return false, err
+	}
+	log.Printf("I! selfupdate: version %v is installed, restarting the agent", m.Version)
+	return true, nil
+}
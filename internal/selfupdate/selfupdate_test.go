@@ -0,0 +1,279 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package selfupdate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const source = "s3://bucket/cwagent"
+
+type testSource struct {
+	t       *testing.T
+	key     ed25519.PrivateKey
+	objects map[string][]byte
+}
+
+func newTestSource(t *testing.T) (*testSource, ed25519.PublicKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &testSource{t: t, key: priv, objects: map[string][]byte{}}, pub
+}
+
+func (s *testSource) fetch(location string) ([]byte, error) {
+	if data, ok := s.objects[location]; ok {
+		return data, nil
+	}
+	return nil, errors.New("NoSuchKey")
+}
+
+// publish uploads the files of the version and its signed manifest
+func (s *testSource) publish(m Manifest, files map[string]string) {
+	if m.Expires.IsZero() {
+		m.Expires = time.Now().Add(time.Hour)
+	}
+	for name, content := range files {
+		s.objects[join(source, m.Version, platform, name)] = []byte(content)
+		sum := sha256.Sum256([]byte(content))
+		m.Files = map[string][]File{platform: append(m.Files[platform], File{Name: name, SHA256: hex.EncodeToString(sum[:])})}
+	}
+	data, err := json.Marshal(m)
+	require.NoError(s.t, err)
+	s.objects[join(source, manifestName)] = data
+	s.objects[join(source, manifestName+signatureSuffix)] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data)))
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func newTestUpdater(t *testing.T, version string) (*updater, *testSource, *int) {
+	binDir, err := ioutil.TempDir("", "selfupdate")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "amazon-cloudwatch-agent"), []byte("agent 1.0"), 0755))
+
+	src, pub := newTestSource(t)
+	restarts := 0
+	u := &updater{
+		cfg:     Config{Source: source, PublicKey: pub},
+		binDir:  binDir,
+		version: version,
+		fetch:   src.fetch,
+		restart: func() { restarts++ },
+	}
+	return u, src, &restarts
+}
+
+func TestParseManifest(t *testing.T) {
+	src, pub := newTestSource(t)
+	src.publish(Manifest{Version: "2.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 2.0"})
+	data, sig := src.objects[join(source, manifestName)], src.objects[join(source, manifestName+signatureSuffix)]
+
+	m, err := parseManifest(data, sig, pub, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", m.Version)
+	assert.Len(t, m.Files[platform], 1)
+
+	_, err = parseManifest(append(data, ' '), sig, pub, time.Now())
+	assert.EqualError(t, err, "the signature of the manifest is not valid")
+
+	data = []byte(`{"version": "2.0", "files": {"linux_amd64": [{"name": "../amazon-cloudwatch-agent"}]}}`)
+	_, err = parseManifest(data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(src.key, data))), pub, time.Now())
+	assert.EqualError(t, err, `invalid file name "../amazon-cloudwatch-agent" in the manifest`)
+
+	data = []byte(`{"version": "2.0", "files": {"windows_amd64": [{"name": "Start-Amazon-CloudWatch-Agent.exe"}]}}`)
+	_, err = parseManifest(data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(src.key, data))), pub, time.Now())
+	assert.EqualError(t, err, "the manifest cannot replace the launcher Start-Amazon-CloudWatch-Agent.exe")
+
+	data = []byte(`{"version": "2.0"}`)
+	_, err = parseManifest(data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(src.key, data))), pub, time.Now())
+	assert.EqualError(t, err, "the manifest has no expiry")
+
+	data = []byte(`{"version": "2.0", "expires": "2020-01-01T00:00:00Z"}`)
+	_, err = parseManifest(data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(src.key, data))), pub, time.Now())
+	assert.EqualError(t, err, "the manifest expired at 2020-01-01T00:00:00Z")
+
+	data = []byte(`{"version": "latest", "expires": "2020-01-01T00:00:00Z"}`)
+	_, err = parseManifest(data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(src.key, data))), pub, time.Now())
+	assert.Error(t, err)
+}
+
+func TestOlderManifestRejected(t *testing.T) {
+	u, src, restarts := newTestUpdater(t, "1.247350.0")
+	defer os.RemoveAll(u.binDir)
+
+	// a manifest signed for an older version is replayed
+	src.publish(Manifest{Version: "1.247349.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 1.247349.0"})
+	restart, err := u.check()
+	require.NoError(t, err)
+	assert.False(t, restart)
+	assert.Equal(t, "agent 1.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+	assert.Equal(t, 0, *restarts)
+
+	src.publish(Manifest{Version: "1.247350.0-beta.1"}, map[string]string{"amazon-cloudwatch-agent": "agent beta"})
+	restart, err = u.check()
+	require.NoError(t, err)
+	assert.False(t, restart)
+
+	// the expired manifest of a newer version is not installed either
+	src.publish(Manifest{Version: "1.247351.0", Expires: time.Now().Add(-time.Minute)}, map[string]string{"amazon-cloudwatch-agent": "agent 1.247351.0"})
+	restart, err = u.check()
+	assert.Error(t, err)
+	assert.False(t, restart)
+	assert.Equal(t, "agent 1.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+}
+
+func TestInRollout(t *testing.T) {
+	none, all := 0, 100
+	assert.True(t, (&Manifest{Version: "2.0"}).inRollout("host"))
+	assert.False(t, (&Manifest{Version: "2.0", RolloutPercent: &none}).inRollout("host"))
+	assert.True(t, (&Manifest{Version: "2.0", RolloutPercent: &all}).inRollout("host"))
+
+	half, rolledOut := 50, 0
+	for i := 0; i < 1000; i++ {
+		if (&Manifest{Version: "2.0", RolloutPercent: &half}).inRollout(string(rune('a'+i%26)) + string(rune(i))) {
+			rolledOut++
+		}
+	}
+	assert.InDelta(t, 500, rolledOut, 100)
+}
+
+func TestUpgradeAndCommit(t *testing.T) {
+	u, src, restarts := newTestUpdater(t, "1.0")
+	defer os.RemoveAll(u.binDir)
+
+	restart, err := u.check()
+	assert.Error(t, err, "no manifest published")
+	assert.False(t, restart)
+
+	src.publish(Manifest{Version: "2.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 2.0", "config-translator": "translator 2.0"})
+	restart, err = u.check()
+	require.NoError(t, err)
+	assert.True(t, restart)
+	assert.Equal(t, "agent 2.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+	assert.Equal(t, "translator 2.0", readFile(t, filepath.Join(u.binDir, "config-translator")))
+	info, err := os.Stat(filepath.Join(u.binDir, "amazon-cloudwatch-agent"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// the new version is started and runs healthy for the health window
+	require.NoError(t, Recover(u.binDir))
+	u.version = "2.0"
+	u.healthy = func() bool { return true }
+	assert.False(t, u.commitWhenHealthy())
+	assert.Equal(t, 0, *restarts)
+	s, err := loadState(u.binDir)
+	require.NoError(t, err)
+	assert.False(t, s.Pending)
+	_, err = os.Stat(filepath.Join(workDir(u.binDir), rollbackDirName))
+	assert.True(t, os.IsNotExist(err))
+
+	restart, err = u.check()
+	require.NoError(t, err)
+	assert.False(t, restart, "the version is already running")
+}
+
+func TestCommitRetriedAfterFailure(t *testing.T) {
+	u, src, _ := newTestUpdater(t, "1.0")
+	defer os.RemoveAll(u.binDir)
+
+	src.publish(Manifest{Version: "2.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 2.0"})
+	restart, err := u.check()
+	require.NoError(t, err)
+	require.True(t, restart)
+
+	// the state cannot be saved at the end of the health window
+	tmp := filepath.Join(workDir(u.binDir), stateFileName+".tmp")
+	require.NoError(t, os.Mkdir(tmp, 0755))
+	u.version = "2.0"
+	u.healthy = func() bool { return true }
+	assert.False(t, u.commitWhenHealthy())
+	s, err := loadState(u.binDir)
+	require.NoError(t, err)
+	assert.True(t, s.Pending)
+
+	// the commit is retried by the next check, which then installs the next version
+	require.NoError(t, os.Remove(tmp))
+	src.objects = map[string][]byte{}
+	src.publish(Manifest{Version: "3.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 3.0"})
+	restart, err = u.check()
+	require.NoError(t, err)
+	assert.True(t, restart)
+	assert.Equal(t, "agent 3.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+	s, err = loadState(u.binDir)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", s.PreviousVersion)
+}
+
+func TestRollbackAfterFailedStarts(t *testing.T) {
+	u, src, _ := newTestUpdater(t, "1.0")
+	defer os.RemoveAll(u.binDir)
+
+	src.publish(Manifest{Version: "2.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 2.0", "config-translator": "translator 2.0"})
+	restart, err := u.check()
+	require.NoError(t, err)
+	require.True(t, restart)
+
+	// the new version crashes before the end of the health window
+	for i := 0; i < maxStarts; i++ {
+		require.NoError(t, Recover(u.binDir))
+		assert.Equal(t, "agent 2.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+	}
+	require.NoError(t, Recover(u.binDir))
+	assert.Equal(t, "agent 1.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+	_, err = os.Stat(filepath.Join(u.binDir, "config-translator"))
+	assert.True(t, os.IsNotExist(err), "the file the previous version did not have is removed")
+
+	// the failed version is not installed again
+	restart, err = u.check()
+	require.NoError(t, err)
+	assert.False(t, restart)
+}
+
+func TestRollbackWhenNotHealthy(t *testing.T) {
+	u, src, restarts := newTestUpdater(t, "1.0")
+	defer os.RemoveAll(u.binDir)
+
+	src.publish(Manifest{Version: "2.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 2.0"})
+	restart, err := u.check()
+	require.NoError(t, err)
+	require.True(t, restart)
+
+	u.version = "2.0"
+	u.healthy = func() bool { return false }
+	assert.True(t, u.commitWhenHealthy())
+	assert.Equal(t, 1, *restarts)
+	assert.Equal(t, "agent 1.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+	s, err := loadState(u.binDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2.0"}, s.FailedVersions)
+}
+
+func TestStageHashMismatch(t *testing.T) {
+	u, src, _ := newTestUpdater(t, "1.0")
+	defer os.RemoveAll(u.binDir)
+
+	src.publish(Manifest{Version: "2.0"}, map[string]string{"amazon-cloudwatch-agent": "agent 2.0"})
+	src.objects[join(source, "2.0", platform, "amazon-cloudwatch-agent")] = []byte("tampered")
+	restart, err := u.check()
+	assert.EqualError(t, err, "the sha256 of amazon-cloudwatch-agent of version 2.0 does not match the manifest")
+	assert.False(t, restart)
+	assert.Equal(t, "agent 1.0", readFile(t, filepath.Join(u.binDir, "amazon-cloudwatch-agent")))
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+const (
+	fetchTimeout = 5 * time.Minute
+	// the region the bucket region is looked up from
+	bucketRegionHint = "us-east-1"
+)
+
+var platform = runtime.GOOS + "_" + runtime.GOARCH
+
+func join(location string, elem ...string) string {
+	return strings.TrimSuffix(location, "/") + "/" + strings.Join(elem, "/")
+}
+
+// fetch returns the content at the location, an s3://, https:// or file:// URL
+func fetch(location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	switch u.Scheme {
+	case "s3":
+		return fetchS3(ctx, u.Host, strings.TrimPrefix(u.Path, "/"))
+	case "https":
+		return fetchHTTP(ctx, location)
+	case "file":
+		return ioutil.ReadFile(u.Path)
+	}
+	return nil, fmt.Errorf("unsupported location %v, expecting s3://, https:// or file://", location)
+}
+
+func fetchS3(ctx context.Context, bucket, key string) ([]byte, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	region, err := s3manager.GetBucketRegion(ctx, sess, bucket, bucketRegionHint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the region of bucket %v: %v", bucket, err)
+	}
+	out, err := s3.New(sess, aws.NewConfig().WithRegion(region)).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return ioutil.ReadAll(out.Body)
+}
+
+func fetchHTTP(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v returned %v: %s", location, resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package selfupdate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// version of the agent, e.g. 1.247350.0 or 1.247350.0-beta.1, compared as a semantic version. The build metadata
+// after + is ignored, and so is the build suffix of the last number of the released versions, e.g. b251780 in
+// 1.247350.0b251780.
+type version struct {
+	numbers    []uint64
+	prerelease []string
+}
+
+func parseVersion(s string) (version, error) {
+	var v version
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if i == len(s)-1 {
+			return v, fmt.Errorf("empty prerelease in version %q", s)
+		}
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			part = trimBuildSuffix(part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("version %q is not made of numbers separated by dots", s)
+		}
+		v.numbers = append(v.numbers, n)
+	}
+	return v, nil
+}
+
+// trimBuildSuffix returns the number the build suffix, a letter followed by letters and digits, is appended to
+func trimBuildSuffix(part string) string {
+	i := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 || !unicode.IsLetter(rune(part[i])) {
+		return part
+	}
+	for _, r := range part[i:] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return part
+		}
+	}
+	return part[:i]
+}
+
+// compare returns -1, 0 or 1 when v is older than, the same as or newer than o. The missing numbers are 0, and a
+// prerelease is older than its release.
+func (v version) compare(o version) int {
+	for i := 0; i < len(v.numbers) || i < len(o.numbers); i++ {
+		var a, b uint64
+		if i < len(v.numbers) {
+			a = v.numbers[i]
+		}
+		if i < len(o.numbers) {
+			b = o.numbers[i]
+		}
+		if a != b {
+			return compareUint(a, b)
+		}
+	}
+	switch {
+	case len(v.prerelease) == 0 && len(o.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(o.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(o.prerelease); i++ {
+		if c := compareIdentifier(v.prerelease[i], o.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(v.prerelease)), uint64(len(o.prerelease)))
+}
+
+// compareIdentifier compares the identifiers of a prerelease, the numeric ones are compared as numbers and are older
+// than the others
+func compareIdentifier(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return compareUint(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// newerVersion returns whether the version of the manifest is newer than the running version, it fails when either
+// cannot be compared so the agent is never moved to a version it cannot tell is newer
+func newerVersion(manifestVersion, runningVersion string) (bool, error) {
+	m, err := parseVersion(manifestVersion)
+	if err != nil {
+		return false, err
+	}
+	r, err := parseVersion(runningVersion)
+	if err != nil {
+		return false, fmt.Errorf("unable to compare the running version: %v", err)
+	}
+	return m.compare(r) > 0, nil
+}
@@ -0,0 +1,54 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package selfupdate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		manifest, running string
+		newer             bool
+	}{
+		{"2.0", "1.0", true},
+		{"1.0", "1.0", false},
+		{"1.0", "2.0", false},
+		{"1.247350.0", "1.9.0", true},
+		{"1.10", "1.9", true},
+		{"1.0.1", "1.0", true},
+		{"1.0.0", "1", false},
+		{"v1.1", "1.0", true},
+		{"1.0.0+build.2", "1.0.0+build.1", false},
+		{"1.0.0", "1.0.0-rc.1", true},
+		{"1.0.0-rc.1", "1.0.0", false},
+		{"1.0.0-rc.2", "1.0.0-rc.1", true},
+		{"1.0.0-rc.10", "1.0.0-rc.9", true},
+		{"1.0.0-rc", "1.0.0-1", true},
+		{"1.0.0-rc.1", "1.0.0-rc", true},
+		{"1.247351.0", "1.247350.0b251780", true},
+		{"1.247350.0b251781", "1.247350.0b251780", false},
+		{"1.247350.0", "1.247350.0b251780", false},
+		{"1.247349.0b251700", "1.247350.0b251780", false},
+	}
+	for _, test := range tests {
+		newer, err := newerVersion(test.manifest, test.running)
+		require.NoError(t, err)
+		assert.Equal(t, test.newer, newer, "%v is newer than %v", test.manifest, test.running)
+	}
+
+	_, err := newerVersion("latest", "1.0")
+	assert.Error(t, err)
+	_, err = newerVersion("2.0", "Unknown")
+	assert.Error(t, err)
+	_, err = newerVersion("2.0-", "1.0")
+	assert.Error(t, err)
+	_, err = newerVersion("2.0b1.1", "1.0")
+	assert.Error(t, err)
+	_, err = newerVersion("2.0b_1", "1.0")
+	assert.Error(t, err)
+}
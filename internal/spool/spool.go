@@ -0,0 +1,320 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package spool persists records on disk so that they survive a restart of the agent.
+//
+// The records are appended to segment files, each record is framed with its length and its CRC32 so
+// that a record torn by a crash or a full disk is detected and skipped on replay. The total size of the
+// segments is capped, the oldest segment is removed when the cap is exceeded. The records may be compressed
+// with zstd, they are framed with their own magic so the compression can be turned on and off between restarts.
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	segmentSuffix = ".spool"
+	recordMagic   = 0x43574131 // CWA1
+	// zstdRecordMagic frames the records compressed with zstd
+	zstdRecordMagic = 0x43574132 // CWA2
+	headerSize      = 12
+	// segmentsPerSpool is the number of segments the size cap is divided into, it bounds how much is
+	// dropped at once when the cap is exceeded
+	segmentsPerSpool = 8
+	minSegmentSize   = 1024 * 1024
+	// maxRecordSize bounds the allocation for the length read from a corrupt header
+	maxRecordSize = 64 * 1024 * 1024
+)
+
+// ErrClosed is returned when appending to a closed spool
+var ErrClosed = errors.New("spool is closed")
+
+// Spool is safe for concurrent use
+type Spool struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+
+	mu       sync.Mutex
+	closed   bool
+	segments []segment
+	current  *os.File
+	nextSeq  uint64
+	encoder  *zstd.Encoder
+}
+
+type segment struct {
+	seq  uint64
+	size int64
+}
+
+// Open opens the spool in the directory, creating it when missing. The records spooled before are
+// kept until they are replayed.
+func Open(dir string, maxSize int64) (*Spool, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid spool size %v", maxSize)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	segmentSize := maxSize / segmentsPerSpool
+	if segmentSize < minSegmentSize {
+		segmentSize = minSegmentSize
+	}
+	s := &Spool{dir: dir, maxSize: maxSize, segmentSize: segmentSize}
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Spool) scan() error {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(f.Name(), segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, segment{seq: seq, size: f.Size()})
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	return nil
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%v", seq, segmentSuffix))
+}
+
+// EnableZstd compresses the records appended from now on with zstd at the level, from 1 (fastest) to 22 (best
+// compression). The size cap applies to the compressed records.
+func (s *Spool) EnableZstd(level int) error {
+	if level < 1 || level > 22 {
+		return fmt.Errorf("invalid zstd level %v, it must be between 1 and 22", level)
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoder = encoder
+	return nil
+}
+
+// Size returns the total size of the segments
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var size int64
+	for _, seg := range s.segments {
+		size += seg.size
+	}
+	return size
+}
+
+// Append writes the record and syncs it to disk
+func (s *Spool) Append(record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if len(record) > maxRecordSize {
+		return fmt.Errorf("record of %v bytes exceeds the spool size %v", len(record), s.maxSize)
+	}
+	magic := uint32(recordMagic)
+	if s.encoder != nil {
+		record = s.encoder.EncodeAll(record, nil)
+		magic = zstdRecordMagic
+	}
+	size := int64(headerSize + len(record))
+	if size > s.maxSize {
+		return fmt.Errorf("record of %v bytes exceeds the spool size %v", len(record), s.maxSize)
+	}
+	if s.current == nil || s.segments[len(s.segments)-1].size+size > s.segmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf[0:4], magic)
+	binary.BigEndian.PutUint32(buf[4:8], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[8:12], crc32.ChecksumIEEE(record))
+	copy(buf[headerSize:], record)
+	n, err := s.current.Write(buf)
+	s.segments[len(s.segments)-1].size += int64(n)
+	if err == nil {
+		err = s.current.Sync()
+	}
+	if err != nil {
+		// the torn record is skipped on replay, the next record goes to a new segment
+		s.current.Close()
+		s.current = nil
+		return err
+	}
+	s.enforceCap()
+	return nil
+}
+
+func (s *Spool) rotate() error {
+	if s.current != nil {
+		s.current.Close()
+		s.current = nil
+	}
+	seq := s.nextSeq
+	f, err := os.OpenFile(s.path(seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.nextSeq++
+	s.current = f
+	s.segments = append(s.segments, segment{seq: seq})
+	return nil
+}
+
+// enforceCap removes the oldest segments, but never the one being written, until the spool fits in its size
+func (s *Spool) enforceCap() {
+	var size int64
+	for _, seg := range s.segments {
+		size += seg.size
+	}
+	for size > s.maxSize && len(s.segments) > 1 {
+		oldest := s.segments[0]
+		if err := os.Remove(s.path(oldest.seq)); err != nil && !os.IsNotExist(err) {
+			log.Printf("E! spool: unable to remove segment %v: %v", s.path(oldest.seq), err)
+			return
+		}
+		log.Printf("W! spool: %v exceeds %v bytes, dropped its oldest %v bytes", s.dir, s.maxSize, oldest.size)
+		size -= oldest.size
+		s.segments = s.segments[1:]
+	}
+}
+
+// Replay calls fn with the records of the segments written so far, oldest first, and removes each segment once
+// all its records are replayed. The records appended meanwhile go to new segments and are not replayed. The
+// records found corrupt are skipped and counted.
+func (s *Spool) Replay(fn func(record []byte)) (replayed, corrupt int, err error) {
+	s.mu.Lock()
+	if s.current != nil {
+		s.current.Close()
+		s.current = nil
+	}
+	segments := append([]segment(nil), s.segments...)
+	s.mu.Unlock()
+
+	for _, seg := range segments {
+		r, c, err := readSegment(s.path(seg.seq), fn)
+		replayed += r
+		corrupt += c
+		if err != nil && !os.IsNotExist(err) {
+			return replayed, corrupt, err
+		}
+		if err := os.Remove(s.path(seg.seq)); err != nil && !os.IsNotExist(err) {
+			return replayed, corrupt, err
+		}
+		s.mu.Lock()
+		for i := range s.segments {
+			if s.segments[i].seq == seg.seq {
+				s.segments = append(s.segments[:i], s.segments[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}
+	return replayed, corrupt, nil
+}
+
+// readSegment stops at the first corrupt record since the framing of the following ones cannot be trusted, a
+// compressed record which cannot be decompressed is skipped alone since its framing is intact
+func readSegment(path string, fn func(record []byte)) (replayed, corrupt int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	var decoder *zstd.Decoder
+	defer func() {
+		if decoder != nil {
+			decoder.Close()
+		}
+	}()
+	r := bufio.NewReader(f)
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return replayed, corrupt, nil
+			}
+			log.Printf("W! spool: %v ends with a truncated record", path)
+			return replayed, corrupt + 1, nil
+		}
+		magic := binary.BigEndian.Uint32(header[0:4])
+		length := binary.BigEndian.Uint32(header[4:8])
+		if (magic != recordMagic && magic != zstdRecordMagic) || int64(length) > maxRecordSize {
+			log.Printf("W! spool: %v has a corrupt record, skipping the rest of the segment", path)
+			return replayed, corrupt + 1, nil
+		}
+		record := make([]byte, length)
+		if _, err := io.ReadFull(r, record); err != nil {
+			log.Printf("W! spool: %v ends with a truncated record", path)
+			return replayed, corrupt + 1, nil
+		}
+		if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[8:12]) {
+			log.Printf("W! spool: %v has a record with a wrong checksum, skipping the rest of the segment", path)
+			return replayed, corrupt + 1, nil
+		}
+		if magic == zstdRecordMagic {
+			if decoder == nil {
+				if decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxRecordSize)); err != nil {
+					return replayed, corrupt, err
+				}
+			}
+			if record, err = decoder.DecodeAll(record, nil); err != nil {
+				log.Printf("W! spool: %v has a record which cannot be decompressed: %v", path, err)
+				corrupt++
+				continue
+			}
+		}
+		fn(record)
+		replayed++
+	}
+}
+
+// Close syncs and closes the segment being written, the records are kept for the next Open
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current = nil
+	return err
+}
@@ -0,0 +1,190 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package spool
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func replayAll(t *testing.T, s *Spool) ([]string, int) {
+	var records []string
+	_, corrupt, err := s.Replay(func(r []byte) { records = append(records, string(r)) })
+	require.NoError(t, err)
+	return records, corrupt
+}
+
+func TestAppendAndReplayAcrossOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := Open(dir, 1024*1024)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprint("record", i))))
+	}
+	require.NoError(t, s.Close())
+	assert.Equal(t, ErrClosed, s.Append([]byte("late")))
+
+	s, err = Open(dir, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("record3")))
+	records, corrupt := replayAll(t, s)
+	assert.Equal(t, []string{"record0", "record1", "record2", "record3"}, records)
+	assert.Equal(t, 0, corrupt)
+	assert.Equal(t, int64(0), s.Size())
+
+	// replayed records are removed
+	records, _ = replayAll(t, s)
+	assert.Empty(t, records)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestReplaySkipsTornRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := Open(dir, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("first")))
+	require.NoError(t, s.Append([]byte("second")))
+	require.NoError(t, s.Close())
+
+	// cut the last record in half as a crash during the write would
+	path := s.path(0)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	s, err = Open(dir, 1024*1024)
+	require.NoError(t, err)
+	records, corrupt := replayAll(t, s)
+	assert.Equal(t, []string{"first"}, records)
+	assert.Equal(t, 1, corrupt)
+}
+
+func TestReplaySkipsWrongChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := Open(dir, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("first")))
+	require.NoError(t, s.Append([]byte("second")))
+	require.NoError(t, s.Close())
+
+	path := s.path(0)
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	content[len(content)-1] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+	// files which are not segments are ignored
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0600))
+
+	s, err = Open(dir, 1024*1024)
+	require.NoError(t, err)
+	records, corrupt := replayAll(t, s)
+	assert.Equal(t, []string{"first"}, records)
+	assert.Equal(t, 1, corrupt)
+}
+
+func TestSizeCapDropsOldestSegments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	maxSize := int64(4 * minSegmentSize)
+	s, err := Open(dir, maxSize)
+	require.NoError(t, err)
+	assert.Equal(t, int64(minSegmentSize), s.segmentSize)
+
+	record := make([]byte, 100*1024)
+	for i := 0; i < 100; i++ {
+		record[0] = byte(i)
+		require.NoError(t, s.Append(record))
+		assert.True(t, s.Size() <= maxSize)
+	}
+	var first []byte
+	replayed, _, err := s.Replay(func(r []byte) {
+		if first == nil {
+			first = r
+		}
+	})
+	require.NoError(t, err)
+	assert.True(t, replayed < 100)
+	assert.True(t, replayed >= 30)
+	// the newest records are kept
+	assert.Equal(t, byte(100-replayed), first[0])
+
+	assert.Error(t, s.Append(make([]byte, maxSize)))
+}
+
+func TestZstdRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := Open(dir, 1024*1024)
+	require.NoError(t, err)
+	assert.Error(t, s.EnableZstd(0))
+	assert.Error(t, s.EnableZstd(23))
+	require.NoError(t, s.Append([]byte("plain")))
+	require.NoError(t, s.EnableZstd(3))
+	compressible := strings.Repeat("compressible ", 1000)
+	require.NoError(t, s.Append([]byte(compressible)))
+	require.NoError(t, s.Append([]byte("short")))
+	assert.True(t, s.Size() < int64(len(compressible)/10))
+	require.NoError(t, s.Close())
+
+	// the compressed records are replayed without the compression enabled
+	s, err = Open(dir, 1024*1024)
+	require.NoError(t, err)
+	records, corrupt := replayAll(t, s)
+	assert.Equal(t, []string{"plain", compressible, "short"}, records)
+	assert.Equal(t, 0, corrupt)
+}
+
+func TestReplaySkipsUndecompressableRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := Open(dir, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, s.EnableZstd(1))
+	require.NoError(t, s.Append([]byte("first")))
+	require.NoError(t, s.Append([]byte("second")))
+	require.NoError(t, s.Close())
+
+	// garble the payload of the first record, keeping its framing and its checksum valid
+	path := s.path(0)
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	length := binary.BigEndian.Uint32(content[4:8])
+	payload := content[headerSize : headerSize+length]
+	for i := range payload {
+		payload[i] = 0
+	}
+	binary.BigEndian.PutUint32(content[8:12], crc32.ChecksumIEEE(payload))
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	s, err = Open(dir, 1024*1024)
+	require.NoError(t, err)
+	records, corrupt := replayAll(t, s)
+	assert.Equal(t, []string{"second"}, records)
+	assert.Equal(t, 1, corrupt)
+}
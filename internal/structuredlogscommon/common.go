@@ -29,6 +29,20 @@ func AppendAttributesInFields(fieldName string, fieldValue interface{}, metric t
 	}
 }
 
+// IsAttributeInFields returns whether the field is an attribute passed in the fields rather than a measurement
+func IsAttributeInFields(tags map[string]string, fieldName string) bool {
+	val, ok := tags[attributesInFields]
+	if !ok {
+		return false
+	}
+	for _, attr := range strings.Split(val, ",") {
+		if attr == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
 func BuildAttributes(metric telegraf.Metric, structuredLogContent map[string]interface{}) {
 	mTags := metric.Tags()
 	// build all the attributesInFields
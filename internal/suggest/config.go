@@ -0,0 +1,335 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package suggest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diffContext is the number of the unchanged lines around the changes of the diff
+const diffContext = 3
+
+// logsSection is the section of the JSON config the settings of the cloudwatchlogs output are translated from
+const logsSection = "logs"
+
+// jsonMember is a member of a JSON object, by the offsets of its key and of its value in the config
+type jsonMember struct {
+	key                  string
+	keyStart             int
+	valueStart, valueEnd int
+}
+
+// Config is the JSON config of the agent and where the settings of its logs section are, the suggestions are made
+// for the JSON config rather than the TOML generated from it as the TOML is translated again at every start
+type Config struct {
+	data  []byte
+	lines []string
+	// logs is the logs section, nil without one
+	logs *jsonMember
+	// members are the members of the logs section in their order
+	members  []jsonMember
+	Settings Settings
+}
+
+// ParseConfig returns the settings of the logs section of the JSON config
+func ParseConfig(data []byte) (*Config, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("unable to parse the config: %v", err)
+	}
+	c := &Config{data: data, lines: strings.Split(string(data), "\n")}
+	for _, m := range objectMembers(data, skipSpace(data, 0)) {
+		if m.key == logsSection && data[m.valueStart] == '{' {
+			m := m
+			c.logs = &m
+		}
+	}
+	logs, ok := root[logsSection].(map[string]interface{})
+	if !ok || c.logs == nil {
+		return c, nil
+	}
+	c.members = objectMembers(data, c.logs.valueStart)
+	for name, value := range logs {
+		switch name {
+		case SettingForceFlushInterval:
+			seconds, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%v of the logs section is not a number of seconds", name)
+			}
+			c.Settings.ForceFlushInterval = time.Duration(seconds * float64(time.Second))
+		case SettingMaxAPITPS:
+			if c.Settings.MaxAPITPS, ok = value.(float64); !ok {
+				return nil, fmt.Errorf("%v of the logs section is not a number", name)
+			}
+		}
+	}
+	return c, nil
+}
+
+// objectMembers returns the members of the object starting at the offset, the data being valid JSON
+func objectMembers(data []byte, start int) []jsonMember {
+	var members []jsonMember
+	i := skipSpace(data, start+1)
+	for i < len(data) && data[i] != '}' {
+		if data[i] == ',' {
+			i = skipSpace(data, i+1)
+			continue
+		}
+		m := jsonMember{keyStart: i}
+		end := skipValue(data, i)
+		json.Unmarshal(data[i:end], &m.key)
+		i = skipSpace(data, end)
+		// the colon
+		m.valueStart = skipSpace(data, i+1)
+		m.valueEnd = skipValue(data, m.valueStart)
+		members = append(members, m)
+		i = skipSpace(data, m.valueEnd)
+	}
+	return members
+}
+
+// member returns the member of the logs section of the key
+func (c *Config) member(key string) (jsonMember, bool) {
+	for _, m := range c.members {
+		if m.key == key {
+			return m, true
+		}
+	}
+	return jsonMember{}, false
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && strings.IndexByte(" \t\r\n", data[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// skipValue returns the offset following the JSON value starting at the offset
+func skipValue(data []byte, i int) int {
+	depth := 0
+	for ; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			if depth == 0 {
+				return i + 1
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case ',', ' ', '\t', '\r', '\n':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// lineOf returns the index of the line of the offset
+func (c *Config) lineOf(offset int) int {
+	return strings.Count(string(c.data[:offset]), "\n")
+}
+
+// indentOf returns the indentation of the line of the offset
+func (c *Config) indentOf(offset int) string {
+	text := c.lines[c.lineOf(offset)]
+	return text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+}
+
+// diffLine is a line of the diff, op is ' ' for the unchanged lines, '-' for the removed ones and '+' for the added ones
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Diff returns the unified diff of the JSON config applying the setting suggestions to its logs section, "" when
+// there are none or when the config has no logs section
+func (c *Config) Diff(name string, suggestions []Suggestion) string {
+	if c.logs == nil {
+		return ""
+	}
+	// the values replaced by their offsets, and the members inserted first in the section
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var inserted []string
+	for _, s := range suggestions {
+		var value string
+		switch s.Setting {
+		case SettingForceFlushInterval:
+			d, err := time.ParseDuration(s.Proposed)
+			if err != nil {
+				continue
+			}
+			value = strconv.FormatInt(int64(d/time.Second), 10)
+		case SettingMaxAPITPS:
+			value = s.Proposed
+		default:
+			continue
+		}
+		if m, ok := c.member(s.Setting); ok {
+			edits = append(edits, edit{m.valueStart, m.valueEnd, value})
+		} else {
+			key, _ := json.Marshal(s.Setting)
+			inserted = append(inserted, string(key)+": "+value)
+		}
+	}
+	if len(edits) == 0 && len(inserted) == 0 {
+		return ""
+	}
+	if len(inserted) > 0 {
+		sort.Strings(inserted)
+		parent := c.indentOf(c.logs.keyStart)
+		indent := parent + "  "
+		if len(c.members) > 0 && c.lineOf(c.members[0].keyStart) != c.lineOf(c.logs.valueStart) {
+			indent = c.indentOf(c.members[0].keyStart)
+		}
+		var sb strings.Builder
+		for i, member := range inserted {
+			sb.WriteString("\n" + indent + member)
+			if i < len(inserted)-1 || len(c.members) > 0 {
+				sb.WriteByte(',')
+			}
+		}
+		// the members following the opening brace on its line move to the lines of their own, as the closing brace
+		// of an empty section does
+		start, end := c.logs.valueStart+1, skipSpace(c.data, c.logs.valueStart+1)
+		switch {
+		case len(c.members) == 0:
+			sb.WriteString("\n" + parent)
+		case c.lineOf(c.members[0].keyStart) == c.lineOf(c.logs.valueStart):
+			sb.WriteString("\n" + indent)
+		default:
+			end = start
+		}
+		edits = append(edits, edit{start, end, sb.String()})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var updated strings.Builder
+	previous := 0
+	for _, e := range edits {
+		updated.Write(c.data[previous:e.start])
+		updated.WriteString(e.text)
+		previous = e.end
+	}
+	updated.Write(c.data[previous:])
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", name, name)
+	for _, h := range hunks(diffLines(c.lines, strings.Split(updated.String(), "\n"))) {
+		sb.WriteString(h)
+	}
+	return sb.String()
+}
+
+// diffLines returns the lines of the diff of the lines before to the lines after, from their longest common subsequence
+func diffLines(before, after []string) []diffLine {
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, diffLine{' ', before[i]})
+			i++
+			j++
+		case i < len(before) && (j == len(after) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{'-', before[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', after[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// hunks returns the hunks of the changed lines with their context, the changes closer than twice the context are in
+// the same hunk
+func hunks(lines []diffLine) []string {
+	var result []string
+	// the line numbers in the old and the new file of the lines
+	oldN, newN := make([]int, len(lines)), make([]int, len(lines))
+	o, n := 1, 1
+	for i, l := range lines {
+		oldN[i], newN[i] = o, n
+		if l.op != '+' {
+			o++
+		}
+		if l.op != '-' {
+			n++
+		}
+	}
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(lines) && j <= end+2*diffContext; j++ {
+			if lines[j].op != ' ' {
+				end = j
+			}
+		}
+		stop := end + diffContext + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+		var body strings.Builder
+		var oldCount, newCount int
+		for _, l := range lines[start:stop] {
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			body.WriteByte('\n')
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		result = append(result, fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", oldN[start], oldCount, newN[start], newCount, body.String()))
+		i = stop
+	}
+	return result
+}
@@ -0,0 +1,264 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package suggest analyses the statistics of the batches the agent published over a period, as reported by the
+// status of its control API, and suggests the changes of its config which would publish them more efficiently: a
+// longer force flush interval for the targets sending small batches, a higher API rate for the ones throttled by it,
+// and the targets whose volume makes them candidates for sampling. The changes are proposed as a diff of the JSON config.
+package suggest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+)
+
+const (
+	// logsTargetPrefix is the prefix of the names of the log streams among the targets
+	logsTargetPrefix = "logs."
+	// maxBatchBytes and maxBatchEvents are the limits of the PutLogEvents batches
+	maxBatchBytes  = 1024 * 1024
+	maxBatchEvents = 10000
+
+	defaultFlushInterval = 5 * time.Second
+	maxFlushInterval     = time.Minute
+	// smallBatchFill is the fill of the batches below which they are too small, targetBatchFill the one the longer
+	// flush interval is chosen for
+	smallBatchFill  = 0.1
+	targetBatchFill = 0.5
+	// rateLimitUse is the share of the max API TPS above which the requests are throttled by it
+	rateLimitUse = 0.8
+	// samplingShare is the share of the bytes above which a target is a candidate for sampling
+	samplingShare = 0.2
+	// maxSamplingCandidates is the number of the biggest targets reported as candidates for sampling
+	maxSamplingCandidates = 5
+)
+
+// Setting names of the cloudwatchlogs output
+const (
+	SettingForceFlushInterval = "force_flush_interval"
+	SettingMaxAPITPS          = "max_api_tps"
+)
+
+// Snapshot is the publishing statistics of the status of the agent at a time
+type Snapshot struct {
+	Time       time.Time
+	Publishing []publishstats.Summary
+}
+
+// Settings are the settings of the cloudwatchlogs output the suggestions are made for
+type Settings struct {
+	ForceFlushInterval time.Duration
+	// MaxAPITPS is 0 when the API rate is not limited
+	MaxAPITPS float64
+}
+
+// TargetStats are the statistics of a target over the period
+type TargetStats struct {
+	Target         string
+	Batches        int64
+	Events         int64
+	Bytes          float64
+	BytesPerSecond float64
+	// Fill is how full the batches are on average, of the larger of their size and event count limits
+	Fill            float64
+	P99FlushLatency time.Duration
+}
+
+// Suggestion is a change of a setting of the cloudwatchlogs output, or a target which is a candidate for sampling
+// when the setting is empty
+type Suggestion struct {
+	Setting  string
+	Current  string
+	Proposed string
+	Target   string
+	Reason   string
+}
+
+// Report is the analysis of the publishing over the period
+type Report struct {
+	Period      time.Duration
+	Targets     []TargetStats
+	Suggestions []Suggestion
+}
+
+// Analyze returns the statistics of the log streams published between the snapshots and the suggestions made from them
+func Analyze(first, last Snapshot, settings Settings) (*Report, error) {
+	period := last.Time.Sub(first.Time)
+	if period <= 0 {
+		return nil, fmt.Errorf("the period between the snapshots is %v", period)
+	}
+	if settings.ForceFlushInterval <= 0 {
+		settings.ForceFlushInterval = defaultFlushInterval
+	}
+	before := map[string]publishstats.Summary{}
+	for _, s := range first.Publishing {
+		before[s.Target] = s
+	}
+
+	report := &Report{Period: period}
+	for _, s := range last.Publishing {
+		if !strings.HasPrefix(s.Target, logsTargetPrefix) {
+			continue
+		}
+		b := before[s.Target]
+		if s.Batches < b.Batches {
+			return nil, fmt.Errorf("the batches of %v went down from %v to %v, the agent restarted during the period", s.Target, b.Batches, s.Batches)
+		}
+		stats := TargetStats{
+			Target:  s.Target,
+			Batches: s.Batches - b.Batches,
+			Events:  s.Events - b.Events,
+			Bytes:   math.Max(0, s.AvgBatchBytes*float64(s.Batches)-b.AvgBatchBytes*float64(b.Batches)),
+		}
+		if stats.Batches == 0 {
+			continue
+		}
+		stats.BytesPerSecond = stats.Bytes / period.Seconds()
+		stats.Fill = math.Max(stats.Bytes/float64(stats.Batches)/maxBatchBytes, float64(stats.Events)/float64(stats.Batches)/maxBatchEvents)
+		stats.P99FlushLatency, _ = time.ParseDuration(s.P99FlushLatency)
+		report.Targets = append(report.Targets, stats)
+	}
+	sort.Slice(report.Targets, func(i, j int) bool { return report.Targets[i].Bytes > report.Targets[j].Bytes })
+
+	if s, ok := flushIntervalSuggestion(report, settings); ok {
+		report.Suggestions = append(report.Suggestions, s)
+	}
+	if s, ok := apiRateSuggestion(report, settings); ok {
+		report.Suggestions = append(report.Suggestions, s)
+	}
+	report.Suggestions = append(report.Suggestions, samplingCandidates(report)...)
+	return report, nil
+}
+
+// flushIntervalSuggestion suggests a longer flush interval when the batches are small and flushed on the interval
+// rather than once full, so that fewer requests send the same log events
+func flushIntervalSuggestion(report *Report, settings Settings) (Suggestion, bool) {
+	var batches, events int64
+	var bytes float64
+	for _, t := range report.Targets {
+		batches += t.Batches
+		events += t.Events
+		bytes += t.Bytes
+	}
+	if batches == 0 {
+		return Suggestion{}, false
+	}
+	fill := math.Max(bytes/float64(batches)/maxBatchBytes, float64(events)/float64(batches)/maxBatchEvents)
+	// the batches of every target expected if they were flushed on every interval
+	flushes := float64(len(report.Targets)) * report.Period.Seconds() / settings.ForceFlushInterval.Seconds()
+	if fill >= smallBatchFill || float64(batches) < 0.8*flushes {
+		return Suggestion{}, false
+	}
+	proposed := time.Duration(float64(settings.ForceFlushInterval) * targetBatchFill / math.Max(fill, 0.001)).Round(time.Second)
+	if proposed > maxFlushInterval {
+		proposed = maxFlushInterval
+	}
+	if proposed <= settings.ForceFlushInterval {
+		return Suggestion{}, false
+	}
+	return Suggestion{
+		Setting:  SettingForceFlushInterval,
+		Current:  settings.ForceFlushInterval.String(),
+		Proposed: proposed.String(),
+		Reason: fmt.Sprintf("the %v batches were %.1f%% full on average and flushed on the interval, flushing every %v sends about %.0f times fewer requests, the log events are delivered up to %v later",
+			batches, fill*100, proposed, proposed.Seconds()/settings.ForceFlushInterval.Seconds(), proposed-settings.ForceFlushInterval),
+	}, true
+}
+
+// apiRateSuggestion suggests a higher max API TPS when the requests were sent close to it and some batches waited
+// longer than the flush interval to be accepted
+func apiRateSuggestion(report *Report, settings Settings) (Suggestion, bool) {
+	if settings.MaxAPITPS <= 0 {
+		return Suggestion{}, false
+	}
+	var batches int64
+	var slowest TargetStats
+	for _, t := range report.Targets {
+		batches += t.Batches
+		if t.P99FlushLatency > slowest.P99FlushLatency {
+			slowest = t
+		}
+	}
+	rate := float64(batches) / report.Period.Seconds()
+	if rate < rateLimitUse*settings.MaxAPITPS || slowest.P99FlushLatency <= settings.ForceFlushInterval {
+		return Suggestion{}, false
+	}
+	return Suggestion{
+		Setting:  SettingMaxAPITPS,
+		Current:  formatFloat(settings.MaxAPITPS),
+		Proposed: formatFloat(math.Ceil(settings.MaxAPITPS * 2)),
+		Reason: fmt.Sprintf("%.2f requests per second were sent, %.0f%% of the max API TPS, and the p99 flush latency of %v was %v, longer than the flush interval",
+			rate, rate/settings.MaxAPITPS*100, slowest.Target, slowest.P99FlushLatency),
+	}, true
+}
+
+// samplingCandidates returns the targets sending the biggest shares of the bytes, the first candidates for sampling or
+// for filtering out their noisy log events
+func samplingCandidates(report *Report) []Suggestion {
+	if len(report.Targets) < 2 {
+		return nil
+	}
+	var total float64
+	for _, t := range report.Targets {
+		total += t.Bytes
+	}
+	var candidates []Suggestion
+	for _, t := range report.Targets {
+		share := t.Bytes / total
+		if share < samplingShare || len(candidates) == maxSamplingCandidates {
+			break
+		}
+		candidates = append(candidates, Suggestion{
+			Target: t.Target,
+			Reason: fmt.Sprintf("it sent %.0f%% of the bytes, %s/s, it is a candidate for sampling or for filtering out its noisy log events",
+				share*100, formatBytes(t.BytesPerSecond)),
+		})
+	}
+	return candidates
+}
+
+func formatFloat(f float64) string {
+	return fmt.Sprintf("%g", f)
+}
+
+func formatBytes(b float64) string {
+	switch {
+	case b >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", b/1024/1024)
+	case b >= 1024:
+		return fmt.Sprintf("%.1f KB", b/1024)
+	default:
+		return fmt.Sprintf("%.0f B", b)
+	}
+}
+
+// String formats the report for the terminal
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Published over %v:\n", r.Period.Round(time.Second))
+	if len(r.Targets) == 0 {
+		sb.WriteString("  no batches\n")
+	}
+	for _, t := range r.Targets {
+		fmt.Fprintf(&sb, "  %v: %v batches, %v events, %s/s, %.1f%% full, p99 flush latency %v\n",
+			t.Target, t.Batches, t.Events, formatBytes(t.BytesPerSecond), t.Fill*100, t.P99FlushLatency)
+	}
+	if len(r.Suggestions) == 0 {
+		sb.WriteString("No suggestions, the publishing is efficient\n")
+		return sb.String()
+	}
+	sb.WriteString("Suggestions:\n")
+	for _, s := range r.Suggestions {
+		if s.Setting != "" {
+			fmt.Fprintf(&sb, "  %v %v -> %v: %v\n", s.Setting, s.Current, s.Proposed, s.Reason)
+		} else {
+			fmt.Fprintf(&sb, "  %v: %v\n", s.Target, s.Reason)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package suggest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshot(t time.Time, summaries ...publishstats.Summary) Snapshot {
+	return Snapshot{Time: t, Publishing: summaries}
+}
+
+func TestAnalyzeSmallBatches(t *testing.T) {
+	start := time.Now()
+	// 2 streams flushed every 5s for 10m with 2KB batches
+	first := snapshot(start,
+		publishstats.Summary{Target: "logs.app/i-1", Batches: 10, Events: 100, AvgBatchBytes: 2048},
+		publishstats.Summary{Target: "logs.audit/i-1", Batches: 10, Events: 100, AvgBatchBytes: 2048})
+	last := snapshot(start.Add(10*time.Minute),
+		publishstats.Summary{Target: "logs.app/i-1", Batches: 130, Events: 1300, AvgBatchBytes: 2048, P99FlushLatency: "150ms"},
+		publishstats.Summary{Target: "logs.audit/i-1", Batches: 130, Events: 1300, AvgBatchBytes: 2048, P99FlushLatency: "90ms"})
+
+	report, err := Analyze(first, last, Settings{})
+	require.NoError(t, err)
+	require.Len(t, report.Targets, 2)
+	assert.Equal(t, int64(120), report.Targets[0].Batches)
+	assert.InDelta(t, 120*2048/600.0, report.Targets[0].BytesPerSecond, 0.01)
+	assert.Equal(t, 150*time.Millisecond, report.Targets[0].P99FlushLatency)
+
+	require.Len(t, report.Suggestions, 3)
+	assert.Equal(t, SettingForceFlushInterval, report.Suggestions[0].Setting)
+	assert.Equal(t, "5s", report.Suggestions[0].Current)
+	assert.Equal(t, "1m0s", report.Suggestions[0].Proposed)
+	// both streams send half of the bytes
+	assert.Equal(t, "logs.app/i-1", report.Suggestions[1].Target)
+	assert.Equal(t, "logs.audit/i-1", report.Suggestions[2].Target)
+	assert.Contains(t, report.String(), "force_flush_interval 5s -> 1m0s")
+}
+
+func TestAnalyzeThrottled(t *testing.T) {
+	start := time.Now()
+	// full batches sent at the max API TPS of 2, waiting up to 20s
+	last := snapshot(start.Add(time.Minute),
+		publishstats.Summary{Target: "logs.app/i-1", Batches: 110, Events: 110 * 5000, AvgBatchBytes: 900 * 1024, P99FlushLatency: "20s"},
+		publishstats.Summary{Target: "metrics.cpu", Batches: 1000})
+	report, err := Analyze(snapshot(start), last, Settings{ForceFlushInterval: 10 * time.Second, MaxAPITPS: 2})
+	require.NoError(t, err)
+	require.Len(t, report.Targets, 1)
+	require.Len(t, report.Suggestions, 1)
+	assert.Equal(t, Suggestion{
+		Setting:  SettingMaxAPITPS,
+		Current:  "2",
+		Proposed: "4",
+		Reason:   "1.83 requests per second were sent, 92% of the max API TPS, and the p99 flush latency of logs.app/i-1 was 20s, longer than the flush interval",
+	}, report.Suggestions[0])
+
+	_, err = Analyze(last, snapshot(start.Add(2*time.Minute), publishstats.Summary{Target: "logs.app/i-1", Batches: 3}), Settings{})
+	assert.Error(t, err, "the agent restarted")
+}
+
+func TestConfigDiff(t *testing.T) {
+	config, err := ParseConfig([]byte(`{
+  "agent": {
+    "metrics_collection_interval": 60
+  },
+  "logs": {
+    "force_flush_interval": 5,
+    "logs_collected": {
+      "files": {"collect_list": [{"file_path": "/var/log/app.log"}]}
+    }
+  }
+}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, Settings{ForceFlushInterval: 5 * time.Second}, config.Settings)
+
+	diff := config.Diff("agent.json", []Suggestion{
+		{Setting: SettingForceFlushInterval, Current: "5s", Proposed: "30s"},
+		{Setting: SettingMaxAPITPS, Current: "0", Proposed: "10"},
+		{Target: "logs.app/i-1"},
+	})
+	assert.Equal(t, "--- agent.json\n+++ agent.json\n"+
+		"@@ -3,7 +3,8 @@\n"+
+		"     \"metrics_collection_interval\": 60\n"+
+		"   },\n"+
+		"   \"logs\": {\n"+
+		"-    \"force_flush_interval\": 5,\n"+
+		"+    \"max_api_tps\": 10,\n"+
+		"+    \"force_flush_interval\": 30,\n"+
+		"     \"logs_collected\": {\n"+
+		"       \"files\": {\"collect_list\": [{\"file_path\": \"/var/log/app.log\"}]}\n"+
+		"     }\n", diff)
+
+	assert.Empty(t, config.Diff("agent.json", []Suggestion{{Target: "logs.app/i-1"}}))
+
+	// the members on the line of the opening brace, and the empty sections
+	config, err = ParseConfig([]byte(`{"logs": {"force_flush_interval": 15, "max_api_tps": 2.5}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Settings{ForceFlushInterval: 15 * time.Second, MaxAPITPS: 2.5}, config.Settings)
+	assert.Equal(t, "--- agent.json\n+++ agent.json\n@@ -1,1 +1,1 @@\n"+
+		"-{\"logs\": {\"force_flush_interval\": 15, \"max_api_tps\": 2.5}}\n"+
+		"+{\"logs\": {\"force_flush_interval\": 15, \"max_api_tps\": 5}}\n",
+		config.Diff("agent.json", []Suggestion{{Setting: SettingMaxAPITPS, Proposed: "5"}}))
+
+	config, err = ParseConfig([]byte("{\n  \"logs\": {}\n}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "--- agent.json\n+++ agent.json\n@@ -1,4 +1,6 @@\n"+
+		" {\n"+
+		"-  \"logs\": {}\n"+
+		"+  \"logs\": {\n"+
+		"+    \"max_api_tps\": 10\n"+
+		"+  }\n"+
+		" }\n"+
+		" \n", config.Diff("agent.json", []Suggestion{{Setting: SettingMaxAPITPS, Proposed: "10"}}))
+
+	config, err = ParseConfig([]byte(`{"logs": {"force_flush_interval": "5s"}}`))
+	assert.EqualError(t, err, "force_flush_interval of the logs section is not a number of seconds")
+
+	empty, err := ParseConfig([]byte(`{"agent": {"metrics_collection_interval": 60}}`))
+	require.NoError(t, err)
+	assert.Empty(t, empty.Diff("agent.json", []Suggestion{{Setting: SettingMaxAPITPS, Proposed: "10"}}))
+
+	_, err = ParseConfig([]byte("[agent]\n"))
+	assert.Error(t, err)
+}
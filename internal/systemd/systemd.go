@@ -0,0 +1,67 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package systemd implements the sd_notify protocol, see https://www.freedesktop.org/software/systemd/man/sd_notify.html
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	notifySocketEnv = "NOTIFY_SOCKET"
+	watchdogUsecEnv = "WATCHDOG_USEC"
+	watchdogPidEnv  = "WATCHDOG_PID"
+
+	StateReady    = "READY=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Status returns the state setting the free form status of the unit shown by systemctl status
+func Status(status string) string {
+	return "STATUS=" + status
+}
+
+// Notify sends the state to the service manager. It returns false without error when the
+// process is not run by systemd with Type=notify, i.e. NOTIFY_SOCKET is not set.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv(notifySocketEnv)
+	if socket == "" {
+		return false, nil
+	}
+	// abstract namespace sockets start with "@"
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Enabled returns whether the process is run by systemd with Type=notify
+func Enabled() bool {
+	return os.Getenv(notifySocketEnv) != ""
+}
+
+// WatchdogInterval returns the interval the service manager expects keep-alive pings within,
+// it is 0 when the watchdog is not enabled for this process.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv(watchdogUsecEnv), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv(watchdogPidEnv); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,63 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package systemd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	os.Unsetenv(notifySocketEnv)
+	sent, err := Notify(StateReady)
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	assert.False(t, Enabled())
+
+	dir, err := ioutil.TempDir("", "systemd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	os.Setenv(notifySocketEnv, socket)
+	defer os.Unsetenv(notifySocketEnv)
+	assert.True(t, Enabled())
+	sent, err = Notify(StateReady + "\n" + Status("Running"))
+	require.NoError(t, err)
+	assert.True(t, sent)
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1\nSTATUS=Running", string(buf[:n]))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	defer os.Unsetenv(watchdogUsecEnv)
+	defer os.Unsetenv(watchdogPidEnv)
+
+	os.Unsetenv(watchdogUsecEnv)
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+
+	os.Setenv(watchdogUsecEnv, "30000000")
+	assert.Equal(t, 30*time.Second, WatchdogInterval())
+
+	os.Setenv(watchdogPidEnv, strconv.Itoa(os.Getpid()))
+	assert.Equal(t, 30*time.Second, WatchdogInterval())
+
+	os.Setenv(watchdogPidEnv, strconv.Itoa(os.Getpid()+1))
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+}
@@ -0,0 +1,40 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package timezone resolves the time zones of the log timestamps, by name from the IANA time zone database.
+//
+// The database of the host is used when it has one, the copy embedded into the agent otherwise, e.g. in minimal
+// containers and on Windows.
+package timezone
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	UTC   = "UTC"
+	Local = "LOCAL"
+)
+
+// Normalize returns UTC or LOCAL for these zones, whatever their case, and the name as is for the others
+func Normalize(name string) string {
+	switch {
+	case strings.EqualFold(name, UTC):
+		return UTC
+	case name == "" || strings.EqualFold(name, Local):
+		return Local
+	}
+	return name
+}
+
+// LoadLocation returns the location of the zone, the local one when the name is empty
+func LoadLocation(name string) (*time.Location, error) {
+	switch Normalize(name) {
+	case UTC:
+		return time.UTC, nil
+	case Local:
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
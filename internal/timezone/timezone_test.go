@@ -0,0 +1,42 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package timezone
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("utc")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+	for _, name := range []string{"", "Local", "LOCAL"} {
+		loc, err = LoadLocation(name)
+		require.NoError(t, err)
+		assert.Equal(t, time.Local, loc)
+	}
+
+	loc, err = LoadLocation("Asia/Kathmandu")
+	require.NoError(t, err)
+	_, offset := time.Date(2020, 1, 1, 0, 0, 0, 0, loc).Zone()
+	assert.Equal(t, 5*3600+45*60, offset)
+
+	_, err = LoadLocation("Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
+
+func TestLoadLocationWithoutHostDatabase(t *testing.T) {
+	// the host database or the embedded one is used when the one set by ZONEINFO is missing
+	os.Setenv("ZONEINFO", "/nonexistent")
+	defer os.Unsetenv("ZONEINFO")
+	loc, err := LoadLocation("America/St_Johns")
+	require.NoError(t, err)
+	_, offset := time.Date(2020, 1, 1, 0, 0, 0, 0, loc).Zone()
+	assert.Equal(t, -(3*3600 + 30*60), offset)
+}
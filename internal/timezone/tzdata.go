@@ -0,0 +1,9 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build go1.15
+
+package timezone
+
+// The embedded database is only used when the host has none, it adds about 450KB to the binaries.
+import _ "time/tzdata"
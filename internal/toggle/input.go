@@ -0,0 +1,110 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package toggle
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// WrapInputs makes the metrics of the inputs droppable by disabling them. The inputs which are log collections are
+// left alone, their log events are dropped by log group by the log agent instead.
+//
+// It must be called once the plugins were looked up by their type, since the wrapped inputs are no longer of the type
+// of their plugin.
+func WrapInputs(inputs []*models.RunningInput, isLogCollection func(telegraf.Input) bool) {
+	for _, ri := range inputs {
+		if isLogCollection(ri.Input) {
+			continue
+		}
+		name := ri.Config.Alias
+		if name == "" {
+			name = ri.Config.Name
+		}
+		ti := toggledInput{Input: ri.Input, name: InputName(name)}
+		if si, ok := ri.Input.(telegraf.ServiceInput); ok {
+			ri.Input = &toggledServiceInput{toggledInput: ti, service: si}
+		} else {
+			ri.Input = &ti
+		}
+	}
+}
+
+type toggledInput struct {
+	telegraf.Input
+	name string
+}
+
+// Init runs the Init of the input, the wrapper is always an initializer so the one of the input is not lost
+func (t *toggledInput) Init() error {
+	if i, ok := t.Input.(telegraf.Initializer); ok {
+		return i.Init()
+	}
+	return nil
+}
+
+func (t *toggledInput) Gather(acc telegraf.Accumulator) error {
+	if Disabled(t.name) {
+		return nil
+	}
+	return t.Input.Gather(&toggledAccumulator{Accumulator: acc, name: t.name})
+}
+
+type toggledServiceInput struct {
+	toggledInput
+	service telegraf.ServiceInput
+}
+
+func (t *toggledServiceInput) Start(acc telegraf.Accumulator) error {
+	return t.service.Start(&toggledAccumulator{Accumulator: acc, name: t.name})
+}
+
+func (t *toggledServiceInput) Stop() {
+	t.service.Stop()
+}
+
+// toggledAccumulator drops the metrics of the service inputs, which produce them outside of Gather, while they are
+// disabled. The tracked metrics are not dropped since the inputs wait for their delivery.
+type toggledAccumulator struct {
+	telegraf.Accumulator
+	name string
+}
+
+func (a *toggledAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if !Disabled(a.name) {
+		a.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (a *toggledAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if !Disabled(a.name) {
+		a.Accumulator.AddGauge(measurement, fields, tags, t...)
+	}
+}
+
+func (a *toggledAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if !Disabled(a.name) {
+		a.Accumulator.AddCounter(measurement, fields, tags, t...)
+	}
+}
+
+func (a *toggledAccumulator) AddSummary(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if !Disabled(a.name) {
+		a.Accumulator.AddSummary(measurement, fields, tags, t...)
+	}
+}
+
+func (a *toggledAccumulator) AddHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if !Disabled(a.name) {
+		a.Accumulator.AddHistogram(measurement, fields, tags, t...)
+	}
+}
+
+func (a *toggledAccumulator) AddMetric(m telegraf.Metric) {
+	if !Disabled(a.name) {
+		a.Accumulator.AddMetric(m)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package toggle disables and enables the inputs and the log targets of the running agent without restarting it,
+// e.g. to mute a noisy debug log during an incident.
+//
+// The targets are named inputs.<name or alias> for the inputs producing metrics, and logs.<log group name> for the
+// log events of the log group. A disabled input keeps running but the metrics it produces are dropped, and the log
+// events of a disabled log group are dropped once read so the files are not read again when it is enabled.
+//
+// The changes made with persist are saved into the state file, which is loaded at every start of the agent so they
+// survive the restarts and the config changes.
+package toggle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	InputPrefix = "inputs."
+	LogPrefix   = "logs."
+)
+
+type state struct {
+	Disabled []string `json:"disabled"`
+}
+
+var (
+	mu        sync.RWMutex
+	disabled  = map[string]bool{}
+	persisted = map[string]bool{}
+	stateFile string
+)
+
+// InputName returns the name of the target of the input with the name or the alias
+func InputName(name string) string {
+	return InputPrefix + name
+}
+
+// LogName returns the name of the target of the log group
+func LogName(group string) string {
+	return LogPrefix + group
+}
+
+// Load reads the targets persisted as disabled from the state file, the later changes made with persist are saved
+// into it. A missing state file has no target disabled.
+func Load(file string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	stateFile = file
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the disabled targets: %v", err)
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("unable to parse the disabled targets of %v: %v", file, err)
+	}
+	for _, name := range s.Disabled {
+		disabled[name] = true
+		persisted[name] = true
+	}
+	return nil
+}
+
+// Disabled returns whether the target is disabled
+func Disabled(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return disabled[name]
+}
+
+// List returns the disabled targets, sorted by name
+func List() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(disabled))
+	for name := range disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Disable drops what the target produces from now on, until it is enabled again. The target stays disabled after a
+// restart of the agent when persist is set.
+func Disable(name string, persist bool) error {
+	return set(name, true, persist)
+}
+
+// Enable undoes Disable. The target is disabled again after a restart of the agent when it was disabled with persist,
+// unless persist is set.
+func Enable(name string, persist bool) error {
+	return set(name, false, persist)
+}
+
+func set(name string, off, persist bool) error {
+	if !strings.HasPrefix(name, InputPrefix) && !strings.HasPrefix(name, LogPrefix) {
+		return fmt.Errorf("invalid target %q, expecting %v<input> or %v<log group name>", name, InputPrefix, LogPrefix)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if persist {
+		if stateFile == "" {
+			return fmt.Errorf("unable to persist %v, the agent has no state file", name)
+		}
+		changed := copyOf(persisted)
+		changed[name] = off
+		if err := save(changed); err != nil {
+			return err
+		}
+		persisted[name] = off
+		if !off {
+			delete(persisted, name)
+		}
+	}
+	if off {
+		disabled[name] = true
+	} else {
+		delete(disabled, name)
+	}
+	return nil
+}
+
+func copyOf(m map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// save writes the targets into a temporary file renamed into place, so it is never left half written
+func save(targets map[string]bool) error {
+	s := state{Disabled: []string{}}
+	for name, off := range targets {
+		if off {
+			s.Disabled = append(s.Disabled, name)
+		}
+	}
+	sort.Strings(s.Disabled)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(stateFile+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("unable to save the disabled targets: %v", err)
+	}
+	return os.Rename(stateFile+".tmp", stateFile)
+}
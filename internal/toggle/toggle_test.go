@@ -0,0 +1,98 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package toggle
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reset() {
+	disabled, persisted, stateFile = map[string]bool{}, map[string]bool{}, ""
+}
+
+func TestDisableAndPersist(t *testing.T) {
+	defer reset()
+	dir, err := ioutil.TempDir("", "toggle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "disabled-targets.json")
+
+	assert.EqualError(t, Disable("logs.group", true), "unable to persist logs.group, the agent has no state file")
+	assert.EqualError(t, Disable("cpu", false), `invalid target "cpu", expecting inputs.<input> or logs.<log group name>`)
+
+	require.NoError(t, Load(file))
+	require.NoError(t, Disable("logs.group", true))
+	require.NoError(t, Disable("inputs.cpu", false))
+	assert.True(t, Disabled("logs.group"))
+	assert.Equal(t, []string{"inputs.cpu", "logs.group"}, List())
+
+	// only the persisted changes are disabled again after a restart
+	reset()
+	require.NoError(t, Load(file))
+	assert.Equal(t, []string{"logs.group"}, List())
+
+	require.NoError(t, Enable("logs.group", false))
+	assert.False(t, Disabled("logs.group"))
+	reset()
+	require.NoError(t, Load(file))
+	assert.True(t, Disabled("logs.group"))
+
+	require.NoError(t, Enable("logs.group", true))
+	reset()
+	require.NoError(t, Load(file))
+	assert.Empty(t, List())
+}
+
+type testInput struct{}
+
+func (testInput) SampleConfig() string { return "" }
+func (testInput) Description() string  { return "" }
+func (testInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("test", map[string]interface{}{"value": 1}, nil)
+	return nil
+}
+
+type testServiceInput struct {
+	testInput
+	acc telegraf.Accumulator
+}
+
+func (s *testServiceInput) Start(acc telegraf.Accumulator) error {
+	s.acc = acc
+	return nil
+}
+func (s *testServiceInput) Stop() {}
+
+func TestWrapInputs(t *testing.T) {
+	defer reset()
+	service := &testServiceInput{}
+	inputs := []*models.RunningInput{
+		models.NewRunningInput(testInput{}, &models.InputConfig{Name: "test"}),
+		models.NewRunningInput(service, &models.InputConfig{Name: "service", Alias: "statsd"}),
+	}
+	WrapInputs(inputs, func(telegraf.Input) bool { return false })
+	_, ok := inputs[1].Input.(telegraf.ServiceInput)
+	require.True(t, ok)
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, inputs[0].Input.Gather(acc))
+	require.NoError(t, inputs[1].Input.(telegraf.ServiceInput).Start(acc))
+	service.acc.AddGauge("statsd", map[string]interface{}{"value": 1}, nil)
+	assert.Equal(t, 2, len(acc.Metrics))
+
+	require.NoError(t, Disable("inputs.test", false))
+	require.NoError(t, Disable("inputs.statsd", false))
+	require.NoError(t, inputs[0].Input.Gather(acc))
+	service.acc.AddGauge("statsd", map[string]interface{}{"value": 1}, nil)
+	assert.Equal(t, 2, len(acc.Metrics))
+}
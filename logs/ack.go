@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+// An Acker is told by a LogDest which log events of its LogSrc were accepted by the destination, e.g. by a
+// PutLogEvents request, a batch at a time. A LogSrc which advances its checkpoint only from its Acker gets
+// at-least-once semantics: the log events which are queued but not accepted yet are read again after a restart.
+// The Acker are compared to group the log events of a batch, e.g. they are pointers to their LogSrc.
+type Acker interface {
+	// Ack is called once per accepted batch with the log events of the Acker in it, in the order they were published
+	Ack(events []LogEvent)
+}
+
+// AckedLogEvent is a LogEvent acknowledged by its Acker rather than by its Done when its batch is accepted.
+// Its Done is still called when the log event is dropped, e.g. sampled out or isolated, and by the LogDest which
+// do not acknowledge batches.
+type AckedLogEvent interface {
+	LogEvent
+	Acker() Acker
+}
+
+// A Batch holds the log events a LogDest publishes in a request until they are acknowledged. The zero value is
+// an empty batch.
+type Batch struct {
+	events []LogEvent
+}
+
+// Add adds the log event to the batch
+func (b *Batch) Add(e LogEvent) {
+	b.events = append(b.events, e)
+}
+
+// Len returns the number of log events in the batch
+func (b *Batch) Len() int {
+	return len(b.events)
+}
+
+// Ack acknowledges the log events of the batch once it is accepted: the AckedLogEvent to their Acker, one call
+// per Acker, and the others with their Done. The batch is not reset.
+func (b *Batch) Ack() {
+	var ackers []Acker
+	acked := map[Acker][]LogEvent{}
+	for i := len(b.events) - 1; i >= 0; i-- {
+		e := b.events[i]
+		ae, ok := e.(AckedLogEvent)
+		if !ok || ae.Acker() == nil {
+			// the later log events first, the LogSrc keeping the highest offset done need not wait for the others
+			e.Done()
+			continue
+		}
+		a := ae.Acker()
+		if _, ok := acked[a]; !ok {
+			ackers = append(ackers, a)
+		}
+		acked[a] = append(acked[a], e)
+	}
+	for _, a := range ackers {
+		events := acked[a]
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+		a.Ack(events)
+	}
+}
+
+// Reset empties the batch without acknowledging its log events, e.g. when the request is dropped
+func (b *Batch) Reset() {
+	for i := range b.events {
+		b.events[i] = nil
+	}
+	b.events = b.events[:0]
+}
@@ -0,0 +1,50 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAcker struct {
+	batches [][]LogEvent
+}
+
+func (a *testAcker) Ack(events []LogEvent) {
+	a.batches = append(a.batches, events)
+}
+
+type testAckedEvent struct {
+	testEvent
+	acker Acker
+}
+
+func (e *testAckedEvent) Acker() Acker { return e.acker }
+
+func TestBatchAck(t *testing.T) {
+	a1, a2 := &testAcker{}, &testAcker{}
+	e1 := &testAckedEvent{testEvent: testEvent{msg: "1"}, acker: a1}
+	e2 := &testAckedEvent{testEvent: testEvent{msg: "2"}, acker: a2}
+	e3 := &testAckedEvent{testEvent: testEvent{msg: "3"}, acker: a1}
+	plain := &testEvent{msg: "plain"}
+
+	var b Batch
+	for _, e := range []LogEvent{e1, e2, plain, e3} {
+		b.Add(e)
+	}
+	assert.Equal(t, 4, b.Len())
+	b.Ack()
+
+	assert.Equal(t, [][]LogEvent{{e1, e3}}, a1.batches)
+	assert.Equal(t, [][]LogEvent{{e2}}, a2.batches)
+	assert.Equal(t, 1, plain.done)
+	assert.Equal(t, 0, e1.done)
+
+	b.Reset()
+	assert.Equal(t, 0, b.Len())
+	b.Ack()
+	assert.Len(t, a1.batches, 1)
+}
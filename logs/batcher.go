@@ -0,0 +1,197 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// ErrBatchFull is returned by the Add of a BatchBuffer when the event does not fit in the batch, the batch is sent
+// before the event is added again.
+var ErrBatchFull = errors.New("batch is full")
+
+var seededRand = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Target is the log group and stream of the log events batched by a Batcher
+type Target struct {
+	Group, Stream string
+}
+
+// A BatchBuffer holds the log events of a Batcher in the format of its backend, it is only used by the
+// goroutine of the Batcher.
+type BatchBuffer interface {
+	// Add adds the event to the batch and returns whether the batch is full and should be sent. It returns
+	// ErrBatchFull when the event does not fit in the batch, and any other error when the event is dropped.
+	Add(e LogEvent) (full bool, err error)
+	// Put sends the batch to the backend once. When it fails, the batch keeps only what should be retried.
+	Put() error
+	// Reset empties the batch once it is sent or dropped
+	Reset()
+}
+
+// Batcher publishes the log events of a single log group and stream to a backend in batches, which are sent
+// when they are full or after the flush timeout. The puts of a batch are retried with an exponential backoff
+// until they succeed or the retry duration elapses, and the events are only acknowledged once they were put.
+type Batcher struct {
+	Target
+	// Backend names the backend in the logs, e.g. the bucket or the stream
+	Backend       string
+	FlushTimeout  time.Duration
+	RetryDuration time.Duration
+	Log           telegraf.Logger
+
+	buffer     BatchBuffer
+	batch      Batch
+	eventsCh   chan LogEvent
+	flushTimer *time.Timer
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+func NewBatcher(target Target, backend string, buffer BatchBuffer, flushTimeout, retryDuration time.Duration, logger telegraf.Logger) *Batcher {
+	b := &Batcher{
+		Target:        target,
+		Backend:       backend,
+		FlushTimeout:  flushTimeout,
+		RetryDuration: retryDuration,
+		Log:           logger,
+
+		buffer:     buffer,
+		eventsCh:   make(chan LogEvent, 100),
+		flushTimer: time.NewTimer(flushTimeout),
+		stop:       make(chan struct{}),
+	}
+	go b.start()
+	return b
+}
+
+// Publish adds the events to the batch, it returns ErrOutputStopped once the Batcher is stopped.
+func (b *Batcher) Publish(events []LogEvent) error {
+	for _, e := range events {
+		select {
+		case <-b.stop:
+			return ErrOutputStopped
+		default:
+		}
+		select {
+		case b.eventsCh <- e:
+		case <-b.stop:
+			return ErrOutputStopped
+		}
+	}
+	select {
+	case <-b.stop:
+		return ErrOutputStopped
+	default:
+		return nil
+	}
+}
+
+// Stop sends the batched events and stops the Batcher, the events still being retried are dropped.
+func (b *Batcher) Stop() {
+	b.stopOnce.Do(func() { close(b.stop) })
+}
+
+func (b *Batcher) start() {
+	for {
+		select {
+		case e := <-b.eventsCh:
+			if b.batch.Len() == 0 {
+				b.resetFlushTimer()
+			}
+			b.add(e)
+		case <-b.flushTimer.C:
+			if b.batch.Len() > 0 {
+				b.send()
+			}
+		case <-b.stop:
+			if b.batch.Len() > 0 {
+				b.send()
+			}
+			return
+		}
+	}
+}
+
+func (b *Batcher) add(e LogEvent) {
+	full, err := b.buffer.Add(e)
+	if err == ErrBatchFull && b.batch.Len() > 0 {
+		b.send()
+		full, err = b.buffer.Add(e)
+	}
+	if err != nil {
+		b.Log.Errorf("Unable to add log event of %v/%v to the batch of %v, event dropped: %v", b.Group, b.Stream, b.Backend, err)
+		return
+	}
+	b.batch.Add(e)
+	if full {
+		b.send()
+	}
+}
+
+// send puts the batch, retrying it until the retry duration elapses. The events of a batch which is dropped are
+// not acknowledged, so they are sent again after a restart.
+func (b *Batcher) send() {
+	defer b.resetFlushTimer()
+	defer b.reset()
+
+	startTime := time.Now()
+	retryCount := 0
+	for {
+		err := b.buffer.Put()
+		if err == nil {
+			b.batch.Ack()
+			b.Log.Debugf("Published %v log events of group: %v stream: %v to %v in %v.", b.batch.Len(), b.Group, b.Stream, b.Backend, time.Since(startTime))
+			return
+		}
+
+		b.Log.Errorf("Error received when sending logs of %v/%v to %v: %v", b.Group, b.Stream, b.Backend, err)
+
+		wait := RetryWait(retryCount)
+		if time.Since(startTime)+wait > b.RetryDuration {
+			b.Log.Errorf("All %v retries to %v for %v/%v failed, %v log events dropped.", retryCount, b.Backend, b.Group, b.Stream, b.batch.Len())
+			return
+		}
+
+		b.Log.Warnf("Retried %v time, going to sleep %v before retrying.", retryCount, wait)
+		select {
+		case <-time.After(wait):
+		case <-b.stop:
+			b.Log.Warnf("Stopped before the logs of %v/%v were sent to %v, %v log events dropped.", b.Group, b.Stream, b.Backend, b.batch.Len())
+			return
+		}
+		retryCount++
+	}
+}
+
+func (b *Batcher) reset() {
+	b.buffer.Reset()
+	b.batch.Reset()
+}
+
+func (b *Batcher) resetFlushTimer() {
+	b.flushTimer.Stop()
+	b.flushTimer.Reset(b.FlushTimeout)
+}
+
+// RetryWait returns the jittered exponential backoff before the retry n, from 200ms up to a minute
+func RetryWait(n int) time.Duration {
+	const base = 200 * time.Millisecond
+	const max = 1 * time.Minute
+	d := base * time.Duration(1<<int64(n))
+	if n > 5 {
+		d = max
+	}
+	seededRand.Lock()
+	defer seededRand.Unlock()
+	return time.Duration(seededRand.Int63n(int64(d/2)) + int64(d/2))
+}
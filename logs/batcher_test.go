@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBuffer struct {
+	sync.Mutex
+	limit    int
+	messages []string
+	puts     [][]string
+	errs     []error
+}
+
+func (b *testBuffer) Add(e LogEvent) (bool, error) {
+	b.Lock()
+	defer b.Unlock()
+	if e.Message() == "" {
+		return false, errors.New("empty message")
+	}
+	if len(b.messages) == b.limit {
+		return false, ErrBatchFull
+	}
+	b.messages = append(b.messages, e.Message())
+	return false, nil
+}
+
+func (b *testBuffer) Put() error {
+	b.Lock()
+	defer b.Unlock()
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return err
+	}
+	b.puts = append(b.puts, append([]string{}, b.messages...))
+	return nil
+}
+
+func (b *testBuffer) Reset() {
+	b.Lock()
+	defer b.Unlock()
+	b.messages = b.messages[:0]
+}
+
+func (b *testBuffer) putCount() int {
+	b.Lock()
+	defer b.Unlock()
+	return len(b.puts)
+}
+
+type syncEvent struct {
+	msg string
+	wg  *sync.WaitGroup
+}
+
+func (e syncEvent) Message() string { return e.msg }
+func (e syncEvent) Time() time.Time { return time.Time{} }
+func (e syncEvent) Done()           { e.wg.Done() }
+
+func TestBatcherSendsFullBatches(t *testing.T) {
+	buffer := &testBuffer{limit: 2}
+	b := NewBatcher(Target{Group: "G", Stream: "S"}, "test", buffer, 50*time.Millisecond, time.Minute, models.NewLogger("logs", "test", ""))
+	defer b.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	assert.NoError(t, b.Publish([]LogEvent{syncEvent{"a", &wg}, syncEvent{"b", &wg}, syncEvent{"c", &wg}}))
+	wg.Wait()
+
+	buffer.Lock()
+	defer buffer.Unlock()
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, buffer.puts)
+}
+
+func TestBatcherRetry(t *testing.T) {
+	buffer := &testBuffer{limit: 10, errs: []error{errors.New("throttled")}}
+	b := NewBatcher(Target{Group: "G", Stream: "S"}, "test", buffer, 10*time.Millisecond, time.Minute, models.NewLogger("logs", "test", ""))
+	defer b.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	// the event which cannot be added is dropped
+	assert.NoError(t, b.Publish([]LogEvent{syncEvent{"", &wg}, syncEvent{"a", &wg}}))
+	wg.Wait()
+	assert.Equal(t, 1, buffer.putCount())
+}
+
+func TestBatcherDropsAfterRetryDuration(t *testing.T) {
+	buffer := &testBuffer{limit: 10, errs: []error{errors.New("throttled")}}
+	b := NewBatcher(Target{Group: "G", Stream: "S"}, "test", buffer, 10*time.Millisecond, 0, models.NewLogger("logs", "test", ""))
+	defer b.Stop()
+
+	e := &testEvent{msg: "a"}
+	assert.NoError(t, b.Publish([]LogEvent{e}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, buffer.putCount())
+	// the dropped events are not acknowledged
+	assert.Equal(t, 0, e.done)
+}
+
+func TestBatcherStopped(t *testing.T) {
+	buffer := &testBuffer{limit: 10}
+	b := NewBatcher(Target{Group: "G", Stream: "S"}, "test", buffer, time.Hour, time.Minute, models.NewLogger("logs", "test", ""))
+	assert.NoError(t, b.Publish(nil))
+
+	b.Stop()
+	b.Stop()
+	var events []LogEvent
+	for i := 0; i < 200; i++ {
+		events = append(events, &testEvent{msg: "a"})
+	}
+	assert.Equal(t, ErrOutputStopped, b.Publish(events))
+}
+
+func TestRetryWait(t *testing.T) {
+	for n := 0; n < 10; n++ {
+		wait := RetryWait(n)
+		assert.True(t, wait >= 100*time.Millisecond && wait <= time.Minute, "retry %v waits %v", n, wait)
+	}
+	assert.True(t, RetryWait(10) >= 30*time.Second)
+}
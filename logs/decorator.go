@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import "log"
+
+// A LogDecorator is a processor which also adds its attributes to the log events, e.g. the key value pairs of the
+// metadata file. The LogAgent runs the messages of the log events through every decorator before their dests.
+type LogDecorator interface {
+	DecorateLog(message string) string
+}
+
+// findDecorators collects the processors which are log decorators, in the order of the processors
+func (l *LogAgent) findDecorators() {
+	l.decorators = nil
+	for _, processor := range l.Config.Processors {
+		if decorator, ok := processor.Processor.(LogDecorator); ok {
+			log.Printf("I! [logagent] found plugin %v is a log decorator", processor.Config.Name)
+			l.decorators = append(l.decorators, decorator)
+		}
+	}
+}
+
+// decorate returns the filter running the messages kept by the given filter through the decorators, the given
+// filter when there is no decorator
+func (l *LogAgent) decorate(filter func(string) (string, bool)) func(string) (string, bool) {
+	if len(l.decorators) == 0 {
+		return filter
+	}
+	decorators := l.decorators
+	return func(message string) (string, bool) {
+		if filter != nil {
+			var ok bool
+			if message, ok = filter(message); !ok {
+				return "", false
+			}
+		}
+		for _, d := range decorators {
+			message = d.DecorateLog(message)
+		}
+		return message, true
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// suffixProcessor is a processor appending its suffix to the messages of the log events
+type suffixProcessor struct {
+	suffix string
+}
+
+func (p *suffixProcessor) SampleConfig() string                          { return "" }
+func (p *suffixProcessor) Description() string                           { return "" }
+func (p *suffixProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric { return in }
+func (p *suffixProcessor) DecorateLog(message string) string             { return message + p.suffix }
+
+func TestLogAgentDecorators(t *testing.T) {
+	backend := &testBackend{messages: map[string][]string{}}
+	c := &config.Config{
+		Outputs: []*models.RunningOutput{{Output: backend, Config: &models.OutputConfig{Name: "test"}}},
+		Processors: models.RunningProcessors{
+			{Processor: &suffixProcessor{suffix: " a"}, Config: &models.ProcessorConfig{Name: "a"}},
+			{Processor: &suffixProcessor{suffix: " b"}, Config: &models.ProcessorConfig{Name: "b"}},
+		},
+	}
+	src := &replicatedSrc{
+		backfillSrc: backfillSrc{group: "app", stream: "host", destination: "test", messages: []string{"INFO started", "ERROR failed"}},
+		replicas:    []Replica{{Group: "pager", Stream: "host", Filter: errorsOnly}},
+	}
+
+	NewLogAgent(c).Backfill([]LogSrc{src})
+	// the decorators run in order, after the filter of the replica
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"INFO started a b", "ERROR failed a b"}, backend.stream("app/host")) &&
+			assert.ObjectsAreEqual([]string{"error failed a b"}, backend.stream("pager/host"))
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, map[string]int{"app/host": 0, "pager/host": 0}, backend.sources)
+}
@@ -0,0 +1,98 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// fanOutDest publishes every log event to all of its dests, the Done of the original
+// event is only called once all the dests have called Done on their copy.
+//
+// A dest which fails to publish the events is done with them, so that the other dests
+// still move the offset of the log source, and a dest which stopped is skipped afterwards.
+type fanOutDest struct {
+	dests   []LogDest
+	stopped map[LogDest]bool
+}
+
+func (f *fanOutDest) Publish(events []LogEvent) error {
+	shared := make([]*sharedEvent, len(events))
+	for i, e := range events {
+		shared[i] = &sharedEvent{LogEvent: e, pending: int32(len(f.dests))}
+	}
+
+	var errs []error
+	for _, d := range f.dests {
+		copies := make([]LogEvent, len(shared))
+		for i, e := range shared {
+			copies[i] = &destEvent{sharedEvent: e}
+		}
+		if f.stopped[d] {
+			doneAll(copies)
+			continue
+		}
+		err := d.Publish(copies)
+		if err == nil {
+			continue
+		}
+		doneAll(copies)
+		if err == ErrOutputStopped {
+			if f.stopped == nil {
+				f.stopped = make(map[LogDest]bool)
+			}
+			f.stopped[d] = true
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to publish to %v of %v destinations: %v", len(errs), len(f.dests), errs)
+	}
+	if len(f.stopped) == len(f.dests) {
+		return ErrOutputStopped
+	}
+	return nil
+}
+
+// close closes the dests which hold resources per log source, i.e. the isolated dests
+func (f *fanOutDest) close() {
+	for _, d := range f.dests {
+		if c, ok := d.(*isolatedDest); ok {
+			c.close()
+		}
+	}
+}
+
+func doneAll(events []LogEvent) {
+	for _, e := range events {
+		e.Done()
+	}
+}
+
+type sharedEvent struct {
+	LogEvent
+	pending int32
+}
+
+func (e *sharedEvent) Done() {
+	if atomic.AddInt32(&e.pending, -1) == 0 {
+		e.LogEvent.Done()
+	}
+}
+
+// destEvent is the copy of a shared event published to one dest, it is done at most once so that the
+// events a failed dest published before its error are not counted twice.
+type destEvent struct {
+	*sharedEvent
+	done int32
+}
+
+func (e *destEvent) Done() {
+	if atomic.CompareAndSwapInt32(&e.done, 0, 1) {
+		e.sharedEvent.Done()
+	}
+}
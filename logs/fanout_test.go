@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEvent struct {
+	msg  string
+	done int
+}
+
+func (e *testEvent) Message() string { return e.msg }
+func (e *testEvent) Time() time.Time { return time.Time{} }
+func (e *testEvent) Done()           { e.done++ }
+
+type testDest struct {
+	events []LogEvent
+	err    error
+}
+
+func (d *testDest) Publish(events []LogEvent) error {
+	d.events = append(d.events, events...)
+	return d.err
+}
+
+func TestFanOutDest(t *testing.T) {
+	d1, d2 := &testDest{}, &testDest{}
+	f := &fanOutDest{dests: []LogDest{d1, d2}}
+	e := &testEvent{msg: "hello"}
+
+	assert.NoError(t, f.Publish([]LogEvent{e}))
+	assert.Len(t, d1.events, 1)
+	assert.Len(t, d2.events, 1)
+	assert.Equal(t, "hello", d2.events[0].Message())
+
+	d1.events[0].Done()
+	assert.Equal(t, 0, e.done, "event should not be done before all destinations are done")
+	d2.events[0].Done()
+	assert.Equal(t, 1, e.done)
+}
+
+func TestFanOutDestStopped(t *testing.T) {
+	d1, d2 := &testDest{err: ErrOutputStopped}, &testDest{}
+	f := &fanOutDest{dests: []LogDest{d1, d2}}
+	e := &testEvent{}
+
+	// the other dest keeps publishing and moves the offset alone
+	assert.NoError(t, f.Publish([]LogEvent{e}))
+	assert.Len(t, d1.events, 1)
+	assert.Len(t, d2.events, 1)
+	d2.events[0].Done()
+	assert.Equal(t, 1, e.done)
+
+	// the stopped dest is skipped
+	assert.NoError(t, f.Publish([]LogEvent{&testEvent{}}))
+	assert.Len(t, d1.events, 1)
+	assert.Len(t, d2.events, 2)
+
+	d2.err = ErrOutputStopped
+	assert.Equal(t, ErrOutputStopped, f.Publish([]LogEvent{&testEvent{}}))
+}
+
+func TestFanOutDestError(t *testing.T) {
+	d1, d2, d3 := &testDest{err: errors.New("failed")}, &testDest{}, &testDest{}
+	f := &fanOutDest{dests: []LogDest{d1, d2, d3}}
+	e := &testEvent{}
+
+	err := f.Publish([]LogEvent{e})
+	assert.EqualError(t, err, "failed to publish to 1 of 3 destinations: [failed]")
+	assert.Len(t, d2.events, 1)
+	assert.Len(t, d3.events, 1)
+
+	// the failed dest counts as done, also when it calls Done on the event it published before failing
+	d1.events[0].Done()
+	d2.events[0].Done()
+	assert.Equal(t, 0, e.done)
+	d3.events[0].Done()
+	assert.Equal(t, 1, e.done)
+}
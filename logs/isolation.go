@@ -0,0 +1,195 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+)
+
+var (
+	// isolatedDestBufferSize is the number of log events buffered for every dest a log source is fanned out to
+	isolatedDestBufferSize = 1000
+	// isolatedDestBlockTimeout is how long an event waits for room in the buffer of a dest before its circuit opens
+	isolatedDestBlockTimeout = 5 * time.Second
+
+	circuits = struct {
+		sync.Mutex
+		dests map[*isolatedDest]struct{}
+	}{dests: make(map[*isolatedDest]struct{})}
+)
+
+// DestCircuit is the state of one dest a log source is fanned out to
+type DestCircuit struct {
+	Dest     string
+	Open     bool
+	Buffered int
+	Dropped  int64
+}
+
+// DestCircuits returns the state of the circuits of all the dests log sources are currently fanned out to
+func DestCircuits() []DestCircuit {
+	circuits.Lock()
+	defer circuits.Unlock()
+	states := make([]DestCircuit, 0, len(circuits.dests))
+	for d := range circuits.dests {
+		states = append(states, d.circuit())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Dest < states[j].Dest })
+	return states
+}
+
+// isolatedDest buffers the log events of a dest and publishes them from its own goroutine, so that a
+// wedged dest does not block the other dests the same log source is fanned out to.
+//
+// When the buffer stays full for isolatedDestBlockTimeout the circuit of the dest opens: the events are
+// dropped for this dest only, until its buffer has drained to half of its size. The dropped events are not
+// marked done and neither are the events published after them, so that the offset of the log source stays
+// behind the first dropped event and the events are read again after a restart.
+type isolatedDest struct {
+	name   string
+	dest   LogDest
+	events chan LogEvent
+	done   chan struct{}
+	err    error
+
+	mu      sync.Mutex
+	open    bool
+	held    bool
+	dropped int64
+}
+
+func newIsolatedDest(name string, dest LogDest) *isolatedDest {
+	d := &isolatedDest{
+		name:   name,
+		dest:   dest,
+		events: make(chan LogEvent, isolatedDestBufferSize),
+		done:   make(chan struct{}),
+	}
+	circuits.Lock()
+	circuits.dests[d] = struct{}{}
+	circuits.Unlock()
+	go d.run()
+	return d
+}
+
+func (d *isolatedDest) run() {
+	defer close(d.done)
+	for e := range d.events {
+		if err := d.dest.Publish([]LogEvent{e}); err != nil {
+			d.err = err
+			return
+		}
+	}
+}
+
+func (d *isolatedDest) Publish(events []LogEvent) error {
+	for _, e := range events {
+		select {
+		case <-d.done:
+			return d.err
+		default:
+		}
+
+		if d.isOpen() {
+			if len(d.events) > cap(d.events)/2 {
+				d.drop(e)
+				continue
+			}
+			d.closeCircuit()
+		}
+
+		if d.isHeld() {
+			e = heldEvent{e}
+		}
+		select {
+		case d.events <- e:
+			continue
+		case <-d.done:
+			return d.err
+		default:
+		}
+
+		timer := time.NewTimer(isolatedDestBlockTimeout)
+		select {
+		case d.events <- e:
+		case <-d.done:
+			timer.Stop()
+			return d.err
+		case <-timer.C:
+			d.openCircuit()
+			d.drop(e)
+		}
+		timer.Stop()
+	}
+	return nil
+}
+
+// close stops the publishing goroutine once the buffered events are published, it is called when the log source stops
+func (d *isolatedDest) close() {
+	circuits.Lock()
+	delete(circuits.dests, d)
+	circuits.Unlock()
+	close(d.events)
+}
+
+// drop drops the event without marking it done, it holds the offset of the log source before the event
+func (d *isolatedDest) drop(e LogEvent) {
+	d.mu.Lock()
+	if !d.held {
+		d.held = true
+		log.Printf("W! [logagent] Log destination %v dropped log events, the offset of the log source is held before them until a restart", d.name)
+	}
+	d.dropped++
+	d.mu.Unlock()
+	profiler.Profiler.AddStats([]string{"logagent", d.name, "dropped"}, 1)
+}
+
+func (d *isolatedDest) isHeld() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.held
+}
+
+func (d *isolatedDest) isOpen() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.open
+}
+
+func (d *isolatedDest) openCircuit() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.open {
+		d.open = true
+		log.Printf("W! [logagent] Log destination %v is not keeping up, dropping its log events until it catches up so that the other destinations are not blocked", d.name)
+	}
+}
+
+func (d *isolatedDest) closeCircuit() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.open {
+		d.open = false
+		log.Printf("I! [logagent] Log destination %v caught up, %v log events were dropped for it so far", d.name, d.dropped)
+	}
+}
+
+func (d *isolatedDest) circuit() DestCircuit {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return DestCircuit{Dest: d.name, Open: d.open, Buffered: len(d.events), Dropped: d.dropped}
+}
+
+// heldEvent is an event published after an event dropped by its dest, it is not marked done so that the offset
+// of its log source does not move past the dropped event.
+type heldEvent struct {
+	LogEvent
+}
+
+func (e heldEvent) Done() {}
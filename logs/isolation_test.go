@@ -0,0 +1,116 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wedgedDest blocks every publish until it is unwedged
+type wedgedDest struct {
+	sync.Mutex
+	unwedge chan struct{}
+	events  []LogEvent
+}
+
+func (d *wedgedDest) Publish(events []LogEvent) error {
+	<-d.unwedge
+	d.Lock()
+	defer d.Unlock()
+	d.events = append(d.events, events...)
+	for _, e := range events {
+		e.Done()
+	}
+	return nil
+}
+
+func (d *wedgedDest) count() int {
+	d.Lock()
+	defer d.Unlock()
+	return len(d.events)
+}
+
+// offsetState keeps the highest offset done, as the tailer src saves it into its state file
+type offsetState struct {
+	sync.Mutex
+	offset int
+}
+
+func (s *offsetState) get() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.offset
+}
+
+type offsetEvent struct {
+	offset int
+	state  *offsetState
+}
+
+func (e *offsetEvent) Message() string { return "" }
+func (e *offsetEvent) Time() time.Time { return time.Time{} }
+func (e *offsetEvent) Done() {
+	e.state.Lock()
+	defer e.state.Unlock()
+	if e.offset > e.state.offset {
+		e.state.offset = e.offset
+	}
+}
+
+func TestIsolatedDestWedged(t *testing.T) {
+	defer func(size int, timeout time.Duration) {
+		isolatedDestBufferSize, isolatedDestBlockTimeout = size, timeout
+	}(isolatedDestBufferSize, isolatedDestBlockTimeout)
+	isolatedDestBufferSize, isolatedDestBlockTimeout = 4, 10*time.Millisecond
+
+	wedged := &wedgedDest{unwedge: make(chan struct{})}
+	healthy := &wedgedDest{unwedge: make(chan struct{})}
+	close(healthy.unwedge)
+	w, h := newIsolatedDest("wedged", wedged), newIsolatedDest("healthy", healthy)
+	f := &fanOutDest{dests: []LogDest{w, h}}
+
+	state := &offsetState{}
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, f.Publish([]LogEvent{&offsetEvent{offset: i, state: state}}))
+	}
+	assert.Eventually(t, func() bool { return healthy.count() == 10 }, time.Second, time.Millisecond)
+
+	var wedgedState DestCircuit
+	for _, c := range DestCircuits() {
+		if c.Dest == "wedged" {
+			wedgedState = c
+		}
+	}
+	assert.True(t, wedgedState.Open)
+	assert.True(t, wedgedState.Dropped > 0)
+	// the events published by the healthy dest only are not done
+	assert.Equal(t, 0, state.get())
+
+	close(wedged.unwedge)
+	assert.Eventually(t, func() bool { return len(w.events) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, f.Publish([]LogEvent{&offsetEvent{offset: 11, state: state}}))
+	assert.False(t, w.isOpen())
+	assert.Eventually(t, func() bool { return healthy.count() == 11 && wedged.count() == 11-int(wedgedState.Dropped) }, time.Second, time.Millisecond)
+
+	// the offset stays before the first event the wedged dest dropped, also once it caught up
+	delivered := 10 - int(wedgedState.Dropped)
+	assert.Equal(t, delivered, state.get())
+
+	f.close()
+	assert.Empty(t, DestCircuits())
+}
+
+func TestIsolatedDestStopped(t *testing.T) {
+	d := newIsolatedDest("stopped", &testDest{err: ErrOutputStopped})
+	defer d.close()
+
+	require.NoError(t, d.Publish([]LogEvent{&testEvent{}}))
+	<-d.done
+	assert.Equal(t, ErrOutputStopped, d.Publish([]LogEvent{&testEvent{}}))
+}
@@ -6,14 +6,24 @@ package logs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal/toggle"
 	"github.com/influxdata/telegraf/config"
 )
 
 var ErrOutputStopped = errors.New("Output plugin stopped")
 
+const circuitReportInterval = time.Minute
+
+// DestinationSeparator separates the backend names when a LogSrc is piped to more than one backend,
+// e.g. "cloudwatchlogs,s3logs"
+const DestinationSeparator = ","
+
 // A LogCollection is a collection of LogSrc, a plugin which can provide many LogSrc
 type LogCollection interface {
 	FindLogSrc() []LogSrc
@@ -48,12 +58,20 @@ type LogDest interface {
 	Publish(events []LogEvent) error
 }
 
+// SourceCountingLogDest is a LogDest counting the LogSrc piped to it, which its backend adds in CreateDest. The
+// LogAgent removes the LogSrc once it ended or the dest stopped.
+type SourceCountingLogDest interface {
+	LogDest
+	RemoveSource()
+}
+
 // LogAgent is the agent handles pure log pipelines
 type LogAgent struct {
 	Config      *config.Config
 	backends    map[string]LogBackend
 	destNames   map[LogDest]string
 	collections []LogCollection
+	decorators  []LogDecorator
 }
 
 func NewLogAgent(c *config.Config) *LogAgent {
@@ -69,18 +87,8 @@ func NewLogAgent(c *config.Config) *LogAgent {
 // based on the configured "destination", and "name"
 func (l *LogAgent) Run(ctx context.Context) {
 	log.Printf("I! [logagent] starting")
-	for _, output := range l.Config.Outputs {
-		backend, ok := output.Output.(LogBackend)
-		if !ok {
-			continue
-		}
-		log.Printf("I! [logagent] found plugin %v is a log backend", output.Config.Name)
-		name := output.Config.Alias
-		if name == "" {
-			name = output.Config.Name
-		}
-		l.backends[name] = backend
-	}
+	l.findBackends()
+	l.findDecorators()
 
 	for _, input := range l.Config.Inputs {
 		if collection, ok := input.Input.(LogCollection); ok {
@@ -91,21 +99,21 @@ func (l *LogAgent) Run(ctx context.Context) {
 
 	t := time.NewTicker(time.Second)
 	defer t.Stop()
+	circuitReport := time.NewTicker(circuitReportInterval)
+	defer circuitReport.Stop()
 	for {
 		select {
+		case <-circuitReport.C:
+			reportOpenCircuits()
 		case <-t.C:
 			for _, c := range l.collections {
 				srcs := c.FindLogSrc()
 				for _, src := range srcs {
-					dname := src.Destination()
-					backend, ok := l.backends[dname]
-					if !ok {
-						log.Printf("E! [logagent] Failed to find destination %v for log source %v/%v(%v) ", dname, src.Group(), src.Stream(), src.Description())
+					dest := l.createDest(src)
+					if dest == nil {
 						continue
 					}
-					dest := backend.CreateDest(src.Group(), src.Stream())
-					l.destNames[dest] = dname
-					log.Printf("I! [logagent] piping log from %v/%v(%v) to %v", src.Group(), src.Stream(), src.Description(), dname)
+					log.Printf("I! [logagent] piping log from %v/%v(%v) to %v", src.Group(), src.Stream(), src.Description(), l.destNames[dest])
 					go l.runSrcToDest(src, dest)
 				}
 			}
@@ -115,9 +123,138 @@ func (l *LogAgent) Run(ctx context.Context) {
 	}
 }
 
+// Backfill pipes the log sources to their destinations and returns once all of them have ended. The sources of the
+// same log stream are piped one after the other in the order given, e.g. the rotated files oldest first, so that
+// their log events reach the stream in order.
+func (l *LogAgent) Backfill(srcs []LogSrc) {
+	l.findBackends()
+	l.findDecorators()
+
+	type piped struct {
+		src  LogSrc
+		dest LogDest
+	}
+	// the dests are all created before the piping starts, which only reads the names of the dests
+	var queues [][]piped
+	streams := make(map[string]int)
+	for _, src := range srcs {
+		dest := l.createDest(src)
+		if dest == nil {
+			src.Stop()
+			continue
+		}
+		key := src.Destination() + "/" + src.Group() + "/" + src.Stream()
+		i, ok := streams[key]
+		if !ok {
+			i = len(queues)
+			streams[key] = i
+			queues = append(queues, nil)
+		}
+		queues[i] = append(queues[i], piped{src, dest})
+	}
+
+	var wg sync.WaitGroup
+	for _, queue := range queues {
+		wg.Add(1)
+		go func(queue []piped) {
+			defer wg.Done()
+			for _, p := range queue {
+				log.Printf("I! [logagent] backfilling log from %v/%v(%v) to %v", p.src.Group(), p.src.Stream(), p.src.Description(), l.destNames[p.dest])
+				l.runSrcToDest(p.src, p.dest)
+			}
+		}(queue)
+	}
+	wg.Wait()
+}
+
+// findBackends collects the outputs which are log backends, named after their alias or their plugin name
+func (l *LogAgent) findBackends() {
+	for _, output := range l.Config.Outputs {
+		backend, ok := output.Output.(LogBackend)
+		if !ok {
+			continue
+		}
+		log.Printf("I! [logagent] found plugin %v is a log backend", output.Config.Name)
+		name := output.Config.Alias
+		if name == "" {
+			name = output.Config.Name
+		}
+		l.backends[name] = backend
+	}
+}
+
+// createDest returns the LogDest of every backend listed in the destination of the LogSrc, and of the replicas of
+// the LogSrc, the returned dest fans out the log events when there is more than one. Each of the fanned out dests
+// is isolated so that one which is wedged does not block the others.
+func (l *LogAgent) createDest(src LogSrc) LogDest {
+	dests, labels := l.createDests(src, src.Destination(), src.Group(), src.Stream(), nil)
+	if r, ok := src.(ReplicatedLogSrc); ok {
+		for _, replica := range r.Replicas() {
+			destination := replica.Destination
+			if destination == "" {
+				destination = src.Destination()
+			}
+			d, names := l.createDests(src, destination, replica.Group, replica.Stream, replica.Filter)
+			dests, labels = append(dests, d...), append(labels, names...)
+		}
+	}
+
+	switch len(dests) {
+	case 0:
+		return nil
+	case 1:
+		return dests[0]
+	default:
+		for i, dest := range dests {
+			dests[i] = newIsolatedDest(labels[i], dest)
+		}
+		dest := &fanOutDest{dests: dests}
+		l.destNames[dest] = src.Destination()
+		return dest
+	}
+}
+
+// createDests returns the LogDest of the group and stream of every backend listed in the destination, along with
+// their names in the logs, with the filter of the replica when it has one and the decorators
+func (l *LogAgent) createDests(src LogSrc, destination, group, stream string, filter func(string) (string, bool)) ([]LogDest, []string) {
+	var dests []LogDest
+	var labels []string
+	for _, dname := range strings.Split(destination, DestinationSeparator) {
+		backend, ok := l.backends[dname]
+		if !ok {
+			log.Printf("E! [logagent] Failed to find destination %v for log source %v/%v(%v) ", dname, group, stream, src.Description())
+			continue
+		}
+		dest := backend.CreateDest(group, stream)
+		if pd, ok := dest.(PrioritizedLogDest); ok {
+			pd.SetPriority(SrcPriority(src))
+		}
+		if filter := l.decorate(filter); filter != nil {
+			dest = &filterDest{dest: dest, filter: filter}
+		}
+		l.destNames[dest] = dname
+		dests = append(dests, dest)
+		labels = append(labels, fmt.Sprintf("%v(%v/%v)", dname, group, stream))
+	}
+	return dests, labels
+}
+
+// reportOpenCircuits logs the dests which are still dropping log events, the transitions are logged when they happen
+func reportOpenCircuits() {
+	for _, c := range DestCircuits() {
+		if c.Open {
+			log.Printf("W! [logagent] Log destination %v is still not keeping up, %v log events buffered and %v dropped", c.Dest, c.Buffered, c.Dropped)
+		}
+	}
+}
+
 func (l *LogAgent) runSrcToDest(src LogSrc, dest LogDest) {
 	eventsCh := make(chan LogEvent)
 	defer src.Stop()
+	defer removeSource(dest)
+	if f, ok := dest.(*fanOutDest); ok {
+		defer f.close()
+	}
 
 	src.SetOutput(func(e LogEvent) {
 		if e == nil {
@@ -128,7 +265,13 @@ func (l *LogAgent) runSrcToDest(src LogSrc, dest LogDest) {
 		eventsCh <- e
 	})
 
+	disabledName := toggle.LogName(src.Group())
 	for e := range eventsCh {
+		if toggle.Disabled(disabledName) {
+			// the event is done so the offset of the file moves past it
+			e.Done()
+			continue
+		}
 		err := dest.Publish([]LogEvent{e})
 		if err == ErrOutputStopped {
 			log.Printf("I! [logagent] Log destination %v has stopped, finalizing %v/%v", l.destNames[dest], src.Group(), src.Stream())
@@ -140,3 +283,19 @@ func (l *LogAgent) runSrcToDest(src LogSrc, dest LogDest) {
 		}
 	}
 }
+
+// removeSource removes the LogSrc from the dests counting their sources, through the dests wrapping them
+func removeSource(dest LogDest) {
+	switch d := dest.(type) {
+	case *fanOutDest:
+		for _, fd := range d.dests {
+			removeSource(fd)
+		}
+	case *isolatedDest:
+		removeSource(d.dest)
+	case *filterDest:
+		removeSource(d.dest)
+	case SourceCountingLogDest:
+		d.RemoveSource()
+	}
+}
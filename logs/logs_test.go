@@ -0,0 +1,116 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBackend is an output whose dests record the messages published to every stream
+type testBackend struct {
+	mu       sync.Mutex
+	messages map[string][]string
+	// sources are the log sources piped to every stream
+	sources map[string]int
+}
+
+func (b *testBackend) Connect() error                        { return nil }
+func (b *testBackend) Close() error                          { return nil }
+func (b *testBackend) Description() string                   { return "" }
+func (b *testBackend) SampleConfig() string                  { return "" }
+func (b *testBackend) Write(metrics []telegraf.Metric) error { return nil }
+
+func (b *testBackend) CreateDest(group, stream string) LogDest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sources == nil {
+		b.sources = make(map[string]int)
+	}
+	b.sources[group+"/"+stream]++
+	return &streamDest{backend: b, name: group + "/" + stream}
+}
+
+type streamDest struct {
+	backend *testBackend
+	name    string
+}
+
+func (d *streamDest) Publish(events []LogEvent) error {
+	d.backend.mu.Lock()
+	defer d.backend.mu.Unlock()
+	for _, e := range events {
+		d.backend.messages[d.name] = append(d.backend.messages[d.name], e.Message())
+	}
+	return nil
+}
+
+func (d *streamDest) RemoveSource() {
+	d.backend.mu.Lock()
+	defer d.backend.mu.Unlock()
+	d.backend.sources[d.name]--
+}
+
+// backfillSrc outputs its messages and ends
+type backfillSrc struct {
+	group, stream, destination string
+	messages                   []string
+	stopped                    bool
+}
+
+func (s *backfillSrc) SetOutput(fn func(LogEvent)) {
+	go func() {
+		for _, msg := range s.messages {
+			fn(&testEvent{msg: msg})
+		}
+		fn(nil)
+	}()
+}
+
+func (s *backfillSrc) Group() string       { return s.group }
+func (s *backfillSrc) Stream() string      { return s.stream }
+func (s *backfillSrc) Destination() string { return s.destination }
+func (s *backfillSrc) Description() string { return "test" }
+func (s *backfillSrc) Stop()               { s.stopped = true }
+
+func TestLogAgentBackfill(t *testing.T) {
+	backend := &testBackend{messages: map[string][]string{}}
+	c := &config.Config{Outputs: []*models.RunningOutput{{Output: backend, Config: &models.OutputConfig{Name: "test"}}}}
+	srcs := []*backfillSrc{
+		{group: "G", stream: "S", destination: "test", messages: []string{"1", "2"}},
+		{group: "G", stream: "T", destination: "test", messages: []string{"a"}},
+		{group: "G", stream: "S", destination: "test", messages: []string{"3"}},
+		{group: "G", stream: "S", destination: "missing", messages: []string{"x"}},
+	}
+	var logSrcs []LogSrc
+	for _, src := range srcs {
+		logSrcs = append(logSrcs, src)
+	}
+
+	NewLogAgent(c).Backfill(logSrcs)
+	// the sources of the same stream are piped in order
+	assert.Equal(t, []string{"1", "2", "3"}, backend.messages["G/S"])
+	assert.Equal(t, []string{"a"}, backend.messages["G/T"])
+	for _, src := range srcs {
+		assert.True(t, src.stopped)
+	}
+	// the sources are removed from the dests once they ended
+	assert.Equal(t, map[string]int{"G/S": 0, "G/T": 0}, backend.sources)
+}
+
+func TestRemoveSource(t *testing.T) {
+	backend := &testBackend{messages: map[string][]string{}}
+	isolated := newIsolatedDest("test", &filterDest{dest: backend.CreateDest("G", "S"), filter: nil})
+	defer isolated.close()
+	f := &fanOutDest{dests: []LogDest{isolated, backend.CreateDest("G", "T"), &testDest{}}}
+	assert.Equal(t, map[string]int{"G/S": 1, "G/T": 1}, backend.sources)
+
+	removeSource(f)
+	assert.Equal(t, map[string]int{"G/S": 0, "G/T": 0}, backend.sources)
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+// The priority classes of the log sources. When a destination is under memory pressure the critical log events,
+// e.g. audit or security logs, are published first, the normal ones wait for room and the bulk ones are deferred
+// or sampled.
+const (
+	PriorityCritical = "critical"
+	PriorityNormal   = "normal"
+	PriorityBulk     = "bulk"
+)
+
+// PrioritizedLogSrc is a LogSrc with a priority class, the LogSrc without one are normal
+type PrioritizedLogSrc interface {
+	LogSrc
+	Priority() string
+}
+
+// PrioritizedLogDest is a LogDest publishing by priority class, the LogAgent sets the priority of every LogSrc
+// piped to it. A LogDest shared by several LogSrc keeps the highest of their priorities.
+type PrioritizedLogDest interface {
+	LogDest
+	SetPriority(priority string)
+}
+
+// SrcPriority returns the priority class of the LogSrc
+func SrcPriority(src LogSrc) string {
+	if p, ok := src.(PrioritizedLogSrc); ok && IsPriority(p.Priority()) {
+		return p.Priority()
+	}
+	return PriorityNormal
+}
+
+// IsPriority returns whether the priority is a known priority class
+func IsPriority(priority string) bool {
+	switch priority {
+	case PriorityCritical, PriorityNormal, PriorityBulk:
+		return true
+	}
+	return false
+}
+
+// HigherPriority returns the higher of the priority classes, an empty one is lower than all of them
+func HigherPriority(a, b string) string {
+	if PriorityRank(b) > PriorityRank(a) {
+		return b
+	}
+	return a
+}
+
+// PriorityRank orders the priority classes, from 0 for bulk to 2 for critical, the unknown ones are normal and an
+// empty one is -1
+func PriorityRank(priority string) int {
+	switch priority {
+	case PriorityCritical:
+		return 2
+	case PriorityBulk:
+		return 0
+	case "":
+		return -1
+	}
+	return 1
+}
+
+// WithPriority returns the LogSrc with the priority class, it is returned as is when the priority is empty
+func WithPriority(src LogSrc, priority string) LogSrc {
+	if priority == "" {
+		return src
+	}
+	return &prioritizedSrc{LogSrc: src, priority: priority}
+}
+
+type prioritizedSrc struct {
+	LogSrc
+	priority string
+}
+
+func (s *prioritizedSrc) Priority() string {
+	return s.priority
+}
@@ -0,0 +1,30 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSrc struct {
+	LogSrc
+}
+
+func TestSrcPriority(t *testing.T) {
+	src := &testSrc{}
+	assert.Equal(t, PriorityNormal, SrcPriority(src))
+	assert.Equal(t, src, WithPriority(src, ""))
+	assert.Equal(t, PriorityCritical, SrcPriority(WithPriority(src, PriorityCritical)))
+	assert.Equal(t, PriorityBulk, SrcPriority(WithPriority(src, PriorityBulk)))
+	assert.Equal(t, PriorityNormal, SrcPriority(WithPriority(src, "urgent")))
+}
+
+func TestHigherPriority(t *testing.T) {
+	assert.Equal(t, PriorityCritical, HigherPriority(PriorityNormal, PriorityCritical))
+	assert.Equal(t, PriorityNormal, HigherPriority(PriorityNormal, PriorityBulk))
+	assert.Equal(t, PriorityBulk, HigherPriority("", PriorityBulk))
+	assert.Equal(t, PriorityBulk, HigherPriority(PriorityBulk, PriorityBulk))
+}
@@ -0,0 +1,56 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+// A Replica is a copy of the log events of a LogSrc sent to another log group and stream, e.g. only the errors to
+// the group alarmed on by the on-call
+type Replica struct {
+	Group, Stream string
+	// Destination is the backend of the replica, the destination of the LogSrc when empty
+	Destination string
+	// Filter returns the message of the log event as it is sent to the replica, or false when the log event is not
+	// sent to it. The log events are all sent as they are when it is nil.
+	Filter func(message string) (string, bool)
+}
+
+// A ReplicatedLogSrc is a LogSrc whose log events are also sent to its replicas, each through its own filter
+type ReplicatedLogSrc interface {
+	LogSrc
+	Replicas() []Replica
+}
+
+// filterDest publishes the log events kept by its filter to its dest, the other log events are done right away
+type filterDest struct {
+	dest   LogDest
+	filter func(message string) (string, bool)
+}
+
+func (d *filterDest) Publish(events []LogEvent) error {
+	kept := make([]LogEvent, 0, len(events))
+	for _, e := range events {
+		message, ok := d.filter(e.Message())
+		if !ok {
+			e.Done()
+			continue
+		}
+		if message != e.Message() {
+			e = &replicaEvent{LogEvent: e, message: message}
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return d.dest.Publish(kept)
+}
+
+// replicaEvent is a log event whose message was transformed by the filter of a replica
+type replicaEvent struct {
+	LogEvent
+	message string
+}
+
+func (e *replicaEvent) Message() string {
+	return e.message
+}
@@ -0,0 +1,75 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func errorsOnly(message string) (string, bool) {
+	if !strings.Contains(message, "ERROR") {
+		return "", false
+	}
+	return strings.ToLower(message), true
+}
+
+func TestFilterDest(t *testing.T) {
+	d := &testDest{}
+	f := &filterDest{dest: d, filter: errorsOnly}
+	info, failure := &testEvent{msg: "INFO started"}, &testEvent{msg: "ERROR failed"}
+
+	assert.NoError(t, f.Publish([]LogEvent{info, failure}))
+	assert.Equal(t, 1, info.done, "the filtered out event is done")
+	if assert.Len(t, d.events, 1) {
+		assert.Equal(t, "error failed", d.events[0].Message())
+		d.events[0].Done()
+	}
+	assert.Equal(t, 1, failure.done)
+}
+
+func (b *testBackend) stream(name string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.messages[name]...)
+}
+
+type replicatedSrc struct {
+	backfillSrc
+	replicas []Replica
+}
+
+func (s *replicatedSrc) Replicas() []Replica { return s.replicas }
+
+func TestLogAgentReplicas(t *testing.T) {
+	backend, other := &testBackend{messages: map[string][]string{}}, &testBackend{messages: map[string][]string{}}
+	c := &config.Config{Outputs: []*models.RunningOutput{
+		{Output: backend, Config: &models.OutputConfig{Name: "test"}},
+		{Output: other, Config: &models.OutputConfig{Name: "other"}},
+	}}
+	src := &replicatedSrc{
+		backfillSrc: backfillSrc{group: "app", stream: "host", destination: "test", messages: []string{"INFO started", "ERROR failed"}},
+		replicas: []Replica{
+			{Group: "ops", Stream: "host"},
+			{Group: "pager", Stream: "host", Filter: errorsOnly},
+			{Group: "archive", Stream: "host", Destination: "other"},
+		},
+	}
+
+	NewLogAgent(c).Backfill([]LogSrc{src})
+	// the fanned out dests are isolated, they publish asynchronously
+	all := []string{"INFO started", "ERROR failed"}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(all, backend.stream("app/host")) &&
+			assert.ObjectsAreEqual(all, backend.stream("ops/host")) &&
+			assert.ObjectsAreEqual([]string{"error failed"}, backend.stream("pager/host")) &&
+			assert.ObjectsAreEqual(all, other.stream("archive/host"))
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, src.stopped)
+}
@@ -3,6 +3,10 @@
 
 package distribution
 
+// Distribution is the value of a field of the samples of a metric, rather than of a single value. The inputs add it
+// to their fields, e.g. the timings of statsd, the cloudwatch output merges the ones of the aggregation interval of
+// the metric, its aws:AggregationInterval tag, and publishes their values and counts, and the cloudwatchlogs output
+// publishes the ones of every metric in the EMF documents.
 type Distribution interface {
 	Maximum() float64
 
@@ -14,6 +14,14 @@ Param (
     [switch]$Start = $false,
     [Parameter(Mandatory = $false)]
     [string]$Mode = 'ec2',
+    [Parameter(Mandatory = $false)]
+    [string]$ProfileName = '',
+    [Parameter(Mandatory = $false)]
+    [string]$LogLevel = '',
+    [Parameter(Mandatory = $false)]
+    [string]$Target = '',
+    [Parameter(Mandatory = $false)]
+    [switch]$Keep = $false,
     [parameter(ValueFromRemainingArguments=$true)]
     $unsupportedVars
 )
@@ -24,7 +32,7 @@ $ErrorActionPreference = "Stop"
 $UsageString = @"
 
 
-        usage: amazon-cloudwatch-agent-ctl.ps1 -a stop|start|status|fetch-config|append-config|remove-config [-m ec2|onPremise|auto] [-c default|all|ssm:<parameter-store-name>|file:<file-path>] [-o default|all|ssm:           <parameter-store-name>|file:<file-path>] [-s]
+        usage: amazon-cloudwatch-agent-ctl.ps1 -a stop|start|status|fetch-config|append-config|remove-config|save-profile|switch-profile|rollback-profile|list-profiles|delete-profile|decommission|snapshot|flush|reload|set-log-level|disable|enable [-m ec2|onPremise|auto] [-c default|all|ssm:<parameter-store-name>|file:<file-path>] [-o default|all|ssm:           <parameter-store-name>|file:<file-path>] [-p <profile-name>] [-l debug|info|warn|error] [-t inputs.<input>|logs.<log-group-name>] [-k] [-s]
 
         e.g.
         1. apply a SSM parameter store config on EC2 instance and restart the agent afterwards:
@@ -33,6 +41,15 @@ $UsageString = @"
             amazon-cloudwatch-agent-ctl.ps1 -a append-config -m onPremise -c file:c:\config.json -s
         3. query agent status:
             amazon-cloudwatch-agent-ctl.ps1 -a status
+        4. save a SSM parameter store config as the profile canary and make it the active config:
+            amazon-cloudwatch-agent-ctl.ps1 -a save-profile -m ec2 -p canary -c ssm:AmazonCloudWatch-Canary.json
+            amazon-cloudwatch-agent-ctl.ps1 -a switch-profile -m ec2 -p canary
+        5. print the metrics the active config publishes, without waiting for them in CloudWatch:
+            amazon-cloudwatch-agent-ctl.ps1 -a snapshot
+        6. log the debug messages of the running agent until its next reload:
+            amazon-cloudwatch-agent-ctl.ps1 -a set-log-level -l debug
+        7. mute the log events of a noisy log group, also after the restarts of the agent:
+            amazon-cloudwatch-agent-ctl.ps1 -a disable -t logs.my-debug-log -k
 
         -a: action
             stop:                                   stop both amazon-cloudwatch-agent and cwagent-otel-collector if running.
@@ -41,6 +58,18 @@ $UsageString = @"
             fetch-config:                           apply config for agent, followed by -c or -o or both. Target config can be based on location (ssm parameter store name, file name), or 'default'.
             append-config:                          append json config with the existing json configs if any, followed by -c. Target config can be based on the location (ssm parameter store name, file name), or 'default'.
             remove-config:                          remove config for agent, followed by -c or -o or both. Target config can be based on the location (ssm parameter store name, file name), or 'all'.
+            save-profile:                           validate and save an amazon-cloudwatch-agent config as a named profile, followed by -p. The config is fetched from -c, or is a copy of the active json configs when -c is absent.
+            switch-profile:                         make the profile given by -p the active amazon-cloudwatch-agent config: validate and translate it, stop the agent to flush what it has buffered, swap the configs and start the agent again if it was running.
+            rollback-profile:                       switch back to the amazon-cloudwatch-agent config which was active before the last switch-profile.
+            list-profiles:                          list the saved profiles, the active one is marked with '*'.
+            delete-profile:                         delete the profile given by -p, the active profile cannot be deleted.
+            decommission:                           stop the agents and delete the resources amazon-cloudwatch-agent manages for the host, e.g. its alarms, before it is terminated.
+            snapshot:                               collect the metrics of the active amazon-cloudwatch-agent config once and print the ones it publishes, with their names, dimensions, values and units, without publishing them.
+            flush:                                  make the running amazon-cloudwatch-agent send the metrics and the log events it has buffered now.
+            reload:                                 make the running amazon-cloudwatch-agent reload its translated config without restarting the process.
+            set-log-level:                          change the log level of the running amazon-cloudwatch-agent to the one given by -l, until its next reload.
+            disable:                                drop what the target given by -t produces from now on, without restarting the running amazon-cloudwatch-agent.
+            enable:                                 undo disable for the target given by -t.
 
         -m: mode
             ec2:                                    indicate this is on ec2 host.
@@ -59,8 +88,21 @@ $UsageString = @"
             file:<file-path>:                       file path on the host.
             all:                                    all existing configs. Only apply to remove-config action.
 
+        -p: profile name
+            <profile-name>:                         letters, digits, '.', '_' and '-', not starting with '.'. Only apply to the profile actions.
+
+        -l: log level
+            debug|info|warn|error:                  the log level. Only apply to the set-log-level action.
+
+        -t: target
+            inputs.<input>:                         the metrics of the input, by its name or its alias, e.g. inputs.cpu.
+            logs.<log-group-name>:                  the log events sent to the log group.
+            this parameter is used for 'disable', 'enable' action only.
+
+        -k: optionally keep the change of 'disable' or 'enable' after the restarts of the agent
+
         -s: optionally restart after configuring the agent configuration
-            this parameter is used for 'fetch-config', 'append-config', 'remove-config' action only.
+            this parameter is used for 'fetch-config', 'append-config', 'remove-config', 'switch-profile' action only.
 
 
 "@
@@ -95,6 +137,12 @@ $YAML="${CWAProgramData}\${CWOCServiceName}\cwagent-otel-collector.yaml"
 $YAML_DIR="${CWAProgramData}\${CWOCServiceName}\Configs"
 $PREDEFINED_CONFIG_DATA="${CWAProgramData}\${CWOCServiceName}\predefined-config-data"
 $COMMON_CONIG="${CWAProgramData}\common-config.toml"
+$ENV_CONFIG="${CWAProgramData}\env-config.json"
+$PROFILES_DIR="${CWAProgramData}\Profiles"
+$ACTIVE_PROFILE_FILE="${PROFILES_DIR}\.active"
+$ROLLBACK_PROFILE='.rollback'
+# Written by the running amazon-cloudwatch-agent with the address and the token of its control API
+$CONTROL_FILE="${CWAProgramData}\control.json"
 
 $EC2 = $false
 # WMI is unavailable on Nano, CIM is unavailable on 2003
@@ -147,6 +195,10 @@ Function AgentStart() {
         if ($CIM) {
             & sc.exe failureflag "${service_name}" 1 | Out-Null
         }
+        # Register the event source the agent writes its start, stop and config error events with
+        if (${service_name} -eq $CWAServiceName -And ![System.Diagnostics.EventLog]::SourceExists("${service_name}")) {
+            New-EventLog -LogName Application -Source "${service_name}"
+        }
     }
     if (${service_name} -eq $CWOCServiceName) {
         $svc | Set-Service -StartupType Automatic -PassThru | Start-Service
@@ -164,6 +216,57 @@ Function StopAll() {
     AgentStop -service_name $CWAServiceName
 }
 
+# DecommissionAll stops the agents and deletes what the outputs of amazon-cloudwatch-agent manage for the host, e.g. the
+# alarms of the cloudwatch output, with the active config
+Function DecommissionAll() {
+    StopAll
+
+    Write-Output "`r`n****** decommissioning amazon-cloudwatch-agent ******"
+    if (!(Test-Path -LiteralPath "${TOML}")) {
+        Write-Output "amazon-cloudwatch-agent is not configured, there is nothing to decommission"
+        return
+    }
+    & "${CWAProgramFiles}\amazon-cloudwatch-agent.exe" -decommission -config "${TOML}"
+    if ($LASTEXITCODE -ne 0) {
+        Write-Output "Failed to decommission amazon-cloudwatch-agent"
+        Exit 1
+    }
+}
+
+# Snapshot collects the metrics of the active config once and prints the datums amazon-cloudwatch-agent publishes for
+# them, without publishing them
+Function Snapshot() {
+    if (!(Test-Path -LiteralPath "${TOML}")) {
+        Write-Output "amazon-cloudwatch-agent is not configured, there is nothing to snapshot"
+        Exit 1
+    }
+    & "${CWAProgramFiles}\amazon-cloudwatch-agent.exe" -snapshot -config "${TOML}"
+    if ($LASTEXITCODE -ne 0) {
+        Write-Output "Failed to snapshot the metrics of amazon-cloudwatch-agent"
+        Exit 1
+    }
+}
+
+# AgentControl runs the verb of the control API of the running amazon-cloudwatch-agent, e.g. flush
+Function AgentControl() {
+    Param (
+        [Parameter(Mandatory = $true)]
+        [string]$verb,
+        [Parameter(Mandatory = $false)]
+        [string[]]$arguments = @()
+    )
+
+    if (!(Test-Path -LiteralPath "${CONTROL_FILE}")) {
+        Write-Output "amazon-cloudwatch-agent is not running"
+        Exit 1
+    }
+    & "${CWAProgramFiles}\amazon-cloudwatch-agent.exe" -control-file "${CONTROL_FILE}" control "${verb}" @arguments
+    if ($LASTEXITCODE -ne 0) {
+        Write-Output "Failed to ${verb} amazon-cloudwatch-agent"
+        Exit 1
+    }
+}
+
 Function AgentStop() {
     Param (
         [Parameter(Mandatory = $true)]
@@ -256,6 +359,7 @@ Function StatusAll() {
     }
 
     $version = ([IO.File]::ReadAllText("${VersionFile}")).Trim()
+    $profile_name = GetActiveProfile
 
     Write-Output "{"
     Write-Output "  `"status`": `"${cwa_status}`","
@@ -264,6 +368,7 @@ Function StatusAll() {
     Write-Output "  `"cwoc_status`": `"${cwoc_status}`","
     Write-Output "  `"cwoc_starttime`": `"${cwoc_starttime}`","
     Write-Output "  `"cwoc_configstatus`": `"${cwoc_config_status}`","
+    Write-Output "  `"profile`": `"${profile_name}`","
     Write-Output "  `"version`": `"${version}`""
     Write-Output "}"
 }
@@ -401,6 +506,9 @@ Function CWAConfig() {
         }
     }
 
+    # the active config no longer is the one of a saved profile
+    Remove-Item -LiteralPath "${ACTIVE_PROFILE_FILE}" -Force -ErrorAction SilentlyContinue
+
     if ($Start) {
         AgentStop -service_name $CWAServiceName
         AgentStart -service_name $CWAServiceName -service_display_name $CWAServiceDisplayName
@@ -468,6 +576,224 @@ Function CWOCConfig() {
     }
 }
 
+# A profile is a directory of ${PROFILES_DIR} holding the json configs of amazon-cloudwatch-agent in the same layout as
+# ${CWAProgramData}, along with the toml and the env-config.json translated from them when the profile was last validated.
+Function CheckProfileName() {
+    if (!($ProfileName -cmatch '^[A-Za-z0-9_-][A-Za-z0-9._-]*$')) {
+        Write-Output "Invalid profile name: '${ProfileName}', it must consist of letters, digits, '.', '_' and '-' and must not start with '.'`n${UsageString}"
+        exit 1
+    }
+}
+
+Function GetActiveProfile() {
+    if (Test-Path -LiteralPath "${ACTIVE_PROFILE_FILE}") {
+        return ([IO.File]::ReadAllText("${ACTIVE_PROFILE_FILE}")).Trim()
+    }
+    return ''
+}
+
+# copy the validated json configs of the directory holding them into the profile directory
+Function ProfileCopyConfigs() {
+    Param (
+        [Parameter(Mandatory = $true)]
+        [string]$from_json,
+        [Parameter(Mandatory = $true)]
+        [string]$from_json_dir,
+        [Parameter(Mandatory = $true)]
+        [string]$to_dir
+    )
+
+    New-Item -ItemType Directory -Force -Path "${to_dir}\Configs" | Out-Null
+    if (Test-Path -LiteralPath "${from_json}") {
+        Copy-Item -LiteralPath "${from_json}" -Destination "${to_dir}\amazon-cloudwatch-agent.json" -Force
+    }
+    if (Test-Path -LiteralPath "${from_json_dir}") {
+        Get-ChildItem "${from_json_dir}\*" -Exclude "*.tmp" | Where-Object { !$_.PSIsContainer } | Copy-Item -Destination "${to_dir}\Configs" -Force
+    }
+}
+
+# translate the json configs of the profile and check the result the same way as CWAConfig does,
+# the directory is removed on failure when it is not a saved profile yet
+Function ProfileValidate() {
+    Param (
+        [Parameter(Mandatory = $true)]
+        [string]$validate_dir,
+        [Parameter(Mandatory = $false)]
+        [switch]$remove_on_failure = $false
+    )
+
+    $param_mode = GetParamMode
+    $failed = $false
+    $configs = Get-ChildItem "${validate_dir}\Configs" | Measure-Object
+    if (!(Test-Path -LiteralPath "${validate_dir}\amazon-cloudwatch-agent.json") -And $configs.count -eq 0) {
+        Write-Output "${validate_dir} holds no amazon-cloudwatch-agent configuration"
+        $failed = $true
+    } else {
+        Write-Output "Start configuration validation of ${validate_dir}..."
+        & cmd /c "`"$CWAProgramFiles\config-translator.exe`" --input ${validate_dir}\amazon-cloudwatch-agent.json --input-dir ${validate_dir}\Configs --output ${validate_dir}\amazon-cloudwatch-agent.toml --mode ${param_mode} --config ${COMMON_CONIG} --multi-config remove 2>&1"
+        $failed = $LASTEXITCODE -ne 0
+    }
+    if (!$failed) {
+        $ErrorActionPreference = "Continue"
+        & cmd /c "`"${CWAProgramFiles}\amazon-cloudwatch-agent.exe`" --schematest --config ${validate_dir}\amazon-cloudwatch-agent.toml 2>&1" | Out-File $CVLogFile
+        $ErrorActionPreference = "Stop"
+        if ($LASTEXITCODE -ne 0) {
+            Write-Output "Configuration validation second phase failed"
+            Write-Output "======== Error Log ========"
+            cat $CVLogFile
+            $failed = $true
+        }
+    }
+    if ($failed) {
+        if ($remove_on_failure) {
+            Remove-Item -LiteralPath "${validate_dir}" -Recurse -Force -ErrorAction SilentlyContinue
+        }
+        exit 1
+    }
+    Write-Output "Configuration validation succeeded"
+}
+
+Function GetParamMode() {
+    if ($EC2) {
+        return "ec2"
+    }
+    return "onPrem"
+}
+
+Function ProfileSave() {
+    CheckProfileName
+    $param_mode = GetParamMode
+    $profile_dir = "${PROFILES_DIR}\${ProfileName}"
+    $tmp_dir = "${PROFILES_DIR}\.${ProfileName}.tmp"
+
+    Remove-Item -LiteralPath "${tmp_dir}" -Recurse -Force -ErrorAction SilentlyContinue
+    New-Item -ItemType Directory -Force -Path "${tmp_dir}\Configs" | Out-Null
+    if ($ConfigLocation) {
+        & $CWAProgramFiles\config-downloader.exe --output-dir "${tmp_dir}\Configs" --download-source "${ConfigLocation}" --mode "${param_mode}" --config "${COMMON_CONIG}" --multi-config default
+        if ($LASTEXITCODE -ne 0) {
+            Remove-Item -LiteralPath "${tmp_dir}" -Recurse -Force
+            exit 1
+        }
+        Get-ChildItem "${tmp_dir}\Configs\*.tmp" | Rename-Item -NewName { $_.name -Replace '\.tmp$','' }
+    } else {
+        ProfileCopyConfigs -from_json "${JSON}" -from_json_dir "${JSON_DIR}" -to_dir "${tmp_dir}"
+    }
+
+    ProfileValidate -validate_dir "${tmp_dir}" -remove_on_failure
+    Remove-Item -LiteralPath "${profile_dir}" -Recurse -Force -ErrorAction SilentlyContinue
+    Rename-Item -LiteralPath "${tmp_dir}" -NewName "${ProfileName}"
+    Write-Output "profile ${ProfileName} has been saved"
+}
+
+# ProfileActivate validates the profile, stops the agent so that it flushes what it has buffered, swaps the active
+# config with the one of the profile and starts the agent again. The config which was active is kept for rollback-profile.
+Function ProfileActivate() {
+    Param (
+        [Parameter(Mandatory = $true)]
+        [string]$profile_dir,
+        [Parameter(Mandatory = $false)]
+        [string]$profile_name = ''
+    )
+
+    ProfileValidate -validate_dir "${profile_dir}"
+
+    $rollback_tmp_dir = "${PROFILES_DIR}\${ROLLBACK_PROFILE}.tmp"
+    Remove-Item -LiteralPath "${rollback_tmp_dir}" -Recurse -Force -ErrorAction SilentlyContinue
+    ProfileCopyConfigs -from_json "${JSON}" -from_json_dir "${JSON_DIR}" -to_dir "${rollback_tmp_dir}"
+    [IO.File]::WriteAllText("${rollback_tmp_dir}\.name", (GetActiveProfile))
+
+    $restart = $Start -Or ((Runstatus -service_name $CWAServiceName) -eq 'running')
+    AgentStop -service_name $CWAServiceName
+
+    # everything is copied next to the active config first so that the swap itself only consists of renames
+    Remove-Item -LiteralPath "${JSON_DIR}.new", "${JSON_DIR}.old" -Recurse -Force -ErrorAction SilentlyContinue
+    Copy-Item -LiteralPath "${profile_dir}\Configs" -Destination "${JSON_DIR}.new" -Recurse
+    Copy-Item -LiteralPath "${profile_dir}\amazon-cloudwatch-agent.toml" -Destination "${TOML}.new" -Force
+    if (Test-Path -LiteralPath "${profile_dir}\env-config.json") {
+        Copy-Item -LiteralPath "${profile_dir}\env-config.json" -Destination "${ENV_CONFIG}" -Force
+    }
+    if (Test-Path -LiteralPath "${JSON_DIR}") {
+        Move-Item -LiteralPath "${JSON_DIR}" -Destination "${JSON_DIR}.old"
+    }
+    Move-Item -LiteralPath "${JSON_DIR}.new" -Destination "${JSON_DIR}"
+    Move-Item -LiteralPath "${TOML}.new" -Destination "${TOML}" -Force
+    if (Test-Path -LiteralPath "${profile_dir}\amazon-cloudwatch-agent.json") {
+        Copy-Item -LiteralPath "${profile_dir}\amazon-cloudwatch-agent.json" -Destination "${JSON}" -Force
+    } else {
+        Remove-Item -LiteralPath "${JSON}" -Force -ErrorAction SilentlyContinue
+    }
+    Remove-Item -LiteralPath "${JSON_DIR}.old" -Recurse -Force -ErrorAction SilentlyContinue
+
+    if ($profile_name) {
+        [IO.File]::WriteAllText("${ACTIVE_PROFILE_FILE}", "${profile_name}")
+    } else {
+        Remove-Item -LiteralPath "${ACTIVE_PROFILE_FILE}" -Force -ErrorAction SilentlyContinue
+    }
+    Remove-Item -LiteralPath "${PROFILES_DIR}\${ROLLBACK_PROFILE}" -Recurse -Force -ErrorAction SilentlyContinue
+    Rename-Item -LiteralPath "${rollback_tmp_dir}" -NewName "${ROLLBACK_PROFILE}"
+    if ($profile_name) {
+        Write-Output "the active amazon-cloudwatch-agent config has been switched to ${profile_name}"
+    } else {
+        Write-Output "the active amazon-cloudwatch-agent config has been switched to the config active before the last switch"
+    }
+
+    if ($restart) {
+        AgentStart -service_name $CWAServiceName -service_display_name $CWAServiceDisplayName
+    }
+}
+
+Function ProfileSwitch() {
+    CheckProfileName
+    if (!(Test-Path -LiteralPath "${PROFILES_DIR}\${ProfileName}")) {
+        Write-Output "profile ${ProfileName} does not exist"
+        exit 1
+    }
+    ProfileActivate -profile_dir "${PROFILES_DIR}\${ProfileName}" -profile_name "${ProfileName}"
+}
+
+Function ProfileRollback() {
+    $rollback_dir = "${PROFILES_DIR}\${ROLLBACK_PROFILE}"
+    if (!(Test-Path -LiteralPath "${rollback_dir}")) {
+        Write-Output "no profile has been switched to yet, there is nothing to roll back"
+        exit 1
+    }
+    $profile_name = ''
+    if (Test-Path -LiteralPath "${rollback_dir}\.name") {
+        $profile_name = ([IO.File]::ReadAllText("${rollback_dir}\.name")).Trim()
+    }
+    ProfileActivate -profile_dir "${rollback_dir}" -profile_name "${profile_name}"
+}
+
+Function ProfileList() {
+    $active = GetActiveProfile
+    $profiles = @(Get-ChildItem "${PROFILES_DIR}" -ErrorAction SilentlyContinue | Where-Object { $_.PSIsContainer -And !$_.Name.StartsWith('.') })
+    if ($profiles.count -eq 0) {
+        Write-Output "no profile has been saved"
+        return
+    }
+    $profiles | ForEach-Object {
+        if ($_.Name -eq $active) {
+            Write-Output "* $($_.Name)"
+        } else {
+            Write-Output "  $($_.Name)"
+        }
+    }
+}
+
+Function ProfileDelete() {
+    CheckProfileName
+    if ($ProfileName -eq (GetActiveProfile)) {
+        Write-Output "profile ${ProfileName} is active and cannot be deleted"
+        exit 1
+    }
+    if (!(Test-Path -LiteralPath "${PROFILES_DIR}\${ProfileName}")) {
+        Write-Output "profile ${ProfileName} does not exist"
+        exit 1
+    }
+    Remove-Item -LiteralPath "${PROFILES_DIR}\${ProfileName}" -Recurse -Force
+    Write-Output "profile ${ProfileName} has been deleted"
+}
+
 # For exes(non cmlet) the $ErrorActionPreference won't help if run cmd result failed,
 # We have to check the $LASTEXITCODE everytime.
 Function CheckCMDResult($ErrorMessag, $SucessMessage) {
@@ -532,6 +858,18 @@ Function main() {
         append-config { ConfigAll -multi_config 'append' }
         remove-config { ConfigAll -multi_config 'remove' }
         status { StatusAll }
+        save-profile { New-Item -ItemType Directory -Force -Path "${PROFILES_DIR}" | Out-Null; ProfileSave }
+        switch-profile { ProfileSwitch }
+        rollback-profile { ProfileRollback }
+        list-profiles { ProfileList }
+        delete-profile { ProfileDelete }
+        decommission { DecommissionAll }
+        snapshot { Snapshot }
+        flush { AgentControl -verb 'flush' }
+        reload { AgentControl -verb 'reload' }
+        set-log-level { AgentControl -verb 'set-log-level' -arguments @("level=${LogLevel}") }
+        disable { AgentControl -verb 'disable' -arguments @("name=${Target}", "persist=$($Keep.IsPresent.ToString().ToLower())") }
+        enable { AgentControl -verb 'enable' -arguments @("name=${Target}", "persist=$($Keep.IsPresent.ToString().ToLower())") }
         prep-restart { PrepRestartAll }
         cond-restart { CondRestartAll }
         preun { PreunAll }
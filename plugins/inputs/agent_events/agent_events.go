@@ -0,0 +1,149 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent_events
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// AgentEvents publishes the significant conditions posted by the components of the agent, e.g. the files which
+// cannot be read or the sustained throttling, to a log group as structured events for the triage across a fleet
+type AgentEvents struct {
+	LogGroupName  string `toml:"log_group_name"`
+	LogStreamName string `toml:"log_stream_name"`
+	Destination   string `toml:"destination"`
+
+	src   *eventsSrc
+	found bool
+}
+
+func (a *AgentEvents) Description() string {
+	return "Publish the significant conditions of the agent, e.g. the files which cannot be read, to a log group"
+}
+
+func (a *AgentEvents) SampleConfig() string {
+	return `
+  log_group_name = "CWAgentEvents"
+  ## The log stream of the log output is used when empty
+  log_stream_name = "{instance_id}"
+  destination = "cloudwatchlogs"
+`
+}
+
+func (a *AgentEvents) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (a *AgentEvents) Start(acc telegraf.Accumulator) error {
+	events, unsubscribe := agentevents.Subscribe()
+	a.src = &eventsSrc{
+		group:       a.LogGroupName,
+		stream:      a.LogStreamName,
+		destination: a.Destination,
+		events:      events,
+		unsubscribe: unsubscribe,
+		done:        make(chan struct{}),
+	}
+	return nil
+}
+
+func (a *AgentEvents) Stop() {
+	if a.src != nil {
+		a.src.Stop()
+	}
+}
+
+// LogGroups returns the log group of the events
+func (a *AgentEvents) LogGroups() []preflight.LogGroup {
+	return []preflight.LogGroup{{Name: a.LogGroupName, Destination: a.Destination}}
+}
+
+// FindLogSrc returns the events source once, it then publishes the events as they are posted
+func (a *AgentEvents) FindLogSrc() []logs.LogSrc {
+	if a.found || a.src == nil {
+		return nil
+	}
+	a.found = true
+	return []logs.LogSrc{a.src}
+}
+
+// record is the event published, with the host and the version of the agent which posted it
+type record struct {
+	agentevents.Event
+	Hostname string `json:"hostname"`
+	Version  string `json:"agent_version"`
+}
+
+type eventsSrc struct {
+	group, stream, destination string
+
+	events      <-chan agentevents.Event
+	unsubscribe func()
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+func (s *eventsSrc) SetOutput(fn func(logs.LogEvent)) {
+	if fn == nil {
+		return
+	}
+	hostname, _ := os.Hostname()
+	go func() {
+		for {
+			select {
+			case e := <-s.events:
+				s.publish(fn, record{Event: e, Hostname: hostname, Version: agentinfo.Version()})
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+func (s *eventsSrc) publish(fn func(logs.LogEvent), r record) {
+	msg, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("E! [inputs.agent_events] Unable to marshal the event: %v", err)
+		return
+	}
+	fn(&event{msg: string(msg), t: r.Time})
+}
+
+func (s *eventsSrc) Group() string       { return s.group }
+func (s *eventsSrc) Stream() string      { return s.stream }
+func (s *eventsSrc) Destination() string { return s.destination }
+func (s *eventsSrc) Description() string { return "agent events" }
+
+func (s *eventsSrc) Stop() {
+	s.stopOnce.Do(func() {
+		s.unsubscribe()
+		close(s.done)
+	})
+}
+
+type event struct {
+	msg string
+	t   time.Time
+}
+
+func (e *event) Message() string { return e.msg }
+func (e *event) Time() time.Time { return e.t }
+func (e *event) Done()           {}
+
+func init() {
+	inputs.Add("agent_events", func() telegraf.Input {
+		return &AgentEvents{}
+	})
+}
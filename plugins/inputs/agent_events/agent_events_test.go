@@ -0,0 +1,62 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent_events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentEvents(t *testing.T) {
+	agentinfo.VersionStr = "1.2.3"
+	// posted before the input starts, e.g. while the outputs connect
+	agentevents.Post(agentevents.Event{Type: agentevents.TypeConfigFallback, Severity: agentevents.SeverityWarning,
+		Component: "aws", Message: "unable to resolve the logs endpoint"})
+
+	a := &AgentEvents{LogGroupName: "events", LogStreamName: "host", Destination: "cloudwatchlogs"}
+	require.NoError(t, a.Start(&testutil.Accumulator{}))
+	defer a.Stop()
+
+	srcs := a.FindLogSrc()
+	require.Len(t, srcs, 1)
+	assert.Empty(t, a.FindLogSrc(), "the source is only found once")
+	src := srcs[0]
+	assert.Equal(t, "events", src.Group())
+	assert.Equal(t, "host", src.Stream())
+	assert.Equal(t, "cloudwatchlogs", src.Destination())
+
+	events := make(chan logs.LogEvent, 10)
+	src.SetOutput(func(e logs.LogEvent) { events <- e })
+	posted := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	agentevents.Post(agentevents.Event{Time: posted, Type: agentevents.TypeFileUnreadable, Severity: agentevents.SeverityError,
+		Component: "inputs.logfile", Message: "unable to open /var/log/a.log", Attributes: map[string]string{"file": "/var/log/a.log"}})
+
+	var records []map[string]interface{}
+	for len(records) < 2 {
+		select {
+		case e := <-events:
+			var r map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(e.Message()), &r))
+			records = append(records, r)
+			if r["type"] == agentevents.TypeFileUnreadable {
+				assert.Equal(t, posted, e.Time())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the events are not published")
+		}
+	}
+	assert.Equal(t, "aws", records[0]["component"])
+	assert.Equal(t, "1.2.3", records[1]["agent_version"])
+	assert.Equal(t, "error", records[1]["severity"])
+	assert.Equal(t, map[string]interface{}{"file": "/var/log/a.log"}, records[1]["attributes"])
+	assert.NotEmpty(t, records[1]["hostname"])
+}
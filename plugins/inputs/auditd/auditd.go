@@ -0,0 +1,395 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package auditd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/tail"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultFilePath     = "/var/log/audit/audit.log"
+	defaultMergeTimeout = 2 * time.Second
+	// maxPendingEvents bounds the events waiting for their other records, the oldest are published first
+	maxPendingEvents = 10000
+)
+
+// Auditd publishes the events of the audit log as JSON objects, the records of an event, e.g. its SYSCALL, CWD and
+// PATH records, are merged by their serial number. The events are routed to the log groups by the keys of the audit
+// rules which matched them.
+type Auditd struct {
+	FilePath      string `toml:"file_path"`
+	LogGroupName  string `toml:"log_group_name"`
+	LogStreamName string `toml:"log_stream_name"`
+	Destination   string `toml:"destination"`
+	// MergeTimeout is how long the records of an event are waited for when the event has no EOE record
+	MergeTimeout internal.Duration `toml:"merge_timeout"`
+	KeyRoutes    []KeyRoute        `toml:"key_route"`
+
+	srcs       []*auditSrc
+	defaultSrc *auditSrc
+	routes     map[string]*auditSrc
+	found      bool
+	started    int
+	tailer     *tail.Tail
+	done       chan struct{}
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+}
+
+// KeyRoute publishes the events matched by the audit rules of the keys, e.g. -k identity, to another log group
+type KeyRoute struct {
+	Keys          []string `toml:"keys"`
+	LogGroupName  string   `toml:"log_group_name"`
+	LogStreamName string   `toml:"log_stream_name"`
+}
+
+// Event is an audit event, the records are in the order of the audit log
+type Event struct {
+	Timestamp string              `json:"timestamp"`
+	Serial    uint64              `json:"serial"`
+	Keys      []string            `json:"keys,omitempty"`
+	Records   []map[string]string `json:"records"`
+
+	time     time.Time
+	received time.Time
+}
+
+func (a *Auditd) Description() string {
+	return "Publish the events of the audit log merged by serial number and routed to log groups by audit key"
+}
+
+func (a *Auditd) SampleConfig() string {
+	return `
+  file_path = "/var/log/audit/audit.log"
+  ## The log group of the events matching no key route, they are dropped when it is empty
+  log_group_name = "audit"
+  ## The log stream of the log output is used when empty
+  log_stream_name = "{instance_id}"
+  destination = "cloudwatchlogs"
+  ## How long the records of an event are waited for when the event has no EOE record
+  merge_timeout = "2s"
+
+  ## Publish the events matched by the audit rules of the keys to another log group
+  # [[inputs.auditd.key_route]]
+  #   keys = ["identity", "privileged"]
+  #   log_group_name = "audit-identity"
+`
+}
+
+func (a *Auditd) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (a *Auditd) Start(acc telegraf.Accumulator) error {
+	if a.FilePath == "" {
+		a.FilePath = defaultFilePath
+	}
+	if a.MergeTimeout.Duration <= 0 {
+		a.MergeTimeout.Duration = defaultMergeTimeout
+	}
+	a.done = make(chan struct{})
+	a.routes = map[string]*auditSrc{}
+	a.srcs = nil
+	if a.LogGroupName != "" {
+		a.defaultSrc = a.newSrc(a.LogGroupName, a.LogStreamName)
+	}
+	for _, route := range a.KeyRoutes {
+		if route.LogGroupName == "" {
+			return fmt.Errorf("auditd: the key route of %v has no log_group_name", route.Keys)
+		}
+		stream := route.LogStreamName
+		if stream == "" {
+			stream = a.LogStreamName
+		}
+		src := a.newSrc(route.LogGroupName, stream)
+		for _, key := range route.Keys {
+			if _, ok := a.routes[key]; ok {
+				return fmt.Errorf("auditd: the key %v is in more than one key route", key)
+			}
+			a.routes[key] = src
+		}
+	}
+	if len(a.srcs) == 0 {
+		return fmt.Errorf("auditd: no log_group_name nor key_route, the events would all be dropped")
+	}
+	return nil
+}
+
+func (a *Auditd) newSrc(group, stream string) *auditSrc {
+	src := &auditSrc{group: group, stream: stream, destination: a.Destination, auditd: a}
+	a.srcs = append(a.srcs, src)
+	return src
+}
+
+func (a *Auditd) Stop() {
+	a.mu.Lock()
+	if a.done != nil {
+		select {
+		case <-a.done:
+		default:
+			close(a.done)
+		}
+	}
+	tailer := a.tailer
+	a.mu.Unlock()
+	if tailer != nil {
+		tailer.Stop()
+	}
+	a.wg.Wait()
+}
+
+// LogGroups returns the log group of the events and the ones of their keys
+func (a *Auditd) LogGroups() []preflight.LogGroup {
+	groups := []preflight.LogGroup{{Name: a.LogGroupName, Destination: a.Destination}}
+	for _, route := range a.KeyRoutes {
+		groups = append(groups, preflight.LogGroup{Name: route.LogGroupName, Destination: a.Destination})
+	}
+	return groups
+}
+
+// FindLogSrc returns the sources of the log groups once
+func (a *Auditd) FindLogSrc() []logs.LogSrc {
+	if a.found || len(a.srcs) == 0 {
+		return nil
+	}
+	a.found = true
+	srcs := make([]logs.LogSrc, 0, len(a.srcs))
+	for _, src := range a.srcs {
+		srcs = append(srcs, src)
+	}
+	return srcs
+}
+
+// outputSet starts tailing the audit log once all the sources have their output, from its end as the agent does not
+// save the offset of the audit log
+func (a *Auditd) outputSet() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.started++
+	if a.started != len(a.srcs) {
+		return
+	}
+	select {
+	case <-a.done:
+		return
+	default:
+	}
+	tailer, err := tail.TailFile(a.FilePath, tail.Config{
+		ReOpen:   true,
+		Follow:   true,
+		Location: &tail.SeekInfo{Whence: io.SeekEnd},
+		Poll:     true,
+	})
+	if err != nil {
+		log.Printf("E! [inputs.auditd] Unable to tail %v: %v", a.FilePath, err)
+		return
+	}
+	a.tailer = tailer
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.run(tailer.Lines)
+	}()
+}
+
+// run merges the records of the lines into events, which are published on their EOE record or once no other
+// record came for the merge timeout
+func (a *Auditd) run(lines <-chan *tail.Line) {
+	ticker := time.NewTicker(a.MergeTimeout.Duration / 2)
+	defer ticker.Stop()
+	m := newMerger(a.MergeTimeout.Duration)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				a.publish(m.flush(time.Time{}))
+				return
+			}
+			if line.Err != nil {
+				log.Printf("E! [inputs.auditd] Error tailing %v: %v", a.FilePath, line.Err)
+				continue
+			}
+			r, err := parseRecord(line.Text)
+			if err != nil {
+				log.Printf("D! [inputs.auditd] Skipping the line of %v: %v", a.FilePath, err)
+				continue
+			}
+			a.publish(m.add(r, time.Now()))
+		case now := <-ticker.C:
+			a.publish(m.flush(now))
+		case <-a.done:
+			a.publish(m.flush(time.Time{}))
+			return
+		}
+	}
+}
+
+// publish routes the events to the source of their first key with a key route, or to the default source
+func (a *Auditd) publish(events []*Event) {
+	for _, e := range events {
+		src := a.defaultSrc
+		for _, key := range e.Keys {
+			if s, ok := a.routes[key]; ok {
+				src = s
+				break
+			}
+		}
+		if src == nil {
+			continue
+		}
+		msg, err := json.Marshal(e)
+		if err != nil {
+			log.Printf("E! [inputs.auditd] Unable to marshal the audit event %v: %v", e.Serial, err)
+			continue
+		}
+		src.output(&event{msg: string(msg), t: e.time})
+	}
+}
+
+// merger merges the records of the events by their serial number
+type merger struct {
+	timeout time.Duration
+	pending map[uint64]*Event
+}
+
+func newMerger(timeout time.Duration) *merger {
+	return &merger{timeout: timeout, pending: map[uint64]*Event{}}
+}
+
+// add adds the record to its event, the event is returned once complete, along with the oldest events when there are
+// too many pending
+func (m *merger) add(r *record, now time.Time) []*Event {
+	e, ok := m.pending[r.Serial]
+	if r.Type == typeEOE {
+		if !ok {
+			// the event was published on the merge timeout
+			return nil
+		}
+		delete(m.pending, r.Serial)
+		return []*Event{e}
+	}
+	if !ok {
+		e = &Event{Serial: r.Serial, time: r.Timestamp, received: now,
+			Timestamp: r.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z07:00")}
+		m.pending[r.Serial] = e
+	}
+	fields := make(map[string]string, len(r.Fields)+1)
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	fields["type"] = r.Type
+	e.Records = append(e.Records, fields)
+	for _, k := range keys(r.Fields["key"]) {
+		if !contains(e.Keys, k) {
+			e.Keys = append(e.Keys, k)
+		}
+	}
+	if len(m.pending) > maxPendingEvents {
+		return m.oldest(len(m.pending) - maxPendingEvents)
+	}
+	return nil
+}
+
+// flush returns the events received before the merge timeout, all of them with a zero time
+func (m *merger) flush(now time.Time) []*Event {
+	var events []*Event
+	for serial, e := range m.pending {
+		if now.IsZero() || now.Sub(e.received) >= m.timeout {
+			events = append(events, e)
+			delete(m.pending, serial)
+		}
+	}
+	sortBySerial(events)
+	return events
+}
+
+func (m *merger) oldest(n int) []*Event {
+	events := make([]*Event, 0, len(m.pending))
+	for _, e := range m.pending {
+		events = append(events, e)
+	}
+	sortBySerial(events)
+	events = events[:n]
+	for _, e := range events {
+		delete(m.pending, e.Serial)
+	}
+	return events
+}
+
+func sortBySerial(events []*Event) {
+	sort.Slice(events, func(i, j int) bool { return events[i].Serial < events[j].Serial })
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type auditSrc struct {
+	group, stream, destination string
+	auditd                     *Auditd
+
+	mu       sync.Mutex
+	outputFn func(logs.LogEvent)
+}
+
+func (s *auditSrc) SetOutput(fn func(logs.LogEvent)) {
+	if fn == nil {
+		return
+	}
+	s.mu.Lock()
+	first := s.outputFn == nil
+	s.outputFn = fn
+	s.mu.Unlock()
+	if first {
+		s.auditd.outputSet()
+	}
+}
+
+func (s *auditSrc) output(e logs.LogEvent) {
+	s.mu.Lock()
+	fn := s.outputFn
+	s.mu.Unlock()
+	if fn != nil {
+		fn(e)
+	}
+}
+
+func (s *auditSrc) Group() string       { return s.group }
+func (s *auditSrc) Stream() string      { return s.stream }
+func (s *auditSrc) Destination() string { return s.destination }
+func (s *auditSrc) Description() string { return "audit log " + s.auditd.FilePath }
+func (s *auditSrc) Stop()               {}
+
+type event struct {
+	msg string
+	t   time.Time
+}
+
+func (e *event) Message() string { return e.msg }
+func (e *event) Time() time.Time { return e.t }
+func (e *event) Done()           {}
+
+func init() {
+	inputs.Add("auditd", func() telegraf.Input {
+		return &Auditd{}
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package auditd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, line string) *record {
+	r, err := parseRecord(line)
+	require.NoError(t, err)
+	return r
+}
+
+func TestMerger(t *testing.T) {
+	m := newMerger(time.Second)
+	now := time.Now()
+	assert.Empty(t, m.add(mustParse(t, `type=SYSCALL msg=audit(1.000:10): syscall=2 key="access"`), now))
+	assert.Empty(t, m.add(mustParse(t, `type=USER_LOGIN msg=audit(1.100:11): res=failed`), now))
+	assert.Empty(t, m.add(mustParse(t, `type=PATH msg=audit(1.000:10): name="/etc/shadow"`), now))
+	events := m.add(mustParse(t, `type=EOE msg=audit(1.000:10): `), now)
+	require.Len(t, events, 1)
+	assert.Equal(t, uint64(10), events[0].Serial)
+	assert.Equal(t, []string{"access"}, events[0].Keys)
+	assert.Equal(t, []map[string]string{{"type": "SYSCALL", "syscall": "2", "key": "access"}, {"type": "PATH", "name": "/etc/shadow"}}, events[0].Records)
+
+	// the events without EOE are published after the merge timeout
+	assert.Empty(t, m.flush(now.Add(time.Second/2)))
+	events = m.flush(now.Add(time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, uint64(11), events[0].Serial)
+	assert.Empty(t, m.add(mustParse(t, `type=EOE msg=audit(1.100:11): `), now))
+}
+
+func TestAuditdRoutes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "audit.log")
+	require.NoError(t, ioutil.WriteFile(file, []byte("type=DAEMON_START msg=audit(1.000:1): op=start\n"), 0600))
+
+	a := &Auditd{FilePath: file, LogGroupName: "audit", MergeTimeout: internal.Duration{Duration: 100 * time.Millisecond},
+		KeyRoutes: []KeyRoute{{Keys: []string{"identity"}, LogGroupName: "audit-identity"}}}
+	require.NoError(t, a.Start(&testutil.Accumulator{}))
+	defer a.Stop()
+	srcs := a.FindLogSrc()
+	require.Len(t, srcs, 2)
+	assert.Empty(t, a.FindLogSrc())
+
+	events := map[string]chan logs.LogEvent{}
+	for _, src := range srcs {
+		ch := make(chan logs.LogEvent, 10)
+		events[src.Group()] = ch
+		src.SetOutput(func(e logs.LogEvent) { ch <- e })
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	// the tailing starts at the end of the file
+	time.Sleep(500 * time.Millisecond)
+	_, err = f.WriteString(`type=SYSCALL msg=audit(1364481363.243:24287): syscall=1 key="identity"
+type=EOE msg=audit(1364481363.243:24287): 
+type=USER_LOGIN msg=audit(1364481364.000:24288): res=failed
+`)
+	require.NoError(t, err)
+
+	receive := func(group string) Event {
+		select {
+		case e := <-events[group]:
+			var event Event
+			require.NoError(t, json.Unmarshal([]byte(e.Message()), &event))
+			assert.Equal(t, event.Timestamp, e.Time().UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no audit event published to %v", group)
+		}
+		return Event{}
+	}
+	identity := receive("audit-identity")
+	assert.Equal(t, uint64(24287), identity.Serial)
+	assert.Equal(t, "2013-03-28T14:36:03.243Z", identity.Timestamp)
+	other := receive("audit")
+	assert.Equal(t, uint64(24288), other.Serial)
+	assert.Equal(t, []map[string]string{{"type": "USER_LOGIN", "res": "failed"}}, other.Records)
+}
+
+func TestAuditdConfig(t *testing.T) {
+	assert.Error(t, (&Auditd{}).Start(nil))
+	assert.Error(t, (&Auditd{KeyRoutes: []KeyRoute{{Keys: []string{"a"}}}}).Start(nil))
+	assert.Error(t, (&Auditd{KeyRoutes: []KeyRoute{{Keys: []string{"a"}, LogGroupName: "a"}, {Keys: []string{"a"}, LogGroupName: "b"}}}).Start(nil))
+	a := &Auditd{KeyRoutes: []KeyRoute{{Keys: []string{"a"}, LogGroupName: "a"}}}
+	require.NoError(t, a.Start(nil))
+	assert.Equal(t, defaultFilePath, a.FilePath)
+	assert.Nil(t, a.defaultSrc)
+}
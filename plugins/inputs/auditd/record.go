@@ -0,0 +1,178 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package auditd
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// the end of the records of a multi-record event
+	typeEOE = "EOE"
+	// the separator of the interpreted fields of the ENRICHED log_format of auditd
+	enrichedSeparator = "\x1d"
+	// the separator of the keys of the rules with several keys, the keys are then hex encoded
+	keySeparator = "\x01"
+	nullKey      = "(null)"
+)
+
+var errNotAuditRecord = errors.New("not an audit record")
+
+// record is a line of the audit log, e.g.
+//
+//	type=SYSCALL msg=audit(1364481363.243:24287): arch=c000003e syscall=2 success=no exit=-13 comm="cat" key="access"
+//
+// The records are prefixed with node=<host> when the name_format of auditd is set, or when they are forwarded from
+// another host, the host is kept in the node field.
+type record struct {
+	Type      string
+	Timestamp time.Time
+	Serial    uint64
+	Fields    map[string]string
+}
+
+func parseRecord(line string) (*record, error) {
+	var node string
+	if strings.HasPrefix(line, "node=") {
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, errNotAuditRecord
+		}
+		node = line[len("node="):i]
+		line = strings.TrimLeft(line[i:], " ")
+	}
+	if !strings.HasPrefix(line, "type=") {
+		return nil, errNotAuditRecord
+	}
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		return nil, errNotAuditRecord
+	}
+	r := &record{Type: line[len("type="):i], Fields: map[string]string{}}
+	rest := strings.TrimLeft(line[i:], " ")
+	if !strings.HasPrefix(rest, "msg=audit(") {
+		return nil, errNotAuditRecord
+	}
+	rest = rest[len("msg=audit("):]
+	end := strings.Index(rest, "):")
+	if end < 0 {
+		return nil, errNotAuditRecord
+	}
+	var err error
+	if r.Timestamp, r.Serial, err = parseEventID(rest[:end]); err != nil {
+		return nil, err
+	}
+	if node != "" {
+		r.Fields["node"] = node
+	}
+	parseFields(strings.Replace(rest[end+2:], enrichedSeparator, " ", -1), r.Fields)
+	return r, nil
+}
+
+// parseEventID parses the 1364481363.243:24287 of msg=audit(1364481363.243:24287), the time in seconds and the
+// serial number of the event
+func parseEventID(id string) (time.Time, uint64, error) {
+	colon := strings.IndexByte(id, ':')
+	if colon < 0 {
+		return time.Time{}, 0, errNotAuditRecord
+	}
+	serial, err := strconv.ParseUint(id[colon+1:], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errNotAuditRecord
+	}
+	secs, millis := id[:colon], "0"
+	if dot := strings.IndexByte(secs, '.'); dot >= 0 {
+		secs, millis = secs[:dot], secs[dot+1:]
+	}
+	s, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errNotAuditRecord
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errNotAuditRecord
+	}
+	return time.Unix(s, ms*int64(time.Millisecond)), serial, nil
+}
+
+// parseFields parses the key=value pairs, the values may be double quoted. The msg='...' of the records of the user
+// space programs holds pairs of its own, they are merged into the fields.
+func parseFields(s string, fields map[string]string) {
+	for {
+		s = strings.TrimLeft(s, " ")
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return
+		}
+		key := s[:eq]
+		if space := strings.IndexByte(key, ' '); space >= 0 {
+			// a word without a value
+			s = s[space:]
+			continue
+		}
+		s = s[eq+1:]
+		var value string
+		switch {
+		case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				end = len(s) - 1
+			}
+			value = s[1 : end+1]
+			if end+2 < len(s) {
+				s = s[end+2:]
+			} else {
+				s = ""
+			}
+			if key == "msg" && strings.Contains(value, "=") {
+				parseFields(value, fields)
+				continue
+			}
+		default:
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+			value = decodeHex(key, value)
+		}
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+}
+
+// decodeHex decodes the values auditd hex encodes when they have spaces or control characters, i.e. the unquoted
+// values of the keys and the proctitle, whose arguments are separated by NULs
+func decodeHex(key, value string) string {
+	if key != "key" && key != "proctitle" {
+		return value
+	}
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return value
+	}
+	if key == "proctitle" {
+		return strings.Replace(string(decoded), "\x00", " ", -1)
+	}
+	return string(decoded)
+}
+
+// keys returns the keys of the rules which matched, the keys of the rules with several keys are separated by \x01
+func keys(value string) []string {
+	if value == "" || value == nullKey {
+		return nil
+	}
+	var result []string
+	for _, k := range strings.Split(value, keySeparator) {
+		if k != "" {
+			result = append(result, k)
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package auditd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecord(t *testing.T) {
+	r, err := parseRecord(`type=SYSCALL msg=audit(1364481363.243:24287): arch=c000003e syscall=2 success=no exit=-13 comm="cat" exe="/usr/bin/cat" key="access"`)
+	require.NoError(t, err)
+	assert.Equal(t, "SYSCALL", r.Type)
+	assert.Equal(t, uint64(24287), r.Serial)
+	assert.Equal(t, time.Unix(1364481363, 243000000), r.Timestamp)
+	assert.Equal(t, map[string]string{"arch": "c000003e", "syscall": "2", "success": "no", "exit": "-13",
+		"comm": "cat", "exe": "/usr/bin/cat", "key": "access"}, r.Fields)
+
+	// the pairs of the msg of the user space programs, and the interpreted fields of the ENRICHED format
+	r, err = parseRecord(`type=USER_LOGIN msg=audit(1364481363.000:24288): pid=1 uid=0 msg='op=login acct="root" exe="/usr/sbin/sshd" addr=10.0.0.1 res=failed'` + "\x1d" + `UID="root"`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pid": "1", "uid": "0", "op": "login", "acct": "root", "exe": "/usr/sbin/sshd",
+		"addr": "10.0.0.1", "res": "failed", "UID": "root"}, r.Fields)
+
+	// the hex encoded keys and proctitle
+	r, err = parseRecord(`type=PROCTITLE msg=audit(1364481363.243:24287): proctitle=636174002F6574632F736861646F77 key=6964656E74697479017072697669`)
+	require.NoError(t, err)
+	assert.Equal(t, "cat /etc/shadow", r.Fields["proctitle"])
+	assert.Equal(t, []string{"identity", "privi"}, keys(r.Fields["key"]))
+	assert.Empty(t, keys("(null)"))
+
+	// the host of the node prefix
+	r, err = parseRecord(`node=web-1 type=SYSCALL msg=audit(1364481363.243:24287): syscall=2 key="access"`)
+	require.NoError(t, err)
+	assert.Equal(t, "SYSCALL", r.Type)
+	assert.Equal(t, uint64(24287), r.Serial)
+	assert.Equal(t, map[string]string{"node": "web-1", "syscall": "2", "key": "access"}, r.Fields)
+
+	for _, line := range []string{"", "node=host", "node=host type=SYSCALL", "type=SYSCALL msg=other", "type=SYSCALL msg=audit(1.0:x): a=b"} {
+		_, err = parseRecord(line)
+		assert.Error(t, err, line)
+	}
+}
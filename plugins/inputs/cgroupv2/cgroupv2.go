@@ -0,0 +1,236 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement = "cgroupv2"
+	// the file of the root of the unified hierarchy, which is not in the cgroup v1 hierarchies
+	controllersFile = "cgroup.controllers"
+	// the cgroup of the agent is tagged as self
+	selfCgroup = "self"
+)
+
+var (
+	procSelfCgroup = "/proc/self/cgroup"
+	selfMountPath  = "/sys/fs/cgroup"
+)
+
+var defaultPaths = []string{"*.slice"}
+
+// statFields are the fields read from the flat keyed files of the cgroups, by file and key
+var statFields = map[string]map[string]string{
+	"memory.events": {
+		"low":      "memory_events_low",
+		"high":     "memory_events_high",
+		"max":      "memory_events_max",
+		"oom":      "memory_events_oom",
+		"oom_kill": "memory_events_oom_kill",
+	},
+	"cpu.stat": {
+		"usage_usec":     "cpu_usage_usec",
+		"user_usec":      "cpu_user_usec",
+		"system_usec":    "cpu_system_usec",
+		"nr_periods":     "cpu_nr_periods",
+		"nr_throttled":   "cpu_nr_throttled",
+		"throttled_usec": "cpu_throttled_usec",
+	},
+}
+
+// valueFields are the fields read from the single value files of the cgroups, by file
+var valueFields = map[string]string{
+	"memory.current":      "memory_current",
+	"memory.max":          "memory_max",
+	"memory.swap.current": "memory_swap_current",
+	"pids.current":        "pids_current",
+}
+
+type CgroupV2 struct {
+	// MountPath is where the unified hierarchy is mounted, /sys/fs/cgroup or /rootfs/sys/fs/cgroup in the containers
+	MountPath string `toml:"mount_path"`
+	// Paths are the cgroups relative to the mount path, the glob patterns are expanded on each collection
+	Paths []string `toml:"paths"`
+	// Self collects the cgroup of the agent, e.g. of its service or its container
+	Self bool `toml:"self"`
+}
+
+var sampleConfig = `
+  ## Where the cgroup v2 unified hierarchy is mounted, /sys/fs/cgroup by default, or /rootfs/sys/fs/cgroup when
+  ## the file system of the host is mounted in the container of the agent
+  # mount_path = "/sys/fs/cgroup"
+
+  ## The cgroups to collect, relative to the mount path, the glob patterns are expanded on each collection.
+  ## The slices of the root, e.g. system.slice and user.slice, by default.
+  # paths = ["system.slice/*.service", "kubepods.slice"]
+
+  ## Collect the cgroup of the agent too, tagged as cgroup=self
+  # self = false
+`
+
+func (c *CgroupV2) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CgroupV2) Description() string {
+	return "Read the memory, OOM kills and CPU throttling of the cgroup v2 slices and services"
+}
+
+func (c *CgroupV2) Gather(acc telegraf.Accumulator) error {
+	mount := c.mountPath()
+	if _, err := os.Stat(filepath.Join(mount, controllersFile)); err != nil {
+		return fmt.Errorf("cgroupv2: %v is not a cgroup v2 unified hierarchy: %v", mount, err)
+	}
+	patterns := c.Paths
+	if len(patterns) == 0 {
+		patterns = defaultPaths
+	}
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		dirs, err := filepath.Glob(filepath.Join(mount, strings.TrimPrefix(pattern, "/")))
+		if err != nil {
+			return fmt.Errorf("cgroupv2: invalid path %v: %v", pattern, err)
+		}
+		for _, dir := range dirs {
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				continue
+			}
+			fields, err := readCgroup(dir)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			name, _ := filepath.Rel(mount, dir)
+			acc.AddFields(measurement, fields, map[string]string{"cgroup": filepath.ToSlash(name)})
+		}
+	}
+	if c.Self {
+		c.gatherSelf(acc)
+	}
+	return nil
+}
+
+// gatherSelf collects the cgroup of the agent from the hierarchy it sees, which is the one of its container when the
+// container has its own cgroup namespace
+func (c *CgroupV2) gatherSelf(acc telegraf.Accumulator) {
+	content, err := ioutil.ReadFile(procSelfCgroup)
+	if err != nil {
+		acc.AddError(fmt.Errorf("cgroupv2: unable to read the cgroup of the agent: %v", err))
+		return
+	}
+	// the entry of the unified hierarchy is 0::<path>
+	var path string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			path = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if path == "" {
+		acc.AddError(fmt.Errorf("cgroupv2: the agent is not in a cgroup v2 unified hierarchy"))
+		return
+	}
+	fields, err := readCgroup(filepath.Join(selfMountPath, path))
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	if len(fields) > 0 {
+		acc.AddFields(measurement, fields, map[string]string{"cgroup": selfCgroup})
+	}
+}
+
+func (c *CgroupV2) mountPath() string {
+	if c.MountPath != "" {
+		return c.MountPath
+	}
+	// the file system of the host is mounted on /rootfs in the containers
+	if _, err := os.Stat(filepath.Join("/rootfs/sys/fs/cgroup", controllersFile)); err == nil {
+		return "/rootfs/sys/fs/cgroup"
+	}
+	return "/sys/fs/cgroup"
+}
+
+// readCgroup returns the fields of the files of the cgroup, the files of the controllers not enabled for the cgroup
+// are missing and skipped
+func readCgroup(dir string) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for file, field := range valueFields {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cgroupv2: unable to read %v: %v", filepath.Join(dir, file), err)
+		}
+		value := strings.TrimSpace(string(content))
+		if value == "max" {
+			// no limit
+			continue
+		}
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cgroupv2: invalid value %q of %v", value, filepath.Join(dir, file))
+		}
+		fields[field] = v
+	}
+	for file, keys := range statFields {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cgroupv2: unable to read %v: %v", filepath.Join(dir, file), err)
+		}
+		if err := parseFlatKeyed(content, keys, fields); err != nil {
+			return nil, fmt.Errorf("cgroupv2: unable to parse %v: %v", filepath.Join(dir, file), err)
+		}
+	}
+	return fields, nil
+}
+
+// parseFlatKeyed adds the fields of the known keys of a flat keyed file, e.g. "nr_throttled 12"
+func parseFlatKeyed(content []byte, keys map[string]string, fields map[string]interface{}) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		field, ok := keys[parts[0]]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q of %v", parts[1], parts[0])
+		}
+		fields[field] = v
+	}
+	return scanner.Err()
+}
+
+func init() {
+	inputs.Add("cgroupv2", func() telegraf.Input {
+		return &CgroupV2{}
+	})
+}
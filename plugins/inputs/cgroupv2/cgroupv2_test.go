@@ -0,0 +1,97 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestGather(t *testing.T) {
+	mount, err := ioutil.TempDir("", "cgroupv2")
+	require.NoError(t, err)
+	defer os.RemoveAll(mount)
+	writeFiles(t, mount, map[string]string{controllersFile: "cpu memory pids\n"})
+	writeFiles(t, filepath.Join(mount, "system.slice"), map[string]string{
+		"memory.current": "104857600\n",
+		"memory.max":     "max\n",
+		"memory.events":  "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n",
+		"cpu.stat":       "usage_usec 5000\nuser_usec 3000\nsystem_usec 2000\nnr_periods 10\nnr_throttled 4\nthrottled_usec 900\n",
+	})
+	writeFiles(t, filepath.Join(mount, "system.slice", "nginx.service"), map[string]string{
+		"memory.current": "1048576\n",
+		"memory.max":     "2097152\n",
+	})
+	// without the files of the controllers
+	writeFiles(t, filepath.Join(mount, "init.scope"), nil)
+
+	var acc testutil.Accumulator
+	c := &CgroupV2{MountPath: mount, Paths: []string{"*.slice", "system.slice/*.service", "init.scope"}}
+	require.NoError(t, c.Gather(&acc))
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "cgroupv2", map[string]interface{}{
+		"memory_current":         uint64(104857600),
+		"memory_events_low":      uint64(0),
+		"memory_events_high":     uint64(0),
+		"memory_events_max":      uint64(3),
+		"memory_events_oom":      uint64(1),
+		"memory_events_oom_kill": uint64(1),
+		"cpu_usage_usec":         uint64(5000),
+		"cpu_user_usec":          uint64(3000),
+		"cpu_system_usec":        uint64(2000),
+		"cpu_nr_periods":         uint64(10),
+		"cpu_nr_throttled":       uint64(4),
+		"cpu_throttled_usec":     uint64(900),
+	}, map[string]string{"cgroup": "system.slice"})
+	acc.AssertContainsTaggedFields(t, "cgroupv2", map[string]interface{}{
+		"memory_current": uint64(1048576),
+		"memory_max":     uint64(2097152),
+	}, map[string]string{"cgroup": "system.slice/nginx.service"})
+}
+
+func TestGatherSelf(t *testing.T) {
+	mount, err := ioutil.TempDir("", "cgroupv2")
+	require.NoError(t, err)
+	defer os.RemoveAll(mount)
+	writeFiles(t, mount, map[string]string{
+		controllersFile: "cpu memory\n",
+		"self_cgroup":   "0::/system.slice/amazon-cloudwatch-agent.service\n",
+	})
+	writeFiles(t, filepath.Join(mount, "system.slice", "amazon-cloudwatch-agent.service"), map[string]string{
+		"memory.current": "52428800\n",
+	})
+	procSelfCgroup, selfMountPath = filepath.Join(mount, "self_cgroup"), mount
+	defer func() { procSelfCgroup, selfMountPath = "/proc/self/cgroup", "/sys/fs/cgroup" }()
+
+	var acc testutil.Accumulator
+	c := &CgroupV2{MountPath: mount, Paths: []string{"none.slice"}, Self: true}
+	require.NoError(t, c.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "cgroupv2", map[string]interface{}{
+		"memory_current": uint64(52428800),
+	}, map[string]string{"cgroup": "self"})
+}
+
+func TestGatherCgroupV1(t *testing.T) {
+	mount, err := ioutil.TempDir("", "cgroupv2")
+	require.NoError(t, err)
+	defer os.RemoveAll(mount)
+
+	var acc testutil.Accumulator
+	c := &CgroupV2{MountPath: mount}
+	assert.Error(t, c.Gather(&acc))
+}
@@ -0,0 +1,218 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package datastore derives the metrics the inputs of the datastores have in common from their cumulative counters,
+// over the collection interval, e.g. the hit rates of their caches.
+package datastore
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// HitRateField is the field of the hit rate of the cache of a datastore, in percent
+const HitRateField = "cache_hit_rate"
+
+// HitRate is the hit rate of a cache computed from the increases of its counters since the previous collection. The
+// hits are the counter of the hits, or the lookups minus the misses when the datastore counts the lookups, e.g. the
+// read requests of the buffer pool of InnoDB.
+type HitRate struct {
+	Field   string
+	Hits    string
+	Lookups string
+	Misses  string
+}
+
+// Increase is the increase of a counter since the previous collection, e.g. the slow queries
+type Increase struct {
+	Field   string
+	Counter string
+}
+
+// Derivations derive the fields of the metrics of a measurement, they keep the counters of the previous collection
+// of every series
+type Derivations struct {
+	Measurement string
+	HitRates    []HitRate
+	Increases   []Increase
+	// DropCounters removes the counters from the metrics, for the inputs only reporting their increases
+	DropCounters bool
+
+	mu       sync.Mutex
+	previous map[string]map[string]float64
+	// seen are the series with metrics since the previous prune
+	seen map[string]bool
+}
+
+// Prune forgets the counters of the series without metrics since the previous prune, e.g. of the servers removed
+// from a load balancer, the inputs with series coming and going call it after every collection
+func (d *Derivations) Prune() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key := range d.previous {
+		if !d.seen[key] {
+			delete(d.previous, key)
+		}
+	}
+	d.seen = nil
+}
+
+// Accumulator returns the accumulator adding the derived fields to the metrics of the measurement, the first
+// collection of a series has none
+func (d *Derivations) Accumulator(acc telegraf.Accumulator) telegraf.Accumulator {
+	return &accumulator{Accumulator: acc, d: d}
+}
+
+func (d *Derivations) derive(measurement string, fields map[string]interface{}, tags map[string]string) map[string]interface{} {
+	if measurement != d.Measurement {
+		return fields
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.previous == nil {
+		d.previous = map[string]map[string]float64{}
+	}
+	if d.seen == nil {
+		d.seen = map[string]bool{}
+	}
+	key := seriesKey(tags)
+	d.seen[key] = true
+	previous := d.previous[key]
+	current := map[string]float64{}
+	for _, counter := range d.counters() {
+		if v, ok := toFloat(fields[counter]); ok {
+			current[counter] = v
+		}
+	}
+	increase := func(counter string) (float64, bool) {
+		v, ok := current[counter]
+		if !ok {
+			return 0, false
+		}
+		p, ok := previous[counter]
+		if !ok {
+			return 0, false
+		}
+		if v < p {
+			// the counter was reset, e.g. by a restart of the datastore
+			return v, true
+		}
+		return v - p, true
+	}
+
+	derived := map[string]interface{}{}
+	for _, r := range d.HitRates {
+		misses, ok := increase(r.Misses)
+		if !ok {
+			continue
+		}
+		var hits float64
+		if r.Lookups != "" {
+			lookups, ok := increase(r.Lookups)
+			if !ok {
+				continue
+			}
+			hits = lookups - misses
+		} else if hits, ok = increase(r.Hits); !ok {
+			continue
+		}
+		// an idle cache is not reported as missing
+		if hits < 0 || hits+misses == 0 {
+			continue
+		}
+		derived[r.Field] = hits / (hits + misses) * 100
+	}
+	for _, i := range d.Increases {
+		if v, ok := increase(i.Counter); ok {
+			derived[i.Field] = v
+		}
+	}
+	if len(current) == 0 {
+		return fields
+	}
+	// the counters of the series are merged since a measurement may be added in several metrics, e.g. the status
+	// and the replication of a server
+	if previous == nil {
+		previous = map[string]float64{}
+		d.previous[key] = previous
+	}
+	for k, v := range current {
+		previous[k] = v
+	}
+	if len(derived) == 0 && !d.DropCounters {
+		return fields
+	}
+	result := make(map[string]interface{}, len(fields)+len(derived))
+	for k, v := range fields {
+		if _, ok := current[k]; ok && d.DropCounters {
+			continue
+		}
+		result[k] = v
+	}
+	for k, v := range derived {
+		result[k] = v
+	}
+	return result
+}
+
+func (d *Derivations) counters() []string {
+	var counters []string
+	for _, r := range d.HitRates {
+		counters = append(counters, r.Hits, r.Lookups, r.Misses)
+	}
+	for _, i := range d.Increases {
+		counters = append(counters, i.Counter)
+	}
+	return counters
+}
+
+func seriesKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + tags[k] + ",")
+	}
+	return b.String()
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+type accumulator struct {
+	telegraf.Accumulator
+	d *Derivations
+}
+
+func (a *accumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	// the metrics with only counters have no fields left on the first collection when the counters are dropped
+	if fields = a.d.derive(measurement, fields, tags); len(fields) > 0 {
+		a.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (a *accumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddGauge(measurement, a.d.derive(measurement, fields, tags), tags, t...)
+}
+
+func (a *accumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddCounter(measurement, a.d.derive(measurement, fields, tags), tags, t...)
+}
@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package datastore
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivations(t *testing.T) {
+	d := &Derivations{
+		Measurement: "mysql",
+		HitRates:    []HitRate{{Field: HitRateField, Lookups: "read_requests", Misses: "reads"}},
+		Increases:   []Increase{{Field: "slow_queries_delta", Counter: "slow_queries"}},
+	}
+	acc := &testutil.Accumulator{}
+	a := d.Accumulator(acc)
+	tags := map[string]string{"server": "db:3306"}
+
+	a.AddFields("mysql", map[string]interface{}{"read_requests": int64(100), "reads": int64(10), "slow_queries": int64(3)}, tags)
+	a.AddFields("mysql", map[string]interface{}{"read_requests": int64(300), "reads": int64(30), "slow_queries": int64(5)}, tags)
+	// the replication of the server is added in another metric of the measurement
+	a.AddFields("mysql", map[string]interface{}{"slave_seconds_behind_master": int64(2)}, tags)
+	// the counters of the other series are not mixed up
+	a.AddFields("mysql", map[string]interface{}{"read_requests": int64(1), "reads": int64(1), "slow_queries": int64(0)}, map[string]string{"server": "other:3306"})
+	// an idle cache has no hit rate and the reset counters increased by their values
+	a.AddFields("mysql", map[string]interface{}{"read_requests": int64(300), "reads": int64(30), "slow_queries": int64(1)}, tags)
+	a.AddFields("redis", map[string]interface{}{"reads": int64(1)}, tags)
+
+	assert.Len(t, acc.Metrics, 6)
+	assert.NotContains(t, acc.Metrics[0].Fields, HitRateField)
+	assert.Equal(t, float64(90), acc.Metrics[1].Fields[HitRateField])
+	assert.Equal(t, float64(2), acc.Metrics[1].Fields["slow_queries_delta"])
+	assert.Equal(t, map[string]interface{}{"slave_seconds_behind_master": int64(2)}, acc.Metrics[2].Fields)
+	assert.NotContains(t, acc.Metrics[3].Fields, HitRateField)
+	assert.NotContains(t, acc.Metrics[4].Fields, HitRateField)
+	assert.Equal(t, float64(1), acc.Metrics[4].Fields["slow_queries_delta"])
+	assert.Equal(t, map[string]interface{}{"reads": int64(1)}, acc.Metrics[5].Fields)
+}
+
+func TestHitRateOfHits(t *testing.T) {
+	d := &Derivations{Measurement: "memcached", HitRates: []HitRate{{Field: HitRateField, Hits: "get_hits", Misses: "get_misses"}}}
+	acc := &testutil.Accumulator{}
+	a := d.Accumulator(acc)
+	a.AddFields("memcached", map[string]interface{}{"get_hits": uint64(10), "get_misses": uint64(10)}, nil)
+	a.AddFields("memcached", map[string]interface{}{"get_hits": uint64(13), "get_misses": uint64(11)}, nil)
+	assert.Equal(t, float64(75), acc.Metrics[1].Fields[HitRateField])
+}
+
+func TestDropCountersAndPrune(t *testing.T) {
+	d := &Derivations{Measurement: "envoy", Increases: []Increase{{Field: "requests", Counter: "upstream_rq_total"}}, DropCounters: true}
+	acc := &testutil.Accumulator{}
+	a := d.Accumulator(acc)
+	backend := map[string]string{"cluster": "backend"}
+	canary := map[string]string{"cluster": "canary"}
+
+	// the metrics with only counters are not added on the first collection
+	a.AddFields("envoy", map[string]interface{}{"upstream_rq_total": uint64(100), "healthy_hosts": uint64(3)}, backend)
+	a.AddFields("envoy", map[string]interface{}{"upstream_rq_total": uint64(10)}, canary)
+	d.Prune()
+	a.AddFields("envoy", map[string]interface{}{"upstream_rq_total": uint64(150), "healthy_hosts": uint64(2)}, backend)
+	d.Prune()
+	// the counters of the series without metrics in the previous collection are forgotten
+	a.AddFields("envoy", map[string]interface{}{"upstream_rq_total": uint64(30)}, canary)
+
+	assert.Len(t, acc.Metrics, 2)
+	assert.Equal(t, map[string]interface{}{"healthy_hosts": uint64(3)}, acc.Metrics[0].Fields)
+	assert.Equal(t, map[string]interface{}{"requests": float64(50), "healthy_hosts": uint64(2)}, acc.Metrics[1].Fields)
+}
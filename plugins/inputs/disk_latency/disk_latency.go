@@ -0,0 +1,311 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/regular"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement           = "disk_latency"
+	defaultSampleInterval = time.Second
+
+	// the awaits are reported as the separate fields of their percentiles, the default
+	latencyOutputPercentiles = "percentiles"
+	// the awaits are reported as distributions, CloudWatch computes their percentiles across the collections
+	latencyOutputDistribution = "distribution"
+)
+
+// percentiles are the percentiles of the await of the samples reported, by field suffix
+var percentiles = []struct {
+	suffix     string
+	percentile float64
+}{
+	{"p50", 50},
+	{"p90", 90},
+	{"p99", 99},
+}
+
+type DiskLatency struct {
+	// Devices are the names of the devices, glob patterns are allowed, the disks but not their partitions by default
+	Devices []string `toml:"devices"`
+	// SampleInterval is how often /proc/diskstats is read, the percentiles are of the await of the samples
+	SampleInterval internal.Duration `toml:"sample_interval"`
+	// ProcPath is where the proc file system is mounted, HOST_PROC or /proc by default
+	ProcPath string `toml:"proc_path"`
+	// LatencyOutput reports the awaits as the separate fields of their percentiles or as distributions
+	LatencyOutput string `toml:"latency_output"`
+
+	sysPath string
+	filter  filter.Filter
+	last    map[string]diskStat
+	// samples are the awaits in milliseconds of the sample intervals with IOs since the last collection
+	samples map[string]*deviceSamples
+	done    chan struct{}
+	wg      sync.WaitGroup
+	sync.Mutex
+}
+
+// diskStat are the cumulative counters of a device of /proc/diskstats
+type diskStat struct {
+	reads, readTime   uint64
+	writes, writeTime uint64
+}
+
+type deviceSamples struct {
+	read, write []float64
+}
+
+var sampleConfig = `
+  ## The devices, glob patterns are allowed, the disks but not their partitions by default
+  # devices = ["nvme*n1", "xvd*"]
+
+  ## How often /proc/diskstats is read, the percentiles are of the average latency of the IOs of each sample
+  # sample_interval = "1s"
+
+  ## Where the proc file system of the host is mounted, HOST_PROC or /proc by default
+  # proc_path = "/rootfs/proc"
+
+  ## Report the awaits as the fields of their percentiles ("percentiles") or as distributions ("distribution"),
+  ## whose percentiles CloudWatch computes over any period rather than per collection
+  # latency_output = "percentiles"
+`
+
+func (d *DiskLatency) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DiskLatency) Description() string {
+	return "Read the distribution of the latency of the reads and writes of the disks from /proc/diskstats"
+}
+
+func (d *DiskLatency) Start(acc telegraf.Accumulator) error {
+	switch d.LatencyOutput {
+	case "", latencyOutputPercentiles, latencyOutputDistribution:
+	default:
+		return fmt.Errorf("disk_latency: latency_output %v is not supported, it must be %v or %v", d.LatencyOutput, latencyOutputPercentiles, latencyOutputDistribution)
+	}
+	f, err := filter.Compile(d.Devices)
+	if err != nil {
+		return fmt.Errorf("disk_latency: invalid devices %v: %v", d.Devices, err)
+	}
+	d.filter = f
+	if d.sysPath == "" {
+		d.sysPath = "/sys"
+		// the file system of the host is mounted on /rootfs in the containers
+		if _, err := os.Lstat("/rootfs/sys"); err == nil {
+			d.sysPath = "/rootfs/sys"
+		}
+	}
+	d.samples = map[string]*deviceSamples{}
+	d.done = make(chan struct{})
+	interval := d.SampleInterval.Duration
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	d.sample()
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.sample()
+			case <-d.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (d *DiskLatency) Stop() {
+	if d.done != nil {
+		close(d.done)
+		d.wg.Wait()
+	}
+}
+
+// Gather adds the percentiles and the max, or the distribution, of the await of the samples since the previous
+// collection, in milliseconds, the devices without IOs have none
+func (d *DiskLatency) Gather(acc telegraf.Accumulator) error {
+	d.Lock()
+	samples := d.samples
+	d.samples = map[string]*deviceSamples{}
+	d.Unlock()
+	for device, s := range samples {
+		fields := map[string]interface{}{}
+		if d.LatencyOutput == latencyOutputDistribution {
+			addDistribution(fields, "read_await", s.read)
+			addDistribution(fields, "write_await", s.write)
+		} else {
+			addPercentiles(fields, "read_await", s.read)
+			addPercentiles(fields, "write_await", s.write)
+		}
+		if len(fields) > 0 {
+			acc.AddFields(measurement, fields, map[string]string{"name": device})
+		}
+	}
+	return nil
+}
+
+func (d *DiskLatency) sample() {
+	content, err := ioutil.ReadFile(filepath.Join(d.procPath(), "diskstats"))
+	if err != nil {
+		log.Printf("E! disk_latency: unable to read diskstats: %v", err)
+		return
+	}
+	stats := parseDiskstats(content)
+	d.Lock()
+	defer d.Unlock()
+	for device, stat := range stats {
+		if !d.included(device) {
+			continue
+		}
+		last, ok := d.last[device]
+		if !ok {
+			continue
+		}
+		s := d.samples[device]
+		if s == nil {
+			s = &deviceSamples{}
+			d.samples[device] = s
+		}
+		if await, ok := average(stat.readTime, last.readTime, stat.reads, last.reads); ok {
+			s.read = append(s.read, await)
+		}
+		if await, ok := average(stat.writeTime, last.writeTime, stat.writes, last.writes); ok {
+			s.write = append(s.write, await)
+		}
+	}
+	d.last = stats
+}
+
+func (d *DiskLatency) included(device string) bool {
+	if len(d.Devices) > 0 {
+		return d.filter.Match(device)
+	}
+	// the directories of /sys/block are the disks, the partitions are under their disks
+	if strings.HasPrefix(device, "loop") || strings.HasPrefix(device, "ram") {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(d.sysPath, "block", device))
+	return err == nil
+}
+
+func (d *DiskLatency) procPath() string {
+	if d.ProcPath != "" {
+		return d.ProcPath
+	}
+	if hostProc := os.Getenv("HOST_PROC"); hostProc != "" {
+		return hostProc
+	}
+	return "/proc"
+}
+
+// average returns the average time of the IOs completed between the samples, false when there is none
+func average(ioTime, lastIoTime, ios, lastIos uint64) (float64, bool) {
+	if ios <= lastIos || ioTime < lastIoTime {
+		return 0, false
+	}
+	return float64(ioTime-lastIoTime) / float64(ios-lastIos), true
+}
+
+func addPercentiles(fields map[string]interface{}, prefix string, samples []float64) {
+	if len(samples) == 0 {
+		return
+	}
+	sort.Float64s(samples)
+	for _, p := range percentiles {
+		fields[prefix+"_"+p.suffix] = percentile(samples, p.percentile)
+	}
+	fields[prefix+"_max"] = samples[len(samples)-1]
+}
+
+// addDistribution adds the samples as the distribution of the field, the cloudwatch output merges the distributions
+// of the collections of the aggregation interval of the metric rather than their percentiles
+func addDistribution(fields map[string]interface{}, field string, samples []float64) {
+	if len(samples) == 0 {
+		return
+	}
+	dist := newDistribution()
+	for _, sample := range samples {
+		dist.AddEntryWithUnit(sample, 1, "Milliseconds")
+	}
+	fields[field] = dist
+}
+
+func newDistribution() distribution.Distribution {
+	if distribution.NewDistribution == nil {
+		// set by the cloudwatch output, which is missing without a metrics section
+		return regular.NewRegularDistribution()
+	}
+	return distribution.NewDistribution()
+}
+
+// percentile returns the nearest rank percentile of the sorted samples
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// parseDiskstats returns the counters of the devices of /proc/diskstats, e.g.
+//
+//	259 0 nvme0n1 1200 0 96000 3600 800 0 64000 8000 0 4000 11600
+//
+// where the reads and their time in milliseconds are the 4th and 7th fields, the writes the 8th and 11th
+func parseDiskstats(content []byte) map[string]diskStat {
+	stats := map[string]diskStat{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 11 {
+			continue
+		}
+		var values [4]uint64
+		valid := true
+		for i, index := range []int{3, 6, 7, 10} {
+			v, err := strconv.ParseUint(parts[index], 10, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[i] = v
+		}
+		if valid {
+			stats[parts[2]] = diskStat{reads: values[0], readTime: values[1], writes: values[2], writeTime: values[3]}
+		}
+	}
+	return stats
+}
+
+func init() {
+	inputs.Add("disk_latency", func() telegraf.Input {
+		return &DiskLatency{}
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diskstats = `   7       0 loop0 50 0 400 10 0 0 0 0 0 10 10
+ 259       0 nvme0n1 1000 0 96000 2000 500 0 64000 5000 0 4000 7000
+ 259       1 nvme0n1p1 900 0 90000 1800 500 0 64000 5000 0 3600 6800
+`
+
+func TestParseDiskstats(t *testing.T) {
+	stats := parseDiskstats([]byte(diskstats + "invalid line\n 8 0 sda x 0 0 0 0 0 0 0 0 0 0\n"))
+	assert.Len(t, stats, 3)
+	assert.Equal(t, diskStat{reads: 1000, readTime: 2000, writes: 500, writeTime: 5000}, stats["nvme0n1"])
+}
+
+func TestPercentile(t *testing.T) {
+	samples := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, 5.0, percentile(samples, 50))
+	assert.Equal(t, 9.0, percentile(samples, 90))
+	assert.Equal(t, 10.0, percentile(samples, 99))
+	assert.Equal(t, 1.0, percentile([]float64{1}, 0))
+}
+
+func TestAverage(t *testing.T) {
+	await, ok := average(2600, 2000, 1100, 1000)
+	assert.True(t, ok)
+	assert.Equal(t, 6.0, await)
+
+	// no IO completed
+	_, ok = average(2600, 2000, 1000, 1000)
+	assert.False(t, ok)
+}
+
+func TestSampleAndGather(t *testing.T) {
+	proc, err := ioutil.TempDir("", "disk_latency")
+	require.NoError(t, err)
+	defer os.RemoveAll(proc)
+	sys := filepath.Join(proc, "sys")
+	require.NoError(t, os.MkdirAll(filepath.Join(sys, "block", "nvme0n1"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(sys, "block", "loop0"), 0755))
+
+	f, err := filter.Compile(nil)
+	require.NoError(t, err)
+	d := &DiskLatency{ProcPath: proc, sysPath: sys, filter: f, samples: map[string]*deviceSamples{}}
+	write := func(content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "diskstats"), []byte(content), 0644))
+		d.sample()
+	}
+	// the baseline, then 100 reads of 4ms, 100 reads of 20ms and no write
+	write(diskstats)
+	write(" 259 0 nvme0n1 1100 0 0 2400 500 0 0 5000 0 0 0\n 7 0 loop0 60 0 0 20 0 0 0 0 0 0 0\n")
+	write(" 259 0 nvme0n1 1200 0 0 4400 500 0 0 5000 0 0 0\n 7 0 loop0 70 0 0 30 0 0 0 0 0 0 0\n")
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	m := acc.Metrics[0]
+	assert.Equal(t, "disk_latency", m.Measurement)
+	assert.Equal(t, map[string]string{"name": "nvme0n1"}, m.Tags)
+	assert.Equal(t, map[string]interface{}{
+		"read_await_p50": 4.0,
+		"read_await_p90": 20.0,
+		"read_await_p99": 20.0,
+		"read_await_max": 20.0,
+	}, m.Fields)
+
+	// the samples are reset by the collection
+	acc.ClearMetrics()
+	require.NoError(t, d.Gather(&acc))
+	assert.Empty(t, acc.Metrics)
+}
+
+func TestGatherDistribution(t *testing.T) {
+	proc, err := ioutil.TempDir("", "disk_latency")
+	require.NoError(t, err)
+	defer os.RemoveAll(proc)
+	sys := filepath.Join(proc, "sys")
+	require.NoError(t, os.MkdirAll(filepath.Join(sys, "block", "nvme0n1"), 0755))
+
+	d := &DiskLatency{ProcPath: proc, sysPath: sys, LatencyOutput: latencyOutputDistribution}
+	var acc testutil.Accumulator
+	require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "diskstats"), []byte(diskstats), 0644))
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	for _, content := range []string{
+		" 259 0 nvme0n1 1100 0 0 2400 500 0 0 5000 0 0 0\n",
+		" 259 0 nvme0n1 1200 0 0 4400 500 0 0 5000 0 0 0\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "diskstats"), []byte(content), 0644))
+		d.sample()
+	}
+
+	require.NoError(t, d.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	dist, ok := acc.Metrics[0].Fields["read_await"].(distribution.Distribution)
+	require.True(t, ok)
+	assert.Equal(t, float64(2), dist.SampleCount())
+	assert.Equal(t, 20.0, dist.Maximum())
+	assert.Equal(t, 4.0, dist.Minimum())
+	assert.Equal(t, "Milliseconds", dist.Unit())
+	assert.NotContains(t, acc.Metrics[0].Fields, "read_await_p99")
+
+	assert.Error(t, (&DiskLatency{LatencyOutput: "histogram"}).Start(&acc))
+}
+
+func TestSampleDevices(t *testing.T) {
+	proc, err := ioutil.TempDir("", "disk_latency")
+	require.NoError(t, err)
+	defer os.RemoveAll(proc)
+
+	d := &DiskLatency{ProcPath: proc, Devices: []string{"nvme*"}, sysPath: filepath.Join(proc, "sys")}
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "diskstats"), []byte(diskstats), 0644))
+	d.sample()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "diskstats"),
+		[]byte(" 259 0 nvme0n1 1000 0 0 2000 600 0 0 5300 0 0 0\n 259 1 nvme0n1p1 900 0 0 1800 600 0 0 5500 0 0 0\n"), 0644))
+	d.sample()
+
+	require.NoError(t, d.Gather(&acc))
+	assert.Len(t, acc.Metrics, 2)
+	for _, m := range acc.Metrics {
+		assert.Contains(t, m.Fields, "write_await_p50")
+		assert.NotContains(t, m.Fields, "read_await_p50")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	dnsPort = 53
+	// maxPendingQueries bounds the queries waiting for their response, the queries beyond are not measured
+	maxPendingQueries = 10000
+
+	protocolUDP      = 17
+	udpHeaderLen     = 8
+	dnsHeaderLen     = 12
+	ipv6HeaderLen    = 40
+	dnsFlagResponse  = 0x8000
+	dnsRcodeMask     = 0x000f
+	dnsRcodeNXDomain = 3
+)
+
+type dnsQueryKey struct {
+	resolver string
+	port     uint16
+	id       uint16
+}
+
+// dnsTracker matches the DNS responses to their queries by resolver, client port and query ID
+type dnsTracker struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[dnsQueryKey]time.Time
+	stats   map[string]*destinationStats
+}
+
+func newDNSTracker(timeout time.Duration) *dnsTracker {
+	return &dnsTracker{timeout: timeout, pending: map[dnsQueryKey]time.Time{}, stats: map[string]*destinationStats{}}
+}
+
+// packet records the DNS query or response of the IP packet, the other packets are ignored. A query seen twice, e.g.
+// on the loopback interface, keeps the time it was first seen.
+func (t *dnsTracker) packet(data []byte, now time.Time) {
+	src, dst, sport, dport, payload, ok := parseUDP(data)
+	if !ok || len(payload) < dnsHeaderLen {
+		return
+	}
+	id := binary.BigEndian.Uint16(payload[0:2])
+	flags := binary.BigEndian.Uint16(payload[2:4])
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if flags&dnsFlagResponse == 0 {
+		if dport != dnsPort {
+			return
+		}
+		key := dnsQueryKey{resolver: resolverName(dst), port: sport, id: id}
+		if _, ok := t.pending[key]; ok || len(t.pending) >= maxPendingQueries {
+			return
+		}
+		t.pending[key] = now
+		t.destination(key.resolver).queries++
+		return
+	}
+	if sport != dnsPort {
+		return
+	}
+	key := dnsQueryKey{resolver: resolverName(src), port: dport, id: id}
+	sent, ok := t.pending[key]
+	if !ok {
+		return
+	}
+	delete(t.pending, key)
+	s := t.destination(key.resolver)
+	s.responses++
+	s.dnsLatency += now.Sub(sent)
+	if rcode := flags & dnsRcodeMask; rcode != 0 && rcode != dnsRcodeNXDomain {
+		s.failures++
+	}
+}
+
+// collect returns the metrics since the previous collect, the queries pending for the timeout count as timeouts
+func (t *dnsTracker) collect(now time.Time) map[string]*destinationStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, sent := range t.pending {
+		if now.Sub(sent) >= t.timeout {
+			delete(t.pending, key)
+			t.destination(key.resolver).timeouts++
+		}
+	}
+	stats := t.stats
+	t.stats = map[string]*destinationStats{}
+	return stats
+}
+
+func (t *dnsTracker) destination(resolver string) *destinationStats {
+	s, ok := t.stats[resolver]
+	if !ok {
+		s = &destinationStats{}
+		t.stats[resolver] = s
+	}
+	return s
+}
+
+func resolverName(ip net.IP) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(dnsPort))
+}
+
+// parseUDP returns the addresses, the ports and the payload of the UDP datagram of the IPv4 or IPv6 packet, the
+// fragments but the first and the IPv6 packets with extension headers are not parsed
+func parseUDP(data []byte) (src, dst net.IP, sport, dport uint16, payload []byte, ok bool) {
+	if len(data) == 0 {
+		return
+	}
+	var udp []byte
+	switch data[0] >> 4 {
+	case 4:
+		if len(data) < 20 {
+			return
+		}
+		ihl := int(data[0]&0x0f) * 4
+		fragmentOffset := binary.BigEndian.Uint16(data[6:8]) & 0x1fff
+		if ihl < 20 || len(data) < ihl+udpHeaderLen || data[9] != protocolUDP || fragmentOffset != 0 {
+			return
+		}
+		src, dst, udp = net.IP(data[12:16]), net.IP(data[16:20]), data[ihl:]
+	case 6:
+		if len(data) < ipv6HeaderLen+udpHeaderLen || data[6] != protocolUDP {
+			return
+		}
+		src, dst, udp = net.IP(data[8:24]), net.IP(data[24:40]), data[ipv6HeaderLen:]
+	default:
+		return
+	}
+	sport = binary.BigEndian.Uint16(udp[0:2])
+	dport = binary.BigEndian.Uint16(udp[2:4])
+	return src, dst, sport, dport, udp[udpHeaderLen:], true
+}
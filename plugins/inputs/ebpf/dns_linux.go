@@ -0,0 +1,150 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package ebpf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// the bytes of the packets kept by the filter, the IP and UDP headers and the DNS header
+	dnsSnapLen = 128
+	// how often the receiving loop checks whether the probe is closed
+	dnsReadTimeout = time.Second
+)
+
+// dnsProbe receives the DNS queries and responses of all the interfaces on a packet socket, the packets other than
+// UDP from or to port 53 are filtered out in the kernel by an eBPF socket filter
+type dnsProbe struct {
+	tracker *dnsTracker
+	prog    *ebpf.Program
+	fd      int
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newDNSProbe(timeout time.Duration) (probe, error) {
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{Name: "dns_filter", Type: ebpf.SocketFilter, Instructions: dnsFilter(),
+		License: programLicense})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the socket filter: %v", err)
+	}
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_ALL)))
+	if err != nil {
+		prog.Close()
+		return nil, fmt.Errorf("unable to open the packet socket: %v", err)
+	}
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_ATTACH_BPF, prog.FD()); err != nil {
+		unix.Close(fd)
+		prog.Close()
+		return nil, fmt.Errorf("unable to attach the socket filter: %v", err)
+	}
+	tv := unix.NsecToTimeval(int64(dnsReadTimeout))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		prog.Close()
+		return nil, fmt.Errorf("unable to set the timeout of the packet socket: %v", err)
+	}
+	p := &dnsProbe{tracker: newDNSTracker(timeout), prog: prog, fd: fd, done: make(chan struct{})}
+	p.wg.Add(1)
+	go p.receive()
+	return p, nil
+}
+
+// receive records the packets, their time is the time they are received by the agent
+func (p *dnsProbe) receive() {
+	defer p.wg.Done()
+	buf := make([]byte, dnsSnapLen)
+	for {
+		n, _, err := unix.Recvfrom(p.fd, buf, 0)
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		if err == unix.EAGAIN || err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			log.Printf("E! [inputs.ebpf] Unable to receive the DNS packets, the DNS probe stops: %v", err)
+			return
+		}
+		p.tracker.packet(buf[:n], time.Now())
+	}
+}
+
+func (p *dnsProbe) name() string {
+	return probeDNS
+}
+
+func (p *dnsProbe) gather(now time.Time) map[string]*destinationStats {
+	return p.tracker.collect(now)
+}
+
+func (p *dnsProbe) close() {
+	close(p.done)
+	p.wg.Wait()
+	unix.Close(p.fd)
+	p.prog.Close()
+}
+
+// dnsFilter keeps the first bytes of the UDP packets from or to port 53, the packets start at the IP header on the
+// SOCK_DGRAM packet sockets
+func dnsFilter() asm.Instructions {
+	return asm.Instructions{
+		// the packet loads need the context in R6
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.LoadAbs(0, asm.Byte),
+		asm.RSh.Imm(asm.R0, 4),
+		asm.JEq.Imm(asm.R0, 4, "ipv4"),
+		asm.JEq.Imm(asm.R0, 6, "ipv6"),
+		asm.Ja.Label("drop"),
+
+		asm.LoadAbs(9, asm.Byte).Sym("ipv4"),
+		asm.JNE.Imm(asm.R0, protocolUDP, "drop"),
+		// the fragments but the first have no UDP header
+		asm.LoadAbs(6, asm.Half),
+		asm.And.Imm(asm.R0, 0x1fff),
+		asm.JNE.Imm(asm.R0, 0, "drop"),
+		asm.LoadAbs(0, asm.Byte),
+		asm.And.Imm(asm.R0, 0x0f),
+		asm.LSh.Imm(asm.R0, 2),
+		asm.Mov.Reg(asm.R7, asm.R0),
+		asm.LoadInd(asm.R0, asm.R7, 0, asm.Half),
+		asm.JEq.Imm(asm.R0, dnsPort, "accept"),
+		asm.LoadInd(asm.R0, asm.R7, 2, asm.Half),
+		asm.JEq.Imm(asm.R0, dnsPort, "accept"),
+		asm.Ja.Label("drop"),
+
+		asm.LoadAbs(6, asm.Byte).Sym("ipv6"),
+		asm.JNE.Imm(asm.R0, protocolUDP, "drop"),
+		asm.LoadAbs(ipv6HeaderLen, asm.Half),
+		asm.JEq.Imm(asm.R0, dnsPort, "accept"),
+		asm.LoadAbs(ipv6HeaderLen+2, asm.Half),
+		asm.JEq.Imm(asm.R0, dnsPort, "accept"),
+
+		asm.Mov.Imm(asm.R0, 0).Sym("drop"),
+		asm.Return(),
+		asm.Mov.Imm(asm.R0, dnsSnapLen).Sym("accept"),
+		asm.Return(),
+	}
+}
+
+// htons returns the value in the network byte order, whatever the byte order of the host
+func htons(v uint16) uint16 {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return *(*uint16)(unsafe.Pointer(&b[0]))
+}
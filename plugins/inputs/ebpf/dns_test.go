@@ -0,0 +1,97 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ipv4Packet(src, dst string, sport, dport uint16, payload []byte) []byte {
+	packet := make([]byte, 20+udpHeaderLen, 20+udpHeaderLen+len(payload))
+	packet[0] = 0x45
+	packet[9] = protocolUDP
+	copy(packet[12:16], net.ParseIP(src).To4())
+	copy(packet[16:20], net.ParseIP(dst).To4())
+	binary.BigEndian.PutUint16(packet[20:22], sport)
+	binary.BigEndian.PutUint16(packet[22:24], dport)
+	return append(packet, payload...)
+}
+
+func ipv6Packet(src, dst string, sport, dport uint16, payload []byte) []byte {
+	packet := make([]byte, ipv6HeaderLen+udpHeaderLen, ipv6HeaderLen+udpHeaderLen+len(payload))
+	packet[0] = 0x60
+	packet[6] = protocolUDP
+	copy(packet[8:24], net.ParseIP(src))
+	copy(packet[24:40], net.ParseIP(dst))
+	binary.BigEndian.PutUint16(packet[40:42], sport)
+	binary.BigEndian.PutUint16(packet[42:44], dport)
+	return append(packet, payload...)
+}
+
+func dnsHeader(id, flags uint16) []byte {
+	header := make([]byte, dnsHeaderLen)
+	binary.BigEndian.PutUint16(header[0:2], id)
+	binary.BigEndian.PutUint16(header[2:4], flags)
+	return header
+}
+
+func TestDNSTracker(t *testing.T) {
+	tracker := newDNSTracker(5 * time.Second)
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tracker.packet(ipv4Packet("10.0.0.5", "10.0.0.2", 40000, dnsPort, dnsHeader(1, 0x0100)), now)
+	// the query seen again, e.g. on the loopback interface
+	tracker.packet(ipv4Packet("10.0.0.5", "10.0.0.2", 40000, dnsPort, dnsHeader(1, 0x0100)), now.Add(time.Millisecond))
+	tracker.packet(ipv4Packet("10.0.0.2", "10.0.0.5", dnsPort, 40000, dnsHeader(1, 0x8180)), now.Add(20*time.Millisecond))
+	// NXDOMAIN is an answer, SERVFAIL is a failure
+	tracker.packet(ipv4Packet("10.0.0.5", "10.0.0.2", 40001, dnsPort, dnsHeader(2, 0x0100)), now)
+	tracker.packet(ipv4Packet("10.0.0.2", "10.0.0.5", dnsPort, 40001, dnsHeader(2, 0x8183)), now.Add(10*time.Millisecond))
+	tracker.packet(ipv4Packet("10.0.0.5", "10.0.0.2", 40002, dnsPort, dnsHeader(3, 0x0100)), now)
+	tracker.packet(ipv4Packet("10.0.0.2", "10.0.0.5", dnsPort, 40002, dnsHeader(3, 0x8182)), now.Add(30*time.Millisecond))
+	// an unsolicited response and a packet which is not DNS
+	tracker.packet(ipv4Packet("10.0.0.2", "10.0.0.5", dnsPort, 40003, dnsHeader(4, 0x8180)), now)
+	tracker.packet(ipv4Packet("10.0.0.5", "10.0.0.9", 40004, 123, dnsHeader(5, 0x0100)), now)
+	tracker.packet(ipv6Packet("fd00::5", "fd00::2", 40005, dnsPort, dnsHeader(6, 0x0100)), now)
+
+	stats := tracker.collect(now.Add(time.Second))
+	require.Len(t, stats, 2)
+	s := stats["10.0.0.2:53"]
+	assert.Equal(t, uint64(3), s.queries)
+	assert.Equal(t, uint64(3), s.responses)
+	assert.Equal(t, uint64(1), s.failures)
+	assert.Equal(t, 60*time.Millisecond, s.dnsLatency)
+	assert.Equal(t, map[string]interface{}{"dns_queries": uint64(3), "dns_failures": uint64(1), "dns_timeouts": uint64(0),
+		"dns_latency": float64(20)}, s.fields(probeDNS))
+	assert.Equal(t, uint64(1), stats["[fd00::2]:53"].queries)
+
+	// the IPv6 query is still pending, then times out
+	assert.Empty(t, tracker.collect(now.Add(2*time.Second)))
+	stats = tracker.collect(now.Add(5 * time.Second))
+	assert.Equal(t, uint64(1), stats["[fd00::2]:53"].timeouts)
+	assert.Empty(t, tracker.pending)
+}
+
+func TestParseUDP(t *testing.T) {
+	_, _, _, _, _, ok := parseUDP(nil)
+	assert.False(t, ok)
+	// a fragment other than the first
+	packet := ipv4Packet("10.0.0.5", "10.0.0.2", 40000, dnsPort, dnsHeader(1, 0x0100))
+	packet[7] = 0x10
+	_, _, _, _, _, ok = parseUDP(packet)
+	assert.False(t, ok)
+
+	src, dst, sport, dport, payload, ok := parseUDP(ipv6Packet("fd00::5", "fd00::2", 40000, dnsPort, []byte{1, 2}))
+	require.True(t, ok)
+	assert.Equal(t, "fd00::5", src.String())
+	assert.Equal(t, "fd00::2", dst.String())
+	assert.Equal(t, uint16(40000), sport)
+	assert.Equal(t, uint16(dnsPort), dport)
+	assert.Equal(t, []byte{1, 2}, payload)
+}
@@ -0,0 +1,222 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement = "ebpf"
+
+	probeTCP = "tcp"
+	probeDNS = "dns"
+
+	defaultMaxDestinations = 50
+	defaultDNSTimeout      = 5 * time.Second
+	// otherDestination is the destination of the metrics of the destinations beyond max_destinations
+	otherDestination = "other"
+)
+
+// EBPF reports the TCP connect latency, the TCP retransmits and the DNS resolution latency by destination with eBPF
+// programs. The probes which cannot be loaded, e.g. on kernels without eBPF or without the tracepoints, are skipped and
+// the others keep being collected.
+type EBPF struct {
+	Probes []string `toml:"probes"`
+	// MaxDestinations is the highest number of destinations of a probe reported by interval, the busiest are kept
+	// and the others are merged into the "other" destination
+	MaxDestinations int `toml:"max_destinations"`
+	// DNSTimeout is how long a DNS query is waited for its response before it counts as a timeout
+	DNSTimeout internal.Duration `toml:"dns_timeout"`
+	// TracefsPath is where the tracefs is mounted, /sys/kernel/tracing or /sys/kernel/debug/tracing by default
+	TracefsPath string `toml:"tracefs_path"`
+
+	probes []probe
+	mu     sync.Mutex
+}
+
+// probe collects the metrics of a destination since the previous gather
+type probe interface {
+	name() string
+	gather(now time.Time) map[string]*destinationStats
+	close()
+}
+
+// destinationStats are the metrics of a destination, a TCP endpoint or a DNS resolver, over an interval
+type destinationStats struct {
+	connects        uint64
+	connectFailures uint64
+	connectLatency  time.Duration
+	retransmits     uint64
+
+	queries    uint64
+	failures   uint64
+	timeouts   uint64
+	dnsLatency time.Duration
+	responses  uint64
+}
+
+func (s *destinationStats) add(o *destinationStats) {
+	s.connects += o.connects
+	s.connectFailures += o.connectFailures
+	s.connectLatency += o.connectLatency
+	s.retransmits += o.retransmits
+	s.queries += o.queries
+	s.failures += o.failures
+	s.timeouts += o.timeouts
+	s.dnsLatency += o.dnsLatency
+	s.responses += o.responses
+}
+
+func (s *destinationStats) events() uint64 {
+	return s.connects + s.connectFailures + s.retransmits + s.queries
+}
+
+func (s *destinationStats) fields(probeName string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	switch probeName {
+	case probeTCP:
+		fields["tcp_connects"] = s.connects
+		fields["tcp_connect_failures"] = s.connectFailures
+		fields["tcp_retransmits"] = s.retransmits
+		if s.connects > 0 {
+			fields["tcp_connect_latency"] = milliseconds(s.connectLatency) / float64(s.connects)
+		}
+	case probeDNS:
+		fields["dns_queries"] = s.queries
+		fields["dns_failures"] = s.failures
+		fields["dns_timeouts"] = s.timeouts
+		if s.responses > 0 {
+			fields["dns_latency"] = milliseconds(s.dnsLatency) / float64(s.responses)
+		}
+	}
+	return fields
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+var sampleConfig = `
+  ## The probes, "tcp" for the connect latency and the retransmits by remote endpoint, "dns" for the resolution
+  ## latency by resolver. The probes the kernel cannot run are skipped with a warning.
+  probes = ["tcp", "dns"]
+  ## The highest number of destinations of a probe reported by interval, the others are merged into "other"
+  # max_destinations = 50
+  ## How long a DNS query is waited for its response before it counts as a timeout
+  # dns_timeout = "5s"
+  ## Where the tracefs is mounted, /sys/kernel/tracing or /sys/kernel/debug/tracing by default
+  # tracefs_path = "/sys/kernel/tracing"
+`
+
+func (e *EBPF) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EBPF) Description() string {
+	return "Report the TCP connect latency and retransmits and the DNS resolution latency by destination with eBPF"
+}
+
+func (e *EBPF) Start(acc telegraf.Accumulator) error {
+	if len(e.Probes) == 0 {
+		e.Probes = []string{probeTCP, probeDNS}
+	}
+	if e.MaxDestinations <= 0 {
+		e.MaxDestinations = defaultMaxDestinations
+	}
+	if e.DNSTimeout.Duration <= 0 {
+		e.DNSTimeout.Duration = defaultDNSTimeout
+	}
+	for _, name := range e.Probes {
+		if name != probeTCP && name != probeDNS {
+			return fmt.Errorf("ebpf: the probe %v is not supported, it must be %v or %v", name, probeTCP, probeDNS)
+		}
+	}
+	raiseMemlockLimit()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, name := range e.Probes {
+		var p probe
+		var err error
+		switch name {
+		case probeTCP:
+			p, err = newTCPProbe(e.TracefsPath)
+		case probeDNS:
+			p, err = newDNSProbe(e.DNSTimeout.Duration)
+		}
+		if err != nil {
+			log.Printf("W! [inputs.ebpf] The %v probe is unavailable, its metrics are not collected: %v", name, err)
+			continue
+		}
+		e.probes = append(e.probes, p)
+	}
+	return nil
+}
+
+func (e *EBPF) Gather(acc telegraf.Accumulator) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	for _, p := range e.probes {
+		for destination, s := range topDestinations(p.gather(now), e.MaxDestinations) {
+			acc.AddFields(measurement, s.fields(p.name()), map[string]string{"probe": p.name(), "destination": destination}, now)
+		}
+	}
+	return nil
+}
+
+func (e *EBPF) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range e.probes {
+		p.close()
+	}
+	e.probes = nil
+}
+
+// topDestinations keeps the max destinations with the most events, the others are merged into the other destination
+func topDestinations(stats map[string]*destinationStats, max int) map[string]*destinationStats {
+	if len(stats) <= max {
+		return stats
+	}
+	destinations := make([]string, 0, len(stats))
+	for d := range stats {
+		destinations = append(destinations, d)
+	}
+	sort.Slice(destinations, func(i, j int) bool {
+		ei, ej := stats[destinations[i]].events(), stats[destinations[j]].events()
+		if ei != ej {
+			return ei > ej
+		}
+		return destinations[i] < destinations[j]
+	})
+	result := make(map[string]*destinationStats, max+1)
+	other := &destinationStats{}
+	for i, d := range destinations {
+		if i < max {
+			result[d] = stats[d]
+		} else {
+			other.add(stats[d])
+		}
+	}
+	if s, ok := result[otherDestination]; ok {
+		other.add(s)
+	}
+	result[otherDestination] = other
+	return result
+}
+
+func init() {
+	inputs.Add("ebpf", func() telegraf.Input {
+		return &EBPF{}
+	})
+}
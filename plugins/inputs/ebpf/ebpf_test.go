@@ -0,0 +1,62 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProbe struct {
+	probeName string
+	stats     map[string]*destinationStats
+	closed    bool
+}
+
+func (p *fakeProbe) name() string { return p.probeName }
+func (p *fakeProbe) gather(now time.Time) map[string]*destinationStats {
+	stats := p.stats
+	p.stats = nil
+	return stats
+}
+func (p *fakeProbe) close() { p.closed = true }
+
+func TestGather(t *testing.T) {
+	p := &fakeProbe{probeName: probeTCP, stats: map[string]*destinationStats{
+		"10.0.0.1:443": {connects: 4, connectLatency: 10 * time.Millisecond, retransmits: 2},
+		"10.0.0.2:443": {connectFailures: 1},
+		"10.0.0.3:443": {connects: 1, connectLatency: time.Millisecond},
+	}}
+	e := &EBPF{MaxDestinations: 1, probes: []probe{p}}
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(&acc))
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, measurement, map[string]interface{}{"tcp_connects": uint64(4), "tcp_connect_failures": uint64(0),
+		"tcp_retransmits": uint64(2), "tcp_connect_latency": 2.5}, map[string]string{"probe": probeTCP, "destination": "10.0.0.1:443"})
+	acc.AssertContainsTaggedFields(t, measurement, map[string]interface{}{"tcp_connects": uint64(1), "tcp_connect_failures": uint64(1),
+		"tcp_retransmits": uint64(0), "tcp_connect_latency": float64(1)}, map[string]string{"probe": probeTCP, "destination": otherDestination})
+
+	e.Stop()
+	assert.True(t, p.closed)
+}
+
+func TestStart(t *testing.T) {
+	var acc testutil.Accumulator
+	e := &EBPF{Probes: []string{"udp"}}
+	assert.EqualError(t, e.Start(&acc), "ebpf: the probe udp is not supported, it must be tcp or dns")
+
+	// the probes which are unavailable are skipped
+	e = &EBPF{Probes: []string{probeTCP}, TracefsPath: "/nonexistent"}
+	require.NoError(t, e.Start(&acc))
+	assert.Empty(t, e.probes)
+	assert.Equal(t, defaultMaxDestinations, e.MaxDestinations)
+	assert.Equal(t, defaultDNSTimeout, e.DNSTimeout.Duration)
+	require.NoError(t, e.Gather(&acc))
+	assert.Empty(t, acc.Metrics)
+	e.Stop()
+}
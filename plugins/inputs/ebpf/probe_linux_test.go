@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package ebpf
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTCPProbe loads the programs in the kernel, it is skipped without the privileges or the tracepoints
+func TestTCPProbe(t *testing.T) {
+	raiseMemlockLimit()
+	p, err := newTCPProbe("")
+	if err != nil {
+		t.Skipf("the TCP probe is unavailable: %v", err)
+	}
+	defer p.close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	conn.Close()
+	l.Close()
+	// nothing listens on the port anymore, the connect is refused
+	_, err = net.Dial("tcp", addr)
+	require.Error(t, err)
+
+	stats := p.gather(time.Now())
+	require.Contains(t, stats, addr)
+	assert.Equal(t, uint64(1), stats[addr].connects)
+	assert.Equal(t, uint64(1), stats[addr].connectFailures)
+	assert.True(t, stats[addr].connectLatency > 0)
+
+	// the counters are reported once
+	assert.NotContains(t, p.gather(time.Now()), addr)
+}
+
+func TestDNSProbe(t *testing.T) {
+	raiseMemlockLimit()
+	p, err := newDNSProbe(time.Second)
+	if err != nil {
+		t.Skipf("the DNS probe is unavailable: %v", err)
+	}
+	defer p.close()
+	server, err := net.ListenPacket("udp4", "127.0.0.1:53")
+	if err != nil {
+		t.Skipf("unable to listen on the DNS port: %v", err)
+	}
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := server.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		// the response of the query, SERVFAIL
+		buf[2], buf[3] = 0x81, 0x82
+		server.WriteTo(buf[:n], addr)
+	}()
+
+	client, err := net.Dial("udp4", "127.0.0.1:53")
+	require.NoError(t, err)
+	defer client.Close()
+	query := []byte{0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0, 0}
+	_, err = client.Write(query)
+	require.NoError(t, err)
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = client.Read(make([]byte, 512))
+	require.NoError(t, err)
+
+	// the packets are received asynchronously
+	s := &destinationStats{}
+	for i := 0; i < 50 && s.responses == 0; i++ {
+		if stats, ok := p.gather(time.Now())["127.0.0.1:53"]; ok {
+			s.add(stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(1), s.queries)
+	assert.Equal(t, uint64(1), s.responses)
+	assert.Equal(t, uint64(1), s.failures)
+	assert.Equal(t, uint64(0), s.timeouts)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !linux
+
+package ebpf
+
+import (
+	"errors"
+	"time"
+)
+
+var errNotSupported = errors.New("eBPF is only available on Linux")
+
+func newTCPProbe(tracefsPath string) (probe, error) {
+	return nil, errNotSupported
+}
+
+func newDNSProbe(timeout time.Duration) (probe, error) {
+	return nil, errNotSupported
+}
+
+func raiseMemlockLimit() {}
@@ -0,0 +1,359 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package ebpf
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	tracepointStateChange = "sock/inet_sock_set_state"
+	tracepointRetransmit  = "tcp/tcp_retransmit_skb"
+
+	tcpEstablished = 1
+	tcpSynSent     = 2
+	afInet6        = 10
+	ipprotoTCP     = 6
+
+	// the sockets connecting and the remote endpoints tracked, the least recently used are evicted beyond
+	maxConnectingSockets = 10240
+	maxTCPDestinations   = 10240
+
+	bpfAny      = 0
+	bpfNoExist  = 1
+	labelExit   = "exit"
+	labelFailed = "failed"
+
+	// the stack of the programs: the socket and the time its connect started, the remote endpoint and its counters
+	stackSocket    = -8
+	stackStartTime = -16
+	stackKey       = -40
+	stackValue     = -72
+)
+
+// tcpKey is the remote endpoint of the connections, the address of the IPv4 endpoints is in the first 4 bytes
+type tcpKey struct {
+	Family uint16
+	Port   uint16
+	Addr   [16]byte
+}
+
+// tcpValue are the counters of the remote endpoint since it was first seen, the latency is in nanoseconds
+type tcpValue struct {
+	Connects        uint64
+	ConnectFailures uint64
+	ConnectLatency  uint64
+	Retransmits     uint64
+}
+
+// tcpProbe reports the connect latency and outcome of the sockets from their state changes, SYN_SENT to ESTABLISHED
+// or CLOSE, and their retransmits by remote endpoint
+type tcpProbe struct {
+	starts *ebpf.Map
+	stats  *ebpf.Map
+	progs  []*ebpf.Program
+	events []int
+	// the counters read by the previous gather
+	previous map[tcpKey]tcpValue
+}
+
+func newTCPProbe(tracefsPath string) (probe, error) {
+	tracefs, err := findTracefs(tracefsPath)
+	if err != nil {
+		return nil, err
+	}
+	stateChange, err := readTracepointFormat(tracefs, tracepointStateChange)
+	if err != nil {
+		return nil, fmt.Errorf("the tracepoint %v is unavailable, it requires Linux 4.16 or later: %v", tracepointStateChange, err)
+	}
+	p := &tcpProbe{previous: map[tcpKey]tcpValue{}}
+	if p.starts, err = ebpf.NewMap(&ebpf.MapSpec{Name: "tcp_starts", Type: ebpf.LRUHash, KeySize: 8, ValueSize: 8,
+		MaxEntries: maxConnectingSockets}); err != nil {
+		return nil, fmt.Errorf("unable to create the map of the connecting sockets: %v", err)
+	}
+	if p.stats, err = ebpf.NewMap(&ebpf.MapSpec{Name: "tcp_stats", Type: ebpf.LRUHash, KeySize: 20, ValueSize: 32,
+		MaxEntries: maxTCPDestinations}); err != nil {
+		p.close()
+		return nil, fmt.Errorf("unable to create the map of the remote endpoints: %v", err)
+	}
+	insns, err := stateChangeProgram(stateChange, p.starts.FD(), p.stats.FD())
+	if err == nil {
+		err = p.attach("tcp_state_change", stateChange, insns)
+	}
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+	// the retransmits are left out on the kernels without the tracepoint, the connects are still reported
+	retransmit, err := readTracepointFormat(tracefs, tracepointRetransmit)
+	if err == nil {
+		if insns, err = retransmitProgram(retransmit, p.stats.FD()); err == nil {
+			err = p.attach("tcp_retransmit", retransmit, insns)
+		}
+	}
+	if err != nil {
+		log.Printf("W! [inputs.ebpf] The TCP retransmits are not collected: %v", err)
+	}
+	return p, nil
+}
+
+func (p *tcpProbe) attach(name string, f *tracepointFormat, insns asm.Instructions) error {
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{Name: name, Type: ebpf.TracePoint, Instructions: insns, License: programLicense})
+	if err != nil {
+		return fmt.Errorf("unable to load the program of the tracepoint %v: %v", f.name, err)
+	}
+	fd, err := attachTracepoint(f, prog)
+	if err != nil {
+		prog.Close()
+		return err
+	}
+	p.progs = append(p.progs, prog)
+	p.events = append(p.events, fd)
+	return nil
+}
+
+func (p *tcpProbe) name() string {
+	return probeTCP
+}
+
+// gather returns the counters of the remote endpoints since the previous gather, the counters of an endpoint evicted
+// and seen again restart from zero
+func (p *tcpProbe) gather(now time.Time) map[string]*destinationStats {
+	stats := map[string]*destinationStats{}
+	current := make(map[tcpKey]tcpValue, len(p.previous))
+	var key tcpKey
+	var value tcpValue
+	iter := p.stats.Iterate()
+	for iter.Next(&key, &value) {
+		current[key] = value
+		prev, ok := p.previous[key]
+		if !ok || value.Connects < prev.Connects || value.ConnectFailures < prev.ConnectFailures ||
+			value.ConnectLatency < prev.ConnectLatency || value.Retransmits < prev.Retransmits {
+			prev = tcpValue{}
+		}
+		s := &destinationStats{
+			connects:        value.Connects - prev.Connects,
+			connectFailures: value.ConnectFailures - prev.ConnectFailures,
+			connectLatency:  time.Duration(value.ConnectLatency - prev.ConnectLatency),
+			retransmits:     value.Retransmits - prev.Retransmits,
+		}
+		if s.events() == 0 {
+			continue
+		}
+		destination := key.String()
+		if existing, ok := stats[destination]; ok {
+			existing.add(s)
+		} else {
+			stats[destination] = s
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("E! [inputs.ebpf] Unable to read the TCP counters: %v", err)
+	}
+	p.previous = current
+	return stats
+}
+
+func (p *tcpProbe) close() {
+	for _, fd := range p.events {
+		unix.Close(fd)
+	}
+	for _, prog := range p.progs {
+		prog.Close()
+	}
+	if p.starts != nil {
+		p.starts.Close()
+	}
+	if p.stats != nil {
+		p.stats.Close()
+	}
+	p.events, p.progs = nil, nil
+}
+
+// String is the remote endpoint, the IPv4-mapped addresses are IPv4 addresses
+func (k tcpKey) String() string {
+	ip := net.IP(k.Addr[:])
+	if k.Family != afInet6 {
+		ip = net.IP(k.Addr[:4])
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(k.Port)))
+}
+
+// programBuilder assembles the instructions of a tracepoint program, the first error is kept
+type programBuilder struct {
+	f     *tracepointFormat
+	insns asm.Instructions
+	err   error
+}
+
+func (b *programBuilder) emit(insns ...asm.Instruction) {
+	b.insns = append(b.insns, insns...)
+}
+
+func (b *programBuilder) load(dst asm.Register, name string) {
+	ins, err := loadField(b.f, dst, name)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	b.emit(ins)
+}
+
+// label marks the next instruction
+func (b *programBuilder) label(name string) {
+	b.emit(asm.Mov.Imm(asm.R0, 0).Sym(name))
+}
+
+// copyBytes copies the array field of the record to the stack
+func (b *programBuilder) copyBytes(name string, stackOffset int16, size int) {
+	field, err := b.f.field(name)
+	if err == nil && field.size != size {
+		err = fmt.Errorf("the field %v of the tracepoint %v has an unexpected size %d", name, b.f.name, field.size)
+	}
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return
+	}
+	for i := 0; i < size; i++ {
+		b.emit(asm.LoadMem(asm.R0, asm.R6, int16(field.offset+i), asm.Byte),
+			asm.StoreMem(asm.RFP, stackOffset+int16(i), asm.R0, asm.Byte))
+	}
+}
+
+// remoteEndpoint stores the tcpKey of the family, dport, daddr and daddr_v6 fields on the stack. The tracepoints of
+// the kernels before Linux 4.20 have no family field, the IPv4 sockets have their IPv4-mapped address in daddr_v6 so
+// the remote endpoint is read from daddr_v6 only.
+func (b *programBuilder) remoteEndpoint() {
+	b.emit(asm.StoreImm(asm.RFP, stackKey, 0, asm.DWord),
+		asm.StoreImm(asm.RFP, stackKey+8, 0, asm.DWord),
+		asm.StoreImm(asm.RFP, stackKey+16, 0, asm.DWord))
+	b.load(asm.R0, "dport")
+	b.emit(asm.StoreMem(asm.RFP, stackKey+2, asm.R0, asm.Half))
+	if _, ok := b.f.fields["family"]; !ok {
+		b.emit(asm.StoreImm(asm.RFP, stackKey, afInet6, asm.Half))
+		b.copyBytes("daddr_v6", stackKey+4, net.IPv6len)
+		return
+	}
+	b.load(asm.R1, "family")
+	b.emit(asm.StoreMem(asm.RFP, stackKey, asm.R1, asm.Half),
+		asm.JEq.Imm(asm.R1, afInet6, "ipv6"))
+	b.copyBytes("daddr", stackKey+4, net.IPv4len)
+	b.emit(asm.Ja.Label("endpoint"))
+	b.label("ipv6")
+	b.copyBytes("daddr_v6", stackKey+4, net.IPv6len)
+	b.label("endpoint")
+}
+
+// endpointCounters points R0 to the tcpValue of the tcpKey of the stack, which is added when missing
+func (b *programBuilder) endpointCounters(stats int) {
+	lookup := []asm.Instruction{
+		asm.LoadMapPtr(asm.R1, stats),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackKey),
+		asm.FnMapLookupElem.Call(),
+	}
+	b.emit(lookup...)
+	b.emit(asm.JNE.Imm(asm.R0, 0, "counters"),
+		asm.StoreImm(asm.RFP, stackValue, 0, asm.DWord),
+		asm.StoreImm(asm.RFP, stackValue+8, 0, asm.DWord),
+		asm.StoreImm(asm.RFP, stackValue+16, 0, asm.DWord),
+		asm.StoreImm(asm.RFP, stackValue+24, 0, asm.DWord),
+		asm.LoadMapPtr(asm.R1, stats),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackKey),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, stackValue),
+		asm.Mov.Imm(asm.R4, bpfNoExist),
+		asm.FnMapUpdateElem.Call())
+	b.emit(lookup...)
+	b.emit(asm.JEq.Imm(asm.R0, 0, labelExit))
+	// a no-op, R0 holds the counters
+	b.emit(asm.Mov.Reg(asm.R1, asm.R0).Sym("counters"))
+}
+
+// addCounter atomically adds src to the counter at the offset of the tcpValue pointed to by R0
+func (b *programBuilder) addCounter(offset int16, src asm.Register) {
+	ins := asm.StoreXAdd(asm.R0, src, asm.DWord)
+	ins.Offset = offset
+	b.emit(ins)
+}
+
+func (b *programBuilder) exit() {
+	b.emit(asm.Mov.Imm(asm.R0, 0).Sym(labelExit), asm.Return())
+}
+
+// stateChangeProgram records the time a socket enters SYN_SENT and counts the connects and their latency, or the
+// failures, when it leaves SYN_SENT, in the maps of the file descriptors
+func stateChangeProgram(f *tracepointFormat, starts, stats int) (asm.Instructions, error) {
+	b := &programBuilder{f: f}
+	b.emit(asm.Mov.Reg(asm.R6, asm.R1))
+	// the SCTP sockets have the same tracepoint, the older kernels have no protocol field
+	if _, ok := f.fields["protocol"]; ok {
+		b.load(asm.R0, "protocol")
+		b.emit(asm.JNE.Imm(asm.R0, ipprotoTCP, labelExit))
+	}
+	b.load(asm.R7, "skaddr")
+	b.emit(asm.StoreMem(asm.RFP, stackSocket, asm.R7, asm.DWord))
+	b.load(asm.R8, "newstate")
+	b.load(asm.R9, "oldstate")
+	b.emit(asm.JNE.Imm(asm.R8, tcpSynSent, "connected"),
+		asm.FnKtimeGetNs.Call(),
+		asm.StoreMem(asm.RFP, stackStartTime, asm.R0, asm.DWord),
+		asm.LoadMapPtr(asm.R1, starts),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackSocket),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, stackStartTime),
+		asm.Mov.Imm(asm.R4, bpfAny),
+		asm.FnMapUpdateElem.Call(),
+		asm.Ja.Label(labelExit),
+		asm.JNE.Imm(asm.R9, tcpSynSent, labelExit).Sym("connected"),
+		asm.LoadMapPtr(asm.R1, starts),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackSocket),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, labelExit),
+		asm.LoadMem(asm.R7, asm.R0, 0, asm.DWord),
+		asm.FnKtimeGetNs.Call(),
+		asm.Sub.Reg(asm.R0, asm.R7),
+		asm.Mov.Reg(asm.R7, asm.R0),
+		asm.LoadMapPtr(asm.R1, starts),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackSocket),
+		asm.FnMapDeleteElem.Call())
+	b.remoteEndpoint()
+	b.endpointCounters(stats)
+	b.emit(asm.JNE.Imm(asm.R8, tcpEstablished, labelFailed),
+		asm.Mov.Imm(asm.R1, 1))
+	b.addCounter(0, asm.R1)
+	b.addCounter(16, asm.R7)
+	b.emit(asm.Ja.Label(labelExit),
+		asm.Mov.Imm(asm.R1, 1).Sym(labelFailed))
+	b.addCounter(8, asm.R1)
+	b.exit()
+	return b.insns, b.err
+}
+
+// retransmitProgram counts the retransmits of the remote endpoints
+func retransmitProgram(f *tracepointFormat, stats int) (asm.Instructions, error) {
+	b := &programBuilder{f: f}
+	b.emit(asm.Mov.Reg(asm.R6, asm.R1))
+	b.remoteEndpoint()
+	b.endpointCounters(stats)
+	b.emit(asm.Mov.Imm(asm.R1, 1))
+	b.addCounter(24, asm.R1)
+	b.exit()
+	return b.insns, b.err
+}
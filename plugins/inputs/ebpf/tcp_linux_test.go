@@ -0,0 +1,99 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package ebpf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/cilium/ebpf/asm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the format of Linux 4.20 and later, with the family and protocol fields
+const stateChangeFormatFamily = `name: inet_sock_set_state
+ID: 1444
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:const void * skaddr;	offset:8;	size:8;	signed:0;
+	field:int oldstate;	offset:16;	size:4;	signed:1;
+	field:int newstate;	offset:20;	size:4;	signed:1;
+	field:__u16 sport;	offset:24;	size:2;	signed:0;
+	field:__u16 dport;	offset:26;	size:2;	signed:0;
+	field:__u16 family;	offset:28;	size:2;	signed:0;
+	field:__u8 protocol;	offset:30;	size:1;	signed:0;
+	field:__u8 saddr[4];	offset:31;	size:4;	signed:0;
+	field:__u8 daddr[4];	offset:35;	size:4;	signed:0;
+	field:__u8 saddr_v6[16];	offset:39;	size:16;	signed:0;
+	field:__u8 daddr_v6[16];	offset:55;	size:16;	signed:0;
+`
+
+// the format of the retransmits of Linux 4.16, without the family field
+const retransmitFormat = `name: tcp_retransmit_skb
+ID: 1450
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:const void * skbaddr;	offset:8;	size:8;	signed:0;
+	field:const void * skaddr;	offset:16;	size:8;	signed:0;
+	field:__u16 sport;	offset:24;	size:2;	signed:0;
+	field:__u16 dport;	offset:26;	size:2;	signed:0;
+	field:__u8 saddr[4];	offset:28;	size:4;	signed:0;
+	field:__u8 daddr[4];	offset:32;	size:4;	signed:0;
+	field:__u8 saddr_v6[16];	offset:36;	size:16;	signed:0;
+	field:__u8 daddr_v6[16];	offset:52;	size:16;	signed:0;
+`
+
+// storesFamily returns whether the program stores the IPv6 family in the tcpKey, rather than the family field
+func storesFamily(insns asm.Instructions) bool {
+	for _, ins := range insns {
+		if ins.Dst == asm.RFP && ins.Offset == stackKey && ins.OpCode == asm.StoreImmOp(asm.Half) && ins.Constant == afInet6 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTCPPrograms(t *testing.T) {
+	for _, test := range []struct {
+		name, format string
+		program      func(f *tracepointFormat) (asm.Instructions, error)
+		noFamily     bool
+	}{
+		{"state change 4.16", stateChangeFormat, func(f *tracepointFormat) (asm.Instructions, error) { return stateChangeProgram(f, 3, 4) }, true},
+		{"state change 4.20", stateChangeFormatFamily, func(f *tracepointFormat) (asm.Instructions, error) { return stateChangeProgram(f, 3, 4) }, false},
+		{"retransmit 4.16", retransmitFormat, func(f *tracepointFormat) (asm.Instructions, error) { return retransmitProgram(f, 4) }, true},
+	} {
+		f, err := parseTracepointFormat(test.name, strings.NewReader(test.format))
+		require.NoError(t, err)
+		insns, err := test.program(f)
+		require.NoError(t, err, test.name)
+		// the jumps are all resolved
+		assert.NoError(t, insns.Marshal(&bytes.Buffer{}, binary.LittleEndian), test.name)
+		assert.Equal(t, test.noFamily, storesFamily(insns), test.name)
+	}
+}
+
+func TestTCPKeyString(t *testing.T) {
+	k := tcpKey{Family: afInet6, Port: 443}
+	copy(k.Addr[:], net.ParseIP("10.0.0.20").To16())
+	// the IPv4-mapped addresses of the kernels without the family field
+	assert.Equal(t, "10.0.0.20:443", k.String())
+
+	copy(k.Addr[:], net.ParseIP("2001:db8::1"))
+	assert.Equal(t, "[2001:db8::1]:443", k.String())
+
+	k = tcpKey{Family: 2, Port: 80}
+	copy(k.Addr[:], net.ParseIP("10.0.0.20").To4())
+	assert.Equal(t, "10.0.0.20:80", k.String())
+}
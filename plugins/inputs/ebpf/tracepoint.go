@@ -0,0 +1,119 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var defaultTracefsPaths = []string{"/sys/kernel/tracing", "/sys/kernel/debug/tracing"}
+
+type tracepointField struct {
+	offset int
+	size   int
+}
+
+// tracepointFormat is the ID and the layout of the record of a tracepoint, read from its format file as the layout
+// differs between the kernel versions
+type tracepointFormat struct {
+	name   string
+	id     int
+	fields map[string]tracepointField
+}
+
+func (f *tracepointFormat) field(name string) (tracepointField, error) {
+	field, ok := f.fields[name]
+	if !ok {
+		return field, fmt.Errorf("the tracepoint %v has no %v field", f.name, name)
+	}
+	return field, nil
+}
+
+// parseTracepointFormat parses the format file of a tracepoint, e.g.
+//
+//	ID: 1444
+//	format:
+//		field:const void * skaddr;	offset:8;	size:8;	signed:0;
+//		field:__u8 daddr[4];	offset:36;	size:4;	signed:0;
+func parseTracepointFormat(name string, r io.Reader) (*tracepointFormat, error) {
+	f := &tracepointFormat{name: name, id: -1, fields: map[string]tracepointField{}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "ID:") {
+			id, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "ID:")))
+			if err != nil {
+				return nil, fmt.Errorf("the ID of the tracepoint %v is not valid: %v", name, err)
+			}
+			f.id = id
+			continue
+		}
+		if !strings.HasPrefix(line, "field:") {
+			continue
+		}
+		var fieldName string
+		var field tracepointField
+		var err error
+		for _, part := range strings.Split(line, ";") {
+			part = strings.TrimSpace(part)
+			switch {
+			case strings.HasPrefix(part, "field:"):
+				words := strings.Fields(strings.TrimPrefix(part, "field:"))
+				if len(words) == 0 {
+					continue
+				}
+				fieldName = strings.TrimLeft(words[len(words)-1], "*")
+				if i := strings.IndexByte(fieldName, '['); i >= 0 {
+					fieldName = fieldName[:i]
+				}
+			case strings.HasPrefix(part, "offset:"):
+				field.offset, err = strconv.Atoi(strings.TrimPrefix(part, "offset:"))
+			case strings.HasPrefix(part, "size:"):
+				field.size, err = strconv.Atoi(strings.TrimPrefix(part, "size:"))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("the field %v of the tracepoint %v is not valid: %v", fieldName, name, err)
+			}
+		}
+		if fieldName != "" {
+			f.fields[fieldName] = field
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if f.id < 0 {
+		return nil, fmt.Errorf("the format of the tracepoint %v has no ID", name)
+	}
+	return f, nil
+}
+
+// readTracepointFormat reads the format of the tracepoint, e.g. sock/inet_sock_set_state, of the tracefs
+func readTracepointFormat(tracefs, name string) (*tracepointFormat, error) {
+	file, err := os.Open(filepath.Join(tracefs, "events", name, "format"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseTracepointFormat(name, file)
+}
+
+// findTracefs returns the configured tracefs or the first of the default paths which is mounted
+func findTracefs(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	for _, p := range defaultTracefsPaths {
+		if _, err := os.Stat(filepath.Join(p, "events")); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("the tracefs is not mounted on %v", strings.Join(defaultTracefsPaths, " nor "))
+}
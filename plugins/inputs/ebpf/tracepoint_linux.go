@@ -0,0 +1,81 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package ebpf
+
+import (
+	"fmt"
+	"log"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"golang.org/x/sys/unix"
+)
+
+// the license of the programs, the kernel rejects some helpers for the programs which are not GPL compatible
+const programLicense = "Dual MIT/GPL"
+
+// attachTracepoint runs the program on each record of the tracepoint until the returned perf event is closed
+func attachTracepoint(f *tracepointFormat, prog *ebpf.Program) (int, error) {
+	attr := unix.PerfEventAttr{
+		Type:        unix.PERF_TYPE_TRACEPOINT,
+		Size:        uint32(unsafe.Sizeof(unix.PerfEventAttr{})),
+		Config:      uint64(f.id),
+		Sample:      1,
+		Sample_type: unix.PERF_SAMPLE_RAW,
+		Wakeup:      1,
+	}
+	// the program of a tracepoint runs on all the CPUs, whichever CPU the perf event is opened on
+	fd, err := unix.PerfEventOpen(&attr, -1, 0, -1, unix.PERF_FLAG_FD_CLOEXEC)
+	if err != nil {
+		return -1, fmt.Errorf("unable to open the perf event of the tracepoint %v: %v", f.name, err)
+	}
+	if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_SET_BPF, prog.FD()); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("unable to attach the program to the tracepoint %v: %v", f.name, err)
+	}
+	if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_ENABLE, 0); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("unable to enable the tracepoint %v: %v", f.name, err)
+	}
+	return fd, nil
+}
+
+// loadField loads the field of the tracepoint record pointed to by R6 into dst
+func loadField(f *tracepointFormat, dst asm.Register, name string) (asm.Instruction, error) {
+	field, err := f.field(name)
+	if err != nil {
+		return asm.Instruction{}, err
+	}
+	size, err := fieldSize(f, name, field.size)
+	if err != nil {
+		return asm.Instruction{}, err
+	}
+	return asm.LoadMem(dst, asm.R6, int16(field.offset), size), nil
+}
+
+func fieldSize(f *tracepointFormat, name string, size int) (asm.Size, error) {
+	switch size {
+	case 1:
+		return asm.Byte, nil
+	case 2:
+		return asm.Half, nil
+	case 4:
+		return asm.Word, nil
+	case 8:
+		return asm.DWord, nil
+	}
+	return 0, fmt.Errorf("the field %v of the tracepoint %v has an unexpected size %d", name, f.name, size)
+}
+
+// raiseMemlockLimit lifts the limit of the memory the maps and the programs may lock, which kernels before 5.11
+// account them against
+func raiseMemlockLimit() {
+	limit := &unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY}
+	if err := unix.Setrlimit(unix.RLIMIT_MEMLOCK, limit); err != nil {
+		log.Printf("D! [inputs.ebpf] Unable to raise the memlock limit: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the format of Linux 4.16, without the protocol field
+const stateChangeFormat = `name: inet_sock_set_state
+ID: 1444
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:const void * skaddr;	offset:8;	size:8;	signed:0;
+	field:int oldstate;	offset:16;	size:4;	signed:1;
+	field:int newstate;	offset:20;	size:4;	signed:1;
+	field:__u16 sport;	offset:24;	size:2;	signed:0;
+	field:__u16 dport;	offset:26;	size:2;	signed:0;
+	field:__u8 saddr[4];	offset:28;	size:4;	signed:0;
+	field:__u8 daddr[4];	offset:32;	size:4;	signed:0;
+	field:__u8 saddr_v6[16];	offset:36;	size:16;	signed:0;
+	field:__u8 daddr_v6[16];	offset:52;	size:16;	signed:0;
+
+print fmt: "sport=%hu dport=%hu saddr=%pI4 daddr=%pI4", REC->sport, REC->dport, REC->saddr, REC->daddr
+`
+
+func TestParseTracepointFormat(t *testing.T) {
+	f, err := parseTracepointFormat("sock/inet_sock_set_state", strings.NewReader(stateChangeFormat))
+	require.NoError(t, err)
+	assert.Equal(t, 1444, f.id)
+	assert.Equal(t, tracepointField{offset: 8, size: 8}, f.fields["skaddr"])
+	assert.Equal(t, tracepointField{offset: 52, size: 16}, f.fields["daddr_v6"])
+	assert.Equal(t, tracepointField{offset: 26, size: 2}, f.fields["dport"])
+
+	_, err = f.field("protocol")
+	assert.EqualError(t, err, "the tracepoint sock/inet_sock_set_state has no protocol field")
+
+	_, err = parseTracepointFormat("tcp/tcp_retransmit_skb", strings.NewReader("format:\n"))
+	assert.Error(t, err)
+}
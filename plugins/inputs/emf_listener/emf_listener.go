@@ -0,0 +1,600 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package emf_listener
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/listenauth"
+	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	// measurement is the name of the metrics of the documents, the cloudwatchlogs output sends their value as is
+	measurement   = "emf"
+	logEntryField = "value"
+
+	// defaultMaxPayloadSize is the max size of a log event of CloudWatch Logs
+	defaultMaxPayloadSize = 256 * 1024
+	defaultTokenKey       = "_tenant_token"
+	defaultStatsNamespace = "CWAgent"
+	// defaultTenant is the name of the senders without a tenant, their documents name their own log group
+	defaultTenant = "default"
+
+	udpMaxPacketSize  = 64 * 1024
+	tcpReadBufferSize = 64 * 1024
+	maxTCPConnections = 256
+)
+
+var defaultServiceAddresses = []string{"udp://127.0.0.1:25888", "tcp://127.0.0.1:25888"}
+
+type EMFListener struct {
+	// ServiceAddresses are the udp:// and tcp:// addresses of the senders without a tenant of their own address
+	ServiceAddresses []string `toml:"service_addresses"`
+	// MaxPayloadSize is the max size in bytes of a document, the larger ones are dropped
+	MaxPayloadSize int `toml:"max_payload_size"`
+	// TokenKey is the top level key of the documents holding the token of their tenant, it is removed before sending
+	TokenKey string   `toml:"token_key"`
+	Tenants  []Tenant `toml:"tenant"`
+	// StatsLogGroupName is the log group the counters of the documents of every tenant are reported to each interval
+	// in the embedded metric format, they are only logged when it is empty
+	StatsLogGroupName  string `toml:"stats_log_group_name"`
+	StatsLogStreamName string `toml:"stats_log_stream_name"`
+	StatsNamespace     string `toml:"stats_namespace"`
+	// Auth authenticates the senders by their address, a shared token and, over tcp, their certificate
+	Auth listenauth.Config `toml:"auth"`
+
+	acc       telegraf.Accumulator
+	auth      *listenauth.Authenticator
+	listeners []*listener
+	// tokenTenants are the tenants with a token, the tokens are compared in constant time
+	tokenTenants []*Tenant
+	done         chan struct{}
+	wg           sync.WaitGroup
+
+	mu       sync.Mutex
+	counters map[string]*counters
+	rejected int64
+}
+
+// Tenant is an application sharing the listener, its documents are received on its own address or carry its token,
+// the log group and stream it sets override the ones of its documents
+type Tenant struct {
+	Name           string `toml:"name"`
+	ServiceAddress string `toml:"service_address"`
+	Token          string `toml:"token"`
+	LogGroupName   string `toml:"log_group_name"`
+	LogStreamName  string `toml:"log_stream_name"`
+}
+
+// counters are the documents of a tenant received since the previous collection
+type counters struct {
+	documents    int64
+	malformed    int64
+	oversized    int64
+	unknownToken int64
+}
+
+type listener struct {
+	network string
+	address string
+	// tenant is nil for the senders without a tenant of their own address
+	tenant *Tenant
+	packet net.PacketConn
+	stream net.Listener
+}
+
+type awsMetadata struct {
+	LogGroupName  string `json:"LogGroupName"`
+	LogStreamName string `json:"LogStreamName"`
+}
+
+var sampleConfig = `
+  ## The addresses the documents of the senders without a tenant of their own address are received on, one
+  ## document per line
+  # service_addresses = ["udp://127.0.0.1:25888", "tcp://127.0.0.1:25888"]
+
+  ## The max size in bytes of a document, the larger ones are dropped
+  # max_payload_size = 262144
+
+  ## The top level key of the documents holding the token of their tenant, removed before sending
+  # token_key = "_tenant_token"
+
+  ## The applications sharing the listener, the documents received on the address of a tenant or carrying its
+  ## token are sent to its log group and stream instead of the ones they name
+  # [[inputs.emf_listener.tenant]]
+  #   name = "orders"
+  #   service_address = "tcp://127.0.0.1:25889"
+  #   log_group_name = "/app/orders"
+  # [[inputs.emf_listener.tenant]]
+  #   name = "billing"
+  #   token = "secret"
+  #   log_group_name = "/app/billing"
+  #   log_stream_name = "{instance_id}"
+
+  ## The log group the counters of the accepted, malformed and oversized documents of every tenant are reported
+  ## to each interval in the embedded metric format, they are only logged by default
+  # stats_log_group_name = "/aws/cwagent/emf_listener"
+  # stats_namespace = "CWAgent"
+
+  ## Authenticate the senders before accepting their documents on non-loopback interfaces:
+  ## by their address, by a shared token sent as the first line "auth:<token>" of each
+  ## datagram or connection, and over tcp by a certificate signed by the allowed CAs
+  # [inputs.emf_listener.auth]
+  #   allowed_cidrs = ["10.0.0.0/8"]
+  #   token_file = "/etc/amazon/emf-token"
+  #   tls_cert = "/etc/amazon/emf.pem"
+  #   tls_key = "/etc/amazon/emf.key"
+  #   tls_allowed_cacerts = ["/etc/amazon/clients-ca.pem"]
+`
+
+func (e *EMFListener) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EMFListener) Description() string {
+	return "Receive the embedded metric format documents of the applications over udp and tcp, mapping them to tenants"
+}
+
+// init validates the tenants and resolves the listeners
+func (e *EMFListener) init() error {
+	if e.MaxPayloadSize <= 0 {
+		e.MaxPayloadSize = defaultMaxPayloadSize
+	}
+	if e.TokenKey == "" {
+		e.TokenKey = defaultTokenKey
+	}
+	if e.StatsNamespace == "" {
+		e.StatsNamespace = defaultStatsNamespace
+	}
+	e.counters = map[string]*counters{defaultTenant: {}}
+	e.tokenTenants = nil
+	e.listeners = nil
+	for _, address := range e.ServiceAddresses {
+		l, err := newListener(address, nil)
+		if err != nil {
+			return err
+		}
+		e.listeners = append(e.listeners, l)
+	}
+	for i := range e.Tenants {
+		t := &e.Tenants[i]
+		if t.Name == "" || t.Name == defaultTenant {
+			return fmt.Errorf("emf_listener: the tenant %d has an invalid name %q", i+1, t.Name)
+		}
+		if _, ok := e.counters[t.Name]; ok {
+			return fmt.Errorf("emf_listener: the tenant %v is configured more than once", t.Name)
+		}
+		e.counters[t.Name] = &counters{}
+		if t.ServiceAddress == "" && t.Token == "" {
+			return fmt.Errorf("emf_listener: the tenant %v has neither a service_address nor a token", t.Name)
+		}
+		if t.Token != "" {
+			for _, other := range e.tokenTenants {
+				if other.Token == t.Token {
+					return fmt.Errorf("emf_listener: the tenants %v and %v have the same token", other.Name, t.Name)
+				}
+			}
+			e.tokenTenants = append(e.tokenTenants, t)
+		}
+		if t.ServiceAddress != "" {
+			l, err := newListener(t.ServiceAddress, t)
+			if err != nil {
+				return err
+			}
+			e.listeners = append(e.listeners, l)
+		}
+	}
+	seen := map[string]bool{}
+	for _, l := range e.listeners {
+		key := l.network + "://" + l.address
+		if seen[key] {
+			return fmt.Errorf("emf_listener: the service address %v is configured more than once", key)
+		}
+		seen[key] = true
+	}
+	auth, err := e.Auth.Authenticator()
+	if err != nil {
+		return fmt.Errorf("emf_listener: invalid auth: %v", err)
+	}
+	e.auth = auth
+	if e.auth.TLSConfig() != nil {
+		for _, l := range e.listeners {
+			if l.network == "udp" {
+				return errors.New("emf_listener: the tls auth requires the service addresses to be tcp")
+			}
+		}
+	}
+	return nil
+}
+
+func newListener(serviceAddress string, tenant *Tenant) (*listener, error) {
+	spl := strings.SplitN(serviceAddress, "://", 2)
+	if len(spl) != 2 || (spl[0] != "udp" && spl[0] != "tcp") || spl[1] == "" {
+		return nil, fmt.Errorf("emf_listener: invalid service address %q, expecting udp://host:port or tcp://host:port", serviceAddress)
+	}
+	return &listener{network: spl[0], address: spl[1], tenant: tenant}, nil
+}
+
+// Preflight checks that the listener is able to listen on all its addresses
+func (e *EMFListener) Preflight() []preflight.Check {
+	if err := e.init(); err != nil {
+		return []preflight.Check{{Kind: preflight.KindSocket, Status: preflight.StatusError, Error: err.Error()}}
+	}
+	checks := make([]preflight.Check, 0, len(e.listeners))
+	for _, l := range e.listeners {
+		checks = append(checks, preflight.CheckListen(l.network, l.address))
+	}
+	return checks
+}
+
+func (e *EMFListener) Start(acc telegraf.Accumulator) error {
+	if err := e.init(); err != nil {
+		return err
+	}
+	e.acc = acc
+	e.done = make(chan struct{})
+	for _, l := range e.listeners {
+		var err error
+		if l.network == "udp" {
+			l.packet, err = net.ListenPacket(l.network, l.address)
+		} else if l.stream, err = net.Listen(l.network, l.address); err == nil {
+			l.stream = e.auth.Listener(l.stream)
+		}
+		if err != nil {
+			e.closeListeners()
+			return fmt.Errorf("emf_listener: unable to listen on %v://%v: %v", l.network, l.address, err)
+		}
+	}
+	for _, l := range e.listeners {
+		e.wg.Add(1)
+		if l.packet != nil {
+			go e.udpListen(l)
+		} else {
+			go e.tcpListen(l)
+		}
+		log.Printf("I! emf_listener: listening on %v://%v for %v", l.network, l.address, tenantName(l.tenant))
+	}
+	return nil
+}
+
+func (e *EMFListener) closeListeners() {
+	for _, l := range e.listeners {
+		if l.packet != nil {
+			l.packet.Close()
+		}
+		if l.stream != nil {
+			l.stream.Close()
+		}
+	}
+}
+
+func (e *EMFListener) Stop() {
+	close(e.done)
+	e.closeListeners()
+	e.wg.Wait()
+}
+
+// udpListen reads the datagrams until the listener is closed, a datagram has one or more documents, one per line
+func (e *EMFListener) udpListen(l *listener) {
+	defer e.wg.Done()
+	buf := make([]byte, udpMaxPacketSize)
+	for {
+		n, addr, err := l.packet.ReadFrom(buf)
+		if err != nil {
+			if !strings.Contains(err.Error(), "closed network") {
+				log.Printf("E! emf_listener: unable to read from %v://%v: %v", l.network, l.address, err)
+				continue
+			}
+			return
+		}
+		packet, err := e.auth.Packet(addr, buf[:n])
+		if err != nil {
+			e.reject(addr, err)
+			continue
+		}
+		for _, line := range bytes.Split(packet, []byte("\n")) {
+			e.handle(l.tenant, line, len(line) > e.MaxPayloadSize)
+		}
+	}
+}
+
+// tcpListen accepts the connections until the listener is closed, each line of a connection is a document
+func (e *EMFListener) tcpListen(l *listener) {
+	defer e.wg.Done()
+	connections := make(chan struct{}, maxTCPConnections)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		connections <- struct{}{}
+		conn, err := l.stream.Accept()
+		if err != nil {
+			if !strings.Contains(err.Error(), "closed network") {
+				log.Printf("E! emf_listener: unable to accept the connections on %v://%v: %v", l.network, l.address, err)
+			}
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				conn.Close()
+				<-connections
+				wg.Done()
+			}()
+			e.serveConn(l.tenant, conn)
+		}()
+	}
+}
+
+func (e *EMFListener) serveConn(tenant *Tenant, conn net.Conn) {
+	// the connections are closed on stop
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-e.done:
+			conn.Close()
+		case <-stopped:
+		}
+	}()
+
+	authenticated, err := e.auth.Conn(conn)
+	if err != nil {
+		e.reject(conn.RemoteAddr(), err)
+		return
+	}
+	r := bufio.NewReaderSize(authenticated, tcpReadBufferSize)
+	var buf []byte
+	for {
+		line, oversized, err := readLine(r, e.MaxPayloadSize, buf[:0])
+		e.handle(tenant, line, oversized)
+		buf = line
+		if err != nil {
+			return
+		}
+	}
+}
+
+// reject counts the datagrams and the connections of the senders which are not authenticated, they are logged at
+// most once every thousand
+func (e *EMFListener) reject(addr net.Addr, err error) {
+	e.mu.Lock()
+	e.rejected++
+	rejected := e.rejected
+	e.mu.Unlock()
+	if rejected == 1 || rejected%1000 == 0 {
+		log.Printf("W! emf_listener: rejected %v datagrams or connections so far, the last one from %v: %v", rejected, addr, err)
+	}
+}
+
+// readLine reads the next line of the reader into buf, the lines longer than max are discarded and reported oversized
+func readLine(r *bufio.Reader, max int, buf []byte) ([]byte, bool, error) {
+	oversized := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !oversized {
+			// the line might end with \r\n
+			if len(buf)+len(chunk) > max+2 {
+				oversized = true
+				buf = buf[:0]
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if oversized || len(bytes.TrimRight(buf, "\r\n")) > max {
+			return buf[:0], true, err
+		}
+		return buf, false, err
+	}
+}
+
+// handle sends a document to the log group and stream of its tenant, the tenant of the address it was received on
+// or the tenant of its token
+func (e *EMFListener) handle(tenant *Tenant, line []byte, oversized bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 && !oversized {
+		return
+	}
+	if oversized {
+		e.count(tenant, func(c *counters) { c.oversized++ })
+		log.Printf("D! emf_listener: dropped a document of %v larger than %d bytes", tenantName(tenant), e.MaxPayloadSize)
+		return
+	}
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(line, &document); err != nil {
+		e.malformed(tenant, fmt.Sprintf("it is not a JSON object: %v", err))
+		return
+	}
+	if raw, ok := document[e.TokenKey]; ok {
+		var token string
+		if err := json.Unmarshal(raw, &token); err != nil {
+			e.malformed(tenant, fmt.Sprintf("its %v is not a string", e.TokenKey))
+			return
+		}
+		if tenant == nil {
+			if tenant = e.tokenTenant(token); tenant == nil {
+				e.count(nil, func(c *counters) { c.unknownToken++ })
+				log.Printf("D! emf_listener: dropped a document with an unknown %v", e.TokenKey)
+				return
+			}
+		}
+		// the token is not sent to CloudWatch Logs
+		delete(document, e.TokenKey)
+		var err error
+		if line, err = json.Marshal(document); err != nil {
+			e.malformed(tenant, err.Error())
+			return
+		}
+	}
+
+	logGroupName, logStreamName, err := destination(document)
+	if err != nil {
+		e.malformed(tenant, err.Error())
+		return
+	}
+	if tenant != nil && tenant.LogGroupName != "" {
+		logGroupName = tenant.LogGroupName
+	}
+	if tenant != nil && tenant.LogStreamName != "" {
+		logStreamName = tenant.LogStreamName
+	}
+	if logGroupName == "" {
+		e.malformed(tenant, "it has no log group name")
+		return
+	}
+	tags := map[string]string{logscommon.LogGroupNameTag: logGroupName}
+	// the stream of the cloudwatchlogs output is used when it is empty
+	if logStreamName != "" {
+		tags[logscommon.LogStreamNameTag] = logStreamName
+	}
+	e.count(tenant, func(c *counters) { c.documents++ })
+	e.acc.AddFields(measurement, map[string]interface{}{logEntryField: string(line)}, tags, time.Now())
+}
+
+// tokenTenant returns the tenant of the token, nil when the token is unknown. All the tokens are compared, in
+// constant time, so the time taken does not tell how much of a token is right.
+func (e *EMFListener) tokenTenant(token string) *Tenant {
+	var tenant *Tenant
+	for _, t := range e.tokenTenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+			tenant = t
+		}
+	}
+	return tenant
+}
+
+// destination returns the log group and stream names of a document, in its _aws metadata in the v1 format and at
+// the top level in the v0 one
+func destination(document map[string]json.RawMessage) (string, string, error) {
+	if raw, ok := document["_aws"]; ok {
+		var metadata awsMetadata
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return "", "", fmt.Errorf("its _aws metadata is invalid: %v", err)
+		}
+		return metadata.LogGroupName, metadata.LogStreamName, nil
+	}
+	var logGroupName, logStreamName string
+	if raw, ok := document["log_group_name"]; ok {
+		if err := json.Unmarshal(raw, &logGroupName); err != nil {
+			return "", "", fmt.Errorf("its log_group_name is not a string")
+		}
+	}
+	if raw, ok := document["log_stream_name"]; ok {
+		if err := json.Unmarshal(raw, &logStreamName); err != nil {
+			return "", "", fmt.Errorf("its log_stream_name is not a string")
+		}
+	}
+	return logGroupName, logStreamName, nil
+}
+
+func (e *EMFListener) malformed(tenant *Tenant, reason string) {
+	e.count(tenant, func(c *counters) { c.malformed++ })
+	log.Printf("D! emf_listener: dropped a malformed document of %v, %v", tenantName(tenant), reason)
+}
+
+func (e *EMFListener) count(tenant *Tenant, f func(c *counters)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	f(e.counters[tenantName(tenant)])
+}
+
+func tenantName(tenant *Tenant) string {
+	if tenant == nil {
+		return defaultTenant
+	}
+	return tenant.Name
+}
+
+// Gather reports the counters of the documents of every tenant since the previous collection, the dropped documents
+// are logged and the counters are sent to the stats log group when it is set
+func (e *EMFListener) Gather(acc telegraf.Accumulator) error {
+	e.mu.Lock()
+	snapshot := e.counters
+	e.counters = make(map[string]*counters, len(snapshot))
+	for name := range snapshot {
+		e.counters[name] = &counters{}
+	}
+	e.mu.Unlock()
+
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	now := time.Now()
+	for _, name := range names {
+		c := snapshot[name]
+		if c.malformed+c.oversized+c.unknownToken > 0 {
+			log.Printf("W! emf_listener: dropped %d malformed, %d oversized and %d documents with an unknown token of %v since the previous collection",
+				c.malformed, c.oversized, c.unknownToken, name)
+		}
+		if e.StatsLogGroupName == "" {
+			continue
+		}
+		document, err := e.statsDocument(name, c, now)
+		if err != nil {
+			return err
+		}
+		tags := map[string]string{logscommon.LogGroupNameTag: e.StatsLogGroupName}
+		if e.StatsLogStreamName != "" {
+			tags[logscommon.LogStreamNameTag] = e.StatsLogStreamName
+		}
+		acc.AddFields("emf_listener", map[string]interface{}{logEntryField: document}, tags, now)
+	}
+	return nil
+}
+
+// statsDocument returns the counters of a tenant in the embedded metric format, dimensioned by tenant
+func (e *EMFListener) statsDocument(tenant string, c *counters, now time.Time) (string, error) {
+	content := map[string]interface{}{
+		"tenant":                  tenant,
+		"documents":               c.documents,
+		"malformed_documents":     c.malformed,
+		"oversized_documents":     c.oversized,
+		"unknown_token_documents": c.unknownToken,
+		"_aws": map[string]interface{}{
+			"Timestamp": now.UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []interface{}{
+				map[string]interface{}{
+					"Namespace":  e.StatsNamespace,
+					"Dimensions": [][]string{{"tenant"}},
+					"Metrics": []map[string]string{
+						{"Name": "documents", "Unit": "Count"},
+						{"Name": "malformed_documents", "Unit": "Count"},
+						{"Name": "oversized_documents", "Unit": "Count"},
+						{"Name": "unknown_token_documents", "Unit": "Count"},
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("emf_listener: unable to encode the counters: %v", err)
+	}
+	return string(b), nil
+}
+
+func init() {
+	inputs.Add("emf_listener", func() telegraf.Input {
+		return &EMFListener{ServiceAddresses: defaultServiceAddresses}
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package emf_listener
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/listenauth"
+	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
+	tlsint "github.com/aws/amazon-cloudwatch-agent/internal/tls"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTenants(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	e := &EMFListener{
+		MaxPayloadSize: 200,
+		Tenants: []Tenant{
+			{Name: "orders", ServiceAddress: "tcp://127.0.0.1:0", LogGroupName: "/app/orders"},
+			{Name: "billing", Token: "secret", LogGroupName: "/app/billing", LogStreamName: "billing"},
+		},
+	}
+	require.NoError(t, e.init())
+	e.acc = acc
+	orders := &e.Tenants[0]
+
+	// the senders without a tenant name their log group
+	e.handle(nil, []byte(`{"_aws":{"LogGroupName":"own","LogStreamName":"s"},"latency":1}`+"\n"), false)
+	e.handle(nil, []byte(`{"log_group_name":"v0","latency":2}`), false)
+	// the tenant of the address overrides the log group of the document
+	e.handle(orders, []byte(`{"_aws":{"LogGroupName":"other"},"latency":3}`), false)
+	// the token is removed from the documents
+	e.handle(nil, []byte(`{"_tenant_token":"secret","latency":4}`), false)
+
+	e.handle(nil, []byte(`{"_tenant_token":"wrong","latency":5}`), false)
+	e.handle(nil, []byte(`{"latency":6}`), false)
+	e.handle(nil, []byte(`not json`), false)
+	e.handle(orders, []byte(`{"_aws":"invalid"}`), false)
+	e.handle(orders, nil, true)
+	e.handle(nil, []byte("  "), false)
+
+	require.Len(t, acc.Metrics, 4)
+	expected := []struct {
+		group, stream, value string
+	}{
+		{"own", "s", `{"_aws":{"LogGroupName":"own","LogStreamName":"s"},"latency":1}`},
+		{"v0", "", `{"log_group_name":"v0","latency":2}`},
+		{"/app/orders", "", `{"_aws":{"LogGroupName":"other"},"latency":3}`},
+		{"/app/billing", "billing", `{"latency":4}`},
+	}
+	for i, m := range acc.Metrics {
+		assert.Equal(t, measurement, m.Measurement)
+		assert.Equal(t, expected[i].group, m.Tags[logscommon.LogGroupNameTag])
+		assert.Equal(t, expected[i].stream, m.Tags[logscommon.LogStreamNameTag])
+		assert.Equal(t, expected[i].value, m.Fields[logEntryField])
+	}
+
+	assert.Equal(t, counters{documents: 2, malformed: 2, unknownToken: 1}, *e.counters[defaultTenant])
+	assert.Equal(t, counters{documents: 1, malformed: 1, oversized: 1}, *e.counters["orders"])
+	assert.Equal(t, counters{documents: 1}, *e.counters["billing"])
+}
+
+func TestInitErrors(t *testing.T) {
+	for _, e := range []*EMFListener{
+		{ServiceAddresses: []string{"127.0.0.1:25888"}},
+		{Tenants: []Tenant{{Name: "a"}}},
+		{Tenants: []Tenant{{Name: "a", Token: "t"}, {Name: "a", Token: "u"}}},
+		{Tenants: []Tenant{{Name: "a", Token: "t"}, {Name: "b", Token: "t"}}},
+		{Tenants: []Tenant{{Name: defaultTenant, Token: "t"}}},
+		{ServiceAddresses: defaultServiceAddresses, Tenants: []Tenant{{Name: "a", ServiceAddress: "udp://127.0.0.1:25888"}}},
+		{ServiceAddresses: []string{"tcp://127.0.0.1:25888"}, Auth: listenauth.Config{TokenFile: "missing"}},
+		// tls is not available over udp
+		{ServiceAddresses: defaultServiceAddresses, Auth: listenauth.Config{ServerConfig: tlsint.ServerConfig{TLSCert: "cert.pem", TLSKey: "key.pem"}}},
+	} {
+		assert.Error(t, e.init())
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader(strings.Repeat("a", 40)+"\nshort\r\n"+strings.Repeat("b", 11)+"\nlast"), 16)
+	var lines []string
+	var oversized []bool
+	for {
+		line, over, err := readLine(r, 10, nil)
+		lines = append(lines, string(line))
+		oversized = append(oversized, over)
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, []string{"", "short\r\n", "", "last"}, lines)
+	assert.Equal(t, []bool{true, false, true, false}, oversized)
+}
+
+func TestListen(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	e := &EMFListener{
+		ServiceAddresses:  []string{"udp://127.0.0.1:0"},
+		Tenants:           []Tenant{{Name: "orders", ServiceAddress: "tcp://127.0.0.1:0", LogGroupName: "/app/orders"}},
+		StatsLogGroupName: "/aws/cwagent/emf_listener",
+	}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	udp, err := net.Dial("udp", e.listeners[0].packet.LocalAddr().String())
+	require.NoError(t, err)
+	defer udp.Close()
+	_, err = udp.Write([]byte(`{"log_group_name":"own"}` + "\n" + `{"log_group_name":"own"}`))
+	require.NoError(t, err)
+
+	tcp, err := net.Dial("tcp", e.listeners[1].stream.Addr().String())
+	require.NoError(t, err)
+	_, err = tcp.Write([]byte(`{"latency":1}` + "\n{malformed\n"))
+	require.NoError(t, err)
+	tcp.Close()
+
+	require.Eventually(t, func() bool { return acc.NMetrics() == 3 }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.counters["orders"].malformed == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stats := &testutil.Accumulator{}
+	require.NoError(t, e.Gather(stats))
+	require.Len(t, stats.Metrics, 2)
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stats.Metrics[1].Fields[logEntryField].(string)), &document))
+	assert.Equal(t, "orders", document["tenant"])
+	assert.Equal(t, float64(1), document["documents"])
+	assert.Equal(t, float64(1), document["malformed_documents"])
+	assert.Equal(t, "/aws/cwagent/emf_listener", stats.Metrics[1].Tags[logscommon.LogGroupNameTag])
+
+	// the counters are reset on every collection
+	require.NoError(t, e.Gather(stats))
+	require.NoError(t, json.Unmarshal([]byte(stats.Metrics[3].Fields[logEntryField].(string)), &document))
+	assert.Equal(t, float64(0), document["documents"])
+}
+
+func TestListenAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emf_listener")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600))
+
+	acc := &testutil.Accumulator{}
+	e := &EMFListener{
+		ServiceAddresses: []string{"udp://127.0.0.1:0", "tcp://127.0.0.1:0"},
+		Auth:             listenauth.Config{TokenFile: tokenFile},
+	}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	udp, err := net.Dial("udp", e.listeners[0].packet.LocalAddr().String())
+	require.NoError(t, err)
+	defer udp.Close()
+	send := func(network, payload string) {
+		if network == "udp" {
+			_, err := udp.Write([]byte(payload))
+			require.NoError(t, err)
+			return
+		}
+		tcp, err := net.Dial("tcp", e.listeners[1].stream.Addr().String())
+		require.NoError(t, err)
+		_, err = tcp.Write([]byte(payload))
+		require.NoError(t, err)
+		tcp.Close()
+	}
+	send("udp", `{"log_group_name":"rejected"}`)
+	send("udp", "auth:wrong\n"+`{"log_group_name":"rejected"}`)
+	send("tcp", "auth:wrong\n"+`{"log_group_name":"rejected"}`+"\n")
+	send("udp", "auth:secret\n"+`{"log_group_name":"udp"}`)
+	send("tcp", "auth:secret\n"+`{"log_group_name":"tcp"}`+"\n")
+
+	require.Eventually(t, func() bool { return acc.NMetrics() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.rejected == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	var groups []string
+	for _, m := range acc.Metrics {
+		groups = append(groups, m.Tags[logscommon.LogGroupNameTag])
+	}
+	assert.ElementsMatch(t, []string{"udp", "tcp"}, groups)
+}
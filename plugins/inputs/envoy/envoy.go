@@ -0,0 +1,252 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package envoy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement    = "envoy"
+	defaultURL     = "http://localhost:9901/stats"
+	defaultTimeout = 5 * time.Second
+)
+
+// scope is the stats of the clusters or of the HTTP connection managers, whose names are the tag of the metrics
+type scope struct {
+	prefix string
+	tag    string
+	// gauges are the stats added as they are, counters the ones added as their increase since the previous collection
+	gauges   map[string]string
+	counters map[string]string
+}
+
+var scopes = []scope{
+	{
+		prefix: "cluster.",
+		tag:    "cluster",
+		gauges: map[string]string{
+			"upstream_cx_active": "active_connections",
+			"membership_healthy": "healthy_hosts",
+			"membership_total":   "hosts",
+		},
+		counters: map[string]string{
+			"upstream_rq_5xx":          "http_5xx",
+			"upstream_rq_retry":        "retries",
+			"upstream_rq_total":        "requests",
+			"upstream_cx_total":        "connections",
+			"upstream_cx_destroy":      "closed_connections",
+			"upstream_cx_connect_fail": "connection_failures",
+		},
+	},
+	{
+		prefix: "http.",
+		tag:    "stat_prefix",
+		gauges: map[string]string{
+			"downstream_cx_active": "active_connections",
+		},
+		counters: map[string]string{
+			"downstream_rq_5xx":     "http_5xx",
+			"downstream_rq_total":   "requests",
+			"downstream_cx_total":   "connections",
+			"downstream_cx_destroy": "closed_connections",
+		},
+	},
+}
+
+// subScopeSuffixes and subScopes are the stats of a subset of the requests of a cluster or of an HTTP connection
+// manager, whose names end with the same stats, e.g. cluster.<name>.canary.upstream_rq_5xx or
+// http.<stat_prefix>.user_agent.ios.downstream_cx_total
+var (
+	subScopeSuffixes = []string{".canary", ".internal", ".external"}
+	subScopes        = []string{".zone.", ".user_agent."}
+)
+
+// adminStatPrefix is the HTTP connection manager of the admin endpoint, whose requests are the ones of the agent
+const adminStatPrefix = "admin"
+
+// Envoy reads the stats of the clusters and of the HTTP connection managers of Envoy from its admin endpoint
+type Envoy struct {
+	// URLs are the /stats URLs of the admin endpoints
+	URLs    []string          `toml:"urls"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	client      *http.Client
+	derivations *datastore.Derivations
+}
+
+var sampleConfig = `
+  ## The /stats URLs of the admin endpoints, http://localhost:9901/stats by default
+  urls = ["http://localhost:9901/stats"]
+
+  ## The timeout of the requests of the stats
+  # timeout = "5s"
+`
+
+func (e *Envoy) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Envoy) Description() string {
+	return "Read the requests, the HTTP errors, the retries, the connections and the healthy hosts of the clusters of Envoy"
+}
+
+func (e *Envoy) Gather(acc telegraf.Accumulator) error {
+	urls := e.URLs
+	if len(urls) == 0 {
+		urls = []string{defaultURL}
+	}
+	if e.client == nil {
+		timeout := e.Timeout.Duration
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		e.client = &http.Client{Timeout: timeout}
+	}
+	if e.derivations == nil {
+		e.derivations = newDerivations()
+	}
+	// the clusters and the HTTP connection managers removed from the config of Envoy are forgotten
+	defer e.derivations.Prune()
+	derived := e.derivations.Accumulator(acc)
+	for _, u := range urls {
+		if err := e.gatherURL(u, derived); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+// newDerivations returns the derivations of the increases of the counters of the scopes, which replace them
+func newDerivations() *datastore.Derivations {
+	d := &datastore.Derivations{Measurement: measurement, DropCounters: true}
+	for _, s := range scopes {
+		for stat, field := range s.counters {
+			d.Increases = append(d.Increases, datastore.Increase{Field: field, Counter: stat})
+		}
+	}
+	sort.Slice(d.Increases, func(i, j int) bool { return d.Increases[i].Counter < d.Increases[j].Counter })
+	return d
+}
+
+func (e *Envoy) gatherURL(address string, acc telegraf.Accumulator) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("envoy: invalid URL: %v", err.(*url.Error).Err)
+	}
+	resp, err := e.client.Get(address)
+	if err != nil {
+		return fmt.Errorf("envoy: unable to get the stats of %v: %v", u.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("envoy: unable to get the stats of %v: %v", u.Host, resp.Status)
+	}
+	now := time.Now()
+	stats, err := parseStats(resp.Body)
+	if err != nil {
+		return fmt.Errorf("envoy: unable to read the stats of %v: %v", u.Host, err)
+	}
+	for i, s := range scopes {
+		for name, values := range stats[i] {
+			acc.AddFields(measurement, fields(s, values), map[string]string{"server": u.Host, s.tag: name}, now)
+		}
+	}
+	return nil
+}
+
+// fields returns the gauges of the stats by field and the counters by stat, whose increases are derived
+func fields(s scope, values map[string]uint64) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for stat, v := range values {
+		if field, ok := s.gauges[stat]; ok {
+			fields[field] = v
+		} else {
+			fields[stat] = v
+		}
+	}
+	return fields
+}
+
+// parseStats returns the stats of the scopes by name from the lines of the text format, e.g.
+// "cluster.backend.upstream_rq_5xx: 3", the histograms and the stats of the other scopes are skipped
+func parseStats(r io.Reader) ([]map[string]map[string]uint64, error) {
+	stats := make([]map[string]map[string]uint64, len(scopes))
+	for i := range stats {
+		stats[i] = map[string]map[string]uint64{}
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		sep := strings.LastIndex(line, ": ")
+		if sep < 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(line[sep+2:], 10, 64)
+		if err != nil {
+			continue
+		}
+		for i, s := range scopes {
+			name, stat, ok := s.split(line[:sep])
+			if ok {
+				if stats[i][name] == nil {
+					stats[i][name] = map[string]uint64{}
+				}
+				stats[i][name][stat] = v
+				break
+			}
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// split returns the name and the stat of a stat of the scope, the names of the clusters may have dots
+func (s scope) split(stat string) (string, string, bool) {
+	if !strings.HasPrefix(stat, s.prefix) {
+		return "", "", false
+	}
+	sep := strings.LastIndex(stat, ".")
+	if sep < len(s.prefix) {
+		return "", "", false
+	}
+	name, stat := stat[len(s.prefix):sep], stat[sep+1:]
+	if _, ok := s.gauges[stat]; !ok {
+		if _, ok := s.counters[stat]; !ok {
+			return "", "", false
+		}
+	}
+	if name == "" || (s.tag == "stat_prefix" && name == adminStatPrefix) {
+		return "", "", false
+	}
+	for _, sub := range subScopeSuffixes {
+		if strings.HasSuffix(name, sub) {
+			return "", "", false
+		}
+	}
+	for _, sub := range subScopes {
+		if strings.Contains(name, sub) {
+			return "", "", false
+		}
+	}
+	return name, stat, true
+}
+
+func init() {
+	inputs.Add(measurement, func() telegraf.Input {
+		return &Envoy{Timeout: internal.Duration{Duration: defaultTimeout}}
+	})
+}
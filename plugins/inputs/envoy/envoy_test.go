@@ -0,0 +1,111 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package envoy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStats(t *testing.T) {
+	stats, err := parseStats(strings.NewReader(`cluster.outbound|9080||reviews.default.svc.cluster.local.upstream_rq_5xx: 3
+cluster.outbound|9080||reviews.default.svc.cluster.local.canary.upstream_rq_5xx: 1
+cluster.backend.zone.us-east-1a.us-east-1b.upstream_rq_5xx: 1
+cluster.backend.membership_healthy: 2
+cluster.backend.upstream_rq_time: P0(nan,1) P25(nan,2.05)
+cluster.backend.upstream_rq_pending_total: 7
+http.ingress_http.downstream_rq_5xx: 4
+http.ingress_http.user_agent.ios.downstream_cx_total: 9
+http.admin.downstream_rq_total: 100
+server.live: 1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]uint64{
+		"outbound|9080||reviews.default.svc.cluster.local": {"upstream_rq_5xx": 3},
+		"backend": {"membership_healthy": 2},
+	}, stats[0])
+	assert.Equal(t, map[string]map[string]uint64{"ingress_http": {"downstream_rq_5xx": 4}}, stats[1])
+}
+
+func TestGather(t *testing.T) {
+	responses := []string{
+		"cluster.backend.upstream_rq_5xx: 10\ncluster.backend.upstream_rq_retry: 4\ncluster.backend.membership_healthy: 3\n" +
+			"http.ingress_http.downstream_rq_total: 1000\nhttp.ingress_http.downstream_cx_active: 8\n",
+		"cluster.backend.upstream_rq_5xx: 15\ncluster.backend.upstream_rq_retry: 4\ncluster.backend.membership_healthy: 2\n" +
+			"http.ingress_http.downstream_rq_total: 1600\nhttp.ingress_http.downstream_cx_active: 9\n",
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, responses[requests])
+		requests++
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	e := &Envoy{URLs: []string{server.URL + "/stats"}}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Gather(acc))
+	require.Empty(t, acc.Errors)
+	// the counters have no increase on the first collection
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"healthy_hosts": uint64(3)},
+		map[string]string{"server": host, "cluster": "backend"})
+	acc.ClearMetrics()
+
+	require.NoError(t, e.Gather(acc))
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"healthy_hosts": uint64(2), "http_5xx": float64(5), "retries": float64(0)},
+		map[string]string{"server": host, "cluster": "backend"})
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"active_connections": uint64(9), "requests": float64(600)},
+		map[string]string{"server": host, "stat_prefix": "ingress_http"})
+}
+
+func TestCounterReset(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	derived := newDerivations().Accumulator(acc)
+	tags := map[string]string{"server": "localhost:9901", "cluster": "backend"}
+	derived.AddFields(measurement, fields(scopes[0], map[string]uint64{"upstream_rq_total": 500}), tags)
+	derived.AddFields(measurement, fields(scopes[0], map[string]uint64{"upstream_rq_total": 20}), tags)
+	// the counters were reset by a restart of Envoy
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, map[string]interface{}{"requests": float64(20)}, acc.Metrics[0].Fields)
+}
+
+func TestGatherRemovedCluster(t *testing.T) {
+	responses := []string{
+		"cluster.backend.upstream_rq_total: 100\ncluster.canary.upstream_rq_total: 10\n",
+		"cluster.backend.upstream_rq_total: 150\n",
+		"cluster.backend.upstream_rq_total: 170\ncluster.canary.upstream_rq_total: 30\n",
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, responses[requests])
+		requests++
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	e := &Envoy{URLs: []string{server.URL + "/stats"}}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Gather(acc))
+	require.NoError(t, e.Gather(acc))
+	acc.ClearMetrics()
+
+	// the counters of the cluster removed are forgotten, it is new when it is added again
+	require.NoError(t, e.Gather(acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"requests": float64(20)},
+		map[string]string{"server": host, "cluster": "backend"})
+}
@@ -0,0 +1,275 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package haproxy
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/secrets"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement    = "haproxy"
+	defaultServer  = "http://127.0.0.1:1936/haproxy?stats"
+	defaultTimeout = 5 * time.Second
+	socketPrefix   = "socket:"
+)
+
+// the types of the rows of the stats, the listeners are not collected
+const (
+	typeFrontend = "0"
+	typeBackend  = "1"
+	typeServer   = "2"
+)
+
+// gauges are the columns of the stats added as they are
+var gauges = map[string]string{
+	"scur": "current_sessions",
+	"act":  "active_servers",
+	"bck":  "backup_servers",
+}
+
+// counters are the columns of the stats added as their increase since the previous collection
+var counters = map[string]string{
+	"hrsp_5xx": "http_5xx",
+	"wretr":    "retries",
+	"wredis":   "redispatches",
+	"stot":     "sessions",
+	"econ":     "connection_errors",
+	"eresp":    "response_errors",
+	"chkfail":  "check_failures",
+}
+
+// HAProxy reads the stats of HAProxy, from the CSV of its stats page or from its stats socket, with the frontends,
+// the backends and the servers of the backends as tags
+type HAProxy struct {
+	// Servers are the URLs of the stats pages and the paths of the stats sockets
+	Servers []string `toml:"servers"`
+	// Username and Password are the basic authentication of the stats pages, the ones of the secret replace them
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+	Timeout  internal.Duration `toml:"timeout"`
+	secrets.Reference
+
+	client      *http.Client
+	derivations *datastore.Derivations
+}
+
+var sampleConfig = `
+  ## The URLs of the stats pages, and the paths of the stats sockets, which need the "stats socket" directive,
+  ## http://127.0.0.1:1936/haproxy?stats by default
+  servers = ["http://127.0.0.1:1936/haproxy?stats", "socket:/run/haproxy/admin.sock"]
+
+  ## The basic authentication of the stats pages
+  # username = "admin"
+  # password = "admin"
+
+  ## The ARN or the name of the secret of Secrets Manager with the basic authentication of the stats pages, a JSON
+  ## object with "username" and "password" keys, they replace the ones above.
+  # secret_id = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/haproxy-stats"
+  # region = "us-east-1"
+
+  ## The timeout of the reads of the stats
+  # timeout = "5s"
+`
+
+func (h *HAProxy) SampleConfig() string {
+	return sampleConfig
+}
+
+func (h *HAProxy) Description() string {
+	return "Read the sessions, the HTTP errors, the retries and the health of the frontends and backends of HAProxy"
+}
+
+func (h *HAProxy) Gather(acc telegraf.Accumulator) error {
+	credentials, ok, err := h.Credentials()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		credentials = secrets.Credentials{Username: h.Username, Password: h.Password}
+	}
+	servers := h.Servers
+	if len(servers) == 0 {
+		servers = []string{defaultServer}
+	}
+	if h.derivations == nil {
+		h.derivations = newDerivations()
+	}
+	// the frontends, the backends and the servers removed from the config of HAProxy are forgotten
+	defer h.derivations.Prune()
+	derived := h.derivations.Accumulator(acc)
+	for _, server := range servers {
+		if err := h.gatherServer(server, credentials, derived); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+// newDerivations returns the derivations of the increases of the counters, which replace them
+func newDerivations() *datastore.Derivations {
+	d := &datastore.Derivations{Measurement: measurement, DropCounters: true}
+	for column, field := range counters {
+		d.Increases = append(d.Increases, datastore.Increase{Field: field, Counter: column})
+	}
+	sort.Slice(d.Increases, func(i, j int) bool { return d.Increases[i].Counter < d.Increases[j].Counter })
+	return d
+}
+
+func (h *HAProxy) timeout() time.Duration {
+	if h.Timeout.Duration > 0 {
+		return h.Timeout.Duration
+	}
+	return defaultTimeout
+}
+
+func (h *HAProxy) gatherServer(server string, credentials secrets.Credentials, acc telegraf.Accumulator) error {
+	if !strings.HasPrefix(server, "http://") && !strings.HasPrefix(server, "https://") {
+		path := strings.TrimPrefix(server, socketPrefix)
+		conn, err := net.DialTimeout("unix", path, h.timeout())
+		if err != nil {
+			return fmt.Errorf("haproxy: unable to connect to the socket %v: %v", path, err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(h.timeout()))
+		if _, err := conn.Write([]byte("show stat\n")); err != nil {
+			return fmt.Errorf("haproxy: unable to write to the socket %v: %v", path, err)
+		}
+		if err := h.addStats(conn, path, acc); err != nil {
+			return fmt.Errorf("haproxy: unable to read the stats of the socket %v: %v", path, err)
+		}
+		return nil
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return fmt.Errorf("haproxy: invalid server URL: %v", err.(*url.Error).Err)
+	}
+	if u.User != nil && credentials.Username == "" && credentials.Password == "" {
+		credentials.Username = u.User.Username()
+		credentials.Password, _ = u.User.Password()
+	}
+	u.User = nil
+	address := u.String()
+	if !strings.HasSuffix(address, ";csv") {
+		address += ";csv"
+	}
+	req, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		return fmt.Errorf("haproxy: unable to create the request of %v: %v", u.Host, err)
+	}
+	if credentials.Username != "" || credentials.Password != "" {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+	if h.client == nil {
+		h.client = &http.Client{Timeout: h.timeout()}
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("haproxy: unable to get the stats of %v: %v", u.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("haproxy: unable to get the stats of %v: %v", u.Host, resp.Status)
+	}
+	if err := h.addStats(resp.Body, u.Host, acc); err != nil {
+		return fmt.Errorf("haproxy: unable to read the stats of %v: %v", u.Host, err)
+	}
+	return nil
+}
+
+// addStats adds a metric per frontend, backend and server of the CSV of the stats, whose header starts with "# "
+func (h *HAProxy) addStats(r io.Reader, server string, acc telegraf.Accumulator) error {
+	now := time.Now()
+	reader := csv.NewReader(r)
+	// the CSV ends with an empty line, and the sockets of the older versions add fields to the rows
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(header[0], "# ") {
+		return fmt.Errorf("invalid header %q", header[0])
+	}
+	header[0] = strings.TrimPrefix(header[0], "# ")
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		columns := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(row) {
+				columns[name] = row[i]
+			}
+		}
+		tags := map[string]string{"server": server}
+		switch columns["type"] {
+		case typeFrontend:
+			tags["frontend"] = columns["pxname"]
+		case typeBackend:
+			tags["backend"] = columns["pxname"]
+		case typeServer:
+			tags["backend"] = columns["pxname"]
+			tags["backend_server"] = columns["svname"]
+		default:
+			continue
+		}
+		acc.AddFields(measurement, fields(columns), tags, now)
+	}
+}
+
+// fields returns the gauges of the columns by field and the counters by column, whose increases are derived
+func fields(columns map[string]string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for column, field := range gauges {
+		if v, err := strconv.ParseUint(columns[column], 10, 64); err == nil {
+			fields[field] = v
+		}
+	}
+	if up, ok := up(columns["type"], columns["status"]); ok {
+		fields["up"] = up
+	}
+	for column := range counters {
+		if v, err := strconv.ParseUint(columns[column], 10, 64); err == nil {
+			fields[column] = v
+		}
+	}
+	return fields
+}
+
+// up returns whether a backend or a server is up, the servers without health check are not reported. The statuses
+// of the transitions, e.g. "UP 1/3", are the ones the check went from.
+func up(rowType string, status string) (int, bool) {
+	if rowType == typeFrontend || status == "" || status == "no check" {
+		return 0, false
+	}
+	if status == "UP" || strings.HasPrefix(status, "UP ") {
+		return 1, true
+	}
+	return 0, true
+}
+
+func init() {
+	inputs.Add(measurement, func() telegraf.Input {
+		return &HAProxy{Timeout: internal.Duration{Duration: defaultTimeout}}
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package haproxy
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statsHeader = "# pxname,svname,qcur,scur,stot,econ,eresp,wretr,wredis,status,act,bck,chkfail,type,hrsp_5xx,\n"
+
+func TestGather(t *testing.T) {
+	responses := []string{
+		statsHeader +
+			"www,FRONTEND,,12,1000,,,,,OPEN,,,,0,5,\n" +
+			"www,listener-1,,3,300,,,,,OPEN,,,,3,,\n" +
+			"app,web1,0,4,400,1,0,2,0,UP,1,0,0,2,3,\n" +
+			"app,web2,0,0,100,9,0,6,1,DOWN,1,0,4,2,0,\n" +
+			"app,BACKEND,0,4,500,10,0,8,1,UP,1,0,,1,3,\n\n",
+		statsHeader +
+			"www,FRONTEND,,10,1200,,,,,OPEN,,,,0,7,\n" +
+			"www,listener-1,,3,340,,,,,OPEN,,,,3,,\n" +
+			"app,web1,0,5,500,1,0,2,0,UP,1,0,0,2,4,\n" +
+			"app,web2,0,0,100,15,0,9,1,DOWN 1/2,1,0,5,2,0,\n" +
+			"app,BACKEND,0,5,600,16,0,11,1,UP,1,0,,1,4,\n\n",
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "admin" || password != "secret" || r.URL.RawQuery != "stats;csv" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, responses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	h := &HAProxy{Servers: []string{server.URL + "/haproxy?stats"}, Username: "admin", Password: "secret"}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, h.Gather(acc))
+	require.Empty(t, acc.Errors)
+	// the counters have no increase on the first collection
+	require.Len(t, acc.Metrics, 4)
+	assert.NotContains(t, acc.Metrics[0].Fields, "http_5xx")
+	acc.ClearMetrics()
+
+	require.NoError(t, h.Gather(acc))
+	require.Empty(t, acc.Errors)
+	host := strings.TrimPrefix(server.URL, "http://")
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"current_sessions": uint64(10), "sessions": float64(200), "http_5xx": float64(2)},
+		map[string]string{"server": host, "frontend": "www"})
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"current_sessions": uint64(0), "active_servers": uint64(1), "backup_servers": uint64(0),
+			"up": 0, "sessions": float64(0), "connection_errors": float64(6), "response_errors": float64(0), "retries": float64(3),
+			"redispatches": float64(0), "check_failures": float64(1), "http_5xx": float64(0)},
+		map[string]string{"server": host, "backend": "app", "backend_server": "web2"})
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"current_sessions": uint64(5), "active_servers": uint64(1), "backup_servers": uint64(0),
+			"up": 1, "sessions": float64(100), "connection_errors": float64(6), "response_errors": float64(0), "retries": float64(3),
+			"redispatches": float64(0), "http_5xx": float64(1)},
+		map[string]string{"server": host, "backend": "app"})
+}
+
+func TestCounterReset(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	derived := newDerivations().Accumulator(acc)
+	tags := map[string]string{"server": "localhost:1936", "backend": "app"}
+	derived.AddFields(measurement, fields(map[string]string{"type": typeBackend, "stot": "500"}), tags)
+	derived.AddFields(measurement, fields(map[string]string{"type": typeBackend, "stot": "20"}), tags)
+	// the counters were reset by a restart or a reload of HAProxy
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, map[string]interface{}{"sessions": float64(20)}, acc.Metrics[0].Fields)
+}
+
+func TestGatherSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "haproxy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "admin.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	responses := []string{
+		statsHeader +
+			"www,FRONTEND,,12,1000,,,,,OPEN,,,,0,5,\n" +
+			"app,web1,0,4,400,1,0,2,0,UP,1,0,0,2,3,\n\n",
+		// the socket of the older versions adds a field to the rows
+		statsHeader +
+			"www,FRONTEND,,10,1200,,,,,OPEN,,,,0,7,,\n\n",
+		statsHeader +
+			"www,FRONTEND,,10,1300,,,,,OPEN,,,,0,7,\n" +
+			"app,web1,0,5,500,1,0,2,0,UP,1,0,0,2,4,\n\n",
+	}
+	go func() {
+		for _, response := range responses {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			command, _ := bufio.NewReader(conn).ReadString('\n')
+			if command == "show stat\n" {
+				fmt.Fprint(conn, response)
+			}
+			conn.Close()
+		}
+	}()
+
+	h := &HAProxy{Servers: []string{socketPrefix + path}}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, h.Gather(acc))
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"current_sessions": uint64(4), "active_servers": uint64(1), "backup_servers": uint64(0), "up": 1},
+		map[string]string{"server": path, "backend": "app", "backend_server": "web1"})
+	acc.ClearMetrics()
+
+	// the counters of the server removed are forgotten
+	require.NoError(t, h.Gather(acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"current_sessions": uint64(10), "sessions": float64(200), "http_5xx": float64(2)},
+		map[string]string{"server": path, "frontend": "www"})
+	acc.ClearMetrics()
+
+	// it is new when it is added again
+	require.NoError(t, h.Gather(acc))
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"current_sessions": uint64(5), "active_servers": uint64(1), "backup_servers": uint64(0), "up": 1},
+		map[string]string{"server": path, "backend": "app", "backend_server": "web1"})
+}
+
+func TestUp(t *testing.T) {
+	for status, expected := range map[string]int{"UP": 1, "UP 1/3": 1, "DOWN": 0, "DOWN 1/2": 0, "MAINT": 0, "NOLB": 0} {
+		up, ok := up(typeServer, status)
+		assert.True(t, ok, status)
+		assert.Equal(t, expected, up, status)
+	}
+	_, ok := up(typeServer, "no check")
+	assert.False(t, ok)
+	_, ok = up(typeFrontend, "OPEN")
+	assert.False(t, ok)
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	h := &HAProxy{Servers: []string{server.URL + "/haproxy?stats"}}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, h.Gather(acc))
+	require.Len(t, acc.Errors, 1)
+	assert.Contains(t, acc.Errors[0].Error(), "401")
+}
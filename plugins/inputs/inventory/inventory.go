@@ -0,0 +1,179 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package inventory
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const defaultPublishInterval = time.Hour
+
+// Inventory publishes the inventory record of the agent to a log group, so the hosts running stale versions or
+// divergent configs can be found across the fleet
+type Inventory struct {
+	LogGroupName    string            `toml:"log_group_name"`
+	LogStreamName   string            `toml:"log_stream_name"`
+	Destination     string            `toml:"destination"`
+	PublishInterval internal.Duration `toml:"publish_interval"`
+
+	src   *inventorySrc
+	found bool
+}
+
+func (i *Inventory) Description() string {
+	return "Publish the inventory record of the agent, i.e. its version, OS, config hash and plugins, to a log group"
+}
+
+func (i *Inventory) SampleConfig() string {
+	return `
+  log_group_name = "CWAgentInventory"
+  ## The log stream of the log output is used when empty
+  log_stream_name = "{instance_id}"
+  destination = "cloudwatchlogs"
+  ## How often the inventory record is published
+  publish_interval = "1h"
+`
+}
+
+func (i *Inventory) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (i *Inventory) Start(acc telegraf.Accumulator) error {
+	interval := i.PublishInterval.Duration
+	if interval <= 0 {
+		interval = defaultPublishInterval
+	}
+	i.src = &inventorySrc{
+		group:       i.LogGroupName,
+		stream:      i.LogStreamName,
+		destination: i.Destination,
+		interval:    interval,
+		done:        make(chan struct{}),
+	}
+	return nil
+}
+
+func (i *Inventory) Stop() {
+	if i.src != nil {
+		i.src.Stop()
+	}
+}
+
+// LogGroups returns the log group of the inventory record
+func (i *Inventory) LogGroups() []preflight.LogGroup {
+	return []preflight.LogGroup{{Name: i.LogGroupName, Destination: i.Destination}}
+}
+
+// FindLogSrc returns the inventory source once, it then publishes the record every publish interval
+func (i *Inventory) FindLogSrc() []logs.LogSrc {
+	if i.found || i.src == nil {
+		return nil
+	}
+	i.found = true
+	return []logs.LogSrc{i.src}
+}
+
+// Record is the inventory record of the agent
+type Record struct {
+	Version      string   `json:"agent_version"`
+	Build        string   `json:"build"`
+	OS           string   `json:"os"`
+	Arch         string   `json:"arch"`
+	Hostname     string   `json:"hostname"`
+	ConfigHash   string   `json:"config_hash"`
+	Inputs       []string `json:"inputs"`
+	Outputs      []string `json:"outputs"`
+	StartTime    string   `json:"start_time"`
+	RecordedTime string   `json:"recorded_time"`
+}
+
+var startTime = time.Now()
+
+func newRecord(now time.Time) Record {
+	hostname, _ := os.Hostname()
+	return Record{
+		Version:      agentinfo.Version(),
+		Build:        agentinfo.Build(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		Hostname:     hostname,
+		ConfigHash:   agentinfo.ConfigHash,
+		Inputs:       agentinfo.InputPlugins,
+		Outputs:      agentinfo.OutputPlugins,
+		StartTime:    startTime.UTC().Format(time.RFC3339),
+		RecordedTime: now.UTC().Format(time.RFC3339),
+	}
+}
+
+type inventorySrc struct {
+	group, stream, destination string
+	interval                   time.Duration
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (s *inventorySrc) SetOutput(fn func(logs.LogEvent)) {
+	if fn == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.publish(fn, time.Now())
+			select {
+			case <-ticker.C:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+func (s *inventorySrc) publish(fn func(logs.LogEvent), now time.Time) {
+	msg, err := json.Marshal(newRecord(now))
+	if err != nil {
+		log.Printf("E! [inputs.inventory] Unable to marshal the inventory record: %v", err)
+		return
+	}
+	fn(&event{msg: string(msg), t: now})
+}
+
+func (s *inventorySrc) Group() string       { return s.group }
+func (s *inventorySrc) Stream() string      { return s.stream }
+func (s *inventorySrc) Destination() string { return s.destination }
+func (s *inventorySrc) Description() string { return "agent inventory" }
+
+func (s *inventorySrc) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+type event struct {
+	msg string
+	t   time.Time
+}
+
+func (e *event) Message() string { return e.msg }
+func (e *event) Time() time.Time { return e.t }
+func (e *event) Done()           {}
+
+func init() {
+	inputs.Add("inventory", func() telegraf.Input {
+		return &Inventory{}
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package inventory
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventory(t *testing.T) {
+	agentinfo.VersionStr = "1.2.3"
+	agentinfo.ConfigHash = "abc"
+	agentinfo.InputPlugins = []string{"cpu", "logfile"}
+	agentinfo.OutputPlugins = []string{"cloudwatch", "cloudwatchlogs"}
+
+	i := &Inventory{LogGroupName: "inventory", LogStreamName: "host", Destination: "cloudwatchlogs",
+		PublishInterval: internal.Duration{Duration: 10 * time.Millisecond}}
+	require.NoError(t, i.Start(&testutil.Accumulator{}))
+	defer i.Stop()
+
+	srcs := i.FindLogSrc()
+	require.Len(t, srcs, 1)
+	assert.Empty(t, i.FindLogSrc(), "the source is only found once")
+	src := srcs[0]
+	assert.Equal(t, "inventory", src.Group())
+	assert.Equal(t, "host", src.Stream())
+	assert.Equal(t, "cloudwatchlogs", src.Destination())
+
+	events := make(chan logs.LogEvent, 10)
+	src.SetOutput(func(e logs.LogEvent) { events <- e })
+	for n := 0; n < 2; n++ {
+		select {
+		case e := <-events:
+			var r Record
+			require.NoError(t, json.Unmarshal([]byte(e.Message()), &r))
+			assert.Equal(t, "1.2.3", r.Version)
+			assert.Equal(t, "abc", r.ConfigHash)
+			assert.Equal(t, []string{"cpu", "logfile"}, r.Inputs)
+			assert.Equal(t, []string{"cloudwatch", "cloudwatchlogs"}, r.Outputs)
+		case <-time.After(time.Second):
+			t.Fatal("the inventory record is not published every interval")
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement    = "ipmi"
+	defaultPath    = "ipmitool"
+	defaultTimeout = 20 * time.Second
+	// ipmitool -E reads the password from this environment variable, so that it is not visible in the process list
+	passwordEnv = "IPMI_PASSWORD"
+)
+
+// [username[:password]@][protocol[(address)]]
+var serverRegex = regexp.MustCompile(`^(?:([^:@]*)(?::([^@]*))?@)?([a-z]+)(?:\(([^)]+)\))?$`)
+
+type Ipmi struct {
+	Path      string            `toml:"path"`
+	UseSudo   bool              `toml:"use_sudo"`
+	Privilege string            `toml:"privilege"`
+	Servers   []string          `toml:"servers"`
+	Timeout   internal.Duration `toml:"timeout"`
+}
+
+var sampleConfig = `
+  ## The path of ipmitool, looked up in PATH by default
+  # path = "/usr/bin/ipmitool"
+
+  ## Run ipmitool with sudo, which must allow it without a password, and keep the IPMI_PASSWORD environment
+  ## variable for the remote servers with a password, e.g. with the SETENV tag
+  # use_sudo = false
+
+  ## The privilege level of the remote sessions: CALLBACK, USER, OPERATOR or ADMINISTRATOR
+  # privilege = "USER"
+
+  ## The BMCs to query as [username[:password]@][protocol[(address)]], the local one when empty
+  # servers = ["USERID:PASSW0RD@lan(192.168.1.1)"]
+
+  ## The timeout of ipmitool
+  # timeout = "20s"
+`
+
+func (m *Ipmi) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Ipmi) Description() string {
+	return "Read the hardware sensors, e.g. temperatures, fan speeds and power supplies, and their threshold breaches via IPMI"
+}
+
+func (m *Ipmi) Gather(acc telegraf.Accumulator) error {
+	if len(m.Servers) == 0 {
+		acc.AddError(m.gatherServer(acc, ""))
+		return nil
+	}
+	var wg sync.WaitGroup
+	for _, server := range m.Servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			acc.AddError(m.gatherServer(acc, server))
+		}(server)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (m *Ipmi) gatherServer(acc telegraf.Accumulator, server string) error {
+	timeout := m.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd, host, err := m.command(ctx, server)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("ipmi: unable to read the sensors of %v: %v", serverName(host), err)
+	}
+	return parseSensors(acc, host, out, time.Now())
+}
+
+// command returns the ipmitool command reading the sensors of the server and its address. sudo resets the
+// environment, it is asked to keep the password, which its policy must allow, e.g. with the SETENV tag.
+func (m *Ipmi) command(ctx context.Context, server string) (*exec.Cmd, string, error) {
+	args, env, host, err := m.arguments(server)
+	if err != nil {
+		return nil, "", err
+	}
+	path := m.Path
+	if path == "" {
+		path = defaultPath
+	}
+	name := path
+	if m.UseSudo {
+		name = "sudo"
+		args = append([]string{path}, args...)
+		if env != nil {
+			args = append([]string{"--preserve-env=" + passwordEnv}, args...)
+		}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd, host, nil
+}
+
+// arguments returns the ipmitool arguments and environment of the server and its address, the local BMC is queried
+// when the server is empty
+func (m *Ipmi) arguments(server string) (args []string, env []string, host string, err error) {
+	if server != "" {
+		match := serverRegex.FindStringSubmatch(server)
+		if match == nil {
+			return nil, nil, "", fmt.Errorf("ipmi: invalid server %q, expecting [username[:password]@][protocol[(address)]]", redact(server))
+		}
+		user, password, protocol, address := match[1], match[2], match[3], match[4]
+		host = address
+		args = append(args, "-I", protocol)
+		if address != "" {
+			args = append(args, "-H", address)
+		}
+		if user != "" {
+			args = append(args, "-U", user)
+		}
+		if password != "" {
+			args = append(args, "-E")
+			env = []string{passwordEnv + "=" + password}
+		}
+		if m.Privilege != "" {
+			args = append(args, "-L", m.Privilege)
+		}
+	}
+	return append(args, "sensor"), env, host, nil
+}
+
+// parseSensors parses the output of ipmitool sensor, each line looks like
+//   CPU Temp | 45.000 | degrees C | ok | 0.000 | 0.000 | 0.000 | 90.000 | 95.000 | 100.000
+//   PS1 Status | 0x1 | discrete | 0x0100| na | na | na | na | na | na
+// with the lower non-recoverable, lower critical, lower non-critical, upper non-critical, upper critical and upper
+// non-recoverable thresholds after the status.
+func parseSensors(acc telegraf.Accumulator, host string, out []byte, measuredAt time.Time) error {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		columns := strings.Split(scanner.Text(), "|")
+		if len(columns) < 4 {
+			continue
+		}
+		for i := range columns {
+			columns[i] = strings.TrimSpace(columns[i])
+		}
+		reading, unit, status := columns[1], columns[2], columns[3]
+		if reading == "na" || reading == "" {
+			// e.g. a sensor of an absent component
+			continue
+		}
+		tags := map[string]string{"name": transform(columns[0])}
+		if host != "" {
+			tags["server"] = host
+		}
+		fields := map[string]interface{}{}
+
+		if unit == "discrete" {
+			value, err := strconv.ParseUint(reading, 0, 64)
+			if err != nil {
+				continue
+			}
+			fields["reading"] = float64(value)
+			// the asserted states, e.g. the presence and the failure of a power supply
+			if state, err := strconv.ParseUint(status, 0, 64); err == nil {
+				fields["state"] = float64(state)
+			}
+			acc.AddFields(measurement, fields, tags, measuredAt)
+			continue
+		}
+
+		value, err := strconv.ParseFloat(reading, 64)
+		if err != nil {
+			continue
+		}
+		tags["unit"] = transform(unit)
+		fields["reading"] = value
+		nonCritical, critical, ok := breaches(status, value, columns[4:])
+		if ok {
+			fields["non_critical_breach"] = boolToFloat(nonCritical)
+			fields["critical_breach"] = boolToFloat(critical)
+		}
+		acc.AddFields(measurement, fields, tags, measuredAt)
+	}
+	return scanner.Err()
+}
+
+// breaches returns whether a non-critical, or worse, and a critical, or worse, threshold is breached. The status of
+// the BMC is used when it has one, otherwise the reading is compared to the thresholds. ok is false when the sensor
+// has neither.
+func breaches(status string, value float64, thresholds []string) (nonCritical, critical, ok bool) {
+	switch strings.ToLower(status) {
+	case "ok":
+		return false, false, true
+	case "nc", "lnc", "unc":
+		return true, false, true
+	case "cr", "lcr", "ucr", "nr", "lnr", "unr":
+		return true, true, true
+	}
+	limits := make([]*float64, 6)
+	for i := 0; i < len(thresholds) && i < len(limits); i++ {
+		if limit, err := strconv.ParseFloat(thresholds[i], 64); err == nil {
+			limits[i] = &limit
+		}
+	}
+	below := func(i int) bool { return limits[i] != nil && value <= *limits[i] }
+	above := func(i int) bool { return limits[i] != nil && value >= *limits[i] }
+	for _, limit := range limits {
+		ok = ok || limit != nil
+	}
+	critical = below(0) || below(1) || above(4) || above(5)
+	nonCritical = critical || below(2) || above(3)
+	return nonCritical, critical, ok
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// transform turns the names and units into dimension values, e.g. "CPU Temp" into "cpu_temp"
+func transform(s string) string {
+	return strings.Replace(strings.ToLower(strings.TrimSpace(s)), " ", "_", -1)
+}
+
+func serverName(host string) string {
+	if host == "" {
+		return "the local BMC"
+	}
+	return host
+}
+
+func redact(server string) string {
+	if i := strings.LastIndex(server, "@"); i >= 0 {
+		return "<redacted>" + server[i:]
+	}
+	return server
+}
+
+func init() {
+	inputs.Add("ipmi", func() telegraf.Input { return &Ipmi{} })
+}
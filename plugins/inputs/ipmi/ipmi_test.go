@@ -0,0 +1,87 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sensorOutput = `CPU Temp         | 45.000     | degrees C  | ok    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 100.000
+System Temp      | 91.000     | degrees C  | unc   | na        | na        | na        | 90.000    | 95.000    | 100.000
+FAN1             | 200.000    | RPM        | na    | 100.000   | 300.000   | 500.000   | 25300.000 | 25400.000 | 25500.000
+Vcpu             | 1.800      | Volts      | na    | na        | na        | na        | na        | na        | na
+FAN2             | na         | RPM        | na    | 100.000   | 300.000   | 500.000   | 25300.000 | 25400.000 | 25500.000
+PS1 Status       | 0x1        | discrete   | 0x0300| na        | na        | na        | na        | na        | na
+`
+
+func TestParseSensors(t *testing.T) {
+	var acc testutil.Accumulator
+	now := time.Now()
+	require.NoError(t, parseSensors(&acc, "10.0.0.1", []byte(sensorOutput), now))
+	require.Len(t, acc.Metrics, 5)
+
+	acc.AssertContainsTaggedFields(t, "ipmi",
+		map[string]interface{}{"reading": 45.0, "non_critical_breach": 0.0, "critical_breach": 0.0},
+		map[string]string{"name": "cpu_temp", "unit": "degrees_c", "server": "10.0.0.1"})
+	acc.AssertContainsTaggedFields(t, "ipmi",
+		map[string]interface{}{"reading": 91.0, "non_critical_breach": 1.0, "critical_breach": 0.0},
+		map[string]string{"name": "system_temp", "unit": "degrees_c", "server": "10.0.0.1"})
+	// without a status, the reading is compared to the thresholds
+	acc.AssertContainsTaggedFields(t, "ipmi",
+		map[string]interface{}{"reading": 200.0, "non_critical_breach": 1.0, "critical_breach": 1.0},
+		map[string]string{"name": "fan1", "unit": "rpm", "server": "10.0.0.1"})
+	acc.AssertContainsTaggedFields(t, "ipmi",
+		map[string]interface{}{"reading": 1.8},
+		map[string]string{"name": "vcpu", "unit": "volts", "server": "10.0.0.1"})
+	acc.AssertContainsTaggedFields(t, "ipmi",
+		map[string]interface{}{"reading": 1.0, "state": float64(0x0300)},
+		map[string]string{"name": "ps1_status", "server": "10.0.0.1"})
+}
+
+func TestArguments(t *testing.T) {
+	m := &Ipmi{Privilege: "USER"}
+	args, env, host, err := m.arguments("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sensor"}, args)
+	assert.Nil(t, env)
+	assert.Equal(t, "", host)
+
+	args, env, host, err = m.arguments("admin:secret@lanplus(192.168.1.1)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-I", "lanplus", "-H", "192.168.1.1", "-U", "admin", "-E", "-L", "USER", "sensor"}, args)
+	assert.Equal(t, []string{"IPMI_PASSWORD=secret"}, env)
+	assert.Equal(t, "192.168.1.1", host)
+
+	_, _, _, err = m.arguments("admin:secret@192.168.1.1")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestCommandWithSudo(t *testing.T) {
+	m := &Ipmi{Path: "/usr/bin/ipmitool", UseSudo: true}
+	cmd, host, err := m.command(context.Background(), "admin:secret@lanplus(192.168.1.1)")
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.1", host)
+	assert.Equal(t, []string{"sudo", "--preserve-env=IPMI_PASSWORD", "/usr/bin/ipmitool",
+		"-I", "lanplus", "-H", "192.168.1.1", "-U", "admin", "-E", "sensor"}, cmd.Args)
+	assert.Contains(t, cmd.Env, "IPMI_PASSWORD=secret")
+
+	// without password, the environment is left as is
+	cmd, _, err = m.command(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sudo", "/usr/bin/ipmitool", "sensor"}, cmd.Args)
+	assert.Nil(t, cmd.Env)
+
+	m.UseSudo = false
+	cmd, _, err = m.command(context.Background(), "admin:secret@lanplus(192.168.1.1)")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/ipmitool", cmd.Args[0])
+	assert.Contains(t, cmd.Env, "IPMI_PASSWORD=secret")
+}
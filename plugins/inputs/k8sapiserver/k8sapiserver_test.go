@@ -69,8 +69,8 @@ func TestK8sAPIServer_Gather(t *testing.T) {
 	assert.NoError(t, err)
 	plugin := &K8sAPIServer{
 		NodeName: hostName,
-		leading:  true,
 	}
+	isLeader = func() bool { return true }
 
 	k8sclient.Get = mockGet
 
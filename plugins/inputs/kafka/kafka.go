@@ -0,0 +1,437 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+import (
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/secrets"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/xdg/scram"
+)
+
+const (
+	measurement    = "kafka"
+	defaultTimeout = 10 * time.Second
+
+	// the SASL mechanisms of the brokers, none when empty
+	mechanismIAM = "AWS_MSK_IAM"
+	scramSHA256  = sarama.SASLTypeSCRAMSHA256
+	scramSHA512  = sarama.SASLTypeSCRAMSHA512
+	plain        = sarama.SASLTypePlaintext
+
+	// iamSigningName and iamTokenExpiry are the ones of the tokens of the IAM authentication of MSK
+	iamSigningName = "kafka-cluster"
+	iamTokenExpiry = 15 * time.Minute
+)
+
+// Kafka reads the lag of the consumer groups of Kafka, the messages of the partitions of their topics they have not
+// committed yet, from the committed offsets of the groups and the end offsets of the partitions. The brokers are either
+// without authentication, or with the IAM, SCRAM or PLAIN authentication of MSK.
+type Kafka struct {
+	Brokers []string `toml:"brokers"`
+	// ConsumerGroups and Topics are the glob patterns of the consumer groups and of the topics, all of them by default
+	ConsumerGroups []string `toml:"consumer_groups"`
+	Topics         []string `toml:"topics"`
+	// PerPartition adds the lag of every partition, on top of the total and the max lag of the topics
+	PerPartition bool `toml:"per_partition"`
+
+	SASLMechanism string `toml:"sasl_mechanism"`
+	// SASLUsername and SASLPassword are the ones of SCRAM and PLAIN, the ones of the secret replace them
+	SASLUsername string `toml:"sasl_username"`
+	SASLPassword string `toml:"sasl_password"`
+	secrets.Reference
+
+	TLS                bool              `toml:"tls"`
+	TLSCA              string            `toml:"tls_ca"`
+	InsecureSkipVerify bool              `toml:"insecure_skip_verify"`
+	Timeout            internal.Duration `toml:"timeout"`
+
+	groupFilter filter.Filter
+	topicFilter filter.Filter
+	credentials secrets.Credentials
+	client      sarama.Client
+	admin       sarama.ClusterAdmin
+}
+
+var sampleConfig = `
+  ## The addresses of the brokers
+  brokers = ["b-1.example.abc123.c2.kafka.us-east-1.amazonaws.com:9098"]
+
+  ## The glob patterns of the consumer groups and of the topics, all of them by default
+  # consumer_groups = ["orders-*"]
+  # topics = ["orders", "payments"]
+
+  ## Add the lag of every partition, on top of the total and the max lag of the topics
+  # per_partition = true
+
+  ## The SASL mechanism of the brokers, AWS_MSK_IAM, SCRAM-SHA-512, SCRAM-SHA-256 or PLAIN, none by default. The IAM
+  ## authentication of MSK is with the credentials of the agent and over TLS.
+  # sasl_mechanism = "AWS_MSK_IAM"
+  # region = "us-east-1"
+
+  ## The user name and the password of SCRAM and PLAIN
+  # sasl_username = "monitor"
+  # sasl_password = "secret"
+
+  ## The ARN or the name of the secret of Secrets Manager with the user name and the password of SCRAM and PLAIN, a
+  ## JSON object with "username" and "password" keys as the AmazonMSK_ secrets, they replace the ones above.
+  # secret_id = "arn:aws:secretsmanager:us-east-1:123456789012:secret:AmazonMSK_monitor"
+
+  ## Connect to the brokers over TLS, with the roots of the system or the ones of tls_ca
+  # tls = true
+  # tls_ca = "/etc/pki/kafka-ca.pem"
+  # insecure_skip_verify = false
+
+  ## The timeout of the requests to the brokers
+  # timeout = "10s"
+`
+
+func (k *Kafka) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *Kafka) Description() string {
+	return "Read the lag of the consumer groups of Kafka per topic and partition"
+}
+
+func (k *Kafka) Start(acc telegraf.Accumulator) error {
+	if len(k.Brokers) == 0 {
+		return fmt.Errorf("kafka: no broker configured")
+	}
+	switch k.SASLMechanism {
+	case "", mechanismIAM, scramSHA256, scramSHA512, plain:
+	default:
+		return fmt.Errorf("kafka: unsupported SASL mechanism %q", k.SASLMechanism)
+	}
+	if k.SASLMechanism == mechanismIAM && k.Region == "" {
+		return fmt.Errorf("kafka: the region of the cluster is required by the IAM authentication")
+	}
+	var err error
+	if k.groupFilter, err = filter.Compile(k.ConsumerGroups); err != nil {
+		return fmt.Errorf("kafka: invalid consumer_groups: %v", err)
+	}
+	if k.topicFilter, err = filter.Compile(k.Topics); err != nil {
+		return fmt.Errorf("kafka: invalid topics: %v", err)
+	}
+	return nil
+}
+
+func (k *Kafka) Stop() {
+	k.close()
+}
+
+func (k *Kafka) close() {
+	if k.admin != nil {
+		// closes the client as well
+		k.admin.Close()
+	}
+	k.client, k.admin = nil, nil
+}
+
+func (k *Kafka) Gather(acc telegraf.Accumulator) error {
+	credentials, ok, err := k.Credentials()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		credentials = secrets.Credentials{Username: k.SASLUsername, Password: k.SASLPassword}
+	}
+	if credentials != k.credentials {
+		// the secret was rotated
+		k.close()
+	}
+	if k.client == nil {
+		if err := k.connect(credentials); err != nil {
+			return err
+		}
+	}
+	if err := k.gatherLag(acc); err != nil {
+		// the brokers are connected again on the next collection, e.g. after a change of the cluster
+		k.close()
+		return err
+	}
+	return nil
+}
+
+func (k *Kafka) connect(credentials secrets.Credentials) error {
+	config, err := k.config(credentials)
+	if err != nil {
+		return err
+	}
+	client, err := sarama.NewClient(k.Brokers, config)
+	if err != nil {
+		return fmt.Errorf("kafka: unable to connect to the brokers: %v", err)
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return fmt.Errorf("kafka: unable to connect to the brokers: %v", err)
+	}
+	k.client, k.admin, k.credentials = client, admin, credentials
+	return nil
+}
+
+func (k *Kafka) config(credentials secrets.Credentials) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	// the version of the offset fetch listing all the committed offsets of a group, below the one of the IAM
+	// authentication of MSK
+	config.Version = sarama.V2_0_0_0
+	config.ClientID = "amazon-cloudwatch-agent"
+	timeout := k.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	config.Net.DialTimeout, config.Net.ReadTimeout, config.Net.WriteTimeout = timeout, timeout, timeout
+
+	if k.TLS || k.SASLMechanism == mechanismIAM {
+		tlsConfig := &tls.Config{InsecureSkipVerify: k.InsecureSkipVerify}
+		if k.TLSCA != "" {
+			content, err := ioutil.ReadFile(k.TLSCA)
+			if err != nil {
+				return nil, fmt.Errorf("kafka: unable to read the tls_ca: %v", err)
+			}
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(content) {
+				return nil, fmt.Errorf("kafka: no certificate in the tls_ca %v", k.TLSCA)
+			}
+			tlsConfig.RootCAs = roots
+		}
+		config.Net.TLS.Enable, config.Net.TLS.Config = true, tlsConfig
+	}
+
+	switch k.SASLMechanism {
+	case "":
+		return config, nil
+	case mechanismIAM:
+		config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		config.Net.SASL.TokenProvider = &iamTokenProvider{
+			region: k.Region,
+			signer: v4.NewSigner(k.CredentialConfig().Credentials().ClientConfig(iamSigningName).Config.Credentials),
+		}
+	case scramSHA256, scramSHA512:
+		mechanism := k.SASLMechanism
+		config.Net.SASL.Mechanism = sarama.SASLMechanism(mechanism)
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(mechanism) }
+	case plain:
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	}
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Handshake = true
+	config.Net.SASL.Version = sarama.SASLHandshakeV1
+	config.Net.SASL.User, config.Net.SASL.Password = credentials.Username, credentials.Password
+	return config, nil
+}
+
+// scramClient is the SCRAM client of sarama, the SCRAM-SHA-512 one of MSK among others
+type scramClient struct {
+	hash scram.HashGeneratorFcn
+	// nonce generates the nonce of the client, a random one when it is nil
+	nonce        scram.NonceGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func newSCRAMClient(mechanism string) *scramClient {
+	if mechanism == scramSHA256 {
+		return &scramClient{hash: scram.SHA256}
+	}
+	return &scramClient{hash: func() hash.Hash { return sha512.New() }}
+}
+
+func (c *scramClient) Begin(username, password, authzID string) error {
+	client, err := c.hash.NewClient(username, password, authzID)
+	if err != nil {
+		return err
+	}
+	if c.nonce != nil {
+		client = client.WithNonceGenerator(c.nonce)
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
+
+// partition is a partition of a topic
+type partition struct {
+	topic string
+	id    int32
+}
+
+func (k *Kafka) gatherLag(acc telegraf.Accumulator) error {
+	groups, err := k.admin.ListConsumerGroups()
+	if err != nil {
+		return fmt.Errorf("kafka: unable to list the consumer groups: %v", err)
+	}
+	now := time.Now()
+	committed := map[string]map[partition]int64{}
+	partitions := map[partition]bool{}
+	for group := range groups {
+		if k.groupFilter != nil && !k.groupFilter.Match(group) {
+			continue
+		}
+		response, err := k.admin.ListConsumerGroupOffsets(group, nil)
+		if err != nil {
+			acc.AddError(fmt.Errorf("kafka: unable to get the offsets of the consumer group %v: %v", group, err))
+			continue
+		}
+		if response.Err != sarama.ErrNoError {
+			acc.AddError(fmt.Errorf("kafka: unable to get the offsets of the consumer group %v: %v", group, response.Err))
+			continue
+		}
+		offsets := map[partition]int64{}
+		for topic, blocks := range response.Blocks {
+			if k.topicFilter != nil && !k.topicFilter.Match(topic) {
+				continue
+			}
+			for id, block := range blocks {
+				// the partitions without committed offset have none
+				if block.Err != sarama.ErrNoError || block.Offset < 0 {
+					continue
+				}
+				p := partition{topic: topic, id: id}
+				offsets[p] = block.Offset
+				partitions[p] = true
+			}
+		}
+		if len(offsets) > 0 {
+			committed[group] = offsets
+		}
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+	ends, err := k.endOffsets(partitions)
+	if err != nil {
+		return err
+	}
+	for group, offsets := range committed {
+		addLag(acc, group, offsets, ends, k.PerPartition, now)
+	}
+	return nil
+}
+
+// endOffsets returns the offsets of the next messages of the partitions, with a request per leader of partitions
+func (k *Kafka) endOffsets(partitions map[partition]bool) (map[partition]int64, error) {
+	requests := map[*sarama.Broker]*sarama.OffsetRequest{}
+	for p := range partitions {
+		leader, err := k.client.Leader(p.topic, p.id)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: unable to find the leader of the partition %v of %v: %v", p.id, p.topic, err)
+		}
+		request, ok := requests[leader]
+		if !ok {
+			request = &sarama.OffsetRequest{Version: 1}
+			requests[leader] = request
+		}
+		request.AddBlock(p.topic, p.id, sarama.OffsetNewest, 1)
+	}
+	ends := map[partition]int64{}
+	for leader, request := range requests {
+		response, err := leader.GetAvailableOffsets(request)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: unable to get the end offsets from the broker %v: %v", leader.Addr(), err)
+		}
+		for topic, blocks := range response.Blocks {
+			for id, block := range blocks {
+				if block.Err == sarama.ErrNoError {
+					ends[partition{topic: topic, id: id}] = block.Offset
+				}
+			}
+		}
+	}
+	return ends, nil
+}
+
+// addLag adds the total and the max lag of the topics of the group, and the lag of their partitions
+func addLag(acc telegraf.Accumulator, group string, offsets map[partition]int64, ends map[partition]int64, perPartition bool, now time.Time) {
+	type topicLag struct {
+		total, max int64
+	}
+	topics := map[string]*topicLag{}
+	for p, offset := range offsets {
+		end, ok := ends[p]
+		if !ok {
+			continue
+		}
+		lag := end - offset
+		if lag < 0 {
+			// the end offset was read before the group committed
+			lag = 0
+		}
+		t, ok := topics[p.topic]
+		if !ok {
+			t = &topicLag{}
+			topics[p.topic] = t
+		}
+		t.total += lag
+		if lag > t.max {
+			t.max = lag
+		}
+		if perPartition {
+			acc.AddFields(measurement, map[string]interface{}{"consumer_lag": lag},
+				map[string]string{"consumer_group": group, "topic": p.topic, "partition": strconv.Itoa(int(p.id))}, now)
+		}
+	}
+	for topic, t := range topics {
+		acc.AddFields(measurement, map[string]interface{}{"consumer_lag_total": t.total, "consumer_lag_max": t.max},
+			map[string]string{"consumer_group": group, "topic": topic}, now)
+	}
+}
+
+// iamTokenProvider returns the tokens of the IAM authentication of MSK, the base64 of a URL presigned with SigV4
+// for the kafka-cluster:Connect action. A token is signed for every connection since signing is cheap.
+type iamTokenProvider struct {
+	region string
+	signer *v4.Signer
+	now    func() time.Time
+}
+
+func (p *iamTokenProvider) Token() (*sarama.AccessToken, error) {
+	req, err := http.NewRequest("GET", "https://kafka."+p.region+".amazonaws.com/?Action=kafka-cluster%3AConnect", nil)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+	if _, err := p.signer.Presign(req, nil, iamSigningName, p.region, iamTokenExpiry, now); err != nil {
+		return nil, fmt.Errorf("kafka: unable to sign the IAM token: %v", err)
+	}
+	query := req.URL.Query()
+	query.Set("User-Agent", agentinfo.UserAgent())
+	req.URL.RawQuery = query.Encode()
+	return &sarama.AccessToken{Token: base64.RawURLEncoding.EncodeToString([]byte(req.URL.String()))}, nil
+}
+
+func init() {
+	inputs.Add(measurement, func() telegraf.Input {
+		return &Kafka{
+			PerPartition: true,
+			Timeout:      internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherLag(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()).
+			SetLeader("orders", 1, broker.BrokerID()).
+			SetLeader("audit", 0, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "billing", broker).
+			SetCoordinator(sarama.CoordinatorGroup, "shipping", broker),
+		"ListGroupsRequest": sarama.NewMockListGroupsResponse(t).
+			AddGroup("billing", "consumer").
+			AddGroup("shipping", "consumer"),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("billing", "orders", 0, 90, "", sarama.ErrNoError).
+			SetOffset("billing", "orders", 1, 200, "", sarama.ErrNoError).
+			SetOffset("billing", "audit", 0, 5, "", sarama.ErrNoError).
+			SetOffset("shipping", "orders", 0, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100).
+			SetOffset("orders", 1, sarama.OffsetNewest, 230),
+	})
+
+	k := inputs.Inputs["kafka"]().(*Kafka)
+	k.Brokers = []string{broker.Addr()}
+	k.Topics = []string{"ord*"}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, k.Start(acc))
+	defer k.Stop()
+	require.NoError(t, k.Gather(acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"consumer_lag_total": int64(40), "consumer_lag_max": int64(30)},
+		map[string]string{"consumer_group": "billing", "topic": "orders"})
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"consumer_lag": int64(10)},
+		map[string]string{"consumer_group": "billing", "topic": "orders", "partition": "0"})
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{"consumer_lag": int64(30)},
+		map[string]string{"consumer_group": "billing", "topic": "orders", "partition": "1"})
+	// the audit topic is filtered out and the shipping group has no committed offset
+	assert.Len(t, acc.Metrics, 3)
+}
+
+func TestStartInvalidConfig(t *testing.T) {
+	k := &Kafka{}
+	assert.Error(t, k.Start(nil))
+	k = &Kafka{Brokers: []string{"localhost:9092"}, SASLMechanism: "GSSAPI"}
+	assert.Error(t, k.Start(nil))
+	k = &Kafka{Brokers: []string{"localhost:9098"}, SASLMechanism: mechanismIAM}
+	assert.Error(t, k.Start(nil))
+}
+
+func TestIAMToken(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	provider := &iamTokenProvider{
+		region: "us-east-1",
+		signer: v4.NewSigner(credentials.NewStaticCredentials("AKID", "SECRET", "")),
+		now:    func() time.Time { return now },
+	}
+	token, err := provider.Token()
+	require.NoError(t, err)
+	decoded, err := base64.RawURLEncoding.DecodeString(token.Token)
+	require.NoError(t, err)
+	u, err := url.Parse(string(decoded))
+	require.NoError(t, err)
+	assert.Equal(t, "kafka.us-east-1.amazonaws.com", u.Host)
+	query := u.Query()
+	assert.Equal(t, "kafka-cluster:Connect", query.Get("Action"))
+	assert.Equal(t, "AKID/20201001/us-east-1/kafka-cluster/aws4_request", query.Get("X-Amz-Credential"))
+	assert.Equal(t, "900", query.Get("X-Amz-Expires"))
+	assert.NotEmpty(t, query.Get("X-Amz-Signature"))
+	assert.NotEmpty(t, query.Get("User-Agent"))
+}
+
+// the exchange of the example of RFC 7677
+func TestSCRAMClient(t *testing.T) {
+	c := newSCRAMClient(scramSHA256)
+	c.nonce = func() string { return "rOprNGfwEbeRWgbNEkqO" }
+	require.NoError(t, c.Begin("user", "pencil", ""))
+
+	message, err := c.Step("")
+	require.NoError(t, err)
+	assert.Equal(t, "n,,n=user,r=rOprNGfwEbeRWgbNEkqO", message)
+
+	message, err = c.Step("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	require.NoError(t, err)
+	assert.Equal(t, "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=", message)
+	assert.False(t, c.Done())
+
+	_, err = c.Step("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=")
+	require.NoError(t, err)
+	assert.True(t, c.Done())
+
+	// the signature of the server is checked
+	require.NoError(t, c.Begin("user", "pencil", ""))
+	_, err = c.Step("")
+	require.NoError(t, err)
+	_, err = c.Step("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	require.NoError(t, err)
+	_, err = c.Step("v=bm90IHRoZSBzaWduYXR1cmU=")
+	assert.Error(t, err)
+}
+
+func TestSCRAMClientSHA512(t *testing.T) {
+	c := newSCRAMClient(scramSHA512)
+	require.NoError(t, c.Begin("user", "pencil", ""))
+	message, err := c.Step("")
+	require.NoError(t, err)
+	assert.Regexp(t, "^n,,n=user,r=.+$", message)
+	// the nonce of the server starts with the one of the client
+	_, err = c.Step("r=xyz123,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	assert.Error(t, err)
+}
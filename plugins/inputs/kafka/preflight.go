@@ -0,0 +1,21 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+)
+
+// iamActions are the actions of the IAM authentication of MSK the lag is read with, the groups are listed and their
+// offsets fetched with DescribeGroup, the metadata and the end offsets of the topics with DescribeTopic
+var iamActions = []string{"kafka-cluster:Connect", "kafka-cluster:DescribeGroup", "kafka-cluster:DescribeTopic"}
+
+// PreflightIAM checks that the actions of the IAM authentication are allowed, and that the secret can be fetched
+func (k *Kafka) PreflightIAM() []preflight.Check {
+	checks := k.Reference.PreflightIAM()
+	if k.SASLMechanism == mechanismIAM {
+		checks = append(checks, preflight.CheckIAMActions(k.CredentialConfig().Credentials(), iamActions)...)
+	}
+	return checks
+}
@@ -0,0 +1,56 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package log_delivery
+
+import (
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const measurement = "log_delivery"
+
+type LogDelivery struct {
+	lags func() []publishstats.Lag
+}
+
+var sampleConfig = `
+  ## No options, the lag of every log stream the agent published to is reported
+`
+
+func (l *LogDelivery) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *LogDelivery) Description() string {
+	return "Report the ingestion lag of the log streams, the time elapsed since the newest log event they accepted"
+}
+
+func (l *LogDelivery) Gather(acc telegraf.Accumulator) error {
+	lags := publishstats.Lags
+	if l.lags != nil {
+		lags = l.lags
+	}
+	now := time.Now()
+	for _, lag := range lags() {
+		tags := lag.Tags
+		if len(tags) == 0 {
+			tags = map[string]string{"target": lag.Target}
+		}
+		fields := map[string]interface{}{
+			"ingestion_lag_seconds": lag.IngestionLag.Seconds(),
+			"acknowledged_events":   lag.Events,
+		}
+		acc.AddFields(measurement, fields, tags, now)
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("log_delivery", func() telegraf.Input {
+		return &LogDelivery{}
+	})
+}
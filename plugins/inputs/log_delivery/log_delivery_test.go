@@ -0,0 +1,35 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package log_delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	l := &LogDelivery{lags: func() []publishstats.Lag {
+		return []publishstats.Lag{
+			{
+				Target:       "logs.G/S",
+				Tags:         map[string]string{"log_group_name": "G", "log_stream_name": "S"},
+				IngestionLag: 90 * time.Second,
+				Events:       12,
+			},
+			{Target: "logs.Other/S", IngestionLag: 1500 * time.Millisecond, Events: 1},
+		}
+	}}
+	var acc testutil.Accumulator
+	require.NoError(t, l.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "log_delivery",
+		map[string]interface{}{"ingestion_lag_seconds": float64(90), "acknowledged_events": int64(12)},
+		map[string]string{"log_group_name": "G", "log_stream_name": "S"})
+	acc.AssertContainsTaggedFields(t, "log_delivery",
+		map[string]interface{}{"ingestion_lag_seconds": 1.5, "acknowledged_events": int64(1)},
+		map[string]string{"target": "logs.Other/S"})
+}
@@ -0,0 +1,156 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/globpath"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/tail"
+)
+
+// Backfill returns the log srcs reading the files matched by the globs once, from their beginning to their end,
+// through the file config matching each of them, the file_path of the file configs when no globs are given. The
+// log events timestamped before since are skipped, as are the files last modified before it. The srcs are ordered
+// by the modification time of their files, oldest first. Nothing is read from or saved to the state folder and
+// the files are never removed.
+func (t *LogFile) Backfill(globs []string, since time.Time) ([]logs.LogSrc, error) {
+	for i := range t.FileConfig {
+		if err := t.FileConfig[i].init(); err != nil {
+			return nil, err
+		}
+	}
+	if len(globs) == 0 {
+		for _, fileconfig := range t.FileConfig {
+			globs = append(globs, fileconfig.FilePath)
+		}
+	}
+
+	var srcs []*backfillSrc
+	seen := make(map[string]bool)
+	for _, glob := range globs {
+		g, err := globpath.Compile(glob)
+		if err != nil {
+			return nil, fmt.Errorf("backfill glob %s failed to compile, %s", glob, err)
+		}
+		for filename, info := range g.Match() {
+			if seen[filename] {
+				continue
+			}
+			seen[filename] = true
+			if isDir, err := isDirectory(filename); err != nil || isDir {
+				continue
+			}
+			if t.FileStateFolder != "" && strings.HasPrefix(filename, t.FileStateFolder) {
+				continue
+			}
+			if isCompressedFile(filename) {
+				t.Log.Infof("Skipping %v, compressed files are not backfilled", filename)
+				continue
+			}
+			if info.ModTime().Before(since) {
+				t.Log.Debugf("Skipping %v, it was last modified at %v", filename, info.ModTime())
+				continue
+			}
+			fileconfig, err := t.matchFileConfig(filename)
+			if err != nil {
+				return nil, err
+			}
+			if fileconfig == nil || !fileconfig.isIncluded(filename) {
+				t.Log.Infof("No file config matches %v, skipping it", filename)
+				continue
+			}
+			src := &backfillSrc{logfile: t, fileconfig: fileconfig, filename: filename, modTime: info.ModTime(), since: since}
+			src.group, src.stream, src.destination = t.srcTarget(fileconfig, filename)
+			srcs = append(srcs, src)
+		}
+	}
+
+	sort.SliceStable(srcs, func(i, j int) bool {
+		if !srcs[i].modTime.Equal(srcs[j].modTime) {
+			return srcs[i].modTime.Before(srcs[j].modTime)
+		}
+		return srcs[i].filename < srcs[j].filename
+	})
+	logSrcs := make([]logs.LogSrc, len(srcs))
+	for i, src := range srcs {
+		logSrcs[i] = src
+	}
+	return logSrcs, nil
+}
+
+// backfillSrc reads a file once, the file is only opened when the src is piped so that the srcs waiting for their
+// turn do not hold it open
+type backfillSrc struct {
+	logfile                    *LogFile
+	fileconfig                 *FileConfig
+	filename                   string
+	modTime                    time.Time
+	since                      time.Time
+	group, stream, destination string
+
+	src *tailerSrc
+}
+
+func (b *backfillSrc) SetOutput(fn func(logs.LogEvent)) {
+	if fn == nil {
+		return
+	}
+	tailer, err := tail.TailFile(b.filename,
+		tail.Config{
+			ReOpen:      false,
+			Follow:      false,
+			MustExist:   true,
+			Poll:        true,
+			MaxLineSize: b.fileconfig.MaxEventSize,
+			IsUTF16:     b.fileconfig.isUTF16(),
+		})
+	if err != nil {
+		b.logfile.Log.Errorf("Failed to backfill file %v with error: %v", b.filename, err)
+		fn(nil)
+		return
+	}
+
+	b.src = b.logfile.newTailerSrc(b.fileconfig, b.filename, "", tailer)
+	// the file has ended rather than gone idle, and it is kept once read
+	b.src.autoRemoval = false
+	b.src.idleTimeout = 0
+
+	var skipped int
+	b.src.SetOutput(func(e logs.LogEvent) {
+		if e == nil {
+			if skipped > 0 {
+				b.logfile.Log.Infof("Skipped %v log events of %v timestamped before %v", skipped, b.filename, b.since.Format(time.RFC3339))
+			}
+			fn(nil)
+			return
+		}
+		if t := e.Time(); !t.IsZero() && t.Before(b.since) {
+			skipped++
+			e.Done()
+			return
+		}
+		fn(e)
+	})
+}
+
+func (b *backfillSrc) Group() string       { return b.group }
+func (b *backfillSrc) Stream() string      { return b.stream }
+func (b *backfillSrc) Destination() string { return b.destination }
+func (b *backfillSrc) Description() string { return b.filename }
+func (b *backfillSrc) Priority() string    { return b.fileconfig.Priority }
+
+func (b *backfillSrc) Replicas() []logs.Replica {
+	return b.fileconfig.replicas(b.stream)
+}
+
+func (b *backfillSrc) Stop() {
+	if b.src != nil {
+		b.src.Stop()
+	}
+}
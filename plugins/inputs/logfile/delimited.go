@@ -0,0 +1,297 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	parserTypeCSV   = "csv"
+	parserTypeW3C   = "w3c"
+	parserTypeRegex = "regex"
+
+	columnTypeString  = "string"
+	columnTypeInteger = "integer"
+	columnTypeFloat   = "float"
+	columnTypeBoolean = "boolean"
+
+	// the W3C extended format of IIS, the times are in UTC
+	w3cFieldsDirective   = "#Fields:"
+	w3cTimestampLayout   = "2006-01-02 15:04:05"
+	w3cNullValue         = "-"
+	maxHeaderLineScanned = 1000
+)
+
+var w3cTimestampColumns = []string{"date", "time"}
+
+//ParserConfig parses the delimiter-separated log entries, e.g. the CSV exports or the ELB access logs, the W3C
+//extended format of IIS, and the entries matched by a pattern, e.g. the access logs of Apache and Nginx, into JSON
+//objects of typed fields.
+type ParserConfig struct {
+	//The type of the parser, "csv", "w3c" or "regex".
+	Type string `toml:"type"`
+	//The regex of the regex parser, the fields are its named groups.
+	Pattern string `toml:"pattern"`
+	//The delimiter of the fields, a comma for csv and a space for w3c by default. The fields may be quoted.
+	Delimiter string `toml:"delimiter"`
+	//The names of the fields. For csv the first line of the file is the header when they are not set, for w3c they are
+	//read from the #Fields directives.
+	Columns []string `toml:"columns"`
+	//The types of the fields, "string", "integer", "float" or "boolean", strings by default. The values which are not
+	//of their type are kept as strings.
+	ColumnTypes map[string]string `toml:"column_types"`
+	//The fields holding the timestamp of the entries, joined by a space, date and time for w3c by default.
+	TimestampColumns []string `toml:"timestamp_columns"`
+	//The layout of the timestamp of the timestamp_columns, "2006-01-02 15:04:05" for w3c and RFC 3339 for csv and
+	//regex by default.
+	TimestampLayout string `toml:"timestamp_layout"`
+	//The value of the fields which have no value, the fields are left out, "-" for w3c by default.
+	NullValue string `toml:"null_value"`
+
+	delimiter rune
+	patternP  *regexp.Regexp
+	location  *time.Location
+}
+
+func (pc *ParserConfig) init(loc *time.Location, timezoneSet bool) error {
+	switch pc.Type {
+	case parserTypeCSV:
+		if pc.Delimiter == "" {
+			pc.Delimiter = ","
+		}
+		if pc.TimestampLayout == "" {
+			pc.TimestampLayout = time.RFC3339
+		}
+	case parserTypeW3C:
+		if pc.Delimiter == "" {
+			pc.Delimiter = " "
+		}
+		if len(pc.TimestampColumns) == 0 {
+			pc.TimestampColumns = w3cTimestampColumns
+		}
+		if pc.TimestampLayout == "" {
+			pc.TimestampLayout = w3cTimestampLayout
+		}
+		if pc.NullValue == "" {
+			pc.NullValue = w3cNullValue
+		}
+		if !timezoneSet {
+			loc = time.UTC
+		}
+	case parserTypeRegex:
+		var err error
+		if pc.patternP, err = regexp.Compile(pc.Pattern); err != nil {
+			return fmt.Errorf("the pattern of the parser has issue, regexp: Compile( %v ): %v", pc.Pattern, err)
+		}
+		pc.Columns = pc.patternP.SubexpNames()[1:]
+		if pc.TimestampLayout == "" {
+			pc.TimestampLayout = time.RFC3339
+		}
+	default:
+		return fmt.Errorf("the parser type %v is not supported, it must be %v, %v or %v", pc.Type, parserTypeCSV, parserTypeW3C, parserTypeRegex)
+	}
+	if pc.Type == parserTypeRegex {
+		return pc.initColumnTypes(loc)
+	}
+	if utf8.RuneCountInString(pc.Delimiter) != 1 {
+		return fmt.Errorf("the delimiter %q of the parser is not a single character", pc.Delimiter)
+	}
+	pc.delimiter, _ = utf8.DecodeRuneInString(pc.Delimiter)
+	if pc.delimiter == '"' || pc.delimiter == '\r' || pc.delimiter == '\n' {
+		return fmt.Errorf("the delimiter %q of the parser is not valid", pc.Delimiter)
+	}
+	return pc.initColumnTypes(loc)
+}
+
+func (pc *ParserConfig) initColumnTypes(loc *time.Location) error {
+	for column, columnType := range pc.ColumnTypes {
+		switch columnType {
+		case columnTypeString, columnTypeInteger, columnTypeFloat, columnTypeBoolean:
+		default:
+			return fmt.Errorf("the type %v of the column %v is not string, integer, float or boolean", columnType, column)
+		}
+	}
+	pc.location = loc
+	return nil
+}
+
+//The parser of a file, the columns of the header line or the #Fields directive are scoped to the file.
+type delimitedParser struct {
+	*ParserConfig
+	filename string
+	columns  []string
+	// whether the columns were looked up at the start of the file, the tailer may resume from a saved offset
+	headerRead bool
+}
+
+func (pc *ParserConfig) newParser(filename string) *delimitedParser {
+	return &delimitedParser{ParserConfig: pc, filename: filename, columns: pc.Columns,
+		headerRead: len(pc.Columns) > 0 || pc.Type == parserTypeRegex}
+}
+
+//Parse the log entry into a JSON object, the timestamp is zero when the entry has no timestamp_columns. The header
+//line and the directives are not published, ok is false for them. The entries which cannot be parsed are published
+//as is.
+func (p *delimitedParser) parse(msg string) (event string, timestamp time.Time, ok bool) {
+	if !p.headerRead {
+		p.headerRead = true
+		p.columns = p.readColumns()
+	}
+	if p.Type == parserTypeW3C && strings.HasPrefix(msg, "#") {
+		if strings.HasPrefix(msg, w3cFieldsDirective) {
+			p.columns = strings.Fields(strings.TrimPrefix(msg, w3cFieldsDirective))
+		}
+		return "", time.Time{}, false
+	}
+	if p.Type == parserTypeRegex {
+		groups := p.patternP.FindStringSubmatch(msg)
+		if groups == nil {
+			log.Printf("D! [logfile] The log entry of %v does not match the pattern of the parser, publishing it as is", p.filename)
+			return msg, time.Time{}, true
+		}
+		return p.encode(msg, groups[1:])
+	}
+	values, err := p.split(msg)
+	if err != nil {
+		log.Printf("D! [logfile] Unable to parse the log entry of %v, publishing it as is: %v", p.filename, err)
+		return msg, time.Time{}, true
+	}
+	if p.Type == parserTypeCSV && len(p.Columns) == 0 && equalFields(values, p.columns) {
+		return "", time.Time{}, false
+	}
+	if p.Type == parserTypeCSV && len(p.columns) == 0 {
+		// the first entry is the header of a file which was empty when the tailing started
+		p.columns = values
+		return "", time.Time{}, false
+	}
+	if len(values) != len(p.columns) {
+		log.Printf("D! [logfile] The log entry of %v has %d fields instead of the %d columns, publishing it as is",
+			p.filename, len(values), len(p.columns))
+		return msg, time.Time{}, true
+	}
+	return p.encode(msg, values)
+}
+
+//Encode the values of the columns into a JSON object, the unnamed groups of the regex parser are left out.
+func (p *delimitedParser) encode(msg string, values []string) (event string, timestamp time.Time, ok bool) {
+	fields := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		if p.columns[i] == "" || (p.NullValue != "" && value == p.NullValue) || (p.Type == parserTypeRegex && value == "") {
+			continue
+		}
+		fields[p.columns[i]] = convertColumn(value, p.ColumnTypes[p.columns[i]])
+	}
+	timestamp = p.timestamp(values)
+	b, err := json.Marshal(fields)
+	if err != nil {
+		log.Printf("E! [logfile] Unable to encode the fields of the log entry of %v, publishing it as is: %v", p.filename, err)
+		return msg, timestamp, true
+	}
+	return string(b), timestamp, true
+}
+
+func (p *delimitedParser) split(msg string) ([]string, error) {
+	if p.delimiter == ' ' {
+		msg = strings.TrimSpace(msg)
+	}
+	r := csv.NewReader(strings.NewReader(msg))
+	r.Comma = p.delimiter
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	return r.Read()
+}
+
+//The columns of the first line of the csv file or of the last #Fields directive of the start of the w3c file.
+func (p *delimitedParser) readColumns() []string {
+	f, err := os.Open(p.filename)
+	if err != nil {
+		log.Printf("W! [logfile] Unable to read the columns of %v: %v", p.filename, err)
+		return nil
+	}
+	defer f.Close()
+	var columns []string
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < maxHeaderLineScanned && scanner.Scan(); i++ {
+		line := scanner.Text()
+		if p.Type == parserTypeCSV {
+			if columns, err = p.split(line); err != nil {
+				log.Printf("W! [logfile] Unable to parse the header of %v: %v", p.filename, err)
+			}
+			return columns
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if strings.HasPrefix(line, w3cFieldsDirective) {
+			columns = strings.Fields(strings.TrimPrefix(line, w3cFieldsDirective))
+		}
+	}
+	return columns
+}
+
+func (p *delimitedParser) timestamp(values []string) time.Time {
+	if len(p.TimestampColumns) == 0 {
+		return time.Time{}
+	}
+	parts := make([]string, 0, len(p.TimestampColumns))
+	for _, column := range p.TimestampColumns {
+		for i, name := range p.columns {
+			if name == column {
+				parts = append(parts, values[i])
+				break
+			}
+		}
+	}
+	if len(parts) != len(p.TimestampColumns) {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(p.TimestampLayout, strings.Join(parts, " "), p.location)
+	if err != nil {
+		log.Printf("D! [logfile] Unable to parse the timestamp of the log entry of %v: %v", p.filename, err)
+		return time.Time{}
+	}
+	return t
+}
+
+//Convert the value to the type of its column, it is kept as a string when it is not of the type.
+func convertColumn(value, columnType string) interface{} {
+	switch columnType {
+	case columnTypeInteger:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case columnTypeFloat:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case columnTypeBoolean:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+func equalFields(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
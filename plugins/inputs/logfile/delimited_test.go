@@ -0,0 +1,149 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserConfig(t *testing.T) {
+	pc := &ParserConfig{Type: "w3c"}
+	require.NoError(t, pc.init(time.Local, false))
+	assert.Equal(t, ' ', pc.delimiter)
+	assert.Equal(t, []string{"date", "time"}, pc.TimestampColumns)
+	assert.Equal(t, time.UTC, pc.location)
+
+	pc = &ParserConfig{Type: "csv"}
+	require.NoError(t, pc.init(time.Local, false))
+	assert.Equal(t, ',', pc.delimiter)
+	assert.Equal(t, time.Local, pc.location)
+
+	assert.EqualError(t, (&ParserConfig{Type: "xml"}).init(time.UTC, false), "the parser type xml is not supported, it must be csv, w3c or regex")
+	assert.Error(t, (&ParserConfig{Type: "csv", Delimiter: "||"}).init(time.UTC, false))
+	assert.Error(t, (&ParserConfig{Type: "csv", Delimiter: `"`}).init(time.UTC, false))
+	assert.Error(t, (&ParserConfig{Type: "csv", ColumnTypes: map[string]string{"a": "date"}}).init(time.UTC, false))
+	assert.Error(t, (&ParserConfig{Type: "regex", Pattern: "(?P<a"}).init(time.UTC, false))
+}
+
+func TestW3CParser(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delimited")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "u_ex210102.log")
+	header := "#Software: Microsoft Internet Information Services 10.0\n#Fields: date time cs-method cs-uri-stem sc-status time-taken\n"
+	require.NoError(t, ioutil.WriteFile(filename, []byte(header), 0644))
+
+	pc := &ParserConfig{Type: "w3c", ColumnTypes: map[string]string{"sc-status": "integer", "time-taken": "integer"}}
+	require.NoError(t, pc.init(time.Local, false))
+	p := pc.newParser(filename)
+
+	// resuming after the directives, the columns are read from the start of the file
+	event, ts, ok := p.parse("2021-01-02 03:04:05 GET /index.html 200 15")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"date":"2021-01-02","time":"03:04:05","cs-method":"GET","cs-uri-stem":"/index.html","sc-status":200,"time-taken":15}`, event)
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), ts)
+
+	_, _, ok = p.parse("#Date: 2021-01-02 04:00:00")
+	assert.False(t, ok)
+	_, _, ok = p.parse("#Fields: date time cs-uri-stem sc-status")
+	assert.False(t, ok)
+	event, _, ok = p.parse("2021-01-02 04:00:01 /missing.html -")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"date":"2021-01-02","time":"04:00:01","cs-uri-stem":"/missing.html"}`, event)
+
+	// the entries with another number of fields are published as is
+	event, ts, ok = p.parse("2021-01-02 04:00:02 /a.html 200 extra")
+	assert.True(t, ok)
+	assert.Equal(t, "2021-01-02 04:00:02 /a.html 200 extra", event)
+	assert.True(t, ts.IsZero())
+}
+
+func TestCSVParser(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delimited")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "export.csv")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("timestamp,user,bytes,ok\n"), 0644))
+
+	pc := &ParserConfig{
+		Type:             "csv",
+		ColumnTypes:      map[string]string{"bytes": "float", "ok": "boolean"},
+		TimestampColumns: []string{"timestamp"},
+	}
+	require.NoError(t, pc.init(time.UTC, false))
+	p := pc.newParser(filename)
+
+	_, _, ok := p.parse("timestamp,user,bytes,ok")
+	assert.False(t, ok)
+	event, ts, ok := p.parse(`2021-01-02T03:04:05Z,"doe, john",12.5,yes`)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"timestamp":"2021-01-02T03:04:05Z","user":"doe, john","bytes":12.5,"ok":"yes"}`, event)
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), ts.UTC())
+
+	// ELB style, space-delimited with quoted fields and the columns given
+	pc = &ParserConfig{
+		Type:        "csv",
+		Delimiter:   " ",
+		Columns:     []string{"type", "time", "elb", "request"},
+		ColumnTypes: map[string]string{"unknown": "integer"},
+	}
+	require.NoError(t, pc.init(time.UTC, false))
+	p = pc.newParser(filepath.Join(dir, "missing.log"))
+	event, ts, ok = p.parse(`https 2021-01-02T03:04:05.123456Z app/my-lb "GET https://example.com:443/ HTTP/1.1"`)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"type":"https","time":"2021-01-02T03:04:05.123456Z","elb":"app/my-lb","request":"GET https://example.com:443/ HTTP/1.1"}`, event)
+	assert.True(t, ts.IsZero())
+}
+
+func TestCSVParserEmptyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delimited")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "export.csv")
+	require.NoError(t, ioutil.WriteFile(filename, nil, 0644))
+
+	pc := &ParserConfig{Type: "csv"}
+	require.NoError(t, pc.init(time.UTC, false))
+	p := pc.newParser(filename)
+	// the header is written after the tailing started
+	_, _, ok := p.parse("a;b,c")
+	assert.False(t, ok)
+	event, _, ok := p.parse("1,2")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"a;b":"1","c":"2"}`, event)
+}
+
+func TestRegexParser(t *testing.T) {
+	pc := &ParserConfig{
+		Type:             "regex",
+		Pattern:          `^(?P<host>\S+) \[(?P<time>[^\]]+)\] "(?:(?P<method>[A-Z]+) (?P<path>\S+)|(?P<request>[^"]*))" (?P<status>\d{3}) (?P<bytes>\S+)`,
+		ColumnTypes:      map[string]string{"status": "integer", "bytes": "integer"},
+		TimestampColumns: []string{"time"},
+		TimestampLayout:  "02/Jan/2006:15:04:05 -0700",
+		NullValue:        "-",
+	}
+	require.NoError(t, pc.init(time.UTC, false))
+	p := pc.newParser("access.log")
+
+	event, ts, ok := p.parse(`10.0.0.1 [10/Oct/2000:13:55:36 -0700] "GET /index.html" 200 2326`)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"host":"10.0.0.1","time":"10/Oct/2000:13:55:36 -0700","method":"GET","path":"/index.html","status":200,"bytes":2326}`, event)
+	assert.Equal(t, time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC), ts.UTC())
+
+	event, _, ok = p.parse(`10.0.0.1 [10/Oct/2000:13:55:37 -0700] "-" 400 -`)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"host":"10.0.0.1","time":"10/Oct/2000:13:55:37 -0700","status":400}`, event)
+
+	event, ts, ok = p.parse("not an access log entry")
+	assert.True(t, ok)
+	assert.Equal(t, "not an access log entry", event)
+	assert.True(t, ts.IsZero())
+}
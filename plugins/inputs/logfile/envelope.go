@@ -0,0 +1,107 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	eventFormatText         = "text"
+	eventFormatJSONEnvelope = "json_envelope"
+	envelopeFilePathField   = "file_path"
+	// maxEnvelopeSize is the size limit of the messages of CloudWatch Logs, i.e. 256KB minus the 26 bytes of the
+	// header of the log events, larger envelopes would be cut by the output into invalid JSON
+	maxEnvelopeSize = 256*1024 - 26
+)
+
+//The JSON envelope of the log events, with the same keys for all the files so that the Logs Insights queries across
+//log groups can rely on the discovered fields, e.g.
+// {"@timestamp":"2021-01-02T03:04:05.678Z","message":"...","host":"ip-10-0-0-1","fields":{"file_path":"/var/log/app.log"}}
+type jsonEnvelope struct {
+	host   string
+	fields map[string]string
+}
+
+type envelopeEvent struct {
+	Timestamp string            `json:"@timestamp"`
+	Message   string            `json:"message"`
+	Host      string            `json:"host"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+func newJSONEnvelope(format string, fields map[string]string) (*jsonEnvelope, error) {
+	switch format {
+	case "", eventFormatText:
+		return nil, nil
+	case eventFormatJSONEnvelope:
+	default:
+		return nil, fmt.Errorf("event_format %v is not supported, it must be %v or %v", format, eventFormatText, eventFormatJSONEnvelope)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		log.Printf("W! [logfile] Unable to get the host name of the JSON envelope of the log events: %v", err)
+	}
+	return &jsonEnvelope{host: host, fields: fields}, nil
+}
+
+//Wrap the message of the log event of the file in the envelope, the ingestion time is used when it has no timestamp.
+//The message is truncated with the suffix so that the envelope fits in maxSize, its JSON serialization is never cut.
+func (env *jsonEnvelope) wrap(msg string, t time.Time, filename string, maxSize int, truncateSuffix string) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	fields := make(map[string]string, len(env.fields)+1)
+	for k, v := range env.fields {
+		fields[k] = v
+	}
+	fields[envelopeFilePathField] = filename
+	if maxSize <= 0 || maxSize > maxEnvelopeSize {
+		maxSize = maxEnvelopeSize
+	}
+	event := envelopeEvent{
+		Timestamp: t.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		Message:   msg,
+		Host:      env.host,
+		Fields:    fields,
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("E! [logfile] Unable to wrap the log event of %v in a JSON envelope, sending it as is: %v", filename, err)
+		return msg
+	}
+	if len(b) <= maxSize {
+		return string(b)
+	}
+	// the escaping of the message makes its size in the envelope unknown, search the longest one which fits
+	var fits []byte
+	for lo, hi := 0, len(msg)-1; lo <= hi; {
+		size := (lo + hi) / 2
+		event.Message = truncateMessage(msg, size, truncateSuffix)
+		if b, err = json.Marshal(event); err == nil && len(b) <= maxSize {
+			fits = b
+			lo = size + 1
+		} else {
+			hi = size - 1
+		}
+	}
+	if fits == nil {
+		event.Message = ""
+		fits, _ = json.Marshal(event)
+	}
+	return string(fits)
+}
+
+// truncateMessage cuts the message at the rune boundary before size and appends the suffix
+func truncateMessage(msg string, size int, suffix string) string {
+	for size > 0 && !utf8.RuneStart(msg[size]) {
+		size--
+	}
+	return msg[:size] + suffix
+}
@@ -0,0 +1,112 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEnvelope(t *testing.T) {
+	env, err := newJSONEnvelope("", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, env)
+	env, err = newJSONEnvelope("text", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, env)
+	_, err = newJSONEnvelope("xml", nil)
+	assert.Error(t, err)
+
+	env, err = newJSONEnvelope("json_envelope", map[string]string{"service": "checkout", "file_path": "ignored"})
+	require.NoError(t, err)
+	env.host = "host1"
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 678000000, time.FixedZone("", 3600))
+	assert.Equal(t,
+		`{"@timestamp":"2021-01-02T02:04:05.678Z","message":"line \"1\"\n\tat a","host":"host1","fields":{"file_path":"/var/log/app.log","service":"checkout"}}`,
+		env.wrap("line \"1\"\n\tat a", ts, "/var/log/app.log", 0, ""))
+
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(env.wrap("no timestamp", time.Time{}, "/var/log/app.log", 0, "")), &event))
+	timestamp, err := time.Parse(time.RFC3339, event["@timestamp"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), timestamp, time.Minute)
+}
+
+func TestJSONEnvelopeTruncation(t *testing.T) {
+	env, err := newJSONEnvelope("json_envelope", nil)
+	require.NoError(t, err)
+	env.host = "host1"
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// the quotes are escaped and the runes are multi-byte, the envelope is larger than the message would suggest
+	msg := strings.Repeat(`"é"`, 100)
+	wrapped := env.wrap(msg, ts, "/var/log/app.log", 200, "[Truncated...]")
+	assert.True(t, len(wrapped) <= 200, "envelope of %v bytes", len(wrapped))
+	var event envelopeEvent
+	require.NoError(t, json.Unmarshal([]byte(wrapped), &event))
+	assert.True(t, strings.HasSuffix(event.Message, "[Truncated...]"))
+	assert.True(t, strings.HasPrefix(msg, strings.TrimSuffix(event.Message, "[Truncated...]")))
+	assert.True(t, utf8.ValidString(event.Message))
+
+	// the size of the log events of CloudWatch Logs bounds the envelopes even if the max event size is larger
+	wrapped = env.wrap(strings.Repeat("a", maxEnvelopeSize), ts, "/var/log/app.log", 1024*1024, "[Truncated...]")
+	assert.True(t, len(wrapped) <= maxEnvelopeSize)
+	require.NoError(t, json.Unmarshal([]byte(wrapped), &event))
+
+	wrapped = env.wrap("short", ts, "/var/log/app.log", 200, "[Truncated...]")
+	require.NoError(t, json.Unmarshal([]byte(wrapped), &event))
+	assert.Equal(t, "short", event.Message)
+}
+
+func TestLogsJSONEnvelope(t *testing.T) {
+	multilineWaitPeriod = 10 * time.Millisecond
+	logEntryString := "2021-01-02 03:04:05 started"
+	tmpfile, err := createTempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString(logEntryString + "\n")
+	require.NoError(t, err)
+
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	tt.FileConfig = []FileConfig{{
+		FilePath:        tmpfile.Name(),
+		FromBeginning:   true,
+		TimestampRegex:  `^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`,
+		TimestampLayout: "2006-01-02 15:04:05",
+		Timezone:        "UTC",
+		EventFormat:     "json_envelope",
+		EventFields:     map[string]string{"env": "test"},
+	}}
+	require.NoError(t, tt.FileConfig[0].init())
+	tt.started = true
+
+	lsrcs := tt.FindLogSrc()
+	require.Len(t, lsrcs, 1)
+	events := make(chan logs.LogEvent, 1)
+	lsrcs[0].SetOutput(func(e logs.LogEvent) {
+		if e != nil {
+			events <- e
+		}
+	})
+	e := <-events
+
+	var event envelopeEvent
+	require.NoError(t, json.Unmarshal([]byte(e.Message()), &event))
+	assert.Equal(t, "2021-01-02T03:04:05.000Z", event.Timestamp)
+	assert.Equal(t, logEntryString, event.Message)
+	assert.Equal(t, map[string]string{"env": "test", "file_path": tmpfile.Name()}, event.Fields)
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), e.Time().UTC())
+
+	lsrcs[0].Stop()
+	tt.Stop()
+}
@@ -7,11 +7,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/timezone"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
 	"golang.org/x/net/html/charset"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/ianaindex"
@@ -28,6 +33,13 @@ type FileConfig struct {
 	FilePath string `toml:"file_path"`
 	//The blacklist used to filter out some files
 	Blacklist string `toml:"blacklist"`
+	//The glob patterns of the files to tail among the ones matched by the file path, all of them when empty.
+	//A pattern is matched against the file name, or against the whole path when it has a path separator.
+	Include []string `toml:"include"`
+	//The glob patterns of the files not to tail among the ones matched by the file path.
+	Exclude []string `toml:"exclude"`
+	//The files not modified for longer than max age are not tailed, e.g. old rotated files, when it is set.
+	MaxAge internal.Duration `toml:"max_age"`
 
 	PublishMultiLogs bool `toml:"publish_multi_logs"`
 
@@ -41,8 +53,14 @@ type FileConfig struct {
 	TimestampRegex string `toml:"timestamp_regex"`
 	//The timestampFromLogLine layout used in GoLang to parse the timestampFromLogLine.
 	TimestampLayout string `toml:"timestamp_layout"`
-	//The time zone used to parse the timestampFromLogLine in the log entry.
+	//The time zone used to parse the timestampFromLogLine in the log entry, UTC, LOCAL or an IANA time zone name.
 	Timezone string `toml:"timezone"`
+	//The regexes and layouts of the other timestamp formats, tried in order when the timestamp_regex does not match.
+	TimestampRegexes []string `toml:"timestamp_regexes"`
+	TimestampLayouts []string `toml:"timestamp_layouts"`
+	//Indicate whether to use the modification time of the file for the log entries none of the timestamp formats matches.
+	//Otherwise they are sent with the ingestion time.
+	TimestampFallbackToMtime bool `toml:"timestamp_fallback_to_mtime"`
 
 	//Indicate whether it is a start of multiline.
 	//If this config is not present, it means the multiline mode is disabled.
@@ -73,16 +91,56 @@ type FileConfig struct {
 	//Suffix to be added to truncated logline to indicate its truncation
 	TruncateSuffix string `toml:"truncate_suffix"`
 
+	//The rules emitting metrics from the log entries.
+	MetricRules []MetricRule `toml:"metric_rule"`
+
+	//The format of the log events, "text" to send the log entries as is or "json_envelope" to wrap them in a JSON object
+	//with the @timestamp, message, host and fields keys.
+	EventFormat string `toml:"event_format"`
+	//The static fields added to the fields of the JSON envelopes, along with the file_path of the log file.
+	EventFields map[string]string `toml:"event_fields"`
+	//The parser of the delimiter-separated log entries, the entries are published as JSON objects of their fields.
+	Parser *ParserConfig `toml:"parser"`
+	//The priority class of the log events when the destination is under memory pressure, "critical", "normal" or "bulk".
+	Priority string `toml:"priority"`
+	//A marker log event is published once the file produced no log entries for the idle timeout, and again every idle
+	//timeout while it stays idle, so that the silence of a critical log can be alarmed on with a metric filter.
+	IdleTimeout internal.Duration `toml:"idle_timeout"`
+	//The message of the idle marker events, it defaults to a message naming the file and the idle timeout.
+	IdleMessage string `toml:"idle_message"`
+	//The copies of the log events sent to other log groups, each through its own filters.
+	Replicas []ReplicaConfig `toml:"replica"`
+	//The field of the sequence number of the log events in their log stream, added to the log events which are JSON
+	//objects, e.g. the JSON envelopes. No sequence number is added when empty.
+	SequenceField string `toml:"sequence_field"`
+	//The glob patterns of the systemd units whose logs are collected, e.g. nginx*, along with the files of the file
+	//path. The files the units write their standard output and error to are tailed, and their journal streams are
+	//read with journalctl. The logs of a unit go to the log group named after the unit when log_group_name is empty.
+	SystemdUnits []string `toml:"systemd_units"`
+
 	//Time *time.Location Go type timezone info.
 	TimezoneLoc *time.Location
 	//Regexp go type timestampFromLogLine regex
 	TimestampRegexP *regexp.Regexp
+	//Regexp go type of the other timestamp regexes
+	TimestampRegexesP []*regexp.Regexp
 	//Regexp go type multiline start regex
 	MultiLineStartPatternP *regexp.Regexp
+	//Regexp go type of the lines matching the multiline start regex which do not start an entry, set by the named patterns
+	MultiLineContinuationPatternP *regexp.Regexp
 	//Regexp go type blacklist regex
 	BlacklistRegexP *regexp.Regexp
 	//Decoder object
 	Enc encoding.Encoding
+
+	//Set once the timestamp formats did not match a log entry, it is only reported once.
+	unmatchedTimestampReported int32
+	//The metrics of the metric rules, nil without rules
+	metrics *logMetrics
+	//The JSON envelope of the log events, nil with the text format
+	envelope *jsonEnvelope
+	//The discovery of the outputs of the systemd units, nil without units
+	units *unitDiscovery
 }
 
 //Initialize some variables in the FileConfig object based on the rest info fetched from the configuration file.
@@ -102,10 +160,8 @@ func (config *FileConfig) init() error {
 		config.LogGroupName = logGroupName(config.FilePath)
 	}
 	//If the timezone info is not specified, we will use the Local timezone as default value.
-	if config.Timezone == time.UTC.String() {
-		config.TimezoneLoc = time.UTC
-	} else {
-		config.TimezoneLoc = time.Local
+	if config.TimezoneLoc, err = timezone.LoadLocation(config.Timezone); err != nil {
+		return fmt.Errorf("timezone %v is not a known time zone: %v", config.Timezone, err)
 	}
 
 	if config.TimestampRegex != "" {
@@ -113,12 +169,34 @@ func (config *FileConfig) init() error {
 			return fmt.Errorf("timestamp_regex has issue, regexp: Compile( %v ): %v", config.TimestampRegex, err.Error())
 		}
 	}
+	if len(config.TimestampRegexes) != len(config.TimestampLayouts) {
+		return fmt.Errorf("timestamp_regexes has %v regexes but timestamp_layouts has %v layouts", len(config.TimestampRegexes), len(config.TimestampLayouts))
+	}
+	config.TimestampRegexesP = nil
+	for _, timestampRegex := range config.TimestampRegexes {
+		timestampRegexP, err := regexp.Compile(timestampRegex)
+		if err != nil {
+			return fmt.Errorf("timestamp_regexes has issue, regexp: Compile( %v ): %v", timestampRegex, err.Error())
+		}
+		config.TimestampRegexesP = append(config.TimestampRegexesP, timestampRegexP)
+	}
 
 	if config.MultiLineStartPattern == "" {
 		config.MultiLineStartPattern = "^[\\S]"
 	}
-	if config.MultiLineStartPattern == "{timestamp_regex}" {
+	if config.MultiLineStartPattern == "{timestamp_regex}" && len(config.TimestampRegexesP) > 0 {
+		//A multiline entry starts with a timestamp of any of the formats
+		pattern := "(?:" + strings.Join(append([]string{config.TimestampRegex}, config.TimestampRegexes...), ")|(?:") + ")"
+		if config.MultiLineStartPatternP, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("multi_line_start_pattern has issue, regexp: Compile( %v ): %v", pattern, err.Error())
+		}
+	} else if config.MultiLineStartPattern == "{timestamp_regex}" {
 		config.MultiLineStartPatternP = config.TimestampRegexP
+	} else if start, continuation, named, err := compileMultilinePattern(config.MultiLineStartPattern); named {
+		if err != nil {
+			return err
+		}
+		config.MultiLineStartPatternP, config.MultiLineContinuationPatternP = start, continuation
 	} else {
 		if config.MultiLineStartPatternP, err = regexp.Compile(config.MultiLineStartPattern); err != nil {
 			return fmt.Errorf("multi_line_start_pattern has issue, regexp: Compile( %v ): %v", config.MultiLineStartPattern, err.Error())
@@ -131,6 +209,43 @@ func (config *FileConfig) init() error {
 		}
 	}
 
+	for _, pattern := range append(append([]string{}, config.Include...), config.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("include/exclude pattern %v has issue: %v", pattern, err)
+		}
+	}
+
+	if config.metrics, err = newLogMetrics(config.MetricRules); err != nil {
+		return err
+	}
+
+	for i := range config.Replicas {
+		if err = config.Replicas[i].init(); err != nil {
+			return err
+		}
+	}
+
+	if config.envelope, err = newJSONEnvelope(config.EventFormat, config.EventFields); err != nil {
+		return err
+	}
+
+	if config.Parser != nil {
+		if err = config.Parser.init(config.TimezoneLoc, config.Timezone != ""); err != nil {
+			return err
+		}
+	}
+
+	config.units = nil
+	if len(config.SystemdUnits) > 0 {
+		if config.units, err = newUnitDiscovery(config.SystemdUnits); err != nil {
+			return err
+		}
+	}
+
+	if config.Priority != "" && !logs.IsPriority(config.Priority) {
+		return fmt.Errorf("priority %v is not critical, normal or bulk", config.Priority)
+	}
+
 	if config.MaxEventSize == 0 {
 		config.MaxEventSize = defaultMaxEventSize
 	}
@@ -152,17 +267,63 @@ func logGroupName(filePath string) string {
 	return strings.TrimSuffix(filePath, suffix)
 }
 
+//Whether the file matched by the file path is selected by the include and exclude patterns.
+func (config *FileConfig) isIncluded(fileName string) bool {
+	matchesAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			name := filepath.Base(fileName)
+			if strings.ContainsRune(pattern, filepath.Separator) {
+				name = fileName
+			}
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(config.Include) == 0 || matchesAny(config.Include)) && !matchesAny(config.Exclude)
+}
+
+//Whether the file was modified too long ago to be tailed.
+func (config *FileConfig) isTooOld(modTime, now time.Time) bool {
+	return config.MaxAge.Duration > 0 && now.Sub(modTime) > config.MaxAge.Duration
+}
+
+//Whether the files are encoded in UTF-16, which is read by the tailer rather than decoded afterwards.
+func (config *FileConfig) isUTF16() bool {
+	switch config.Encoding {
+	case "utf-16", "utf-16le", "UTF-16", "UTF-16LE":
+		return true
+	}
+	return false
+}
+
 //Try to parse the timestampFromLogLine value from the log entry line.
-//The parser logic will be based on the timestampFromLogLine regex, and time zone info.
+//The parser logic will be based on the timestampFromLogLine regex, and time zone info, then on the other timestamp formats in order.
 //If the parsing operation encounters any issue, int64(0) is returned.
 func (config *FileConfig) timestampFromLogLine(logValue string) time.Time {
 	if config.TimestampRegexP == nil {
 		return time.Time{}
 	}
-	index := config.TimestampRegexP.FindStringSubmatchIndex(logValue)
+	timestamp, err := parseTimestamp(config.TimestampRegexP, config.TimestampLayout, logValue, config.TimezoneLoc)
+	for i := 0; timestamp.IsZero() && i < len(config.TimestampRegexesP) && i < len(config.TimestampLayouts); i++ {
+		var otherErr error
+		if timestamp, otherErr = parseTimestamp(config.TimestampRegexesP[i], config.TimestampLayouts[i], logValue, config.TimezoneLoc); err == nil {
+			err = otherErr
+		}
+	}
+	if timestamp.IsZero() && err != nil {
+		log.Printf("E! Error parsing timestampFromLogLine: %s", err)
+	}
+	return timestamp
+}
+
+//Parse the timestamp matched by the regex with the layout, the zero time is returned when the regex does not match.
+func parseTimestamp(timestampRegexP *regexp.Regexp, timestampLayout, logValue string, loc *time.Location) (time.Time, error) {
+	index := timestampRegexP.FindStringSubmatchIndex(logValue)
 	if index != nil && len(index) > 3 {
 		timestampContent := (logValue)[index[2]:index[3]]
-		if len(index) > 5 {
+		if len(index) > 5 && index[4] >= 0 {
 			start := index[4] - index[2]
 			end := index[5] - index[2]
 			//append "000" to 2nd submatch in order to guarantee the fractional second at least has 3 digits
@@ -170,10 +331,18 @@ func (config *FileConfig) timestampFromLogLine(logValue string) time.Time {
 			replacement := fmt.Sprintf(".%s", fracSecond[:3])
 			timestampContent = fmt.Sprintf("%s%s%s", timestampContent[:start], replacement, timestampContent[end:])
 		}
-		timestamp, err := time.ParseInLocation(config.TimestampLayout, timestampContent, config.TimezoneLoc)
+		timestamp, err := time.ParseInLocation(timestampLayout, timestampContent, loc)
+		if err != nil && strings.Contains(timestampLayout, "-0700") {
+			//The numeric zone of %z also matches the ISO8601 offsets, e.g. Z or -07:00
+			for _, isoLayout := range []string{"Z07:00", "Z0700"} {
+				if isoTimestamp, isoErr := time.ParseInLocation(strings.Replace(timestampLayout, "-0700", isoLayout, 1), timestampContent, loc); isoErr == nil {
+					timestamp, err = isoTimestamp, nil
+					break
+				}
+			}
+		}
 		if err != nil {
-			log.Printf("E! Error parsing timestampFromLogLine: %s", err)
-			return time.Time{}
+			return time.Time{}, err
 		}
 		if timestamp.Year() == 0 {
 			now := time.Now()
@@ -185,9 +354,43 @@ func (config *FileConfig) timestampFromLogLine(logValue string) time.Time {
 				timestamp = timestamp.AddDate(-1, 0, 0)
 			}
 		}
+		return timestamp, nil
+	}
+	return time.Time{}, nil
+}
+
+//The timestamp function of the log entries of the file. When timestamp formats are set and none matches a log entry,
+//the modification time of the file is used if configured so, otherwise the ingestion time.
+func (config *FileConfig) timestampFn(filename string) func(string) time.Time {
+	if config.TimestampRegexP == nil {
+		return config.timestampFromLogLine
+	}
+	return func(logValue string) time.Time {
+		timestamp := config.timestampFromLogLine(logValue)
+		if !timestamp.IsZero() {
+			return timestamp
+		}
+		fallback := "the ingestion time"
+		if config.TimestampFallbackToMtime {
+			fallback = "the modification time of the file"
+			if fi, err := os.Stat(filename); err == nil {
+				timestamp = fi.ModTime()
+			}
+		}
+		if atomic.CompareAndSwapInt32(&config.unmatchedTimestampReported, 0, 1) {
+			log.Printf("W! [logfile] The timestamp_format of %v does not match the log entries of %v, they get %v instead, e.g. %q",
+				config.FilePath, filename, fallback, truncateForLog(logValue))
+		}
 		return timestamp
 	}
-	return time.Time{}
+}
+
+func truncateForLog(logValue string) string {
+	const maxLen = 128
+	if len(logValue) > maxLen {
+		return logValue[:maxLen] + "..."
+	}
+	return logValue
 }
 
 //This method determine whether the line is a start line for multiline log entry.
@@ -196,5 +399,6 @@ func (config *FileConfig) isMultilineStart(logValue string) bool {
 	if config.MultiLineStartPatternP == nil {
 		return false
 	}
-	return config.MultiLineStartPatternP.MatchString(logValue)
+	return config.MultiLineStartPatternP.MatchString(logValue) &&
+		(config.MultiLineContinuationPatternP == nil || !config.MultiLineContinuationPatternP.MatchString(logValue))
 }
@@ -5,6 +5,8 @@ package logfile
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"regexp"
 	"testing"
 	"time"
@@ -134,6 +136,73 @@ func TestTimestampParserWithFracSeconds(t *testing.T) {
 		fmt.Sprintf("The timestampFromLogLine value %v is not the same as expected %v.", timestamp, expectedTimestamp))
 }
 
+func TestTimestampParserWithMultipleFormats(t *testing.T) {
+	fileConfig := &FileConfig{
+		FilePath:              "/tmp/logfile.log",
+		TimestampRegex:        "(\\d{4}-\\d{2}-\\d{2}T\\d{2}:\\d{2}:\\d{2}\\.(\\d{1,9})(?:Z|[\\+-]\\d{2}:?\\d{2}))",
+		TimestampLayout:       "2006-01-02T15:04:05..000-0700",
+		TimestampRegexes:      []string{"(\\d{2}/\\w{3}/\\d{4}:\\d{2}:\\d{2}:\\d{2})"},
+		TimestampLayouts:      []string{"02/Jan/2006:15:04:05"},
+		Timezone:              "UTC",
+		MultiLineStartPattern: "{timestamp_regex}",
+	}
+	require.NoError(t, fileConfig.init())
+
+	expectedTimestamp := time.Unix(1497882318, 234000000)
+	for _, timestampString := range []string{"2017-06-19T14:25:18.234088Z", "2017-06-19T16:25:18.234+02:00", "2017-06-19T09:25:18.234-0500"} {
+		timestamp := fileConfig.timestampFromLogLine(fmt.Sprintf("%s [INFO] This is a test message.", timestampString))
+		assert.Equal(t, expectedTimestamp.UnixNano(), timestamp.UnixNano(), timestampString)
+	}
+	timestamp := fileConfig.timestampFromLogLine("127.0.0.1 - - [19/Jun/2017:14:25:18] \"GET / HTTP/1.1\" 200")
+	assert.Equal(t, time.Unix(1497882318, 0).UnixNano(), timestamp.UnixNano())
+	assert.True(t, fileConfig.timestampFromLogLine("no timestamp").IsZero())
+
+	assert.True(t, fileConfig.isMultilineStart("2017-06-19T14:25:18.234Z first line"))
+	assert.True(t, fileConfig.isMultilineStart("[19/Jun/2017:14:25:18] first line"))
+	assert.False(t, fileConfig.isMultilineStart("  continued line"))
+
+	fileConfig.TimestampLayouts = nil
+	assert.Error(t, fileConfig.init())
+}
+
+func TestTimestampFallbackToMtime(t *testing.T) {
+	file, err := ioutil.TempFile("", "timestamps")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.Close()
+	mtime := time.Unix(1497882318, 0)
+	require.NoError(t, os.Chtimes(file.Name(), mtime, mtime))
+
+	fileConfig := &FileConfig{
+		FilePath:        file.Name(),
+		TimestampRegex:  "(\\d{2} \\w{3} \\d{4} \\d{2}:\\d{2}:\\d{2})",
+		TimestampLayout: "02 Jan 2006 15:04:05",
+		Timezone:        "UTC",
+	}
+	require.NoError(t, fileConfig.init())
+	assert.True(t, fileConfig.timestampFn(file.Name())("no timestamp").IsZero())
+
+	fileConfig.TimestampFallbackToMtime = true
+	timestampFn := fileConfig.timestampFn(file.Name())
+	assert.Equal(t, mtime.UnixNano(), timestampFn("no timestamp").UnixNano())
+	assert.Equal(t, time.Unix(1497882319, 0).UnixNano(), timestampFn("19 Jun 2017 14:25:19 message").UnixNano())
+}
+
+func TestTimestampParserWithTimezoneName(t *testing.T) {
+	fileConfig := &FileConfig{
+		FilePath:        "/tmp/logfile.log",
+		TimestampRegex:  "(\\d{2} \\w{3} \\d{4} \\d{2}:\\d{2}:\\d{2})",
+		TimestampLayout: "02 Jan 2006 15:04:05",
+		Timezone:        "Asia/Kolkata",
+	}
+	require.NoError(t, fileConfig.init())
+	timestamp := fileConfig.timestampFromLogLine("19 Jun 2017 19:55:18 [INFO] This is a test message.")
+	assert.Equal(t, time.Unix(1497882318, 0).UnixNano(), timestamp.UnixNano())
+
+	fileConfig.Timezone = "Asia/Atlantis"
+	assert.Error(t, fileConfig.init())
+}
+
 func TestMultiLineStartPattern(t *testing.T) {
 	multiLineStartPattern := "---"
 	fileConfig := &FileConfig{
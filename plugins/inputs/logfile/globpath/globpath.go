@@ -20,13 +20,20 @@ type GlobPath struct {
 	hasSuperMeta bool
 	g            glob.Glob
 	root         string
+	// stream is the alternate data stream of the files on Windows, e.g. ":audit" of C:\logs\*.log:audit
+	stream string
 }
 
 func Compile(path string) (*GlobPath, error) {
+	var stream string
+	if runtime.GOOS == "windows" {
+		path, stream = splitStream(path)
+	}
 	out := GlobPath{
 		hasMeta:      hasMeta(path),
 		hasSuperMeta: hasSuperMeta(path),
 		path:         path,
+		stream:       stream,
 	}
 
 	// if there are no glob meta characters in the path, don't bother compiling
@@ -50,6 +57,23 @@ func Compile(path string) (*GlobPath, error) {
 }
 
 func (g *GlobPath) Match() map[string]os.FileInfo {
+	if g.stream == "" {
+		return g.matchFiles()
+	}
+	// the streams are not listed with the files of the directories, they are looked up in the matching files
+	out := make(map[string]os.FileInfo)
+	for file := range g.matchFiles() {
+		name := file + g.stream
+		if info, err := os.Stat(name); err == nil {
+			out[name] = info
+		} else {
+			log.Printf("D! Stat file %v failed due to %v", name, err)
+		}
+	}
+	return out
+}
+
+func (g *GlobPath) matchFiles() map[string]os.FileInfo {
 	if !g.hasMeta {
 		out := make(map[string]os.FileInfo)
 		info, err := os.Stat(g.path)
@@ -76,6 +100,24 @@ func (g *GlobPath) Match() map[string]os.FileInfo {
 	return walkFilePath(g.root, g.g)
 }
 
+// MatchString reports whether the file name is one Match would return when the file exists.
+func (g *GlobPath) MatchString(filename string) bool {
+	if g.stream != "" {
+		if !strings.HasSuffix(filename, g.stream) {
+			return false
+		}
+		filename = strings.TrimSuffix(filename, g.stream)
+	}
+	if !g.hasMeta {
+		return filename == g.path
+	}
+	if !g.hasSuperMeta {
+		matched, _ := filepath.Match(g.path, filename)
+		return matched
+	}
+	return g.g.Match(filename)
+}
+
 // walk the filepath from the given root and return a list of files that match
 // the given glob.
 func walkFilePath(root string, g glob.Glob) map[string]os.FileInfo {
@@ -120,6 +162,21 @@ func findRootDir(path string) string {
 	return out
 }
 
+// splitStream splits a Windows path into the path of the file and its alternate data stream, e.g.
+// C:\logs\app.log:audit -> C:\logs\app.log and :audit. The colon of the drive does not start a stream.
+func splitStream(path string) (string, string) {
+	start := strings.LastIndexAny(path, `\/`) + 1
+	if start == 0 && len(path) >= 2 && path[1] == ':' {
+		// a path relative to the current directory of a drive, e.g. C:app.log
+		start = 2
+	}
+	i := strings.Index(path[start:], ":")
+	if i < 0 {
+		return path, ""
+	}
+	return path[:start+i], path[start+i:]
+}
+
 // escapeSeparator escapes the windows path separator '\' in glob pattern
 // old "\\" - first '\' escapes the following path separator
 // new "\\\\" - first '\' escapes second '\' which will be used as escape indicator in glob pattern
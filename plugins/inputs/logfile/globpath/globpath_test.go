@@ -41,6 +41,31 @@ func TestCompileAndMatch(t *testing.T) {
 	assert.Len(t, matches, 0)
 }
 
+func TestSplitStream(t *testing.T) {
+	tests := []struct {
+		path, file, stream string
+	}{
+		{`C:\inetpub\logs\u_ex*.log:audit`, `C:\inetpub\logs\u_ex*.log`, ":audit"},
+		{`C:\logs\app.log:audit:$DATA`, `C:\logs\app.log`, ":audit:$DATA"},
+		{`C:\logs\app.log`, `C:\logs\app.log`, ""},
+		{`C:app.log:audit`, `C:app.log`, ":audit"},
+		{`C:\logs\**`, `C:\logs\**`, ""},
+		{`\\server\share\app.log:audit`, `\\server\share\app.log`, ":audit"},
+	}
+	for _, test := range tests {
+		file, stream := splitStream(test.path)
+		assert.Equal(t, test.file, file, test.path)
+		assert.Equal(t, test.stream, stream, test.path)
+	}
+}
+
+func TestMatchStringStream(t *testing.T) {
+	g := &GlobPath{path: "/var/log/*.log", hasMeta: true, stream: ":audit"}
+	assert.True(t, g.MatchString("/var/log/app.log:audit"))
+	assert.False(t, g.MatchString("/var/log/app.log"))
+	assert.False(t, g.MatchString("/var/log/app.txt:audit"))
+}
+
 func TestFindRootDir(t *testing.T) {
 	tests := []struct {
 		input  string
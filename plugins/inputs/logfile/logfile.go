@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
 	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
 	"github.com/aws/amazon-cloudwatch-agent/logs"
 	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/globpath"
@@ -36,6 +37,10 @@ type LogFile struct {
 	done              chan struct{}
 	removeTailerSrcCh chan *tailerSrc
 	started           bool
+	// the sequences of the log streams whose structured log events are numbered
+	sequences map[string]*streamSequence
+	// the srcs of the journal streams of the systemd units, by unit
+	journals map[*FileConfig]map[string]*journalSrc
 }
 
 func NewLogFile() *LogFile {
@@ -65,8 +70,16 @@ const sampleConfig = `
 
   [[inputs.logs.file_config]]
       file_path = "/tmp/logfile.log*"
+      ## Also collect the logs of the systemd units matching the patterns, from the files they write their output to
+      ## and from their journal streams
+      # systemd_units = ["nginx*"]
       ## Regular expression for log files to ignore
       blacklist = "logfile.log.bak"
+      ## Glob patterns of the file names to tail, and not to tail, among the matched files
+      # include = ["logfile.log*"]
+      # exclude = ["*.bak"]
+      ## Do not tail the matched files which were not modified for longer than max_age
+      # max_age = "168h"
       ## Publish all log files that match file_path
       publish_multi_logs = false
       log_group_name = "logfile.log"
@@ -85,6 +98,44 @@ const sampleConfig = `
       max_event_size = 262144
       ## Suffix to be added to truncated logline to indicate its truncation, defaults to "[Truncated...]"
       truncate_suffix = "[Truncated...]"
+      ## Send the log entries as is ("text") or wrapped in JSON envelopes ("json_envelope") with consistent keys
+      # event_format = "json_envelope"
+      # event_fields = { service = "checkout" }
+      ## The priority class of the log events when the destination is under memory pressure: "critical", "normal" or "bulk"
+      # priority = "critical"
+      ## Publish a marker log event every idle_timeout while the file produces no log entries
+      # idle_timeout = "15m"
+      # idle_message = "IDLE audit.log"
+      ## Add the sequence number of the log event in its log stream to the structured log events, the JSON objects,
+      ## so that the gaps and the duplicates can be detected downstream. It is kept in the state across restarts
+      # sequence_field = "ingest_sequence"
+      ## Emit metrics from the matching log entries, published every collection interval
+      # [[inputs.logs.file_config.metric_rule]]
+      #   metric_name = "ErrorCount"
+      #   pattern = "ERROR (?P<component>\\w+)"
+      #   dimensions = ["component"]
+      # [[inputs.logs.file_config.metric_rule]]
+      #   metric_name = "Latency"
+      #   pattern = "latency=(?P<latency>[\\d.]+)ms"
+      #   value_group = "latency"
+      #   unit = "Milliseconds"
+      ## Send a copy of the log events to other log groups, each through its own filters applied in order
+      # [[inputs.logs.file_config.replica]]
+      #   log_group_name = "logfile.log.errors"
+      #   [[inputs.logs.file_config.replica.filters]]
+      #     type = "include"
+      #     expression = "ERROR|FATAL"
+      #   [[inputs.logs.file_config.replica.filters]]
+      #     type = "replace"
+      #     expression = "password=\\S+"
+      #     replacement = "password=***"
+      ## Publish the delimiter-separated entries ("csv") or the W3C extended format of IIS ("w3c") as JSON objects
+      ## of typed fields, timestamped by the timestamp_columns
+      # [inputs.logs.file_config.parser]
+      #   type = "w3c"
+      #   column_types = { sc-status = "integer", time-taken = "integer" }
+      #   timestamp_columns = ["date", "time"]
+      #   timestamp_layout = "2006-01-02 15:04:05"
 
 `
 
@@ -97,6 +148,14 @@ func (t *LogFile) Description() string {
 }
 
 func (t *LogFile) Gather(acc telegraf.Accumulator) error {
+	if !t.started {
+		return nil
+	}
+	for i := range t.FileConfig {
+		if t.FileConfig[i].metrics != nil {
+			t.FileConfig[i].metrics.gather(acc)
+		}
+	}
 	return nil
 }
 
@@ -153,9 +212,17 @@ func (t *LogFile) FindLogSrc() []logs.LogSrc {
 	for i := range t.FileConfig {
 		fileconfig := &t.FileConfig[i]
 
-		targetFiles, err := t.getTargetFiles(fileconfig)
-		if err != nil {
-			t.Log.Errorf("Failed to find target files for file config %v, with error: %v", fileconfig.FilePath, err)
+		var targetFiles []string
+		var err error
+		if fileconfig.FilePath != "" {
+			targetFiles, err = t.getTargetFiles(fileconfig)
+			if err != nil {
+				t.Log.Errorf("Failed to find target files for file config %v, with error: %v", fileconfig.FilePath, err)
+			}
+		}
+		if outputs := t.unitOutputs(fileconfig); len(outputs) > 0 {
+			targetFiles = appendUnitFiles(targetFiles, outputs)
+			srcs = append(srcs, t.findJournalSrcs(fileconfig, outputs)...)
 		}
 
 		for _, filename := range targetFiles {
@@ -181,11 +248,6 @@ func (t *LogFile) FindLogSrc() []logs.LogSrc {
 				seekFile = &tail.SeekInfo{Whence: io.SeekEnd, Offset: 0}
 			}
 
-			isutf16 := false
-			if fileconfig.Encoding == "utf-16" || fileconfig.Encoding == "utf-16le" || fileconfig.Encoding == "UTF-16" || fileconfig.Encoding == "UTF-16LE" {
-				isutf16 = true
-			}
-
 			tailer, err := tail.TailFile(filename,
 				tail.Config{
 					ReOpen:      false,
@@ -195,49 +257,22 @@ func (t *LogFile) FindLogSrc() []logs.LogSrc {
 					Pipe:        fileconfig.Pipe,
 					Poll:        true,
 					MaxLineSize: fileconfig.MaxEventSize,
-					IsUTF16:     isutf16,
+					IsUTF16:     fileconfig.isUTF16(),
 				})
 
 			if err != nil {
 				t.Log.Errorf("Failed to tail file %v with error: %v", filename, err)
+				agentevents.Post(agentevents.Event{
+					Type:       agentevents.TypeFileUnreadable,
+					Severity:   agentevents.SeverityError,
+					Component:  "inputs.logfile",
+					Message:    err.Error(),
+					Attributes: map[string]string{"file": filename, "log_group_name": fileconfig.LogGroupName},
+				})
 				continue
 			}
 
-			var mlCheck func(string) bool
-			if fileconfig.MultiLineStartPattern != "" {
-				mlCheck = fileconfig.isMultilineStart
-			}
-
-			groupName := fileconfig.LogGroupName
-			streamName := fileconfig.LogStreamName
-
-			// In case of multilog, the group and stream has to be generated here
-			// since it is based on the actual file name
-			if fileconfig.PublishMultiLogs {
-				if groupName == "" {
-					groupName = generateLogGroupName(filename)
-				} else {
-					streamName = generateLogStreamName(filename, fileconfig.LogStreamName)
-				}
-			}
-
-			destination := fileconfig.Destination
-			if destination == "" {
-				destination = t.Destination
-			}
-
-			src := NewTailerSrc(
-				groupName, streamName,
-				t.Destination,
-				t.getStateFilePath(filename),
-				tailer,
-				fileconfig.AutoRemoval,
-				mlCheck,
-				fileconfig.timestampFromLogLine,
-				fileconfig.Enc,
-				fileconfig.MaxEventSize,
-				fileconfig.TruncateSuffix,
-			)
+			src := t.newTailerSrc(fileconfig, filename, t.getStateFilePath(filename), tailer)
 
 			src.AddCleanUpFn(func(ts *tailerSrc) func() {
 				return func() {
@@ -258,6 +293,76 @@ func (t *LogFile) FindLogSrc() []logs.LogSrc {
 	return srcs
 }
 
+// newTailerSrc creates the log src of a file matched by the file config
+func (t *LogFile) newTailerSrc(fileconfig *FileConfig, filename, stateFilePath string, tailer *tail.Tail) *tailerSrc {
+	var mlCheck func(string) bool
+	if fileconfig.MultiLineStartPattern != "" {
+		mlCheck = fileconfig.isMultilineStart
+	}
+
+	groupName, streamName, destination := t.srcTarget(fileconfig, filename)
+
+	src := NewTailerSrc(
+		groupName, streamName,
+		destination,
+		stateFilePath,
+		tailer,
+		fileconfig.AutoRemoval,
+		mlCheck,
+		fileconfig.timestampFn(filename),
+		fileconfig.Enc,
+		fileconfig.MaxEventSize,
+		fileconfig.TruncateSuffix,
+	)
+	src.metrics = fileconfig.metrics
+	src.envelope = fileconfig.envelope
+	if fileconfig.Parser != nil {
+		src.parser = fileconfig.Parser.newParser(filename)
+	}
+	src.priority = fileconfig.Priority
+	src.idleTimeout = fileconfig.IdleTimeout.Duration
+	src.idleMessage = fileconfig.IdleMessage
+	src.replicas = fileconfig.replicas(streamName)
+	if fileconfig.SequenceField != "" {
+		// the replayed and backfilled files do not resume the sequence of the tailed ones
+		var saved int64
+		if stateFilePath != "" {
+			saved = t.restoreSequence(filename)
+		}
+		src.sequenceField = fileconfig.SequenceField
+		src.sequence = t.streamSequence(groupName, streamName, saved)
+		src.lastSeq = saved
+	}
+	return src
+}
+
+// srcTarget returns the log group, the log stream and the destination of the log src of a file matched by the file config
+func (t *LogFile) srcTarget(fileconfig *FileConfig, filename string) (group, stream, destination string) {
+	group = fileconfig.LogGroupName
+	stream = fileconfig.LogStreamName
+
+	// In case of multilog, the group and stream has to be generated here
+	// since it is based on the actual file name
+	if fileconfig.PublishMultiLogs {
+		if group == "" {
+			group = generateLogGroupName(filename)
+		} else {
+			stream = generateLogStreamName(filename, fileconfig.LogStreamName)
+		}
+	}
+
+	// the files of the systemd units go to the log group of their unit by default
+	if group == "" && fileconfig.units != nil {
+		group = fileconfig.units.unitOf(filename)
+	}
+
+	destination = fileconfig.Destination
+	if destination == "" {
+		destination = t.Destination
+	}
+	return group, stream, destination
+}
+
 func (t *LogFile) getTargetFiles(fileconfig *FileConfig) ([]string, error) {
 	filePath := fileconfig.FilePath
 	blacklistP := fileconfig.BlacklistRegexP
@@ -269,6 +374,7 @@ func (t *LogFile) getTargetFiles(fileconfig *FileConfig) ([]string, error) {
 	var targetFileList []string
 	var targetFileName string
 	var targetModTime time.Time
+	now := time.Now()
 	for matchedFileName, matchedFileInfo := range g.Match() {
 
 		// we do not allow customer to monitor the file in t.FileStateFolder, it will monitor all of the state files
@@ -292,6 +398,13 @@ func (t *LogFile) getTargetFiles(fileconfig *FileConfig) ([]string, error) {
 		if blacklistP != nil && blacklistP.MatchString(fileBaseName) {
 			continue
 		}
+		if !fileconfig.isIncluded(matchedFileName) {
+			continue
+		}
+		if fileconfig.isTooOld(matchedFileInfo.ModTime(), now) {
+			t.Log.Debugf("Skipping %v matched by %v, it was last modified at %v", matchedFileName, filePath, matchedFileInfo.ModTime())
+			continue
+		}
 		if !fileconfig.PublishMultiLogs {
 			if targetFileName == "" || matchedFileInfo.ModTime().After(targetModTime) {
 				targetFileName = matchedFileName
@@ -354,7 +467,7 @@ func (t *LogFile) cleanupStateFolder() {
 			continue
 		}
 
-		if strings.Contains(file, logscommon.WindowsEventLogPrefix) {
+		if strings.Contains(file, logscommon.WindowsEventLogPrefix) || strings.Contains(file, logscommon.JournalStatePrefix) {
 			continue
 		}
 
@@ -9,12 +9,16 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal"
 	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -89,6 +93,50 @@ func TestLogs(t *testing.T) {
 	tt.Stop()
 }
 
+func TestLogsIdleMarker(t *testing.T) {
+	multilineWaitPeriod = 10 * time.Millisecond
+	tmpfile, err := createTempFile("", "")
+	defer os.Remove(tmpfile.Name())
+	require.NoError(t, err)
+	_, err = tmpfile.WriteString("started\n")
+	require.NoError(t, err)
+
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	tt.FileConfig = []FileConfig{{
+		FilePath:      tmpfile.Name(),
+		FromBeginning: true,
+		IdleTimeout:   internal.Duration{Duration: 100 * time.Millisecond},
+		IdleMessage:   "IDLE",
+	}}
+	require.NoError(t, tt.FileConfig[0].init())
+	tt.started = true
+
+	lsrcs := tt.FindLogSrc()
+	require.Len(t, lsrcs, 1)
+	events := make(chan logs.LogEvent, 10)
+	lsrc := lsrcs[0]
+	lsrc.SetOutput(func(e logs.LogEvent) {
+		if e != nil {
+			events <- e
+		}
+	})
+
+	assert.Equal(t, "started", (<-events).Message())
+	// the marker is published again while the file stays idle
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-events:
+			assert.Equal(t, "IDLE", e.Message())
+		case <-time.After(5 * time.Second):
+			t.Fatal("No idle marker was published")
+		}
+	}
+
+	lsrc.Stop()
+	tt.Stop()
+}
+
 func TestLogsEncoding(t *testing.T) {
 	multilineWaitPeriod = 10 * time.Millisecond
 	//2 * rune_len when it is coded in gbk encoding.
@@ -607,6 +655,60 @@ func TestLogsFileTruncate(t *testing.T) {
 	tt.Stop()
 }
 
+func TestLogsFileRewrittenInPlace(t *testing.T) {
+	multilineWaitPeriod = 10 * time.Millisecond
+	lineBeforeRewrite := "lineBeforeRewrite"
+	linesAfterRewrite := []string{"firstLineAfterRewrite", "secondLineAfterRewrite"}
+
+	tmpfile, err := createTempFile("", "")
+	defer os.Remove(tmpfile.Name())
+	require.NoError(t, err)
+
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	tt.FileConfig = []FileConfig{{FilePath: tmpfile.Name(), FromBeginning: true}}
+	tt.FileConfig[0].init()
+	tt.started = true
+
+	lsrcs := tt.FindLogSrc()
+	if len(lsrcs) != 1 {
+		t.Fatalf("%v log src was returned when 1 should be available", len(lsrcs))
+	}
+
+	lsrc := lsrcs[0]
+	evts := make(chan logs.LogEvent)
+	lsrc.SetOutput(func(e logs.LogEvent) {
+		evts <- e
+	})
+
+	go func() {
+		_, err := tmpfile.WriteString(lineBeforeRewrite + "\n")
+		require.NoError(t, err)
+		time.Sleep(1 * time.Second)
+
+		// Truncate the file and write past the previous size before the size is polled again
+		f, err := os.OpenFile(tmpfile.Name(), os.O_RDWR|os.O_TRUNC, 0600)
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = f.WriteString(linesAfterRewrite[0] + "\n" + linesAfterRewrite[1] + "\n")
+		require.NoError(t, err)
+	}()
+
+	e := <-evts
+	if e.Message() != lineBeforeRewrite {
+		t.Errorf("Wrong log found before rewrite: \n%v\nExpecting:\n%v\n", e.Message(), lineBeforeRewrite)
+	}
+	for _, line := range linesAfterRewrite {
+		e = <-evts
+		if e.Message() != line {
+			t.Errorf("Wrong log found after rewrite: \n%v\nExpecting:\n%v\n", e.Message(), line)
+		}
+	}
+
+	lsrc.Stop()
+	tt.Stop()
+}
+
 func TestLogsFileWithOffset(t *testing.T) {
 	multilineWaitPeriod = 10 * time.Millisecond
 	logEntryString := "xxxxxxxxxxContentAfterOffset"
@@ -1045,6 +1147,41 @@ func TestLogFileMultiLogsReadingWithBlacklist(t *testing.T) {
 	tt.Stop()
 }
 
+func TestGetTargetFilesWithIncludeExcludeAndMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "targetfiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"app.log", "app.log.1", "app.log.bak", "other.log", "app.log.2020"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("line\n"), 0644))
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "app.log.2020"), old, old))
+
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	fileConfig := &FileConfig{
+		FilePath:         filepath.Join(dir, "*"),
+		PublishMultiLogs: true,
+		Include:          []string{"app.log*"},
+		Exclude:          []string{"*.bak"},
+		MaxAge:           internal.Duration{Duration: 7 * 24 * time.Hour},
+	}
+	require.NoError(t, fileConfig.init())
+	files, err := tt.getTargetFiles(fileConfig)
+	require.NoError(t, err)
+	sort.Strings(files)
+	assert.Equal(t, []string{filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1")}, files)
+
+	fileConfig.Include = []string{filepath.Join(dir, "other.*")}
+	fileConfig.MaxAge = internal.Duration{}
+	files, err = tt.getTargetFiles(fileConfig)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "other.log")}, files)
+
+	fileConfig.Exclude = []string{"[app"}
+	assert.Error(t, fileConfig.init())
+}
+
 func TestGenerateLogGroupName(t *testing.T) {
 	multilineWaitPeriod = 10 * time.Millisecond
 	fileName := "C:\\tmp\\soak Test\\tmp0.log"
@@ -1063,3 +1200,125 @@ func TestGenerateLogGroupName(t *testing.T) {
 		logGroupName,
 		expectLogGroup))
 }
+
+func TestReplayLogs(t *testing.T) {
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	tt.FileConfig = []FileConfig{
+		{FilePath: "/var/log/app/*.log", Blacklist: "^debug", LogGroupName: "app", LogStreamName: "host"},
+		{FilePath: "/var/log/**.log", LogGroupName: "all", MultiLineStartPattern: "^begin"},
+	}
+
+	var samples []recorder.Sample
+	record := func(filename string, lines ...string) {
+		for _, l := range lines {
+			samples = append(samples, recorder.Sample{Kind: recorder.KindLogLine, Source: filename, Payload: []byte(l)})
+		}
+	}
+	record("/var/log/app/server.log", "line1", "line2")
+	record("/var/log/app/debug.log", "begin1", " append1")
+	record("/tmp/unmatched.log", "skipped")
+	samples = append(samples, recorder.Sample{Kind: recorder.KindStatsd, Source: ":8125", Payload: []byte("skipped:1|c")})
+	record("/var/log/app/server.log", "line3")
+
+	var replayed []string
+	require.NoError(t, tt.ReplayLogs(samples, func(group, stream string, e logs.LogEvent) {
+		replayed = append(replayed, fmt.Sprintf("%v/%v: %v", group, stream, e.Message()))
+	}))
+	assert.Equal(t, []string{
+		"app/host: line1",
+		"app/host: line2",
+		"app/host: line3",
+		"all/: begin1\n append1",
+	}, replayed)
+}
+
+func TestPreflight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app.log"), []byte("line\n"), 0644))
+
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	tt.FileStateFolder = filepath.Join(dir, "state")
+	tt.FileConfig = []FileConfig{
+		{FilePath: filepath.Join(dir, "*.log")},
+		{FilePath: filepath.Join(dir, "*.txt")},
+		{FilePath: filepath.Join(dir, "missing", "**.log")},
+	}
+
+	checks := tt.Preflight()
+	require.Len(t, checks, 4)
+	assert.Equal(t, preflight.KindDirectory, checks[0].Kind)
+	assert.Equal(t, preflight.StatusWarning, checks[0].Status)
+	assert.Equal(t, filepath.Join(dir, "app.log"), checks[1].Path)
+	assert.Equal(t, preflight.StatusOK, checks[1].Status)
+	assert.Equal(t, []string{"no file matches the file_path yet"}, checks[2].Hints)
+	assert.Equal(t, filepath.Join(dir, "missing"), checks[3].Path)
+	assert.Equal(t, preflight.StatusWarning, checks[3].Status)
+}
+
+func TestBackfill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backfill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const layout = "2006-01-02T15:04:05"
+	now := time.Now()
+	line := func(age time.Duration, msg string) string {
+		return now.Add(-age).Format(layout) + " " + msg + "\n"
+	}
+	write := func(name, content string, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	write("app.log", line(2*time.Hour, "recent"), now)
+	write("app.log.1", line(50*time.Hour, "skipped")+line(30*time.Hour, "rotated"), now.Add(-30*time.Hour))
+	write("app.log.2", line(80*time.Hour, "too old"), now.Add(-80*time.Hour))
+	write("app.log.3.gz", "compressed", now)
+	write("other.txt", "unmatched", now)
+
+	tt := NewLogFile()
+	tt.Log = TestLogger{t}
+	tt.FileConfig = []FileConfig{{
+		FilePath:        filepath.Join(dir, "app.log*"),
+		LogGroupName:    "app",
+		TimestampRegex:  `^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})`,
+		TimestampLayout: layout,
+		Timezone:        "Local",
+		AutoRemoval:     true,
+	}}
+
+	srcs, err := tt.Backfill([]string{filepath.Join(dir, "*")}, now.Add(-48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, srcs, 2)
+	// the rotated file first
+	assert.Equal(t, filepath.Join(dir, "app.log.1"), srcs[0].Description())
+	assert.Equal(t, "app", srcs[0].Group())
+
+	var messages []string
+	for _, src := range srcs {
+		done := make(chan struct{})
+		src.SetOutput(func(e logs.LogEvent) {
+			if e == nil {
+				close(done)
+				return
+			}
+			messages = append(messages, e.Message())
+		})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("The backfill of %v has not ended", src.Description())
+		}
+		src.Stop()
+	}
+	assert.Len(t, messages, 2)
+	assert.Contains(t, messages[0], "rotated")
+	assert.Contains(t, messages[1], "recent")
+	// the files are kept despite auto_removal
+	_, err = os.Stat(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+}
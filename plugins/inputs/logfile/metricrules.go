@@ -0,0 +1,199 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/regular"
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// The log metrics are routed to the outputs of the metrics section, as the metrics of its inputs
+	metricPathTag     = "metricPath"
+	metricPathMetrics = "metrics"
+	// maxLogMetricSeries bounds the series of the dimensions of the metric rules of a file config per interval
+	maxLogMetricSeries = 1000
+)
+
+//The metric rule emits a metric from the log entries of the file matching its pattern. The metric counts the matching
+//entries or, with a value group, aggregates the values of the group as a statistic set. The metrics are published
+//through the metrics pipeline every collection interval.
+type MetricRule struct {
+	//The name of the metric.
+	MetricName string `toml:"metric_name"`
+	//The regex matching the log entries, its named groups are referred by the value group and the dimensions.
+	Pattern string `toml:"pattern"`
+	//The named group the value of the metric is parsed from, the metric is the count of the matching entries when empty.
+	ValueGroup string `toml:"value_group"`
+	//The named groups whose values are the dimensions of the metric.
+	Dimensions []string `toml:"dimensions"`
+	//The unit of the values of the metric.
+	Unit string `toml:"unit"`
+
+	patternP   *regexp.Regexp
+	valueIndex int
+	dimIndexes []int
+}
+
+func (r *MetricRule) init() error {
+	if r.MetricName == "" {
+		return fmt.Errorf("metric rule with pattern %v has no metric_name", r.Pattern)
+	}
+	var err error
+	if r.patternP, err = regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("metric rule %v pattern has issue, regexp: Compile( %v ): %v", r.MetricName, r.Pattern, err)
+	}
+	groupIndex := func(name string) (int, error) {
+		for i, n := range r.patternP.SubexpNames() {
+			if n == name && name != "" {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("metric rule %v pattern %v has no group named %v", r.MetricName, r.Pattern, name)
+	}
+	r.valueIndex = -1
+	if r.ValueGroup != "" {
+		if r.valueIndex, err = groupIndex(r.ValueGroup); err != nil {
+			return err
+		}
+	}
+	r.dimIndexes = nil
+	for _, dim := range r.Dimensions {
+		i, err := groupIndex(dim)
+		if err != nil {
+			return err
+		}
+		r.dimIndexes = append(r.dimIndexes, i)
+	}
+	return nil
+}
+
+type logMetricSeries struct {
+	rule   *MetricRule
+	tags   map[string]string
+	count  float64
+	values distribution.Distribution
+}
+
+//The log metrics of the entries of the files of a file config, since the last collection interval.
+type logMetrics struct {
+	rules []MetricRule
+
+	mu              sync.Mutex
+	series          map[string]*logMetricSeries
+	droppedReported bool
+}
+
+func newLogMetrics(rules []MetricRule) (*logMetrics, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	for i := range rules {
+		if err := rules[i].init(); err != nil {
+			return nil, err
+		}
+	}
+	return &logMetrics{rules: rules, series: map[string]*logMetricSeries{}}, nil
+}
+
+//Match the log entry against the metric rules.
+func (lm *logMetrics) match(msg string) {
+	for i := range lm.rules {
+		rule := &lm.rules[i]
+		groups := rule.patternP.FindStringSubmatch(msg)
+		if groups == nil {
+			continue
+		}
+		value := 1.0
+		if rule.valueIndex >= 0 {
+			var err error
+			if value, err = strconv.ParseFloat(strings.TrimSpace(groups[rule.valueIndex]), 64); err != nil {
+				log.Printf("D! [logfile] Metric rule %v cannot parse the value %q: %v", rule.MetricName, groups[rule.valueIndex], err)
+				continue
+			}
+		}
+		key := strconv.Itoa(i)
+		for _, dimIndex := range rule.dimIndexes {
+			key += "\x00" + groups[dimIndex]
+		}
+
+		lm.mu.Lock()
+		s, ok := lm.series[key]
+		if !ok {
+			if len(lm.series) >= maxLogMetricSeries {
+				if !lm.droppedReported {
+					log.Printf("W! [logfile] The metric rules exceed %v dimension values per interval, the entries with new values are not counted", maxLogMetricSeries)
+					lm.droppedReported = true
+				}
+				lm.mu.Unlock()
+				continue
+			}
+			s = &logMetricSeries{rule: rule, tags: map[string]string{}}
+			for j, dimIndex := range rule.dimIndexes {
+				s.tags[rule.Dimensions[j]] = groups[dimIndex]
+			}
+			lm.series[key] = s
+		}
+		if rule.valueIndex < 0 {
+			s.count++
+		} else {
+			if s.values == nil {
+				s.values = newDistribution()
+			}
+			s.values.AddEntryWithUnit(value, 1, rule.Unit)
+		}
+		lm.mu.Unlock()
+	}
+}
+
+//Add the metrics of the interval to the accumulator and reset them. The counts of the rules without dimensions are
+//added when nothing matched too, so that they report 0 rather than missing data.
+func (lm *logMetrics) gather(acc telegraf.Accumulator) {
+	lm.mu.Lock()
+	series := lm.series
+	lm.series = map[string]*logMetricSeries{}
+	lm.droppedReported = false
+	lm.mu.Unlock()
+
+	for i := range lm.rules {
+		rule := &lm.rules[i]
+		key := strconv.Itoa(i)
+		if _, ok := series[key]; !ok && rule.valueIndex < 0 && len(rule.dimIndexes) == 0 {
+			series[key] = &logMetricSeries{rule: rule, tags: map[string]string{}}
+		}
+	}
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := series[key]
+		s.tags[metricPathTag] = metricPathMetrics
+		var value interface{} = s.count
+		if s.values != nil {
+			value = s.values
+		} else if s.rule.valueIndex >= 0 {
+			continue
+		}
+		acc.AddFields(s.rule.MetricName, map[string]interface{}{"value": value}, s.tags)
+	}
+}
+
+func newDistribution() distribution.Distribution {
+	if distribution.NewDistribution == nil {
+		// set by the cloudwatch output, which is missing without a metrics section
+		return regular.NewRegularDistribution()
+	}
+	return distribution.NewDistribution()
+}
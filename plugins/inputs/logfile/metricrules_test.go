@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMetrics(t *testing.T) {
+	lm, err := newLogMetrics([]MetricRule{
+		{MetricName: "ErrorCount", Pattern: `ERROR`},
+		{MetricName: "StatusCount", Pattern: `status=(?P<status>\d{3})`, Dimensions: []string{"status"}},
+		{MetricName: "Latency", Pattern: `latency=(?P<latency>[\d.]+)ms`, ValueGroup: "latency", Unit: "Milliseconds"},
+	})
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"ERROR status=500 latency=12.5ms",
+		"INFO status=200 latency=2ms",
+		"INFO status=200 latency=abc", // counted with status 200, its latency is not parsed
+		"ERROR request failed",
+	} {
+		lm.match(line)
+	}
+	acc := &testutil.Accumulator{}
+	lm.gather(acc)
+
+	errors := acc.Metrics[0]
+	assert.Equal(t, "ErrorCount", errors.Measurement)
+	assert.Equal(t, 2.0, errors.Fields["value"])
+	assert.Equal(t, map[string]string{"metricPath": "metrics"}, errors.Tags)
+
+	assert.Equal(t, "StatusCount", acc.Metrics[1].Measurement)
+	assert.Equal(t, 2.0, acc.Metrics[1].Fields["value"])
+	assert.Equal(t, "200", acc.Metrics[1].Tags["status"])
+	assert.Equal(t, 1.0, acc.Metrics[2].Fields["value"])
+	assert.Equal(t, "500", acc.Metrics[2].Tags["status"])
+
+	latency := acc.Metrics[3].Fields["value"].(distribution.Distribution)
+	assert.Equal(t, "Latency", acc.Metrics[3].Measurement)
+	assert.Equal(t, 2.0, latency.SampleCount())
+	assert.Equal(t, 14.5, latency.Sum())
+	assert.Equal(t, "Milliseconds", latency.Unit())
+	assert.Len(t, acc.Metrics, 4)
+
+	// the count without dimensions is reported as 0 when nothing matched
+	acc.ClearMetrics()
+	lm.gather(acc)
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "ErrorCount", acc.Metrics[0].Measurement)
+	assert.Equal(t, 0.0, acc.Metrics[0].Fields["value"])
+}
+
+func TestLogMetricsInvalidRules(t *testing.T) {
+	_, err := newLogMetrics([]MetricRule{{MetricName: "Count", Pattern: `(`}})
+	assert.Error(t, err)
+	_, err = newLogMetrics([]MetricRule{{MetricName: "Latency", Pattern: `latency=(\d+)`, ValueGroup: "latency"}})
+	assert.Error(t, err)
+	_, err = newLogMetrics([]MetricRule{{MetricName: "Count", Pattern: `status=(?P<status>\d+)`, Dimensions: []string{"code"}}})
+	assert.Error(t, err)
+	_, err = newLogMetrics([]MetricRule{{Pattern: `ERROR`}})
+	assert.Error(t, err)
+	lm, err := newLogMetrics(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, lm)
+}
@@ -0,0 +1,118 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// multilinePattern is a named multi-line start pattern, a line starts a log entry when it matches start and does not
+// match continuation, since the Go regexps have no negative lookahead
+type multilinePattern struct {
+	description  string
+	start        string
+	continuation string
+}
+
+// multilinePatterns are the multi-line start patterns selected by name, e.g. multi_line_start_pattern = "{java}"
+var multilinePatterns = map[string]multilinePattern{
+	"java": {
+		description:  "Java stack traces, the exception, at, Caused by and ... more lines continue the entry",
+		start:        `^\S`,
+		continuation: `^(?:\s|Caused by:|Suppressed:|[\w$.]+(?:Exception|Error|Throwable)(?::|$))`,
+	},
+	"python": {
+		description:  "Python tracebacks, the Traceback header, the frames and the exception line continue the entry",
+		start:        `^\S`,
+		continuation: `^(?:\s|Traceback \(most recent call last\):|During handling of the above exception|The above exception was the direct cause|[\w.]+(?:Error|Exception|Warning|Exit|Interrupt)(?::|$))`,
+	},
+	"go_panic": {
+		description:  "Go panics, the goroutine dumps, the frames and the exit status continue the entry",
+		start:        `^\S`,
+		continuation: `^(?:\s|$|goroutine \d+ \[|created by |exit status \d+|\[signal |[\w./*()%-]+\(.*\)$)`,
+	},
+	"nginx_error": {
+		description: "Nginx error log, an entry starts with its 2006/01/02 15:04:05 [level] prefix",
+		start:       `^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[\w+\]`,
+	},
+}
+
+var multilinePatternNameRegex = regexp.MustCompile(`^\{(\w+)\}$`)
+
+// compileMultilinePattern compiles the named pattern, ok is false when the pattern is not a name
+func compileMultilinePattern(pattern string) (start, continuation *regexp.Regexp, ok bool, err error) {
+	m := multilinePatternNameRegex.FindStringSubmatch(pattern)
+	if m == nil || m[1] == "timestamp_regex" {
+		return nil, nil, false, nil
+	}
+	p, found := multilinePatterns[m[1]]
+	if !found {
+		return nil, nil, true, fmt.Errorf("multi_line_start_pattern %v is not one of %v", pattern, strings.Join(MultilinePatternNames(), ", "))
+	}
+	start = regexp.MustCompile(p.start)
+	if p.continuation != "" {
+		continuation = regexp.MustCompile(p.continuation)
+	}
+	return start, continuation, true, nil
+}
+
+// MultilinePatternNames returns the names of the multi-line start patterns of the library, sorted
+func MultilinePatternNames() []string {
+	names := make([]string, 0, len(multilinePatterns))
+	for name := range multilinePatterns {
+		names = append(names, "{"+name+"}")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintMultilinePatterns prints the names and the descriptions of the multi-line start patterns of the library
+func PrintMultilinePatterns(w io.Writer) {
+	for _, name := range MultilinePatternNames() {
+		fmt.Fprintf(w, "  %-14s %s\n", name, multilinePatterns[strings.Trim(name, "{}")].description)
+	}
+}
+
+// PrintMultilineEntries prints the log entries the lines of the sample are merged into with the multi-line start
+// pattern, a regex or a name of the library, the same way as the lines of the tailed files.
+func PrintMultilineEntries(pattern string, sample io.Reader, w io.Writer) error {
+	config := &FileConfig{MultiLineStartPattern: pattern}
+	if err := config.init(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(sample)
+	scanner.Buffer(make([]byte, 0, 64*1024), defaultMaxEventSize)
+	var entry []string
+	entries, lines, firstLine := 0, 0, 0
+	flush := func() {
+		if len(entry) == 0 {
+			return
+		}
+		entries++
+		fmt.Fprintf(w, "----- entry %d, lines %d-%d\n%s\n", entries, firstLine, firstLine+len(entry)-1, strings.Join(entry, "\n"))
+		entry = nil
+	}
+	for scanner.Scan() {
+		lines++
+		line := scanner.Text()
+		if len(entry) > 0 && config.isMultilineStart(line) {
+			flush()
+		}
+		if len(entry) == 0 {
+			firstLine = lines
+		}
+		entry = append(entry, line)
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "----- %d lines merged into %d entries\n", lines, entries)
+	return nil
+}
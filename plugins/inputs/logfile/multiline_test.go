@@ -0,0 +1,76 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedMultilinePatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		sample  string
+		entries int
+	}{
+		{"{java}", `2020-06-19 14:25:18 ERROR Request failed
+java.lang.IllegalStateException: state
+	at com.example.Service.handle(Service.java:42)
+	at java.lang.Thread.run(Thread.java:748)
+Caused by: java.io.IOException: closed
+	at com.example.Client.read(Client.java:10)
+	... 2 more
+2020-06-19 14:25:19 INFO Next request`, 2},
+		{"{python}", `2020-06-19 14:25:18,234 ERROR Unhandled
+Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ValueError: invalid literal
+
+During handling of the above exception, another exception occurred:
+
+Traceback (most recent call last):
+  File "app.py", line 5, in <module>
+KeyError: 'x'
+2020-06-19 14:25:19,000 INFO Next`, 2},
+		{"{go_panic}", `2020/06/19 14:25:18 serving
+panic: runtime error: index out of range [3] with length 3
+
+goroutine 1 [running]:
+main.main()
+	/src/main.go:8 +0x1d
+exit status 2
+2020/06/19 14:25:19 restarted`, 3},
+		{"{nginx_error}", `2020/06/19 14:25:18 [error] 1234#0: *5 open() "/usr/share/nginx/html/x" failed (2: No such file or directory)
+    while reading the request
+2020/06/19 14:25:19 [warn] 1234#0: *6 an upstream response is buffered`, 2},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		require.NoError(t, PrintMultilineEntries(test.pattern, strings.NewReader(test.sample), &out), test.pattern)
+		assert.Equal(t, test.entries, strings.Count(out.String(), "----- entry "), test.pattern+"\n"+out.String())
+	}
+}
+
+func TestPrintMultilineEntries(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, PrintMultilineEntries("^START", strings.NewReader("first\nSTART a\nb\nc\nSTART d\n"), &out))
+	assert.Equal(t, "----- entry 1, lines 1-1\nfirst\n----- entry 2, lines 2-4\nSTART a\nb\nc\n----- entry 3, lines 5-5\nSTART d\n----- 5 lines merged into 3 entries\n", out.String())
+
+	assert.Error(t, PrintMultilineEntries("{ruby}", strings.NewReader(""), &out))
+	assert.Error(t, PrintMultilineEntries("(", strings.NewReader(""), &out))
+}
+
+func TestFileConfigWithNamedMultilinePattern(t *testing.T) {
+	fileConfig := &FileConfig{FilePath: "/tmp/logfile.log", MultiLineStartPattern: "{java}"}
+	require.NoError(t, fileConfig.init())
+	assert.True(t, fileConfig.isMultilineStart("2020-06-19 14:25:18 ERROR Request failed"))
+	assert.False(t, fileConfig.isMultilineStart("java.lang.NullPointerException"))
+	assert.False(t, fileConfig.isMultilineStart("\tat com.example.Service.handle(Service.java:42)"))
+	assert.False(t, fileConfig.isMultilineStart("Caused by: java.io.IOException: closed"))
+}
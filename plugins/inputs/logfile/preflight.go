@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+)
+
+// LogGroups returns the log groups of the files, except the ones named after the files or their systemd units
+func (t *LogFile) LogGroups() []preflight.LogGroup {
+	var groups []preflight.LogGroup
+	for _, fileconfig := range t.FileConfig {
+		if fileconfig.LogGroupName == "" {
+			continue
+		}
+		destination := fileconfig.Destination
+		if destination == "" {
+			destination = t.Destination
+		}
+		groups = append(groups, preflight.LogGroup{Name: fileconfig.LogGroupName, Destination: destination})
+	}
+	return groups
+}
+
+// Preflight checks that the state folder is writable and that the files which would be tailed can be opened.
+// When no file matches a file_path yet, the directory it starts from is checked instead.
+func (t *LogFile) Preflight() []preflight.Check {
+	var checks []preflight.Check
+	if t.FileStateFolder != "" {
+		checks = append(checks, preflight.CheckWritableDirectory(t.FileStateFolder))
+	}
+	for i := range t.FileConfig {
+		fileconfig := &t.FileConfig[i]
+		if err := fileconfig.init(); err != nil {
+			checks = append(checks, preflight.Failed(preflight.KindFile, fileconfig.FilePath, err))
+			continue
+		}
+		filenames, err := t.getTargetFiles(fileconfig)
+		if err != nil {
+			checks = append(checks, preflight.Failed(preflight.KindFile, fileconfig.FilePath, err))
+			continue
+		}
+		for _, filename := range filenames {
+			checks = append(checks, preflight.CheckFile(filename))
+		}
+		if len(filenames) > 0 {
+			continue
+		}
+		if !strings.ContainsAny(fileconfig.FilePath, "*?[") {
+			checks = append(checks, preflight.CheckFile(fileconfig.FilePath))
+			continue
+		}
+		check := preflight.CheckDirectory(globRoot(fileconfig.FilePath))
+		if check.Status == preflight.StatusOK {
+			check = preflight.Warning(preflight.KindFile, fileconfig.FilePath, "no file matches the file_path yet")
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// globRoot returns the closest directory of the glob without glob meta characters
+func globRoot(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
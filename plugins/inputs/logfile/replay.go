@@ -0,0 +1,85 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/globpath"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/tail"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
+)
+
+// ReplayLogs runs the recorded log lines through the file config matching the file they were read from,
+// the same way as they are when tailing the file, and passes the resulting log events to the output.
+// Lines of files not matched by any file config are skipped. Nothing is read from or saved on disk.
+func (t *LogFile) ReplayLogs(samples []recorder.Sample, output func(group, stream string, e logs.LogEvent)) error {
+	for i := range t.FileConfig {
+		if err := t.FileConfig[i].init(); err != nil {
+			return err
+		}
+	}
+
+	lines := make(map[string][]recorder.Sample)
+	var filenames []string
+	for _, s := range recorder.SamplesOfKind(samples, recorder.KindLogLine) {
+		if _, ok := lines[s.Source]; !ok {
+			filenames = append(filenames, s.Source)
+		}
+		lines[s.Source] = append(lines[s.Source], s)
+	}
+
+	for _, filename := range filenames {
+		fileconfig, err := t.matchFileConfig(filename)
+		if err != nil {
+			return err
+		}
+		if fileconfig == nil {
+			t.Log.Infof("No file config matches %v, skipping its %v recorded lines", filename, len(lines[filename]))
+			continue
+		}
+
+		tailer := &tail.Tail{Filename: filename, Lines: make(chan *tail.Line)}
+		src := t.newTailerSrc(fileconfig, filename, "", tailer)
+		finished := make(chan struct{})
+		src.SetOutput(func(e logs.LogEvent) {
+			if e == nil {
+				close(finished)
+				return
+			}
+			output(src.Group(), src.Stream(), e)
+		})
+
+		var offset int64
+		for _, s := range lines[filename] {
+			offset += int64(len(s.Payload)) + 1
+			tailer.Lines <- &tail.Line{Text: string(s.Payload), Time: s.Time, Offset: offset}
+		}
+		close(tailer.Lines)
+		<-finished
+		src.Stop()
+	}
+	return nil
+}
+
+// matchFileConfig returns the first file config whose file_path matches the file name and whose blacklist does not
+func (t *LogFile) matchFileConfig(filename string) (*FileConfig, error) {
+	for i := range t.FileConfig {
+		fileconfig := &t.FileConfig[i]
+		g, err := globpath.Compile(fileconfig.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("file_path glob %s failed to compile, %s", fileconfig.FilePath, err)
+		}
+		if !g.MatchString(filename) {
+			continue
+		}
+		if fileconfig.BlacklistRegexP != nil && fileconfig.BlacklistRegexP.MatchString(filepath.Base(filename)) {
+			continue
+		}
+		return fileconfig, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+)
+
+const (
+	replicaFilterInclude = "include"
+	replicaFilterExclude = "exclude"
+	replicaFilterReplace = "replace"
+)
+
+// The replica sends a copy of the log events of the file to another log group, e.g. only the errors to the group
+// alarmed on by the on-call, through its own filters.
+type ReplicaConfig struct {
+	//The log group of the replica, required.
+	LogGroupName string `toml:"log_group_name"`
+	//The log stream of the replica, the log stream of the file when empty.
+	LogStreamName string `toml:"log_stream_name"`
+	//The destination of the replica, the destination of the file when empty.
+	Destination string `toml:"destination"`
+	//The filters of the log events sent to the replica, applied in order.
+	Filters []ReplicaFilter `toml:"filters"`
+}
+
+// The replica filter keeps the log events matching its expression ("include"), drops them ("exclude"), or replaces
+// the matches of the expression in their messages ("replace").
+type ReplicaFilter struct {
+	Type        string `toml:"type"`
+	Expression  string `toml:"expression"`
+	Replacement string `toml:"replacement"`
+
+	expressionP *regexp.Regexp
+}
+
+func (r *ReplicaConfig) init() error {
+	if r.LogGroupName == "" {
+		return fmt.Errorf("replica has no log_group_name")
+	}
+	for i := range r.Filters {
+		f := &r.Filters[i]
+		switch f.Type {
+		case replicaFilterInclude, replicaFilterExclude, replicaFilterReplace:
+		default:
+			return fmt.Errorf("replica %v filter type %v is not include, exclude or replace", r.LogGroupName, f.Type)
+		}
+		var err error
+		if f.expressionP, err = regexp.Compile(f.Expression); err != nil {
+			return fmt.Errorf("replica %v filter expression has issue, regexp: Compile( %v ): %v", r.LogGroupName, f.Expression, err)
+		}
+	}
+	return nil
+}
+
+// filter returns the message of the log event as it is sent to the replica, or false when it is filtered out.
+func (r *ReplicaConfig) filter(message string) (string, bool) {
+	for _, f := range r.Filters {
+		switch f.Type {
+		case replicaFilterInclude:
+			if !f.expressionP.MatchString(message) {
+				return "", false
+			}
+		case replicaFilterExclude:
+			if f.expressionP.MatchString(message) {
+				return "", false
+			}
+		case replicaFilterReplace:
+			message = f.expressionP.ReplaceAllString(message, f.Replacement)
+		}
+	}
+	return message, true
+}
+
+// replicas returns the replicas of the log src of the file config, whose log stream is the one given.
+func (config *FileConfig) replicas(stream string) []logs.Replica {
+	if len(config.Replicas) == 0 {
+		return nil
+	}
+	replicas := make([]logs.Replica, 0, len(config.Replicas))
+	for i := range config.Replicas {
+		r := &config.Replicas[i]
+		replica := logs.Replica{Group: r.LogGroupName, Stream: r.LogStreamName, Destination: r.Destination}
+		if replica.Stream == "" {
+			replica.Stream = stream
+		}
+		if len(r.Filters) > 0 {
+			replica.Filter = r.filter
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
@@ -0,0 +1,63 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicas(t *testing.T) {
+	config := &FileConfig{
+		FilePath: "/tmp/app.log",
+		Replicas: []ReplicaConfig{
+			{LogGroupName: "ops"},
+			{
+				LogGroupName:  "errors",
+				LogStreamName: "pager",
+				Destination:   "cloudwatchlogs",
+				Filters: []ReplicaFilter{
+					{Type: "include", Expression: `ERROR|FATAL`},
+					{Type: "exclude", Expression: `healthcheck`},
+					{Type: "replace", Expression: `password=\S+`, Replacement: "password=***"},
+				},
+			},
+		},
+	}
+	require.NoError(t, config.init())
+
+	replicas := config.replicas("host")
+	require.Len(t, replicas, 2)
+	assert.Equal(t, "ops", replicas[0].Group)
+	assert.Equal(t, "host", replicas[0].Stream)
+	assert.Equal(t, "", replicas[0].Destination)
+	assert.Nil(t, replicas[0].Filter)
+
+	assert.Equal(t, "errors", replicas[1].Group)
+	assert.Equal(t, "pager", replicas[1].Stream)
+	assert.Equal(t, "cloudwatchlogs", replicas[1].Destination)
+	filter := replicas[1].Filter
+	_, ok := filter("INFO started")
+	assert.False(t, ok)
+	_, ok = filter("ERROR healthcheck failed")
+	assert.False(t, ok)
+	message, ok := filter("ERROR login failed password=secret user=bob")
+	assert.True(t, ok)
+	assert.Equal(t, "ERROR login failed password=*** user=bob", message)
+
+	assert.Nil(t, (&FileConfig{}).replicas("host"))
+}
+
+func TestReplicasInvalidConfig(t *testing.T) {
+	for name, replica := range map[string]ReplicaConfig{
+		"no log group":    {Filters: []ReplicaFilter{{Type: "include", Expression: "ERROR"}}},
+		"unknown type":    {LogGroupName: "errors", Filters: []ReplicaFilter{{Type: "drop", Expression: "ERROR"}}},
+		"invalid pattern": {LogGroupName: "errors", Filters: []ReplicaFilter{{Type: "include", Expression: "(ERROR"}}},
+	} {
+		config := &FileConfig{FilePath: "/tmp/app.log", Replicas: []ReplicaConfig{replica}}
+		assert.Error(t, config.init(), name)
+	}
+}
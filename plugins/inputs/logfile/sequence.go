@@ -0,0 +1,85 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// streamSequence numbers the structured log events of a log stream, shared by the files of the stream
+type streamSequence struct {
+	last int64
+}
+
+// next returns the number of the next log event of the stream
+func (s *streamSequence) next() int64 {
+	return atomic.AddInt64(&s.last, 1)
+}
+
+// resume moves the sequence to the number saved in the state of a file of the stream, unless it is past it
+func (s *streamSequence) resume(saved int64) {
+	for {
+		last := atomic.LoadInt64(&s.last)
+		if saved <= last || atomic.CompareAndSwapInt64(&s.last, last, saved) {
+			return
+		}
+	}
+}
+
+// streamSequence returns the sequence of the log stream, resumed from the number saved for one of its files
+func (t *LogFile) streamSequence(group, stream string, saved int64) *streamSequence {
+	if t.sequences == nil {
+		t.sequences = make(map[string]*streamSequence)
+	}
+	key := group + "/" + stream
+	s, ok := t.sequences[key]
+	if !ok {
+		s = &streamSequence{}
+		t.sequences[key] = s
+	}
+	s.resume(saved)
+	return s
+}
+
+// restoreSequence returns the sequence number of the last log event of the file saved in its state, the third line
+// of the state file, 0 when it has none
+func (t *LogFile) restoreSequence(filename string) int64 {
+	filePath := t.getStateFilePath(filename)
+	if filePath == "" {
+		return 0
+	}
+	byteArray, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return 0
+	}
+	lines := strings.Split(string(byteArray), "\n")
+	if len(lines) < 3 {
+		return 0
+	}
+	seq, err := strconv.ParseInt(lines[2], 10, 64)
+	if err != nil {
+		t.Log.Warnf("Issue encountered when parsing sequence value %v of %s: %v", lines[2], filename, err)
+		return 0
+	}
+	return seq
+}
+
+// isJSONObject returns whether the message is a JSON object, as the JSON envelopes and the parsed log entries are
+func isJSONObject(msg string) bool {
+	return len(msg) >= 2 && msg[0] == '{' && msg[len(msg)-1] == '}'
+}
+
+// withSequence adds the field of the sequence number to the JSON object of the message
+func withSequence(msg, field string, seq int64) string {
+	key, _ := json.Marshal(field)
+	member := string(key) + ":" + strconv.FormatInt(seq, 10)
+	if strings.TrimSpace(msg[1:len(msg)-1]) == "" {
+		return "{" + member + "}"
+	}
+	return msg[:len(msg)-1] + "," + member + "}"
+}
@@ -0,0 +1,97 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/tail"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSequence(t *testing.T) {
+	assert.True(t, isJSONObject(`{"a":1}`))
+	assert.False(t, isJSONObject("plain text"))
+	assert.False(t, isJSONObject("{"))
+	assert.Equal(t, `{"a":1,"seq":7}`, withSequence(`{"a":1}`, "seq", 7))
+	assert.Equal(t, `{"seq":7}`, withSequence(`{ }`, "seq", 7))
+	assert.Equal(t, `{"a":1,"my \"seq\"":7}`, withSequence(`{"a":1}`, `my "seq"`, 7))
+}
+
+func TestSequenceSurvivesRestart(t *testing.T) {
+	multilineWaitPeriod = 100 * time.Millisecond
+	dir, err := ioutil.TempDir("", "sequence")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("first\n{\"level\":\"info\"}\nthird\n"), 0644))
+
+	// tail reads the file from the saved offset and returns the sequence numbers of the events, and the state saved
+	tail := func(events int) ([]interface{}, string) {
+		lf := &LogFile{FileStateFolder: dir, Log: models.NewLogger("inputs", "logfile", "")}
+		fc := &FileConfig{FilePath: filename, EventFormat: eventFormatJSONEnvelope, SequenceField: "ingest_sequence"}
+		require.NoError(t, fc.init())
+		offset, _ := lf.restoreState(filename)
+		tailer, err := tail.TailFile(filename, tail.Config{
+			Follow:      true,
+			Location:    &tail.SeekInfo{Whence: io.SeekStart, Offset: offset},
+			MustExist:   true,
+			Poll:        true,
+			MaxLineSize: defaultMaxEventSize,
+		})
+		require.NoError(t, err)
+		src := lf.newTailerSrc(fc, filename, lf.getStateFilePath(filename), tailer)
+
+		received := make(chan logs.LogEvent, 10)
+		src.SetOutput(func(e logs.LogEvent) {
+			if e != nil {
+				received <- e
+			}
+		})
+		var seqs []interface{}
+		for i := 0; i < events; i++ {
+			select {
+			case e := <-received:
+				var m map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(e.Message()), &m))
+				seqs = append(seqs, m["ingest_sequence"])
+				e.Done()
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "the log events were not all received")
+			}
+		}
+		// the offset of the last log event is saved
+		time.Sleep(300 * time.Millisecond)
+		state, err := ioutil.ReadFile(lf.getStateFilePath(filename))
+		require.NoError(t, err)
+		src.Stop()
+		tailer.Stop()
+		// the state is saved once more when the src stops
+		time.Sleep(100 * time.Millisecond)
+		return seqs, string(state)
+	}
+
+	seqs, state := tail(3)
+	assert.Equal(t, []interface{}{1.0, 2.0, 3.0}, seqs)
+	assert.Equal(t, []string{"29", filename, "3"}, strings.Split(state, "\n"))
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	fmt.Fprintln(f, "fourth")
+	f.Close()
+	seqs, state = tail(1)
+	assert.Equal(t, []interface{}{4.0}, seqs)
+	assert.Equal(t, []string{"36", filename, "4"}, strings.Split(state, "\n"))
+}
@@ -0,0 +1,501 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+)
+
+// unitDiscoveryInterval is how often the units matching the patterns and their outputs are listed again
+const unitDiscoveryInterval = time.Minute
+
+var (
+	// systemctl runs systemctl and returns its output, replaced in the tests
+	systemctl = func(args ...string) ([]byte, error) {
+		return exec.Command("systemctl", args...).Output()
+	}
+	// journalctl returns the command of journalctl, replaced in the tests
+	journalctl = func(args ...string) *exec.Cmd {
+		return exec.Command("journalctl", args...)
+	}
+)
+
+// unitOutput is where the standard output and error of a systemd unit go, the files they are appended to and
+// whether they go to the journal
+type unitOutput struct {
+	unit    string
+	files   []string
+	journal bool
+}
+
+// unitDiscovery finds the outputs of the systemd units matching the patterns, e.g. nginx*, so that the logs of the
+// units are collected wherever the distro puts them
+type unitDiscovery struct {
+	patterns []string
+
+	last     time.Time
+	outputs  []unitOutput
+	reported bool
+}
+
+func newUnitDiscovery(patterns []string) (*unitDiscovery, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("systemd unit pattern %v has issue: %v", pattern, err)
+		}
+	}
+	return &unitDiscovery{patterns: patterns}, nil
+}
+
+// discover returns the outputs of the units, they are listed again once the discovery interval has passed. The
+// error of the first failed discovery is returned, the next ones are not to be reported again.
+func (d *unitDiscovery) discover(now time.Time) ([]unitOutput, error) {
+	if !d.last.IsZero() && now.Sub(d.last) < unitDiscoveryInterval {
+		return d.outputs, nil
+	}
+	d.last = now
+	outputs, err := listUnitOutputs(d.patterns)
+	if err != nil {
+		if d.reported {
+			return d.outputs, nil
+		}
+		d.reported = true
+		return d.outputs, err
+	}
+	d.outputs = outputs
+	return outputs, nil
+}
+
+// unitOf returns the unit whose output is the file, or ""
+func (d *unitDiscovery) unitOf(filename string) string {
+	for _, o := range d.outputs {
+		for _, f := range o.files {
+			if f == filename {
+				return o.unit
+			}
+		}
+	}
+	return ""
+}
+
+// listUnitOutputs lists the loaded units matching the patterns and the outputs of their services
+func listUnitOutputs(patterns []string) ([]unitOutput, error) {
+	out, err := systemctl(append([]string{"list-units", "--all", "--plain", "--no-legend", "--no-pager", "--"}, patterns...)...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the systemd units %v: %v", patterns, err)
+	}
+	units := parseUnitList(out)
+	if len(units) == 0 {
+		return nil, nil
+	}
+	out, err = systemctl(append([]string{"show", "--no-pager",
+		"--property=Id,StandardOutput,StandardOutputFileDescriptorName,StandardError,StandardErrorFileDescriptorName", "--"},
+		units...)...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to show the outputs of the systemd units %v: %v", units, err)
+	}
+	return parseUnitOutputs(out), nil
+}
+
+// parseUnitList returns the names of the units listed by systemctl list-units --plain --no-legend, the first column
+func parseUnitList(out []byte) []string {
+	var units []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			units = append(units, fields[0])
+		}
+	}
+	sort.Strings(units)
+	return units
+}
+
+// parseUnitOutputs returns the outputs of the units shown by systemctl show, the properties of the units are
+// separated by empty lines. The path of the file outputs is the file descriptor name of the output.
+func parseUnitOutputs(out []byte) []unitOutput {
+	var outputs []unitOutput
+	for _, block := range strings.Split(string(out), "\n\n") {
+		props := map[string]string{}
+		for _, line := range strings.Split(block, "\n") {
+			if i := strings.IndexByte(line, '='); i > 0 {
+				props[line[:i]] = line[i+1:]
+			}
+		}
+		if props["Id"] == "" {
+			continue
+		}
+		o := unitOutput{unit: props["Id"]}
+		stdout := props["StandardOutput"]
+		o.add(stdout, props["StandardOutputFileDescriptorName"])
+		if stderr := props["StandardError"]; stderr == "inherit" {
+			// the standard error goes where the standard output goes
+			o.add(stdout, props["StandardOutputFileDescriptorName"])
+		} else {
+			o.add(stderr, props["StandardErrorFileDescriptorName"])
+		}
+		if o.journal || len(o.files) > 0 {
+			outputs = append(outputs, o)
+		}
+	}
+	return outputs
+}
+
+// add records the output of the kind, e.g. journal+console, or append with the path of the file
+func (o *unitOutput) add(kind, file string) {
+	switch {
+	case strings.HasPrefix(kind, "journal"), strings.HasPrefix(kind, "kmsg"):
+		o.journal = true
+	case kind == "file", kind == "append", kind == "truncate":
+		if file == "" {
+			return
+		}
+		for _, f := range o.files {
+			if f == file {
+				return
+			}
+		}
+		o.files = append(o.files, file)
+	}
+}
+
+// unitOutputs returns the outputs of the systemd units of the file config, without any units
+func (t *LogFile) unitOutputs(fileconfig *FileConfig) []unitOutput {
+	if fileconfig.units == nil {
+		return nil
+	}
+	outputs, err := fileconfig.units.discover(time.Now())
+	if err != nil {
+		t.Log.Errorf("Failed to find the outputs of the systemd units %v: %v", fileconfig.SystemdUnits, err)
+	}
+	return outputs
+}
+
+// appendUnitFiles appends the existing output files of the units which are not among the target files yet
+func appendUnitFiles(targetFiles []string, outputs []unitOutput) []string {
+	for _, o := range outputs {
+		for _, f := range o.files {
+			if _, err := os.Stat(f); err != nil {
+				continue
+			}
+			found := false
+			for _, target := range targetFiles {
+				found = found || target == f
+			}
+			if !found {
+				targetFiles = append(targetFiles, f)
+			}
+		}
+	}
+	return targetFiles
+}
+
+// findJournalSrcs returns the log srcs of the journal streams of the systemd units of the file config which are not
+// read yet
+func (t *LogFile) findJournalSrcs(fileconfig *FileConfig, outputs []unitOutput) []logs.LogSrc {
+	if t.journals == nil {
+		t.journals = map[*FileConfig]map[string]*journalSrc{}
+	}
+	srcs, ok := t.journals[fileconfig]
+	if !ok {
+		srcs = map[string]*journalSrc{}
+		t.journals[fileconfig] = srcs
+	}
+
+	var found []logs.LogSrc
+	for _, o := range outputs {
+		if !o.journal {
+			continue
+		}
+		if src, ok := srcs[o.unit]; ok {
+			select {
+			case <-src.exited:
+				// journalctl exited, the journal is read again after the discovery interval
+				if time.Since(src.exitedAt) < unitDiscoveryInterval {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		group, stream, destination := t.srcTarget(fileconfig, o.unit)
+		if group == "" {
+			group = o.unit
+		}
+		src := &journalSrc{
+			unit:          o.unit,
+			group:         group,
+			stream:        stream,
+			destination:   destination,
+			stateFilePath: t.getStateFilePath(logscommon.JournalStatePrefix + o.unit),
+			fromBeginning: fileconfig.FromBeginning,
+			maxEventSize:  fileconfig.MaxEventSize,
+			truncSuffix:   fileconfig.TruncateSuffix,
+			priority:      fileconfig.Priority,
+			cursorCh:      make(chan journalCursor, 2000),
+			done:          make(chan struct{}),
+			exited:        make(chan struct{}),
+		}
+		srcs[o.unit] = src
+		found = append(found, src)
+	}
+	return found
+}
+
+// journalCursor is the position of a log event in the journal, n orders the log events read by the src
+type journalCursor struct {
+	n      int64
+	cursor string
+}
+
+// journalSrc reads the journal stream of a systemd unit with journalctl, the cursor of the last log event accepted
+// by the destination is saved in the state file so that the journal is read again from it after a restart
+type journalSrc struct {
+	unit                       string
+	group, stream, destination string
+	stateFilePath              string
+	fromBeginning              bool
+	maxEventSize               int
+	truncSuffix                string
+	priority                   string
+
+	outputFn  func(logs.LogEvent)
+	cursorCh  chan journalCursor
+	done      chan struct{}
+	exited    chan struct{}
+	exitedAt  time.Time
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+func (js *journalSrc) SetOutput(fn func(logs.LogEvent)) {
+	if fn == nil {
+		return
+	}
+	js.outputFn = fn
+	js.startOnce.Do(func() {
+		go js.runSaveState()
+		go js.run()
+	})
+}
+
+func (js *journalSrc) Group() string       { return js.group }
+func (js *journalSrc) Stream() string      { return js.stream }
+func (js *journalSrc) Destination() string { return js.destination }
+func (js *journalSrc) Description() string { return "journal of " + js.unit }
+
+// Priority returns the priority class of the log events of the unit, "" for the default one
+func (js *journalSrc) Priority() string { return js.priority }
+
+func (js *journalSrc) Stop() {
+	js.stopOnce.Do(func() { close(js.done) })
+}
+
+// Done keeps the cursor of the log event, dropped if the state is not saved fast enough
+func (js *journalSrc) Done(c journalCursor) {
+	select {
+	case js.cursorCh <- c:
+	default:
+	}
+}
+
+// Ack advances the cursor to the last log event of the accepted batch
+func (js *journalSrc) Ack(events []logs.LogEvent) {
+	var last journalCursor
+	for _, e := range events {
+		if je, ok := e.(*journalEvent); ok {
+			last = je.cursor
+		}
+	}
+	select {
+	case js.cursorCh <- last:
+	case <-js.done:
+	}
+}
+
+// args returns the arguments of journalctl, the journal is followed from the saved cursor, from its beginning or
+// from its end
+func (js *journalSrc) args() []string {
+	args := []string{"--unit=" + js.unit, "--follow", "--output=json", "--no-pager", "--quiet"}
+	if cursor := js.restoreState(); cursor != "" {
+		return append(args, "--after-cursor="+cursor)
+	}
+	if js.fromBeginning {
+		return append(args, "--lines=all")
+	}
+	return append(args, "--lines=0")
+}
+
+func (js *journalSrc) run() {
+	defer func() {
+		js.exitedAt = time.Now()
+		close(js.exited)
+	}()
+	defer js.outputFn(nil) // inform logs agent the journal src's exit, to stop runSrcToDest
+
+	cmd := journalctl(js.args()...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Printf("E! [logfile] Unable to read the journal of %v: %v", js.unit, err)
+		return
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Start(); err != nil {
+		log.Printf("E! [logfile] Unable to read the journal of %v: %v", js.unit, err)
+		return
+	}
+	go func() {
+		<-js.done
+		cmd.Process.Kill()
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 2*js.maxEventSize+64*1024)
+	var n int64
+	for scanner.Scan() {
+		e, err := parseJournalEntry(scanner.Bytes(), js.maxEventSize, js.truncSuffix)
+		if err != nil {
+			log.Printf("W! [logfile] Skipping a journal entry of %v: %v", js.unit, err)
+			continue
+		}
+		if e == nil {
+			continue
+		}
+		n++
+		e.cursor.n = n
+		e.src = js
+		js.outputFn(e)
+	}
+	if err = scanner.Err(); err != nil {
+		log.Printf("E! [logfile] Error reading the journal of %v: %v", js.unit, err)
+	}
+	if err = cmd.Wait(); err != nil {
+		select {
+		case <-js.done:
+		default:
+			log.Printf("E! [logfile] journalctl of %v exited: %v %s", js.unit, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+}
+
+func (js *journalSrc) runSaveState() {
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+
+	var cursor, lastSaved journalCursor
+	for {
+		select {
+		case c := <-js.cursorCh:
+			if c.n > cursor.n {
+				cursor = c
+			}
+		case <-t.C:
+			if cursor == lastSaved {
+				continue
+			}
+			if err := js.saveState(cursor); err != nil {
+				log.Printf("E! [logfile] Error happened when saving the journal state of %s to %s: %v", js.unit, js.stateFilePath, err)
+				continue
+			}
+			lastSaved = cursor
+		case <-js.done:
+			if err := js.saveState(cursor); err != nil {
+				log.Printf("E! [logfile] Error happened during final journal state saving of %s to %s, duplicate log maybe sent at next start: %v", js.unit, js.stateFilePath, err)
+			}
+			return
+		}
+	}
+}
+
+func (js *journalSrc) saveState(c journalCursor) error {
+	if js.stateFilePath == "" || c.cursor == "" {
+		return nil
+	}
+	return ioutil.WriteFile(js.stateFilePath, []byte(c.cursor+"\n"+js.unit), stateFileMode)
+}
+
+// restoreState returns the cursor saved in the state file, "" without one
+func (js *journalSrc) restoreState() string {
+	if js.stateFilePath == "" {
+		return ""
+	}
+	byteArray, err := ioutil.ReadFile(js.stateFilePath)
+	if err != nil {
+		return ""
+	}
+	return strings.Split(string(byteArray), "\n")[0]
+}
+
+// journalEvent is a log event of the journal
+type journalEvent struct {
+	msg    string
+	t      time.Time
+	cursor journalCursor
+	src    *journalSrc
+}
+
+func (je *journalEvent) Message() string { return je.msg }
+func (je *journalEvent) Time() time.Time { return je.t }
+func (je *journalEvent) Done()           { je.src.Done(je.cursor) }
+
+// Acker returns the journal src, which saves the cursor of the last log event of the batches accepted by the
+// destination
+func (je *journalEvent) Acker() logs.Acker {
+	if je.src == nil {
+		return nil
+	}
+	return je.src
+}
+
+// parseJournalEntry returns the log event of an entry of journalctl --output=json, nil for the entries without a
+// message. The message is a string, or an array of bytes when it is not valid UTF-8.
+func parseJournalEntry(line []byte, maxEventSize int, truncateSuffix string) (*journalEvent, error) {
+	var entry struct {
+		Message  json.RawMessage `json:"MESSAGE"`
+		Realtime string          `json:"__REALTIME_TIMESTAMP"`
+		Cursor   string          `json:"__CURSOR"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, err
+	}
+	var msg string
+	if err := json.Unmarshal(entry.Message, &msg); err != nil {
+		var raw []byte
+		var ints []int
+		if err := json.Unmarshal(entry.Message, &ints); err != nil {
+			return nil, nil
+		}
+		for _, i := range ints {
+			raw = append(raw, byte(i))
+		}
+		msg = string(raw)
+	}
+	if msg == "" {
+		return nil, nil
+	}
+	if maxEventSize > 0 && len(msg) > maxEventSize {
+		msg = msg[:maxEventSize-len(truncateSuffix)] + truncateSuffix
+	}
+	e := &journalEvent{msg: msg, cursor: journalCursor{cursor: entry.Cursor}}
+	if usec, err := strconv.ParseInt(entry.Realtime, 10, 64); err == nil {
+		e.t = time.Unix(0, usec*int64(time.Microsecond))
+	}
+	return e, nil
+}
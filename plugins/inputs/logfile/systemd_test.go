@@ -0,0 +1,151 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logfile
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnitOutputs(t *testing.T) {
+	assert.Equal(t, []string{"nginx.service", "nginx@a.service"},
+		parseUnitList([]byte("nginx@a.service loaded active running A\nnginx.service loaded inactive dead B\n")))
+
+	outputs := parseUnitOutputs([]byte(`Id=nginx.service
+StandardOutput=journal
+StandardOutputFileDescriptorName=stdout
+StandardError=inherit
+StandardErrorFileDescriptorName=stderr
+
+Id=app.service
+StandardOutput=append
+StandardOutputFileDescriptorName=/var/log/app.log
+StandardError=file
+StandardErrorFileDescriptorName=/var/log/app.err
+
+Id=quiet.service
+StandardOutput=null
+StandardOutputFileDescriptorName=stdout
+StandardError=inherit
+StandardErrorFileDescriptorName=stderr
+`))
+	assert.Equal(t, []unitOutput{
+		{unit: "nginx.service", journal: true},
+		{unit: "app.service", files: []string{"/var/log/app.log", "/var/log/app.err"}},
+	}, outputs)
+}
+
+func TestSystemdUnitsDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "systemd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "app.log")
+	require.NoError(t, ioutil.WriteFile(logFile, []byte("started\n"), 0644))
+
+	var calls [][]string
+	defer func(f func(...string) ([]byte, error)) { systemctl = f }(systemctl)
+	systemctl = func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "list-units" {
+			return []byte("app.service loaded active running App\nweb.service loaded active running Web\n"), nil
+		}
+		return []byte("Id=app.service\nStandardOutput=append\nStandardOutputFileDescriptorName=" + logFile +
+			"\nStandardError=inherit\n\nId=web.service\nStandardOutput=journal\nStandardError=inherit\n"), nil
+	}
+
+	lf := NewLogFile()
+	lf.Log = models.NewLogger("inputs", "logfile", "")
+	lf.FileStateFolder = dir
+	lf.FileConfig = []FileConfig{{SystemdUnits: []string{"app*", "web*"}, LogStreamName: "host"}}
+	require.NoError(t, lf.Start(nil))
+	defer lf.Stop()
+
+	srcs := lf.FindLogSrc()
+	require.Len(t, srcs, 2)
+	assert.Equal(t, []string{"list-units", "--all", "--plain", "--no-legend", "--no-pager", "--", "app*", "web*"}, calls[0])
+	var groups []string
+	for _, src := range srcs {
+		groups = append(groups, src.Group()+" "+src.Description())
+		assert.Equal(t, "host", src.Stream())
+		defer src.Stop()
+	}
+	assert.ElementsMatch(t, []string{"web.service journal of web.service", "app.service " + logFile}, groups)
+
+	// the units are not listed again before the discovery interval
+	assert.Empty(t, lf.FindLogSrc())
+	assert.Len(t, calls, 2)
+}
+
+func TestJournalSrc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	entries := filepath.Join(dir, "entries")
+	require.NoError(t, ioutil.WriteFile(entries, []byte(strings.Join([]string{
+		`{"MESSAGE":"first","__REALTIME_TIMESTAMP":"1600000000000000","__CURSOR":"s=1"}`,
+		`{"MESSAGE":[104,105,255],"__REALTIME_TIMESTAMP":"1600000001000000","__CURSOR":"s=2"}`,
+		`{"__REALTIME_TIMESTAMP":"1600000002000000","__CURSOR":"s=3"}`,
+	}, "\n")), 0644))
+
+	var args []string
+	defer func(f func(...string) *exec.Cmd) { journalctl = f }(journalctl)
+	journalctl = func(a ...string) *exec.Cmd {
+		args = a
+		return exec.Command("cat", entries)
+	}
+
+	lf := &LogFile{FileStateFolder: dir, Log: models.NewLogger("inputs", "logfile", "")}
+	fc := &FileConfig{SystemdUnits: []string{"web*"}, LogGroupName: "web"}
+	require.NoError(t, fc.init())
+	read := func() []logs.LogEvent {
+		srcs := lf.findJournalSrcs(fc, []unitOutput{{unit: "web.service", journal: true}})
+		require.Len(t, srcs, 1)
+		assert.Equal(t, "web", srcs[0].Group())
+		received := make(chan logs.LogEvent, 10)
+		srcs[0].SetOutput(func(e logs.LogEvent) { received <- e })
+		var events []logs.LogEvent
+		for e := range received {
+			if e == nil {
+				break
+			}
+			events = append(events, e)
+		}
+		for _, e := range events {
+			e.Done()
+		}
+		time.Sleep(300 * time.Millisecond)
+		srcs[0].Stop()
+		<-srcs[0].(*journalSrc).exited
+		// the state is saved once more when the src stops
+		time.Sleep(100 * time.Millisecond)
+		return events
+	}
+
+	events := read()
+	require.Len(t, events, 2)
+	assert.Equal(t, "first", events[0].Message())
+	assert.Equal(t, time.Unix(1600000000, 0), events[0].Time())
+	assert.Equal(t, "hi\xff", events[1].Message())
+	assert.Equal(t, "--lines=0", args[len(args)-1])
+	state, err := ioutil.ReadFile(lf.getStateFilePath(logscommon.JournalStatePrefix + "web.service"))
+	require.NoError(t, err)
+	assert.Equal(t, "s=2\nweb.service", string(state))
+
+	// the exited src is only replaced after the discovery interval, then the journal is read from the saved cursor
+	assert.Empty(t, lf.findJournalSrcs(fc, []unitOutput{{unit: "web.service", journal: true}}))
+	lf.journals[fc]["web.service"].exitedAt = time.Now().Add(-unitDiscoveryInterval)
+	read()
+	assert.Equal(t, "--after-cursor=s=2", args[len(args)-1])
+}
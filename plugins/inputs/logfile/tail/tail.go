@@ -5,6 +5,7 @@ package tail
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -25,6 +26,9 @@ var (
 	exitOnDeletionWaitDuration  = 5 * time.Minute
 )
 
+// fingerprintSize is the number of bytes before the offset compared to detect the files rewritten in place
+const fingerprintSize = 64
+
 type Line struct {
 	Text   string
 	Time   time.Time
@@ -95,6 +99,10 @@ type Tail struct {
 	curOffset int64
 	tomb.Tomb // provides: Done, Kill, Dying
 
+	// the bytes before the offset at the end of the file, and where they start
+	fingerprint       []byte
+	fingerprintOffset int64
+
 	lk sync.Mutex
 }
 
@@ -399,6 +407,7 @@ func (tail *Tail) tailFileSync() {
 			// When EOF is reached, wait for more data to become
 			// available. Wait strategy is based on the `tail.watcher`
 			// implementation (inotify or polling).
+			tail.takeFingerprint()
 			err := tail.waitForChanges()
 			if err != nil {
 				if err == ErrDeletedNotReOpen {
@@ -441,6 +450,10 @@ func (tail *Tail) watchChanges() error {
 	if err != nil {
 		return err
 	}
+	if pw, ok := tail.watcher.(*watch.PollingFileWatcher); ok {
+		// the rotations are detected with the identity of the opened file rather than of the file of the name
+		pw.FileInfo, _ = tail.file.Stat()
+	}
 	tail.changes, err = tail.watcher.ChangeEvents(&tail.Tomb, pos)
 	return err
 }
@@ -455,6 +468,16 @@ func (tail *Tail) waitForChanges() error {
 
 	select {
 	case <-tail.changes.Modified:
+		if !tail.rewrittenInPlace() {
+			return nil
+		}
+		// the file was truncated and written past the offset again between two polls of its size
+		tail.Logger.Printf("Re-opening %s rewritten in place ...", tail.Filename)
+		if err := tail.reopen(); err != nil {
+			return err
+		}
+		tail.Logger.Printf("Successfully reopened rewritten %s", tail.Filename)
+		tail.openReader()
 		return nil
 	case <-tail.changes.Deleted:
 		tail.changes = nil
@@ -486,6 +509,40 @@ func (tail *Tail) waitForChanges() error {
 	panic("unreachable")
 }
 
+// takeFingerprint keeps the bytes before the offset, which do not change while the file is only appended to
+func (tail *Tail) takeFingerprint() {
+	tail.fingerprint = nil
+	if tail.Pipe || tail.file == nil {
+		return
+	}
+	offset, err := tail.Tell()
+	if err != nil || offset <= 0 {
+		return
+	}
+	start := offset - fingerprintSize
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, offset-start)
+	if _, err := tail.file.ReadAt(buf, start); err != nil {
+		return
+	}
+	tail.fingerprint, tail.fingerprintOffset = buf, start
+}
+
+// rewrittenInPlace reports whether the bytes before the offset are not the ones of the fingerprint anymore
+func (tail *Tail) rewrittenInPlace() bool {
+	if tail.fingerprint == nil || tail.file == nil {
+		return false
+	}
+	buf := make([]byte, len(tail.fingerprint))
+	n, err := tail.file.ReadAt(buf, tail.fingerprintOffset)
+	if err != nil && err != io.EOF {
+		return false
+	}
+	return !bytes.Equal(buf[:n], tail.fingerprint)
+}
+
 func (tail *Tail) openReader() {
 	tail.lk.Lock()
 	if tail.MaxLineSize > 0 {
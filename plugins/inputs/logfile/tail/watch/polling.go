@@ -16,10 +16,13 @@ import (
 type PollingFileWatcher struct {
 	Filename string
 	Size     int64
+	// FileInfo is the identity of the opened file, the file is deleted once the name is of another file.
+	// The file of the name when ChangeEvents is called by default.
+	FileInfo os.FileInfo
 }
 
 func NewPollingFileWatcher(filename string) *PollingFileWatcher {
-	fw := &PollingFileWatcher{filename, 0}
+	fw := &PollingFileWatcher{Filename: filename}
 	return fw
 }
 
@@ -43,9 +46,12 @@ func (fw *PollingFileWatcher) BlockUntilExists(t *tomb.Tomb) error {
 }
 
 func (fw *PollingFileWatcher) ChangeEvents(t *tomb.Tomb, pos int64) (*FileChanges, error) {
-	origFi, err := os.Stat(fw.Filename)
-	if err != nil {
-		return nil, err
+	origFi := fw.FileInfo
+	if origFi == nil {
+		var err error
+		if origFi, err = os.Stat(fw.Filename); err != nil {
+			return nil, err
+		}
 	}
 
 	changes := NewFileChanges()
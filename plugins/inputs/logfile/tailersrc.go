@@ -5,6 +5,7 @@ package logfile
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/aws/amazon-cloudwatch-agent/logs"
 	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile/tail"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
 	"golang.org/x/text/encoding"
 )
 
@@ -28,6 +30,8 @@ var (
 
 type fileOffset struct {
 	seq, offset int64 // Seq handles file trucation, when file is trucated, we increase the offset seq
+	// ingestSeq is the sequence number of the last structured log event of the stream up to the offset
+	ingestSeq int64
 }
 
 func (fo *fileOffset) SetOffset(o int64) {
@@ -56,6 +60,15 @@ func (le LogEvent) Done() {
 	le.src.Done(le.offset)
 }
 
+// Acker returns the tailer src, which saves the offset of the last log event of the batches accepted by the
+// destination
+func (le LogEvent) Acker() logs.Acker {
+	if le.src == nil {
+		return nil
+	}
+	return le.src
+}
+
 type tailerSrc struct {
 	group, stream  string
 	destination    string
@@ -66,6 +79,17 @@ type tailerSrc struct {
 	enc            encoding.Encoding
 	maxEventSize   int
 	truncateSuffix string
+	metrics        *logMetrics
+	envelope       *jsonEnvelope
+	parser         *delimitedParser
+	priority       string
+	idleTimeout    time.Duration
+	idleMessage    string
+	replicas       []logs.Replica
+	// the sequence numbering the structured log events of the stream, and the last number of the file
+	sequenceField string
+	sequence      *streamSequence
+	lastSeq       int64
 
 	outputFn        func(logs.LogEvent)
 	isMLStart       func(string) bool
@@ -109,6 +133,39 @@ func (ts *tailerSrc) SetOutput(fn func(logs.LogEvent)) {
 	if fn == nil {
 		return
 	}
+	if ts.metrics != nil || ts.envelope != nil || ts.parser != nil || ts.sequence != nil {
+		output := fn
+		fn = func(e logs.LogEvent) {
+			if e != nil {
+				if ts.metrics != nil {
+					ts.metrics.match(e.Message())
+				}
+				if le, ok := e.(*LogEvent); ok && ts.parser != nil {
+					msg, t, ok := ts.parser.parse(le.msg)
+					if !ok {
+						// the header lines and the directives
+						return
+					}
+					le.msg = msg
+					if !t.IsZero() {
+						le.t = t
+					}
+				}
+				if le, ok := e.(*LogEvent); ok && ts.envelope != nil {
+					le.msg = ts.envelope.wrap(le.msg, le.t, ts.tailer.Filename, ts.maxEventSize, ts.truncateSuffix)
+				}
+				if le, ok := e.(*LogEvent); ok && ts.sequence != nil {
+					// only the structured log events are numbered
+					if isJSONObject(le.msg) {
+						ts.lastSeq = ts.sequence.next()
+						le.msg = withSequence(le.msg, ts.sequenceField, ts.lastSeq)
+					}
+					le.offset.ingestSeq = ts.lastSeq
+				}
+			}
+			output(e)
+		}
+	}
 	ts.outputFn = fn
 	ts.startTailerOnce.Do(func() { go ts.runTail() })
 }
@@ -129,7 +186,15 @@ func (ts tailerSrc) Destination() string {
 	return ts.destination
 }
 
-func (ts tailerSrc) Done(offset fileOffset) {
+func (ts *tailerSrc) Priority() string {
+	return ts.priority
+}
+
+func (ts *tailerSrc) Replicas() []logs.Replica {
+	return ts.replicas
+}
+
+func (ts *tailerSrc) Done(offset fileOffset) {
 	// ts.offsetCh will only be blocked when the runSaveState func has exited,
 	// which only happens when the original file has been removed, thus making
 	// Keeping its offset useless
@@ -139,6 +204,21 @@ func (ts tailerSrc) Done(offset fileOffset) {
 	}
 }
 
+// Ack advances the offset to the last log event of the accepted batch. Unlike Done it waits for room in
+// ts.offsetCh, so that the offset of a batch is not lost, until the runSaveState func has exited.
+func (ts *tailerSrc) Ack(events []logs.LogEvent) {
+	var last fileOffset
+	for _, e := range events {
+		if le, ok := e.(*LogEvent); ok {
+			last = le.offset
+		}
+	}
+	select {
+	case ts.offsetCh <- last:
+	case <-ts.done:
+	}
+}
+
 func (ts *tailerSrc) Stop() {
 	close(ts.done)
 }
@@ -155,6 +235,8 @@ func (ts *tailerSrc) runTail() {
 	var msgBuf bytes.Buffer
 	var cnt int
 	fo := &fileOffset{}
+	// the time of the last line, or of the last idle marker
+	lastActive := time.Now()
 
 	ignoreUntilNextEvent := false
 	for {
@@ -180,6 +262,9 @@ func (ts *tailerSrc) runTail() {
 				continue
 			}
 
+			lastActive = time.Now()
+			recorder.Recorder.Record(recorder.KindLogLine, ts.tailer.Filename, []byte(line.Text))
+
 			text := line.Text
 			if ts.enc != nil {
 				var err error
@@ -229,6 +314,11 @@ func (ts *tailerSrc) runTail() {
 			fo.SetOffset(line.Offset)
 			cnt = 0
 		case <-t.C:
+			if ts.idleTimeout > 0 && msgBuf.Len() == 0 && time.Since(lastActive) >= ts.idleTimeout {
+				ts.outputFn(ts.idleEvent(*fo))
+				lastActive = time.Now()
+			}
+
 			if msgBuf.Len() > 0 {
 				cnt++
 			}
@@ -253,6 +343,21 @@ func (ts *tailerSrc) runTail() {
 	}
 }
 
+// idleEvent returns the marker log event of the idle file, its offset is the one of the last log event so that
+// it does not move the saved state.
+func (ts *tailerSrc) idleEvent(offset fileOffset) *LogEvent {
+	msg := ts.idleMessage
+	if msg == "" {
+		msg = fmt.Sprintf("No log entries were written to %s for %v", ts.tailer.Filename, ts.idleTimeout)
+	}
+	return &LogEvent{
+		msg:    msg,
+		t:      time.Now(),
+		offset: offset,
+		src:    ts,
+	}
+}
+
 func (ts *tailerSrc) cleanUp() {
 	if ts.autoRemoval {
 		if err := os.Remove(ts.tailer.Filename); err != nil {
@@ -282,14 +387,14 @@ func (ts *tailerSrc) runSaveState() {
 			if offset == lastSavedOffset {
 				continue
 			}
-			err := ts.saveState(offset.offset)
+			err := ts.saveState(offset)
 			if err != nil {
 				log.Printf("E! [logfile] Error happened when saving file state %s to file state folder %s: %v", ts.tailer.Filename, ts.stateFilePath, err)
 				continue
 			}
 			lastSavedOffset = offset
 		case <-ts.done:
-			err := ts.saveState(offset.offset)
+			err := ts.saveState(offset)
 			if err != nil {
 				log.Printf("E! [logfile] Error happened during final file state saving of logfile %s to file state folder %s, duplicate log maybe sent at next start: %v", ts.tailer.Filename, ts.stateFilePath, err)
 			}
@@ -298,11 +403,14 @@ func (ts *tailerSrc) runSaveState() {
 	}
 }
 
-func (ts *tailerSrc) saveState(offset int64) error {
-	if ts.stateFilePath == "" || offset == 0 {
+func (ts *tailerSrc) saveState(offset fileOffset) error {
+	if ts.stateFilePath == "" || offset.offset == 0 {
 		return nil
 	}
 
-	content := []byte(strconv.FormatInt(offset, 10) + "\n" + ts.tailer.Filename)
+	content := []byte(strconv.FormatInt(offset.offset, 10) + "\n" + ts.tailer.Filename)
+	if offset.ingestSeq > 0 {
+		content = append(content, "\n"+strconv.FormatInt(offset.ingestSeq, 10)...)
+	}
 	return ioutil.WriteFile(ts.stateFilePath, content, stateFileMode)
 }
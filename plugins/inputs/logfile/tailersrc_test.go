@@ -302,3 +302,25 @@ func logLine(s string, l int, t time.Time) string {
 	line = line[:l]
 	return line
 }
+
+func TestAckBatch(t *testing.T) {
+	ts := &tailerSrc{offsetCh: make(chan fileOffset, 1), done: make(chan struct{})}
+	e1 := &LogEvent{msg: "1", offset: fileOffset{offset: 10}, src: ts}
+	e2 := &LogEvent{msg: "2", offset: fileOffset{offset: 20}, src: ts}
+	if e1.Acker() != ts {
+		t.Errorf("Wrong acker of the log event: %v", e1.Acker())
+	}
+
+	ts.Ack([]logs.LogEvent{e1, e2})
+	if o := <-ts.offsetCh; o.offset != 20 {
+		t.Errorf("Wrong offset of the acked batch: %v, expecting 20", o.offset)
+	}
+
+	// the offsetCh is full, it does not block once the tailer src is stopped
+	ts.Ack([]logs.LogEvent{e1})
+	close(ts.done)
+	ts.Ack([]logs.LogEvent{e2})
+	if o := <-ts.offsetCh; o.offset != 10 {
+		t.Errorf("Wrong offset of the acked batch: %v, expecting 10", o.offset)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package memcached
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	telegrafmemcached "github.com/influxdata/telegraf/plugins/inputs/memcached"
+)
+
+const measurement = "memcached"
+
+// Memcached is the memcached input of telegraf with the hit rate of the gets computed over the collection interval.
+// The servers have no credentials since the text protocol of memcached has no authentication.
+type Memcached struct {
+	telegrafmemcached.Memcached
+
+	derivations *datastore.Derivations
+}
+
+func (m *Memcached) Gather(acc telegraf.Accumulator) error {
+	return m.Memcached.Gather(m.derivations.Accumulator(acc))
+}
+
+func init() {
+	// replaces the memcached input of telegraf registered by its package
+	inputs.Add(measurement, func() telegraf.Input {
+		return &Memcached{
+			derivations: &datastore.Derivations{
+				Measurement: measurement,
+				HitRates:    []datastore.HitRate{{Field: datastore.HitRateField, Hits: "get_hits", Misses: "get_misses"}},
+			},
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package memcached
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherHitRate(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	responses := []string{
+		"STAT curr_connections 3\r\nSTAT get_hits 100\r\nSTAT get_misses 20\r\nEND\r\n",
+		"STAT curr_connections 4\r\nSTAT get_hits 190\r\nSTAT get_misses 30\r\nEND\r\n",
+	}
+	go func() {
+		for _, response := range responses {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			bufio.NewReader(conn).ReadString('\n')
+			fmt.Fprint(conn, response)
+			conn.Close()
+		}
+	}()
+
+	m := inputs.Inputs["memcached"]().(*Memcached)
+	m.Servers = []string{l.Addr().String()}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, m.Gather(acc))
+	require.NoError(t, m.Gather(acc))
+	require.Len(t, acc.Metrics, 2)
+	assert.NotContains(t, acc.Metrics[0].Fields, datastore.HitRateField)
+	assert.Equal(t, float64(90), acc.Metrics[1].Fields[datastore.HitRateField])
+	assert.Equal(t, int64(4), acc.Metrics[1].Fields["curr_connections"])
+}
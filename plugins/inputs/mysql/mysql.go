@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/secrets"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	telegrafmysql "github.com/influxdata/telegraf/plugins/inputs/mysql"
+)
+
+// MySQL is the mysql input of telegraf with the credentials of its servers kept in Secrets Manager, and the hit rate
+// of the buffer pool and the slow queries computed over the collection interval
+type MySQL struct {
+	telegrafmysql.Mysql
+	secrets.Reference
+
+	// servers are the configured servers, without the credentials of the secret
+	servers     []string
+	derivations *datastore.Derivations
+}
+
+const secretSampleConfig = `
+  ## The ARN or the name of the secret of Secrets Manager with the user name and the password of the servers, a JSON
+  ## object with "username" and "password" keys as the secrets of RDS, they replace the ones of the servers.
+  # secret_id = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/mysql-monitor"
+  # region = "us-east-1"
+`
+
+func (m *MySQL) SampleConfig() string {
+	return m.Mysql.SampleConfig() + secretSampleConfig
+}
+
+func (m *MySQL) Description() string {
+	return "Read metrics from one or many mysql servers, with their credentials in Secrets Manager"
+}
+
+func (m *MySQL) Gather(acc telegraf.Accumulator) error {
+	if m.servers == nil {
+		m.servers = append([]string{}, m.Mysql.Servers...)
+	}
+	credentials, ok, err := m.Credentials()
+	if err != nil {
+		return err
+	}
+	if ok {
+		servers := m.servers
+		if len(servers) == 0 {
+			// localhost
+			servers = []string{""}
+		}
+		m.Mysql.Servers = make([]string, len(servers))
+		for i, server := range servers {
+			if m.Mysql.Servers[i], err = withCredentials(server, credentials); err != nil {
+				return err
+			}
+		}
+	}
+	return m.Mysql.Gather(m.derivations.Accumulator(acc))
+}
+
+// withCredentials returns the DSN of the server with the user name, when the secret has one, and the password of
+// the secret
+func withCredentials(dsn string, credentials secrets.Credentials) (string, error) {
+	config, err := driver.ParseDSN(dsn)
+	if err != nil {
+		// the DSN is not logged since it may have a password
+		return "", fmt.Errorf("invalid server DSN: %v", err)
+	}
+	if credentials.Username != "" {
+		config.User = credentials.Username
+	}
+	config.Passwd = credentials.Password
+	return config.FormatDSN(), nil
+}
+
+func init() {
+	// replaces the mysql input of telegraf registered by its package, whose defaults are kept
+	telegrafCreator := inputs.Inputs["mysql"]
+	inputs.Add("mysql", func() telegraf.Input {
+		return &MySQL{
+			Mysql: *telegrafCreator().(*telegrafmysql.Mysql),
+			derivations: &datastore.Derivations{
+				Measurement: "mysql",
+				HitRates:    []datastore.HitRate{{Field: datastore.HitRateField, Lookups: "innodb_buffer_pool_read_requests", Misses: "innodb_buffer_pool_reads"}},
+				Increases:   []datastore.Increase{{Field: "slow_queries_delta", Counter: "slow_queries"}},
+			},
+		}
+	})
+}
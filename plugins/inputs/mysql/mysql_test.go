@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/secrets"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	m := inputs.Inputs["mysql"]().(*MySQL)
+	require.NoError(t, toml.Unmarshal([]byte(`
+servers = ["tcp(db.internal:3306)/"]
+metric_version = 2
+gather_slave_status = true
+secret_id = "prod/mysql"
+region = "us-east-1"
+`), m))
+	assert.Equal(t, []string{"tcp(db.internal:3306)/"}, m.Mysql.Servers)
+	assert.True(t, m.GatherSlaveStatus)
+	assert.Equal(t, "prod/mysql", m.SecretID)
+	assert.Equal(t, "us-east-1", m.Region)
+	// the defaults of telegraf are kept
+	assert.True(t, m.GatherGlobalVars)
+}
+
+func TestWithCredentials(t *testing.T) {
+	dsn, err := withCredentials("monitor@tcp(db.internal:3306)/?tls=false", secrets.Credentials{Password: "p@ss:w/rd"})
+	require.NoError(t, err)
+	assert.Equal(t, "monitor:p@ss:w/rd@tcp(db.internal:3306)/?tls=false", dsn)
+
+	dsn, err = withCredentials("", secrets.Credentials{Username: "reader", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "reader:secret@tcp(127.0.0.1:3306)/", dsn)
+
+	_, err = withCredentials("tcp(db.internal:3306", secrets.Credentials{Password: "secret"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package paging
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const measurement = "paging"
+
+// counters are the counters of /proc/vmstat the rates are computed from
+var counters = []string{"pgfault", "pgmajfault", "pswpin", "pswpout", "pgpgin", "pgpgout"}
+
+type Paging struct {
+	// ProcPath is where the proc file system is mounted, HOST_PROC or /proc by default
+	ProcPath string `toml:"proc_path"`
+
+	last     map[string]uint64
+	lastTime time.Time
+}
+
+var sampleConfig = `
+  ## Where the proc file system of the host is mounted, HOST_PROC or /proc by default
+  # proc_path = "/rootfs/proc"
+`
+
+func (p *Paging) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Paging) Description() string {
+	return "Read the rates of the page faults and of the swapping and paging of the memory from /proc/vmstat"
+}
+
+// Gather adds the rates per second of the counters since the previous collection, there is none on the first one
+func (p *Paging) Gather(acc telegraf.Accumulator) error {
+	content, err := ioutil.ReadFile(filepath.Join(p.procPath(), "vmstat"))
+	if err != nil {
+		return fmt.Errorf("paging: unable to read vmstat: %v", err)
+	}
+	now := time.Now()
+	values, err := parseVmstat(content)
+	if err != nil {
+		return fmt.Errorf("paging: unable to parse vmstat: %v", err)
+	}
+	fields := rates(p.last, values, now.Sub(p.lastTime))
+	p.last, p.lastTime = values, now
+	if len(fields) > 0 {
+		acc.AddFields(measurement, fields, nil, now)
+	}
+	return nil
+}
+
+func (p *Paging) procPath() string {
+	if p.ProcPath != "" {
+		return p.ProcPath
+	}
+	if hostProc := os.Getenv("HOST_PROC"); hostProc != "" {
+		return hostProc
+	}
+	return "/proc"
+}
+
+// rates returns the <counter>_per_sec fields of the counters found in both of the collections
+func rates(last map[string]uint64, values map[string]uint64, elapsed time.Duration) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if last == nil || elapsed <= 0 {
+		return fields
+	}
+	for name, value := range values {
+		previous, ok := last[name]
+		if !ok || value < previous {
+			continue
+		}
+		fields[name+"_per_sec"] = float64(value-previous) / elapsed.Seconds()
+	}
+	return fields
+}
+
+// parseVmstat returns the counters of the lines of /proc/vmstat, e.g. "pgmajfault 1234"
+func parseVmstat(content []byte) (map[string]uint64, error) {
+	wanted := map[string]bool{}
+	for _, name := range counters {
+		wanted[name] = true
+	}
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 || !wanted[parts[0]] {
+			continue
+		}
+		v, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q of %v", parts[1], parts[0])
+		}
+		values[parts[0]] = v
+	}
+	return values, scanner.Err()
+}
+
+func init() {
+	inputs.Add("paging", func() telegraf.Input {
+		return &Paging{}
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package paging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vmstat = `nr_free_pages 123
+pgpgin 1000
+pgpgout 2000
+pswpin 10
+pswpout 20
+pgfault 50000
+pgmajfault 100
+`
+
+func TestParseVmstat(t *testing.T) {
+	values, err := parseVmstat([]byte(vmstat))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint64{
+		"pgpgin": 1000, "pgpgout": 2000, "pswpin": 10, "pswpout": 20, "pgfault": 50000, "pgmajfault": 100,
+	}, values)
+
+	_, err = parseVmstat([]byte("pgfault abc\n"))
+	assert.Error(t, err)
+}
+
+func TestRates(t *testing.T) {
+	last := map[string]uint64{"pgfault": 50000, "pgmajfault": 100, "pswpin": 10, "pswpout": 30}
+	values := map[string]uint64{"pgfault": 56000, "pgmajfault": 160, "pswpin": 10, "pswpout": 20, "pgpgin": 5}
+	fields := rates(last, values, time.Minute)
+	// pswpout went back and pgpgin is new
+	assert.Equal(t, map[string]interface{}{
+		"pgfault_per_sec":    100.0,
+		"pgmajfault_per_sec": 1.0,
+		"pswpin_per_sec":     0.0,
+	}, fields)
+
+	assert.Empty(t, rates(nil, values, time.Minute))
+}
+
+func TestGather(t *testing.T) {
+	proc, err := ioutil.TempDir("", "paging")
+	require.NoError(t, err)
+	defer os.RemoveAll(proc)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "vmstat"), []byte(vmstat), 0644))
+
+	var acc testutil.Accumulator
+	p := &Paging{ProcPath: proc}
+	// the first collection is the baseline of the rates
+	require.NoError(t, p.Gather(&acc))
+	assert.Empty(t, acc.Metrics)
+
+	require.NoError(t, p.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "paging", acc.Metrics[0].Measurement)
+	assert.Len(t, acc.Metrics[0].Fields, len(counters))
+	assert.Equal(t, 0.0, acc.Metrics[0].Fields["pgmajfault_per_sec"])
+}
@@ -0,0 +1,224 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package port_check
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	measurement    = "port_check"
+	defaultTimeout = 5 * time.Second
+	protocolTCP    = "tcp"
+	protocolUDP    = "udp"
+	// maxResponse is the size of the responses read for the expected string
+	maxResponse = 64 * 1024
+)
+
+// Target is a host:port checked with a TCP connection or a UDP datagram
+type Target struct {
+	Address string `toml:"address"`
+	// Protocol is tcp or udp, tcp by default
+	Protocol string `toml:"protocol"`
+	// Send is written once connected, it is required for UDP since the servers only answer to a request
+	Send string `toml:"send"`
+	// Expect is the string the response must contain, any response is expected when it is empty. TCP targets without
+	// Send nor Expect are only connected to.
+	Expect string `toml:"expect"`
+}
+
+func (t *Target) validate() error {
+	if _, _, err := net.SplitHostPort(t.Address); err != nil {
+		return fmt.Errorf("invalid address %q: %v", t.Address, err)
+	}
+	switch t.Protocol {
+	case "":
+		t.Protocol = protocolTCP
+	case protocolTCP, protocolUDP:
+	default:
+		return fmt.Errorf("unsupported protocol %q of %v", t.Protocol, t.Address)
+	}
+	if t.Protocol == protocolUDP && t.Send == "" {
+		return fmt.Errorf("the UDP target %v has nothing to send", t.Address)
+	}
+	return nil
+}
+
+// PortCheck checks that the hard dependencies of the host, e.g. its database or its DNS resolver, accept
+// connections, and reports the times they took
+type PortCheck struct {
+	Targets []Target          `toml:"target"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	mu sync.Mutex
+	// failing are the targets failing at the previous collection, whose errors were logged
+	failing map[string]bool
+}
+
+var sampleConfig = `
+  ## The timeout of the checks of the targets, from the connection to the expected response
+  # timeout = "5s"
+
+  [[inputs.port_check.target]]
+    address = "db.internal:5432"
+
+  [[inputs.port_check.target]]
+    address = "cache.internal:6379"
+    ## Written once connected
+    send = "PING\r\n"
+    ## The string the response must contain, any response when empty
+    expect = "+PONG"
+
+  [[inputs.port_check.target]]
+    ## UDP targets need something to send, since the servers only answer to a request
+    address = "statsd.internal:8125"
+    protocol = "udp"
+    send = "health"
+    expect = "up"
+`
+
+func (p *PortCheck) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PortCheck) Description() string {
+	return "Check that TCP and UDP targets answer, with the time of the connection and of the response"
+}
+
+func (p *PortCheck) Start(acc telegraf.Accumulator) error {
+	if len(p.Targets) == 0 {
+		return fmt.Errorf("port_check: no target configured")
+	}
+	for i := range p.Targets {
+		if err := p.Targets[i].validate(); err != nil {
+			return fmt.Errorf("port_check: %v", err)
+		}
+	}
+	return nil
+}
+
+func (p *PortCheck) Stop() {
+}
+
+// Gather checks the targets concurrently, so that a target timing out does not delay the others
+func (p *PortCheck) Gather(acc telegraf.Accumulator) error {
+	timeout := p.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	var wg sync.WaitGroup
+	for i := range p.Targets {
+		wg.Add(1)
+		go func(t *Target) {
+			defer wg.Done()
+			now := time.Now()
+			fields, err := check(t, timeout)
+			p.logTransition(t, err)
+			acc.AddFields(measurement, fields, map[string]string{"address": t.Address, "protocol": t.Protocol}, now)
+		}(&p.Targets[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+// logTransition logs the error of a target when it starts failing and when it recovers, rather than at every
+// collection
+func (p *PortCheck) logTransition(t *Target, err error) {
+	key := t.Protocol + "://" + t.Address
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing == nil {
+		p.failing = map[string]bool{}
+	}
+	if err != nil && !p.failing[key] {
+		log.Printf("W! port_check: the check of %v failed: %v", key, err)
+	} else if err == nil && p.failing[key] {
+		log.Printf("I! port_check: the check of %v succeeded again", key)
+	}
+	p.failing[key] = err != nil
+}
+
+// check returns the success of the check of the target, the time of the connection of the TCP targets, and the time
+// of the response from the write of what is sent
+func check(t *Target, timeout time.Duration) (map[string]interface{}, error) {
+	fields := map[string]interface{}{"success": 0}
+	start := time.Now()
+	conn, err := net.DialTimeout(t.Protocol, t.Address, timeout)
+	if err != nil {
+		return fields, err
+	}
+	defer conn.Close()
+	if t.Protocol == protocolTCP {
+		fields["connect_time"] = milliseconds(time.Since(start))
+	}
+	conn.SetDeadline(start.Add(timeout))
+
+	if t.Send == "" && t.Expect == "" {
+		fields["success"] = 1
+		return fields, nil
+	}
+	sent := time.Now()
+	if t.Send != "" {
+		if _, err := conn.Write([]byte(t.Send)); err != nil {
+			return fields, fmt.Errorf("unable to send: %v", err)
+		}
+	}
+	if err := readExpected(conn, t.Expect); err != nil {
+		return fields, err
+	}
+	fields["response_time"] = milliseconds(time.Since(sent))
+	fields["success"] = 1
+	return fields, nil
+}
+
+// readExpected reads the response until it contains the expected string, the first read when any response is
+// expected. The reads of the UDP targets are the datagrams of the response.
+func readExpected(conn net.Conn, expect string) error {
+	var response []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+		if n > 0 && (expect == "" || bytes.Contains(response, []byte(expect))) {
+			return nil
+		}
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return fmt.Errorf("no expected response before the timeout, got %q", truncate(response))
+			}
+			return fmt.Errorf("unable to read the response: %v", err)
+		}
+		if len(response) >= maxResponse {
+			return fmt.Errorf("the expected string is not in the first %v bytes of the response", maxResponse)
+		}
+	}
+}
+
+func truncate(response []byte) string {
+	s := strings.TrimSpace(string(response))
+	if len(s) > 64 {
+		return s[:64] + "..."
+	}
+	return s
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func init() {
+	inputs.Add(measurement, func() telegraf.Input {
+		return &PortCheck{Timeout: internal.Duration{Duration: defaultTimeout}}
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package port_check
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	target := Target{Address: "localhost:80"}
+	require.NoError(t, target.validate())
+	assert.Equal(t, protocolTCP, target.Protocol)
+
+	for _, target := range []Target{
+		{Address: "localhost"},
+		{Address: "localhost:53", Protocol: "icmp"},
+		{Address: "localhost:53", Protocol: protocolUDP},
+	} {
+		assert.Error(t, target.validate(), target.Address)
+	}
+	assert.Error(t, (&PortCheck{}).Start(&testutil.Accumulator{}))
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if line, err := bufio.NewReader(conn).ReadString('\n'); err == nil && line == "PING\r\n" {
+					conn.Write([]byte("+PONG\r\n"))
+				}
+			}()
+		}
+	}()
+	address := listener.Addr().String()
+
+	fields, err := check(&Target{Address: address, Protocol: protocolTCP}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fields["success"])
+	assert.Contains(t, fields, "connect_time")
+	assert.NotContains(t, fields, "response_time")
+
+	fields, err = check(&Target{Address: address, Protocol: protocolTCP, Send: "PING\r\n", Expect: "+PONG"}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fields["success"])
+	assert.Contains(t, fields, "response_time")
+
+	// nothing is answered to another request
+	fields, err = check(&Target{Address: address, Protocol: protocolTCP, Send: "QUIT\r\n", Expect: "+PONG"}, 200*time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, 0, fields["success"])
+	assert.Contains(t, fields, "connect_time")
+	assert.NotContains(t, fields, "response_time")
+
+	listener.Close()
+	fields, err = check(&Target{Address: address, Protocol: protocolTCP}, time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"success": 0}, fields)
+}
+
+func TestUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if string(buf[:n]) == "health" {
+				conn.WriteTo([]byte("up"), addr)
+			}
+		}
+	}()
+	address := conn.LocalAddr().String()
+
+	fields, err := check(&Target{Address: address, Protocol: protocolUDP, Send: "health", Expect: "up"}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fields["success"])
+	assert.Contains(t, fields, "response_time")
+	assert.NotContains(t, fields, "connect_time")
+
+	fields, err = check(&Target{Address: address, Protocol: protocolUDP, Send: "status"}, 200*time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"success": 0}, fields)
+}
+
+func TestGather(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed.Close()
+
+	p := &PortCheck{
+		Targets: []Target{{Address: listener.Addr().String()}, {Address: closed.Addr().String()}},
+		Timeout: internal.Duration{Duration: time.Second},
+	}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, p.Start(acc))
+	require.NoError(t, p.Gather(acc))
+	require.Len(t, acc.Metrics, 2)
+	assert.Empty(t, acc.Errors)
+
+	for _, m := range acc.Metrics {
+		assert.Equal(t, protocolTCP, m.Tags["protocol"])
+		if m.Tags["address"] == listener.Addr().String() {
+			assert.Equal(t, 1, m.Fields["success"])
+		} else {
+			assert.Equal(t, closed.Addr().String(), m.Tags["address"])
+			assert.Equal(t, 0, m.Fields["success"])
+		}
+	}
+	assert.Equal(t, map[string]bool{"tcp://" + listener.Addr().String(): false, "tcp://" + closed.Addr().String(): true}, p.failing)
+}
@@ -0,0 +1,182 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/secrets"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	telegrafpostgresql "github.com/influxdata/telegraf/plugins/inputs/postgresql"
+)
+
+const (
+	measurement = "postgresql"
+	localhost   = "host=localhost sslmode=disable"
+
+	defaultSlowQueryThreshold = 10 * time.Second
+
+	// serverQuery returns the replication lag of a standby, null on a primary, and the number of the queries running
+	// for longer than the threshold in seconds
+	serverQuery = `SELECT
+  CASE WHEN pg_is_in_recovery() THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END,
+  (SELECT count(*) FROM pg_stat_activity WHERE state = 'active' AND now() - query_start > $1 * interval '1 second')`
+)
+
+// PostgreSQL is the postgresql input of telegraf with the credentials of its server kept in Secrets Manager, the
+// replication lag and the slow queries of the server, and the hit rate of the buffers of the databases computed over
+// the collection interval
+type PostgreSQL struct {
+	telegrafpostgresql.Postgresql
+	secrets.Reference
+	// SlowQueryThreshold is the duration after which the running queries are counted as slow
+	SlowQueryThreshold internal.Duration `toml:"slow_query_threshold"`
+
+	// address is the configured address, without the credentials of the secret
+	address     string
+	credentials secrets.Credentials
+	derivations *datastore.Derivations
+}
+
+const secretSampleConfig = `
+  ## The ARN or the name of the secret of Secrets Manager with the user name and the password of the server, a JSON
+  ## object with "username" and "password" keys as the secrets of RDS, they replace the ones of the address.
+  # secret_id = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/postgresql-monitor"
+  # region = "us-east-1"
+
+  ## The duration after which the running queries are counted as slow
+  # slow_query_threshold = "10s"
+`
+
+func (p *PostgreSQL) SampleConfig() string {
+	return p.Postgresql.SampleConfig() + secretSampleConfig
+}
+
+func (p *PostgreSQL) Description() string {
+	return "Read metrics from a postgresql server, with its credentials in Secrets Manager"
+}
+
+func (p *PostgreSQL) Start(acc telegraf.Accumulator) error {
+	p.address = p.Address
+	if p.address == "" || p.address == "localhost" {
+		p.address = localhost
+	}
+	credentials, ok, err := p.Credentials()
+	if err != nil {
+		return err
+	}
+	if ok && p.Outputaddress == "" {
+		// the server tag is the one of the configured address rather than the one with the user name of the secret
+		p.Address = p.address
+		if p.Outputaddress, err = p.SanitizedAddress(); err != nil {
+			return err
+		}
+	}
+	return p.connect(credentials, ok, acc)
+}
+
+func (p *PostgreSQL) connect(credentials secrets.Credentials, ok bool, acc telegraf.Accumulator) error {
+	p.Address, p.credentials = p.address, credentials
+	if ok {
+		address, err := withCredentials(p.address, credentials)
+		if err != nil {
+			return err
+		}
+		p.Address = address
+	}
+	return p.Postgresql.Start(acc)
+}
+
+func (p *PostgreSQL) Gather(acc telegraf.Accumulator) error {
+	credentials, ok, err := p.Credentials()
+	if err != nil {
+		return err
+	}
+	if ok && credentials != p.credentials {
+		// the secret was rotated
+		p.Postgresql.Stop()
+		if err := p.connect(credentials, ok, acc); err != nil {
+			return err
+		}
+	}
+	if err := p.Postgresql.Gather(p.derivations.Accumulator(acc)); err != nil {
+		return err
+	}
+	if p.IsPgBouncer {
+		return nil
+	}
+	return p.gatherServer(acc)
+}
+
+func (p *PostgreSQL) gatherServer(acc telegraf.Accumulator) error {
+	threshold := p.SlowQueryThreshold.Duration
+	if threshold <= 0 {
+		threshold = defaultSlowQueryThreshold
+	}
+	var lag sql.NullFloat64
+	var slowQueries int64
+	if err := p.DB.QueryRow(serverQuery, threshold.Seconds()).Scan(&lag, &slowQueries); err != nil {
+		return err
+	}
+	server, err := p.SanitizedAddress()
+	if err != nil {
+		return err
+	}
+	fields := map[string]interface{}{"slow_queries": slowQueries}
+	if lag.Valid {
+		fields["replication_lag"] = lag.Float64
+	}
+	acc.AddFields(measurement, fields, map[string]string{"server": server})
+	return nil
+}
+
+// withCredentials returns the address with the user name, when the secret has one, and the password of the secret.
+// The address is either a URL or key value pairs, the values of the later pairs replace the ones of the former.
+func withCredentials(address string, credentials secrets.Credentials) (string, error) {
+	if strings.HasPrefix(address, "postgres://") || strings.HasPrefix(address, "postgresql://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			// the address is not logged since it may have a password
+			return "", fmt.Errorf("invalid address URL: %v", err.(*url.Error).Err)
+		}
+		username := credentials.Username
+		if username == "" && u.User != nil {
+			username = u.User.Username()
+		}
+		u.User = url.UserPassword(username, credentials.Password)
+		return u.String(), nil
+	}
+	if credentials.Username != "" {
+		address += " user=" + quote(credentials.Username)
+	}
+	return address + " password=" + quote(credentials.Password), nil
+}
+
+var quoter = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func quote(value string) string {
+	return "'" + quoter.Replace(value) + "'"
+}
+
+func init() {
+	// replaces the postgresql input of telegraf registered by its package, whose defaults are kept
+	telegrafCreator := inputs.Inputs[measurement]
+	inputs.Add(measurement, func() telegraf.Input {
+		return &PostgreSQL{
+			Postgresql:         *telegrafCreator().(*telegrafpostgresql.Postgresql),
+			SlowQueryThreshold: internal.Duration{Duration: defaultSlowQueryThreshold},
+			derivations: &datastore.Derivations{
+				Measurement: measurement,
+				HitRates:    []datastore.HitRate{{Field: datastore.HitRateField, Hits: "blks_hit", Misses: "blks_read"}},
+			},
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package pressure
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const measurement = "pressure"
+
+// resources are the files of /proc/pressure, which prefix their fields
+var resources = []string{"cpu", "memory", "io"}
+
+type Pressure struct {
+	// ProcPath is where the proc file system is mounted, HOST_PROC or /proc by default
+	ProcPath string `toml:"proc_path"`
+
+	warned bool
+}
+
+var sampleConfig = `
+  ## Where the proc file system of the host is mounted, HOST_PROC or /proc by default
+  # proc_path = "/rootfs/proc"
+`
+
+func (p *Pressure) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Pressure) Description() string {
+	return "Read the pressure stall information (PSI) of the CPU, the memory and the IO from /proc/pressure"
+}
+
+func (p *Pressure) Gather(acc telegraf.Accumulator) error {
+	dir := filepath.Join(p.procPath(), "pressure")
+	fields := map[string]interface{}{}
+	for _, resource := range resources {
+		content, err := ioutil.ReadFile(filepath.Join(dir, resource))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("pressure: unable to read the %v pressure: %v", resource, err)
+		}
+		if err := parsePressure(resource, content, fields); err != nil {
+			return fmt.Errorf("pressure: unable to parse the %v pressure: %v", resource, err)
+		}
+	}
+	if len(fields) == 0 {
+		// the kernel is older than 4.20 or it is booted without psi=1
+		if !p.warned {
+			log.Printf("W! pressure: no pressure stall information in %v, it needs Linux 4.20 or later with PSI enabled", dir)
+			p.warned = true
+		}
+		return nil
+	}
+	acc.AddFields(measurement, fields, nil)
+	return nil
+}
+
+func (p *Pressure) procPath() string {
+	if p.ProcPath != "" {
+		return p.ProcPath
+	}
+	if hostProc := os.Getenv("HOST_PROC"); hostProc != "" {
+		return hostProc
+	}
+	return "/proc"
+}
+
+// parsePressure adds the fields of the lines of a pressure file, e.g.
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//
+// as <prefix>_some_avg10 etc. The averages are percentages of the time, the total is the stall time in
+// microseconds.
+func parsePressure(prefix string, content []byte, fields map[string]interface{}) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		kind := parts[0]
+		if kind != "some" && kind != "full" {
+			return fmt.Errorf("unknown line %q", scanner.Text())
+		}
+		for _, part := range parts[1:] {
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid value %q", part)
+			}
+			name := prefix + "_" + kind + "_" + kv[0]
+			if kv[0] == "total" {
+				total, err := strconv.ParseUint(kv[1], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid value %q: %v", part, err)
+				}
+				fields[name] = total
+				continue
+			}
+			avg, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %q: %v", part, err)
+			}
+			fields[name] = avg
+		}
+	}
+	return scanner.Err()
+}
+
+func init() {
+	inputs.Add("pressure", func() telegraf.Input {
+		return &Pressure{}
+	})
+}
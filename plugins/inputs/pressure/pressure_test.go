@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package pressure
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	proc, err := ioutil.TempDir("", "pressure")
+	require.NoError(t, err)
+	defer os.RemoveAll(proc)
+	require.NoError(t, os.Mkdir(filepath.Join(proc, "pressure"), 0755))
+	files := map[string]string{
+		"cpu":    "some avg10=1.50 avg60=0.75 avg300=0.20 total=123456\n",
+		"memory": "some avg10=0.00 avg60=0.10 avg300=0.00 total=42\nfull avg10=0.00 avg60=0.05 avg300=0.00 total=21\n",
+		"io":     "some avg10=12.00 avg60=8.00 avg300=4.00 total=9000000\nfull avg10=10.00 avg60=6.00 avg300=3.00 total=8000000\n",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "pressure", name), []byte(content), 0644))
+	}
+
+	var acc testutil.Accumulator
+	p := &Pressure{ProcPath: proc}
+	require.NoError(t, p.Gather(&acc))
+	acc.AssertContainsFields(t, "pressure", map[string]interface{}{
+		"cpu_some_avg10":     1.5,
+		"cpu_some_avg60":     0.75,
+		"cpu_some_avg300":    0.2,
+		"cpu_some_total":     uint64(123456),
+		"memory_some_avg10":  0.0,
+		"memory_some_avg60":  0.1,
+		"memory_some_avg300": 0.0,
+		"memory_some_total":  uint64(42),
+		"memory_full_avg10":  0.0,
+		"memory_full_avg60":  0.05,
+		"memory_full_avg300": 0.0,
+		"memory_full_total":  uint64(21),
+		"io_some_avg10":      12.0,
+		"io_some_avg60":      8.0,
+		"io_some_avg300":     4.0,
+		"io_some_total":      uint64(9000000),
+		"io_full_avg10":      10.0,
+		"io_full_avg60":      6.0,
+		"io_full_avg300":     3.0,
+		"io_full_total":      uint64(8000000),
+	})
+}
+
+func TestGatherWithoutPressure(t *testing.T) {
+	proc, err := ioutil.TempDir("", "pressure")
+	require.NoError(t, err)
+	defer os.RemoveAll(proc)
+
+	var acc testutil.Accumulator
+	p := &Pressure{ProcPath: proc}
+	require.NoError(t, p.Gather(&acc))
+	assert.Empty(t, acc.Metrics)
+	assert.True(t, p.warned)
+}
+
+func TestParsePressureInvalid(t *testing.T) {
+	assert.Error(t, parsePressure("cpu", []byte("some avg10=abc\n"), map[string]interface{}{}))
+	assert.Error(t, parsePressure("cpu", []byte("partial avg10=1.00\n"), map[string]interface{}{}))
+}
@@ -5,10 +5,27 @@ package prometheus_scraper
 
 import (
 	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/mapWithExpiry"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/textparse"
+)
+
+const (
+	// processStartTimeMetric is the start time of the process of the target exposed by the client libraries, the
+	// counters of the target were reset when it changes even if their values did not go down
+	processStartTimeMetric = "process_start_time_seconds"
+	// counterResetsMetric is the number of the counters of the target reset since the previous scrape, only reported
+	// when some were
+	counterResetsMetric = "cwagent_counter_resets"
 )
 
 type Calculator struct {
 	deltaCalculator *DeltaCalculator
+	// startTimes are the process start times of the targets by job and instance
+	startTimes          *mapWithExpiry.MapWithExpiry
+	lastCleanUpTimeInMs int64
 }
 
 // Do calculation based on metric type
@@ -17,18 +34,28 @@ func (c *Calculator) Calculate(pmb PrometheusMetricBatch) (result PrometheusMetr
 	var counters PrometheusMetricBatch
 	var summaries PrometheusMetricBatch
 
+	restarted := c.restarted(pmb)
+	resets := 0
+	delta := func(pm *PrometheusMetric) *PrometheusMetric {
+		calculatedMetric, reset := c.deltaCalculator.calculate(pm, restarted)
+		if reset {
+			resets++
+		}
+		return calculatedMetric
+	}
+
 	for _, pm := range pmb {
 		if pm.isGauge() && !pm.isValueStale() {
 			gauges = append(gauges, pm)
 		} else if pm.isCounter() {
-			if calculatedMetric := c.deltaCalculator.calculate(pm); calculatedMetric != nil {
+			if calculatedMetric := delta(pm); calculatedMetric != nil {
 				counters = append(counters, calculatedMetric)
 			}
 		} else if pm.isSummary() && !pm.isValueStale() {
 			// calculate the delta for <basename>_count and <basename>_sum metrics as well
 			if strings.HasSuffix(pm.metricName, histogramSummaryCountSuffix) ||
 				strings.HasSuffix(pm.metricName, histogramSummarySumSuffix) {
-				if calculatedMetric := c.deltaCalculator.calculate(pm); calculatedMetric != nil {
+				if calculatedMetric := delta(pm); calculatedMetric != nil {
 					summaries = append(summaries, calculatedMetric)
 				}
 			} else {
@@ -40,11 +67,57 @@ func (c *Calculator) Calculate(pmb PrometheusMetricBatch) (result PrometheusMetr
 	result = append(result, gauges...)
 	result = append(result, counters...)
 	result = append(result, summaries...)
+	if resets > 0 {
+		result = append(result, counterResets(pmb, resets))
+	}
 	return
 }
 
+// restarted returns whether the process start time of the target of the batch changed since the previous scrape
+func (c *Calculator) restarted(pmb PrometheusMetricBatch) bool {
+	for _, pm := range pmb {
+		if pm.metricName != processStartTimeMetric || pm.isValueStale() {
+			continue
+		}
+		key := pm.tags[model.JobLabel] + "/" + pm.tags[model.InstanceLabel]
+		v, ok := c.startTimes.Get(key)
+		c.startTimes.Set(key, pm.metricValue)
+
+		// Clean up the start times of the targets which are gone periodically
+		if pm.timeInMS-c.lastCleanUpTimeInMs >= CleanUpTimeThreshold {
+			c.startTimes.CleanUp(time.Now())
+			c.lastCleanUpTimeInMs = pm.timeInMS
+		}
+		return ok && v.(float64) != pm.metricValue
+	}
+	return false
+}
+
+// counterResets returns the metric of the number of the counters of the target of the batch reset since the
+// previous scrape, with the job and instance of the target
+func counterResets(pmb PrometheusMetricBatch, resets int) *PrometheusMetric {
+	pm := &PrometheusMetric{
+		metricName:  counterResetsMetric,
+		metricValue: float64(resets),
+		metricType:  textparse.MetricTypeGauge,
+		tags:        map[string]string{prometheusMetricTypeKey: textparse.MetricTypeGauge},
+	}
+	for _, m := range pmb {
+		if m.timeInMS > pm.timeInMS {
+			pm.timeInMS = m.timeInMS
+		}
+		for _, label := range []string{model.JobLabel, model.InstanceLabel} {
+			if v, ok := m.tags[label]; ok {
+				pm.tags[label] = v
+			}
+		}
+	}
+	return pm
+}
+
 func NewCalculator() *Calculator {
 	return &Calculator{
 		deltaCalculator: NewDeltaCalculator(),
+		startTimes:      mapWithExpiry.NewMapWithExpiry(CacheTTL),
 	}
 }
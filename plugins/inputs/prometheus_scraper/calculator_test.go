@@ -0,0 +1,51 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package prometheus_scraper
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/textparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrapeBatch(timeInMS int64, startTime float64, requests float64) PrometheusMetricBatch {
+	tags := func() map[string]string {
+		return map[string]string{"job": "app", "instance": "host:8080"}
+	}
+	return PrometheusMetricBatch{
+		{metricName: processStartTimeMetric, metricValue: startTime, metricType: textparse.MetricTypeGauge, timeInMS: timeInMS, tags: tags()},
+		{metricName: "requests_total", metricValue: requests, metricType: textparse.MetricTypeCounter, timeInMS: timeInMS, tags: tags()},
+	}
+}
+
+func metricValues(pmb PrometheusMetricBatch) map[string]float64 {
+	values := map[string]float64{}
+	for _, pm := range pmb {
+		values[pm.metricName] = pm.metricValue
+	}
+	return values
+}
+
+func TestCalculateCounterResets(t *testing.T) {
+	c := NewCalculator()
+	assert.Equal(t, map[string]float64{processStartTimeMetric: 100}, metricValues(c.Calculate(scrapeBatch(1000, 100, 50))))
+	assert.Equal(t, map[string]float64{processStartTimeMetric: 100, "requests_total": 20}, metricValues(c.Calculate(scrapeBatch(2000, 100, 70))))
+
+	// the counter went down, it counted from 0 since the reset
+	result := c.Calculate(scrapeBatch(3000, 100, 5))
+	assert.Equal(t, map[string]float64{processStartTimeMetric: 100, "requests_total": 5, counterResetsMetric: 1}, metricValues(result))
+	resets := result[len(result)-1]
+	assert.Equal(t, map[string]string{"job": "app", "instance": "host:8080", prometheusMetricTypeKey: "gauge"}, resets.tags)
+	assert.Equal(t, int64(3000), resets.timeInMS)
+
+	// the process restarted, the counter was reset even though it is higher than before
+	result = c.Calculate(scrapeBatch(4000, 3500, 80))
+	assert.Equal(t, map[string]float64{processStartTimeMetric: 3500, "requests_total": 80, counterResetsMetric: 1}, metricValues(result))
+
+	result = c.Calculate(scrapeBatch(5000, 3500, 90))
+	require.Len(t, result, 2)
+	assert.Equal(t, map[string]float64{processStartTimeMetric: 3500, "requests_total": 10}, metricValues(result))
+}
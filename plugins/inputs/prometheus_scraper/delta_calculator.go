@@ -23,12 +23,14 @@ type DeltaCalculator struct {
 	lastCleanUpTimeInMs int64
 }
 
-func (dc *DeltaCalculator) calculate(pm *PrometheusMetric) (res *PrometheusMetric) {
+// calculate returns the metric with its delta since the previous data point, nil for the first one, and whether the
+// counter was reset since, when its value went down or when its target restarted
+func (dc *DeltaCalculator) calculate(pm *PrometheusMetric, restarted bool) (res *PrometheusMetric, reset bool) {
 	metricKey := getUniqMetricKey(pm)
 
 	if pm.isValueStale() {
 		dc.preDataPoints.Delete(metricKey)
-		return nil
+		return nil, false
 	}
 
 	curVal := pm.metricValue
@@ -36,11 +38,12 @@ func (dc *DeltaCalculator) calculate(pm *PrometheusMetric) (res *PrometheusMetri
 	if v, ok := dc.preDataPoints.Get(metricKey); ok {
 		preDataPoint := v.(dataPoint)
 		if curTimeInMS > preDataPoint.timeInMS {
-			if curVal >= preDataPoint.value {
+			if curVal >= preDataPoint.value && !restarted {
 				pm.metricValue = curVal - preDataPoint.value
 			} else {
-				// the counter has been reset, keep the current value as delta
+				// the counter has been reset, it counted from 0 since, keep the current value as delta
 				pm.metricValue = curVal
+				reset = true
 			}
 		}
 		res = pm
@@ -54,7 +57,7 @@ func (dc *DeltaCalculator) calculate(pm *PrometheusMetric) (res *PrometheusMetri
 
 	dc.preDataPoints.Set(metricKey, dataPoint{value: curVal, timeInMS: curTimeInMS})
 
-	return
+	return res, reset
 }
 
 func NewDeltaCalculator() *DeltaCalculator {
@@ -0,0 +1,314 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package prometheus_scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/pkg/exemplar"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+const (
+	// exemplarsField is the attribute of the EMF documents holding the exemplars of their metrics by metric name
+	exemplarsField = "exemplars"
+
+	openMetricsAcceptHeader = "application/openmetrics-text; version=0.0.1"
+	openMetricsContentType  = "application/openmetrics-text"
+	// maxExemplarScrapeBytes bounds the size of the responses read for the exemplars
+	maxExemplarScrapeBytes = 64 * 1024 * 1024
+	exemplarTickInterval   = time.Second
+)
+
+// errNotOpenMetrics is returned for the targets which do not expose their metrics in the OpenMetrics format
+var errNotOpenMetrics = errors.New("the target does not expose the OpenMetrics format")
+
+// traceIDLabels are the labels of the exemplars holding their trace id, as set by the OpenTelemetry and the
+// Prometheus client libraries
+var traceIDLabels = []string{"trace_id", "traceID", "traceId"}
+
+var (
+	w3cTraceID  = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	xrayTraceID = regexp.MustCompile(`^1-[0-9a-f]{8}-[0-9a-f]{24}$`)
+)
+
+// Exemplar is the exemplar of a metric of an EMF document, the trace id is also given in the X-Ray format when it
+// is a W3C one so that the datapoints can be correlated with the X-Ray traces
+type Exemplar struct {
+	TraceID     string            `json:"trace_id,omitempty"`
+	XRayTraceID string            `json:"xray_trace_id,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Value       float64           `json:"value"`
+	// Timestamp is in milliseconds, 0 when the exemplar has none
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// exemplarCollector scrapes the targets in the OpenMetrics format once more each scrape interval of their job and
+// keeps the exemplars of their samples, which the scrape loop of prometheus drops, for the metrics handler. The
+// targets answering in another format are not scraped again until they are gone or the config is reloaded.
+type exemplarCollector struct {
+	sm ScrapeManager
+
+	mu sync.Mutex
+	// jobs are the clients of the jobs by the name of their scrape config
+	jobs map[string]*exemplarJob
+	// exemplars are the exemplars of the last scrape of every target by job and instance, then by metric name
+	exemplars map[targetKey]map[string][]seriesExemplar
+	scraped   map[targetKey]time.Time
+	inflight  map[targetKey]bool
+	// unsupported are the targets which do not expose the OpenMetrics format
+	unsupported map[targetKey]bool
+}
+
+type exemplarJob struct {
+	client   *http.Client
+	interval time.Duration
+	timeout  time.Duration
+}
+
+type targetKey struct {
+	job      string
+	instance string
+}
+
+type seriesExemplar struct {
+	// labels are the labels of the series in the exposition, without the metric name and the target labels
+	labels   labels.Labels
+	exemplar exemplar.Exemplar
+}
+
+func newExemplarCollector() *exemplarCollector {
+	return &exemplarCollector{
+		jobs:        map[string]*exemplarJob{},
+		exemplars:   map[targetKey]map[string][]seriesExemplar{},
+		scraped:     map[targetKey]time.Time{},
+		inflight:    map[targetKey]bool{},
+		unsupported: map[targetKey]bool{},
+	}
+}
+
+// SetScrapeManager sets the manager of the targets scraped for the exemplars
+func (ec *exemplarCollector) SetScrapeManager(sm ScrapeManager) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.sm = sm
+}
+
+// applyConfig creates the clients of the jobs of the prometheus config, it is called on every reload
+func (ec *exemplarCollector) applyConfig(cfg *config.Config) error {
+	jobs := map[string]*exemplarJob{}
+	for _, sc := range cfg.ScrapeConfigs {
+		client, err := config_util.NewClientFromConfig(sc.HTTPClientConfig, sc.JobName, false)
+		if err != nil {
+			return fmt.Errorf("unable to create the client of the exemplars of the job %v: %v", sc.JobName, err)
+		}
+		jobs[sc.JobName] = &exemplarJob{client: client, interval: time.Duration(sc.ScrapeInterval), timeout: time.Duration(sc.ScrapeTimeout)}
+	}
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.jobs = jobs
+	// the targets may have switched to the OpenMetrics format since the last reload
+	ec.unsupported = map[targetKey]bool{}
+	return nil
+}
+
+// run scrapes the targets whose scrape interval elapsed until the shutdown
+func (ec *exemplarCollector) run(shutDownChan chan interface{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(exemplarTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ec.scrapeDue(time.Now())
+		case <-shutDownChan:
+			return
+		}
+	}
+}
+
+// scrapeDue starts the scrapes of the targets due and forgets the exemplars of the targets which are gone
+func (ec *exemplarCollector) scrapeDue(now time.Time) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if ec.sm == nil {
+		return
+	}
+	active := map[targetKey]bool{}
+	for jobName, targets := range ec.sm.TargetsAll() {
+		job, ok := ec.jobs[jobName]
+		if !ok {
+			continue
+		}
+		for _, t := range targets {
+			key := targetKey{job: t.Labels().Get(model.JobLabel), instance: t.Labels().Get(model.InstanceLabel)}
+			active[key] = true
+			if ec.inflight[key] || ec.unsupported[key] || now.Sub(ec.scraped[key]) < job.interval {
+				continue
+			}
+			ec.inflight[key] = true
+			ec.scraped[key] = now
+			go ec.scrape(key, t, job)
+		}
+	}
+	for key := range ec.scraped {
+		if !active[key] {
+			delete(ec.scraped, key)
+			delete(ec.exemplars, key)
+			delete(ec.unsupported, key)
+		}
+	}
+}
+
+func (ec *exemplarCollector) scrape(key targetKey, t *scrape.Target, job *exemplarJob) {
+	exemplars, err := scrapeExemplars(job.client, t.URL().String(), job.timeout, t.Labels())
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	delete(ec.inflight, key)
+	if err == errNotOpenMetrics {
+		log.Printf("D! prometheus_scraper: %v does not expose the OpenMetrics format, its exemplars are not scraped", t.URL())
+		if _, ok := ec.scraped[key]; ok {
+			ec.unsupported[key] = true
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("D! prometheus_scraper: unable to scrape the exemplars of %v: %v", t.URL(), err)
+		return
+	}
+	if _, ok := ec.scraped[key]; ok {
+		ec.exemplars[key] = exemplars
+	}
+}
+
+// scrapeExemplars returns the exemplars of the samples of the target by metric name, errNotOpenMetrics when it does
+// not expose its metrics in the OpenMetrics format
+func scrapeExemplars(client *http.Client, url string, timeout time.Duration, targetLabels labels.Labels) (map[string][]seriesExemplar, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", openMetricsAcceptHeader)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), openMetricsContentType) {
+		return nil, errNotOpenMetrics
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxExemplarScrapeBytes))
+	if err != nil {
+		return nil, err
+	}
+	return parseExemplars(body, targetLabels)
+}
+
+// parseExemplars returns the exemplars of the samples of an OpenMetrics exposition by metric name
+func parseExemplars(body []byte, targetLabels labels.Labels) (map[string][]seriesExemplar, error) {
+	result := map[string][]seriesExemplar{}
+	p := textparse.NewOpenMetricsParser(body)
+	for {
+		entry, err := p.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry != textparse.EntrySeries {
+			continue
+		}
+		var e exemplar.Exemplar
+		if !p.Exemplar(&e) {
+			continue
+		}
+		var lset labels.Labels
+		p.Metric(&lset)
+		name := lset.Get(model.MetricNameLabel)
+		series := make(labels.Labels, 0, len(lset))
+		for _, l := range lset {
+			// the labels of the target replace the ones of the exposition unless honor_labels is set, they are
+			// not compared
+			if l.Name != model.MetricNameLabel && targetLabels.Get(l.Name) == "" {
+				series = append(series, l)
+			}
+		}
+		result[name] = append(result[name], seriesExemplar{labels: series, exemplar: e})
+	}
+}
+
+// lookup returns the exemplar of the series of the metric whose labels are among the tags, the tags of the metrics
+// have the target labels on top of the ones of the exposition
+func (ec *exemplarCollector) lookup(job, instance, metricName string, tags map[string]string) (Exemplar, bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	for _, s := range ec.exemplars[targetKey{job: job, instance: instance}][metricName] {
+		if matchesTags(s.labels, tags) {
+			return newExemplar(s.exemplar), true
+		}
+	}
+	return Exemplar{}, false
+}
+
+func matchesTags(lset labels.Labels, tags map[string]string) bool {
+	for _, l := range lset {
+		if v, ok := tags[l.Name]; !ok || v != l.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func newExemplar(e exemplar.Exemplar) Exemplar {
+	result := Exemplar{Labels: e.Labels.Map(), Value: e.Value}
+	if e.HasTs {
+		result.Timestamp = e.Ts
+	}
+	for _, name := range traceIDLabels {
+		if id := e.Labels.Get(name); id != "" {
+			result.TraceID = id
+			result.XRayTraceID = toXRayTraceID(id)
+			break
+		}
+	}
+	return result
+}
+
+// toXRayTraceID returns the X-Ray trace id of a W3C trace id, its first 8 hex digits are the epoch of the trace,
+// "" when it is not a W3C or an X-Ray trace id
+func toXRayTraceID(id string) string {
+	id = strings.ToLower(id)
+	switch {
+	case xrayTraceID.MatchString(id):
+		return id
+	case w3cTraceID.MatchString(id):
+		return "1-" + id[:8] + "-" + id[8:]
+	}
+	return ""
+}
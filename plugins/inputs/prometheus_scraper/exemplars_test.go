@@ -0,0 +1,160 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package prometheus_scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openMetricsExposition = `# TYPE http_requests counter
+http_requests_total{code="200",job="app"} 10 # {trace_id="0af7651916cd43dd8448eb211c80319c"} 1.5 1600000000.123
+http_requests_total{code="500"} 1
+# TYPE queue_size gauge
+queue_size 3
+# EOF
+`
+
+func TestParseExemplars(t *testing.T) {
+	exemplars, err := parseExemplars([]byte(openMetricsExposition), labels.FromStrings("job", "jobA", "instance", "host:8080"))
+	require.NoError(t, err)
+	require.Len(t, exemplars, 1)
+	require.Len(t, exemplars["http_requests_total"], 1)
+	series := exemplars["http_requests_total"][0]
+	// the job label of the exposition is replaced by the one of the target
+	assert.Equal(t, labels.FromStrings("code", "200"), series.labels)
+
+	e := newExemplar(series.exemplar)
+	assert.Equal(t, Exemplar{
+		TraceID:     "0af7651916cd43dd8448eb211c80319c",
+		XRayTraceID: "1-0af76519-16cd43dd8448eb211c80319c",
+		Labels:      map[string]string{"trace_id": "0af7651916cd43dd8448eb211c80319c"},
+		Value:       1.5,
+		Timestamp:   1600000000123,
+	}, e)
+
+	_, err = parseExemplars([]byte("invalid{"), nil)
+	assert.Error(t, err)
+}
+
+func TestToXRayTraceID(t *testing.T) {
+	assert.Equal(t, "1-5f84c7a1-e3b7d5a2c4f6e8d0b2a49c1e", toXRayTraceID("1-5f84c7a1-e3b7d5a2c4f6e8d0b2a49c1e"))
+	assert.Equal(t, "1-5f84c7a1-e3b7d5a2c4f6e8d0b2a49c1e", toXRayTraceID("5F84C7A1E3B7D5A2C4F6E8D0B2A49C1E"))
+	assert.Equal(t, "", toXRayTraceID("span-1"))
+}
+
+func TestScrapeExemplars(t *testing.T) {
+	contentType := "application/openmetrics-text; version=0.0.1; charset=utf-8"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Accept"), "application/openmetrics-text")
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(openMetricsExposition))
+	}))
+	defer server.Close()
+
+	exemplars, err := scrapeExemplars(http.DefaultClient, server.URL, time.Second, nil)
+	require.NoError(t, err)
+	assert.Len(t, exemplars["http_requests_total"], 1)
+
+	// the targets exposing the text format have no exemplars
+	contentType = "text/plain; version=0.0.4"
+	exemplars, err = scrapeExemplars(http.DefaultClient, server.URL, time.Second, nil)
+	assert.Equal(t, errNotOpenMetrics, err)
+	assert.Empty(t, exemplars)
+}
+
+type targetsScrapeManager map[string][]*scrape.Target
+
+func (sm targetsScrapeManager) TargetsAll() map[string][]*scrape.Target {
+	return sm
+}
+
+func TestExemplarCollectorNotOpenMetrics(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte("queue_size 3\n"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	lset := labels.FromMap(map[string]string{
+		model.JobLabel:         "app",
+		model.InstanceLabel:    serverURL.Host,
+		model.AddressLabel:     serverURL.Host,
+		model.SchemeLabel:      "http",
+		model.MetricsPathLabel: "/metrics",
+	})
+	ec := newExemplarCollector()
+	ec.jobs["app"] = &exemplarJob{client: http.DefaultClient, interval: time.Minute, timeout: time.Second}
+	ec.SetScrapeManager(targetsScrapeManager{"app": {scrape.NewTarget(lset, lset, nil)}})
+	key := targetKey{job: "app", instance: serverURL.Host}
+	unsupported := func() bool {
+		ec.mu.Lock()
+		defer ec.mu.Unlock()
+		return ec.unsupported[key]
+	}
+
+	now := time.Now()
+	ec.scrapeDue(now)
+	require.Eventually(t, unsupported, 5*time.Second, 10*time.Millisecond)
+
+	// the target is not scraped again once it answered in another format
+	ec.scrapeDue(now.Add(time.Minute))
+	ec.scrapeDue(now.Add(2 * time.Minute))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// until it is gone
+	ec.SetScrapeManager(targetsScrapeManager{})
+	ec.scrapeDue(now.Add(3 * time.Minute))
+	assert.False(t, unsupported())
+}
+
+func TestMetricsHandlerExemplars(t *testing.T) {
+	ec := newExemplarCollector()
+	exemplars, err := parseExemplars([]byte(openMetricsExposition), labels.FromStrings("job", "jobA", "instance", "host:8080"))
+	require.NoError(t, err)
+	ec.exemplars[targetKey{job: "jobA", instance: "host:8080"}] = exemplars
+
+	acc := &testutil.Accumulator{}
+	mh := &metricsHandler{acc: acc, exemplars: ec}
+	tags := func(code string) map[string]string {
+		return map[string]string{"job": "jobA", "instance": "host:8080", "code": code}
+	}
+	mms := []*metricMaterial{
+		{tags: tags("200"), fields: map[string]interface{}{"http_requests_total": 2.0}},
+		{tags: tags("500"), fields: map[string]interface{}{"http_requests_total": 1.0}},
+	}
+	mh.setEmfMetadata(mms)
+	mh.publish(mms)
+	require.Len(t, acc.Metrics, 2)
+	for _, m := range acc.Metrics {
+		if m.Tags["code"] == "200" {
+			assert.Equal(t, exemplarsField, m.Tags["attributesInFields"])
+			e := m.Fields[exemplarsField].(map[string]Exemplar)
+			assert.Equal(t, "1-0af76519-16cd43dd8448eb211c80319c", e["http_requests_total"].XRayTraceID)
+		} else {
+			assert.NotContains(t, m.Fields, exemplarsField)
+		}
+	}
+
+	// the exemplars of the other targets are not used
+	assert.Empty(t, mh.metricExemplars(&metricMaterial{
+		tags:   map[string]string{"job": "jobB", "instance": "host:8080", "code": "200"},
+		fields: map[string]interface{}{"http_requests_total": 2.0},
+	}))
+}
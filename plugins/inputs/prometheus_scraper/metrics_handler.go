@@ -7,10 +7,15 @@ import (
 	"log"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/aws/amazon-cloudwatch-agent/internal/containerinsightscommon"
+	"github.com/aws/amazon-cloudwatch-agent/internal/k8sCommon/k8sleader"
 	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
+	"github.com/aws/amazon-cloudwatch-agent/internal/structuredlogscommon"
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/prometheus/common/model"
 )
 
 // Use metricMaterial instead of mbMetric to avoid unnecessary tags&fields copy
@@ -27,6 +32,16 @@ type metricsHandler struct {
 	filter      *MetricsFilter
 	clusterName string
 	mtHandler   *metricsTypeHandler
+	// leaderOnly drops the metrics unless the replica is the leader, after the deltas are calculated so the new
+	// leader publishes the right ones once it takes over
+	leaderOnly bool
+	// exemplars are the exemplars of the targets embedded in the documents, nil when they are not collected
+	exemplars *exemplarCollector
+}
+
+// isLeader returns whether this replica of the agent publishes the metrics of the cluster-wide targets
+var isLeader = func() bool {
+	return k8sleader.Get().IsLeader()
 }
 
 func (mh *metricsHandler) start(shutDownChan chan interface{}, wg *sync.WaitGroup) {
@@ -45,24 +60,65 @@ func (mh *metricsHandler) start(shutDownChan chan interface{}, wg *sync.WaitGrou
 func (mh *metricsHandler) handle(pmb PrometheusMetricBatch) {
 	// Add metric type info
 	pmb = mh.mtHandler.Handle(pmb)
+	recordBatch(pmb)
+
+	mh.process(pmb)
+}
 
+// process publishes the metrics of a scrape whose types are known
+func (mh *metricsHandler) process(pmb PrometheusMetricBatch) {
 	// Filter out Histogram and untyped Metrics and adding logging
 	pmb = mh.filter.Filter(pmb)
 
 	// do calculation: calculate delta for counter
 	pmb = mh.calculator.Calculate(pmb)
 
+	if mh.leaderOnly && !isLeader() {
+		return
+	}
+
 	// do merge: merge metrics which are sharing same tags
 	metricMaterials := mergeMetrics(pmb)
 
 	// set emf
 	mh.setEmfMetadata(metricMaterials)
 
+	mh.publish(metricMaterials)
+}
+
+// publish adds the documents to the accumulator with the exemplars of their metrics
+func (mh *metricsHandler) publish(metricMaterials []*metricMaterial) {
 	for _, metricMaterial := range metricMaterials {
+		if exemplars := mh.metricExemplars(metricMaterial); len(exemplars) > 0 {
+			m, err := metric.New("prometheus_scraper", metricMaterial.tags, metricMaterial.fields, time.Now())
+			if err == nil {
+				structuredlogscommon.AppendAttributesInFields(exemplarsField, exemplars, m)
+				mh.acc.AddMetric(m)
+				continue
+			}
+		}
 		mh.acc.AddFields("prometheus_scraper", metricMaterial.fields, metricMaterial.tags)
 	}
 }
 
+// metricExemplars returns the exemplars of the metrics of the document by metric name
+func (mh *metricsHandler) metricExemplars(mm *metricMaterial) map[string]Exemplar {
+	if mh.exemplars == nil {
+		return nil
+	}
+	job, instance := mm.tags[model.JobLabel], mm.tags[model.InstanceLabel]
+	var result map[string]Exemplar
+	for name := range mm.fields {
+		if e, ok := mh.exemplars.lookup(job, instance, name, mm.tags); ok {
+			if result == nil {
+				result = map[string]Exemplar{}
+			}
+			result[name] = e
+		}
+	}
+	return result
+}
+
 // set timestamp, version, logstream
 func (mh *metricsHandler) setEmfMetadata(mms []*metricMaterial) {
 	for _, mm := range mms {
@@ -4,9 +4,12 @@
 package prometheus_scraper
 
 import (
+	"os"
 	"sync"
 
 	"github.com/aws/amazon-cloudwatch-agent/internal/ecsservicediscovery"
+	"github.com/aws/amazon-cloudwatch-agent/internal/k8sCommon/k8sleader"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -15,15 +18,27 @@ type PrometheusScraper struct {
 	PrometheusConfigPath string                                      `toml:"prometheus_config_path"`
 	ClusterName          string                                      `toml:"cluster_name"`
 	ECSSDConfig          *ecsservicediscovery.ServiceDiscoveryConfig `toml:"ecs_service_discovery"`
-	mbCh                 chan PrometheusMetricBatch
-	shutDownChan         chan interface{}
-	wg                   sync.WaitGroup
+	// Only publish the metrics of the cluster-wide targets from the replica of the agent elected as the leader
+	LeaderElection bool `toml:"leader_election"`
+	// Scrape the targets in the OpenMetrics format once more each scrape interval for the exemplars of their samples,
+	// embedded in the documents of their metrics
+	Exemplars    bool `toml:"exemplars"`
+	mbCh         chan PrometheusMetricBatch
+	shutDownChan chan interface{}
+	wg           sync.WaitGroup
 }
 
+const envHostName = "HOST_NAME"
+
 const sampleConfig = `
   [[inputs.prometheus_scraper]]
     cluster_name = "EC2-EC2-Justin-Testing"
     prometheus_config_path = "/opt/aws/amazon-cloudwatch-agent/etc/prometheus.yaml"
+    ## Only publish the metrics from the replica elected as the leader with the
+    ## cwagent-clusterleader lease in K8S_NAMESPACE, for the cluster-wide targets
+    # leader_election = true
+    ## Embed the exemplars of the OpenMetrics targets, e.g. their trace ids, in the documents of their metrics
+    # exemplars = true
     [inputs.prometheus_scraper.ecs_service_discovery]
       sd_cluster_region = "us-east-2"
       sd_frequency = "15s"
@@ -68,6 +83,18 @@ func (p *PrometheusScraper) Start(accIn telegraf.Accumulator) error {
 		filter:      NewMetricsFilter(),
 		clusterName: p.ClusterName,
 		mtHandler:   mth,
+		leaderOnly:  p.LeaderElection,
+	}
+	var ec *exemplarCollector
+	if p.Exemplars {
+		ec = newExemplarCollector()
+		handler.exemplars = ec
+	}
+
+	if p.LeaderElection {
+		if err := k8sleader.Get().Start(leaderIdentity()); err != nil {
+			return err
+		}
 	}
 
 	ecssd := &ecsservicediscovery.ServiceDiscovery{Config: p.ECSSDConfig}
@@ -78,7 +105,12 @@ func (p *PrometheusScraper) Start(accIn telegraf.Accumulator) error {
 
 	// start metric collecting
 	p.wg.Add(1)
-	go Start(p.PrometheusConfigPath, receiver, p.shutDownChan, &p.wg, mth)
+	go Start(p.PrometheusConfigPath, receiver, p.shutDownChan, &p.wg, mth, ec)
+
+	if ec != nil {
+		p.wg.Add(1)
+		go ec.run(p.shutDownChan, &p.wg)
+	}
 
 	// start metric handling
 	p.wg.Add(1)
@@ -90,6 +122,18 @@ func (p *PrometheusScraper) Start(accIn telegraf.Accumulator) error {
 func (p *PrometheusScraper) Stop() {
 	close(p.shutDownChan)
 	p.wg.Wait()
+	if p.LeaderElection {
+		k8sleader.Get().Stop()
+	}
+}
+
+// leaderIdentity returns the identity of the replica in the leader election, the node it runs on
+func leaderIdentity() string {
+	if nodeName := os.Getenv(envHostName); nodeName != "" {
+		return nodeName
+	}
+	hostName, _ := os.Hostname()
+	return hostName
 }
 
 func init() {
@@ -98,3 +142,11 @@ func init() {
 			shutDownChan: make(chan interface{})}
 	})
 }
+
+// PreflightIAM checks that the actions of the ECS service discovery are allowed when it is configured
+func (p *PrometheusScraper) PreflightIAM() []preflight.Check {
+	if p.ECSSDConfig == nil {
+		return nil
+	}
+	return p.ECSSDConfig.PreflightIAM()
+}
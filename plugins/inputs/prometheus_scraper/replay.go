@@ -0,0 +1,106 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package prometheus_scraper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
+	"github.com/influxdata/telegraf"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// recordBatch records the samples of a scrape once their types are known, in the text exposition format with the
+// type of every sample in its prom_metric_type label, since the metadata of the target is not available on replay
+func recordBatch(pmb PrometheusMetricBatch) {
+	if len(pmb) == 0 || !recorder.Recorder.Enabled() {
+		return
+	}
+	_, instance, _ := GetScrapeTargetInfo(pmb)
+	recorder.Recorder.Record(recorder.KindPrometheus, instance, encodeBatch(pmb))
+}
+
+func encodeBatch(pmb PrometheusMetricBatch) []byte {
+	var buf bytes.Buffer
+	for _, pm := range pmb {
+		names := make([]string, 0, len(pm.tags))
+		for name := range pm.tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		buf.WriteString(pm.metricName)
+		buf.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, `%s="%s"`, name, labelValueEscaper.Replace(pm.tags[name]))
+		}
+		fmt.Fprintf(&buf, "} %s %d\n", strconv.FormatFloat(pm.metricValue, 'g', -1, 64), pm.timeInMS)
+	}
+	return buf.Bytes()
+}
+
+func decodeBatch(payload []byte) (PrometheusMetricBatch, error) {
+	var pmb PrometheusMetricBatch
+	p := textparse.NewPromParser(payload)
+	for {
+		entry, err := p.Next()
+		if err == io.EOF {
+			return pmb, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry != textparse.EntrySeries {
+			continue
+		}
+		_, ts, v := p.Series()
+		var ls labels.Labels
+		p.Metric(&ls)
+
+		pm := &PrometheusMetric{tags: make(map[string]string, len(ls)), metricValue: v}
+		for _, l := range ls {
+			if l.Name == model.MetricNameLabel {
+				pm.metricName = l.Value
+				continue
+			}
+			pm.tags[l.Name] = l.Value
+		}
+		pm.metricType = pm.tags[prometheusMetricTypeKey]
+		if ts != nil {
+			pm.timeInMS = *ts
+		}
+		pmb = append(pmb, pm)
+	}
+}
+
+// ReplayMetrics runs the recorded scrapes through the filter, the delta calculation and the merge of the metrics
+// into the documents, in the order they were recorded. The leader election and the exemplars are not replayed.
+func (p *PrometheusScraper) ReplayMetrics(samples []recorder.Sample, acc telegraf.Accumulator) error {
+	handler := &metricsHandler{
+		acc:         acc,
+		calculator:  NewCalculator(),
+		filter:      NewMetricsFilter(),
+		clusterName: p.ClusterName,
+	}
+	for _, sample := range recorder.SamplesOfKind(samples, recorder.KindPrometheus) {
+		pmb, err := decodeBatch(sample.Payload)
+		if err != nil {
+			return fmt.Errorf("invalid scrape of %v recorded at %v: %v", sample.Source, sample.Time, err)
+		}
+		handler.process(pmb)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package prometheus_scraper
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordedScrape(timeInMS int64, requests, inflight float64) PrometheusMetricBatch {
+	return PrometheusMetricBatch{
+		{metricName: "requests_total", metricValue: requests, metricType: "counter", timeInMS: timeInMS,
+			tags: map[string]string{"job": "app", "instance": "10.0.0.1:8080", "path": `/a"b`, prometheusMetricTypeKey: "counter"}},
+		{metricName: "inflight", metricValue: inflight, metricType: "gauge", timeInMS: timeInMS,
+			tags: map[string]string{"job": "app", "instance": "10.0.0.1:8080", "path": `/a"b`, prometheusMetricTypeKey: "gauge"}},
+	}
+}
+
+func TestEncodeDecodeBatch(t *testing.T) {
+	pmb := recordedScrape(1000, 10, 3)
+	decoded, err := decodeBatch(encodeBatch(pmb))
+	assert.NoError(t, err)
+	assert.Equal(t, pmb, decoded)
+}
+
+func TestReplayMetrics(t *testing.T) {
+	p := &PrometheusScraper{ClusterName: "cluster"}
+	acc := &testutil.Accumulator{}
+	samples := []recorder.Sample{
+		{Kind: recorder.KindPrometheus, Source: "10.0.0.1:8080", Payload: encodeBatch(recordedScrape(1000, 10, 3))},
+		{Kind: recorder.KindStatsd, Payload: []byte("ignored:1|c")},
+		{Kind: recorder.KindPrometheus, Source: "10.0.0.1:8080", Payload: encodeBatch(recordedScrape(61000, 15, 4))},
+	}
+
+	assert.NoError(t, p.ReplayMetrics(samples, acc))
+	var fields []map[string]interface{}
+	for _, m := range acc.Metrics {
+		assert.Equal(t, "cluster", m.Tags["ClusterName"])
+		fields = append(fields, m.Fields)
+	}
+	// the first scrape only sets the base of the counter
+	assert.ElementsMatch(t, []map[string]interface{}{
+		{"inflight": float64(3)},
+		{"inflight": float64(4)},
+		{"requests_total": float64(5)},
+	}, fields)
+}
+
+func TestReplayMetricsInvalidPayload(t *testing.T) {
+	p := &PrometheusScraper{}
+	samples := []recorder.Sample{{Kind: recorder.KindPrometheus, Payload: []byte("requests_total{ 1\n")}}
+	assert.Error(t, p.ReplayMetrics(samples, &testutil.Accumulator{}))
+}
@@ -58,7 +58,7 @@ func init() {
 	prometheus.MustRegister(version.NewCollector("prometheus"))
 }
 
-func Start(configFilePath string, receiver storage.Appendable, shutDownChan chan interface{}, wg *sync.WaitGroup, mth *metricsTypeHandler) {
+func Start(configFilePath string, receiver storage.Appendable, shutDownChan chan interface{}, wg *sync.WaitGroup, mth *metricsTypeHandler, ec *exemplarCollector) {
 	infoLevel := &promlog.AllowedLevel{}
 	_ = infoLevel.Set("info")
 
@@ -112,6 +112,10 @@ func Start(configFilePath string, receiver storage.Appendable, shutDownChan chan
 			return discoveryManagerScrape.ApplyConfig(c)
 		},
 	}
+	if ec != nil {
+		ec.SetScrapeManager(scrapeManager)
+		reloaders = append(reloaders, ec.applyConfig)
+	}
 	prometheus.MustRegister(configSuccess)
 	prometheus.MustRegister(configSuccessTime)
 
@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/internal/secrets"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/datastore"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	telegrafredis "github.com/influxdata/telegraf/plugins/inputs/redis"
+)
+
+const measurement = "redis"
+
+// Redis is the redis input of telegraf with the password of its servers kept in Secrets Manager, and the hit rate of
+// the keyspace computed over the collection interval rather than since the start of the servers
+type Redis struct {
+	telegrafredis.Redis
+	secrets.Reference
+
+	derivations *datastore.Derivations
+}
+
+const secretSampleConfig = `
+  ## The ARN or the name of the secret of Secrets Manager with the password of the servers, either the password
+  ## itself or a JSON object with a "password" key, it replaces the one of the servers.
+  # secret_id = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/redis-auth"
+  # region = "us-east-1"
+`
+
+func (r *Redis) SampleConfig() string {
+	return r.Redis.SampleConfig() + secretSampleConfig
+}
+
+func (r *Redis) Description() string {
+	return "Read metrics from one or many redis servers, with their password in Secrets Manager"
+}
+
+func (r *Redis) Gather(acc telegraf.Accumulator) error {
+	credentials, ok, err := r.Credentials()
+	if err != nil {
+		return err
+	}
+	if ok && credentials.Password != r.Password {
+		// the clients of the servers are created with the password on the first collection, they are created again
+		// when the secret was rotated
+		r.Redis = telegrafredis.Redis{
+			Servers:      r.Servers,
+			Password:     credentials.Password,
+			ClientConfig: r.ClientConfig,
+			Log:          r.Log,
+		}
+	}
+	return r.Redis.Gather(r.derivations.Accumulator(acc))
+}
+
+func init() {
+	// replaces the redis input of telegraf registered by its package
+	inputs.Add(measurement, func() telegraf.Input {
+		return &Redis{
+			derivations: &datastore.Derivations{
+				Measurement: measurement,
+				HitRates:    []datastore.HitRate{{Field: datastore.HitRateField, Hits: "keyspace_hits", Misses: "keyspace_misses"}},
+			},
+		}
+	})
+}
@@ -0,0 +1,27 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	r := inputs.Inputs["redis"]().(*Redis)
+	require.NoError(t, toml.Unmarshal([]byte(`
+servers = ["tcp://cache.internal:6379"]
+secret_id = "prod/redis-auth"
+region = "us-east-1"
+role_arn = "arn:aws:iam::123456789012:role/monitor"
+`), r))
+	assert.Equal(t, []string{"tcp://cache.internal:6379"}, r.Servers)
+	assert.Equal(t, "prod/redis-auth", r.SecretID)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/monitor", r.RoleARN)
+	assert.Empty(t, r.Password)
+}
@@ -0,0 +1,60 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"strings"
+)
+
+// NamespaceTagKey is the tag routing the metrics of a prefix rule to its namespace, the translator configures the
+// cloudwatch output to publish the metrics with this tag to the namespace of its value and to drop the tag.
+const NamespaceTagKey = "aws:StatsDNamespace"
+
+// PrefixRule maps the metrics whose name starts with the prefix to a namespace and their tags to dimensions, so that
+// a single statsd listener can serve several applications.
+type PrefixRule struct {
+	// Prefix of the bucket names as sent by the clients, i.e. before the templates and the metric separator apply
+	Prefix string `toml:"prefix"`
+	// Namespace of the metrics, the one of the cloudwatch output when empty
+	Namespace string `toml:"namespace"`
+	// StripPrefix removes the prefix from the metric names
+	StripPrefix bool `toml:"strip_prefix"`
+	// Dimensions are the tags kept as dimensions, the other tags are dropped. All the tags are kept when empty.
+	Dimensions []string `toml:"dimensions"`
+}
+
+// prefixRule returns the first rule matching the bucket, nil when none does
+func (s *Statsd) prefixRule(bucket string) *PrefixRule {
+	for i := range s.PrefixRules {
+		if strings.HasPrefix(bucket, s.PrefixRules[i].Prefix) {
+			return &s.PrefixRules[i]
+		}
+	}
+	return nil
+}
+
+// stripPrefix returns the bucket without the prefix of the rule when configured, the rule may be nil
+func (r *PrefixRule) stripPrefix(bucket string) string {
+	if r == nil || !r.StripPrefix || len(bucket) <= len(r.Prefix) {
+		return bucket
+	}
+	return bucket[len(r.Prefix):]
+}
+
+// apply returns the tags of the metric mapped by the rule
+func (r *PrefixRule) apply(tags map[string]string) map[string]string {
+	if len(r.Dimensions) > 0 {
+		kept := make(map[string]string, len(r.Dimensions)+1)
+		for _, dim := range r.Dimensions {
+			if v, ok := tags[dim]; ok {
+				kept[dim] = v
+			}
+		}
+		tags = kept
+	}
+	if r.Namespace != "" {
+		tags[NamespaceTagKey] = r.Namespace
+	}
+	return tags
+}
@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixRules(t *testing.T) {
+	s := NewTestStatsd()
+	s.ParseDataDogTags = true
+	s.PrefixRules = []PrefixRule{
+		{Prefix: "checkout.", Namespace: "Checkout", StripPrefix: true, Dimensions: []string{"endpoint"}},
+		{Prefix: "check", Namespace: "Other"},
+		{Prefix: "search.", Dimensions: []string{"index"}},
+	}
+	for _, line := range []string{
+		"checkout.requests:1|c|#endpoint:/pay,pod:abc",
+		"checkout.requests:2|c|#endpoint:/pay,pod:def",
+		"checkout:5|g",
+		"checks.failed:3|c",
+		"search.latency:12|g|#index:products,pod:abc",
+		"other.requests:4|c|#pod:abc",
+	} {
+		assert.NoError(t, s.parseStatsdLine(line))
+	}
+	acc := &testutil.Accumulator{}
+	assert.NoError(t, s.Gather(acc))
+
+	acc.AssertContainsTaggedFields(t, "requests", map[string]interface{}{"value": int64(3)},
+		map[string]string{"endpoint": "/pay", NamespaceTagKey: "Checkout"})
+	// the first matching rule applies
+	acc.AssertContainsTaggedFields(t, "checkout", map[string]interface{}{"value": float64(5)},
+		map[string]string{"metric_type": "gauge", NamespaceTagKey: "Other"})
+	acc.AssertContainsTaggedFields(t, "checks_failed", map[string]interface{}{"value": int64(3)},
+		map[string]string{"metric_type": "counter", NamespaceTagKey: "Other"})
+	acc.AssertContainsTaggedFields(t, "search_latency", map[string]interface{}{"value": float64(12)},
+		map[string]string{"index": "products"})
+	acc.AssertContainsTaggedFields(t, "other_requests", map[string]interface{}{"value": int64(4)},
+		map[string]string{"metric_type": "counter", "pod": "abc"})
+}
@@ -4,10 +4,15 @@
 package statsd
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/listenauth"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/statsd/graphite"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
 	"log"
 	"net"
 	"sort"
@@ -31,6 +36,9 @@ const (
 
 	defaultSeparator           = "_"
 	defaultAllowPendingMessage = 10000
+
+	// maxTCPConnections bounds the connections served at once, the others wait to be accepted
+	maxTCPConnections = 256
 )
 
 var dropwarn = "E! Error: statsd message queue full. " +
@@ -40,6 +48,10 @@ var dropwarn = "E! Error: statsd message queue full. " +
 type Statsd struct {
 	// Address & Port to serve from
 	ServiceAddress string
+	// Protocol is udp, the default, or tcp, the lines of the tcp connections are separated by newlines
+	Protocol string `toml:"protocol"`
+	// Auth authenticates the clients by their address, a shared token and, over tcp, their certificate
+	Auth listenauth.Config `toml:"auth"`
 
 	// Number of messages allowed to queue up in between calls to Gather. If this
 	// fills up, packets will get dropped until the next Gather interval is ran.
@@ -82,7 +94,19 @@ type Statsd struct {
 	// bucket -> influx templates
 	Templates []string
 
-	listener *net.UDPConn
+	// TimingOutput publishes the timings and histograms as distributions, the default, or as the separate metrics of
+	// their statistics and Percentiles with "statistics"
+	TimingOutput string            `toml:"timing_output"`
+	Percentiles  []internal.Number `toml:"percentiles"`
+
+	// The rules mapping the metric name prefixes to namespaces and dimensions, the first matching rule applies
+	PrefixRules []PrefixRule `toml:"prefix_rule"`
+
+	listener    *net.UDPConn
+	tcpListener net.Listener
+	auth        *listenauth.Authenticator
+	// rejected counts the datagrams and connections of the clients which were not authenticated
+	rejected int
 
 	graphiteParser *graphite.GraphiteParser
 }
@@ -133,6 +157,18 @@ func (_ *Statsd) Description() string {
 const sampleConfig = `
   ## Address and port to host UDP listener on
   service_address = ":8125"
+  ## The protocol of the listener, "udp" or "tcp"
+  # protocol = "udp"
+
+  ## Authenticate the clients before accepting their metrics on non-loopback interfaces:
+  ## by their address, by a shared token sent as the first line "auth:<token>" of each
+  ## datagram or connection, and over tcp by a certificate signed by the allowed CAs
+  # [inputs.statsd.auth]
+  #   allowed_cidrs = ["10.0.0.0/8"]
+  #   token_file = "/etc/amazon/statsd-token"
+  #   tls_cert = "/etc/amazon/statsd.pem"
+  #   tls_key = "/etc/amazon/statsd.key"
+  #   tls_allowed_cacerts = ["/etc/amazon/clients-ca.pem"]
 
   ## The following configuration options control when telegraf clears it's cache
   ## of previous values. If set to false, then telegraf will only clear it's
@@ -166,6 +202,19 @@ const sampleConfig = `
   ## The aggregation interval for the metrics
   metric_aggregation_interval = "60s"
 
+  ## Publish the timings and histograms as distributions ("distribution") or as the separate
+  ## metrics of their min, max, avg, count and percentiles ("statistics")
+  # timing_output = "statistics"
+  # percentiles = [50, 90, 99]
+
+  ## Map the metrics whose name starts with a prefix to a namespace and keep only the given
+  ## tags as dimensions, the first matching rule applies
+  # [[inputs.statsd.prefix_rule]]
+  #   prefix = "checkout."
+  #   namespace = "Checkout"
+  #   strip_prefix = true
+  #   dimensions = ["endpoint"]
+
 `
 
 func (_ *Statsd) SampleConfig() string {
@@ -178,7 +227,7 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	now := time.Now()
 
 	for _, metric := range s.timings {
-		acc.AddFields(metric.name, metric.fields, metric.tags, now)
+		acc.AddFields(metric.name, s.timingFields(metric.fields), metric.tags, now)
 	}
 	if s.DeleteTimings {
 		s.timings = make(map[string]cachedtimings)
@@ -213,10 +262,55 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 }
 
 func (s *Statsd) Start(_ telegraf.Accumulator) error {
+	if err := s.validateTimingOutput(); err != nil {
+		return err
+	}
+	auth, err := s.Auth.Authenticator()
+	if err != nil {
+		return fmt.Errorf("statsd: invalid auth: %v", err)
+	}
+	s.auth = auth
 	// Make data structures
 	s.done = make(chan struct{})
 	s.in = make(chan []byte, s.AllowedPendingMessages)
+	s.initCache()
+
+	switch s.Protocol {
+	case "", "udp":
+		if s.auth.TLSConfig() != nil {
+			return errors.New("statsd: the tls auth requires the tcp protocol")
+		}
+		// IPv6 addresses must be enclosed in brackets, e.g. [::1]:8125
+		address, err := net.ResolveUDPAddr("udp", s.ServiceAddress)
+		if err != nil {
+			return fmt.Errorf("statsd: invalid address %v: %v", s.ServiceAddress, err)
+		}
+		if s.listener, err = net.ListenUDP("udp", address); err != nil {
+			return fmt.Errorf("statsd: unable to listen on %v: %v", s.ServiceAddress, err)
+		}
+		log.Println("I! Statsd listener listening on: ", s.listener.LocalAddr().String())
+		s.wg.Add(2)
+		// Start the UDP listener
+		go s.udpListen()
+	case "tcp":
+		l, err := net.Listen("tcp", s.ServiceAddress)
+		if err != nil {
+			return fmt.Errorf("statsd: unable to listen on %v: %v", s.ServiceAddress, err)
+		}
+		s.tcpListener = s.auth.Listener(l)
+		log.Println("I! Statsd listener listening on tcp: ", l.Addr().String())
+		s.wg.Add(2)
+		go s.tcpListen()
+	default:
+		return fmt.Errorf("statsd: invalid protocol %v, it must be udp or tcp", s.Protocol)
+	}
+	// Start the line parser
+	go s.parser()
+	log.Printf("I! Started the statsd service on %s\n", s.ServiceAddress)
+	return nil
+}
 
+func (s *Statsd) initCache() {
 	s.gauges = make(map[string]cachedgauge)
 	s.counters = make(map[string]cachedcounter)
 	s.sets = make(map[string]cachedset)
@@ -225,53 +319,138 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 	if s.MetricSeparator == "" {
 		s.MetricSeparator = defaultSeparator
 	}
+}
 
-	s.wg.Add(2)
-	// Start the UDP listener
-	go s.udpListen()
-	// Start the line parser
-	go s.parser()
-	log.Printf("I! Started the statsd service on %s\n", s.ServiceAddress)
-	return nil
+// Preflight checks that the statsd service is able to listen on its address
+func (s *Statsd) Preflight() []preflight.Check {
+	protocol := s.Protocol
+	if protocol == "" {
+		protocol = "udp"
+	}
+	return []preflight.Check{preflight.CheckListen(protocol, s.ServiceAddress)}
 }
 
-// udpListen starts listening for udp packets on the configured port.
-func (s *Statsd) udpListen() error {
-	defer s.wg.Done()
-	var err error
-	address, _ := net.ResolveUDPAddr("udp", s.ServiceAddress)
-	s.listener, err = net.ListenUDP("udp", address)
-	if err != nil {
-		log.Fatalf("ERROR: ListenUDP - %s", err)
+// ReplayMetrics parses the recorded statsd datagrams and gathers the resulting metrics once,
+// as if they had all been received within a single collection interval.
+func (s *Statsd) ReplayMetrics(samples []recorder.Sample, acc telegraf.Accumulator) error {
+	s.initCache()
+	for _, sample := range recorder.SamplesOfKind(samples, recorder.KindStatsd) {
+		s.parsePacket(sample.Payload)
 	}
-	log.Println("I! Statsd listener listening on: ", s.listener.LocalAddr().String())
+	return s.Gather(acc)
+}
 
+// udpListen reads the udp packets of the listener until it is closed
+func (s *Statsd) udpListen() error {
+	defer s.wg.Done()
 	buf := make([]byte, UDP_MAX_PACKET_SIZE)
 	for {
 		select {
 		case <-s.done:
 			return nil
 		default:
-			n, _, err := s.listener.ReadFromUDP(buf)
+			n, addr, err := s.listener.ReadFromUDP(buf)
 			if err != nil && !strings.Contains(err.Error(), "closed network") {
 				log.Printf("E! Error READ: %s\n", err.Error())
 				continue
 			}
-			bufCopy := make([]byte, n)
-			copy(bufCopy, buf[:n])
-
-			select {
-			case s.in <- bufCopy:
-			default:
-				s.drops++
-				if s.drops == 1 || s.AllowedPendingMessages == 0 || s.drops%s.AllowedPendingMessages == 0 {
-					log.Printf(dropwarn, s.drops)
+			packet := buf[:n]
+			if addr != nil {
+				if packet, err = s.auth.Packet(addr, packet); err != nil {
+					s.reject(addr, err)
+					continue
 				}
 			}
+			bufCopy := make([]byte, len(packet))
+			copy(bufCopy, packet)
+			s.enqueue(bufCopy)
+		}
+	}
+}
+
+// tcpListen accepts the tcp connections until the listener is closed, each line of a connection is a packet
+func (s *Statsd) tcpListen() {
+	defer s.wg.Done()
+	connections := make(chan struct{}, maxTCPConnections)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		connections <- struct{}{}
+		conn, err := s.tcpListener.Accept()
+		if err != nil {
+			if !strings.Contains(err.Error(), "closed network") {
+				log.Printf("E! statsd: unable to accept tcp connections: %v", err)
+			}
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				conn.Close()
+				<-connections
+				wg.Done()
+			}()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+func (s *Statsd) serveConn(conn net.Conn) {
+	// the connections are closed on stop
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-s.done:
+			conn.Close()
+		case <-stopped:
+		}
+	}()
+
+	r, err := s.auth.Conn(conn)
+	if err != nil {
+		s.reject(conn.RemoteAddr(), err)
+		return
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), UDP_MAX_PACKET_SIZE)
+	for scanner.Scan() {
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+		s.enqueue(line)
+	}
+	if err := scanner.Err(); err != nil && !strings.Contains(err.Error(), "closed network") {
+		log.Printf("D! statsd: closed the tcp connection of %v: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// enqueue records the packet and queues it for the parser, it is dropped when the queue is full
+func (s *Statsd) enqueue(packet []byte) {
+	recorder.Recorder.Record(recorder.KindStatsd, s.ServiceAddress, packet)
+	select {
+	case s.in <- packet:
+	default:
+		s.Lock()
+		s.drops++
+		drops := s.drops
+		s.Unlock()
+		if drops == 1 || s.AllowedPendingMessages == 0 || drops%s.AllowedPendingMessages == 0 {
+			log.Printf(dropwarn, drops)
 		}
 	}
 }
 
+// reject logs the first of the packets and connections which were not authenticated, and every thousandth after
+func (s *Statsd) reject(addr net.Addr, err error) {
+	s.Lock()
+	s.rejected++
+	rejected := s.rejected
+	s.Unlock()
+	if rejected == 1 || rejected%1000 == 0 {
+		log.Printf("W! statsd: rejected %v packets or connections so far, the last one from %v: %v", rejected, addr, err)
+	}
+}
+
 // parser monitors the s.in channel, if there is a packet ready, it parses the
 // packet into statsd strings and then calls parseStatsdLine, which parses a
 // single statsd metric into a struct.
@@ -283,13 +462,18 @@ func (s *Statsd) parser() error {
 		case <-s.done:
 			return nil
 		case packet = <-s.in:
-			lines := strings.Split(string(packet), "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if line != "" {
-					s.parseStatsdLine(line)
-				}
-			}
+			s.parsePacket(packet)
+		}
+	}
+}
+
+// parsePacket splits the packet into statsd lines and parses each of them.
+func (s *Statsd) parsePacket(packet []byte) {
+	lines := strings.Split(string(packet), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			s.parseStatsdLine(line)
 		}
 	}
 }
@@ -420,7 +604,8 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		}
 
 		// Parse the name & tags from bucket
-		m.name, m.field, m.tags = s.parseName(m.bucket)
+		rule := s.prefixRule(m.bucket)
+		m.name, m.field, m.tags = s.parseName(rule.stripPrefix(m.bucket))
 		switch m.mtype {
 		case "c":
 			m.tags["metric_type"] = "counter"
@@ -440,6 +625,10 @@ func (s *Statsd) parseStatsdLine(line string) error {
 			}
 		}
 
+		if rule != nil {
+			m.tags = rule.apply(m.tags)
+		}
+
 		// Make a unique key for the measurement name/tags
 		var tg []string
 		for k, v := range m.tags {
@@ -602,7 +791,12 @@ func (s *Statsd) aggregate(m metric) {
 func (s *Statsd) Stop() {
 	log.Println("D! Stopping the statsd service")
 	close(s.done)
-	s.listener.Close()
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	if s.tcpListener != nil {
+		s.tcpListener.Close()
+	}
 	s.wg.Wait()
 	close(s.in)
 	log.Println("D! Stopped the statsd service")
@@ -6,10 +6,18 @@ package statsd
 import (
 	"errors"
 	"fmt"
+	"github.com/aws/amazon-cloudwatch-agent/internal/listenauth"
+	tlsint "github.com/aws/amazon-cloudwatch-agent/internal/tls"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/seh1"
+	"github.com/aws/amazon-cloudwatch-agent/recorder"
+	"io/ioutil"
 	"math"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
@@ -1481,3 +1489,87 @@ func test_validate_gauge(
 func init() {
 	distribution.NewDistribution = seh1.NewSEH1Distribution
 }
+
+func TestReplayMetrics(t *testing.T) {
+	s := &Statsd{}
+	acc := &testutil.Accumulator{}
+	samples := []recorder.Sample{
+		{Kind: recorder.KindStatsd, Payload: []byte("requests:1|c\nlatency:5|g")},
+		{Kind: recorder.KindLogLine, Payload: []byte("ignored:1|c")},
+		{Kind: recorder.KindStatsd, Payload: []byte("requests:2|c\n")},
+	}
+
+	assert.NoError(t, s.ReplayMetrics(samples, acc))
+	acc.AssertContainsFields(t, "requests", map[string]interface{}{"value": int64(3)})
+	acc.AssertContainsFields(t, "latency", map[string]interface{}{"value": float64(5)})
+	assert.False(t, acc.HasMeasurement("ignored"))
+}
+
+func TestTCPListenerToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statsd")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600))
+
+	s := NewTestStatsd()
+	s.ServiceAddress = "127.0.0.1:0"
+	s.Protocol = "tcp"
+	s.AllowedPendingMessages = 10
+	s.Auth = listenauth.Config{TokenFile: tokenFile}
+	assert.NoError(t, s.Start(nil))
+	defer s.Stop()
+
+	send := func(lines string) {
+		conn, err := net.Dial("tcp", s.tcpListener.Addr().String())
+		assert.NoError(t, err)
+		_, err = conn.Write([]byte(lines))
+		assert.NoError(t, err)
+		conn.Close()
+	}
+	counter := func(name string) (int64, bool) {
+		s.Lock()
+		defer s.Unlock()
+		for _, c := range s.counters {
+			if c.name == name {
+				return c.fields[defaultFieldName].(int64), true
+			}
+		}
+		return 0, false
+	}
+
+	send("auth:wrong\nrejected:1|c\n")
+	send("auth:secret\naccepted:1|c\naccepted:2|c\n")
+	assert.Eventually(t, func() bool {
+		value, ok := counter("accepted")
+		return ok && value == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	_, ok := counter("rejected")
+	assert.False(t, ok)
+}
+
+func TestStartInvalidAuth(t *testing.T) {
+	s := NewTestStatsd()
+	s.ServiceAddress = "127.0.0.1:0"
+	s.Auth = listenauth.Config{ServerConfig: tlsint.ServerConfig{TLSCert: "cert.pem", TLSKey: "key.pem"}}
+	assert.Error(t, s.Start(nil))
+
+	s = NewTestStatsd()
+	s.Protocol = "sctp"
+	assert.EqualError(t, s.Start(nil), "statsd: invalid protocol sctp, it must be udp or tcp")
+}
+
+func TestStartInvalidUDPAddress(t *testing.T) {
+	s := NewTestStatsd()
+	s.ServiceAddress = "::1:8125"
+	assert.Error(t, s.Start(nil))
+
+	s = NewTestStatsd()
+	s.ServiceAddress = "[::1]:8125:8125"
+	assert.Error(t, s.Start(nil))
+
+	s = NewTestStatsd()
+	s.ServiceAddress = "127.0.0.1:0"
+	assert.NoError(t, s.Start(nil))
+	s.Stop()
+}
@@ -0,0 +1,105 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+)
+
+const (
+	// The timings are published as distributions, CloudWatch computes their percentiles
+	timingOutputDistribution = "distribution"
+	// The timings are published as separate metrics of their statistics and percentiles
+	timingOutputStatistics = "statistics"
+)
+
+var defaultPercentiles = []float64{50, 90, 99}
+
+func (s *Statsd) validateTimingOutput() error {
+	switch s.TimingOutput {
+	case "", timingOutputDistribution, timingOutputStatistics:
+	default:
+		return fmt.Errorf("timing_output %v is not supported, it must be %v or %v", s.TimingOutput, timingOutputDistribution, timingOutputStatistics)
+	}
+	for _, p := range s.Percentiles {
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("percentile %v is not in (0, 100]", p.Value)
+		}
+	}
+	return nil
+}
+
+// timingFields returns the fields of the cached timings as published, with the statistics output each distribution
+// is replaced by its min, max, avg, count and percentiles, e.g. the "value" field by "min" ... "p99" and the "upper"
+// field by "upper_min" ... "upper_p99".
+func (s *Statsd) timingFields(fields map[string]interface{}) map[string]interface{} {
+	if s.TimingOutput != timingOutputStatistics {
+		return fields
+	}
+	percentiles := defaultPercentiles
+	if len(s.Percentiles) > 0 {
+		percentiles = make([]float64, len(s.Percentiles))
+		for i, p := range s.Percentiles {
+			percentiles[i] = p.Value
+		}
+	}
+	result := make(map[string]interface{}, len(fields)*(4+len(percentiles)))
+	for name, field := range fields {
+		dist, ok := field.(distribution.Distribution)
+		if !ok || dist.SampleCount() == 0 {
+			continue
+		}
+		prefix := ""
+		if name != defaultFieldName {
+			prefix = name + "_"
+		}
+		result[prefix+"min"] = dist.Minimum()
+		result[prefix+"max"] = dist.Maximum()
+		result[prefix+"avg"] = dist.Sum() / dist.SampleCount()
+		result[prefix+"count"] = dist.SampleCount()
+		for _, p := range percentiles {
+			result[prefix+percentileFieldName(p)] = percentile(dist, p)
+		}
+	}
+	return result
+}
+
+// percentileFieldName returns e.g. p99 for 99 and p99_9 for 99.9
+func percentileFieldName(p float64) string {
+	return "p" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1)
+}
+
+// percentile returns the smallest value of the distribution which at least p percent of the samples do not exceed
+func percentile(dist distribution.Distribution, p float64) float64 {
+	values, counts := dist.ValuesAndCounts()
+	indexes := make([]int, len(values))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.Slice(indexes, func(i, j int) bool { return values[indexes[i]] < values[indexes[j]] })
+
+	rank := p / 100 * dist.SampleCount()
+	var cumulated float64
+	result := dist.Maximum()
+	for _, i := range indexes {
+		cumulated += counts[i]
+		if cumulated >= rank {
+			result = values[i]
+			break
+		}
+	}
+	// the values of the distribution may be approximations of the samples
+	if result < dist.Minimum() {
+		return dist.Minimum()
+	}
+	if result > dist.Maximum() {
+		return dist.Maximum()
+	}
+	return result
+}
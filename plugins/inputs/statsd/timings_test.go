@@ -0,0 +1,71 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingStatistics(t *testing.T) {
+	s := NewTestStatsd()
+	s.TimingOutput = timingOutputStatistics
+	s.Percentiles = []internal.Number{{Value: 50}, {Value: 99.9}}
+	require.NoError(t, s.validateTimingOutput())
+	for i := 1; i <= 100; i++ {
+		require.NoError(t, s.parseStatsdLine(fmt.Sprintf("latency:%v|ms", i)))
+	}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Gather(acc))
+
+	require.Len(t, acc.Metrics, 1)
+	fields := acc.Metrics[0].Fields
+	assert.Equal(t, 1.0, fields["min"])
+	assert.Equal(t, 100.0, fields["max"])
+	assert.Equal(t, 50.5, fields["avg"])
+	assert.Equal(t, 100.0, fields["count"])
+	// the values of the distribution are approximated within 10%
+	assert.InDelta(t, 50, fields["p50"], 5)
+	assert.InDelta(t, 100, fields["p99_9"], 10)
+	assert.Len(t, fields, 6)
+}
+
+func TestTimingDistribution(t *testing.T) {
+	s := NewTestStatsd()
+	require.NoError(t, s.parseStatsdLine("latency:5|ms"))
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Gather(acc))
+	require.Len(t, acc.Metrics, 1)
+	_, ok := acc.Metrics[0].Fields["value"].(distribution.Distribution)
+	assert.True(t, ok)
+}
+
+func TestPercentile(t *testing.T) {
+	dist := distribution.NewDistribution()
+	for _, v := range []float64{10, 20, 30, 40} {
+		dist.AddEntry(v, 1)
+	}
+	dist.AddEntry(1000, 0.5)
+	// 2.25 of the 4.5 samples do not exceed 30
+	assert.InDelta(t, 30, percentile(dist, 50), 3)
+	assert.Equal(t, 1000.0, percentile(dist, 100))
+	assert.Equal(t, "p90", percentileFieldName(90))
+	assert.Equal(t, "p99_99", percentileFieldName(99.99))
+}
+
+func TestValidateTimingOutput(t *testing.T) {
+	s := NewTestStatsd()
+	assert.NoError(t, s.validateTimingOutput())
+	s.TimingOutput = "raw"
+	assert.Error(t, s.validateTimingOutput())
+	s.TimingOutput = timingOutputDistribution
+	s.Percentiles = []internal.Number{{Value: 0}}
+	assert.Error(t, s.validateTimingOutput())
+}
@@ -0,0 +1,220 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tls_cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const measurement = "tls_cert"
+
+// errNoCertificate is ignored for the files of the directories, which may be the private keys of the certificates
+var errNoCertificate = errors.New("no certificate found")
+
+// certExtensions are the extensions of the files read from the directories
+var certExtensions = map[string]bool{".pem": true, ".crt": true, ".cer": true, ".der": true}
+
+type TLSCert struct {
+	// Paths are the certificate files and the directories of certificate files, glob patterns are allowed
+	Paths []string `toml:"paths"`
+	// CAFile are the root certificates the chains are verified with, the roots of the system by default
+	CAFile string `toml:"ca_file"`
+
+	roots *x509.CertPool
+	now   func() time.Time
+}
+
+var sampleConfig = `
+  ## The certificate files, PEM or DER, and the directories of certificate files (.pem, .crt, .cer and .der),
+  ## glob patterns are allowed. The first certificate of a file is the certificate of the server, the others are
+  ## the intermediates of its chain.
+  paths = ["/etc/nginx/ssl/*.crt", "/etc/pki/tls/certs/server.pem"]
+
+  ## The root certificates the chains are verified with, the roots of the system by default
+  # ca_file = "/etc/pki/ca-trust/source/anchors/corp-ca.pem"
+`
+
+func (t *TLSCert) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TLSCert) Description() string {
+	return "Read the days until the expiry and the validity of the chain of the local TLS certificate files"
+}
+
+func (t *TLSCert) Gather(acc telegraf.Accumulator) error {
+	if t.CAFile != "" && t.roots == nil {
+		content, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls_cert: unable to read the ca file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(content) {
+			return fmt.Errorf("tls_cert: no certificate in the ca file %v", t.CAFile)
+		}
+		t.roots = roots
+	}
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+	files, inDirs := t.files(acc)
+	for _, file := range files {
+		certs, err := readCerts(file)
+		if err == errNoCertificate && inDirs[file] {
+			continue
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("tls_cert: unable to read %v: %v", file, err))
+			continue
+		}
+		fields, tags := t.certFields(certs, now)
+		tags["path"] = file
+		acc.AddFields(measurement, fields, tags, now)
+	}
+	return nil
+}
+
+// files returns the certificate files of the paths and which of them are in the directories of the paths, the files
+// listed are returned even when they do not exist so that their error is reported
+func (t *TLSCert) files(acc telegraf.Accumulator) ([]string, map[string]bool) {
+	var files []string
+	seen := map[string]bool{}
+	inDirs := map[string]bool{}
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, path := range t.Paths {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			acc.AddError(fmt.Errorf("tls_cert: invalid path %v: %v", path, err))
+			continue
+		}
+		if len(matches) == 0 && !strings.ContainsAny(path, "*?[") {
+			add(path)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				add(match)
+				continue
+			}
+			entries, err := ioutil.ReadDir(match)
+			if err != nil {
+				acc.AddError(fmt.Errorf("tls_cert: unable to read %v: %v", match, err))
+				continue
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() && certExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+					file := filepath.Join(match, entry.Name())
+					if !seen[file] {
+						inDirs[file] = true
+					}
+					add(file)
+				}
+			}
+		}
+	}
+	return files, inDirs
+}
+
+// certFields returns the fields and the tags of the first certificate, which is verified with the others of the file
+// as intermediates
+func (t *TLSCert) certFields(certs []*x509.Certificate, now time.Time) (map[string]interface{}, map[string]string) {
+	leaf := certs[0]
+	fields := map[string]interface{}{
+		"days_until_expiry": daysUntil(leaf.NotAfter, now),
+		"chain_valid":       0,
+	}
+	tags := map[string]string{"common_name": leaf.Subject.CommonName}
+
+	opts := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		Roots:         t.roots,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	chains, err := leaf.Verify(opts)
+	if err != nil {
+		log.Printf("D! tls_cert: the chain of %v is invalid: %v", leaf.Subject.CommonName, err)
+		return fields, tags
+	}
+	fields["chain_valid"] = 1
+	// the chain expires with its first certificate to expire, which may be an intermediate
+	var expiry time.Time
+	for _, chain := range chains {
+		chainExpiry := chain[0].NotAfter
+		for _, cert := range chain[1:] {
+			if cert.NotAfter.Before(chainExpiry) {
+				chainExpiry = cert.NotAfter
+			}
+		}
+		if chainExpiry.After(expiry) {
+			expiry = chainExpiry
+		}
+	}
+	fields["chain_days_until_expiry"] = daysUntil(expiry, now)
+	return fields, tags
+}
+
+func daysUntil(t time.Time, now time.Time) float64 {
+	return t.Sub(now).Hours() / 24
+}
+
+// readCerts returns the certificates of the PEM blocks of the file, or the certificate of the file in DER
+func readCerts(file string) ([]*x509.Certificate, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	rest := content
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+	cert, err := x509.ParseCertificate(content)
+	if err != nil {
+		return nil, errNoCertificate
+	}
+	return []*x509.Certificate{cert}, nil
+}
+
+func init() {
+	inputs.Add("tls_cert", func() telegraf.Input {
+		return &TLSCert{}
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tls_cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newCert(t *testing.T, name string, notAfter time.Time, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func writePEM(t *testing.T, file string, certs ...*testCert) {
+	var content []byte
+	for _, c := range certs {
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der})...)
+	}
+	require.NoError(t, ioutil.WriteFile(file, content, 0644))
+}
+
+func TestGather(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls_cert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := newCert(t, "root", now.Add(3650*24*time.Hour), nil)
+	intermediate := newCert(t, "intermediate", now.Add(10*24*time.Hour), root)
+	leaf := newCert(t, "example.com", now.Add(30*24*time.Hour), intermediate)
+	writePEM(t, filepath.Join(dir, "ca.pem"), root)
+	certs := filepath.Join(dir, "certs")
+	require.NoError(t, os.Mkdir(certs, 0755))
+	writePEM(t, filepath.Join(certs, "chain.crt"), leaf, intermediate)
+	// without its intermediate
+	writePEM(t, filepath.Join(certs, "leaf.pem"), leaf)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(certs, "key.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(certs, "README"), []byte("not a certificate"), 0644))
+
+	tc := &TLSCert{
+		Paths:  []string{certs, filepath.Join(dir, "missing.pem")},
+		CAFile: filepath.Join(dir, "ca.pem"),
+		now:    func() time.Time { return now },
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, tc.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	assert.Contains(t, acc.Errors[0].Error(), "missing.pem")
+
+	acc.AssertContainsTaggedFields(t, "tls_cert", map[string]interface{}{
+		"days_until_expiry":       30.0,
+		"chain_valid":             1,
+		"chain_days_until_expiry": 10.0,
+	}, map[string]string{"common_name": "example.com", "path": filepath.Join(certs, "chain.crt")})
+	acc.AssertContainsTaggedFields(t, "tls_cert", map[string]interface{}{
+		"days_until_expiry": 30.0,
+		"chain_valid":       0,
+	}, map[string]string{"common_name": "example.com", "path": filepath.Join(certs, "leaf.pem")})
+	assert.Len(t, acc.Metrics, 2)
+}
+
+func TestExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls_cert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := newCert(t, "root", now.Add(-12*time.Hour), nil)
+	file := filepath.Join(dir, "root.der")
+	require.NoError(t, ioutil.WriteFile(file, root.der, 0644))
+
+	tc := &TLSCert{Paths: []string{filepath.Join(dir, "*.der")}, now: func() time.Time { return now }}
+	var acc testutil.Accumulator
+	require.NoError(t, tc.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "tls_cert", map[string]interface{}{
+		"days_until_expiry": -0.5,
+		"chain_valid":       0,
+	}, map[string]string{"common_name": "root", "path": file})
+}
+
+func TestInvalidCAFile(t *testing.T) {
+	tc := &TLSCert{CAFile: "/nonexistent/ca.pem"}
+	var acc testutil.Accumulator
+	assert.Error(t, tc.Gather(&acc))
+}
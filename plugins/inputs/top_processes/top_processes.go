@@ -0,0 +1,225 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/shirou/gopsutil/process"
+)
+
+const (
+	defaultTopN      = 5
+	defaultNamespace = "CWAgent"
+	// the field of the metrics the cloudwatchlogs output sends as is
+	logEntryField = "value"
+
+	metricCPU = "top_process_cpu_usage"
+	metricRSS = "top_process_memory_rss"
+)
+
+type TopProcesses struct {
+	// TopN is the number of the processes of each report
+	TopN          int    `toml:"top_n"`
+	LogGroupName  string `toml:"log_group_name"`
+	LogStreamName string `toml:"log_stream_name"`
+	// Namespace of the metrics of the top process by CPU and by RSS extracted from the reports
+	Namespace string `toml:"namespace"`
+
+	// the CPU time of the processes at the previous collection by pid
+	lastCPU  map[int32]processCPU
+	lastTime time.Time
+	hostname string
+}
+
+type processCPU struct {
+	name    string
+	cpuTime float64
+}
+
+type processInfo struct {
+	pid  int32
+	name string
+	// cpuTime is the user and system CPU time of the process in seconds
+	cpuTime float64
+	rss     uint64
+}
+
+// Entry is a process of a report
+type Entry struct {
+	Name  string  `json:"name"`
+	Pid   int32   `json:"pid"`
+	Value float64 `json:"value"`
+}
+
+var sampleConfig = `
+  ## The number of the processes of each report
+  # top_n = 5
+
+  ## The log group and stream the reports are sent to in the embedded metric format, the stream of the
+  ## cloudwatchlogs output by default
+  log_group_name = "/aws/cwagent/top_processes"
+  # log_stream_name = "{hostname}"
+
+  ## The namespace of the metrics of the top process by CPU and by RSS, dimensioned by host
+  # namespace = "CWAgent"
+`
+
+var listProcesses = func() ([]processInfo, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]processInfo, 0, len(processes))
+	for _, p := range processes {
+		// the processes exiting or not visible to the agent are skipped
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		info := processInfo{pid: p.Pid, name: name}
+		if times, err := p.Times(); err == nil {
+			info.cpuTime = times.User + times.System
+		}
+		if memory, err := p.MemoryInfo(); err == nil {
+			info.rss = memory.RSS
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (t *TopProcesses) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TopProcesses) Description() string {
+	return "Report the processes using the most CPU and memory each interval as embedded metric format logs"
+}
+
+func (t *TopProcesses) Gather(acc telegraf.Accumulator) error {
+	if t.LogGroupName == "" {
+		return fmt.Errorf("top_processes: log_group_name is required")
+	}
+	processes, err := listProcesses()
+	if err != nil {
+		return fmt.Errorf("top_processes: unable to list the processes: %v", err)
+	}
+	now := time.Now()
+	topCPU := t.topCPU(processes, now)
+	topRSS := topRSS(processes, t.topN())
+
+	document, err := t.document(topCPU, topRSS, now)
+	if err != nil {
+		return err
+	}
+	tags := map[string]string{logscommon.LogGroupNameTag: t.LogGroupName}
+	if t.LogStreamName != "" {
+		tags[logscommon.LogStreamNameTag] = t.LogStreamName
+	}
+	acc.AddFields("top_processes", map[string]interface{}{logEntryField: document}, tags, now)
+	return nil
+}
+
+func (t *TopProcesses) topN() int {
+	if t.TopN <= 0 {
+		return defaultTopN
+	}
+	return t.TopN
+}
+
+// topCPU returns the processes using the most CPU since the previous collection in percent of a core, none on the
+// first collection
+func (t *TopProcesses) topCPU(processes []processInfo, now time.Time) []Entry {
+	elapsed := now.Sub(t.lastTime).Seconds()
+	current := make(map[int32]processCPU, len(processes))
+	entries := []Entry{}
+	for _, p := range processes {
+		current[p.pid] = processCPU{name: p.name, cpuTime: p.cpuTime}
+		last, ok := t.lastCPU[p.pid]
+		// a pid reused by another process
+		if !ok || last.name != p.name || p.cpuTime < last.cpuTime || elapsed <= 0 {
+			continue
+		}
+		entries = append(entries, Entry{Name: p.name, Pid: p.pid, Value: (p.cpuTime - last.cpuTime) / elapsed * 100})
+	}
+	t.lastCPU, t.lastTime = current, now
+	return top(entries, t.topN())
+}
+
+// topRSS returns the processes with the most resident memory in bytes
+func topRSS(processes []processInfo, n int) []Entry {
+	entries := make([]Entry, 0, len(processes))
+	for _, p := range processes {
+		entries = append(entries, Entry{Name: p.name, Pid: p.pid, Value: float64(p.rss)})
+	}
+	return top(entries, n)
+}
+
+func top(entries []Entry, n int) []Entry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].Pid < entries[j].Pid
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// document returns the report in the embedded metric format, the values of the top process by CPU and by RSS are
+// extracted as the metrics and the lists are kept in the log for the queries
+func (t *TopProcesses) document(topCPU []Entry, topRSS []Entry, now time.Time) (string, error) {
+	if t.hostname == "" {
+		t.hostname, _ = os.Hostname()
+	}
+	namespace := t.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	metrics := []map[string]string{}
+	content := map[string]interface{}{
+		"host":    t.hostname,
+		"top_cpu": topCPU,
+		"top_rss": topRSS,
+	}
+	if len(topCPU) > 0 {
+		metrics = append(metrics, map[string]string{"Name": metricCPU, "Unit": "Percent"})
+		content[metricCPU] = topCPU[0].Value
+	}
+	if len(topRSS) > 0 {
+		metrics = append(metrics, map[string]string{"Name": metricRSS, "Unit": "Bytes"})
+		content[metricRSS] = topRSS[0].Value
+	}
+	content["_aws"] = map[string]interface{}{
+		"Timestamp": now.UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []interface{}{
+			map[string]interface{}{
+				"Namespace":  namespace,
+				"Dimensions": [][]string{{"host"}},
+				"Metrics":    metrics,
+			},
+		},
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("top_processes: unable to encode the report: %v", err)
+	}
+	return string(b), nil
+}
+
+func init() {
+	inputs.Add("top_processes", func() telegraf.Input {
+		return &TopProcesses{}
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type report struct {
+	Host   string  `json:"host"`
+	TopCPU []Entry `json:"top_cpu"`
+	TopRSS []Entry `json:"top_rss"`
+	CPU    float64 `json:"top_process_cpu_usage"`
+	AWS    struct {
+		CloudWatchMetrics []struct {
+			Namespace  string
+			Dimensions [][]string
+			Metrics    []map[string]string
+		}
+	} `json:"_aws"`
+}
+
+func TestGather(t *testing.T) {
+	processes := []processInfo{
+		{pid: 1, name: "systemd", cpuTime: 10, rss: 10 << 20},
+		{pid: 100, name: "java", cpuTime: 100, rss: 2 << 30},
+		{pid: 200, name: "nginx", cpuTime: 50, rss: 50 << 20},
+		{pid: 300, name: "python", cpuTime: 5, rss: 100 << 20},
+	}
+	original := listProcesses
+	listProcesses = func() ([]processInfo, error) { return processes, nil }
+	defer func() { listProcesses = original }()
+
+	tp := &TopProcesses{TopN: 2, LogGroupName: "top", hostname: "host1"}
+	var acc testutil.Accumulator
+	require.NoError(t, tp.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, map[string]string{"log_group_name": "top"}, acc.Metrics[0].Tags)
+
+	var first report
+	require.NoError(t, json.Unmarshal([]byte(acc.Metrics[0].Fields["value"].(string)), &first))
+	// no CPU usage before a second collection
+	assert.Empty(t, first.TopCPU)
+	assert.Equal(t, []Entry{{Name: "java", Pid: 100, Value: 2 << 30}, {Name: "python", Pid: 300, Value: 100 << 20}}, first.TopRSS)
+	require.Len(t, first.AWS.CloudWatchMetrics, 1)
+	assert.Equal(t, "CWAgent", first.AWS.CloudWatchMetrics[0].Namespace)
+	assert.Equal(t, []map[string]string{{"Name": "top_process_memory_rss", "Unit": "Bytes"}}, first.AWS.CloudWatchMetrics[0].Metrics)
+
+	// a second later, the pid of python is reused by another process
+	tp.lastTime = tp.lastTime.Add(-time.Second)
+	processes = []processInfo{
+		{pid: 1, name: "systemd", cpuTime: 10.01},
+		{pid: 100, name: "java", cpuTime: 100.5},
+		{pid: 200, name: "nginx", cpuTime: 50.9},
+		{pid: 300, name: "stress", cpuTime: 6},
+	}
+	acc.ClearMetrics()
+	require.NoError(t, tp.Gather(&acc))
+	var second report
+	require.NoError(t, json.Unmarshal([]byte(acc.Metrics[0].Fields["value"].(string)), &second))
+	require.Len(t, second.TopCPU, 2)
+	assert.Equal(t, "nginx", second.TopCPU[0].Name)
+	assert.InDelta(t, 90, second.TopCPU[0].Value, 1)
+	assert.Equal(t, "java", second.TopCPU[1].Name)
+	assert.InDelta(t, 50, second.TopCPU[1].Value, 1)
+	assert.InDelta(t, 90, second.CPU, 1)
+	assert.Equal(t, "host1", second.Host)
+	assert.Len(t, second.AWS.CloudWatchMetrics[0].Metrics, 2)
+}
+
+func TestGatherWithoutLogGroup(t *testing.T) {
+	var acc testutil.Accumulator
+	assert.Error(t, new(TopProcesses).Gather(&acc))
+}
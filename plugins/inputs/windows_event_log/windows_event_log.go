@@ -13,6 +13,7 @@ import (
 
 	"github.com/aws/amazon-cloudwatch-agent/internal/logscommon"
 	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
 	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/windows_event_log/wineventlog"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -30,6 +31,9 @@ type EventConfig struct {
 	LogGroupName  string   `toml:"log_group_name"`
 	LogStreamName string   `toml:"log_stream_name"`
 	Destination   string   `toml:"destination"`
+	Priority      string   `toml:"priority"`
+	// FieldMapping renames the fields of the events in the json format, the fields renamed to "" are removed
+	FieldMapping map[string]string `toml:"event_field_mapping"`
 }
 
 type Plugin struct {
@@ -55,6 +59,15 @@ func (s *Plugin) SampleConfig() string {
 	log_group_name = "System"
 	log_stream_name = "STREAM_NAME"
 	destination = "cloudwatchlogs"
+	## The priority class of the events under memory pressure: "critical", "normal" or "bulk"
+	# priority = "critical"
+	## The format of the events: "xml", "text" or "json". The json format is an object with the EventID, Provider,
+	## Level, TaskCategory, Message, UserSID and the EventData of the events.
+	# event_format = "json"
+	## Renames the fields of the json format, the fields of EventData included, a field renamed to "" is removed
+	# [inputs.windows_event_log.event_config.event_field_mapping]
+	#   EventID = "event_id"
+	#   Computer = ""
 	`
 }
 
@@ -62,6 +75,19 @@ func (s *Plugin) Gather(acc telegraf.Accumulator) (err error) {
 	return nil
 }
 
+// LogGroups returns the log groups of the event logs
+func (s *Plugin) LogGroups() []preflight.LogGroup {
+	var groups []preflight.LogGroup
+	for _, eventConfig := range s.Events {
+		destination := eventConfig.Destination
+		if destination == "" {
+			destination = s.Destination
+		}
+		groups = append(groups, preflight.LogGroup{Name: eventConfig.LogGroupName, Destination: destination})
+	}
+	return groups
+}
+
 func (s *Plugin) FindLogSrc() []logs.LogSrc {
 	events := s.newEvents
 	s.newEvents = nil
@@ -87,12 +113,13 @@ func (s *Plugin) Start(acc telegraf.Accumulator) error {
 			destination,
 			stateFilePath,
 			eventConfig.BatchReadSize,
+			eventConfig.FieldMapping,
 		)
 		err := eventLog.Init()
 		if err != nil {
 			return err
 		}
-		s.newEvents = append(s.newEvents, eventLog)
+		s.newEvents = append(s.newEvents, logs.WithPriority(eventLog, eventConfig.Priority))
 	}
 	return nil
 }
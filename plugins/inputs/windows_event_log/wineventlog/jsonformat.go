@@ -0,0 +1,105 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wineventlog
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// jsonEvent is the part of the XML of a formatted event which is published in the json format
+type jsonEvent struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID       string `xml:"EventID"`
+		Level         string `xml:"Level"`
+		Task          string `xml:"Task"`
+		EventRecordID string `xml:"EventRecordID"`
+		TimeCreated   struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		Channel  string `xml:"Channel"`
+		Computer string `xml:"Computer"`
+		Security struct {
+			UserID string `xml:"UserID,attr"`
+		} `xml:"Security"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+	RenderingInfo struct {
+		Message string `xml:"Message"`
+		Level   string `xml:"Level"`
+		Task    string `xml:"Task"`
+	} `xml:"RenderingInfo"`
+}
+
+// renderJSON converts the XML of a formatted event into a JSON object. The fieldMapping renames the fields, the
+// top level ones as well as the ones of EventData, a field renamed to "" is removed.
+func renderJSON(content []byte, fieldMapping map[string]string) (string, error) {
+	var event jsonEvent
+	if err := xml.Unmarshal(content, &event); err != nil {
+		return "", err
+	}
+
+	fields := map[string]interface{}{}
+	add := func(m map[string]interface{}, key string, value interface{}) {
+		if s, ok := value.(string); ok && s == "" {
+			return
+		}
+		if name, ok := fieldMapping[key]; ok {
+			key = name
+		}
+		if key != "" {
+			m[key] = value
+		}
+	}
+
+	level := event.RenderingInfo.Level
+	if level == "" {
+		level = event.System.Level
+	}
+	taskCategory := event.RenderingInfo.Task
+	if taskCategory == "" {
+		taskCategory = event.System.Task
+	}
+	add(fields, "EventID", event.System.EventID)
+	add(fields, "Provider", event.System.Provider.Name)
+	add(fields, "Channel", event.System.Channel)
+	add(fields, "Computer", event.System.Computer)
+	add(fields, "EventRecordID", event.System.EventRecordID)
+	add(fields, "TimeCreated", event.System.TimeCreated.SystemTime)
+	add(fields, "Level", level)
+	add(fields, "TaskCategory", taskCategory)
+	add(fields, "Message", strings.TrimSpace(event.RenderingInfo.Message))
+	add(fields, "UserSID", event.System.Security.UserID)
+
+	if len(event.EventData.Data) > 0 {
+		data := map[string]interface{}{}
+		for i, d := range event.EventData.Data {
+			name := d.Name
+			if name == "" {
+				// the classic providers do not name their data, the messages refer to them as %1, %2...
+				name = fmt.Sprintf("param%d", i+1)
+			}
+			add(data, name, d.Value)
+		}
+		if len(data) > 0 {
+			add(fields, "EventData", data)
+		}
+	}
+
+	value, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
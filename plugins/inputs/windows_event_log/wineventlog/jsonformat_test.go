@@ -0,0 +1,78 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wineventlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const formattedEvent = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='Microsoft-Windows-Security-Auditing' Guid='{54849625-5478-4994-A5BA-3E3B0328C30D}'/><EventID>4625</EventID><Version>0</Version><Level>0</Level><Task>12544</Task><Opcode>0</Opcode><Keywords>0x8010000000000000</Keywords><TimeCreated SystemTime='2019-05-05T23:12:37.349476100Z'/><EventRecordID>694674</EventRecordID><Channel>Security</Channel><Computer>EC2AMAZ-5J6IFSF</Computer><Security UserID='S-1-5-18'/></System><EventData><Data Name='TargetUserName'>ADMINISTRATOR</Data><Data Name='TargetDomainName'></Data><Data Name='IpAddress'>146.56.6.166</Data><Data Name='LogonType'>3</Data></EventData><RenderingInfo Culture='en-US'><Message>An account failed to log on.
+
+Subject:
+	Security ID:		NULL SID
+</Message><Level>Information</Level><Task>Logon</Task><Opcode>Info</Opcode><Channel>Security</Channel><Provider>Microsoft Windows security auditing.</Provider></RenderingInfo></Event>`
+
+func TestRenderJSON(t *testing.T) {
+	value, err := renderJSON([]byte(formattedEvent), nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"EventID": "4625",
+		"Provider": "Microsoft-Windows-Security-Auditing",
+		"Channel": "Security",
+		"Computer": "EC2AMAZ-5J6IFSF",
+		"EventRecordID": "694674",
+		"TimeCreated": "2019-05-05T23:12:37.349476100Z",
+		"Level": "Information",
+		"TaskCategory": "Logon",
+		"Message": "An account failed to log on.\n\nSubject:\n\tSecurity ID:\t\tNULL SID",
+		"UserSID": "S-1-5-18",
+		"EventData": {"TargetUserName": "ADMINISTRATOR", "IpAddress": "146.56.6.166", "LogonType": "3"}
+	}`, value)
+}
+
+func TestRenderJSONFieldMapping(t *testing.T) {
+	mapping := map[string]string{
+		"EventID":     "event_id",
+		"EventData":   "data",
+		"IpAddress":   "source_ip",
+		"Computer":    "",
+		"LogonType":   "",
+		"Message":     "",
+		"Channel":     "",
+		"UserSID":     "",
+		"Provider":    "provider",
+		"TimeCreated": "",
+	}
+	value, err := renderJSON([]byte(formattedEvent), mapping)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"event_id": "4625",
+		"provider": "Microsoft-Windows-Security-Auditing",
+		"EventRecordID": "694674",
+		"Level": "Information",
+		"TaskCategory": "Logon",
+		"data": {"TargetUserName": "ADMINISTRATOR", "source_ip": "146.56.6.166"}
+	}`, value)
+}
+
+func TestRenderJSONClassicProvider(t *testing.T) {
+	// no rendering info and data without names
+	content := `<Event><System><Provider Name='Service Control Manager'/><EventID>7036</EventID><Level>4</Level><Task>0</Task><Channel>System</Channel></System><EventData><Data>Windows Update</Data><Data>stopped</Data></EventData></Event>`
+	value, err := renderJSON([]byte(content), nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"EventID": "7036",
+		"Provider": "Service Control Manager",
+		"Channel": "System",
+		"Level": "4",
+		"TaskCategory": "0",
+		"EventData": {"param1": "Windows Update", "param2": "stopped"}
+	}`, value)
+
+	_, err = renderJSON([]byte("<Event><System>"), nil)
+	assert.Error(t, err)
+}
@@ -38,6 +38,7 @@ type windowsEventLog struct {
 	maxToRead     int // Maximum number returned in one read.
 	destination   string
 	stateFilePath string
+	fieldMapping  map[string]string // The renamed fields of the json format.
 
 	eventHandle EvtHandle
 	eventOffset uint64
@@ -48,7 +49,7 @@ type windowsEventLog struct {
 	startOnce sync.Once
 }
 
-func NewEventLog(name string, levels []string, logGroupName, logStreamName, renderFormat, destination, stateFilePath string, maximumToRead int) *windowsEventLog {
+func NewEventLog(name string, levels []string, logGroupName, logStreamName, renderFormat, destination, stateFilePath string, maximumToRead int, fieldMapping map[string]string) *windowsEventLog {
 	eventLog := &windowsEventLog{
 		name:          name,
 		levels:        levels,
@@ -58,6 +59,7 @@ func NewEventLog(name string, levels []string, logGroupName, logStreamName, rend
 		maxToRead:     maximumToRead,
 		destination:   destination,
 		stateFilePath: stateFilePath,
+		fieldMapping:  fieldMapping,
 
 		offsetCh: make(chan uint64, 100),
 		done:     make(chan struct{}),
@@ -347,6 +349,10 @@ func (l *windowsEventLog) getRecords(handles []EvtHandle) (records []*windowsEve
 			}
 
 			newRecord.System.Description = recordMessage.Message
+		case FormatJSON:
+			if newRecord.JSONFormatContent, err = renderJSON(descriptionBytes, l.fieldMapping); err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("format %s is not recognized", l.renderFormat)
 		}
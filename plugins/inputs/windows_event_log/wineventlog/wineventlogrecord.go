@@ -14,6 +14,7 @@ import (
 const (
 	FormatXml       = "xml"
 	FormatPlainText = "text"
+	FormatJSON      = "json"
 	FormatDefault   = ""
 )
 
@@ -25,7 +26,8 @@ type eventMessage struct {
 type windowsEventLogRecord struct {
 	windowsEventLog *windowsEventLog
 
-	XmlFormatContent string
+	XmlFormatContent  string
+	JSONFormatContent string
 
 	System struct {
 		Description   string
@@ -67,6 +69,8 @@ func (record *windowsEventLogRecord) Value() (valueString string, err error) {
 		valueString = fmt.Sprintf("[%s] [%s] [%s] [%s] [%s] [%s]", record.System.Channel,
 			WindowsEventLogLevelName(int32(levelId)), record.System.EventIdentifier.ID, record.System.Provider.Name,
 			record.System.Computer, record.System.Description)
+	case FormatJSON:
+		valueString = record.JSONFormatContent
 	default:
 		err = fmt.Errorf("renderFormat %s is not recognized", record.windowsEventLog.renderFormat)
 	}
@@ -0,0 +1,120 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultNamespace = `root\cimv2`
+	defaultMaxRows   = 100
+
+	valueTypeFloat   = "float"
+	valueTypeInteger = "integer"
+	valueTypeBoolean = "boolean"
+)
+
+// Query is a WQL query, each row of its result is a metric of the measurement Name
+type Query struct {
+	Name      string `toml:"name"`
+	Namespace string `toml:"namespace"`
+	// Query is the WQL SELECT statement, the data queries only
+	Query string `toml:"query"`
+	// Properties are the fields of the metrics, their values must be of the ValueType
+	Properties []string `toml:"properties"`
+	// TagProperties are the tags of the metrics
+	TagProperties []string `toml:"tag_properties"`
+	// ValueType is the type the values of the Properties are validated and converted to, float by default
+	ValueType string `toml:"value_type"`
+
+	running bool
+}
+
+// Counter is a performance counter path, e.g. \Processor(_Total)\% Processor Time, for the counters which are not
+// collected by the objects of win_perf_counters
+type Counter struct {
+	Measurement string `toml:"measurement"`
+	Field       string `toml:"field"`
+	Path        string `toml:"path"`
+}
+
+func (q *Query) validate() error {
+	if q.Name == "" {
+		return fmt.Errorf("the name of the query %q is missing", q.Query)
+	}
+	fields := strings.Fields(q.Query)
+	// the SELECT statements only, the ASSOCIATORS OF and REFERENCES OF statements may walk large parts of the repository
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "SELECT") {
+		return fmt.Errorf("the query %v is not a WQL SELECT statement", q.Name)
+	}
+	if len(q.Properties) == 0 {
+		return fmt.Errorf("the query %v has no properties", q.Name)
+	}
+	switch q.ValueType {
+	case "":
+		q.ValueType = valueTypeFloat
+	case valueTypeFloat, valueTypeInteger, valueTypeBoolean:
+	default:
+		return fmt.Errorf("the value_type %v of the query %v is not float, integer or boolean", q.ValueType, q.Name)
+	}
+	if q.Namespace == "" {
+		q.Namespace = defaultNamespace
+	}
+	return nil
+}
+
+func (c *Counter) validate() error {
+	if c.Path == "" || !strings.HasPrefix(c.Path, `\`) {
+		return fmt.Errorf("the counter path %q is not of the form \\Object(Instance)\\Counter", c.Path)
+	}
+	if c.Field == "" {
+		return fmt.Errorf("the field of the counter %v is missing", c.Path)
+	}
+	if c.Measurement == "" {
+		c.Measurement = "wmi_counter"
+	}
+	return nil
+}
+
+// convertValue validates the value of a property and converts it to the value type. WMI returns the 64 bit integers
+// as strings, so strings are parsed.
+func convertValue(value interface{}, valueType string) (interface{}, error) {
+	switch valueType {
+	case valueTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case valueTypeInteger:
+		switch v := value.(type) {
+		case int8, int16, int32, int64, uint8, uint16, uint32:
+			return strconv.ParseInt(fmt.Sprint(v), 10, 64)
+		case uint64:
+			if v > math.MaxInt64 {
+				return nil, fmt.Errorf("%v overflows an integer", v)
+			}
+			return int64(v), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case valueTypeFloat:
+		switch v := value.(type) {
+		case float32:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+			return strconv.ParseFloat(fmt.Sprint(v), 64)
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+	}
+	return nil, fmt.Errorf("%v (%T) is not a valid %v", value, value, valueType)
+}
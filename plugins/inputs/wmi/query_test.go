@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryValidate(t *testing.T) {
+	q := &Query{Name: "service", Query: "select ProcessId from Win32_Service", Properties: []string{"ProcessId"}}
+	assert.NoError(t, q.validate())
+	assert.Equal(t, valueTypeFloat, q.ValueType)
+	assert.Equal(t, `root\cimv2`, q.Namespace)
+
+	q = &Query{Name: "service", Query: "ASSOCIATORS OF {Win32_Service.Name='w32time'}", Properties: []string{"ProcessId"}}
+	assert.EqualError(t, q.validate(), "the query service is not a WQL SELECT statement")
+	q = &Query{Query: "SELECT ProcessId FROM Win32_Service", Properties: []string{"ProcessId"}}
+	assert.Error(t, q.validate())
+	q = &Query{Name: "service", Query: "SELECT ProcessId FROM Win32_Service"}
+	assert.EqualError(t, q.validate(), "the query service has no properties")
+	q = &Query{Name: "service", Query: "SELECT ProcessId FROM Win32_Service", Properties: []string{"ProcessId"}, ValueType: "string"}
+	assert.EqualError(t, q.validate(), "the value_type string of the query service is not float, integer or boolean")
+}
+
+func TestCounterValidate(t *testing.T) {
+	c := &Counter{Field: "requests", Path: `\DHCP Server\Requests/sec`}
+	assert.NoError(t, c.validate())
+	assert.Equal(t, "wmi_counter", c.Measurement)
+	assert.Error(t, (&Counter{Field: "requests", Path: "DHCP Server"}).validate())
+	assert.Error(t, (&Counter{Path: `\DHCP Server\Requests/sec`}).validate())
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		value     interface{}
+		valueType string
+		expected  interface{}
+	}{
+		{int32(42), valueTypeFloat, 42.0},
+		{"18446744073709551615", valueTypeFloat, 18446744073709551615.0},
+		{float32(0.5), valueTypeFloat, 0.5},
+		{uint8(7), valueTypeInteger, int64(7)},
+		{"9007199254740993", valueTypeInteger, int64(9007199254740993)},
+		{uint64(3), valueTypeInteger, int64(3)},
+		{true, valueTypeBoolean, true},
+		{"False", valueTypeBoolean, false},
+	}
+	for _, test := range tests {
+		actual, err := convertValue(test.value, test.valueType)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, actual)
+	}
+
+	invalid := []struct {
+		value     interface{}
+		valueType string
+	}{
+		{"running", valueTypeFloat},
+		{nil, valueTypeFloat},
+		{1.5, valueTypeInteger},
+		{uint64(math.MaxUint64), valueTypeInteger},
+		{int32(1), valueTypeBoolean},
+	}
+	for _, test := range invalid {
+		_, err := convertValue(test.value, test.valueType)
+		assert.Error(t, err, "%v as %v", test.value, test.valueType)
+	}
+}
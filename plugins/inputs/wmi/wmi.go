@@ -0,0 +1,283 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build windows
+
+package wmi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/inputs/win_perf_counters"
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+
+	// S_FALSE is returned by CoInitializeEx when COM is already initialized on the thread
+	sFalse                    = 0x00000001
+	wbemFlagReturnImmediately = 0x10
+	wbemFlagForwardOnly       = 0x20
+)
+
+var errTooManyRows = errors.New("too many rows")
+
+type WMI struct {
+	// Timeout is how long a query may run, the query is skipped until it completes once it times out
+	Timeout internal.Duration `toml:"timeout"`
+	// MaxRows is the highest number of rows of a query, the queries returning more rows are dropped
+	MaxRows  int       `toml:"max_rows"`
+	Queries  []Query   `toml:"query"`
+	Counters []Counter `toml:"counter"`
+
+	initialized bool
+	pdhQuery    win_perf_counters.PDH_HQUERY
+	handles     []win_perf_counters.PDH_HCOUNTER
+	counters    []Counter
+	sync.Mutex
+}
+
+var sampleConfig = `
+  ## How long a query may run, a query timing out is skipped until it completes
+  # timeout = "5s"
+  ## The highest number of rows of a query, the queries returning more rows are dropped
+  # max_rows = 100
+
+  ## The WQL SELECT statements, each row of the result is a metric of the measurement name
+  [[inputs.wmi.query]]
+    name = "win_service_state"
+    # namespace = "root\\cimv2"
+    query = "SELECT Name, ProcessId FROM Win32_Service WHERE StartMode = 'Auto'"
+    ## The fields, the values are validated and converted to the value_type: "float", "integer" or "boolean"
+    properties = ["ProcessId"]
+    # value_type = "float"
+    tag_properties = ["Name"]
+
+  ## The performance counter paths
+  # [[inputs.wmi.counter]]
+  #   measurement = "wmi_counter"
+  #   field = "dhcp_requests"
+  #   path = "\\DHCP Server\\Requests/sec"
+`
+
+func (w *WMI) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *WMI) Description() string {
+	return "Read the results of WMI queries and performance counter paths, with a timeout and a row limit for the queries"
+}
+
+func (w *WMI) init() error {
+	for i := range w.Queries {
+		if err := w.Queries[i].validate(); err != nil {
+			return fmt.Errorf("wmi: %v", err)
+		}
+	}
+	for i := range w.Counters {
+		if err := w.Counters[i].validate(); err != nil {
+			return fmt.Errorf("wmi: %v", err)
+		}
+	}
+	if w.Timeout.Duration <= 0 {
+		w.Timeout.Duration = defaultTimeout
+	}
+	if w.MaxRows <= 0 {
+		w.MaxRows = defaultMaxRows
+	}
+	if len(w.Counters) > 0 {
+		if ret := win_perf_counters.PdhOpenQuery(0, 0, &w.pdhQuery); ret != win_perf_counters.ERROR_SUCCESS {
+			return fmt.Errorf("wmi: unable to open the counter query: %v", win_perf_counters.PdhFormatError(ret))
+		}
+		for _, c := range w.Counters {
+			var handle win_perf_counters.PDH_HCOUNTER
+			if ret := win_perf_counters.PdhAddEnglishCounter(w.pdhQuery, c.Path, 0, &handle); ret != win_perf_counters.ERROR_SUCCESS {
+				log.Printf("E! wmi: unable to add the counter %v: %v", c.Path, win_perf_counters.PdhFormatError(ret))
+				continue
+			}
+			w.handles = append(w.handles, handle)
+			w.counters = append(w.counters, c)
+		}
+		// the rate counters need two samples
+		win_perf_counters.PdhCollectQueryData(w.pdhQuery)
+	}
+	w.initialized = true
+	return nil
+}
+
+func (w *WMI) Gather(acc telegraf.Accumulator) error {
+	if !w.initialized {
+		if err := w.init(); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := range w.Queries {
+		q := &w.Queries[i]
+		w.Lock()
+		running := q.running
+		q.running = true
+		w.Unlock()
+		if running {
+			acc.AddError(fmt.Errorf("wmi: the query %v is skipped, its previous run has not completed", q.Name))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.gatherQuery(acc, q, now)
+		}()
+	}
+	w.gatherCounters(acc, now)
+	wg.Wait()
+	return nil
+}
+
+// gatherQuery waits for the results of the query until the timeout, the query keeps running in the background after
+func (w *WMI) gatherQuery(acc telegraf.Accumulator, q *Query, now time.Time) {
+	type result struct {
+		rows []map[string]interface{}
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		rows, err := w.runQuery(q)
+		w.Lock()
+		q.running = false
+		w.Unlock()
+		done <- result{rows, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(w.Timeout.Duration):
+		acc.AddError(fmt.Errorf("wmi: the query %v timed out after %v", q.Name, w.Timeout.Duration))
+		return
+	}
+	if r.err != nil {
+		acc.AddError(fmt.Errorf("wmi: the query %v failed: %v", q.Name, r.err))
+		return
+	}
+	for _, row := range r.rows {
+		fields := map[string]interface{}{}
+		tags := map[string]string{}
+		for _, p := range q.Properties {
+			value, err := convertValue(row[p], q.ValueType)
+			if err != nil {
+				log.Printf("D! wmi: the property %v of the query %v is skipped: %v", p, q.Name, err)
+				continue
+			}
+			fields[p] = value
+		}
+		for _, p := range q.TagProperties {
+			if v := row[p]; v != nil {
+				tags[p] = fmt.Sprint(v)
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(q.Name, fields, tags, now)
+		}
+	}
+}
+
+// runQuery returns the properties of the rows of the query, COM being initialized on the thread of the goroutine
+func (w *WMI) runQuery(q *Query) ([]map[string]interface{}, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
+		if oleErr, ok := err.(*ole.OleError); !ok || (oleErr.Code() != ole.S_OK && oleErr.Code() != sFalse) {
+			return nil, err
+		}
+	}
+	defer ole.CoUninitialize()
+
+	unknown, err := oleutil.CreateObject("WbemScripting.SWbemLocator")
+	if err != nil {
+		return nil, err
+	}
+	defer unknown.Release()
+	locator, err := unknown.QueryInterface(ole.IID_IDispatch)
+	if err != nil {
+		return nil, err
+	}
+	defer locator.Release()
+
+	serviceRaw, err := oleutil.CallMethod(locator, "ConnectServer", nil, q.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer serviceRaw.Clear()
+	resultRaw, err := oleutil.CallMethod(serviceRaw.ToIDispatch(), "ExecQuery", q.Query, "WQL",
+		wbemFlagForwardOnly|wbemFlagReturnImmediately)
+	if err != nil {
+		return nil, err
+	}
+	defer resultRaw.Clear()
+
+	properties := append(append([]string{}, q.Properties...), q.TagProperties...)
+	var rows []map[string]interface{}
+	err = oleutil.ForEach(resultRaw.ToIDispatch(), func(v *ole.VARIANT) error {
+		if len(rows) >= w.MaxRows {
+			return errTooManyRows
+		}
+		item := v.ToIDispatch()
+		row := make(map[string]interface{}, len(properties))
+		for _, p := range properties {
+			value, err := oleutil.GetProperty(item, p)
+			if err != nil {
+				return fmt.Errorf("unable to read the property %v: %v", p, err)
+			}
+			row[p] = value.Value()
+			value.Clear()
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err == errTooManyRows {
+		return nil, fmt.Errorf("more than %d rows, the query must be more selective", w.MaxRows)
+	}
+	return rows, err
+}
+
+func (w *WMI) gatherCounters(acc telegraf.Accumulator, now time.Time) {
+	if len(w.handles) == 0 {
+		return
+	}
+	if ret := win_perf_counters.PdhCollectQueryData(w.pdhQuery); ret != win_perf_counters.ERROR_SUCCESS {
+		acc.AddError(fmt.Errorf("wmi: unable to collect the counters: %v", win_perf_counters.PdhFormatError(ret)))
+		return
+	}
+	for i, handle := range w.handles {
+		c := w.counters[i]
+		var counterType uint32
+		var value win_perf_counters.PDH_FMT_COUNTERVALUE_DOUBLE
+		ret := win_perf_counters.PdhGetFormattedCounterValueDouble(handle, &counterType, &value)
+		if ret != win_perf_counters.ERROR_SUCCESS {
+			log.Printf("D! wmi: unable to read the counter %v: %v", c.Path, win_perf_counters.PdhFormatError(ret))
+			continue
+		}
+		if value.CStatus != win_perf_counters.PDH_CSTATUS_VALID_DATA && value.CStatus != win_perf_counters.PDH_CSTATUS_NEW_DATA {
+			log.Printf("D! wmi: the value of the counter %v is not valid: %v", c.Path, win_perf_counters.PdhFormatError(value.CStatus))
+			continue
+		}
+		acc.AddFields(c.Measurement, map[string]interface{}{c.Field: value.DoubleValue}, map[string]string{"path": c.Path}, now)
+	}
+}
+
+func init() {
+	inputs.Add("wmi", func() telegraf.Input {
+		return &WMI{}
+	})
+}
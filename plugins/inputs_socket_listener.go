@@ -0,0 +1,8 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !custom inputs inputs.socket_listener
+
+package plugins
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/socket_listener"
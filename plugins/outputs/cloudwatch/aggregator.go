@@ -14,6 +14,7 @@ import (
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 const (
@@ -21,23 +22,47 @@ const (
 	durationAggregationChanBufferSize = 10000
 )
 
+// the policies of the datapoints arriving after the window they belong to was published
+const (
+	// the window is re-opened and published again on the next flush, CloudWatch combines the datapoints of the same
+	// timestamp, the default
+	lateDataPolicyRepublish = "republish"
+	// the datapoints are aggregated into the window currently open
+	lateDataPolicyNextWindow = "next_window"
+	// the datapoints are dropped and counted
+	lateDataPolicyDrop = "drop"
+)
+
+// lateMetricsDropped counts the datapoints dropped by the drop policy since the start of the agent
+var lateMetricsDropped = selfstat.Register("cloudwatch", "late_metrics_dropped", map[string]string{})
+
+func validateLateDataPolicy(policy string) error {
+	switch policy {
+	case "", lateDataPolicyRepublish, lateDataPolicyNextWindow, lateDataPolicyDrop:
+		return nil
+	}
+	return fmt.Errorf("late_data_policy %v is not supported, it must be %v, %v or %v", policy, lateDataPolicyRepublish, lateDataPolicyNextWindow, lateDataPolicyDrop)
+}
+
 type Aggregator interface {
 	AddMetric(m telegraf.Metric)
 }
 
 type aggregator struct {
-	durationMap  map[time.Duration]*durationAggregator
-	metricChan   chan<- telegraf.Metric
-	shutdownChan <-chan struct{}
-	wg           *sync.WaitGroup
+	durationMap    map[time.Duration]*durationAggregator
+	metricChan     chan<- telegraf.Metric
+	shutdownChan   <-chan struct{}
+	wg             *sync.WaitGroup
+	lateDataPolicy string
 }
 
-func NewAggregator(metricChan chan<- telegraf.Metric, shutdownChan <-chan struct{}, wg *sync.WaitGroup) Aggregator {
+func NewAggregator(metricChan chan<- telegraf.Metric, shutdownChan <-chan struct{}, wg *sync.WaitGroup, lateDataPolicy string) Aggregator {
 	return &aggregator{
-		durationMap:  make(map[time.Duration]*durationAggregator),
-		metricChan:   metricChan,
-		shutdownChan: shutdownChan,
-		wg:           wg,
+		durationMap:    make(map[time.Duration]*durationAggregator),
+		metricChan:     metricChan,
+		shutdownChan:   shutdownChan,
+		wg:             wg,
+		lateDataPolicy: lateDataPolicy,
 	}
 }
 
@@ -77,6 +102,7 @@ func (agg *aggregator) AddMetric(m telegraf.Metric) {
 	var durationAgg *durationAggregator
 	if durationAgg, ok = agg.durationMap[aggDurationMapKey]; !ok {
 		durationAgg = newDurationAggregator(aggDurationMapKey, agg.metricChan, agg.shutdownChan, agg.wg)
+		durationAgg.lateDataPolicy = agg.lateDataPolicy
 		agg.durationMap[aggDurationMapKey] = durationAgg
 	}
 
@@ -96,6 +122,11 @@ type durationAggregator struct {
 	ticker              *time.Ticker
 	metricMap           map[string]telegraf.Metric //metric hash string + time sec int64 -> Metric object
 	aggregationChan     chan telegraf.Metric
+	lateDataPolicy      string
+	// closedBefore is the start of the window open at the last flush, the windows before it were published
+	closedBefore time.Time
+	// lateDropped are the datapoints dropped since the last flush
+	lateDropped int64
 }
 
 func newDurationAggregator(durationInSeconds time.Duration,
@@ -112,87 +143,120 @@ func newDurationAggregator(durationInSeconds time.Duration,
 		aggregationChan:     make(chan telegraf.Metric, durationAggregationChanBufferSize),
 	}
 
+	// the routine is registered before it starts, so that the output waits for its final flush when it stops
+	wg.Add(1)
 	go durationAgg.aggregating()
 
 	return durationAgg
 }
 
 func (durationAgg *durationAggregator) aggregating() {
-	durationAgg.wg.Add(1)
-	// sleep for some time until next round duration from now.
+	defer durationAgg.wg.Done()
+	// wait until next round duration from now, the metrics are aggregated meanwhile
 	now := time.Now()
-	time.Sleep(now.Truncate(durationAgg.aggregationDuration).Add(durationAgg.aggregationDuration).Sub(now))
-	durationAgg.ticker = time.NewTicker(durationAgg.aggregationDuration)
-	defer durationAgg.ticker.Stop()
+	aligned := time.NewTimer(now.Truncate(durationAgg.aggregationDuration).Add(durationAgg.aggregationDuration).Sub(now))
+	defer aligned.Stop()
+	var tick <-chan time.Time
 	for {
 		select {
 		case m := <-durationAgg.aggregationChan:
-			// https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_MetricDatum.html
-			aggregatedTime := m.Time().Truncate(durationAgg.aggregationDuration)
-			metricMapKey := fmt.Sprint(computeHash(m), aggregatedTime.Unix())
-			var aggregatedMetric telegraf.Metric
-			var ok bool
-			var err error
-			if aggregatedMetric, ok = durationAgg.metricMap[metricMapKey]; !ok {
-				aggregatedMetric, err = metric.New(m.Name(), m.Tags(), map[string]interface{}{}, aggregatedTime)
-				if err != nil {
-					log.Printf("E! CloudWatch metrics aggregation failed: %v. The metric %v will be dropped.", err, m.Name())
-					continue
-				}
-				durationAgg.metricMap[metricMapKey] = aggregatedMetric
-			}
-			//When the code comes here, it means the aggregatedMetric object has the same metric name, tags and aggregated time.
-			//We just need to aggregate the additional fields if any and the values for the fields.
-			for k, v := range m.Fields() {
-				var value float64
-				var dist distribution.Distribution
-				switch t := v.(type) {
-				case int:
-					value = float64(t)
-				case int32:
-					value = float64(t)
-				case int64:
-					value = float64(t)
-				case float64:
-					value = t
-				case bool:
-					if t {
-						value = 1
-					} else {
-						value = 0
-					}
-				case time.Time:
-					value = float64(t.Unix())
-				case distribution.Distribution:
-					dist = t
-				default:
-					// Skip unsupported type.
-					continue
-				}
-				var existingValue interface{}
-				if existingValue, ok = aggregatedMetric.Fields()[k]; !ok {
-					existingValue = distribution.NewDistribution()
-					aggregatedMetric.AddField(k, existingValue)
-				}
-				existingDist := existingValue.(distribution.Distribution)
-				if dist != nil {
-					existingDist.AddDistribution(dist)
-				} else {
-					existingDist.AddEntry(value, 1)
-				}
-			}
-		case <-durationAgg.ticker.C:
+			durationAgg.aggregate(m)
+		case <-aligned.C:
+			durationAgg.ticker = time.NewTicker(durationAgg.aggregationDuration)
+			defer durationAgg.ticker.Stop()
+			tick = durationAgg.ticker.C
+		case <-tick:
 			durationAgg.flush()
 		case <-durationAgg.shutdownChan:
 			log.Printf("D! CloudWatch: aggregating routine receives the shutdown signal, do the final flush now for aggregation interval %v", durationAgg.aggregationDuration)
+			// the metrics added before the shutdown are part of the final flush
+			for len(durationAgg.aggregationChan) > 0 {
+				durationAgg.aggregate(<-durationAgg.aggregationChan)
+			}
 			durationAgg.flush()
 			log.Printf("D! CloudWatch: aggregating routine receives the shutdown signal, exiting.")
-			durationAgg.wg.Done()
 			return
 		}
 	}
 }
 
+// aggregate adds the values of the metric to the aggregated metric of its window
+func (durationAgg *durationAggregator) aggregate(m telegraf.Metric) {
+	// https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_MetricDatum.html
+	aggregatedTime, ok := durationAgg.window(m)
+	if !ok {
+		return
+	}
+	metricMapKey := fmt.Sprint(computeHash(m), aggregatedTime.Unix())
+	var aggregatedMetric telegraf.Metric
+	var err error
+	if aggregatedMetric, ok = durationAgg.metricMap[metricMapKey]; !ok {
+		aggregatedMetric, err = metric.New(m.Name(), m.Tags(), map[string]interface{}{}, aggregatedTime)
+		if err != nil {
+			log.Printf("E! CloudWatch metrics aggregation failed: %v. The metric %v will be dropped.", err, m.Name())
+			return
+		}
+		durationAgg.metricMap[metricMapKey] = aggregatedMetric
+	}
+	//When the code comes here, it means the aggregatedMetric object has the same metric name, tags and aggregated time.
+	//We just need to aggregate the additional fields if any and the values for the fields.
+	for k, v := range m.Fields() {
+		var value float64
+		var dist distribution.Distribution
+		switch t := v.(type) {
+		case int:
+			value = float64(t)
+		case int32:
+			value = float64(t)
+		case int64:
+			value = float64(t)
+		case float64:
+			value = t
+		case bool:
+			if t {
+				value = 1
+			} else {
+				value = 0
+			}
+		case time.Time:
+			value = float64(t.Unix())
+		case distribution.Distribution:
+			dist = t
+		default:
+			// Skip unsupported type.
+			continue
+		}
+		var existingValue interface{}
+		if existingValue, ok = aggregatedMetric.Fields()[k]; !ok {
+			existingValue = distribution.NewDistribution()
+			aggregatedMetric.AddField(k, existingValue)
+		}
+		existingDist := existingValue.(distribution.Distribution)
+		if dist != nil {
+			existingDist.AddDistribution(dist)
+		} else {
+			existingDist.AddEntry(value, 1)
+		}
+	}
+}
+
+// window returns the start of the aggregation window of the metric, false when the late data policy drops it
+func (durationAgg *durationAggregator) window(m telegraf.Metric) (time.Time, bool) {
+	aggregatedTime := m.Time().Truncate(durationAgg.aggregationDuration)
+	if !aggregatedTime.Before(durationAgg.closedBefore) {
+		return aggregatedTime, true
+	}
+	switch durationAgg.lateDataPolicy {
+	case lateDataPolicyNextWindow:
+		return durationAgg.closedBefore, true
+	case lateDataPolicyDrop:
+		durationAgg.lateDropped++
+		lateMetricsDropped.Incr(1)
+		return time.Time{}, false
+	}
+	return aggregatedTime, true
+}
+
 func (durationAgg *durationAggregator) addMetric(m telegraf.Metric) {
 	durationAgg.aggregationChan <- m
 }
@@ -202,4 +266,10 @@ func (durationAgg *durationAggregator) flush() {
 		durationAgg.metricChan <- v
 	}
 	durationAgg.metricMap = make(map[string]telegraf.Metric)
+	durationAgg.closedBefore = time.Now().Truncate(durationAgg.aggregationDuration)
+	if durationAgg.lateDropped > 0 {
+		log.Printf("W! CloudWatch: dropped %v datapoints arriving after their aggregation window of %v was published",
+			durationAgg.lateDropped, durationAgg.aggregationDuration)
+		durationAgg.lateDropped = 0
+	}
 }
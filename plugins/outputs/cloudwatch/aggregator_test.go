@@ -15,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var metricName = "metric1"
@@ -159,6 +160,7 @@ func TestDurationAggregator_aggregating(t *testing.T) {
 		metricMap:           make(map[string]telegraf.Metric),
 		aggregationChan:     make(chan telegraf.Metric, durationAggregationChanBufferSize),
 	}
+	wg.Add(1)
 	go durationAgg.aggregating()
 
 	timestamp := time.Now()
@@ -192,6 +194,55 @@ func TestDurationAggregator_aggregating(t *testing.T) {
 	assert.Equal(t, 4, len(durationAgg.metricChan))
 }
 
+func TestDurationAggregator_flushOnShutdown(t *testing.T) {
+	metricChan := make(chan telegraf.Metric, metricChanBufferSize)
+	shutdownChan := make(chan struct{})
+	var wg sync.WaitGroup
+	durationAgg := newDurationAggregator(time.Hour, metricChan, shutdownChan, &wg)
+
+	tags := map[string]string{"d1key": "d1value"}
+	for i := 0; i < 10; i++ {
+		m, _ := metric.New(metricName, tags, map[string]interface{}{"value": i}, time.Now())
+		durationAgg.addMetric(m)
+	}
+	// the metrics still queued are aggregated and flushed although the window is far from over
+	close(shutdownChan)
+	wg.Wait()
+	assert.Empty(t, durationAgg.aggregationChan)
+	require.Len(t, metricChan, 1)
+	m := <-metricChan
+	assert.Equal(t, float64(10), m.Fields()["value"].(distribution.Distribution).SampleCount())
+}
+
+func TestDurationAggregator_mergeDistributions(t *testing.T) {
+	distribution.NewDistribution = seh1.NewSEH1Distribution
+	metricChan := make(chan telegraf.Metric, metricChanBufferSize)
+	shutdownChan := make(chan struct{})
+	var wg sync.WaitGroup
+	durationAgg := newDurationAggregator(time.Hour, metricChan, shutdownChan, &wg)
+
+	// the distributions of the collections of the window, e.g. the awaits of disk_latency, are merged
+	tags := map[string]string{"name": "nvme0n1"}
+	now := time.Now()
+	for _, samples := range [][]float64{{1, 2, 2}, {3, 250}} {
+		dist := seh1.NewSEH1Distribution()
+		for _, sample := range samples {
+			dist.AddEntryWithUnit(sample, 1, "Milliseconds")
+		}
+		m, _ := metric.New("disk_latency", tags, map[string]interface{}{"read_await": dist}, now)
+		durationAgg.addMetric(m)
+	}
+	close(shutdownChan)
+	wg.Wait()
+	require.Len(t, metricChan, 1)
+	dist := (<-metricChan).Fields()["read_await"].(distribution.Distribution)
+	assert.Equal(t, float64(5), dist.SampleCount())
+	assert.Equal(t, float64(258), dist.Sum())
+	assert.Equal(t, float64(1), dist.Minimum())
+	assert.Equal(t, float64(250), dist.Maximum())
+	assert.Equal(t, "Milliseconds", dist.Unit())
+}
+
 type expectedFieldContent struct {
 	fieldName                  string
 	max, min, sampleCount, sum float64
@@ -204,7 +255,7 @@ func testPreparation() (chan telegraf.Metric, chan struct{}, Aggregator) {
 	distribution.NewDistribution = seh1.NewSEH1Distribution
 	metricChan := make(chan telegraf.Metric, metricChanBufferSize)
 	shutdownChan := make(chan struct{})
-	aggregator := NewAggregator(metricChan, shutdownChan, &wg)
+	aggregator := NewAggregator(metricChan, shutdownChan, &wg, "")
 	return metricChan, shutdownChan, aggregator
 }
 
@@ -259,3 +310,41 @@ func assertNoMetricsInChan(t *testing.T, metricChan <-chan telegraf.Metric) {
 	default:
 	}
 }
+
+func TestDurationAggregator_lateDataPolicy(t *testing.T) {
+	aggregationInterval := time.Minute
+	now := time.Now()
+	closedBefore := now.Truncate(aggregationInterval)
+	tags := map[string]string{"d1key": "d1value"}
+	late, _ := metric.New(metricName, tags, map[string]interface{}{"value": 1}, closedBefore.Add(-time.Second))
+	onTime, _ := metric.New(metricName, tags, map[string]interface{}{"value": 1}, closedBefore.Add(time.Second))
+
+	for _, test := range []struct {
+		policy   string
+		expected time.Time
+		kept     bool
+	}{
+		{"", closedBefore.Add(-aggregationInterval), true},
+		{lateDataPolicyRepublish, closedBefore.Add(-aggregationInterval), true},
+		{lateDataPolicyNextWindow, closedBefore, true},
+		{lateDataPolicyDrop, time.Time{}, false},
+	} {
+		durationAgg := &durationAggregator{aggregationDuration: aggregationInterval, lateDataPolicy: test.policy, closedBefore: closedBefore}
+		window, ok := durationAgg.window(late)
+		assert.Equal(t, test.kept, ok, test.policy)
+		assert.Equal(t, test.expected, window, test.policy)
+		window, ok = durationAgg.window(onTime)
+		assert.True(t, ok, test.policy)
+		assert.Equal(t, closedBefore, window, test.policy)
+	}
+
+	durationAgg := &durationAggregator{aggregationDuration: aggregationInterval, lateDataPolicy: lateDataPolicyDrop, closedBefore: closedBefore}
+	dropped := lateMetricsDropped.Get()
+	durationAgg.window(late)
+	assert.Equal(t, dropped+1, lateMetricsDropped.Get())
+	assert.Equal(t, int64(1), durationAgg.lateDropped)
+
+	assert.NoError(t, validateLateDataPolicy(""))
+	assert.NoError(t, validateLateDataPolicy(lateDataPolicyNextWindow))
+	assert.Error(t, validateLateDataPolicy("reopen"))
+}
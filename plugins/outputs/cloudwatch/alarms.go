@@ -0,0 +1,173 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+const (
+	defaultAlarmPeriod            = 300
+	defaultAlarmEvaluationPeriods = 2
+	defaultAlarmStatistic         = "MAX"
+	defaultAlarmComparison        = cloudwatch.ComparisonOperatorGreaterThanOrEqualToThreshold
+	defaultAlarmTreatMissingData  = "missing"
+)
+
+// AlarmsConfig declares the alarms the agent manages for its instance: they are created or updated at startup and
+// deleted when the instance is decommissioned. The alarms query the metrics of the namespace carrying the InstanceId
+// dimension with Metrics Insights, so that they do not depend on the other dimensions of the metrics.
+type AlarmsConfig struct {
+	InstanceID string `toml:"instance_id"`
+	// NamePrefix is prepended to the names of the alarms, which end with the instance ID
+	NamePrefix        string        `toml:"name_prefix"`
+	AlarmActions      []string      `toml:"alarm_actions"`
+	OKActions         []string      `toml:"ok_actions"`
+	Period            int           `toml:"period"`
+	EvaluationPeriods int           `toml:"evaluation_periods"`
+	Alarms            []AlarmConfig `toml:"alarm"`
+}
+
+// AlarmConfig is an alarm on a metric of the instance, e.g. the disk used percent
+type AlarmConfig struct {
+	Name       string  `toml:"name"`
+	MetricName string  `toml:"metric_name"`
+	Statistic  string  `toml:"statistic"`
+	Comparison string  `toml:"comparison"`
+	Threshold  float64 `toml:"threshold"`
+	// TreatMissingData is breaching for the alarms on the agent health, which fire when the agent stops publishing
+	TreatMissingData string `toml:"treat_missing_data"`
+}
+
+func (a *AlarmsConfig) validate() error {
+	if a.InstanceID == "" {
+		return fmt.Errorf("alarms require the instance_id")
+	}
+	for _, alarm := range a.Alarms {
+		if alarm.Name == "" || alarm.MetricName == "" {
+			return fmt.Errorf("alarm requires both name and metric_name, found %q and %q", alarm.Name, alarm.MetricName)
+		}
+	}
+	return nil
+}
+
+func (a *AlarmsConfig) alarmName(alarm AlarmConfig) string {
+	return a.NamePrefix + alarm.Name + "-" + a.InstanceID
+}
+
+// alarmNames returns the names of the alarms of the instance
+func (a *AlarmsConfig) alarmNames() []*string {
+	names := make([]*string, 0, len(a.Alarms))
+	for _, alarm := range a.Alarms {
+		names = append(names, aws.String(a.alarmName(alarm)))
+	}
+	return names
+}
+
+// putMetricAlarmInputs returns the requests creating or updating the alarms of the instance
+func (a *AlarmsConfig) putMetricAlarmInputs(namespace string) []*cloudwatch.PutMetricAlarmInput {
+	period := a.Period
+	if period <= 0 {
+		period = defaultAlarmPeriod
+	}
+	evaluationPeriods := a.EvaluationPeriods
+	if evaluationPeriods <= 0 {
+		evaluationPeriods = defaultAlarmEvaluationPeriods
+	}
+	inputs := make([]*cloudwatch.PutMetricAlarmInput, 0, len(a.Alarms))
+	for _, alarm := range a.Alarms {
+		statistic := alarm.Statistic
+		if statistic == "" {
+			statistic = defaultAlarmStatistic
+		}
+		comparison := alarm.Comparison
+		if comparison == "" {
+			comparison = defaultAlarmComparison
+		}
+		treatMissingData := alarm.TreatMissingData
+		if treatMissingData == "" {
+			treatMissingData = defaultAlarmTreatMissingData
+		}
+		query := fmt.Sprintf(`SELECT %s("%s") FROM "%s" WHERE InstanceId = '%s'`,
+			statistic, quoteInsights(alarm.MetricName), quoteInsights(namespace), a.InstanceID)
+		input := &cloudwatch.PutMetricAlarmInput{
+			AlarmName:          aws.String(a.alarmName(alarm)),
+			AlarmDescription:   aws.String(fmt.Sprintf("Managed by the CloudWatch agent of %s", a.InstanceID)),
+			ComparisonOperator: aws.String(comparison),
+			Threshold:          aws.Float64(alarm.Threshold),
+			EvaluationPeriods:  aws.Int64(int64(evaluationPeriods)),
+			TreatMissingData:   aws.String(treatMissingData),
+			Metrics: []*cloudwatch.MetricDataQuery{{
+				Id:         aws.String("m1"),
+				Expression: aws.String(query),
+				Period:     aws.Int64(int64(period)),
+				ReturnData: aws.Bool(true),
+			}},
+		}
+		if len(a.AlarmActions) > 0 {
+			input.AlarmActions = aws.StringSlice(a.AlarmActions)
+		}
+		if len(a.OKActions) > 0 {
+			input.OKActions = aws.StringSlice(a.OKActions)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// quoteInsights escapes the double quotes of an identifier of a Metrics Insights query
+func quoteInsights(identifier string) string {
+	return strings.Replace(identifier, `"`, `\"`, -1)
+}
+
+// putAlarms creates or updates the alarms of the instance, the ones which fail are logged and the others still put
+func (a *AlarmsConfig) putAlarms(svc cloudwatchiface.CloudWatchAPI, namespace string) {
+	failed := 0
+	for _, input := range a.putMetricAlarmInputs(namespace) {
+		if _, err := svc.PutMetricAlarm(input); err != nil {
+			log.Printf("E! cloudwatch: Failed to put alarm %v: %v", *input.AlarmName, err)
+			failed++
+		}
+	}
+	log.Printf("I! cloudwatch: Put %v alarms of instance %v, %v failed", len(a.Alarms), a.InstanceID, failed)
+}
+
+// deleteAlarms deletes the alarms of the instance, DeleteAlarms ignores the names of the alarms which do not exist
+func (a *AlarmsConfig) deleteAlarms(svc cloudwatchiface.CloudWatchAPI) error {
+	names := a.alarmNames()
+	// DeleteAlarms accepts up to 100 alarm names
+	for len(names) > 0 {
+		n := len(names)
+		if n > 100 {
+			n = 100
+		}
+		if _, err := svc.DeleteAlarms(&cloudwatch.DeleteAlarmsInput{AlarmNames: names[:n]}); err != nil {
+			return fmt.Errorf("failed to delete the alarms of instance %v: %v", a.InstanceID, err)
+		}
+		names = names[n:]
+	}
+	log.Printf("I! cloudwatch: Deleted %v alarms of instance %v", len(a.Alarms), a.InstanceID)
+	return nil
+}
+
+// Decommission deletes the alarms the output manages for the instance, e.g. before the instance is terminated
+func (c *CloudWatch) Decommission() error {
+	if c.Alarms == nil {
+		return nil
+	}
+	if err := c.Alarms.validate(); err != nil {
+		return err
+	}
+	svc, err := c.newService()
+	if err != nil {
+		return err
+	}
+	return c.Alarms.deleteAlarms(svc)
+}
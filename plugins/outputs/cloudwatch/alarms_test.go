@@ -0,0 +1,88 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alarmsCloudWatchClient records the alarms put and deleted, failing the ones put with the failing name
+type alarmsCloudWatchClient struct {
+	cloudwatchiface.CloudWatchAPI
+	failing string
+	put     []*cloudwatch.PutMetricAlarmInput
+	deleted []*string
+}
+
+func (svc *alarmsCloudWatchClient) PutMetricAlarm(input *cloudwatch.PutMetricAlarmInput) (*cloudwatch.PutMetricAlarmOutput, error) {
+	svc.put = append(svc.put, input)
+	if *input.AlarmName == svc.failing {
+		return nil, errors.New("AccessDenied")
+	}
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (svc *alarmsCloudWatchClient) DeleteAlarms(input *cloudwatch.DeleteAlarmsInput) (*cloudwatch.DeleteAlarmsOutput, error) {
+	svc.deleted = append(svc.deleted, input.AlarmNames...)
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+func testAlarmsConfig() *AlarmsConfig {
+	return &AlarmsConfig{
+		InstanceID:   "i-0123456789abcdef0",
+		NamePrefix:   "CWAgent-",
+		AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:ops"},
+		Alarms: []AlarmConfig{
+			{Name: "disk-full", MetricName: "disk_used_percent", Threshold: 90},
+			{Name: "agent-health", MetricName: "mem_used_percent", Statistic: "COUNT",
+				Comparison: cloudwatch.ComparisonOperatorLessThanThreshold, Threshold: 1, TreatMissingData: "breaching"},
+		},
+	}
+}
+
+func TestAlarmsValidate(t *testing.T) {
+	assert.NoError(t, testAlarmsConfig().validate())
+	assert.Error(t, (&AlarmsConfig{}).validate())
+	assert.Error(t, (&AlarmsConfig{InstanceID: "i-1", Alarms: []AlarmConfig{{Name: "disk-full"}}}).validate())
+}
+
+func TestPutMetricAlarmInputs(t *testing.T) {
+	inputs := testAlarmsConfig().putMetricAlarmInputs("CWAgent")
+	require.Len(t, inputs, 2)
+
+	disk := inputs[0]
+	assert.Equal(t, "CWAgent-disk-full-i-0123456789abcdef0", *disk.AlarmName)
+	assert.Equal(t, cloudwatch.ComparisonOperatorGreaterThanOrEqualToThreshold, *disk.ComparisonOperator)
+	assert.Equal(t, 90.0, *disk.Threshold)
+	assert.Equal(t, int64(defaultAlarmEvaluationPeriods), *disk.EvaluationPeriods)
+	assert.Equal(t, "missing", *disk.TreatMissingData)
+	assert.Equal(t, []*string{aws.String("arn:aws:sns:us-east-1:123456789012:ops")}, disk.AlarmActions)
+	require.Len(t, disk.Metrics, 1)
+	assert.Equal(t, `SELECT MAX("disk_used_percent") FROM "CWAgent" WHERE InstanceId = 'i-0123456789abcdef0'`, *disk.Metrics[0].Expression)
+	assert.Equal(t, int64(defaultAlarmPeriod), *disk.Metrics[0].Period)
+
+	health := inputs[1]
+	assert.Equal(t, `SELECT COUNT("mem_used_percent") FROM "CWAgent" WHERE InstanceId = 'i-0123456789abcdef0'`, *health.Metrics[0].Expression)
+	assert.Equal(t, cloudwatch.ComparisonOperatorLessThanThreshold, *health.ComparisonOperator)
+	assert.Equal(t, "breaching", *health.TreatMissingData)
+}
+
+func TestPutAndDeleteAlarms(t *testing.T) {
+	a := testAlarmsConfig()
+	svc := &alarmsCloudWatchClient{failing: "CWAgent-disk-full-i-0123456789abcdef0"}
+
+	// a failing alarm does not prevent the others from being put
+	a.putAlarms(svc, "CWAgent")
+	assert.Len(t, svc.put, 2)
+
+	require.NoError(t, a.deleteAlarms(svc))
+	assert.Equal(t, a.alarmNames(), svc.deleted)
+}
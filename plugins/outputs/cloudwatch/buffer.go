@@ -0,0 +1,237 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/handlers"
+	"github.com/aws/amazon-cloudwatch-agent/internal/spool"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+const (
+	defaultBufferMaxSize   = 64 * 1024 * 1024
+	defaultBufferZstdLevel = 3
+	// CloudWatch rejects the datapoints older than 2 weeks, they are not replayed
+	maxBufferedDatumAge = 14 * 24 * time.Hour
+)
+
+// openBuffer opens the on-disk buffer of the metric datums which could not be published, they are replayed now
+// and whenever CloudWatch accepts metrics again after failures. Connect goes on without it on failure.
+func (c *CloudWatch) openBuffer() {
+	if c.BufferDir == "" {
+		return
+	}
+	maxSize := c.BufferMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultBufferMaxSize
+	}
+	b, err := spool.Open(c.BufferDir, maxSize)
+	if err != nil {
+		log.Printf("E! cloudwatch: unable to open the metric buffer in %v, the queued metrics are lost on restart: %v", c.BufferDir, err)
+		return
+	}
+	c.compressBuffer(b)
+	c.buffer = b
+	c.inflight = &inflightRequests{}
+	c.bufferReplayChan = make(chan struct{}, 1)
+	c.bufferReplayChan <- struct{}{}
+	go c.replayBuffer()
+}
+
+// compressBuffer compresses the datums appended to the buffer with zstd when configured, the ones buffered before
+// are replayed either way
+func (c *CloudWatch) compressBuffer(b *spool.Spool) {
+	if c.BufferCompression != "zstd" {
+		return
+	}
+	level := c.BufferCompressionLevel
+	if level == 0 {
+		level = defaultBufferZstdLevel
+	}
+	if err := b.EnableZstd(level); err != nil {
+		log.Printf("E! cloudwatch: unable to compress the metric buffer in %v, the metrics are buffered uncompressed: %v", c.BufferDir, err)
+	}
+}
+
+func (c *CloudWatch) replayBuffer() {
+	for {
+		select {
+		case <-c.shutdownChan:
+			return
+		case <-c.bufferReplayChan:
+		}
+		if c.buffer.Size() == 0 {
+			continue
+		}
+		expired := 0
+		replayed, corrupt, err := c.buffer.Replay(func(record []byte) {
+			var datums []*cloudwatch.MetricDatum
+			if err := json.Unmarshal(record, &datums); err != nil {
+				log.Printf("W! cloudwatch: skipping a buffered metric batch which cannot be decoded: %v", err)
+				return
+			}
+			kept := dropExpiredDatums(datums, time.Now())
+			expired += len(datums) - len(kept)
+			if len(kept) > 0 {
+				c.publisher.Publish(kept)
+			}
+		})
+		if err != nil {
+			log.Printf("E! cloudwatch: unable to replay the metric buffer in %v: %v", c.BufferDir, err)
+		}
+		log.Printf("I! cloudwatch: replayed %v metric batches from the buffer in %v, %v corrupt batches and %v expired datums were dropped",
+			replayed, c.BufferDir, corrupt, expired)
+	}
+}
+
+func dropExpiredDatums(datums []*cloudwatch.MetricDatum, now time.Time) []*cloudwatch.MetricDatum {
+	kept := datums[:0]
+	for _, d := range datums {
+		if d.Timestamp == nil || now.Sub(aws.TimeValue(d.Timestamp)) < maxBufferedDatumAge {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// inflightRequests are the requests being published, the buffer is closed once they are done so that the failed
+// ones are buffered. Its methods are no-ops on nil, when there is no buffer.
+type inflightRequests struct {
+	sync.Mutex
+	wg      sync.WaitGroup
+	closing bool
+}
+
+// begin registers a request in flight, it returns false once the buffer is closing
+func (r *inflightRequests) begin() bool {
+	if r == nil {
+		return true
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.closing {
+		return false
+	}
+	r.wg.Add(1)
+	return true
+}
+
+func (r *inflightRequests) done() {
+	if r != nil {
+		r.wg.Done()
+	}
+}
+
+// close waits for the requests in flight, the ones beginning afterwards are refused
+func (r *inflightRequests) close() {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	r.closing = true
+	r.Unlock()
+	r.wg.Wait()
+}
+
+// triggerBufferReplay replays the buffer once CloudWatch accepts metrics again, it is a no-op when the buffer is
+// empty or a replay is already pending
+func (c *CloudWatch) triggerBufferReplay() {
+	if c.buffer == nil || c.buffer.Size() == 0 {
+		return
+	}
+	select {
+	case c.bufferReplayChan <- struct{}{}:
+	default:
+	}
+}
+
+// bufferDatums persists the datums of a failed request, it returns false when they are dropped
+func (c *CloudWatch) bufferDatums(datums []*cloudwatch.MetricDatum) bool {
+	if c.buffer == nil || len(datums) == 0 {
+		return false
+	}
+	record, err := json.Marshal(datums)
+	if err == nil {
+		err = c.buffer.Append(record)
+	}
+	if err != nil {
+		log.Printf("E! cloudwatch: unable to buffer %v metric datums in %v: %v", len(datums), c.BufferDir, err)
+		return false
+	}
+	return true
+}
+
+// isBufferable returns whether a failed request may succeed later, the requests rejected as invalid are not buffered
+func isBufferable(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return true
+	}
+	switch awsErr.Code() {
+	case cloudwatch.ErrCodeInvalidParameterValueException, cloudwatch.ErrCodeInvalidParameterCombinationException,
+		cloudwatch.ErrCodeMissingRequiredParameterException:
+		return false
+	case cloudwatch.ErrCodeLimitExceededFault, cloudwatch.ErrCodeInternalServiceFault, handlers.ErrCodeCircuitOpen:
+		return true
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() == 0 || reqErr.StatusCode() >= 500 || reqErr.StatusCode() == 429
+	}
+	// e.g. a connection error
+	return true
+}
+
+// persistQueued buffers the datums still queued when the output stops, so that a planned restart during an
+// outage of CloudWatch does not lose them. It is called once the routines have stopped.
+func (c *CloudWatch) persistQueued() {
+	<-c.pushDone
+	// the requests still in flight stop retrying once the output stops, they are buffered when they fail
+	c.inflight.close()
+
+	batches := 0
+	persist := func(datums []*cloudwatch.MetricDatum) {
+		if c.bufferDatums(datums) {
+			batches++
+		}
+	}
+
+	var pending []*cloudwatch.MetricDatum
+	for len(c.metricChan) > 0 {
+		pending = append(pending, c.BuildMetricDatum(<-c.metricChan)...)
+	}
+	pending = append(c.metricDatumBatch.Partition, pending...)
+	for len(pending) > 0 {
+		n := c.MaxDatumsPerCall
+		if n > len(pending) {
+			n = len(pending)
+		}
+		persist(pending[:n])
+		pending = pending[n:]
+	}
+	for len(c.datumBatchChan) > 0 {
+		persist(<-c.datumBatchChan)
+	}
+	if c.queue != nil {
+		for {
+			req, ok := c.queue.Dequeue()
+			if !ok {
+				break
+			}
+			persist(req.([]*cloudwatch.MetricDatum))
+		}
+	}
+	if batches > 0 {
+		log.Printf("I! cloudwatch: buffered %v metric batches in %v, they are published on the next start", batches, c.BufferDir)
+	}
+	if err := c.buffer.Close(); err != nil {
+		log.Printf("E! cloudwatch: unable to close the metric buffer in %v: %v", c.BufferDir, err)
+	}
+}
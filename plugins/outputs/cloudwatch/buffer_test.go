@@ -0,0 +1,229 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publisher"
+	"github.com/aws/amazon-cloudwatch-agent/internal/spool"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testDatums(name string, n int) []*cloudwatch.MetricDatum {
+	datums := make([]*cloudwatch.MetricDatum, n)
+	for i := range datums {
+		datums[i] = &cloudwatch.MetricDatum{MetricName: aws.String(name), Value: aws.Float64(float64(i)), Timestamp: aws.Time(time.Now())}
+	}
+	return datums
+}
+
+// recordingCloudWatchClient fails the first requests with the given errors and records all of them
+type recordingCloudWatchClient struct {
+	cloudwatchiface.CloudWatchAPI
+	sync.Mutex
+	errs   []error
+	inputs []*cloudwatch.PutMetricDataInput
+}
+
+func (svc *recordingCloudWatchClient) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	svc.Lock()
+	defer svc.Unlock()
+	svc.inputs = append(svc.inputs, input)
+	if len(svc.errs) > 0 {
+		err := svc.errs[0]
+		svc.errs = svc.errs[1:]
+		return nil, err
+	}
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+func (svc *recordingCloudWatchClient) requests() []*cloudwatch.PutMetricDataInput {
+	svc.Lock()
+	defer svc.Unlock()
+	return append([]*cloudwatch.PutMetricDataInput(nil), svc.inputs...)
+}
+
+func TestFailedRequestIsBufferedAndReplayed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricbuffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	unavailable := awserr.NewRequestFailure(awserr.New("ServiceUnavailable", "", nil), 503, "")
+	svc := &recordingCloudWatchClient{errs: []error{unavailable}}
+	c := newCloudWatchClient(svc)
+	c.publisher, _ = publisher.NewPublisher(publisher.NewNonBlockingFifoQueue(10), 10, 2*time.Second, c.WriteToCloudWatch)
+	c.BufferDir = dir
+	c.openBuffer()
+	require.NotNil(t, c.buffer)
+
+	c.WriteToCloudWatch(testDatums("failed", 2))
+	assert.True(t, c.buffer.Size() > 0)
+
+	c.WriteToCloudWatch(testDatums("succeeded", 1))
+	assert.Eventually(t, func() bool { return c.buffer.Size() == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(svc.requests()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	replayed := svc.requests()[2]
+	assert.Len(t, replayed.MetricData, 2)
+	assert.Equal(t, "failed", *replayed.MetricData[0].MetricName)
+	c.Close()
+}
+
+func TestInvalidRequestIsNotBuffered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricbuffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	svc := new(mockCloudWatchClient)
+	invalid := awserr.NewRequestFailure(awserr.New(cloudwatch.ErrCodeInvalidParameterValueException, "", nil), 400, "")
+	svc.On("PutMetricData", mock.Anything).Return(&cloudwatch.PutMetricDataOutput{}, invalid)
+	c := newCloudWatchClient(svc)
+	c.BufferDir = dir
+	c.openBuffer()
+
+	c.WriteToCloudWatch(testDatums("invalid", 1))
+	assert.Equal(t, int64(0), c.buffer.Size())
+	assert.False(t, isBufferable(invalid))
+	assert.True(t, isBufferable(awserr.NewRequestFailure(awserr.New("Throttling", "", nil), 429, "")))
+	assert.False(t, isBufferable(awserr.NewRequestFailure(awserr.New("AccessDenied", "", nil), 403, "")))
+	assert.True(t, isBufferable(awserr.New("RequestError", "send request failed", nil)))
+}
+
+func TestPersistQueuedOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricbuffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &CloudWatch{
+		BufferDir:        dir,
+		MaxDatumsPerCall: 2,
+		metricChan:       make(chan telegraf.Metric, 10),
+		datumBatchChan:   make(chan []*cloudwatch.MetricDatum, 10),
+		metricDatumBatch: newMetricDatumBatch(2, 0),
+		pushDone:         make(chan struct{}),
+		queue:            publisher.NewNonBlockingFifoQueue(10),
+	}
+	close(c.pushDone)
+	c.buffer, err = spool.Open(dir, defaultBufferMaxSize)
+	require.NoError(t, err)
+
+	c.metricDatumBatch.Partition = testDatums("partial", 3)
+	m, _ := metric.New("pending", map[string]string{"host": "h"}, map[string]interface{}{"value": 1}, time.Now())
+	c.metricChan <- m
+	c.datumBatchChan <- testDatums("batched", 2)
+	c.queue.Enqueue(testDatums("queued", 2))
+	c.persistQueued()
+	assert.Equal(t, spool.ErrClosed, c.buffer.Append([]byte("late")))
+
+	s, err := spool.Open(dir, defaultBufferMaxSize)
+	require.NoError(t, err)
+	var names []string
+	replayed, corrupt, err := s.Replay(func(record []byte) {
+		var datums []*cloudwatch.MetricDatum
+		require.NoError(t, json.Unmarshal(record, &datums))
+		assert.True(t, len(datums) <= 2)
+		for _, d := range datums {
+			names = append(names, *d.MetricName)
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, replayed)
+	assert.Equal(t, 0, corrupt)
+	assert.Equal(t, []string{"partial", "partial", "partial", "pending", "batched", "batched", "queued", "queued"}, names)
+}
+
+// slowCloudWatchClient takes longer than the publisher waits for the requests in flight on close, then fails them
+type slowCloudWatchClient struct {
+	cloudwatchiface.CloudWatchAPI
+	started chan struct{}
+}
+
+func (svc *slowCloudWatchClient) PutMetricData(*cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	close(svc.started)
+	time.Sleep(1500 * time.Millisecond)
+	return nil, awserr.NewRequestFailure(awserr.New(cloudwatch.ErrCodeInternalServiceFault, "", nil), 500, "")
+}
+
+func TestInflightRequestIsBufferedOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricbuffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	svc := &slowCloudWatchClient{started: make(chan struct{})}
+	c := newCloudWatchClient(svc)
+	c.queue = publisher.NewNonBlockingFifoQueue(10)
+	c.publisher, _ = publisher.NewPublisher(c.queue, 10, 2*time.Second, c.WriteToCloudWatch)
+	c.BufferDir = dir
+	c.openBuffer()
+	require.NotNil(t, c.buffer)
+
+	c.publisher.Publish(testDatums("inflight", 2))
+	<-svc.started
+	c.Close()
+	assert.False(t, c.inflight.begin())
+
+	s, err := spool.Open(dir, defaultBufferMaxSize)
+	require.NoError(t, err)
+	var names []string
+	_, _, err = s.Replay(func(record []byte) {
+		var datums []*cloudwatch.MetricDatum
+		require.NoError(t, json.Unmarshal(record, &datums))
+		for _, d := range datums {
+			names = append(names, *d.MetricName)
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"inflight", "inflight"}, names)
+}
+
+func TestDropExpiredDatums(t *testing.T) {
+	now := time.Now()
+	datums := []*cloudwatch.MetricDatum{
+		{MetricName: aws.String("old"), Timestamp: aws.Time(now.Add(-15 * 24 * time.Hour))},
+		{MetricName: aws.String("recent"), Timestamp: aws.Time(now.Add(-time.Hour))},
+		{MetricName: aws.String("untimed")},
+	}
+	kept := dropExpiredDatums(datums, now)
+	assert.Len(t, kept, 2)
+	assert.Equal(t, "recent", *kept[0].MetricName)
+	assert.Equal(t, "untimed", *kept[1].MetricName)
+}
+
+func TestCompressedBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricbuffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &CloudWatch{BufferDir: dir, BufferCompression: "zstd"}
+	c.buffer, err = spool.Open(dir, defaultBufferMaxSize)
+	require.NoError(t, err)
+	c.compressBuffer(c.buffer)
+
+	datums := testDatums("compressed", 20)
+	record, err := json.Marshal(datums)
+	require.NoError(t, err)
+	assert.True(t, c.bufferDatums(datums))
+	assert.True(t, c.buffer.Size() < int64(len(record)))
+
+	var replayed []*cloudwatch.MetricDatum
+	_, corrupt, err := c.buffer.Replay(func(record []byte) {
+		require.NoError(t, json.Unmarshal(record, &replayed))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, corrupt)
+	assert.Len(t, replayed, 20)
+}
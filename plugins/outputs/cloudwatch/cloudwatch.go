@@ -6,7 +6,6 @@ package cloudwatch
 import (
 	"log"
 	"math"
-	"net/http"
 	"reflect"
 	"runtime"
 	"sort"
@@ -14,7 +13,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal/catalog"
+	"github.com/aws/amazon-cloudwatch-agent/internal/flush"
+	"github.com/aws/amazon-cloudwatch-agent/internal/lifecycle"
 	"github.com/aws/amazon-cloudwatch-agent/internal/publisher"
+	"github.com/aws/amazon-cloudwatch-agent/internal/spool"
 
 	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
 	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
@@ -48,34 +51,64 @@ const (
 )
 
 type CloudWatch struct {
-	Region             string                   `toml:"region"`
-	EndpointOverride   string                   `toml:"endpoint_override"`
-	AccessKey          string                   `toml:"access_key"`
-	SecretKey          string                   `toml:"secret_key"`
-	RoleARN            string                   `toml:"role_arn"`
-	Profile            string                   `toml:"profile"`
-	Filename           string                   `toml:"shared_credential_file"`
-	Token              string                   `toml:"token"`
-	ForceFlushInterval internal.Duration        `toml:"force_flush_interval"` // unit is second
-	MaxDatumsPerCall   int                      `toml:"max_datums_per_call"`
-	MaxValuesPerDatum  int                      `toml:"max_values_per_datum"`
-	MetricConfigs      []MetricDecorationConfig `toml:"metric_decoration"`
-	RollupDimensions   [][]string               `toml:"rollup_dimensions"`
-	Namespace          string                   `toml:"namespace"` // CloudWatch Metrics Namespace
+	Region               string                   `toml:"region"`
+	EndpointOverride     string                   `toml:"endpoint_override"`
+	UseDualStackEndpoint bool                     `toml:"use_dualstack_endpoint"`
+	AccessKey            string                   `toml:"access_key"`
+	SecretKey            string                   `toml:"secret_key"`
+	RoleARN              string                   `toml:"role_arn"`
+	ExternalID           string                   `toml:"external_id"`
+	Profile              string                   `toml:"profile"`
+	Filename             string                   `toml:"shared_credential_file"`
+	Token                string                   `toml:"token"`
+	ForceFlushInterval   internal.Duration        `toml:"force_flush_interval"` // unit is second
+	MaxDatumsPerCall     int                      `toml:"max_datums_per_call"`
+	MaxValuesPerDatum    int                      `toml:"max_values_per_datum"`
+	MetricConfigs        []MetricDecorationConfig `toml:"metric_decoration"`
+	RollupDimensions     [][]string               `toml:"rollup_dimensions"`
+	Namespace            string                   `toml:"namespace"` // CloudWatch Metrics Namespace
+	RoleOverrides        []RoleOverrideConfig     `toml:"role_override"`
+	BufferDir            string                   `toml:"buffer_dir"`
+	BufferMaxSize        int64                    `toml:"buffer_max_size"`
+	Alarms               *AlarmsConfig            `toml:"alarms"`
+
+	// Compress the metrics of buffer_dir with zstd at the level, the buffer_max_size applies to the compressed size
+	BufferCompression      string `toml:"buffer_compression"`
+	BufferCompressionLevel int    `toml:"buffer_compression_level"`
+
+	// Publish the buffered metrics once the termination notice of the instance is received, and every second after
+	FlushOnTerminationNotice bool `toml:"flush_on_termination_notice"`
+	// PublishJitter is the most the publishes are delayed after the multiples of ForceFlushInterval on the wall clock,
+	// random per agent to spread the API calls of a fleet, the whole ForceFlushInterval when not set. The publishes
+	// are not delayed when it is 0.
+	PublishJitter *internal.Duration `toml:"publish_jitter"`
+	// LateDataPolicy is what the aggregation does with the datapoints arriving after their window was published
+	LateDataPolicy string `toml:"late_data_policy"`
 
 	svc                    cloudwatchiface.CloudWatchAPI
 	aggregator             Aggregator
 	aggregatorShutdownChan chan struct{}
-	aggregatorWaitGroup    sync.WaitGroup
+	aggregatorWaitGroup    *sync.WaitGroup
 	metricChan             chan telegraf.Metric
 	datumBatchChan         chan []*cloudwatch.MetricDatum
 	datumBatchFullChan     chan bool
 	metricDatumBatch       *MetricDatumBatch
 	shutdownChan           chan struct{}
 	pushTicker             *time.Ticker
+	// now and after are the clock of the start of the publishes, the wall clock when nil
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
 	metricDecorations      *MetricDecorations
 	retries                int
 	publisher              *publisher.Publisher
+	queue                  publisher.Queue
+	roleOverrides          []*roleOverride
+	pushDone               chan struct{}
+	buffer                 *spool.Spool
+	bufferReplayChan       chan struct{}
+	terminating            <-chan struct{}
+	// inflight are the requests being published while the buffer is open, nil without buffer
+	inflight *inflightRequests
 }
 
 var sampleConfig = `
@@ -96,12 +129,62 @@ var sampleConfig = `
   #role_arn = ""
   #profile = ""
   #shared_credential_file = ""
+  ## The external ID required by the trust policy of the role, e.g. of a monitoring account
+  #external_id = ""
 
   ## Namespace for the CloudWatch MetricDatums
   namespace = "InfluxData/Telegraf"
 
   ## RollupDimensions
   # RollupDimensions = [["host"],["host", "ImageId"],[]]
+
+  ## Publish the metrics with a matching tag value to another namespace and/or with another role
+  # [[outputs.cloudwatch.role_override]]
+  #   tag_key = "team"
+  #   tag_value = "app"
+  #   namespace = "App/Metrics"
+  #   role_arn = "arn:aws:iam::123456789012:role/AppMetricsPublisher"
+  #   ## Drop the tag from the dimensions, and replace the rollup of the output
+  #   # remove_tag = true
+  #   # rollup_dimensions = [["host"]]
+
+  ## Persist the metrics which could not be published, e.g. during an outage, and the ones
+  ## still queued on stop in this directory, they are published once CloudWatch accepts
+  ## metrics again or on the next start. The buffer is capped to buffer_max_size bytes.
+  # buffer_dir = "/opt/aws/amazon-cloudwatch-agent/var/metric-buffer"
+  # buffer_max_size = 67108864
+  ## Compress the buffered metrics with zstd at the level, from 1 (fastest) to 22
+  # buffer_compression = "zstd"
+  # buffer_compression_level = 3
+
+  ## Create or update alarms on the metrics of the instance at startup, they are
+  ## deleted by running the agent with -decommission, e.g. before terminating it
+  # [outputs.cloudwatch.alarms]
+  #   instance_id = "i-1234567890abcdef0"
+  #   name_prefix = "CWAgent-"
+  #   alarm_actions = ["arn:aws:sns:us-east-1:123456789012:ops"]
+  #   period = 300
+  #   evaluation_periods = 2
+  #   [[outputs.cloudwatch.alarms.alarm]]
+  #     name = "disk-full"
+  #     metric_name = "disk_used_percent"
+  #     threshold = 90.0
+
+  ## Publish the buffered metrics once the spot interruption or auto scaling
+  ## scale-in notice of the instance is received, and every second after
+  # flush_on_termination_notice = false
+
+  ## The most the publishes are delayed after the multiples of force_flush_interval on the
+  ## wall clock, random per agent to spread the API calls, force_flush_interval by default.
+  ## The publishes are not delayed with "0s".
+  # publish_jitter = "5s"
+
+  ## What the aggregation of the metrics with an aggregation interval does with the
+  ## datapoints arriving after their window was published, e.g. from the clients of
+  ## statsd buffering them: "republish" their window on the next flush, the default,
+  ## add them to the "next_window", or "drop" them, counted by the late_metrics_dropped
+  ## field of the internal_cloudwatch metric of the internal input
+  # late_data_policy = "republish"
 `
 
 func (c *CloudWatch) SampleConfig() string {
@@ -115,48 +198,81 @@ func (c *CloudWatch) Description() string {
 func (c *CloudWatch) Connect() error {
 	var err error
 
-	c.publisher, _ = publisher.NewPublisher(publisher.NewNonBlockingFifoQueue(metricChanBufferSize), maxConcurrentPublisher, 2*time.Second, c.WriteToCloudWatch)
-
 	if c.metricDecorations, err = NewMetricDecorations(c.MetricConfigs); err != nil {
 		return err
 	}
 
-	credentialConfig := &internalaws.CredentialConfig{
-		Region:    c.Region,
-		AccessKey: c.AccessKey,
-		SecretKey: c.SecretKey,
-		RoleARN:   c.RoleARN,
-		Profile:   c.Profile,
-		Filename:  c.Filename,
-		Token:     c.Token,
+	if err = validateLateDataPolicy(c.LateDataPolicy); err != nil {
+		return err
 	}
-	configProvider := credentialConfig.Credentials()
 
-	svc := cloudwatch.New(
-		configProvider,
-		&aws.Config{
-			Endpoint:   aws.String(c.EndpointOverride),
-			HTTPClient: &http.Client{Timeout: 1 * time.Minute},
-		})
+	if c.Alarms != nil {
+		if err = c.Alarms.validate(); err != nil {
+			return err
+		}
+	}
 
-	svc.Handlers.Build.PushBackNamed(handlers.NewRequestCompressionHandler([]string{opPutLogEvents, opPutMetricData}))
-	svc.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("User-Agent", agentinfo.UserAgent()))
+	svc, err := c.newService()
+	if err != nil {
+		return err
+	}
+
+	// the role overrides are connected before anything of the output is started, which would leak when one fails
+	if err = c.connectRoleOverrides(); err != nil {
+		return err
+	}
+
+	c.queue = publisher.NewNonBlockingFifoQueue(metricChanBufferSize)
+	c.publisher, _ = publisher.NewPublisher(c.queue, maxConcurrentPublisher, 2*time.Second, c.WriteToCloudWatch)
 
 	//Format unique roll up list
 	c.RollupDimensions = GetUniqueRollupList(c.RollupDimensions)
 
 	c.svc = svc
 	c.startRoutines()
+	c.openBuffer()
+	if c.Alarms != nil {
+		go c.Alarms.putAlarms(c.svc, c.Namespace)
+	}
 	return nil
 }
 
+func (c *CloudWatch) newService() (*cloudwatch.CloudWatch, error) {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:     c.Region,
+		AccessKey:  c.AccessKey,
+		SecretKey:  c.SecretKey,
+		RoleARN:    c.RoleARN,
+		Profile:    c.Profile,
+		Filename:   c.Filename,
+		Token:      c.Token,
+		ExternalID: c.ExternalID,
+	}
+	configProvider := credentialConfig.Credentials()
+
+	config, err := internalaws.EndpointConfig(cloudwatch.EndpointsID, c.Region, c.EndpointOverride, internalaws.EndpointOptions{DualStack: c.UseDualStackEndpoint})
+	if err != nil {
+		return nil, err
+	}
+	config.HTTPClient = internalaws.HTTPClient(1 * time.Minute)
+	svc := cloudwatch.New(configProvider, config)
+
+	svc.Handlers.Build.PushBackNamed(handlers.NewRequestCompressionHandler([]string{opPutLogEvents, opPutMetricData}))
+	svc.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("User-Agent", agentinfo.UserAgent()))
+	handlers.AddPayloadSamplingHandlers(&svc.Handlers, []string{opPutMetricData})
+	handlers.AddCircuitBreakerHandlers(&svc.Handlers)
+	return svc, nil
+}
+
 func (c *CloudWatch) startRoutines() {
 	c.metricChan = make(chan telegraf.Metric, metricChanBufferSize)
 	c.datumBatchChan = make(chan []*cloudwatch.MetricDatum, datumBatchChanBufferSize)
 	c.datumBatchFullChan = make(chan bool, 1)
 	c.shutdownChan = make(chan struct{})
+	c.pushDone = make(chan struct{})
 	c.aggregatorShutdownChan = make(chan struct{})
-	c.aggregator = NewAggregator(c.metricChan, c.aggregatorShutdownChan, &c.aggregatorWaitGroup)
+	c.aggregatorWaitGroup = &sync.WaitGroup{}
+	c.aggregator = NewAggregator(c.metricChan, c.aggregatorShutdownChan, c.aggregatorWaitGroup, c.LateDataPolicy)
 	if c.ForceFlushInterval.Duration == 0 {
 		c.ForceFlushInterval.Duration = pushIntervalInSec * time.Second
 	}
@@ -169,6 +285,9 @@ func (c *CloudWatch) startRoutines() {
 	setNewDistributionFunc(c.MaxValuesPerDatum)
 	perRequestConstSize := overallConstPerRequestSize + len(c.Namespace) + namespaceOverheads
 	c.metricDatumBatch = newMetricDatumBatch(c.MaxDatumsPerCall, perRequestConstSize)
+	if c.FlushOnTerminationNotice {
+		c.terminating = lifecycle.Terminating()
+	}
 	go c.pushMetricDatum()
 	go c.publish()
 }
@@ -190,13 +309,17 @@ func (c *CloudWatch) Close() error {
 	}
 	close(c.shutdownChan)
 	c.publisher.Close()
+	if c.buffer != nil {
+		c.persistQueued()
+	}
+	c.closeRoleOverrides()
 	log.Println("D! Stopped the CloudWatch output plugin")
 	return nil
 }
 
 func (c *CloudWatch) Write(metrics []telegraf.Metric) error {
 	for _, m := range metrics {
-		c.aggregator.AddMetric(m)
+		c.route(m).aggregator.AddMetric(m)
 	}
 	return nil
 }
@@ -205,8 +328,10 @@ func (c *CloudWatch) Write(metrics []telegraf.Metric) error {
 // is equal to one MetricDatum. There is a limit on how many MetricDatums a
 // request can have so we process one Point at a time.
 func (c *CloudWatch) pushMetricDatum() {
+	defer close(c.pushDone)
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
+	terminating, terminated := c.terminating, false
 	for {
 		select {
 		case point := <-c.metricChan:
@@ -222,11 +347,29 @@ func (c *CloudWatch) pushMetricDatum() {
 				}
 			}
 		case <-ticker.C:
-			if c.timeToPublish(c.metricDatumBatch) {
+			if c.timeToPublish(c.metricDatumBatch) || (terminated && len(c.metricDatumBatch.Partition) > 0) {
 				// if the time to publish comes
 				c.datumBatchChan <- c.metricDatumBatch.Partition
 				c.metricDatumBatch.clear()
 			}
+			if terminated {
+				c.pushMetricDatumBatch()
+			}
+		case <-terminating:
+			// publish what is buffered and every second after until the instance is gone
+			log.Printf("I! cloudwatch: publishing the buffered metrics since the instance is terminating: %v", lifecycle.TerminationNotice())
+			terminating, terminated = nil, true
+			if len(c.metricDatumBatch.Partition) > 0 {
+				c.datumBatchChan <- c.metricDatumBatch.Partition
+				c.metricDatumBatch.clear()
+			}
+			c.pushMetricDatumBatch()
+		case <-flush.Requested():
+			if len(c.metricDatumBatch.Partition) > 0 {
+				c.datumBatchChan <- c.metricDatumBatch.Partition
+				c.metricDatumBatch.clear()
+			}
+			c.pushMetricDatumBatch()
 		case <-c.shutdownChan:
 			return
 		}
@@ -265,12 +408,40 @@ func (c *CloudWatch) timeToPublish(b *MetricDatumBatch) bool {
 	return len(b.Partition) > 0 && time.Now().Sub(b.BeginTime) >= c.ForceFlushInterval.Duration
 }
 
+// maxPublishJitter returns the most the publishes are delayed after the multiples of the flush interval, the jitter
+// is in whole seconds so it is 0 below a second
+func (c *CloudWatch) maxPublishJitter() time.Duration {
+	if c.PublishJitter == nil || c.PublishJitter.Duration >= c.ForceFlushInterval.Duration {
+		return c.ForceFlushInterval.Duration
+	}
+	if c.PublishJitter.Duration < time.Second {
+		return 0
+	}
+	return c.PublishJitter.Duration
+}
+
+// publishDelay returns how long until the next multiple of the flush interval on the wall clock plus the jitter
+func publishDelay(now time.Time, forceFlushInterval time.Duration, publishJitter time.Duration) time.Duration {
+	if forceFlushInterval <= 0 {
+		return 0
+	}
+	return now.Truncate(forceFlushInterval).Add(forceFlushInterval).Add(publishJitter).Sub(now)
+}
+
 func (c *CloudWatch) publish() {
-	now := time.Now()
+	now, after := time.Now, time.After
+	if c.now != nil {
+		now, after = c.now, c.after
+	}
 	forceFlushInterval := c.ForceFlushInterval.Duration
-	publishJitter := publishJitter(forceFlushInterval)
+	publishJitter := publishJitter(c.maxPublishJitter())
 	log.Printf("I! cloudwatch: publish with ForceFlushInterval: %v, Publish Jitter: %v", forceFlushInterval, publishJitter)
-	time.Sleep(now.Truncate(forceFlushInterval).Add(publishJitter).Sub(now))
+	// the metrics of the first interval are still published when the agent stops before its end
+	select {
+	case <-after(publishDelay(now(), forceFlushInterval, publishJitter)):
+	case <-c.aggregatorShutdownChan:
+	case <-c.shutdownChan:
+	}
 	c.pushTicker = time.NewTicker(c.ForceFlushInterval.Duration)
 	defer c.pushTicker.Stop()
 	shouldPublish := false
@@ -318,8 +489,8 @@ func (c *CloudWatch) pushMetricDatumBatch() {
 	}
 }
 
-//sleep some back off time before retries.
-func (c *CloudWatch) backoffSleep() {
+//sleep some back off time before retries, it returns false when the output stops meanwhile.
+func (c *CloudWatch) backoffSleep() bool {
 	var backoffInMillis int64 = 60 * 1000 // 1 minute
 	if c.retries <= defaultRetryCount {
 		backoffInMillis = int64(backoffRetryBase * math.Pow(2, float64(c.retries)))
@@ -327,11 +498,23 @@ func (c *CloudWatch) backoffSleep() {
 	sleepDuration := time.Millisecond * time.Duration(backoffInMillis)
 	log.Printf("W! %v retries, going to sleep %v before retrying.", c.retries, sleepDuration)
 	c.retries++
-	time.Sleep(sleepDuration)
+	timer := time.NewTimer(sleepDuration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.shutdownChan:
+		return false
+	}
 }
 
 func (c *CloudWatch) WriteToCloudWatch(req interface{}) {
 	datums := req.([]*cloudwatch.MetricDatum)
+	if !c.inflight.begin() {
+		log.Printf("E! WriteToCloudWatch failure, %v datums are dropped since the output has stopped", len(datums))
+		return
+	}
+	defer c.inflight.done()
 	params := &cloudwatch.PutMetricDataInput{
 		MetricData: datums,
 		Namespace:  aws.String(c.Namespace),
@@ -344,15 +527,19 @@ func (c *CloudWatch) WriteToCloudWatch(req interface{}) {
 			awsErr, ok := err.(awserr.Error)
 			if !ok {
 				log.Printf("E! Cannot cast PutMetricData error %v into awserr.Error.", err)
-				c.backoffSleep()
+				if !c.backoffSleep() {
+					break
+				}
 				continue
 			}
 			switch awsErr.Code() {
-			case cloudwatch.ErrCodeLimitExceededFault, cloudwatch.ErrCodeInternalServiceFault:
+			case cloudwatch.ErrCodeLimitExceededFault, cloudwatch.ErrCodeInternalServiceFault, handlers.ErrCodeCircuitOpen:
 				log.Printf("W! cloudwatch putmetricdate met issue: %s, message: %s",
 					awsErr.Code(),
 					awsErr.Message())
-				c.backoffSleep()
+				if !c.backoffSleep() {
+					break
+				}
 				continue
 
 			default:
@@ -365,8 +552,14 @@ func (c *CloudWatch) WriteToCloudWatch(req interface{}) {
 		break
 	}
 	if err != nil {
+		if isBufferable(err) && c.bufferDatums(datums) {
+			log.Printf("W! WriteToCloudWatch failure, %v datums are buffered until CloudWatch accepts metrics again, err: %v", len(datums), err)
+			return
+		}
 		log.Println("E! WriteToCloudWatch failure, err: ", err)
+		return
 	}
+	c.triggerBufferReplay()
 }
 
 func (c *CloudWatch) decorateMetricName(category string, name string) (decoratedName string) {
@@ -466,6 +659,7 @@ func (c *CloudWatch) BuildMetricDatum(point telegraf.Metric) []*cloudwatch.Metri
 		}
 
 		for _, dimensions := range dimensionsList {
+			catalog.Record(c.Namespace, *metricName, dimensionNames(dimensions), unit, point.Name())
 			if len(distList) == 0 {
 				datum := &cloudwatch.MetricDatum{
 					MetricName: metricName,
@@ -510,6 +704,16 @@ func (c *CloudWatch) BuildMetricDatum(point telegraf.Metric) []*cloudwatch.Metri
 	return datums
 }
 
+// dimensionNames returns the sorted names of the dimensions the catalog of the metrics is keyed by
+func dimensionNames(dimensions []*cloudwatch.Dimension) []string {
+	names := make([]string, 0, len(dimensions))
+	for _, d := range dimensions {
+		names = append(names, aws.StringValue(d.Name))
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Make a list of Dimensions by using a Point's tags. CloudWatch supports up to
 // 10 dimensions per metric so we only keep up to the first 10 alphabetically.
 // This always includes the "host" tag if it exists.
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/catalog"
 	"github.com/aws/amazon-cloudwatch-agent/internal/publisher"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/regular"
@@ -571,3 +572,55 @@ func TestBuildMetricDatums_SkipEmptyTags(t *testing.T) {
 	datums := c.BuildMetricDatum(input)
 	require.Len(t, datums[0].Dimensions, 1)
 }
+
+func TestBuildMetricDatums_Catalog(t *testing.T) {
+	c := &CloudWatch{Namespace: "CatalogTest", RollupDimensions: [][]string{{"host"}, {}}}
+	c.metricDecorations, _ = NewMetricDecorations([]MetricDecorationConfig{{Category: "mem", Metric: "used_percent", Unit: "Percent"}})
+	input := testutil.MustMetric(
+		"mem",
+		map[string]string{"host": "example.org", "zone": "a"},
+		map[string]interface{}{"used_percent": 42.0},
+		time.Unix(0, 0),
+	)
+	c.BuildMetricDatum(input)
+
+	metrics, truncated := catalog.Report("CatalogTest")
+	assert.False(t, truncated)
+	require.Len(t, metrics, 3)
+	for i, dimensions := range [][]string{{}, {"host"}, {"host", "zone"}} {
+		assert.Equal(t, "mem_used_percent", metrics[i].MetricName)
+		assert.Equal(t, dimensions, metrics[i].Dimensions)
+		assert.Equal(t, "Percent", metrics[i].Unit)
+		assert.Equal(t, "mem", metrics[i].Source)
+	}
+}
+
+func TestFlushOnTerminationNotice(t *testing.T) {
+	svc := new(mockCloudWatchClient)
+	svc.On("PutMetricData", mock.Anything).Return(&cloudwatch.PutMetricDataOutput{}, nil)
+	terminating := make(chan struct{})
+	cloudWatchOutput := &CloudWatch{
+		svc:                svc,
+		ForceFlushInterval: internal.Duration{Duration: time.Hour},
+		terminating:        terminating,
+	}
+	cloudWatchOutput.startRoutines()
+	cloudWatchOutput.publisher, _ = publisher.NewPublisher(publisher.NewNonBlockingFifoQueue(10), 10, 2*time.Second, cloudWatchOutput.WriteToCloudWatch)
+
+	m, _ := metric.New("Test_namespace", map[string]string{"dimension_name1": "dimension_value2"}, map[string]interface{}{"usage_user": 100}, time.Now())
+	cloudWatchOutput.Write([]telegraf.Metric{m})
+	time.Sleep(100 * time.Millisecond)
+	svc.AssertNumberOfCalls(t, "PutMetricData", 0)
+
+	close(terminating)
+	time.Sleep(time.Second)
+	svc.AssertNumberOfCalls(t, "PutMetricData", 1)
+	cloudWatchOutput.Close()
+}
+
+func TestConnectUnresolvedEndpoint(t *testing.T) {
+	c := &CloudWatch{Namespace: "CWAgent", Region: "us-iso-east-1", UseDualStackEndpoint: true}
+	assert.Error(t, c.Connect())
+	assert.Nil(t, c.publisher)
+	assert.Nil(t, c.metricChan)
+}
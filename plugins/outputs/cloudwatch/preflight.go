@@ -0,0 +1,40 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+)
+
+// iamActions are the actions the output calls CloudWatch with
+func (c *CloudWatch) iamActions() []string {
+	actions := []string{"cloudwatch:PutMetricData"}
+	if c.Alarms != nil {
+		actions = append(actions, "cloudwatch:PutMetricAlarm")
+	}
+	return actions
+}
+
+// PreflightIAM checks that the actions of the output are allowed, with the credentials of the output and the ones of
+// the role overrides
+func (c *CloudWatch) PreflightIAM() []preflight.Check {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:     c.Region,
+		AccessKey:  c.AccessKey,
+		SecretKey:  c.SecretKey,
+		RoleARN:    c.RoleARN,
+		Profile:    c.Profile,
+		Filename:   c.Filename,
+		Token:      c.Token,
+		ExternalID: c.ExternalID,
+	}
+	checks := preflight.CheckIAMActions(credentialConfig.Credentials(), c.iamActions())
+	for _, r := range c.RoleOverrides {
+		if r.RoleARN != "" && r.RoleARN != c.RoleARN {
+			checks = append(checks, c.newRoleOverrideOutput(r).PreflightIAM()...)
+		}
+	}
+	return checks
+}
@@ -0,0 +1,129 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+
+	"github.com/influxdata/telegraf"
+)
+
+// bufferDirName replaces the characters of the tag of a role override which are not safe in a directory name
+var bufferDirName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// RoleOverrideConfig routes the metrics carrying the given tag value to their own namespace
+// and publishes them with their own IAM role, e.g. so application metrics on a shared host can
+// be published into the application team's account while infra metrics stay in the platform account.
+type RoleOverrideConfig struct {
+	TagKey    string `toml:"tag_key"`
+	TagValue  string `toml:"tag_value"`
+	Namespace string `toml:"namespace"`
+	RoleARN   string `toml:"role_arn"`
+	// RemoveTag drops the tag from the dimensions of the matching metrics, for the tags set only to route them
+	RemoveTag bool `toml:"remove_tag"`
+	// RollupDimensions replaces the rollup of the parent output when set
+	RollupDimensions [][]string `toml:"rollup_dimensions"`
+}
+
+func (r *RoleOverrideConfig) validate() error {
+	if r.TagKey == "" || r.TagValue == "" {
+		return fmt.Errorf("role_override requires both tag_key and tag_value, found %q=%q", r.TagKey, r.TagValue)
+	}
+	if r.Namespace == "" && r.RoleARN == "" {
+		return fmt.Errorf("role_override for %q=%q requires namespace or role_arn", r.TagKey, r.TagValue)
+	}
+	return nil
+}
+
+type roleOverride struct {
+	RoleOverrideConfig
+	output *CloudWatch
+}
+
+// newRoleOverrideOutput creates the CloudWatch output that publishes the matching metrics.
+// Everything except the namespace, the role, the rollup and the buffer is inherited from the parent output, which is
+// not connected yet. The overrides and the alarms are the parent's own.
+func (c *CloudWatch) newRoleOverrideOutput(r RoleOverrideConfig) *CloudWatch {
+	child := *c
+	child.RoleOverrides, child.roleOverrides = nil, nil
+	child.Alarms = nil
+	child.ExternalID = ""
+	child.BufferDir, child.BufferMaxSize = "", 0
+	if r.RoleARN != "" && r.RoleARN != c.RoleARN {
+		// the external ID is the one of the role of the parent output
+		child.RoleARN = r.RoleARN
+	} else {
+		child.ExternalID = c.ExternalID
+	}
+	if r.Namespace != "" {
+		child.Namespace = r.Namespace
+	}
+	if r.RollupDimensions != nil {
+		child.RollupDimensions = r.RollupDimensions
+	}
+	if c.BufferDir != "" {
+		// the datums are replayed by the output which buffered them, with its own namespace and role
+		child.BufferDir = filepath.Join(c.BufferDir, "role_override", bufferDirName.ReplaceAllString(r.TagKey+"="+r.TagValue, "_"))
+		child.BufferMaxSize = c.BufferMaxSize
+	}
+	return &child
+}
+
+func (c *CloudWatch) connectRoleOverrides() error {
+	for _, r := range c.RoleOverrides {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	for _, r := range c.RoleOverrides {
+		child := c.newRoleOverrideOutput(r)
+		if err := child.Connect(); err != nil {
+			c.closeRoleOverrides()
+			return err
+		}
+		log.Printf("I! cloudwatch: metrics with tag %v=%v will be published to namespace %v", r.TagKey, r.TagValue, child.Namespace)
+		c.roleOverrides = append(c.roleOverrides, &roleOverride{RoleOverrideConfig: r, output: child})
+	}
+	return nil
+}
+
+// overrideFor returns the first override matching the given tags, nil when none does.
+func (c *CloudWatch) overrideFor(tags map[string]string) *roleOverride {
+	for _, r := range c.roleOverrides {
+		if v, ok := tags[r.TagKey]; ok && v == r.TagValue {
+			return r
+		}
+	}
+	return nil
+}
+
+// outputFor returns the output that should publish the given tags, the first matching override wins.
+func (c *CloudWatch) outputFor(tags map[string]string) *CloudWatch {
+	if r := c.overrideFor(tags); r != nil {
+		return r.output
+	}
+	return c
+}
+
+// route returns the output that should publish the metric, removing the tag of its override when configured.
+func (c *CloudWatch) route(m telegraf.Metric) *CloudWatch {
+	r := c.overrideFor(m.Tags())
+	if r == nil {
+		return c
+	}
+	if r.RemoveTag {
+		m.RemoveTag(r.TagKey)
+	}
+	return r.output
+}
+
+func (c *CloudWatch) closeRoleOverrides() {
+	for _, r := range c.roleOverrides {
+		r.output.Close()
+	}
+	c.roleOverrides = nil
+}
@@ -0,0 +1,124 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleOverrideValidate(t *testing.T) {
+	assert.Error(t, (&RoleOverrideConfig{TagKey: "team", RoleARN: "arn"}).validate())
+	assert.Error(t, (&RoleOverrideConfig{TagKey: "team", TagValue: "app"}).validate())
+	assert.NoError(t, (&RoleOverrideConfig{TagKey: "team", TagValue: "app", Namespace: "App"}).validate())
+	assert.NoError(t, (&RoleOverrideConfig{TagKey: "team", TagValue: "app", RoleARN: "arn"}).validate())
+}
+
+func TestNewRoleOverrideOutput(t *testing.T) {
+	c := &CloudWatch{
+		Region:           "us-east-1",
+		RoleARN:          "arn:aws:iam::111111111111:role/Platform",
+		Namespace:        "CWAgent",
+		RollupDimensions: [][]string{{"host"}},
+		PublishJitter:    &internal.Duration{Duration: 5 * time.Second},
+	}
+
+	child := c.newRoleOverrideOutput(RoleOverrideConfig{TagKey: "team", TagValue: "app", RoleARN: "arn:aws:iam::222222222222:role/App"})
+	assert.Equal(t, "arn:aws:iam::222222222222:role/App", child.RoleARN)
+	assert.Equal(t, "CWAgent", child.Namespace)
+	assert.Equal(t, "us-east-1", child.Region)
+	assert.Equal(t, [][]string{{"host"}}, child.RollupDimensions)
+	assert.Nil(t, child.RoleOverrides)
+	assert.Equal(t, 5*time.Second, child.PublishJitter.Duration)
+
+	child = c.newRoleOverrideOutput(RoleOverrideConfig{TagKey: "team", TagValue: "app", Namespace: "App"})
+	assert.Equal(t, "arn:aws:iam::111111111111:role/Platform", child.RoleARN)
+	assert.Equal(t, "App", child.Namespace)
+
+	child = c.newRoleOverrideOutput(RoleOverrideConfig{TagKey: "team", TagValue: "app", Namespace: "App", RollupDimensions: [][]string{{}}})
+	assert.Equal(t, [][]string{{}}, child.RollupDimensions)
+}
+
+func TestNewRoleOverrideOutputInheritsSettings(t *testing.T) {
+	c := &CloudWatch{
+		Namespace:              "CWAgent",
+		LateDataPolicy:         lateDataPolicyDrop,
+		BufferDir:              "/buffer",
+		BufferCompression:      "zstd",
+		BufferCompressionLevel: 5,
+		Alarms:                 &AlarmsConfig{InstanceID: "i-0123456789abcdef0"},
+		RoleOverrides:          []RoleOverrideConfig{{TagKey: "team", TagValue: "app", Namespace: "App"}},
+	}
+
+	child := c.newRoleOverrideOutput(c.RoleOverrides[0])
+	assert.Equal(t, lateDataPolicyDrop, child.LateDataPolicy)
+	assert.Equal(t, "zstd", child.BufferCompression)
+	assert.Equal(t, 5, child.BufferCompressionLevel)
+	assert.Equal(t, "/buffer/role_override/team_app", child.BufferDir)
+	// the alarms and the overrides are the parent's own
+	assert.Nil(t, child.Alarms)
+	assert.Nil(t, child.RoleOverrides)
+}
+
+func TestConnectInvalidRoleOverride(t *testing.T) {
+	c := &CloudWatch{
+		Namespace:     "CWAgent",
+		RoleOverrides: []RoleOverrideConfig{{TagKey: "team", TagValue: "app", Namespace: "App"}, {TagKey: "team"}},
+	}
+	assert.Error(t, c.Connect())
+	// nothing of the output was started
+	assert.Nil(t, c.metricChan)
+	assert.Nil(t, c.publisher)
+	assert.Empty(t, c.roleOverrides)
+}
+
+func TestNewRoleOverrideOutputExternalID(t *testing.T) {
+	c := &CloudWatch{
+		Region:     "us-east-1",
+		RoleARN:    "arn:aws:iam::111111111111:role/Monitoring",
+		ExternalID: "o-source",
+		Namespace:  "CWAgent",
+	}
+
+	// the external ID is the one of the role of the output
+	child := c.newRoleOverrideOutput(RoleOverrideConfig{TagKey: "team", TagValue: "app", Namespace: "App"})
+	assert.Equal(t, "o-source", child.ExternalID)
+
+	child = c.newRoleOverrideOutput(RoleOverrideConfig{TagKey: "team", TagValue: "app", RoleARN: "arn:aws:iam::222222222222:role/App"})
+	assert.Equal(t, "", child.ExternalID)
+}
+
+func TestOutputFor(t *testing.T) {
+	app := &CloudWatch{Namespace: "App"}
+	db := &CloudWatch{Namespace: "DB"}
+	c := &CloudWatch{Namespace: "CWAgent"}
+	c.roleOverrides = []*roleOverride{
+		{RoleOverrideConfig: RoleOverrideConfig{TagKey: "team", TagValue: "app"}, output: app},
+		{RoleOverrideConfig: RoleOverrideConfig{TagKey: "service", TagValue: "db"}, output: db},
+	}
+
+	assert.Equal(t, app, c.outputFor(map[string]string{"team": "app", "service": "db"}))
+	assert.Equal(t, db, c.outputFor(map[string]string{"team": "infra", "service": "db"}))
+	assert.Equal(t, c, c.outputFor(map[string]string{"team": "infra"}))
+	assert.Equal(t, c, c.outputFor(map[string]string{}))
+}
+
+func TestRouteRemovesTag(t *testing.T) {
+	app := &CloudWatch{Namespace: "App"}
+	c := &CloudWatch{Namespace: "CWAgent"}
+	c.roleOverrides = []*roleOverride{
+		{RoleOverrideConfig: RoleOverrideConfig{TagKey: "aws:StatsDNamespace", TagValue: "App", RemoveTag: true}, output: app},
+	}
+
+	m, _ := metric.New("requests", map[string]string{"aws:StatsDNamespace": "App", "host": "h"}, map[string]interface{}{"value": 1}, time.Now())
+	assert.Equal(t, app, c.route(m))
+	assert.Equal(t, map[string]string{"host": "h"}, m.Tags())
+
+	m, _ = metric.New("requests", map[string]string{"host": "h"}, map[string]interface{}{"value": 1}, time.Now())
+	assert.Equal(t, c, c.route(m))
+}
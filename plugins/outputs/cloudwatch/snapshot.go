@@ -0,0 +1,127 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/influxdata/telegraf"
+)
+
+// SnapshotDatum is a datum the output would publish, as printed by the snapshot of the agent
+type SnapshotDatum struct {
+	Namespace  string            `json:"namespace"`
+	MetricName string            `json:"metric_name"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	Value      *float64          `json:"value,omitempty"`
+	// Statistics are the ones of the values of the distributions, e.g. of the timings of statsd
+	Statistics        *SnapshotStatistics `json:"statistics,omitempty"`
+	Unit              string              `json:"unit,omitempty"`
+	StorageResolution int64               `json:"storage_resolution,omitempty"`
+	Timestamp         time.Time           `json:"timestamp"`
+}
+
+type SnapshotStatistics struct {
+	Maximum     float64 `json:"maximum"`
+	Minimum     float64 `json:"minimum"`
+	SampleCount float64 `json:"sample_count"`
+	Sum         float64 `json:"sum"`
+}
+
+// Snapshot returns the datums the output would publish for the metrics, without connecting to CloudWatch: the metrics
+// are routed to the role overrides and their datums are built with the decorations and the rollup of their output.
+// The metrics are not aggregated over their aggregation interval, a datum is returned for every collected value. The
+// datums are sorted by namespace, metric name and dimensions.
+//
+// It is only called on an output which is not connected.
+func (c *CloudWatch) Snapshot(metrics []telegraf.Metric) ([]SnapshotDatum, error) {
+	if err := c.prepareSnapshot(); err != nil {
+		return nil, err
+	}
+	c.roleOverrides = nil
+	for _, r := range c.RoleOverrides {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		child := c.newRoleOverrideOutput(r)
+		if err := child.prepareSnapshot(); err != nil {
+			return nil, err
+		}
+		c.roleOverrides = append(c.roleOverrides, &roleOverride{RoleOverrideConfig: r, output: child})
+	}
+
+	var datums []SnapshotDatum
+	for _, m := range metrics {
+		m = m.Copy()
+		output := c.route(m)
+		// the aggregation sets the storage resolution of the short aggregation intervals
+		if interval, ok := m.GetTag(aggregationIntervalTagKey); ok {
+			m.RemoveTag(aggregationIntervalTagKey)
+			if d, err := time.ParseDuration(interval); err == nil && d.Truncate(time.Second) < time.Minute {
+				m.AddTag(highResolutionTagKey, "true")
+			}
+		}
+		for _, datum := range output.BuildMetricDatum(m) {
+			datums = append(datums, newSnapshotDatum(output.Namespace, datum))
+		}
+	}
+	sort.SliceStable(datums, func(i, j int) bool {
+		if datums[i].Namespace != datums[j].Namespace {
+			return datums[i].Namespace < datums[j].Namespace
+		}
+		if datums[i].MetricName != datums[j].MetricName {
+			return datums[i].MetricName < datums[j].MetricName
+		}
+		return dimensionsKey(datums[i].Dimensions) < dimensionsKey(datums[j].Dimensions)
+	})
+	return datums, nil
+}
+
+// prepareSnapshot sets up what Connect does for building the datums
+func (c *CloudWatch) prepareSnapshot() (err error) {
+	if c.metricDecorations, err = NewMetricDecorations(c.MetricConfigs); err != nil {
+		return err
+	}
+	c.RollupDimensions = GetUniqueRollupList(c.RollupDimensions)
+	return nil
+}
+
+func newSnapshotDatum(namespace string, datum *cloudwatch.MetricDatum) SnapshotDatum {
+	d := SnapshotDatum{
+		Namespace:  namespace,
+		MetricName: *datum.MetricName,
+		Value:      datum.Value,
+	}
+	if datum.Unit != nil {
+		d.Unit = *datum.Unit
+	}
+	if datum.StorageResolution != nil {
+		d.StorageResolution = *datum.StorageResolution
+	}
+	if datum.Timestamp != nil {
+		d.Timestamp = *datum.Timestamp
+	}
+	if s := datum.StatisticValues; s != nil {
+		d.Statistics = &SnapshotStatistics{Maximum: *s.Maximum, Minimum: *s.Minimum, SampleCount: *s.SampleCount, Sum: *s.Sum}
+	}
+	if len(datum.Dimensions) > 0 {
+		d.Dimensions = make(map[string]string, len(datum.Dimensions))
+		for _, dimension := range datum.Dimensions {
+			d.Dimensions[*dimension.Name] = *dimension.Value
+		}
+	}
+	return d
+}
+
+func dimensionsKey(dimensions map[string]string) string {
+	pairs := make([]string, 0, len(dimensions))
+	for k, v := range dimensions {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	c := &CloudWatch{
+		Namespace:        "CWAgent",
+		RollupDimensions: [][]string{{"host"}, {"host"}},
+		MetricConfigs:    []MetricDecorationConfig{{Category: "mem", Metric: "used_percent", Rename: "MemoryUsed", Unit: "Percent"}},
+		RoleOverrides:    []RoleOverrideConfig{{TagKey: "team", TagValue: "app", Namespace: "App", RemoveTag: true}},
+	}
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("mem", map[string]string{"host": "web-1"},
+			map[string]interface{}{"used_percent": 42.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "web-1", "team": "app", aggregationIntervalTagKey: "10s"},
+			map[string]interface{}{"usage_idle": 90}, time.Unix(0, 0)),
+	}
+
+	datums, err := c.Snapshot(metrics)
+	require.NoError(t, err)
+	assert.Equal(t, []SnapshotDatum{
+		{Namespace: "App", MetricName: "cpu_usage_idle", Dimensions: map[string]string{"host": "web-1"},
+			Value: aws.Float64(90), Unit: "Percent", StorageResolution: 1, Timestamp: time.Unix(0, 0)},
+		{Namespace: "CWAgent", MetricName: "MemoryUsed", Dimensions: map[string]string{"host": "web-1"},
+			Value: aws.Float64(42), Unit: "Percent", Timestamp: time.Unix(0, 0)},
+	}, datums)
+	// the metrics of the snapshot are copied
+	assert.True(t, metrics[1].HasTag("team"))
+}
+
+func TestSnapshotInvalidRoleOverride(t *testing.T) {
+	c := &CloudWatch{Namespace: "CWAgent", RoleOverrides: []RoleOverrideConfig{{TagKey: "team"}}}
+	_, err := c.Snapshot(nil)
+	assert.Error(t, err)
+}
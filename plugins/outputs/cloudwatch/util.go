@@ -47,6 +47,9 @@ const (
 )
 
 func publishJitter(publishInterval time.Duration) (publishJitter time.Duration) {
+	if publishInterval < time.Second {
+		return 0
+	}
 	r := rand.New(rand.NewSource(time.Now().Unix()))
 	jitter := r.Int63n(int64(publishInterval.Seconds()))
 	publishJitter = time.Duration(jitter) * time.Second
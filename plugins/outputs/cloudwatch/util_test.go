@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/regular"
 	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/seh1"
@@ -24,6 +25,59 @@ func TestPublishJitter(t *testing.T) {
 	assert.True(t, publishJitter < time.Minute)
 }
 
+func TestMaxPublishJitter(t *testing.T) {
+	c := &CloudWatch{ForceFlushInterval: internal.Duration{Duration: time.Minute}}
+	assert.Equal(t, time.Minute, c.maxPublishJitter())
+	c.PublishJitter = &internal.Duration{Duration: 5 * time.Second}
+	assert.Equal(t, 5*time.Second, c.maxPublishJitter())
+	assert.True(t, publishJitter(c.maxPublishJitter()) < 5*time.Second)
+	// the publishes are not delayed with a jitter below a second, and at most the flush interval
+	c.PublishJitter = &internal.Duration{}
+	assert.Equal(t, time.Duration(0), c.maxPublishJitter())
+	assert.Equal(t, time.Duration(0), publishJitter(c.maxPublishJitter()))
+	c.PublishJitter = &internal.Duration{Duration: 500 * time.Millisecond}
+	assert.Equal(t, time.Duration(0), c.maxPublishJitter())
+	c.PublishJitter = &internal.Duration{Duration: 2 * time.Minute}
+	assert.Equal(t, time.Minute, c.maxPublishJitter())
+}
+
+func TestPublishDelay(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 45, 500000000, time.UTC)
+	// the publishes start at the next multiple of the flush interval plus the jitter, never in the past
+	assert.Equal(t, 14500*time.Millisecond, publishDelay(now, time.Minute, 0))
+	assert.Equal(t, 24500*time.Millisecond, publishDelay(now, time.Minute, 10*time.Second))
+	assert.Equal(t, 74500*time.Millisecond, publishDelay(now, time.Minute, time.Minute))
+	assert.Equal(t, time.Minute, publishDelay(now.Truncate(time.Minute), time.Minute, 0))
+	assert.Equal(t, time.Duration(0), publishDelay(now, 0, 0))
+}
+
+func TestPublishStart(t *testing.T) {
+	fakeNow := time.Date(2020, 10, 1, 12, 0, 45, 0, time.UTC)
+	var delays []time.Duration
+	c := &CloudWatch{
+		ForceFlushInterval: internal.Duration{Duration: time.Minute},
+		PublishJitter:      &internal.Duration{Duration: 10 * time.Second},
+		shutdownChan:       make(chan struct{}),
+		now:                func() time.Time { return fakeNow },
+		after: func(d time.Duration) <-chan time.Time {
+			delays = append(delays, d)
+			ch := make(chan time.Time, 1)
+			ch <- fakeNow.Add(d)
+			return ch
+		},
+	}
+	published := make(chan struct{})
+	go func() {
+		c.publish()
+		close(published)
+	}()
+	close(c.shutdownChan)
+	<-published
+	assert.Len(t, delays, 1)
+	assert.True(t, delays[0] >= 15*time.Second, delays[0])
+	assert.True(t, delays[0] < 25*time.Second, delays[0])
+}
+
 func TestSetNewDistributionFunc(t *testing.T) {
 	setNewDistributionFunc(maxValuesPerDatum)
 	_, ok := distribution.NewDistribution().(*seh1.SEH1Distribution)
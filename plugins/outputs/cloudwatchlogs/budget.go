@@ -0,0 +1,125 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+)
+
+const (
+	bulkPolicyDefer  = "defer"
+	bulkPolicySample = "sample"
+	// bulkSampleRate is the 1 in n bulk log events kept under pressure with the sample policy
+	bulkSampleRate = 10
+	// criticalPressureFlushInterval is how often the critical log events are sent under pressure, rather than
+	// every flush interval
+	criticalPressureFlushInterval = time.Second
+)
+
+// memoryBudget bounds the size of the log events buffered by all the dests which are not sent yet. When the budget
+// is used up the log events are admitted by the priority class of their source: the critical ones always, the normal
+// ones once there is room and the bulk ones once half of the budget is free, or 1 in bulkSampleRate of them with the
+// sample policy.
+type memoryBudget struct {
+	limit      int64
+	bulkPolicy string
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	used        int64
+	closed      bool
+	bulkSampled int64
+}
+
+func newMemoryBudget(limit int64, bulkPolicy string) *memoryBudget {
+	b := &memoryBudget{limit: limit, bulkPolicy: bulkPolicy}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// budgetedEvent is a log event holding its size of the budget until the pusher sends or drops it
+type budgetedEvent struct {
+	logs.LogEvent
+	size int64
+}
+
+// admit waits for room for the log event in the budget and returns it as a budgetedEvent, it returns nil when
+// the event is sampled out, after calling its Done.
+func (b *memoryBudget) admit(e logs.LogEvent, priority string) logs.LogEvent {
+	size := int64(eventSize(e.Message()))
+	b.mu.Lock()
+	// the limit the event waits for, none for the critical events and the sampled bulk ones
+	limit := b.limit
+	switch priority {
+	case logs.PriorityCritical:
+		limit = -1
+	case logs.PriorityBulk:
+		if b.bulkPolicy != bulkPolicySample {
+			limit = b.limit / 2
+			break
+		}
+		limit = -1
+		if !b.closed && b.pressured(size, b.limit/2) {
+			b.bulkSampled++
+			if b.bulkSampled%bulkSampleRate != 0 {
+				b.mu.Unlock()
+				e.Done()
+				profiler.Profiler.AddStats([]string{"cloudwatchlogs", "bulkSampledOut"}, 1)
+				return nil
+			}
+		}
+	}
+	for limit >= 0 && !b.closed && b.pressured(size, limit) {
+		b.cond.Wait()
+	}
+	b.used += size
+	b.mu.Unlock()
+	return &budgetedEvent{LogEvent: e, size: size}
+}
+
+// pressured returns whether the log event would exceed the limit, an event always fits in an empty budget
+func (b *memoryBudget) pressured(size, limit int64) bool {
+	return b.used > 0 && b.used+size > limit
+}
+
+// underPressure returns whether the budget is used up, the critical log events are then sent first
+func (b *memoryBudget) underPressure() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used >= b.limit
+}
+
+// release frees the size of the log events sent or dropped by a pusher
+func (b *memoryBudget) release(size int64) {
+	if b == nil || size == 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= size
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// close admits all the log events from now on, so that no source waits for a pusher which is stopped
+func (b *memoryBudget) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// eventSize is the size of the log event in a request, once truncated
+func eventSize(message string) int {
+	if len(message) > msgSizeLimit {
+		return msgSizeLimit + eventHeaderSize
+	}
+	return len(message) + eventHeaderSize
+}
@@ -0,0 +1,87 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBudgetCriticalNotBlocked(t *testing.T) {
+	b := newMemoryBudget(100, bulkPolicyDefer)
+	msg := strings.Repeat("x", 100)
+	require.NotNil(t, b.admit(evtMock{m: msg}, logs.PriorityNormal))
+	assert.True(t, b.underPressure())
+
+	admitted := make(chan struct{})
+	go func() {
+		b.admit(evtMock{m: msg}, logs.PriorityCritical)
+		close(admitted)
+	}()
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("critical log event is blocked by the budget")
+	}
+}
+
+func TestMemoryBudgetNormalWaitsForRelease(t *testing.T) {
+	b := newMemoryBudget(100, bulkPolicyDefer)
+	msg := strings.Repeat("x", 50)
+	first := b.admit(evtMock{m: msg}, logs.PriorityNormal).(*budgetedEvent)
+
+	admitted := make(chan struct{})
+	go func() {
+		b.admit(evtMock{m: msg}, logs.PriorityNormal)
+		close(admitted)
+	}()
+	select {
+	case <-admitted:
+		t.Fatal("normal log event is admitted over the budget")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	b.release(first.size)
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("normal log event is not admitted once the budget is released")
+	}
+}
+
+func TestMemoryBudgetBulkSample(t *testing.T) {
+	b := newMemoryBudget(100, bulkPolicySample)
+	require.NotNil(t, b.admit(evtMock{m: strings.Repeat("x", 50)}, logs.PriorityNormal))
+
+	kept, done := 0, 0
+	for i := 0; i < 2*bulkSampleRate; i++ {
+		if b.admit(evtMock{m: "bulk", d: func() { done++ }}, logs.PriorityBulk) != nil {
+			kept++
+		}
+	}
+	assert.Equal(t, 2, kept)
+	assert.Equal(t, 2*bulkSampleRate-2, done)
+}
+
+func TestMemoryBudgetClose(t *testing.T) {
+	b := newMemoryBudget(100, bulkPolicyDefer)
+	require.NotNil(t, b.admit(evtMock{m: strings.Repeat("x", 100)}, logs.PriorityNormal))
+
+	admitted := make(chan struct{})
+	go func() {
+		b.admit(evtMock{m: "bulk"}, logs.PriorityBulk)
+		close(admitted)
+	}()
+	b.close()
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("bulk log event is blocked once the budget is closed")
+	}
+}
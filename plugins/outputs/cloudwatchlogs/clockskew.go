@@ -0,0 +1,134 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const (
+	// clockSkewSamples is the number of the latest responses the skew of the clock is estimated from
+	clockSkewSamples = 5
+	// clockSkewThreshold is the skew below which the clock is taken as right, the Date header of the responses has a
+	// resolution of a second and the latency of the requests is only halved
+	clockSkewThreshold = 30 * time.Second
+)
+
+// clockSkew estimates how far the clock of the host is behind the one of CloudWatch Logs from the Date header of
+// the responses of a log stream. The skew is persistent once all the latest samples are beyond the threshold on the
+// same side, a single late or early response does not count. CloudWatch Logs rejects the log events timestamped
+// more than 2 hours ahead of its clock, so the hosts whose clock is ahead silently lose their log events.
+type clockSkew struct {
+	name  string
+	stats *publishstats.Stats
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	skew    time.Duration
+}
+
+func newClockSkew(name string, stats *publishstats.Stats) *clockSkew {
+	return &clockSkew{name: name, stats: stats}
+}
+
+// observe records the skew of the response received with the server time, for a request sent at sent
+func (c *clockSkew) observe(serverTime, sent, received time.Time) {
+	if c == nil || serverTime.IsZero() || sent.IsZero() || received.Before(sent) {
+		return
+	}
+	// the Date header is truncated to the second, the server time is the middle of its second
+	local := sent.Add(received.Sub(sent) / 2)
+	sample := serverTime.Add(500 * time.Millisecond).Sub(local)
+
+	c.mu.Lock()
+	if len(c.samples) < clockSkewSamples {
+		c.samples = append(c.samples, sample)
+	} else {
+		c.samples[c.next] = sample
+		c.next = (c.next + 1) % clockSkewSamples
+	}
+	skew := c.estimate()
+	changed := skew != 0 && c.skew == 0 || skew == 0 && c.skew != 0
+	c.skew = skew
+	c.mu.Unlock()
+
+	switch {
+	case !changed:
+	case skew > 0:
+		log.Printf("W! [outputs.cloudwatchlogs] The clock of the host is %v behind CloudWatch Logs for %v", skew, c.name)
+	case skew < 0:
+		log.Printf("W! [outputs.cloudwatchlogs] The clock of the host is %v ahead of CloudWatch Logs for %v, the log events timestamped more than 2h ahead of CloudWatch Logs are rejected", -skew, c.name)
+	default:
+		log.Printf("I! [outputs.cloudwatchlogs] The clock of the host agrees with CloudWatch Logs again for %v", c.name)
+	}
+	c.stats.ClockSkew(skew)
+}
+
+// estimate returns the median of the samples once they are all beyond the threshold on the same side, 0 otherwise
+func (c *clockSkew) estimate() time.Duration {
+	if len(c.samples) < clockSkewSamples {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), c.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if !(sorted[0] > clockSkewThreshold || sorted[len(sorted)-1] < -clockSkewThreshold) {
+		return 0
+	}
+	return sorted[len(sorted)/2].Round(time.Second)
+}
+
+// Skew returns the persistent skew of the clock, how far it is behind CloudWatch Logs and negative when it is ahead,
+// or 0
+func (c *clockSkew) Skew() time.Duration {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.skew
+}
+
+// serverTime returns the time of the Date header of the response, the zero time without one
+func serverTime(header http.Header) time.Time {
+	t, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// addHandlers observes the responses of every attempt of the calls of the SDK v1 client
+func (c *clockSkew) addHandlers(h *request.Handlers) {
+	h.Send.PushBackNamed(request.NamedHandler{
+		Name: "ClockSkewHandler",
+		Fn: func(r *request.Request) {
+			if r.HTTPResponse != nil {
+				c.observe(serverTime(r.HTTPResponse.Header), r.AttemptTime, time.Now())
+			}
+		},
+	})
+}
+
+// addMiddleware observes the responses of every attempt of the calls of the SDK v2 client
+func (c *clockSkew) addMiddleware(stack *middleware.Stack) error {
+	return stack.Deserialize.Add(middleware.DeserializeMiddlewareFunc("ClockSkew", func(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+		sent := time.Now()
+		out, metadata, err := next.HandleDeserialize(ctx, in)
+		if resp, ok := out.RawResponse.(*smithyhttp.Response); ok {
+			c.observe(serverTime(resp.Header), sent, time.Now())
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
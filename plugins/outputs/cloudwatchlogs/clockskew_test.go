@@ -0,0 +1,97 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSkew(t *testing.T) {
+	stats := publishstats.Get("logs.SkewG/S")
+	c := newClockSkew("SkewG/S", stats)
+	sent := time.Now()
+	received := sent.Add(200 * time.Millisecond)
+	// the server time of the Date header is truncated to the second
+	ahead := sent.Add(-3 * time.Hour).Truncate(time.Second)
+
+	for i := 0; i < clockSkewSamples-1; i++ {
+		c.observe(ahead, sent, received)
+	}
+	assert.Equal(t, time.Duration(0), c.Skew(), "not persistent yet")
+	c.observe(ahead, sent, received)
+	assert.InDelta(t, float64(-3*time.Hour), float64(c.Skew()), float64(2*time.Second))
+	assert.NotEmpty(t, stats.Summary().ClockSkew)
+
+	// a single response within the threshold ends the skew
+	c.observe(sent.Truncate(time.Second), sent, received)
+	assert.Equal(t, time.Duration(0), c.Skew())
+	assert.Empty(t, stats.Summary().ClockSkew)
+
+	// the skews within the threshold are ignored
+	c = newClockSkew("SkewG/S", nil)
+	for i := 0; i < clockSkewSamples; i++ {
+		c.observe(sent.Add(10*time.Second), sent, received)
+	}
+	assert.Equal(t, time.Duration(0), c.Skew())
+
+	var missing *clockSkew
+	missing.observe(ahead, sent, received)
+	assert.Equal(t, time.Duration(0), missing.Skew())
+}
+
+func TestClockSkewHandlers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`{"nextSequenceToken": "2"}`))
+	}))
+	defer server.Close()
+
+	c := newClockSkew("G/S", nil)
+	client := cloudwatchlogs.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	})
+	c.addHandlers(&client.Handlers)
+	v2 := newSDKV2Service(Target{Group: "G", Stream: "S"}, "us-east-1", &aws.Config{Endpoint: aws.String(server.URL)},
+		credentials.NewStaticCredentials("AKID", "SECRET", ""), "agent/1.0", nil, nil, c)
+
+	for _, service := range []CloudWatchLogsService{client, v2, client, v2, client} {
+		_, err := service.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S")})
+		require.NoError(t, err)
+	}
+	assert.InDelta(t, float64(time.Hour), float64(c.Skew()), float64(2*time.Second))
+}
+
+func TestPusherCompensatesClockSkew(t *testing.T) {
+	var timestamps []int64
+	s := &svcMock{ple: func(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+		for _, e := range in.LogEvents {
+			timestamps = append(timestamps, *e.Timestamp)
+		}
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}}
+	p := NewPusher(Target{"G", "S"}, s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p.clockSkew = newClockSkew("G/S", nil)
+	p.clockSkew.skew = -time.Hour
+
+	now := time.Now()
+	p.AddEvent(evtMock{m: "msg", t: now})
+	p.Stop()
+	<-p.Stopped()
+	require.Len(t, timestamps, 1)
+	assert.Equal(t, now.Add(-time.Hour).UnixNano()/int64(time.Millisecond), timestamps[0])
+}
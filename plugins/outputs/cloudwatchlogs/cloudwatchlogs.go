@@ -6,7 +6,6 @@ package cloudwatchlogs
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -15,8 +14,12 @@ import (
 	configaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
 	"github.com/aws/amazon-cloudwatch-agent/handlers"
 	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/lifecycle"
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
 	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/influxdata/telegraf"
@@ -41,14 +44,24 @@ const (
 )
 
 type CloudWatchLogs struct {
-	Region           string `toml:"region"`
-	EndpointOverride string `toml:"endpoint_override"`
-	AccessKey        string `toml:"access_key"`
-	SecretKey        string `toml:"secret_key"`
-	RoleARN          string `toml:"role_arn"`
-	Profile          string `toml:"profile"`
-	Filename         string `toml:"shared_credential_file"`
-	Token            string `toml:"token"`
+	Region               string `toml:"region"`
+	EndpointOverride     string `toml:"endpoint_override"`
+	UseFipsEndpoint      bool   `toml:"use_fips_endpoint"`
+	UseDualStackEndpoint bool   `toml:"use_dualstack_endpoint"`
+	// The signature of the requests, sigv4 by default or sigv4a for the endpoints which require it, e.g. the
+	// multi-region ones. The SigV4A signatures are valid in the regions of signing_region_set, all of them by default.
+	SigningAlgorithm string   `toml:"signing_algorithm"`
+	SigningRegionSet []string `toml:"signing_region_set"`
+	// The AWS SDK of the client sending the log events, v1 by default or v2 for its adaptive retries and endpoint
+	// resolution. The payload sampling and the circuit breaker are not available with v2, nor is SigV4A.
+	SDKVersion string `toml:"sdk_version"`
+	AccessKey  string `toml:"access_key"`
+	SecretKey  string `toml:"secret_key"`
+	RoleARN    string `toml:"role_arn"`
+	ExternalID string `toml:"external_id"`
+	Profile    string `toml:"profile"`
+	Filename   string `toml:"shared_credential_file"`
+	Token      string `toml:"token"`
 
 	//log group and stream names
 	LogStreamName string `toml:"log_stream_name"`
@@ -56,22 +69,109 @@ type CloudWatchLogs struct {
 
 	ForceFlushInterval internal.Duration `toml:"force_flush_interval"` // unit is second
 
+	// The size of the log events buffered by all the log streams, the critical log sources are published first
+	// and the bulk ones are deferred or sampled once it is used up. Unbounded when 0.
+	MemoryBudgetMB int `toml:"memory_budget_mb"`
+	// What happens to the bulk log events under memory pressure, defer or sample
+	BulkUnderPressure string `toml:"bulk_under_pressure"`
+	// Keep the timestamps of every log stream increasing, the log events whose timestamp is not after the one
+	// before them in the stream are moved 1ms after it
+	MonotonicTimestamps bool `toml:"monotonic_timestamps"`
+	// Send the buffered log events and a final one telling the instance is terminating once its spot interruption
+	// or scale-in notice is received, and every second after
+	FlushOnTerminationNotice bool `toml:"flush_on_termination_notice"`
+	// Hold the log events of the streams written by more than one source for this long, to send them in the order
+	// of their timestamps across the sources. Not reordered when 0.
+	ReorderWindow internal.Duration `toml:"reorder_window"`
+	// Move the timestamps of the log events by the skew of the clock of the host once it is persistent, estimated
+	// from the responses of CloudWatch Logs for every log stream. The skew is reported in the status either way.
+	ClockSkewCompensation bool `toml:"clock_skew_compensation"`
+	// What happens to the log events whose message is not valid UTF-8, which CloudWatch Logs rejects: replace
+	// the invalid bytes with U+FFFD, drop them or base64 encode the whole message. replace by default.
+	InvalidUTF8 string `toml:"invalid_utf8"`
+	// The calls per second to CloudWatch Logs of all the log streams, including the retries, unlimited when 0. The
+	// streams take turns, and the rate is lowered for a while when the calls are throttled.
+	MaxAPITPS float64 `toml:"max_api_tps"`
+	// The calls made at once before max_api_tps applies, max_api_tps by default
+	APIBurst int `toml:"api_burst"`
+
 	Log telegraf.Logger `toml:"-"`
 
-	cwDests map[Target]*cwDest
+	cwDests   map[Target]*cwDest
+	budget    *memoryBudget
+	limiter   *apiLimiter
+	endpoint  *aws.Config
+	resources *resourceCache
 }
 
 func (c *CloudWatchLogs) Connect() error {
-	return nil
+	if c.MemoryBudgetMB > 0 {
+		switch c.BulkUnderPressure {
+		case "", bulkPolicyDefer, bulkPolicySample:
+		default:
+			return fmt.Errorf("invalid bulk_under_pressure %q, expecting %v or %v", c.BulkUnderPressure, bulkPolicyDefer, bulkPolicySample)
+		}
+		c.budget = newMemoryBudget(int64(c.MemoryBudgetMB)*1024*1024, c.BulkUnderPressure)
+	}
+	switch c.InvalidUTF8 {
+	case "", invalidUTF8Replace, invalidUTF8Drop, invalidUTF8Base64:
+	default:
+		return fmt.Errorf("invalid invalid_utf8 %q, expecting %v, %v or %v", c.InvalidUTF8, invalidUTF8Replace, invalidUTF8Drop, invalidUTF8Base64)
+	}
+	switch c.SigningAlgorithm {
+	case "", handlers.SigningAlgorithmV4, handlers.SigningAlgorithmV4A:
+	default:
+		return fmt.Errorf("invalid signing_algorithm %q, expecting %v or %v", c.SigningAlgorithm, handlers.SigningAlgorithmV4, handlers.SigningAlgorithmV4A)
+	}
+	switch c.SDKVersion {
+	case "", sdkVersionV1:
+	case sdkVersionV2:
+		if c.SigningAlgorithm == handlers.SigningAlgorithmV4A {
+			return fmt.Errorf("signing_algorithm %v is not supported with sdk_version %v", c.SigningAlgorithm, sdkVersionV2)
+		}
+	default:
+		return fmt.Errorf("invalid sdk_version %q, expecting %v or %v", c.SDKVersion, sdkVersionV1, sdkVersionV2)
+	}
+	if c.MaxAPITPS < 0 {
+		return fmt.Errorf("invalid max_api_tps %v, expecting a positive rate or 0 for unlimited", c.MaxAPITPS)
+	}
+	if c.MaxAPITPS > 0 {
+		c.limiter = newAPILimiter(c.MaxAPITPS, c.APIBurst)
+	}
+	// without an override the endpoint and the signing region are resolved from the partition of the region
+	var err error
+	c.endpoint, err = configaws.EndpointConfig(cloudwatchlogs.EndpointsID, c.Region, c.EndpointOverride,
+		configaws.EndpointOptions{FIPS: c.UseFipsEndpoint, DualStack: c.UseDualStackEndpoint})
+	return err
 }
 
 func (c *CloudWatchLogs) Close() error {
+	if c.budget != nil {
+		c.budget.close()
+	}
+	if c.limiter != nil {
+		c.limiter.stop()
+	}
 	for _, d := range c.cwDests {
 		d.Stop()
 	}
 	return nil
 }
 
+// WaitClosed waits up to the timeout for the log streams to send the log events queued before the output was closed,
+// it returns false when some of them are still sending, e.g. retrying their last batch
+func (c *CloudWatchLogs) WaitClosed(timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for _, d := range c.cwDests {
+		select {
+		case <-d.Stopped():
+		case <-deadline:
+			return false
+		}
+	}
+	return true
+}
+
 func (c *CloudWatchLogs) Write(metrics []telegraf.Metric) error {
 	for _, m := range metrics {
 		c.writeMetricAsStructuredLog(m)
@@ -91,7 +191,9 @@ func (c *CloudWatchLogs) CreateDest(group, stream string) logs.LogDest {
 		Group:  group,
 		Stream: stream,
 	}
-	return c.getDest(t)
+	cwd := c.getDest(t)
+	cwd.AddSource()
+	return cwd
 }
 
 func (c *CloudWatchLogs) getDest(t Target) *cwDest {
@@ -100,31 +202,77 @@ func (c *CloudWatchLogs) getDest(t Target) *cwDest {
 	}
 
 	credentialConfig := &configaws.CredentialConfig{
-		Region:    c.Region,
-		AccessKey: c.AccessKey,
-		SecretKey: c.SecretKey,
-		RoleARN:   c.RoleARN,
-		Profile:   c.Profile,
-		Filename:  c.Filename,
-		Token:     c.Token,
-	}
-
-	client := cloudwatchlogs.New(
-		credentialConfig.Credentials(),
-		&aws.Config{
-			Endpoint:   aws.String(c.EndpointOverride),
-			HTTPClient: &http.Client{Timeout: 1 * time.Minute},
-		},
-	)
-	client.Handlers.Build.PushBackNamed(handlers.NewRequestCompressionHandler([]string{"PutLogEvents"}))
-	client.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("User-Agent", agentinfo.UserAgent()))
+		Region:     c.Region,
+		AccessKey:  c.AccessKey,
+		SecretKey:  c.SecretKey,
+		RoleARN:    c.RoleARN,
+		Profile:    c.Profile,
+		Filename:   c.Filename,
+		Token:      c.Token,
+		ExternalID: c.ExternalID,
+	}
+
+	config := c.endpoint.Copy()
+	config.HTTPClient = configaws.HTTPClient(1 * time.Minute)
+	provider := credentialConfig.Credentials()
+	stats := publishstats.Get(publishStatsName(t))
+	skew := newClockSkew(t.Group+"/"+t.Stream, stats)
+	// the calls of the dest wait for the rate limiter by its priority class, set once the sources are piped to it
+	var cwd *cwDest
+	priority := func() string { return cwd.Priority() }
+	var service CloudWatchLogsService
+	if c.SDKVersion == sdkVersionV2 {
+		creds := provider.ClientConfig(cloudwatchlogs.EndpointsID).Config.Credentials
+		service = newSDKV2Service(t, c.Region, config, creds, agentinfo.UserAgent(), c.limiter, priority, skew)
+	} else {
+		client := c.newSDKV1Service(provider, config, priority)
+		skew.addHandlers(&client.Handlers)
+		service = client
+	}
 
-	pusher := NewPusher(t, client, c.ForceFlushInterval.Duration, maxRetryTimeout, c.Log)
-	cwd := &cwDest{pusher: pusher}
+	pusher := NewPusher(t, service, c.ForceFlushInterval.Duration, maxRetryTimeout, c.Log)
+	pusher.budget = c.budget
+	pusher.resources = c.resources
+	pusher.stats = stats
+	pusher.stats.SetTags(map[string]string{"log_group_name": t.Group, "log_stream_name": t.Stream})
+	if c.ClockSkewCompensation {
+		pusher.clockSkew = skew
+	}
+	if c.ReorderWindow.Duration > 0 {
+		pusher.reorder = newReorderBuffer(c.ReorderWindow.Duration)
+	}
+	pusher.monotonic = c.MonotonicTimestamps
+	pusher.invalidUTF8 = c.InvalidUTF8
+	if c.FlushOnTerminationNotice {
+		pusher.terminating = lifecycle.Terminating()
+	}
+	cwd = &cwDest{pusher: pusher}
 	c.cwDests[t] = cwd
 	return cwd
 }
 
+// newSDKV1Service returns the aws-sdk-go client of the config, with the handlers of the agent
+func (c *CloudWatchLogs) newSDKV1Service(provider client.ConfigProvider, config *aws.Config, priority func() string) *cloudwatchlogs.CloudWatchLogs {
+	client := cloudwatchlogs.New(provider, config)
+	if c.SigningAlgorithm == handlers.SigningAlgorithmV4A {
+		regionSet := c.SigningRegionSet
+		if len(regionSet) == 0 {
+			regionSet = []string{"*"}
+		}
+		handlers.UseSigV4A(&client.Handlers, regionSet)
+	}
+	// the size of the payloads is measured before they are compressed
+	addPublishStatsHandlers(&client.Handlers)
+	client.Handlers.Build.PushBackNamed(handlers.NewRequestCompressionHandler([]string{"PutLogEvents"}))
+	client.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("User-Agent", agentinfo.UserAgent()))
+	handlers.AddPayloadSamplingHandlers(&client.Handlers, []string{"PutLogEvents"})
+	handlers.AddCircuitBreakerHandlers(&client.Handlers)
+	if c.limiter != nil {
+		c.limiter.addHandlers(&client.Handlers, priority)
+	}
+	return client
+}
+
 func (c *CloudWatchLogs) writeMetricAsStructuredLog(m telegraf.Metric) {
 	t, err := c.getTargetFromMetric(m)
 	if err != nil {
@@ -219,6 +367,12 @@ func (c *CloudWatchLogs) getLogEventFromMetric(metric telegraf.Metric) *structur
 				value = t
 			case time.Time:
 				value = float64(t.Unix())
+			case distribution.Distribution:
+				if t.Size() == 0 {
+					// the distribution does not have a value
+					continue
+				}
+				value = emfDistribution(t)
 
 			default:
 				c.Log.Errorf("Detected unexpected fields (%s,%v) when encoding structured log event, value type %T is not supported", k, v, v)
@@ -240,6 +394,26 @@ func (c *CloudWatchLogs) getLogEventFromMetric(metric telegraf.Metric) *structur
 	}
 }
 
+// maxEMFDistributionValues is the most values of a metric of an EMF document
+const maxEMFDistributionValues = 100
+
+// emfDistribution returns the EMF value of a distribution, its values and counts with its statistics, or only its
+// statistics when it has more values than an EMF metric allows
+func emfDistribution(dist distribution.Distribution) map[string]interface{} {
+	value := map[string]interface{}{
+		"Max":   dist.Maximum(),
+		"Min":   dist.Minimum(),
+		"Count": dist.SampleCount(),
+		"Sum":   dist.Sum(),
+	}
+	if dist.Size() <= maxEMFDistributionValues {
+		values, counts := dist.ValuesAndCounts()
+		value["Values"] = values
+		value["Counts"] = counts
+	}
+	return value
+}
+
 type structuredLogEvent struct {
 	msg string
 	t   time.Time
@@ -260,6 +434,8 @@ type cwDest struct {
 	sync.Mutex
 	isEMF   bool
 	stopped bool
+	// set once a message which is not valid UTF-8 was reported, it is only reported once
+	invalidUTF8Reported bool
 }
 
 func (cd *cwDest) Publish(events []logs.LogEvent) error {
@@ -270,6 +446,16 @@ func (cd *cwDest) Publish(events []logs.LogEvent) error {
 				cd.switchToEMF()
 			}
 		}
+		// before the budget, which holds the log events by the size they are sent with
+		if e = cd.validUTF8(e); e == nil {
+			continue
+		}
+		// the EMF log events are dropped rather than waited for when the pusher is not keeping up
+		if cd.budget != nil && !cd.isEMF {
+			if e = cd.budget.admit(e, cd.Priority()); e == nil {
+				continue
+			}
+		}
 		cd.AddEvent(e)
 	}
 	if cd.stopped {
@@ -278,6 +464,30 @@ func (cd *cwDest) Publish(events []logs.LogEvent) error {
 	return nil
 }
 
+// validUTF8 returns the log event with a valid UTF-8 message by the invalid_utf8 policy, or nil when its message
+// is not valid UTF-8 and the policy drops it, after calling its Done
+func (cd *cwDest) validUTF8(e logs.LogEvent) logs.LogEvent {
+	msg := e.Message()
+	valid, ok := validUTF8(msg, cd.invalidUTF8)
+	if ok && valid == msg {
+		return e
+	}
+	if !cd.invalidUTF8Reported {
+		cd.invalidUTF8Reported = true
+		policy := cd.invalidUTF8
+		if policy == "" {
+			policy = invalidUTF8Replace
+		}
+		cd.Log.Warnf("The log events of (%v/%v) are not all valid UTF-8, which CloudWatch Logs rejects, they are handled by the invalid_utf8 policy %v", cd.Group, cd.Stream, policy)
+	}
+	cd.addStats("invalidUTF8", 1)
+	if !ok {
+		e.Done()
+		return nil
+	}
+	return &validEvent{LogEvent: e, message: valid}
+}
+
 func (cd *cwDest) Stop() {
 	cd.pusher.Stop()
 	cd.stopped = true
@@ -297,9 +507,11 @@ func (cd *cwDest) switchToEMF() {
 	defer cd.Unlock()
 	if !cd.isEMF {
 		cd.isEMF = true
-		cwl, ok := cd.Service.(*cloudwatchlogs.CloudWatchLogs)
-		if ok {
-			cwl.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("x-amzn-logs-format", "json/emf"))
+		switch s := cd.Service.(type) {
+		case *cloudwatchlogs.CloudWatchLogs:
+			s.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("x-amzn-logs-format", "json/emf"))
+		case emfFormatter:
+			s.setEMFFormat()
 		}
 	}
 }
@@ -324,6 +536,23 @@ var sampleConfig = `
   ## Amazon REGION
   region = "us-east-1"
 
+  ## Send to the FIPS endpoint of the region, the endpoint is resolved from the
+  ## region unless endpoint_override is set
+  #use_fips_endpoint = false
+
+  ## Send to the dual-stack endpoint of the region, required on IPv6-only hosts
+  #use_dualstack_endpoint = false
+
+  ## Sign the requests with SigV4A rather than SigV4, e.g. for the multi-region
+  ## endpoints, the signatures are valid in the regions of signing_region_set
+  #signing_algorithm = "sigv4"
+  #signing_region_set = ["*"]
+
+  ## The AWS SDK sending the log events, v2 retries the throttled calls with its
+  ## adaptive mode. The payload sampling, the circuit breaker and SigV4A are only
+  ## available with v1
+  #sdk_version = "v1"
+
   ## Amazon Credentials
   ## Credentials are loaded in the following order
   ## 1) Assumed credentials via STS if role_arn is specified
@@ -338,9 +567,48 @@ var sampleConfig = `
   #role_arn = ""
   #profile = ""
   #shared_credential_file = ""
+  ## The external ID required by the trust policy of the role, e.g. of a monitoring account
+  #external_id = ""
 
   # The log stream name.
   log_stream_name = "<log_stream_name>"
+
+  ## Bound the log events buffered by all the log streams, in MiB. Once it is used
+  ## up the critical log sources are published first, the normal ones wait and the
+  ## bulk ones are deferred until half of it is free, or sampled 1 in 10
+  #memory_budget_mb = 64
+  #bulk_under_pressure = "defer"
+
+  ## Keep the timestamps of every log stream increasing, e.g. for the log files
+  ## written by several threads, by moving the log events whose timestamp is not
+  ## after the previous one 1ms after it
+  #monotonic_timestamps = false
+
+  ## Flush the buffered log events once the spot interruption or auto scaling
+  ## scale-in notice of the instance is received, each log stream ends with an
+  ## event telling the instance is terminating
+  #flush_on_termination_notice = false
+
+  ## Hold the log events of the log streams written by more than one source, e.g.
+  ## several files, for this long to send them in the order of their timestamps
+  #reorder_window = "0s"
+
+  ## Move the timestamps of the log events by the skew of the clock of the host
+  ## once it is persistent, as estimated from the Date of the responses of every
+  ## log stream, so that they are not rejected as too far in the future. The skew
+  ## is logged and reported in the status either way
+  #clock_skew_compensation = false
+
+  ## What happens to the log events which are not valid UTF-8, rejected by
+  ## CloudWatch Logs: "replace" the invalid bytes with U+FFFD, "drop" them or
+  ## send the message "base64" encoded as {"invalid_utf8_base64":"..."}
+  #invalid_utf8 = "replace"
+
+  ## Limit the calls per second to CloudWatch Logs of all the log streams, the
+  ## retries included, to stay below the limits of the account. The streams take
+  ## turns and the rate is halved while the calls are throttled
+  #max_api_tps = 0
+  #api_burst = 0
 `
 
 // SampleConfig returns the default configuration of the Output
@@ -353,6 +621,7 @@ func init() {
 		return &CloudWatchLogs{
 			ForceFlushInterval: internal.Duration{Duration: defaultFlushTimeout},
 			cwDests:            make(map[Target]*cwDest),
+			resources:          newResourceCache(),
 		}
 	})
 }
@@ -4,9 +4,19 @@
 package cloudwatchlogs
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/regular"
+	"github.com/aws/amazon-cloudwatch-agent/plugins/outputs/cloudwatchlogs/cloudwatchlogstest"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateDest(t *testing.T) {
@@ -44,3 +54,129 @@ func TestCreateDest(t *testing.T) {
 		t.Errorf("Empty create dest should return dest to default group and stream, %v/%v found", d.pusher.Group, d.pusher.Stream)
 	}
 }
+
+func TestPublishToMockServer(t *testing.T) {
+	server := cloudwatchlogstest.NewServer()
+	defer server.Close()
+	server.ThrottleNext(1)
+
+	c := outputs.Outputs["cloudwatchlogs"]().(*CloudWatchLogs)
+	c.Region = "us-east-1"
+	c.EndpointOverride = server.URL
+	c.AccessKey = "AKID"
+	c.SecretKey = "SECRET"
+	c.ForceFlushInterval = internal.Duration{Duration: 10 * time.Millisecond}
+	c.Log = models.NewLogger("outputs", "cloudwatchlogs", "")
+	require.NoError(t, c.Connect())
+
+	now := time.Now()
+	d := c.CreateDest("/aws/app", "host")
+	require.NoError(t, d.Publish([]logs.LogEvent{
+		evtMock{m: "second", t: now},
+		evtMock{m: "first", t: now.Add(-time.Second)},
+	}))
+
+	events, err := server.WaitForEvents("/aws/app", "host", 2, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "first", events[0].Message)
+	assert.Equal(t, "second", events[1].Message)
+	assert.Empty(t, server.Violations())
+	assert.Equal(t, 1, server.Calls("CreateLogGroup"))
+	c.Close()
+}
+
+func TestConnectUnresolvedEndpoint(t *testing.T) {
+	c := outputs.Outputs["cloudwatchlogs"]().(*CloudWatchLogs)
+	c.Region = "cn-north-1"
+	c.UseFipsEndpoint = true
+	assert.EqualError(t, c.Connect(), "unable to resolve the logs endpoint: logs has no FIPS endpoint in region cn-north-1")
+	// the endpoint override is used as is
+	c.EndpointOverride = "https://logs.example.com"
+	assert.NoError(t, c.Connect())
+}
+
+func TestPublishSignedWithSigV4A(t *testing.T) {
+	c := outputs.Outputs["cloudwatchlogs"]().(*CloudWatchLogs)
+	c.SigningAlgorithm = "sigv5"
+	assert.Error(t, c.Connect())
+
+	server := cloudwatchlogstest.NewServer()
+	defer server.Close()
+	c.SigningAlgorithm = "sigv4a"
+	c.Region = "us-east-1"
+	c.EndpointOverride = server.URL
+	c.AccessKey = "AKID"
+	c.SecretKey = "SECRET"
+	c.ForceFlushInterval = internal.Duration{Duration: 10 * time.Millisecond}
+	c.Log = models.NewLogger("outputs", "cloudwatchlogs", "")
+	require.NoError(t, c.Connect())
+
+	d := c.CreateDest("/aws/app", "host")
+	require.NoError(t, d.Publish([]logs.LogEvent{evtMock{m: "signed", t: time.Now()}}))
+	events, err := server.WaitForEvents("/aws/app", "host", 1, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "signed", events[0].Message)
+	c.Close()
+}
+
+func TestPublishWithSDKV2(t *testing.T) {
+	c := outputs.Outputs["cloudwatchlogs"]().(*CloudWatchLogs)
+	c.SDKVersion = "v3"
+	assert.Error(t, c.Connect())
+	c.SDKVersion = "v2"
+	c.SigningAlgorithm = "sigv4a"
+	assert.Error(t, c.Connect())
+
+	server := cloudwatchlogstest.NewServer()
+	defer server.Close()
+	c.SigningAlgorithm = ""
+	c.Region = "us-east-1"
+	c.EndpointOverride = server.URL
+	c.AccessKey = "AKID"
+	c.SecretKey = "SECRET"
+	c.ForceFlushInterval = internal.Duration{Duration: 10 * time.Millisecond}
+	c.Log = models.NewLogger("outputs", "cloudwatchlogs", "")
+	require.NoError(t, c.Connect())
+
+	// the group and the stream are created, and the throttled call is retried by the SDK
+	server.ThrottleNext(1)
+	d := c.CreateDest("/aws/app", "host")
+	require.NoError(t, d.Publish([]logs.LogEvent{evtMock{m: "sent with v2", t: time.Now()}}))
+	events, err := server.WaitForEvents("/aws/app", "host", 1, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "sent with v2", events[0].Message)
+	assert.Empty(t, server.Violations())
+	c.Close()
+}
+
+func TestDistributionInStructuredLog(t *testing.T) {
+	c := outputs.Outputs["cloudwatchlogs"]().(*CloudWatchLogs)
+	dist := regular.NewRegularDistribution()
+	dist.AddEntry(10, 1)
+	dist.AddEntry(10, 1)
+	dist.AddEntry(250, 1)
+	large := regular.NewRegularDistribution()
+	for i := 0; i <= maxEMFDistributionValues; i++ {
+		large.AddEntry(float64(i), 1)
+	}
+	m, err := metric.New("latency", map[string]string{"Service": "checkout"},
+		map[string]interface{}{"request_time": dist, "wait_time": large, "empty": regular.NewRegularDistribution()}, time.Now())
+	require.NoError(t, err)
+
+	e := c.getLogEventFromMetric(m)
+	require.NotNil(t, e)
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(e.Message()), &content))
+	requestTime := content["request_time"].(map[string]interface{})
+	assert.Equal(t, float64(250), requestTime["Max"])
+	assert.Equal(t, float64(10), requestTime["Min"])
+	assert.Equal(t, float64(3), requestTime["Count"])
+	assert.Equal(t, float64(270), requestTime["Sum"])
+	assert.Len(t, requestTime["Values"], 2)
+	assert.Len(t, requestTime["Counts"], 2)
+	// the values beyond the limit of EMF are published as their statistics only
+	waitTime := content["wait_time"].(map[string]interface{})
+	assert.Equal(t, float64(maxEMFDistributionValues+1), waitTime["Count"])
+	assert.NotContains(t, waitTime, "Values")
+	assert.NotContains(t, content, "empty")
+}
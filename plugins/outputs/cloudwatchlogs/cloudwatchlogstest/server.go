@@ -0,0 +1,106 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogstest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+const targetPrefix = "Logs_20140328."
+
+// Server serves the Service over the json protocol of CloudWatch Logs, so that the agent can
+// be pointed to it with endpoint_override and any static credentials.
+type Server struct {
+	*httptest.Server
+	*Service
+}
+
+// NewServer starts a server backed by a new Service, it has to be closed once the test is done
+func NewServer() *Server {
+	s := &Server{Service: NewService()}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeError(w, awserr.New("SerializationException", fmt.Sprintf("invalid gzip body: %v", err), nil))
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	payload, err := ioutil.ReadAll(body)
+	if err != nil {
+		writeError(w, awserr.New("SerializationException", err.Error(), nil))
+		return
+	}
+
+	var output interface{}
+	switch operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix); operation {
+	case "PutLogEvents":
+		input := &cloudwatchlogs.PutLogEventsInput{}
+		if err = json.Unmarshal(payload, input); err == nil {
+			output, err = s.PutLogEvents(input)
+		}
+	case "CreateLogGroup":
+		input := &cloudwatchlogs.CreateLogGroupInput{}
+		if err = json.Unmarshal(payload, input); err == nil {
+			output, err = s.CreateLogGroup(input)
+		}
+	case "CreateLogStream":
+		input := &cloudwatchlogs.CreateLogStreamInput{}
+		if err = json.Unmarshal(payload, input); err == nil {
+			output, err = s.CreateLogStream(input)
+		}
+	default:
+		err = awserr.New("UnknownOperationException", fmt.Sprintf("operation %q is not supported", operation), nil)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	b, err := jsonutil.BuildJSON(output)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Write(b)
+}
+
+// writeError writes the error the way the SDK unmarshals it back into the typed errors of the cloudwatchlogs package
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	body := map[string]interface{}{"__type": "SerializationException", "message": err.Error()}
+	if awsErr, ok := err.(awserr.Error); ok {
+		body["__type"] = awsErr.Code()
+		body["message"] = awsErr.Message()
+	}
+	switch e := err.(type) {
+	case *cloudwatchlogs.InvalidSequenceTokenException:
+		body["expectedSequenceToken"] = e.ExpectedSequenceToken
+	case *cloudwatchlogs.ServiceUnavailableException:
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,277 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package cloudwatchlogstest provides an in-memory CloudWatch Logs to write integration tests of
+// the log pipeline against, either in-process or as an http server the AWS SDK can be pointed to.
+package cloudwatchlogstest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+// Limits of the PutLogEvents API enforced by the Service,
+// see https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+const (
+	MaxBatchSize    = 1024 * 1024
+	MaxBatchEvents  = 10000
+	EventHeaderSize = 26
+	MaxEventSize    = 256*1024 - EventHeaderSize
+	MaxBatchSpan    = 24 * time.Hour
+	MaxEventAge     = 14 * 24 * time.Hour
+	MaxEventAhead   = 2 * time.Hour
+)
+
+// Event is a log event accepted by the Service
+type Event struct {
+	Timestamp time.Time
+	Message   string
+}
+
+type stream struct {
+	events        []Event
+	sequenceToken int
+}
+
+// Service is an in-memory CloudWatch Logs implementing the calls made by the cloudwatchlogs output. PutLogEvents
+// requests are validated the way CloudWatch Logs does: the batch size, number of events, chronological order and
+// time span of a batch, the sequence token and the existence of the log group and stream. Invalid requests fail
+// with the error CloudWatch Logs returns and are listed by Violations, events out of the accepted time window
+// are rejected through the RejectedLogEventsInfo of the response.
+type Service struct {
+	// Now returns the time the timestamps of the events are validated against, time.Now is used when nil
+	Now func() time.Time
+
+	mu         sync.Mutex
+	groups     map[string]map[string]*stream
+	faults     []error
+	violations []error
+	calls      map[string]int
+	rejected   int
+}
+
+func NewService() *Service {
+	return &Service{
+		groups: make(map[string]map[string]*stream),
+		calls:  make(map[string]int),
+	}
+}
+
+// ThrottlingError is the error returned by CloudWatch Logs when the request rate is exceeded
+func ThrottlingError() error {
+	return awserr.New("ThrottlingException", "Rate exceeded", nil)
+}
+
+// ServiceUnavailableError is the error returned by CloudWatch Logs when it is temporarily unable to serve a request
+func ServiceUnavailableError() error {
+	return &cloudwatchlogs.ServiceUnavailableException{Message_: aws.String("The service is unavailable, please try again later")}
+}
+
+// FailNext makes the next PutLogEvents calls fail with the errors, one call per error, before they are validated
+func (s *Service) FailNext(errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, errs...)
+}
+
+// ThrottleNext makes the next n PutLogEvents calls fail with a ThrottlingError
+func (s *Service) ThrottleNext(n int) {
+	for i := 0; i < n; i++ {
+		s.FailNext(ThrottlingError())
+	}
+}
+
+// Events returns the events accepted into the log stream, in the order CloudWatch Logs would return them
+func (s *Service) Events(group, stream string) []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.groups[group][stream]
+	if !ok {
+		return nil
+	}
+	events := append([]Event(nil), st.events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	return events
+}
+
+// WaitForEvents waits until at least n events were accepted into the log stream and returns them
+func (s *Service) WaitForEvents(group, stream string, n int, timeout time.Duration) ([]Event, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		events := s.Events(group, stream)
+		if len(events) >= n {
+			return events, nil
+		}
+		if time.Now().After(deadline) {
+			return events, fmt.Errorf("%v events received in %v/%v after %v, expecting %v", len(events), group, stream, timeout, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// LogGroups returns the names of the log groups created
+func (s *Service) LogGroups() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var groups []string
+	for g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Violations returns the errors of the invalid requests received, they are the mistakes of the client
+func (s *Service) Violations() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.violations...)
+}
+
+// Calls returns how many times the operation, e.g. "PutLogEvents", was called
+func (s *Service) Calls(operation string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[operation]
+}
+
+// Rejected returns the number of events rejected for being out of the accepted time window
+func (s *Service) Rejected() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rejected
+}
+
+func (s *Service) CreateLogGroup(input *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls["CreateLogGroup"]++
+	group := aws.StringValue(input.LogGroupName)
+	if group == "" {
+		return nil, s.violation(&cloudwatchlogs.InvalidParameterException{Message_: aws.String("log group name is required")})
+	}
+	if _, ok := s.groups[group]; ok {
+		return nil, &cloudwatchlogs.ResourceAlreadyExistsException{Message_: aws.String("The specified log group already exists")}
+	}
+	s.groups[group] = make(map[string]*stream)
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (s *Service) CreateLogStream(input *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls["CreateLogStream"]++
+	streams, ok := s.groups[aws.StringValue(input.LogGroupName)]
+	if !ok {
+		return nil, &cloudwatchlogs.ResourceNotFoundException{Message_: aws.String("The specified log group does not exist.")}
+	}
+	name := aws.StringValue(input.LogStreamName)
+	if name == "" {
+		return nil, s.violation(&cloudwatchlogs.InvalidParameterException{Message_: aws.String("log stream name is required")})
+	}
+	if _, ok := streams[name]; ok {
+		return nil, &cloudwatchlogs.ResourceAlreadyExistsException{Message_: aws.String("The specified log stream already exists")}
+	}
+	streams[name] = &stream{}
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (s *Service) PutLogEvents(input *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls["PutLogEvents"]++
+	if len(s.faults) > 0 {
+		err := s.faults[0]
+		s.faults = s.faults[1:]
+		return nil, err
+	}
+
+	st, ok := s.groups[aws.StringValue(input.LogGroupName)][aws.StringValue(input.LogStreamName)]
+	if !ok {
+		return nil, &cloudwatchlogs.ResourceNotFoundException{Message_: aws.String("The specified log stream does not exist.")}
+	}
+	if err := validateBatch(input.LogEvents); err != nil {
+		return nil, s.violation(err)
+	}
+	if st.sequenceToken > 0 && aws.StringValue(input.SequenceToken) != strconv.Itoa(st.sequenceToken) {
+		return nil, &cloudwatchlogs.InvalidSequenceTokenException{
+			Message_:              aws.String(fmt.Sprintf("The given sequenceToken is invalid. The next expected sequenceToken is: %v", st.sequenceToken)),
+			ExpectedSequenceToken: aws.String(strconv.Itoa(st.sequenceToken)),
+		}
+	}
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	output := &cloudwatchlogs.PutLogEventsOutput{}
+	info := &cloudwatchlogs.RejectedLogEventsInfo{}
+	for i, e := range input.LogEvents {
+		t := time.Unix(0, aws.Int64Value(e.Timestamp)*int64(time.Millisecond))
+		switch {
+		case now.Sub(t) > MaxEventAge:
+			info.TooOldLogEventEndIndex = aws.Int64(int64(i + 1))
+		case t.Sub(now) > MaxEventAhead:
+			if info.TooNewLogEventStartIndex == nil {
+				info.TooNewLogEventStartIndex = aws.Int64(int64(i))
+			}
+		default:
+			st.events = append(st.events, Event{Timestamp: t, Message: aws.StringValue(e.Message)})
+			continue
+		}
+		s.rejected++
+	}
+	if info.TooOldLogEventEndIndex != nil || info.TooNewLogEventStartIndex != nil {
+		output.RejectedLogEventsInfo = info
+	}
+
+	st.sequenceToken++
+	output.NextSequenceToken = aws.String(strconv.Itoa(st.sequenceToken))
+	return output, nil
+}
+
+func (s *Service) violation(err error) error {
+	s.violations = append(s.violations, err)
+	return err
+}
+
+func validateBatch(events []*cloudwatchlogs.InputLogEvent) error {
+	if len(events) == 0 || len(events) > MaxBatchEvents {
+		return invalidParameter("a batch must contain from 1 to %v log events, got %v", MaxBatchEvents, len(events))
+	}
+	size := 0
+	first, last := aws.Int64Value(events[0].Timestamp), aws.Int64Value(events[0].Timestamp)
+	for i, e := range events {
+		msg := aws.StringValue(e.Message)
+		if msg == "" {
+			return invalidParameter("the message of log event %v is empty", i)
+		}
+		if len(msg) > MaxEventSize {
+			return invalidParameter("log event %v is %v bytes, the limit is %v bytes", i, len(msg), MaxEventSize)
+		}
+		size += len(msg) + EventHeaderSize
+		t := aws.Int64Value(e.Timestamp)
+		if t < last {
+			return invalidParameter("Log events in a single PutLogEvents request must be in chronological order.")
+		}
+		last = t
+	}
+	if size > MaxBatchSize {
+		return invalidParameter("the batch is %v bytes, the limit is %v bytes", size, MaxBatchSize)
+	}
+	if time.Duration(last-first)*time.Millisecond > MaxBatchSpan {
+		return invalidParameter("the log events of a batch must not span more than %v", MaxBatchSpan)
+	}
+	return nil
+}
+
+func invalidParameter(format string, args ...interface{}) error {
+	return &cloudwatchlogs.InvalidParameterException{Message_: aws.String(fmt.Sprintf(format, args...))}
+}
@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogstest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func events(timestamps ...time.Time) []*cloudwatchlogs.InputLogEvent {
+	var result []*cloudwatchlogs.InputLogEvent
+	for _, t := range timestamps {
+		result = append(result, &cloudwatchlogs.InputLogEvent{Message: aws.String("msg " + t.String()), Timestamp: aws.Int64(t.UnixNano() / 1e6)})
+	}
+	return result
+}
+
+func newServiceWithStream(t *testing.T) *Service {
+	s := NewService()
+	_, err := s.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("G")})
+	require.NoError(t, err)
+	_, err = s.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S")})
+	require.NoError(t, err)
+	return s
+}
+
+func TestPutLogEventsValidation(t *testing.T) {
+	now := time.Now()
+	s := newServiceWithStream(t)
+
+	_, err := s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("missing"), LogEvents: events(now)})
+	assert.IsType(t, &cloudwatchlogs.ResourceNotFoundException{}, err)
+
+	_, err = s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(now, now.Add(-time.Second))})
+	assert.IsType(t, &cloudwatchlogs.InvalidParameterException{}, err)
+
+	_, err = s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(now.Add(-25*time.Hour), now)})
+	assert.IsType(t, &cloudwatchlogs.InvalidParameterException{}, err)
+
+	large := &cloudwatchlogs.InputLogEvent{Message: aws.String(strings.Repeat("x", MaxEventSize)), Timestamp: aws.Int64(now.UnixNano() / 1e6)}
+	_, err = s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: []*cloudwatchlogs.InputLogEvent{large, large, large, large, large}})
+	assert.IsType(t, &cloudwatchlogs.InvalidParameterException{}, err)
+
+	assert.Len(t, s.Violations(), 3)
+	assert.Equal(t, 4, s.Calls("PutLogEvents"))
+	assert.Empty(t, s.Events("G", "S"))
+}
+
+func TestPutLogEventsSequenceTokenAndTimeWindow(t *testing.T) {
+	now := time.Now()
+	s := newServiceWithStream(t)
+
+	out, err := s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(now.Add(-15 * 24 * time.Hour))})
+	require.NoError(t, err)
+	require.NotNil(t, out.RejectedLogEventsInfo)
+	assert.Equal(t, int64(1), *out.RejectedLogEventsInfo.TooOldLogEventEndIndex)
+
+	_, err = s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(now)})
+	require.IsType(t, &cloudwatchlogs.InvalidSequenceTokenException{}, err)
+	assert.Equal(t, *out.NextSequenceToken, *err.(*cloudwatchlogs.InvalidSequenceTokenException).ExpectedSequenceToken)
+
+	out, err = s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(now, now.Add(3*time.Hour)), SequenceToken: out.NextSequenceToken})
+	require.NoError(t, err)
+	require.NotNil(t, out.RejectedLogEventsInfo)
+	assert.Equal(t, int64(1), *out.RejectedLogEventsInfo.TooNewLogEventStartIndex)
+
+	assert.Equal(t, 2, s.Rejected())
+	assert.Len(t, s.Events("G", "S"), 1)
+	assert.Empty(t, s.Violations())
+}
+
+func TestFaultInjection(t *testing.T) {
+	s := newServiceWithStream(t)
+	s.ThrottleNext(1)
+	s.FailNext(ServiceUnavailableError())
+
+	input := &cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(time.Now())}
+	_, err := s.PutLogEvents(input)
+	require.Error(t, err)
+	assert.Equal(t, "ThrottlingException", err.(awserr.Error).Code())
+	_, err = s.PutLogEvents(input)
+	assert.IsType(t, &cloudwatchlogs.ServiceUnavailableException{}, err)
+	_, err = s.PutLogEvents(input)
+	assert.NoError(t, err)
+}
+
+func TestServerWithSDKClient(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	sess := session.Must(session.NewSession())
+	client := cloudwatchlogs.New(sess, &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		MaxRetries:  aws.Int(0),
+	})
+
+	input := &cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S"), LogEvents: events(time.Now())}
+	_, err := client.PutLogEvents(input)
+	assert.IsType(t, &cloudwatchlogs.ResourceNotFoundException{}, err)
+
+	_, err = client.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("G")})
+	require.NoError(t, err)
+	_, err = client.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String("G"), LogStreamName: aws.String("S")})
+	require.NoError(t, err)
+	out, err := client.PutLogEvents(input)
+	require.NoError(t, err)
+
+	_, err = client.PutLogEvents(input)
+	require.IsType(t, &cloudwatchlogs.InvalidSequenceTokenException{}, err)
+	assert.Equal(t, *out.NextSequenceToken, *err.(*cloudwatchlogs.InvalidSequenceTokenException).ExpectedSequenceToken)
+
+	server.ThrottleNext(1)
+	input.SequenceToken = out.NextSequenceToken
+	_, err = client.PutLogEvents(input)
+	require.Error(t, err)
+	assert.Equal(t, "ThrottlingException", err.(awserr.Error).Code())
+
+	_, err = client.PutLogEvents(input)
+	require.NoError(t, err)
+	assert.Len(t, server.Events("G", "S"), 2)
+}
@@ -0,0 +1,200 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	errCodeThrottling = "ThrottlingException"
+	// minAPIRate is the lowest rate the limiter slows down to while the calls are throttled
+	minAPIRate = 1
+	// apiRateRecoverySteps is how many successful calls bring the rate back from the lowest to the configured one
+	apiRateRecoverySteps = 100
+	// sustainedThrottling is how long the calls are throttled, without the rate recovering, before it is posted
+	sustainedThrottling = 5 * time.Minute
+)
+
+// apiLimiter is the token bucket shared by all the dests for their calls to CloudWatch Logs, including the retries,
+// so that the agents with many streams stay below the TPS limits of the account instead of every stream retrying on
+// its own. The tokens are handed out by the priority class of the dests, a call waits while calls of a higher class
+// wait, so that the critical streams are sent first when the rate is limited and the bulk ones are deferred. Every
+// pusher waits for one call at a time, so the streams of a class take turns.
+//
+// The rate is halved whenever a call is throttled, e.g. when other agents of the account use up its limits, and grows
+// back to the configured one with the successful calls. The calls stop waiting once the output is closed, so the
+// streams send what they have buffered before the agent exits.
+type apiLimiter struct {
+	maxRate float64
+	burst   float64
+
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	// sleep returns false when the limiter is stopped before the end of the duration
+	sleep    func(time.Duration) bool
+	done     chan struct{}
+	stopOnce sync.Once
+	// waiting are the calls waiting for a token by priority rank
+	waiting [3]int
+
+	// throttledSince is when the calls started being throttled, zero once the rate recovered
+	throttledSince     time.Time
+	throttlingReported bool
+}
+
+func newAPILimiter(tps float64, burst int) *apiLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(tps))
+	}
+	l := &apiLimiter{
+		maxRate: tps,
+		burst:   float64(burst),
+		rate:    tps,
+		tokens:  float64(burst),
+		last:    time.Now(),
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}
+	l.sleep = l.sleepUnlessClosed
+	return l
+}
+
+func (l *apiLimiter) sleepUnlessClosed(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+// stop makes the calls waiting for a token, and the next ones, go without waiting
+func (l *apiLimiter) stop() {
+	l.stopOnce.Do(func() { close(l.done) })
+}
+
+// reserve takes a token unless none is left or calls of a higher priority rank wait for one, it returns how long
+// to wait for the next token otherwise
+func (l *apiLimiter) reserve(rank int, queued bool) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 && !l.higherWaiting(rank) {
+		l.tokens--
+		if queued {
+			l.waiting[rank]--
+		}
+		return 0, true
+	}
+	if !queued {
+		l.waiting[rank]++
+	}
+	if l.tokens >= 1 {
+		// the calls of a higher rank take the tokens first
+		return time.Duration(float64(time.Second) / l.rate), false
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second)), false
+}
+
+func (l *apiLimiter) higherWaiting(rank int) bool {
+	for r := rank + 1; r < len(l.waiting); r++ {
+		if l.waiting[r] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wait waits for a token for a call of a dest of the priority class
+func (l *apiLimiter) wait(priority string) {
+	rank := logs.PriorityRank(priority)
+	if rank < 0 {
+		rank = logs.PriorityRank(logs.PriorityNormal)
+	}
+	var waited time.Duration
+	for queued := false; ; queued = true {
+		d, ok := l.reserve(rank, queued)
+		if ok {
+			break
+		}
+		waited += d
+		if !l.sleep(d) {
+			l.mu.Lock()
+			l.waiting[rank]--
+			l.mu.Unlock()
+			break
+		}
+	}
+	if waited > 0 {
+		profiler.Profiler.AddStats([]string{"cloudwatchlogs", "apiRateLimitedMs"}, float64(waited/time.Millisecond))
+	}
+}
+
+func (l *apiLimiter) throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = math.Max(minAPIRate, l.rate/2)
+	now := l.now()
+	if l.throttledSince.IsZero() {
+		l.throttledSince = now
+	} else if !l.throttlingReported && now.Sub(l.throttledSince) >= sustainedThrottling {
+		l.throttlingReported = true
+		agentevents.Post(agentevents.Event{
+			Type:      agentevents.TypeThrottlingSustained,
+			Severity:  agentevents.SeverityWarning,
+			Component: "outputs.cloudwatchlogs",
+			Message: fmt.Sprintf("the calls to CloudWatch Logs are throttled since %v, slowed down to %.2f per second",
+				l.throttledSince.UTC().Format(time.RFC3339), l.rate),
+		})
+	}
+}
+
+func (l *apiLimiter) succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = math.Min(l.maxRate, l.rate+l.maxRate/apiRateRecoverySteps)
+	if l.rate == l.maxRate {
+		l.throttledSince, l.throttlingReported = time.Time{}, false
+	}
+}
+
+func isThrottling(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == errCodeThrottling
+}
+
+// addHandlers makes every attempt of the calls of the client wait for a token, the retries of the SDK included, with
+// the priority class of its dest. The retry handlers run after every failed attempt, whether it is retried or not.
+func (l *apiLimiter) addHandlers(h *request.Handlers, priority func() string) {
+	h.Send.PushFrontNamed(request.NamedHandler{Name: "APIRateLimitHandler", Fn: func(req *request.Request) {
+		l.wait(priority())
+	}})
+	h.Retry.PushFrontNamed(request.NamedHandler{Name: "APIRateThrottledHandler", Fn: func(req *request.Request) {
+		if isThrottling(req.Error) {
+			l.throttled()
+		}
+	}})
+	h.Complete.PushBackNamed(request.NamedHandler{Name: "APIRateRecoveryHandler", Fn: func(req *request.Request) {
+		if req.Error == nil {
+			l.succeeded()
+		}
+	}})
+}
@@ -0,0 +1,178 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/agentevents"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPILimiterStop(t *testing.T) {
+	l := newAPILimiter(0.001, 1)
+	l.wait(logs.PriorityNormal)
+
+	waited := make(chan struct{})
+	go func() {
+		l.wait(logs.PriorityNormal)
+		close(waited)
+	}()
+	l.stop()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("the call still waits for a token once the limiter is stopped")
+	}
+	l.wait(logs.PriorityNormal)
+	assert.Equal(t, [3]int{}, l.waiting)
+}
+
+func newTestLimiter(tps float64, burst int) (*apiLimiter, *time.Time, *[]time.Duration) {
+	l := newAPILimiter(tps, burst)
+	now := time.Now()
+	var waits []time.Duration
+	l.last = now
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) bool {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return true
+	}
+	return l, &now, &waits
+}
+
+func TestAPILimiterQueuesCallers(t *testing.T) {
+	l, now, waits := newTestLimiter(10, 2)
+	l.wait(logs.PriorityNormal)
+	l.wait(logs.PriorityBulk)
+	assert.Empty(t, *waits, "the burst is not limited")
+
+	// every caller waits for the next token
+	l.wait(logs.PriorityNormal)
+	l.wait(logs.PriorityNormal)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}, *waits)
+
+	*now = now.Add(time.Second)
+	*waits = nil
+	l.wait("")
+	assert.Empty(t, *waits)
+}
+
+func TestAPILimiterPriority(t *testing.T) {
+	l, now, _ := newTestLimiter(10, 1)
+	critical, normal, bulk := logs.PriorityRank(logs.PriorityCritical), logs.PriorityRank(logs.PriorityNormal), logs.PriorityRank(logs.PriorityBulk)
+	_, ok := l.reserve(normal, false)
+	require.True(t, ok)
+
+	// no token is left, the callers wait for the next one
+	d, ok := l.reserve(bulk, false)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, d)
+	_, ok = l.reserve(normal, false)
+	assert.False(t, ok)
+	_, ok = l.reserve(critical, false)
+	assert.False(t, ok)
+
+	// the next token goes to the critical caller, then to the normal one, the bulk one waiting for both
+	*now = now.Add(100 * time.Millisecond)
+	_, ok = l.reserve(bulk, true)
+	assert.False(t, ok)
+	_, ok = l.reserve(normal, true)
+	assert.False(t, ok)
+	_, ok = l.reserve(critical, true)
+	assert.True(t, ok)
+
+	*now = now.Add(100 * time.Millisecond)
+	_, ok = l.reserve(bulk, true)
+	assert.False(t, ok)
+	_, ok = l.reserve(normal, true)
+	assert.True(t, ok)
+
+	*now = now.Add(100 * time.Millisecond)
+	_, ok = l.reserve(bulk, true)
+	assert.True(t, ok)
+	assert.Equal(t, [3]int{}, l.waiting)
+}
+
+func TestAPILimiterSlowsDownWhenThrottled(t *testing.T) {
+	l, _, _ := newTestLimiter(100, 0)
+	assert.Equal(t, float64(100), l.burst)
+
+	l.throttled()
+	assert.Equal(t, float64(50), l.rate)
+	for i := 0; i < 10; i++ {
+		l.throttled()
+	}
+	assert.Equal(t, float64(minAPIRate), l.rate)
+
+	for i := 0; i < apiRateRecoverySteps; i++ {
+		l.succeeded()
+	}
+	assert.Equal(t, float64(100), l.rate)
+}
+
+func TestAPILimiterPostsSustainedThrottling(t *testing.T) {
+	events, unsubscribe := agentevents.Subscribe()
+	defer unsubscribe()
+	for len(events) > 0 {
+		<-events
+	}
+	l, now, _ := newTestLimiter(100, 0)
+	l.throttled()
+	*now = now.Add(sustainedThrottling / 2)
+	l.throttled()
+	assert.Empty(t, events)
+
+	*now = now.Add(sustainedThrottling / 2)
+	l.throttled()
+	l.throttled()
+	require.Len(t, events, 1)
+	assert.Equal(t, agentevents.TypeThrottlingSustained, (<-events).Type)
+
+	// the rate recovered, the throttling is posted again once sustained
+	for i := 0; i < apiRateRecoverySteps; i++ {
+		l.succeeded()
+	}
+	assert.True(t, l.throttledSince.IsZero())
+	assert.False(t, l.throttlingReported)
+}
+
+func TestAPILimiterHandlers(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ThrottlingException","message":"Rate exceeded"}`))
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := cloudwatchlogs.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(1),
+	})
+	l, _, waits := newTestLimiter(10, 1)
+	l.addHandlers(&client.Handlers, func() string { return logs.PriorityNormal })
+
+	_, err := client.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("group")})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Len(t, *waits, 1, "the retry of the SDK waits for a token")
+	assert.Equal(t, 5+10/float64(apiRateRecoverySteps), l.rate)
+}
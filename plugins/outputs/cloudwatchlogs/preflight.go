@@ -0,0 +1,59 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"fmt"
+
+	configaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+)
+
+// iamActions are the actions the pushers call CloudWatch Logs with, the log groups and the log streams are created
+// when they do not exist yet
+var iamActions = []string{"logs:PutLogEvents", "logs:CreateLogStream", "logs:CreateLogGroup"}
+
+// PreflightIAM checks that the actions of the output are allowed with its credentials
+func (c *CloudWatchLogs) PreflightIAM() []preflight.Check {
+	return c.PreflightIAMLogGroups(nil)
+}
+
+// PreflightIAMLogGroups checks that the actions of the output are allowed on the log groups the inputs send their
+// log events to through the output, and on the log group of the output
+func (c *CloudWatchLogs) PreflightIAMLogGroups(groups []preflight.LogGroup) []preflight.Check {
+	credentialConfig := &configaws.CredentialConfig{
+		Region:     c.Region,
+		AccessKey:  c.AccessKey,
+		SecretKey:  c.SecretKey,
+		RoleARN:    c.RoleARN,
+		Profile:    c.Profile,
+		Filename:   c.Filename,
+		Token:      c.Token,
+		ExternalID: c.ExternalID,
+	}
+	return preflight.CheckIAMActionsOn(credentialConfig.Credentials(), iamActions, c.logGroupARNs(groups))
+}
+
+// logGroupARNs returns the ARNs of the log groups sent to the output, in its region, nil when there are none
+func (c *CloudWatchLogs) logGroupARNs(groups []preflight.LogGroup) preflight.ResourceARNs {
+	var names []string
+	seen := map[string]bool{}
+	for _, g := range append(groups, preflight.LogGroup{Name: c.LogGroupName}) {
+		if g.Name == "" || seen[g.Name] || (g.Destination != "" && g.Destination != "cloudwatchlogs") {
+			continue
+		}
+		seen[g.Name] = true
+		names = append(names, g.Name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return func(partition, account string) []string {
+		arns := make([]string, len(names))
+		for i, name := range names {
+			arns[i] = fmt.Sprintf("arn:%v:logs:%v:%v:log-group:%v:*", partition, c.Region, account, name)
+		}
+		return arns
+	}
+}
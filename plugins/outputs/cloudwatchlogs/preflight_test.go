@@ -0,0 +1,27 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogGroupARNs(t *testing.T) {
+	c := &CloudWatchLogs{Region: "us-east-1", LogGroupName: "emf"}
+	arns := c.logGroupARNs([]preflight.LogGroup{
+		{Name: "app"},
+		{Name: "app", Destination: "cloudwatchlogs"},
+		{Name: "stream", Destination: "kinesislogs"},
+		{Name: ""},
+	})
+	assert.Equal(t, []string{
+		"arn:aws:logs:us-east-1:123456789012:log-group:app:*",
+		"arn:aws:logs:us-east-1:123456789012:log-group:emf:*",
+	}, arns("aws", "123456789012"))
+
+	assert.Nil(t, (&CloudWatchLogs{}).logGroupARNs(nil), "the actions are checked on every log group")
+}
@@ -0,0 +1,66 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+type payloadSizeKey struct{}
+
+// publishStatsName is the name of the target of the log stream in the statistics
+func publishStatsName(t Target) string {
+	return "logs." + t.Group + "/" + t.Stream
+}
+
+// newestTimestamp returns the timestamp of the newest log event of the batch, the zero time when it is empty
+func newestTimestamp(events []*cloudwatchlogs.InputLogEvent) time.Time {
+	var newest int64
+	for _, e := range events {
+		if ts := aws.Int64Value(e.Timestamp); ts > newest {
+			newest = ts
+		}
+	}
+	if newest == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, newest*int64(time.Millisecond))
+}
+
+// addPublishStatsHandlers records the size of the PutLogEvents payloads before and after they are compressed, they
+// must be added before the compression handler
+func addPublishStatsHandlers(h *request.Handlers) {
+	h.Build.PushBackNamed(request.NamedHandler{
+		Name: "PublishStatsPayloadSizeHandler",
+		Fn: func(r *request.Request) {
+			if r.Operation.Name != "PutLogEvents" || r.Error != nil {
+				return
+			}
+			if size, err := aws.SeekerLen(r.GetBody()); err == nil {
+				r.SetContext(context.WithValue(r.Context(), payloadSizeKey{}, size))
+			}
+		},
+	})
+	h.Complete.PushBackNamed(request.NamedHandler{
+		Name: "PublishStatsSentSizeHandler",
+		Fn: func(r *request.Request) {
+			input, ok := r.Params.(*cloudwatchlogs.PutLogEventsInput)
+			if !ok || r.Error != nil || r.HTTPRequest.ContentLength <= 0 {
+				return
+			}
+			size, ok := r.Context().Value(payloadSizeKey{}).(int64)
+			if !ok {
+				return
+			}
+			t := Target{Group: aws.StringValue(input.LogGroupName), Stream: aws.StringValue(input.LogStreamName)}
+			publishstats.Get(publishStatsName(t)).Sent(size, r.HTTPRequest.ContentLength)
+		},
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/handlers"
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishStatsHandlers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := cloudwatchlogs.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	addPublishStatsHandlers(&client.Handlers)
+	client.Handlers.Build.PushBackNamed(handlers.NewRequestCompressionHandler([]string{"PutLogEvents"}))
+
+	_, err := client.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String("PublishStatsG"),
+		LogStreamName: aws.String("S"),
+		LogEvents: []*cloudwatchlogs.InputLogEvent{
+			{Message: aws.String(strings.Repeat("compressible ", 1000)), Timestamp: aws.Int64(1)},
+		},
+	})
+	require.NoError(t, err)
+
+	stats := publishstats.Get(publishStatsName(Target{"PublishStatsG", "S"}))
+	stats.Batch(1, 13000, time.Second)
+	assert.Greater(t, stats.Summary().CompressionRatio, float64(10))
+}
+
+func TestNewestTimestamp(t *testing.T) {
+	assert.True(t, newestTimestamp(nil).IsZero())
+	events := []*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("a"), Timestamp: aws.Int64(1600000000500)},
+		{Message: aws.String("b"), Timestamp: aws.Int64(1600000002250)},
+		{Message: aws.String("c"), Timestamp: aws.Int64(1600000001000)},
+	}
+	assert.Equal(t, time.Unix(1600000002, 250*int64(time.Millisecond)), newestTimestamp(events))
+}
@@ -4,11 +4,17 @@
 package cloudwatchlogs
 
 import (
+	"fmt"
 	"math/rand"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/handlers"
+	"github.com/aws/amazon-cloudwatch-agent/internal/flush"
+	"github.com/aws/amazon-cloudwatch-agent/internal/lifecycle"
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
 	"github.com/aws/amazon-cloudwatch-agent/logs"
 	"github.com/aws/amazon-cloudwatch-agent/profiler"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -40,7 +46,7 @@ type pusher struct {
 
 	events              []*cloudwatchlogs.InputLogEvent
 	minT, maxT          *time.Time
-	doneCallbacks       []func()
+	batch               logs.Batch
 	eventsCh            chan logs.LogEvent
 	nonBlockingEventsCh chan logs.LogEvent
 	bufferredSize       int
@@ -49,10 +55,42 @@ type pusher struct {
 	lastValidTime       int64
 	needSort            bool
 	stop                chan struct{}
-	lastSentTime        time.Time
+	// stopped is closed once the events queued before the stop are sent
+	stopped      chan struct{}
+	lastSentTime time.Time
 
 	initNonBlockingChOnce sync.Once
 	startNonBlockCh       chan struct{}
+
+	// budget bounds the log events buffered by all the pushers, nil when unbounded
+	budget       *memoryBudget
+	budgetedSize int64
+	priority     atomic.Value
+
+	// invalidUTF8 is the policy of the log events whose message is not valid UTF-8, replace, drop or base64
+	invalidUTF8 string
+
+	// clockSkew moves the timestamps of the log events by the persistent skew of the clock, nil when they are not
+	// compensated
+	clockSkew *clockSkew
+
+	// monotonic keeps the timestamps of the stream increasing, lastTimestamp is the one of the last log event
+	monotonic     bool
+	lastTimestamp int64
+
+	// reorder holds the log events for the reorder window once more than one source writes to the stream, nil when
+	// the events are not reordered
+	reorder *reorderBuffer
+	sources int32
+
+	// resources caches the log groups known to exist and the denied creations for all the pushers, nil when not shared
+	resources *resourceCache
+
+	// stats collects the statistics of the batches published to the stream, nil when not collected
+	stats *publishstats.Stats
+
+	// terminating is closed once the termination notice of the instance is received
+	terminating <-chan struct{}
 }
 
 func NewPusher(target Target, service CloudWatchLogsService, flushTimeout time.Duration, retryDuration time.Duration, logger telegraf.Logger) *pusher {
@@ -67,6 +105,7 @@ func NewPusher(target Target, service CloudWatchLogsService, flushTimeout time.D
 		eventsCh:        make(chan logs.LogEvent, 100),
 		flushTimer:      time.NewTimer(flushTimeout),
 		stop:            make(chan struct{}),
+		stopped:         make(chan struct{}),
 		startNonBlockCh: make(chan struct{}),
 	}
 	go p.start()
@@ -118,15 +157,36 @@ func hasValidTime(e logs.LogEvent) bool {
 	return true
 }
 
+// Priority returns the priority class of the log sources of the pusher
+func (p *pusher) Priority() string {
+	if priority, ok := p.priority.Load().(string); ok {
+		return priority
+	}
+	return logs.PriorityNormal
+}
+
+// SetPriority raises the priority class of the pusher to the one of a log source piped to it
+func (p *pusher) SetPriority(priority string) {
+	current, _ := p.priority.Load().(string)
+	p.priority.Store(logs.HigherPriority(current, priority))
+}
+
 func (p *pusher) Stop() {
 	close(p.stop)
 }
 
+// Stopped returns the channel closed once the pusher has sent the log events queued before it was stopped
+func (p *pusher) Stopped() <-chan struct{} {
+	return p.stopped
+}
+
 func (p *pusher) start() {
+	defer close(p.stopped)
 	ec := make(chan logs.LogEvent)
 
-	// Merge events from both blocking and non-blocking channel
+	// Merge events from both blocking and non-blocking channel, the events queued are still merged once stopped
 	go func() {
+		defer close(ec)
 		for {
 			select {
 			case e := <-p.eventsCh:
@@ -135,44 +195,61 @@ func (p *pusher) start() {
 				ec <- e
 			case <-p.startNonBlockCh:
 			case <-p.stop:
-				return
+				for {
+					select {
+					case e := <-p.eventsCh:
+						ec <- e
+					case e := <-p.nonBlockingEventsCh:
+						ec <- e
+					default:
+						return
+					}
+				}
 			}
 		}
 	}()
 
+	var reorderTick <-chan time.Time
 	for {
 		select {
-		case e := <-ec:
-			// Start timer when first event of the batch is added (happens after a flush timer timeout)
-			if len(p.events) == 0 {
-				p.resetFlushTimer()
-			}
-
-			ce := p.convertEvent(e)
-			et := time.Unix(*ce.Timestamp/1000, *ce.Timestamp%1000) // Cloudwatch Log Timestamp is in Millisecond
-
-			// A batch of log events in a single request cannot span more than 24 hours.
-			if (p.minT != nil && et.Sub(*p.minT) > 24*time.Hour) || (p.maxT != nil && p.maxT.Sub(et) > 24*time.Hour) {
-				p.send()
+		case e, ok := <-ec:
+			if !ok {
+				p.releaseReordered()
+				if len(p.events) > 0 {
+					p.send()
+				}
+				return
 			}
-
-			size := len(*ce.Message) + eventHeaderSize
-			if p.bufferredSize+size > reqSizeLimit || len(p.events) == reqEventsLimit {
-				p.send()
+			if p.reorder == nil || atomic.LoadInt32(&p.sources) < 2 {
+				// the events held while there were more sources go first
+				p.releaseReordered()
+				p.add(e)
+				break
 			}
-
-			if len(p.events) > 0 && *ce.Timestamp < *p.events[len(p.events)-1].Timestamp {
-				p.needSort = true
+			if reorderTick == nil {
+				ticker := time.NewTicker(reorderTickInterval(p.reorder.window))
+				defer ticker.Stop()
+				reorderTick = ticker.C
 			}
-
-			p.events = append(p.events, ce)
-			p.doneCallbacks = append(p.doneCallbacks, e.Done)
-			p.bufferredSize += size
-			if p.minT == nil || p.minT.After(et) {
-				p.minT = &et
-			}
-			if p.maxT == nil || p.maxT.Before(et) {
-				p.maxT = &et
+			p.reorder.push(e, time.Now())
+
+		case <-reorderTick:
+			// the held events count against the budget, they are not held while the sources wait for room
+			p.reorder.release(time.Now(), p.budget.underPressure(), p.add)
+
+		case <-p.terminating:
+			// flush what is buffered and every second after until the instance is gone
+			p.terminating = nil
+			p.FlushTimeout = terminatingFlushTimeout
+			notice := lifecycle.TerminationNotice()
+			p.releaseReordered()
+			p.add(&terminatingEvent{msg: fmt.Sprintf("The instance is terminating: %v", notice), t: time.Now()})
+			p.send()
+
+		case <-flush.Requested():
+			p.releaseReordered()
+			if len(p.events) > 0 {
+				p.send()
 			}
 
 		case <-p.flushTimer.C:
@@ -181,25 +258,92 @@ func (p *pusher) start() {
 			} else {
 				p.resetFlushTimer()
 			}
-		case <-p.stop:
-			if len(p.events) > 0 {
-				p.send()
-			}
-			return
 		}
 	}
 }
 
+// AddSource counts the sources writing to the stream, its log events are reordered once there are more than one
+func (p *pusher) AddSource() {
+	atomic.AddInt32(&p.sources, 1)
+}
+
+// RemoveSource stops counting a source which ended
+func (p *pusher) RemoveSource() {
+	atomic.AddInt32(&p.sources, -1)
+}
+
+// releaseReordered adds all the log events held for reordering to the batch
+func (p *pusher) releaseReordered() {
+	if p.reorder != nil {
+		p.reorder.release(time.Now(), true, p.add)
+	}
+}
+
+// reorderTickInterval is how often the held log events are checked, they are released at most a tick late
+func reorderTickInterval(window time.Duration) time.Duration {
+	if tick := window / 4; tick > 10*time.Millisecond {
+		return tick
+	}
+	return 10 * time.Millisecond
+}
+
+// add buffers the log event, sending the batch first when the event does not fit in it
+func (p *pusher) add(e logs.LogEvent) {
+	// Start timer when first event of the batch is added (happens after a flush timer timeout)
+	if len(p.events) == 0 {
+		p.resetFlushTimer()
+	}
+
+	ce := p.convertEvent(e)
+	et := time.Unix(*ce.Timestamp/1000, *ce.Timestamp%1000) // Cloudwatch Log Timestamp is in Millisecond
+
+	// A batch of log events in a single request cannot span more than 24 hours.
+	if (p.minT != nil && et.Sub(*p.minT) > 24*time.Hour) || (p.maxT != nil && p.maxT.Sub(et) > 24*time.Hour) {
+		p.send()
+	}
+
+	size := len(*ce.Message) + eventHeaderSize
+	if p.bufferredSize+size > reqSizeLimit || len(p.events) == reqEventsLimit {
+		p.send()
+	}
+
+	if len(p.events) > 0 && *ce.Timestamp < *p.events[len(p.events)-1].Timestamp {
+		p.needSort = true
+	}
+
+	p.events = append(p.events, ce)
+	p.bufferredSize += size
+	if be, ok := e.(*budgetedEvent); ok {
+		p.budgetedSize += be.size
+		e = be.LogEvent
+	}
+	// the batch acknowledges the log event of the source rather than the valid copy
+	if ve, ok := e.(*validEvent); ok {
+		e = ve.LogEvent
+	}
+	p.batch.Add(e)
+	if p.minT == nil || p.minT.After(et) {
+		p.minT = &et
+	}
+	if p.maxT == nil || p.maxT.Before(et) {
+		p.maxT = &et
+	}
+
+	// the critical log events do not wait for the flush interval when the budget is used up
+	if p.Priority() == logs.PriorityCritical && time.Since(p.lastSentTime) >= criticalPressureFlushInterval && p.budget.underPressure() {
+		p.send()
+	}
+}
+
 func (p *pusher) reset() {
 	for i := 0; i < len(p.events); i++ {
 		p.events[i] = nil
 	}
 	p.events = p.events[:0]
-	for i := 0; i < len(p.doneCallbacks); i++ {
-		p.doneCallbacks[i] = nil
-	}
-	p.doneCallbacks = p.doneCallbacks[:0]
+	p.batch.Reset()
 	p.bufferredSize = 0
+	p.budget.release(p.budgetedSize)
+	p.budgetedSize = 0
 	p.needSort = false
 	p.minT = nil
 	p.maxT = nil
@@ -241,13 +385,13 @@ func (p *pusher) send() {
 				}
 			}
 
-			for i := len(p.doneCallbacks) - 1; i >= 0; i-- {
-				done := p.doneCallbacks[i]
-				done()
-			}
+			// the checkpoints of the log sources only advance once the batch is accepted
+			p.batch.Ack()
 
 			p.Log.Debugf("Pusher published %v log events to group: %v stream: %v with size %v KB in %v.", len(p.events), p.Group, p.Stream, p.bufferredSize/1024, time.Since(startTime))
 			p.addStats("rawSize", float64(p.bufferredSize))
+			p.stats.Batch(len(p.events), p.bufferredSize, time.Since(startTime))
+			p.stats.Acknowledged(newestTimestamp(p.events))
 
 			p.reset()
 			p.lastSentTime = time.Now()
@@ -266,7 +410,10 @@ func (p *pusher) send() {
 		switch e := awsErr.(type) {
 		case *cloudwatchlogs.ResourceNotFoundException:
 			err := p.createLogGroupAndStream()
-			if err != nil {
+			if _, denied := err.(*createDeniedError); denied {
+				// the denial is explained once by the resource cache
+				p.Log.Debugf("Unable to create log stream %v/%v: %v", p.Group, p.Stream, err)
+			} else if err != nil {
 				p.Log.Errorf("Unable to create log stream %v/%v: %v", p.Group, p.Stream, e.Message())
 			}
 		case *cloudwatchlogs.InvalidSequenceTokenException:
@@ -281,6 +428,11 @@ func (p *pusher) send() {
 			p.reset()
 			return
 		default:
+			if awsErr.Code() == handlers.ErrCodeCircuitOpen {
+				// the events stay buffered, the breaker logs when it opens and closes
+				p.Log.Debugf("Not sending logs to %v/%v: %v", p.Group, p.Stream, awsErr)
+				break
+			}
 			p.Log.Errorf("Aws error received when sending logs to %v/%v: %v", p.Group, p.Stream, awsErr)
 		}
 
@@ -307,25 +459,32 @@ func retryWait(n int) time.Duration {
 }
 
 func (p *pusher) createLogGroupAndStream() error {
-	_, err := p.Service.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
-		LogGroupName:  &p.Group,
-		LogStreamName: &p.Stream,
-	})
+	startTime := time.Now()
+	err := p.createLogStream()
 
 	if err != nil {
 		p.Log.Debugf("creating stream fail due to : %v \n", err)
 		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
-			_, err = p.Service.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
-				LogGroupName: &p.Group,
-			})
+			// the group is not created again when another pusher created it in the meantime
+			if !p.resources.groupExistsSince(p.Group, startTime) {
+				err = p.resources.create(opCreateLogGroup, p.Group, "", func() error {
+					_, err := p.Service.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
+						LogGroupName: &p.Group,
+					})
+					return err
+				})
+				if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cloudwatchlogs.ErrCodeResourceAlreadyExistsException {
+					err = nil
+				}
+			} else {
+				err = nil
+			}
 
 			// create stream again if group created successfully.
 			if err == nil {
-				_, err = p.Service.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
-					LogGroupName:  &p.Group,
-					LogStreamName: &p.Stream,
-				})
-			} else {
+				p.resources.groupExists(p.Group)
+				err = p.createLogStream()
+			} else if _, ok := err.(*createDeniedError); !ok {
 				p.Log.Errorf("creating group fail due to : %v \n", err)
 			}
 		}
@@ -334,17 +493,30 @@ func (p *pusher) createLogGroupAndStream() error {
 	return err
 }
 
+func (p *pusher) createLogStream() error {
+	err := p.resources.create(opCreateLogStream, p.Group, p.Stream, func() error {
+		_, err := p.Service.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  &p.Group,
+			LogStreamName: &p.Stream,
+		})
+		return err
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cloudwatchlogs.ErrCodeResourceAlreadyExistsException {
+		err = nil
+	}
+	if err == nil {
+		p.resources.groupExists(p.Group)
+	}
+	return err
+}
+
 func (p *pusher) resetFlushTimer() {
 	p.flushTimer.Stop()
 	p.flushTimer.Reset(p.FlushTimeout)
 }
 
 func (p *pusher) convertEvent(e logs.LogEvent) *cloudwatchlogs.InputLogEvent {
-	message := e.Message()
-
-	if len(message) > msgSizeLimit {
-		message = message[:msgSizeLimit-len(truncatedSuffix)] + truncatedSuffix
-	}
+	message := truncateMessage(e.Message())
 	var t int64
 	if e.Time().IsZero() {
 		if p.lastValidTime != 0 {
@@ -359,6 +531,17 @@ func (p *pusher) convertEvent(e logs.LogEvent) *cloudwatchlogs.InputLogEvent {
 		t = e.Time().UnixNano() / 1000000
 		p.lastValidTime = t
 	}
+	if skew := p.clockSkew.Skew(); skew != 0 {
+		t += int64(skew / time.Millisecond)
+		p.addStats("clockSkewCorrected", 1)
+	}
+	if p.monotonic {
+		if t <= p.lastTimestamp {
+			t = p.lastTimestamp + 1
+			p.addStats("timestampCorrected", 1)
+		}
+		p.lastTimestamp = t
+	}
 	return &cloudwatchlogs.InputLogEvent{
 		Message:   &message,
 		Timestamp: &t,
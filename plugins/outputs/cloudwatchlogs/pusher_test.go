@@ -11,9 +11,11 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-cloudwatch-agent/logs"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
@@ -143,6 +145,30 @@ func TestStopPusherWouldDoFinalSend(t *testing.T) {
 	}
 }
 
+func TestStopPusherSendsQueuedEvents(t *testing.T) {
+	var s svcMock
+	var sent int32
+	s.ple = func(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+		atomic.AddInt32(&sent, int32(len(in.LogEvents)))
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}
+
+	p := NewPusher(Target{"G", "S"}, &s, 1*time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	for i := 0; i < 50; i++ {
+		p.AddEvent(evtMock{fmt.Sprintf("MSG %v", i), time.Now(), nil})
+	}
+	// the events still queued when stopped are sent too
+	p.Stop()
+	select {
+	case <-p.Stopped():
+	case <-time.After(time.Second):
+		t.Fatal("the pusher has not stopped")
+	}
+	if n := atomic.LoadInt32(&sent); n != 50 {
+		t.Errorf("%v log events sent once stopped, expecting 50", n)
+	}
+}
+
 func TestLongMessageGetsTruncated(t *testing.T) {
 	var s svcMock
 	nst := "NEXT_SEQ_TOKEN"
@@ -672,3 +698,96 @@ func TestAddEventNonBlocking(t *testing.T) {
 	}
 	p.Stop()
 }
+
+type ackerMock struct {
+	acked chan []logs.LogEvent
+}
+
+func (a *ackerMock) Ack(events []logs.LogEvent) { a.acked <- events }
+
+type ackedEvtMock struct {
+	evtMock
+	a logs.Acker
+}
+
+func (e ackedEvtMock) Acker() logs.Acker { return e.a }
+
+func TestAckBatchOnceAccepted(t *testing.T) {
+	var s svcMock
+	accepted := false
+	s.ple = func(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+		if !accepted {
+			accepted = true
+			return nil, awserr.New("SomeError", "Some error", nil)
+		}
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}
+
+	a := &ackerMock{acked: make(chan []logs.LogEvent, 1)}
+	p := NewPusher(Target{"G", "S"}, &s, 10*time.Millisecond, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p.AddEvent(ackedEvtMock{evtMock{"MSG1", time.Now(), func() { t.Errorf("Done called on an acked event") }}, a})
+	p.AddEvent(ackedEvtMock{evtMock{"MSG2", time.Now(), nil}, a})
+
+	select {
+	case events := <-a.acked:
+		if len(events) != 2 || events[0].Message() != "MSG1" || events[1].Message() != "MSG2" {
+			t.Errorf("Wrong log events acked: %v", events)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("The batch was not acked once accepted")
+	}
+	p.Stop()
+}
+
+func TestMonotonicTimestamps(t *testing.T) {
+	p := &pusher{monotonic: true}
+	now := time.Unix(1600000000, 0)
+	ms := now.UnixNano() / 1000000
+	events := []time.Time{now, now, now.Add(-time.Second), now.Add(time.Second), {}}
+	expected := []int64{ms, ms + 1, ms + 2, ms + 1000, ms + 1001}
+	for i, et := range events {
+		if ts := *p.convertEvent(evtMock{"MSG", et, nil}).Timestamp; ts != expected[i] {
+			t.Errorf("Wrong timestamp of log event %v: %v, expecting %v", i, ts, expected[i])
+		}
+	}
+
+	p = &pusher{}
+	p.convertEvent(evtMock{"MSG", now, nil})
+	if ts := *p.convertEvent(evtMock{"MSG", now.Add(-time.Second), nil}).Timestamp; ts != ms-1000 {
+		t.Errorf("Timestamp corrected without monotonic_timestamps: %v", ts)
+	}
+}
+
+func TestFlushOnTerminationNotice(t *testing.T) {
+	var s svcMock
+	sent := make(chan []string, 1)
+	s.ple = func(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+		var msgs []string
+		for _, e := range in.LogEvents {
+			msgs = append(msgs, *e.Message)
+		}
+		sent <- msgs
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}
+
+	terminating := make(chan struct{})
+	p := NewPusher(Target{"G", "S"}, &s, 1*time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p.terminating = terminating
+	defer p.Stop()
+	p.AddEvent(evtMock{"MSG", time.Now(), nil})
+	time.Sleep(10 * time.Millisecond)
+	close(terminating)
+
+	select {
+	case events := <-sent:
+		if len(events) != 2 || events[0] != "MSG" || !strings.HasPrefix(events[1], "The instance is terminating") {
+			t.Errorf("Wrong log events sent on the termination notice: %v", events)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("The log events are not sent on the termination notice")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if p.FlushTimeout != terminatingFlushTimeout {
+		t.Errorf("Wrong flush timeout once the instance is terminating: %v", p.FlushTimeout)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+)
+
+// reorderBuffer holds the log events of a stream written by several sources for the reorder window, and releases
+// them by timestamp, so the events of the sources are merged in order across the batches rather than only within
+// each batch. No event is held longer than the window: once the event which arrived first was held for the window,
+// it is released along with the events held with an earlier timestamp. An event arriving later than the window behind
+// the others is still sent but out of order.
+type reorderBuffer struct {
+	window time.Duration
+	events reorderHeap
+	// arrivals are the events in the order they arrived, the released ones are skipped
+	arrivals []*reorderedEvent
+	seq      int64
+}
+
+type reorderedEvent struct {
+	logs.LogEvent
+	t       time.Time
+	arrival time.Time
+	// seq keeps the events with the same timestamp in the order they arrived
+	seq      int64
+	released bool
+}
+
+type reorderHeap []*reorderedEvent
+
+func (h reorderHeap) Len() int { return len(h) }
+func (h reorderHeap) Less(i, j int) bool {
+	if h[i].t.Equal(h[j].t) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].t.Before(h[j].t)
+}
+func (h reorderHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *reorderHeap) Push(x interface{}) { *h = append(*h, x.(*reorderedEvent)) }
+func (h *reorderHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+func newReorderBuffer(window time.Duration) *reorderBuffer {
+	return &reorderBuffer{window: window}
+}
+
+// push holds the event, the events without a timestamp are ordered by their arrival
+func (b *reorderBuffer) push(e logs.LogEvent, now time.Time) {
+	t := e.Time()
+	if t.IsZero() {
+		t = now
+	}
+	b.seq++
+	re := &reorderedEvent{LogEvent: e, t: t, arrival: now, seq: b.seq}
+	heap.Push(&b.events, re)
+	b.arrivals = append(b.arrivals, re)
+}
+
+// release passes the events due at now to add in timestamp order, all of them when all is set
+func (b *reorderBuffer) release(now time.Time, all bool, add func(logs.LogEvent)) {
+	for b.events.Len() > 0 && (all || b.due(now)) {
+		e := heap.Pop(&b.events).(*reorderedEvent)
+		e.released = true
+		add(e.LogEvent)
+	}
+	b.due(now)
+}
+
+// due returns whether the event held the longest was held for the window, after skipping the released ones
+func (b *reorderBuffer) due(now time.Time) bool {
+	for len(b.arrivals) > 0 && b.arrivals[0].released {
+		b.arrivals[0] = nil
+		b.arrivals = b.arrivals[1:]
+	}
+	return len(b.arrivals) > 0 && now.Sub(b.arrivals[0].arrival) >= b.window
+}
+
+func (b *reorderBuffer) len() int {
+	return b.events.Len()
+}
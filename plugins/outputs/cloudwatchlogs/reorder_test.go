@@ -0,0 +1,119 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorderBuffer(t *testing.T) {
+	b := newReorderBuffer(time.Second)
+	now := time.Now()
+	var released []string
+	add := func(e logs.LogEvent) { released = append(released, e.Message()) }
+
+	b.push(evtMock{m: "b1", t: now.Add(-2 * time.Second)}, now)
+	b.push(evtMock{m: "a1", t: now.Add(-3 * time.Second)}, now.Add(100*time.Millisecond))
+	b.push(evtMock{m: "b2", t: now.Add(-2 * time.Second)}, now.Add(200*time.Millisecond))
+	b.push(evtMock{m: "a2", t: now.Add(-time.Second)}, now.Add(900*time.Millisecond))
+
+	b.release(now.Add(500*time.Millisecond), false, add)
+	assert.Empty(t, released, "the events are held for the window")
+
+	// a2 arrived less than the window ago
+	b.release(now.Add(1200*time.Millisecond), false, add)
+	assert.Equal(t, []string{"a1", "b1", "b2"}, released)
+	assert.Equal(t, 1, b.len())
+
+	b.push(evtMock{m: "none", t: time.Time{}}, now.Add(1300*time.Millisecond))
+	b.release(now, true, add)
+	assert.Equal(t, []string{"a1", "b1", "b2", "a2", "none"}, released)
+	assert.Equal(t, 0, b.len())
+}
+
+func TestReorderBufferMaxHold(t *testing.T) {
+	b := newReorderBuffer(time.Second)
+	now := time.Now()
+	var released []string
+	add := func(e logs.LogEvent) { released = append(released, e.Message()) }
+
+	// the events arriving with an earlier timestamp do not hold back the event which arrived first
+	b.push(evtMock{m: "a", t: now.Add(-time.Second)}, now)
+	for i := 1; i <= 10; i++ {
+		b.push(evtMock{m: "b", t: now.Add(-time.Duration(i) * time.Second)}, now.Add(time.Duration(i)*90*time.Millisecond))
+		b.release(now.Add(time.Duration(i)*90*time.Millisecond), false, add)
+	}
+	b.release(now.Add(time.Second), false, add)
+	assert.Equal(t, []string{"b", "b", "b", "b", "b", "b", "b", "b", "b", "a"}, released)
+	assert.Equal(t, 1, b.len())
+}
+
+// newReorderPusher returns a pusher reordering the events of two sources, and the channel of the messages it sends
+func newReorderPusher(window time.Duration, budget *memoryBudget) (*pusher, chan []string) {
+	var s svcMock
+	sent := make(chan []string, 10)
+	s.ple = func(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+		var msgs []string
+		for _, e := range in.LogEvents {
+			msgs = append(msgs, *e.Message)
+		}
+		sent <- msgs
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}
+	p := NewPusher(Target{"G", "S"}, &s, 10*time.Millisecond, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p.reorder = newReorderBuffer(window)
+	p.budget = budget
+	p.AddSource()
+	p.AddSource()
+	return p, sent
+}
+
+func TestReorderReleasedUnderPressure(t *testing.T) {
+	budget := newMemoryBudget(1, "")
+	p, sent := newReorderPusher(4*time.Second, budget)
+	defer p.Stop()
+
+	p.AddEvent(budget.admit(evtMock{m: "held", t: time.Now()}, logs.PriorityNormal))
+	budget.mu.Lock()
+	assert.NotZero(t, budget.used, "the held events count against the budget")
+	budget.mu.Unlock()
+
+	// the budget is used up, the event is released before the window
+	select {
+	case msgs := <-sent:
+		assert.Equal(t, []string{"held"}, msgs)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the held events are not released under pressure")
+	}
+	require.Eventually(t, func() bool { return !budget.underPressure() }, time.Second, 10*time.Millisecond)
+}
+
+func TestReorderSourceRemoved(t *testing.T) {
+	p, sent := newReorderPusher(time.Hour, nil)
+	defer p.Stop()
+
+	p.AddEvent(evtMock{m: "held", t: time.Now()})
+	p.RemoveSource()
+	p.AddEvent(evtMock{m: "direct", t: time.Now()})
+
+	// the events are not reordered with a single source, the ones held go first
+	select {
+	case msgs := <-sent:
+		assert.Equal(t, []string{"held", "direct"}, msgs)
+	case <-time.After(time.Second):
+		t.Fatal("the events are still held once a single source is left")
+	}
+}
+
+func TestReorderTickInterval(t *testing.T) {
+	assert.Equal(t, 500*time.Millisecond, reorderTickInterval(2*time.Second))
+	assert.Equal(t, 10*time.Millisecond, reorderTickInterval(time.Millisecond))
+}
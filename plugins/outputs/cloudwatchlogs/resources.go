@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+const (
+	errCodeAccessDenied = "AccessDeniedException"
+
+	opCreateLogGroup  = "CreateLogGroup"
+	opCreateLogStream = "CreateLogStream"
+
+	// the creations denied by IAM are tried again after these delays, doubling every time they are denied again
+	createDeniedMinBackoff = time.Minute
+	createDeniedMaxBackoff = time.Hour
+)
+
+// createDeniedError is returned without calling CloudWatch Logs while the creation is denied and backing off
+type createDeniedError struct {
+	op, resource string
+	until        time.Time
+	err          error
+}
+
+func (e *createDeniedError) Error() string {
+	return fmt.Sprintf("%v of %v is denied, not trying again until %v: %v", e.op, e.resource, e.until.Format(time.RFC3339), e.err)
+}
+
+type createDenial struct {
+	until   time.Time
+	backoff time.Duration
+	err     error
+}
+
+// resourceCache is shared by the pushers of the plugin, so the log groups created or found by one of them are not
+// created again by the others, and the creations denied by IAM are not tried again with every batch of every stream.
+// The creations of the log streams are denied by the log group and the stream, since the policies can grant them for
+// some of the streams of a group only, e.g. arn:aws:logs:*:*:log-group:G:log-stream:app-*, and the denial of a
+// stream must not hold back the others.
+type resourceCache struct {
+	mu sync.Mutex
+	// groups are the log groups known to exist, by the time they were last known to
+	groups  map[string]time.Time
+	denials map[string]*createDenial
+	// diagnosed are the denials already explained in the log
+	diagnosed map[string]bool
+	now       func() time.Time
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{
+		groups:    make(map[string]time.Time),
+		denials:   make(map[string]*createDenial),
+		diagnosed: make(map[string]bool),
+		now:       time.Now,
+	}
+}
+
+// groupExistsSince returns whether the log group was known to exist at or after the time, e.g. it was created by
+// another pusher since
+func (c *resourceCache) groupExistsSince(group string, since time.Time) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.groups[group]
+	return ok && !t.Before(since)
+}
+
+func (c *resourceCache) groupExists(group string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups[group] = c.now()
+}
+
+// create runs the creation of the log group, or of the log stream when stream is not empty, unless it is backing
+// off from being denied. The denials are backed off exponentially and the first one of every creation is explained
+// in the log.
+func (c *resourceCache) create(op, group, stream string, fn func() error) error {
+	if c == nil {
+		return fn()
+	}
+	resource := "log group " + group
+	if stream != "" {
+		resource = "log stream " + group + "/" + stream
+	}
+	key := op + " " + resource
+	c.mu.Lock()
+	if d, ok := c.denials[key]; ok && c.now().Before(d.until) {
+		c.mu.Unlock()
+		return &createDeniedError{op: op, resource: resource, until: d.until, err: d.err}
+	}
+	c.mu.Unlock()
+
+	err := fn()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != errCodeAccessDenied {
+		delete(c.denials, key)
+		return err
+	}
+	d, ok := c.denials[key]
+	if !ok {
+		d = &createDenial{backoff: createDeniedMinBackoff}
+		c.denials[key] = d
+	} else if d.backoff *= 2; d.backoff > createDeniedMaxBackoff {
+		d.backoff = createDeniedMaxBackoff
+	}
+	d.until, d.err = c.now().Add(d.backoff), err
+	if !c.diagnosed[key] {
+		c.diagnosed[key] = true
+		log.Printf("E! cloudwatchlogs: %v of %v is denied: %v. Grant logs:%v on the log group to the IAM "+
+			"role or user of the agent, or create the log group and its log streams beforehand. The log events "+
+			"stay buffered, the creation is tried again after %v and then less and less often, up to every %v.",
+			op, resource, err, op, createDeniedMinBackoff, createDeniedMaxBackoff)
+	}
+	return err
+}
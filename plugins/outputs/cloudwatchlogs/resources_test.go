@@ -0,0 +1,132 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceCacheBacksOffDeniedCreations(t *testing.T) {
+	c := newResourceCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var s svcMock
+	var cls int
+	s.cls = func(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+		cls++
+		return nil, awserr.New(errCodeAccessDenied, "not authorized to perform: logs:CreateLogStream", nil)
+	}
+	p1 := NewPusher(Target{"G", "S1"}, &s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p2 := NewPusher(Target{"G", "S2"}, &s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	defer p1.Stop()
+	defer p2.Stop()
+	p1.resources, p2.resources = c, c
+
+	err, ok := p1.createLogGroupAndStream().(awserr.Error)
+	assert.True(t, ok)
+	assert.Equal(t, errCodeAccessDenied, err.Code())
+	_, denied := p1.createLogGroupAndStream().(*createDeniedError)
+	assert.True(t, denied)
+	assert.Equal(t, 1, cls)
+
+	now = now.Add(createDeniedMinBackoff)
+	p1.createLogGroupAndStream()
+	assert.Equal(t, 2, cls)
+	now = now.Add(createDeniedMinBackoff)
+	p1.createLogGroupAndStream()
+	assert.Equal(t, 2, cls, "the backoff doubles")
+	assert.Equal(t, 2*createDeniedMinBackoff, c.denials[opCreateLogStream+" log stream G/S1"].backoff)
+
+	// the streams of the group the creation is allowed for are not held back by the denied one
+	s.cls = func(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+		cls++
+		if *in.LogStreamName == "S1" {
+			return nil, awserr.New(errCodeAccessDenied, "not authorized to perform: logs:CreateLogStream", nil)
+		}
+		return nil, nil
+	}
+	assert.NoError(t, p2.createLogGroupAndStream())
+	assert.Equal(t, 3, cls)
+
+	// the denial is cleared once the creation is allowed
+	s.cls = func(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+		cls++
+		return nil, nil
+	}
+	now = now.Add(2 * createDeniedMinBackoff)
+	assert.NoError(t, p1.createLogGroupAndStream())
+	assert.Equal(t, 4, cls)
+	assert.Empty(t, c.denials)
+}
+
+func TestResourceCacheSharesGroupDenials(t *testing.T) {
+	c := newResourceCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var s svcMock
+	var clg int
+	s.cls = func(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+		return nil, awserr.New(cloudwatchlogs.ErrCodeResourceNotFoundException, "", nil)
+	}
+	s.clg = func(in *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+		clg++
+		return nil, awserr.New(errCodeAccessDenied, "not authorized to perform: logs:CreateLogGroup", nil)
+	}
+	p1 := NewPusher(Target{"G", "S1"}, &s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p2 := NewPusher(Target{"G", "S2"}, &s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	defer p1.Stop()
+	defer p2.Stop()
+	p1.resources, p2.resources = c, c
+
+	assert.Error(t, p1.createLogGroupAndStream())
+	_, denied := p2.createLogGroupAndStream().(*createDeniedError)
+	assert.True(t, denied, "the streams of the group share the denial of the group")
+	assert.Equal(t, 1, clg)
+}
+
+func TestResourceCacheSharesGroups(t *testing.T) {
+	c := newResourceCache()
+	var s svcMock
+	var clg int
+	s.clg = func(in *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+		clg++
+		return nil, nil
+	}
+	p := NewPusher(Target{"G", "S"}, &s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	defer p.Stop()
+	p.resources = c
+
+	// another pusher creates the group while the stream is created
+	created := false
+	s.cls = func(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+		if !created {
+			created = true
+			c.groupExists("G")
+			return nil, awserr.New(cloudwatchlogs.ErrCodeResourceNotFoundException, "", nil)
+		}
+		return nil, nil
+	}
+	assert.NoError(t, p.createLogGroupAndStream())
+	assert.Equal(t, 0, clg)
+
+	// the group was deleted since it was known to exist
+	created = false
+	s.cls = func(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+		if !created {
+			created = true
+			return nil, awserr.New(cloudwatchlogs.ErrCodeResourceNotFoundException, "", nil)
+		}
+		return nil, nil
+	}
+	assert.NoError(t, p.createLogGroupAndStream())
+	assert.Equal(t, 1, clg)
+}
@@ -0,0 +1,268 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/publishstats"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	cwlv2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const (
+	// sdkVersionV1 sends the log events through the aws-sdk-go client, the default
+	sdkVersionV1 = "v1"
+	// sdkVersionV2 sends them through the aws-sdk-go-v2 client, with the adaptive retries
+	sdkVersionV2 = "v2"
+)
+
+// emfFormatter is a service which can mark the log events it sends as EMF, the SDK v1 client is given the header
+// handler instead
+type emfFormatter interface {
+	setEMFFormat()
+}
+
+// sdkV2Service is the CloudWatchLogsService of the aws-sdk-go-v2 client. It takes and returns the SDK v1 types, and
+// the errors of the calls are the SDK v1 ones, so that the pusher handles both clients the same way.
+//
+// The SDK retries the throttled calls with the adaptive mode, which slows the client down until they succeed. The
+// requests are compressed, signed with SigV4 and sent with the agent's User-Agent as with the SDK v1 client, the
+// sampling of the payloads and the circuit breaker are only available with the latter.
+type sdkV2Service struct {
+	client *cwlv2.Client
+	emf    int32
+	// the options of every call, and the ones of PutLogEvents
+	options    []func(*cwlv2.Options)
+	putOptions []func(*cwlv2.Options)
+}
+
+// newSDKV2Service returns the service calling the endpoint of the config, resolved by the SDK v2 for the region
+// when the config has none, with the credentials of the SDK v1
+func newSDKV2Service(t Target, region string, config *awsv1.Config, creds *credentials.Credentials, userAgent string, limiter *apiLimiter, priority func() string, skew *clockSkew) *sdkV2Service {
+	options := cwlv2.Options{
+		Region:      region,
+		Credentials: v1CredentialsProvider{creds: creds},
+		HTTPClient:  http.DefaultClient,
+		Retryer:     retry.NewAdaptiveMode(),
+	}
+	if config.HTTPClient != nil {
+		options.HTTPClient = config.HTTPClient
+	}
+	if endpoint := awsv1.StringValue(config.Endpoint); endpoint != "" {
+		signingRegion := awsv1.StringValue(config.Region)
+		options.EndpointResolver = cwlv2.EndpointResolverFromURL(endpoint, func(e *aws.Endpoint) {
+			if signingRegion != "" {
+				e.SigningRegion = signingRegion
+			}
+		})
+	}
+
+	s := &sdkV2Service{client: cwlv2.New(options)}
+	s.options = append(s.options, cwlv2.WithAPIOptions(
+		finalizeHeader("UserAgentHeader", "User-Agent", func() string { return userAgent }),
+		finalizeHeader("EMFFormatHeader", "x-amzn-logs-format", func() string {
+			if atomic.LoadInt32(&s.emf) == 0 {
+				return ""
+			}
+			return "json/emf"
+		}),
+	))
+	if limiter != nil {
+		s.options = append(s.options, cwlv2.WithAPIOptions(limiter.middleware(priority)))
+	}
+	if skew != nil {
+		s.options = append(s.options, cwlv2.WithAPIOptions(skew.addMiddleware))
+	}
+	s.putOptions = append(append(s.putOptions, s.options...), cwlv2.WithAPIOptions(compressionMiddleware(t)))
+	return s
+}
+
+func (s *sdkV2Service) setEMFFormat() {
+	atomic.StoreInt32(&s.emf, 1)
+}
+
+func (s *sdkV2Service) PutLogEvents(input *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	events := make([]types.InputLogEvent, len(input.LogEvents))
+	for i, e := range input.LogEvents {
+		events[i] = types.InputLogEvent{Message: e.Message, Timestamp: e.Timestamp}
+	}
+	out, err := s.client.PutLogEvents(context.Background(), &cwlv2.PutLogEventsInput{
+		LogEvents:     events,
+		LogGroupName:  input.LogGroupName,
+		LogStreamName: input.LogStreamName,
+		SequenceToken: input.SequenceToken,
+	}, s.putOptions...)
+	if err != nil {
+		return nil, sdkV1Error(err)
+	}
+	output := &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: out.NextSequenceToken}
+	if info := out.RejectedLogEventsInfo; info != nil {
+		output.RejectedLogEventsInfo = &cloudwatchlogs.RejectedLogEventsInfo{
+			ExpiredLogEventEndIndex:  int64Ptr(info.ExpiredLogEventEndIndex),
+			TooNewLogEventStartIndex: int64Ptr(info.TooNewLogEventStartIndex),
+			TooOldLogEventEndIndex:   int64Ptr(info.TooOldLogEventEndIndex),
+		}
+	}
+	return output, nil
+}
+
+func (s *sdkV2Service) CreateLogStream(input *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	_, err := s.client.CreateLogStream(context.Background(), &cwlv2.CreateLogStreamInput{
+		LogGroupName:  input.LogGroupName,
+		LogStreamName: input.LogStreamName,
+	}, s.options...)
+	if err != nil {
+		return nil, sdkV1Error(err)
+	}
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (s *sdkV2Service) CreateLogGroup(input *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	_, err := s.client.CreateLogGroup(context.Background(), &cwlv2.CreateLogGroupInput{
+		LogGroupName: input.LogGroupName,
+		KmsKeyId:     input.KmsKeyId,
+		Tags:         awsv1.StringValueMap(input.Tags),
+	}, s.options...)
+	if err != nil {
+		return nil, sdkV1Error(err)
+	}
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func int64Ptr(v *int32) *int64 {
+	if v == nil {
+		return nil
+	}
+	return awsv1.Int64(int64(*v))
+}
+
+// sdkV1Error returns the SDK v1 error of the SDK v2 one, the exceptions handled by the pusher are converted to their
+// SDK v1 types and the other API errors keep their code. The requests which got no response are request errors.
+func sdkV1Error(err error) error {
+	var notFound *types.ResourceNotFoundException
+	var invalidToken *types.InvalidSequenceTokenException
+	var invalidParameter *types.InvalidParameterException
+	var alreadyAccepted *types.DataAlreadyAcceptedException
+	var apiErr smithy.APIError
+	switch {
+	case errors.As(err, &notFound):
+		return &cloudwatchlogs.ResourceNotFoundException{Message_: notFound.Message}
+	case errors.As(err, &invalidToken):
+		return &cloudwatchlogs.InvalidSequenceTokenException{Message_: invalidToken.Message, ExpectedSequenceToken: invalidToken.ExpectedSequenceToken}
+	case errors.As(err, &invalidParameter):
+		return &cloudwatchlogs.InvalidParameterException{Message_: invalidParameter.Message}
+	case errors.As(err, &alreadyAccepted):
+		return &cloudwatchlogs.DataAlreadyAcceptedException{Message_: alreadyAccepted.Message, ExpectedSequenceToken: alreadyAccepted.ExpectedSequenceToken}
+	case errors.As(err, &apiErr):
+		return awserr.New(apiErr.ErrorCode(), apiErr.ErrorMessage(), err)
+	}
+	return awserr.New(request.ErrCodeRequestError, "send request failed", err)
+}
+
+// v1CredentialsProvider provides the SDK v1 credentials to the SDK v2 client, they are cached and renewed by the
+// former
+type v1CredentialsProvider struct {
+	creds *credentials.Credentials
+}
+
+func (p v1CredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	v, err := p.creds.GetWithContext(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	c := aws.Credentials{
+		AccessKeyID:     v.AccessKeyID,
+		SecretAccessKey: v.SecretAccessKey,
+		SessionToken:    v.SessionToken,
+		Source:          v.ProviderName,
+	}
+	if expires, err := p.creds.ExpiresAt(); err == nil {
+		c.CanExpire, c.Expires = true, expires
+	}
+	return c, nil
+}
+
+// finalizeHeader sets the header of the requests to the value, unless it is empty
+func finalizeHeader(id, header string, value func() string) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc(id, func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				if v := value(); v != "" {
+					req.Header.Set(header, v)
+				}
+			}
+			return next.HandleFinalize(ctx, in)
+		}), middleware.Before)
+	}
+}
+
+// compressionMiddleware gzips the payloads when they get smaller, before they are signed, and records the sizes of
+// the log events sent to the target as the publish stats handlers of the SDK v1 client
+func compressionMiddleware(t Target) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("RequestCompression", func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+			req, ok := in.Request.(*smithyhttp.Request)
+			if !ok || req.GetStream() == nil {
+				return next.HandleBuild(ctx, in)
+			}
+			payload, err := ioutil.ReadAll(req.GetStream())
+			if err != nil {
+				return middleware.BuildOutput{}, middleware.Metadata{}, err
+			}
+			body := payload
+			buf := new(bytes.Buffer)
+			g := gzip.NewWriter(buf)
+			if _, err := g.Write(payload); err == nil && g.Close() == nil && buf.Len() < len(payload) {
+				body = buf.Bytes()
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+			if req, err = req.SetStream(bytes.NewReader(body)); err != nil {
+				return middleware.BuildOutput{}, middleware.Metadata{}, err
+			}
+			req.ContentLength = int64(len(body))
+			in.Request = req
+
+			out, metadata, err := next.HandleBuild(ctx, in)
+			if err == nil {
+				publishstats.Get(publishStatsName(t)).Sent(int64(len(payload)), int64(len(body)))
+			}
+			return out, metadata, err
+		}), middleware.After)
+	}
+}
+
+// middleware makes every attempt of the calls of the SDK v2 client wait for a token, as the handlers of the SDK
+// v1 client. It is inside the retries, which the adaptive mode slows down on its own.
+func (l *apiLimiter) middleware(priority func() string) func(stack *middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("APIRateLimit", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			l.wait(priority())
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			var apiErr smithy.APIError
+			if err == nil {
+				l.succeeded()
+			} else if errors.As(err, &apiErr) && apiErr.ErrorCode() == errCodeThrottling {
+				l.throttled()
+			}
+			return out, metadata, err
+		}), middleware.After)
+	}
+}
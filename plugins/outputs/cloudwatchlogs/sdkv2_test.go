@@ -0,0 +1,67 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSDKV1Error(t *testing.T) {
+	err := sdkV1Error(&types.InvalidSequenceTokenException{Message: aws.String("invalid"), ExpectedSequenceToken: aws.String("42")})
+	require.IsType(t, &cloudwatchlogs.InvalidSequenceTokenException{}, err)
+	assert.Equal(t, "42", aws.StringValue(err.(*cloudwatchlogs.InvalidSequenceTokenException).ExpectedSequenceToken))
+
+	assert.IsType(t, &cloudwatchlogs.ResourceNotFoundException{}, sdkV1Error(&types.ResourceNotFoundException{}))
+	assert.IsType(t, &cloudwatchlogs.InvalidParameterException{}, sdkV1Error(&types.InvalidParameterException{}))
+	assert.IsType(t, &cloudwatchlogs.DataAlreadyAcceptedException{}, sdkV1Error(&types.DataAlreadyAcceptedException{}))
+
+	err = sdkV1Error(&smithy.GenericAPIError{Code: errCodeAccessDenied, Message: "denied"})
+	assert.True(t, isAWSErrCode(err, errCodeAccessDenied))
+	assert.True(t, isAWSErrCode(sdkV1Error(errors.New("connection refused")), request.ErrCodeRequestError))
+}
+
+func isAWSErrCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+func TestSDKV2ServiceHeaders(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"nextSequenceToken": "2", "rejectedLogEventsInfo": {"tooOldLogEventEndIndex": 1}}`))
+	}))
+	defer server.Close()
+
+	config := &aws.Config{Endpoint: aws.String(server.URL)}
+	s := newSDKV2Service(Target{Group: "G", Stream: "S"}, "us-east-1", config,
+		credentials.NewStaticCredentials("AKID", "SECRET", ""), "agent/1.0", nil, nil, nil)
+	s.setEMFFormat()
+	output, err := s.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String("G"),
+		LogStreamName: aws.String("S"),
+		LogEvents:     []*cloudwatchlogs.InputLogEvent{{Message: aws.String(string(make([]byte, 1024))), Timestamp: aws.Int64(1)}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "2", aws.StringValue(output.NextSequenceToken))
+	assert.Equal(t, int64(1), aws.Int64Value(output.RejectedLogEventsInfo.TooOldLogEventEndIndex))
+	assert.Equal(t, "agent/1.0", headers.Get("User-Agent"))
+	assert.Equal(t, "json/emf", headers.Get("x-amzn-logs-format"))
+	assert.Equal(t, "gzip", headers.Get("Content-Encoding"))
+	assert.Contains(t, headers.Get("Authorization"), "Credential=AKID/")
+}
@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import "time"
+
+// terminatingFlushTimeout is how often the pushers send once the termination notice of the instance is received,
+// rather than every flush interval
+const terminatingFlushTimeout = time.Second
+
+// terminatingEvent is the final log event of the stream telling the instance is terminating
+type terminatingEvent struct {
+	msg string
+	t   time.Time
+}
+
+func (e *terminatingEvent) Message() string {
+	return e.msg
+}
+
+func (e *terminatingEvent) Time() time.Time {
+	return e.t
+}
+
+func (e *terminatingEvent) Done() {}
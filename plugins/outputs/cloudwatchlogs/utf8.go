@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"encoding/base64"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+)
+
+const (
+	// invalidUTF8Replace replaces the invalid byte sequences of the messages with U+FFFD, the default
+	invalidUTF8Replace = "replace"
+	// invalidUTF8Drop drops the log events whose message is not valid UTF-8
+	invalidUTF8Drop = "drop"
+	// invalidUTF8Base64 sends the messages which are not valid UTF-8 base64 encoded, in a JSON object of the
+	// base64Key, so that their bytes can be recovered
+	invalidUTF8Base64 = "base64"
+
+	base64Key = "invalid_utf8_base64"
+)
+
+// base64Overhead is the size of the JSON object around the base64 encoded message
+var base64Overhead = len(`{"` + base64Key + `":""}`)
+
+// validUTF8 returns the message of the log event as it is sent to CloudWatch Logs, which rejects the requests of
+// the messages which are not valid UTF-8, or false when the log event is dropped by the policy
+func validUTF8(message, policy string) (string, bool) {
+	if utf8.ValidString(message) {
+		return message, true
+	}
+	switch policy {
+	case invalidUTF8Drop:
+		return "", false
+	case invalidUTF8Base64:
+		// the message is cut before it is encoded rather than after, which would leave an invalid base64 string
+		if limit := base64.StdEncoding.DecodedLen(msgSizeLimit - base64Overhead); len(message) > limit {
+			message = message[:limit]
+		}
+		return `{"` + base64Key + `":"` + base64.StdEncoding.EncodeToString([]byte(message)) + `"}`, true
+	}
+	return strings.ToValidUTF8(message, string(utf8.RuneError)), true
+}
+
+// truncateMessage cuts the message to the size limit of the log events with the truncated suffix, on a rune
+// boundary so that the truncated message is still valid UTF-8
+func truncateMessage(message string) string {
+	if len(message) <= msgSizeLimit {
+		return message
+	}
+	end := msgSizeLimit - len(truncatedSuffix)
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end] + truncatedSuffix
+}
+
+// validEvent is a log event whose message was made valid UTF-8
+type validEvent struct {
+	logs.LogEvent
+	message string
+}
+
+func (e *validEvent) Message() string {
+	return e.message
+}
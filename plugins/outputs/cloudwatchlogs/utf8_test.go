@@ -0,0 +1,90 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cloudwatchlogs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidUTF8(t *testing.T) {
+	invalid := "user=\xff\xfebob"
+
+	for _, policy := range []string{"", invalidUTF8Replace, invalidUTF8Drop, invalidUTF8Base64} {
+		message, ok := validUTF8("héllo", policy)
+		assert.True(t, ok, policy)
+		assert.Equal(t, "héllo", message, policy)
+	}
+
+	message, ok := validUTF8(invalid, "")
+	assert.True(t, ok)
+	assert.Equal(t, "user=�bob", message)
+	message, ok = validUTF8(invalid, invalidUTF8Replace)
+	assert.True(t, ok)
+	assert.Equal(t, "user=�bob", message)
+
+	_, ok = validUTF8(invalid, invalidUTF8Drop)
+	assert.False(t, ok)
+
+	message, ok = validUTF8(invalid, invalidUTF8Base64)
+	assert.True(t, ok)
+	var wrapped map[string]string
+	require.NoError(t, json.Unmarshal([]byte(message), &wrapped))
+	decoded, err := base64.StdEncoding.DecodeString(wrapped[base64Key])
+	require.NoError(t, err)
+	assert.Equal(t, invalid, string(decoded))
+
+	// the encoded message of the largest log events fits in one
+	message, _ = validUTF8(strings.Repeat("\xff", msgSizeLimit), invalidUTF8Base64)
+	assert.LessOrEqual(t, len(message), msgSizeLimit)
+	require.NoError(t, json.Unmarshal([]byte(message), &wrapped))
+}
+
+func TestTruncateMessage(t *testing.T) {
+	assert.Equal(t, "short", truncateMessage("short"))
+
+	// the 3 bytes runes are not cut in the middle
+	message := truncateMessage(strings.Repeat("€", msgSizeLimit/3+1))
+	assert.LessOrEqual(t, len(message), msgSizeLimit)
+	assert.True(t, strings.HasSuffix(message, truncatedSuffix))
+	assert.True(t, utf8.ValidString(message))
+}
+
+func TestPublishInvalidUTF8(t *testing.T) {
+	var messages []string
+	s := &svcMock{ple: func(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+		for _, e := range in.LogEvents {
+			messages = append(messages, *e.Message)
+		}
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}}
+	p := NewPusher(Target{"G", "S"}, s, time.Hour, maxRetryTimeout, models.NewLogger("cloudwatchlogs", "test", ""))
+	p.invalidUTF8 = invalidUTF8Drop
+	cd := &cwDest{pusher: p}
+
+	var done int32
+	countDone := func() { atomic.AddInt32(&done, 1) }
+	require.NoError(t, cd.Publish([]logs.LogEvent{
+		evtMock{m: "valid", t: time.Now(), d: countDone},
+		evtMock{m: "invalid \xff", t: time.Now(), d: countDone},
+	}))
+	// the dropped log event is done right away, the other once it is sent
+	assert.Equal(t, int32(1), atomic.LoadInt32(&done))
+	p.Stop()
+	<-p.Stopped()
+	assert.Equal(t, []string{"valid"}, messages)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&done))
+	assert.True(t, cd.invalidUTF8Reported)
+}
@@ -0,0 +1,167 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	influxSerializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+	jsonSerializer "github.com/influxdata/telegraf/plugins/serializers/json"
+)
+
+const (
+	formatInflux = "influx"
+	formatJSON   = "json"
+
+	defaultMaxArchives = 5
+)
+
+// File writes what would otherwise be sent to CloudWatch into a local file: metrics in line
+// protocol or json, one per line, and log events as json lines along with their log group and stream.
+// It is meant for checking the parsing and filtering of a configuration without calling any AWS API.
+type File struct {
+	Path                string            `toml:"path"`
+	MetricFormat        string            `toml:"metric_format"`
+	RotationMaxSize     int64             `toml:"rotation_max_size"`
+	RotationInterval    internal.Duration `toml:"rotation_interval"`
+	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	LogStreamName       string            `toml:"log_stream_name"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	writer     *rotatingWriter
+	serializer serializers.Serializer
+}
+
+// logRecord is the json line written for every log event
+type logRecord struct {
+	Timestamp     string `json:"timestamp"`
+	LogGroupName  string `json:"log_group_name"`
+	LogStreamName string `json:"log_stream_name"`
+	Message       string `json:"message"`
+}
+
+func (f *File) Connect() error {
+	if f.Path == "" {
+		return fmt.Errorf("path is required for the file output")
+	}
+
+	switch f.MetricFormat {
+	case "", formatInflux:
+		f.serializer = influxSerializer.NewSerializer()
+	case formatJSON:
+		s, err := jsonSerializer.NewSerializer(time.Second)
+		if err != nil {
+			return err
+		}
+		f.serializer = s
+	default:
+		return fmt.Errorf("unsupported metric_format %q, valid values are %q and %q", f.MetricFormat, formatInflux, formatJSON)
+	}
+
+	w, err := newRotatingWriter(f.Path, f.RotationMaxSize, f.RotationInterval.Duration, f.RotationMaxArchives)
+	if err != nil {
+		return fmt.Errorf("unable to open %v: %v", f.Path, err)
+	}
+	f.writer = w
+	return nil
+}
+
+func (f *File) Close() error {
+	if f.writer == nil {
+		return nil
+	}
+	return f.writer.Close()
+}
+
+func (f *File) Write(metrics []telegraf.Metric) error {
+	for _, m := range metrics {
+		b, err := f.serializer.Serialize(m)
+		if err != nil {
+			f.Log.Debugf("Could not serialize metric %v: %v", m.Name(), err)
+			continue
+		}
+		if _, err := f.writer.Write(b); err != nil {
+			return fmt.Errorf("failed to write metrics to %v: %v", f.Path, err)
+		}
+	}
+	return nil
+}
+
+func (f *File) CreateDest(group, stream string) logs.LogDest {
+	if stream == "" {
+		stream = f.LogStreamName
+	}
+	return &fileDest{file: f, group: group, stream: stream}
+}
+
+type fileDest struct {
+	file          *File
+	group, stream string
+}
+
+func (d *fileDest) Publish(events []logs.LogEvent) error {
+	for _, e := range events {
+		t := e.Time()
+		if t.IsZero() {
+			t = time.Now()
+		}
+		line, err := json.Marshal(logRecord{
+			Timestamp:     t.Format(time.RFC3339Nano),
+			LogGroupName:  d.group,
+			LogStreamName: d.stream,
+			Message:       e.Message(),
+		})
+		if err != nil {
+			d.file.Log.Errorf("Unable to marshal log event of %v/%v: %v", d.group, d.stream, err)
+			continue
+		}
+		if _, err := d.file.writer.Write(append(line, '\n')); err != nil {
+			d.file.Log.Errorf("Unable to write log event of %v/%v to %v: %v", d.group, d.stream, d.file.Path, err)
+			return logs.ErrOutputStopped
+		}
+		e.Done()
+	}
+	return nil
+}
+
+// Description returns a one-sentence description on the Output
+func (f *File) Description() string {
+	return "Write the metrics and log events into a local file instead of sending them to AWS, for debugging."
+}
+
+var sampleConfig = `
+  ## The file to write to, it is created when missing
+  path = "/tmp/amazon-cloudwatch-agent-output.log"
+
+  ## Format of the metrics, either "influx" (line protocol) or "json". Log events are always json lines.
+  #metric_format = "influx"
+
+  ## Archive the file once it is older than the interval or larger than the size in bytes, 0 disables either of them
+  #rotation_interval = "0s"
+  #rotation_max_size = 0
+
+  ## Number of archives to keep, -1 keeps all of them
+  #rotation_max_archives = 5
+`
+
+// SampleConfig returns the default configuration of the Output
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func init() {
+	outputs.Add("file", func() telegraf.Output {
+		return &File{
+			RotationMaxArchives: defaultMaxArchives,
+		}
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type evtMock struct {
+	m    string
+	t    time.Time
+	done *bool
+}
+
+func (e evtMock) Message() string { return e.m }
+func (e evtMock) Time() time.Time { return e.t }
+func (e evtMock) Done()           { *e.done = true }
+
+func newTestFile(t *testing.T, format string) (*File, string) {
+	dir, err := ioutil.TempDir("", "fileoutput")
+	require.NoError(t, err)
+	f := &File{
+		Path:                filepath.Join(dir, "out", "agent.log"),
+		MetricFormat:        format,
+		RotationMaxArchives: defaultMaxArchives,
+		LogStreamName:       "default",
+		Log:                 models.NewLogger("outputs", "file", ""),
+	}
+	require.NoError(t, f.Connect())
+	return f, dir
+}
+
+func TestWriteMetrics(t *testing.T) {
+	f, dir := newTestFile(t, formatJSON)
+	defer os.RemoveAll(dir)
+
+	m, err := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage_idle": 90.5}, time.Unix(1596634200, 0))
+	require.NoError(t, err)
+	require.NoError(t, f.Write([]telegraf.Metric{m}))
+	require.NoError(t, f.Close())
+
+	b, err := ioutil.ReadFile(f.Path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fields":{"usage_idle":90.5},"name":"cpu","tags":{"host":"a"},"timestamp":1596634200}`, string(b))
+}
+
+func TestUnsupportedMetricFormat(t *testing.T) {
+	f := &File{Path: "/tmp/agent.log", MetricFormat: "csv"}
+	assert.Error(t, f.Connect())
+}
+
+func TestPublishLogs(t *testing.T) {
+	f, dir := newTestFile(t, "")
+	defer os.RemoveAll(dir)
+
+	done := false
+	d := f.CreateDest("/aws/app", "")
+	require.NoError(t, d.Publish([]logs.LogEvent{evtMock{m: "hello", t: time.Date(2020, 8, 5, 13, 30, 0, 0, time.UTC), done: &done}}))
+	assert.True(t, done)
+	require.NoError(t, f.Close())
+
+	b, err := ioutil.ReadFile(f.Path)
+	require.NoError(t, err)
+	var r logRecord
+	require.NoError(t, json.Unmarshal(b, &r))
+	assert.Equal(t, logRecord{Timestamp: "2020-08-05T13:30:00Z", LogGroupName: "/aws/app", LogStreamName: "default", Message: "hello"}, r)
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileoutput")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "agent.log")
+	w, err := newRotatingWriter(path, 10, 0, 2)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte("0123456789"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	archives, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, archives, 2)
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(b))
+}
+
+func TestRotateByInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileoutput")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "agent.log")
+	w, err := newRotatingWriter(path, 0, time.Millisecond, -1)
+	require.NoError(t, err)
+	w.Write([]byte("first\n"))
+	time.Sleep(5 * time.Millisecond)
+	w.Write([]byte("second\n"))
+	require.NoError(t, w.Close())
+
+	archives, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	b, err := ioutil.ReadFile(archives[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "first"))
+}
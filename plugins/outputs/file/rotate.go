@@ -0,0 +1,133 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const archiveTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// rotatingWriter appends to a file, which is archived next to it once it grows over maxSize
+// bytes or gets older than interval. Only the newest maxArchives archives are kept.
+type rotatingWriter struct {
+	sync.Mutex
+	path        string
+	maxSize     int64
+	interval    time.Duration
+	maxArchives int
+
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+func newRotatingWriter(path string, maxSize int64, interval time.Duration, maxArchives int) (*rotatingWriter, error) {
+	w := &rotatingWriter{
+		path:        path,
+		maxSize:     maxSize,
+		interval:    interval,
+		maxArchives: maxArchives,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	w.opened = time.Now()
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.needsRotation(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) needsRotation(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && time.Since(w.opened) >= w.interval
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	archive := fmt.Sprintf("%v.%v", w.path, time.Now().UTC().Format(archiveTimeFormat))
+	if err := os.Rename(w.path, archive); err != nil {
+		return err
+	}
+	if err := w.purgeArchives(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// purgeArchives removes the oldest archives, a negative maxArchives keeps all of them.
+func (w *rotatingWriter) purgeArchives() error {
+	if w.maxArchives < 0 {
+		return nil
+	}
+	archives, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return err
+	}
+	// the archive time format sorts lexically in chronological order
+	sort.Strings(archives)
+	for len(archives) > w.maxArchives {
+		if err := os.Remove(archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+func (w *rotatingWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
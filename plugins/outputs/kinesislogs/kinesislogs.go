@@ -0,0 +1,223 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kinesislogs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	configaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/handlers"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultFlushTimeout = 5 * time.Second
+	defaultPartitionKey = "{log_stream_name}"
+	maxRetryTimeout     = 1 * time.Hour
+
+	logGroupNamePlaceholder  = "{log_group_name}"
+	logStreamNamePlaceholder = "{log_stream_name}"
+	hostnamePlaceholder      = "{hostname}"
+	randomPlaceholder        = "{random}"
+)
+
+// KinesisLogs is a log backend delivering the log events to either a Kinesis data stream
+// or a Firehose delivery stream.
+type KinesisLogs struct {
+	Region               string `toml:"region"`
+	EndpointOverride     string `toml:"endpoint_override"`
+	UseDualStackEndpoint bool   `toml:"use_dualstack_endpoint"`
+	AccessKey            string `toml:"access_key"`
+	SecretKey            string `toml:"secret_key"`
+	RoleARN              string `toml:"role_arn"`
+	Profile              string `toml:"profile"`
+	Filename             string `toml:"shared_credential_file"`
+	Token                string `toml:"token"`
+
+	StreamName         string `toml:"stream_name"`
+	DeliveryStreamName string `toml:"delivery_stream_name"`
+	PartitionKey       string `toml:"partition_key"`
+	JoinRecords        bool   `toml:"join_records"`
+	KPLAggregation     bool   `toml:"kpl_aggregation"`
+	LogStreamName      string `toml:"log_stream_name"`
+
+	ForceFlushInterval internal.Duration `toml:"force_flush_interval"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	putter   recordPutter
+	endpoint *aws.Config
+	dests    map[logs.Target]*logs.Batcher
+}
+
+func (k *KinesisLogs) Connect() error {
+	if (k.StreamName == "") == (k.DeliveryStreamName == "") {
+		return fmt.Errorf("exactly one of stream_name and delivery_stream_name must be set")
+	}
+	if k.KPLAggregation && (k.StreamName == "" || k.JoinRecords) {
+		return fmt.Errorf("kpl_aggregation is only supported with stream_name and without join_records")
+	}
+	service := kinesis.EndpointsID
+	if k.StreamName == "" {
+		service = firehose.EndpointsID
+	}
+	var err error
+	k.endpoint, err = configaws.EndpointConfig(service, k.Region, k.EndpointOverride, configaws.EndpointOptions{DualStack: k.UseDualStackEndpoint})
+	return err
+}
+
+func (k *KinesisLogs) Close() error {
+	for _, d := range k.dests {
+		d.Stop()
+	}
+	return nil
+}
+
+// Write drops the metrics, only log events are delivered
+func (k *KinesisLogs) Write(metrics []telegraf.Metric) error {
+	return nil
+}
+
+func (k *KinesisLogs) CreateDest(group, stream string) logs.LogDest {
+	if stream == "" {
+		stream = k.LogStreamName
+	}
+	t := logs.Target{Group: group, Stream: stream}
+	if d, ok := k.dests[t]; ok {
+		return d
+	}
+
+	d := newProducer(t, k.getPutter(), partitionKeyFunc(k.PartitionKey, t), k.JoinRecords, k.KPLAggregation, k.ForceFlushInterval.Duration, maxRetryTimeout, k.Log)
+	k.dests[t] = d
+	return d
+}
+
+func (k *KinesisLogs) getPutter() recordPutter {
+	if k.putter != nil {
+		return k.putter
+	}
+	credentialConfig := &configaws.CredentialConfig{
+		Region:    k.Region,
+		AccessKey: k.AccessKey,
+		SecretKey: k.SecretKey,
+		RoleARN:   k.RoleARN,
+		Profile:   k.Profile,
+		Filename:  k.Filename,
+		Token:     k.Token,
+	}
+	userAgentHandler := handlers.NewCustomHeaderHandler("User-Agent", agentinfo.UserAgent())
+
+	if k.StreamName != "" {
+		awsConfig := k.endpoint.Copy()
+		awsConfig.HTTPClient = configaws.HTTPClient(1 * time.Minute)
+		client := kinesis.New(credentialConfig.Credentials(), awsConfig)
+		client.Handlers.Build.PushBackNamed(userAgentHandler)
+		handlers.AddCircuitBreakerHandlers(&client.Handlers)
+		k.putter = &kinesisPutter{service: client, streamName: k.StreamName}
+	} else {
+		awsConfig := k.endpoint.Copy()
+		awsConfig.HTTPClient = configaws.HTTPClient(1 * time.Minute)
+		client := firehose.New(credentialConfig.Credentials(), awsConfig)
+		client.Handlers.Build.PushBackNamed(userAgentHandler)
+		handlers.AddCircuitBreakerHandlers(&client.Handlers)
+		k.putter = &firehosePutter{service: client, deliveryStreamName: k.DeliveryStreamName}
+	}
+	return k.putter
+}
+
+// partitionKeyFunc resolves the placeholders of the partition key template for the target,
+// only {random} is resolved again for every record.
+func partitionKeyFunc(template string, t logs.Target) func() string {
+	if template == "" {
+		template = defaultPartitionKey
+	}
+	hostname, _ := os.Hostname()
+	key := strings.NewReplacer(
+		logGroupNamePlaceholder, t.Group,
+		logStreamNamePlaceholder, t.Stream,
+		hostnamePlaceholder, hostname,
+	).Replace(template)
+
+	if strings.Contains(key, randomPlaceholder) {
+		return func() string {
+			return truncatePartitionKey(strings.Replace(key, randomPlaceholder, randomPartitionKey(), -1))
+		}
+	}
+	key = truncatePartitionKey(key)
+	if key == "" {
+		// Kinesis rejects empty partition keys
+		key = t.Group
+	}
+	return func() string {
+		return key
+	}
+}
+
+func truncatePartitionKey(key string) string {
+	if len(key) > partitionKeyLimit {
+		return key[:partitionKeyLimit]
+	}
+	return key
+}
+
+// Description returns a one-sentence description on the Output
+func (k *KinesisLogs) Description() string {
+	return "Configuration for delivering logs to AWS Kinesis Data Streams or Kinesis Data Firehose."
+}
+
+var sampleConfig = `
+  ## Amazon REGION
+  region = "us-east-1"
+
+  ## Amazon Credentials, loaded in the same order as the cloudwatchlogs output
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## Exactly one of the Kinesis data stream or the Firehose delivery stream to deliver to
+  stream_name = "my-log-stream"
+  #delivery_stream_name = ""
+
+  ## Partition key of the Kinesis records, supports the {log_group_name}, {log_stream_name},
+  ## {hostname} and {random} placeholders. Ignored by Firehose.
+  #partition_key = "{log_stream_name}"
+
+  ## Join consecutive log events into newline delimited records of up to 1000 KiB. These are not
+  ## KPL aggregated records, the consumers split the records on the newlines.
+  #join_records = false
+
+  ## Put the log events in KPL aggregated records of up to 1000 KiB, which the KCL and the KPL
+  ## deaggregation libraries split back into one record per log event. Only with stream_name.
+  #kpl_aggregation = false
+
+  ## Max time to wait before sending a batch of records
+  #force_flush_interval = "5s"
+`
+
+// SampleConfig returns the default configuration of the Output
+func (k *KinesisLogs) SampleConfig() string {
+	return sampleConfig
+}
+
+func init() {
+	outputs.Add("kinesislogs", func() telegraf.Output {
+		return &KinesisLogs{
+			ForceFlushInterval: internal.Duration{Duration: defaultFlushTimeout},
+			dests:              make(map[logs.Target]*logs.Batcher),
+		}
+	})
+}
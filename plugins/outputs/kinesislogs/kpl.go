@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kinesislogs
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+)
+
+// kplMagic starts the KPL aggregated records, it is followed by the AggregatedRecord protobuf message and its MD5
+// digest, so that the KCL and the deaggregation libraries split it back into the user records
+var kplMagic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+const (
+	// the protobuf tags of the fields of the AggregatedRecord and Record messages
+	tagPartitionKeyTable = 1<<3 | 2
+	tagRecords           = 3<<3 | 2
+	tagPartitionKeyIndex = 1<<3 | 0
+	tagData              = 3<<3 | 2
+
+	// kplRecordOverhead bounds the bytes a log event adds to an aggregated record besides its data, i.e. its
+	// partition key when it is new and the tags and lengths of the fields
+	kplRecordOverhead = partitionKeyLimit + 32
+	kplFixedSize      = 4 + md5.Size
+)
+
+// kplAggregate is a KPL aggregated record being built from the log events, the partition keys of the log events are
+// only added once to its table
+type kplAggregate struct {
+	keys     []byte
+	keyIndex map[string]uint64
+	firstKey string
+	records  []byte
+}
+
+func (a *kplAggregate) empty() bool {
+	return len(a.records) == 0
+}
+
+// size is the size of the aggregated record
+func (a *kplAggregate) size() int {
+	return kplFixedSize + len(a.keys) + len(a.records)
+}
+
+func (a *kplAggregate) add(partitionKey string, data []byte) {
+	if a.keyIndex == nil {
+		a.keyIndex = make(map[string]uint64)
+		a.firstKey = partitionKey
+	}
+	index, ok := a.keyIndex[partitionKey]
+	if !ok {
+		index = uint64(len(a.keyIndex))
+		a.keyIndex[partitionKey] = index
+		a.keys = appendBytesField(a.keys, tagPartitionKeyTable, []byte(partitionKey))
+	}
+	record := appendUvarint([]byte{tagPartitionKeyIndex}, index)
+	record = appendBytesField(record, tagData, data)
+	a.records = appendBytesField(a.records, tagRecords, record)
+}
+
+// record returns the aggregated record, whose partition key is the one of its first log event
+func (a *kplAggregate) record() record {
+	message := make([]byte, 0, len(a.keys)+len(a.records))
+	message = append(append(message, a.keys...), a.records...)
+	digest := md5.Sum(message)
+	data := make([]byte, 0, kplFixedSize+len(message))
+	data = append(append(append(data, kplMagic...), message...), digest[:]...)
+	return record{data: data, partitionKey: a.firstKey}
+}
+
+func (a *kplAggregate) reset() {
+	*a = kplAggregate{}
+}
+
+func appendBytesField(b []byte, tag byte, value []byte) []byte {
+	b = append(b, tag)
+	b = appendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,149 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kinesislogs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userRecord struct {
+	partitionKey string
+	data         string
+}
+
+// deaggregate splits a KPL aggregated record back into its user records, as the KCL does
+func deaggregate(data []byte) ([]userRecord, error) {
+	if !bytes.HasPrefix(data, kplMagic) || len(data) < kplFixedSize {
+		return nil, errors.New("not an aggregated record")
+	}
+	message := data[len(kplMagic) : len(data)-md5.Size]
+	if digest := md5.Sum(message); !bytes.Equal(digest[:], data[len(data)-md5.Size:]) {
+		return nil, errors.New("invalid digest")
+	}
+	var keys []string
+	var records []userRecord
+	for len(message) > 0 {
+		tag, value, rest, err := readField(message)
+		if err != nil {
+			return nil, err
+		}
+		message = rest
+		switch tag {
+		case tagPartitionKeyTable:
+			keys = append(keys, string(value))
+		case tagRecords:
+			var r userRecord
+			for len(value) > 0 {
+				if value[0] == tagPartitionKeyIndex {
+					index, n := binary.Uvarint(value[1:])
+					if n <= 0 || int(index) >= len(keys) {
+						return nil, errors.New("invalid partition key index")
+					}
+					r.partitionKey = keys[index]
+					value = value[1+n:]
+					continue
+				}
+				tag, data, rest, err := readField(value)
+				if err != nil || tag != tagData {
+					return nil, errors.New("invalid record")
+				}
+				r.data = string(data)
+				value = rest
+			}
+			records = append(records, r)
+		default:
+			return nil, errors.New("unexpected field")
+		}
+	}
+	return records, nil
+}
+
+func readField(b []byte) (byte, []byte, []byte, error) {
+	length, n := binary.Uvarint(b[1:])
+	if n <= 0 || uint64(len(b)-1-n) < length {
+		return 0, nil, nil, errors.New("invalid length")
+	}
+	return b[0], b[1+n : 1+n+int(length)], b[1+n+int(length):], nil
+}
+
+func TestKPLAggregate(t *testing.T) {
+	var a kplAggregate
+	assert.True(t, a.empty())
+	a.add("a", []byte("msg1"))
+	a.add("b", []byte("msg2"))
+	a.add("a", []byte(""))
+	r := a.record()
+	assert.Equal(t, "a", r.partitionKey)
+	assert.Equal(t, len(r.data), a.size())
+
+	records, err := deaggregate(r.data)
+	require.NoError(t, err)
+	assert.Equal(t, []userRecord{{"a", "msg1"}, {"b", "msg2"}, {"a", ""}}, records)
+
+	a.reset()
+	assert.True(t, a.empty())
+}
+
+func TestProducerKPLAggregation(t *testing.T) {
+	var k kinesisMock
+	target := logs.Target{Group: "G", Stream: "S"}
+	p := newProducer(target, &kinesisPutter{service: &k, streamName: "logs"}, partitionKeyFunc("", target), false, true, 50*time.Millisecond, time.Minute, models.NewLogger("kinesislogs", "test", ""))
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	msg := strings.Repeat("a", recordSizeLimit-kplRecordOverhead-kplFixedSize)
+	for _, m := range []string{"msg1", "msg2", msg} {
+		p.Publish([]logs.LogEvent{evtMock{m: m, d: wg.Done}})
+	}
+	wg.Wait()
+
+	calls := k.calls()
+	require.Len(t, calls, 1)
+	// the large event does not fit in the first aggregated record
+	require.Len(t, calls[0].Records, 2)
+	records, err := deaggregate(calls[0].Records[0].Data)
+	require.NoError(t, err)
+	assert.Equal(t, []userRecord{{"S", "msg1"}, {"S", "msg2"}}, records)
+	assert.Equal(t, "S", *calls[0].Records[0].PartitionKey)
+	records, err = deaggregate(calls[0].Records[1].Data)
+	require.NoError(t, err)
+	assert.Equal(t, []userRecord{{"S", msg}}, records)
+	for _, r := range calls[0].Records {
+		assert.True(t, len(r.Data) <= recordSizeLimit)
+	}
+}
+
+func TestProducerKPLAggregationTruncates(t *testing.T) {
+	var k kinesisMock
+	target := logs.Target{Group: "G", Stream: "S"}
+	p := newProducer(target, &kinesisPutter{service: &k, streamName: "logs"}, partitionKeyFunc("", target), false, true, 50*time.Millisecond, time.Minute, models.NewLogger("kinesislogs", "test", ""))
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	p.Publish([]logs.LogEvent{evtMock{m: strings.Repeat("a", 2*recordSizeLimit), d: wg.Done}})
+	wg.Wait()
+
+	calls := k.calls()
+	require.Len(t, calls, 1)
+	require.Len(t, calls[0].Records, 1)
+	assert.True(t, len(calls[0].Records[0].Data) <= recordSizeLimit)
+	records, err := deaggregate(calls[0].Records[0].Data)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, strings.HasSuffix(records[0].data, truncatedSuffix))
+}
@@ -0,0 +1,242 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kinesislogs
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// the limits of Firehose PutRecordBatch, which are lower than the Kinesis PutRecords ones
+	recordSizeLimit   = 1000 * 1024
+	reqSizeLimit      = 4 * 1024 * 1024
+	reqRecordsLimit   = 500
+	partitionKeyLimit = 256
+	truncatedSuffix   = "[Truncated...]"
+)
+
+type KinesisService interface {
+	PutRecords(*kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
+}
+
+type FirehoseService interface {
+	PutRecordBatch(*firehose.PutRecordBatchInput) (*firehose.PutRecordBatchOutput, error)
+}
+
+type record struct {
+	data         []byte
+	partitionKey string
+}
+
+// recordPutter sends a batch of records, returning the indexes of the records which failed and should be retried.
+type recordPutter interface {
+	putRecords(records []record) (failed []int, err error)
+	String() string
+}
+
+type kinesisPutter struct {
+	service    KinesisService
+	streamName string
+}
+
+func (k *kinesisPutter) putRecords(records []record) ([]int, error) {
+	entries := make([]*kinesis.PutRecordsRequestEntry, len(records))
+	for i, r := range records {
+		entries[i] = &kinesis.PutRecordsRequestEntry{Data: r.data, PartitionKey: aws.String(r.partitionKey)}
+	}
+	output, err := k.service.PutRecords(&kinesis.PutRecordsInput{Records: entries, StreamName: aws.String(k.streamName)})
+	if err != nil {
+		return nil, err
+	}
+	var failed []int
+	if aws.Int64Value(output.FailedRecordCount) > 0 {
+		for i, r := range output.Records {
+			if r.ErrorCode != nil {
+				failed = append(failed, i)
+			}
+		}
+	}
+	return failed, nil
+}
+
+func (k *kinesisPutter) String() string {
+	return "kinesis stream " + k.streamName
+}
+
+type firehosePutter struct {
+	service            FirehoseService
+	deliveryStreamName string
+}
+
+func (f *firehosePutter) putRecords(records []record) ([]int, error) {
+	entries := make([]*firehose.Record, len(records))
+	for i, r := range records {
+		entries[i] = &firehose.Record{Data: r.data}
+	}
+	output, err := f.service.PutRecordBatch(&firehose.PutRecordBatchInput{Records: entries, DeliveryStreamName: aws.String(f.deliveryStreamName)})
+	if err != nil {
+		return nil, err
+	}
+	var failed []int
+	if aws.Int64Value(output.FailedPutCount) > 0 {
+		for i, r := range output.RequestResponses {
+			if r.ErrorCode != nil {
+				failed = append(failed, i)
+			}
+		}
+	}
+	return failed, nil
+}
+
+func (f *firehosePutter) String() string {
+	return "firehose delivery stream " + f.deliveryStreamName
+}
+
+// producer batches the log events of a single log group and stream into records.
+// With join enabled, consecutive log events are joined into newline delimited records
+// up to the record size limit, to reduce the number of billed records. The records are
+// not KPL aggregated records, the consumers split them on the newlines. With aggregate
+// enabled, the log events are put in KPL aggregated records instead, which the KCL and
+// the deaggregation libraries split back into one record per log event.
+type producer struct {
+	logs.Target
+	putter       recordPutter
+	partitionKey func() string
+	Join         bool
+	Aggregate    bool
+	Log          telegraf.Logger
+
+	records      []record
+	joined       []byte
+	aggregate    kplAggregate
+	bufferedSize int
+}
+
+func newProducer(target logs.Target, putter recordPutter, partitionKey func() string, join, aggregate bool, flushTimeout, retryDuration time.Duration, logger telegraf.Logger) *logs.Batcher {
+	p := &producer{
+		Target:       target,
+		putter:       putter,
+		partitionKey: partitionKey,
+		Join:         join,
+		Aggregate:    aggregate,
+		Log:          logger,
+	}
+	return logs.NewBatcher(target, putter.String(), p, flushTimeout, retryDuration, logger)
+}
+
+func (p *producer) Add(e logs.LogEvent) (bool, error) {
+	if p.Aggregate {
+		return p.addAggregated(e)
+	}
+	data := []byte(e.Message())
+	if len(data)+1 > recordSizeLimit {
+		data = append(data[:recordSizeLimit-1-len(truncatedSuffix)], truncatedSuffix...)
+	}
+	data = append(data, '\n')
+	if p.bufferedSize+len(data) > reqSizeLimit {
+		return false, logs.ErrBatchFull
+	}
+
+	if p.Join {
+		if len(p.joined)+len(data) > recordSizeLimit {
+			if len(p.records) == reqRecordsLimit {
+				return false, logs.ErrBatchFull
+			}
+			p.closeJoined()
+		}
+		p.joined = append(p.joined, data...)
+	} else {
+		if len(p.records) == reqRecordsLimit {
+			return false, logs.ErrBatchFull
+		}
+		p.records = append(p.records, record{data: data, partitionKey: p.partitionKey()})
+	}
+	p.bufferedSize += len(data)
+	return false, nil
+}
+
+// addAggregated adds the log event to the KPL aggregated record, which is closed once the event does not fit in it
+func (p *producer) addAggregated(e logs.LogEvent) (bool, error) {
+	data := []byte(e.Message())
+	if len(data)+kplRecordOverhead+kplFixedSize > recordSizeLimit {
+		data = append(data[:recordSizeLimit-kplRecordOverhead-kplFixedSize-len(truncatedSuffix)], truncatedSuffix...)
+	}
+	size := len(data) + kplRecordOverhead
+	if p.bufferedSize+size > reqSizeLimit {
+		return false, logs.ErrBatchFull
+	}
+	if !p.aggregate.empty() && p.aggregate.size()+size > recordSizeLimit {
+		if len(p.records) == reqRecordsLimit {
+			return false, logs.ErrBatchFull
+		}
+		p.closeAggregate()
+	}
+	p.aggregate.add(p.partitionKey(), data)
+	p.bufferedSize += size
+	return false, nil
+}
+
+// closeAggregate turns the aggregated log events into a record
+func (p *producer) closeAggregate() {
+	if p.aggregate.empty() {
+		return
+	}
+	p.records = append(p.records, p.aggregate.record())
+	p.aggregate.reset()
+}
+
+// closeJoined turns the joined log events into a record
+func (p *producer) closeJoined() {
+	if len(p.joined) == 0 {
+		return
+	}
+	p.records = append(p.records, record{data: p.joined, partitionKey: p.partitionKey()})
+	p.joined = nil
+}
+
+func (p *producer) Reset() {
+	p.records = p.records[:0]
+	p.joined = nil
+	p.aggregate.reset()
+	p.bufferedSize = 0
+}
+
+// Put puts the batched records, only the records which failed are kept to be retried.
+func (p *producer) Put() error {
+	p.closeJoined()
+	p.closeAggregate()
+	failed, err := p.putter.putRecords(p.records)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		retry := make([]record, len(failed))
+		for i, idx := range failed {
+			retry[i] = p.records[idx]
+		}
+		total := len(p.records)
+		p.records = retry
+		return fmt.Errorf("%v of %v records failed to be put", len(failed), total)
+	}
+	p.addStats("records", float64(len(p.records)))
+	return nil
+}
+
+func (p *producer) addStats(statsName string, value float64) {
+	statsKey := []string{"kinesislogs", p.Group, statsName}
+	profiler.Profiler.AddStats(statsKey, value)
+}
+
+func randomPartitionKey() string {
+	return fmt.Sprintf("%016x", rand.Int63())
+}
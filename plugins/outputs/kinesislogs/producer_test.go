@@ -0,0 +1,199 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kinesislogs
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kinesisMock struct {
+	sync.Mutex
+	failFirst bool
+	err       error
+	inputs    []*kinesis.PutRecordsInput
+}
+
+func (k *kinesisMock) PutRecords(in *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+	k.Lock()
+	defer k.Unlock()
+	k.inputs = append(k.inputs, in)
+	if k.err != nil {
+		err := k.err
+		k.err = nil
+		return nil, err
+	}
+	output := &kinesis.PutRecordsOutput{FailedRecordCount: aws.Int64(0)}
+	for i := range in.Records {
+		entry := &kinesis.PutRecordsResultEntry{}
+		if k.failFirst && i == 0 {
+			entry.ErrorCode = aws.String(kinesis.ErrCodeProvisionedThroughputExceededException)
+			output.FailedRecordCount = aws.Int64(1)
+		}
+		output.Records = append(output.Records, entry)
+	}
+	k.failFirst = false
+	return output, nil
+}
+
+func (k *kinesisMock) calls() []*kinesis.PutRecordsInput {
+	k.Lock()
+	defer k.Unlock()
+	return append([]*kinesis.PutRecordsInput{}, k.inputs...)
+}
+
+type firehoseMock struct {
+	sync.Mutex
+	inputs []*firehose.PutRecordBatchInput
+}
+
+func (f *firehoseMock) PutRecordBatch(in *firehose.PutRecordBatchInput) (*firehose.PutRecordBatchOutput, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.inputs = append(f.inputs, in)
+	return &firehose.PutRecordBatchOutput{FailedPutCount: aws.Int64(0)}, nil
+}
+
+type evtMock struct {
+	m string
+	t time.Time
+	d func()
+}
+
+func (e evtMock) Message() string { return e.m }
+func (e evtMock) Time() time.Time { return e.t }
+func (e evtMock) Done() {
+	if e.d != nil {
+		e.d()
+	}
+}
+
+func newTestProducer(putter recordPutter, join bool) *logs.Batcher {
+	t := logs.Target{Group: "G", Stream: "S"}
+	return newProducer(t, putter, partitionKeyFunc("", t), join, false, 50*time.Millisecond, time.Minute, models.NewLogger("kinesislogs", "test", ""))
+}
+
+func TestProducerKinesis(t *testing.T) {
+	var k kinesisMock
+	p := newTestProducer(&kinesisPutter{service: &k, streamName: "logs"}, false)
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	p.Publish([]logs.LogEvent{evtMock{m: "msg1", d: wg.Done}})
+	p.Publish([]logs.LogEvent{evtMock{m: "msg2", d: wg.Done}})
+	wg.Wait()
+
+	calls := k.calls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "logs", *calls[0].StreamName)
+	require.Len(t, calls[0].Records, 2)
+	assert.Equal(t, "msg1\n", string(calls[0].Records[0].Data))
+	assert.Equal(t, "S", *calls[0].Records[0].PartitionKey)
+}
+
+func TestProducerJoin(t *testing.T) {
+	var f firehoseMock
+	p := newTestProducer(&firehosePutter{service: &f, deliveryStreamName: "logs"}, true)
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		p.Publish([]logs.LogEvent{evtMock{m: "msg", d: wg.Done}})
+	}
+	wg.Wait()
+
+	f.Lock()
+	defer f.Unlock()
+	require.Len(t, f.inputs, 1)
+	assert.Equal(t, "logs", *f.inputs[0].DeliveryStreamName)
+	require.Len(t, f.inputs[0].Records, 1)
+	assert.Equal(t, "msg\nmsg\nmsg\n", string(f.inputs[0].Records[0].Data))
+}
+
+func TestProducerJoinRecordSizeLimit(t *testing.T) {
+	var k kinesisMock
+	p := newTestProducer(&kinesisPutter{service: &k, streamName: "logs"}, true)
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	msg := strings.Repeat("a", recordSizeLimit/2)
+	for i := 0; i < 3; i++ {
+		p.Publish([]logs.LogEvent{evtMock{m: msg, d: wg.Done}})
+	}
+	wg.Wait()
+
+	calls := k.calls()
+	require.Len(t, calls, 1)
+	assert.Len(t, calls[0].Records, 3)
+}
+
+func TestProducerRetryFailedRecords(t *testing.T) {
+	k := kinesisMock{failFirst: true}
+	p := newTestProducer(&kinesisPutter{service: &k, streamName: "logs"}, false)
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	p.Publish([]logs.LogEvent{evtMock{m: "msg1", d: wg.Done}})
+	p.Publish([]logs.LogEvent{evtMock{m: "msg2", d: wg.Done}})
+	wg.Wait()
+
+	calls := k.calls()
+	require.Len(t, calls, 2)
+	require.Len(t, calls[1].Records, 1)
+	assert.Equal(t, "msg1\n", string(calls[1].Records[0].Data))
+}
+
+func TestProducerRetryError(t *testing.T) {
+	k := kinesisMock{err: errors.New("throttled")}
+	p := newTestProducer(&kinesisPutter{service: &k, streamName: "logs"}, false)
+	defer p.Stop()
+
+	done := make(chan struct{})
+	p.Publish([]logs.LogEvent{evtMock{m: "msg", d: func() { close(done) }}})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("done callback not called after retry")
+	}
+	assert.Len(t, k.calls(), 2)
+}
+
+func TestPartitionKeyFunc(t *testing.T) {
+	target := logs.Target{Group: "/aws/app", Stream: "i-1234"}
+	assert.Equal(t, "i-1234", partitionKeyFunc("", target)())
+	assert.Equal(t, "/aws/app-i-1234", partitionKeyFunc("{log_group_name}-{log_stream_name}", target)())
+	assert.Len(t, partitionKeyFunc(strings.Repeat("k", 300), target)(), partitionKeyLimit)
+
+	random := partitionKeyFunc("app-{random}", target)
+	assert.True(t, strings.HasPrefix(random(), "app-"))
+	assert.NotEqual(t, random(), random())
+}
+
+func TestConnectRequiresOneStream(t *testing.T) {
+	assert.Error(t, (&KinesisLogs{}).Connect())
+	assert.Error(t, (&KinesisLogs{StreamName: "a", DeliveryStreamName: "b"}).Connect())
+	assert.NoError(t, (&KinesisLogs{StreamName: "a"}).Connect())
+	assert.NoError(t, (&KinesisLogs{DeliveryStreamName: "b"}).Connect())
+	// Firehose does not deaggregate the KPL aggregated records put directly
+	assert.NoError(t, (&KinesisLogs{StreamName: "a", KPLAggregation: true}).Connect())
+	assert.Error(t, (&KinesisLogs{DeliveryStreamName: "b", KPLAggregation: true}).Connect())
+	assert.Error(t, (&KinesisLogs{StreamName: "a", KPLAggregation: true, JoinRecords: true}).Connect())
+	// the dual-stack endpoint is required once requested
+	assert.Error(t, (&KinesisLogs{StreamName: "a", Region: "us-iso-east-1", UseDualStackEndpoint: true}).Connect())
+}
@@ -0,0 +1,207 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package opensearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	reqSizeLimit    = 5 * 1024 * 1024
+	reqEventsLimit  = 1000
+	timestampField  = "@timestamp"
+	messageField    = "message"
+	logGroupField   = "log_group_name"
+	logStreamField  = "log_stream_name"
+	indexNameLimit  = 255
+	groupHolder     = "{log_group_name}"
+	streamHolder    = "{log_stream_name}"
+	dateHolder      = "{date}"
+	dateIndexFormat = "2006.01.02"
+)
+
+var (
+	// characters which are not allowed in index names
+	invalidIndexChars = regexp.MustCompile(`[^a-z0-9_.+-]+`)
+)
+
+// bulkSender posts a bulk request body, it signs the request when configured so.
+type bulkSender interface {
+	send(body []byte) (*http.Response, error)
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// indexer batches the log events of a single log group and stream into bulk index requests.
+type indexer struct {
+	logs.Target
+	sender        bulkSender
+	IndexTemplate string
+	Log           telegraf.Logger
+
+	lines        [][]byte
+	bufferedSize int
+}
+
+func newIndexer(target logs.Target, sender bulkSender, indexTemplate string, flushTimeout, retryDuration time.Duration, logger telegraf.Logger) *logs.Batcher {
+	i := &indexer{
+		Target:        target,
+		sender:        sender,
+		IndexTemplate: indexTemplate,
+		Log:           logger,
+	}
+	return logs.NewBatcher(target, "opensearch", i, flushTimeout, retryDuration, logger)
+}
+
+func (i *indexer) Add(e logs.LogEvent) (bool, error) {
+	action, doc, err := i.convertEvent(e)
+	if err != nil {
+		return false, fmt.Errorf("unable to convert log event into a document: %v", err)
+	}
+	size := len(action) + len(doc)
+	if i.bufferedSize+size > reqSizeLimit || len(i.lines) == 2*reqEventsLimit {
+		return false, logs.ErrBatchFull
+	}
+	i.lines = append(i.lines, action, doc)
+	i.bufferedSize += size
+	return false, nil
+}
+
+// convertEvent returns the bulk action and document lines of the event. Log events which are
+// json objects are indexed as they are, the other ones are indexed under the message field.
+func (i *indexer) convertEvent(e logs.LogEvent) (action, doc []byte, err error) {
+	t := e.Time()
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	fields := map[string]interface{}{}
+	msg := e.Message()
+	if !strings.HasPrefix(strings.TrimSpace(msg), "{") || json.Unmarshal([]byte(msg), &fields) != nil {
+		fields = map[string]interface{}{messageField: msg}
+	}
+	if _, ok := fields[timestampField]; !ok {
+		fields[timestampField] = t.UTC().Format(time.RFC3339Nano)
+	}
+	fields[logGroupField] = i.Group
+	fields[logStreamField] = i.Stream
+
+	action, err = json.Marshal(map[string]interface{}{"index": map[string]string{"_index": i.indexName(t)}})
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err = json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(action, '\n'), append(doc, '\n'), nil
+}
+
+// indexName resolves the index template for an event, index names are lower case and
+// the characters not allowed in them are replaced by "-".
+func (i *indexer) indexName(t time.Time) string {
+	name := strings.NewReplacer(
+		groupHolder, strings.Trim(i.Group, "/"),
+		streamHolder, i.Stream,
+		dateHolder, t.UTC().Format(dateIndexFormat),
+	).Replace(i.IndexTemplate)
+	name = invalidIndexChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.TrimLeft(name, "-_+.")
+	if len(name) > indexNameLimit {
+		name = name[:indexNameLimit]
+	}
+	return name
+}
+
+func (i *indexer) Reset() {
+	for j := 0; j < len(i.lines); j++ {
+		i.lines[j] = nil
+	}
+	i.lines = i.lines[:0]
+	i.bufferedSize = 0
+}
+
+// Put posts the batched documents, the whole request is retried on transport errors and only
+// the documents rejected with a retryable status otherwise.
+func (i *indexer) Put() error {
+	failed, err := i.bulk(i.lines)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		retry := make([][]byte, 0, 2*len(failed))
+		for _, idx := range failed {
+			retry = append(retry, i.lines[2*idx], i.lines[2*idx+1])
+		}
+		total := len(i.lines) / 2
+		i.lines = retry
+		return fmt.Errorf("%v of %v documents were rejected by OpenSearch", len(failed), total)
+	}
+	i.addStats("rawSize", float64(i.bufferedSize))
+	return nil
+}
+
+// bulk posts the bulk request and returns the indexes of the documents which should be retried.
+func (i *indexer) bulk(lines [][]byte) ([]int, error) {
+	resp, err := i.sender.send(bytes.Join(lines, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bulk request failed with status %v: %s", resp.StatusCode, body)
+	}
+
+	var result bulkResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse bulk response: %v", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+
+	var failed []int
+	for idx, item := range result.Items {
+		for _, r := range item {
+			if r.Error == nil {
+				continue
+			}
+			if r.Status == http.StatusTooManyRequests || r.Status >= http.StatusInternalServerError {
+				failed = append(failed, idx)
+			} else {
+				i.Log.Errorf("Document of %v/%v rejected with status %v, will not retry: %v %v", i.Group, i.Stream, r.Status, r.Error.Type, r.Error.Reason)
+			}
+		}
+	}
+	return failed, nil
+}
+
+func (i *indexer) addStats(statsName string, value float64) {
+	statsKey := []string{"opensearch", i.Group, statsName}
+	profiler.Profiler.AddStats(statsKey, value)
+}
@@ -0,0 +1,152 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package opensearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type evtMock struct {
+	m string
+	t time.Time
+	d func()
+}
+
+func (e evtMock) Message() string { return e.m }
+func (e evtMock) Time() time.Time { return e.t }
+func (e evtMock) Done() {
+	if e.d != nil {
+		e.d()
+	}
+}
+
+type bulkServer struct {
+	sync.Mutex
+	*httptest.Server
+	requests [][]map[string]interface{}
+	// statuses of the items of the next responses, consumed one response at a time
+	statuses [][]int
+}
+
+func newBulkServer() *bulkServer {
+	s := &bulkServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		var lines []map[string]interface{}
+		body, _ := ioutil.ReadAll(r.Body)
+		scanner := bufio.NewScanner(strings.NewReader(string(body)))
+		for scanner.Scan() {
+			var line map[string]interface{}
+			json.Unmarshal(scanner.Bytes(), &line)
+			lines = append(lines, line)
+		}
+		s.requests = append(s.requests, lines)
+
+		var statuses []int
+		if len(s.statuses) > 0 {
+			statuses, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		hasErrors := false
+		var items []string
+		for j := 0; j < len(lines)/2; j++ {
+			status := http.StatusCreated
+			if j < len(statuses) {
+				status = statuses[j]
+			}
+			if status >= 300 {
+				hasErrors = true
+				items = append(items, fmt.Sprintf(`{"index":{"status":%d,"error":{"type":"error","reason":"rejected"}}}`, status))
+			} else {
+				items = append(items, fmt.Sprintf(`{"index":{"status":%d}}`, status))
+			}
+		}
+		fmt.Fprintf(w, `{"errors":%v,"items":[%v]}`, hasErrors, strings.Join(items, ","))
+	}))
+	return s
+}
+
+func (s *bulkServer) received() [][]map[string]interface{} {
+	s.Lock()
+	defer s.Unlock()
+	return append([][]map[string]interface{}{}, s.requests...)
+}
+
+func newTestIndexer(s *bulkServer) *logs.Batcher {
+	sender := &httpSender{url: s.URL + "/_bulk", client: s.Client()}
+	return newIndexer(logs.Target{Group: "/aws/App", Stream: "host"}, sender, defaultIndexTemplate, 50*time.Millisecond, time.Minute, models.NewLogger("opensearch", "test", ""))
+}
+
+func TestIndexerBulk(t *testing.T) {
+	s := newBulkServer()
+	defer s.Close()
+	i := newTestIndexer(s)
+	defer i.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	et := time.Date(2020, 8, 5, 13, 30, 0, 0, time.UTC)
+	i.Publish([]logs.LogEvent{evtMock{m: `{"level":"info","msg":"started"}`, t: et, d: wg.Done}})
+	i.Publish([]logs.LogEvent{evtMock{m: "plain text", t: et, d: wg.Done}})
+	wg.Wait()
+
+	requests := s.received()
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0], 4)
+	assert.Equal(t, map[string]interface{}{"_index": "cwagent-aws-app-2020.08.05"}, requests[0][0]["index"])
+	assert.Equal(t, map[string]interface{}{
+		"level":           "info",
+		"msg":             "started",
+		"@timestamp":      "2020-08-05T13:30:00Z",
+		"log_group_name":  "/aws/App",
+		"log_stream_name": "host",
+	}, requests[0][1])
+	assert.Equal(t, "plain text", requests[0][3]["message"])
+}
+
+func TestIndexerRetryRejectedDocuments(t *testing.T) {
+	s := newBulkServer()
+	defer s.Close()
+	s.statuses = [][]int{{http.StatusTooManyRequests, http.StatusBadRequest, http.StatusCreated}}
+	i := newTestIndexer(s)
+	defer i.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for j := 0; j < 3; j++ {
+		i.Publish([]logs.LogEvent{evtMock{m: fmt.Sprintf("msg%d", j), d: wg.Done}})
+	}
+	wg.Wait()
+
+	requests := s.received()
+	require.Len(t, requests, 2)
+	require.Len(t, requests[1], 2)
+	assert.Equal(t, "msg0", requests[1][1]["message"])
+}
+
+func TestIndexName(t *testing.T) {
+	i := &indexer{Target: logs.Target{Group: "/aws/lambda/My_Func", Stream: "Stream 1"}}
+	et := time.Date(2020, 8, 5, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*3600))
+
+	i.IndexTemplate = defaultIndexTemplate
+	assert.Equal(t, "cwagent-aws-lambda-my_func-2020.08.06", i.indexName(et))
+	i.IndexTemplate = "{log_group_name}"
+	assert.Equal(t, "aws-lambda-my_func", i.indexName(et))
+	i.IndexTemplate = "logs-{log_stream_name}"
+	assert.Equal(t, "logs-stream-1", i.indexName(et))
+}
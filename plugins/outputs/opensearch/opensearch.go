@@ -0,0 +1,191 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package opensearch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	configaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultFlushTimeout  = 5 * time.Second
+	defaultIndexTemplate = "cwagent-" + groupHolder + "-" + dateHolder
+	defaultService       = "es"
+	serverlessService    = "aoss"
+	maxRetryTimeout      = 1 * time.Hour
+)
+
+// OpenSearch is a log backend bulk indexing the log events into an Amazon OpenSearch Service
+// domain or an OpenSearch Serverless collection.
+type OpenSearch struct {
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	RoleARN   string `toml:"role_arn"`
+	Profile   string `toml:"profile"`
+	Filename  string `toml:"shared_credential_file"`
+	Token     string `toml:"token"`
+
+	Endpoint      string `toml:"endpoint"`
+	Serverless    bool   `toml:"serverless"`
+	DisableSigV4  bool   `toml:"disable_sigv4"`
+	IndexTemplate string `toml:"index"`
+	LogStreamName string `toml:"log_stream_name"`
+
+	ForceFlushInterval internal.Duration `toml:"force_flush_interval"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	sender bulkSender
+	dests  map[logs.Target]*logs.Batcher
+}
+
+func (o *OpenSearch) Connect() error {
+	if o.Endpoint == "" {
+		return fmt.Errorf("endpoint is required for the opensearch output")
+	}
+	return nil
+}
+
+func (o *OpenSearch) Close() error {
+	for _, d := range o.dests {
+		d.Stop()
+	}
+	return nil
+}
+
+// Write drops the metrics, only log events are indexed
+func (o *OpenSearch) Write(metrics []telegraf.Metric) error {
+	return nil
+}
+
+func (o *OpenSearch) CreateDest(group, stream string) logs.LogDest {
+	if stream == "" {
+		stream = o.LogStreamName
+	}
+	t := logs.Target{Group: group, Stream: stream}
+	if d, ok := o.dests[t]; ok {
+		return d
+	}
+
+	d := newIndexer(t, o.getSender(), o.IndexTemplate, o.ForceFlushInterval.Duration, maxRetryTimeout, o.Log)
+	o.dests[t] = d
+	return d
+}
+
+func (o *OpenSearch) getSender() bulkSender {
+	if o.sender != nil {
+		return o.sender
+	}
+	s := &httpSender{
+		url:    strings.TrimSuffix(o.Endpoint, "/") + "/_bulk",
+		client: &http.Client{Timeout: 1 * time.Minute},
+		region: o.Region,
+	}
+	if !o.DisableSigV4 {
+		credentialConfig := &configaws.CredentialConfig{
+			Region:    o.Region,
+			AccessKey: o.AccessKey,
+			SecretKey: o.SecretKey,
+			RoleARN:   o.RoleARN,
+			Profile:   o.Profile,
+			Filename:  o.Filename,
+			Token:     o.Token,
+		}
+		s.service = defaultService
+		if o.Serverless {
+			s.service = serverlessService
+		}
+		s.signer = v4.NewSigner(credentialConfig.Credentials().ClientConfig(s.service).Config.Credentials)
+	}
+	o.sender = s
+	return o.sender
+}
+
+type httpSender struct {
+	url     string
+	client  *http.Client
+	signer  *v4.Signer
+	service string
+	region  string
+}
+
+func (s *httpSender) send(body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", agentinfo.UserAgent())
+	if s.signer != nil {
+		if s.service == serverlessService {
+			// OpenSearch Serverless requires the payload hash header, which the signer only adds for S3
+			hash := sha256.Sum256(body)
+			req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
+		}
+		if _, err := s.signer.Sign(req, bytes.NewReader(body), s.service, s.region, time.Now()); err != nil {
+			return nil, fmt.Errorf("unable to sign the bulk request: %v", err)
+		}
+	}
+	return s.client.Do(req)
+}
+
+// Description returns a one-sentence description on the Output
+func (o *OpenSearch) Description() string {
+	return "Configuration for indexing logs into Amazon OpenSearch Service."
+}
+
+var sampleConfig = `
+  ## Amazon REGION
+  region = "us-east-1"
+
+  ## Amazon Credentials used to sign the requests, loaded in the same order as the cloudwatchlogs output
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## Endpoint of the domain or the serverless collection
+  endpoint = "https://search-my-domain.us-east-1.es.amazonaws.com"
+  ## Sign the requests for OpenSearch Serverless (aoss) instead of OpenSearch Service (es)
+  #serverless = false
+  ## Send the requests unsigned, for domains using fine grained access control only
+  #disable_sigv4 = false
+
+  ## Index name template, supports the {log_group_name}, {log_stream_name} and {date} (yyyy.MM.dd) placeholders
+  #index = "cwagent-{log_group_name}-{date}"
+
+  ## Max time to wait before sending a bulk request
+  #force_flush_interval = "5s"
+`
+
+// SampleConfig returns the default configuration of the Output
+func (o *OpenSearch) SampleConfig() string {
+	return sampleConfig
+}
+
+func init() {
+	outputs.Add("opensearch", func() telegraf.Output {
+		return &OpenSearch{
+			IndexTemplate:      defaultIndexTemplate,
+			ForceFlushInterval: internal.Duration{Duration: defaultFlushTimeout},
+			dests:              make(map[logs.Target]*logs.Batcher),
+		}
+	})
+}
@@ -0,0 +1,201 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package s3logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/amazon-cloudwatch-agent/profiler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/influxdata/telegraf"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	maxRetryTimeout = 1 * time.Hour
+	objectSuffix    = ".ndjson"
+
+	compressionGzip  = "gzip"
+	compressionZstd  = "zstd"
+	defaultZstdLevel = 3
+)
+
+type S3Service interface {
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+}
+
+// archiveRecord is a single line of the newline delimited json objects written to S3
+type archiveRecord struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// compressor compresses the objects, it is reset for every object
+type compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// archiver batches the log events of a single log group and stream into a gzip or zstd
+// compressed object, which never spans more than one hour of log events.
+type archiver struct {
+	logs.Target
+	Service       S3Service
+	Bucket        string
+	KeyPrefix     string
+	MaxObjectSize int
+	Log           telegraf.Logger
+
+	buf           bytes.Buffer
+	compression   string
+	compressor    compressor
+	closed        bool
+	rawSize       int
+	hour          time.Time
+	lastValidTime time.Time
+	// err is the error of the compressor, the object is not uploaded once it failed since it would be truncated
+	err error
+}
+
+func newArchiver(target logs.Target, service S3Service, bucket, keyPrefix string, flushTimeout time.Duration, maxObjectSize int, compression string, compressionLevel int, logger telegraf.Logger) *logs.Batcher {
+	a := &archiver{
+		Target:        target,
+		Service:       service,
+		Bucket:        bucket,
+		KeyPrefix:     keyPrefix,
+		MaxObjectSize: maxObjectSize,
+		Log:           logger,
+	}
+	a.compression, a.compressor = newCompressor(compression, compressionLevel, &a.buf, logger)
+	return logs.NewBatcher(target, "s3://"+bucket, a, flushTimeout, maxRetryTimeout, logger)
+}
+
+// newCompressor returns the compressor of the compression at the level, 0 being the default level. It falls back to
+// gzip at its default level when the compressor cannot be created.
+func newCompressor(compression string, level int, w io.Writer, logger telegraf.Logger) (string, compressor) {
+	switch compression {
+	case compressionZstd:
+		if level == 0 {
+			level = defaultZstdLevel
+		}
+		encoder, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		if err == nil {
+			return compressionZstd, encoder
+		}
+		logger.Errorf("Unable to create the zstd compressor, the objects are compressed with gzip: %v", err)
+	case compressionGzip, "":
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gz, err := gzip.NewWriterLevel(w, level)
+		if err == nil {
+			return compressionGzip, gz
+		}
+		logger.Errorf("Invalid gzip compression level %v, the default level is used: %v", level, err)
+	default:
+		logger.Errorf("Unknown compression %v, the objects are compressed with gzip", compression)
+	}
+	return compressionGzip, gzip.NewWriter(w)
+}
+
+// Add compresses the event into the object, an event of another hour than the others is put in the next object.
+func (a *archiver) Add(e logs.LogEvent) (bool, error) {
+	t := a.eventTime(e)
+	hour := t.UTC().Truncate(time.Hour)
+	if a.rawSize > 0 && !hour.Equal(a.hour) {
+		return false, logs.ErrBatchFull
+	}
+
+	line, err := json.Marshal(archiveRecord{Timestamp: t.UnixNano() / int64(time.Millisecond), Message: e.Message()})
+	if err != nil {
+		return false, err
+	}
+	line = append(line, '\n')
+	if a.err != nil {
+		return false, a.err
+	}
+	if _, err := a.compressor.Write(line); err != nil {
+		a.err = fmt.Errorf("unable to compress the log events: %v", err)
+		return false, a.err
+	}
+	a.hour = hour
+	a.rawSize += len(line)
+	return a.rawSize >= a.MaxObjectSize, nil
+}
+
+// eventTime falls back to the last valid timestamp, or the current time, for events without a timestamp.
+func (a *archiver) eventTime(e logs.LogEvent) time.Time {
+	t := e.Time()
+	if t.IsZero() {
+		if !a.lastValidTime.IsZero() {
+			return a.lastValidTime
+		}
+		return time.Now()
+	}
+	a.lastValidTime = t
+	return t
+}
+
+// objectKey returns <prefix>/<group>/<yyyy>/<mm>/<dd>/<hh>/<stream>-<unix nano>.ndjson.gz, or .ndjson.zst with zstd
+func (a *archiver) objectKey(now time.Time) string {
+	extension := ".gz"
+	if a.compression == compressionZstd {
+		extension = ".zst"
+	}
+	name := fmt.Sprintf("%v-%d%v%v", a.Stream, now.UnixNano(), objectSuffix, extension)
+	return path.Join(a.KeyPrefix, strings.Trim(a.Group, "/"), a.hour.Format("2006/01/02/15"), name)
+}
+
+func (a *archiver) Reset() {
+	a.buf.Reset()
+	a.compressor.Reset(&a.buf)
+	a.closed = false
+	a.err = nil
+	a.rawSize = 0
+}
+
+func (a *archiver) Put() error {
+	if a.err != nil {
+		return a.err
+	}
+	if !a.closed {
+		if err := a.compressor.Close(); err != nil {
+			a.err = fmt.Errorf("unable to compress the log events: %v", err)
+			return a.err
+		}
+		a.closed = true
+	}
+
+	startTime := time.Now()
+	key := a.objectKey(startTime)
+	body := a.buf.Bytes()
+	_, err := a.Service.PutObject(&s3.PutObjectInput{
+		Bucket:          aws.String(a.Bucket),
+		Key:             aws.String(key),
+		Body:            bytes.NewReader(body),
+		ContentType:     aws.String("application/x-ndjson"),
+		ContentEncoding: aws.String(a.compression),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to put s3://%v/%v: %v", a.Bucket, key, err)
+	}
+	a.Log.Debugf("Archived log events of group: %v stream: %v to s3://%v/%v with size %v KB in %v.", a.Group, a.Stream, a.Bucket, key, len(body)/1024, time.Since(startTime))
+	a.addStats("rawSize", float64(a.rawSize))
+	a.addStats("compressedSize", float64(len(body)))
+	return nil
+}
+
+func (a *archiver) addStats(statsName string, value float64) {
+	statsKey := []string{"s3logs", a.Group, statsName}
+	profiler.Profiler.AddStats(statsKey, value)
+}
@@ -0,0 +1,273 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package s3logs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/influxdata/telegraf/models"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type s3Mock struct {
+	sync.Mutex
+	err       error
+	keys      []string
+	encodings []string
+	records   [][]archiveRecord
+}
+
+func (s *s3Mock) PutObject(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.err != nil {
+		err := s.err
+		s.err = nil
+		return nil, err
+	}
+
+	var body io.Reader
+	switch *in.ContentEncoding {
+	case "zstd":
+		decoder, err := zstd.NewReader(in.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		body = decoder
+	default:
+		gz, err := gzip.NewReader(in.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = gz
+	}
+	var records []archiveRecord
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		var r archiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	s.keys = append(s.keys, *in.Key)
+	s.encodings = append(s.encodings, *in.ContentEncoding)
+	s.records = append(s.records, records)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (s *s3Mock) puts() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.keys)
+}
+
+type evtMock struct {
+	m string
+	t time.Time
+	d func()
+}
+
+func (e evtMock) Message() string { return e.m }
+func (e evtMock) Time() time.Time { return e.t }
+func (e evtMock) Done() {
+	if e.d != nil {
+		e.d()
+	}
+}
+
+func waitForPuts(t *testing.T, s *s3Mock, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for s.puts() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v objects to be archived, got %v", n, s.puts())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestArchiverFlushOnTimeout(t *testing.T) {
+	var s s3Mock
+	var done int
+	var mu sync.Mutex
+	a := newArchiver(logs.Target{Group: "/aws/app", Stream: "host"}, &s, "bucket", "prefix", 50*time.Millisecond, defaultMaxObjectSize, "gzip", 0, models.NewLogger("s3logs", "test", ""))
+	defer a.Stop()
+
+	et := time.Date(2020, 8, 5, 13, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		a.Publish([]logs.LogEvent{evtMock{m: "msg", t: et, d: func() { mu.Lock(); done++; mu.Unlock() }}})
+	}
+	assert.Eventually(t, func() bool { mu.Lock(); defer mu.Unlock(); return done == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	s.Lock()
+	defer s.Unlock()
+	assert.Regexp(t, `^prefix/aws/app/2020/08/05/13/host-\d+\.ndjson\.gz$`, s.keys[0])
+	assert.Equal(t, "gzip", s.encodings[0])
+	require.Len(t, s.records[0], 3)
+	assert.Equal(t, archiveRecord{Timestamp: et.UnixNano() / int64(time.Millisecond), Message: "msg"}, s.records[0][0])
+}
+
+func TestArchiverZstd(t *testing.T) {
+	var s s3Mock
+	a := newArchiver(logs.Target{Group: "G", Stream: "S"}, &s, "bucket", "", 50*time.Millisecond, defaultMaxObjectSize, "zstd", 19, models.NewLogger("s3logs", "test", ""))
+	defer a.Stop()
+	for i := 0; i < 3; i++ {
+		a.Publish([]logs.LogEvent{evtMock{m: "msg", t: time.Date(2020, 8, 5, 13, 30, 0, 0, time.UTC)}})
+	}
+	waitForPuts(t, &s, 1)
+
+	s.Lock()
+	defer s.Unlock()
+	assert.Regexp(t, `^G/2020/08/05/13/S-\d+\.ndjson\.zst$`, s.keys[0])
+	assert.Equal(t, "zstd", s.encodings[0])
+	assert.Len(t, s.records[0], 3)
+}
+
+func TestNewCompressor(t *testing.T) {
+	logger := models.NewLogger("s3logs", "test", "")
+	compression, _ := newCompressor("", 0, ioutil.Discard, logger)
+	assert.Equal(t, "gzip", compression)
+	compression, _ = newCompressor("zstd", 1, ioutil.Discard, logger)
+	assert.Equal(t, "zstd", compression)
+	// an invalid gzip level falls back to the default level
+	compression, c := newCompressor("gzip", 22, ioutil.Discard, logger)
+	assert.Equal(t, "gzip", compression)
+	assert.NotNil(t, c)
+	compression, _ = newCompressor("lz4", 0, ioutil.Discard, logger)
+	assert.Equal(t, "gzip", compression)
+}
+
+func TestArchiverSplitsByHour(t *testing.T) {
+	var s s3Mock
+	a := newArchiver(logs.Target{Group: "G", Stream: "S"}, &s, "bucket", "", time.Hour, defaultMaxObjectSize, "gzip", 0, models.NewLogger("s3logs", "test", ""))
+
+	a.Publish([]logs.LogEvent{evtMock{m: "first", t: time.Date(2020, 8, 5, 13, 59, 0, 0, time.UTC)}})
+	a.Publish([]logs.LogEvent{evtMock{m: "second", t: time.Date(2020, 8, 5, 14, 0, 0, 0, time.UTC)}})
+	waitForPuts(t, &s, 1)
+	a.Stop()
+	waitForPuts(t, &s, 2)
+
+	s.Lock()
+	defer s.Unlock()
+	assert.Regexp(t, `^G/2020/08/05/13/S-\d+\.ndjson\.gz$`, s.keys[0])
+	assert.Regexp(t, `^G/2020/08/05/14/S-\d+\.ndjson\.gz$`, s.keys[1])
+	assert.Equal(t, "first", s.records[0][0].Message)
+	assert.Equal(t, "second", s.records[1][0].Message)
+}
+
+func TestArchiverFlushOnSize(t *testing.T) {
+	var s s3Mock
+	a := newArchiver(logs.Target{Group: "G", Stream: "S"}, &s, "bucket", "", time.Hour, 100, "gzip", 0, models.NewLogger("s3logs", "test", ""))
+	defer a.Stop()
+
+	et := time.Now()
+	for i := 0; i < 4; i++ {
+		a.Publish([]logs.LogEvent{evtMock{m: "0123456789012345678901234567890123456789", t: et}})
+	}
+	waitForPuts(t, &s, 2)
+
+	s.Lock()
+	defer s.Unlock()
+	assert.Len(t, s.records[0], 2)
+	assert.Len(t, s.records[1], 2)
+}
+
+func TestArchiverRetry(t *testing.T) {
+	s := s3Mock{err: errors.New("throttled")}
+	called := make(chan struct{})
+	a := newArchiver(logs.Target{Group: "G", Stream: "S"}, &s, "bucket", "", 10*time.Millisecond, defaultMaxObjectSize, "gzip", 0, models.NewLogger("s3logs", "test", ""))
+	defer a.Stop()
+
+	a.Publish([]logs.LogEvent{evtMock{m: "msg", t: time.Now(), d: func() { close(called) }}})
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("done callback not called after retry")
+	}
+	assert.Equal(t, 1, s.puts())
+}
+
+// failingCompressor fails its writes after limit bytes, or its Close
+type failingCompressor struct {
+	limit      int
+	written    int
+	closeError error
+}
+
+func (c *failingCompressor) Write(p []byte) (int, error) {
+	if c.written+len(p) > c.limit {
+		return 0, errors.New("compressor failed")
+	}
+	c.written += len(p)
+	return len(p), nil
+}
+
+func (c *failingCompressor) Close() error {
+	err := c.closeError
+	c.closeError = nil
+	return err
+}
+
+func (c *failingCompressor) Reset(io.Writer) { c.written = 0 }
+
+func TestArchiverCompressionError(t *testing.T) {
+	var s s3Mock
+	a := &archiver{Target: logs.Target{Group: "G", Stream: "S"}, Service: &s, Bucket: "bucket", MaxObjectSize: defaultMaxObjectSize,
+		Log: models.NewLogger("s3logs", "test", ""), compression: compressionGzip, compressor: &failingCompressor{limit: 50}}
+	e := evtMock{m: "msg", t: time.Now()}
+	_, err := a.Add(e)
+	require.NoError(t, err)
+	_, err = a.Add(e)
+	assert.Error(t, err)
+	// the object missing the events which could not be compressed is not uploaded, even when retried
+	assert.Error(t, a.Put())
+	assert.Error(t, a.Put())
+	assert.Equal(t, 0, s.puts())
+
+	a.Reset()
+	a.compressor = &failingCompressor{limit: 50, closeError: errors.New("flush failed")}
+	_, err = a.Add(e)
+	require.NoError(t, err)
+	assert.Error(t, a.Put())
+	assert.Error(t, a.Put(), "the truncated object is not uploaded once the close failed")
+	assert.Equal(t, 0, s.puts())
+}
+
+func TestS3DestStopped(t *testing.T) {
+	var s s3Mock
+	c := &S3Logs{
+		Bucket:        "bucket",
+		LogStreamName: "default",
+		MaxObjectSize: defaultMaxObjectSize,
+		Log:           models.NewLogger("s3logs", "test", ""),
+		svc:           &s,
+		dests:         make(map[logs.Target]*logs.Batcher),
+	}
+
+	d := c.CreateDest("G", "")
+	assert.Equal(t, d, c.CreateDest("G", "default"))
+	assert.NoError(t, d.Publish(nil))
+
+	c.Close()
+	assert.Equal(t, logs.ErrOutputStopped, d.Publish(nil))
+	// the events published once the dest stopped do not block
+	var events []logs.LogEvent
+	for i := 0; i < 200; i++ {
+		events = append(events, evtMock{m: "msg", t: time.Now()})
+	}
+	assert.Equal(t, logs.ErrOutputStopped, d.Publish(events))
+}
@@ -0,0 +1,160 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package s3logs
+
+import (
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/agentinfo"
+	configaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/handlers"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultFlushTimeout  = 5 * time.Minute
+	defaultMaxObjectSize = 64 * 1024 * 1024
+)
+
+// S3Logs is a log backend archiving the log events into gzip or zstd compressed objects in S3.
+// The objects are partitioned by log group, date and hour of the log events.
+type S3Logs struct {
+	Region               string `toml:"region"`
+	EndpointOverride     string `toml:"endpoint_override"`
+	UseDualStackEndpoint bool   `toml:"use_dualstack_endpoint"`
+	AccessKey            string `toml:"access_key"`
+	SecretKey            string `toml:"secret_key"`
+	RoleARN              string `toml:"role_arn"`
+	Profile              string `toml:"profile"`
+	Filename             string `toml:"shared_credential_file"`
+	Token                string `toml:"token"`
+
+	Bucket        string `toml:"bucket"`
+	KeyPrefix     string `toml:"key_prefix"`
+	LogStreamName string `toml:"log_stream_name"`
+	// uncompressed size at which an object is uploaded before the flush interval elapses
+	MaxObjectSize int `toml:"max_object_size"`
+	// Compression is gzip or zstd, at the CompressionLevel or the default level of the compression when it is 0
+	Compression      string `toml:"compression"`
+	CompressionLevel int    `toml:"compression_level"`
+
+	ForceFlushInterval internal.Duration `toml:"force_flush_interval"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	svc   S3Service
+	dests map[logs.Target]*logs.Batcher
+}
+
+func (c *S3Logs) Connect() error {
+	return nil
+}
+
+func (c *S3Logs) Close() error {
+	for _, d := range c.dests {
+		d.Stop()
+	}
+	return nil
+}
+
+// Write drops the metrics, only log events are archived
+func (c *S3Logs) Write(metrics []telegraf.Metric) error {
+	return nil
+}
+
+func (c *S3Logs) CreateDest(group, stream string) logs.LogDest {
+	if stream == "" {
+		stream = c.LogStreamName
+	}
+	t := logs.Target{Group: group, Stream: stream}
+	if d, ok := c.dests[t]; ok {
+		return d
+	}
+
+	d := newArchiver(t, c.service(), c.Bucket, c.KeyPrefix, c.ForceFlushInterval.Duration, c.MaxObjectSize, c.Compression, c.CompressionLevel, c.Log)
+	c.dests[t] = d
+	return d
+}
+
+func (c *S3Logs) service() S3Service {
+	if c.svc != nil {
+		return c.svc
+	}
+	credentialConfig := &configaws.CredentialConfig{
+		Region:    c.Region,
+		AccessKey: c.AccessKey,
+		SecretKey: c.SecretKey,
+		RoleARN:   c.RoleARN,
+		Profile:   c.Profile,
+		Filename:  c.Filename,
+		Token:     c.Token,
+	}
+	client := s3.New(
+		credentialConfig.Credentials(),
+		&aws.Config{
+			Endpoint:     aws.String(c.EndpointOverride),
+			UseDualStack: aws.Bool(c.UseDualStackEndpoint),
+			HTTPClient:   configaws.HTTPClient(5 * time.Minute),
+		},
+	)
+	client.Handlers.Build.PushBackNamed(handlers.NewCustomHeaderHandler("User-Agent", agentinfo.UserAgent()))
+	handlers.AddCircuitBreakerHandlers(&client.Handlers)
+	c.svc = client
+	return c.svc
+}
+
+// Description returns a one-sentence description on the Output
+func (c *S3Logs) Description() string {
+	return "Configuration for archiving logs into AWS S3."
+}
+
+var sampleConfig = `
+  ## Amazon REGION
+  region = "us-east-1"
+
+  ## Amazon Credentials, loaded in the same order as the cloudwatchlogs output
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## The bucket the log objects are written to, objects are named
+  ## <key_prefix>/<log_group_name>/<yyyy>/<mm>/<dd>/<hh>/<log_stream_name>-<unix_nano>.ndjson.gz,
+  ## or .ndjson.zst with the zstd compression
+  bucket = "my-log-archive"
+  #key_prefix = "cwagent"
+
+  ## Max time to wait before uploading an object
+  #force_flush_interval = "5m"
+
+  ## Upload the object earlier once this many uncompressed bytes are buffered
+  #max_object_size = 67108864
+
+  ## The compression of the objects, gzip or zstd, and its level, 1 to 9 for gzip and
+  ## 1 (fastest) to 22 for zstd. zstd costs noticeably less CPU than gzip for a similar ratio.
+  #compression = "gzip"
+  #compression_level = 3
+`
+
+// SampleConfig returns the default configuration of the Output
+func (c *S3Logs) SampleConfig() string {
+	return sampleConfig
+}
+
+func init() {
+	outputs.Add("s3logs", func() telegraf.Output {
+		return &S3Logs{
+			ForceFlushInterval: internal.Duration{Duration: defaultFlushTimeout},
+			MaxObjectSize:      defaultMaxObjectSize,
+			dests:              make(map[logs.Target]*logs.Batcher),
+		}
+	})
+}
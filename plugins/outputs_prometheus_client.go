@@ -0,0 +1,8 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !custom outputs outputs.prometheus_client
+
+package plugins
+
+import _ "github.com/influxdata/telegraf/plugins/outputs/prometheus_client"
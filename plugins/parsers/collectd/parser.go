@@ -0,0 +1,153 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package collectd reloads the typesdb files of the collectd parsers when they change, so that the plugins installing
+// their own types do not require a restart of the agent.
+package collectd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// Parser parses the collectd packets with the types of the typesdb paths, the files and the files of the directories,
+// and reloads them when they change
+type Parser struct {
+	AuthFile      string
+	SecurityLevel string
+	Split         string
+	Paths         []string
+
+	mu          sync.RWMutex
+	parser      parsers.Parser
+	defaultTags map[string]string
+	fingerprint string
+}
+
+// NewParser returns the parser of the typesdb paths, it fails when they cannot be loaded
+func NewParser(authFile, securityLevel, split string, paths []string) (*Parser, error) {
+	p := &Parser{AuthFile: authFile, SecurityLevel: securityLevel, Split: split, Paths: paths}
+	if _, err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.parser.Parse(buf)
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.parser.ParseLine(line)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaultTags = tags
+	p.parser.SetDefaultTags(tags)
+}
+
+// reload loads the typesdb files again when any of them was added, removed or modified since the last load, it
+// returns whether they were reloaded. The previous types are kept on failure.
+func (p *Parser) reload() (bool, error) {
+	files := TypesDBFiles(p.Paths)
+	fingerprint := fingerprintOf(files)
+	p.mu.RLock()
+	unchanged := p.parser != nil && fingerprint == p.fingerprint
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	parser, err := parsers.NewCollectdParser(p.AuthFile, p.SecurityLevel, files, p.Split)
+	if err != nil {
+		return false, fmt.Errorf("unable to load the collectd typesdb files %v: %v", files, err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.defaultTags != nil {
+		parser.SetDefaultTags(p.defaultTags)
+	}
+	p.parser = parser
+	p.fingerprint = fingerprint
+	return true, nil
+}
+
+// Run reloads the typesdb files every interval until the context is done
+func (p *Parser) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := p.reload()
+		if err != nil {
+			log.Printf("E! collectd: %v, the previous types are kept", err)
+		} else if reloaded {
+			log.Printf("I! collectd: reloaded the typesdb files of %v", p.Paths)
+		}
+	}
+}
+
+// TypesDBFiles returns the typesdb files of the paths, the regular files of the directories are sorted by name and
+// the missing paths are skipped
+func TypesDBFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("D! collectd: skipping the typesdb path %v: %v", path, err)
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			log.Printf("W! collectd: unable to list the typesdb directory %v: %v", path, err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Mode().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+func fingerprintOf(files []string) string {
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collectd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypesDBFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typesdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	custom := filepath.Join(dir, "custom")
+	require.NoError(t, os.Mkdir(custom, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(custom, "b.db"), []byte("btype value:GAUGE:0:U\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(custom, "a.db"), []byte("atype value:GAUGE:0:U\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(custom, ".hidden"), []byte(""), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(custom, "nested"), 0755))
+	types := filepath.Join(dir, "types.db")
+	require.NoError(t, ioutil.WriteFile(types, []byte("mytype value:GAUGE:0:U\n"), 0644))
+
+	files := TypesDBFiles([]string{types, custom, filepath.Join(dir, "missing")})
+	assert.Equal(t, []string{types, filepath.Join(custom, "a.db"), filepath.Join(custom, "b.db")}, files)
+}
+
+func TestParserReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typesdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "types.db"), []byte("mytype value:GAUGE:0:U\n"), 0644))
+
+	p, err := NewParser("", "none", "split", []string{dir})
+	require.NoError(t, err)
+	p.SetDefaultTags(map[string]string{"metricPath": "metrics"})
+
+	reloaded, err := p.reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// a type added by a plugin installed later
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.db"), []byte("plugintype value:GAUGE:0:U\n"), 0644))
+	reloaded, err = p.reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+
+	// a type removed with its plugin
+	require.NoError(t, os.Remove(filepath.Join(dir, "plugin.db")))
+	reloaded, err = p.reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, map[string]string{"metricPath": "metrics"}, p.defaultTags)
+}
@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collectd
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+const socketListenerInput = "socket_listener"
+
+// WatchTypesDB replaces the collectd parsers of the socket listeners of the config file with parsers reloading the
+// typesdb paths of CWAGENT_COLLECTD_TYPESDB, set by the translator, until the context is done. The TOML config only
+// lists the typesdb files which existed when it was translated, since the collectd parser of telegraf cannot load
+// directories. It must be called before the agent starts the inputs.
+func WatchTypesDB(ctx context.Context, configPath string, inputs []*models.RunningInput) {
+	paths := filepath.SplitList(os.Getenv(envconfig.CWAGENT_COLLECTD_TYPESDB))
+	if len(paths) == 0 {
+		return
+	}
+	tables := collectdSocketListenerTables(configPath)
+	i := 0
+	for _, input := range inputs {
+		if input.Config.Name != socketListenerInput {
+			continue
+		}
+		parserInput, ok := input.Input.(parsers.ParserInput)
+		if !ok || i >= len(tables) {
+			continue
+		}
+		table := tables[i]
+		i++
+		if stringField(table, "data_format") != "collectd" {
+			continue
+		}
+		parser, err := NewParser(stringField(table, "collectd_auth_file"), stringField(table, "collectd_security_level"),
+			stringField(table, "collectd_parse_multivalue"), paths)
+		if err != nil {
+			log.Printf("E! collectd: %v, the typesdb files are not reloaded", err)
+			continue
+		}
+		parserInput.SetParser(parser)
+		go parser.Run(ctx, defaultReloadInterval)
+		log.Printf("I! collectd: reloading the typesdb files of %v when they change", paths)
+	}
+}
+
+// collectdSocketListenerTables returns the socket listener tables of the config file in order, as the inputs loaded by
+// telegraf
+func collectdSocketListenerTables(configPath string) []*ast.Table {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		log.Printf("E! collectd: unable to read the config %v: %v", configPath, err)
+		return nil
+	}
+	root, err := toml.Parse(data)
+	if err != nil {
+		log.Printf("E! collectd: unable to parse the config %v: %v", configPath, err)
+		return nil
+	}
+	inputsTable, ok := root.Fields["inputs"].(*ast.Table)
+	if !ok {
+		return nil
+	}
+	tables, _ := inputsTable.Fields[socketListenerInput].([]*ast.Table)
+	return tables
+}
+
+func stringField(table *ast.Table, name string) string {
+	if kv, ok := table.Fields[name].(*ast.KeyValue); ok {
+		if s, ok := kv.Value.(*ast.String); ok {
+			return s.Value
+		}
+	}
+	return ""
+}
@@ -1,47 +1,40 @@
 // Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
 // SPDX-License-Identifier: MIT
 
+// Package plugins enables the plugins compiled into the agent, each plugin in its own file.
+//
+// All the plugins are compiled in by default. Building with the custom tag only compiles in the plugins allowed by
+// the other tags, a kind of plugins, e.g. "outputs", or a plugin named after its section, e.g. "inputs.cpu":
+//   go build -tags "custom inputs.cpu inputs.logfile outputs.cloudwatch outputs.cloudwatchlogs processors"
 package plugins
 
 import (
-	//Enable cloudwatch-agent process plugins
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/processors/delta"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/processors/ec2tagger"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/processors/ecsdecorator"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/processors/emfProcessor"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/processors/k8sdecorator"
+	"sort"
+
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/processors"
 
 	// Enabled parsers registry
 	_ "github.com/aws/amazon-cloudwatch-agent/plugins/parsers"
-
-	// Enabled cloudwatch-agent input plugins
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/awscsm"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/cadvisor"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/demo"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/k8sapiserver"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/logfile"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/prometheus_scraper"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/statsd"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/win_perf_counters"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/inputs/windows_event_log"
-
-	// Enabled cloudwatch-agent output plugins
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/outputs/awscsm"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/outputs/cloudwatch"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/outputs/cloudwatchlogs"
-	_ "github.com/aws/amazon-cloudwatch-agent/plugins/outputs/console"
-
-	// Enabled telegraf input plugins
-	// NOTE: any plugins that are dependencies of the plugins enabled will be enabled too
-	// e.g.: cpu plguin from telegraf would enable the system plugin as its dependency
-	_ "github.com/influxdata/telegraf/plugins/inputs/cpu"
-	_ "github.com/influxdata/telegraf/plugins/inputs/disk"
-	_ "github.com/influxdata/telegraf/plugins/inputs/diskio"
-	_ "github.com/influxdata/telegraf/plugins/inputs/ethtool"
-	_ "github.com/influxdata/telegraf/plugins/inputs/mem"
-	_ "github.com/influxdata/telegraf/plugins/inputs/net"
-	_ "github.com/influxdata/telegraf/plugins/inputs/processes"
-	_ "github.com/influxdata/telegraf/plugins/inputs/procstat"
-	_ "github.com/influxdata/telegraf/plugins/inputs/socket_listener"
-	_ "github.com/influxdata/telegraf/plugins/inputs/swap"
 )
+
+// List returns the plugins compiled in, named after their sections as in the build tags, e.g. "inputs.cpu"
+func List() []string {
+	var names []string
+	for name := range inputs.Inputs {
+		names = append(names, "inputs."+name)
+	}
+	for name := range outputs.Outputs {
+		names = append(names, "outputs."+name)
+	}
+	for name := range processors.Processors {
+		names = append(names, "processors."+name)
+	}
+	for name := range aggregators.Aggregators {
+		names = append(names, "aggregators."+name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !custom
+
+package plugins
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	names := List()
+	assert.True(t, sort.StringsAreSorted(names))
+	// the plugins are named after their sections, as in the build tags
+	assert.Contains(t, names, "inputs.logfile")
+	assert.Contains(t, names, "inputs.cpu")
+	assert.Contains(t, names, "outputs.cloudwatch")
+	assert.Contains(t, names, "processors.ec2tagger")
+}
@@ -0,0 +1,510 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const (
+	modeThreshold = "threshold"
+	modeEWMA      = "ewma"
+
+	defaultAlpha          = 0.3
+	defaultDeviations     = 3.0
+	defaultWarmup         = 10
+	defaultTriggerCount   = 3
+	defaultClearCount     = 3
+	defaultCommandTimeout = 30 * time.Second
+	// maxSeries bounds the series tracked by the rules, the metrics of the new series are not evaluated once it is
+	// reached until the series no longer collected expire
+	maxSeries = 10000
+	// seriesExpiry is how long a series is tracked after its last datapoint
+	seriesExpiry = time.Hour
+
+	stateAlarm = "ALARM"
+	stateOK    = "OK"
+)
+
+// Rule detects the anomalies of a metric, by comparing its values to the thresholds or to the exponentially weighted
+// moving average of its previous values. The state changes after a number of consecutive anomalous or normal
+// datapoints, so that a single outlier does not trigger the actions and a flapping metric does not trigger them at
+// every datapoint.
+type Rule struct {
+	Name string `toml:"name"`
+	// Metric is the name of the measurement and of the field joined by "_", e.g. mem_used_percent
+	Metric string `toml:"metric"`
+	// Tags are the tags the metrics must have, all the series of the metric are evaluated when empty
+	Tags map[string]string `toml:"tags"`
+	// Mode is threshold or ewma, threshold by default
+	Mode  string   `toml:"mode"`
+	Above *float64 `toml:"above"`
+	Below *float64 `toml:"below"`
+	// Alpha is the weight of the latest value in the moving average
+	Alpha float64 `toml:"alpha"`
+	// Deviations is how many standard deviations from the moving average a value is anomalous at
+	Deviations float64 `toml:"deviations"`
+	// Warmup is the number of datapoints the moving average is computed from before the values are evaluated
+	Warmup int `toml:"warmup"`
+	// TriggerCount is the number of consecutive anomalous datapoints changing the state to ALARM
+	TriggerCount int `toml:"trigger_count"`
+	// ClearCount is the number of consecutive normal datapoints changing the state back to OK
+	ClearCount int `toml:"clear_count"`
+	// Command is run with its arguments on every change of the state
+	Command []string `toml:"command"`
+	// SNSTopicARN is the topic the changes of the state are published to
+	SNSTopicARN string `toml:"sns_topic_arn"`
+}
+
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("a rule has no name")
+	}
+	if r.Metric == "" {
+		return fmt.Errorf("the rule %v has no metric", r.Name)
+	}
+	switch r.Mode {
+	case "":
+		r.Mode = modeThreshold
+		fallthrough
+	case modeThreshold:
+		if r.Above == nil && r.Below == nil {
+			return fmt.Errorf("the threshold rule %v has neither above nor below", r.Name)
+		}
+	case modeEWMA:
+		if r.Alpha == 0 {
+			r.Alpha = defaultAlpha
+		}
+		if r.Alpha < 0 || r.Alpha > 1 {
+			return fmt.Errorf("the alpha %v of the rule %v is not between 0 and 1", r.Alpha, r.Name)
+		}
+		if r.Deviations <= 0 {
+			r.Deviations = defaultDeviations
+		}
+		if r.Warmup <= 0 {
+			r.Warmup = defaultWarmup
+		}
+	default:
+		return fmt.Errorf("unsupported mode %q of the rule %v, expecting threshold or ewma", r.Mode, r.Name)
+	}
+	if r.TriggerCount <= 0 {
+		r.TriggerCount = defaultTriggerCount
+	}
+	if r.ClearCount <= 0 {
+		r.ClearCount = defaultClearCount
+	}
+	return nil
+}
+
+func (r *Rule) matches(metric telegraf.Metric, field string) bool {
+	if metric.Name()+"_"+field != r.Metric {
+		return false
+	}
+	for k, v := range r.Tags {
+		if tv, ok := metric.GetTag(k); !ok || tv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// series is the state of a rule for the series of the metric with a set of tags
+type series struct {
+	lastSeen time.Time
+	alarm    bool
+	// streak is the number of consecutive datapoints contradicting the state
+	streak   int
+	count    int
+	mean     float64
+	variance float64
+}
+
+// Event is a change of the state of a rule, it is the message published to the topic and the JSON given to the
+// command in the ANOMALY_EVENT environment variable
+type Event struct {
+	Rule   string            `json:"rule"`
+	State  string            `json:"state"`
+	Metric string            `json:"metric"`
+	Tags   map[string]string `json:"tags"`
+	Value  float64           `json:"value"`
+	// Expected is the moving average of the ewma rules
+	Expected *float64  `json:"expected,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+type Anomaly struct {
+	Rules []Rule `toml:"rule"`
+	// CommandTimeout is how long the commands run before they are killed
+	CommandTimeout internal.Duration `toml:"command_timeout"`
+
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	RoleARN   string `toml:"role_arn"`
+	Profile   string `toml:"profile"`
+	Filename  string `toml:"shared_credential_file"`
+	Token     string `toml:"token"`
+
+	mu          sync.Mutex
+	series      map[string]*series
+	seriesFull  bool
+	lastExpiry  time.Time
+	now         func() time.Time
+	sns         snsiface.SNSAPI
+	newSNS      func(*internalaws.CredentialConfig) snsiface.SNSAPI
+	runCommand  func(ctx context.Context, command []string, env []string) ([]byte, error)
+	actionsDone sync.WaitGroup
+}
+
+var sampleConfig = `
+  ## How long the commands of the rules run before they are killed
+  # command_timeout = "30s"
+
+  ## The region and the credentials of the SNS topics
+  # region = "us-east-1"
+  # role_arn = ""
+  # profile = ""
+  # shared_credential_file = ""
+
+  [[processors.anomaly.rule]]
+    name = "memory-exhaustion"
+    ## The name of the measurement and of the field joined by "_"
+    metric = "mem_used_percent"
+    ## The state changes when a value is above or below the thresholds
+    mode = "threshold"
+    above = 95.0
+    ## The state changes to ALARM after trigger_count consecutive anomalous datapoints, and back to OK after
+    ## clear_count consecutive normal ones
+    # trigger_count = 3
+    # clear_count = 3
+    ## Run with its arguments on every change of the state, the event is in the ANOMALY_EVENT environment variable
+    command = ["/usr/local/bin/restart-worker.sh"]
+
+  [[processors.anomaly.rule]]
+    name = "latency-spike"
+    metric = "nginx_request_time"
+    ## The state changes when a value is more than deviations standard deviations away from the exponentially
+    ## weighted moving average of the previous values
+    mode = "ewma"
+    # alpha = 0.3
+    # deviations = 3.0
+    # warmup = 10
+    sns_topic_arn = "arn:aws:sns:us-east-1:123456789012:on-call"
+    [processors.anomaly.rule.tags]
+      host = "web-1"
+`
+
+func (a *Anomaly) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *Anomaly) Description() string {
+	return "Detect the anomalies of metrics on the host and run local actions within seconds, until the CloudWatch alarms evaluate"
+}
+
+func (a *Anomaly) Init() error {
+	needsSNS := false
+	for i := range a.Rules {
+		if err := a.Rules[i].validate(); err != nil {
+			return fmt.Errorf("anomaly: %v", err)
+		}
+		needsSNS = needsSNS || a.Rules[i].SNSTopicARN != ""
+	}
+	if needsSNS && a.sns == nil {
+		a.sns = a.newSNS(a.credentialConfig())
+	}
+	a.series = map[string]*series{}
+	if a.now == nil {
+		a.now = time.Now
+	}
+	a.lastExpiry = a.now()
+	return nil
+}
+
+// Stop waits for the actions running in the background, for at most the command timeout since the commands are killed
+// at it
+func (a *Anomaly) Stop() error {
+	done := make(chan struct{})
+	go func() {
+		a.actionsDone.Wait()
+		close(done)
+	}()
+	timeout := a.CommandTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("W! anomaly: the actions of the rules are still running after %v, they are abandoned", timeout)
+	}
+	return nil
+}
+
+func (a *Anomaly) credentialConfig() *internalaws.CredentialConfig {
+	return &internalaws.CredentialConfig{
+		Region:    a.Region,
+		AccessKey: a.AccessKey,
+		SecretKey: a.SecretKey,
+		RoleARN:   a.RoleARN,
+		Profile:   a.Profile,
+		Filename:  a.Filename,
+		Token:     a.Token,
+	}
+}
+
+// PreflightIAM checks that the changes of the state can be published to the topics of the rules
+func (a *Anomaly) PreflightIAM() []preflight.Check {
+	var topics []string
+	seen := map[string]bool{}
+	for _, r := range a.Rules {
+		if r.SNSTopicARN != "" && !seen[r.SNSTopicARN] {
+			seen[r.SNSTopicARN] = true
+			topics = append(topics, r.SNSTopicARN)
+		}
+	}
+	if len(topics) == 0 {
+		return nil
+	}
+	return preflight.CheckIAMActionsOn(a.credentialConfig().Credentials(), []string{"sns:Publish"}, preflight.StaticARNs(topics...))
+}
+
+// HasActions tells whether a rule runs a command or publishes to a topic, i.e. whether the processor acts outside of
+// the agent on the metrics
+func (a *Anomaly) HasActions() bool {
+	for _, r := range a.Rules {
+		if len(r.Command) > 0 || r.SNSTopicARN != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply evaluates the fields of the metrics against the rules, the metrics are passed through unchanged
+func (a *Anomaly) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+			for i := range a.Rules {
+				if a.Rules[i].matches(metric, field.Key) {
+					a.evaluate(&a.Rules[i], metric, value)
+				}
+			}
+		}
+	}
+	return in
+}
+
+func (a *Anomaly) evaluate(r *Rule, metric telegraf.Metric, value float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	if now.Sub(a.lastExpiry) >= seriesExpiry {
+		a.expireSeries(now)
+	}
+	key := r.Name + "\x00" + tagsKey(metric.Tags())
+	s, ok := a.series[key]
+	if !ok {
+		if len(a.series) >= maxSeries {
+			if !a.seriesFull {
+				log.Printf("W! anomaly: %v series are tracked already, the new series are not evaluated", maxSeries)
+				a.seriesFull = true
+			}
+			return
+		}
+		s = &series{}
+		a.series[key] = s
+	}
+	s.lastSeen = now
+
+	anomalous, evaluated := false, true
+	var expected *float64
+	switch r.Mode {
+	case modeThreshold:
+		anomalous = (r.Above != nil && value > *r.Above) || (r.Below != nil && value < *r.Below)
+	case modeEWMA:
+		if s.count >= r.Warmup {
+			mean := s.mean
+			expected = &mean
+			anomalous = math.Abs(value-s.mean) > r.Deviations*math.Sqrt(s.variance)
+		} else {
+			evaluated = false
+		}
+		// the moving average follows the values in alarm too, so that a lasting change of the level clears
+		if s.count == 0 {
+			s.mean = value
+		} else {
+			diff := value - s.mean
+			incr := r.Alpha * diff
+			s.mean += incr
+			s.variance = (1 - r.Alpha) * (s.variance + diff*incr)
+		}
+		s.count++
+	}
+	if !evaluated {
+		return
+	}
+
+	if anomalous == s.alarm {
+		s.streak = 0
+		return
+	}
+	s.streak++
+	if (anomalous && s.streak < r.TriggerCount) || (!anomalous && s.streak < r.ClearCount) {
+		return
+	}
+	s.alarm, s.streak = anomalous, 0
+	event := Event{Rule: r.Name, State: stateOK, Metric: r.Metric, Tags: metric.Tags(), Value: value, Expected: expected, Time: metric.Time()}
+	if anomalous {
+		event.State = stateAlarm
+		log.Printf("W! anomaly: the rule %v is in ALARM, %v is %v with the tags %v", r.Name, r.Metric, value, event.Tags)
+	} else {
+		log.Printf("I! anomaly: the rule %v is OK again, %v is %v with the tags %v", r.Name, r.Metric, value, event.Tags)
+	}
+	a.runActions(r, event)
+}
+
+// expireSeries forgets the series without datapoints for seriesExpiry, e.g. of the processes or the containers gone
+func (a *Anomaly) expireSeries(now time.Time) {
+	for key, s := range a.series {
+		if now.Sub(s.lastSeen) >= seriesExpiry {
+			delete(a.series, key)
+		}
+	}
+	if a.seriesFull && len(a.series) < maxSeries {
+		log.Printf("I! anomaly: the expired series were forgotten, the new series are evaluated again")
+		a.seriesFull = false
+	}
+	a.lastExpiry = now
+}
+
+// runActions runs the command and publishes to the topic of the rule in the background, so that the metrics are not
+// delayed by the actions
+func (a *Anomaly) runActions(r *Rule, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("E! anomaly: unable to encode the event of the rule %v: %v", r.Name, err)
+		return
+	}
+	if len(r.Command) > 0 {
+		a.actionsDone.Add(1)
+		go func(command []string) {
+			defer a.actionsDone.Done()
+			timeout := a.CommandTimeout.Duration
+			if timeout <= 0 {
+				timeout = defaultCommandTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			env := []string{
+				"ANOMALY_RULE=" + event.Rule,
+				"ANOMALY_STATE=" + event.State,
+				"ANOMALY_METRIC=" + event.Metric,
+				fmt.Sprintf("ANOMALY_VALUE=%v", event.Value),
+				"ANOMALY_EVENT=" + string(data),
+			}
+			if output, err := a.runCommand(ctx, command, env); err != nil {
+				log.Printf("E! anomaly: the command of the rule %v failed: %v: %s", event.Rule, err, truncate(output))
+			} else {
+				log.Printf("D! anomaly: the command of the rule %v succeeded: %s", event.Rule, truncate(output))
+			}
+		}(r.Command)
+	}
+	if r.SNSTopicARN != "" && a.sns != nil {
+		a.actionsDone.Add(1)
+		go func(topic string) {
+			defer a.actionsDone.Done()
+			_, err := a.sns.Publish(&sns.PublishInput{
+				TopicArn: aws.String(topic),
+				Subject:  aws.String(fmt.Sprintf("%v is %v", event.Rule, event.State)),
+				Message:  aws.String(string(data)),
+			})
+			if err != nil {
+				log.Printf("E! anomaly: unable to publish the event of the rule %v to %v: %v", event.Rule, topic, err)
+			}
+		}(r.SNSTopicARN)
+	}
+}
+
+func runCommand(ctx context.Context, command []string, env []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.CombinedOutput()
+}
+
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int64:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case bool:
+		if t {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func truncate(output []byte) string {
+	s := strings.TrimSpace(string(output))
+	if len(s) > 256 {
+		return s[:256] + "..."
+	}
+	return s
+}
+
+func init() {
+	processors.Add("anomaly", func() telegraf.Processor {
+		return &Anomaly{
+			CommandTimeout: internal.Duration{Duration: defaultCommandTimeout},
+			newSNS: func(c *internalaws.CredentialConfig) snsiface.SNSAPI {
+				return sns.New(c.Credentials())
+			},
+			runCommand: runCommand,
+			now:        time.Now,
+		}
+	})
+}
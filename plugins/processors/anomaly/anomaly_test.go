@@ -0,0 +1,246 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSNS struct {
+	snsiface.SNSAPI
+	mu        sync.Mutex
+	published []*sns.PublishInput
+}
+
+func (m *mockSNS) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, input)
+	return &sns.PublishOutput{}, nil
+}
+
+type commands struct {
+	mu   sync.Mutex
+	envs [][]string
+}
+
+func (c *commands) run(_ context.Context, _ []string, env []string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.envs = append(c.envs, env)
+	return nil, nil
+}
+
+func newAnomaly(t *testing.T, config string) (*Anomaly, *mockSNS, *commands) {
+	a := &Anomaly{}
+	require.NoError(t, toml.Unmarshal([]byte(config), a))
+	s, c := &mockSNS{}, &commands{}
+	a.newSNS = func(*internalaws.CredentialConfig) snsiface.SNSAPI { return s }
+	a.runCommand = c.run
+	require.NoError(t, a.Init())
+	return a, s, c
+}
+
+func memMetric(host string, value float64) telegraf.Metric {
+	return testutil.MustMetric("mem", map[string]string{"host": host}, map[string]interface{}{"used_percent": value}, time.Unix(0, 0))
+}
+
+func TestThreshold(t *testing.T) {
+	a, s, c := newAnomaly(t, `
+[[rule]]
+  name = "memory"
+  metric = "mem_used_percent"
+  above = 90.0
+  trigger_count = 2
+  clear_count = 2
+  command = ["/bin/true"]
+  sns_topic_arn = "arn:aws:sns:us-east-1:123456789012:ops"
+  [rule.tags]
+    host = "a"
+`)
+	// the single outliers and the other hosts do not trigger the actions
+	for _, v := range []float64{50, 95, 50, 95} {
+		out := a.Apply(memMetric("a", v), memMetric("b", 99))
+		assert.Len(t, out, 2)
+	}
+	a.actionsDone.Wait()
+	assert.Empty(t, c.envs)
+
+	for _, v := range []float64{96, 97, 50, 98, 40, 30} {
+		a.Apply(memMetric("a", v))
+	}
+	a.actionsDone.Wait()
+	require.Len(t, c.envs, 2)
+	assert.Contains(t, c.envs[0], "ANOMALY_STATE=ALARM")
+	assert.Contains(t, c.envs[0], "ANOMALY_VALUE=96")
+	assert.Contains(t, c.envs[1], "ANOMALY_STATE=OK")
+	require.Len(t, s.published, 2)
+	var events []Event
+	for _, p := range s.published {
+		var event Event
+		require.NoError(t, json.Unmarshal([]byte(*p.Message), &event))
+		events = append(events, event)
+	}
+	assert.ElementsMatch(t, []string{"ALARM", "OK"}, []string{events[0].State, events[1].State})
+	for _, event := range events {
+		assert.Equal(t, "memory", event.Rule)
+		assert.Equal(t, map[string]string{"host": "a"}, event.Tags)
+	}
+}
+
+func TestEWMA(t *testing.T) {
+	a, _, c := newAnomaly(t, `
+[[rule]]
+  name = "latency"
+  metric = "mem_used_percent"
+  mode = "ewma"
+  warmup = 5
+  trigger_count = 1
+  clear_count = 1
+  command = ["/bin/true"]
+`)
+	for _, v := range []float64{10, 11, 10, 9, 10, 11, 10, 9} {
+		a.Apply(memMetric("a", v))
+	}
+	a.actionsDone.Wait()
+	assert.Empty(t, c.envs)
+
+	a.Apply(memMetric("a", 100))
+	a.actionsDone.Wait()
+	require.Len(t, c.envs, 1)
+	assert.Contains(t, c.envs[0], "ANOMALY_STATE=ALARM")
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(c.envs[0][4], "ANOMALY_EVENT=")), &event))
+	require.NotNil(t, event.Expected)
+	assert.InDelta(t, 10, *event.Expected, 1)
+}
+
+func TestInit(t *testing.T) {
+	for config, err := range map[string]string{
+		`[[rule]]
+		  metric = "m"
+		  above = 1.0`: "a rule has no name",
+		`[[rule]]
+		  name = "r"
+		  above = 1.0`: "the rule r has no metric",
+		`[[rule]]
+		  name = "r"
+		  metric = "m"`: "the threshold rule r has neither above nor below",
+		`[[rule]]
+		  name = "r"
+		  metric = "m"
+		  mode = "ewma"
+		  alpha = 2.0`: "the alpha 2 of the rule r is not between 0 and 1",
+		`[[rule]]
+		  name = "r"
+		  metric = "m"
+		  mode = "zscore"`: `unsupported mode "zscore" of the rule r, expecting threshold or ewma`,
+	} {
+		a := &Anomaly{}
+		require.NoError(t, toml.Unmarshal([]byte(config), a))
+		assert.EqualError(t, a.Init(), "anomaly: "+err)
+	}
+
+	a, s, _ := newAnomaly(t, `
+[[rule]]
+  name = "r"
+  metric = "m"
+  mode = "ewma"
+`)
+	assert.Nil(t, a.sns, "no client without a topic")
+	assert.Equal(t, defaultAlpha, a.Rules[0].Alpha)
+	assert.Equal(t, defaultWarmup, a.Rules[0].Warmup)
+	assert.Equal(t, defaultTriggerCount, a.Rules[0].TriggerCount)
+	assert.Empty(t, s.published)
+}
+
+func TestHasActions(t *testing.T) {
+	a, _, _ := newAnomaly(t, `
+[[rule]]
+  name = "r"
+  metric = "m"
+  above = 1.0
+`)
+	assert.False(t, a.HasActions())
+	a, _, _ = newAnomaly(t, `
+[[rule]]
+  name = "r"
+  metric = "m"
+  above = 1.0
+  command = ["true"]
+`)
+	assert.True(t, a.HasActions())
+	a, _, _ = newAnomaly(t, `
+[[rule]]
+  name = "r"
+  metric = "m"
+  above = 1.0
+  sns_topic_arn = "arn:aws:sns:us-east-1:123456789012:topic"
+`)
+	assert.True(t, a.HasActions())
+}
+
+func TestSeriesExpiry(t *testing.T) {
+	a, _, _ := newAnomaly(t, `
+[[rule]]
+  name = "memory"
+  metric = "mem_used_percent"
+  above = 90.0
+`)
+	now := time.Unix(0, 0)
+	a.now = func() time.Time { return now }
+	a.lastExpiry = now
+	for i := 0; i < maxSeries; i++ {
+		a.Apply(memMetric(strconv.Itoa(i), 50))
+	}
+	a.Apply(memMetric("new", 50))
+	assert.Len(t, a.series, maxSeries)
+	assert.True(t, a.seriesFull)
+
+	// the series still collected are kept, the others expire and leave room for the new series
+	now = now.Add(seriesExpiry / 2)
+	a.Apply(memMetric("0", 50))
+	now = now.Add(seriesExpiry / 2)
+	a.Apply(memMetric("new", 50))
+	assert.Len(t, a.series, 2)
+	assert.False(t, a.seriesFull)
+}
+
+func TestStopWaitsForActions(t *testing.T) {
+	a, _, _ := newAnomaly(t, `
+[[rule]]
+  name = "memory"
+  metric = "mem_used_percent"
+  above = 90.0
+  trigger_count = 1
+  command = ["/bin/true"]
+`)
+	release := make(chan struct{})
+	var done int32
+	a.runCommand = func(context.Context, []string, []string) ([]byte, error) {
+		<-release
+		atomic.StoreInt32(&done, 1)
+		return nil, nil
+	}
+	a.Apply(memMetric("a", 95))
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	assert.NoError(t, a.Stop())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&done))
+}
@@ -4,7 +4,9 @@
 package delta
 
 import (
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
 	"github.com/influxdata/telegraf"
+	tmetric "github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"log"
 	"reflect"
@@ -16,6 +18,12 @@ const (
 	IgnoredFieldsForDelta string = "ignored_fields_for_delta"
 	FieldSeparator        string = ","
 	TrueValue             string = "true"
+
+	// the counter_resets field of the cwagent metric, i.e. cwagent_counter_resets, is the number of the fields of a
+	// metric reset since its previous value, the metric it counts is in its metric tag
+	resetsMeasurement string = "cwagent"
+	resetsField       string = "counter_resets"
+	resetsMetricTag   string = "metric"
 )
 
 type metricFields struct {
@@ -32,7 +40,7 @@ func copyMetricFields(metric telegraf.Metric) *metricFields {
 	}
 	for _, field := range metric.FieldList() {
 		fv, ok := metric.GetField(field.Key)
-		if ok {
+		if _, isDistribution := fv.(distribution.Distribution); ok && !isDistribution {
 			metricFieldsAndTime.fields[field.Key] = fv
 		}
 	}
@@ -50,28 +58,39 @@ func (d *Delta) Description() string {
 	return "Output the delta between current value and previous value."
 }
 
-func diff(num1 interface{}, num2 interface{}) interface{} {
+// diff returns the delta of the current value num1 since the previous value num2, the counter was reset, e.g. by a
+// reboot, when it went down and the delta is then its current value rather than a negative spike
+func diff(num1 interface{}, num2 interface{}) (interface{}, bool) {
 	num1Int, ok1 := num1.(int64)
 	num2Int, ok2 := num2.(int64)
 	if ok1 && ok2 {
-		return num1Int - num2Int
+		if num1Int < num2Int {
+			return num1Int, true
+		}
+		return num1Int - num2Int, false
 	}
 
 	num1Uint, ok1 := num1.(uint64)
 	num2Uint, ok2 := num2.(uint64)
 	if ok1 && ok2 {
-		return num1Uint - num2Uint
+		if num1Uint < num2Uint {
+			return num1Uint, true
+		}
+		return num1Uint - num2Uint, false
 	}
 
 	num1Float64, ok1 := num1.(float64)
 	num2Float64, ok2 := num2.(float64)
 	if ok1 && ok2 {
-		return num1Float64 - num2Float64
+		if num1Float64 < num2Float64 {
+			return num1Float64, true
+		}
+		return num1Float64 - num2Float64, false
 	}
 
 	log.Printf("E! system: Unexpected value types: %s, %s\n",
 		reflect.TypeOf(num1), reflect.TypeOf(num2))
-	return 0
+	return 0, false
 }
 
 func isIgnoredField(metric telegraf.Metric, fieldKey string) bool {
@@ -106,11 +125,21 @@ func (d *Delta) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		}
 
 		//update cache and modify original metric in place
+		resets := 0
 		for _, field := range metric.FieldList() {
 			fv, _ := metric.GetField(field.Key)
+			if _, ok := fv.(distribution.Distribution); ok {
+				// the distributions are of the samples of the interval already, they are not counters
+				continue
+			}
 			last, ok := lastMetric.fields[field.Key]
 			if ok && !isIgnoredField(metric, field.Key) {
-				metric.AddField(field.Key, diff(fv, last))
+				delta, reset := diff(fv, last)
+				if reset {
+					log.Printf("D! delta: the counter %v of %v went down from %v to %v, it was reset", field.Key, metric.Name(), last, fv)
+					resets++
+				}
+				metric.AddField(field.Key, delta)
 			}
 			d.cache[metricID].fields[field.Key] = fv
 		}
@@ -119,11 +148,22 @@ func (d *Delta) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		metric.RemoveTag(IgnoredFieldsForDelta)
 
 		result = append(result, metric)
+		if resets > 0 {
+			result = append(result, counterResets(metric, resets))
+		}
 	}
 
 	return result
 }
 
+// counterResets returns the cwagent_counter_resets metric of the metric, with its tags
+func counterResets(metric telegraf.Metric, resets int) telegraf.Metric {
+	tags := metric.Tags()
+	tags[resetsMetricTag] = metric.Name()
+	m, _ := tmetric.New(resetsMeasurement, tags, map[string]interface{}{resetsField: int64(resets)}, metric.Time())
+	return m
+}
+
 func init() {
 	processors.Add("delta", func() telegraf.Processor {
 		return &Delta{
@@ -4,6 +4,8 @@
 package delta
 
 import (
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution"
+	"github.com/aws/amazon-cloudwatch-agent/metric/distribution/regular"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/stretchr/testify/assert"
@@ -85,28 +87,29 @@ func createTestMetric(reportDeltaTag string, ignoredFieldsTag string) []telegraf
 	return []telegraf.Metric{metric1, metric2, metric3, metric4, metric5}
 }
 
-func computeDiffInt64(prevMetric, curMetric telegraf.Metric, fieldKey string) int64 {
-	prev, _ := prevMetric.GetField(fieldKey)
-	cur, _ := curMetric.GetField(fieldKey)
-	pv, _ := prev.(int64)
-	cv, _ := cur.(int64)
-	return cv - pv
-}
-
-func computeDiffUint64(prevMetric, curMetric telegraf.Metric, fieldKey string) uint64 {
-	prev, _ := prevMetric.GetField(fieldKey)
-	cur, _ := curMetric.GetField(fieldKey)
-	pv, _ := prev.(uint64)
-	cv, _ := cur.(uint64)
-	return cv - pv
+// splitCounterResets returns the metrics with their deltas and the counter_resets of the cwagent metrics following
+// them by index
+func splitCounterResets(metrics []telegraf.Metric) ([]telegraf.Metric, map[int]interface{}) {
+	var deltas []telegraf.Metric
+	resets := map[int]interface{}{}
+	for _, m := range metrics {
+		if m.Name() == resetsMeasurement {
+			resets[len(deltas)-1], _ = m.GetField(resetsField)
+			continue
+		}
+		deltas = append(deltas, m)
+	}
+	return deltas, resets
 }
 
-func computeDiffFloat64(prevMetric, curMetric telegraf.Metric, fieldKey string) float64 {
-	prev, _ := prevMetric.GetField(fieldKey)
-	cur, _ := curMetric.GetField(fieldKey)
-	pv, _ := prev.(float64)
-	cv, _ := cur.(float64)
-	return cv - pv
+func checkDeltas(t *testing.T, metrics []telegraf.Metric, input []telegraf.Metric, expected []map[string]interface{}) {
+	assert.Equal(t, len(expected), len(metrics))
+	for i, metric := range metrics {
+		assert.Equal(t, expected[i], metric.Fields())
+		assert.Equal(t, metric.Time(), input[i+1].Time())
+		assert.False(t, metric.HasTag(ReportDelta))
+		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
+	}
 }
 
 func checkValueInt64(t *testing.T, metric telegraf.Metric, fieldKey string, expected int64) {
@@ -121,30 +124,6 @@ func checkValueInt64(t *testing.T, metric telegraf.Metric, fieldKey string, expe
 	assert.Equal(t, expected, int64(v))
 }
 
-func checkValueUint64(t *testing.T, metric telegraf.Metric, fieldKey string, expected uint64) {
-	value, ok := metric.GetField(fieldKey)
-	if !ok {
-		assert.Fail(t, "Value is not set")
-	}
-	v, ok := value.(uint64)
-	if !ok {
-		assert.Fail(t, "Value is of wrong type")
-	}
-	assert.Equal(t, expected, uint64(v))
-}
-
-func checkValueFloat64(t *testing.T, metric telegraf.Metric, fieldKey string, expected float64) {
-	value, ok := metric.GetField(fieldKey)
-	if !ok {
-		assert.Fail(t, "Value is not set")
-	}
-	v, ok := value.(float64)
-	if !ok {
-		assert.Fail(t, "Value is of wrong type")
-	}
-	assert.Equal(t, expected, float64(v))
-}
-
 func TestReportDeltaWithSingleIgnoredField(t *testing.T) {
 	processor := Delta{make(map[uint64]*metricFields)}
 	input := createTestMetric("true", "value4")
@@ -153,23 +132,15 @@ func TestReportDeltaWithSingleIgnoredField(t *testing.T) {
 		inputCopy[i] = metric.Copy()
 	}
 
-	metrics := processor.Apply(input...)
+	metrics, resets := splitCounterResets(processor.Apply(input...))
 
-	assert.Equal(t, len(inputCopy)-1, len(metrics))
-	for i, metric := range metrics {
-		diff1 := computeDiffInt64(inputCopy[i], inputCopy[i+1], "value1")
-		checkValueInt64(t, metric, "value1", diff1)
-		diff2 := computeDiffUint64(inputCopy[i], inputCopy[i+1], "value2")
-		checkValueUint64(t, metric, "value2", diff2)
-		diff3 := computeDiffFloat64(inputCopy[i], inputCopy[i+1], "value3")
-		checkValueFloat64(t, metric, "value3", diff3)
-		fv, _ := inputCopy[i+1].GetField("value4")
-		prev, _ := fv.(int64)
-		checkValueInt64(t, metric, "value4", prev)
-		assert.Equal(t, metric.Time(), inputCopy[i+1].Time())
-		assert.False(t, metric.HasTag(ReportDelta))
-		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
-	}
+	checkDeltas(t, metrics, inputCopy, []map[string]interface{}{
+		{"value1": int64(9), "value2": uint64(100), "value3": float64(20), "value4": int64(9)},
+		{"value1": int64(0), "value2": uint64(200), "value3": float64(30), "value4": int64(13)},
+		{"value1": int64(7), "value2": uint64(150), "value3": float64(3), "value4": int64(19)},
+		{"value1": int64(1), "value2": uint64(25), "value3": float64(13), "value4": int64(25)},
+	})
+	assert.Equal(t, map[int]interface{}{1: int64(2), 2: int64(2)}, resets)
 }
 
 func TestReportDeltaWithTwoIgnoredFields(t *testing.T) {
@@ -180,24 +151,15 @@ func TestReportDeltaWithTwoIgnoredFields(t *testing.T) {
 		inputCopy[i] = metric.Copy()
 	}
 
-	metrics := processor.Apply(input...)
+	metrics, resets := splitCounterResets(processor.Apply(input...))
 
-	assert.Equal(t, len(inputCopy)-1, len(metrics))
-	for i, metric := range metrics {
-		fv1, _ := inputCopy[i+1].GetField("value1")
-		prev1, _ := fv1.(int64)
-		checkValueInt64(t, metric, "value1", prev1)
-		diff2 := computeDiffUint64(inputCopy[i], inputCopy[i+1], "value2")
-		checkValueUint64(t, metric, "value2", diff2)
-		diff3 := computeDiffFloat64(inputCopy[i], inputCopy[i+1], "value3")
-		checkValueFloat64(t, metric, "value3", diff3)
-		fv4, _ := inputCopy[i+1].GetField("value4")
-		prev4, _ := fv4.(int64)
-		checkValueInt64(t, metric, "value4", prev4)
-		assert.Equal(t, metric.Time(), inputCopy[i+1].Time())
-		assert.False(t, metric.HasTag(ReportDelta))
-		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
-	}
+	checkDeltas(t, metrics, inputCopy, []map[string]interface{}{
+		{"value1": int64(10), "value2": uint64(100), "value3": float64(20), "value4": int64(9)},
+		{"value1": int64(10), "value2": uint64(200), "value3": float64(30), "value4": int64(13)},
+		{"value1": int64(7), "value2": uint64(150), "value3": float64(3), "value4": int64(19)},
+		{"value1": int64(8), "value2": uint64(25), "value3": float64(13), "value4": int64(25)},
+	})
+	assert.Equal(t, map[int]interface{}{1: int64(2), 2: int64(1)}, resets)
 }
 
 func TestNotReportDelta(t *testing.T) {
@@ -221,24 +183,12 @@ func TestReportDeltaWithAllFieldsIgnored(t *testing.T) {
 
 	metrics := processor.Apply(input...)
 
-	assert.Equal(t, len(inputCopy)-1, len(metrics))
-	for i, metric := range metrics {
-		fv1, _ := inputCopy[i+1].GetField("value1")
-		prev1, _ := fv1.(int64)
-		checkValueInt64(t, metric, "value1", prev1)
-		fv2, _ := inputCopy[i+1].GetField("value2")
-		prev2, _ := fv2.(uint64)
-		checkValueUint64(t, metric, "value2", prev2)
-		fv3, _ := inputCopy[i+1].GetField("value3")
-		prev3, _ := fv3.(float64)
-		checkValueFloat64(t, metric, "value3", prev3)
-		fv4, _ := inputCopy[i+1].GetField("value4")
-		prev4, _ := fv4.(int64)
-		checkValueInt64(t, metric, "value4", prev4)
-		assert.Equal(t, metric.Time(), inputCopy[i+1].Time())
-		assert.False(t, metric.HasTag(ReportDelta))
-		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
-	}
+	checkDeltas(t, metrics, inputCopy, []map[string]interface{}{
+		{"value1": int64(10), "value2": uint64(300), "value3": float64(40), "value4": int64(9)},
+		{"value1": int64(10), "value2": uint64(200), "value3": float64(30), "value4": int64(13)},
+		{"value1": int64(7), "value2": uint64(150), "value3": float64(33), "value4": int64(19)},
+		{"value1": int64(8), "value2": uint64(175), "value3": float64(46), "value4": int64(25)},
+	})
 }
 
 func TestReportDeltaWithOneMetricAndWithMore(t *testing.T) {
@@ -256,22 +206,14 @@ func TestReportDeltaWithOneMetricAndWithMore(t *testing.T) {
 
 	//continue to process more metric
 	moreMetrics := input[1:]
-	metrics = processor.Apply(moreMetrics...)
-	assert.Equal(t, len(inputCopy)-1, len(metrics))
-	for i, metric := range metrics {
-		diff1 := computeDiffInt64(inputCopy[i], inputCopy[i+1], "value1")
-		checkValueInt64(t, metric, "value1", diff1)
-		diff2 := computeDiffUint64(inputCopy[i], inputCopy[i+1], "value2")
-		checkValueUint64(t, metric, "value2", diff2)
-		diff3 := computeDiffFloat64(inputCopy[i], inputCopy[i+1], "value3")
-		checkValueFloat64(t, metric, "value3", diff3)
-		fv, _ := inputCopy[i+1].GetField("value4")
-		prev, _ := fv.(int64)
-		checkValueInt64(t, metric, "value4", prev)
-		assert.Equal(t, metric.Time(), inputCopy[i+1].Time())
-		assert.False(t, metric.HasTag(ReportDelta))
-		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
-	}
+	metrics, resets := splitCounterResets(processor.Apply(moreMetrics...))
+	checkDeltas(t, metrics, inputCopy, []map[string]interface{}{
+		{"value1": int64(9), "value2": uint64(100), "value3": float64(20), "value4": int64(9)},
+		{"value1": int64(0), "value2": uint64(200), "value3": float64(30), "value4": int64(13)},
+		{"value1": int64(7), "value2": uint64(150), "value3": float64(3), "value4": int64(19)},
+		{"value1": int64(1), "value2": uint64(25), "value3": float64(13), "value4": int64(25)},
+	})
+	assert.Equal(t, map[int]interface{}{1: int64(2), 2: int64(2)}, resets)
 }
 
 func TestReportDeltaWithRandomIgnoredFields(t *testing.T) {
@@ -282,22 +224,15 @@ func TestReportDeltaWithRandomIgnoredFields(t *testing.T) {
 		inputCopy[i] = metric.Copy()
 	}
 
-	metrics := processor.Apply(input...)
+	metrics, resets := splitCounterResets(processor.Apply(input...))
 
-	assert.Equal(t, len(inputCopy)-1, len(metrics))
-	for i, metric := range metrics {
-		diff1 := computeDiffInt64(inputCopy[i], inputCopy[i+1], "value1")
-		checkValueInt64(t, metric, "value1", diff1)
-		diff2 := computeDiffUint64(inputCopy[i], inputCopy[i+1], "value2")
-		checkValueUint64(t, metric, "value2", diff2)
-		diff3 := computeDiffFloat64(inputCopy[i], inputCopy[i+1], "value3")
-		checkValueFloat64(t, metric, "value3", diff3)
-		diff4 := computeDiffInt64(inputCopy[i], inputCopy[i+1], "value4")
-		checkValueInt64(t, metric, "value4", diff4)
-		assert.Equal(t, metric.Time(), inputCopy[i+1].Time())
-		assert.False(t, metric.HasTag(ReportDelta))
-		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
-	}
+	checkDeltas(t, metrics, inputCopy, []map[string]interface{}{
+		{"value1": int64(9), "value2": uint64(100), "value3": float64(20), "value4": int64(4)},
+		{"value1": int64(0), "value2": uint64(200), "value3": float64(30), "value4": int64(4)},
+		{"value1": int64(7), "value2": uint64(150), "value3": float64(3), "value4": int64(6)},
+		{"value1": int64(1), "value2": uint64(25), "value3": float64(13), "value4": int64(6)},
+	})
+	assert.Equal(t, map[int]interface{}{1: int64(2), 2: int64(2)}, resets)
 }
 
 func TestWithRandomTags(t *testing.T) {
@@ -341,20 +276,55 @@ func TestWithOnlyReportDeltaTag(t *testing.T) {
 		inputCopy[i] = metric.Copy()
 	}
 
+	metrics, resets := splitCounterResets(processor.Apply(input...))
+
+	checkDeltas(t, metrics, inputCopy, []map[string]interface{}{
+		{"value1": int64(9), "value2": uint64(100), "value3": float64(20), "value4": int64(4)},
+		{"value1": int64(0), "value2": uint64(200), "value3": float64(30), "value4": int64(4)},
+		{"value1": int64(7), "value2": uint64(150), "value3": float64(3), "value4": int64(6)},
+		{"value1": int64(1), "value2": uint64(25), "value3": float64(13), "value4": int64(6)},
+	})
+	assert.Equal(t, map[int]interface{}{1: int64(2), 2: int64(2)}, resets)
+}
+
+func TestReportDeltaCounterReset(t *testing.T) {
+	processor := Delta{make(map[uint64]*metricFields)}
+	tags := map[string]string{"report_deltas": "true", "metric_tag": "from_metric"}
+	now := time.Now()
+	var input []telegraf.Metric
+	for i, v := range []int64{100, 40, 70} {
+		m, _ := metric.New("m1", deepCopy(tags), map[string]interface{}{"requests": v}, now.Add(time.Duration(i)*time.Minute))
+		input = append(input, m)
+	}
+
 	metrics := processor.Apply(input...)
 
-	assert.Equal(t, len(inputCopy)-1, len(metrics))
-	for i, metric := range metrics {
-		diff1 := computeDiffInt64(inputCopy[i], inputCopy[i+1], "value1")
-		checkValueInt64(t, metric, "value1", diff1)
-		diff2 := computeDiffUint64(inputCopy[i], inputCopy[i+1], "value2")
-		checkValueUint64(t, metric, "value2", diff2)
-		diff3 := computeDiffFloat64(inputCopy[i], inputCopy[i+1], "value3")
-		checkValueFloat64(t, metric, "value3", diff3)
-		diff4 := computeDiffInt64(inputCopy[i], inputCopy[i+1], "value4")
-		checkValueInt64(t, metric, "value4", diff4)
-		assert.Equal(t, metric.Time(), inputCopy[i+1].Time())
-		assert.False(t, metric.HasTag(ReportDelta))
-		assert.False(t, metric.HasTag(IgnoredFieldsForDelta))
+	// the counter went down, it counted from 0 since the reset
+	assert.Len(t, metrics, 3)
+	checkValueInt64(t, metrics[0], "requests", 40)
+	assert.Equal(t, "cwagent", metrics[1].Name())
+	assert.Equal(t, map[string]string{"metric_tag": "from_metric", "metric": "m1"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{"counter_resets": int64(1)}, metrics[1].Fields())
+	assert.Equal(t, now.Add(time.Minute), metrics[1].Time())
+	checkValueInt64(t, metrics[2], "requests", 30)
+}
+
+func TestDistributionNotReportedAsDelta(t *testing.T) {
+	processor := Delta{make(map[uint64]*metricFields)}
+	tags := map[string]string{"report_deltas": "true"}
+	var input []telegraf.Metric
+	for _, v := range []float64{10, 30} {
+		dist := regular.NewRegularDistribution()
+		dist.AddEntry(v, 1)
+		m, _ := metric.New("m1", deepCopy(tags), map[string]interface{}{"count": int64(v), "latency": dist}, time.Now())
+		input = append(input, m)
 	}
+
+	metrics := processor.Apply(input...)
+
+	assert.Len(t, metrics, 1)
+	checkValueInt64(t, metrics[0], "count", 20)
+	dist, ok := metrics[0].Fields()["latency"].(distribution.Distribution)
+	assert.True(t, ok)
+	assert.Equal(t, float64(30), dist.Sum())
 }
@@ -8,11 +8,13 @@ import (
 	"fmt"
 	"hash/fnv"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
 	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/aws/amazon-cloudwatch-agent/internal/ec2tags"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -45,9 +47,16 @@ const sampleConfig = `
   ## If this configuration is not provided, or has an empty list, no EC2 Instance Tags are applied.
   ## If this configuration contains one entry and its value is "*", then ALL EC2 Instance Tags for the instance are applied.
   ## Note: This plugin renames the "aws:autoscaling:groupName" EC2 Instance Tag key to be spelled "AutoScalingGroupName".
-  ## This aligns it with the AutoScaling dimension-name seen in AWS CloudWatch.
+  ## This aligns it with the AutoScaling dimension-name seen in AWS CloudWatch. The "eks:nodegroup-name" and
+  ## "aws:cloudformation:stack-name" keys are renamed to "EKSNodegroupName" and "CloudFormationStackName".
+  ## The tags are retrieved once for all the ec2taggers of the agent, and from the instance metadata when
+  ## DescribeTags fails and the instance metadata tags are enabled on the instance.
   # ec2_instance_tag_keys = ["aws:autoscaling:groupName", "Name"]
   ##
+  ## Rename the EC2 Instance Tags, the dimension the tag is added as by tag key.
+  # [processors.ec2tagger.ec2_instance_tag_dimensions]
+  #   team = "Team"
+  ##
   ## Retrieve ebs_volume_id for the specified devices, add ebs_volume_id as tag. The specified devices are
   ## the values corresponding to the tag key "disk_device_tag_key" in the input metric.
   ## If this configuration is not provided, or has an empty list, no ebs volume is applied.
@@ -57,6 +66,11 @@ const sampleConfig = `
   ## Specify which tag to use to get the specified disk device name from input Metric
   # disk_device_tag_key = "device"
   ##
+  ## Add the ID of the EBS volume of the device, e.g. vol-0123456789abcdef0, as this tag to the disk and diskio
+  ## metrics. It is read from the serial of the NVMe device on the Nitro instances, and from the volumes retrieved
+  ## for "ebs_device_keys" otherwise.
+  # ebs_volume_id_tag_key = "VolumeId"
+  ##
   ## Amazon Credentials
   ## Credentials are loaded in the following order
   ## 1) Assumed credentials via STS if role_arn is specified
@@ -74,14 +88,20 @@ const sampleConfig = `
 `
 
 const (
-	ec2InstanceTagKeyASG = "aws:autoscaling:groupName"
-	cwDimensionASG       = "AutoScalingGroupName"
-	mdKeyInstanceId      = "InstanceId"
-	mdKeyImageId         = "ImageId"
-	mdKeyInstaneType     = "InstanceType"
-	ebsVolumeId          = "EBSVolumeId"
+	mdKeyInstanceId  = "InstanceId"
+	mdKeyImageId     = "ImageId"
+	mdKeyInstaneType = "InstanceType"
+	ebsVolumeId      = "EBSVolumeId"
 )
 
+// builtinTagDimensions are the dimensions the tags set by AWS are added as, by tag key. The AutoScalingGroupName
+// matches the dimension applied by the AutoScaling service.
+var builtinTagDimensions = map[string]string{
+	"aws:autoscaling:groupName":     "AutoScalingGroupName",
+	"eks:nodegroup-name":            "EKSNodegroupName",
+	"aws:cloudformation:stack-name": "CloudFormationStackName",
+}
+
 var (
 	defaultRefreshInterval = 180 * time.Second
 	// backoff retry for ec2 describe instances API call. Assuming the throttle limit is 20 per second. 10 mins allow 12000 API calls.
@@ -99,6 +119,7 @@ type ec2ProviderType func(*internalaws.CredentialConfig) ec2iface.EC2API
 type ec2Metadata interface {
 	Available() bool
 	GetInstanceIdentityDocument() (ec2metadata.EC2InstanceIdentityDocument, error)
+	GetMetadata(p string) (string, error)
 }
 
 type Tagger struct {
@@ -109,6 +130,11 @@ type Tagger struct {
 	EBSDeviceKeys          []string          `toml:"ebs_device_keys"`
 	//The tag key in the metrics for disk device
 	DiskDeviceTagKey string `toml:"disk_device_tag_key"`
+	// EBSVolumeIdTagKey is the tag the ID of the EBS volume is added as to the disk and diskio metrics
+	EBSVolumeIdTagKey string `toml:"ebs_volume_id_tag_key"`
+	// EC2InstanceTagDimensions are the dimensions the instance tags are added as by tag key, the tags without one
+	// are added as their key
+	EC2InstanceTagDimensions map[string]string `toml:"ec2_instance_tag_dimensions"`
 
 	// unlike other AWS plugins, this one determines the region from ec2 metadata not user configuration
 	AccessKey string `toml:"access_key"`
@@ -127,11 +153,14 @@ type Tagger struct {
 	ec2Provider    ec2ProviderType
 	ec2            ec2iface.EC2API
 	ec2metadata    ec2Metadata
+	tagCache       *ec2tags.Cache
 	refreshTicker  *time.Ticker
 	shutdownC      chan bool
-	tagFilters     []*ec2.Filter
+	tagKeys        map[string]bool
+	tagDimensions  map[string]string
 	metadataLookup metadataLookup
 	ebsVolume      *EbsVolume
+	nvmeSerial     *nvmeSerial
 
 	sync.RWMutex //to protect ec2TagCache
 }
@@ -173,6 +202,13 @@ func (t *Tagger) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		if t.metadataLookup.instanceType {
 			metric.AddTag(mdKeyInstaneType, t.instanceType)
 		}
+		if t.EBSVolumeIdTagKey != "" {
+			if tag, ok := volumeIdDeviceTags[metric.Name()]; ok && metric.HasTag(tag) {
+				if volId := t.volumeId(metric.Tags()[tag]); volId != "" {
+					metric.AddTag(t.EBSVolumeIdTagKey, volId)
+				}
+			}
+		}
 		if t.ebsVolume != nil && metric.HasTag(t.DiskDeviceTagKey) {
 			devName := metric.Tags()[t.DiskDeviceTagKey]
 			ebsVolId := t.ebsVolume.getEbsVolumeId(devName)
@@ -184,30 +220,35 @@ func (t *Tagger) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	return in
 }
 
+// volumeId returns the ID of the EBS volume of the device from the serial of its NVMe controller, or from the
+// attachments of the volumes when ebs_device_keys are configured, e.g. for the Xen instances
+func (t *Tagger) volumeId(devName string) string {
+	if volId := t.nvmeSerial.volumeId(devName); volId != "" {
+		return volId
+	}
+	if t.ebsVolume == nil {
+		return ""
+	}
+	if !strings.HasPrefix(devName, "/dev/") {
+		devName = "/dev/" + devName
+	}
+	// the volumes are named like aws://us-east-1a/vol-0123456789abcdef0
+	volId := t.ebsVolume.getEbsVolumeId(devName)
+	return volId[strings.LastIndex(volId, "/")+1:]
+}
+
 // updateTags calls EC2 Describe Tags and replaces the Tagger's tagCache with the newly retrieved values
 func (t *Tagger) updateTags() error {
-	tags := make(map[string]string)
-	input := &ec2.DescribeTagsInput{
-		Filters: t.tagFilters,
+	instanceTags, err := t.tagCache.Get(t.ec2, t.ec2metadata, t.instanceId)
+	if err != nil {
+		return err
 	}
-
-	for {
-		result, err := t.ec2.DescribeTags(input)
-		if err != nil {
-			return err
-		}
-		for _, tag := range result.Tags {
-			key := *tag.Key
-			if ec2InstanceTagKeyASG == key {
-				// rename to match CW dimension as applied by AutoScaling service, not the EC2 tag
-				key = cwDimensionASG
-			}
-			tags[key] = *tag.Value
-		}
-		if result.NextToken == nil {
-			break
+	tags := make(map[string]string)
+	for key, value := range instanceTags {
+		if t.tagKeys != nil && !t.tagKeys[key] {
+			continue
 		}
-		input.SetNextToken(*result.NextToken)
+		tags[t.tagDimension(key)] = value
 	}
 	t.Lock()
 	defer t.Unlock()
@@ -215,6 +256,14 @@ func (t *Tagger) updateTags() error {
 	return nil
 }
 
+// tagDimension returns the dimension the instance tag is added as
+func (t *Tagger) tagDimension(key string) string {
+	if dimension, ok := t.tagDimensions[key]; ok {
+		return dimension
+	}
+	return key
+}
+
 // Shutdown currently does not get called, as telegraf does not have a cleanup hook for Filter plugins
 func (t *Tagger) Shutdown() {
 	close(t.shutdownC)
@@ -266,12 +315,10 @@ func (t *Tagger) ec2TagsRetrieved() bool {
 	defer t.RUnlock()
 	if t.ec2TagCache != nil {
 		for _, key := range t.EC2InstanceTagKeys {
-			if key == ec2InstanceTagKeyASG {
-				key = cwDimensionASG
-			}
 			if key == "*" {
 				continue
 			}
+			key = t.tagDimension(key)
 			if _, ok := t.ec2TagCache[key]; !ok {
 				allTagsRetrieved = false
 				break
@@ -302,6 +349,9 @@ func (t *Tagger) ebsVolumesRetrieved() bool {
 func (t *Tagger) Init() error {
 	t.shutdownC = make(chan bool)
 	t.ec2TagCache = map[string]string{}
+	if t.EBSVolumeIdTagKey != "" && t.nvmeSerial == nil {
+		t.nvmeSerial = newNvmeSerial()
+	}
 
 	for _, tag := range t.EC2MetadataTags {
 		switch tag {
@@ -334,32 +384,28 @@ func (t *Tagger) Init() error {
 	t.instanceType = doc.InstanceType
 	t.imageId = doc.ImageID
 
-	t.tagFilters = []*ec2.Filter{
-		{
-			Name:   aws.String("resource-type"),
-			Values: aws.StringSlice([]string{"instance"}),
-		},
-		{
-			Name:   aws.String("resource-id"),
-			Values: aws.StringSlice([]string{t.instanceId}),
-		},
+	t.tagDimensions = map[string]string{}
+	for key, dimension := range builtinTagDimensions {
+		t.tagDimensions[key] = dimension
+	}
+	for key, dimension := range t.EC2InstanceTagDimensions {
+		t.tagDimensions[key] = dimension
 	}
 
 	useAllTags := len(t.EC2InstanceTagKeys) == 1 && t.EC2InstanceTagKeys[0] == "*"
 
 	if !useAllTags && len(t.EC2InstanceTagKeys) > 0 {
 		// if the customer said 'AutoScalingGroupName' (the CW dimension), do what they mean not what they said
-		// and filter for the EC2 tag name called 'aws:autoscaling:groupName'
+		// and look for the EC2 tag name called 'aws:autoscaling:groupName'
+		t.tagKeys = map[string]bool{}
 		for i, key := range t.EC2InstanceTagKeys {
-			if cwDimensionASG == key {
-				t.EC2InstanceTagKeys[i] = ec2InstanceTagKeyASG
+			for tagKey, dimension := range builtinTagDimensions {
+				if dimension == key {
+					t.EC2InstanceTagKeys[i] = tagKey
+				}
 			}
+			t.tagKeys[t.EC2InstanceTagKeys[i]] = true
 		}
-
-		t.tagFilters = append(t.tagFilters, &ec2.Filter{
-			Name:   aws.String("key"),
-			Values: aws.StringSlice(t.EC2InstanceTagKeys),
-		})
 	}
 
 	if len(t.EC2InstanceTagKeys) > 0 || len(t.EBSDeviceKeys) > 0 {
@@ -457,6 +503,13 @@ func (t *Tagger) setStarted() {
 	t.Log.Infof("ec2tagger: EC2 tagger has started, finished initial retrieval of tags and Volumes")
 }
 
+// Started tells if the initial retrieval of the tags and the volumes finished, the metrics are dropped until then
+func (t *Tagger) Started() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.started
+}
+
 // This function never return until calling updateTags() and updateVolumes() succeed or shutdown happen.
 func (t *Tagger) initialRetrievalOfTagsAndVolumes() {
 	tagsRetrieved := len(t.EC2InstanceTagKeys) == 0
@@ -538,6 +591,7 @@ func init() {
 		return &Tagger{
 			ec2metadata: ec2metadata.New(mdConfigProvider),
 			ec2Provider: ec2Provider,
+			tagCache:    ec2tags.Shared,
 		}
 	})
 }
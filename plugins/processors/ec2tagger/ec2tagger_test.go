@@ -204,6 +204,10 @@ func (m *mockEC2Metadata) GetInstanceIdentityDocument() (ec2metadata.EC2Instance
 	return ec2metadata.EC2InstanceIdentityDocument{}, errors.New("No instance identity document")
 }
 
+func (m *mockEC2Metadata) GetMetadata(string) (string, error) {
+	return "", errors.New("No instance metadata tags")
+}
+
 func TestInitFailWithNoMetadata(t *testing.T) {
 	assert := assert.New(t)
 	mockMetadata := &mockEC2Metadata{
@@ -288,6 +292,42 @@ func TestInitSuccessWithNoTagsVolumesUpdate(t *testing.T) {
 	assert.Equal(expectedVolumes, tagger.ebsVolume.dev2Vol)
 }
 
+//run Init() and check the instance tags are added as their dimensions
+func TestInitSuccessWithTagDimensions(t *testing.T) {
+	assert := assert.New(t)
+	mockMetadata := &mockEC2Metadata{
+		IsAvailable:              true,
+		InstanceIdentityDocument: mockedInstanceIdentityDoc,
+	}
+	ec2Client := &mockEC2Client{
+		tagsCallCount:    0,
+		tagsFailLimit:    -1,
+		tagsPartialLimit: -1,
+	}
+	ec2Provider := func(*internalaws.CredentialConfig) ec2iface.EC2API {
+		return ec2Client
+	}
+	tagger := Tagger{
+		Log:                      testutil.Logger{},
+		RefreshIntervalSeconds:   internal.Duration{Duration: 0},
+		ec2Provider:              ec2Provider,
+		ec2metadata:              mockMetadata,
+		EC2InstanceTagKeys:       []string{"tagKey1", "AutoScalingGroupName"},
+		EC2InstanceTagDimensions: map[string]string{"tagKey1": "Team"},
+	}
+	err := tagger.Init()
+	assert.Nil(err)
+	time.Sleep(100 * time.Millisecond)
+	//tagKey2 is not asked for
+	expectedTags := map[string]string{
+		"Team":                 "tagVal1",
+		"AutoScalingGroupName": "ASG-1",
+	}
+	tagger.RLock()
+	defer tagger.RUnlock()
+	assert.Equal(expectedTags, tagger.ec2TagCache)
+}
+
 //run Init() and check all tags/volumes are retrieved and saved and then updated
 func TestInitSuccessWithTagsVolumesUpdate(t *testing.T) {
 	assert := assert.New(t)
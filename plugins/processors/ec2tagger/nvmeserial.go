@@ -0,0 +1,77 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ec2tagger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// volumeIdDeviceTags are the tags of the device of the metrics the ID of the EBS volume is added to
+var volumeIdDeviceTags = map[string]string{
+	"disk":   "device",
+	"diskio": "name",
+}
+
+// nvmeSerial maps the block devices to the IDs of their EBS volumes with the serial of the NVMe controller, which
+// is the ID of the volume without its dash on the Nitro instances, e.g. vol0123456789abcdef0. The instance store
+// volumes and the devices of the Xen instances have no such serial.
+type nvmeSerial struct {
+	sysfs string
+	// volumes are the IDs found by device, empty for the devices which are not EBS volumes
+	volumes map[string]string
+	sync.Mutex
+}
+
+func newNvmeSerial() *nvmeSerial {
+	sysfs := "/sys"
+	// the file system of the host is mounted on /rootfs in the containers
+	if _, err := os.Lstat("/rootfs/sys"); err == nil {
+		sysfs = "/rootfs/sys"
+	}
+	return &nvmeSerial{sysfs: sysfs, volumes: map[string]string{}}
+}
+
+// volumeId returns the ID of the EBS volume of the device or of its partition, e.g. nvme0n1p1, empty when it is not
+// an EBS volume
+func (n *nvmeSerial) volumeId(device string) string {
+	if n == nil {
+		return ""
+	}
+	device = strings.TrimPrefix(device, "/dev/")
+	n.Lock()
+	defer n.Unlock()
+	if id, ok := n.volumes[device]; ok {
+		return id
+	}
+	id := n.lookup(device)
+	n.volumes[device] = id
+	return id
+}
+
+func (n *nvmeSerial) lookup(device string) string {
+	if !strings.HasPrefix(device, "nvme") {
+		return ""
+	}
+	serial, err := ioutil.ReadFile(filepath.Join(n.sysfs, "block", device, "device", "serial"))
+	if os.IsNotExist(err) {
+		// a partition is under the directory of its disk, e.g. /sys/class/block/nvme0n1p1 -> .../nvme0n1/nvme0n1p1
+		path, evalErr := filepath.EvalSymlinks(filepath.Join(n.sysfs, "class", "block", device))
+		if evalErr != nil {
+			return ""
+		}
+		serial, err = ioutil.ReadFile(filepath.Join(filepath.Dir(path), "device", "serial"))
+	}
+	if err != nil {
+		return ""
+	}
+	id := strings.TrimSpace(string(serial))
+	if !strings.HasPrefix(id, "vol") || len(id) <= len("vol") {
+		return ""
+	}
+	return "vol-" + strings.TrimPrefix(strings.TrimPrefix(id, "vol"), "-")
+}
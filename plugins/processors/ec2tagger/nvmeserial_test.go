@@ -0,0 +1,80 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ec2tagger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSysfs creates the sysfs of a nitro instance with an EBS volume, nvme0n1 and its partition, and an instance
+// store volume, nvme1n1
+func newTestSysfs(t *testing.T) string {
+	sysfs, err := ioutil.TempDir("", "sysfs")
+	require.NoError(t, err)
+	devices := map[string]string{"nvme0n1": "vol0123456789abcdef0\n", "nvme1n1": "AWS1A2B3C4D5E6F7G8H9\n"}
+	for device, serial := range devices {
+		dir := filepath.Join(sysfs, "devices", "pci0000:00", "nvme", device)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "device"), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "device", "serial"), []byte(serial), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(sysfs, "block"), 0755))
+		require.NoError(t, os.Symlink(dir, filepath.Join(sysfs, "block", device)))
+	}
+	partition := filepath.Join(sysfs, "devices", "pci0000:00", "nvme", "nvme0n1", "nvme0n1p1")
+	require.NoError(t, os.MkdirAll(partition, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(sysfs, "class", "block"), 0755))
+	require.NoError(t, os.Symlink(partition, filepath.Join(sysfs, "class", "block", "nvme0n1p1")))
+	return sysfs
+}
+
+func TestNvmeSerialVolumeId(t *testing.T) {
+	sysfs := newTestSysfs(t)
+	defer os.RemoveAll(sysfs)
+	n := &nvmeSerial{sysfs: sysfs, volumes: map[string]string{}}
+
+	assert.Equal(t, "vol-0123456789abcdef0", n.volumeId("nvme0n1"))
+	assert.Equal(t, "vol-0123456789abcdef0", n.volumeId("/dev/nvme0n1p1"))
+	assert.Equal(t, "", n.volumeId("nvme1n1"), "instance store volume")
+	assert.Equal(t, "", n.volumeId("xvda1"))
+	assert.Equal(t, "", n.volumeId("nvme2n1"))
+
+	var none *nvmeSerial
+	assert.Equal(t, "", none.volumeId("nvme0n1"))
+}
+
+func TestApplyVolumeId(t *testing.T) {
+	sysfs := newTestSysfs(t)
+	defer os.RemoveAll(sysfs)
+	tagger := Tagger{
+		Log:               testutil.Logger{},
+		ec2metadata:       &mockEC2Metadata{IsAvailable: true, InstanceIdentityDocument: mockedInstanceIdentityDoc},
+		EBSVolumeIdTagKey: "VolumeId",
+		nvmeSerial:        &nvmeSerial{sysfs: sysfs, volumes: map[string]string{}},
+		ebsVolume:         &EbsVolume{dev2Vol: map[string]string{"/dev/xvdf": "aws://us-east-1a/vol-0c241693efb58734a"}},
+	}
+	require.NoError(t, tagger.Init())
+
+	now := time.Now()
+	input := []telegraf.Metric{
+		testutil.MustMetric("disk", map[string]string{"device": "nvme0n1p1"}, map[string]interface{}{"used_percent": 50}, now),
+		testutil.MustMetric("diskio", map[string]string{"name": "xvdf"}, map[string]interface{}{"reads": 1}, now),
+		testutil.MustMetric("diskio", map[string]string{"name": "nvme1n1"}, map[string]interface{}{"reads": 1}, now),
+		testutil.MustMetric("mem", map[string]string{"device": "nvme0n1"}, map[string]interface{}{"used_percent": 50}, now),
+	}
+	expected := []telegraf.Metric{
+		testutil.MustMetric("disk", map[string]string{"device": "nvme0n1p1", "VolumeId": "vol-0123456789abcdef0"}, map[string]interface{}{"used_percent": 50}, now),
+		testutil.MustMetric("diskio", map[string]string{"name": "xvdf", "VolumeId": "vol-0c241693efb58734a"}, map[string]interface{}{"reads": 1}, now),
+		testutil.MustMetric("diskio", map[string]string{"name": "nvme1n1"}, map[string]interface{}{"reads": 1}, now),
+		testutil.MustMetric("mem", map[string]string{"device": "nvme0n1"}, map[string]interface{}{"used_percent": 50}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, tagger.Apply(input...))
+}
@@ -0,0 +1,52 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ec2tagger
+
+import (
+	"errors"
+
+	internalaws "github.com/aws/amazon-cloudwatch-agent/cfg/aws"
+	"github.com/aws/amazon-cloudwatch-agent/preflight"
+)
+
+// iamActions are the actions the tagger calls EC2 with to look up the tags of the instance and its EBS volumes
+func (t *Tagger) iamActions() []string {
+	var actions []string
+	if len(t.EC2InstanceTagKeys) > 0 {
+		actions = append(actions, "ec2:DescribeTags")
+	}
+	if len(t.EBSDeviceKeys) > 0 {
+		actions = append(actions, "ec2:DescribeVolumes")
+	}
+	return actions
+}
+
+// PreflightIAM checks that the actions of the tagger are allowed, in the region of the instance
+func (t *Tagger) PreflightIAM() []preflight.Check {
+	actions := t.iamActions()
+	if len(actions) == 0 {
+		return nil
+	}
+	region := t.region
+	if region == "" {
+		if !t.ec2metadata.Available() {
+			return []preflight.Check{preflight.Failed(preflight.KindIAM, actions[0], errors.New("the region is unknown since the instance metadata is not available"))}
+		}
+		doc, err := t.ec2metadata.GetInstanceIdentityDocument()
+		if err != nil {
+			return []preflight.Check{preflight.Failed(preflight.KindIAM, actions[0], err)}
+		}
+		region = doc.Region
+	}
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:    region,
+		AccessKey: t.AccessKey,
+		SecretKey: t.SecretKey,
+		RoleARN:   t.RoleARN,
+		Profile:   t.Profile,
+		Filename:  t.Filename,
+		Token:     t.Token,
+	}
+	return preflight.CheckIAMActions(credentialConfig.Credentials(), actions)
+}
@@ -45,6 +45,10 @@ func (m *metricDeclaration) process(tags map[string]string, fields map[string]in
 	// For metric matching the labels_matcher, try match its fields with metric_selectors
 Loop:
 	for fieldKey := range fields {
+		// the attributes, e.g. the exemplars, are not metrics even when a selector matches them
+		if structuredlogscommon.IsAttributeInFields(tags, fieldKey) {
+			continue
+		}
 		for _, regexP := range m.metricRegexPs {
 			if regexP.MatchString(fieldKey) {
 				if unit, ok := metricUnit[fieldKey]; ok {
@@ -23,6 +23,8 @@ type K8sDecorator struct {
 	HostIP          string `toml:"host_ip"`
 	NodeName        string `toml:"node_name"`
 	PrefFullPodName bool   `toml:"prefer_full_pod_name"`
+	// Aggregate the pod metrics by the workload owning the pods, in addition to the pod name
+	WorkloadDimensions bool `toml:"workload_dimensions"`
 }
 
 func (k *K8sDecorator) Description() string {
@@ -54,6 +56,9 @@ OUTER:
 		structuredlogsadapter.AddKubernetesInfo(metric, kubernetesBlob)
 		structuredlogsadapter.TagMetricSource(metric)
 		structuredlogsadapter.TagMetricRule(metric)
+		if k.WorkloadDimensions {
+			structuredlogsadapter.TagWorkloadMetricRule(metric)
+		}
 		structuredlogsadapter.TagLogGroup(metric)
 		metric.AddTag(logscommon.LogStreamNameTag, k.NodeName)
 		out = append(out, metric)
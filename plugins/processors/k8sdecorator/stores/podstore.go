@@ -567,6 +567,9 @@ func (p *PodStore) addPodOwnersAndPodName(metric telegraf.Metric, pod *corev1.Po
 	}
 	if len(owners) > 0 {
 		kubernetesBlob["pod_owners"] = owners
+		// the workload owning the pod, so the metrics can be aggregated across its ephemeral pods
+		metric.AddTag(WorkloadTypeKey, owners[0].OwnerKind)
+		metric.AddTag(WorkloadNameKey, owners[0].OwnerName)
 	}
 
 	// if podName is not set according to a well-known controllers, then set it to its own name
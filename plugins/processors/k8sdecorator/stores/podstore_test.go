@@ -503,6 +503,8 @@ func TestPodStore_addPodOwnersAndPodName(t *testing.T) {
 	expectedOwnerName = "cpu-limit"
 	assert.Equal(t, expectedOwnerName, m.Tags()[PodNameKey])
 	assert.Equal(t, expectedOwner, kubernetesBlob)
+	assert.Equal(t, StatefulSet, m.Tags()[WorkloadTypeKey])
+	assert.Equal(t, ssName, m.Tags()[WorkloadNameKey])
 
 	// Test ReplicationController
 	rcName := "ReplicationControllerTest"
@@ -548,6 +550,8 @@ func TestPodStore_addPodOwnersAndPodName(t *testing.T) {
 	expectedOwnerName = dpName
 	assert.Equal(t, expectedOwnerName, m.Tags()[PodNameKey])
 	assert.Equal(t, expectedOwner, kubernetesBlob)
+	assert.Equal(t, Deployment, m.Tags()[WorkloadTypeKey])
+	assert.Equal(t, dpName, m.Tags()[WorkloadNameKey])
 
 	// Test CronJob
 	m, _ = metric.New("test", tags, map[string]interface{}{}, time.Now())
@@ -121,6 +121,25 @@ var staticMetricRule = map[string][]structuredlogscommon.MetricRule{
 	TypeNodeFS:           nodeFSMetricRules,
 }
 
+// workloadMetricRules aggregate the pod metrics by the workload owning the pods, e.g. the Deployment or the
+// StatefulSet, rather than by the pod name
+var workloadMetricRules = map[string][]structuredlogscommon.MetricRule{
+	TypePod: {
+		{
+			Metrics: []structuredlogscommon.MetricAttr{
+				{Unit: Percent, Name: MetricName(TypePod, CpuUtilization)},
+				{Unit: Percent, Name: MetricName(TypePod, MemUtilization)},
+				{Unit: BytesPerSec, Name: MetricName(TypePod, NetRxBytes)},
+				{Unit: BytesPerSec, Name: MetricName(TypePod, NetTxBytes)},
+				{Unit: Percent, Name: MetricName(TypePod, CpuUtilizationOverPodLimit)},
+				{Unit: Percent, Name: MetricName(TypePod, MemUtilizationOverPodLimit)},
+				{Unit: Count, Name: MetricName(TypePod, ContainerRestartCount)}},
+			DimensionSets: [][]string{{WorkloadTypeKey, WorkloadNameKey, K8sNamespace, ClusterNameKey}},
+			Namespace:     cloudwatchNamespace,
+		},
+	},
+}
+
 func TagMetricRule(metric telegraf.Metric) {
 	rules, ok := staticMetricRule[metric.Tags()[MetricType]]
 	if !ok {
@@ -128,3 +147,12 @@ func TagMetricRule(metric telegraf.Metric) {
 	}
 	structuredlogscommon.AttachMetricRule(metric, rules)
 }
+
+// TagWorkloadMetricRule adds the workload dimensions to the metrics of the pods owned by a workload
+func TagWorkloadMetricRule(metric telegraf.Metric) {
+	rules, ok := workloadMetricRules[metric.Tags()[MetricType]]
+	if !ok {
+		return
+	}
+	structuredlogscommon.AttachMetricRule(metric, rules)
+}
@@ -89,6 +89,28 @@ func TestPodFullLackOfService(t *testing.T) {
 	assert.Equal(t, expected, actual, "Expected to be equal")
 }
 
+func TestPodWorkload(t *testing.T) {
+	tags := map[string]string{MetricType: TypePod, PodNameKey: "TestPodName", ClusterNameKey: "TestClusterName", K8sNamespace: "TestNamespace",
+		WorkloadTypeKey: StatefulSet, WorkloadNameKey: "TestStatefulSet"}
+	fields := map[string]interface{}{MetricName(TypePod, CpuUtilization): 0, MetricName(TypePod, MemUtilization): 0,
+		MetricName(TypePod, NetRxBytes): 0, MetricName(TypePod, NetTxBytes): 0, MetricName(TypePod, CpuUtilizationOverPodLimit): 0,
+		MetricName(TypePod, MemUtilizationOverPodLimit): 0, MetricName(TypePod, ContainerRestartCount): 0}
+	m, _ := metric.New("test", tags, fields, time.Now())
+	TagWorkloadMetricRule(m)
+	actual := m.Fields()[structuredlogscommon.MetricRuleKey].([]structuredlogscommon.MetricRule)
+	expected := []structuredlogscommon.MetricRule{}
+	deepCopy(&expected, workloadMetricRules[TypePod])
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	// the pods not owned by a workload are not aggregated
+	delete(tags, WorkloadTypeKey)
+	delete(tags, WorkloadNameKey)
+	m, _ = metric.New("test", tags, fields, time.Now())
+	TagWorkloadMetricRule(m)
+	_, ok := m.Fields()[structuredlogscommon.MetricRuleKey]
+	assert.False(t, ok)
+}
+
 func TestNodeFSFull(t *testing.T) {
 	tags := map[string]string{MetricType: TypeNodeFS, NodeNameKey: "TestNodeName", ClusterNameKey: "TestClusterName", InstanceId: "i-123"}
 	fields := map[string]interface{}{MetricName(TypeNodeFS, FSUtilization): 0}
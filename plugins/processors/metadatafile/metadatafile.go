@@ -0,0 +1,186 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metadatafile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+type MetadataFile struct {
+	// File is the JSON or YAML file of the key value pairs added as tags to the metrics
+	File string `toml:"file"`
+	// ReloadInterval is how often the file is checked for changes
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+
+	tags      map[string]string
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+	sync.Mutex
+}
+
+var sampleConfig = `
+  ## The JSON or YAML file of the key value pairs added as tags to the metrics, e.g.
+  ##   {"service": "checkout", "env": "prod", "team": "payments"}
+  file = "/etc/cwagent/metadata.json"
+
+  ## How often the file is checked for changes, the tags are updated once it changes
+  # reload_interval = "30s"
+`
+
+func (m *MetadataFile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *MetadataFile) Description() string {
+	return "Add the key value pairs of a local metadata file as tags to the metrics and as fields to the JSON log events, re-reading the file when it changes"
+}
+
+// Apply adds the tags of the file to the metrics which do not have them already, the tags of the metrics win
+func (m *MetadataFile) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	tags := m.currentTags(time.Now())
+	if len(tags) == 0 {
+		return in
+	}
+	for _, metric := range in {
+		for k, v := range tags {
+			if !metric.HasTag(k) {
+				metric.AddTag(k, v)
+			}
+		}
+	}
+	return in
+}
+
+// DecorateLog adds the key value pairs of the file to the log events which are JSON objects, e.g. the embedded metric
+// format logs of the applications, as string fields. The fields of the log events win and the other log events are
+// left as they are.
+func (m *MetadataFile) DecorateLog(message string) string {
+	tags := m.currentTags(time.Now())
+	if len(tags) == 0 {
+		return message
+	}
+	object := strings.TrimRight(message, " \t\r\n")
+	if !strings.HasPrefix(strings.TrimLeft(object, " \t"), "{") || !strings.HasSuffix(object, "}") {
+		return message
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(object), &fields); err != nil {
+		return message
+	}
+	var keys []string
+	for k := range tags {
+		if _, ok := fields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return message
+	}
+	sort.Strings(keys)
+
+	// the fields are appended to the object, which is not encoded again so that the log event keeps its formatting
+	var b strings.Builder
+	b.WriteString(object[:len(object)-1])
+	for i, k := range keys {
+		if i > 0 || len(fields) > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		value, _ := json.Marshal(tags[k])
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	b.WriteString(message[len(object):])
+	return b.String()
+}
+
+// currentTags returns the tags of the file, after reloading them when the file has changed since the last check
+func (m *MetadataFile) currentTags(now time.Time) map[string]string {
+	m.Lock()
+	defer m.Unlock()
+	interval := m.ReloadInterval.Duration
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	if !m.lastCheck.IsZero() && now.Sub(m.lastCheck) < interval {
+		return m.tags
+	}
+	m.lastCheck = now
+
+	info, err := os.Stat(m.File)
+	if err != nil {
+		if m.tags != nil || m.modTime.IsZero() {
+			log.Printf("W! metadatafile: unable to read %v, no tags are added: %v", m.File, err)
+		}
+		m.tags = nil
+		// the file is loaded again once it exists
+		m.modTime, m.size = time.Unix(0, 0), 0
+		return nil
+	}
+	if info.ModTime().Equal(m.modTime) && info.Size() == m.size {
+		return m.tags
+	}
+	m.modTime, m.size = info.ModTime(), info.Size()
+	tags, err := readMetadata(m.File)
+	if err != nil {
+		// keep the tags of the previous version of the file
+		log.Printf("E! metadatafile: unable to load %v, the previous tags are kept: %v", m.File, err)
+		return m.tags
+	}
+	log.Printf("I! metadatafile: loaded %d tags from %v", len(tags), m.File)
+	m.tags = tags
+	return m.tags
+}
+
+// readMetadata returns the key value pairs of the file, YAML being a superset of JSON both are parsed the same way.
+// The values must be scalars.
+func readMetadata(file string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(values))
+	for k, v := range values {
+		switch v.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("the value of %v is not a string, a number or a boolean", k)
+		case nil:
+			continue
+		}
+		value := fmt.Sprint(v)
+		if k == "" || value == "" {
+			continue
+		}
+		tags[k] = value
+	}
+	return tags, nil
+}
+
+func init() {
+	processors.Add("metadatafile", func() telegraf.Processor {
+		return &MetadataFile{}
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metadatafile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(t *testing.T, tags map[string]string) telegraf.Metric {
+	m, err := metric.New("cpu", tags, map[string]interface{}{"usage_idle": 90.0}, time.Now())
+	require.NoError(t, err)
+	return m
+}
+
+func TestReadMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadatafile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "metadata.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"service": "checkout", "shard": 3, "canary": false, "empty": ""}`), 0644))
+	tags, err := readMetadata(file)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"service": "checkout", "shard": "3", "canary": "false"}, tags)
+
+	file = filepath.Join(dir, "metadata.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("service: checkout\nenv: prod\n"), 0644))
+	tags, err = readMetadata(file)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"service": "checkout", "env": "prod"}, tags)
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("team:\n  name: payments\n"), 0644))
+	_, err = readMetadata(file)
+	assert.EqualError(t, err, "the value of team is not a string, a number or a boolean")
+}
+
+func TestApplyReloads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadatafile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "metadata.yaml")
+
+	m := &MetadataFile{File: file}
+	now := time.Now()
+	// the file does not exist yet
+	assert.Empty(t, m.currentTags(now))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("service: checkout\nenv: prod\n"), 0644))
+	// not checked again before the reload interval
+	assert.Empty(t, m.currentTags(now.Add(time.Second)))
+	now = now.Add(defaultReloadInterval)
+	assert.Equal(t, map[string]string{"service": "checkout", "env": "prod"}, m.currentTags(now))
+
+	metrics := m.Apply(newMetric(t, map[string]string{"env": "staging"}))
+	assert.Equal(t, map[string]string{"service": "checkout", "env": "staging"}, metrics[0].Tags())
+
+	// an invalid version of the file keeps the previous tags
+	require.NoError(t, ioutil.WriteFile(file, []byte("service: [checkout\n"), 0644))
+	now = now.Add(defaultReloadInterval)
+	assert.Equal(t, map[string]string{"service": "checkout", "env": "prod"}, m.currentTags(now))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("service: cart\n"), 0644))
+	require.NoError(t, os.Chtimes(file, now, now.Add(time.Minute)))
+	now = now.Add(defaultReloadInterval)
+	assert.Equal(t, map[string]string{"service": "cart"}, m.currentTags(now))
+
+	require.NoError(t, os.Remove(file))
+	now = now.Add(defaultReloadInterval)
+	assert.Empty(t, m.currentTags(now))
+	metrics = m.Apply(newMetric(t, nil))
+	assert.Empty(t, metrics[0].Tags())
+}
+
+func TestDecorateLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadatafile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "metadata.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("service: checkout\nenv: prod\n"), 0644))
+
+	m := &MetadataFile{File: file}
+	assert.Equal(t, `{"level": "info", "env": "dev","service":"checkout"}`+"\n", m.DecorateLog(`{"level": "info", "env": "dev"}`+"\n"))
+	assert.Equal(t, `{ "env":"prod","service":"checkout"}`, m.DecorateLog(`{ }`))
+	assert.Equal(t, `{"env": "dev", "service": "cart"}`, m.DecorateLog(`{"env": "dev", "service": "cart"}`))
+	// the log events which are not JSON objects are left as they are
+	for _, message := range []string{"INFO started", `["a"]`, `{"level": "info"`, `{"a": 1} {"b": 2}`} {
+		assert.Equal(t, message, m.DecorateLog(message))
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !custom processors processors.k8sdecorator
+
+package plugins
+
+import _ "github.com/aws/amazon-cloudwatch-agent/plugins/processors/k8sdecorator"
@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build linux
+
+package preflight
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"syscall"
+)
+
+const (
+	selinuxEnforce   = "/sys/fs/selinux/enforce"
+	apparmorEnabled  = "/sys/module/apparmor/parameters/enabled"
+	processAttribute = "/proc/self/attr/current"
+)
+
+// platformHints explains the denials of the mandatory access control, which the mode of the file does not tell
+func platformHints(path string) []string {
+	process := readTrimmed(processAttribute)
+	switch {
+	case readTrimmed(selinuxEnforce) == "1":
+		return []string{fmt.Sprintf("SELinux is enforcing, the agent runs in the context %v and %v has the context %v, "+
+			"relabel it or allow the access with a policy module (see audit2allow)", process, path, selinuxContext(path))}
+	case readTrimmed(apparmorEnabled) == "Y" && process != "" && process != "unconfined":
+		return []string{fmt.Sprintf("AppArmor confines the agent with the profile %v, check that it allows the access to %v", process, path)}
+	}
+	return nil
+}
+
+func selinuxContext(path string) string {
+	buf := make([]byte, 256)
+	n, err := syscall.Getxattr(path, "security.selinux", buf)
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimRight(string(buf[:n]), "\x00")
+}
+
+func readTrimmed(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(b), "\x00\n")
+}
@@ -0,0 +1,10 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// +build !linux
+
+package preflight
+
+func platformHints(string) []string {
+	return nil
+}
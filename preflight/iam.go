@@ -0,0 +1,226 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package preflight
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+const (
+	KindIAM = "iam"
+
+	decisionAllowed              = "allowed"
+	errCodeDryRunOperation       = "DryRunOperation"
+	errCodeUnauthorizedOperation = "UnauthorizedOperation"
+)
+
+// IAMChecker is implemented by the plugins calling AWS to check that their IAM actions are allowed, the plugin of the
+// checks is set by the caller. They are only checked when asked for since the checks call AWS.
+type IAMChecker interface {
+	PreflightIAM() []Check
+}
+
+// LogGroup is a log group an input sends its log events to, through the output of the destination
+type LogGroup struct {
+	Name        string
+	Destination string
+}
+
+// LogGroupsSource is implemented by the inputs sending their log events to the log groups of their config
+type LogGroupsSource interface {
+	LogGroups() []LogGroup
+}
+
+// LogGroupsIAMChecker is implemented by the outputs sending the log events of the inputs, their IAM actions are
+// checked on the log groups of the inputs instead of on every resource
+type LogGroupsIAMChecker interface {
+	PreflightIAMLogGroups(groups []LogGroup) []Check
+}
+
+// ResourceARNs returns the ARNs of the resources the IAM actions are checked on, from the partition and the account
+// of the principal, e.g. of the log groups of the config
+type ResourceARNs func(partition, account string) []string
+
+// StaticARNs returns the ARNs which do not depend on the principal, e.g. of the topics of the config
+func StaticARNs(arns ...string) ResourceARNs {
+	return func(string, string) []string {
+		return arns
+	}
+}
+
+// dryRuns check the EC2 actions with dry runs when the policies of the principal cannot be simulated
+var dryRuns = map[string]func(ec2iface.EC2API) error{
+	"ec2:DescribeTags": func(svc ec2iface.EC2API) error {
+		_, err := svc.DescribeTags(&ec2.DescribeTagsInput{DryRun: aws.Bool(true)})
+		return err
+	},
+	"ec2:DescribeVolumes": func(svc ec2iface.EC2API) error {
+		_, err := svc.DescribeVolumes(&ec2.DescribeVolumesInput{DryRun: aws.Bool(true)})
+		return err
+	},
+	"ec2:DescribeInstances": func(svc ec2iface.EC2API) error {
+		_, err := svc.DescribeInstances(&ec2.DescribeInstancesInput{DryRun: aws.Bool(true)})
+		return err
+	},
+}
+
+type iamClients struct {
+	sts stsiface.STSAPI
+	iam iamiface.IAMAPI
+	ec2 ec2iface.EC2API
+}
+
+// CheckIAMActions checks that the IAM actions are allowed to the principal of the credentials, e.g. logs:PutLogEvents,
+// with the policy simulator of IAM. The EC2 actions are checked with dry runs when the principal is not allowed to
+// simulate its policies, the other actions are then reported as warnings.
+func CheckIAMActions(p client.ConfigProvider, actions []string) []Check {
+	return CheckIAMActionsOn(p, actions, nil)
+}
+
+// CheckIAMActionsOn checks that the IAM actions are allowed on the resources, e.g. logs:PutLogEvents on the log
+// groups of the config, as CheckIAMActions. They are checked on every resource when resources is nil.
+func CheckIAMActionsOn(p client.ConfigProvider, actions []string, resources ResourceARNs) []Check {
+	return checkIAMActions(iamClients{sts: sts.New(p), iam: iam.New(p), ec2: ec2.New(p)}, actions, resources)
+}
+
+func checkIAMActions(c iamClients, actions []string, resources ResourceARNs) []Check {
+	checks := make([]Check, len(actions))
+	for i, action := range actions {
+		checks[i] = Check{Kind: KindIAM, Path: action, Status: StatusOK}
+	}
+	identity, err := c.sts.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		for i := range checks {
+			checks[i].Status, checks[i].Error = StatusError, err.Error()
+			checks[i].Hints = []string{"the agent has no valid AWS credentials, check the credentials of the plugin or the role of the instance"}
+		}
+		return checks
+	}
+	principal := principalArn(aws.StringValue(identity.Arn))
+	if strings.HasSuffix(principal, ":root") {
+		// the root user of the account is allowed every action
+		return checks
+	}
+
+	var arns []string
+	if resources != nil {
+		partition, account := partitionAndAccount(principal)
+		arns = resources(partition, account)
+	}
+	decisions, err := simulate(c.iam, principal, actions, arns)
+	for i, action := range actions {
+		if err != nil {
+			checks[i] = dryRun(c.ec2, checks[i], principal, err)
+			continue
+		}
+		d, ok := decisions[action]
+		if !ok || d.decision == decisionAllowed {
+			continue
+		}
+		checks[i].Status = StatusError
+		if d.resource == "" || d.resource == "*" {
+			checks[i].Error = fmt.Sprintf("%v is not allowed to %v: %v", principal, action, d.decision)
+			checks[i].Hints = []string{fmt.Sprintf("allow %v in a policy of %v", action, principal)}
+		} else {
+			checks[i].Error = fmt.Sprintf("%v is not allowed to %v on %v: %v", principal, action, d.resource, d.decision)
+			checks[i].Hints = []string{fmt.Sprintf("allow %v on %v in a policy of %v", action, d.resource, principal)}
+		}
+	}
+	return checks
+}
+
+// decision of the policies for an action, the denied resource is kept when the action is not allowed on all of them
+type decision struct {
+	decision string
+	resource string
+}
+
+// simulate returns the decisions of the policies of the principal by action, on the resources when there are any
+func simulate(svc iamiface.IAMAPI, principal string, actions, resources []string) (map[string]decision, error) {
+	decisions := map[string]decision{}
+	input := &iam.SimulatePrincipalPolicyInput{PolicySourceArn: aws.String(principal), ActionNames: aws.StringSlice(actions)}
+	if len(resources) > 0 {
+		input.ResourceArns = aws.StringSlice(resources)
+	}
+	add := func(action, resource, value string) {
+		if d, ok := decisions[action]; ok && d.decision != decisionAllowed {
+			return
+		}
+		decisions[action] = decision{decision: value, resource: resource}
+	}
+	collect := func(page *iam.SimulatePolicyResponse, _ bool) bool {
+		for _, r := range page.EvaluationResults {
+			action := aws.StringValue(r.EvalActionName)
+			if len(r.ResourceSpecificResults) == 0 {
+				add(action, aws.StringValue(r.EvalResourceName), aws.StringValue(r.EvalDecision))
+			}
+			for _, rr := range r.ResourceSpecificResults {
+				add(action, aws.StringValue(rr.EvalResourceName), aws.StringValue(rr.EvalResourceDecision))
+			}
+		}
+		return true
+	}
+	err := svc.SimulatePrincipalPolicyPages(input, collect)
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeNoSuchEntityException && strings.Contains(principal, ":role/") {
+		// the ARN of the assumed role does not have the path of the role
+		role, getErr := svc.GetRole(&iam.GetRoleInput{RoleName: aws.String(principal[strings.LastIndex(principal, "/")+1:])})
+		if getErr != nil {
+			return nil, err
+		}
+		input.PolicySourceArn = role.Role.Arn
+		err = svc.SimulatePrincipalPolicyPages(input, collect)
+	}
+	return decisions, err
+}
+
+func dryRun(svc ec2iface.EC2API, c Check, principal string, simulateErr error) Check {
+	fn, ok := dryRuns[c.Path]
+	if !ok {
+		c.Status = StatusWarning
+		c.Error = fmt.Sprintf("unable to simulate the policies of %v: %v", principal, simulateErr)
+		c.Hints = []string{fmt.Sprintf("allow iam:SimulatePrincipalPolicy to %v to check its actions", principal)}
+		return c
+	}
+	err := fn(svc)
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == errCodeDryRunOperation {
+		return c
+	}
+	c.Status = StatusError
+	c.Error = fmt.Sprintf("the dry run of %v failed: %v", c.Path, err)
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == errCodeUnauthorizedOperation {
+		c.Hints = []string{fmt.Sprintf("allow %v in a policy of %v", c.Path, principal)}
+	}
+	return c
+}
+
+// partitionAndAccount returns the partition and the account of the ARN of the principal
+func partitionAndAccount(arn string) (string, string) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 {
+		return "aws", ""
+	}
+	return parts[1], parts[4]
+}
+
+// principalArn returns the ARN of the IAM role of an assumed role, the policies are simulated for the role
+func principalArn(arn string) string {
+	// arn:aws:sts::123456789012:assumed-role/role/session
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return arn
+	}
+	role := strings.Split(strings.TrimPrefix(parts[5], "assumed-role/"), "/")[0]
+	return fmt.Sprintf("arn:%v:iam::%v:role/%v", parts[1], parts[4], role)
+}
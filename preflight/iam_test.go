@@ -0,0 +1,159 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package preflight
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type stsMock struct {
+	stsiface.STSAPI
+	arn string
+	err error
+}
+
+func (m *stsMock) GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Arn: aws.String(m.arn)}, m.err
+}
+
+type iamMock struct {
+	iamiface.IAMAPI
+	allowed map[string]bool
+	// denied are the resources the actions are not allowed on
+	denied    map[string]bool
+	sources   []string
+	resources []string
+	roleArn   string
+	err       error
+}
+
+func (m *iamMock) SimulatePrincipalPolicyPages(in *iam.SimulatePrincipalPolicyInput, fn func(*iam.SimulatePolicyResponse, bool) bool) error {
+	m.sources = append(m.sources, aws.StringValue(in.PolicySourceArn))
+	if m.roleArn != "" && aws.StringValue(in.PolicySourceArn) != m.roleArn {
+		return awserr.New(iam.ErrCodeNoSuchEntityException, "no such role", nil)
+	}
+	if m.err != nil {
+		return m.err
+	}
+	m.resources = aws.StringValueSlice(in.ResourceArns)
+	var page iam.SimulatePolicyResponse
+	for _, action := range in.ActionNames {
+		decision := "implicitDeny"
+		if m.allowed[*action] {
+			decision = decisionAllowed
+		}
+		result := &iam.EvaluationResult{EvalActionName: action, EvalDecision: aws.String(decision), EvalResourceName: aws.String("*")}
+		for _, resource := range in.ResourceArns {
+			resourceDecision := decision
+			if m.denied[*resource] {
+				resourceDecision = "explicitDeny"
+			}
+			result.ResourceSpecificResults = append(result.ResourceSpecificResults,
+				&iam.ResourceSpecificResult{EvalResourceName: resource, EvalResourceDecision: aws.String(resourceDecision)})
+		}
+		page.EvaluationResults = append(page.EvaluationResults, result)
+	}
+	fn(&page, true)
+	return nil
+}
+
+func (m *iamMock) GetRole(in *iam.GetRoleInput) (*iam.GetRoleOutput, error) {
+	return &iam.GetRoleOutput{Role: &iam.Role{Arn: aws.String(m.roleArn)}}, nil
+}
+
+type ec2Mock struct {
+	ec2iface.EC2API
+	code string
+}
+
+func (m *ec2Mock) DescribeTags(*ec2.DescribeTagsInput) (*ec2.DescribeTagsOutput, error) {
+	return nil, awserr.New(m.code, "", nil)
+}
+
+func TestCheckIAMActions(t *testing.T) {
+	clients := iamClients{
+		sts: &stsMock{arn: "arn:aws:sts::123456789012:assumed-role/agent/i-0123"},
+		iam: &iamMock{allowed: map[string]bool{"logs:PutLogEvents": true}},
+	}
+	checks := checkIAMActions(clients, []string{"logs:PutLogEvents", "logs:CreateLogGroup"}, nil)
+	assert.Equal(t, []string{"arn:aws:iam::123456789012:role/agent"}, clients.iam.(*iamMock).sources)
+	assert.Equal(t, StatusOK, checks[0].Status)
+	assert.Equal(t, StatusError, checks[1].Status)
+	assert.Equal(t, "logs:CreateLogGroup", checks[1].Path)
+	assert.Equal(t, []string{"allow logs:CreateLogGroup in a policy of arn:aws:iam::123456789012:role/agent"}, checks[1].Hints)
+}
+
+func TestCheckIAMActionsOnResources(t *testing.T) {
+	m := &iamMock{
+		allowed: map[string]bool{"logs:PutLogEvents": true},
+		denied:  map[string]bool{"arn:aws:logs:us-east-1:123456789012:log-group:secure:*": true},
+	}
+	clients := iamClients{sts: &stsMock{arn: "arn:aws:sts::123456789012:assumed-role/agent/i-0123"}, iam: m}
+	groups := func(partition, account string) []string {
+		return []string{
+			"arn:" + partition + ":logs:us-east-1:" + account + ":log-group:app:*",
+			"arn:" + partition + ":logs:us-east-1:" + account + ":log-group:secure:*",
+		}
+	}
+	checks := checkIAMActions(clients, []string{"logs:PutLogEvents"}, groups)
+	assert.Equal(t, groups("aws", "123456789012"), m.resources)
+	assert.Equal(t, StatusError, checks[0].Status)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/agent is not allowed to logs:PutLogEvents on "+
+		"arn:aws:logs:us-east-1:123456789012:log-group:secure:*: explicitDeny", checks[0].Error)
+
+	m.denied = nil
+	checks = checkIAMActions(clients, []string{"logs:PutLogEvents"}, StaticARNs("arn:aws:sns:us-east-1:123456789012:on-call"))
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:on-call"}, m.resources)
+	assert.Equal(t, StatusOK, checks[0].Status)
+}
+
+func TestCheckIAMActionsRoleWithPath(t *testing.T) {
+	m := &iamMock{allowed: map[string]bool{"logs:PutLogEvents": true}, roleArn: "arn:aws:iam::123456789012:role/service/agent"}
+	clients := iamClients{sts: &stsMock{arn: "arn:aws:sts::123456789012:assumed-role/agent/i-0123"}, iam: m}
+	checks := checkIAMActions(clients, []string{"logs:PutLogEvents"}, nil)
+	assert.Equal(t, StatusOK, checks[0].Status)
+	assert.Equal(t, []string{"arn:aws:iam::123456789012:role/agent", "arn:aws:iam::123456789012:role/service/agent"}, m.sources)
+}
+
+func TestCheckIAMActionsWithoutSimulation(t *testing.T) {
+	denied := awserr.New("AccessDenied", "not authorized to perform: iam:SimulatePrincipalPolicy", nil)
+	clients := iamClients{
+		sts: &stsMock{arn: "arn:aws:iam::123456789012:user/agent"},
+		iam: &iamMock{err: denied},
+		ec2: &ec2Mock{code: errCodeDryRunOperation},
+	}
+	checks := checkIAMActions(clients, []string{"ec2:DescribeTags", "cloudwatch:PutMetricData"}, nil)
+	assert.Equal(t, StatusOK, checks[0].Status, "the dry run succeeded")
+	assert.Equal(t, StatusWarning, checks[1].Status)
+
+	clients.ec2 = &ec2Mock{code: errCodeUnauthorizedOperation}
+	checks = checkIAMActions(clients, []string{"ec2:DescribeTags"}, nil)
+	assert.Equal(t, StatusError, checks[0].Status)
+	assert.Equal(t, []string{"allow ec2:DescribeTags in a policy of arn:aws:iam::123456789012:user/agent"}, checks[0].Hints)
+}
+
+func TestCheckIAMActionsWithoutCredentials(t *testing.T) {
+	checks := checkIAMActions(iamClients{sts: &stsMock{err: errors.New("no credentials")}}, []string{"logs:PutLogEvents"}, nil)
+	assert.Equal(t, StatusError, checks[0].Status)
+	assert.Equal(t, "no credentials", checks[0].Error)
+
+	checks = checkIAMActions(iamClients{sts: &stsMock{arn: "arn:aws:iam::123456789012:root"}}, []string{"logs:PutLogEvents"}, nil)
+	assert.Equal(t, StatusOK, checks[0].Status)
+}
+
+func TestPrincipalArn(t *testing.T) {
+	assert.Equal(t, "arn:aws-cn:iam::123456789012:role/agent", principalArn("arn:aws-cn:sts::123456789012:assumed-role/agent/session"))
+	assert.Equal(t, "arn:aws:iam::123456789012:user/agent", principalArn("arn:aws:iam::123456789012:user/agent"))
+}
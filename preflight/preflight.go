@@ -0,0 +1,232 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package preflight checks on start that the agent is able to open the files and the sockets of its
+// inputs, so that missing permissions are reported at once instead of as runtime errors over hours.
+package preflight
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	KindFile      = "file"
+	KindDirectory = "directory"
+	KindSocket    = "socket"
+
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+	StatusError   = "error"
+)
+
+// Check is the result of checking the access to one path or address
+type Check struct {
+	Plugin string   `json:"plugin"`
+	Kind   string   `json:"kind"`
+	Path   string   `json:"path"`
+	Status string   `json:"status"`
+	Errno  string   `json:"errno,omitempty"`
+	Error  string   `json:"error,omitempty"`
+	Hints  []string `json:"hints,omitempty"`
+}
+
+// Checker is implemented by the inputs able to check the access to their files and sockets,
+// the plugin of the checks is set by the caller
+type Checker interface {
+	Preflight() []Check
+}
+
+// Report is the result of all the checks run on start
+type Report struct {
+	Checks   []Check `json:"checks"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+}
+
+// NewReport counts the errors and the warnings of the checks
+func NewReport(checks []Check) Report {
+	r := Report{Checks: checks}
+	for _, c := range checks {
+		switch c.Status {
+		case StatusError:
+			r.Errors++
+		case StatusWarning:
+			r.Warnings++
+		}
+	}
+	return r
+}
+
+// Warning returns a passed check with a hint, e.g. a glob which matches no file yet
+func Warning(kind, path, hint string) Check {
+	return Check{Kind: kind, Path: path, Status: StatusWarning, Hints: []string{hint}}
+}
+
+// Failed returns a failed check for an error found by the input itself, e.g. a glob which does not compile
+func Failed(kind, path string, err error) Check {
+	return Check{Kind: kind, Path: path}.fail(err)
+}
+
+// CheckFile checks that the file can be opened for reading. Named pipes are not opened since
+// opening them blocks until a writer shows up.
+func CheckFile(path string) Check {
+	c := Check{Kind: KindFile, Path: path, Status: StatusOK}
+	info, err := os.Stat(path)
+	if err != nil {
+		return c.fail(err)
+	}
+	if info.Mode()&os.ModeNamedPipe != 0 {
+		return c
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return c.fail(err)
+	}
+	f.Close()
+	return c
+}
+
+// CheckDirectory checks that the directory can be listed
+func CheckDirectory(path string) Check {
+	c := Check{Kind: KindDirectory, Path: path, Status: StatusOK}
+	f, err := os.Open(path)
+	if err != nil {
+		return c.fail(err)
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return c.fail(err)
+	}
+	return c
+}
+
+// CheckWritableDirectory checks that files can be created in the directory, or in its closest
+// existing parent when the agent has to create it first.
+func CheckWritableDirectory(path string) Check {
+	c := Check{Kind: KindDirectory, Path: path, Status: StatusOK}
+	dir := existingParent(path)
+	f, err := ioutil.TempFile(dir, ".preflight")
+	if err != nil {
+		return c.fail(err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	if dir != path {
+		c.Status = StatusWarning
+		c.Hints = []string{fmt.Sprintf("the directory does not exist, the agent creates it in %v", dir)}
+	}
+	return c
+}
+
+// CheckListen checks that the agent is able to listen on the address, the network is the one of
+// net.Listen or net.ListenPacket. For unix sockets the directory of the socket must be writable.
+func CheckListen(network, address string) Check {
+	c := Check{Kind: KindSocket, Path: network + "://" + address, Status: StatusOK}
+	var err error
+	switch network {
+	case "unix", "unixpacket", "unixgram":
+		dir := CheckWritableDirectory(filepath.Dir(address))
+		if dir.Status == StatusError {
+			dir.Kind, dir.Path = KindSocket, c.Path
+			return dir
+		}
+		return c
+	case "udp", "udp4", "udp6":
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket(network, address); err == nil {
+			conn.Close()
+		}
+	default:
+		var l net.Listener
+		if l, err = net.Listen(network, address); err == nil {
+			l.Close()
+		}
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+	return c
+}
+
+func (c Check) fail(err error) Check {
+	c.Status = StatusError
+	c.Error = err.Error()
+	c.Errno = errnoName(err)
+
+	switch {
+	case os.IsNotExist(err):
+		// a log file which does not exist yet is tailed once it is created
+		c.Status = StatusWarning
+		if parent := existingParent(c.Path); parent != filepath.Dir(c.Path) && parent != c.Path {
+			c.Hints = append(c.Hints, fmt.Sprintf("the directory %v does not exist, the closest existing one is %v", filepath.Dir(c.Path), parent))
+		} else {
+			c.Hints = append(c.Hints, fmt.Sprintf("the %v does not exist yet", c.Kind))
+		}
+	case os.IsPermission(err):
+		if c.Kind == KindSocket {
+			c.Hints = append(c.Hints, "listening on a port below 1024 requires root or the CAP_NET_BIND_SERVICE capability")
+		} else {
+			c.Hints = append(c.Hints, fmt.Sprintf("the agent runs as %v, check the owner and the mode of %v and of its parent directories", currentUser(), c.Path))
+		}
+		c.Hints = append(c.Hints, platformHints(existingParent(c.Path))...)
+	case errors.Is(err, syscall.EADDRINUSE):
+		c.Hints = append(c.Hints, "another process already listens on the address")
+	case errors.Is(err, syscall.EADDRNOTAVAIL):
+		c.Hints = append(c.Hints, "the address is not assigned to any interface of the host")
+	}
+	return c
+}
+
+var errnoNames = map[syscall.Errno]string{
+	syscall.EACCES:        "EACCES",
+	syscall.EPERM:         "EPERM",
+	syscall.ENOENT:        "ENOENT",
+	syscall.ENOTDIR:       "ENOTDIR",
+	syscall.EISDIR:        "EISDIR",
+	syscall.ELOOP:         "ELOOP",
+	syscall.EMFILE:        "EMFILE",
+	syscall.EROFS:         "EROFS",
+	syscall.EADDRINUSE:    "EADDRINUSE",
+	syscall.EADDRNOTAVAIL: "EADDRNOTAVAIL",
+}
+
+func errnoName(err error) string {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return ""
+	}
+	if name, ok := errnoNames[errno]; ok {
+		return name
+	}
+	return fmt.Sprintf("errno %d", uintptr(errno))
+}
+
+// existingParent returns the path itself when it exists, otherwise its closest existing parent
+func existingParent(path string) string {
+	for {
+		if _, err := os.Lstat(path); err == nil || !os.IsNotExist(err) {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return fmt.Sprintf("uid %d", os.Getuid())
+	}
+	return fmt.Sprintf("%v (uid %v)", u.Username, u.Uid)
+}
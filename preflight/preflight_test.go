@@ -0,0 +1,88 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package preflight
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.log")
+	require.NoError(t, ioutil.WriteFile(file, []byte("line\n"), 0644))
+
+	c := CheckFile(file)
+	assert.Equal(t, StatusOK, c.Status)
+	assert.Empty(t, c.Hints)
+
+	c = CheckFile(filepath.Join(dir, "missing.log"))
+	assert.Equal(t, StatusWarning, c.Status)
+	assert.Equal(t, "ENOENT", c.Errno)
+	assert.Equal(t, []string{"the file does not exist yet"}, c.Hints)
+
+	c = CheckFile(filepath.Join(dir, "missing", "app.log"))
+	assert.Equal(t, StatusWarning, c.Status)
+	require.Len(t, c.Hints, 1)
+	assert.Contains(t, c.Hints[0], "the closest existing one is "+dir)
+
+	if os.Getuid() == 0 {
+		t.Skip("root is not denied the access")
+	}
+	require.NoError(t, os.Chmod(file, 0))
+	c = CheckFile(file)
+	assert.Equal(t, StatusError, c.Status)
+	assert.Equal(t, "EACCES", c.Errno)
+	assert.Contains(t, c.Hints[0], "check the owner and the mode of "+file)
+}
+
+func TestCheckDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Equal(t, StatusOK, CheckDirectory(dir).Status)
+	assert.Equal(t, StatusOK, CheckWritableDirectory(dir).Status)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "the file created by the check must be removed")
+
+	c := CheckWritableDirectory(filepath.Join(dir, "state"))
+	assert.Equal(t, StatusWarning, c.Status)
+	assert.Contains(t, c.Hints[0], "the agent creates it in "+dir)
+}
+
+func TestCheckListen(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := conn.LocalAddr().String()
+
+	c := CheckListen("udp", address)
+	assert.Equal(t, StatusError, c.Status)
+	assert.Equal(t, "EADDRINUSE", c.Errno)
+	assert.Equal(t, "udp://"+address, c.Path)
+	assert.Equal(t, []string{"another process already listens on the address"}, c.Hints)
+
+	conn.Close()
+	assert.Equal(t, StatusOK, CheckListen("udp", address).Status)
+}
+
+func TestNewReport(t *testing.T) {
+	r := NewReport([]Check{
+		{Status: StatusOK},
+		{Status: StatusWarning},
+		{Status: StatusError},
+		{Status: StatusError},
+	})
+	assert.Equal(t, 2, r.Errors)
+	assert.Equal(t, 1, r.Warnings)
+}
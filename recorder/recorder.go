@@ -0,0 +1,144 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-cloudwatch-agent/logs"
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// KindLogLine samples are the raw lines read from a log file, the source is the file name
+	KindLogLine = "logfile"
+	// KindStatsd samples are the statsd datagrams received, the source is the service address
+	KindStatsd = "statsd"
+	// KindPrometheus samples are the samples of a prometheus scrape in the text exposition format, once their types
+	// are known, the source is the instance of the target
+	KindPrometheus = "prometheus"
+)
+
+var Recorder recorder
+
+// Sample is one raw input recorded, it is stored as a json line. The payload is kept as bytes
+// since log files are not always utf-8 encoded.
+type Sample struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Source  string    `json:"source"`
+	Payload []byte    `json:"payload"`
+}
+
+// LogReplayer is implemented by the log inputs able to turn recorded samples into log events
+type LogReplayer interface {
+	ReplayLogs(samples []Sample, output func(group, stream string, e logs.LogEvent)) error
+}
+
+// MetricReplayer is implemented by the metric inputs able to turn recorded samples into metrics
+type MetricReplayer interface {
+	ReplayMetrics(samples []Sample, acc telegraf.Accumulator) error
+}
+
+type recorder struct {
+	// enabled is set while the file is open, so that Record does not lock when recording is disabled
+	enabled int32
+
+	sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// Enable starts appending the samples recorded into the file
+func (r *recorder) Enable(path string) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.file != nil {
+		return fmt.Errorf("recording into %v is already enabled", r.file.Name())
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.enc = json.NewEncoder(f)
+	atomic.StoreInt32(&r.enabled, 1)
+	return nil
+}
+
+// Enabled tells whether the samples are recorded, for the inputs to skip encoding them otherwise
+func (r *recorder) Enabled() bool {
+	return atomic.LoadInt32(&r.enabled) == 1
+}
+
+// Record stores the payload when recording is enabled, it is a no-op otherwise
+func (r *recorder) Record(kind, source string, payload []byte) {
+	if atomic.LoadInt32(&r.enabled) == 0 {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.file == nil {
+		return
+	}
+	if err := r.enc.Encode(Sample{Time: time.Now(), Kind: kind, Source: source, Payload: payload}); err != nil {
+		log.Printf("E! Unable to record %v sample of %v into %v: %v", kind, source, r.file.Name(), err)
+	}
+}
+
+// Close stops the recording
+func (r *recorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	atomic.StoreInt32(&r.enabled, 0)
+	err := r.file.Close()
+	r.file = nil
+	r.enc = nil
+	return err
+}
+
+// ReadSamples returns the samples of a recording in the order they were recorded
+func ReadSamples(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("invalid sample at line %v of %v: %v", line, path, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+// SamplesOfKind returns the samples of the given kind, keeping their order
+func SamplesOfKind(samples []Sample, kind string) []Sample {
+	var result []Sample
+	for _, s := range samples {
+		if s.Kind == kind {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package recorder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReadSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.ndjson")
+
+	r := &recorder{}
+	r.Record(KindStatsd, ":8125", []byte("dropped before enabling"))
+	require.NoError(t, r.Enable(path))
+	assert.Error(t, r.Enable(path))
+	r.Record(KindLogLine, "/var/log/app.log", []byte("first line"))
+	r.Record(KindStatsd, ":8125", []byte("requests:1|c"))
+	r.Record(KindLogLine, "/var/log/app.log", []byte{0xff, 0xfe})
+	require.NoError(t, r.Close())
+	r.Record(KindStatsd, ":8125", []byte("dropped after closing"))
+
+	samples, err := ReadSamples(path)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	assert.Equal(t, "first line", string(samples[0].Payload))
+	assert.Equal(t, []byte{0xff, 0xfe}, samples[2].Payload)
+
+	logLines := SamplesOfKind(samples, KindLogLine)
+	require.Len(t, logLines, 2)
+	assert.Equal(t, "/var/log/app.log", logLines[0].Source)
+	assert.Equal(t, "requests:1|c", string(SamplesOfKind(samples, KindStatsd)[0].Payload))
+}
+
+func TestRecordDisabledDoesNotLock(t *testing.T) {
+	r := &recorder{}
+	r.Lock()
+	defer r.Unlock()
+
+	recorded := make(chan struct{})
+	go func() {
+		r.Record(KindStatsd, ":8125", []byte("requests:1|c"))
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(time.Second):
+		t.Fatal("Record locked the recorder while recording is disabled")
+	}
+}
+
+func TestReadInvalidSamples(t *testing.T) {
+	f, err := ioutil.TempFile("", "recording")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("{\"kind\":\"statsd\"}\nnot json\n")
+	f.Close()
+
+	_, err = ReadSamples(f.Name())
+	assert.EqualError(t, err, "invalid sample at line 2 of "+f.Name()+": invalid character 'o' in literal null (expecting 'u')")
+}
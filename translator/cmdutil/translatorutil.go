@@ -4,12 +4,15 @@
 package cmdutil
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/aws/amazon-cloudwatch-agent/translator"
@@ -17,6 +20,7 @@ import (
 	"github.com/aws/amazon-cloudwatch-agent/translator/context"
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig"
 	"github.com/aws/amazon-cloudwatch-agent/translator/toenvconfig"
+	"github.com/aws/amazon-cloudwatch-agent/translator/tootelconfig"
 	"github.com/aws/amazon-cloudwatch-agent/translator/totomlconfig"
 	translatorUtil "github.com/aws/amazon-cloudwatch-agent/translator/util"
 
@@ -31,13 +35,20 @@ const (
 	exitSuccessMessage       = "Configuration validation first phase succeeded"
 )
 
-// Translate from Json map to toml file
+// Translate from Json map to toml file, and to the OpenTelemetry Collector config file when its path is set
 func TranslateJsonMapToTomlFile(jsonConfigValue map[string]interface{}, tomlConfigFilePath string) {
-	res := totomlconfig.ToTomlConfig(jsonConfigValue)
+	translated := totomlconfig.Translate(jsonConfigValue)
+	res := totomlconfig.AnnotateTomlConfig(totomlconfig.EncodeTomlConfig(translated))
 	if translator.IsTranslateSuccess() {
 		if error := ioutil.WriteFile(tomlConfigFilePath, []byte(res), tomlFileMode); error != nil {
 			panic(fmt.Sprintf("Failed to create the configuration validation file. Reason: %s \n", error.Error()))
 		} else {
+			if otelConfigFilePath := context.CurrentContext().OutputOtelFilePath(); otelConfigFilePath != "" {
+				otelConfig := tootelconfig.ToOtelConfig(translated)
+				if error := ioutil.WriteFile(otelConfigFilePath, []byte(otelConfig), tomlFileMode); error != nil {
+					panic(fmt.Sprintf("Failed to create the OpenTelemetry Collector configuration file. Reason: %s \n", error.Error()))
+				}
+			}
 			for _, infoMessage := range translator.InfoMessages {
 				fmt.Println(infoMessage)
 			}
@@ -48,6 +59,54 @@ func TranslateJsonMapToTomlFile(jsonConfigValue map[string]interface{}, tomlConf
 	}
 }
 
+// ExplainJsonMapTranslation prints the section of the json config at the dotted path, e.g.
+// "metrics.metrics_collected.cpu", and the toml it is translated into
+func ExplainJsonMapTranslation(jsonConfigValue map[string]interface{}, jsonPath string) {
+	section, ok := jsonValueAt(jsonConfigValue, jsonPath)
+	if !ok {
+		panic(fmt.Sprintf("%v is not in the json config.", jsonPath))
+	}
+	translated := totomlconfig.Translate(jsonConfigValue)
+	if !translator.IsTranslateSuccess() {
+		panic("Failed to generate configuration validation content. ")
+	}
+	data, err := json.MarshalIndent(section, "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to encode %v. Reason: %s \n", jsonPath, err.Error()))
+	}
+	fmt.Printf("%v:\n%s\n\n", jsonPath, data)
+	if explained := totomlconfig.ExplainTomlConfig(translated, jsonPath); explained != "" {
+		fmt.Printf("is translated into:\n%s", explained)
+	} else {
+		fmt.Println("is not translated into any plugin, it may not be supported on this os.")
+	}
+}
+
+// jsonValueAt returns the value at the dotted path of the json config, the indexes of the lists are numbers, e.g.
+// "logs.logs_collected.files.collect_list.0"
+func jsonValueAt(jsonConfigValue map[string]interface{}, jsonPath string) (interface{}, bool) {
+	var val interface{} = jsonConfigValue
+	for _, key := range strings.Split(strings.Trim(jsonPath, "."), ".") {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			val = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			val = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return val, true
+}
+
 // Generate env config based on the input json config
 func TranslateJsonMapToEnvConfigFile(jsonConfigValue map[string]interface{}, envConfigPath string) {
 	if envConfigPath == "" {
@@ -93,10 +152,26 @@ func GetTomlConfigPath(tomlFilePath string) string {
 	return tomlFilePath
 }
 
+// relaxedRequirements matches the contexts of the anyOf which only make a property required unless an alternative is
+// set, e.g. the file_path of the collect_list entries unless they collect systemd_units. Their error that no
+// alternative validates is dropped, the required error of the property says what is missing.
+var relaxedRequirements = regexp.MustCompile(`^\(root\)\.logs\.logs_collected\.files\.collect_list\.\d+$`)
+
 func RunSchemaValidation(inputJsonMap map[string]interface{}) (*gojsonschema.Result, error) {
 	schemaLoader := gojsonschema.NewStringLoader(config.GetJsonSchema())
 	jsonInputLoader := gojsonschema.NewGoLoader(inputJsonMap)
-	return gojsonschema.Validate(schemaLoader, jsonInputLoader)
+	result, err := gojsonschema.Validate(schemaLoader, jsonInputLoader)
+	if err != nil || result.Valid() {
+		return result, err
+	}
+	specific := &gojsonschema.Result{}
+	for _, e := range result.Errors() {
+		if _, ok := e.(*gojsonschema.NumberAnyOfError); ok && relaxedRequirements.MatchString(e.Context().String()) {
+			continue
+		}
+		specific.AddError(e, e.Details())
+	}
+	return specific, nil
 }
 
 func checkSchema(inputJsonMap map[string]interface{}) {
@@ -200,6 +275,12 @@ func GenerateMergedJsonConfigMap(ctx *context.Context) (map[string]interface{},
 		}
 	}
 
+	for path, jsonConfigMap := range jsonConfigMapMap {
+		if err := jsonconfig.ApplyOsConditions(jsonConfigMap, ctx.Os()); err != nil {
+			return nil, fmt.Errorf("unable to apply the os conditions of %v: %v", path, err)
+		}
+	}
+
 	defaultConfig, err := translatorUtil.GetDefaultJsonConfigMap(ctx.Os(), ctx.Mode())
 	if err != nil {
 		return nil, err
@@ -60,6 +60,170 @@ var schema = `{
         "omit_hostname": {
           "description": "Hostname will be tagged by default unless you specifying append_dimensions, this flag allow you to omit hostname from tags without specifying append_dimensions",
           "type": "boolean"
+        },
+        "use_dualstack_endpoint": {
+          "description": "Send to the dual-stack endpoints of the AWS services, required on IPv6-only hosts",
+          "type": "boolean"
+        },
+        "monitoring_account": {
+          "description": "Publish the metrics and the logs to the monitoring account of the CloudWatch cross-account observability, with its role",
+          "type": "object",
+          "properties": {
+            "sink_arn": {
+              "description": "The ARN of the OAM sink of the monitoring account",
+              "type": "string",
+              "pattern": "^arn:[a-z-]+:oam:[a-z0-9-]+:\\d{12}:sink/.+$"
+            },
+            "account_id": {
+              "description": "The ID of the monitoring account, when the ARN of its sink is not set",
+              "type": "string",
+              "pattern": "^\\d{12}$"
+            },
+            "role_name": {
+              "description": "The role of the monitoring account the agent assumes, CloudWatchAgentMonitoringAccountRole by default",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 64
+            },
+            "external_id": {
+              "description": "The external ID required by the trust policy of the role of the monitoring account",
+              "type": "string",
+              "minLength": 2,
+              "maxLength": 1224
+            }
+          },
+          "anyOf": [
+            {
+              "required": [
+                "sink_arn"
+              ]
+            },
+            {
+              "required": [
+                "account_id"
+              ]
+            }
+          ],
+          "additionalProperties": false
+        },
+        "metadata_file": {
+          "description": "A JSON or YAML file of key value pairs added as dimensions to all the metrics and as fields to the JSON log events, re-read when it changes",
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 4096
+        },
+        "flush_on_termination_notice": {
+          "description": "Flush the buffered logs and metrics once the spot interruption or auto scaling scale-in notice of the instance is received",
+          "type": "boolean"
+        },
+        "align_to_wall_clock": {
+          "description": "Collect the metrics at the multiples of their interval on the wall clock, e.g. at :00 and :30 of the minute every 30 seconds, and publish them within publish_jitter of the multiples of force_flush_interval, so that the datapoints of a fleet line up",
+          "type": "boolean"
+        },
+        "self_update": {
+          "description": "Upgrade the agent from the signed packages published to an S3 or HTTPS location, rolling back the versions failing to run healthy",
+          "type": "object",
+          "properties": {
+            "source": {
+              "description": "Location of manifest.json and manifest.json.sig, e.g. s3://bucket/prefix or https://host/path",
+              "type": "string",
+              "pattern": "^(s3|https|file)://"
+            },
+            "public_key": {
+              "description": "The base64 encoded ed25519 public key verifying the signature of the manifest",
+              "type": "string",
+              "minLength": 1
+            },
+            "check_interval": {
+              "description": "Seconds between the checks for a new version, 3600 by default",
+              "type": "integer",
+              "minimum": 60
+            },
+            "health_window": {
+              "description": "Seconds a new version must run healthy before the previous version is discarded, 600 by default",
+              "type": "integer",
+              "minimum": 1
+            }
+          },
+          "required": [
+            "source",
+            "public_key"
+          ],
+          "additionalProperties": false
+        },
+        "debug_payload_sampling": {
+          "description": "Write a copy of the PutLogEvents and PutMetricData requests, with the status of their response, into a local directory to troubleshoot the data not showing up",
+          "type": "object",
+          "properties": {
+            "directory": {
+              "description": "The directory the samples are written into",
+              "type": "string",
+              "minLength": 1
+            },
+            "max_payloads": {
+              "description": "The number of samples kept, the oldest are removed first, 10 by default",
+              "type": "integer",
+              "minimum": 1
+            },
+            "max_size_mb": {
+              "description": "The total size of the samples kept in MB, the oldest are removed first, 10 by default",
+              "type": "integer",
+              "minimum": 1
+            },
+            "sample_every": {
+              "description": "Sample one in every this many requests, 1 by default",
+              "type": "integer",
+              "minimum": 1
+            },
+            "redact": {
+              "description": "Regular expressions of what is redacted from the samples on top of the AWS access keys, the passwords, the secrets and the tokens",
+              "type": "array",
+              "items": {
+                "type": "string",
+                "minLength": 1
+              }
+            }
+          },
+          "required": [
+            "directory"
+          ],
+          "additionalProperties": false
+        },
+        "http_client": {
+          "description": "The tuning of the HTTP transport shared by the AWS service clients of the outputs, the defaults of the transport are kept for the options not set",
+          "type": "object",
+          "properties": {
+            "max_idle_conns_per_host": {
+              "description": "The idle connections kept open to each endpoint for the next requests, 2 by default",
+              "type": "integer",
+              "minimum": 1
+            },
+            "idle_conn_timeout": {
+              "description": "The seconds an idle connection is kept open, 90 by default",
+              "type": "integer",
+              "minimum": 1
+            },
+            "tls_handshake_timeout": {
+              "description": "The seconds the TLS handshake of a connection can take, 10 by default",
+              "type": "integer",
+              "minimum": 1
+            },
+            "disable_http2": {
+              "description": "Send the requests over HTTP/1.1 even when the endpoint supports HTTP/2",
+              "type": "boolean"
+            },
+            "dns_cache_ttl": {
+              "description": "The seconds the addresses of the endpoints are cached, they are resolved again sooner when none of them can be connected to. 60 by default, 0 resolves them for every connection",
+              "type": "integer",
+              "minimum": 0
+            }
+          },
+          "additionalProperties": false
+        },
+        "bind_address": {
+          "description": "The IP address the statsd, collectd and emf listeners listen on when their service_address is not set, e.g. ::1 on IPv6-only hosts",
+          "type": "string",
+          "minLength": 1
         }
       },
       "additionalProperties": true
@@ -127,7 +291,7 @@ var schema = `{
         },
         "append_dimensions": {
           "type": "object",
-          "description": "Adds Amazon EC2 metric dimensions to all metrics collected by the agent, we only support fixed key value pair now: ImageId:{aws:ImageId},InstanceId:{aws:InstanceId},InstanceType:{aws:InstanceType},AutoScalingGroupName:{aws:AutoScalingGroupName}. ",
+          "description": "Adds Amazon EC2 metric dimensions to all metrics collected by the agent, we only support fixed key value pair now: ImageId:{aws:ImageId},InstanceId:{aws:InstanceId},InstanceType:{aws:InstanceType},AutoScalingGroupName:{aws:AutoScalingGroupName}, EKSNodegroupName:{aws:EKSNodegroupName}, CloudFormationStackName:{aws:CloudFormationStackName}, <dimension>:{aws:tag/<key of the instance tag>}, and VolumeId:{aws:VolumeId} for the disk and diskio metrics. ",
           "maxProperties": 10,
           "additionalProperties": {
             "type": "string",
@@ -173,6 +337,73 @@ var schema = `{
             },
             "ethtool": {
               "$ref": "#/definitions/metricsDefinition/definitions/ethtoolDefinitions"
+            },
+            "ipmi": {
+              "$ref": "#/definitions/metricsDefinition/definitions/ipmiDefinitions"
+            },
+            "pressure": {
+              "$ref": "#/definitions/metricsDefinition/definitions/pressureDefinitions"
+            },
+            "ebpf": {
+              "$ref": "#/definitions/metricsDefinition/definitions/ebpfDefinitions"
+            },
+            "cgroupv2": {
+              "$ref": "#/definitions/metricsDefinition/definitions/cgroupv2Definitions"
+            },
+            "paging": {
+              "$ref": "#/definitions/metricsDefinition/definitions/pagingDefinitions"
+            },
+            "disk_latency": {
+              "$ref": "#/definitions/metricsDefinition/definitions/diskLatencyDefinitions"
+            },
+            "tls_cert": {
+              "$ref": "#/definitions/metricsDefinition/definitions/tlsCertDefinitions"
+            },
+            "wmi": {
+              "$ref": "#/definitions/metricsDefinition/definitions/wmiDefinitions"
+            },
+            "log_delivery": {
+              "$ref": "#/definitions/metricsDefinition/definitions/logDeliveryDefinitions"
+            },
+            "mysql": {
+              "$ref": "#/definitions/metricsDefinition/definitions/mysqlDefinitions"
+            },
+            "postgresql": {
+              "$ref": "#/definitions/metricsDefinition/definitions/postgresqlDefinitions"
+            },
+            "redis": {
+              "$ref": "#/definitions/metricsDefinition/definitions/redisDefinitions"
+            },
+            "memcached": {
+              "$ref": "#/definitions/metricsDefinition/definitions/memcachedDefinitions"
+            },
+            "haproxy": {
+              "$ref": "#/definitions/metricsDefinition/definitions/haproxyDefinitions"
+            },
+            "envoy": {
+              "$ref": "#/definitions/metricsDefinition/definitions/envoyDefinitions"
+            },
+            "kafka": {
+              "$ref": "#/definitions/metricsDefinition/definitions/kafkaDefinitions"
+            },
+            "port_check": {
+              "$ref": "#/definitions/metricsDefinition/definitions/portCheckDefinitions"
+            },
+            "hyperv": {
+              "description": "The Hyper-V counters of the host: the CPU wait of the virtual processors, the dynamic memory pressure and the VHD latency",
+              "$ref": "#/definitions/metricsDefinition/definitions/windowsPresetDefinition"
+            },
+            "failover_cluster": {
+              "description": "The failover cluster counters of the node: the health of the resources and of the cluster network, and the CSV latency",
+              "$ref": "#/definitions/metricsDefinition/definitions/windowsPresetDefinition"
+            },
+            "active_directory": {
+              "description": "The Active Directory counters of the domain controller: the LDAP bind time and load, the replication queue and the authentications",
+              "$ref": "#/definitions/metricsDefinition/definitions/windowsPresetDefinition"
+            },
+            "dns_server": {
+              "description": "The DNS server counters: the query and response rates, the recursion failures and the zone transfers",
+              "$ref": "#/definitions/metricsDefinition/definitions/windowsPresetDefinition"
             }
           },
           "minProperties": 1,
@@ -184,6 +415,21 @@ var schema = `{
           "description": "Max time to wait before batch publishing the metrics, unit is second.",
           "$ref": "#/definitions/timeIntervalDefinition"
         },
+        "publish_jitter": {
+          "description": "The most seconds the publishes are delayed after the multiples of force_flush_interval on the wall clock, random per agent to spread the API calls of a fleet, 0 to not delay them. Default is force_flush_interval, or 5 with align_to_wall_clock",
+          "type": "integer",
+          "minimum": 0,
+          "maximum": 86400
+        },
+        "late_data_policy": {
+          "description": "What the aggregation of the metrics with metrics_aggregation_interval does with the datapoints arriving after their window was published: republish the window, the default, add them to the next window, or drop them",
+          "type": "string",
+          "enum": [
+            "republish",
+            "next_window",
+            "drop"
+          ]
+        },
         "credentials": {
           "description": "The credentials with which agent can access aws resources",
           "$ref": "#/definitions/credentialsDefinition"
@@ -191,118 +437,427 @@ var schema = `{
         "endpoint_override": {
           "description": "The override endpoint to use to access cloudwatch",
           "$ref": "#/definitions/endpointOverrideDefinition"
-        }
-      },
-      "additionalProperties": false,
-      "required": [
-        "metrics_collected"
-      ],
-      "definitions": {
-        "basicMetricDefinition": {
+        },
+        "buffer_dir": {
+          "description": "Persist the metrics which could not be published, and the ones queued on stop, into this directory until CloudWatch accepts them",
+          "type": "string",
+          "minLength": 1
+        },
+        "buffer_max_size_mb": {
+          "description": "The size cap of buffer_dir in MiB, the oldest buffered metrics are dropped first. Default is 64",
+          "type": "integer",
+          "minimum": 1,
+          "maximum": 10240
+        },
+        "buffer_compression": {
+          "description": "Compress the metrics persisted into buffer_dir, buffer_max_size_mb applies to the compressed size",
+          "type": "string",
+          "enum": [
+            "zstd"
+          ]
+        },
+        "buffer_compression_level": {
+          "description": "The zstd level of buffer_compression, from 1 (fastest) to 22. Default is 3",
+          "type": "integer",
+          "minimum": 1,
+          "maximum": 22
+        },
+        "alarms": {
+          "description": "The standard alarms created or updated for the instance at startup and deleted by the decommission action, on the metrics with the InstanceId dimension",
           "type": "object",
           "properties": {
-            "metrics_collection_interval": {
-              "$ref": "#/definitions/timeIntervalDefinition"
+            "name_prefix": {
+              "description": "The prefix of the alarm names, which end with the instance ID. Default is CWAgent-",
+              "type": "string",
+              "maxLength": 128
             },
-            "append_dimensions": {
-              "$ref": "#/definitions/generalAppendDimensionsDefinition"
+            "alarm_actions": {
+              "$ref": "#/definitions/metricsDefinition/definitions/alarmActionsDefinition"
             },
-            "measurement": {
-              "$ref": "#/definitions/metricsDefinition/definitions/metricsMeasurementDefinition"
+            "ok_actions": {
+              "$ref": "#/definitions/metricsDefinition/definitions/alarmActionsDefinition"
+            },
+            "period": {
+              "description": "The period of the alarms in seconds. Default is 300",
+              "type": "integer",
+              "minimum": 10
+            },
+            "evaluation_periods": {
+              "description": "The number of periods breaching the threshold before the alarms fire. Default is 2",
+              "type": "integer",
+              "minimum": 1
+            },
+            "disk_full": {
+              "description": "Alarm when the used percent of a disk reaches the threshold, 90 by default",
+              "$ref": "#/definitions/metricsDefinition/definitions/alarmThresholdDefinition"
+            },
+            "memory": {
+              "description": "Alarm when the used percent of the memory reaches the threshold, 90 by default",
+              "$ref": "#/definitions/metricsDefinition/definitions/alarmThresholdDefinition"
+            },
+            "agent_health": {
+              "description": "Alarm when the agent stops publishing the memory metrics",
+              "type": "boolean"
+            }
+          },
+          "additionalProperties": false
+        },
+        "anomaly_detection": {
+          "description": "Detect the anomalies of the collected metrics on the host and run local actions when a rule goes into and out of alarm",
+          "type": "object",
+          "properties": {
+            "command_timeout": {
+              "description": "Seconds the commands of the rules run before they are killed. Default is 30",
+              "type": "integer",
+              "minimum": 1
+            },
+            "rules": {
+              "type": "array",
+              "minItems": 1,
+              "maxItems": 100,
+              "items": {
+                "$ref": "#/definitions/metricsDefinition/definitions/anomalyRuleDefinition"
+              }
             }
           },
+          "additionalProperties": false,
           "required": [
-            "measurement"
+            "rules"
           ]
         },
-        "basicResourcesDefinition": {
+        "file": {
+          "description": "Write the collected metrics into a local file, for debugging",
+          "$ref": "#/definitions/fileOutputDefinition"
+        },
+        "prometheus_exporter": {
+          "description": "Serve the collected metrics on a local endpoint in the Prometheus exposition format, alongside publishing them to CloudWatch",
           "type": "object",
           "properties": {
-            "resources": {
+            "listen_address": {
+              "description": "Address the endpoint listens on, 127.0.0.1:9273 or port 9273 of the bind_address of the agent by default",
+              "type": "string",
+              "minLength": 1
+            },
+            "path": {
+              "type": "string",
+              "pattern": "^/"
+            },
+            "metric_version": {
+              "description": "Mapping of the metrics into the Prometheus format, 2 by default",
+              "type": "integer",
+              "enum": [1, 2]
+            },
+            "expiration_interval": {
+              "description": "Seconds a metric is served after it was last collected, 0 to never expire",
+              "type": "integer",
+              "minimum": 0
+            },
+            "ip_range": {
+              "description": "The IP ranges allowed to scrape the endpoint",
               "type": "array",
               "items": {
                 "type": "string",
-                "minLength": 1,
-                "maxLength": 4096
-              },
-              "maxItems": 256
+                "minLength": 1
+              }
+            },
+            "tls_cert": {
+              "type": "string",
+              "minLength": 1
+            },
+            "tls_key": {
+              "type": "string",
+              "minLength": 1
             }
+          },
+          "additionalProperties": false,
+          "dependencies": {
+            "tls_cert": ["tls_key"],
+            "tls_key": ["tls_cert"]
           }
         },
-        "collectdDefinitions": {
+        "role_overrides": {
+          "description": "Publish the metrics carrying a given tag value to another namespace and/or with another IAM role",
+          "type": "array",
+          "minItems": 1,
+          "maxItems": 64,
+          "items": {
+            "$ref": "#/definitions/metricsDefinition/definitions/roleOverrideDefinition"
+          }
+        }
+      },
+      "additionalProperties": false,
+      "required": [
+        "metrics_collected"
+      ],
+      "definitions": {
+        "anomalyRuleDefinition": {
           "type": "object",
           "properties": {
-            "service_address": {
+            "name": {
               "type": "string",
               "minLength": 1,
               "maxLength": 255
             },
-            "name_prefix": {
+            "metric_name": {
+              "description": "The metric the rule evaluates, the name of its measurement and of its field joined by _ as collected, before the rename of the measurement, e.g. mem_used_percent",
               "type": "string",
-              "minLength": 1,
-              "maxLength": 255
+              "minLength": 1
             },
-            "collectd_auth_file": {
-              "type": "string",
-              "minLength": 1,
-              "maxLength": 4096
+            "dimensions": {
+              "description": "The tags the series of the metric must carry to be evaluated",
+              "type": "object",
+              "additionalProperties": {
+                "type": "string"
+              }
             },
-            "collectd_security_level": {
+            "mode": {
+              "description": "threshold compares the values to above and below, ewma to the moving average of the series. Default is threshold",
               "type": "string",
               "enum": [
-                "none",
-                "sign",
-                "encrypt"
+                "threshold",
+                "ewma"
               ]
             },
-            "collectd_typesdb": {
+            "above": {
+              "type": "number"
+            },
+            "below": {
+              "type": "number"
+            },
+            "alpha": {
+              "description": "The weight of the new values in the moving average. Default is 0.3",
+              "type": "number",
+              "minimum": 0,
+              "exclusiveMinimum": true,
+              "maximum": 1
+            },
+            "deviations": {
+              "description": "The standard deviations from the moving average a value is anomalous at. Default is 3",
+              "type": "number",
+              "minimum": 0,
+              "exclusiveMinimum": true
+            },
+            "warmup": {
+              "description": "The values of a series the moving average learns from before it is evaluated. Default is 10",
+              "type": "integer",
+              "minimum": 1
+            },
+            "trigger_count": {
+              "description": "The consecutive anomalous values going into alarm. Default is 3",
+              "type": "integer",
+              "minimum": 1
+            },
+            "clear_count": {
+              "description": "The consecutive normal values going out of alarm. Default is 3",
+              "type": "integer",
+              "minimum": 1
+            },
+            "command": {
+              "description": "The command run, with its arguments, when the rule goes into and out of alarm",
               "type": "array",
-              "maxItems": 10,
+              "minItems": 1,
               "items": {
                 "type": "string",
-                "minLength": 1,
-                "maxLength": 4096
+                "minLength": 1
               }
             },
-            "metrics_aggregation_interval": {
-              "$ref": "#/definitions/timeIntervalWithZeroDefinition"
+            "sns_topic_arn": {
+              "description": "The SNS topic the events of the rule are published to",
+              "type": "string",
+              "minLength": 1
             }
           },
-          "additionalProperties": false
+          "additionalProperties": false,
+          "required": [
+            "name",
+            "metric_name"
+          ]
         },
-        "cpuDefinitions": {
-          "type": "object",
-          "allOf": [
+        "alarmActionsDefinition": {
+          "description": "The ARNs of the actions of the alarms, e.g. SNS topics",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "minLength": 1,
+            "maxLength": 1024
+          },
+          "maxItems": 5,
+          "uniqueItems": true
+        },
+        "alarmThresholdDefinition": {
+          "oneOf": [
             {
-              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+              "type": "boolean"
             },
             {
               "type": "object",
               "properties": {
-                "resources": {
-                  "type": "array",
-                  "maxItems": 1,
-                  "items": {
-                    "type": "string",
-                    "enum": [
-                      "*"
-                    ]
-                  }
-                },
-                "totalcpu": {
-                  "type": "boolean"
+                "threshold": {
+                  "type": "number",
+                  "minimum": 1,
+                  "maximum": 100
                 }
-              }
+              },
+              "additionalProperties": false
             }
           ]
         },
-        "diskDefinitions": {
+        "roleOverrideDefinition": {
           "type": "object",
-          "allOf": [
-            {
-              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
-            },
-            {
+          "properties": {
+            "tag_key": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            },
+            "tag_value": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            },
+            "namespace": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            },
+            "role_arn": {
+              "type": "string",
+              "minLength": 20,
+              "maxLength": 2048
+            }
+          },
+          "required": [
+            "tag_key",
+            "tag_value"
+          ],
+          "anyOf": [
+            {
+              "required": [
+                "namespace"
+              ]
+            },
+            {
+              "required": [
+                "role_arn"
+              ]
+            }
+          ],
+          "additionalProperties": false
+        },
+        "basicMetricDefinition": {
+          "type": "object",
+          "properties": {
+            "metrics_collection_interval": {
+              "$ref": "#/definitions/timeIntervalDefinition"
+            },
+            "collection_jitter": {
+              "description": "The collections of the plugin are delayed by a random number of seconds up to the jitter, bounded to half of its interval, so that the plugins do not all collect at the same instant",
+              "$ref": "#/definitions/timeIntervalWithZeroDefinition"
+            },
+            "append_dimensions": {
+              "$ref": "#/definitions/generalAppendDimensionsDefinition"
+            },
+            "measurement": {
+              "$ref": "#/definitions/metricsDefinition/definitions/metricsMeasurementDefinition"
+            }
+          },
+          "required": [
+            "measurement"
+          ]
+        },
+        "basicResourcesDefinition": {
+          "type": "object",
+          "properties": {
+            "resources": {
+              "type": "array",
+              "items": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 4096
+              },
+              "maxItems": 256
+            }
+          }
+        },
+        "collectdDefinitions": {
+          "type": "object",
+          "properties": {
+            "service_address": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            },
+            "name_prefix": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            },
+            "collectd_auth_file": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "collectd_security_level": {
+              "type": "string",
+              "enum": [
+                "none",
+                "sign",
+                "encrypt"
+              ]
+            },
+            "collectd_typesdb": {
+              "description": "The typesdb files and directories of files of the collectd types, they are reloaded when they change",
+              "type": "array",
+              "maxItems": 10,
+              "items": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 4096
+              }
+            },
+            "metrics_aggregation_interval": {
+              "$ref": "#/definitions/timeIntervalWithZeroDefinition"
+            },
+            "collection_jitter": {
+              "description": "The collections of the socket listener of collectd are delayed by a random number of seconds up to the jitter, bounded to half of its interval",
+              "$ref": "#/definitions/timeIntervalWithZeroDefinition"
+            }
+          },
+          "additionalProperties": false
+        },
+        "cpuDefinitions": {
+          "type": "object",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "resources": {
+                  "type": "array",
+                  "maxItems": 1,
+                  "items": {
+                    "type": "string",
+                    "enum": [
+                      "*"
+                    ]
+                  }
+                },
+                "totalcpu": {
+                  "type": "boolean"
+                }
+              }
+            }
+          ]
+        },
+        "diskDefinitions": {
+          "type": "object",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
               "$ref": "#/definitions/metricsDefinition/definitions/basicResourcesDefinition"
             },
             {
@@ -348,6 +903,17 @@ var schema = `{
               "minLength": 1,
               "maxLength": 255
             },
+            "protocol": {
+              "description": "The protocol of the listener, udp by default, the lines of the tcp connections are separated by newlines",
+              "type": "string",
+              "enum": [
+                "udp",
+                "tcp"
+              ]
+            },
+            "auth": {
+              "$ref": "#/definitions/listenerAuthDefinition"
+            },
             "metrics_collection_interval": {
               "$ref": "#/definitions/timeIntervalDefinition"
             },
@@ -358,6 +924,84 @@ var schema = `{
               "type": "string",
               "minLength": 1,
               "maxLength": 255
+            },
+            "timing_output": {
+              "description": "Publish the timings and histograms as distributions, or as the separate metrics of their min, max, avg, count and percentiles with statistics",
+              "type": "string",
+              "enum": [
+                "distribution",
+                "statistics"
+              ]
+            },
+            "percentiles": {
+              "description": "The percentiles of the timings published with the statistics timing_output, 50, 90 and 99 by default",
+              "type": "array",
+              "items": {
+                "type": "number",
+                "minimum": 0,
+                "exclusiveMinimum": true,
+                "maximum": 100
+              },
+              "minItems": 1,
+              "maxItems": 10,
+              "uniqueItems": true
+            },
+            "prefix_rules": {
+              "description": "Map the metrics whose name starts with a prefix to a namespace and keep only the given tags as dimensions, the first matching rule applies",
+              "type": "array",
+              "minItems": 1,
+              "maxItems": 64,
+              "items": {
+                "type": "object",
+                "properties": {
+                  "prefix": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "namespace": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "strip_prefix": {
+                    "type": "boolean"
+                  },
+                  "dimensions": {
+                    "description": "The tags kept as dimensions, all of them when not set",
+                    "type": "array",
+                    "items": {
+                      "type": "string",
+                      "minLength": 1,
+                      "maxLength": 255
+                    },
+                    "minItems": 1,
+                    "maxItems": 30,
+                    "uniqueItems": true
+                  },
+                  "aggregation_dimensions": {
+                    "description": "The aggregation dimensions of the namespace, the ones of the metrics section when not set",
+                    "type": "array",
+                    "items": {
+                      "type": "array",
+                      "items": {
+                        "type": "string",
+                        "minLength": 1,
+                        "maxLength": 255
+                      },
+                      "uniqueItems": true,
+                      "maxItems": 10
+                    },
+                    "uniqueItems": true,
+                    "minItems": 1,
+                    "maxItems": 1024
+                  }
+                },
+                "required": [
+                  "prefix"
+                ],
+                "additionalProperties": false
+              }
             }
           },
           "additionalProperties": false
@@ -470,62 +1114,790 @@ var schema = `{
           },
           "additionalProperties": false
         },
-        "metricsMeasurementWithoutDecorationDefinition": {
-          "type": "array",
-          "items": {
-            "type": "string",
-            "minLength": 1,
-            "maxLength": 255
-          },
-          "uniqueItems": true
-        },
-        "metricsMeasurementDefinition": {
-          "type": "array",
-          "items": {
-            "oneOf": [
-              {
+        "windowsPresetDefinition": {
+          "type": "object",
+          "properties": {
+            "measurement": {
+              "description": "The counters of the preset to collect, all of them by default",
+              "$ref": "#/definitions/metricsDefinition/definitions/metricsMeasurementWithoutDecorationDefinition"
+            },
+            "metrics_collection_interval": {
+              "$ref": "#/definitions/timeIntervalDefinition"
+            },
+            "append_dimensions": {
+              "$ref": "#/definitions/generalAppendDimensionsDefinition"
+            },
+            "resources": {
+              "description": "The instances of the counter objects having instances, e.g. the VMs, all of them by default",
+              "type": "array",
+              "items": {
                 "type": "string",
                 "minLength": 1,
-                "maxLength": 255
+                "maxLength": 4096
               },
-              {
+              "maxItems": 256
+            }
+          },
+          "additionalProperties": false
+        },
+        "wmiDefinitions": {
+          "description": "The results of WMI queries and performance counter paths of Windows, for the metrics not exposed by the performance counter objects",
+          "type": "object",
+          "properties": {
+            "queries": {
+              "type": "array",
+              "minItems": 1,
+              "maxItems": 64,
+              "items": {
                 "type": "object",
                 "properties": {
                   "name": {
+                    "description": "The measurement of the rows of the query",
                     "type": "string",
                     "minLength": 1,
                     "maxLength": 255
                   },
-                  "rename": {
+                  "namespace": {
+                    "description": "The WMI namespace of the query, root\\cimv2 by default",
                     "type": "string",
                     "minLength": 1,
                     "maxLength": 255
                   },
-                  "unit": {
+                  "query": {
+                    "description": "The WQL SELECT statement",
                     "type": "string",
-                    "minLength": 1,
-                    "maxLength": 256
+                    "pattern": "^\\s*[Ss][Ee][Ll][Ee][Cc][Tt]\\s",
+                    "maxLength": 4096
+                  },
+                  "properties": {
+                    "description": "The properties published as fields",
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                      "type": "string",
+                      "minLength": 1,
+                      "maxLength": 255
+                    }
+                  },
+                  "tag_properties": {
+                    "description": "The properties published as dimensions",
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                      "type": "string",
+                      "minLength": 1,
+                      "maxLength": 255
+                    }
+                  },
+                  "value_type": {
+                    "description": "The type the values of the properties are validated and converted to, float by default",
+                    "type": "string",
+                    "enum": [
+                      "float",
+                      "integer",
+                      "boolean"
+                    ]
                   }
-                }
+                },
+                "required": [
+                  "name",
+                  "query",
+                  "properties"
+                ],
+                "additionalProperties": false
               }
-            ]
-          },
-          "uniqueItems": true
-        }
-      }
-    },
-    "logsDefinition": {
-      "type": "object",
-      "descriptions": "configuration for collecting logs and upload to cloudWatch log service",
-      "properties": {
-        "logs_collected": {
-          "type": "object",
+            },
+            "counters": {
+              "type": "array",
+              "minItems": 1,
+              "maxItems": 256,
+              "items": {
+                "type": "object",
+                "properties": {
+                  "measurement": {
+                    "description": "The measurement of the counter, wmi_counter by default",
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "field": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "path": {
+                    "description": "The performance counter path, e.g. \\Processor(_Total)\\% Processor Time",
+                    "type": "string",
+                    "minLength": 2,
+                    "maxLength": 1024
+                  }
+                },
+                "required": [
+                  "field",
+                  "path"
+                ],
+                "additionalProperties": false
+              }
+            },
+            "timeout": {
+              "description": "The number of seconds a query may run, a query timing out is skipped until it completes",
+              "type": "integer",
+              "minimum": 1,
+              "maximum": 300
+            },
+            "max_rows": {
+              "description": "The highest number of rows of a query, the queries returning more rows are dropped",
+              "type": "integer",
+              "minimum": 1,
+              "maximum": 10000
+            },
+            "metrics_collection_interval": {
+              "$ref": "#/definitions/timeIntervalDefinition"
+            }
+          },
+          "additionalProperties": false
+        },
+        "tlsCertDefinitions": {
+          "description": "The days until the expiry and the validity of the chain of the local TLS certificate files",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "paths": {
+                  "description": "The certificate files and the directories of certificate files, glob patterns are allowed",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 4096
+                  }
+                },
+                "ca_file": {
+                  "description": "The root certificates the chains are verified with, the roots of the system by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                }
+              },
+              "required": [
+                "paths"
+              ]
+            }
+          ]
+        },
+        "mysqlDefinitions": {
+          "description": "The connections, the slow queries, the hit rate of the InnoDB buffer pool and the replication lag of MySQL servers",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "servers": {
+                  "description": "The DSNs of the servers as [username[:password]@][protocol[(address)]]/[?param=value], the local server by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 1024
+                  }
+                },
+                "secret_id": {
+                  "description": "The ARN or the name of the secret of Secrets Manager with the user name and the password of the servers, a JSON object with username and password keys",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 2048
+                },
+                "gather_replica_status": {
+                  "description": "Whether the replication lag of the replicas is collected, it needs the REPLICATION CLIENT privilege",
+                  "type": "boolean"
+                }
+              }
+            }
+          ]
+        },
+        "postgresqlDefinitions": {
+          "description": "The connections and the buffer hit rate of the databases, the slow queries and the replication lag of a PostgreSQL server",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "address": {
+                  "description": "The address of the server, a postgres:// URL or key value pairs as host=localhost sslmode=disable, the local server by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 1024
+                },
+                "secret_id": {
+                  "description": "The ARN or the name of the secret of Secrets Manager with the user name and the password of the server, a JSON object with username and password keys",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 2048
+                },
+                "databases": {
+                  "description": "The databases collected, all of them by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 63
+                  }
+                },
+                "ignored_databases": {
+                  "description": "The databases not collected",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 63
+                  }
+                },
+                "slow_query_threshold": {
+                  "description": "The seconds after which the running queries are counted as slow, 10 by default",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 86400
+                }
+              }
+            }
+          ]
+        },
+        "redisDefinitions": {
+          "description": "The clients, the keyspace hit rate and the replication of Redis servers",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "servers": {
+                  "description": "The URLs of the servers as tcp://[:password@]host:port or unix:///path, the local server by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 1024
+                  }
+                },
+                "secret_id": {
+                  "description": "The ARN or the name of the secret of Secrets Manager with the password of the servers, the password itself or a JSON object with a password key",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 2048
+                }
+              }
+            }
+          ]
+        },
+        "memcachedDefinitions": {
+          "description": "The connections, the items and the get hit rate of Memcached servers",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "servers": {
+                  "description": "The addresses of the servers as host:port, localhost:11211 by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 1024
+                  }
+                },
+                "unix_sockets": {
+                  "description": "The unix sockets of the servers",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 4096
+                  }
+                }
+              }
+            }
+          ]
+        },
+        "haproxyDefinitions": {
+          "description": "The sessions, the HTTP errors, the retries and the health of the frontends, the backends and the servers of HAProxy",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "servers": {
+                  "description": "The URLs of the stats pages and the paths of the stats sockets as socket:/path, http://127.0.0.1:1936/haproxy?stats by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 4096
+                  }
+                },
+                "secret_id": {
+                  "description": "The ARN or the name of the secret of Secrets Manager with the basic authentication of the stats pages, a JSON object with username and password keys",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 2048
+                }
+              }
+            }
+          ]
+        },
+        "envoyDefinitions": {
+          "description": "The requests, the HTTP errors, the retries, the connections and the healthy hosts of the clusters and the HTTP connection managers of Envoy",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "urls": {
+                  "description": "The /stats URLs of the admin endpoints, http://localhost:9901/stats by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 4096
+                  }
+                }
+              }
+            }
+          ]
+        },
+        "kafkaDefinitions": {
+          "description": "The lag of the consumer groups of Kafka or MSK per topic and partition, the messages they have not committed yet",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "brokers": {
+                  "description": "The addresses of the brokers as host:port",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 1024
+                  }
+                },
+                "consumer_groups": {
+                  "description": "The glob patterns of the consumer groups, all of them by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  }
+                },
+                "topics": {
+                  "description": "The glob patterns of the topics, all of them by default",
+                  "type": "array",
+                  "minItems": 1,
+                  "uniqueItems": true,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  }
+                },
+                "per_partition": {
+                  "description": "Whether the lag of every partition is collected, on top of the total and the max lag of the topics, true by default",
+                  "type": "boolean"
+                },
+                "sasl_mechanism": {
+                  "description": "The SASL mechanism of the brokers, none by default. AWS_MSK_IAM is the IAM authentication of MSK with the credentials of the agent, over TLS",
+                  "type": "string",
+                  "enum": [
+                    "AWS_MSK_IAM",
+                    "SCRAM-SHA-512",
+                    "SCRAM-SHA-256",
+                    "PLAIN"
+                  ]
+                },
+                "secret_id": {
+                  "description": "The ARN or the name of the secret of Secrets Manager with the user name and the password of SCRAM or PLAIN, a JSON object with username and password keys",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 2048
+                },
+                "tls": {
+                  "description": "Whether the brokers are connected to over TLS, always with AWS_MSK_IAM",
+                  "type": "boolean"
+                }
+              },
+              "required": [
+                "brokers"
+              ]
+            }
+          ]
+        },
+        "portCheckDefinitions": {
+          "description": "The checks of TCP and UDP targets, whether they answer and the time of the connection and of the response",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "targets": {
+                  "type": "array",
+                  "minItems": 1,
+                  "items": {
+                    "type": "object",
+                    "properties": {
+                      "address": {
+                        "description": "The host:port of the target",
+                        "type": "string",
+                        "minLength": 1,
+                        "maxLength": 1024
+                      },
+                      "protocol": {
+                        "description": "The protocol of the target, tcp by default",
+                        "type": "string",
+                        "enum": [
+                          "tcp",
+                          "udp"
+                        ]
+                      },
+                      "send": {
+                        "description": "The string written once connected, required for UDP since the servers only answer to a request",
+                        "type": "string",
+                        "minLength": 1,
+                        "maxLength": 4096
+                      },
+                      "expect": {
+                        "description": "The string the response must contain, any response when it is not set",
+                        "type": "string",
+                        "minLength": 1,
+                        "maxLength": 4096
+                      }
+                    },
+                    "required": [
+                      "address"
+                    ],
+                    "additionalProperties": false
+                  }
+                },
+                "timeout": {
+                  "description": "The timeout of the checks in seconds, from the connection to the expected response, default is 5",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 60
+                }
+              },
+              "required": [
+                "targets"
+              ]
+            }
+          ]
+        },
+        "diskLatencyDefinitions": {
+          "description": "The percentiles of the latency of the reads and writes of the disks read from /proc/diskstats",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicResourcesDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "sample_interval": {
+                  "description": "How often in seconds /proc/diskstats is read, the percentiles are of the average latency of the IOs of each sample",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 60
+                },
+                "proc_path": {
+                  "description": "Where the proc file system of the host is mounted, HOST_PROC or /proc by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                },
+                "latency_output": {
+                  "description": "Publish the awaits as the separate metrics of their percentiles, the default, or as distributions whose percentiles CloudWatch computes over any period",
+                  "type": "string",
+                  "enum": [
+                    "percentiles",
+                    "distribution"
+                  ]
+                },
+                "metrics_aggregation_interval": {
+                  "description": "The interval in seconds the distributions of the awaits of the collections are merged over before they are published, 60 by default, 0 to publish the ones of every collection",
+                  "$ref": "#/definitions/timeIntervalWithZeroDefinition"
+                }
+              }
+            }
+          ]
+        },
+        "logDeliveryDefinitions": {
+          "description": "The ingestion lag of the log streams the agent publishes to, the time elapsed since the newest log event they accepted",
+          "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+        },
+        "pagingDefinitions": {
+          "description": "The rates of the page faults and of the swapping read from /proc/vmstat",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "proc_path": {
+                  "description": "Where the proc file system of the host is mounted, HOST_PROC or /proc by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                }
+              }
+            }
+          ]
+        },
+        "cgroupv2Definitions": {
+          "description": "The memory, OOM kills and CPU throttling of the cgroups of the cgroup v2 unified hierarchy",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "mount_path": {
+                  "description": "Where the unified hierarchy is mounted, /sys/fs/cgroup by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                },
+                "paths": {
+                  "description": "The cgroups to collect relative to the mount path, glob patterns are allowed, the slices of the root by default",
+                  "type": "array",
+                  "maxItems": 256,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 4096
+                  }
+                },
+                "self": {
+                  "description": "Collect the cgroup of the agent too",
+                  "type": "boolean"
+                }
+              }
+            }
+          ]
+        },
+        "ebpfDefinitions": {
+          "description": "The TCP connect latency and retransmits and the DNS resolution latency by destination collected with eBPF, the probes the kernel cannot run are skipped",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "probes": {
+                  "description": "The probes, those of the measurements by default",
+                  "type": "array",
+                  "items": {
+                    "type": "string",
+                    "enum": [
+                      "tcp",
+                      "dns"
+                    ]
+                  },
+                  "minItems": 1,
+                  "uniqueItems": true
+                },
+                "max_destinations": {
+                  "description": "The highest number of destinations of a probe reported by interval, the others are merged into the other destination, 50 by default",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 1000
+                },
+                "dns_timeout": {
+                  "description": "Seconds a DNS query is waited for its response before it counts as a timeout, 5 by default",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 60
+                },
+                "tracefs_path": {
+                  "description": "Where the tracefs is mounted, /sys/kernel/tracing or /sys/kernel/debug/tracing by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                }
+              }
+            }
+          ]
+        },
+        "pressureDefinitions": {
+          "description": "The pressure stall information (PSI) of the CPU, the memory and the IO read from /proc/pressure, Linux 4.20 or later",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "proc_path": {
+                  "description": "Where the proc file system of the host is mounted, HOST_PROC or /proc by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                }
+              }
+            }
+          ]
+        },
+        "ipmiDefinitions": {
+          "description": "The hardware sensors read with ipmitool, e.g. temperatures, fan speeds and power supplies, and their threshold breaches",
+          "allOf": [
+            {
+              "$ref": "#/definitions/metricsDefinition/definitions/basicMetricDefinition"
+            },
+            {
+              "type": "object",
+              "properties": {
+                "path": {
+                  "description": "The path of ipmitool, looked up in PATH by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 4096
+                },
+                "use_sudo": {
+                  "description": "Run ipmitool with sudo, which must allow it without a password and keep the IPMI_PASSWORD environment variable of the servers with a password",
+                  "type": "boolean"
+                },
+                "privilege": {
+                  "description": "The privilege level of the sessions of the servers",
+                  "type": "string",
+                  "enum": [
+                    "CALLBACK",
+                    "USER",
+                    "OPERATOR",
+                    "ADMINISTRATOR"
+                  ]
+                },
+                "servers": {
+                  "description": "The BMCs to query as [username[:password]@][protocol[(address)]], the local one when missing",
+                  "type": "array",
+                  "maxItems": 64,
+                  "items": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 1024
+                  }
+                },
+                "timeout": {
+                  "description": "The timeout of ipmitool in seconds, default is 20",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 300
+                }
+              }
+            }
+          ]
+        },
+        "metricsMeasurementWithoutDecorationDefinition": {
+          "type": "array",
+          "items": {
+            "type": "string",
+            "minLength": 1,
+            "maxLength": 255
+          },
+          "uniqueItems": true
+        },
+        "metricsMeasurementDefinition": {
+          "type": "array",
+          "items": {
+            "oneOf": [
+              {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 255
+              },
+              {
+                "type": "object",
+                "properties": {
+                  "name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "rename": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "unit": {
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 256
+                  }
+                }
+              }
+            ]
+          },
+          "uniqueItems": true
+        }
+      }
+    },
+    "logsDefinition": {
+      "type": "object",
+      "descriptions": "configuration for collecting logs and upload to cloudWatch log service",
+      "properties": {
+        "logs_collected": {
+          "type": "object",
           "properties": {
             "files": {
               "$ref": "#/definitions/logsDefinition/definitions/logsFilesDefinition"
             },
             "windows_events": {
               "$ref": "#/definitions/logsDefinition/definitions/logsWindowsEventsDefinition"
+            },
+            "auditd": {
+              "$ref": "#/definitions/logsDefinition/definitions/logsAuditdDefinition"
             }
           },
           "minProperties": 1,
@@ -553,11 +1925,42 @@ var schema = `{
                 },
                 "metrics_collection_interval": {
                   "$ref": "#/definitions/timeIntervalDefinition"
+                },
+                "workload_dimensions": {
+                  "description": "Aggregate the pod metrics by the workload owning the pods, i.e. WorkloadType and WorkloadName",
+                  "type": "boolean"
+                },
+                "api_server": {
+                  "description": "Limits the requests to the kubernetes api server and the objects cached from it, shared by every component watching the cluster",
+                  "type": "object",
+                  "properties": {
+                    "qps": {
+                      "type": "number",
+                      "exclusiveMinimum": true,
+                      "minimum": 0
+                    },
+                    "burst": {
+                      "type": "integer",
+                      "minimum": 1
+                    },
+                    "resync_interval": {
+                      "description": "Interval in seconds the cached objects are resynced, 0 to never resync",
+                      "type": "integer",
+                      "minimum": 0
+                    },
+                    "max_cached_objects": {
+                      "description": "Maximum number of objects of each kind cached, 0 for no limit",
+                      "type": "integer",
+                      "minimum": 0
+                    }
+                  },
+                  "additionalProperties": false
                 }
               },
               "additionalProperties": true
             },
             "prometheus":{
+              "description": "Scrape the Prometheus targets and publish their metrics in the embedded metric format. The counters reset since the previous scrape, i.e. lower than before or whose target reported another process_start_time_seconds, are counted in the cwagent_counter_resets metric of the target",
               "type": "object",
               "properties": {
                 "cluster_name": {
@@ -569,6 +1972,14 @@ var schema = `{
                 "prometheus_config_path": {
                   "type": "string"
                 },
+                "leader_election": {
+                  "description": "Only publish the metrics from the replica of the agent elected as the leader, for the cluster-wide targets",
+                  "type": "boolean"
+                },
+                "exemplars": {
+                  "description": "Scrape the OpenMetrics targets once more each scrape interval for the exemplars of their samples, e.g. their trace ids, and embed them in the documents of their metrics. This doubles the scrapes of the targets, the targets which do not answer in the OpenMetrics format are not scraped again until the config is reloaded",
+                  "type": "boolean"
+                },
                 "emf_processor": {
                   "$ref": "#/definitions/emfProcessorDefinition"
                 },
@@ -577,6 +1988,46 @@ var schema = `{
                 }
               },
               "additionalProperties": false
+            },
+            "emf": {
+              "$ref": "#/definitions/emfListenerDefinition"
+            },
+            "structuredlog": {
+              "$ref": "#/definitions/emfListenerDefinition"
+            },
+            "top_processes": {
+              "description": "Report the processes using the most CPU and memory each interval to CloudWatch Logs in the embedded metric format",
+              "type": "object",
+              "properties": {
+                "metrics_collection_interval": {
+                  "$ref": "#/definitions/timeIntervalDefinition"
+                },
+                "top_n": {
+                  "description": "The number of the processes of each report, default is 5",
+                  "type": "integer",
+                  "minimum": 1,
+                  "maximum": 50
+                },
+                "log_group_name": {
+                  "description": "The log group of the reports, default is /aws/cwagent/top_processes",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 512
+                },
+                "log_stream_name": {
+                  "description": "The log stream of the reports, the log_stream_name of the logs section by default",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 512
+                },
+                "namespace": {
+                  "description": "The namespace of the metrics of the top process by CPU and by memory, default is CWAgent",
+                  "type": "string",
+                  "minLength": 1,
+                  "maxLength": 255
+                }
+              },
+              "additionalProperties": false
             }
           },
           "additionalProperties": true
@@ -595,6 +2046,218 @@ var schema = `{
         "endpoint_override": {
           "description": "The override endpoint to use to access cloudwatch logs",
           "$ref": "#/definitions/endpointOverrideDefinition"
+        },
+        "use_fips_endpoint": {
+          "description": "Send to the FIPS endpoint of the region, resolved from the region unless endpoint_override is set",
+          "type": "boolean"
+        },
+        "signing_algorithm": {
+          "description": "The signature of the requests to cloudwatch logs, sigv4a for the endpoints which require it, e.g. the multi-region ones",
+          "type": "string",
+          "enum": [
+            "sigv4",
+            "sigv4a"
+          ]
+        },
+        "signing_region_set": {
+          "description": "The regions the SigV4A signatures are valid in, all of them when unset",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "minLength": 1
+          },
+          "minItems": 1,
+          "uniqueItems": true
+        },
+        "sdk_version": {
+          "description": "The AWS SDK sending the log events to cloudwatch logs, v2 retries the throttled calls with its adaptive mode",
+          "type": "string",
+          "enum": [
+            "v1",
+            "v2"
+          ]
+        },
+        "invalid_utf8": {
+          "description": "What happens to the log events which are not valid UTF-8, rejected by cloudwatch logs: their invalid bytes are replaced with U+FFFD, they are dropped or their message is sent base64 encoded",
+          "type": "string",
+          "enum": [
+            "replace",
+            "drop",
+            "base64"
+          ]
+        },
+        "memory_budget_mb": {
+          "description": "The MB of log events buffered by cloudwatchlogs before they are admitted by the priority of their sources, unbounded when unset",
+          "type": "integer",
+          "minimum": 1
+        },
+        "max_api_tps": {
+          "description": "The calls per second to CloudWatch Logs of all the log streams, including the retries, to stay below the limits of the account, unlimited when unset",
+          "type": "number",
+          "exclusiveMinimum": true,
+          "minimum": 0
+        },
+        "api_burst": {
+          "description": "The calls to CloudWatch Logs made at once before max_api_tps applies, max_api_tps by default",
+          "type": "integer",
+          "minimum": 1
+        },
+        "reorder_window": {
+          "description": "Seconds the log events of the log streams written by more than one source are held to send them in the order of their timestamps across the sources, not reordered when unset",
+          "type": "integer",
+          "minimum": 1,
+          "maximum": 60
+        },
+        "monotonic_timestamps": {
+          "description": "Keep the timestamps of every log stream increasing, the log events whose timestamp is not after the previous one in the stream are moved 1ms after it",
+          "type": "boolean"
+        },
+        "clock_skew_compensation": {
+          "description": "Move the timestamps of the log events by the skew of the clock of the host once it is persistent, as estimated from the responses of cloudwatch logs",
+          "type": "boolean"
+        },
+        "bulk_under_pressure": {
+          "description": "What to do with the log events of the bulk sources once half of memory_budget_mb is used, defer them until there is room or sample 1 in 10 of them",
+          "type": "string",
+          "enum": [
+            "defer",
+            "sample"
+          ]
+        },
+        "defaults": {
+          "description": "The keys inherited by all the entries of logs_collected.files.collect_list, an entry setting a key overrides its default",
+          "type": "object",
+          "properties": {
+            "log_group_name": {
+              "$ref": "#/definitions/logsDefinition/definitions/logGroupNameDefinition"
+            },
+            "log_stream_name": {
+              "$ref": "#/definitions/logsDefinition/definitions/logStreamNameDefinition"
+            },
+            "multi_line_start_pattern": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "timestamp_format": {
+              "$ref": "#/definitions/logsDefinition/definitions/timestampFormatDefinition"
+            },
+            "timestamp_fallback_to_mtime": {
+              "type": "boolean"
+            },
+            "timezone": {
+              "$ref": "#/definitions/logsDefinition/definitions/timezoneDefinition"
+            },
+            "encoding": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "auto_removal": {
+              "type": "boolean"
+            },
+            "blacklist": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "publish_multi_logs": {
+              "type": "boolean"
+            },
+            "include": {
+              "$ref": "#/definitions/logsDefinition/definitions/fileNamePatternsDefinition"
+            },
+            "exclude": {
+              "$ref": "#/definitions/logsDefinition/definitions/fileNamePatternsDefinition"
+            },
+            "max_age": {
+              "description": "The files matched by file_path which were not modified for longer than this number of seconds are not tailed",
+              "type": "integer",
+              "minimum": 1
+            },
+            "event_format": {
+              "$ref": "#/definitions/logsDefinition/definitions/eventFormatDefinition"
+            },
+            "event_fields": {
+              "$ref": "#/definitions/logsDefinition/definitions/eventFieldsDefinition"
+            },
+            "idle_timeout": {
+              "description": "Publish a marker log event every this number of seconds while the file produces no log entries, so that its silence can be alarmed on",
+              "type": "integer",
+              "minimum": 60
+            },
+            "idle_message": {
+              "description": "The message of the idle marker log events, a message naming the file by default",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "priority": {
+              "$ref": "#/definitions/logsDefinition/definitions/priorityDefinition"
+            }
+          },
+          "additionalProperties": false
+        },
+        "s3_archive": {
+          "description": "Archive the collected logs into gzip or zstd compressed objects in S3",
+          "$ref": "#/definitions/logsDefinition/definitions/s3ArchiveDefinition"
+        },
+        "kinesis": {
+          "description": "Deliver the collected logs to a Kinesis data stream or a Firehose delivery stream",
+          "$ref": "#/definitions/logsDefinition/definitions/kinesisDefinition"
+        },
+        "opensearch": {
+          "description": "Index the collected logs into Amazon OpenSearch Service or OpenSearch Serverless",
+          "$ref": "#/definitions/logsDefinition/definitions/openSearchDefinition"
+        },
+        "file": {
+          "description": "Write the collected logs into a local file, for debugging",
+          "$ref": "#/definitions/fileOutputDefinition"
+        },
+        "agent_events": {
+          "description": "Publish the significant conditions of the agent, e.g. the files which cannot be read, the configs falling back to a default, the credentials which cannot be renewed or the sustained throttling, to a log group as structured events",
+          "type": "object",
+          "properties": {
+            "log_group_name": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 512
+            },
+            "log_stream_name": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 512
+            }
+          },
+          "required": [
+            "log_group_name"
+          ],
+          "additionalProperties": false
+        },
+        "inventory": {
+          "description": "Publish the inventory record of the agent, i.e. its version, OS, config hash and plugins, to a log group to find the hosts running stale versions or divergent configs",
+          "type": "object",
+          "properties": {
+            "log_group_name": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 512
+            },
+            "log_stream_name": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 512
+            },
+            "publish_interval": {
+              "description": "Seconds between the inventory records, 3600 by default",
+              "type": "integer",
+              "minimum": 60
+            }
+          },
+          "required": [
+            "log_group_name"
+          ],
+          "additionalProperties": false
         }
       },
       "additionalProperties": false,
@@ -611,6 +2274,145 @@ var schema = `{
         }
       ],
       "definitions": {
+        "priorityDefinition": {
+          "description": "The priority class of the log events under memory pressure or API rate limiting, critical ones are published first, normal ones wait for room and bulk ones are deferred or sampled",
+          "type": "string",
+          "enum": [
+            "critical",
+            "normal",
+            "bulk"
+          ]
+        },
+        "eventFormatDefinition": {
+          "description": "The format of the log events, text to send the log entries as is or json_envelope to wrap them in JSON objects with the @timestamp, message, host and fields keys",
+          "type": "string",
+          "enum": [
+            "text",
+            "json_envelope"
+          ]
+        },
+        "parserDefinition": {
+          "description": "Publish the delimiter-separated log entries (csv), the W3C extended format of IIS (w3c) or the entries matched by a pattern (regex) as JSON objects of typed fields",
+          "type": "object",
+          "properties": {
+            "type": {
+              "type": "string",
+              "enum": [
+                "csv",
+                "w3c",
+                "regex"
+              ]
+            },
+            "pattern": {
+              "description": "The regex of the regex parser, the fields are its named groups",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "delimiter": {
+              "description": "The delimiter of the fields, a comma for csv and a space for w3c by default",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 1
+            },
+            "columns": {
+              "description": "The names of the fields, the first line of the csv files and the #Fields directives of the w3c files by default",
+              "type": "array",
+              "items": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 255
+              },
+              "minItems": 1
+            },
+            "column_types": {
+              "description": "The types of the fields, strings by default",
+              "type": "object",
+              "additionalProperties": {
+                "type": "string",
+                "enum": [
+                  "string",
+                  "integer",
+                  "float",
+                  "boolean"
+                ]
+              }
+            },
+            "timestamp_columns": {
+              "description": "The fields holding the timestamp of the entries, joined by a space, date and time for w3c by default",
+              "type": "array",
+              "items": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 255
+              },
+              "minItems": 1
+            },
+            "timestamp_format": {
+              "description": "The format of the timestamp of the timestamp_columns, %Y-%m-%d %H:%M:%S for w3c and RFC 3339 for csv and regex by default",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "null_value": {
+              "description": "The value of the fields which have no value, they are left out, - for w3c by default",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            }
+          },
+          "required": [
+            "type"
+          ],
+          "additionalProperties": false
+        },
+        "eventFieldsDefinition": {
+          "description": "The static fields added to the fields of the JSON envelopes, along with the file_path of the log file",
+          "type": "object",
+          "additionalProperties": {
+            "type": "string",
+            "maxLength": 1024
+          },
+          "maxProperties": 30
+        },
+        "fileNamePatternsDefinition": {
+          "description": "Glob patterns of the files matched by file_path, matched against the file name or, when they have a path separator, the whole path",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "minLength": 1,
+            "maxLength": 4096
+          },
+          "minItems": 1,
+          "uniqueItems": true
+        },
+        "timezoneDefinition": {
+          "description": "The time zone of the timestamps of the log entries, Local, UTC or an IANA time zone name, e.g. America/New_York",
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 255
+        },
+        "timestampFormatDefinition": {
+          "description": "The format of the timestamps of the log entries, or a list of formats tried in order",
+          "oneOf": [
+            {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            {
+              "type": "array",
+              "items": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 4096
+              },
+              "minItems": 1,
+              "maxItems": 16,
+              "uniqueItems": true
+            }
+          ]
+        },
         "logsFilesDefinition": {
           "type": "object",
           "descriptions": "Specifies the log files to be collected",
@@ -631,22 +2433,20 @@ var schema = `{
                     "$ref": "#/definitions/logsDefinition/definitions/logStreamNameDefinition"
                   },
                   "multi_line_start_pattern": {
+                    "description": "The regex of the first lines of the log entries, {timestamp_format} or a named pattern: {java}, {python}, {go_panic} or {nginx_error}",
                     "type": "string",
                     "minLength": 1,
                     "maxLength": 4096
                   },
                   "timestamp_format": {
-                    "type": "string",
-                    "minLength": 1,
-                    "maxLength": 4096
+                    "$ref": "#/definitions/logsDefinition/definitions/timestampFormatDefinition"
+                  },
+                  "timestamp_fallback_to_mtime": {
+                    "description": "Use the modification time of the file for the log entries none of the timestamp formats matches, instead of the ingestion time",
+                    "type": "boolean"
                   },
                   "timezone": {
-                    "type": "string",
-                    "enum": [
-                      "Local",
-                      "LOCAL",
-                      "UTC"
-                    ]
+                    "$ref": "#/definitions/logsDefinition/definitions/timezoneDefinition"
                   },
                   "encoding": {
                     "type": "string",
@@ -663,10 +2463,215 @@ var schema = `{
                   },
                   "publish_multi_logs": {
                     "type": "boolean"
+                  },
+                  "include": {
+                    "$ref": "#/definitions/logsDefinition/definitions/fileNamePatternsDefinition"
+                  },
+                  "exclude": {
+                    "$ref": "#/definitions/logsDefinition/definitions/fileNamePatternsDefinition"
+                  },
+                  "max_age": {
+                    "description": "The files matched by file_path which were not modified for longer than this number of seconds are not tailed",
+                    "type": "integer",
+                    "minimum": 1
+                  },
+                  "event_format": {
+                    "$ref": "#/definitions/logsDefinition/definitions/eventFormatDefinition"
+                  },
+                  "event_fields": {
+                    "$ref": "#/definitions/logsDefinition/definitions/eventFieldsDefinition"
+                  },
+                  "idle_timeout": {
+                    "description": "Publish a marker log event every this number of seconds while the file produces no log entries, so that its silence can be alarmed on",
+                    "type": "integer",
+                    "minimum": 60
+                  },
+                  "idle_message": {
+                    "description": "The message of the idle marker log events, a message naming the file by default",
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 4096
+                  },
+                  "priority": {
+                    "$ref": "#/definitions/logsDefinition/definitions/priorityDefinition"
+                  },
+                  "sequence_field": {
+                    "description": "Add the sequence number of the log events in their log stream to the ones which are JSON objects, e.g. with the json_envelope event_format, it is kept across the restarts of the agent",
+                    "type": "string",
+                    "minLength": 1,
+                    "maxLength": 255
+                  },
+                  "systemd_units": {
+                    "description": "Glob patterns of the systemd units whose logs are collected, e.g. nginx*, from the files they write their standard output and error to and from their journal streams. The logs of a unit go to the log group named after the unit when log_group_name and file_path are not set",
+                    "type": "array",
+                    "items": {
+                      "type": "string",
+                      "minLength": 1,
+                      "maxLength": 255
+                    },
+                    "minItems": 1,
+                    "maxItems": 100,
+                    "uniqueItems": true
+                  },
+                  "parser": {
+                    "$ref": "#/definitions/logsDefinition/definitions/parserDefinition"
+                  },
+                  "access_log": {
+                    "description": "Parse the common or combined log format of Apache and Nginx and emit the request count, the status classes counts and the latency as metrics, it cannot be set with a parser",
+                    "type": "object",
+                    "properties": {
+                      "format": {
+                        "type": "string",
+                        "enum": [
+                          "common",
+                          "combined"
+                        ]
+                      },
+                      "latency_unit": {
+                        "description": "The unit of the request time appended to the format, e.g. seconds for the $request_time of Nginx or microseconds for the %D of Apache",
+                        "type": "string",
+                        "enum": [
+                          "seconds",
+                          "milliseconds",
+                          "microseconds"
+                        ]
+                      },
+                      "metric_name_prefix": {
+                        "type": "string",
+                        "minLength": 1,
+                        "maxLength": 128
+                      }
+                    },
+                    "additionalProperties": false
+                  },
+                  "metric_rules": {
+                    "description": "Emit metrics from the log entries matching the patterns, published with the metrics of the metrics section every collection interval",
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "properties": {
+                        "metric_name": {
+                          "type": "string",
+                          "minLength": 1,
+                          "maxLength": 255
+                        },
+                        "pattern": {
+                          "description": "The regex matching the log entries, with named groups, e.g. (?P<status>\\d{3})",
+                          "type": "string",
+                          "minLength": 1,
+                          "maxLength": 4096
+                        },
+                        "value_group": {
+                          "description": "The named group the value of the metric is parsed from, the metric counts the matching entries without it",
+                          "type": "string",
+                          "minLength": 1
+                        },
+                        "dimensions": {
+                          "description": "The named groups whose values are the dimensions of the metric",
+                          "type": "array",
+                          "items": {
+                            "type": "string",
+                            "minLength": 1
+                          },
+                          "maxItems": 10,
+                          "uniqueItems": true
+                        },
+                        "unit": {
+                          "type": "string",
+                          "minLength": 1,
+                          "maxLength": 256
+                        }
+                      },
+                      "required": [
+                        "metric_name",
+                        "pattern"
+                      ],
+                      "additionalProperties": false
+                    },
+                    "minItems": 1,
+                    "maxItems": 100
+                  },
+                  "replicas": {
+                    "description": "Send copies of the log events to other log groups, each through its own filters applied in order",
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "properties": {
+                        "log_group_name": {
+                          "type": "string",
+                          "minLength": 1,
+                          "maxLength": 512
+                        },
+                        "log_stream_name": {
+                          "description": "The log stream of the replica, the log stream of the file by default",
+                          "type": "string",
+                          "minLength": 1,
+                          "maxLength": 512
+                        },
+                        "destination": {
+                          "description": "The output of the logs section the replica is sent to, the outputs of the file by default",
+                          "type": "string",
+                          "enum": [
+                            "cloudwatchlogs",
+                            "kinesislogs",
+                            "s3logs",
+                            "opensearch"
+                          ]
+                        },
+                        "filters": {
+                          "type": "array",
+                          "items": {
+                            "type": "object",
+                            "properties": {
+                              "type": {
+                                "description": "include keeps the log events matching the expression, exclude drops them and replace replaces the matches with the replacement",
+                                "type": "string",
+                                "enum": [
+                                  "include",
+                                  "exclude",
+                                  "replace"
+                                ]
+                              },
+                              "expression": {
+                                "type": "string",
+                                "minLength": 1,
+                                "maxLength": 4096
+                              },
+                              "replacement": {
+                                "type": "string",
+                                "maxLength": 4096
+                              }
+                            },
+                            "required": [
+                              "type",
+                              "expression"
+                            ],
+                            "additionalProperties": false
+                          },
+                          "minItems": 1,
+                          "maxItems": 20
+                        }
+                      },
+                      "required": [
+                        "log_group_name"
+                      ],
+                      "additionalProperties": false
+                    },
+                    "minItems": 1,
+                    "maxItems": 10
                   }
                 },
-                "required": [
-                  "file_path"
+                "anyOf": [
+                  {
+                    "required": [
+                      "file_path"
+                    ]
+                  },
+                  {
+                    "required": [
+                      "systemd_units"
+                    ]
+                  }
                 ],
                 "additionalProperties": false
               },
@@ -725,8 +2730,19 @@ var schema = `{
                     "type": "string",
                     "enum": [
                       "text",
-                      "xml"
+                      "xml",
+                      "json"
                     ]
+                  },
+                  "event_field_mapping": {
+                    "description": "Renames the fields of the events in the json format, the fields of EventData included, a field renamed to an empty string is removed",
+                    "type": "object",
+                    "additionalProperties": {
+                      "type": "string"
+                    }
+                  },
+                  "priority": {
+                    "$ref": "#/definitions/logsDefinition/definitions/priorityDefinition"
                   }
                 },
                 "required": [
@@ -745,6 +2761,62 @@ var schema = `{
             "collect_list"
           ]
         },
+        "logsAuditdDefinition": {
+          "description": "Publish the events of the Linux audit log merged by serial number, routed to the log groups by the keys of the audit rules",
+          "type": "object",
+          "properties": {
+            "file_path": {
+              "description": "The audit log, /var/log/audit/audit.log by default",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 4096
+            },
+            "log_group_name": {
+              "description": "The log group of the events matching no key route, they are dropped when it is not set",
+              "$ref": "#/definitions/logsDefinition/definitions/logGroupNameDefinition"
+            },
+            "log_stream_name": {
+              "$ref": "#/definitions/logsDefinition/definitions/logStreamNameDefinition"
+            },
+            "merge_timeout": {
+              "description": "Seconds the records of an event are waited for when it has no EOE record, 2 by default",
+              "type": "integer",
+              "minimum": 1,
+              "maximum": 60
+            },
+            "key_routes": {
+              "type": "array",
+              "items": {
+                "type": "object",
+                "properties": {
+                  "keys": {
+                    "type": "array",
+                    "items": {
+                      "type": "string",
+                      "minLength": 1,
+                      "maxLength": 31
+                    },
+                    "minItems": 1,
+                    "uniqueItems": true
+                  },
+                  "log_group_name": {
+                    "$ref": "#/definitions/logsDefinition/definitions/logGroupNameDefinition"
+                  },
+                  "log_stream_name": {
+                    "$ref": "#/definitions/logsDefinition/definitions/logStreamNameDefinition"
+                  }
+                },
+                "required": [
+                  "keys",
+                  "log_group_name"
+                ],
+                "additionalProperties": false
+              },
+              "minItems": 1
+            }
+          },
+          "additionalProperties": false
+        },
         "logGroupNameDefinition": {
           "type": "string",
           "minLength": 1,
@@ -754,9 +2826,209 @@ var schema = `{
           "type": "string",
           "minLength": 1,
           "maxLength": 512
+        },
+        "s3ArchiveDefinition": {
+          "type": "object",
+          "properties": {
+            "bucket": {
+              "type": "string",
+              "minLength": 3,
+              "maxLength": 63
+            },
+            "key_prefix": {
+              "type": "string",
+              "maxLength": 512
+            },
+            "region": {
+              "type": "string",
+              "minLength": 1
+            },
+            "role_arn": {
+              "type": "string",
+              "minLength": 1
+            },
+            "endpoint_override": {
+              "description": "The override endpoint to use to access S3",
+              "$ref": "#/definitions/endpointOverrideDefinition"
+            },
+            "force_flush_interval": {
+              "description": "Max time to wait before uploading an archive object, unit is second.",
+              "$ref": "#/definitions/timeIntervalDefinition"
+            },
+            "max_object_size": {
+              "description": "Upload the archive object earlier once this many uncompressed bytes are buffered.",
+              "type": "integer",
+              "minimum": 1024
+            },
+            "archive_only": {
+              "description": "Only archive the collected logs into S3 without sending them to CloudWatch Logs",
+              "type": "boolean"
+            },
+            "compression": {
+              "description": "The compression of the archive objects. Default is gzip",
+              "type": "string",
+              "enum": [
+                "gzip",
+                "zstd"
+              ]
+            },
+            "compression_level": {
+              "description": "The level of the compression, from 1 to 9 for gzip and from 1 (fastest) to 22 for zstd",
+              "type": "integer",
+              "minimum": 1,
+              "maximum": 22
+            }
+          },
+          "required": [
+            "bucket"
+          ],
+          "additionalProperties": false
+        },
+        "kinesisDefinition": {
+          "type": "object",
+          "properties": {
+            "stream_name": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 128
+            },
+            "delivery_stream_name": {
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 64
+            },
+            "partition_key": {
+              "description": "Partition key of the Kinesis records, supports the {log_group_name}, {log_stream_name}, {hostname} and {random} placeholders",
+              "type": "string",
+              "minLength": 1
+            },
+            "join_records": {
+              "description": "Join consecutive log events into newline delimited records, which are not KPL aggregated records",
+              "type": "boolean"
+            },
+            "kpl_aggregation": {
+              "description": "Put the log events in KPL aggregated records, which the KCL and the KPL deaggregation libraries split back into one record per log event. Only with stream_name and without join_records",
+              "type": "boolean"
+            },
+            "region": {
+              "type": "string",
+              "minLength": 1
+            },
+            "role_arn": {
+              "type": "string",
+              "minLength": 1
+            },
+            "endpoint_override": {
+              "description": "The override endpoint to use to access Kinesis or Firehose",
+              "$ref": "#/definitions/endpointOverrideDefinition"
+            },
+            "force_flush_interval": {
+              "description": "Max time to wait before sending a batch of records, unit is second.",
+              "$ref": "#/definitions/timeIntervalDefinition"
+            },
+            "kinesis_only": {
+              "description": "Only deliver the collected logs to Kinesis without sending them to CloudWatch Logs",
+              "type": "boolean"
+            }
+          },
+          "oneOf": [
+            {
+              "required": [
+                "stream_name"
+              ]
+            },
+            {
+              "required": [
+                "delivery_stream_name"
+              ]
+            }
+          ],
+          "additionalProperties": false
+        },
+        "openSearchDefinition": {
+          "type": "object",
+          "properties": {
+            "endpoint": {
+              "description": "The endpoint of the OpenSearch domain or the OpenSearch Serverless collection",
+              "type": "string",
+              "format": "uri"
+            },
+            "index": {
+              "description": "Index name template, supports the {log_group_name}, {log_stream_name} and {date} placeholders",
+              "type": "string",
+              "minLength": 1,
+              "maxLength": 255
+            },
+            "serverless": {
+              "description": "Sign the requests for OpenSearch Serverless instead of OpenSearch Service",
+              "type": "boolean"
+            },
+            "disable_sigv4": {
+              "description": "Send the requests unsigned, for domains using fine grained access control only",
+              "type": "boolean"
+            },
+            "region": {
+              "type": "string",
+              "minLength": 1
+            },
+            "role_arn": {
+              "type": "string",
+              "minLength": 1
+            },
+            "force_flush_interval": {
+              "description": "Max time to wait before sending a bulk request, unit is second.",
+              "$ref": "#/definitions/timeIntervalDefinition"
+            },
+            "opensearch_only": {
+              "description": "Only index the collected logs into OpenSearch without sending them to CloudWatch Logs",
+              "type": "boolean"
+            }
+          },
+          "required": [
+            "endpoint"
+          ],
+          "additionalProperties": false
         }
       }
     },
+    "fileOutputDefinition": {
+      "type": "object",
+      "properties": {
+        "path": {
+          "type": "string",
+          "minLength": 1
+        },
+        "metric_format": {
+          "type": "string",
+          "enum": [
+            "influx",
+            "json"
+          ]
+        },
+        "rotation_interval": {
+          "description": "Archive the file once it is older than this, unit is second.",
+          "$ref": "#/definitions/timeIntervalDefinition"
+        },
+        "rotation_max_size": {
+          "description": "Archive the file once it is larger than this many bytes.",
+          "type": "integer",
+          "minimum": 1
+        },
+        "rotation_max_archives": {
+          "description": "Number of archives to keep, -1 keeps all of them.",
+          "type": "integer",
+          "minimum": -1
+        },
+        "file_only": {
+          "description": "Only write into the file without sending anything to CloudWatch",
+          "type": "boolean"
+        }
+      },
+      "required": [
+        "path"
+      ],
+      "additionalProperties": false
+    },
     "timeIntervalDefinition": {
       "type": "integer",
       "minimum": 1,
@@ -772,6 +3044,55 @@ var schema = `{
       "minimum": 1024,
       "maximum": 65535
     },
+    "listenerAuthDefinition": {
+      "description": "Authenticate the clients of a listener by their address, a shared token and, over tcp, their certificate",
+      "type": "object",
+      "properties": {
+        "allowed_cidrs": {
+          "description": "The addresses and CIDR blocks of the clients, the others are dropped",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "minLength": 1,
+            "maxLength": 64
+          },
+          "minItems": 1,
+          "uniqueItems": true
+        },
+        "token_file": {
+          "description": "The file holding the token the clients send as the first line auth:<token> of each datagram or connection",
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 4096
+        },
+        "tls_cert": {
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 4096
+        },
+        "tls_key": {
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 4096
+        },
+        "tls_allowed_cacerts": {
+          "description": "The CAs the certificates of the clients must be signed by",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "minLength": 1,
+            "maxLength": 4096
+          },
+          "minItems": 1
+        }
+      },
+      "dependencies": {
+        "tls_cert": ["tls_key"],
+        "tls_key": ["tls_cert"],
+        "tls_allowed_cacerts": ["tls_cert"]
+      },
+      "additionalProperties": false
+    },
     "generalAppendDimensionsDefinition": {
       "descriptions": "Additional customized dimensions to use",
       "type": "object",
@@ -909,6 +3230,92 @@ var schema = `{
         }
       }
     },
+    "emfListenerDefinition": {
+      "type": "object",
+      "description": "Receive the documents of the applications in the embedded metric format over udp and tcp",
+      "properties": {
+        "service_address": {
+          "description": "The udp:// or tcp:// address the documents are received on, both udp and tcp on 127.0.0.1:25888 by default",
+          "type": "string",
+          "pattern": "^(udp|tcp)://.+"
+        },
+        "max_payload_size": {
+          "description": "The max size in bytes of a document, the larger ones are dropped, default is 262144",
+          "type": "integer",
+          "minimum": 1,
+          "maximum": 262144
+        },
+        "token_key": {
+          "description": "The top level key of the documents holding the token of their tenant, removed before sending, default is _tenant_token",
+          "type": "string",
+          "minLength": 1
+        },
+        "tenants": {
+          "description": "The applications sharing the listener, the documents received on the address of a tenant or carrying its token are sent to its log group and stream",
+          "type": "array",
+          "minItems": 1,
+          "items": {
+            "type": "object",
+            "properties": {
+              "name": {
+                "description": "The name of the tenant, the dimension of its counters",
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 255,
+                "not": {
+                  "enum": ["default"]
+                }
+              },
+              "service_address": {
+                "description": "The udp:// or tcp:// address the documents of the tenant are received on",
+                "type": "string",
+                "pattern": "^(udp|tcp)://.+"
+              },
+              "token": {
+                "description": "The token the documents of the tenant carry in their token_key",
+                "type": "string",
+                "minLength": 1
+              },
+              "log_group_name": {
+                "description": "The log group of the documents of the tenant, overriding the one they name",
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 512
+              },
+              "log_stream_name": {
+                "description": "The log stream of the documents of the tenant, overriding the one they name",
+                "$ref": "#/definitions/logsDefinition/definitions/logStreamNameDefinition"
+              }
+            },
+            "required": ["name"],
+            "anyOf": [
+              {"required": ["service_address"]},
+              {"required": ["token"]}
+            ],
+            "additionalProperties": false
+          }
+        },
+        "stats_log_group_name": {
+          "description": "The log group the counters of the accepted, malformed and oversized documents of every tenant are reported to in the embedded metric format, they are only logged by default",
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 512
+        },
+        "stats_log_stream_name": {
+          "$ref": "#/definitions/logsDefinition/definitions/logStreamNameDefinition"
+        },
+        "stats_namespace": {
+          "description": "The namespace of the counters, default is CWAgent",
+          "type": "string",
+          "minLength": 1,
+          "maxLength": 255
+        },
+        "auth": {
+          "$ref": "#/definitions/listenerAuthDefinition"
+        }
+      },
+      "additionalProperties": true
+    },
     "emfProcessorDefinition": {
       "type": "object",
       "descriptions": "Define EMF Processor to set metric filter",
@@ -980,7 +3387,7 @@ var schema = `{
 `
 
 func GetJsonSchema() string {
-	return schema
+	return withSectionSchemas(schema)
 }
 
 // Translate Sample:
@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	sectionSchemasMu sync.Mutex
+	// sectionSchemas are the schemas of the metrics_collected sections registered outside of the translator, by key
+	sectionSchemas = map[string]interface{}{}
+)
+
+// RegisterMetricsCollectedSchema adds the JSON schema of a metrics_collected section to the schema of the config,
+// the key must not be one of the sections built in the schema
+func RegisterMetricsCollectedSchema(key string, schema string) error {
+	var s interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return fmt.Errorf("invalid schema of the metrics_collected section %v: %v", key, err)
+	}
+	if _, ok := s.(map[string]interface{}); !ok {
+		return fmt.Errorf("invalid schema of the metrics_collected section %v: expecting an object", key)
+	}
+	builtIn, err := metricsCollectedProperties(parseSchema())
+	if err != nil {
+		return err
+	}
+	if _, ok := builtIn[key]; ok {
+		return fmt.Errorf("the metrics_collected section %v is built in the schema", key)
+	}
+
+	sectionSchemasMu.Lock()
+	defer sectionSchemasMu.Unlock()
+	if _, ok := sectionSchemas[key]; ok {
+		return fmt.Errorf("the schema of the metrics_collected section %v is already registered", key)
+	}
+	sectionSchemas[key] = s
+	return nil
+}
+
+// withSectionSchemas returns the schema with the registered sections, it is unchanged when none is registered
+func withSectionSchemas(schema string) string {
+	sectionSchemasMu.Lock()
+	defer sectionSchemasMu.Unlock()
+	if len(sectionSchemas) == 0 {
+		return schema
+	}
+	s := parseSchema()
+	properties, err := metricsCollectedProperties(s)
+	if err != nil {
+		panic(err)
+	}
+	keys := make([]string, 0, len(sectionSchemas))
+	for key := range sectionSchemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		properties[key] = sectionSchemas[key]
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func parseSchema() map[string]interface{} {
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func metricsCollectedProperties(s map[string]interface{}) (map[string]interface{}, error) {
+	var node interface{} = s
+	for _, key := range []string{"definitions", "metricsDefinition", "properties", "metrics_collected", "properties"} {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the schema has no %v for the metrics_collected sections", key)
+		}
+		node = m[key]
+	}
+	properties, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the schema has no properties for the metrics_collected sections")
+	}
+	return properties, nil
+}
@@ -39,12 +39,14 @@ type Context struct {
 	inputJsonDirPath    string
 	multiConfig         string
 	outputTomlFilePath  string
+	outputOtelFilePath  string
 	mode                string
 	credentials         map[string]string
 	proxy               map[string]string
 	ssl                 map[string]string
 	cloudWatchLogConfig map[string]interface{}
 	runInContainer      bool
+	collectionJitters   map[string][]string
 }
 
 func (ctx *Context) Os() string {
@@ -87,6 +89,14 @@ func (ctx *Context) SetOutputTomlFilePath(outputTomlFilePath string) {
 	ctx.outputTomlFilePath = outputTomlFilePath
 }
 
+func (ctx *Context) OutputOtelFilePath() string {
+	return ctx.outputOtelFilePath
+}
+
+func (ctx *Context) SetOutputOtelFilePath(outputOtelFilePath string) {
+	ctx.outputOtelFilePath = outputOtelFilePath
+}
+
 func (ctx *Context) Mode() string {
 	if ctx.mode == "" {
 		ctx.mode = config.ModeEC2
@@ -144,3 +154,12 @@ func (ctx *Context) RunInContainer() bool {
 func (ctx *Context) SetRunInContainer(runInContainer bool) {
 	ctx.runInContainer = runInContainer
 }
+
+// CollectionJitters are the collection jitters of the translated inputs by input name, one per instance
+func (ctx *Context) CollectionJitters() map[string][]string {
+	return ctx.collectionJitters
+}
+
+func (ctx *Context) SetCollectionJitters(jitters map[string][]string) {
+	ctx.collectionJitters = jitters
+}
@@ -0,0 +1,124 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package jsonconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/config"
+)
+
+// OsConditionKey makes the object it is in, e.g. a section or an entry of a collect_list, apply only to the listed
+// platforms, e.g. "os": ["linux", "darwin"]. The same config can then be used by the agents of all the platforms.
+// The condition is a list so that the maps of free form strings, e.g. the dimensions, can still have an os key.
+const OsConditionKey = "os"
+
+// ApplyOsConditions removes the objects of the config whose os condition does not include the platform, and the
+// conditions from the others, before the config is validated against the schema
+func ApplyOsConditions(jsonConfigMap map[string]interface{}, osType string) error {
+	keep, err := matchOs(jsonConfigMap, osType, "/")
+	if err != nil {
+		return err
+	}
+	if !keep {
+		// a config file only for the other platforms
+		for key := range jsonConfigMap {
+			delete(jsonConfigMap, key)
+		}
+		return nil
+	}
+	_, err = applyOsConditions(jsonConfigMap, osType, "/")
+	return err
+}
+
+// applyOsConditions returns the value without the objects for the other platforms
+func applyOsConditions(value interface{}, osType string, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := path + key + "/"
+			keep, err := matchOs(child, osType, childPath)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				delete(v, key)
+				continue
+			}
+			before := size(child)
+			if child, err = applyOsConditions(child, osType, childPath); err != nil {
+				return nil, err
+			}
+			if before > 0 && size(child) == 0 {
+				// e.g. a collect_list whose entries are all for the other platforms
+				delete(v, key)
+				continue
+			}
+			v[key] = child
+		}
+	case []interface{}:
+		kept := make([]interface{}, 0, len(v))
+		for i, child := range v {
+			childPath := fmt.Sprintf("%v%d/", path, i)
+			keep, err := matchOs(child, osType, childPath)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				continue
+			}
+			if child, err = applyOsConditions(child, osType, childPath); err != nil {
+				return nil, err
+			}
+			kept = append(kept, child)
+		}
+		return kept, nil
+	}
+	return value, nil
+}
+
+// size returns the number of the entries of the objects and the lists, -1 for the other values
+func size(value interface{}) int {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	}
+	return -1
+}
+
+// matchOs returns whether the value applies to the platform, and removes its os condition
+func matchOs(value interface{}, osType string, path string) (bool, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return true, nil
+	}
+	condition, ok := m[OsConditionKey].([]interface{})
+	if !ok {
+		return true, nil
+	}
+	match := false
+	for _, entry := range condition {
+		name, ok := entry.(string)
+		if !ok || !isSupportedOs(name) {
+			return false, fmt.Errorf("invalid os condition %v in %v, expecting a list of %v, %v or %v",
+				entry, path, config.OS_TYPE_LINUX, config.OS_TYPE_WINDOWS, config.OS_TYPE_DARWIN)
+		}
+		if strings.EqualFold(name, osType) {
+			match = true
+		}
+	}
+	delete(m, OsConditionKey)
+	return match, nil
+}
+
+func isSupportedOs(name string) bool {
+	switch strings.ToLower(name) {
+	case config.OS_TYPE_LINUX, config.OS_TYPE_WINDOWS, config.OS_TYPE_DARWIN:
+		return true
+	}
+	return false
+}
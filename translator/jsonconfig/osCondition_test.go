@@ -0,0 +1,93 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package jsonconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const osConditionsConfig = `{
+  "metrics": {
+    "append_dimensions": {"os": "shared"},
+    "metrics_collected": {
+      "mem": {"os": ["linux", "darwin"], "measurement": ["mem_used_percent"]},
+      "Memory": {"os": ["windows"], "measurement": ["% Committed Bytes In Use"]}
+    }
+  },
+  "logs": {
+    "logs_collected": {
+      "files": {
+        "collect_list": [
+          {"os": ["linux"], "file_path": "/var/log/messages", "log_group_name": "system"},
+          {"os": ["Windows"], "file_path": "C:\\app.log", "log_group_name": "app"},
+          {"file_path": "/opt/app.log", "log_group_name": "app"}
+        ]
+      },
+      "windows_events": {
+        "collect_list": [
+          {"os": ["windows"], "event_name": "System", "event_levels": ["ERROR"], "log_group_name": "events"}
+        ]
+      }
+    }
+  }
+}`
+
+func applyOsConditionsTo(t *testing.T, content string, osType string) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(content), &m))
+	err := ApplyOsConditions(m, osType)
+	return m, err
+}
+
+func TestApplyOsConditions(t *testing.T) {
+	linux, err := applyOsConditionsTo(t, osConditionsConfig, "linux")
+	require.NoError(t, err)
+	expected := `{
+	  "metrics": {
+	    "append_dimensions": {"os": "shared"},
+	    "metrics_collected": {"mem": {"measurement": ["mem_used_percent"]}}
+	  },
+	  "logs": {
+	    "logs_collected": {
+	      "files": {
+	        "collect_list": [
+	          {"file_path": "/var/log/messages", "log_group_name": "system"},
+	          {"file_path": "/opt/app.log", "log_group_name": "app"}
+	        ]
+	      }
+	    }
+	  }
+	}`
+	actual, err := json.Marshal(linux)
+	require.NoError(t, err)
+	assert.JSONEq(t, expected, string(actual), "the windows_events only for windows are removed")
+
+	windows, err := applyOsConditionsTo(t, osConditionsConfig, "windows")
+	require.NoError(t, err)
+	metrics := windows["metrics"].(map[string]interface{})["metrics_collected"].(map[string]interface{})
+	assert.Equal(t, []string{"Memory"}, keys(metrics))
+	files := windows["logs"].(map[string]interface{})["logs_collected"].(map[string]interface{})["files"].(map[string]interface{})
+	assert.Len(t, files["collect_list"], 2)
+
+	darwin, err := applyOsConditionsTo(t, `{"os": ["windows"], "metrics": {}}`, "darwin")
+	require.NoError(t, err)
+	assert.Empty(t, darwin, "the whole file is for the other platforms")
+}
+
+func TestApplyOsConditionsInvalid(t *testing.T) {
+	_, err := applyOsConditionsTo(t, `{"logs": {"logs_collected": {"files": {"collect_list": [{"os": ["solaris"]}]}}}}`, "linux")
+	assert.EqualError(t, err, "invalid os condition solaris in /logs/logs_collected/files/collect_list/0/, expecting a list of linux, windows or darwin")
+}
+
+func keys(m map[string]interface{}) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
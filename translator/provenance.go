@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package translator
+
+import (
+	"strings"
+)
+
+// Provenance maps the plugins of the generated TOML, e.g. "inputs.cpu", to the paths of the JSON config they are
+// translated from, e.g. "metrics.metrics_collected.cpu"
+var Provenance = map[string][]string{}
+
+// JsonPath returns the dotted path of the JSON config of the path of a rule, e.g. "/metrics/metrics_collected/cpu/"
+// is "metrics.metrics_collected.cpu"
+func JsonPath(curPath string) string {
+	return strings.Replace(strings.Trim(curPath, "/"), "/", ".", -1)
+}
+
+// AddProvenance records that the plugin of the TOML is translated from the path of the JSON config. The path is not
+// recorded when a path under it is already, the sections record the plugins after their children do.
+func AddProvenance(tomlPath, jsonPath string) {
+	for _, p := range Provenance[tomlPath] {
+		if p == jsonPath || strings.HasPrefix(p, jsonPath+".") {
+			return
+		}
+	}
+	Provenance[tomlPath] = append(Provenance[tomlPath], jsonPath)
+}
+
+// IsProvenanceOf returns whether the plugin of the TOML is translated from the path of the JSON config, from a path
+// under it or from one of its parents
+func IsProvenanceOf(tomlPath, jsonPath string) bool {
+	for _, p := range Provenance[tomlPath] {
+		if p == jsonPath || strings.HasPrefix(p, jsonPath+".") || strings.HasPrefix(jsonPath, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetProvenance is called before a translation
+func ResetProvenance() {
+	Provenance = map[string][]string{}
+}
@@ -0,0 +1,32 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddProvenance(t *testing.T) {
+	ResetProvenance()
+	defer ResetProvenance()
+	assert.Equal(t, "metrics.metrics_collected.cpu", JsonPath("/metrics/metrics_collected/cpu/"))
+
+	AddProvenance("inputs.socket_listener", "metrics.metrics_collected.collectd")
+	AddProvenance("inputs.socket_listener", "logs.metrics_collected.emf")
+	// the sections do not override the more specific paths of their children
+	AddProvenance("inputs.socket_listener", "metrics")
+	AddProvenance("inputs.socket_listener", "logs")
+	AddProvenance("outputs.cloudwatch", "metrics")
+	assert.Equal(t, []string{"metrics.metrics_collected.collectd", "logs.metrics_collected.emf"}, Provenance["inputs.socket_listener"])
+	assert.Equal(t, []string{"metrics"}, Provenance["outputs.cloudwatch"])
+
+	assert.True(t, IsProvenanceOf("inputs.socket_listener", "logs.metrics_collected.emf"))
+	assert.True(t, IsProvenanceOf("inputs.socket_listener", "logs"))
+	assert.True(t, IsProvenanceOf("inputs.socket_listener", "metrics.metrics_collected.collectd.service_address"))
+	assert.False(t, IsProvenanceOf("inputs.socket_listener", "metrics.metrics_collected.cpu"))
+	assert.True(t, IsProvenanceOf("outputs.cloudwatch", "metrics.metrics_collected.cpu"))
+	assert.False(t, IsProvenanceOf("outputs.cloudwatch", "metrics_other"))
+}
@@ -4,8 +4,13 @@
 package toenvconfig
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/aws/amazon-cloudwatch-agent/cfg/commonconfig"
 	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
@@ -15,10 +20,20 @@ import (
 	"github.com/aws/amazon-cloudwatch-agent/translator/util"
 )
 
-const userAgentKey = "user_agent"
+const (
+	userAgentKey  = "user_agent"
+	selfUpdateKey = "self_update"
+	samplingKey   = "debug_payload_sampling"
+	httpClientKey = "http_client"
+)
 
 func ToEnvConfig(jsonConfigValue map[string]interface{}) []byte {
 	envVars := make(map[string]string)
+	// The hosts running the same JSON config report the same hash in their inventory record
+	if hash, err := configHash(jsonConfigValue); err == nil {
+		envVars[envconfig.CWAGENT_CONFIG_HASH] = hash
+	}
+
 	// If csm has a configuration section, then also turn on csm for the agent itself
 	if _, ok := jsonConfigValue[csm.JSONSectionKey]; ok {
 		envVars[envconfig.AWS_CSM_ENABLED] = "TRUE"
@@ -29,6 +44,40 @@ func ToEnvConfig(jsonConfigValue map[string]interface{}) []byte {
 		if userAgent, ok := agentMap[userAgentKey].(string); ok {
 			envVars[envconfig.CWAGENT_USER_AGENT] = userAgent
 		}
+		// The images are upgraded instead of the agent when running in a container
+		if selfUpdate, ok := agentMap[selfUpdateKey].(map[string]interface{}); ok && !context.CurrentContext().RunInContainer() {
+			for key, value := range selfUpdateOptions(selfUpdate) {
+				envVars[key] = value
+			}
+		}
+		if sampling, ok := agentMap[samplingKey].(map[string]interface{}); ok {
+			for key, value := range payloadSamplingOptions(sampling) {
+				envVars[key] = value
+			}
+		}
+		// The transport is shared by the AWS service clients of the outputs
+		if httpClient, ok := agentMap[httpClientKey].(map[string]interface{}); ok {
+			for key, value := range httpClientOptions(httpClient) {
+				envVars[key] = value
+			}
+		}
+	}
+
+	// The agent reloads the configured collectd typesdb files and directories when they change
+	if typesDB := collectdTypesDB(jsonConfigValue); len(typesDB) > 0 {
+		envVars[envconfig.CWAGENT_COLLECTD_TYPESDB] = strings.Join(typesDB, string(os.PathListSeparator))
+	}
+
+	// The collection jitters of the inputs are set by the translation of the TOML config, which runs first
+	if jitters := context.CurrentContext().CollectionJitters(); len(jitters) > 0 {
+		if data, err := json.Marshal(jitters); err == nil {
+			envVars[envconfig.CWAGENT_COLLECTION_JITTER] = string(data)
+		}
+	}
+
+	// The kubernetes client shared by the container insights components is limited by the api_server options
+	for key, value := range k8sAPIServerOptions(jsonConfigValue) {
+		envVars[key] = value
 	}
 
 	proxy := util.GetHttpProxy(context.CurrentContext().Proxy())
@@ -57,3 +106,110 @@ func ToEnvConfig(jsonConfigValue map[string]interface{}) []byte {
 	}
 	return bytes
 }
+
+// configHash returns the hex encoded sha256 of the JSON config, its keys are sorted by the encoding so the hash does
+// not depend on their order in the config files
+func configHash(jsonConfigValue map[string]interface{}) (string, error) {
+	data, err := json.Marshal(jsonConfigValue)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func collectdTypesDB(jsonConfigValue map[string]interface{}) []string {
+	metrics, _ := jsonConfigValue["metrics"].(map[string]interface{})
+	collected, _ := metrics["metrics_collected"].(map[string]interface{})
+	collectd, _ := collected["collectd"].(map[string]interface{})
+	paths, _ := collectd["collectd_typesdb"].([]interface{})
+	var typesDB []string
+	for _, p := range paths {
+		if path, ok := p.(string); ok {
+			typesDB = append(typesDB, path)
+		}
+	}
+	return typesDB
+}
+
+func payloadSamplingOptions(sampling map[string]interface{}) map[string]string {
+	options := make(map[string]string)
+	if dir, ok := sampling["directory"].(string); ok {
+		options[envconfig.CWAGENT_DEBUG_SAMPLE_DIR] = dir
+	}
+	if maxPayloads, ok := sampling["max_payloads"].(float64); ok {
+		options[envconfig.CWAGENT_DEBUG_SAMPLE_MAX_PAYLOADS] = strconv.Itoa(int(maxPayloads))
+	}
+	if maxSize, ok := sampling["max_size_mb"].(float64); ok {
+		options[envconfig.CWAGENT_DEBUG_SAMPLE_MAX_BYTES] = strconv.Itoa(int(maxSize) * 1024 * 1024)
+	}
+	if every, ok := sampling["sample_every"].(float64); ok {
+		options[envconfig.CWAGENT_DEBUG_SAMPLE_EVERY] = strconv.Itoa(int(every))
+	}
+	if redact, ok := sampling["redact"].([]interface{}); ok && len(redact) > 0 {
+		if data, err := json.Marshal(redact); err == nil {
+			options[envconfig.CWAGENT_DEBUG_SAMPLE_REDACT] = string(data)
+		}
+	}
+	return options
+}
+
+func httpClientOptions(httpClient map[string]interface{}) map[string]string {
+	options := make(map[string]string)
+	if maxIdle, ok := httpClient["max_idle_conns_per_host"].(float64); ok {
+		options[envconfig.CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST] = strconv.Itoa(int(maxIdle))
+	}
+	if timeout, ok := httpClient["idle_conn_timeout"].(float64); ok {
+		options[envconfig.CWAGENT_HTTP_IDLE_CONN_TIMEOUT] = strconv.Itoa(int(timeout)) + "s"
+	}
+	if timeout, ok := httpClient["tls_handshake_timeout"].(float64); ok {
+		options[envconfig.CWAGENT_HTTP_TLS_HANDSHAKE_TIMEOUT] = strconv.Itoa(int(timeout)) + "s"
+	}
+	if disabled, ok := httpClient["disable_http2"].(bool); ok && disabled {
+		options[envconfig.CWAGENT_HTTP_DISABLE_HTTP2] = "true"
+	}
+	// 0 disables the cache of the addresses of the endpoints
+	if ttl, ok := httpClient["dns_cache_ttl"].(float64); ok {
+		options[envconfig.CWAGENT_DNS_CACHE_TTL] = strconv.Itoa(int(ttl)) + "s"
+	}
+	return options
+}
+
+func selfUpdateOptions(selfUpdate map[string]interface{}) map[string]string {
+	options := make(map[string]string)
+	if source, ok := selfUpdate["source"].(string); ok {
+		options[envconfig.CWAGENT_SELF_UPDATE_SOURCE] = source
+	}
+	if key, ok := selfUpdate["public_key"].(string); ok {
+		options[envconfig.CWAGENT_SELF_UPDATE_PUBLIC_KEY] = key
+	}
+	if interval, ok := selfUpdate["check_interval"].(float64); ok {
+		options[envconfig.CWAGENT_SELF_UPDATE_CHECK_INTERVAL] = strconv.Itoa(int(interval)) + "s"
+	}
+	if window, ok := selfUpdate["health_window"].(float64); ok {
+		options[envconfig.CWAGENT_SELF_UPDATE_HEALTH_WINDOW] = strconv.Itoa(int(window)) + "s"
+	}
+	return options
+}
+
+func k8sAPIServerOptions(jsonConfigValue map[string]interface{}) map[string]string {
+	logs, _ := jsonConfigValue["logs"].(map[string]interface{})
+	collected, _ := logs["metrics_collected"].(map[string]interface{})
+	kubernetes, _ := collected["kubernetes"].(map[string]interface{})
+	apiServer, _ := kubernetes["api_server"].(map[string]interface{})
+	options := make(map[string]string)
+	if qps, ok := apiServer["qps"].(float64); ok {
+		options[envconfig.CWAGENT_K8S_API_QPS] = strconv.FormatFloat(qps, 'f', -1, 32)
+	}
+	if burst, ok := apiServer["burst"].(float64); ok {
+		options[envconfig.CWAGENT_K8S_API_BURST] = strconv.Itoa(int(burst))
+	}
+	// 0 is the default of the resync interval and the cap, i.e. never resync and no limit
+	if resync, ok := apiServer["resync_interval"].(float64); ok && resync > 0 {
+		options[envconfig.CWAGENT_K8S_RESYNC_INTERVAL] = strconv.Itoa(int(resync)) + "s"
+	}
+	if maxObjects, ok := apiServer["max_cached_objects"].(float64); ok && maxObjects > 0 {
+		options[envconfig.CWAGENT_K8S_MAX_CACHED_OBJECTS] = strconv.Itoa(int(maxObjects))
+	}
+	return options
+}
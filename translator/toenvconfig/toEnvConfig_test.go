@@ -17,8 +17,11 @@ import (
 	"os"
 
 	commonconfig "github.com/aws/amazon-cloudwatch-agent/cfg/commonconfig"
+	"github.com/aws/amazon-cloudwatch-agent/cfg/envconfig"
 	"github.com/aws/amazon-cloudwatch-agent/translator/config"
 	"github.com/aws/amazon-cloudwatch-agent/translator/context"
+	"github.com/aws/amazon-cloudwatch-agent/translator/totomlconfig"
+	metricsutil "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,6 +44,9 @@ func checkIfTranslateSucceed(t *testing.T, jsonStr string, targetOs string, expe
 		var actualEnvVars = make(map[string]string)
 		err := json.Unmarshal(envVarsBytes, &actualEnvVars)
 		assert.NoError(t, err)
+		// the hash of the config is checked by TestConfigHash
+		assert.Len(t, actualEnvVars[envconfig.CWAGENT_CONFIG_HASH], 64)
+		delete(actualEnvVars, envconfig.CWAGENT_CONFIG_HASH)
 		assert.Equal(t, expectedEnvVars, actualEnvVars, "Expect to be equal")
 	} else {
 		fmt.Printf("Got error %v", e)
@@ -75,8 +81,9 @@ func TestCompleteConfig(t *testing.T) {
 	expectedEnvVars := map[string]string{
 		"CWAGENT_USER_AGENT": "CUSTOM USER AGENT VALUE",
 	}
-	checkIfTranslateSucceed(t, ReadFromFile("../totomlconfig/sampleConfig/complete_linux_config.json"), "linux", expectedEnvVars)
 	checkIfTranslateSucceed(t, ReadFromFile("../totomlconfig/sampleConfig/complete_windows_config.json"), "windows", expectedEnvVars)
+	expectedEnvVars["CWAGENT_COLLECTD_TYPESDB"] = "/usr/share/collectd/types.db"
+	checkIfTranslateSucceed(t, ReadFromFile("../totomlconfig/sampleConfig/complete_linux_config.json"), "linux", expectedEnvVars)
 }
 
 func TestWindowsEventOnlyConfig(t *testing.T) {
@@ -167,6 +174,110 @@ func TestECSNodeMetricConfig(t *testing.T) {
 	checkIfTranslateSucceed(t, ReadFromFile("../totomlconfig/sampleConfig/log_ecs_metric_only.json"), "linux", expectedEnvVars)
 }
 
+func TestK8sAPIServerConfig(t *testing.T) {
+	resetContext()
+	expectedEnvVars := map[string]string{
+		"CWAGENT_K8S_API_QPS":            "2.5",
+		"CWAGENT_K8S_API_BURST":          "10",
+		"CWAGENT_K8S_RESYNC_INTERVAL":    "300s",
+		"CWAGENT_K8S_MAX_CACHED_OBJECTS": "5000",
+	}
+	checkIfTranslateSucceed(t, `{"logs": {"metrics_collected": {"kubernetes": {"cluster_name": "TestCluster",
+		"api_server": {"qps": 2.5, "burst": 10, "resync_interval": 300, "max_cached_objects": 5000}}}}}`, "linux", expectedEnvVars)
+}
+
+func TestSelfUpdateConfig(t *testing.T) {
+	resetContext()
+	// the self-update is left to the images in a container
+	context.CurrentContext().SetRunInContainer(false)
+	expectedEnvVars := map[string]string{
+		"CWAGENT_SELF_UPDATE_SOURCE":         "s3://bucket/cwagent",
+		"CWAGENT_SELF_UPDATE_PUBLIC_KEY":     "cHVibGljLWtleQ==",
+		"CWAGENT_SELF_UPDATE_CHECK_INTERVAL": "3600s",
+		"CWAGENT_SELF_UPDATE_HEALTH_WINDOW":  "300s",
+	}
+	checkIfTranslateSucceed(t, `{"agent": {"self_update": {"source": "s3://bucket/cwagent", "public_key": "cHVibGljLWtleQ==",
+		"check_interval": 3600, "health_window": 300}}, "metrics": {"metrics_collected": {"mem": {"measurement": ["used_percent"]}}}}`, "linux", expectedEnvVars)
+}
+
+func TestPayloadSamplingConfig(t *testing.T) {
+	resetContext()
+	expectedEnvVars := map[string]string{
+		"CWAGENT_DEBUG_SAMPLE_DIR":          "/tmp/samples",
+		"CWAGENT_DEBUG_SAMPLE_MAX_PAYLOADS": "20",
+		"CWAGENT_DEBUG_SAMPLE_MAX_BYTES":    "5242880",
+		"CWAGENT_DEBUG_SAMPLE_EVERY":        "10",
+		"CWAGENT_DEBUG_SAMPLE_REDACT":       `["user-[0-9]+"]`,
+	}
+	checkIfTranslateSucceed(t, `{"agent": {"debug_payload_sampling": {"directory": "/tmp/samples", "max_payloads": 20,
+		"max_size_mb": 5, "sample_every": 10, "redact": ["user-[0-9]+"]}}, "metrics": {"metrics_collected": {"mem": {"measurement": ["used_percent"]}}}}`, "linux", expectedEnvVars)
+}
+
+func TestHTTPClientConfig(t *testing.T) {
+	resetContext()
+	expectedEnvVars := map[string]string{
+		"CWAGENT_HTTP_MAX_IDLE_CONNS_PER_HOST": "50",
+		"CWAGENT_HTTP_IDLE_CONN_TIMEOUT":       "30s",
+		"CWAGENT_HTTP_TLS_HANDSHAKE_TIMEOUT":   "5s",
+		"CWAGENT_HTTP_DISABLE_HTTP2":           "true",
+		"CWAGENT_DNS_CACHE_TTL":                "0s",
+	}
+	checkIfTranslateSucceed(t, `{"agent": {"http_client": {"max_idle_conns_per_host": 50, "idle_conn_timeout": 30,
+		"tls_handshake_timeout": 5, "disable_http2": true, "dns_cache_ttl": 0}}, "metrics": {"metrics_collected": {"mem": {"measurement": ["used_percent"]}}}}`, "linux", expectedEnvVars)
+}
+
+// checkCollectionJitters translates the TOML config first, as the config translator does, since the collection jitters
+// are the ones of the translated inputs
+func checkCollectionJitters(t *testing.T, jsonStr string, targetOs string, expectedJitters string) {
+	var input map[string]interface{}
+	translator.SetTargetPlatform(targetOs)
+	assert.NoError(t, json.Unmarshal([]byte(jsonStr), &input))
+	translated := totomlconfig.Translate(input)
+	// the jitters are removed from the inputs before they are encoded
+	assert.Empty(t, metricsutil.ExtractCollectionJitters(translated))
+
+	var actualEnvVars map[string]string
+	assert.NoError(t, json.Unmarshal(ToEnvConfig(input), &actualEnvVars))
+	assert.Equal(t, expectedJitters, actualEnvVars[envconfig.CWAGENT_COLLECTION_JITTER])
+}
+
+func TestCollectionJitterConfig(t *testing.T) {
+	resetContext()
+	checkCollectionJitters(t, `{"metrics": {"metrics_collected": {"cpu": {"measurement": ["usage_idle"], "collection_jitter": 5},
+		"mem": {"measurement": ["used_percent"], "collection_jitter": 0},
+		"procstat": [{"exe": "a", "measurement": ["cpu_usage"]}, {"exe": "b", "measurement": ["cpu_usage"], "collection_jitter": 2}]}}}`,
+		"linux", `{"cpu":["5s"],"procstat":["0s","2s"]}`)
+}
+
+func TestCollectionJitterCollectd(t *testing.T) {
+	resetContext()
+	checkCollectionJitters(t, `{"metrics": {"metrics_collected": {"collectd": {"collection_jitter": 3},
+		"mem": {"measurement": ["used_percent"]}}}}`, "linux", `{"socket_listener":["3s"]}`)
+}
+
+func TestCollectionJitterWindowsObjects(t *testing.T) {
+	resetContext()
+	// the objects of the same jitter are merged into an instance of win_perf_counters, the others get their own
+	checkCollectionJitters(t, `{"metrics": {"metrics_collected": {"Processor": {"measurement": ["% Idle Time"], "collection_jitter": 5},
+		"Memory": {"measurement": ["Available Bytes"], "collection_jitter": 5},
+		"Paging File": {"measurement": ["% Usage"]}}}}`, "windows", `{"win_perf_counters":["5s","0s"]}`)
+}
+
+func TestConfigHash(t *testing.T) {
+	hash := func(jsonStr string) string {
+		var input map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(jsonStr), &input))
+		h, err := configHash(input)
+		assert.NoError(t, err)
+		return h
+	}
+	config := `{"agent": {"interval": "60s"}, "metrics": {"metrics_collected": {"mem": {"measurement": ["used_percent"]}}}}`
+	reordered := `{"metrics": {"metrics_collected": {"mem": {"measurement": ["used_percent"]}}}, "agent": {"interval": "60s"}}`
+	changed := `{"agent": {"interval": "30s"}, "metrics": {"metrics_collected": {"mem": {"measurement": ["used_percent"]}}}}`
+	assert.Equal(t, hash(config), hash(reordered))
+	assert.NotEqual(t, hash(config), hash(changed))
+}
+
 func readCommonConifg() {
 	ctx := context.CurrentContext()
 	config := commonconfig.New()
@@ -0,0 +1,449 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tootelconfig
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultInterval = "60s"
+	metricsPipeline = "metrics"
+	header          = `# OpenTelemetry Collector configuration equivalent to the supported pipelines of the CloudWatch agent
+# configuration, for the side by side validation. The metrics are named after the OpenTelemetry semantic
+# conventions, the measurement lists, the dimensions and the metric decorations are not translated.
+`
+)
+
+// hostmetricsScrapers maps the telegraf inputs to the scrapers of the hostmetrics receiver
+var hostmetricsScrapers = map[string]string{
+	"cpu":       "cpu",
+	"disk":      "filesystem",
+	"diskio":    "disk",
+	"mem":       "memory",
+	"net":       "network",
+	"processes": "processes",
+	"swap":      "paging",
+}
+
+// ignoredPlugins have no counterpart to translate, e.g. the delta processor since the awsemf exporter
+// converts the cumulative metrics to deltas itself
+var ignoredPlugins = map[string]bool{
+	"processors.delta": true,
+}
+
+type logDestination struct {
+	group  string
+	stream string
+}
+
+type converter struct {
+	agentInterval   string
+	receivers       map[string]interface{}
+	processors      map[string]interface{}
+	exporters       map[string]interface{}
+	metricReceivers []string
+	logReceivers    map[logDestination][]string
+	logsOutput      map[string]interface{}
+	untranslated    []string
+}
+
+// ToOtelConfig converts the translated agent config, i.e. the one encoded in the TOML config, into an equivalent
+// OpenTelemetry Collector config. The plugins without an equivalent are listed in the header of the config.
+func ToOtelConfig(translated interface{}) string {
+	config, untranslated := convert(translated)
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+	buf := strings.Builder{}
+	buf.WriteString(header)
+	if len(untranslated) > 0 {
+		buf.WriteString("# Not translated: " + strings.Join(untranslated, ", ") + "\n")
+	}
+	buf.Write(out)
+	return buf.String()
+}
+
+func convert(translated interface{}) (map[string]interface{}, []string) {
+	root, _ := translated.(map[string]interface{})
+	c := &converter{
+		agentInterval: defaultInterval,
+		receivers:     map[string]interface{}{},
+		processors:    map[string]interface{}{},
+		exporters:     map[string]interface{}{},
+		logReceivers:  map[logDestination][]string{},
+	}
+	if agent, ok := root["agent"].(map[string]interface{}); ok {
+		if interval, ok := agent["interval"].(string); ok && interval != "" {
+			c.agentInterval = interval
+		}
+	}
+	outputs, _ := root["outputs"].(map[string]interface{})
+	if logsOutputs := sections(outputs, "cloudwatchlogs"); len(logsOutputs) > 0 {
+		c.logsOutput = logsOutputs[0]
+	}
+
+	inputs, _ := root["inputs"].(map[string]interface{})
+	for _, name := range sortedKeys(inputs) {
+		for _, input := range sections(inputs, name) {
+			c.convertInput(name, input)
+		}
+	}
+	for _, name := range sortedKeys(outputs) {
+		if name != "cloudwatch" && name != "cloudwatchlogs" {
+			c.untranslate("outputs." + name)
+		}
+	}
+	processors, _ := root["processors"].(map[string]interface{})
+	for _, name := range sortedKeys(processors) {
+		if taggers := sections(processors, name); name == "ec2tagger" && len(taggers) > 0 {
+			c.convertEC2Tagger(taggers[0])
+		} else {
+			c.untranslate("processors." + name)
+		}
+	}
+
+	pipelines := map[string]interface{}{}
+	if len(c.metricReceivers) > 0 {
+		if cloudwatch := sections(outputs, "cloudwatch"); len(cloudwatch) > 0 {
+			c.exporters["awsemf"] = emfExporter(cloudwatch[0])
+			c.processors["batch/metrics"] = map[string]interface{}{}
+			var metricProcessors []string
+			if _, ok := c.processors["resourcedetection"]; ok {
+				metricProcessors = append(metricProcessors, "resourcedetection")
+			}
+			pipelines[metricsPipeline] = map[string]interface{}{
+				"receivers":  dedup(c.metricReceivers),
+				"processors": append(metricProcessors, "batch/metrics"),
+				"exporters":  []string{"awsemf"},
+			}
+		}
+	}
+	if len(c.logReceivers) > 0 {
+		c.processors["batch/logs"] = map[string]interface{}{}
+		for i, destination := range sortedDestinations(c.logReceivers) {
+			exporter := fmt.Sprintf("awscloudwatchlogs/%d", i)
+			c.exporters[exporter] = c.logsExporter(destination)
+			pipelines[fmt.Sprintf("logs/%d", i)] = map[string]interface{}{
+				"receivers":  c.logReceivers[destination],
+				"processors": []string{"batch/logs"},
+				"exporters":  []string{exporter},
+			}
+		}
+	}
+
+	config := map[string]interface{}{
+		"receivers":  c.receivers,
+		"processors": c.processors,
+		"exporters":  c.exporters,
+		"service":    map[string]interface{}{"pipelines": pipelines},
+	}
+	return config, c.untranslated
+}
+
+func (c *converter) convertInput(name string, input map[string]interface{}) {
+	interval := c.agentInterval
+	if i, ok := input["interval"].(string); ok && i != "" {
+		interval = i
+	}
+	if scraper, ok := hostmetricsScrapers[name]; ok {
+		receiver := c.receiver("hostmetrics/"+interval, map[string]interface{}{
+			"collection_interval": interval,
+			"scrapers":            map[string]interface{}{},
+		})
+		receiver["scrapers"].(map[string]interface{})[scraper] = scraperConfig(name, input)
+		c.metricReceivers = append(c.metricReceivers, "hostmetrics/"+interval)
+		return
+	}
+	switch name {
+	case "statsd":
+		receiver := map[string]interface{}{
+			"endpoint":             listenAddress(stringValue(input, "service_address")),
+			"aggregation_interval": interval,
+		}
+		if tags, ok := input["tags"].(map[string]interface{}); ok {
+			if aggregation, ok := tags["aws:AggregationInterval"].(string); ok && aggregation != "" {
+				receiver["aggregation_interval"] = aggregation
+			}
+		}
+		id := c.nextID("statsd")
+		c.receivers[id] = receiver
+		c.metricReceivers = append(c.metricReceivers, id)
+	case "win_perf_counters":
+		receiver := c.receiver("windowsperfcounters/"+interval, map[string]interface{}{
+			"collection_interval": interval,
+			"perfcounters":        []interface{}{},
+		})
+		for _, object := range sections(input, "object") {
+			receiver["perfcounters"] = append(receiver["perfcounters"].([]interface{}), perfCounter(object))
+		}
+		c.metricReceivers = append(c.metricReceivers, "windowsperfcounters/"+interval)
+	case "logfile":
+		if c.logsOutput == nil {
+			c.untranslate("inputs." + name)
+			return
+		}
+		for _, file := range sections(input, "file_config") {
+			c.convertLogFile(file)
+		}
+	case "windows_event_log":
+		if c.logsOutput == nil {
+			c.untranslate("inputs." + name)
+			return
+		}
+		for _, event := range sections(input, "event_config") {
+			id := c.nextID("windowseventlog")
+			c.receivers[id] = map[string]interface{}{
+				"channel":  stringValue(event, "event_name"),
+				"start_at": "end",
+			}
+			c.addLogReceiver(event, id)
+		}
+	default:
+		c.untranslate("inputs." + name)
+	}
+}
+
+func (c *converter) convertLogFile(file map[string]interface{}) {
+	receiver := map[string]interface{}{
+		"include":  []string{stringValue(file, "file_path")},
+		"start_at": "end",
+	}
+	if fromBeginning, ok := file["from_beginning"].(bool); ok && fromBeginning {
+		receiver["start_at"] = "beginning"
+	}
+	if encoding := stringValue(file, "encoding"); encoding != "" {
+		receiver["encoding"] = encoding
+	}
+	if pattern := stringValue(file, "multi_line_start_pattern"); pattern != "" {
+		if pattern == "{timestamp_regex}" {
+			pattern = stringValue(file, "timestamp_regex")
+		}
+		if pattern != "" {
+			receiver["multiline"] = map[string]interface{}{"line_start_pattern": pattern}
+		}
+	}
+	id := c.nextID("filelog")
+	c.receivers[id] = receiver
+	c.addLogReceiver(file, id)
+}
+
+func (c *converter) addLogReceiver(input map[string]interface{}, id string) {
+	destination := logDestination{group: stringValue(input, "log_group_name"), stream: stringValue(input, "log_stream_name")}
+	if destination.stream == "" {
+		destination.stream = stringValue(c.logsOutput, "log_stream_name")
+	}
+	c.logReceivers[destination] = append(c.logReceivers[destination], id)
+}
+
+func (c *converter) convertEC2Tagger(tagger map[string]interface{}) {
+	detector := map[string]interface{}{}
+	var tags []string
+	for _, key := range stringList(tagger["ec2_instance_tag_keys"]) {
+		if key == "*" {
+			tags = []string{".*"}
+			break
+		}
+		tags = append(tags, "^"+regexp.QuoteMeta(key)+"$")
+	}
+	if len(tags) > 0 {
+		detector["tags"] = tags
+	}
+	c.processors["resourcedetection"] = map[string]interface{}{
+		"detectors": []string{"ec2"},
+		"ec2":       detector,
+	}
+}
+
+func (c *converter) logsExporter(destination logDestination) map[string]interface{} {
+	exporter := map[string]interface{}{
+		"log_group_name":  destination.group,
+		"log_stream_name": destination.stream,
+	}
+	copyValues(exporter, c.logsOutput)
+	return exporter
+}
+
+func emfExporter(cloudwatch map[string]interface{}) map[string]interface{} {
+	exporter := map[string]interface{}{
+		"namespace":                        stringValue(cloudwatch, "namespace"),
+		"resource_to_telemetry_conversion": map[string]interface{}{"enabled": true},
+	}
+	copyValues(exporter, cloudwatch)
+	return exporter
+}
+
+// copyValues copies the connection options shared by the CloudWatch outputs and the AWS exporters
+func copyValues(exporter map[string]interface{}, output map[string]interface{}) {
+	for outputKey, exporterKey := range map[string]string{"region": "region", "endpoint_override": "endpoint", "role_arn": "role_arn"} {
+		if value := stringValue(output, outputKey); value != "" {
+			exporter[exporterKey] = value
+		}
+	}
+}
+
+func scraperConfig(name string, input map[string]interface{}) map[string]interface{} {
+	config := map[string]interface{}{}
+	switch name {
+	case "disk":
+		if mountPoints := stringList(input["mount_points"]); len(mountPoints) > 0 {
+			config["include_mount_points"] = map[string]interface{}{"mount_points": mountPoints, "match_type": "strict"}
+		}
+		if fsTypes := stringList(input["ignore_fs"]); len(fsTypes) > 0 {
+			config["exclude_fs_types"] = map[string]interface{}{"fs_types": fsTypes, "match_type": "strict"}
+		}
+	case "diskio":
+		if devices := stringList(input["devices"]); len(devices) > 0 && !hasWildcard(devices) {
+			config["include"] = map[string]interface{}{"devices": devices, "match_type": "strict"}
+		}
+	case "net":
+		if interfaces := stringList(input["interfaces"]); len(interfaces) > 0 && !hasWildcard(interfaces) {
+			config["include"] = map[string]interface{}{"interfaces": interfaces, "match_type": "strict"}
+		}
+	}
+	return config
+}
+
+func perfCounter(object map[string]interface{}) map[string]interface{} {
+	var counters []interface{}
+	for _, counter := range stringList(object["Counters"]) {
+		counters = append(counters, map[string]interface{}{"name": counter})
+	}
+	perfCounter := map[string]interface{}{
+		"object":   stringValue(object, "ObjectName"),
+		"counters": counters,
+	}
+	// "------" is the instance of the objects which have no instances
+	if instances := stringList(object["Instances"]); len(instances) > 0 && instances[0] != "------" {
+		perfCounter["instances"] = instances
+	}
+	return perfCounter
+}
+
+// receiver returns the receiver of the id, created from the config when it does not exist yet
+func (c *converter) receiver(id string, config map[string]interface{}) map[string]interface{} {
+	if existing, ok := c.receivers[id].(map[string]interface{}); ok {
+		return existing
+	}
+	c.receivers[id] = config
+	return config
+}
+
+func (c *converter) nextID(kind string) string {
+	for i := 0; ; i++ {
+		id := fmt.Sprintf("%s/%d", kind, i)
+		if _, ok := c.receivers[id]; !ok {
+			return id
+		}
+	}
+}
+
+func (c *converter) untranslate(plugin string) {
+	if ignoredPlugins[plugin] {
+		return
+	}
+	for _, p := range c.untranslated {
+		if p == plugin {
+			return
+		}
+	}
+	c.untranslated = append(c.untranslated, plugin)
+}
+
+// listenAddress makes the telegraf service addresses, e.g. ":8125", explicit for the collector
+func listenAddress(address string) string {
+	if strings.HasPrefix(address, ":") {
+		return "0.0.0.0" + address
+	}
+	return address
+}
+
+// sections returns the tables of the key, the translation rules return them in slices of various types,
+// e.g. util.MetricArray for the windows perf counter objects
+func sections(m map[string]interface{}, key string) []map[string]interface{} {
+	if section, ok := m[key].(map[string]interface{}); ok {
+		return []map[string]interface{}{section}
+	}
+	var result []map[string]interface{}
+	if v := reflect.ValueOf(m[key]); v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			if section, ok := v.Index(i).Interface().(map[string]interface{}); ok {
+				result = append(result, section)
+			}
+		}
+	}
+	return result
+}
+
+func stringValue(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var result []string
+		for _, s := range list {
+			if str, ok := s.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func hasWildcard(values []string) bool {
+	for _, v := range values {
+		if strings.ContainsAny(v, "*?[") {
+			return true
+		}
+	}
+	return false
+}
+
+func dedup(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedDestinations(m map[logDestination][]string) []logDestination {
+	destinations := make([]logDestination, 0, len(m))
+	for d := range m {
+		destinations = append(destinations, d)
+	}
+	sort.Slice(destinations, func(i, j int) bool {
+		if destinations[i].group != destinations[j].group {
+			return destinations[i].group < destinations[j].group
+		}
+		return destinations[i].stream < destinations[j].stream
+	})
+	return destinations
+}
@@ -0,0 +1,129 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tootelconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// translated is the form of the translated config, the one encoded in the TOML config
+func translated() map[string]interface{} {
+	return map[string]interface{}{
+		"agent": map[string]interface{}{"interval": "30s"},
+		"inputs": map[string]interface{}{
+			"cpu":  []interface{}{map[string]interface{}{"percpu": true}},
+			"mem":  []interface{}{map[string]interface{}{"interval": "60s"}},
+			"disk": []interface{}{map[string]interface{}{"mount_points": []interface{}{"/"}, "ignore_fs": []interface{}{"tmpfs"}}},
+			"net":  []interface{}{map[string]interface{}{"interfaces": []interface{}{"*"}}},
+			"statsd": []interface{}{map[string]interface{}{"service_address": ":8125", "interval": "10s",
+				"tags": map[string]interface{}{"aws:AggregationInterval": "60s"}}},
+			"procstat": []interface{}{map[string]interface{}{"exe": "nginx"}},
+			"logfile": []interface{}{map[string]interface{}{"file_config": []interface{}{
+				map[string]interface{}{"file_path": "/var/log/a.log", "log_group_name": "a", "from_beginning": true,
+					"multi_line_start_pattern": "{timestamp_regex}", "timestamp_regex": `(\d{2}:\d{2}:\d{2})`},
+				map[string]interface{}{"file_path": "/var/log/b.log", "log_group_name": "a"},
+				map[string]interface{}{"file_path": "/var/log/c.log", "log_group_name": "c", "log_stream_name": "s", "encoding": "utf-16"},
+			}}},
+		},
+		"outputs": map[string]interface{}{
+			"cloudwatch":     []interface{}{map[string]interface{}{"namespace": "CWAgent", "region": "us-east-1", "role_arn": "arn"}},
+			"cloudwatchlogs": []interface{}{map[string]interface{}{"region": "us-east-1", "log_stream_name": "host", "endpoint_override": "https://logs"}},
+		},
+		"processors": map[string]interface{}{
+			"delta":        []interface{}{map[string]interface{}{}},
+			"ec2tagger":    []interface{}{map[string]interface{}{"ec2_instance_tag_keys": []string{"aws:autoscaling:groupName"}}},
+			"k8sdecorator": []interface{}{map[string]interface{}{}},
+		},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	config, untranslated := convert(translated())
+	assert.Equal(t, []string{"inputs.procstat", "processors.k8sdecorator"}, untranslated)
+
+	receivers := config["receivers"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"collection_interval": "30s",
+		"scrapers": map[string]interface{}{
+			"cpu": map[string]interface{}{},
+			"filesystem": map[string]interface{}{
+				"include_mount_points": map[string]interface{}{"mount_points": []string{"/"}, "match_type": "strict"},
+				"exclude_fs_types":     map[string]interface{}{"fs_types": []string{"tmpfs"}, "match_type": "strict"},
+			},
+			// the wildcards match all the interfaces
+			"network": map[string]interface{}{},
+		},
+	}, receivers["hostmetrics/30s"])
+	assert.Equal(t, map[string]interface{}{"collection_interval": "60s", "scrapers": map[string]interface{}{"memory": map[string]interface{}{}}},
+		receivers["hostmetrics/60s"])
+	assert.Equal(t, map[string]interface{}{"endpoint": "0.0.0.0:8125", "aggregation_interval": "60s"}, receivers["statsd/0"])
+	assert.Equal(t, map[string]interface{}{"include": []string{"/var/log/a.log"}, "start_at": "beginning",
+		"multiline": map[string]interface{}{"line_start_pattern": `(\d{2}:\d{2}:\d{2})`}}, receivers["filelog/0"])
+	assert.Equal(t, map[string]interface{}{"include": []string{"/var/log/c.log"}, "start_at": "end", "encoding": "utf-16"}, receivers["filelog/2"])
+
+	processors := config["processors"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"detectors": []string{"ec2"},
+		"ec2": map[string]interface{}{"tags": []string{`^aws:autoscaling:groupName$`}}}, processors["resourcedetection"])
+
+	exporters := config["exporters"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"namespace": "CWAgent", "region": "us-east-1", "role_arn": "arn",
+		"resource_to_telemetry_conversion": map[string]interface{}{"enabled": true}}, exporters["awsemf"])
+	// the log stream of the output is the default one
+	assert.Equal(t, map[string]interface{}{"log_group_name": "a", "log_stream_name": "host", "region": "us-east-1",
+		"endpoint": "https://logs"}, exporters["awscloudwatchlogs/0"])
+	assert.Equal(t, "s", exporters["awscloudwatchlogs/1"].(map[string]interface{})["log_stream_name"])
+
+	pipelines := config["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	require.Len(t, pipelines, 3)
+	assert.Equal(t, map[string]interface{}{
+		"receivers":  []string{"hostmetrics/30s", "hostmetrics/60s", "statsd/0"},
+		"processors": []string{"resourcedetection", "batch/metrics"},
+		"exporters":  []string{"awsemf"},
+	}, pipelines["metrics"])
+	assert.Equal(t, []string{"filelog/0", "filelog/1"}, pipelines["logs/0"].(map[string]interface{})["receivers"])
+	assert.Equal(t, []string{"awscloudwatchlogs/1"}, pipelines["logs/1"].(map[string]interface{})["exporters"])
+}
+
+func TestConvertWindows(t *testing.T) {
+	config, untranslated := convert(map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"win_perf_counters": []interface{}{
+				map[string]interface{}{"interval": "10s", "object": []interface{}{map[string]interface{}{
+					"ObjectName": "Processor", "Instances": []string{"_Total"}, "Counters": []string{"% Processor Time"}}}},
+				map[string]interface{}{"interval": "10s", "object": []interface{}{map[string]interface{}{
+					"ObjectName": "System", "Instances": []string{"------"}, "Counters": []string{"Processor Queue Length"}}}},
+			},
+			"windows_event_log": []interface{}{map[string]interface{}{"event_config": []interface{}{
+				map[string]interface{}{"event_name": "System", "log_group_name": "System", "log_stream_name": "host"},
+			}}},
+		},
+		// the events are not translated without the cloudwatchlogs output
+		"outputs": map[string]interface{}{"cloudwatch": []interface{}{map[string]interface{}{"namespace": "CWAgent"}}},
+	})
+	assert.Equal(t, []string{"inputs.windows_event_log"}, untranslated)
+	assert.Equal(t, map[string]interface{}{
+		"collection_interval": "10s",
+		"perfcounters": []interface{}{
+			map[string]interface{}{"object": "Processor", "instances": []string{"_Total"},
+				"counters": []interface{}{map[string]interface{}{"name": "% Processor Time"}}},
+			map[string]interface{}{"object": "System", "counters": []interface{}{map[string]interface{}{"name": "Processor Queue Length"}}},
+		},
+	}, config["receivers"].(map[string]interface{})["windowsperfcounters/10s"])
+	pipelines := config["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	assert.Equal(t, []string{"windowsperfcounters/10s"}, pipelines["metrics"].(map[string]interface{})["receivers"])
+}
+
+func TestToOtelConfig(t *testing.T) {
+	out := ToOtelConfig(translated())
+	assert.True(t, strings.HasPrefix(out, header+"# Not translated: inputs.procstat, processors.k8sdecorator\n"))
+	var config map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(out), &config))
+	assert.Contains(t, config, "receivers")
+	assert.Contains(t, config, "service")
+}
@@ -0,0 +1,70 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package totomlconfig
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// pluginHeader matches the headers of the tables of the agent, the global tags and the plugins, not of their sub
+// tables, e.g. "  [[inputs.cpu]]" but not "    [inputs.cpu.tags]"
+var pluginHeader = regexp.MustCompile(`^(\s*)\[\[?([a-z_]+(?:\.[a-z0-9_]+)?)\]\]?$`)
+
+// pluginKinds are the sections of the TOML whose plugins have their provenance recorded
+var pluginKinds = []string{"inputs", "outputs", "processors", "aggregators"}
+
+// AnnotateTomlConfig adds a comment above the tables of the plugins with the paths of the JSON config they are
+// translated from, as recorded by the last translation
+func AnnotateTomlConfig(tomlConfig string) string {
+	lines := strings.Split(tomlConfig, "\n")
+	annotated := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if m := pluginHeader.FindStringSubmatch(line); m != nil {
+			if paths := translator.Provenance[m[2]]; len(paths) > 0 {
+				annotated = append(annotated, m[1]+"# translated from "+strings.Join(paths, ", "))
+			}
+		}
+		annotated = append(annotated, line)
+	}
+	return strings.Join(annotated, "\n")
+}
+
+// ExplainTomlConfig returns the annotated TOML of the plugins translated from the path of the JSON config, e.g.
+// "metrics.metrics_collected.cpu", from a path under it or from one of its parents, e.g. the output of the metrics.
+// It is empty when nothing is translated from the path nor from its parents.
+func ExplainTomlConfig(val interface{}, jsonPath string) string {
+	jsonPath = strings.Trim(jsonPath, ".")
+	root, ok := val.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	explained := map[string]interface{}{}
+	for _, section := range []string{"agent", "global_tags"} {
+		if v, ok := root[section]; ok && translator.IsProvenanceOf(section, jsonPath) {
+			explained[section] = v
+		}
+	}
+	for _, kind := range pluginKinds {
+		plugins, ok := root[kind].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matched := map[string]interface{}{}
+		for name, v := range plugins {
+			if translator.IsProvenanceOf(kind+"."+name, jsonPath) {
+				matched[name] = v
+			}
+		}
+		if len(matched) > 0 {
+			explained[kind] = matched
+		}
+	}
+	if len(explained) == 0 {
+		return ""
+	}
+	return AnnotateTomlConfig(EncodeTomlConfig(explained))
+}
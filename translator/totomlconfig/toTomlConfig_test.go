@@ -56,6 +56,11 @@ func TestLogMetricOnly(t *testing.T) {
 	os.Unsetenv(config.HOST_IP)
 }
 
+func TestTopProcessesConfig(t *testing.T) {
+	resetContext()
+	checkIfTranslateSucceed(t, ReadFromFile("./sampleConfig/top_processes_config.json"), "./sampleConfig/top_processes_config_linux.conf", "linux")
+}
+
 func TestLogMetricAndLog(t *testing.T) {
 	resetContext()
 	context.CurrentContext().SetRunInContainer(true)
@@ -151,6 +156,16 @@ func TestDeltaConfigLinux(t *testing.T) {
 	checkIfTranslateSucceed(t, ReadFromFile("./sampleConfig/delta_config_linux.json"), "./sampleConfig/delta_config_linux.conf", "darwin")
 }
 
+func TestMetadataFileConfigLinux(t *testing.T) {
+	resetContext()
+	checkIfTranslateSucceed(t, ReadFromFile("./sampleConfig/metadata_file_config_linux.json"), "./sampleConfig/metadata_file_config_linux.conf", "linux")
+}
+
+func TestWMIConfigWindows(t *testing.T) {
+	resetContext()
+	checkIfTranslateSucceed(t, ReadFromFile("./sampleConfig/wmi_config_windows.json"), "./sampleConfig/wmi_config_windows.conf", "windows")
+}
+
 func TestCsmServiceAdressesConfig(t *testing.T) {
 	resetContext()
 	checkIfTranslateSucceed(t, ReadFromFile("./sampleConfig/csm_service_addresses.json"), "./sampleConfig/csm_service_addresses_windows.conf", "windows")
@@ -188,3 +203,35 @@ func resetContext() {
 
 	os.Setenv("ProgramData", "c:\\ProgramData")
 }
+
+func TestExplainTomlConfig(t *testing.T) {
+	resetContext()
+	agent.Global_Config = *new(agent.Agent)
+	translator.SetTargetPlatform("linux")
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"agent": {"region": "us-east-1"},
+		"metrics": {"metrics_collected": {"cpu": {"measurement": ["usage_idle"]}, "mem": {"measurement": ["used_percent"]}}},
+		"logs": {"logs_collected": {"files": {"collect_list": [{"file_path": "/var/log/a.log"}]}}}
+	}`), &input))
+	translated := Translate(input)
+
+	annotated := AnnotateTomlConfig(EncodeTomlConfig(translated))
+	assert.Contains(t, annotated, "# translated from agent\n[agent]")
+	assert.Contains(t, annotated, "  # translated from metrics.metrics_collected.cpu\n  [[inputs.cpu]]")
+	assert.Contains(t, annotated, "  # translated from logs.logs_collected.files\n  [[inputs.logfile]]")
+	assert.Contains(t, annotated, "  # translated from metrics\n  [[outputs.cloudwatch]]")
+	assert.NotContains(t, annotated, "# translated from metrics.metrics_collected.cpu\n    [inputs.cpu.tags]")
+
+	explained := ExplainTomlConfig(translated, "metrics.metrics_collected.cpu.measurement")
+	assert.Contains(t, explained, "[[inputs.cpu]]")
+	assert.Contains(t, explained, "[[outputs.cloudwatch]]")
+	assert.NotContains(t, explained, "[[inputs.mem]]")
+	assert.NotContains(t, explained, "[[inputs.logfile]]")
+	assert.NotContains(t, explained, "[agent]")
+
+	explained = ExplainTomlConfig(translated, "metrics.metrics_collected.disk")
+	assert.NotContains(t, explained, "[[inputs.")
+	assert.Contains(t, explained, "[[outputs.cloudwatch]]")
+	assert.Equal(t, "", ExplainTomlConfig(translated, "csm"))
+}
@@ -24,11 +24,17 @@ func RegisterRule(fieldname string, r translator.Rule) {
 }
 
 type Agent struct {
-	Interval    string
-	Credentials map[string]interface{}
-	Region      string
-	Internal    bool
-	Role_arn    string
+	Interval                 string
+	Credentials              map[string]interface{}
+	Region                   string
+	Internal                 bool
+	Role_arn                 string
+	UseDualStackEndpoint     bool
+	BindAddress              string
+	FlushOnTerminationNotice bool
+	AlignToWallClock         bool
+	MetadataFile             string
+	MonitoringAccount        MonitoringAccountConfig
 }
 
 var Global_Config Agent = *new(Agent)
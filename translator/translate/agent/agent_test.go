@@ -177,3 +177,139 @@ func restoreProxyEnv() {
 	os.Setenv("https_proxy", httpsProxy)
 	os.Setenv("no_proxy", noProxy)
 }
+
+func TestIPv6Options(t *testing.T) {
+	a := new(Agent)
+	translator.SetTargetPlatform(config.OS_TYPE_LINUX)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"agent":{"use_dualstack_endpoint": true, "bind_address": "::1"}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.True(t, Global_Config.UseDualStackEndpoint)
+	assert.Equal(t, "::1", Global_Config.BindAddress)
+	assert.Equal(t, "[::1]:25826", ListenAddress("127.0.0.1", 25826))
+
+	e = json.Unmarshal([]byte(`{"agent":{}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.False(t, Global_Config.UseDualStackEndpoint)
+	assert.Equal(t, "", Global_Config.BindAddress)
+	assert.Equal(t, "127.0.0.1:25826", ListenAddress("127.0.0.1", 25826))
+	assert.Equal(t, ":8125", ListenAddress("", 8125))
+}
+
+func TestFlushOnTerminationNotice(t *testing.T) {
+	a := new(Agent)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"agent":{"flush_on_termination_notice": true}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.True(t, Global_Config.FlushOnTerminationNotice)
+
+	e = json.Unmarshal([]byte(`{"agent":{}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.False(t, Global_Config.FlushOnTerminationNotice)
+}
+
+func TestAlignToWallClock(t *testing.T) {
+	a := new(Agent)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"agent":{"align_to_wall_clock": true}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := a.ApplyRule(input)
+	assert.True(t, Global_Config.AlignToWallClock)
+	assert.Equal(t, true, val.(map[string]interface{})["round_interval"])
+
+	e = json.Unmarshal([]byte(`{"agent":{}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val = a.ApplyRule(input)
+	assert.False(t, Global_Config.AlignToWallClock)
+	assert.Equal(t, false, val.(map[string]interface{})["round_interval"])
+}
+
+func TestMetadataFile(t *testing.T) {
+	a := new(Agent)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"agent":{"metadata_file": "/etc/cwagent/metadata.json"}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := a.ApplyRule(input)
+	assert.Equal(t, "/etc/cwagent/metadata.json", Global_Config.MetadataFile)
+	assert.NotContains(t, val, MetadataFileKey)
+
+	e = json.Unmarshal([]byte(`{"agent":{}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.Equal(t, "", Global_Config.MetadataFile)
+}
+
+func TestMonitoringAccount(t *testing.T) {
+	a := new(Agent)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"agent":{"monitoring_account": {
+			"sink_arn": "arn:aws-us-gov:oam:us-gov-west-1:111122223333:sink/f3f42f60-f0f2-425c-1234-12347bdd821f",
+			"external_id": "source"}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.Equal(t, MonitoringAccountConfig{
+		AccountID:  "111122223333",
+		Partition:  "aws-us-gov",
+		RoleName:   DefaultMonitoringAccountRoleName,
+		ExternalID: "source",
+	}, Global_Config.MonitoringAccount)
+	assert.Equal(t, map[string]interface{}{
+		"role_arn":    "arn:aws-us-gov:iam::111122223333:role/CloudWatchAgentMonitoringAccountRole",
+		"external_id": "source",
+	}, MonitoringAccountCredentials())
+
+	e = json.Unmarshal([]byte(`{"agent":{"region": "us-east-1", "monitoring_account": {"account_id": "111122223333", "role_name": "Monitoring"}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{"role_arn": "arn:aws:iam::111122223333:role/Monitoring"}, MonitoringAccountCredentials())
+
+	e = json.Unmarshal([]byte(`{"agent":{}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	a.ApplyRule(input)
+	assert.Nil(t, MonitoringAccountCredentials())
+}
+
+func TestMonitoringAccountInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"sink_arn": "arn:aws:logs:us-east-1:111122223333:log-group:test"}`,
+		`{"sink_arn": "arn:aws:oam:us-east-1:111122223333:sink/id", "account_id": "444455556666"}`,
+		`{"role_name": "Monitoring"}`,
+	} {
+		translator.ResetMessages()
+		var input interface{}
+		e := json.Unmarshal([]byte(`{"agent":{"monitoring_account": `+config+`}}`), &input)
+		if e != nil {
+			assert.Fail(t, e.Error())
+		}
+		new(MonitoringAccount).ApplyRule(input.(map[string]interface{})["agent"])
+		assert.Len(t, translator.ErrorMessages, 1, config)
+		assert.Equal(t, MonitoringAccountConfig{}, Global_Config.MonitoringAccount)
+	}
+	translator.ResetMessages()
+}
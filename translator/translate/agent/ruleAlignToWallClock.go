@@ -0,0 +1,29 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type AlignToWallClock struct {
+}
+
+const (
+	AlignToWallClockKey = "align_to_wall_clock"
+)
+
+// The metrics are collected at the multiples of their interval on the wall clock, e.g. at :00 and :30 of the minute
+// every 30 seconds, and published shortly after the multiples of the flush interval of the metrics. This should be
+// applied before interpreting other component.
+func (obj *AlignToWallClock) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase(AlignToWallClockKey, false, input)
+	Global_Config.AlignToWallClock = val.(bool)
+	return
+}
+
+func init() {
+	obj := new(AlignToWallClock)
+	RegisterRule(AlignToWallClockKey, obj)
+}
@@ -0,0 +1,46 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type BindAddress struct {
+}
+
+const (
+	BindAddressKey = "bind_address"
+)
+
+// This bind address will be used by the listeners of statsd, collectd and emf without a service_address,
+// e.g. "::1" on IPv6-only hosts. This should be applied before interpreting other component.
+func (obj *BindAddress) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase(BindAddressKey, "", input)
+	address := val.(string)
+	if address != "" && net.ParseIP(address) == nil {
+		translator.AddErrorMessages(GetCurPath()+BindAddressKey, fmt.Sprintf("bind_address %q is not an IP address", address))
+		address = ""
+	}
+	Global_Config.BindAddress = address
+	return
+}
+
+// ListenAddress returns the host:port of a listener, on the bind address when it is set and on the
+// default host otherwise. IPv6 addresses are enclosed in brackets.
+func ListenAddress(defaultHost string, port int) string {
+	host := defaultHost
+	if Global_Config.BindAddress != "" {
+		host = Global_Config.BindAddress
+	}
+	return net.JoinHostPort(host, fmt.Sprint(port))
+}
+
+func init() {
+	obj := new(BindAddress)
+	RegisterRule(BindAddressKey, obj)
+}
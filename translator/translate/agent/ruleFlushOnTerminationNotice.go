@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type FlushOnTerminationNotice struct {
+}
+
+const (
+	FlushOnTerminationNoticeKey = "flush_on_termination_notice"
+)
+
+// The logs and metrics outputs flush what they buffer once the spot interruption or scale-in notice of the instance
+// is received. This should be applied before interpreting other component.
+func (obj *FlushOnTerminationNotice) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase(FlushOnTerminationNoticeKey, false, input)
+	Global_Config.FlushOnTerminationNotice = val.(bool)
+	return
+}
+
+func init() {
+	obj := new(FlushOnTerminationNotice)
+	RegisterRule(FlushOnTerminationNoticeKey, obj)
+}
@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type MetadataFile struct {
+}
+
+const (
+	MetadataFileKey = "metadata_file"
+)
+
+// The key value pairs of the metadata file are added as tags to all the metrics and as fields to the JSON log events by
+// the metadatafile processor, see translate.go
+func (obj *MetadataFile) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase(MetadataFileKey, "", input)
+	Global_Config.MetadataFile = val.(string)
+	return
+}
+
+func init() {
+	obj := new(MetadataFile)
+	RegisterRule(MetadataFileKey, obj)
+}
@@ -0,0 +1,110 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+type MonitoringAccount struct {
+}
+
+type MonitoringAccountConfig struct {
+	AccountID  string
+	Partition  string
+	RoleName   string
+	ExternalID string
+}
+
+const (
+	MonitoringAccountKey = "monitoring_account"
+	SinkArnKey           = "sink_arn"
+	AccountIdKey         = "account_id"
+	RoleNameKey          = "role_name"
+	ExternalIdKey        = "external_id"
+
+	DefaultMonitoringAccountRoleName = "CloudWatchAgentMonitoringAccountRole"
+)
+
+var accountIdPattern = regexp.MustCompile(`^\d{12}$`)
+
+// The metrics and the logs are published to the monitoring account of the CloudWatch cross-account observability,
+// identified by its OAM sink or its account ID, with the role of the monitoring account. The external ID guards the
+// role against the confused deputy problem. The outputs apply it through MonitoringAccountCredentials.
+func (obj *MonitoringAccount) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	Global_Config.MonitoringAccount = MonitoringAccountConfig{}
+	val, ok := input.(map[string]interface{})[MonitoringAccountKey]
+	if !ok {
+		return
+	}
+	path := GetCurPath() + MonitoringAccountKey + "/"
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		translator.AddErrorMessages(path, "Invalid format, expected an object")
+		return
+	}
+	account := MonitoringAccountConfig{RoleName: DefaultMonitoringAccountRoleName}
+	if sinkArn, ok := m[SinkArnKey].(string); ok {
+		parsed, err := arn.Parse(sinkArn)
+		if err != nil || parsed.Service != "oam" || !strings.HasPrefix(parsed.Resource, "sink/") {
+			translator.AddErrorMessages(path+SinkArnKey, fmt.Sprintf("%v is not the ARN of an OAM sink", sinkArn))
+			return
+		}
+		account.AccountID, account.Partition = parsed.AccountID, parsed.Partition
+	}
+	if accountId, ok := m[AccountIdKey].(string); ok {
+		if account.AccountID != "" && account.AccountID != accountId {
+			translator.AddErrorMessages(path+AccountIdKey, fmt.Sprintf("%v is not the account of the sink", accountId))
+			return
+		}
+		account.AccountID = accountId
+	}
+	if !accountIdPattern.MatchString(account.AccountID) {
+		translator.AddErrorMessages(path, "The sink_arn or the account_id of the monitoring account is missing")
+		return
+	}
+	if roleName, ok := m[RoleNameKey].(string); ok {
+		account.RoleName = roleName
+	}
+	if externalId, ok := m[ExternalIdKey].(string); ok {
+		account.ExternalID = externalId
+	}
+	Global_Config.MonitoringAccount = account
+	return
+}
+
+// MonitoringAccountCredentials returns the role and the external ID the CloudWatch and CloudWatch Logs outputs
+// publish to the monitoring account with, nothing without a monitoring account
+func MonitoringAccountCredentials() map[string]interface{} {
+	account := Global_Config.MonitoringAccount
+	if account.AccountID == "" {
+		return nil
+	}
+	partition := account.Partition
+	if partition == "" {
+		// the partition of the region the agent publishes to
+		partition = endpoints.AwsPartitionID
+		if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), Global_Config.Region); ok {
+			partition = p.ID()
+		}
+	}
+	result := map[string]interface{}{
+		Role_Arn_Key: fmt.Sprintf("arn:%v:iam::%v:role/%v", partition, account.AccountID, account.RoleName),
+	}
+	if account.ExternalID != "" {
+		result[ExternalIdKey] = account.ExternalID
+	}
+	return result
+}
+
+func init() {
+	obj := new(MonitoringAccount)
+	RegisterRule(MonitoringAccountKey, obj)
+}
@@ -12,6 +12,10 @@ type RoundInterval struct {
 
 func (r *RoundInterval) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
 	returnKey, returnVal = translator.DefaultCase("round_interval", false, input)
+	// the collections of the inputs are aligned to the wall clock by the round interval of telegraf
+	if _, align := translator.DefaultCase(AlignToWallClockKey, false, input); align == true {
+		returnVal = true
+	}
 	return
 }
 
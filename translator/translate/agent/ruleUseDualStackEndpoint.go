@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package agent
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type UseDualStackEndpoint struct {
+}
+
+const (
+	UseDualStackEndpointKey = "use_dualstack_endpoint"
+)
+
+// The dual-stack endpoints will be used by all the outputs, they are required on IPv6-only hosts
+// This should be applied before interpreting other component.
+func (obj *UseDualStackEndpoint) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase(UseDualStackEndpointKey, false, input)
+	Global_Config.UseDualStackEndpoint = val.(bool)
+	return
+}
+
+func init() {
+	obj := new(UseDualStackEndpoint)
+	RegisterRule(UseDualStackEndpointKey, obj)
+}
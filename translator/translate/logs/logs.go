@@ -4,6 +4,8 @@
 package logs
 
 import (
+	"strings"
+
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonRule"
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonUtil"
@@ -18,8 +20,23 @@ var ChildRule = map[string]Rule{}
 const (
 	SectionKey             = "logs"
 	Output_Cloudwatch_Logs = "cloudwatchlogs"
+	Output_S3_Logs         = "s3logs"
+	Output_Kinesis_Logs    = "kinesislogs"
+	Output_OpenSearch_Logs = "opensearch"
+	Output_File_Logs       = util.FileOutputPluginName
 )
 
+// additionalLogOutputs are the log outputs which receive the collected logs in addition to CloudWatch Logs,
+// or instead of it when the exclusive key of any of them is set to true.
+var additionalLogOutputs = []struct {
+	sectionKey, output, exclusiveKey string
+}{
+	{S3ArchiveSectionKey, Output_S3_Logs, ArchiveOnlyKey},
+	{KinesisSectionKey, Output_Kinesis_Logs, KinesisOnlyKey},
+	{OpenSearchSectionKey, Output_OpenSearch_Logs, OpenSearchOnlyKey},
+	{util.FileOutputSectionKey, Output_File_Logs, util.FileOnlyKey},
+}
+
 func GetCurPath() string {
 	curPath := parent.GetCurPath() + SectionKey + "/"
 	return curPath
@@ -32,9 +49,13 @@ func RegisterRule(fieldname string, r Rule) {
 type Logs struct {
 	FileStateFolder string
 	MetadataInfo    map[string]string
+	// Destination is the comma separated list of outputs the collected logs are sent to
+	Destination string
+	// Defaults are the keys inherited by the entries of the collect_list of the log files
+	Defaults map[string]interface{}
 }
 
-var GlobalLogConfig = Logs{}
+var GlobalLogConfig = Logs{Destination: Output_Cloudwatch_Logs}
 
 func (l *Logs) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
 	im := input.(map[string]interface{})
@@ -42,6 +63,7 @@ func (l *Logs) ApplyRule(input interface{}) (returnKey string, returnVal interfa
 	inputs := map[string]interface{}{}
 	processors := map[string]interface{}{}
 	cloudwatchConfig := map[string]interface{}{}
+	additionalConfigs := map[string]map[string]interface{}{}
 	GlobalLogConfig.MetadataInfo = util.GetMetadataInfo()
 
 	//Check if this plugin exist in the input instance
@@ -52,6 +74,8 @@ func (l *Logs) ApplyRule(input interface{}) (returnKey string, returnVal interfa
 		translator.AddInfoMessages("", "No log configuration found.")
 	} else {
 		//If yes, process it
+		GlobalLogConfig.Destination = logDestination(im[SectionKey])
+		GlobalLogConfig.Defaults = logsDefaults(im[SectionKey])
 		for _, rule := range ChildRule {
 			key, val := rule.ApplyRule(im[SectionKey])
 			//If key == "", then no instance of this class in input
@@ -75,12 +99,22 @@ func (l *Logs) ApplyRule(input interface{}) (returnKey string, returnVal interfa
 					inputs = translator.MergeTwoUniqueMaps(inputs, val.(map[string]interface{}))
 				} else if key == Output_Cloudwatch_Logs {
 					cloudwatchConfig = translator.MergeTwoUniqueMaps(cloudwatchConfig, val.(map[string]interface{}))
+				} else if key == Output_S3_Logs || key == Output_Kinesis_Logs || key == Output_OpenSearch_Logs || key == Output_File_Logs {
+					additionalConfigs[key] = val.(map[string]interface{})
 				}
 			}
 		}
 
 		cloudwatchInfo := map[string]interface{}{}
-		cloudwatchInfo["cloudwatchlogs"] = []interface{}{cloudwatchConfig}
+		if !util.IsFileOnly(im[SectionKey]) {
+			cloudwatchInfo["cloudwatchlogs"] = []interface{}{cloudwatchConfig}
+		}
+		for output, config := range additionalConfigs {
+			if streamName, ok := cloudwatchConfig["log_stream_name"]; ok {
+				config["log_stream_name"] = streamName
+			}
+			cloudwatchInfo[output] = []interface{}{config}
+		}
 		result["outputs"] = cloudwatchInfo
 
 		if len(inputs) > 0 {
@@ -103,12 +137,42 @@ func (l *Logs) ApplyRule(input interface{}) (returnKey string, returnVal interfa
 			translator.SetMetricPathForOneInput(result, SectionKey, "socket_listener", []string{})
 		}
 
+		if _, ok = inputs["emf_listener"]; ok {
+			translator.SetMetricPathForOneInput(result, SectionKey, "emf_listener", []string{})
+		}
+
+		if _, ok = inputs["top_processes"]; ok {
+			// the reports are complete log events, the processors do not apply to them
+			translator.SetMetricPathForOneInput(result, SectionKey, "top_processes", []string{})
+		}
+
 		returnKey = SectionKey
 		returnVal = result
 	}
 	return
 }
 
+// logDestination returns the comma separated list of outputs the collected log files are sent to.
+func logDestination(input interface{}) string {
+	im := input.(map[string]interface{})
+	destinations := []string{}
+	skipCloudWatchLogs := false
+	for _, o := range additionalLogOutputs {
+		section, ok := im[o.sectionKey].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if exclusive, ok := section[o.exclusiveKey].(bool); ok && exclusive {
+			skipCloudWatchLogs = true
+		}
+		destinations = append(destinations, o.output)
+	}
+	if !skipCloudWatchLogs {
+		destinations = append([]string{Output_Cloudwatch_Logs}, destinations...)
+	}
+	return strings.Join(destinations, ",")
+}
+
 var MergeRuleMap = map[string]mergeJsonRule.MergeRule{}
 
 func (l *Logs) Merge(source map[string]interface{}, result map[string]interface{}) {
@@ -121,4 +185,7 @@ func init() {
 	parent.RegisterDarwinRule(SectionKey, l)
 	parent.RegisterWindowsRule(SectionKey, l)
 	mergeJsonUtil.MergeRuleMap[SectionKey] = l
+	RegisterRule(util.FileOutputSectionKey, util.GetFileOutputRule(Output_File_Logs, ""))
+	RegisterRule("dualstack", util.GetDualStackEndpointRule(Output_Cloudwatch_Logs))
+	RegisterRule("terminationnotice", util.GetFlushOnTerminationNoticeRule(Output_Cloudwatch_Logs))
 }
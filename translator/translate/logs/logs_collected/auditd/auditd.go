@@ -0,0 +1,75 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package auditd
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/logs/logs_collected"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+//
+//	"auditd": {
+//		"log_group_name": "audit",
+//		"key_routes": [
+//			{"keys": ["identity"], "log_group_name": "audit-identity"}
+//		]
+//	}
+//
+const SectionKey = "auditd"
+
+// Auditd publishes the events of the audit log merged by serial number, routed to the log groups by audit key
+type Auditd struct {
+}
+
+func GetCurPath() string {
+	return parent.GetCurPath() + SectionKey + "/"
+}
+
+func (a *Auditd) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	section, ok := input.(map[string]interface{})[SectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	result := map[string]interface{}{"destination": logs.GlobalLogConfig.Destination}
+	if _, filePath := translator.DefaultCase("file_path", "", section); filePath != "" {
+		result["file_path"] = filePath
+	}
+	setLogGroup(section, result)
+	if _, ok := section["merge_timeout"]; ok {
+		_, result["merge_timeout"] = translator.DefaultTimeIntervalCase("merge_timeout", float64(2), section)
+	}
+	if routes, ok := section["key_routes"].([]interface{}); ok {
+		var keyRoutes []interface{}
+		for _, r := range routes {
+			route := r.(map[string]interface{})
+			keyRoute := map[string]interface{}{"keys": route["keys"]}
+			setLogGroup(route, keyRoute)
+			keyRoutes = append(keyRoutes, keyRoute)
+		}
+		result["key_route"] = keyRoutes
+	}
+	if _, ok := result["log_group_name"]; !ok && result["key_route"] == nil {
+		translator.AddErrorMessages(GetCurPath(), "The auditd section has no log_group_name nor key_routes")
+		return
+	}
+
+	returnKey = "inputs"
+	returnVal = map[string]interface{}{"auditd": []interface{}{result}}
+	return
+}
+
+func setLogGroup(input, result map[string]interface{}) {
+	if _, group := translator.DefaultCase("log_group_name", "", input); group != "" {
+		result["log_group_name"] = util.ResolvePlaceholder(group.(string), logs.GlobalLogConfig.MetadataInfo)
+	}
+	if _, stream := translator.DefaultCase("log_stream_name", "", input); stream != "" {
+		result["log_stream_name"] = util.ResolvePlaceholder(stream.(string), logs.GlobalLogConfig.MetadataInfo)
+	}
+}
+
+func init() {
+	parent.RegisterLinuxRule(SectionKey, new(Auditd))
+}
@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package auditd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditd(t *testing.T) {
+	logs.GlobalLogConfig.Destination = "cloudwatchlogs"
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"auditd": {
+		"log_group_name": "audit",
+		"merge_timeout": 5,
+		"key_routes": [
+			{"keys": ["identity", "privileged"], "log_group_name": "audit-identity", "log_stream_name": "identity"}
+		]
+	}}`), &input))
+
+	_, actual := new(Auditd).ApplyRule(input)
+	expected := map[string]interface{}{
+		"auditd": []interface{}{
+			map[string]interface{}{
+				"destination":    "cloudwatchlogs",
+				"log_group_name": "audit",
+				"merge_timeout":  "5s",
+				"key_route": []interface{}{
+					map[string]interface{}{
+						"keys":            []interface{}{"identity", "privileged"},
+						"log_group_name":  "audit-identity",
+						"log_stream_name": "identity",
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, actual)
+
+	translator.ResetMessages()
+	require.NoError(t, json.Unmarshal([]byte(`{"auditd": {"file_path": "/audit.log"}}`), &input))
+	key, _ := new(Auditd).ApplyRule(input)
+	assert.Equal(t, "", key)
+	assert.Len(t, translator.ErrorMessages, 1)
+	translator.ResetMessages()
+}
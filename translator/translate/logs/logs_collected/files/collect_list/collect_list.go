@@ -14,6 +14,7 @@ import (
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonRule"
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonUtil"
 	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
 	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/logs/logs_collected/files"
 )
 
@@ -53,9 +54,10 @@ func (f *FileConfig) ApplyRule(input interface{}) (returnKey string, returnVal i
 		for i := 0; i < len(configArr); i++ {
 			Index += 1
 			result := map[string]interface{}{}
+			entry := logs.WithDefaults(configArr[i].(map[string]interface{}))
 			for _, ruleArr := range ChildRule {
 				for j := 0; j < len(ruleArr); j++ {
-					key, val := ruleArr[j].ApplyRule(configArr[i])
+					key, val := ruleArr[j].ApplyRule(entry)
 					if key != "" {
 						result[key] = val
 					}
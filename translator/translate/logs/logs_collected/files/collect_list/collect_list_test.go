@@ -15,6 +15,7 @@ import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 	"github.com/aws/amazon-cloudwatch-agent/translator/context"
 	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -208,6 +209,71 @@ func TestTimestampFormat_Template(t *testing.T) {
 	assert.Equal(t, time.Date(0, 8, 9, 20, 45, 51, 0, time.Local), parsedTime)
 }
 
+func TestTimestampFormats(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"path1",
+				"timestamp_format":["%Y-%m-%dT%H:%M:%S.%f%z", "%d/%b/%Y:%H:%M:%S"],
+				"timestamp_fallback_to_mtime":true
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":                   "path1",
+		"from_beginning":              true,
+		"pipe":                        false,
+		"timestamp_layout":            "2006-01-02T15:04:05..000-0700",
+		"timestamp_regex":             "(\\d{4}-\\d{2}-\\d{2}T\\d{2}:\\d{2}:\\d{2}\\.(\\d{1,9})(?:Z|[\\+-]\\d{2}:?\\d{2}))",
+		"timestamp_layouts":           []interface{}{"02/Jan/2006:15:04:05"},
+		"timestamp_regexes":           []interface{}{"(\\d{2}/\\w{3}/\\d{4}:\\d{2}:\\d{2}:\\d{2})"},
+		"timestamp_fallback_to_mtime": true,
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
+func TestTimezoneName(t *testing.T) {
+	translator.ResetMessages()
+	defer translator.ResetMessages()
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"path1",
+				"timestamp_format":"%H:%M:%S",
+				"timezone":"Australia/Lord_Howe"
+			},
+			{
+				"file_path":"path2",
+				"timestamp_format":"%H:%M:%S",
+				"timezone":"Local"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	assert.Equal(t, "Australia/Lord_Howe", val.([]interface{})[0].(map[string]interface{})["timezone"])
+	assert.Equal(t, "LOCAL", val.([]interface{})[1].(map[string]interface{})["timezone"])
+	assert.True(t, translator.IsTranslateSuccess())
+
+	e = json.Unmarshal([]byte(`{"collect_list":[{"file_path":"path1","timestamp_format":"%H:%M:%S","timezone":"Europe/Atlantis"}]}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	f.ApplyRule(input)
+	assert.False(t, translator.IsTranslateSuccess())
+	assert.Equal(t, []string{"Under path : /logs/logs_collected/files/collect_list/timezone | Error : Timezone Europe/Atlantis is an invalid value."}, translator.ErrorMessages)
+}
+
 func TestMultiLineStartPattern(t *testing.T) {
 	f := new(FileConfig)
 	var input interface{}
@@ -292,6 +358,346 @@ func TestEncoding_Invalid(t *testing.T) {
 	assert.Equal(t, "Under path : /logs/logs_collected/files/collect_list/encoding | Error : Encoding xxx is an invalid value.", translator.ErrorMessages[0])
 }
 
+func TestDefaults(t *testing.T) {
+	logs.GlobalLogConfig.Defaults = map[string]interface{}{
+		"timestamp_format": "%H:%M:%S %y %b %d",
+		"timezone":         "UTC",
+		"encoding":         "gbk",
+	}
+	defer func() { logs.GlobalLogConfig.Defaults = nil }()
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"path1"
+			},
+			{
+				"file_path":"path2",
+				"timezone":"Local",
+				"encoding":"utf-8"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":        "path1",
+		"from_beginning":   true,
+		"pipe":             false,
+		"timestamp_layout": "15:04:05 06 Jan 02",
+		"timestamp_regex":  "(\\d{2}:\\d{2}:\\d{2} \\d{2} \\w{3} \\d{2})",
+		"timezone":         "UTC",
+		"encoding":         "gbk",
+	}, map[string]interface{}{
+		"file_path":        "path2",
+		"from_beginning":   true,
+		"pipe":             false,
+		"timestamp_layout": "15:04:05 06 Jan 02",
+		"timestamp_regex":  "(\\d{2}:\\d{2}:\\d{2} \\d{2} \\w{3} \\d{2})",
+		"timezone":         "LOCAL",
+		"encoding":         "utf-8",
+	}}
+	assert.Equal(t, expectVal, val)
+	assert.NotContains(t, input.(map[string]interface{})["collect_list"].([]interface{})[0], "timezone")
+}
+
+func TestFileFilters(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app/*",
+				"blacklist":"\\.gz$",
+				"include":["app-*.log", "/var/log/app/audit.*"],
+				"exclude":["*.tmp"],
+				"max_age":604800
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/app/*",
+		"from_beginning": true,
+		"pipe":           false,
+		"blacklist":      "\\.gz$",
+		"include":        []string{"app-*.log", "/var/log/app/audit.*"},
+		"exclude":        []string{"*.tmp"},
+		"max_age":        "604800s",
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
+func TestMetricRules(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app.log",
+				"metric_rules":[
+					{"metric_name":"ErrorCount", "pattern":"ERROR"},
+					{
+						"metric_name":"Latency",
+						"pattern":"status=(?P<status>\\d+) latency=(?P<latency>\\d+)ms",
+						"value_group":"latency",
+						"dimensions":["status"],
+						"unit":"Milliseconds"
+					}
+				]
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/app.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"metric_rule": []interface{}{
+			map[string]interface{}{"metric_name": "ErrorCount", "pattern": "ERROR"},
+			map[string]interface{}{
+				"metric_name": "Latency",
+				"pattern":     "status=(?P<status>\\d+) latency=(?P<latency>\\d+)ms",
+				"value_group": "latency",
+				"dimensions":  []string{"status"},
+				"unit":        "Milliseconds",
+			},
+		},
+	}}
+	assert.Equal(t, expectVal, val)
+
+	translator.ResetMessages()
+	e = json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app.log",
+				"metric_rules":[{"metric_name":"Latency", "pattern":"latency=(\\d+)ms", "value_group":"latency"}]
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	f.ApplyRule(input)
+	assert.Len(t, translator.ErrorMessages, 1)
+	translator.ResetMessages()
+}
+
+func TestReplicas(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app.log",
+				"replicas":[
+					{"log_group_name":"ops"},
+					{
+						"log_group_name":"errors",
+						"log_stream_name":"pager",
+						"destination":"cloudwatchlogs",
+						"filters":[
+							{"type":"include", "expression":"ERROR|FATAL"},
+							{"type":"replace", "expression":"password=\\S+", "replacement":"password=***"}
+						]
+					}
+				]
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/app.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"replica": []interface{}{
+			map[string]interface{}{"log_group_name": "ops"},
+			map[string]interface{}{
+				"log_group_name":  "errors",
+				"log_stream_name": "pager",
+				"destination":     "cloudwatchlogs",
+				"filters": []interface{}{
+					map[string]interface{}{"type": "include", "expression": "ERROR|FATAL"},
+					map[string]interface{}{"type": "replace", "expression": "password=\\S+", "replacement": "password=***"},
+				},
+			},
+		},
+	}}
+	assert.Equal(t, expectVal, val)
+
+	translator.ResetMessages()
+	e = json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app.log",
+				"replicas":[{"log_group_name":"errors", "destination":"kinesislogs", "filters":[{"type":"include", "expression":"(ERROR"}]}]
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	f.ApplyRule(input)
+	assert.Len(t, translator.ErrorMessages, 2)
+	translator.ResetMessages()
+}
+
+func TestEventFormat(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app.log",
+				"event_format":"json_envelope",
+				"event_fields":{"service":"checkout", "env":"prod"}
+			},
+			{
+				"file_path":"/var/log/other.log",
+				"event_format":"text"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/app.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"event_format":   "json_envelope",
+		"event_fields":   map[string]interface{}{"service": "checkout", "env": "prod"},
+	}, map[string]interface{}{
+		"file_path":      "/var/log/other.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"event_format":   "text",
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
+func TestPriority(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/audit/audit.log",
+				"priority":"critical"
+			},
+			{
+				"file_path":"/var/log/app.log"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/audit/audit.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"priority":       "critical",
+	}, map[string]interface{}{
+		"file_path":      "/var/log/app.log",
+		"from_beginning": true,
+		"pipe":           false,
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
+func TestSequenceField(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/app.log",
+				"event_format":"json_envelope",
+				"sequence_field":"ingest_sequence"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/app.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"event_format":   "json_envelope",
+		"sequence_field": "ingest_sequence",
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
+func TestSystemdUnits(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"systemd_units":["nginx*", "php-fpm.service"],
+				"log_stream_name":"host"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	translator.ResetMessages()
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"systemd_units":   []string{"nginx*", "php-fpm.service"},
+		"log_stream_name": "host",
+		"from_beginning":  true,
+		"pipe":            false,
+	}}
+	assert.Equal(t, expectVal, val)
+	assert.Empty(t, translator.ErrorMessages)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/audit/audit.log",
+				"idle_timeout":900,
+				"idle_message":"IDLE audit.log"
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "/var/log/audit/audit.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"idle_timeout":   "900s",
+		"idle_message":   "IDLE audit.log",
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
 func TestAutoRemoval(t *testing.T) {
 	f := new(FileConfig)
 	var input interface{}
@@ -467,3 +873,105 @@ func TestPublishMultiLogs_WithBlackList(t *testing.T) {
 	}}
 	assert.Equal(t, expectVal, val)
 }
+
+func TestParser(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"C:\\inetpub\\logs\\LogFiles\\W3SVC1\\*.log",
+				"parser":{
+					"type":"w3c",
+					"column_types":{"sc-status":"integer", "time-taken":"integer"}
+				}
+			},
+			{
+				"file_path":"/var/log/export.csv",
+				"parser":{
+					"type":"csv",
+					"delimiter":";",
+					"columns":["when", "user"],
+					"timestamp_columns":["when"],
+					"timestamp_format":"%d/%m/%Y %H:%M:%S"
+				}
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	expectVal := []interface{}{map[string]interface{}{
+		"file_path":      "C:\\inetpub\\logs\\LogFiles\\W3SVC1\\*.log",
+		"from_beginning": true,
+		"pipe":           false,
+		"parser": map[string]interface{}{
+			"type":         "w3c",
+			"column_types": map[string]interface{}{"sc-status": "integer", "time-taken": "integer"},
+		},
+	}, map[string]interface{}{
+		"file_path":      "/var/log/export.csv",
+		"from_beginning": true,
+		"pipe":           false,
+		"parser": map[string]interface{}{
+			"type":              "csv",
+			"delimiter":         ";",
+			"columns":           []interface{}{"when", "user"},
+			"timestamp_columns": []interface{}{"when"},
+			"timestamp_layout":  "02/01/2006 15:04:05",
+		},
+	}}
+	assert.Equal(t, expectVal, val)
+}
+
+func TestAccessLog(t *testing.T) {
+	f := new(FileConfig)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"collect_list":[
+			{
+				"file_path":"/var/log/nginx/access.log",
+				"access_log":{"latency_unit":"seconds", "metric_name_prefix":"nginx_"},
+				"metric_rules":[{"metric_name":"Bots", "pattern":"Googlebot"}]
+			}
+		]
+	}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, val := f.ApplyRule(input)
+	config := val.([]interface{})[0].(map[string]interface{})
+
+	parser := config["parser"].(map[string]interface{})
+	assert.Equal(t, "regex", parser["type"])
+	assert.Equal(t, []interface{}{"time_local"}, parser["timestamp_columns"])
+	assert.Equal(t, map[string]interface{}{"status": "integer", "bytes": "integer", "request_time": "float"}, parser["column_types"])
+	line := `10.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a\"b HTTP/1.1" 503 512 "-" "curl/7.68.0" 0.125`
+	groups := regexp.MustCompile(parser["pattern"].(string)).FindStringSubmatch(line)
+	if assert.NotNil(t, groups) {
+		assert.Equal(t, []string{line, "10.0.0.1", "-", "-", "10/Oct/2000:13:55:36 -0700", "GET", `/a\"b`, "HTTP/1.1", "", "503", "512", "-", "curl/7.68.0", "0.125"}, groups)
+	}
+
+	var matched []string
+	for _, r := range config["metric_rule"].([]interface{}) {
+		rule := r.(map[string]interface{})
+		if regexp.MustCompile(rule["pattern"].(string)).MatchString(line) {
+			matched = append(matched, rule["metric_name"].(string))
+		}
+	}
+	assert.Equal(t, []string{"nginx_Requests", "nginx_Status5xx", "nginx_Latency"}, matched)
+	latency := config["metric_rule"].([]interface{})[5].(map[string]interface{})
+	assert.Equal(t, "latency", latency["value_group"])
+	assert.Equal(t, "Seconds", latency["unit"])
+	assert.Equal(t, "Bots", config["metric_rule"].([]interface{})[6].(map[string]interface{})["metric_name"])
+
+	// the common format without the request time
+	e = json.Unmarshal([]byte(`{"collect_list":[{"file_path":"/var/log/httpd/access_log","access_log":{"format":"common"}}]}`), &input)
+	assert.NoError(t, e)
+	_, val = f.ApplyRule(input)
+	config = val.([]interface{})[0].(map[string]interface{})
+	assert.Len(t, config["metric_rule"], 5)
+	pattern := regexp.MustCompile(config["parser"].(map[string]interface{})["pattern"].(string))
+	assert.True(t, pattern.MatchString(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`))
+}
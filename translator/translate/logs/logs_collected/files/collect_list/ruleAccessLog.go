@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"fmt"
+)
+
+const (
+	AccessLogSectionKey = "access_log"
+
+	accessLogFormatCommon   = "common"
+	accessLogFormatCombined = "combined"
+
+	// a quoted field of the access logs, Apache escapes the quotes as \" and Nginx as \x22
+	accessLogQuoted     = `(?:[^"\\]|\\.)*`
+	accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+var accessLogLatencyUnits = map[string]string{
+	"seconds":      "Seconds",
+	"milliseconds": "Milliseconds",
+	"microseconds": "Microseconds",
+}
+
+// The access_log preset of a file parses the entries of the common or combined log format of Apache and Nginx and
+// emits the request count, the counts of the status classes and, when the request time is appended to the format,
+// the latency distribution. It translates to the parser and the metric rules of the file.
+func accessLog(m map[string]interface{}) (format, latencyUnit, prefix string, ok bool) {
+	val, ok := m[AccessLogSectionKey]
+	if !ok {
+		return
+	}
+	al, ok := val.(map[string]interface{})
+	if !ok {
+		// rejected by the schema
+		return
+	}
+	format, _ = al["format"].(string)
+	if format == "" {
+		format = accessLogFormatCombined
+	}
+	latencyUnit, _ = al["latency_unit"].(string)
+	prefix, _ = al["metric_name_prefix"].(string)
+	return format, latencyUnit, prefix, true
+}
+
+func accessLogParser(format, latencyUnit string) map[string]interface{} {
+	pattern := `^(?P<remote_addr>\S+) (?P<ident>\S+) (?P<remote_user>\S+) \[(?P<time_local>[^\]]+)\] ` +
+		`"(?:(?P<method>[A-Z]+) (?P<path>\S+) (?P<protocol>[^"]*)|(?P<request>` + accessLogQuoted + `))" ` +
+		`(?P<status>\d{3}) (?P<bytes>\S+)`
+	columnTypes := map[string]interface{}{"status": "integer", "bytes": "integer"}
+	if format == accessLogFormatCombined {
+		pattern += ` "(?P<referer>` + accessLogQuoted + `)" "(?P<user_agent>` + accessLogQuoted + `)"`
+	}
+	if latencyUnit != "" {
+		pattern += ` (?P<request_time>\d+(?:\.\d+)?)`
+		columnTypes["request_time"] = "float"
+	}
+	return map[string]interface{}{
+		"type":              "regex",
+		"pattern":           pattern,
+		"column_types":      columnTypes,
+		"timestamp_columns": []interface{}{"time_local"},
+		"timestamp_layout":  accessLogTimeLayout,
+		"null_value":        "-",
+	}
+}
+
+func accessLogMetricRules(format, latencyUnit, prefix string) []interface{} {
+	request := `^\S+ \S+ \S+ \[[^\]]+\] "` + accessLogQuoted + `" `
+	rules := []interface{}{
+		map[string]interface{}{"metric_name": prefix + "Requests", "pattern": request + `\d{3}\b`},
+	}
+	for class := 2; class <= 5; class++ {
+		rules = append(rules, map[string]interface{}{
+			"metric_name": fmt.Sprintf("%sStatus%dxx", prefix, class),
+			"pattern":     fmt.Sprintf(`%s%d\d\d\b`, request, class),
+		})
+	}
+	if latencyUnit != "" {
+		pattern := request + `\d{3} \S+`
+		if format == accessLogFormatCombined {
+			pattern += ` "` + accessLogQuoted + `" "` + accessLogQuoted + `"`
+		}
+		rules = append(rules, map[string]interface{}{
+			"metric_name": prefix + "Latency",
+			"pattern":     pattern + ` (?P<latency>\d+(?:\.\d+)?)`,
+			"value_group": "latency",
+			"unit":        accessLogLatencyUnits[latencyUnit],
+		})
+	}
+	return rules
+}
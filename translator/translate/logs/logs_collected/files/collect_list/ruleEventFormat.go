@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	EventFormatSectionKey = "event_format"
+	EventFieldsSectionKey = "event_fields"
+)
+
+// EventFormat wraps the log entries in JSON envelopes for Logs Insights when it is json_envelope
+type EventFormat struct {
+}
+
+func (e *EventFormat) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase(EventFormatSectionKey, "", input)
+	if val == "" {
+		return
+	}
+	return key, val
+}
+
+// EventFields are the static fields of the JSON envelopes
+type EventFields struct {
+}
+
+func (e *EventFields) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	fields, ok := im[EventFieldsSectionKey].(map[string]interface{})
+	if !ok || len(fields) == 0 {
+		return
+	}
+	result := map[string]interface{}{}
+	for k, v := range fields {
+		result[k] = v
+	}
+	return EventFieldsSectionKey, result
+}
+
+func init() {
+	RegisterRule(EventFormatSectionKey, []Rule{new(EventFormat)})
+	RegisterRule(EventFieldsSectionKey, []Rule{new(EventFields)})
+}
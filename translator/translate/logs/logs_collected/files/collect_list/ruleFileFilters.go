@@ -0,0 +1,43 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	IncludeSectionKey = "include"
+	ExcludeSectionKey = "exclude"
+	MaxAgeSectionKey  = "max_age"
+)
+
+// FilePatterns translates the include or exclude glob patterns of the files matched by file_path
+type FilePatterns struct {
+	key string
+}
+
+func (f *FilePatterns) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if _, ok := m[f.key]; !ok {
+		return
+	}
+	return translator.DefaultStringArrayCase(f.key, nil, input)
+}
+
+type MaxAge struct {
+}
+
+func (r *MaxAge) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if _, ok := m[MaxAgeSectionKey]; !ok {
+		return
+	}
+	return translator.DefaultTimeIntervalCase(MaxAgeSectionKey, float64(0), input)
+}
+
+func init() {
+	r := []Rule{&FilePatterns{key: IncludeSectionKey}, &FilePatterns{key: ExcludeSectionKey}, new(MaxAge)}
+	RegisterRule("file_filters", r)
+}
@@ -12,7 +12,13 @@ type FilePath struct {
 }
 
 func (f *FilePath) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
-	//Should be mandatory case
+	//Should be mandatory case, unless the logs of systemd units are collected
+	m := input.(map[string]interface{})
+	if _, ok := m[SystemdUnitsSectionKey]; ok {
+		if _, ok := m["file_path"]; !ok {
+			return
+		}
+	}
 	if translator.IsValid(input, "file_path", GetCurPath()+"file_path"+strconv.Itoa(Index)) {
 		returnKey, returnVal = translator.DefaultCase("file_path", "", input)
 	} else {
@@ -0,0 +1,41 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	IdleTimeoutSectionKey = "idle_timeout"
+	IdleMessageSectionKey = "idle_message"
+)
+
+// IdleTimeout publishes a marker log event every idle_timeout seconds while the file produces no log entries
+type IdleTimeout struct {
+}
+
+func (r *IdleTimeout) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if _, ok := m[IdleTimeoutSectionKey]; !ok {
+		return
+	}
+	return translator.DefaultTimeIntervalCase(IdleTimeoutSectionKey, float64(0), input)
+}
+
+// IdleMessage is the message of the idle marker log events
+type IdleMessage struct {
+}
+
+func (r *IdleMessage) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase(IdleMessageSectionKey, "", input)
+	if val == "" {
+		return
+	}
+	return key, val
+}
+
+func init() {
+	RegisterRule(IdleTimeoutSectionKey, []Rule{new(IdleTimeout), new(IdleMessage)})
+}
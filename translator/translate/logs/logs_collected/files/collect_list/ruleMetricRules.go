@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const MetricRulesSectionKey = "metric_rules"
+
+// MetricRules translates the rules emitting metrics from the log entries of the file, they are published by the
+// cloudwatch output of the metrics section
+type MetricRules struct {
+}
+
+func (m *MetricRules) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	rules, ok := im[MetricRulesSectionKey].([]interface{})
+	format, latencyUnit, prefix, isAccessLog := accessLog(im)
+	if !ok && !isAccessLog {
+		return
+	}
+	result := []interface{}{}
+	if isAccessLog {
+		result = append(result, accessLogMetricRules(format, latencyUnit, prefix)...)
+	}
+	for _, r := range rules {
+		rule := r.(map[string]interface{})
+		pattern, _ := rule["pattern"].(string)
+		patternP, err := regexp.Compile(pattern)
+		if err != nil {
+			translator.AddErrorMessages(GetCurPath()+MetricRulesSectionKey, fmt.Sprintf("Pattern %v is an invalid regex: %v", pattern, err))
+			continue
+		}
+		groups := map[string]bool{}
+		for _, name := range patternP.SubexpNames() {
+			groups[name] = true
+		}
+		translated := map[string]interface{}{
+			"metric_name": rule["metric_name"],
+			"pattern":     pattern,
+		}
+		if valueGroup, ok := rule["value_group"].(string); ok {
+			if !groups[valueGroup] {
+				translator.AddErrorMessages(GetCurPath()+MetricRulesSectionKey, fmt.Sprintf("Pattern %v has no group named %v.", pattern, valueGroup))
+			}
+			translated["value_group"] = valueGroup
+		}
+		if _, ok := rule["dimensions"]; ok {
+			_, dimensions := translator.DefaultStringArrayCase("dimensions", nil, rule)
+			dims, _ := dimensions.([]string)
+			for _, dim := range dims {
+				if !groups[dim] {
+					translator.AddErrorMessages(GetCurPath()+MetricRulesSectionKey, fmt.Sprintf("Pattern %v has no group named %v.", pattern, dim))
+				}
+			}
+			translated["dimensions"] = dimensions
+		}
+		if unit, ok := rule["unit"]; ok {
+			translated["unit"] = unit
+		}
+		result = append(result, translated)
+	}
+	return "metric_rule", result
+}
+
+func init() {
+	RegisterRule(MetricRulesSectionKey, []Rule{new(MetricRules)})
+}
@@ -0,0 +1,48 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const ParserSectionKey = "parser"
+
+var parserTargetList = []string{"type", "pattern", "delimiter", "columns", "column_types", "timestamp_columns", "null_value"}
+
+// Parser publishes the delimiter-separated and the W3C log entries as JSON objects of typed fields, the
+// timestamp_format of the timestamp_columns is translated to the Go layout
+type Parser struct {
+}
+
+func (p *Parser) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	val, ok := im[ParserSectionKey]
+	if format, latencyUnit, _, isAccessLog := accessLog(im); isAccessLog {
+		if ok {
+			translator.AddErrorMessages(GetCurPath()+AccessLogSectionKey, "The access_log preset has its own parser, it cannot be set with a parser")
+			return
+		}
+		return ParserSectionKey, accessLogParser(format, latencyUnit)
+	}
+	if !ok {
+		return
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		translator.AddErrorMessages(GetCurPath()+ParserSectionKey, "Invalid format, expected an object")
+		return
+	}
+	parser := map[string]interface{}{}
+	util.SetWithSameKeyIfFound(m, parserTargetList, parser)
+	if format, ok := m[TimestampFormatSectionKey].(string); ok && format != "" {
+		parser["timestamp_layout"] = timestampLayout(format)
+	}
+	return ParserSectionKey, parser
+}
+
+func init() {
+	RegisterRule(ParserSectionKey, []Rule{new(Parser)})
+}
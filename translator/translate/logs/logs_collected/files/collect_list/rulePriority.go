@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const PrioritySectionKey = "priority"
+
+// Priority is the priority class of the log events when cloudwatchlogs is under memory pressure
+type Priority struct {
+}
+
+func (p *Priority) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase(PrioritySectionKey, "", input)
+	if val == "" {
+		return
+	}
+	return key, val
+}
+
+func init() {
+	RegisterRule(PrioritySectionKey, []Rule{new(Priority)})
+}
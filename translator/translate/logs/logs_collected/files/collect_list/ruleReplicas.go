@@ -0,0 +1,81 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+const ReplicasSectionKey = "replicas"
+
+// Replicas translates the copies of the log events of the file sent to other log groups, each through its own
+// filters
+type Replicas struct {
+}
+
+func (r *Replicas) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	replicas, ok := im[ReplicasSectionKey].([]interface{})
+	if !ok {
+		return
+	}
+	result := []interface{}{}
+	for _, rep := range replicas {
+		replica := rep.(map[string]interface{})
+		group, _ := replica["log_group_name"].(string)
+		translated := map[string]interface{}{
+			"log_group_name": util.ResolvePlaceholder(group, logs.GlobalLogConfig.MetadataInfo),
+		}
+		if stream, ok := replica["log_stream_name"].(string); ok {
+			translated["log_stream_name"] = util.ResolvePlaceholder(stream, logs.GlobalLogConfig.MetadataInfo)
+		}
+		if destination, ok := replica["destination"].(string); ok {
+			if !isLogDestination(destination) {
+				translator.AddErrorMessages(GetCurPath()+ReplicasSectionKey, fmt.Sprintf("Destination %v of the replica %v is not an output of the logs section: %v.", destination, group, logs.GlobalLogConfig.Destination))
+			}
+			translated["destination"] = destination
+		}
+		if filters, ok := replica["filters"].([]interface{}); ok {
+			translatedFilters := []interface{}{}
+			for _, f := range filters {
+				filter := f.(map[string]interface{})
+				expression, _ := filter["expression"].(string)
+				if _, err := regexp.Compile(expression); err != nil {
+					translator.AddErrorMessages(GetCurPath()+ReplicasSectionKey, fmt.Sprintf("Expression %v is an invalid regex: %v", expression, err))
+					continue
+				}
+				translatedFilter := map[string]interface{}{
+					"type":       filter["type"],
+					"expression": expression,
+				}
+				if replacement, ok := filter["replacement"]; ok {
+					translatedFilter["replacement"] = replacement
+				}
+				translatedFilters = append(translatedFilters, translatedFilter)
+			}
+			translated["filters"] = translatedFilters
+		}
+		result = append(result, translated)
+	}
+	return "replica", result
+}
+
+func isLogDestination(destination string) bool {
+	for _, d := range strings.Split(logs.GlobalLogConfig.Destination, ",") {
+		if d == destination {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	RegisterRule(ReplicasSectionKey, []Rule{new(Replicas)})
+}
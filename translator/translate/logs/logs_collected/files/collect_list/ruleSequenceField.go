@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const SequenceFieldSectionKey = "sequence_field"
+
+// SequenceField is the field of the sequence number of the structured log events in their log stream
+type SequenceField struct {
+}
+
+func (s *SequenceField) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase(SequenceFieldSectionKey, "", input)
+	if val == "" {
+		return
+	}
+	return key, val
+}
+
+func init() {
+	RegisterRule(SequenceFieldSectionKey, []Rule{new(SequenceField)})
+}
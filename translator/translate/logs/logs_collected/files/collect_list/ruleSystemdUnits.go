@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collect_list
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const SystemdUnitsSectionKey = "systemd_units"
+
+// SystemdUnits translates the glob patterns of the systemd units whose files and journal streams are collected
+type SystemdUnits struct {
+}
+
+func (s *SystemdUnits) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if _, ok := m[SystemdUnitsSectionKey]; !ok {
+		return
+	}
+	return translator.DefaultStringArrayCase(SystemdUnitsSectionKey, nil, input)
+}
+
+func init() {
+	RegisterRule(SystemdUnitsSectionKey, []Rule{new(SystemdUnits)})
+}
@@ -4,7 +4,16 @@
 package collect_list
 
 import (
+	"fmt"
 	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/internal/timezone"
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	TimestampFormatSectionKey          = "timestamp_format"
+	TimestampFallbackToMtimeSectionKey = "timestamp_fallback_to_mtime"
 )
 
 /*
@@ -95,7 +104,7 @@ var TimeFormatRexMap = map[string]string{
 	"%y":  "\\d{2}",
 	"%p":  "\\w{2}",
 	"%Z":  "\\w{3}",
-	"%z":  "[\\+-]\\d{4}",
+	"%z":  "(?:Z|[\\+-]\\d{2}:?\\d{2})",
 	"%f":  "(\\d{1,9})",
 }
 
@@ -132,6 +141,34 @@ func checkAndReplace(input string, timestampFormatMap map[string]string) string
 	return res
 }
 
+// timestampFormats returns the timestamp formats of the entry, timestamp_format is either a format or a list of
+// formats tried in order, the schema ensures they are strings
+func timestampFormats(m map[string]interface{}) []string {
+	switch val := m[TimestampFormatSectionKey].(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		formats := []string{}
+		for _, format := range val {
+			if format, ok := format.(string); ok {
+				formats = append(formats, format)
+			}
+		}
+		return formats
+	}
+	return nil
+}
+
+func timestampRegex(format string) string {
+	res := checkAndReplace(format, TimeFormatRegexEscapeMap)
+	res = checkAndReplace(res, TimeFormatRexMap)
+	return "(" + res + ")"
+}
+
+func timestampLayout(format string) string {
+	return checkAndReplace(format, TimeFormatMap)
+}
+
 type TimestampRegax struct {
 }
 
@@ -139,16 +176,13 @@ func (t *TimestampRegax) ApplyRule(input interface{}) (returnKey string, returnV
 	//Convert the input string into []rune and iterate the map and build the output []rune
 	m := input.(map[string]interface{})
 	//If user not specify the timestamp_format, then no config entry for "timestamp_layout" in TOML
-	if val, ok := m["timestamp_format"]; !ok {
+	if formats := timestampFormats(m); len(formats) == 0 {
 		returnKey = ""
 		returnVal = ""
 	} else {
 		//If user provide with the specific timestamp_format, use the one that user provide
-		res := checkAndReplace(val.(string), TimeFormatRegexEscapeMap)
-		res = checkAndReplace(res, TimeFormatRexMap)
-		res = "(" + res + ")"
 		returnKey = "timestamp_regex"
-		returnVal = res
+		returnVal = timestampRegex(formats[0])
 	}
 	return
 }
@@ -160,18 +194,59 @@ func (t *TimestampLayout) ApplyRule(input interface{}) (returnKey string, return
 	//Convert the input string into []rune and iterate the map and build the output []rune
 	m := input.(map[string]interface{})
 	//If user not specify the timestamp_format, then no config entry for "timestamp_layout" in TOML
-	if val, ok := m["timestamp_format"]; !ok {
+	if formats := timestampFormats(m); len(formats) == 0 {
 		returnKey = ""
 		returnVal = ""
 	} else {
-		res := checkAndReplace(val.(string), TimeFormatMap)
 		//If user provide with the specific timestamp_format, use the one that user provide
 		returnKey = "timestamp_layout"
-		returnVal = res
+		returnVal = timestampLayout(formats[0])
 	}
 	return
 }
 
+// TimestampRegexes translates the formats after the first one of a list of timestamp formats
+type TimestampRegexes struct {
+}
+
+func (t *TimestampRegexes) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	formats := timestampFormats(input.(map[string]interface{}))
+	if len(formats) < 2 {
+		return
+	}
+	regexes := []interface{}{}
+	for _, format := range formats[1:] {
+		regexes = append(regexes, timestampRegex(format))
+	}
+	return "timestamp_regexes", regexes
+}
+
+type TimestampLayouts struct {
+}
+
+func (t *TimestampLayouts) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	formats := timestampFormats(input.(map[string]interface{}))
+	if len(formats) < 2 {
+		return
+	}
+	layouts := []interface{}{}
+	for _, format := range formats[1:] {
+		layouts = append(layouts, timestampLayout(format))
+	}
+	return "timestamp_layouts", layouts
+}
+
+type TimestampFallbackToMtime struct {
+}
+
+func (t *TimestampFallbackToMtime) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, returnVal = translator.DefaultCase(TimestampFallbackToMtimeSectionKey, false, input)
+	if fallback, ok := returnVal.(bool); ok && fallback {
+		return TimestampFallbackToMtimeSectionKey, true
+	}
+	return "", ""
+}
+
 type Timezone struct {
 }
 
@@ -183,10 +258,10 @@ func (t *Timezone) ApplyRule(input interface{}) (returnKey string, returnVal int
 	} else {
 		//If user provide with the specific timestamp_format, use the one that user provide
 		returnKey = "timezone"
-		if val == "UTC" {
-			returnVal = "UTC"
-		} else {
-			returnVal = "LOCAL"
+		name, _ := val.(string)
+		returnVal = timezone.Normalize(name)
+		if _, err := timezone.LoadLocation(name); err != nil {
+			translator.AddErrorMessages(GetCurPath()+"timezone", fmt.Sprintf("Timezone %s is an invalid value.", name))
 		}
 	}
 	return
@@ -195,6 +270,9 @@ func init() {
 	t1 := new(TimestampLayout)
 	t2 := new(TimestampRegax)
 	t3 := new(Timezone)
-	r := []Rule{t1, t2, t3}
-	RegisterRule("timestamp_format", r)
+	t4 := new(TimestampLayouts)
+	t5 := new(TimestampRegexes)
+	t6 := new(TimestampFallbackToMtime)
+	r := []Rule{t1, t2, t3, t4, t5, t6}
+	RegisterRule(TimestampFormatSectionKey, r)
 }
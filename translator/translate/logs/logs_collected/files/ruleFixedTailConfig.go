@@ -3,12 +3,16 @@
 
 package files
 
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+)
+
 type FixedTailConfig struct {
 }
 
 func (f *FixedTailConfig) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
 	rm := map[string]interface{}{
-		"destination": "cloudwatchlogs",
+		"destination": logs.GlobalLogConfig.Destination,
 	}
 	return "fixedTailConfig", rm
 }
@@ -62,10 +62,13 @@ func (l *LogsCollected) ApplyRule(input interface{}) (returnKey string, returnVa
 		returnKey = ""
 		returnVal = ""
 	} else {
-		for _, rule := range targetRuleMap {
+		for ruleName, rule := range targetRuleMap {
 			key, val := rule.ApplyRule(im[SectionKey])
 			if key == "inputs" {
 				result = translator.MergeTwoUniqueMaps(result, val.(map[string]interface{}))
+				for k := range val.(map[string]interface{}) {
+					translator.AddProvenance("inputs."+k, translator.JsonPath(GetCurPath()+ruleName))
+				}
 			}
 		}
 		returnKey = "inputs"
@@ -32,6 +32,18 @@ func TestApplyRule(t *testing.T) {
         ],
         "event_format": "xml",
         "log_group_name": "Application"
+      },
+      {
+        "event_name": "Security",
+        "event_levels": [
+          "INFORMATION"
+        ],
+        "event_format": "json",
+        "event_field_mapping": {
+          "EventID": "event_id",
+          "Computer": ""
+        },
+        "log_group_name": "Security"
       }
     ]
 }
@@ -52,6 +64,14 @@ func TestApplyRule(t *testing.T) {
 			"log_group_name":  "Application",
 			"batch_read_size": BatchReadSizeValue,
 		},
+		map[string]interface{}{
+			"event_name":          "Security",
+			"event_levels":        []interface{}{"4", "0"},
+			"event_format":        "json",
+			"event_field_mapping": map[string]interface{}{"EventID": "event_id", "Computer": ""},
+			"log_group_name":      "Security",
+			"batch_read_size":     BatchReadSizeValue,
+		},
 	}
 
 	var actual interface{}
@@ -0,0 +1,31 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collectlist
+
+import (
+	"fmt"
+)
+
+const EventFieldMappingSectionKey = "event_field_mapping"
+
+type EventFieldMapping struct {
+}
+
+// ApplyRule passes the renaming of the fields of the json format through, a field renamed to "" is removed
+func (r *EventFieldMapping) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	mapping, ok := m[EventFieldMappingSectionKey].(map[string]interface{})
+	if !ok || len(mapping) == 0 {
+		return
+	}
+	result := map[string]interface{}{}
+	for field, name := range mapping {
+		result[field] = fmt.Sprint(name)
+	}
+	return EventFieldMappingSectionKey, result
+}
+
+func init() {
+	RegisterRule(EventFieldMappingSectionKey, new(EventFieldMapping))
+}
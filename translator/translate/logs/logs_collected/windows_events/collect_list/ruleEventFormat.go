@@ -13,6 +13,7 @@ const (
 
 	EventFormatXML       = "xml"  //xml format in windows event viewer
 	EVentFormatPlainText = "text" //old ssm agent format
+	EventFormatJSON      = "json" //structured json object
 )
 
 type EventFormat struct {
@@ -23,7 +24,7 @@ func (r *EventFormat) ApplyRule(input interface{}) (returnKey string, returnVal
 	if returnVal == "" {
 		return
 	}
-	if returnVal != EventFormatXML && returnVal != EVentFormatPlainText {
+	if returnVal != EventFormatXML && returnVal != EVentFormatPlainText && returnVal != EventFormatJSON {
 		translator.AddErrorMessages(GetCurPath()+EventFormatSectionKey, fmt.Sprintf("event_format value %s is not a valid value.", returnVal))
 		return
 	}
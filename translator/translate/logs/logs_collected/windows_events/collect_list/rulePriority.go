@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package collectlist
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const PrioritySectionKey = "priority"
+
+type Priority struct {
+}
+
+func (r *Priority) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, returnVal = translator.DefaultCase(PrioritySectionKey, "", input)
+	if returnVal == "" {
+		return
+	}
+	returnKey = PrioritySectionKey
+	return
+}
+
+func init() {
+	RegisterRule(PrioritySectionKey, new(Priority))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonRule"
 	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonUtil"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
 	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/logs/logs_collected"
 )
 
@@ -28,7 +29,7 @@ func RegisterRule(ruleName string, r translator.Rule) {
 func (w *WindowsEvent) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
 	im := input.(map[string]interface{})
 	windowsEventConfig := map[string]interface{}{
-		"destination": "cloudwatchlogs",
+		"destination": logs.GlobalLogConfig.Destination,
 	}
 
 	if _, ok := im[SectionKey]; ok {
@@ -197,3 +197,545 @@ func TestLogs_EndpointOverride(t *testing.T) {
 
 	ctx.SetMode(config.ModeEC2) //reset back to default mode
 }
+
+func TestLogs_S3Archive(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"log_stream_name":"LOG_STREAM_NAME","s3_archive":{"bucket":"log-archive","key_prefix":"cwagent","force_flush_interval":600}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"log_stream_name":      "LOG_STREAM_NAME",
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+			"s3logs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"bucket":               "log-archive",
+					"key_prefix":           "cwagent",
+					"log_stream_name":      "LOG_STREAM_NAME",
+					"force_flush_interval": "600s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, actual, "Expected to be equal")
+	assert.Equal(t, "cloudwatchlogs,s3logs", GlobalLogConfig.Destination)
+
+	e = json.Unmarshal([]byte(`{"logs":{"s3_archive":{"bucket":"log-archive","archive_only":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	l.ApplyRule(input)
+	assert.Equal(t, "s3logs", GlobalLogConfig.Destination)
+
+	e = json.Unmarshal([]byte(`{"logs":{"s3_archive":{"bucket":"log-archive","compression":"zstd","compression_level":6}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, actual = l.ApplyRule(input)
+	s3logs := actual.(map[string]interface{})["outputs"].(map[string]interface{})["s3logs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "zstd", s3logs["compression"])
+	assert.Equal(t, 6, s3logs["compression_level"])
+
+	e = json.Unmarshal([]byte(`{"logs":{}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	l.ApplyRule(input)
+	assert.Equal(t, "cloudwatchlogs", GlobalLogConfig.Destination)
+}
+
+func TestLogs_Kinesis(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"log_stream_name":"LOG_STREAM_NAME","kinesis":{"stream_name":"central-logs","partition_key":"{hostname}","join_records":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	_, actual := l.ApplyRule(input)
+	expected := []interface{}{
+		map[string]interface{}{
+			"region":               "us-east-1",
+			"stream_name":          "central-logs",
+			"partition_key":        "{hostname}",
+			"join_records":         true,
+			"log_stream_name":      "LOG_STREAM_NAME",
+			"force_flush_interval": "5s",
+			"tagexclude":           []string{"metricPath"},
+			"tagpass":              map[string][]string{"metricPath": {"logs"}},
+		},
+	}
+	assert.Equal(t, expected, actual.(map[string]interface{})["outputs"].(map[string]interface{})["kinesislogs"])
+	assert.Equal(t, "cloudwatchlogs,kinesislogs", GlobalLogConfig.Destination)
+
+	e = json.Unmarshal([]byte(`{"logs":{"s3_archive":{"bucket":"log-archive"},"kinesis":{"delivery_stream_name":"central-logs","kinesis_only":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	l.ApplyRule(input)
+	assert.Equal(t, "s3logs,kinesislogs", GlobalLogConfig.Destination)
+
+	e = json.Unmarshal([]byte(`{"logs":{"kinesis":{"stream_name":"central-logs","kpl_aggregation":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, actual = l.ApplyRule(input)
+	assert.Equal(t, true, actual.(map[string]interface{})["outputs"].(map[string]interface{})["kinesislogs"].([]interface{})[0].(map[string]interface{})["kpl_aggregation"])
+}
+
+func TestLogs_OpenSearch(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"log_stream_name":"LOG_STREAM_NAME","opensearch":{"endpoint":"https://search-logs.us-east-1.es.amazonaws.com","index":"app-{date}","opensearch_only":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	_, actual := l.ApplyRule(input)
+	expected := []interface{}{
+		map[string]interface{}{
+			"region":               "us-east-1",
+			"endpoint":             "https://search-logs.us-east-1.es.amazonaws.com",
+			"index":                "app-{date}",
+			"log_stream_name":      "LOG_STREAM_NAME",
+			"force_flush_interval": "5s",
+			"tagexclude":           []string{"metricPath"},
+			"tagpass":              map[string][]string{"metricPath": {"logs"}},
+		},
+	}
+	assert.Equal(t, expected, actual.(map[string]interface{})["outputs"].(map[string]interface{})["opensearch"])
+	assert.Equal(t, "opensearch", GlobalLogConfig.Destination)
+
+	e = json.Unmarshal([]byte(`{"logs":{"opensearch":{"endpoint":"https://search-logs.us-east-1.es.amazonaws.com","disable_sigv4":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, actual = l.ApplyRule(input)
+	assert.Equal(t, true, actual.(map[string]interface{})["outputs"].(map[string]interface{})["opensearch"].([]interface{})[0].(map[string]interface{})["disable_sigv4"])
+}
+
+func TestLogs_FileOutput(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"log_stream_name":"LOG_STREAM_NAME","file":{"path":"/tmp/logs.out"}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	_, actual := l.ApplyRule(input)
+	outputs := actual.(map[string]interface{})["outputs"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"path":            "/tmp/logs.out",
+			"log_stream_name": "LOG_STREAM_NAME",
+			"tagexclude":      []string{"metricPath"},
+			"tagpass":         map[string][]string{"metricPath": {"logs"}},
+		},
+	}, outputs["file"])
+	assert.Contains(t, outputs, "cloudwatchlogs")
+	assert.Equal(t, "cloudwatchlogs,file", GlobalLogConfig.Destination)
+
+	e = json.Unmarshal([]byte(`{"logs":{"file":{"path":"/tmp/logs.out","file_only":true}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+	_, actual = l.ApplyRule(input)
+	assert.NotContains(t, actual.(map[string]interface{})["outputs"], "cloudwatchlogs")
+	assert.Equal(t, "file", GlobalLogConfig.Destination)
+}
+
+func TestLogs_UseFipsEndpoint(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-gov-west-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"use_fips_endpoint":true}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-gov-west-1",
+					"use_fips_endpoint":    true,
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_MemoryBudget(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"memory_budget_mb":64,"bulk_under_pressure":"sample"}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"memory_budget_mb":     64,
+					"bulk_under_pressure":  "sample",
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_APIRateLimit(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"max_api_tps":2.5,"api_burst":10}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"max_api_tps":          2.5,
+					"api_burst":            10,
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_ReorderWindow(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"reorder_window":2}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"reorder_window":       "2s",
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_SigningAlgorithm(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"signing_algorithm":"sigv4a","signing_region_set":["us-east-1","us-west-2"]}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"signing_algorithm":    "sigv4a",
+					"signing_region_set":   []interface{}{"us-east-1", "us-west-2"},
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_SDKVersion(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"sdk_version":"v2"}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"sdk_version":          "v2",
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_InvalidUTF8(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"invalid_utf8":"base64"}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"invalid_utf8":         "base64",
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_MonotonicTimestamps(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"monotonic_timestamps":true}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":               "us-east-1",
+					"monotonic_timestamps": true,
+					"log_stream_name":      hostname,
+					"force_flush_interval": "5s",
+					"tagexclude":           []string{"metricPath"},
+					"tagpass":              map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_ClockSkewCompensation(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"clock_skew_compensation":true}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"cloudwatchlogs": []interface{}{
+				map[string]interface{}{
+					"region":                  "us-east-1",
+					"clock_skew_compensation": true,
+					"log_stream_name":         hostname,
+					"force_flush_interval":    "5s",
+					"tagexclude":              []string{"metricPath"},
+					"tagpass":                 map[string][]string{"metricPath": {"logs"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual, "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_Inventory(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"inventory":{"log_group_name":"CWAgentInventory","log_stream_name":"{hostname}"}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	ctx := context.CurrentContext()
+	ctx.SetMode(config.ModeOnPrem)
+
+	hostname, _ := os.Hostname()
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"inventory": []interface{}{
+			map[string]interface{}{
+				"destination":      "cloudwatchlogs",
+				"log_group_name":   "CWAgentInventory",
+				"log_stream_name":  hostname,
+				"publish_interval": "3600s",
+				"tags":             map[string]interface{}{"metricPath": "logs"},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual.(map[string]interface{})["inputs"], "Expected to be equal")
+
+	ctx.SetMode(config.ModeEC2) //reset back to default mode
+}
+
+func TestLogs_AgentEvents(t *testing.T) {
+	l := new(Logs)
+	agent.Global_Config.Region = "us-east-1"
+
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs":{"agent_events":{"log_group_name":"CWAgentEvents"}}}`), &input)
+	if e != nil {
+		assert.Fail(t, e.Error())
+	}
+
+	_, actual := l.ApplyRule(input)
+	expected := map[string]interface{}{
+		"agent_events": []interface{}{
+			map[string]interface{}{
+				"destination":    "cloudwatchlogs",
+				"log_group_name": "CWAgentEvents",
+				"tags":           map[string]interface{}{"metricPath": "logs"},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, actual.(map[string]interface{})["inputs"], "Expected to be equal")
+}
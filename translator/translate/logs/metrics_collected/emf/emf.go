@@ -5,7 +5,6 @@ package emf
 
 import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
-	"github.com/aws/amazon-cloudwatch-agent/translator/context"
 	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/logs/metrics_collected"
 )
 
@@ -43,15 +42,16 @@ func (obj *EMF) ApplyRule(input interface{}) (returnKey string, returnVal interf
 	if _, ok := m[SectionKey]; !ok {
 		returnKey = ""
 		returnVal = ""
+	} else if listener, ok := emfListener(m[SectionKey]); ok {
+		// the tenants and the limits of the documents are only supported by the emf_listener
+		returnKey = EMFListenerKey
+		returnVal = []interface{}{listener}
 	} else {
 		//If exists, process it
 		//Check if there are some config entry with rules applied
 		if sectionMap, ok := m[SectionKey].(map[string]interface{}); ok && len(sectionMap) == 0 {
 			// not configured
-			defaultEndpointSuffix := "://127.0.0.1:25888"
-			if context.CurrentContext().RunInContainer() {
-				defaultEndpointSuffix = "://:25888"
-			}
+			defaultEndpointSuffix := defaultEndpointSuffix()
 			resArray = []interface{}{
 				map[string]interface{}{
 					"service_address": "udp" + defaultEndpointSuffix,
@@ -0,0 +1,111 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package emf
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+	translateutil "github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+// EMFListenerKey is the input receiving the documents when the section sets any of the options of the tenants or of
+// the limits of the documents, the socket_listener is used otherwise
+const EMFListenerKey = "emf_listener"
+
+const (
+	SectionKeyTenants            = "tenants"
+	SectionKeyMaxPayloadSize     = "max_payload_size"
+	SectionKeyTokenKey           = "token_key"
+	SectionKeyStatsLogGroupName  = "stats_log_group_name"
+	SectionKeyStatsLogStreamName = "stats_log_stream_name"
+	SectionKeyStatsNamespace     = "stats_namespace"
+	SectionKeyAuth               = "auth"
+)
+
+var authKeys = []string{"allowed_cidrs", "token_file", "tls_cert", "tls_key", "tls_allowed_cacerts"}
+
+var emfListenerKeys = []string{
+	SectionKeyTenants,
+	SectionKeyMaxPayloadSize,
+	SectionKeyTokenKey,
+	SectionKeyStatsLogGroupName,
+	SectionKeyStatsLogStreamName,
+	SectionKeyStatsNamespace,
+	SectionKeyAuth,
+}
+
+// emfListener returns the config of the emf_listener input of the section, false when it sets none of its options
+func emfListener(input interface{}) (map[string]interface{}, bool) {
+	section, ok := input.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	used := false
+	for _, key := range emfListenerKeys {
+		if _, ok := section[key]; ok {
+			used = true
+		}
+	}
+	if !used {
+		return nil, false
+	}
+
+	result := map[string]interface{}{}
+	if address, ok := section[SectionKeyServiceAddress].(string); ok {
+		result["service_addresses"] = []string{address}
+	} else {
+		suffix := defaultEndpointSuffix()
+		result["service_addresses"] = []string{"udp" + suffix, "tcp" + suffix}
+	}
+	if size, ok := section[SectionKeyMaxPayloadSize].(float64); ok {
+		result[SectionKeyMaxPayloadSize] = int(size)
+	}
+	for _, key := range []string{SectionKeyTokenKey, SectionKeyStatsNamespace} {
+		if val, ok := section[key].(string); ok {
+			result[key] = val
+		}
+	}
+	for _, key := range []string{SectionKeyStatsLogGroupName, SectionKeyStatsLogStreamName} {
+		if val, ok := section[key].(string); ok {
+			result[key] = translateutil.ResolvePlaceholder(val, logs.GlobalLogConfig.MetadataInfo)
+		}
+	}
+
+	if auth, ok := section[SectionKeyAuth]; ok {
+		if _, ok := auth.(map[string]interface{}); ok {
+			converted := map[string]interface{}{}
+			util.SetWithSameKeyIfFound(auth, authKeys, converted)
+			result[SectionKeyAuth] = converted
+		} else {
+			translator.AddErrorMessages(GetCurPath()+SectionKeyAuth, "Invalid format, expected an object")
+		}
+	}
+
+	tenants := []interface{}{}
+	if list, ok := section[SectionKeyTenants].([]interface{}); ok {
+		for _, t := range list {
+			tenant, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			converted := map[string]interface{}{}
+			for _, key := range []string{"name", "service_address", "token"} {
+				if val, ok := tenant[key].(string); ok {
+					converted[key] = val
+				}
+			}
+			for _, key := range []string{"log_group_name", "log_stream_name"} {
+				if val, ok := tenant[key].(string); ok {
+					converted[key] = translateutil.ResolvePlaceholder(val, logs.GlobalLogConfig.MetadataInfo)
+				}
+			}
+			tenants = append(tenants, converted)
+		}
+	}
+	if len(tenants) > 0 {
+		result["tenant"] = tenants
+	}
+	return result, true
+}
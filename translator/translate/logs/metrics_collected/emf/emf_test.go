@@ -54,3 +54,69 @@ func TestEMF_MinimumConfig(t *testing.T) {
 
 	assert.Equal(t, expect, actual)
 }
+
+func TestEMF_Tenants(t *testing.T) {
+	obj := new(EMF)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"emf": {
+					"service_address": "tcp://:25888",
+					"max_payload_size": 65536,
+					"tenants": [
+						{"name": "orders", "service_address": "tcp://:25889", "log_group_name": "/app/orders"},
+						{"name": "billing", "token": "secret", "log_group_name": "/app/billing", "log_stream_name": "billing"}
+					],
+					"stats_log_group_name": "/aws/cwagent/emf_listener"
+					}}`), &input)
+	assert.NoError(t, err)
+
+	key, actual := obj.ApplyRule(input)
+
+	expect := []interface{}{
+		map[string]interface{}{
+			"service_addresses":    []string{"tcp://:25888"},
+			"max_payload_size":     65536,
+			"stats_log_group_name": "/aws/cwagent/emf_listener",
+			"tenant": []interface{}{
+				map[string]interface{}{
+					"name":            "orders",
+					"service_address": "tcp://:25889",
+					"log_group_name":  "/app/orders",
+				},
+				map[string]interface{}{
+					"name":            "billing",
+					"token":           "secret",
+					"log_group_name":  "/app/billing",
+					"log_stream_name": "billing",
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, EMFListenerKey, key)
+	assert.Equal(t, expect, actual)
+}
+
+func TestEMF_Auth(t *testing.T) {
+	obj := new(EMF)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"emf": {
+					"service_address": "tcp://:25888",
+					"auth": {"allowed_cidrs": ["10.0.0.0/8"], "token_file": "/etc/amazon/emf-token", "unknown": true}
+					}}`), &input)
+	assert.NoError(t, err)
+
+	key, actual := obj.ApplyRule(input)
+
+	expect := []interface{}{
+		map[string]interface{}{
+			"service_addresses": []string{"tcp://:25888"},
+			"auth": map[string]interface{}{
+				"allowed_cidrs": []interface{}{"10.0.0.0/8"},
+				"token_file":    "/etc/amazon/emf-token",
+			},
+		},
+	}
+
+	assert.Equal(t, EMFListenerKey, key)
+	assert.Equal(t, expect, actual)
+}
@@ -6,6 +6,7 @@ package emf
 import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 	"github.com/aws/amazon-cloudwatch-agent/translator/context"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
 )
 
 type ServiceAddress struct {
@@ -13,13 +14,21 @@ type ServiceAddress struct {
 
 const SectionKeyServiceAddress = "service_address"
 
+const defaultPort = 25888
+
 func (obj *ServiceAddress) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
-	defaultServiceAddress := "udp://127.0.0.1:25888"
+	returnKey, returnVal = translator.DefaultCase(SectionKeyServiceAddress, "udp"+defaultEndpointSuffix(), input)
+	return
+}
+
+// defaultEndpointSuffix listens on the loopback address, or on all the addresses in containers, unless the
+// agent has a bind address
+func defaultEndpointSuffix() string {
+	host := "127.0.0.1"
 	if context.CurrentContext().RunInContainer() {
-		defaultServiceAddress = "udp://:25888"
+		host = ""
 	}
-	returnKey, returnVal = translator.DefaultCase(SectionKeyServiceAddress, defaultServiceAddress, input)
-	return
+	return "://" + agent.ListenAddress(host, defaultPort)
 }
 
 func init() {
@@ -5,7 +5,6 @@ package emf
 
 import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
-	"github.com/aws/amazon-cloudwatch-agent/translator/context"
 	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/logs/metrics_collected"
 )
 
@@ -32,15 +31,16 @@ func (obj *StructuredLog) ApplyRule(input interface{}) (returnKey string, return
 	if _, ok := m[SectionKeyStructuredLog]; !ok {
 		returnKey = ""
 		returnVal = ""
+	} else if listener, ok := emfListener(m[SectionKeyStructuredLog]); ok {
+		// the tenants and the limits of the documents are only supported by the emf_listener
+		returnKey = EMFListenerKey
+		returnVal = []interface{}{listener}
 	} else {
 		//If exists, process it
 		//Check if there are some config entry with rules applied
 		if sectionMap, ok := m[SectionKeyStructuredLog].(map[string]interface{}); ok && len(sectionMap) == 0 {
 			// not configured
-			defaultEndpointSuffix := "://127.0.0.1:25888"
-			if context.CurrentContext().RunInContainer() {
-				defaultEndpointSuffix = "://:25888"
-			}
+			defaultEndpointSuffix := defaultEndpointSuffix()
 			resArray = []interface{}{
 				map[string]interface{}{
 					"service_address": "udp" + defaultEndpointSuffix,
@@ -0,0 +1,27 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package k8sdecorator
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	SectionKeyWorkloadDimensions = "workload_dimensions"
+)
+
+// WorkloadDimensions aggregates the pod metrics by the workload owning the pods, i.e. WorkloadType and WorkloadName
+type WorkloadDimensions struct {
+}
+
+func (w *WorkloadDimensions) ApplyRule(input interface{}) (string, interface{}) {
+	if _, ok := input.(map[string]interface{})[SectionKeyWorkloadDimensions]; !ok {
+		return "", nil
+	}
+	return translator.DefaultCase(SectionKeyWorkloadDimensions, false, input)
+}
+
+func init() {
+	RegisterRule(SectionKeyWorkloadDimensions, new(WorkloadDimensions))
+}
@@ -75,8 +75,8 @@ func (c *CollectMetrics) ApplyRule(input interface{}) (returnKey string, returnV
 		//If yes, process it
 
 		featureInited := false // kubernetes, ecs, prometheus are mutually exclusive, use this flag to ensure only one feature could be turned on.
-		for _, rule := range getOrderedRules(targetRuleMap) {
-			key, val := rule.ApplyRule(im[SectionKey])
+		for _, ruleName := range getOrderedRuleNames(targetRuleMap) {
+			key, val := targetRuleMap[ruleName].ApplyRule(im[SectionKey])
 			if key == "kubernetes" || key == "ecs" || key == "prometheus" {
 				if featureInited {
 					translator.AddErrorMessages(GetCurPath(), fmt.Sprint("Feature kubernetes, ecs, prometheus are mutually exclusive"))
@@ -88,17 +88,20 @@ func (c *CollectMetrics) ApplyRule(input interface{}) (returnKey string, returnV
 					if tmpInputs, ok := result["inputs"]; ok {
 						for k, v := range tmpInputs {
 							inputs[k] = v
+							translator.AddProvenance("inputs."+k, translator.JsonPath(GetCurPath()+ruleName))
 						}
 					}
 					if tmpProcessors, ok := result["processors"]; ok {
 						for k, v := range tmpProcessors {
 							processors[k] = v
+							translator.AddProvenance("processors."+k, translator.JsonPath(GetCurPath()+ruleName))
 						}
 					}
 				}
 			} else {
 				if key != "" {
 					inputs[key] = val
+					translator.AddProvenance("inputs."+key, translator.JsonPath(GetCurPath()+ruleName))
 				}
 			}
 		}
@@ -111,17 +114,13 @@ func (c *CollectMetrics) ApplyRule(input interface{}) (returnKey string, returnV
 }
 
 // Adding alphabet order to the Rules
-func getOrderedRules(ruleMap map[string]Rule) []Rule {
-	var orderedRules []Rule
+func getOrderedRuleNames(ruleMap map[string]Rule) []string {
 	var orderedRuleNames []string
 	for ruleName := range ruleMap {
 		orderedRuleNames = append(orderedRuleNames, ruleName)
 	}
 	sort.Strings(orderedRuleNames)
-	for _, orderedRuleName := range orderedRuleNames {
-		orderedRules = append(orderedRules, ruleMap[orderedRuleName])
-	}
-	return orderedRules
+	return orderedRuleNames
 }
 
 var MergeRuleMap = map[string]mergeJsonRule.MergeRule{}
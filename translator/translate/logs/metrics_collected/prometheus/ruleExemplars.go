@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package emfprocessor
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	SectionKeyExemplars = "exemplars"
+)
+
+// Exemplars embeds the exemplars of the OpenMetrics targets, e.g. their trace ids, in the documents of their metrics
+// so that the datapoints can be correlated with the X-Ray traces
+type Exemplars struct {
+}
+
+func (e *Exemplars) ApplyRule(input interface{}) (string, interface{}) {
+	if _, ok := input.(map[string]interface{})[SectionKeyExemplars]; !ok {
+		return "", nil
+	}
+	return translator.DefaultCase(SectionKeyExemplars, false, input)
+}
+
+func init() {
+	RegisterRule(SectionKeyExemplars, new(Exemplars))
+}
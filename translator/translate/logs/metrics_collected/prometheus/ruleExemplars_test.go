@@ -0,0 +1,29 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package emfprocessor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExemplars(t *testing.T) {
+	obj := new(Exemplars)
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"exemplars": true}`), &input))
+	key, actual := obj.ApplyRule(input)
+	assert.Equal(t, "exemplars", key)
+	assert.Equal(t, true, actual)
+}
+
+func TestExemplarsNotConfigured(t *testing.T) {
+	obj := new(Exemplars)
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"cluster_name": "cluster"}`), &input))
+	key, actual := obj.ApplyRule(input)
+	assert.Equal(t, "", key)
+	assert.Nil(t, actual)
+}
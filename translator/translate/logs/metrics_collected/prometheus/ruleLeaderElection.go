@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package emfprocessor
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	SectionKeyLeaderElection = "leader_election"
+)
+
+// LeaderElection only publishes the metrics from the replica of the agent elected as the leader, e.g. when the
+// prometheus config scrapes the cluster-wide targets from every pod of the daemonset
+type LeaderElection struct {
+}
+
+func (l *LeaderElection) ApplyRule(input interface{}) (string, interface{}) {
+	if _, ok := input.(map[string]interface{})[SectionKeyLeaderElection]; !ok {
+		return "", nil
+	}
+	return translator.DefaultCase(SectionKeyLeaderElection, false, input)
+}
+
+func init() {
+	RegisterRule(SectionKeyLeaderElection, new(LeaderElection))
+}
@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	SectionKeyInterval = "metrics_collection_interval"
+	defaultInterval    = float64(60)
+)
+
+type Interval struct {
+}
+
+func (obj *Interval) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, returnVal = translator.DefaultTimeIntervalCase(SectionKeyInterval, defaultInterval, input)
+	returnKey = "interval"
+	return
+}
+
+func init() {
+	RegisterRule(SectionKeyInterval, new(Interval))
+}
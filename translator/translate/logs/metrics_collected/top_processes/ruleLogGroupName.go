@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+const (
+	SectionKeyLogGroupName = "log_group_name"
+	defaultLogGroupName    = "/aws/cwagent/top_processes"
+)
+
+type LogGroupName struct {
+}
+
+func (obj *LogGroupName) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultCase(SectionKeyLogGroupName, defaultLogGroupName, input)
+	returnVal = util.ResolvePlaceholder(returnVal.(string), logs.GlobalLogConfig.MetadataInfo)
+	return
+}
+
+func init() {
+	RegisterRule(SectionKeyLogGroupName, new(LogGroupName))
+}
@@ -0,0 +1,29 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/logs"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+const SectionKeyLogStreamName = "log_stream_name"
+
+type LogStreamName struct {
+}
+
+func (obj *LogStreamName) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase(SectionKeyLogStreamName, "", input)
+	if val == "" {
+		return
+	}
+	returnKey = key
+	returnVal = util.ResolvePlaceholder(val.(string), logs.GlobalLogConfig.MetadataInfo)
+	return
+}
+
+func init() {
+	RegisterRule(SectionKeyLogStreamName, new(LogStreamName))
+}
@@ -0,0 +1,22 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+const SectionKeyNamespace = "namespace"
+
+type Namespace struct {
+}
+
+func (obj *Namespace) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKeyNamespace]; ok {
+		returnKey = SectionKeyNamespace
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	RegisterRule(SectionKeyNamespace, new(Namespace))
+}
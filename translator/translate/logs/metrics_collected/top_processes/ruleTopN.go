@@ -0,0 +1,24 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	SectionKeyTopN = "top_n"
+	defaultTopN    = float64(5)
+)
+
+type TopN struct {
+}
+
+func (obj *TopN) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	return translator.DefaultIntegralCase(SectionKeyTopN, defaultTopN, input)
+}
+
+func init() {
+	RegisterRule(SectionKeyTopN, new(TopN))
+}
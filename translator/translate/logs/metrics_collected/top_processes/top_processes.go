@@ -0,0 +1,55 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/logs/metrics_collected"
+)
+
+//
+//   "top_processes" : {
+//       "top_n": 5,
+//       "metrics_collection_interval": 60,
+//       "log_group_name": "/aws/cwagent/top_processes"
+//   }
+//
+const SectionKey = "top_processes"
+
+var ChildRule = map[string]translator.Rule{}
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type TopProcesses struct {
+}
+
+func (obj *TopProcesses) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		result = translator.ProcessRuleToApply(m[SectionKey], ChildRule, result)
+		returnKey = SectionKey
+		returnVal = []interface{}{result}
+	}
+	return
+}
+
+func init() {
+	obj := new(TopProcesses)
+	parent.RegisterLinuxRule(SectionKey, obj)
+	parent.RegisterDarwinRule(SectionKey, obj)
+	parent.RegisterWindowsRule(SectionKey, obj)
+}
@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package top_processes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	obj := new(TopProcesses)
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"top_processes": {}}`), &input))
+	key, actual := obj.ApplyRule(input)
+	assert.Equal(t, "top_processes", key)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"interval":       "60s",
+		"top_n":          5,
+		"log_group_name": "/aws/cwagent/top_processes",
+	}}, actual)
+}
+
+func TestFullConfig(t *testing.T) {
+	obj := new(TopProcesses)
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"top_processes": {
+		"metrics_collection_interval": 30,
+		"top_n": 10,
+		"log_group_name": "top",
+		"log_stream_name": "{date}-top",
+		"namespace": "Processes"
+	}}`), &input))
+	_, actual := obj.ApplyRule(input)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"interval":        "30s",
+		"top_n":           10,
+		"log_group_name":  "top",
+		"log_stream_name": time.Now().Format("2006-01-02") + "-top",
+		"namespace":       "Processes",
+	}}, actual)
+}
+
+func TestNotConfigured(t *testing.T) {
+	obj := new(TopProcesses)
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"emf": {}}`), &input))
+	key, _ := obj.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
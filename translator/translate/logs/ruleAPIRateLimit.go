@@ -0,0 +1,32 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// APIRateLimit limits the calls to CloudWatch Logs of all the log streams of cloudwatchlogs
+type APIRateLimit struct {
+}
+
+func (r *APIRateLimit) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	if _, ok := im["max_api_tps"]; !ok {
+		return
+	}
+	result := map[string]interface{}{}
+	if _, val := translator.DefaultCase("max_api_tps", float64(0), input); val.(float64) > 0 {
+		result["max_api_tps"] = val
+	}
+	// the burst is an integer number of calls
+	if _, val := translator.DefaultCase("api_burst", float64(0), input); val.(float64) > 0 {
+		result["api_burst"] = int(val.(float64))
+	}
+	return Output_Cloudwatch_Logs, result
+}
+
+func init() {
+	RegisterRule("max_api_tps", new(APIRateLimit))
+}
@@ -0,0 +1,37 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+const AgentEventsSectionKey = "agent_events"
+
+// AgentEvents publishes the significant conditions of the agent, e.g. the files which cannot be read, to the log
+// group of the "agent_events" section
+type AgentEvents struct {
+}
+
+func (r *AgentEvents) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	section, ok := input.(map[string]interface{})[AgentEventsSectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	result := map[string]interface{}{"destination": Output_Cloudwatch_Logs}
+	_, group := translator.DefaultCase("log_group_name", "", section)
+	result["log_group_name"] = util.ResolvePlaceholder(group.(string), GlobalLogConfig.MetadataInfo)
+	if _, stream := translator.DefaultCase("log_stream_name", "", section); stream != "" {
+		result["log_stream_name"] = util.ResolvePlaceholder(stream.(string), GlobalLogConfig.MetadataInfo)
+	}
+
+	returnKey = "inputs"
+	returnVal = map[string]interface{}{"agent_events": []interface{}{result}}
+	return
+}
+
+func init() {
+	RegisterRule(AgentEventsSectionKey, new(AgentEvents))
+}
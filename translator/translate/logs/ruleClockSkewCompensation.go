@@ -0,0 +1,25 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// ClockSkewCompensation moves the timestamps of the log events by the persistent skew of the clock of the host
+type ClockSkewCompensation struct {
+}
+
+func (r *ClockSkewCompensation) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase("clock_skew_compensation", false, input)
+	if val == true {
+		returnKey = Output_Cloudwatch_Logs
+		returnVal = map[string]interface{}{key: val}
+	}
+	return
+}
+
+func init() {
+	RegisterRule("clock_skew_compensation", new(ClockSkewCompensation))
+}
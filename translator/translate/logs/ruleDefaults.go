@@ -0,0 +1,51 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonRule"
+	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonUtil"
+)
+
+const DefaultsSectionKey = "defaults"
+
+// logsDefaults returns the keys of the logs.defaults section, they are inherited by all the entries of the
+// collect_list of the log files which do not set them.
+func logsDefaults(input interface{}) map[string]interface{} {
+	im := input.(map[string]interface{})
+	if defaults, ok := im[DefaultsSectionKey].(map[string]interface{}); ok {
+		return defaults
+	}
+	return map[string]interface{}{}
+}
+
+// WithDefaults returns the collect_list entry with the keys of logs.defaults it does not set, the entry itself
+// is left unchanged.
+func WithDefaults(entry map[string]interface{}) map[string]interface{} {
+	if len(GlobalLogConfig.Defaults) == 0 {
+		return entry
+	}
+	result := map[string]interface{}{}
+	for k, v := range GlobalLogConfig.Defaults {
+		result[k] = v
+	}
+	for k, v := range entry {
+		result[k] = v
+	}
+	return result
+}
+
+type Defaults struct {
+}
+
+var defaultsMergeRuleMap = map[string]mergeJsonRule.MergeRule{}
+
+// Merge merges the defaults of the config files key by key, a key set to different values is still an error
+func (d *Defaults) Merge(source map[string]interface{}, result map[string]interface{}) {
+	mergeJsonUtil.MergeMap(source, result, DefaultsSectionKey, defaultsMergeRuleMap, GetCurPath()+DefaultsSectionKey+"/")
+}
+
+func init() {
+	MergeRuleMap[DefaultsSectionKey] = new(Defaults)
+}
@@ -0,0 +1,24 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// InvalidUTF8 selects what happens to the log events which are not valid UTF-8, replaced by default
+type InvalidUTF8 struct {
+}
+
+func (r *InvalidUTF8) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase("invalid_utf8", "", input)
+	if val == "" {
+		return
+	}
+	return Output_Cloudwatch_Logs, map[string]interface{}{"invalid_utf8": val}
+}
+
+func init() {
+	RegisterRule("invalid_utf8", new(InvalidUTF8))
+}
@@ -0,0 +1,38 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+const InventorySectionKey = "inventory"
+
+// Inventory publishes the inventory record of the agent, i.e. its version, OS, config hash and plugins, to the
+// log group of the "inventory" section
+type Inventory struct {
+}
+
+func (r *Inventory) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	section, ok := input.(map[string]interface{})[InventorySectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	result := map[string]interface{}{"destination": Output_Cloudwatch_Logs}
+	_, group := translator.DefaultCase("log_group_name", "", section)
+	result["log_group_name"] = util.ResolvePlaceholder(group.(string), GlobalLogConfig.MetadataInfo)
+	if _, stream := translator.DefaultCase("log_stream_name", "", section); stream != "" {
+		result["log_stream_name"] = util.ResolvePlaceholder(stream.(string), GlobalLogConfig.MetadataInfo)
+	}
+	_, result["publish_interval"] = translator.DefaultTimeIntervalCase("publish_interval", float64(3600), section)
+
+	returnKey = "inputs"
+	returnVal = map[string]interface{}{"inventory": []interface{}{result}}
+	return
+}
+
+func init() {
+	RegisterRule(InventorySectionKey, new(Inventory))
+}
@@ -0,0 +1,42 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const (
+	KinesisSectionKey = "kinesis"
+	KinesisOnlyKey    = "kinesis_only"
+)
+
+var kinesisTargetList = []string{"stream_name", "delivery_stream_name", "partition_key", "join_records", "kpl_aggregation", "endpoint_override", agent.RegionKey, Role_Arn_Key}
+
+type Kinesis struct {
+}
+
+func (k *Kinesis) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	kinesisSection, ok := im[KinesisSectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	result := additionalOutputConfig(im)
+	util.SetWithSameKeyIfFound(kinesisSection, kinesisTargetList, result)
+
+	key, val := translator.DefaultTimeIntervalCase("force_flush_interval", float64(5), kinesisSection)
+	result[key] = val
+
+	returnKey = Output_Kinesis_Logs
+	returnVal = result
+	return
+}
+
+func init() {
+	RegisterRule(KinesisSectionKey, new(Kinesis))
+}
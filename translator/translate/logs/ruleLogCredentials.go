@@ -4,6 +4,7 @@
 package logs
 
 import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
 	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
 	"github.com/aws/amazon-cloudwatch-agent/translator/util"
 )
@@ -19,20 +20,48 @@ const (
 var credsTargetList = []string{Role_Arn_Key}
 
 func (c *LogCreds) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey = Output_Cloudwatch_Logs
+	returnVal = logCredentials(input, true)
+	return
+}
+
+// logCredentials returns the role of the logs outputs, the role of the monitoring account is only the one of the
+// cloudwatchlogs output
+func logCredentials(input interface{}, monitoringAccount bool) map[string]interface{} {
 	result := map[string]interface{}{}
 
 	if agent.Global_Config.Role_arn != "" {
 		result[Role_Arn_Key] = agent.Global_Config.Role_arn
 	}
+	if monitoringAccount {
+		for k, v := range agent.MonitoringAccountCredentials() {
+			result[k] = v
+		}
+	}
 
 	// Read fromm Json first.
 	if val, ok := input.(map[string]interface{})[CredentialsSectionKey]; ok {
+		if creds, _ := val.(map[string]interface{}); creds[Role_Arn_Key] != nil {
+			// the external ID is the one of the role of the monitoring account
+			delete(result, agent.ExternalIdKey)
+		}
 		util.SetWithSameKeyIfFound(val, credsTargetList, result)
 	}
+	return result
+}
 
-	returnKey = Output_Cloudwatch_Logs
-	returnVal = result
-	return
+// additionalOutputConfig returns the region, endpoint and credentials of the cloudwatchlogs output, which are
+// shared by the outputs receiving the collected logs in addition to CloudWatch Logs.
+func additionalOutputConfig(input map[string]interface{}) map[string]interface{} {
+	result := translator.MergeTwoUniqueMaps(map[string]interface{}{}, agent.Global_Config.Credentials)
+	result[agent.RegionKey] = agent.Global_Config.Region
+	if agent.Global_Config.UseDualStackEndpoint {
+		result[agent.UseDualStackEndpointKey] = true
+	}
+	for k, v := range logCredentials(input, false) {
+		result[k] = v
+	}
+	return result
 }
 
 func init() {
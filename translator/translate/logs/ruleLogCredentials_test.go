@@ -46,3 +46,18 @@ func TestWithAgentConfig(t *testing.T) {
 
 	agent.Global_Config.Role_arn = ""
 }
+
+func TestWithMonitoringAccount(t *testing.T) {
+	agent.Global_Config.Region = "cn-north-1"
+	agent.Global_Config.MonitoringAccount = agent.MonitoringAccountConfig{AccountID: "111122223333", RoleName: "Monitoring"}
+	defer func() { agent.Global_Config.MonitoringAccount = agent.MonitoringAccountConfig{} }()
+	c := new(LogCreds)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"logs_collected": {}}`), &input)
+	assert.NoError(t, e)
+	_, returnVal := c.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{"role_arn": "arn:aws-cn:iam::111122223333:role/Monitoring"}, returnVal)
+
+	// only the cloudwatchlogs output publishes to the monitoring account
+	assert.NotContains(t, additionalOutputConfig(input.(map[string]interface{})), "role_arn")
+}
@@ -0,0 +1,34 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// MemoryBudget bounds the log events buffered by cloudwatchlogs, they are then admitted by the priority of their
+// sources
+type MemoryBudget struct {
+}
+
+func (r *MemoryBudget) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	if _, ok := im["memory_budget_mb"]; !ok {
+		return
+	}
+	result := map[string]interface{}{}
+	// the budget is an integer in MB
+	_, val := translator.DefaultCase("memory_budget_mb", float64(0), input)
+	if mb, ok := val.(float64); ok {
+		result["memory_budget_mb"] = int(mb)
+	}
+	if _, val := translator.DefaultCase("bulk_under_pressure", "", input); val != "" {
+		result["bulk_under_pressure"] = val
+	}
+	return Output_Cloudwatch_Logs, result
+}
+
+func init() {
+	RegisterRule("memory_budget_mb", new(MemoryBudget))
+}
@@ -0,0 +1,25 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// MonotonicTimestamps keeps the timestamps of every log stream increasing
+type MonotonicTimestamps struct {
+}
+
+func (r *MonotonicTimestamps) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase("monotonic_timestamps", false, input)
+	if val == true {
+		returnKey = Output_Cloudwatch_Logs
+		returnVal = map[string]interface{}{key: val}
+	}
+	return
+}
+
+func init() {
+	RegisterRule("monotonic_timestamps", new(MonotonicTimestamps))
+}
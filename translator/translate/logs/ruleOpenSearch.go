@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const (
+	OpenSearchSectionKey = "opensearch"
+	OpenSearchOnlyKey    = "opensearch_only"
+)
+
+var openSearchTargetList = []string{"endpoint", "index", "serverless", "disable_sigv4", agent.RegionKey, Role_Arn_Key}
+
+type OpenSearch struct {
+}
+
+func (o *OpenSearch) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	openSearchSection, ok := im[OpenSearchSectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	result := additionalOutputConfig(im)
+	// the endpoint of the domain or collection is used as configured
+	delete(result, agent.UseDualStackEndpointKey)
+	util.SetWithSameKeyIfFound(openSearchSection, openSearchTargetList, result)
+
+	key, val := translator.DefaultTimeIntervalCase("force_flush_interval", float64(5), openSearchSection)
+	result[key] = val
+
+	returnKey = Output_OpenSearch_Logs
+	returnVal = result
+	return
+}
+
+func init() {
+	RegisterRule(OpenSearchSectionKey, new(OpenSearch))
+}
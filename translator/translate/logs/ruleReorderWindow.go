@@ -0,0 +1,25 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// ReorderWindow holds the log events of the streams written by several sources to send them by timestamp
+type ReorderWindow struct {
+}
+
+func (r *ReorderWindow) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	if _, ok := im["reorder_window"]; !ok {
+		return
+	}
+	key, val := translator.DefaultTimeIntervalCase("reorder_window", float64(0), input)
+	return Output_Cloudwatch_Logs, map[string]interface{}{key: val}
+}
+
+func init() {
+	RegisterRule("reorder_window", new(ReorderWindow))
+}
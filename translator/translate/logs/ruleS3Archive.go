@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const (
+	S3ArchiveSectionKey = "s3_archive"
+	ArchiveOnlyKey      = "archive_only"
+)
+
+var s3ArchiveTargetList = []string{"bucket", "key_prefix", "endpoint_override", agent.RegionKey, Role_Arn_Key}
+
+type S3Archive struct {
+}
+
+func (s *S3Archive) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	archive, ok := im[S3ArchiveSectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	result := additionalOutputConfig(im)
+	util.SetWithSameKeyIfFound(archive, s3ArchiveTargetList, result)
+
+	key, val := translator.DefaultTimeIntervalCase("force_flush_interval", float64(300), archive)
+	result[key] = val
+	if _, ok := archive["max_object_size"]; ok {
+		key, val = translator.DefaultIntegralCase("max_object_size", float64(0), archive)
+		result[key] = val
+	}
+	if compression, ok := archive["compression"]; ok {
+		result["compression"] = compression
+	}
+	if _, ok := archive["compression_level"]; ok {
+		key, val = translator.DefaultIntegralCase("compression_level", float64(0), archive)
+		result[key] = val
+	}
+
+	returnKey = Output_S3_Logs
+	returnVal = result
+	return
+}
+
+func init() {
+	RegisterRule(S3ArchiveSectionKey, new(S3Archive))
+}
@@ -0,0 +1,24 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// SDKVersion selects the AWS SDK of the cloudwatchlogs client, v1 unless v2 is set
+type SDKVersion struct {
+}
+
+func (r *SDKVersion) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase("sdk_version", "", input)
+	if val == "" {
+		return
+	}
+	return Output_Cloudwatch_Logs, map[string]interface{}{"sdk_version": val}
+}
+
+func init() {
+	RegisterRule("sdk_version", new(SDKVersion))
+}
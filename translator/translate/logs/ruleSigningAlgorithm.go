@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// SigningAlgorithm selects the signature of the requests of cloudwatchlogs, e.g. SigV4A for the multi-region endpoints
+type SigningAlgorithm struct {
+}
+
+func (r *SigningAlgorithm) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, val := translator.DefaultCase("signing_algorithm", "", input)
+	if val == "" {
+		return
+	}
+	result := map[string]interface{}{"signing_algorithm": val}
+	if regions, ok := input.(map[string]interface{})["signing_region_set"]; ok {
+		result["signing_region_set"] = regions
+	}
+	return Output_Cloudwatch_Logs, result
+}
+
+func init() {
+	RegisterRule("signing_algorithm", new(SigningAlgorithm))
+}
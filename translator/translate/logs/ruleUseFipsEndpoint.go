@@ -0,0 +1,24 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type UseFipsEndpoint struct {
+}
+
+func (r *UseFipsEndpoint) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase("use_fips_endpoint", false, input)
+	if val == true {
+		returnKey = Output_Cloudwatch_Logs
+		returnVal = map[string]interface{}{key: val}
+	}
+	return
+}
+
+func init() {
+	RegisterRule("use_fips_endpoint", new(UseFipsEndpoint))
+}
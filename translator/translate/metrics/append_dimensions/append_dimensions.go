@@ -8,6 +8,7 @@ import (
 	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics"
 	credsutil "github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
 	"sort"
+	"strings"
 )
 
 type appendDimensions struct {
@@ -16,6 +17,10 @@ type appendDimensions struct {
 const SectionKey = "append_dimensions"
 const CredsKey = "creds"
 
+// the dimensions of the custom instance tags, e.g. "Team": "${aws:tag/team}"
+const tagValuePrefix = "${aws:tag/"
+const tagValueSuffix = "}"
+
 var ChildRule = map[string]translator.Rule{}
 
 func (ad *appendDimensions) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
@@ -51,6 +56,14 @@ func (ad *appendDimensions) ApplyRule(input interface{}) (returnKey string, retu
 				}
 			}
 		}
+		if tagDimensions := customTagDimensions(im[SectionKey]); len(tagDimensions) > 0 {
+			for tagKey := range tagDimensions {
+				EC2_Instance_Tags = append(EC2_Instance_Tags, tagKey)
+			}
+			sort.Strings(EC2_Instance_Tags)
+			temp["ec2_instance_tag_keys"] = EC2_Instance_Tags
+			temp["ec2_instance_tag_dimensions"] = tagDimensions
+		}
 		result["ec2tagger"] = []interface{}{temp}
 
 		returnKey = "processors"
@@ -71,6 +84,22 @@ func CheckIfExactMatch(input interface{}, desiredKey string, desiredValue string
 	return
 }
 
+// customTagDimensions returns the dimensions of the custom instance tags by tag key
+func customTagDimensions(input interface{}) map[string]interface{} {
+	tagDimensions := map[string]interface{}{}
+	for dimension, v := range input.(map[string]interface{}) {
+		value, ok := v.(string)
+		if !ok || !strings.HasPrefix(value, tagValuePrefix) || !strings.HasSuffix(value, tagValueSuffix) {
+			continue
+		}
+		tagKey := strings.TrimSuffix(strings.TrimPrefix(value, tagValuePrefix), tagValueSuffix)
+		if tagKey != "" {
+			tagDimensions[tagKey] = dimension
+		}
+	}
+	return tagDimensions
+}
+
 func RegisterRule(fieldname string, r translator.Rule) {
 	ChildRule[fieldname] = r
 }
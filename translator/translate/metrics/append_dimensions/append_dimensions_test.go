@@ -40,3 +40,51 @@ func TestAppendDimensions(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestAppendDimensionsVolumeId(t *testing.T) {
+	e := new(appendDimensions)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"append_dimensions": {"InstanceId": "${aws:InstanceId}", "VolumeId": "${aws:VolumeId}"}}`), &input)
+	if err != nil {
+		panic(err)
+	}
+	_, actual := e.ApplyRule(input)
+	expected := map[string]interface{}{
+		"ec2tagger": []interface{}{
+			map[string]interface{}{
+				"ec2_metadata_tags":        []string{"InstanceId"},
+				"ebs_volume_id_tag_key":    "VolumeId",
+				"refresh_interval_seconds": "0s",
+			},
+		},
+	}
+	assert.Equal(t, expected, actual, "Expect to be equal")
+}
+
+func TestAppendDimensionsInstanceTags(t *testing.T) {
+	e := new(appendDimensions)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"append_dimensions": {
+		"EKSNodegroupName": "${aws:EKSNodegroupName}",
+		"CloudFormationStackName": "${aws:CloudFormationStackName}",
+		"Team": "${aws:tag/team}",
+		"CostCenter": "${aws:tag/cost-center}"
+	}}`), &input)
+	if err != nil {
+		panic(err)
+	}
+	_, actual := e.ApplyRule(input)
+	expected := map[string]interface{}{
+		"ec2tagger": []interface{}{
+			map[string]interface{}{
+				"ec2_instance_tag_keys": []string{"aws:cloudformation:stack-name", "cost-center", "eks:nodegroup-name", "team"},
+				"ec2_instance_tag_dimensions": map[string]interface{}{
+					"team":        "Team",
+					"cost-center": "CostCenter",
+				},
+				"refresh_interval_seconds": "0s",
+			},
+		},
+	}
+	assert.Equal(t, expected, actual, "Expect to be equal")
+}
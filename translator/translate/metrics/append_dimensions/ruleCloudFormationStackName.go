@@ -0,0 +1,19 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package append_dimensions
+
+type CloudFormationStackName struct {
+}
+
+const Reserved_Key_CloudFormationStack = "CloudFormationStackName"
+const Reserved_Val_CloudFormationStack = "${aws:CloudFormationStackName}"
+
+func (c *CloudFormationStackName) ApplyRule(input interface{}) (string, interface{}) {
+	return CheckIfExactMatch(input, Reserved_Key_CloudFormationStack, Reserved_Val_CloudFormationStack, "ec2_instance_tag_keys", "aws:cloudformation:stack-name")
+}
+
+func init() {
+	c := new(CloudFormationStackName)
+	RegisterRule(Reserved_Key_CloudFormationStack, c)
+}
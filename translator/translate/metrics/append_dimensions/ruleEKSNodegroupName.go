@@ -0,0 +1,19 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package append_dimensions
+
+type EKSNodegroupName struct {
+}
+
+const Reserved_Key_EKSNodegroup = "EKSNodegroupName"
+const Reserved_Val_EKSNodegroup = "${aws:EKSNodegroupName}"
+
+func (e *EKSNodegroupName) ApplyRule(input interface{}) (string, interface{}) {
+	return CheckIfExactMatch(input, Reserved_Key_EKSNodegroup, Reserved_Val_EKSNodegroup, "ec2_instance_tag_keys", "eks:nodegroup-name")
+}
+
+func init() {
+	e := new(EKSNodegroupName)
+	RegisterRule(Reserved_Key_EKSNodegroup, e)
+}
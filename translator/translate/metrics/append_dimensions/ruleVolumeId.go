@@ -0,0 +1,21 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package append_dimensions
+
+// VolumeId adds the ID of the EBS volume as a dimension to the disk and diskio metrics, e.g. to join them with the
+// metrics of AWS/EBS
+type VolumeId struct {
+}
+
+const Reserved_Key_Volume_Id = "VolumeId"
+const Reserved_Val_Volume_Id = "${aws:VolumeId}"
+
+func (v *VolumeId) ApplyRule(input interface{}) (string, interface{}) {
+	return CheckIfExactMatch(input, Reserved_Key_Volume_Id, Reserved_Val_Volume_Id, "ebs_volume_id_tag_key", Reserved_Key_Volume_Id)
+}
+
+func init() {
+	v := new(VolumeId)
+	RegisterRule(Reserved_Key_Volume_Id, v)
+}
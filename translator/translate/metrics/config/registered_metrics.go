@@ -16,11 +16,36 @@ var Registered_Metrics_Linux = map[string][]string{
 	"netstat":   {"tcp_close", "tcp_close_wait", "tcp_closing", "tcp_established", "tcp_fin_wait1", "tcp_fin_wait2", "tcp_last_ack", "tcp_listen", "tcp_none", "tcp_syn_sent", "tcp_syn_recv", "tcp_time_wait", "udp_socket"},
 	"processes": {"blocked", "dead", "idle", "paging", "running", "sleeping", "stopped", "total", "total_threads", "wait", "zombies"},
 	"internal":  {"memstats_alloc_bytes", "memstats_heap_in_use_bytes", "agent_metrics_dropped", "agent_metrics_gathered"},
+	"ipmi":      {"critical_breach", "non_critical_breach", "reading", "state"},
+	"tls_cert":  {"days_until_expiry", "chain_valid", "chain_days_until_expiry"},
+	"disk_latency": {"read_await_p50", "read_await_p90", "read_await_p99", "read_await_max",
+		"write_await_p50", "write_await_p90", "write_await_p99", "write_await_max", "read_await", "write_await"},
+	"paging": {"pgfault_per_sec", "pgmajfault_per_sec", "pswpin_per_sec", "pswpout_per_sec", "pgpgin_per_sec", "pgpgout_per_sec"},
+	"cgroupv2": {"memory_current", "memory_max", "memory_swap_current", "memory_events_low", "memory_events_high", "memory_events_max", "memory_events_oom", "memory_events_oom_kill",
+		"cpu_usage_usec", "cpu_user_usec", "cpu_system_usec", "cpu_nr_periods", "cpu_nr_throttled", "cpu_throttled_usec", "pids_current"},
+	"pressure": {"cpu_some_avg10", "cpu_some_avg60", "cpu_some_avg300", "cpu_some_total", "cpu_full_avg10", "cpu_full_avg60", "cpu_full_avg300", "cpu_full_total",
+		"memory_some_avg10", "memory_some_avg60", "memory_some_avg300", "memory_some_total", "memory_full_avg10", "memory_full_avg60", "memory_full_avg300", "memory_full_total",
+		"io_some_avg10", "io_some_avg60", "io_some_avg300", "io_some_total", "io_full_avg10", "io_full_avg60", "io_full_avg300", "io_full_total"},
+	"ebpf": {"tcp_connects", "tcp_connect_failures", "tcp_connect_latency", "tcp_retransmits",
+		"dns_queries", "dns_failures", "dns_timeouts", "dns_latency"},
 	"procstat": {"cpu_time", "cpu_time_guest", "cpu_time_guest_nice", "cpu_time_idle", "cpu_time_iowait", "cpu_time_irq", "cpu_time_nice", "cpu_time_soft_irq", "cpu_time_steal", "cpu_time_stolen", "cpu_time_system", "cpu_time_user", "cpu_usage", "involuntary_context_switches",
 		"memory_data", "memory_locked", "memory_rss", "memory_stack", "memory_swap", "memory_vms", "nice_priority", "num_fds", "num_threads", "pid",
 		"read_bytes", "read_count", "realtime_priority", "rlimit_cpu_time_hard", "rlimit_cpu_time_soft", "rlimit_file_locks_hard", "rlimit_file_locks_soft", "rlimit_memory_data_hard", "rlimit_memory_data_soft", "rlimit_memory_locked_hard", "rlimit_memory_locked_soft",
 		"rlimit_memory_rss_hard", "rlimit_memory_rss_soft", "rlimit_memory_stack_hard", "rlimit_memory_stack_soft", "rlimit_memory_vms_hard", "rlimit_memory_vms_soft", "rlimit_nice_priority_hard", "rlimit_nice_priority_soft", "rlimit_num_fds_hard", "rlimit_num_fds_soft",
 		"rlimit_realtime_priority_hard", "rlimit_realtime_priority_soft", "rlimit_signals_pending_hard", "rlimit_signals_pending_soft", "signals_pending", "voluntary_context_switches", "write_bytes", "write_count", "pid_count"},
+	"log_delivery": {"ingestion_lag_seconds", "acknowledged_events"},
+	"mysql": {"threads_connected", "threads_running", "connections", "aborted_connects", "max_used_connections", "queries", "slow_queries", "slow_queries_delta",
+		"innodb_buffer_pool_reads", "innodb_buffer_pool_read_requests", "cache_hit_rate", "slave_seconds_behind_master"},
+	"postgresql": {"numbackends", "xact_commit", "xact_rollback", "blks_read", "blks_hit", "deadlocks", "temp_files", "temp_bytes", "cache_hit_rate",
+		"replication_lag", "slow_queries"},
+	"redis": {"connected_clients", "blocked_clients", "rejected_connections", "used_memory", "evicted_keys", "expired_keys", "instantaneous_ops_per_sec",
+		"keyspace_hits", "keyspace_misses", "keyspace_hitrate", "cache_hit_rate", "master_last_io_seconds_ago", "lag"},
+	"memcached": {"curr_connections", "total_connections", "rejected_connections", "curr_items", "bytes", "evictions", "get_hits", "get_misses", "cache_hit_rate"},
+	"haproxy": {"current_sessions", "active_servers", "backup_servers", "up", "http_5xx", "retries", "redispatches", "sessions", "connection_errors",
+		"response_errors", "check_failures"},
+	"envoy":      {"http_5xx", "retries", "requests", "connections", "closed_connections", "connection_failures", "active_connections", "healthy_hosts", "hosts"},
+	"kafka":      {"consumer_lag", "consumer_lag_total", "consumer_lag_max"},
+	"port_check": {"success", "connect_time", "response_time"},
 }
 
 // This served as the whitelisted metric name, which is registered under the plugin name
@@ -39,6 +64,20 @@ var Registered_Metrics_Darwin = map[string][]string{
 	"procstat": {"cpu_time_system", "cpu_time_user", "cpu_usage",
 		"memory_data", "memory_locked", "memory_rss", "memory_stack", "memory_swap", "memory_vms", "pid",
 		"pid_count"},
+	"tls_cert":     {"days_until_expiry", "chain_valid", "chain_days_until_expiry"},
+	"log_delivery": {"ingestion_lag_seconds", "acknowledged_events"},
+	"mysql": {"threads_connected", "threads_running", "connections", "aborted_connects", "max_used_connections", "queries", "slow_queries", "slow_queries_delta",
+		"innodb_buffer_pool_reads", "innodb_buffer_pool_read_requests", "cache_hit_rate", "slave_seconds_behind_master"},
+	"postgresql": {"numbackends", "xact_commit", "xact_rollback", "blks_read", "blks_hit", "deadlocks", "temp_files", "temp_bytes", "cache_hit_rate",
+		"replication_lag", "slow_queries"},
+	"redis": {"connected_clients", "blocked_clients", "rejected_connections", "used_memory", "evicted_keys", "expired_keys", "instantaneous_ops_per_sec",
+		"keyspace_hits", "keyspace_misses", "keyspace_hitrate", "cache_hit_rate", "master_last_io_seconds_ago", "lag"},
+	"memcached": {"curr_connections", "total_connections", "rejected_connections", "curr_items", "bytes", "evictions", "get_hits", "get_misses", "cache_hit_rate"},
+	"haproxy": {"current_sessions", "active_servers", "backup_servers", "up", "http_5xx", "retries", "redispatches", "sessions", "connection_errors",
+		"response_errors", "check_failures"},
+	"envoy":      {"http_5xx", "retries", "requests", "connections", "closed_connections", "connection_failures", "active_connections", "healthy_hosts", "hosts"},
+	"kafka":      {"consumer_lag", "consumer_lag_total", "consumer_lag_max"},
+	"port_check": {"success", "connect_time", "response_time"},
 }
 
 var Registered_Metrics_Windows = map[string][]string{
@@ -50,6 +89,17 @@ var Registered_Metrics_Windows = map[string][]string{
 }
 
 var DisableWinPerfCounters = map[string]bool{
-	"statsd":   true,
-	"procstat": true,
+	"statsd":       true,
+	"procstat":     true,
+	"tls_cert":     true,
+	"wmi":          true,
+	"log_delivery": true,
+	"mysql":        true,
+	"postgresql":   true,
+	"redis":        true,
+	"memcached":    true,
+	"haproxy":      true,
+	"envoy":        true,
+	"kafka":        true,
+	"port_check":   true,
 }
@@ -16,8 +16,13 @@ type Rule translator.Rule
 var ChildRule = map[string]Rule{}
 
 const (
-	SectionKey = "metrics"
-	OutputsKey = "outputs"
+	SectionKey    = "metrics"
+	OutputsKey    = "outputs"
+	ProcessorsKey = "processors"
+	FileOutputKey = "file_output"
+
+	// the file output of the logs section is the log backend named "file"
+	fileOutputAlias = "metrics_file"
 )
 
 func GetCurPath() string {
@@ -36,6 +41,7 @@ func (m *Metrics) ApplyRule(input interface{}) (returnKey string, returnVal inte
 	im := input.(map[string]interface{})
 	result := map[string]interface{}{}
 	outputPlugInfo := map[string]interface{}{}
+	var fileOutputInfo, prometheusOutputInfo interface{}
 
 	//Check if this plugin exist in the input instance
 	//If not, not process
@@ -51,8 +57,16 @@ func (m *Metrics) ApplyRule(input interface{}) (returnKey string, returnVal inte
 			if key != "" {
 				if key == OutputsKey {
 					outputPlugInfo = translator.MergeTwoUniqueMaps(outputPlugInfo, val.(map[string]interface{}))
+				} else if key == FileOutputKey {
+					fileOutputInfo = val
+				} else if key == PrometheusOutputKey {
+					prometheusOutputInfo = val
 				} else if key == "metric_decoration" {
 					addDecorations(key, val, outputPlugInfo)
+				} else if key == ProcessorsKey {
+					// the ec2tagger of the append dimensions and the anomaly processor
+					processors, _ := result[key].(map[string]interface{})
+					result[key] = translator.MergeTwoUniqueMaps(processors, val.(map[string]interface{}))
 				} else {
 					result[key] = val
 				}
@@ -60,7 +74,15 @@ func (m *Metrics) ApplyRule(input interface{}) (returnKey string, returnVal inte
 		}
 
 		cloudwatchInfo := map[string]interface{}{}
-		cloudwatchInfo["cloudwatch"] = []interface{}{outputPlugInfo}
+		if !util.IsFileOnly(im[SectionKey]) {
+			cloudwatchInfo["cloudwatch"] = []interface{}{outputPlugInfo}
+		}
+		if fileOutputInfo != nil {
+			cloudwatchInfo[util.FileOutputPluginName] = []interface{}{fileOutputInfo}
+		}
+		if prometheusOutputInfo != nil {
+			cloudwatchInfo[PrometheusOutputPluginName] = []interface{}{prometheusOutputInfo}
+		}
 		result["outputs"] = cloudwatchInfo
 		translator.SetMetricPath(result, SectionKey)
 		returnKey = SectionKey
@@ -88,6 +110,9 @@ func init() {
 	parent.RegisterWindowsRule(SectionKey, m)
 	ChildRule["globalcredentials"] = util.GetCredsRule(OutputsKey)
 	ChildRule["region"] = util.GetRegionRule(OutputsKey)
+	ChildRule["dualstack"] = util.GetDualStackEndpointRule(OutputsKey)
+	ChildRule["terminationnotice"] = util.GetFlushOnTerminationNoticeRule(OutputsKey)
+	ChildRule["file"] = util.GetFileOutputRule(FileOutputKey, fileOutputAlias)
 
 	mergeJsonUtil.MergeRuleMap[SectionKey] = m
 }
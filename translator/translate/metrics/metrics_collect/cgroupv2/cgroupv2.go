@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "cgroupv2" : {
+//       "measurement": [
+//           "memory_current",
+//           "memory_events_oom_kill",
+//           "cpu_nr_throttled"
+//       ],
+//       "paths": ["system.slice/*.service"],
+//       "self": true
+//   }
+//
+const SectionKey_CgroupV2 = "cgroupv2"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_CgroupV2 + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type CgroupV2 struct {
+}
+
+func (c *CgroupV2) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_CgroupV2]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_CgroupV2], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_CgroupV2], SectionKey_CgroupV2, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_CgroupV2
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	c := new(CgroupV2)
+	parent.RegisterLinuxRule(SectionKey_CgroupV2, c)
+}
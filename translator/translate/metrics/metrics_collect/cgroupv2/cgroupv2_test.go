@@ -0,0 +1,57 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	c := new(CgroupV2)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"cgroupv2": {"measurement": ["memory_current", "cgroupv2_memory_events_oom_kill"]}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass": []string{"memory_current", "memory_events_oom_kill"},
+		"self":      false,
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestFullConfig(t *testing.T) {
+	c := new(CgroupV2)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"cgroupv2": {
+					"measurement": ["memory_current", "cpu_nr_throttled", "cpu_throttled_usec"],
+					"metrics_collection_interval": 120,
+					"mount_path": "/rootfs/sys/fs/cgroup",
+					"paths": ["system.slice/*.service", "kubepods.slice"],
+					"self": true
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass":  []string{"memory_current", "cpu_nr_throttled", "cpu_throttled_usec"},
+		"interval":   "120s",
+		"mount_path": "/rootfs/sys/fs/cgroup",
+		"paths":      []interface{}{"system.slice/*.service", "kubepods.slice"},
+		"self":       true,
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(CgroupV2)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"cgroupv2": {"measurement": ["memory_rss"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+type MountPath struct {
+}
+
+const SectionKey_MountPath = "mount_path"
+
+func (obj *MountPath) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_MountPath]; ok {
+		returnKey = SectionKey_MountPath
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(MountPath)
+	RegisterRule(SectionKey_MountPath, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+type Paths struct {
+}
+
+const SectionKey_Paths = "paths"
+
+func (obj *Paths) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Paths]; ok {
+		returnKey = SectionKey_Paths
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Paths)
+	RegisterRule(SectionKey_Paths, obj)
+}
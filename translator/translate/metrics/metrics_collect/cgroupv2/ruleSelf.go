@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package cgroupv2
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type Self struct {
+}
+
+const SectionKey_Self = "self"
+
+func (obj *Self) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultCase(SectionKey_Self, false, input)
+	return
+}
+
+func init() {
+	obj := new(Self)
+	RegisterRule(SectionKey_Self, obj)
+}
@@ -6,6 +6,7 @@ package collected
 import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
 )
 
 //
@@ -54,6 +55,7 @@ func (obj *CollectD) ApplyRule(input interface{}) (returnKey string, returnVal i
 		//If exists, process it
 		//Check if there are some config entry with rules applied
 		result = translator.ProcessRuleToApply(m[SectionKey], ChildRule, result)
+		util.SetCollectionJitter(m[SectionKey].(map[string]interface{}), result)
 		resArray = append(resArray, result)
 		returnKey = SectionMappedKey
 		returnVal = resArray
@@ -5,8 +5,12 @@ package collected
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,3 +66,28 @@ func TestCollectD_MinimumConfig(t *testing.T) {
 
 	assert.Equal(t, expect, actual)
 }
+
+func TestCollectD_BindAddress(t *testing.T) {
+	agent.Global_Config.BindAddress = "::1"
+	defer func() { agent.Global_Config.BindAddress = "" }()
+
+	obj := new(CollectD)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"collectd": {}}`), &input)
+	assert.NoError(t, err)
+
+	_, actual := obj.ApplyRule(input)
+	assert.Equal(t, "udp://[::1]:25826", actual.([]interface{})[0].(map[string]interface{})["service_address"])
+}
+
+func TestCollectD_TypesDBDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typesdb")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.db"), []byte("plugintype value:GAUGE:0:U\n"), 0644))
+
+	obj := new(TypesDB)
+	input := map[string]interface{}{"collectd_typesdb": []interface{}{"/missing/types.db", dir}}
+	_, actual := obj.ApplyRule(input)
+	assert.Equal(t, []interface{}{"/missing/types.db", filepath.Join(dir, "plugin.db")}, actual)
+}
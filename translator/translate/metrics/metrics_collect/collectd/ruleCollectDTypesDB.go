@@ -4,6 +4,9 @@
 package collected
 
 import (
+	"os"
+
+	"github.com/aws/amazon-cloudwatch-agent/plugins/parsers/collectd"
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 )
 
@@ -12,8 +15,31 @@ type TypesDB struct {
 
 const SectionKey_TypesDB = "collectd_typesdb"
 
+// ApplyRule lists the typesdb files of the configured files and directories, since the collectd parser only loads
+// files. The agent reloads the configured paths, passed in the env config, when they change.
 func (obj *TypesDB) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
 	returnKey, returnVal = translator.DefaultCase(SectionKey_TypesDB, []interface{}{"/usr/share/collectd/types.db"}, input)
+	paths, ok := returnVal.([]interface{})
+	if !ok {
+		return
+	}
+	files := []interface{}{}
+	for _, p := range paths {
+		path, ok := p.(string)
+		if !ok {
+			files = append(files, p)
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			// e.g. translated before collectd is installed, it is left to the collectd parser
+			files = append(files, path)
+			continue
+		}
+		for _, file := range collectd.TypesDBFiles([]string{path}) {
+			files = append(files, file)
+		}
+	}
+	returnVal = files
 	return
 }
 
@@ -5,6 +5,7 @@ package collected
 
 import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
 )
 
 type ServiceAddress struct {
@@ -13,7 +14,7 @@ type ServiceAddress struct {
 const SectionKey_ServiceAddress = "service_address"
 
 func (obj *ServiceAddress) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
-	returnKey, returnVal = translator.DefaultCase(SectionKey_ServiceAddress, "udp://127.0.0.1:25826", input)
+	returnKey, returnVal = translator.DefaultCase(SectionKey_ServiceAddress, "udp://"+agent.ListenAddress("127.0.0.1", 25826), input)
 	return
 }
 
@@ -25,7 +25,20 @@ func GetObjectPath(object string) string {
 func (c *customizedMetric) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
 	win_Perf_Counters_Array := []interface{}{}
 
-	inputmap := input.(map[string]interface{})
+	inputmap := map[string]interface{}{}
+	for objectName, objectConfig := range input.(map[string]interface{}) {
+		if _, ok := presets[objectName]; ok {
+			for presetObjectName, presetObjectConfig := range expandPreset(objectName, objectConfig) {
+				inputmap[presetObjectName] = presetObjectConfig
+			}
+		}
+	}
+	// the objects configured explicitly override the ones of the presets
+	for objectName, objectConfig := range input.(map[string]interface{}) {
+		if _, ok := presets[objectName]; !ok {
+			inputmap[objectName] = objectConfig
+		}
+	}
 	inputObjectNames := []string{}
 	for objectName := range inputmap {
 		if config.DisableWinPerfCounters[objectName] {
@@ -57,6 +70,7 @@ func (c *customizedMetric) ApplyRule(input interface{}) (returnKey string, retur
 func addToWinPerfArray(input interface{}, win_Perf_Counters_Array []interface{}) []interface{} {
 	inputmap := input.(map[string]interface{})
 	var input_interval string
+	var input_jitter string
 	var input_tag interface{}
 	var input_object interface{}
 	// extract "key" to see if this win_perf_counters can be merged with others
@@ -66,6 +80,11 @@ func addToWinPerfArray(input interface{}, win_Perf_Counters_Array []interface{})
 		input_interval = val.(string)
 	}
 
+	// extract collection jitter:
+	if val, ok := inputmap[util.Collection_Jitter_Key]; ok {
+		input_jitter = val.(string)
+	}
+
 	// extract tags:
 	if val, ok := inputmap["tags"]; ok {
 		input_tag = val
@@ -78,24 +97,28 @@ func addToWinPerfArray(input interface{}, win_Perf_Counters_Array []interface{})
 
 	// check if this can be merged with existing win_perf_counters
 	// otherwise create an new entry
-	if !mergeIfMatch(input_interval, input_tag, input_object, win_Perf_Counters_Array) {
+	if !mergeIfMatch(input_interval, input_jitter, input_tag, input_object, win_Perf_Counters_Array) {
 		return append(win_Perf_Counters_Array, input)
 	}
 	return win_Perf_Counters_Array
 }
 
-func mergeIfMatch(inputInterval string, inputTags interface{}, inputObject interface{}, win_Perf_Counters_Array []interface{}) bool {
+func mergeIfMatch(inputInterval string, inputJitter string, inputTags interface{}, inputObject interface{}, win_Perf_Counters_Array []interface{}) bool {
 	for _, perf_counter := range win_Perf_Counters_Array {
 		var target_interval string
+		var target_jitter string
 		var target_tags interface{}
 		pm := perf_counter.(map[string]interface{})
 		if val, ok := pm["interval"]; ok {
 			target_interval = val.(string)
 		}
+		if val, ok := pm[util.Collection_Jitter_Key]; ok {
+			target_jitter = val.(string)
+		}
 		if val, ok := pm["tags"]; ok {
 			target_tags = val
 		}
-		if target_interval == inputInterval && reflect.DeepEqual(inputTags, target_tags) {
+		if target_interval == inputInterval && target_jitter == inputJitter && reflect.DeepEqual(inputTags, target_tags) {
 			pm["object"] = append(pm["object"].([]interface{}), inputObject.([]interface{})...)
 			return true
 		}
@@ -0,0 +1,119 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package customizedmetrics
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+// presetObject is a performance counter object of a preset, its instances are all collected unless the preset
+// lists its resources
+type presetObject struct {
+	name      string
+	counters  []string
+	instances bool
+}
+
+// The presets expand into the counter objects of a role of the host, so that it can be covered without listing them.
+//
+//	"hyperv": {
+//	    "measurement": ["% Guest Run Time", "Latency"],
+//	    "metrics_collection_interval": 60,
+//	    "resources": ["*"],
+//	    "append_dimensions": {...}
+//	}
+var presets = map[string][]presetObject{
+	// Hyper-V hosts: the CPU wait of the virtual processors, the dynamic memory pressure and the VHD latency
+	"hyperv": {
+		{name: "Hyper-V Hypervisor Logical Processor", counters: []string{"% Total Run Time"}, instances: true},
+		{name: "Hyper-V Hypervisor Virtual Processor", counters: []string{"% Guest Run Time", "% Total Run Time", "CPU Wait Time Per Dispatch"}, instances: true},
+		{name: "Hyper-V Dynamic Memory Balancer", counters: []string{"Available Memory", "Average Pressure"}, instances: true},
+		{name: "Hyper-V Dynamic Memory VM", counters: []string{"Average Pressure", "Current Pressure", "Guest Visible Physical Memory", "Physical Memory"}, instances: true},
+		{name: "Hyper-V Virtual Storage Device", counters: []string{"Latency", "Queue Length", "Read Bytes/sec", "Read Operations/Sec", "Write Bytes/sec", "Write Operations/Sec"}, instances: true},
+		{name: "Hyper-V Virtual Machine Health Summary", counters: []string{"Health Critical", "Health Ok"}},
+	},
+	// Failover cluster nodes: the health of the resources and of the cluster network, and the CSV latency
+	"failover_cluster": {
+		{name: "Cluster Resource Control Manager", counters: []string{"Groups Online", "RHS Processes", "RHS Restarts"}},
+		{name: "Cluster Resources", counters: []string{"Resource Failure", "Resource Failure Deadlock"}, instances: true},
+		{name: "Cluster Network Messages", counters: []string{"Messages Outstanding"}, instances: true},
+		{name: "Cluster Network Reconnections", counters: []string{"Reconnect Count"}, instances: true},
+		{name: "Cluster CSV File System", counters: []string{"Read Latency", "Write Latency", "Reads/sec", "Writes/sec"}, instances: true},
+	},
+	// Domain controllers: the LDAP bind time and load, the replication queue and the authentications
+	"active_directory": {
+		{name: "NTDS", counters: []string{"ATQ Outstanding Queued Requests", "DRA Inbound Bytes Total/sec", "DRA Outbound Bytes Total/sec",
+			"DRA Pending Replication Operations", "DRA Pending Replication Synchronizations", "DS Threads in Use", "LDAP Bind Time",
+			"LDAP Client Sessions", "LDAP Searches/sec", "LDAP Successful Binds/sec"}},
+		{name: "Security System-Wide Statistics", counters: []string{"Kerberos Authentications", "NTLM Authentications"}},
+	},
+	// DNS servers: the query and response rates, the recursion failures and the zone transfers
+	"dns_server": {
+		{name: "DNS", counters: []string{"Dynamic Update Received/sec", "Recursive Queries/sec", "Recursive Query Failure/sec",
+			"TCP Query Received/sec", "Total Query Received/sec", "Total Response Sent/sec", "UDP Query Received/sec",
+			"Zone Transfer Failure", "Zone Transfer Success"}},
+	},
+}
+
+// expandPreset returns the object configs of the preset, they share its interval and dimensions. The counters are
+// all collected unless the preset lists some of them as its measurement, like the metrics of cpu.
+func expandPreset(preset string, input interface{}) map[string]interface{} {
+	inputMap, _ := input.(map[string]interface{})
+	selected := selectedCounters(preset, inputMap)
+	objects := map[string]interface{}{}
+	for _, object := range presets[preset] {
+		measurement := []interface{}{}
+		for _, counter := range object.counters {
+			if selected == nil || selected[counter] {
+				measurement = append(measurement, counter)
+			}
+		}
+		if len(measurement) == 0 {
+			continue
+		}
+		objectConfig := map[string]interface{}{util.Measurement_Key: measurement}
+		if object.instances {
+			objectConfig[util.Resource_Key] = []interface{}{util.Asterisk_Key}
+			if val, ok := inputMap[util.Resource_Key]; ok {
+				objectConfig[util.Resource_Key] = val
+			}
+		}
+		for _, key := range []string{util.Collect_Interval_Key, util.Append_Dimensions_Key} {
+			if val, ok := inputMap[key]; ok {
+				objectConfig[key] = val
+			}
+		}
+		objects[object.name] = objectConfig
+	}
+	return objects
+}
+
+// selectedCounters returns the counters listed as the measurement of the preset, nil when it has none. The counters
+// which are not in the preset are reported.
+func selectedCounters(preset string, inputMap map[string]interface{}) map[string]bool {
+	list, ok := inputMap[util.Measurement_Key].([]interface{})
+	if !ok {
+		return nil
+	}
+	known := map[string]bool{}
+	for _, object := range presets[preset] {
+		for _, counter := range object.counters {
+			known[counter] = true
+		}
+	}
+	selected := map[string]bool{}
+	for _, item := range list {
+		counter, _ := item.(string)
+		if !known[counter] {
+			translator.AddErrorMessages(GetObjectPath(preset)+util.Measurement_Key,
+				fmt.Sprintf("%v is not a counter of %v", item, preset))
+			continue
+		}
+		selected[counter] = true
+	}
+	return selected
+}
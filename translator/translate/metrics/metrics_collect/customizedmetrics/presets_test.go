@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package customizedmetrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func objectsOf(val interface{}) map[string]map[string]interface{} {
+	objects := map[string]map[string]interface{}{}
+	for _, perfC := range val.([]interface{}) {
+		for _, object := range perfC.(map[string]interface{})["object"].(util.MetricArray) {
+			o := object.(map[string]interface{})
+			objects[o["ObjectName"].(string)] = o
+		}
+	}
+	return objects
+}
+
+func TestHyperVPreset(t *testing.T) {
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hyperv": {"metrics_collection_interval": 120, "resources": ["vm1"]},
+		"Memory": {"measurement": ["Available Bytes"]}
+	}`), &input))
+
+	key, val := new(customizedMetric).ApplyRule(input)
+	assert.Equal(t, Win_Rerf_Counters_Key, key)
+	// the Memory object has another interval, so it goes to another win_perf_counters
+	assert.Len(t, val, 2)
+	objects := objectsOf(val)
+	assert.Len(t, objects, len(presets["hyperv"])+1)
+
+	vhd := objects["Hyper-V Virtual Storage Device"]
+	assert.Equal(t, []string{"Latency", "Queue Length", "Read Bytes/sec", "Read Operations/Sec", "Write Bytes/sec", "Write Operations/Sec"}, vhd["Counters"])
+	assert.Equal(t, []interface{}{"vm1"}, vhd["Instances"])
+	assert.Equal(t, []string{"------"}, objects["Hyper-V Virtual Machine Health Summary"]["Instances"])
+	for _, perfC := range val.([]interface{}) {
+		mp := perfC.(map[string]interface{})
+		if len(mp["object"].(util.MetricArray)) > 1 {
+			assert.Equal(t, "120s", mp["interval"])
+		}
+	}
+}
+
+func TestPresetObjectOverride(t *testing.T) {
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"failover_cluster": {},
+		"Cluster CSV File System": {"measurement": ["Read Latency"], "resources": ["Volume1"]}
+	}`), &input))
+
+	_, val := new(customizedMetric).ApplyRule(input)
+	objects := objectsOf(val)
+	assert.Len(t, objects, len(presets["failover_cluster"]))
+	csv := objects["Cluster CSV File System"]
+	assert.Equal(t, []string{"Read Latency"}, csv["Counters"])
+	assert.Equal(t, []interface{}{"Volume1"}, csv["Instances"])
+	assert.Equal(t, []string{"*"}, objects["Cluster Network Messages"]["Instances"])
+}
+
+func TestPresetMeasurement(t *testing.T) {
+	translator.ResetMessages()
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"active_directory": {"measurement": ["LDAP Bind Time", "DRA Pending Replication Synchronizations", "Unknown Counter"]},
+		"dns_server": {}
+	}`), &input))
+
+	_, val := new(customizedMetric).ApplyRule(input)
+	objects := objectsOf(val)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, []string{"DRA Pending Replication Synchronizations", "LDAP Bind Time"}, objects["NTDS"]["Counters"])
+	assert.Contains(t, objects["DNS"]["Counters"], "Total Query Received/sec")
+	assert.Equal(t, []string{"------"}, objects["DNS"]["Instances"])
+	assert.Len(t, translator.ErrorMessages, 1)
+	assert.Contains(t, translator.ErrorMessages[0], "Unknown Counter is not a counter of active_directory")
+	translator.ResetMessages()
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+	translateutil "github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "disk_latency" : {
+//       "resources": [
+//           "nvme0n1"
+//       ],
+//       "measurement": [
+//           "read_await_p99",
+//           "write_await_p99"
+//       ]
+//   }
+//
+const SectionKey_DiskLatency = "disk_latency"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_DiskLatency + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type DiskLatency struct {
+}
+
+func (d *DiskLatency) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_DiskLatency]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_DiskLatency], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_DiskLatency], SectionKey_DiskLatency, GetCurPath(), result)
+		if hasValidMetric && result[SectionKey_LatencyOutput] == LatencyOutputDistribution {
+			addAggregationInterval(m[SectionKey_DiskLatency], result)
+		}
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_DiskLatency
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+// addAggregationInterval adds the aggregation interval tag of the distributions of the awaits to the tags of the
+// append_dimensions, the cloudwatch output merges the distributions of the interval before publishing them
+func addAggregationInterval(input interface{}, result map[string]interface{}) {
+	_, val := util.ProcessMetricsAggregationInterval(input, "60s", SectionKey_DiskLatency)
+	aggregationTags, ok := val.(map[string]interface{})
+	if !ok {
+		return
+	}
+	tags, ok := result[util.Append_Dimensions_Mapped_Key].(map[string]interface{})
+	if !ok {
+		result[util.Append_Dimensions_Mapped_Key] = aggregationTags
+		return
+	}
+	// the cloudwatch output sets the storage resolution from the aggregation interval
+	delete(tags, translateutil.High_Resolution_Tag_Key)
+	for k, v := range aggregationTags {
+		tags[k] = v
+	}
+}
+
+func init() {
+	d := new(DiskLatency)
+	parent.RegisterLinuxRule(SectionKey_DiskLatency, d)
+}
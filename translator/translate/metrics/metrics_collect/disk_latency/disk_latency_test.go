@@ -0,0 +1,90 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	d := new(DiskLatency)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"disk_latency": {
+					"resources": ["nvme0n1", "xvd*"],
+					"measurement": ["read_await_p99", "disk_latency_write_await_p99"],
+					"metrics_collection_interval": 120,
+					"sample_interval": 2,
+					"proc_path": "/rootfs/proc",
+					"latency_output": "distribution"
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := d.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"devices":         []interface{}{"nvme0n1", "xvd*"},
+		"fieldpass":       []string{"read_await_p99", "write_await_p99"},
+		"interval":        "120s",
+		"sample_interval": "2s",
+		"proc_path":       "/rootfs/proc",
+		"latency_output":  "distribution",
+		"tags":            map[string]interface{}{"aws:AggregationInterval": "60s"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestDistributionAggregationInterval(t *testing.T) {
+	d := new(DiskLatency)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"disk_latency": {
+					"measurement": ["read_await"],
+					"metrics_collection_interval": 10,
+					"latency_output": "distribution",
+					"metrics_aggregation_interval": 300,
+					"append_dimensions": {"Cluster": "db"}
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := d.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{"Cluster": "db", "aws:AggregationInterval": "300s"}, actual.([]interface{})[0].(map[string]interface{})["tags"])
+
+	// the distributions of every collection are published with 0
+	e = json.Unmarshal([]byte(`{"disk_latency": {
+					"measurement": ["read_await"],
+					"latency_output": "distribution",
+					"metrics_aggregation_interval": 0
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual = d.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{"aws:StorageResolution": "true"}, actual.([]interface{})[0].(map[string]interface{})["tags"])
+}
+
+func TestDefaultConfig(t *testing.T) {
+	d := new(DiskLatency)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"disk_latency": {
+					"resources": ["*"],
+					"measurement": ["read_await_p99"],
+					"metrics_collection_interval": 120
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := d.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass":       []string{"read_await_p99"},
+		"interval":        "120s",
+		"sample_interval": "1s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	d := new(DiskLatency)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"disk_latency": {"measurement": ["await"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := d.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
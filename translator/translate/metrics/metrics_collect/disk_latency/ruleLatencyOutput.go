@@ -0,0 +1,27 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+type LatencyOutput struct {
+}
+
+const (
+	SectionKey_LatencyOutput = "latency_output"
+	// LatencyOutputDistribution publishes the awaits as distributions, merged over the aggregation interval
+	LatencyOutputDistribution = "distribution"
+)
+
+func (obj *LatencyOutput) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_LatencyOutput]; ok {
+		returnKey = SectionKey_LatencyOutput
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(LatencyOutput)
+	RegisterRule(SectionKey_LatencyOutput, obj)
+}
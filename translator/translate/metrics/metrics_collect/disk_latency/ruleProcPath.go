@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+type ProcPath struct {
+}
+
+const SectionKey_ProcPath = "proc_path"
+
+func (obj *ProcPath) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_ProcPath]; ok {
+		returnKey = SectionKey_ProcPath
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(ProcPath)
+	RegisterRule(SectionKey_ProcPath, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package disk_latency
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type SampleInterval struct {
+}
+
+const SectionKey_SampleInterval = "sample_interval"
+
+func (obj *SampleInterval) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultTimeIntervalCase(SectionKey_SampleInterval, float64(1), input)
+	return
+}
+
+func init() {
+	obj := new(SampleInterval)
+	RegisterRule(SectionKey_SampleInterval, obj)
+}
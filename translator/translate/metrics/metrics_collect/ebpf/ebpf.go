@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "ebpf" : {
+//       "measurement": [
+//           "tcp_connect_latency",
+//           "tcp_retransmits",
+//           "dns_latency"
+//       ],
+//       "max_destinations": 20,
+//       "dns_timeout": 5
+//   }
+//
+const SectionKey_EBPF = "ebpf"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_EBPF + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type EBPF struct {
+}
+
+func (e *EBPF) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_EBPF]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_EBPF], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_EBPF], SectionKey_EBPF, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_EBPF
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	e := new(EBPF)
+	parent.RegisterLinuxRule(SectionKey_EBPF, e)
+}
@@ -0,0 +1,57 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	e := new(EBPF)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"ebpf": {
+					"measurement": ["tcp_connect_latency", "ebpf_dns_latency", "dns_timeouts"],
+					"probes": ["tcp", "dns"],
+					"max_destinations": 20,
+					"dns_timeout": 2,
+					"tracefs_path": "/sys/kernel/debug/tracing",
+					"metrics_collection_interval": 120
+					}}`), &input)
+	assert.NoError(t, err)
+	_, actual := e.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass":        []string{"tcp_connect_latency", "dns_latency", "dns_timeouts"},
+		"interval":         "120s",
+		"probes":           []interface{}{"tcp", "dns"},
+		"max_destinations": 20,
+		"dns_timeout":      "2s",
+		"tracefs_path":     "/sys/kernel/debug/tracing",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestProbesOfMeasurements(t *testing.T) {
+	e := new(EBPF)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"ebpf": {"measurement": ["tcp_connect_latency", {"name": "tcp_retransmits", "unit": "Count"}]}}`), &input)
+	assert.NoError(t, err)
+	_, actual := e.ApplyRule(input)
+	result := actual.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []string{"tcp"}, result["probes"])
+	assert.Equal(t, 50, result["max_destinations"])
+	assert.Equal(t, "5s", result["dns_timeout"])
+}
+
+func TestNoValidMetric(t *testing.T) {
+	e := new(EBPF)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"ebpf": {"measurement": ["udp_latency"]}}`), &input)
+	assert.NoError(t, err)
+	key, _ := e.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
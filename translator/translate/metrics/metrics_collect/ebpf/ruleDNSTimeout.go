@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type DNSTimeout struct {
+}
+
+const SectionKey_DNSTimeout = "dns_timeout"
+
+func (obj *DNSTimeout) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultTimeIntervalCase(SectionKey_DNSTimeout, float64(5), input)
+	return
+}
+
+func init() {
+	obj := new(DNSTimeout)
+	RegisterRule(SectionKey_DNSTimeout, obj)
+}
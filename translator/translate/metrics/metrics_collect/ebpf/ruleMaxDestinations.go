@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type MaxDestinations struct {
+}
+
+const SectionKey_MaxDestinations = "max_destinations"
+
+func (obj *MaxDestinations) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultIntegralCase(SectionKey_MaxDestinations, float64(50), input)
+	return
+}
+
+func init() {
+	obj := new(MaxDestinations)
+	RegisterRule(SectionKey_MaxDestinations, obj)
+}
@@ -0,0 +1,52 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+import (
+	"strings"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+type Probes struct {
+}
+
+const SectionKey_Probes = "probes"
+
+var probePrefixes = []string{"tcp", "dns"}
+
+// The probes are those of the measurements when they are not set, the DNS probe opens a packet socket whose traffic
+// is not needed for the TCP measurements only.
+func (obj *Probes) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Probes]; ok {
+		return SectionKey_Probes, val
+	}
+	measurements, ok := m[util.Measurement_Key].([]interface{})
+	if !ok {
+		return
+	}
+	probes := []string{}
+	for _, prefix := range probePrefixes {
+		for _, measurement := range measurements {
+			name, _ := measurement.(string)
+			if metric, ok := measurement.(map[string]interface{}); ok {
+				name, _ = metric["name"].(string)
+			}
+			if strings.HasPrefix(strings.TrimPrefix(name, SectionKey_EBPF+"_"), prefix+"_") {
+				probes = append(probes, prefix)
+				break
+			}
+		}
+	}
+	if len(probes) > 0 {
+		returnKey, returnVal = SectionKey_Probes, probes
+	}
+	return
+}
+
+func init() {
+	obj := new(Probes)
+	RegisterRule(SectionKey_Probes, obj)
+}
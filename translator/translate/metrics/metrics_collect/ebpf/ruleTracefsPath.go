@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ebpf
+
+type TracefsPath struct {
+}
+
+const SectionKey_TracefsPath = "tracefs_path"
+
+func (obj *TracefsPath) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_TracefsPath]; ok {
+		returnKey = SectionKey_TracefsPath
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(TracefsPath)
+	RegisterRule(SectionKey_TracefsPath, obj)
+}
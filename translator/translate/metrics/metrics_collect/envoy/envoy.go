@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package envoy
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "envoy" : {
+//       "measurement": [
+//           "http_5xx",
+//           "retries",
+//           "healthy_hosts"
+//       ],
+//       "urls": ["http://localhost:9901/stats"]
+//   }
+//
+const SectionKey_Envoy = "envoy"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_Envoy + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type Envoy struct {
+}
+
+func (obj *Envoy) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_Envoy]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_Envoy], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_Envoy], SectionKey_Envoy, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_Envoy
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(Envoy)
+	parent.RegisterLinuxRule(SectionKey_Envoy, obj)
+	parent.RegisterDarwinRule(SectionKey_Envoy, obj)
+	parent.RegisterWindowsRule(SectionKey_Envoy, obj)
+}
@@ -0,0 +1,37 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package envoy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	c := new(Envoy)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"envoy": {
+					"urls": ["http://localhost:9901/stats"],
+					"measurement": ["http_5xx", "retries", "healthy_hosts"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"urls":      []interface{}{"http://localhost:9901/stats"},
+		"fieldpass": []string{"http_5xx", "retries", "healthy_hosts"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(Envoy)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"envoy": {"measurement": ["upstream_rq_5xx"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package envoy
+
+type URLs struct {
+}
+
+const SectionKey_URLs = "urls"
+
+func (obj *URLs) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_URLs]; ok {
+		returnKey = SectionKey_URLs
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(URLs)
+	RegisterRule(SectionKey_URLs, obj)
+}
@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package haproxy
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "haproxy" : {
+//       "measurement": [
+//           "http_5xx",
+//           "retries",
+//           "up"
+//       ],
+//       "servers": ["http://127.0.0.1:1936/haproxy?stats", "socket:/run/haproxy/admin.sock"],
+//       "secret_id": "prod/haproxy-stats"
+//   }
+//
+const SectionKey_HAProxy = "haproxy"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_HAProxy + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type HAProxy struct {
+}
+
+func (obj *HAProxy) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_HAProxy]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_HAProxy], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_HAProxy], SectionKey_HAProxy, GetCurPath(), result)
+		if hasValidMetric {
+			//The basic authentication of the stats pages is fetched from Secrets Manager at runtime
+			util.ProcessSecretID(m[SectionKey_HAProxy], result)
+			resArray = append(resArray, result)
+			returnKey = SectionKey_HAProxy
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(HAProxy)
+	parent.RegisterLinuxRule(SectionKey_HAProxy, obj)
+	parent.RegisterDarwinRule(SectionKey_HAProxy, obj)
+	parent.RegisterWindowsRule(SectionKey_HAProxy, obj)
+}
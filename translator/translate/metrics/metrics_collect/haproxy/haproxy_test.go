@@ -0,0 +1,43 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package haproxy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{}
+	c := new(HAProxy)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"haproxy": {
+					"servers": ["http://127.0.0.1:1936/haproxy?stats", "socket:/run/haproxy/admin.sock"],
+					"secret_id": "prod/haproxy-stats",
+					"measurement": ["http_5xx", "retries", "up"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"servers":   []interface{}{"http://127.0.0.1:1936/haproxy?stats", "socket:/run/haproxy/admin.sock"},
+		"secret_id": "prod/haproxy-stats",
+		"region":    "us-east-1",
+		"fieldpass": []string{"http_5xx", "retries", "up"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(HAProxy)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"haproxy": {"measurement": ["hrsp_5xx"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,67 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "ipmi" : {
+//       "measurement": [
+//           "reading",
+//           "critical_breach"
+//       ],
+//       "servers": ["admin:password@lanplus(10.0.0.10)"],
+//       "use_sudo": true
+//   }
+//
+const SectionKey_Ipmi = "ipmi"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_Ipmi + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type Ipmi struct {
+}
+
+func (i *Ipmi) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_Ipmi]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_Ipmi], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_Ipmi], SectionKey_Ipmi, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_Ipmi
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	i := new(Ipmi)
+	parent.RegisterLinuxRule(SectionKey_Ipmi, i)
+}
@@ -0,0 +1,64 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	i := new(Ipmi)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"ipmi": {
+					"measurement": ["reading", "ipmi_critical_breach"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := i.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass": []string{"reading", "critical_breach"},
+		"use_sudo":  false,
+		"timeout":   "20s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestFullConfig(t *testing.T) {
+	i := new(Ipmi)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"ipmi": {
+					"measurement": ["reading", "non_critical_breach", "critical_breach", "state"],
+					"metrics_collection_interval": 120,
+					"path": "/usr/sbin/ipmitool",
+					"use_sudo": true,
+					"privilege": "USER",
+					"servers": ["admin:password@lanplus(10.0.0.10)"],
+					"timeout": 10
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := i.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass": []string{"reading", "non_critical_breach", "critical_breach", "state"},
+		"interval":  "120s",
+		"path":      "/usr/sbin/ipmitool",
+		"use_sudo":  true,
+		"privilege": "USER",
+		"servers":   []interface{}{"admin:password@lanplus(10.0.0.10)"},
+		"timeout":   "10s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	i := new(Ipmi)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"ipmi": {"measurement": ["unknown"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := i.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+type Path struct {
+}
+
+const SectionKey_Path = "path"
+
+func (obj *Path) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Path]; ok {
+		returnKey = SectionKey_Path
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Path)
+	RegisterRule(SectionKey_Path, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+type Privilege struct {
+}
+
+const SectionKey_Privilege = "privilege"
+
+func (obj *Privilege) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Privilege]; ok {
+		returnKey = SectionKey_Privilege
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Privilege)
+	RegisterRule(SectionKey_Privilege, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+type Servers struct {
+}
+
+const SectionKey_Servers = "servers"
+
+func (obj *Servers) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Servers]; ok {
+		returnKey = SectionKey_Servers
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Servers)
+	RegisterRule(SectionKey_Servers, obj)
+}
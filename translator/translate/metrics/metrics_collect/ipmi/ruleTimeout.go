@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type Timeout struct {
+}
+
+const SectionKey_Timeout = "timeout"
+
+func (obj *Timeout) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultTimeIntervalCase(SectionKey_Timeout, float64(20), input)
+	return
+}
+
+func init() {
+	obj := new(Timeout)
+	RegisterRule(SectionKey_Timeout, obj)
+}
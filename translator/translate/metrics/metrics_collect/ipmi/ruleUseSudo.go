@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package ipmi
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type UseSudo struct {
+}
+
+const SectionKey_UseSudo = "use_sudo"
+
+func (obj *UseSudo) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultCase(SectionKey_UseSudo, false, input)
+	return
+}
+
+func init() {
+	obj := new(UseSudo)
+	RegisterRule(SectionKey_UseSudo, obj)
+}
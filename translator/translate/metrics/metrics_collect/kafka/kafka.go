@@ -0,0 +1,79 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "kafka" : {
+//       "measurement": [
+//           "consumer_lag_total",
+//           "consumer_lag_max"
+//       ],
+//       "brokers": ["b-1.example.abc123.c2.kafka.us-east-1.amazonaws.com:9098"],
+//       "consumer_groups": ["orders-*"],
+//       "sasl_mechanism": "AWS_MSK_IAM"
+//   }
+//
+const (
+	SectionKey_Kafka = "kafka"
+	IAM_Mechanism    = "AWS_MSK_IAM"
+)
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_Kafka + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type Kafka struct {
+}
+
+func (obj *Kafka) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_Kafka]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_Kafka], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_Kafka], SectionKey_Kafka, GetCurPath(), result)
+		if hasValidMetric {
+			//The credentials of SCRAM and PLAIN are fetched from Secrets Manager at runtime
+			util.ProcessSecretID(m[SectionKey_Kafka], result)
+			//The IAM authentication of MSK signs with the credentials of the agent
+			if result[SectionKey_SASLMechanism] == IAM_Mechanism {
+				util.ProcessAgentCredentials(result)
+			}
+			resArray = append(resArray, result)
+			returnKey = SectionKey_Kafka
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(Kafka)
+	parent.RegisterLinuxRule(SectionKey_Kafka, obj)
+	parent.RegisterDarwinRule(SectionKey_Kafka, obj)
+	parent.RegisterWindowsRule(SectionKey_Kafka, obj)
+}
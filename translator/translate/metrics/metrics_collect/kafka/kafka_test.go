@@ -0,0 +1,78 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIAMConfig(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{"profile": "msk"}
+	defer func() { agent.Global_Config.Credentials = map[string]interface{}{} }()
+	c := new(Kafka)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"kafka": {
+					"brokers": ["b-1.example.kafka.us-east-1.amazonaws.com:9098"],
+					"consumer_groups": ["orders-*"],
+					"sasl_mechanism": "AWS_MSK_IAM",
+					"measurement": ["consumer_lag_total", "consumer_lag_max"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"brokers":         []interface{}{"b-1.example.kafka.us-east-1.amazonaws.com:9098"},
+		"consumer_groups": []interface{}{"orders-*"},
+		"sasl_mechanism":  "AWS_MSK_IAM",
+		"per_partition":   true,
+		"region":          "us-east-1",
+		"profile":         "msk",
+		"fieldpass":       []string{"consumer_lag_total", "consumer_lag_max"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestSCRAMConfig(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{}
+	c := new(Kafka)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"kafka": {
+					"brokers": ["b-1.example.kafka.us-east-1.amazonaws.com:9096"],
+					"topics": ["orders"],
+					"per_partition": false,
+					"sasl_mechanism": "SCRAM-SHA-512",
+					"secret_id": "AmazonMSK_monitor",
+					"tls": true,
+					"measurement": ["consumer_lag_total"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"brokers":        []interface{}{"b-1.example.kafka.us-east-1.amazonaws.com:9096"},
+		"topics":         []interface{}{"orders"},
+		"per_partition":  false,
+		"sasl_mechanism": "SCRAM-SHA-512",
+		"secret_id":      "AmazonMSK_monitor",
+		"region":         "us-east-1",
+		"tls":            true,
+		"fieldpass":      []string{"consumer_lag_total"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(Kafka)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"kafka": {"brokers": ["localhost:9092"], "measurement": ["lag"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+type Brokers struct {
+}
+
+const SectionKey_Brokers = "brokers"
+
+func (obj *Brokers) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Brokers]; ok {
+		returnKey = SectionKey_Brokers
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Brokers)
+	RegisterRule(SectionKey_Brokers, obj)
+}
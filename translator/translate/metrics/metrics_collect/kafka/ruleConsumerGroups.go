@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+type ConsumerGroups struct {
+}
+
+const SectionKey_ConsumerGroups = "consumer_groups"
+
+func (obj *ConsumerGroups) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_ConsumerGroups]; ok {
+		returnKey = SectionKey_ConsumerGroups
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(ConsumerGroups)
+	RegisterRule(SectionKey_ConsumerGroups, obj)
+}
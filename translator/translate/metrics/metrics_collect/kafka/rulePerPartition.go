@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// PerPartition adds the lag of every partition, on top of the total and the max lag of the topics
+type PerPartition struct {
+}
+
+const SectionKey_PerPartition = "per_partition"
+
+func (obj *PerPartition) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	return translator.DefaultCase(SectionKey_PerPartition, true, input)
+}
+
+func init() {
+	obj := new(PerPartition)
+	RegisterRule(SectionKey_PerPartition, obj)
+}
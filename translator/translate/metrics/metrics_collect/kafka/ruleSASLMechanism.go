@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+type SASLMechanism struct {
+}
+
+const SectionKey_SASLMechanism = "sasl_mechanism"
+
+func (obj *SASLMechanism) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_SASLMechanism]; ok {
+		returnKey = SectionKey_SASLMechanism
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(SASLMechanism)
+	RegisterRule(SectionKey_SASLMechanism, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+type TLS struct {
+}
+
+const SectionKey_TLS = "tls"
+
+func (obj *TLS) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_TLS]; ok {
+		returnKey = SectionKey_TLS
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(TLS)
+	RegisterRule(SectionKey_TLS, obj)
+}
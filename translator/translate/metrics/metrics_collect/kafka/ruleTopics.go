@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package kafka
+
+type Topics struct {
+}
+
+const SectionKey_Topics = "topics"
+
+func (obj *Topics) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Topics]; ok {
+		returnKey = SectionKey_Topics
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Topics)
+	RegisterRule(SectionKey_Topics, obj)
+}
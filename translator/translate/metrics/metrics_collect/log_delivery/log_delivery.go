@@ -0,0 +1,57 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package log_delivery
+
+import (
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+//
+//   "log_delivery" : {
+//       "measurement": [
+//           "ingestion_lag_seconds",
+//           "acknowledged_events"
+//       ]
+//   }
+//
+const SectionKey_LogDelivery = "log_delivery"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_LogDelivery + "/"
+	return curPath
+}
+
+type LogDelivery struct {
+}
+
+func (l *LogDelivery) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_LogDelivery]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_LogDelivery], SectionKey_LogDelivery, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_LogDelivery
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	l := new(LogDelivery)
+	parent.RegisterLinuxRule(SectionKey_LogDelivery, l)
+	parent.RegisterDarwinRule(SectionKey_LogDelivery, l)
+	parent.RegisterWindowsRule(SectionKey_LogDelivery, l)
+}
@@ -0,0 +1,37 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package log_delivery
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	l := new(LogDelivery)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"log_delivery": {
+					"measurement": ["log_delivery_ingestion_lag_seconds", "acknowledged_events"],
+					"metrics_collection_interval": 60
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := l.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass": []string{"ingestion_lag_seconds", "acknowledged_events"},
+		"interval":  "60s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	l := new(LogDelivery)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"log_delivery": {"measurement": ["lag"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := l.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package memcached
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "memcached" : {
+//       "measurement": [
+//           "curr_connections",
+//           "cache_hit_rate"
+//       ],
+//       "servers": ["localhost:11211"]
+//   }
+//
+const SectionKey_Memcached = "memcached"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_Memcached + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type Memcached struct {
+}
+
+func (obj *Memcached) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_Memcached]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_Memcached], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_Memcached], SectionKey_Memcached, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_Memcached
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(Memcached)
+	parent.RegisterLinuxRule(SectionKey_Memcached, obj)
+	parent.RegisterDarwinRule(SectionKey_Memcached, obj)
+	parent.RegisterWindowsRule(SectionKey_Memcached, obj)
+}
@@ -0,0 +1,39 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package memcached
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	c := new(Memcached)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"memcached": {
+					"servers": ["cache.internal:11211"],
+					"unix_sockets": ["/var/run/memcached.sock"],
+					"measurement": ["curr_connections", "cache_hit_rate"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"servers":      []interface{}{"cache.internal:11211"},
+		"unix_sockets": []interface{}{"/var/run/memcached.sock"},
+		"fieldpass":    []string{"curr_connections", "cache_hit_rate"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(Memcached)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"memcached": {"measurement": ["hits"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
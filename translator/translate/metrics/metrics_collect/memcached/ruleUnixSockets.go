@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package memcached
+
+type UnixSockets struct {
+}
+
+const SectionKey_UnixSockets = "unix_sockets"
+
+func (obj *UnixSockets) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_UnixSockets]; ok {
+		returnKey = SectionKey_UnixSockets
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(UnixSockets)
+	RegisterRule(SectionKey_UnixSockets, obj)
+}
@@ -70,12 +70,13 @@ func (c *CollectMetrics) ApplyRule(input interface{}) (returnKey string, returnV
 		returnVal = ""
 	} else {
 		//If yes, process it
-		for _, rule := range getOrderedRules(targetRuleMap) {
-			key, val := rule.ApplyRule(im[SectionKey])
+		for _, ruleName := range getOrderedRuleNames(targetRuleMap) {
+			key, val := targetRuleMap[ruleName].ApplyRule(im[SectionKey])
 
 			//If key == "", then no instance of this class in input
 			if key != "" {
 				result[key] = val
+				translator.AddProvenance("inputs."+key, translator.JsonPath(GetCurPath()+ruleName))
 			}
 		}
 	}
@@ -85,17 +86,13 @@ func (c *CollectMetrics) ApplyRule(input interface{}) (returnKey string, returnV
 }
 
 // Adding alphabet order to the Rules
-func getOrderedRules(ruleMap map[string]Rule) []Rule {
-	var orderedRules []Rule
+func getOrderedRuleNames(ruleMap map[string]Rule) []string {
 	var orderedRuleNames []string
 	for ruleName := range ruleMap {
 		orderedRuleNames = append(orderedRuleNames, ruleName)
 	}
 	sort.Strings(orderedRuleNames)
-	for _, orderedRuleName := range orderedRuleNames {
-		orderedRules = append(orderedRules, ruleMap[orderedRuleName])
-	}
-	return orderedRules
+	return orderedRuleNames
 }
 
 var MergeRuleMap = map[string]mergeJsonRule.MergeRule{}
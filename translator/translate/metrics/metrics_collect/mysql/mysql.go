@@ -0,0 +1,74 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package mysql
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "mysql" : {
+//       "measurement": [
+//           "threads_connected",
+//           "slow_queries_delta",
+//           "cache_hit_rate",
+//           "slave_seconds_behind_master"
+//       ],
+//       "servers": ["monitor@tcp(db.internal:3306)/"],
+//       "secret_id": "prod/mysql-monitor",
+//       "gather_replica_status": true
+//   }
+//
+const SectionKey_MySQL = "mysql"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_MySQL + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type MySQL struct {
+}
+
+func (obj *MySQL) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_MySQL]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_MySQL], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_MySQL], SectionKey_MySQL, GetCurPath(), result)
+		if hasValidMetric {
+			//The credentials are fetched from Secrets Manager at runtime
+			util.ProcessSecretID(m[SectionKey_MySQL], result)
+			resArray = append(resArray, result)
+			returnKey = SectionKey_MySQL
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(MySQL)
+	parent.RegisterLinuxRule(SectionKey_MySQL, obj)
+	parent.RegisterDarwinRule(SectionKey_MySQL, obj)
+	parent.RegisterWindowsRule(SectionKey_MySQL, obj)
+}
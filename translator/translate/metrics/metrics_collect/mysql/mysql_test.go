@@ -0,0 +1,48 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{}
+	c := new(MySQL)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"mysql": {
+					"servers": ["tcp(db.internal:3306)/"],
+					"secret_id": "prod/mysql",
+					"gather_replica_status": true,
+					"measurement": ["connections", "slow_queries_delta", "cache_hit_rate"],
+					"metrics_collection_interval": 120
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"servers":             []interface{}{"tcp(db.internal:3306)/"},
+		"secret_id":           "prod/mysql",
+		"region":              "us-east-1",
+		"gather_slave_status": true,
+		"metric_version":      2,
+		"fieldpass":           []string{"connections", "slow_queries_delta", "cache_hit_rate"},
+		"interval":            "120s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(MySQL)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"mysql": {"measurement": ["queries_per_second"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,28 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package mysql
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// GatherReplicaStatus collects the replication lag of the replicas, which needs the REPLICATION CLIENT privilege
+type GatherReplicaStatus struct {
+}
+
+const (
+	SectionKey_GatherReplicaStatus = "gather_replica_status"
+	GatherSlaveStatusKey           = "gather_slave_status"
+)
+
+func (obj *GatherReplicaStatus) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, returnVal = translator.DefaultCase(SectionKey_GatherReplicaStatus, false, input)
+	returnKey = GatherSlaveStatusKey
+	return
+}
+
+func init() {
+	obj := new(GatherReplicaStatus)
+	RegisterRule(SectionKey_GatherReplicaStatus, obj)
+}
@@ -0,0 +1,20 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package mysql
+
+// MetricVersion is always the latest version of the fields of telegraf, whose names are the lower case ones of the
+// server, e.g. slave_seconds_behind_master
+type MetricVersion struct {
+}
+
+const SectionKey_MetricVersion = "metric_version"
+
+func (obj *MetricVersion) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	return SectionKey_MetricVersion, 2
+}
+
+func init() {
+	obj := new(MetricVersion)
+	RegisterRule(SectionKey_MetricVersion, obj)
+}
@@ -0,0 +1,39 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package paging
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	p := new(Paging)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"paging": {
+					"measurement": ["pgmajfault_per_sec", "paging_pswpin_per_sec", "pswpout_per_sec"],
+					"metrics_collection_interval": 120,
+					"proc_path": "/rootfs/proc"
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := p.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass": []string{"pgmajfault_per_sec", "pswpin_per_sec", "pswpout_per_sec"},
+		"interval":  "120s",
+		"proc_path": "/rootfs/proc",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	p := new(Paging)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"paging": {"measurement": ["pgfault"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := p.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
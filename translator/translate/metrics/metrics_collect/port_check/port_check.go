@@ -0,0 +1,75 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package port_check
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "port_check" : {
+//       "measurement": [
+//           "success",
+//           "connect_time"
+//       ],
+//       "targets": [
+//           {"address": "db.internal:5432"},
+//           {"address": "statsd.internal:8125", "protocol": "udp", "send": "health", "expect": "up"}
+//       ]
+//   }
+//
+const SectionKey_PortCheck = "port_check"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_PortCheck + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type PortCheck struct {
+}
+
+func (obj *PortCheck) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_PortCheck]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_PortCheck], ChildRule, result)
+		if result[SectionMappedKey_Target] == nil {
+			translator.AddErrorMessages(GetCurPath(), "port_check has no targets.")
+			return
+		}
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_PortCheck], SectionKey_PortCheck, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_PortCheck
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(PortCheck)
+	parent.RegisterLinuxRule(SectionKey_PortCheck, obj)
+	parent.RegisterDarwinRule(SectionKey_PortCheck, obj)
+	parent.RegisterWindowsRule(SectionKey_PortCheck, obj)
+}
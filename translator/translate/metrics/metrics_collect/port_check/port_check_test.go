@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package port_check
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	c := new(PortCheck)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"port_check": {
+					"targets": [
+						{"address": "db.internal:5432"},
+						{"address": "statsd.internal:8125", "protocol": "udp", "send": "health", "expect": "up"}
+					],
+					"timeout": 3,
+					"measurement": ["success", "connect_time"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"target": []interface{}{
+			map[string]interface{}{"address": "db.internal:5432"},
+			map[string]interface{}{"address": "statsd.internal:8125", "protocol": "udp", "send": "health", "expect": "up"},
+		},
+		"timeout":   "3s",
+		"fieldpass": []string{"success", "connect_time"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoTargets(t *testing.T) {
+	translator.ResetMessages()
+	c := new(PortCheck)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"port_check": {"measurement": ["success"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+	assert.Len(t, translator.ErrorMessages, 1)
+	translator.ResetMessages()
+}
@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package port_check
+
+type Targets struct {
+}
+
+const (
+	SectionKey_Targets      = "targets"
+	SectionMappedKey_Target = "target"
+)
+
+func (obj *Targets) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Targets].([]interface{}); ok && len(val) > 0 {
+		returnKey = SectionMappedKey_Target
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Targets)
+	RegisterRule(SectionKey_Targets, obj)
+}
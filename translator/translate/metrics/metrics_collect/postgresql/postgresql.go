@@ -0,0 +1,74 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "postgresql" : {
+//       "measurement": [
+//           "numbackends",
+//           "cache_hit_rate",
+//           "replication_lag",
+//           "slow_queries"
+//       ],
+//       "address": "host=db.internal user=monitor sslmode=require",
+//       "secret_id": "prod/postgresql-monitor",
+//       "slow_query_threshold": 5
+//   }
+//
+const SectionKey_PostgreSQL = "postgresql"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_PostgreSQL + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type PostgreSQL struct {
+}
+
+func (obj *PostgreSQL) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_PostgreSQL]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_PostgreSQL], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_PostgreSQL], SectionKey_PostgreSQL, GetCurPath(), result)
+		if hasValidMetric {
+			//The credentials are fetched from Secrets Manager at runtime
+			util.ProcessSecretID(m[SectionKey_PostgreSQL], result)
+			resArray = append(resArray, result)
+			returnKey = SectionKey_PostgreSQL
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(PostgreSQL)
+	parent.RegisterLinuxRule(SectionKey_PostgreSQL, obj)
+	parent.RegisterDarwinRule(SectionKey_PostgreSQL, obj)
+	parent.RegisterWindowsRule(SectionKey_PostgreSQL, obj)
+}
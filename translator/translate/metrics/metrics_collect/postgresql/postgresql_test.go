@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{}
+	c := new(PostgreSQL)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"postgresql": {
+					"address": "host=db.internal sslmode=require",
+					"secret_id": "prod/postgresql",
+					"ignored_databases": ["template0", "template1"],
+					"slow_query_threshold": 5,
+					"measurement": ["numbackends", "cache_hit_rate", "replication_lag"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"address":              "host=db.internal sslmode=require",
+		"secret_id":            "prod/postgresql",
+		"region":               "us-east-1",
+		"ignored_databases":    []interface{}{"template0", "template1"},
+		"slow_query_threshold": "5s",
+		"fieldpass":            []string{"numbackends", "cache_hit_rate", "replication_lag"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(PostgreSQL)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"postgresql": {"measurement": ["locks"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+type Address struct {
+}
+
+const SectionKey_Address = "address"
+
+func (obj *Address) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Address]; ok {
+		returnKey = SectionKey_Address
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Address)
+	RegisterRule(SectionKey_Address, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+type Databases struct {
+}
+
+const SectionKey_Databases = "databases"
+
+func (obj *Databases) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Databases]; ok {
+		returnKey = SectionKey_Databases
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Databases)
+	RegisterRule(SectionKey_Databases, obj)
+}
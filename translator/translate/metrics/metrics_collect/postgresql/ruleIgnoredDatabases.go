@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+type IgnoredDatabases struct {
+}
+
+const SectionKey_IgnoredDatabases = "ignored_databases"
+
+func (obj *IgnoredDatabases) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_IgnoredDatabases]; ok {
+		returnKey = SectionKey_IgnoredDatabases
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(IgnoredDatabases)
+	RegisterRule(SectionKey_IgnoredDatabases, obj)
+}
@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package postgresql
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type SlowQueryThreshold struct {
+}
+
+const SectionKey_SlowQueryThreshold = "slow_query_threshold"
+
+func (obj *SlowQueryThreshold) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultTimeIntervalCase(SectionKey_SlowQueryThreshold, float64(10), input)
+	return
+}
+
+func init() {
+	obj := new(SlowQueryThreshold)
+	RegisterRule(SectionKey_SlowQueryThreshold, obj)
+}
@@ -0,0 +1,39 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package pressure
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	p := new(Pressure)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"pressure": {
+					"measurement": ["cpu_some_avg10", "pressure_memory_full_avg60", "io_some_total"],
+					"metrics_collection_interval": 120,
+					"proc_path": "/rootfs/proc"
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := p.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"fieldpass": []string{"cpu_some_avg10", "memory_full_avg60", "io_some_total"},
+		"interval":  "120s",
+		"proc_path": "/rootfs/proc",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	p := new(Pressure)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"pressure": {"measurement": ["cpu_some_avg30"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := p.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
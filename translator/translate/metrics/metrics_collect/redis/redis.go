@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "redis" : {
+//       "measurement": [
+//           "connected_clients",
+//           "cache_hit_rate",
+//           "master_last_io_seconds_ago"
+//       ],
+//       "servers": ["tcp://cache.internal:6379"],
+//       "secret_id": "prod/redis-auth"
+//   }
+//
+const SectionKey_Redis = "redis"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_Redis + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type Redis struct {
+}
+
+func (obj *Redis) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_Redis]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_Redis], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_Redis], SectionKey_Redis, GetCurPath(), result)
+		if hasValidMetric {
+			//The credentials are fetched from Secrets Manager at runtime
+			util.ProcessSecretID(m[SectionKey_Redis], result)
+			resArray = append(resArray, result)
+			returnKey = SectionKey_Redis
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	obj := new(Redis)
+	parent.RegisterLinuxRule(SectionKey_Redis, obj)
+	parent.RegisterDarwinRule(SectionKey_Redis, obj)
+	parent.RegisterWindowsRule(SectionKey_Redis, obj)
+}
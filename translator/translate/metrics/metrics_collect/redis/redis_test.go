@@ -0,0 +1,46 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{}
+	agent.Global_Config.Role_arn = "arn:aws:iam::123456789012:role/monitor"
+	defer func() { agent.Global_Config.Role_arn = "" }()
+	c := new(Redis)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"redis": {
+					"servers": ["tcp://cache.internal:6379"],
+					"secret_id": "prod/redis-auth",
+					"measurement": ["connected_clients", "cache_hit_rate"]
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"servers":   []interface{}{"tcp://cache.internal:6379"},
+		"secret_id": "prod/redis-auth",
+		"region":    "us-east-1",
+		"role_arn":  "arn:aws:iam::123456789012:role/monitor",
+		"fieldpass": []string{"connected_clients", "cache_hit_rate"},
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(Redis)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"redis": {"measurement": ["hits"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
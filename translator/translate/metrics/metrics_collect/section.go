@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics_collect
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/config"
+	"github.com/aws/amazon-cloudwatch-agent/translator/jsonconfig/mergeJsonRule"
+	metricsconfig "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/config"
+)
+
+// Section is a metrics_collected section translated by a package outside of the translator, e.g. for an input added
+// by a fork of the agent, so the section is supported without changing the files of the translator. The package
+// registers it from its init, and is imported for its side effects next to the rules of the translator.
+type Section struct {
+	// Key of the section under metrics_collected
+	Key string
+	// Rule translates the metrics_collected map, it returns the name of the input and its config, or an empty key
+	// when the section is not configured
+	Rule Rule
+	// Platforms the section is translated for, all of them when empty
+	Platforms []string
+	// Schema is the JSON schema the section is validated against, any object is accepted when empty
+	Schema string
+	// Merge merges the section of the config files of the agent, the section is only allowed in one of them or
+	// identical in all when nil
+	Merge mergeJsonRule.MergeRule
+}
+
+var sectionPlatforms = map[string]map[string]Rule{
+	config.OS_TYPE_LINUX:   linuxMetricCollectRule,
+	config.OS_TYPE_DARWIN:  darwinMetricCollectRule,
+	config.OS_TYPE_WINDOWS: windowsMetricCollectRule,
+}
+
+// RegisterSection adds the section to the translator, it panics when the section is invalid or its key is already
+// taken since the registrations happen on init
+func RegisterSection(s Section) {
+	if s.Key == "" || s.Rule == nil {
+		panic("the metrics_collected section must have a key and a rule")
+	}
+	platforms := s.Platforms
+	if len(platforms) == 0 {
+		platforms = []string{config.OS_TYPE_LINUX, config.OS_TYPE_DARWIN, config.OS_TYPE_WINDOWS}
+	}
+	for _, platform := range platforms {
+		if _, ok := sectionPlatforms[platform]; !ok {
+			panic(fmt.Sprintf("unknown platform %v of the metrics_collected section %v", platform, s.Key))
+		}
+	}
+	schema := s.Schema
+	if schema == "" {
+		schema = `{"type": "object"}`
+	}
+	if err := config.RegisterMetricsCollectedSchema(s.Key, schema); err != nil {
+		panic(err)
+	}
+	for _, platform := range platforms {
+		sectionPlatforms[platform][sectionRuleName(s.Key)] = s.Rule
+	}
+	if s.Merge != nil {
+		MergeRuleMap[s.Key] = s.Merge
+	}
+	// the sections are not Windows performance counter objects
+	metricsconfig.DisableWinPerfCounters[s.Key] = true
+}
+
+// the rules of the sections are named apart from the built-in rules
+func sectionRuleName(key string) string {
+	return "section/" + key
+}
@@ -0,0 +1,61 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package sectiontest helps testing the metrics_collected sections registered with metrics_collect.RegisterSection,
+// the tests import the package of the section for its side effects and check what it is translated into, e.g.
+//
+//	inputs := sectiontest.Translate(t, "linux", `{"my_input": {"metrics_collection_interval": 10}}`)
+//	assert.Equal(t, expected, inputs["my_input"])
+package sectiontest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/config"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func parse(t *testing.T, metricsCollected string) interface{} {
+	t.Helper()
+	var sections interface{}
+	if err := json.Unmarshal([]byte(metricsCollected), &sections); err != nil {
+		t.Fatalf("invalid metrics_collected %v: %v", metricsCollected, err)
+	}
+	return sections
+}
+
+// Validate returns the errors of the validation of the metrics_collected sections against the schema of the config,
+// none when they are valid
+func Validate(t *testing.T, metricsCollected string) []string {
+	t.Helper()
+	input := map[string]interface{}{
+		"metrics": map[string]interface{}{
+			"metrics_collected": parse(t, metricsCollected),
+		},
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(config.GetJsonSchema()), gojsonschema.NewGoLoader(input))
+	if err != nil {
+		t.Fatalf("unable to validate the schema: %v", err)
+	}
+	var errs []string
+	for _, e := range result.Errors() {
+		errs = append(errs, e.String())
+	}
+	return errs
+}
+
+// Translate translates the metrics_collected sections for the platform, linux, darwin or windows, and returns the
+// inputs they are translated into by name
+func Translate(t *testing.T, platform string, metricsCollected string) map[string]interface{} {
+	t.Helper()
+	previous := translator.GetTargetPlatform()
+	translator.SetTargetPlatform(platform)
+	defer translator.SetTargetPlatform(previous)
+
+	input := map[string]interface{}{"metrics_collected": parse(t, metricsCollected)}
+	_, inputs := new(metrics_collect.CollectMetrics).ApplyRule(input)
+	return inputs.(map[string]interface{})
+}
@@ -0,0 +1,76 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package sectiontest_test
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator/config"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	_ "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect/cpu"
+	_ "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect/customizedmetrics"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect/sectiontest"
+	"github.com/stretchr/testify/assert"
+)
+
+type fooRule struct{}
+
+func (f *fooRule) ApplyRule(input interface{}) (string, interface{}) {
+	im := input.(map[string]interface{})
+	section, ok := im["foo"]
+	if !ok {
+		return "", nil
+	}
+	result := map[string]interface{}{"address": section.(map[string]interface{})["address"]}
+	return "foo", []interface{}{result}
+}
+
+func init() {
+	metrics_collect.RegisterSection(metrics_collect.Section{
+		Key:       "foo",
+		Rule:      new(fooRule),
+		Platforms: []string{config.OS_TYPE_LINUX, config.OS_TYPE_WINDOWS},
+		Schema: `{
+			"type": "object",
+			"properties": {"address": {"type": "string"}},
+			"required": ["address"],
+			"additionalProperties": false
+		}`,
+	})
+}
+
+func TestTranslate(t *testing.T) {
+	expected := []interface{}{map[string]interface{}{"address": "localhost:1234"}}
+	for _, platform := range []string{config.OS_TYPE_LINUX, config.OS_TYPE_WINDOWS} {
+		inputs := sectiontest.Translate(t, platform, `{"foo": {"address": "localhost:1234"}}`)
+		assert.Equal(t, expected, inputs["foo"], platform)
+		// the section is not a performance counter object
+		assert.NotContains(t, inputs, "win_perf_counters", platform)
+	}
+	assert.Empty(t, sectiontest.Translate(t, config.OS_TYPE_DARWIN, `{"foo": {"address": "localhost:1234"}}`))
+
+	inputs := sectiontest.Translate(t, config.OS_TYPE_LINUX, `{"foo": {"address": "localhost:1234"}, "cpu": {"measurement": ["usage_idle"]}}`)
+	assert.Contains(t, inputs, "cpu", "the built-in sections are still translated")
+}
+
+func TestValidate(t *testing.T) {
+	assert.Empty(t, sectiontest.Validate(t, `{"foo": {"address": "localhost:1234"}}`))
+	assert.Len(t, sectiontest.Validate(t, `{"foo": {}}`), 1)
+	assert.Len(t, sectiontest.Validate(t, `{"foo": {"address": "localhost:1234", "port": 1}}`), 1)
+}
+
+func TestRegisterSectionConflicts(t *testing.T) {
+	assert.Panics(t, func() {
+		metrics_collect.RegisterSection(metrics_collect.Section{Key: "foo", Rule: new(fooRule)})
+	}, "the section is already registered")
+	assert.Panics(t, func() {
+		metrics_collect.RegisterSection(metrics_collect.Section{Key: "cpu", Rule: new(fooRule)})
+	}, "the section is built in")
+	assert.Panics(t, func() {
+		metrics_collect.RegisterSection(metrics_collect.Section{Key: "bar", Rule: new(fooRule), Platforms: []string{"plan9"}})
+	})
+	assert.Panics(t, func() {
+		metrics_collect.RegisterSection(metrics_collect.Section{Key: "bar", Rule: new(fooRule), Schema: `[]`})
+	})
+}
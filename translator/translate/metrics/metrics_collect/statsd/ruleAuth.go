@@ -0,0 +1,36 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+// Auth authenticates the statsd clients by their address, a shared token and their certificate
+type Auth struct {
+}
+
+const SectionKey_Auth = "auth"
+
+var authTargetList = []string{"allowed_cidrs", "token_file", "tls_cert", "tls_key", "tls_allowed_cacerts"}
+
+func (obj *Auth) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	val, ok := input.(map[string]interface{})[SectionKey_Auth]
+	if !ok {
+		return
+	}
+	if _, ok := val.(map[string]interface{}); !ok {
+		translator.AddErrorMessages(GetCurPath()+SectionKey_Auth, "Invalid format, expected an object")
+		return
+	}
+	auth := map[string]interface{}{}
+	util.SetWithSameKeyIfFound(val, authTargetList, auth)
+	return SectionKey_Auth, auth
+}
+
+func init() {
+	obj := new(Auth)
+	RegisterRule(SectionKey_Auth, obj)
+}
@@ -0,0 +1,42 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+// PrefixRules maps the metric name prefixes to namespaces and dimensions, the metrics section routes the namespaces
+// with role_override tables of the cloudwatch output
+type PrefixRules struct {
+}
+
+const SectionKey_PrefixRules = "prefix_rules"
+
+var prefixRuleTargetList = []string{"prefix", "namespace", "strip_prefix", "dimensions"}
+
+func (obj *PrefixRules) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	val, ok := input.(map[string]interface{})[SectionKey_PrefixRules]
+	if !ok {
+		return
+	}
+	rules, ok := val.([]interface{})
+	if !ok {
+		translator.AddErrorMessages(GetCurPath()+SectionKey_PrefixRules, "Invalid format, expected a list of objects")
+		return
+	}
+	var result []interface{}
+	for _, r := range rules {
+		rule := map[string]interface{}{}
+		util.SetWithSameKeyIfFound(r, prefixRuleTargetList, rule)
+		result = append(result, rule)
+	}
+	return "prefix_rule", result
+}
+
+func init() {
+	obj := new(PrefixRules)
+	RegisterRule(SectionKey_PrefixRules, obj)
+}
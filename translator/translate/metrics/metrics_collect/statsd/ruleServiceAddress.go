@@ -5,6 +5,7 @@ package statsd
 
 import (
 	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
 )
 
 type ServiceAddress struct {
@@ -13,7 +14,7 @@ type ServiceAddress struct {
 const SectionKey_ServiceAddress = "service_address"
 
 func (obj *ServiceAddress) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
-	returnKey, returnVal = translator.DefaultCase(SectionKey_ServiceAddress, ":8125", input)
+	returnKey, returnVal = translator.DefaultCase(SectionKey_ServiceAddress, agent.ListenAddress("", 8125), input)
 	return
 }
 
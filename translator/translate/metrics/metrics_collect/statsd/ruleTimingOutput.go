@@ -0,0 +1,40 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package statsd
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// TimingOutput publishes the timings as distributions or as the separate metrics of their statistics and percentiles
+type TimingOutput struct {
+}
+
+const (
+	SectionKey_TimingOutput = "timing_output"
+	SectionKey_Percentiles  = "percentiles"
+)
+
+func (obj *TimingOutput) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	key, val := translator.DefaultCase(SectionKey_TimingOutput, "", input)
+	if val != "" {
+		return key, val
+	}
+	return
+}
+
+type Percentiles struct {
+}
+
+func (obj *Percentiles) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	if val, ok := input.(map[string]interface{})[SectionKey_Percentiles]; ok {
+		return SectionKey_Percentiles, val
+	}
+	return
+}
+
+func init() {
+	RegisterRule(SectionKey_TimingOutput, new(TimingOutput))
+	RegisterRule(SectionKey_Percentiles, new(Percentiles))
+}
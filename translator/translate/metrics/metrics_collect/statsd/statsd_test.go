@@ -100,3 +100,94 @@ func TestStatsD_MetricSeparator(t *testing.T) {
 
 	assert.Equal(t, expect, actual)
 }
+
+func TestStatsD_PrefixRules(t *testing.T) {
+	obj := new(StatsD)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"statsd": {
+					"prefix_rules": [
+						{"prefix": "checkout.", "namespace": "Checkout", "strip_prefix": true, "dimensions": ["endpoint"], "aggregation_dimensions": [[]]},
+						{"prefix": "search."}
+					]
+					}}`), &input)
+	assert.NoError(t, err)
+
+	_, actual := obj.ApplyRule(input)
+
+	expect := []interface{}{
+		map[string]interface{}{
+			"service_address":     ":8125",
+			"interval":            "10s",
+			"parse_data_dog_tags": true,
+			"tags":                map[string]interface{}{"aws:AggregationInterval": "60s"},
+			"prefix_rule": []interface{}{
+				map[string]interface{}{"prefix": "checkout.", "namespace": "Checkout", "strip_prefix": true, "dimensions": []interface{}{"endpoint"}},
+				map[string]interface{}{"prefix": "search."},
+			},
+		},
+	}
+
+	assert.Equal(t, expect, actual)
+}
+
+func TestStatsD_TimingOutput(t *testing.T) {
+	obj := new(StatsD)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"statsd": {
+					"timing_output": "statistics",
+					"percentiles": [50, 99.9]
+					}}`), &input)
+	assert.NoError(t, err)
+
+	_, actual := obj.ApplyRule(input)
+
+	expect := []interface{}{
+		map[string]interface{}{
+			"service_address":     ":8125",
+			"interval":            "10s",
+			"parse_data_dog_tags": true,
+			"tags":                map[string]interface{}{"aws:AggregationInterval": "60s"},
+			"timing_output":       "statistics",
+			"percentiles":         []interface{}{float64(50), 99.9},
+		},
+	}
+
+	assert.Equal(t, expect, actual)
+}
+
+func TestStatsD_Auth(t *testing.T) {
+	obj := new(StatsD)
+	var input interface{}
+	err := json.Unmarshal([]byte(`{"statsd": {
+					"protocol": "tcp",
+					"auth": {
+						"allowed_cidrs": ["10.0.0.0/8"],
+						"token_file": "/etc/statsd-token",
+						"tls_cert": "/etc/statsd.pem",
+						"tls_key": "/etc/statsd.key",
+						"tls_allowed_cacerts": ["/etc/ca.pem"]
+					}
+					}}`), &input)
+	assert.NoError(t, err)
+
+	_, actual := obj.ApplyRule(input)
+
+	expect := []interface{}{
+		map[string]interface{}{
+			"service_address":     ":8125",
+			"protocol":            "tcp",
+			"interval":            "10s",
+			"parse_data_dog_tags": true,
+			"tags":                map[string]interface{}{"aws:AggregationInterval": "60s"},
+			"auth": map[string]interface{}{
+				"allowed_cidrs":       []interface{}{"10.0.0.0/8"},
+				"token_file":          "/etc/statsd-token",
+				"tls_cert":            "/etc/statsd.pem",
+				"tls_key":             "/etc/statsd.key",
+				"tls_allowed_cacerts": []interface{}{"/etc/ca.pem"},
+			},
+		},
+	}
+
+	assert.Equal(t, expect, actual)
+}
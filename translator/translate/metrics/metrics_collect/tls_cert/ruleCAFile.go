@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tls_cert
+
+type CAFile struct {
+}
+
+const SectionKey_CAFile = "ca_file"
+
+func (obj *CAFile) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_CAFile]; ok {
+		returnKey = SectionKey_CAFile
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(CAFile)
+	RegisterRule(SectionKey_CAFile, obj)
+}
@@ -0,0 +1,70 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tls_cert
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/util"
+)
+
+var ChildRule = map[string]translator.Rule{}
+
+//
+//   "tls_cert" : {
+//       "paths": [
+//           "/etc/nginx/ssl/*.crt"
+//       ],
+//       "measurement": [
+//           "days_until_expiry",
+//           "chain_valid"
+//       ]
+//   }
+//
+const SectionKey_TLSCert = "tls_cert"
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey_TLSCert + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type TLSCert struct {
+}
+
+func (t *TLSCert) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey_TLSCert]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey_TLSCert], ChildRule, result)
+
+		//Process common config, like measurement
+		hasValidMetric := util.ProcessLinuxCommonConfig(m[SectionKey_TLSCert], SectionKey_TLSCert, GetCurPath(), result)
+		if hasValidMetric {
+			resArray = append(resArray, result)
+			returnKey = SectionKey_TLSCert
+			returnVal = resArray
+		} else {
+			returnKey = ""
+		}
+	}
+	return
+}
+
+func init() {
+	t := new(TLSCert)
+	parent.RegisterLinuxRule(SectionKey_TLSCert, t)
+	parent.RegisterDarwinRule(SectionKey_TLSCert, t)
+	parent.RegisterWindowsRule(SectionKey_TLSCert, t)
+}
@@ -0,0 +1,41 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package tls_cert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	c := new(TLSCert)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"tls_cert": {
+					"paths": ["/etc/nginx/ssl/*.crt", "/etc/pki/tls/certs"],
+					"ca_file": "/etc/pki/corp-ca.pem",
+					"measurement": ["days_until_expiry", "tls_cert_chain_valid"],
+					"metrics_collection_interval": 300
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := c.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"paths":     []interface{}{"/etc/nginx/ssl/*.crt", "/etc/pki/tls/certs"},
+		"ca_file":   "/etc/pki/corp-ca.pem",
+		"fieldpass": []string{"days_until_expiry", "chain_valid"},
+		"interval":  "300s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoValidMetric(t *testing.T) {
+	c := new(TLSCert)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"tls_cert": {"paths": ["/etc/ssl"], "measurement": ["expiry"]}}`), &input)
+	assert.NoError(t, e)
+	key, _ := c.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
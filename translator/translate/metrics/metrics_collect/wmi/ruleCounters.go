@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+type Counters struct {
+}
+
+const (
+	SectionKey_Counters      = "counters"
+	SectionMappedKey_Counter = "counter"
+)
+
+func (obj *Counters) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Counters].([]interface{}); ok && len(val) > 0 {
+		returnKey = SectionMappedKey_Counter
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Counters)
+	RegisterRule(SectionKey_Counters, obj)
+}
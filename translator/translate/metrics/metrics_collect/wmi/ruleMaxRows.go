@@ -0,0 +1,27 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+type MaxRows struct {
+}
+
+const SectionKey_MaxRows = "max_rows"
+
+func (obj *MaxRows) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	returnKey, returnVal = translator.DefaultCase(SectionKey_MaxRows, "", input)
+	if returnVal != "" {
+		// By default json unmarshal will store number as float64
+		return returnKey, int(returnVal.(float64))
+	}
+	return "", nil
+}
+
+func init() {
+	obj := new(MaxRows)
+	RegisterRule(SectionKey_MaxRows, obj)
+}
@@ -0,0 +1,26 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+type Queries struct {
+}
+
+const (
+	SectionKey_Queries     = "queries"
+	SectionMappedKey_Query = "query"
+)
+
+func (obj *Queries) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	if val, ok := m[SectionKey_Queries].([]interface{}); ok && len(val) > 0 {
+		returnKey = SectionMappedKey_Query
+		returnVal = val
+	}
+	return
+}
+
+func init() {
+	obj := new(Queries)
+	RegisterRule(SectionKey_Queries, obj)
+}
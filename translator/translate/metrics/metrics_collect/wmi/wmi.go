@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	parent "github.com/aws/amazon-cloudwatch-agent/translator/translate/metrics/metrics_collect"
+)
+
+//	"wmi" : {
+//	    "queries": [
+//	        {
+//	            "name": "win_service_state",
+//	            "query": "SELECT Name, ProcessId FROM Win32_Service WHERE StartMode = 'Auto'",
+//	            "properties": ["ProcessId"],
+//	            "tag_properties": ["Name"]
+//	        }
+//	    ],
+//	    "counters": [
+//	        {"field": "dhcp_requests", "path": "\\DHCP Server\\Requests/sec"}
+//	    ],
+//	    "timeout": 5,
+//	    "max_rows": 100,
+//	    "metrics_collection_interval": 60
+//	}
+const SectionKey = "wmi"
+
+var ChildRule = map[string]translator.Rule{}
+
+func GetCurPath() string {
+	curPath := parent.GetCurPath() + SectionKey + "/"
+	return curPath
+}
+
+func RegisterRule(fieldname string, r translator.Rule) {
+	ChildRule[fieldname] = r
+}
+
+type WMI struct {
+}
+
+func (obj *WMI) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	m := input.(map[string]interface{})
+	resArray := []interface{}{}
+	result := map[string]interface{}{}
+	//Check if this plugin exist in the input instance
+	//If not, not process
+	if _, ok := m[SectionKey]; !ok {
+		returnKey = ""
+		returnVal = ""
+	} else {
+		//If exists, process it
+		//Check if there are some config entry with rules applied
+		result = translator.ProcessRuleToApply(m[SectionKey], ChildRule, result)
+		if result[SectionMappedKey_Query] == nil && result[SectionMappedKey_Counter] == nil {
+			translator.AddErrorMessages(GetCurPath(), "wmi has neither queries nor counters.")
+			return
+		}
+		resArray = append(resArray, result)
+		returnKey = SectionKey
+		returnVal = resArray
+	}
+	return
+}
+
+func init() {
+	parent.RegisterWindowsRule(SectionKey, new(WMI))
+}
@@ -0,0 +1,59 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package wmi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullConfig(t *testing.T) {
+	obj := new(WMI)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"wmi": {
+					"queries": [{
+						"name": "win_service_state",
+						"query": "SELECT Name, ProcessId FROM Win32_Service",
+						"properties": ["ProcessId"],
+						"tag_properties": ["Name"],
+						"value_type": "integer"
+					}],
+					"counters": [{"field": "dhcp_requests", "path": "\\DHCP Server\\Requests/sec"}],
+					"timeout": 10,
+					"max_rows": 50,
+					"metrics_collection_interval": 120
+					}}`), &input)
+	assert.NoError(t, e)
+	_, actual := obj.ApplyRule(input)
+
+	expected := []interface{}{map[string]interface{}{
+		"query": []interface{}{map[string]interface{}{
+			"name":           "win_service_state",
+			"query":          "SELECT Name, ProcessId FROM Win32_Service",
+			"properties":     []interface{}{"ProcessId"},
+			"tag_properties": []interface{}{"Name"},
+			"value_type":     "integer",
+		}},
+		"counter":  []interface{}{map[string]interface{}{"field": "dhcp_requests", "path": `\DHCP Server\Requests/sec`}},
+		"timeout":  "10s",
+		"max_rows": 50,
+		"interval": "120s",
+	}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestNoQueries(t *testing.T) {
+	translator.ResetMessages()
+	obj := new(WMI)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"wmi": {"timeout": 10}}`), &input)
+	assert.NoError(t, e)
+	key, _ := obj.ApplyRule(input)
+	assert.Equal(t, "", key)
+	assert.Len(t, translator.ErrorMessages, 1)
+	translator.ResetMessages()
+}
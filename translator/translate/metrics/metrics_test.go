@@ -90,3 +90,173 @@ func TestMetrics_EndpointOverride(t *testing.T) {
 	)
 	assert.Equal(t, expected, actual, "Expected to be equal")
 }
+
+func TestMetrics_RoleOverrides(t *testing.T) {
+	m := new(Metrics)
+	var input interface{}
+	agent.Global_Config.Region = "auto"
+	e := json.Unmarshal([]byte(`{"metrics":{"role_overrides":[{"tag_key":"team","tag_value":"app","namespace":"App/Metrics","role_arn":"arn:aws:iam::123456789012:role/AppMetricsPublisher"}]}}`), &input)
+	assert.NoError(t, e)
+	_, actual := m.ApplyRule(input)
+	expected := map[string]interface{}(
+		map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"cloudwatch": []interface{}{
+					map[string]interface{}{
+						"force_flush_interval": "60s",
+						"namespace":            "CWAgent",
+						"region":               "auto",
+						"role_override": []interface{}{
+							map[string]interface{}{
+								"tag_key":   "team",
+								"tag_value": "app",
+								"namespace": "App/Metrics",
+								"role_arn":  "arn:aws:iam::123456789012:role/AppMetricsPublisher",
+							},
+						},
+						"tagexclude": []string{"metricPath"},
+						"tagpass":    map[string][]string{"metricPath": []string{"metrics"}},
+					},
+				},
+			},
+		},
+	)
+	assert.Equal(t, expected, actual, "Expected to be equal")
+}
+
+func TestRoleOverrides_StatsDPrefixRules(t *testing.T) {
+	r := new(RoleOverrides)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{
+		"metrics_collected":{"statsd":{"prefix_rules":[
+			{"prefix":"checkout.", "namespace":"Checkout", "aggregation_dimensions":[["endpoint"]]},
+			{"prefix":"cart.", "namespace":"Checkout"},
+			{"prefix":"search.", "dimensions":["index"]}
+		]}},
+		"role_overrides":[{"tag_key":"team","tag_value":"app","namespace":"App/Metrics"}]
+	}`), &input)
+	assert.NoError(t, e)
+	key, actual := r.ApplyRule(input)
+	assert.Equal(t, OutputsKey, key)
+	expected := map[string]interface{}{
+		"role_override": []interface{}{
+			map[string]interface{}{
+				"tag_key":           "aws:StatsDNamespace",
+				"tag_value":         "Checkout",
+				"namespace":         "Checkout",
+				"remove_tag":        true,
+				"rollup_dimensions": []interface{}{[]interface{}{"endpoint"}},
+			},
+			map[string]interface{}{
+				"tag_key":   "team",
+				"tag_value": "app",
+				"namespace": "App/Metrics",
+			},
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestMetrics_FileOutput(t *testing.T) {
+	m := new(Metrics)
+	var input interface{}
+	agent.Global_Config.Region = "auto"
+	e := json.Unmarshal([]byte(`{"metrics":{"file":{"path":"/tmp/metrics.out","metric_format":"json","rotation_max_size":1048576,"rotation_interval":3600,"file_only":true}}}`), &input)
+	assert.NoError(t, e)
+	_, actual := m.ApplyRule(input)
+	expected := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"file": []interface{}{
+				map[string]interface{}{
+					"alias":             "metrics_file",
+					"path":              "/tmp/metrics.out",
+					"metric_format":     "json",
+					"rotation_max_size": 1048576,
+					"rotation_interval": "3600s",
+					"tagexclude":        []string{"metricPath"},
+					"tagpass":           map[string][]string{"metricPath": []string{"metrics"}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, actual, "Expected to be equal")
+}
+
+func TestMetrics_PrometheusExporter(t *testing.T) {
+	m := new(Metrics)
+	var input interface{}
+	agent.Global_Config.Region = "auto"
+	e := json.Unmarshal([]byte(`{"metrics":{"prometheus_exporter":{"listen_address":"127.0.0.1:9464","ip_range":["10.0.0.0/8"]}}}`), &input)
+	assert.NoError(t, e)
+	_, actual := m.ApplyRule(input)
+	outputs := actual.(map[string]interface{})["outputs"].(map[string]interface{})
+	assert.Contains(t, outputs, "cloudwatch")
+	expected := []interface{}{
+		map[string]interface{}{
+			"listen":              "127.0.0.1:9464",
+			"metric_version":      2,
+			"expiration_interval": "60s",
+			"ip_range":            []interface{}{"10.0.0.0/8"},
+			"collectors_exclude":  []string{"gocollector", "process"},
+			"tagexclude":          []string{"metricPath"},
+			"tagpass":             map[string][]string{"metricPath": []string{"metrics"}},
+		},
+	}
+	assert.Equal(t, expected, outputs["prometheus_client"], "Expected to be equal")
+
+	// the endpoint listens on the loopback address by default, or on the bind address
+	e = json.Unmarshal([]byte(`{"metrics":{"prometheus_exporter":{}}}`), &input)
+	assert.NoError(t, e)
+	_, actual = m.ApplyRule(input)
+	outputs = actual.(map[string]interface{})["outputs"].(map[string]interface{})
+	assert.Equal(t, "127.0.0.1:9273", outputs["prometheus_client"].([]interface{})[0].(map[string]interface{})["listen"])
+
+	agent.Global_Config.BindAddress = "::1"
+	defer func() { agent.Global_Config.BindAddress = "" }()
+	_, actual = m.ApplyRule(input)
+	outputs = actual.(map[string]interface{})["outputs"].(map[string]interface{})
+	assert.Equal(t, "[::1]:9273", outputs["prometheus_client"].([]interface{})[0].(map[string]interface{})["listen"])
+}
+
+func TestMetrics_PublishJitter(t *testing.T) {
+	m := new(Metrics)
+	var input interface{}
+	agent.Global_Config.Region = "auto"
+	agent.Global_Config.AlignToWallClock = true
+	defer func() { agent.Global_Config.AlignToWallClock = false }()
+	e := json.Unmarshal([]byte(`{"metrics":{}}`), &input)
+	assert.NoError(t, e)
+	_, actual := m.ApplyRule(input)
+	cloudwatch := actual.(map[string]interface{})["outputs"].(map[string]interface{})["cloudwatch"].([]interface{})[0]
+	assert.Equal(t, "5s", cloudwatch.(map[string]interface{})["publish_jitter"])
+
+	e = json.Unmarshal([]byte(`{"metrics":{"publish_jitter": 10}}`), &input)
+	assert.NoError(t, e)
+	_, actual = m.ApplyRule(input)
+	cloudwatch = actual.(map[string]interface{})["outputs"].(map[string]interface{})["cloudwatch"].([]interface{})[0]
+	assert.Equal(t, "10s", cloudwatch.(map[string]interface{})["publish_jitter"])
+
+	e = json.Unmarshal([]byte(`{"metrics":{"publish_jitter": 0}}`), &input)
+	assert.NoError(t, e)
+	_, actual = m.ApplyRule(input)
+	cloudwatch = actual.(map[string]interface{})["outputs"].(map[string]interface{})["cloudwatch"].([]interface{})[0]
+	assert.Equal(t, "0s", cloudwatch.(map[string]interface{})["publish_jitter"], "the jitter is disabled")
+
+	agent.Global_Config.AlignToWallClock = false
+	e = json.Unmarshal([]byte(`{"metrics":{}}`), &input)
+	assert.NoError(t, e)
+	_, actual = m.ApplyRule(input)
+	cloudwatch = actual.(map[string]interface{})["outputs"].(map[string]interface{})["cloudwatch"].([]interface{})[0]
+	assert.NotContains(t, cloudwatch, "publish_jitter")
+}
+
+func TestMetrics_LateDataPolicy(t *testing.T) {
+	m := new(Metrics)
+	var input interface{}
+	agent.Global_Config.Region = "auto"
+	e := json.Unmarshal([]byte(`{"metrics":{"late_data_policy": "next_window"}}`), &input)
+	assert.NoError(t, e)
+	_, actual := m.ApplyRule(input)
+	cloudwatch := actual.(map[string]interface{})["outputs"].(map[string]interface{})["cloudwatch"].([]interface{})[0]
+	assert.Equal(t, "next_window", cloudwatch.(map[string]interface{})["late_data_policy"])
+}
@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/config"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util/ec2util"
+)
+
+const (
+	AlarmsKey            = "alarms"
+	alarmDiskFullKey     = "disk_full"
+	alarmMemoryKey       = "memory"
+	alarmAgentHealthKey  = "agent_health"
+	alarmThresholdKey    = "threshold"
+	alarmInstanceIdKey   = "InstanceId"
+	alarmAppendDimsKey   = "append_dimensions"
+	alarmDefaultPrefix   = "CWAgent-"
+	alarmDefaultDiskFull = float64(90)
+	alarmDefaultMemory   = float64(90)
+)
+
+// alarmInstanceID returns the ID of the instance the alarms are created for
+var alarmInstanceID = func() string {
+	return ec2util.GetEC2UtilSingleton().InstanceID
+}
+
+// alarmMetrics are the metrics of the standard alarms by platform: the disk and memory ones are compared to the
+// threshold as used percents, the free space of the windows disks is compared to its complement
+var alarmMetrics = map[string]map[string]string{
+	config.OS_TYPE_LINUX:   {alarmDiskFullKey: "disk_used_percent", alarmMemoryKey: "mem_used_percent"},
+	config.OS_TYPE_DARWIN:  {alarmDiskFullKey: "disk_used_percent", alarmMemoryKey: "mem_used_percent"},
+	config.OS_TYPE_WINDOWS: {alarmDiskFullKey: "LogicalDisk % Free Space", alarmMemoryKey: "Memory % Committed Bytes In Use"},
+}
+
+// Alarms translates the standard alarms the cloudwatch output creates or updates for the instance at startup
+//
+//	"alarms": {
+//	    "alarm_actions": ["arn:aws:sns:us-east-1:123456789012:ops"],
+//	    "disk_full": {"threshold": 90},
+//	    "memory": {"threshold": 95},
+//	    "agent_health": true
+//	}
+type Alarms struct {
+}
+
+func (a *Alarms) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	im := input.(map[string]interface{})
+	alarms, ok := im[AlarmsKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	path := GetCurPath() + AlarmsKey
+	instanceID := alarmInstanceID()
+	if instanceID == "" {
+		translator.AddErrorMessages(path, "alarms require the instance ID, which is only available on EC2")
+		return
+	}
+	if dims, ok := im[alarmAppendDimsKey].(map[string]interface{}); !ok || dims[alarmInstanceIdKey] == nil {
+		translator.AddErrorMessages(path, "alarms require the InstanceId dimension in append_dimensions")
+		return
+	}
+
+	metricNames := alarmMetrics[translator.GetTargetPlatform()]
+	if metricNames == nil {
+		metricNames = alarmMetrics[config.OS_TYPE_LINUX]
+	}
+	var list []interface{}
+	if threshold, ok := alarmThreshold(alarms, alarmDiskFullKey, alarmDefaultDiskFull); ok {
+		alarm := map[string]interface{}{"name": "disk-full", "metric_name": metricNames[alarmDiskFullKey], "threshold": threshold}
+		if translator.GetTargetPlatform() == config.OS_TYPE_WINDOWS {
+			alarm["statistic"] = "MIN"
+			alarm["comparison"] = "LessThanOrEqualToThreshold"
+			alarm["threshold"] = 100 - threshold
+		}
+		list = append(list, alarm)
+	}
+	if threshold, ok := alarmThreshold(alarms, alarmMemoryKey, alarmDefaultMemory); ok {
+		list = append(list, map[string]interface{}{"name": "memory", "metric_name": metricNames[alarmMemoryKey], "threshold": threshold})
+	}
+	if health, _ := alarms[alarmAgentHealthKey].(bool); health {
+		// the agent is unhealthy once it stops publishing the memory metric
+		list = append(list, map[string]interface{}{
+			"name":               "agent-health",
+			"metric_name":        metricNames[alarmMemoryKey],
+			"statistic":          "COUNT",
+			"comparison":         "LessThanThreshold",
+			"threshold":          float64(1),
+			"treat_missing_data": "breaching",
+		})
+	}
+	if len(list) == 0 {
+		return
+	}
+
+	res := map[string]interface{}{"instance_id": instanceID, "alarm": list}
+	_, res["name_prefix"] = translator.DefaultCase("name_prefix", alarmDefaultPrefix, alarms)
+	for _, key := range []string{"alarm_actions", "ok_actions"} {
+		if _, ok := alarms[key]; ok {
+			_, res[key] = translator.DefaultStringArrayCase(key, nil, alarms)
+		}
+	}
+	for _, key := range []string{"period", "evaluation_periods"} {
+		if _, ok := alarms[key]; ok {
+			_, res[key] = translator.DefaultIntegralCase(key, float64(0), alarms)
+		}
+	}
+	returnKey = OutputsKey
+	returnVal = map[string]interface{}{AlarmsKey: res}
+	return
+}
+
+// alarmThreshold returns the threshold of the standard alarm, which is enabled by its object or by true
+func alarmThreshold(alarms map[string]interface{}, key string, defaultThreshold float64) (float64, bool) {
+	switch v := alarms[key].(type) {
+	case bool:
+		return defaultThreshold, v
+	case map[string]interface{}:
+		if threshold, ok := v[alarmThresholdKey].(float64); ok {
+			return threshold, true
+		}
+		return defaultThreshold, true
+	}
+	return 0, false
+}
+
+func init() {
+	RegisterRule(AlarmsKey, new(Alarms))
+}
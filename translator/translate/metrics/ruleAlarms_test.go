@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withAlarmInstanceID(t *testing.T, id string) {
+	previous := alarmInstanceID
+	alarmInstanceID = func() string { return id }
+	t.Cleanup(func() { alarmInstanceID = previous })
+}
+
+func applyAlarms(t *testing.T, js string) (string, interface{}) {
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(js), &input))
+	return new(Alarms).ApplyRule(input)
+}
+
+func TestAlarms(t *testing.T) {
+	withAlarmInstanceID(t, "i-0123456789abcdef0")
+	translator.SetTargetPlatform(config.OS_TYPE_LINUX)
+
+	key, val := applyAlarms(t, `{
+		"append_dimensions": {"InstanceId": "${aws:InstanceId}"},
+		"alarms": {
+			"alarm_actions": ["arn:aws:sns:us-east-1:123456789012:ops"],
+			"period": 60,
+			"disk_full": {"threshold": 80},
+			"memory": true,
+			"agent_health": true
+		}
+	}`)
+	assert.Equal(t, OutputsKey, key)
+	assert.Equal(t, map[string]interface{}{AlarmsKey: map[string]interface{}{
+		"instance_id":   "i-0123456789abcdef0",
+		"name_prefix":   "CWAgent-",
+		"alarm_actions": []string{"arn:aws:sns:us-east-1:123456789012:ops"},
+		"period":        60,
+		"alarm": []interface{}{
+			map[string]interface{}{"name": "disk-full", "metric_name": "disk_used_percent", "threshold": float64(80)},
+			map[string]interface{}{"name": "memory", "metric_name": "mem_used_percent", "threshold": float64(90)},
+			map[string]interface{}{"name": "agent-health", "metric_name": "mem_used_percent", "statistic": "COUNT",
+				"comparison": "LessThanThreshold", "threshold": float64(1), "treat_missing_data": "breaching"},
+		},
+	}}, val)
+}
+
+func TestAlarms_Windows(t *testing.T) {
+	withAlarmInstanceID(t, "i-0123456789abcdef0")
+	translator.SetTargetPlatform(config.OS_TYPE_WINDOWS)
+	defer translator.SetTargetPlatform(config.OS_TYPE_LINUX)
+
+	_, val := applyAlarms(t, `{
+		"append_dimensions": {"InstanceId": "${aws:InstanceId}"},
+		"alarms": {"disk_full": {"threshold": 85}}
+	}`)
+	alarms := val.(map[string]interface{})[AlarmsKey].(map[string]interface{})["alarm"]
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "disk-full", "metric_name": "LogicalDisk % Free Space",
+		"statistic": "MIN", "comparison": "LessThanOrEqualToThreshold", "threshold": float64(15)}}, alarms)
+}
+
+func TestAlarms_Invalid(t *testing.T) {
+	translator.SetTargetPlatform(config.OS_TYPE_LINUX)
+
+	withAlarmInstanceID(t, "")
+	translator.ResetMessages()
+	key, _ := applyAlarms(t, `{"append_dimensions": {"InstanceId": "${aws:InstanceId}"}, "alarms": {"memory": true}}`)
+	assert.Equal(t, "", key)
+	assert.Len(t, translator.ErrorMessages, 1)
+
+	withAlarmInstanceID(t, "i-0123456789abcdef0")
+	translator.ResetMessages()
+	key, _ = applyAlarms(t, `{"alarms": {"memory": true}}`)
+	assert.Equal(t, "", key)
+	assert.Len(t, translator.ErrorMessages, 1)
+	translator.ResetMessages()
+}
@@ -0,0 +1,96 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	translatorUtil "github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const (
+	AnomalyDetectionKey = "anomaly_detection"
+	anomalyRulesKey     = "rules"
+	// defaultAnomalyCommandTimeout is how many seconds the commands of the rules run before they are killed
+	defaultAnomalyCommandTimeout = float64(30)
+	// anomalyProcessorOrder runs the processor after the ec2tagger, which has no order, so that the dimensions of the
+	// rules can be the ones it adds
+	anomalyProcessorOrder = 1
+)
+
+var (
+	anomalyRuleTargetList  = []string{"name", "mode", "above", "below", "alpha", "deviations", "command", "sns_topic_arn"}
+	anomalyRuleCountList   = []string{"warmup", "trigger_count", "clear_count"}
+	anomalyRuleRenamedKeys = map[string]string{"metric_name": "metric", "dimensions": "tags"}
+)
+
+// AnomalyDetection translates the anomaly detection rules into the anomaly processor, which runs the local actions of
+// the rules when the metrics are anomalous
+//
+//	"anomaly_detection": {
+//	    "command_timeout": 30,
+//	    "rules": [{
+//	        "name": "memory-exhaustion",
+//	        "metric_name": "mem_used_percent",
+//	        "above": 95,
+//	        "command": ["/usr/local/bin/restart-worker.sh"]
+//	    }]
+//	}
+type AnomalyDetection struct {
+}
+
+func (a *AnomalyDetection) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	section, ok := input.(map[string]interface{})[AnomalyDetectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	path := GetCurPath() + AnomalyDetectionKey
+	rules, _ := section[anomalyRulesKey].([]interface{})
+	if len(rules) == 0 {
+		translator.AddErrorMessages(path, "anomaly_detection has no rules.")
+		return
+	}
+
+	var list []interface{}
+	for _, r := range rules {
+		m, _ := r.(map[string]interface{})
+		if mode, _ := m["mode"].(string); mode == "" || mode == "threshold" {
+			_, above := m["above"]
+			_, below := m["below"]
+			if !above && !below {
+				translator.AddErrorMessages(path, fmt.Sprintf("The threshold rule %v has neither above nor below.", m["name"]))
+				return
+			}
+		}
+		rule := map[string]interface{}{}
+		translatorUtil.SetWithSameKeyIfFound(r, anomalyRuleTargetList, rule)
+		translatorUtil.SetWithCustomizedKeyIfFound(r, anomalyRuleRenamedKeys, rule)
+		// the counts are integers in the processor, the json numbers are floats
+		for _, key := range anomalyRuleCountList {
+			if count, ok := r.(map[string]interface{})[key].(float64); ok {
+				rule[key] = int(count)
+			}
+		}
+		list = append(list, rule)
+	}
+	processor := map[string]interface{}{"rule": list, "order": anomalyProcessorOrder}
+	key, val := translator.DefaultTimeIntervalCase("command_timeout", defaultAnomalyCommandTimeout, section)
+	processor[key] = val
+	if agent.Global_Config.Region != "" {
+		processor["region"] = agent.Global_Config.Region
+	}
+	for k, v := range agent.Global_Config.Credentials {
+		processor[k] = v
+	}
+
+	returnKey = ProcessorsKey
+	returnVal = map[string]interface{}{"anomaly": []interface{}{processor}}
+	return
+}
+
+func init() {
+	RegisterRule(AnomalyDetectionKey, new(AnomalyDetection))
+}
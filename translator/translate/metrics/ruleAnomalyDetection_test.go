@@ -0,0 +1,79 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func applyAnomalyDetection(t *testing.T, js string) (string, interface{}) {
+	var input interface{}
+	require.NoError(t, json.Unmarshal([]byte(js), &input))
+	return new(AnomalyDetection).ApplyRule(input)
+}
+
+func TestAnomalyDetection(t *testing.T) {
+	agent.Global_Config.Region = "us-east-1"
+	agent.Global_Config.Credentials = map[string]interface{}{}
+
+	key, val := applyAnomalyDetection(t, `{
+		"anomaly_detection": {
+			"rules": [{
+				"name": "memory-exhaustion",
+				"metric_name": "mem_used_percent",
+				"dimensions": {"host": "web-1"},
+				"above": 95,
+				"trigger_count": 2,
+				"command": ["/usr/local/bin/restart-worker.sh"]
+			}, {
+				"name": "disk-latency",
+				"metric_name": "diskio_read_time",
+				"mode": "ewma",
+				"warmup": 20,
+				"sns_topic_arn": "arn:aws:sns:us-east-1:123456789012:ops"
+			}]
+		}
+	}`)
+	assert.Equal(t, ProcessorsKey, key)
+	assert.Equal(t, map[string]interface{}{"anomaly": []interface{}{map[string]interface{}{
+		"command_timeout": "30s",
+		"region":          "us-east-1",
+		"order":           anomalyProcessorOrder,
+		"rule": []interface{}{
+			map[string]interface{}{"name": "memory-exhaustion", "metric": "mem_used_percent",
+				"tags": map[string]interface{}{"host": "web-1"}, "above": float64(95), "trigger_count": 2,
+				"command": []interface{}{"/usr/local/bin/restart-worker.sh"}},
+			map[string]interface{}{"name": "disk-latency", "metric": "diskio_read_time", "mode": "ewma", "warmup": 20,
+				"sns_topic_arn": "arn:aws:sns:us-east-1:123456789012:ops"},
+		},
+	}}}, val)
+}
+
+func TestAnomalyDetection_NoRules(t *testing.T) {
+	translator.ResetMessages()
+	key, _ := applyAnomalyDetection(t, `{"anomaly_detection": {"command_timeout": 10}}`)
+	assert.Equal(t, "", key)
+	assert.Len(t, translator.ErrorMessages, 1)
+	translator.ResetMessages()
+
+	key, _ = applyAnomalyDetection(t, `{}`)
+	assert.Equal(t, "", key)
+}
+
+func TestAnomalyDetection_ThresholdWithoutBounds(t *testing.T) {
+	translator.ResetMessages()
+	key, _ := applyAnomalyDetection(t, `{"anomaly_detection": {"rules": [
+		{"name": "spike", "metric_name": "cpu_usage_user", "mode": "ewma"},
+		{"name": "memory", "metric_name": "mem_used_percent"}]}}`)
+	assert.Equal(t, "", key)
+	require.Len(t, translator.ErrorMessages, 1)
+	assert.Contains(t, translator.ErrorMessages[0], "The threshold rule memory has neither above nor below.")
+	translator.ResetMessages()
+}
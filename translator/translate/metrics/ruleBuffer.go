@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+const (
+	BufferDirKey              = "buffer_dir"
+	BufferMaxSizeMBKey        = "buffer_max_size_mb"
+	BufferCompressionKey      = "buffer_compression"
+	BufferCompressionLevelKey = "buffer_compression_level"
+)
+
+// Buffer persists the metrics which could not be published into buffer_dir, capped to buffer_max_size_mb and
+// compressed with buffer_compression
+type Buffer struct {
+}
+
+func (b *Buffer) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	_, dir := translator.DefaultCase(BufferDirKey, "", input)
+	if dir == "" {
+		return
+	}
+	res := map[string]interface{}{BufferDirKey: dir}
+	if _, size := translator.DefaultIntegralCase(BufferMaxSizeMBKey, float64(64), input); size.(int) > 0 {
+		res["buffer_max_size"] = int64(size.(int)) * 1024 * 1024
+	}
+	if _, compression := translator.DefaultCase(BufferCompressionKey, "", input); compression != "" {
+		res[BufferCompressionKey] = compression
+		if _, level := translator.DefaultIntegralCase(BufferCompressionLevelKey, float64(0), input); level.(int) > 0 {
+			res[BufferCompressionLevelKey] = level
+		}
+	}
+	returnKey = "outputs"
+	returnVal = res
+	return
+}
+
+func init() {
+	RegisterRule("buffer", new(Buffer))
+}
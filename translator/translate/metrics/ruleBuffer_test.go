@@ -0,0 +1,35 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	b := new(Buffer)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"buffer_dir": "/var/buffer", "buffer_max_size_mb": 16, "buffer_compression": "zstd", "buffer_compression_level": 9}`), &input)
+	assert.NoError(t, e)
+	_, returnVal := b.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{
+		"buffer_dir":               "/var/buffer",
+		"buffer_max_size":          int64(16 * 1024 * 1024),
+		"buffer_compression":       "zstd",
+		"buffer_compression_level": 9,
+	}, returnVal)
+
+	e = json.Unmarshal([]byte(`{"buffer_dir": "/var/buffer", "buffer_compression_level": 9}`), &input)
+	assert.NoError(t, e)
+	_, returnVal = b.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{"buffer_dir": "/var/buffer", "buffer_max_size": int64(64 * 1024 * 1024)}, returnVal)
+
+	e = json.Unmarshal([]byte(`{"buffer_compression": "zstd"}`), &input)
+	assert.NoError(t, e)
+	key, _ := b.ApplyRule(input)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,29 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+)
+
+// LateDataPolicy is what the aggregation of the cloudwatch output does with the datapoints arriving after their
+// aggregation window was published
+type LateDataPolicy struct {
+}
+
+func (r *LateDataPolicy) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	res := map[string]interface{}{}
+	key, val := translator.DefaultCase("late_data_policy", "", input)
+	res[key] = val
+	if val != "" {
+		returnKey = "outputs"
+		returnVal = res
+	}
+	return
+}
+
+func init() {
+	r := new(LateDataPolicy)
+	RegisterRule("late_data_policy", r)
+}
@@ -24,9 +24,16 @@ func (c *MetricsCreds) ApplyRule(input interface{}) (returnKey string, returnVal
 	if agent.Global_Config.Role_arn != "" {
 		result[Role_Arn_Key] = agent.Global_Config.Role_arn
 	}
+	for k, v := range agent.MonitoringAccountCredentials() {
+		result[k] = v
+	}
 
 	// Read fromm Json first.
 	if val, ok := input.(map[string]interface{})[CredentialsSectionKey]; ok {
+		if creds, _ := val.(map[string]interface{}); creds[Role_Arn_Key] != nil {
+			// the external ID is the one of the role of the monitoring account
+			delete(result, agent.ExternalIdKey)
+		}
 		util.SetWithSameKeyIfFound(val, credsTargetList, result)
 	}
 
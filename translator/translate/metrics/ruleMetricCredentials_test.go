@@ -44,3 +44,32 @@ func TestWithAgentConfig(t *testing.T) {
 		panic(e)
 	}
 }
+
+func TestWithMonitoringAccount(t *testing.T) {
+	agent.Global_Config.Role_arn = "global_role_arn_test"
+	agent.Global_Config.Region = "us-west-2"
+	agent.Global_Config.MonitoringAccount = agent.MonitoringAccountConfig{
+		AccountID:  "111122223333",
+		RoleName:   agent.DefaultMonitoringAccountRoleName,
+		ExternalID: "source",
+	}
+	defer func() {
+		agent.Global_Config.Role_arn = ""
+		agent.Global_Config.MonitoringAccount = agent.MonitoringAccountConfig{}
+	}()
+	c := new(MetricsCreds)
+	var input interface{}
+	e := json.Unmarshal([]byte(`{"metrics_collected": {}}`), &input)
+	assert.NoError(t, e)
+	_, returnVal := c.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{
+		"role_arn":    "arn:aws:iam::111122223333:role/CloudWatchAgentMonitoringAccountRole",
+		"external_id": "source",
+	}, returnVal)
+
+	// the role of the section wins, without the external ID of the monitoring account
+	e = json.Unmarshal([]byte(`{"credentials": {"role_arn": "role_value"}}`), &input)
+	assert.NoError(t, e)
+	_, returnVal = c.ApplyRule(input)
+	assert.Equal(t, map[string]interface{}{"role_arn": "role_value"}, returnVal)
+}
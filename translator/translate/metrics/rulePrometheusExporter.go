@@ -0,0 +1,46 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const (
+	PrometheusExporterSectionKey = "prometheus_exporter"
+	PrometheusOutputKey          = "prometheus_output"
+	PrometheusOutputPluginName   = "prometheus_client"
+)
+
+var prometheusExporterTargetList = []string{"path", "ip_range", "tls_cert", "tls_key"}
+
+// PrometheusExporter translates the "prometheus_exporter" section into the config of the prometheus client output,
+// it serves the metrics published to CloudWatch in the Prometheus exposition format so they can be scraped too
+type PrometheusExporter struct {
+}
+
+func (p *PrometheusExporter) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	section, ok := input.(map[string]interface{})[PrometheusExporterSectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	result := map[string]interface{}{}
+	// the endpoint is only reachable from the host by default, or on the bind address of the agent
+	_, result["listen"] = translator.DefaultCase("listen_address", agent.ListenAddress("127.0.0.1", 9273), section)
+	_, result["metric_version"] = translator.DefaultIntegralCase("metric_version", float64(2), section)
+	_, result["expiration_interval"] = translator.DefaultTimeIntervalCase("expiration_interval", float64(60), section)
+	util.SetWithSameKeyIfFound(section, prometheusExporterTargetList, result)
+	// only expose the metrics flowing through the agent, not the ones of the agent process itself
+	result["collectors_exclude"] = []string{"gocollector", "process"}
+
+	returnKey = PrometheusOutputKey
+	returnVal = result
+	return
+}
+
+func init() {
+	RegisterRule(PrometheusExporterSectionKey, new(PrometheusExporter))
+}
@@ -0,0 +1,33 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+)
+
+const (
+	SectionKey_PublishJitter = "publish_jitter"
+	// defaultAlignedPublishJitter spreads the API calls of a fleet aligned to the wall clock over a few seconds
+	defaultAlignedPublishJitter = float64(5)
+)
+
+type PublishJitter struct {
+}
+
+// ApplyRule sets the most the publishes are delayed after the multiples of the flush interval, a few seconds when the
+// agent is aligned to the wall clock, the whole flush interval otherwise
+func (p *PublishJitter) ApplyRule(input interface{}) (string, interface{}) {
+	_, ok := input.(map[string]interface{})[SectionKey_PublishJitter]
+	if !ok && !agent.Global_Config.AlignToWallClock {
+		return "", nil
+	}
+	key, val := translator.DefaultTimeIntervalCase(SectionKey_PublishJitter, defaultAlignedPublishJitter, input)
+	return OutputsKey, map[string]interface{}{key: val}
+}
+
+func init() {
+	RegisterRule(SectionKey_PublishJitter, new(PublishJitter))
+}
@@ -0,0 +1,74 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/translate/util"
+	translatorUtil "github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const RoleOverridesSectionKey = "role_overrides"
+
+var roleOverrideTargetList = []string{"tag_key", "tag_value", "namespace", Role_Arn_Key}
+
+type RoleOverrides struct {
+}
+
+// ApplyRule translates the "role_overrides" list into the role_override tables of the cloudwatch output.
+// The namespaces of the statsd prefix rules are routed by role_override tables too, ahead of the configured ones.
+func (r *RoleOverrides) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	result := statsDNamespaceOverrides(input)
+	val, ok := input.(map[string]interface{})[RoleOverridesSectionKey]
+	overrides, isList := val.([]interface{})
+	if ok && !isList {
+		translator.AddErrorMessages(GetCurPath()+RoleOverridesSectionKey, "Invalid format, expected a list of objects")
+		return
+	}
+
+	for _, o := range overrides {
+		override := map[string]interface{}{}
+		translatorUtil.SetWithSameKeyIfFound(o, roleOverrideTargetList, override)
+		result = append(result, override)
+	}
+	if len(result) == 0 {
+		return
+	}
+
+	returnKey = OutputsKey
+	returnVal = map[string]interface{}{"role_override": result}
+	return
+}
+
+// statsDNamespaceOverrides publishes the statsd metrics tagged by the prefix rules to the namespaces of the rules, with
+// the aggregation dimensions of their first rule when set
+func statsDNamespaceOverrides(input interface{}) (result []interface{}) {
+	metricsCollected, _ := input.(map[string]interface{})["metrics_collected"].(map[string]interface{})
+	statsd, _ := metricsCollected["statsd"].(map[string]interface{})
+	rules, _ := statsd["prefix_rules"].([]interface{})
+	seen := map[string]bool{}
+	for _, r := range rules {
+		rule, _ := r.(map[string]interface{})
+		namespace, ok := rule["namespace"].(string)
+		if !ok || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		override := map[string]interface{}{
+			"tag_key":    util.StatsD_Namespace_Tag_Key,
+			"tag_value":  namespace,
+			"namespace":  namespace,
+			"remove_tag": true,
+		}
+		if rollup, ok := rule["aggregation_dimensions"]; ok {
+			override["rollup_dimensions"] = rollup
+		}
+		result = append(result, override)
+	}
+	return
+}
+
+func init() {
+	RegisterRule(RoleOverridesSectionKey, new(RoleOverrides))
+}
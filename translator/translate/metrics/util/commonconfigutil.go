@@ -5,6 +5,8 @@ package util
 
 import (
 	"fmt"
+	"strconv"
+
 	"github.com/aws/amazon-cloudwatch-agent/translator"
 	"github.com/aws/amazon-cloudwatch-agent/translator/config"
 	"github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
@@ -22,6 +24,7 @@ const (
 	Windows_Measurement_Key      = "Measurement"
 	Windows_WarnOnMissing_Key    = "WarnOnMissing"
 	Windows_Disable_Replacer_Key = "DisableReplacer"
+	Collection_Jitter_Key        = "collection_jitter"
 )
 
 // ProcessLinuxCommonConfig is used by both Linux and Darwin.
@@ -48,6 +51,7 @@ func ProcessLinuxCommonConfig(input interface{}, pluginName string, path string,
 
 	// Set input plugin specific interval
 	isHighRsolution = setTimeInterval(inputMap, result, isHighRsolution, pluginName)
+	SetCollectionJitter(inputMap, result)
 
 	//Set append_dimensions as tags
 	if val, ok := inputMap[Append_Dimensions_Key]; ok {
@@ -80,6 +84,7 @@ func ProcessWindowsCommonConfig(input interface{}, pluginName string, path strin
 
 	// 1. Set input plugin specific interval
 	isHighRsolution = setTimeInterval(inputMap, returnVal, isHighRsolution, pluginName)
+	SetCollectionJitter(inputMap, returnVal)
 
 	// 2. Set append_dimensions as tags
 	if val, ok := inputMap[Append_Dimensions_Key]; ok {
@@ -199,3 +204,40 @@ func ListContains(inputs []string, desiredVal string) bool {
 	}
 	return false
 }
+
+// SetCollectionJitter sets the collection jitter of the section on the plugin instance translated from it. It is not
+// a field of the plugin, ExtractCollectionJitters moves it out of the inputs before they are encoded.
+func SetCollectionJitter(inputMap map[string]interface{}, result map[string]interface{}) {
+	if jitter, ok := inputMap[Collection_Jitter_Key].(float64); ok && jitter > 0 {
+		result[Collection_Jitter_Key] = strconv.Itoa(int(jitter)) + "s"
+	}
+}
+
+// ExtractCollectionJitters removes the collection jitters from the translated inputs and returns them by the name of
+// the input, one per instance in the order of the instances, "0s" for the instances without jitter. Only the inputs
+// with at least one jittered instance are returned.
+func ExtractCollectionJitters(translated interface{}) map[string][]string {
+	root, _ := translated.(map[string]interface{})
+	inputs, _ := root["inputs"].(map[string]interface{})
+	jitters := make(map[string][]string)
+	for name, plugin := range inputs {
+		instances, _ := plugin.([]interface{})
+		var values []string
+		jittered := false
+		for _, instance := range instances {
+			value := "0s"
+			if m, ok := instance.(map[string]interface{}); ok {
+				if jitter, ok := m[Collection_Jitter_Key].(string); ok {
+					value = jitter
+					jittered = true
+					delete(m, Collection_Jitter_Key)
+				}
+			}
+			values = append(values, value)
+		}
+		if jittered {
+			jitters[name] = values
+		}
+	}
+	return jitters
+}
@@ -0,0 +1,35 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package util
+
+import "github.com/aws/amazon-cloudwatch-agent/translator/translate/agent"
+
+const (
+	Secret_ID_Key = "secret_id"
+	Region_Key    = "region"
+	Role_Arn_Key  = "role_arn"
+)
+
+// ProcessSecretID sets the secret of Secrets Manager with the credentials of a plugin, e.g. the password of a
+// database, with the region and the credentials of the agent the plugin fetches it with
+func ProcessSecretID(input interface{}, result map[string]interface{}) {
+	m := input.(map[string]interface{})
+	val, ok := m[Secret_ID_Key]
+	if !ok {
+		return
+	}
+	result[Secret_ID_Key] = val
+	ProcessAgentCredentials(result)
+}
+
+// ProcessAgentCredentials sets the region and the credentials of the agent, for the plugins calling AWS themselves
+func ProcessAgentCredentials(result map[string]interface{}) {
+	result[Region_Key] = agent.Global_Config.Region
+	for k, v := range agent.Global_Config.Credentials {
+		result[k] = v
+	}
+	if agent.Global_Config.Role_arn != "" {
+		result[Role_Arn_Key] = agent.Global_Config.Role_arn
+	}
+}
@@ -59,9 +59,12 @@ func (t *Translator) ApplyRule(input interface{}) (returnKey string, returnVal i
 		panic("unknown target platform " + translator.GetTargetPlatform())
 	}
 
+	translator.ResetProvenance()
+
 	//We need to apply agent rule first, since global setting lies there, which will impact the override logic
 	key, val := agent.Global_Config.ApplyRule(input)
 	result[key] = val
+	translator.AddProvenance(key, key)
 
 	// sort rule here so that we could get the output plugin instance in a stable order
 	sortedRuleKey := make([]string, 0, len(targetRuleMap))
@@ -77,9 +80,11 @@ func (t *Translator) ApplyRule(input interface{}) (returnKey string, returnVal i
 		if key != "" {
 			if key == "agent" || key == "global_tags" {
 				result[key] = val
+				translator.AddProvenance(key, key)
 			} else {
 
 				valMap := val.(map[string]interface{})
+				addProvenance(valMap, key)
 				if inputs, ok := valMap["inputs"]; ok {
 					allInputPlugin = translator.MergePlugins(allInputPlugin, inputs.(map[string]interface{}))
 				}
@@ -106,6 +111,20 @@ func (t *Translator) ApplyRule(input interface{}) (returnKey string, returnVal i
 		deltaProcessorSettings := make([]interface{}, 0)
 		deltaProcessorSettings = append(deltaProcessorSettings, make(map[string]interface{}))
 		allProcessorPlugin["delta"] = deltaProcessorSettings
+		for _, p := range []string{"inputs.diskio", "inputs.net"} {
+			for _, jsonPath := range translator.Provenance[p] {
+				translator.AddProvenance("processors.delta", jsonPath)
+			}
+		}
+	}
+
+	//the tags of the metadata file are added to all the metrics, the ones sent as logs too, and to the JSON log events
+	if agent.Global_Config.MetadataFile != "" {
+		if allProcessorPlugin == nil {
+			allProcessorPlugin = make(map[string]interface{})
+		}
+		allProcessorPlugin["metadatafile"] = []interface{}{map[string]interface{}{"file": agent.Global_Config.MetadataFile}}
+		translator.AddProvenance("processors.metadatafile", "agent.metadata_file")
 	}
 
 	if allProcessorPlugin != nil {
@@ -118,3 +137,15 @@ func (t *Translator) ApplyRule(input interface{}) (returnKey string, returnVal i
 	returnVal = result
 	return
 }
+
+// addProvenance records the plugins translated from the section of the JSON config, the ones its children did not
+// record are from the section itself, e.g. the cloudwatch output from the metrics section
+func addProvenance(valMap map[string]interface{}, section string) {
+	for _, kind := range []string{"inputs", "outputs", "processors", "aggregators"} {
+		if plugins, ok := valMap[kind].(map[string]interface{}); ok {
+			for name := range plugins {
+				translator.AddProvenance(kind+"."+name, section)
+			}
+		}
+	}
+}
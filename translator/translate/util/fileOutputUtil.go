@@ -0,0 +1,66 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package util
+
+import (
+	"github.com/aws/amazon-cloudwatch-agent/translator"
+	"github.com/aws/amazon-cloudwatch-agent/translator/util"
+)
+
+const (
+	FileOutputSectionKey = "file"
+	FileOnlyKey          = "file_only"
+	FileOutputPluginName = "file"
+)
+
+var fileOutputTargetList = []string{"path", "metric_format", "rotation_max_size", "rotation_max_archives"}
+
+type FileOutput struct {
+	returnTargetKey string
+	alias           string
+}
+
+// Translate the "file" section into the config of the file output plugin
+func (f *FileOutput) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	section, ok := input.(map[string]interface{})[FileOutputSectionKey].(map[string]interface{})
+	if !ok {
+		return
+	}
+	result := map[string]interface{}{}
+	util.SetWithSameKeyIfFound(section, fileOutputTargetList, result)
+	for _, key := range []string{"rotation_max_size", "rotation_max_archives"} {
+		if _, ok := result[key]; ok {
+			k, v := translator.DefaultIntegralCase(key, float64(0), section)
+			result[k] = v
+		}
+	}
+	if _, ok := section["rotation_interval"]; ok {
+		k, v := translator.DefaultTimeIntervalCase("rotation_interval", float64(0), section)
+		result[k] = v
+	}
+	if f.alias != "" {
+		result["alias"] = f.alias
+	}
+
+	returnKey = f.returnTargetKey
+	returnVal = result
+	return
+}
+
+// IsFileOnly returns whether the "file" section replaces the CloudWatch output of the section
+func IsFileOnly(input interface{}) bool {
+	section, ok := input.(map[string]interface{})[FileOutputSectionKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	fileOnly, ok := section[FileOnlyKey].(bool)
+	return ok && fileOnly
+}
+
+func GetFileOutputRule(returnTargetKey, alias string) *FileOutput {
+	f := new(FileOutput)
+	f.returnTargetKey = returnTargetKey
+	f.alias = alias
+	return f
+}
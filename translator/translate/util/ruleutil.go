@@ -21,3 +21,41 @@ func GetRegionRule(returnTargetKey string) *Region {
 	r.returnTargetKey = returnTargetKey
 	return r
 }
+
+type DualStackEndpoint struct {
+	returnTargetKey string
+}
+
+// Grant the global dual-stack endpoint option(if set)
+func (r *DualStackEndpoint) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	if agent.Global_Config.UseDualStackEndpoint {
+		returnKey = r.returnTargetKey
+		returnVal = map[string]interface{}{agent.UseDualStackEndpointKey: true}
+	}
+	return
+}
+
+func GetDualStackEndpointRule(returnTargetKey string) *DualStackEndpoint {
+	r := new(DualStackEndpoint)
+	r.returnTargetKey = returnTargetKey
+	return r
+}
+
+type FlushOnTerminationNotice struct {
+	returnTargetKey string
+}
+
+// Grant the global flush on termination notice option(if set)
+func (r *FlushOnTerminationNotice) ApplyRule(input interface{}) (returnKey string, returnVal interface{}) {
+	if agent.Global_Config.FlushOnTerminationNotice {
+		returnKey = r.returnTargetKey
+		returnVal = map[string]interface{}{agent.FlushOnTerminationNoticeKey: true}
+	}
+	return
+}
+
+func GetFlushOnTerminationNoticeRule(returnTargetKey string) *FlushOnTerminationNotice {
+	r := new(FlushOnTerminationNotice)
+	r.returnTargetKey = returnTargetKey
+	return r
+}
@@ -6,9 +6,11 @@ package util
 const (
 	High_Resolution_Tag_Key      = "aws:StorageResolution"
 	Aggregation_Interval_Tag_Key = "aws:AggregationInterval"
+	// The tag routing the statsd metrics of a prefix rule to its namespace, dropped by the cloudwatch output
+	StatsD_Namespace_Tag_Key = "aws:StatsDNamespace"
 )
 
-var Reserved_Tag_Keys = []string{High_Resolution_Tag_Key, Aggregation_Interval_Tag_Key}
+var Reserved_Tag_Keys = []string{High_Resolution_Tag_Key, Aggregation_Interval_Tag_Key, StatsD_Namespace_Tag_Key}
 
 func AddHighResolutionTag(tags interface{}) {
 	tagMap := tags.(map[string]interface{})